@@ -12,6 +12,7 @@ import (
 	"personaworlds/backend/internal/api"
 	"personaworlds/backend/internal/config"
 	"personaworlds/backend/internal/db"
+	"personaworlds/backend/internal/envelope"
 	"personaworlds/backend/internal/observability"
 )
 
@@ -19,10 +20,23 @@ func main() {
 	cfg := config.Load()
 	logger := observability.NewLogger("api")
 
+	if _, err := envelope.NewKeyProvider(cfg); err != nil {
+		logger.Error("startup_failed", observability.Fields{
+			"step":  "calibration_keys",
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	pool, err := db.ConnectPool(ctx, cfg.DatabaseURL, db.PoolOptionsFromConfig(cfg.DBAPIPool, db.SlowQueryOptions{
+		Service:    "api",
+		Threshold:  cfg.SlowQueryThreshold,
+		ExplainPct: cfg.SlowQueryExplainPct,
+		Logger:     logger,
+	}))
 	if err != nil {
 		logger.Error("startup_failed", observability.Fields{
 			"step":  "db_connect",
@@ -42,6 +56,25 @@ func main() {
 
 	llm := ai.NewFromConfig(cfg)
 	server := api.New(cfg, pool, llm)
+	if cfg.StatsDEnabled() {
+		sink, err := observability.NewStatsDSink(observability.StatsDConfig{
+			Addr:       cfg.StatsDAddr,
+			Prefix:     cfg.StatsDPrefix,
+			Format:     cfg.StatsDFormat,
+			Tags:       append([]string{"service:api"}, cfg.StatsDTags...),
+			FlushEvery: cfg.StatsDFlushEvery,
+		})
+		if err != nil {
+			logger.Warn("statsd_disabled", observability.Fields{"addr": cfg.StatsDAddr, "error": err.Error()})
+		} else {
+			defer sink.Close()
+			server.SetMetricsSink(sink)
+			go server.RunQueueDepthRefresh(ctx)
+			logger.Info("statsd_enabled", observability.Fields{"addr": cfg.StatsDAddr, "format": cfg.StatsDFormat})
+		}
+	}
+
+	go server.RunThreadUpdates(ctx)
 
 	httpServer := &http.Server{
 		Addr:              ":" + cfg.Port,
@@ -62,18 +95,32 @@ func main() {
 		}
 	}()
 
+	listening := true
 	select {
 	case <-ctx.Done():
 	case err := <-serverErrCh:
 		logger.Error("http_server_failed", observability.Fields{"error": err.Error()})
-		stop()
+		listening = false
+	}
+	// A second signal during the drain stops the process right away.
+	stop()
+
+	// Fail /readyz first and keep serving for API_DRAIN_DELAY, so the load
+	// balancer takes this instance out of rotation before the listener
+	// closes. Requests in flight then get up to API_SHUTDOWN_GRACE to finish.
+	server.BeginDrain()
+	httpServer.SetKeepAlivesEnabled(false)
+	if listening && cfg.APIDrainDelay > 0 {
+		logger.Info("api_drain_delay", observability.Fields{"delay": cfg.APIDrainDelay.String()})
+		time.Sleep(cfg.APIDrainDelay)
 	}
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.APIShutdownGrace)
 	defer cancel()
 
 	if err := httpServer.Shutdown(shutdownCtx); err != nil {
 		logger.Error("graceful_shutdown_failed", observability.Fields{"error": err.Error()})
+		_ = httpServer.Close()
 	}
 	logger.Info("api_stopped", nil)
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"personaworlds/backend/internal/backup"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+)
+
+const usage = `usage:
+  backup export [-user EMAIL] [-out FILE]
+  backup restore -in FILE [-policy skip|overwrite|rename] [-dry-run]`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	cfg := config.Load()
+	ctx := context.Background()
+
+	switch os.Args[1] {
+	case "export":
+		runExport(ctx, cfg, os.Args[2:])
+	case "restore":
+		runRestore(ctx, cfg, os.Args[2:])
+	default:
+		log.Fatalf("unknown command %q\n%s", os.Args[1], usage)
+	}
+}
+
+func runExport(ctx context.Context, cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	databaseURL := flags.String("database-url", cfg.DatabaseURL, "Postgres URL to export from")
+	userEmail := flags.String("user", "", "export only this user's content (default: whole instance)")
+	out := flags.String("out", "", "archive path, - for stdout (default: personaworlds-backup-<timestamp>.tar.gz)")
+	flags.Parse(args)
+
+	path := *out
+	if path == "" {
+		path = fmt.Sprintf("personaworlds-backup-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	pool, err := db.Connect(ctx, *databaseURL)
+	if err != nil {
+		log.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("create archive failed: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	manifest, err := backup.Export(ctx, pool, backup.ExportOptions{UserEmail: *userEmail}, w)
+	if err != nil {
+		if path != "-" {
+			os.Remove(path)
+		}
+		log.Fatalf("export failed: %v", err)
+	}
+
+	for _, table := range manifest.Tables {
+		log.Printf("%s: %d rows", table.Name, table.Rows)
+	}
+	log.Printf("export completed: scope=%s schema=%s archive=%s", manifest.Scope, manifest.SchemaVersion, path)
+}
+
+func runRestore(ctx context.Context, cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	databaseURL := flags.String("database-url", cfg.DatabaseURL, "Postgres URL to restore into")
+	in := flags.String("in", "", "archive path, - for stdin")
+	policyRaw := flags.String("policy", string(backup.PolicySkip), "what to do when a row already exists: skip, overwrite or rename")
+	dryRun := flags.Bool("dry-run", false, "restore inside a transaction and roll it back")
+	flags.Parse(args)
+
+	if *in == "" {
+		log.Fatalf("-in is required\n%s", usage)
+	}
+	policy, err := backup.ParseConflictPolicy(*policyRaw)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			log.Fatalf("open archive failed: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	pool, err := db.Connect(ctx, *databaseURL)
+	if err != nil {
+		log.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	report, err := backup.Restore(ctx, pool, r, backup.RestoreOptions{Policy: policy, DryRun: *dryRun})
+	if err != nil {
+		log.Fatalf("restore failed: %v", err)
+	}
+
+	for _, table := range report.Tables {
+		log.Printf("%s: inserted=%d overwritten=%d renamed=%d matched=%d skipped=%d",
+			table.Name, table.Inserted, table.Overwritten, table.Renamed, table.Matched, table.Skipped)
+	}
+	if report.DryRun {
+		log.Printf("dry run completed: policy=%s, nothing was written", report.Policy)
+		return
+	}
+	log.Printf("restore completed: policy=%s scope=%s", report.Policy, report.Manifest.Scope)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+)
+
+func main() {
+	cfg := config.Load()
+
+	databaseURLs := flag.String("database-url", cfg.DatabaseURL, "comma-separated Postgres URLs to migrate")
+	migrationsDir := flag.String("dir", cfg.MigrationsDir, "migrations directory")
+	toVersion := flag.String("to-version", "", "migrate up or down to this version (file name or numeric prefix, 0 reverts everything)")
+	dryRun := flag.Bool("dry-run", false, "print planned changes without applying them")
+	status := flag.Bool("status", false, "print migration status and exit")
+	appliedBy := flag.String("applied-by", "", "name recorded in schema_migration_history (defaults to $USER)")
+	flag.Parse()
+
+	ctx := context.Background()
+	targets := parseDatabaseURLs(*databaseURLs)
+	if len(targets) == 0 {
+		log.Fatalf("no database url configured")
+	}
+
+	for i, databaseURL := range targets {
+		label := databaseLabel(i, len(targets))
+		pool, err := db.Connect(ctx, databaseURL)
+		if err != nil {
+			log.Fatalf("%sdb connect failed: %v", label, err)
+		}
+
+		if *status {
+			statuses, err := db.MigrationStatuses(ctx, pool, *migrationsDir)
+			pool.Close()
+			if err != nil {
+				log.Fatalf("%sstatus failed: %v", label, err)
+			}
+			for _, item := range statuses {
+				state := "pending"
+				if item.Applied {
+					state = "applied " + item.AppliedAt.UTC().Format("2006-01-02T15:04:05Z")
+				}
+				if item.Drifted {
+					state += " (checksum drift)"
+				}
+				down := ""
+				if item.HasDown {
+					down = " [down]"
+				}
+				log.Printf("%s%s %s%s", label, item.Version, state, down)
+			}
+			continue
+		}
+
+		err = db.Migrate(ctx, pool, *migrationsDir, db.MigrateOptions{
+			ToVersion: *toVersion,
+			DryRun:    *dryRun,
+			AppliedBy: *appliedBy,
+			Logf: func(format string, args ...any) {
+				log.Printf(label+format, args...)
+			},
+		})
+		pool.Close()
+		if err != nil {
+			log.Fatalf("%smigration failed: %v", label, err)
+		}
+	}
+
+	if *dryRun {
+		log.Printf("dry run completed: %d database(s)", len(targets))
+		return
+	}
+	log.Printf("migrate completed: %d database(s)", len(targets))
+}
+
+func parseDatabaseURLs(raw string) []string {
+	parts := strings.Split(raw, ",")
+	urls := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if clean := strings.TrimSpace(part); clean != "" {
+			urls = append(urls, clean)
+		}
+	}
+	return urls
+}
+
+func databaseLabel(index, total int) string {
+	if total <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("[db %d] ", index+1)
+}
@@ -0,0 +1,370 @@
+// Command rotatekeys maintains the encryption of persona calibration lists.
+// By default it rewraps every user data key under the active master key
+// (the first CALIBRATION_KEYS entry) and seals calibration lists still
+// stored in plaintext. -new-data-keys also gives every persona owner a fresh
+// data key, reseals their lists with it and drops the old keys. -decrypt
+// writes plaintext back, for turning encryption off.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+	"personaworlds/backend/internal/envelope"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var calibrationFields = []common.PersonaListField{
+	common.WritingSamplesField,
+	common.DoNotSayField,
+	common.CatchphrasesField,
+}
+
+type rotation struct {
+	pool        *pgxpool.Pool
+	provider    envelope.KeyProvider
+	cipher      *common.CalibrationCipher
+	newDataKeys bool
+	decrypt     bool
+	dryRun      bool
+	batchSize   int
+	rewrapped   int
+	users       int
+	resealed    int
+	droppedKeys int
+	failedUsers int
+}
+
+func main() {
+	cfg := config.Load()
+
+	databaseURL := flag.String("database-url", cfg.DatabaseURL, "Postgres URL")
+	newDataKeys := flag.Bool("new-data-keys", false, "give every persona owner a new data key and reseal their calibration with it")
+	decrypt := flag.Bool("decrypt", false, "write calibration lists back in plaintext")
+	dryRun := flag.Bool("dry-run", false, "count what would change without writing")
+	batchSize := flag.Int("batch", 200, "rows read per query")
+	flag.Parse()
+
+	if *newDataKeys && *decrypt {
+		log.Fatalf("-new-data-keys and -decrypt cannot be combined")
+	}
+	if *batchSize <= 0 {
+		log.Fatalf("-batch must be positive")
+	}
+	provider, err := envelope.NewKeyProvider(cfg)
+	if err != nil {
+		log.Fatalf("calibration keys: %v", err)
+	}
+	if provider == nil {
+		log.Fatalf("CALIBRATION_KEYS is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, *databaseURL)
+	if err != nil {
+		log.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	r := &rotation{
+		pool:        pool,
+		provider:    provider,
+		cipher:      common.NewCalibrationCipher(provider),
+		newDataKeys: *newDataKeys,
+		decrypt:     *decrypt,
+		dryRun:      *dryRun,
+		batchSize:   *batchSize,
+	}
+	if !r.decrypt {
+		if err := r.rewrapDataKeys(ctx); err != nil {
+			log.Fatalf("rewrap data keys failed: %v", err)
+		}
+	}
+	if err := r.resealPersonas(ctx); err != nil {
+		log.Fatalf("reseal personas failed: %v", err)
+	}
+	if err := r.reportMasterKeys(ctx); err != nil {
+		log.Fatalf("report master keys failed: %v", err)
+	}
+
+	prefix := ""
+	if r.dryRun {
+		prefix = "dry run: "
+	}
+	log.Printf("%srewrapped %d data key(s) under %q; checked %d user(s), resealed %d list(s), dropped %d old data key(s), %d user(s) failed",
+		prefix, r.rewrapped, provider.ActiveKeyID(), r.users, r.resealed, r.droppedKeys, r.failedUsers)
+	if r.failedUsers > 0 {
+		log.Fatalf("some users could not be processed; see the log above")
+	}
+}
+
+// rewrapDataKeys moves every data key wrapped by an older master key onto
+// the active one. The data keys themselves do not change, so nothing sealed
+// with them needs rewriting.
+func (r *rotation) rewrapDataKeys(ctx context.Context) error {
+	active := r.provider.ActiveKeyID()
+	lastUserID, lastVersion := "", 0
+	for {
+		rows, err := r.pool.Query(ctx, `
+			SELECT user_id::text, version, master_key_id, wrapped_key
+			FROM user_data_keys
+			WHERE master_key_id <> $1
+			  AND (user_id::text, version) > ($2, $3)
+			ORDER BY user_id::text, version
+			LIMIT $4
+		`, active, lastUserID, lastVersion, r.batchSize)
+		if err != nil {
+			return err
+		}
+		type dataKey struct {
+			userID      string
+			version     int
+			masterKeyID string
+			wrapped     []byte
+		}
+		batch := make([]dataKey, 0, r.batchSize)
+		for rows.Next() {
+			var key dataKey
+			if err := rows.Scan(&key.userID, &key.version, &key.masterKeyID, &key.wrapped); err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		for _, key := range batch {
+			lastUserID, lastVersion = key.userID, key.version
+			plain, err := r.provider.Unwrap(ctx, key.masterKeyID, key.wrapped)
+			if err != nil {
+				return err
+			}
+			wrapped, err := r.provider.Wrap(ctx, active, plain)
+			if err != nil {
+				return err
+			}
+			r.rewrapped++
+			if r.dryRun {
+				continue
+			}
+			// The master_key_id check keeps a concurrent run from
+			// overwriting a key it already rewrapped.
+			if _, err := r.pool.Exec(ctx, `
+				UPDATE user_data_keys
+				SET master_key_id = $3,
+					wrapped_key = $4,
+					rewrapped_at = NOW()
+				WHERE user_id = $1
+				  AND version = $2
+				  AND master_key_id = $5
+			`, key.userID, key.version, active, wrapped, key.masterKeyID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resealPersonas walks persona owners one transaction at a time. A user
+// whose lists fail to open is logged and skipped so one bad row does not
+// stop the run.
+func (r *rotation) resealPersonas(ctx context.Context) error {
+	lastUserID := ""
+	for {
+		rows, err := r.pool.Query(ctx, `
+			SELECT DISTINCT user_id::text
+			FROM personas
+			WHERE user_id::text > $1
+			ORDER BY user_id::text
+			LIMIT $2
+		`, lastUserID, r.batchSize)
+		if err != nil {
+			return err
+		}
+		userIDs := make([]string, 0, r.batchSize)
+		for rows.Next() {
+			var userID string
+			if err := rows.Scan(&userID); err != nil {
+				rows.Close()
+				return err
+			}
+			userIDs = append(userIDs, userID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return nil
+		}
+
+		for _, userID := range userIDs {
+			lastUserID = userID
+			r.users++
+			if err := r.resealUser(ctx, userID); err != nil {
+				r.failedUsers++
+				log.Printf("user %s: %v", userID, err)
+			}
+		}
+	}
+}
+
+func (r *rotation) resealUser(ctx context.Context, userID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	newVersion := 0
+	if r.newDataKeys && !r.dryRun {
+		if newVersion, err = r.cipher.RotateDataKey(ctx, tx, userID); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id::text, writing_samples, do_not_say, catchphrases
+		FROM personas
+		WHERE user_id = $1
+		ORDER BY id
+		FOR UPDATE
+	`, userID)
+	if err != nil {
+		return err
+	}
+	type personaLists struct {
+		id    string
+		lists [3][]byte
+	}
+	personas := make([]personaLists, 0)
+	for rows.Next() {
+		var persona personaLists
+		if err := rows.Scan(&persona.id, &persona.lists[0], &persona.lists[1], &persona.lists[2]); err != nil {
+			rows.Close()
+			return err
+		}
+		personas = append(personas, persona)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	resealed := 0
+	for _, persona := range personas {
+		changed := false
+		for i, field := range calibrationFields {
+			if !r.needsReseal(persona.lists[i], newVersion) {
+				continue
+			}
+			resealed++
+			changed = true
+			if r.dryRun {
+				continue
+			}
+			plain, err := r.cipher.Open(ctx, tx, field, persona.lists[i])
+			if err != nil {
+				return err
+			}
+			if r.decrypt {
+				persona.lists[i] = plain
+			} else if persona.lists[i], err = r.cipher.Seal(ctx, tx, userID, field, plain); err != nil {
+				return err
+			}
+		}
+		if !changed || r.dryRun {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE personas
+			SET writing_samples = $2::jsonb,
+				do_not_say = $3::jsonb,
+				catchphrases = $4::jsonb
+			WHERE id = $1
+		`, persona.id, persona.lists[0], persona.lists[1], persona.lists[2]); err != nil {
+			return err
+		}
+	}
+
+	dropped := 0
+	if newVersion > 0 {
+		// Pending persona imports hold lists sealed with the old key; keep it
+		// until a later run, after the worker has created those personas.
+		tag, err := tx.Exec(ctx, `
+			DELETE FROM user_data_keys
+			WHERE user_id = $1
+			  AND version < $2
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM persona_import_batches
+				WHERE user_id = $1
+				  AND status = 'PENDING'
+			  )
+		`, userID, newVersion)
+		if err != nil {
+			return err
+		}
+		dropped = int(tag.RowsAffected())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	r.resealed += resealed
+	r.droppedKeys += dropped
+	return nil
+}
+
+// needsReseal reports whether a stored list has to be rewritten: sealed
+// lists when decrypting, plaintext lists when encrypting, and lists sealed
+// with an older data key after -new-data-keys created newVersion. In a dry
+// run with -new-data-keys every sealed list counts.
+func (r *rotation) needsReseal(raw []byte, newVersion int) bool {
+	version, sealed := common.SealedCalibrationKeyVersion(raw)
+	switch {
+	case r.decrypt:
+		return sealed
+	case !sealed:
+		return true
+	case r.newDataKeys && r.dryRun:
+		return true
+	default:
+		return newVersion > 0 && version < newVersion
+	}
+}
+
+// reportMasterKeys lists the master keys data keys are still wrapped with;
+// a key missing from the list can be removed from CALIBRATION_KEYS.
+func (r *rotation) reportMasterKeys(ctx context.Context) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT master_key_id, COUNT(*)::int
+		FROM user_data_keys
+		GROUP BY master_key_id
+		ORDER BY master_key_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			masterKeyID string
+			count       int
+		)
+		if err := rows.Scan(&masterKeyID, &count); err != nil {
+			return err
+		}
+		log.Printf("master key %q wraps %d data key(s)", masterKeyID, count)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"personaworlds/backend/internal/webpush"
+)
+
+// vapidkeys prints a fresh VAPID key pair in .env format. Rotating keys
+// invalidates every stored push subscription, so generate them once per
+// environment.
+func main() {
+	keys, err := webpush.GenerateKeys()
+	if err != nil {
+		log.Fatalf("generate vapid keys: %v", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", keys.PublicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", keys.PrivateKey)
+}
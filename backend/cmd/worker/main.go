@@ -11,6 +11,7 @@ import (
 	"personaworlds/backend/internal/ai"
 	"personaworlds/backend/internal/config"
 	"personaworlds/backend/internal/db"
+	"personaworlds/backend/internal/envelope"
 	"personaworlds/backend/internal/observability"
 	"personaworlds/backend/internal/worker"
 )
@@ -19,10 +20,23 @@ func main() {
 	cfg := config.Load()
 	logger := observability.NewLogger("worker")
 
+	if _, err := envelope.NewKeyProvider(cfg); err != nil {
+		logger.Error("startup_failed", observability.Fields{
+			"step":  "calibration_keys",
+			"error": err.Error(),
+		})
+		os.Exit(1)
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	pool, err := db.ConnectPool(ctx, cfg.DatabaseURL, db.PoolOptionsFromConfig(cfg.DBWorkerPool, db.SlowQueryOptions{
+		Service:    "worker",
+		Threshold:  cfg.SlowQueryThreshold,
+		ExplainPct: cfg.SlowQueryExplainPct,
+		Logger:     logger,
+	}))
 	if err != nil {
 		logger.Error("startup_failed", observability.Fields{
 			"step":  "db_connect",
@@ -42,6 +56,22 @@ func main() {
 
 	llm := ai.NewFromConfig(cfg)
 	w := worker.New(cfg, pool, llm)
+	if cfg.StatsDEnabled() {
+		sink, err := observability.NewStatsDSink(observability.StatsDConfig{
+			Addr:       cfg.StatsDAddr,
+			Prefix:     cfg.StatsDPrefix,
+			Format:     cfg.StatsDFormat,
+			Tags:       append([]string{"service:worker"}, cfg.StatsDTags...),
+			FlushEvery: cfg.StatsDFlushEvery,
+		})
+		if err != nil {
+			logger.Warn("statsd_disabled", observability.Fields{"addr": cfg.StatsDAddr, "error": err.Error()})
+		} else {
+			defer sink.Close()
+			w.SetMetricsSink(sink)
+			logger.Info("statsd_enabled", observability.Fields{"addr": cfg.StatsDAddr, "format": cfg.StatsDFormat})
+		}
+	}
 	observabilityServer := &http.Server{
 		Addr:              ":" + cfg.WorkerObservabilityPort,
 		Handler:           w.ObservabilityHandler(),
@@ -63,6 +93,8 @@ func main() {
 
 	logger.Info("worker_started", observability.Fields{
 		"poll_every_ms": cfg.WorkerPollEvery.Milliseconds(),
+		"shard_index":   cfg.WorkerShardIndex,
+		"shard_count":   cfg.WorkerShardCount,
 	})
 	workerDoneCh := make(chan struct{})
 	go func() {
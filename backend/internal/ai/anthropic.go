@@ -0,0 +1,198 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai/prompts"
+)
+
+const (
+	anthropicVersion = "2023-06-01"
+	// anthropicRequestIDHeader is the id Anthropic assigns to each call.
+	anthropicRequestIDHeader = "request-id"
+	// anthropicRetryAfterMax caps how long a retry-after header can hold a
+	// call; longer waits fail the call and leave the retry to the job queue.
+	anthropicRetryAfterMax = 30 * time.Second
+)
+
+// AnthropicClient generates through the Anthropic Messages API.
+type AnthropicClient struct {
+	promptedClient
+	apiKey         string
+	baseURL        string
+	model          string
+	maxTokens      int
+	requestTimeout time.Duration
+	maxRetries     int
+	retryBase      time.Duration
+	http           *http.Client
+}
+
+func NewAnthropicClient(apiKey, baseURL, model string, maxTokens int, requestTimeout time.Duration, maxRetries int, retryBase time.Duration) *AnthropicClient {
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+	if requestTimeout <= 0 {
+		requestTimeout = 30 * time.Second
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if maxRetries > 5 {
+		maxRetries = 5
+	}
+	if retryBase <= 0 {
+		retryBase = 400 * time.Millisecond
+	}
+
+	client := &AnthropicClient{
+		apiKey:         apiKey,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		model:          model,
+		maxTokens:      maxTokens,
+		requestTimeout: requestTimeout,
+		maxRetries:     maxRetries,
+		retryBase:      retryBase,
+		http: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+	client.promptedClient = promptedClient{prompts: prompts.MustNewRegistry(), complete: client.chat}
+	return client
+}
+
+func (c *AnthropicClient) endpoint() string {
+	if strings.HasSuffix(c.baseURL, "/v1") {
+		return c.baseURL + "/messages"
+	}
+	return c.baseURL + "/v1/messages"
+}
+
+func (c *AnthropicClient) chat(ctx context.Context, system, user string) (string, error) {
+	if c.apiKey == "" {
+		return "", errors.New("ANTHROPIC_API_KEY is required for anthropic provider")
+	}
+	ctx, cancel := contextWithDefaultTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	requestBody := map[string]any{
+		"model":      c.model,
+		"max_tokens": c.maxTokens,
+		"system":     system,
+		"messages": []map[string]string{
+			{"role": "user", "content": user},
+		},
+		"temperature": 0.7,
+	}
+
+	jsonBody, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(jsonBody))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("x-api-key", c.apiKey)
+		req.Header.Set("anthropic-version", anthropicVersion)
+		req.Header.Set("Content-Type", "application/json")
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+
+		content, retryAfter, retryable, err := c.chatOnce(req)
+		if err == nil {
+			return content, nil
+		}
+		lastErr = err
+		if !retryable || attempt >= c.maxRetries || retryAfter > anthropicRetryAfterMax {
+			break
+		}
+
+		recordRetry(ctx)
+		wait := max(retryDelay(c.retryBase, attempt), retryAfter)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return "", lastErr
+}
+
+// chatOnce makes one call. On 429 and overload responses it also returns the
+// wait the provider asked for in retry-after, if any.
+func (c *AnthropicClient) chatOnce(req *http.Request) (string, time.Duration, bool, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return "", 0, false, err
+		}
+		return "", 0, true, err
+	}
+	defer resp.Body.Close()
+	providerRequestID := strings.TrimSpace(resp.Header.Get(anthropicRequestIDHeader))
+	recordProviderRequestID(req.Context(), providerRequestID)
+
+	if resp.StatusCode >= 400 {
+		bodySnippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		message := strings.TrimSpace(string(bodySnippet))
+		if message == "" {
+			message = fmt.Sprintf("status %d", resp.StatusCode)
+		}
+		err := &ProviderError{Provider: "anthropic", StatusCode: resp.StatusCode, Body: message, RequestID: providerRequestID}
+		return "", parseRetryAfter(resp.Header.Get("retry-after")), err.Overloaded(), err
+	}
+
+	var out struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", 0, true, err
+	}
+	addUsage(req.Context(), out.Usage.InputTokens, out.Usage.OutputTokens)
+
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	content := strings.TrimSpace(text.String())
+	if content == "" {
+		return "", 0, true, fmt.Errorf("anthropic provider returned empty content (stop_reason=%s)", out.StopReason)
+	}
+	return content, 0, false, nil
+}
+
+// parseRetryAfter reads a retry-after header given in seconds. Anything else
+// means no hint.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
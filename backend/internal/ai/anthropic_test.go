@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/config"
+)
+
+func TestAnthropicClientSendsMessagesRequest(t *testing.T) {
+	var got struct {
+		Model     string `json:"model"`
+		MaxTokens int    `json:"max_tokens"`
+		System    string `json:"system"`
+		Messages  []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("missing auth or version headers: %v", r.Header)
+		}
+		if r.Header.Get(RequestIDHeader) != "req-1" {
+			t.Errorf("expected request id header, got %q", r.Header.Get(RequestIDHeader))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("request-id", "msg-req-9")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"A reply"},{"type":"text","text":" in two blocks."}],"stop_reason":"end_turn","usage":{"input_tokens":120,"output_tokens":30}}`))
+	}))
+	defer provider.Close()
+
+	recorder := &fakeRecorder{}
+	client := Instrument(NewAnthropicClient("key", provider.URL, "claude-test", 256, time.Second, 0, time.Millisecond), "anthropic", recorder, nil)
+	reply, err := client.GenerateReply(WithRequestID(context.Background(), "req-1"), PersonaContext{Name: "Ada"}, PostContext{Content: "Ship weekly?"}, nil)
+	if err != nil {
+		t.Fatalf("generate reply: %v", err)
+	}
+	if reply != "A reply in two blocks." {
+		t.Fatalf("expected joined text blocks, got %q", reply)
+	}
+	if got.Model != "claude-test" || got.MaxTokens != 256 || got.System == "" || len(got.Messages) != 1 || got.Messages[0].Role != "user" {
+		t.Fatalf("unexpected request %+v", got)
+	}
+	want := recordedLLMRequest{prompts.OpReply, "anthropic", RequestStatusOK, 120, 30}
+	if len(recorder.requests) != 1 || recorder.requests[0] != want {
+		t.Fatalf("expected usage recorded as %+v, got %+v", want, recorder.requests)
+	}
+}
+
+func TestAnthropicClientRetriesOverloaded(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(529)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Recovered."}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer provider.Close()
+
+	client := NewAnthropicClient("key", provider.URL+"/v1", "claude-test", 0, time.Second, 1, time.Millisecond)
+	out, err := client.SummarizeThread(context.Background(), PostContext{Content: "Topic"}, nil)
+	if err != nil || out != "Recovered." || calls != 2 {
+		t.Fatalf("expected a retried success, got %q, %v after %d calls", out, err, calls)
+	}
+}
+
+func TestAnthropicClientDoesNotRetryBadRequests(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("request-id", "req-bad")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error"}}`))
+	}))
+	defer provider.Close()
+
+	client := NewAnthropicClient("key", provider.URL, "claude-test", 0, time.Second, 3, time.Millisecond)
+	_, err := client.SummarizeThread(context.Background(), PostContext{Content: "Topic"}, nil)
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadRequest || providerErr.RequestID != "req-bad" {
+		t.Fatalf("expected a provider error with its request id, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retries, got %d calls", calls)
+	}
+}
+
+func TestAnthropicClientGivesUpOnLongRetryAfter(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("retry-after", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer provider.Close()
+
+	client := NewAnthropicClient("key", provider.URL, "claude-test", 0, time.Second, 3, time.Millisecond)
+	if _, err := client.SummarizeThread(context.Background(), PostContext{Content: "Topic"}, nil); !IsProviderUnavailable(err) {
+		t.Fatalf("expected the provider to be reported unavailable, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retry past the retry-after cap, got %d calls", calls)
+	}
+}
+
+func TestAnthropicClientRequiresAPIKey(t *testing.T) {
+	client := NewAnthropicClient("", "http://127.0.0.1:1", "claude-test", 0, time.Second, 0, time.Millisecond)
+	if _, err := client.SummarizeThread(context.Background(), PostContext{Content: "Topic"}, nil); err == nil {
+		t.Fatalf("expected an error without an api key")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Fatalf("expected 2s, got %s", got)
+	}
+	if got := parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"); got != 0 {
+		t.Fatalf("expected dates to be ignored, got %s", got)
+	}
+	if got := parseRetryAfter(""); got != 0 {
+		t.Fatalf("expected no hint, got %s", got)
+	}
+}
+
+func TestNewFromConfigPicksAnthropic(t *testing.T) {
+	cfg := config.Config{LLMProvider: "anthropic", AnthropicModel: "claude-test", LLMCanaryModel: "claude-next", LLMCanaryPct: 10}
+	if _, ok := NewFromConfig(cfg).(*AnthropicClient); !ok {
+		t.Fatalf("expected an anthropic client")
+	}
+	if ProviderName(cfg) != "anthropic" || ModelName(cfg) != "claude-test" {
+		t.Fatalf("unexpected provider %q or model %q", ProviderName(cfg), ModelName(cfg))
+	}
+	canary, ok := NewCanaryFromConfig(cfg).(*AnthropicClient)
+	if !ok || canary.model != "claude-next" {
+		t.Fatalf("expected an anthropic canary on the candidate model, got %#v", canary)
+	}
+	if ProviderName(config.Config{LLMProvider: "claude"}) != "mock" {
+		t.Fatalf("expected unknown providers to fall back to mock")
+	}
+}
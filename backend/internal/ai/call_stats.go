@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallStats adds up every LLM call made with a context from WithCallStats,
+// for example all calls behind one battle turn. Calls are counted by the
+// instrumented client, so only clients built with Instrument report them.
+type CallStats struct {
+	mu       sync.Mutex
+	snapshot CallStatsSnapshot
+}
+
+// CallStatsSnapshot is what a CallStats has seen so far. Retries are the
+// provider retries inside calls; Latency is the summed wall time of calls.
+type CallStatsSnapshot struct {
+	Calls            int
+	Retries          int
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+}
+
+type callStatsKey struct{}
+
+// WithCallStats returns a context whose LLM calls are added to the returned
+// stats, and to any stats of outer contexts.
+func WithCallStats(ctx context.Context) (context.Context, *CallStats) {
+	stats := &CallStats{}
+	return context.WithValue(ctx, callStatsKey{}, stats), stats
+}
+
+// CallStatsFromContext returns the stats ctx records into, or nil.
+func CallStatsFromContext(ctx context.Context) *CallStats {
+	stats, _ := ctx.Value(callStatsKey{}).(*CallStats)
+	return stats
+}
+
+func (s *CallStats) Snapshot() CallStatsSnapshot {
+	if s == nil {
+		return CallStatsSnapshot{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot
+}
+
+func recordCallStats(ctx context.Context, latency time.Duration, promptTokens, completionTokens int) {
+	stats := CallStatsFromContext(ctx)
+	if stats == nil {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.snapshot.Calls++
+	stats.snapshot.Latency += latency
+	stats.snapshot.PromptTokens += promptTokens
+	stats.snapshot.CompletionTokens += completionTokens
+}
+
+// recordRetry counts a provider retry against the call being measured.
+func recordRetry(ctx context.Context) {
+	stats := CallStatsFromContext(ctx)
+	if stats == nil {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.snapshot.Retries++
+}
@@ -1,6 +1,10 @@
 package ai
 
-import "context"
+import (
+	"context"
+
+	"personaworlds/backend/internal/ai/prompts"
+)
 
 type PersonaContext struct {
 	ID                string
@@ -12,23 +16,59 @@ type PersonaContext struct {
 	Catchphrases      []string
 	PreferredLanguage string
 	Formality         int
+	Style             PersonaStyle
+	// Mood optionally shifts a single draft on top of Tone; see DraftMoods.
+	Mood string
+}
+
+// PersonaStyle holds the persona's style sliders, each from 0 (none) to 100.
+type PersonaStyle struct {
+	Humor         int
+	Assertiveness int
+	Technicality  int
+	Brevity       int
 }
 
 type RoomContext struct {
 	ID          string
+	Slug        string
 	Name        string
 	Description string
 	Variant     int
+	// Hint is the room moderators' guidance for drafts in the room.
+	Hint RoomHint
+}
+
+// RoomHint is moderator-curated guidance for generating in a room: themes to
+// emphasize and structures to prefer. Either may be empty.
+type RoomHint struct {
+	Emphasize string
+	Structure string
 }
 
 type PostContext struct {
 	ID      string
 	Content string
+	// AvoidReply is another persona's reply that a first attempt came too
+	// close to; when set, the reply should argue from a different angle.
+	AvoidReply string
+	// RepeatedReply is the persona's own earlier reply in the thread that a
+	// first attempt restated; when set, the reply should move past it.
+	RepeatedReply string
+	// RoomHint is the guidance of the room the post is in.
+	RoomHint RoomHint
+	// ContextPack is the battle's shared background, summarized from the
+	// room's recent posts on the topic, when the owner asked for one.
+	ContextPack string
 }
 
+// ReplyContext is a reply in a thread. Human marks replies people wrote
+// themselves rather than a persona.
 type ReplyContext struct {
-	ID      string
-	Content string
+	ID        string
+	PersonaID string
+	Content   string
+	Human     bool
 }
 
 type DigestStats struct {
@@ -43,9 +83,40 @@ type DigestThreadContext struct {
 	ActivityCount int
 }
 
+type BattleTurnContext struct {
+	Turn        int
+	PersonaID   string
+	PersonaName string
+	Content     string
+}
+
+// ContextSourceContext is a room post a battle context pack draws on.
+type ContextSourceContext struct {
+	PostID      string
+	PersonaName string
+	Content     string
+}
+
 type LLMClient interface {
 	GeneratePostDraft(ctx context.Context, persona PersonaContext, room RoomContext) (string, error)
 	GenerateReply(ctx context.Context, persona PersonaContext, post PostContext, thread []ReplyContext) (string, error)
 	SummarizeThread(ctx context.Context, post PostContext, replies []ReplyContext) (string, error)
 	SummarizePersonaActivity(ctx context.Context, persona PersonaContext, stats DigestStats, threads []DigestThreadContext) (string, error)
+	CoachBattlePersona(ctx context.Context, persona PersonaContext, topic string, turns []BattleTurnContext) (string, error)
+	// SummarizeBattleHighlights condenses the selected best turns of a
+	// finished battle into a short recap.
+	SummarizeBattleHighlights(ctx context.Context, topic string, turns []BattleTurnContext) (string, error)
+	// SummarizeContextPack condenses the room posts picked for a battle into
+	// shared background both personas argue from.
+	SummarizeContextPack(ctx context.Context, topic string, sources []ContextSourceContext) (string, error)
+	TranslatePost(ctx context.Context, post PostContext, sourceLanguage, targetLanguage string) (string, error)
+	AnswerQuestion(ctx context.Context, persona PersonaContext, question string) (string, error)
+	ProposeBattleTopic(ctx context.Context, post PostContext, room RoomContext) (string, error)
+	// CheckRoomFit returns the raw JSON verdict on whether post belongs in room,
+	// suggesting candidate slugs when it does not.
+	CheckRoomFit(ctx context.Context, post PostContext, room RoomContext, candidates []RoomContext) (string, error)
+	// ClassifyPersonaStyle returns raw JSON slider values estimated from the
+	// persona's tone, bio and writing samples.
+	ClassifyPersonaStyle(ctx context.Context, persona PersonaContext) (string, error)
+	Prompts() *prompts.Registry
 }
@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	// RequestID is the provider's id for the failed call, when it sent one.
+	RequestID string
+}
+
+func (e *ProviderError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s provider error: status=%d request_id=%s body=%s", e.Provider, e.StatusCode, e.RequestID, e.Body)
+	}
+	return fmt.Sprintf("%s provider error: status=%d body=%s", e.Provider, e.StatusCode, e.Body)
+}
+
+func (e *ProviderError) Overloaded() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// IsProviderUnavailable reports whether err means the provider is rate limiting,
+// failing or unreachable, as opposed to rejecting one specific request.
+func IsProviderUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Overloaded()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -3,15 +3,70 @@ package ai
 import "personaworlds/backend/internal/config"
 
 func NewFromConfig(cfg config.Config) LLMClient {
-	if cfg.LLMProvider == "openai" {
-		return NewOpenAIClient(
-			cfg.OpenAIAPIKey,
-			cfg.OpenAIBaseURL,
-			cfg.OpenAIModel,
-			cfg.OpenAIRequestTimeout,
-			cfg.OpenAIMaxRetries,
-			cfg.OpenAIRetryBase,
-		)
+	switch ProviderName(cfg) {
+	case "openai":
+		return newOpenAIFromConfig(cfg, cfg.OpenAIModel)
+	case "anthropic":
+		return newAnthropicFromConfig(cfg, cfg.AnthropicModel)
 	}
 	return NewMockClient()
 }
+
+// NewCanaryFromConfig returns the client for the candidate model that
+// shadows generations, or nil when the canary is off. It shares the
+// provider settings of the main client; the mock provider gets a mock.
+func NewCanaryFromConfig(cfg config.Config) LLMClient {
+	if !cfg.LLMCanaryEnabled() {
+		return nil
+	}
+	switch ProviderName(cfg) {
+	case "openai":
+		return newOpenAIFromConfig(cfg, cfg.LLMCanaryModel)
+	case "anthropic":
+		return newAnthropicFromConfig(cfg, cfg.LLMCanaryModel)
+	}
+	return NewMockClient()
+}
+
+func newOpenAIFromConfig(cfg config.Config, model string) *OpenAIClient {
+	return NewOpenAIClient(
+		cfg.OpenAIAPIKey,
+		cfg.OpenAIBaseURL,
+		model,
+		cfg.OpenAIRequestTimeout,
+		cfg.OpenAIMaxRetries,
+		cfg.OpenAIRetryBase,
+	)
+}
+
+func newAnthropicFromConfig(cfg config.Config, model string) *AnthropicClient {
+	return NewAnthropicClient(
+		cfg.AnthropicAPIKey,
+		cfg.AnthropicBaseURL,
+		model,
+		cfg.AnthropicMaxTokens,
+		cfg.AnthropicRequestTimeout,
+		cfg.AnthropicMaxRetries,
+		cfg.AnthropicRetryBase,
+	)
+}
+
+// ModelName is the model NewFromConfig generates with.
+func ModelName(cfg config.Config) string {
+	switch ProviderName(cfg) {
+	case "openai":
+		return cfg.OpenAIModel
+	case "anthropic":
+		return cfg.AnthropicModel
+	}
+	return "mock"
+}
+
+// ProviderName is the provider NewFromConfig picks, as used in metric labels.
+func ProviderName(cfg config.Config) string {
+	switch cfg.LLMProvider {
+	case "openai", "anthropic":
+		return cfg.LLMProvider
+	}
+	return "mock"
+}
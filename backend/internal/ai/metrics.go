@@ -0,0 +1,197 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	RequestStatusOK          = "ok"
+	RequestStatusError       = "error"
+	RequestStatusUnavailable = "unavailable"
+)
+
+// MetricsRecorder receives one observation per LLM call. Both
+// observability.APIMetrics and observability.WorkerMetrics implement it.
+type MetricsRecorder interface {
+	ObserveLLMRequest(operation, provider, status string, duration time.Duration, promptTokens, completionTokens int)
+}
+
+// tokenUsage sums the token counts a provider reported for one call,
+// including its retries.
+type tokenUsage struct {
+	mu         sync.Mutex
+	prompt     int
+	completion int
+}
+
+type tokenUsageKey struct{}
+
+func withTokenUsage(ctx context.Context) (context.Context, *tokenUsage) {
+	usage := &tokenUsage{}
+	return context.WithValue(ctx, tokenUsageKey{}, usage), usage
+}
+
+// addUsage adds a provider response's token counts to the call being
+// measured, if any.
+func addUsage(ctx context.Context, promptTokens, completionTokens int) {
+	usage, ok := ctx.Value(tokenUsageKey{}).(*tokenUsage)
+	if !ok {
+		return
+	}
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.prompt += promptTokens
+	usage.completion += completionTokens
+}
+
+func (u *tokenUsage) totals() (int, int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.prompt, u.completion
+}
+
+// RequestStatus classifies a call's error the way the llm_requests_total
+// status label does.
+func RequestStatus(err error) string {
+	switch {
+	case err == nil:
+		return RequestStatusOK
+	case IsProviderUnavailable(err):
+		return RequestStatusUnavailable
+	default:
+		return RequestStatusError
+	}
+}
+
+// instrumentedClient records the operation, outcome, latency and token usage
+// of every call it passes to next, and logs each call with our correlation id
+// next to the provider's request id.
+type instrumentedClient struct {
+	next     LLMClient
+	provider string
+	recorder MetricsRecorder
+	logger   *observability.Logger
+}
+
+// Instrument wraps next so every call is reported to recorder under the
+// provider label and logged to logger, which may be nil.
+func Instrument(next LLMClient, provider string, recorder MetricsRecorder, logger *observability.Logger) LLMClient {
+	if recorder == nil {
+		return next
+	}
+	return &instrumentedClient{next: next, provider: provider, recorder: recorder, logger: logger}
+}
+
+func (c *instrumentedClient) observe(ctx context.Context, operation string, call func(context.Context) (string, error)) (string, error) {
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		requestID = newRequestID()
+		ctx = WithRequestID(ctx, requestID)
+	}
+	ctx, usage := withTokenUsage(ctx)
+	ctx, trace := withProviderTrace(ctx)
+	startedAt := time.Now()
+	out, err := call(ctx)
+	latency := time.Since(startedAt)
+	status := RequestStatus(err)
+	promptTokens, completionTokens := usage.totals()
+	c.recorder.ObserveLLMRequest(operation, c.provider, status, latency, promptTokens, completionTokens)
+	recordCallStats(ctx, latency, promptTokens, completionTokens)
+
+	fields := observability.Fields{
+		"operation":           operation,
+		"provider":            c.provider,
+		"status":              status,
+		"latency_ms":          latency.Milliseconds(),
+		"request_id":          requestID,
+		"provider_request_id": trace.providerRequestID(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		c.logger.Warn("llm_request_failed", fields)
+	} else {
+		c.logger.Info("llm_request", fields)
+	}
+	return out, err
+}
+
+func (c *instrumentedClient) GeneratePostDraft(ctx context.Context, persona PersonaContext, room RoomContext) (string, error) {
+	return c.observe(ctx, prompts.OpPostDraft, func(ctx context.Context) (string, error) {
+		return c.next.GeneratePostDraft(ctx, persona, room)
+	})
+}
+
+func (c *instrumentedClient) GenerateReply(ctx context.Context, persona PersonaContext, post PostContext, thread []ReplyContext) (string, error) {
+	return c.observe(ctx, prompts.OpReply, func(ctx context.Context) (string, error) {
+		return c.next.GenerateReply(ctx, persona, post, thread)
+	})
+}
+
+func (c *instrumentedClient) SummarizeThread(ctx context.Context, post PostContext, replies []ReplyContext) (string, error) {
+	return c.observe(ctx, prompts.OpThreadSummary, func(ctx context.Context) (string, error) {
+		return c.next.SummarizeThread(ctx, post, replies)
+	})
+}
+
+func (c *instrumentedClient) SummarizePersonaActivity(ctx context.Context, persona PersonaContext, stats DigestStats, threads []DigestThreadContext) (string, error) {
+	return c.observe(ctx, prompts.OpPersonaActivitySummary, func(ctx context.Context) (string, error) {
+		return c.next.SummarizePersonaActivity(ctx, persona, stats, threads)
+	})
+}
+
+func (c *instrumentedClient) CoachBattlePersona(ctx context.Context, persona PersonaContext, topic string, turns []BattleTurnContext) (string, error) {
+	return c.observe(ctx, prompts.OpBattleCoaching, func(ctx context.Context) (string, error) {
+		return c.next.CoachBattlePersona(ctx, persona, topic, turns)
+	})
+}
+
+func (c *instrumentedClient) SummarizeBattleHighlights(ctx context.Context, topic string, turns []BattleTurnContext) (string, error) {
+	return c.observe(ctx, prompts.OpBattleHighlights, func(ctx context.Context) (string, error) {
+		return c.next.SummarizeBattleHighlights(ctx, topic, turns)
+	})
+}
+
+func (c *instrumentedClient) SummarizeContextPack(ctx context.Context, topic string, sources []ContextSourceContext) (string, error) {
+	return c.observe(ctx, prompts.OpBattleContextPack, func(ctx context.Context) (string, error) {
+		return c.next.SummarizeContextPack(ctx, topic, sources)
+	})
+}
+
+func (c *instrumentedClient) TranslatePost(ctx context.Context, post PostContext, sourceLanguage, targetLanguage string) (string, error) {
+	return c.observe(ctx, prompts.OpPostTranslation, func(ctx context.Context) (string, error) {
+		return c.next.TranslatePost(ctx, post, sourceLanguage, targetLanguage)
+	})
+}
+
+func (c *instrumentedClient) AnswerQuestion(ctx context.Context, persona PersonaContext, question string) (string, error) {
+	return c.observe(ctx, prompts.OpPersonaAnswer, func(ctx context.Context) (string, error) {
+		return c.next.AnswerQuestion(ctx, persona, question)
+	})
+}
+
+func (c *instrumentedClient) ProposeBattleTopic(ctx context.Context, post PostContext, room RoomContext) (string, error) {
+	return c.observe(ctx, prompts.OpBattleProposition, func(ctx context.Context) (string, error) {
+		return c.next.ProposeBattleTopic(ctx, post, room)
+	})
+}
+
+func (c *instrumentedClient) CheckRoomFit(ctx context.Context, post PostContext, room RoomContext, candidates []RoomContext) (string, error) {
+	return c.observe(ctx, prompts.OpRoomFit, func(ctx context.Context) (string, error) {
+		return c.next.CheckRoomFit(ctx, post, room, candidates)
+	})
+}
+
+func (c *instrumentedClient) ClassifyPersonaStyle(ctx context.Context, persona PersonaContext) (string, error) {
+	return c.observe(ctx, prompts.OpPersonaStyle, func(ctx context.Context) (string, error) {
+		return c.next.ClassifyPersonaStyle(ctx, persona)
+	})
+}
+
+func (c *instrumentedClient) Prompts() *prompts.Registry {
+	return c.next.Prompts()
+}
@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai/prompts"
+)
+
+type recordedLLMRequest struct {
+	operation, provider, status    string
+	promptTokens, completionTokens int
+}
+
+type fakeRecorder struct {
+	requests []recordedLLMRequest
+}
+
+func (f *fakeRecorder) ObserveLLMRequest(operation, provider, status string, _ time.Duration, promptTokens, completionTokens int) {
+	f.requests = append(f.requests, recordedLLMRequest{operation, provider, status, promptTokens, completionTokens})
+}
+
+func TestInstrumentRecordsProviderUsage(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"A reply."}}],"usage":{"prompt_tokens":120,"completion_tokens":30}}`))
+	}))
+	defer provider.Close()
+
+	recorder := &fakeRecorder{}
+	client := Instrument(NewOpenAIClient("key", provider.URL, "model", time.Second, 1, time.Millisecond), "openai", recorder, nil)
+	if _, err := client.GenerateReply(context.Background(), PersonaContext{Name: "Ada"}, PostContext{Content: "Ship weekly?"}, nil); err != nil {
+		t.Fatalf("generate reply: %v", err)
+	}
+
+	want := recordedLLMRequest{prompts.OpReply, "openai", RequestStatusOK, 120, 30}
+	if len(recorder.requests) != 1 || recorder.requests[0] != want {
+		t.Fatalf("expected one retried call recorded as %+v, got %+v", want, recorder.requests)
+	}
+}
+
+func TestInstrumentRecordsUnavailableProvider(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer provider.Close()
+
+	recorder := &fakeRecorder{}
+	client := Instrument(NewOpenAIClient("key", provider.URL, "model", time.Second, 0, time.Millisecond), "openai", recorder, nil)
+	if _, err := client.SummarizeThread(context.Background(), PostContext{Content: "Ship weekly?"}, nil); err == nil {
+		t.Fatalf("expected provider error")
+	}
+
+	want := recordedLLMRequest{prompts.OpThreadSummary, "openai", RequestStatusUnavailable, 0, 0}
+	if len(recorder.requests) != 1 || recorder.requests[0] != want {
+		t.Fatalf("expected %+v, got %+v", want, recorder.requests)
+	}
+}
+
+func TestInstrumentPropagatesRequestID(t *testing.T) {
+	var seen []string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		w.Header().Set("x-request-id", "req_provider_1")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"bad prompt"}`))
+	}))
+	defer provider.Close()
+
+	client := Instrument(NewOpenAIClient("key", provider.URL, "model", time.Second, 0, time.Millisecond), "openai", &fakeRecorder{}, nil)
+	_, err := client.GenerateReply(WithRequestID(context.Background(), "abc-123"), PersonaContext{Name: "Ada"}, PostContext{Content: "Ship weekly?"}, nil)
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.RequestID != "req_provider_1" {
+		t.Fatalf("expected provider request id on the error, got %v", err)
+	}
+	if len(seen) != 1 || seen[0] != "abc-123" {
+		t.Fatalf("expected correlation id to reach the provider, got %v", seen)
+	}
+
+	seen = nil
+	_, _ = client.SummarizeThread(context.Background(), PostContext{Content: "Ship weekly?"}, nil)
+	if len(seen) != 1 || !strings.HasPrefix(seen[0], "llm-") {
+		t.Fatalf("expected a generated correlation id, got %v", seen)
+	}
+}
+
+func TestInstrumentAddsUpCallStats(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"A reply."}}],"usage":{"prompt_tokens":120,"completion_tokens":30}}`))
+	}))
+	defer provider.Close()
+
+	client := Instrument(NewOpenAIClient("key", provider.URL, "model", time.Second, 1, time.Millisecond), "openai", &fakeRecorder{}, nil)
+	ctx, stats := WithCallStats(context.Background())
+	for i := 0; i < 2; i++ {
+		if _, err := client.GenerateReply(ctx, PersonaContext{Name: "Ada"}, PostContext{Content: "Ship weekly?"}, nil); err != nil {
+			t.Fatalf("generate reply: %v", err)
+		}
+	}
+
+	got := stats.Snapshot()
+	if got.Calls != 2 || got.Retries != 1 || got.PromptTokens != 240 || got.CompletionTokens != 60 || got.Latency <= 0 {
+		t.Fatalf("unexpected call stats %+v", got)
+	}
+	if CallStatsFromContext(context.Background()).Snapshot() != (CallStatsSnapshot{}) {
+		t.Fatalf("expected a context without stats to report nothing")
+	}
+}
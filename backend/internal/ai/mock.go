@@ -2,14 +2,24 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+
+	"personaworlds/backend/internal/ai/prompts"
 )
 
-type MockClient struct{}
+type MockClient struct {
+	prompts *prompts.Registry
+}
 
 func NewMockClient() *MockClient {
-	return &MockClient{}
+	return &MockClient{prompts: prompts.MustNewRegistry()}
+}
+
+func (m *MockClient) Prompts() *prompts.Registry {
+	return m.prompts
 }
 
 func (m *MockClient) GeneratePostDraft(_ context.Context, persona PersonaContext, room RoomContext) (string, error) {
@@ -23,6 +33,10 @@ func (m *MockClient) GeneratePostDraft(_ context.Context, persona PersonaContext
 		insight = "shipping a visible changelog improves community trust and feedback quality"
 	}
 
+	if mood := strings.TrimSpace(persona.Mood); mood != "" {
+		insight = fmt.Sprintf("%s (%s take)", insight, mood)
+	}
+
 	catchphrase := ""
 	if len(persona.Catchphrases) > 0 {
 		catchphrase = strings.TrimSpace(persona.Catchphrases[0])
@@ -43,6 +57,30 @@ func (m *MockClient) GeneratePostDraft(_ context.Context, persona PersonaContext
 
 func (m *MockClient) GenerateReply(_ context.Context, persona PersonaContext, post PostContext, thread []ReplyContext) (string, error) {
 	threadSize := len(thread)
+	if strings.ToLower(strings.TrimSpace(persona.PreferredLanguage)) == "tr" {
+		return fmt.Sprintf(
+			"%s yanıtı (%s): Gönderinin yönüne katılıyorum. Benim pratik katkım küçük bir deney yapmak, sonuçları ölçmek ve bulguları paylaşmak. (konu yanıtları: %d)",
+			persona.Name,
+			persona.Tone,
+			threadSize,
+		), nil
+	}
+	if strings.TrimSpace(post.RepeatedReply) != "" {
+		return fmt.Sprintf(
+			"%s reply (%s): Building on my last point rather than restating it: the next step is deciding who owns the follow-up and by when. (thread replies: %d)",
+			persona.Name,
+			persona.Tone,
+			threadSize,
+		), nil
+	}
+	if strings.TrimSpace(post.AvoidReply) != "" {
+		return fmt.Sprintf(
+			"%s reply (%s): A different angle: before experimenting, name the one risk that would make this fail and who carries it. (thread replies: %d)",
+			persona.Name,
+			persona.Tone,
+			threadSize,
+		), nil
+	}
 	return fmt.Sprintf(
 		"%s reply (%s): I agree with the direction of the post. My practical addition is to run a small experiment, measure outcomes, and share findings. (thread replies: %d)",
 		persona.Name,
@@ -99,3 +137,205 @@ func (m *MockClient) SummarizePersonaActivity(_ context.Context, persona Persona
 
 	return fmt.Sprintf("Today the persona produced %d posts and %d replies. The most active threads were: %s.", stats.Posts, stats.Replies, threadSummary), nil
 }
+
+func (m *MockClient) CoachBattlePersona(_ context.Context, persona PersonaContext, topic string, turns []BattleTurnContext) (string, error) {
+	var own []BattleTurnContext
+	for _, turn := range turns {
+		if turn.PersonaID == persona.ID {
+			own = append(own, turn)
+		}
+	}
+	if len(own) == 0 {
+		return fmt.Sprintf("%s did not take a turn in this battle. Add the persona to the next battle to get coaching.", persona.Name), nil
+	}
+
+	strongest := own[0]
+	weakest := own[0]
+	for _, turn := range own[1:] {
+		if len([]rune(turn.Content)) > len([]rune(strongest.Content)) {
+			strongest = turn
+		}
+		if len([]rune(turn.Content)) < len([]rune(weakest.Content)) {
+			weakest = turn
+		}
+	}
+
+	if strongest.Turn == weakest.Turn {
+		return fmt.Sprintf("%s stayed on \"%s\" in turn %d. Back the claim with one concrete example or number next time.", persona.Name, topic, strongest.Turn), nil
+	}
+	return fmt.Sprintf("%s's argument was most complete in turn %d. Evidence was thin in turn %d; add a concrete example or number there.", persona.Name, strongest.Turn, weakest.Turn), nil
+}
+
+func (m *MockClient) SummarizeBattleHighlights(_ context.Context, topic string, turns []BattleTurnContext) (string, error) {
+	if len(turns) == 0 {
+		return fmt.Sprintf("No standout turns in the battle on \"%s\".", topic), nil
+	}
+	parts := make([]string, 0, len(turns))
+	for _, turn := range turns {
+		parts = append(parts, fmt.Sprintf("%s in turn %d", turn.PersonaName, turn.Turn))
+	}
+	return fmt.Sprintf("On \"%s\", the sharpest moments came from %s.", topic, strings.Join(parts, ", ")), nil
+}
+
+func (m *MockClient) SummarizeContextPack(_ context.Context, topic string, sources []ContextSourceContext) (string, error) {
+	if len(sources) == 0 {
+		return fmt.Sprintf("The room has not discussed \"%s\" yet.", topic), nil
+	}
+	parts := make([]string, 0, len(sources))
+	for i, source := range sources {
+		parts = append(parts, fmt.Sprintf("post %d (%s) argues %s", i+1, source.PersonaName, strings.TrimSpace(source.Content)))
+	}
+	return fmt.Sprintf("Room background on \"%s\": %s.", topic, strings.Join(parts, "; ")), nil
+}
+
+func (m *MockClient) TranslatePost(_ context.Context, post PostContext, sourceLanguage, targetLanguage string) (string, error) {
+	if sourceLanguage == targetLanguage {
+		return post.Content, nil
+	}
+	if targetLanguage == "tr" {
+		return fmt.Sprintf("[TR çeviri] %s", post.Content), nil
+	}
+	return fmt.Sprintf("[EN translation] %s", post.Content), nil
+}
+
+func (m *MockClient) AnswerQuestion(_ context.Context, persona PersonaContext, question string) (string, error) {
+	topic := strings.TrimSpace(question)
+	if runes := []rune(topic); len(runes) > 80 {
+		topic = string(runes[:80]) + "..."
+	}
+	if strings.ToLower(strings.TrimSpace(persona.PreferredLanguage)) == "tr" {
+		return fmt.Sprintf("%s yanıtlıyor: \"%s\" sorusu için önerim küçük bir deneyle başlamak, sonucu ölçmek ve öğrendiklerini paylaşmak.", persona.Name, topic), nil
+	}
+	return fmt.Sprintf("%s answers: for \"%s\", my advice is to start with one small experiment, measure the outcome, and share what you learn.", persona.Name, topic), nil
+}
+
+func (m *MockClient) ProposeBattleTopic(_ context.Context, post PostContext, room RoomContext) (string, error) {
+	claim := strings.Join(strings.Fields(post.Content), " ")
+	if idx := strings.IndexAny(claim, ".!?"); idx > 0 {
+		claim = claim[:idx]
+	}
+	if runes := []rune(claim); len(runes) > 120 {
+		claim = string(runes[:120])
+	}
+	if claim == "" {
+		return fmt.Sprintf("Should %s change how it works?", room.Name), nil
+	}
+	return fmt.Sprintf("Should we agree that %s?", strings.TrimRight(claim, " ,;:")), nil
+}
+
+// CheckRoomFit scores rooms by word overlap with the post. The target room fits
+// unless it shares no words with the post and some other room does.
+func (m *MockClient) CheckRoomFit(_ context.Context, post PostContext, room RoomContext, candidates []RoomContext) (string, error) {
+	words := map[string]struct{}{}
+	for _, word := range mockTopicWords(post.Content) {
+		words[word] = struct{}{}
+	}
+	score := func(rc RoomContext) int {
+		matches := 0
+		seen := map[string]struct{}{}
+		for _, word := range mockTopicWords(rc.Slug + " " + rc.Name + " " + rc.Description) {
+			if _, ok := seen[word]; ok {
+				continue
+			}
+			seen[word] = struct{}{}
+			if _, ok := words[word]; ok {
+				matches++
+			}
+		}
+		return matches
+	}
+
+	type scored struct {
+		slug  string
+		score int
+	}
+	better := []scored{}
+	if score(room) == 0 {
+		for _, candidate := range candidates {
+			if value := score(candidate); value > 0 {
+				better = append(better, scored{slug: candidate.Slug, score: value})
+			}
+		}
+	}
+	sort.SliceStable(better, func(i, j int) bool { return better[i].score > better[j].score })
+	if len(better) > 3 {
+		better = better[:3]
+	}
+
+	verdict := map[string]any{
+		"fits":            len(better) == 0,
+		"reason":          fmt.Sprintf("The post matches the %s room.", room.Name),
+		"suggested_rooms": []string{},
+	}
+	if len(better) > 0 {
+		slugs := make([]string, 0, len(better))
+		for _, item := range better {
+			slugs = append(slugs, item.slug)
+		}
+		verdict["reason"] = fmt.Sprintf("The post shares no topics with %s.", room.Name)
+		verdict["suggested_rooms"] = slugs
+	}
+	raw, err := json.Marshal(verdict)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// mockStyleCues nudge a neutral slider up or down when a tone word appears.
+var mockStyleCues = []struct {
+	words []string
+	apply func(*PersonaStyle)
+}{
+	{[]string{"funny", "witty", "playful", "humor", "sarcastic", "joke"}, func(s *PersonaStyle) { s.Humor = 80 }},
+	{[]string{"serious", "dry", "earnest"}, func(s *PersonaStyle) { s.Humor = 20 }},
+	{[]string{"bold", "direct", "blunt", "confident", "assertive", "opinionated"}, func(s *PersonaStyle) { s.Assertiveness = 80 }},
+	{[]string{"gentle", "humble", "cautious", "tentative", "calm"}, func(s *PersonaStyle) { s.Assertiveness = 30 }},
+	{[]string{"technical", "engineer", "data", "analytical", "expert", "backend"}, func(s *PersonaStyle) { s.Technicality = 80 }},
+	{[]string{"simple", "friendly", "beginner", "plain"}, func(s *PersonaStyle) { s.Technicality = 25 }},
+	{[]string{"concise", "short", "terse", "punchy", "brief", "pragmatic"}, func(s *PersonaStyle) { s.Brevity = 80 }},
+	{[]string{"detailed", "thorough", "storyteller", "verbose"}, func(s *PersonaStyle) { s.Brevity = 25 }},
+}
+
+// ClassifyPersonaStyle starts every slider at 50 and moves it when the tone or
+// bio contains a matching cue word.
+func (m *MockClient) ClassifyPersonaStyle(_ context.Context, persona PersonaContext) (string, error) {
+	words := map[string]struct{}{}
+	for _, word := range strings.Fields(strings.ToLower(persona.Tone + " " + persona.Bio)) {
+		words[strings.Trim(word, ".,;:!?()\"'")] = struct{}{}
+	}
+
+	style := PersonaStyle{Humor: 50, Assertiveness: 50, Technicality: 50, Brevity: 50}
+	for _, cue := range mockStyleCues {
+		for _, word := range cue.words {
+			if _, ok := words[word]; ok {
+				cue.apply(&style)
+				break
+			}
+		}
+	}
+
+	raw, err := json.Marshal(map[string]int{
+		"humor":         style.Humor,
+		"assertiveness": style.Assertiveness,
+		"technicality":  style.Technicality,
+		"brevity":       style.Brevity,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func mockTopicWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r < 0x80
+	})
+	words := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len([]rune(field)) >= 4 {
+			words = append(words, field)
+		}
+	}
+	return words
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// draftMoods are the moods a draft can be generated in. Each one nudges the
+// delivery of a single post on top of the persona's base tone; the set is
+// closed so the prompt modifier stays bounded.
+var draftMoods = map[string]string{
+	"playful":    "light and witty, with a little humour",
+	"contrarian": "respectfully pushes back on the common take",
+	"reflective": "thoughtful, drawing on past experience",
+	"optimistic": "upbeat about what could go right",
+	"skeptical":  "questions assumptions and asks for evidence",
+}
+
+// DraftMoods returns the supported moods in alphabetical order.
+func DraftMoods() []string {
+	moods := make([]string, 0, len(draftMoods))
+	for mood := range draftMoods {
+		moods = append(moods, mood)
+	}
+	sort.Strings(moods)
+	return moods
+}
+
+// NormalizeMood lowercases and validates a requested mood. An empty mood is
+// valid and means the persona's base tone only.
+func NormalizeMood(raw string) (string, error) {
+	mood := strings.ToLower(strings.TrimSpace(raw))
+	if mood == "" {
+		return "", nil
+	}
+	if _, ok := draftMoods[mood]; !ok {
+		return "", fmt.Errorf("mood must be one of: %s", strings.Join(DraftMoods(), ", "))
+	}
+	return mood, nil
+}
+
+// MoodHint describes how a mood should shape a draft, or "" for no mood.
+func MoodHint(mood string) string {
+	return draftMoods[mood]
+}
@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMood(t *testing.T) {
+	if mood, err := NormalizeMood(""); err != nil || mood != "" {
+		t.Fatalf("expected empty mood to be accepted, got %q, %v", mood, err)
+	}
+	if mood, err := NormalizeMood("  Playful "); err != nil || mood != "playful" {
+		t.Fatalf("expected playful, got %q, %v", mood, err)
+	}
+	_, err := NormalizeMood("furious")
+	if err == nil {
+		t.Fatal("expected unknown mood to be rejected")
+	}
+	if !strings.Contains(err.Error(), "contrarian, optimistic, playful, reflective, skeptical") {
+		t.Fatalf("expected error to list moods, got %q", err.Error())
+	}
+}
+
+func TestMockDraftReflectsMood(t *testing.T) {
+	client := NewMockClient()
+	room := RoomContext{Name: "product", Variant: 1}
+
+	plain, err := client.GeneratePostDraft(context.Background(), PersonaContext{Name: "Ada"}, room)
+	if err != nil {
+		t.Fatalf("draft failed: %v", err)
+	}
+	moody, err := client.GeneratePostDraft(context.Background(), PersonaContext{Name: "Ada", Mood: "contrarian"}, room)
+	if err != nil {
+		t.Fatalf("draft failed: %v", err)
+	}
+	if strings.Contains(plain, "contrarian") || !strings.Contains(moody, "contrarian take") {
+		t.Fatalf("expected only the mood draft to mention the mood, got %q and %q", plain, moody)
+	}
+}
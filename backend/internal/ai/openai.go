@@ -15,6 +15,7 @@ import (
 )
 
 type OpenAIClient struct {
+	promptedClient
 	apiKey         string
 	baseURL        string
 	model          string
@@ -38,7 +39,7 @@ func NewOpenAIClient(apiKey, baseURL, model string, requestTimeout time.Duration
 		retryBase = 400 * time.Millisecond
 	}
 
-	return &OpenAIClient{
+	client := &OpenAIClient{
 		apiKey:         apiKey,
 		baseURL:        strings.TrimRight(baseURL, "/"),
 		model:          model,
@@ -49,81 +50,8 @@ func NewOpenAIClient(apiKey, baseURL, model string, requestTimeout time.Duration
 			Timeout: requestTimeout,
 		},
 	}
-}
-
-func (c *OpenAIClient) GeneratePostDraft(ctx context.Context, persona PersonaContext, room RoomContext) (string, error) {
-	prompt := prompts.PostDraft(
-		prompts.Persona{
-			Name:              persona.Name,
-			Bio:               persona.Bio,
-			Tone:              persona.Tone,
-			WritingSamples:    persona.WritingSamples,
-			DoNotSay:          persona.DoNotSay,
-			Catchphrases:      persona.Catchphrases,
-			PreferredLanguage: persona.PreferredLanguage,
-			Formality:         persona.Formality,
-		},
-		prompts.Room{
-			Name:        room.Name,
-			Description: room.Description,
-			Variant:     room.Variant,
-		},
-	)
-	return c.chat(ctx, prompt.System, prompt.User)
-}
-
-func (c *OpenAIClient) GenerateReply(ctx context.Context, persona PersonaContext, post PostContext, thread []ReplyContext) (string, error) {
-	promptThread := make([]prompts.ReplyItem, 0, len(thread))
-	for _, reply := range thread {
-		promptThread = append(promptThread, prompts.ReplyItem{Content: reply.Content})
-	}
-
-	prompt := prompts.Reply(
-		prompts.Persona{
-			Name: persona.Name,
-			Bio:  persona.Bio,
-			Tone: persona.Tone,
-		},
-		prompts.Post{Content: post.Content},
-		promptThread,
-	)
-	return c.chat(ctx, prompt.System, prompt.User)
-}
-
-func (c *OpenAIClient) SummarizeThread(ctx context.Context, post PostContext, replies []ReplyContext) (string, error) {
-	promptReplies := make([]prompts.ReplyItem, 0, len(replies))
-	for _, reply := range replies {
-		promptReplies = append(promptReplies, prompts.ReplyItem{Content: reply.Content})
-	}
-
-	prompt := prompts.ThreadSummary(prompts.Post{Content: post.Content}, promptReplies)
-	return c.chat(ctx, prompt.System, prompt.User)
-}
-
-func (c *OpenAIClient) SummarizePersonaActivity(ctx context.Context, persona PersonaContext, stats DigestStats, threads []DigestThreadContext) (string, error) {
-	promptThreads := make([]prompts.DigestThread, 0, len(threads))
-	for _, thread := range threads {
-		promptThreads = append(promptThreads, prompts.DigestThread{
-			PostID:        thread.PostID,
-			RoomName:      thread.RoomName,
-			PostPreview:   thread.PostPreview,
-			ActivityCount: thread.ActivityCount,
-		})
-	}
-
-	prompt := prompts.PersonaActivitySummary(
-		prompts.Persona{
-			Name:              persona.Name,
-			Tone:              persona.Tone,
-			PreferredLanguage: persona.PreferredLanguage,
-		},
-		prompts.DigestStats{
-			Posts:   stats.Posts,
-			Replies: stats.Replies,
-		},
-		promptThreads,
-	)
-	return c.chat(ctx, prompt.System, prompt.User)
+	client.promptedClient = promptedClient{prompts: prompts.MustNewRegistry(), complete: client.chat}
+	return client
 }
 
 func (c *OpenAIClient) endpoint() string {
@@ -162,6 +90,9 @@ func (c *OpenAIClient) chat(ctx context.Context, system, user string) (string, e
 		}
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 		req.Header.Set("Content-Type", "application/json")
+		if requestID := RequestIDFromContext(ctx); requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
 
 		content, retryable, err := c.chatOnce(req)
 		if err == nil {
@@ -172,6 +103,7 @@ func (c *OpenAIClient) chat(ctx context.Context, system, user string) (string, e
 			break
 		}
 
+		recordRetry(ctx)
 		wait := retryDelay(c.retryBase, attempt)
 		timer := time.NewTimer(wait)
 		select {
@@ -193,6 +125,8 @@ func (c *OpenAIClient) chatOnce(req *http.Request) (string, bool, error) {
 		return "", true, err
 	}
 	defer resp.Body.Close()
+	providerRequestID := strings.TrimSpace(resp.Header.Get(providerRequestIDHeader))
+	recordProviderRequestID(req.Context(), providerRequestID)
 
 	if resp.StatusCode >= 400 {
 		bodySnippet, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
@@ -200,11 +134,8 @@ func (c *OpenAIClient) chatOnce(req *http.Request) (string, bool, error) {
 		if message == "" {
 			message = fmt.Sprintf("status %d", resp.StatusCode)
 		}
-		err := fmt.Errorf("openai provider error: status=%d body=%s", resp.StatusCode, message)
-		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
-			return "", true, err
-		}
-		return "", false, err
+		err := &ProviderError{Provider: "openai", StatusCode: resp.StatusCode, Body: message, RequestID: providerRequestID}
+		return "", err.Overloaded(), err
 	}
 
 	var out struct {
@@ -213,10 +144,15 @@ func (c *OpenAIClient) chatOnce(req *http.Request) (string, bool, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 		return "", true, err
 	}
+	addUsage(req.Context(), out.Usage.PromptTokens, out.Usage.CompletionTokens)
 	if len(out.Choices) == 0 {
 		return "", true, errors.New("openai provider returned no choices")
 	}
@@ -0,0 +1,251 @@
+package ai
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/ai/prompts"
+)
+
+// promptedClient implements LLMClient on top of a single chat completion:
+// it renders each operation's prompt from the registry and hands the system
+// and user messages to complete. Providers embed it and supply complete.
+type promptedClient struct {
+	prompts  *prompts.Registry
+	complete func(ctx context.Context, system, user string) (string, error)
+}
+
+func (c *promptedClient) Prompts() *prompts.Registry {
+	return c.prompts
+}
+
+func (c *promptedClient) GeneratePostDraft(ctx context.Context, persona PersonaContext, room RoomContext) (string, error) {
+	prompt, err := c.prompts.PostDraft(
+		prompts.Persona{
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			WritingSamples:    persona.WritingSamples,
+			DoNotSay:          persona.DoNotSay,
+			Catchphrases:      persona.Catchphrases,
+			PreferredLanguage: persona.PreferredLanguage,
+			Formality:         persona.Formality,
+			Style:             prompts.Style(persona.Style),
+			Mood:              persona.Mood,
+			MoodHint:          MoodHint(persona.Mood),
+		},
+		prompts.Room{
+			Name:        room.Name,
+			Description: room.Description,
+			Variant:     room.Variant,
+			Hint:        prompts.RoomHint(room.Hint),
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) GenerateReply(ctx context.Context, persona PersonaContext, post PostContext, thread []ReplyContext) (string, error) {
+	promptThread := make([]prompts.ReplyItem, 0, len(thread))
+	for _, reply := range thread {
+		promptThread = append(promptThread, prompts.ReplyItem{
+			Content: reply.Content,
+			Own:     reply.PersonaID != "" && reply.PersonaID == persona.ID,
+			Human:   reply.Human,
+		})
+	}
+
+	prompt, err := c.prompts.Reply(
+		prompts.Persona{
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			PreferredLanguage: persona.PreferredLanguage,
+			Style:             prompts.Style(persona.Style),
+		},
+		prompts.Post{Content: post.Content, AvoidReply: post.AvoidReply, RepeatedReply: post.RepeatedReply, RoomHint: prompts.RoomHint(post.RoomHint), ContextPack: post.ContextPack},
+		promptThread,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) SummarizeThread(ctx context.Context, post PostContext, replies []ReplyContext) (string, error) {
+	promptReplies := make([]prompts.ReplyItem, 0, len(replies))
+	for _, reply := range replies {
+		promptReplies = append(promptReplies, prompts.ReplyItem{Content: reply.Content, Human: reply.Human})
+	}
+
+	prompt, err := c.prompts.ThreadSummary(prompts.Post{Content: post.Content}, promptReplies)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) SummarizePersonaActivity(ctx context.Context, persona PersonaContext, stats DigestStats, threads []DigestThreadContext) (string, error) {
+	promptThreads := make([]prompts.DigestThread, 0, len(threads))
+	for _, thread := range threads {
+		promptThreads = append(promptThreads, prompts.DigestThread{
+			PostID:        thread.PostID,
+			RoomName:      thread.RoomName,
+			PostPreview:   thread.PostPreview,
+			ActivityCount: thread.ActivityCount,
+		})
+	}
+
+	prompt, err := c.prompts.PersonaActivitySummary(
+		prompts.Persona{
+			Name:              persona.Name,
+			Tone:              persona.Tone,
+			PreferredLanguage: persona.PreferredLanguage,
+		},
+		prompts.DigestStats{
+			Posts:   stats.Posts,
+			Replies: stats.Replies,
+		},
+		promptThreads,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) CoachBattlePersona(ctx context.Context, persona PersonaContext, topic string, turns []BattleTurnContext) (string, error) {
+	promptTurns := make([]prompts.BattleTurn, 0, len(turns))
+	for _, turn := range turns {
+		promptTurns = append(promptTurns, prompts.BattleTurn{
+			Turn:        turn.Turn,
+			PersonaName: turn.PersonaName,
+			Content:     turn.Content,
+		})
+	}
+
+	prompt, err := c.prompts.BattleCoaching(
+		prompts.Persona{
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			PreferredLanguage: persona.PreferredLanguage,
+		},
+		topic,
+		promptTurns,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) SummarizeBattleHighlights(ctx context.Context, topic string, turns []BattleTurnContext) (string, error) {
+	promptTurns := make([]prompts.BattleTurn, 0, len(turns))
+	for _, turn := range turns {
+		promptTurns = append(promptTurns, prompts.BattleTurn{
+			Turn:        turn.Turn,
+			PersonaName: turn.PersonaName,
+			Content:     turn.Content,
+		})
+	}
+
+	prompt, err := c.prompts.BattleHighlights(topic, promptTurns)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) SummarizeContextPack(ctx context.Context, topic string, sources []ContextSourceContext) (string, error) {
+	promptSources := make([]prompts.ContextSource, 0, len(sources))
+	for _, source := range sources {
+		promptSources = append(promptSources, prompts.ContextSource{
+			PersonaName: source.PersonaName,
+			Content:     source.Content,
+		})
+	}
+
+	prompt, err := c.prompts.BattleContextPack(topic, promptSources)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) TranslatePost(ctx context.Context, post PostContext, sourceLanguage, targetLanguage string) (string, error) {
+	prompt, err := c.prompts.PostTranslation(prompts.Post{Content: post.Content}, sourceLanguage, targetLanguage)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) AnswerQuestion(ctx context.Context, persona PersonaContext, question string) (string, error) {
+	prompt, err := c.prompts.PersonaAnswer(
+		prompts.Persona{
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			WritingSamples:    persona.WritingSamples,
+			DoNotSay:          persona.DoNotSay,
+			Catchphrases:      persona.Catchphrases,
+			PreferredLanguage: persona.PreferredLanguage,
+			Formality:         persona.Formality,
+			Style:             prompts.Style(persona.Style),
+		},
+		question,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) ProposeBattleTopic(ctx context.Context, post PostContext, room RoomContext) (string, error) {
+	prompt, err := c.prompts.BattleProposition(
+		prompts.Post{Content: post.Content},
+		prompts.Room{Name: room.Name, Description: room.Description},
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) CheckRoomFit(ctx context.Context, post PostContext, room RoomContext, candidates []RoomContext) (string, error) {
+	promptCandidates := make([]prompts.Room, 0, len(candidates))
+	for _, candidate := range candidates {
+		promptCandidates = append(promptCandidates, prompts.Room{
+			Slug:        candidate.Slug,
+			Name:        candidate.Name,
+			Description: candidate.Description,
+		})
+	}
+
+	prompt, err := c.prompts.RoomFit(
+		prompts.Post{Content: post.Content},
+		prompts.Room{Slug: room.Slug, Name: room.Name, Description: room.Description},
+		promptCandidates,
+	)
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
+
+func (c *promptedClient) ClassifyPersonaStyle(ctx context.Context, persona PersonaContext) (string, error) {
+	prompt, err := c.prompts.PersonaStyle(prompts.Persona{
+		Name:           persona.Name,
+		Bio:            persona.Bio,
+		Tone:           persona.Tone,
+		WritingSamples: persona.WritingSamples,
+		Catchphrases:   persona.Catchphrases,
+		Formality:      persona.Formality,
+	})
+	if err != nil {
+		return "", err
+	}
+	return c.complete(ctx, prompt.System, prompt.User)
+}
@@ -14,20 +14,56 @@ type Persona struct {
 	Catchphrases      []string
 	PreferredLanguage string
 	Formality         int
+	Style             Style
+	Mood              string
+	MoodHint          string
+}
+
+type Style struct {
+	Humor         int
+	Assertiveness int
+	Technicality  int
+	Brevity       int
 }
 
 type Room struct {
+	Slug        string
 	Name        string
 	Description string
 	Variant     int
+	Hint        RoomHint
+}
+
+// RoomHint is moderator guidance for a room; templates render it only when
+// one of the fields is set.
+type RoomHint struct {
+	Emphasize string
+	Structure string
 }
 
 type Post struct {
-	Content string
+	Content       string
+	AvoidReply    string
+	RepeatedReply string
+	RoomHint      RoomHint
+	ContextPack   string
 }
 
+// ReplyItem is a reply in a thread. Own marks replies written by the persona
+// the prompt is for; Human marks replies a person wrote.
 type ReplyItem struct {
 	Content string
+	Own     bool
+	Human   bool
+}
+
+// line renders the reply as it appears in a thread, labelling human replies
+// so the model can tell them apart from persona replies.
+func (r ReplyItem) line() string {
+	if r.Human {
+		return "(human) " + r.Content
+	}
+	return r.Content
 }
 
 type DigestStats struct {
@@ -42,51 +78,61 @@ type DigestThread struct {
 	ActivityCount int
 }
 
+type BattleTurn struct {
+	Turn        int
+	PersonaName string
+	Content     string
+}
+
+// ContextSource is a room post summarized into a battle context pack.
+type ContextSource struct {
+	PersonaName string
+	Content     string
+}
+
 type ChatPrompt struct {
-	System string
-	User   string
-}
-
-func PostDraft(persona Persona, room Room) ChatPrompt {
-	system := "You create concise social posts for an AI persona. Keep output non-spam, no links, and no hashtag stuffing."
-	user := fmt.Sprintf(
-		"Persona: %s\nBio: %s\nTone: %s\nPreferred language: %s\nFormality (0 casual - 3 formal): %d\nWriting samples: %s\nDo not say list: %s\nCatchphrases: %s\nRoom: %s\nRoom Description: %s\nVariant: %d\nOutput rules: <= 90 words, exactly two sentences, first sentence has one practical insight, second sentence has one question. Avoid banned phrases and do not sound promotional.",
-		persona.Name,
-		persona.Bio,
-		persona.Tone,
-		persona.PreferredLanguage,
-		persona.Formality,
-		formatStringList(persona.WritingSamples),
-		formatStringList(persona.DoNotSay),
-		formatStringList(persona.Catchphrases),
-		room.Name,
-		room.Description,
-		room.Variant,
-	)
-	return ChatPrompt{System: system, User: user}
-}
-
-func Reply(persona Persona, post Post, thread []ReplyItem) ChatPrompt {
-	var threadLines []string
+	Operation string
+	Version   string
+	System    string
+	User      string
+}
+
+func (r *Registry) PostDraft(persona Persona, room Room) (ChatPrompt, error) {
+	return r.render(OpPostDraft, map[string]any{
+		"Persona": persona,
+		"Room":    room,
+	})
+}
+
+func (r *Registry) Reply(persona Persona, post Post, thread []ReplyItem) (ChatPrompt, error) {
+	threadLines := make([]string, 0, len(thread))
+	ownLines := make([]string, 0)
 	for _, reply := range thread {
-		threadLines = append(threadLines, reply.Content)
+		threadLines = append(threadLines, reply.line())
+		if reply.Own {
+			ownLines = append(ownLines, reply.Content)
+		}
 	}
-	system := "You create one short, constructive social reply for a persona."
-	user := fmt.Sprintf("Persona: %s\nBio: %s\nTone: %s\nPost: %s\nThread: %s\nGenerate one reply in <=90 words.", persona.Name, persona.Bio, persona.Tone, post.Content, strings.Join(threadLines, "\n- "))
-	return ChatPrompt{System: system, User: user}
+	return r.render(OpReply, map[string]any{
+		"Persona":    persona,
+		"Post":       post,
+		"Thread":     threadLines,
+		"OwnReplies": ownLines,
+	})
 }
 
-func ThreadSummary(post Post, replies []ReplyItem) ChatPrompt {
-	var parts []string
+func (r *Registry) ThreadSummary(post Post, replies []ReplyItem) (ChatPrompt, error) {
+	parts := make([]string, 0, len(replies))
 	for _, reply := range replies {
-		parts = append(parts, reply.Content)
+		parts = append(parts, reply.line())
 	}
-	system := "You summarize threads in a few bullet-like sentences with neutral tone."
-	user := fmt.Sprintf("Post: %s\nReplies: %s\nProvide a compact summary in <=120 words.", post.Content, strings.Join(parts, "\n- "))
-	return ChatPrompt{System: system, User: user}
+	return r.render(OpThreadSummary, map[string]any{
+		"Post":    post,
+		"Replies": parts,
+	})
 }
 
-func PersonaActivitySummary(persona Persona, stats DigestStats, threads []DigestThread) ChatPrompt {
+func (r *Registry) PersonaActivitySummary(persona Persona, stats DigestStats, threads []DigestThread) (ChatPrompt, error) {
 	threadLines := make([]string, 0, len(threads))
 	for _, thread := range threads {
 		threadLines = append(threadLines, fmt.Sprintf("post_id=%s | room=%s | activity=%d | preview=%s", thread.PostID, thread.RoomName, thread.ActivityCount, thread.PostPreview))
@@ -94,18 +140,101 @@ func PersonaActivitySummary(persona Persona, stats DigestStats, threads []Digest
 	if len(threadLines) == 0 {
 		threadLines = append(threadLines, "No active threads")
 	}
+	return r.render(OpPersonaActivitySummary, map[string]any{
+		"Persona": persona,
+		"Stats":   stats,
+		"Threads": threadLines,
+	})
+}
+
+func (r *Registry) BattleCoaching(persona Persona, topic string, turns []BattleTurn) (ChatPrompt, error) {
+	turnLines := make([]string, 0, len(turns))
+	for _, turn := range turns {
+		turnLines = append(turnLines, fmt.Sprintf("turn %d | %s: %s", turn.Turn, turn.PersonaName, turn.Content))
+	}
+	if len(turnLines) == 0 {
+		turnLines = append(turnLines, "No turns")
+	}
+	return r.render(OpBattleCoaching, map[string]any{
+		"Persona": persona,
+		"Topic":   topic,
+		"Turns":   turnLines,
+	})
+}
+
+func (r *Registry) BattleHighlights(topic string, turns []BattleTurn) (ChatPrompt, error) {
+	turnLines := make([]string, 0, len(turns))
+	for _, turn := range turns {
+		turnLines = append(turnLines, fmt.Sprintf("turn %d | %s: %s", turn.Turn, turn.PersonaName, turn.Content))
+	}
+	if len(turnLines) == 0 {
+		turnLines = append(turnLines, "No turns")
+	}
+	return r.render(OpBattleHighlights, map[string]any{
+		"Topic": topic,
+		"Turns": turnLines,
+	})
+}
+
+func (r *Registry) BattleContextPack(topic string, sources []ContextSource) (ChatPrompt, error) {
+	sourceLines := make([]string, 0, len(sources))
+	for i, source := range sources {
+		sourceLines = append(sourceLines, fmt.Sprintf("post %d | %s: %s", i+1, source.PersonaName, source.Content))
+	}
+	if len(sourceLines) == 0 {
+		sourceLines = append(sourceLines, "No posts")
+	}
+	return r.render(OpBattleContextPack, map[string]any{
+		"Topic":   topic,
+		"Sources": sourceLines,
+	})
+}
+
+func (r *Registry) PostTranslation(post Post, sourceLanguage, targetLanguage string) (ChatPrompt, error) {
+	return r.render(OpPostTranslation, map[string]any{
+		"Post":           post,
+		"SourceLanguage": sourceLanguage,
+		"TargetLanguage": targetLanguage,
+	})
+}
+
+func (r *Registry) PersonaAnswer(persona Persona, question string) (ChatPrompt, error) {
+	return r.render(OpPersonaAnswer, map[string]any{
+		"Persona":  persona,
+		"Question": question,
+	})
+}
+
+func (r *Registry) BattleProposition(post Post, room Room) (ChatPrompt, error) {
+	return r.render(OpBattleProposition, map[string]any{
+		"Post": post,
+		"Room": room,
+	})
+}
+
+func (r *Registry) RoomFit(post Post, room Room, candidates []Room) (ChatPrompt, error) {
+	candidateLines := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateLines = append(candidateLines, fmt.Sprintf("- %s | %s: %s", candidate.Slug, candidate.Name, candidate.Description))
+	}
+	if len(candidateLines) == 0 {
+		candidateLines = append(candidateLines, "none")
+	}
+	return r.render(OpRoomFit, map[string]any{
+		"Post":       post,
+		"Room":       room,
+		"Candidates": strings.Join(candidateLines, "\n"),
+	})
+}
+
+func (r *Registry) PersonaStyle(persona Persona) (ChatPrompt, error) {
+	return r.render(OpPersonaStyle, map[string]any{
+		"Persona": persona,
+	})
+}
 
-	system := "You write one concise digest paragraph describing what happened while the user was away."
-	user := fmt.Sprintf(
-		"Persona: %s\nTone: %s\nPreferred language: %s\nStats today: posts=%d, replies=%d\nTop threads: %s\nOutput rules: 1 paragraph, <=120 words, concrete and neutral, mention thread themes.",
-		persona.Name,
-		persona.Tone,
-		persona.PreferredLanguage,
-		stats.Posts,
-		stats.Replies,
-		strings.Join(threadLines, "\n- "),
-	)
-	return ChatPrompt{System: system, User: user}
+func formatStyle(style Style) string {
+	return fmt.Sprintf("humor %d, assertiveness %d, technicality %d, brevity %d", style.Humor, style.Assertiveness, style.Technicality, style.Brevity)
 }
 
 func formatStringList(items []string) string {
@@ -0,0 +1,237 @@
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	OpPostDraft              = "post_draft"
+	OpReply                  = "reply"
+	OpThreadSummary          = "thread_summary"
+	OpPersonaActivitySummary = "persona_activity_summary"
+	OpBattleCoaching         = "battle_coaching"
+	OpPostTranslation        = "post_translation"
+	OpPersonaAnswer          = "persona_answer"
+	OpBattleProposition      = "battle_proposition"
+	OpRoomFit                = "room_fit"
+	OpPersonaStyle           = "persona_style"
+	OpBattleHighlights       = "battle_highlights"
+	OpBattleContextPack      = "battle_context_pack"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+type OperationStatus struct {
+	Operation string   `json:"operation"`
+	Active    string   `json:"active_version"`
+	Default   string   `json:"default_version"`
+	Pinned    bool     `json:"pinned"`
+	Versions  []string `json:"versions"`
+}
+
+// Registry holds every embedded prompt template version. The newest version of
+// an operation is active unless a pin (stored in prompt_versions) overrides it.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]*template.Template
+	versions  map[string][]string
+	pins      map[string]string
+	syncedAt  time.Time
+}
+
+func NewRegistry() (*Registry, error) {
+	entries, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		templates: map[string]map[string]*template.Template{},
+		versions:  map[string][]string{},
+		pins:      map[string]string{},
+	}
+	funcs := template.FuncMap{
+		"join":  strings.Join,
+		"list":  formatStringList,
+		"style": formatStyle,
+	}
+	for _, entry := range entries {
+		operation, version, ok := parseTemplateName(path.Base(entry))
+		if !ok {
+			return nil, fmt.Errorf("invalid prompt template name %q", entry)
+		}
+		raw, err := templateFS.ReadFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(entry).Option("missingkey=error").Funcs(funcs).Parse(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("parse prompt template %s: %w", entry, err)
+		}
+		if tmpl.Lookup("system") == nil || tmpl.Lookup("user") == nil {
+			return nil, fmt.Errorf("prompt template %s must define system and user", entry)
+		}
+		if r.templates[operation] == nil {
+			r.templates[operation] = map[string]*template.Template{}
+		}
+		r.templates[operation][version] = tmpl
+		r.versions[operation] = append(r.versions[operation], version)
+	}
+	for operation := range r.versions {
+		sort.Slice(r.versions[operation], func(i, j int) bool {
+			return versionNumber(r.versions[operation][i]) < versionNumber(r.versions[operation][j])
+		})
+	}
+	return r, nil
+}
+
+func MustNewRegistry() *Registry {
+	r, err := NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Registry) Operations() []string {
+	operations := make([]string, 0, len(r.versions))
+	for operation := range r.versions {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+func (r *Registry) HasVersion(operation, version string) bool {
+	_, ok := r.templates[operation][version]
+	return ok
+}
+
+func (r *Registry) Active(operation string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.activeLocked(operation)
+}
+
+func (r *Registry) activeLocked(operation string) string {
+	if pinned, ok := r.pins[operation]; ok {
+		return pinned
+	}
+	versions := r.versions[operation]
+	if len(versions) == 0 {
+		return ""
+	}
+	return versions[len(versions)-1]
+}
+
+func (r *Registry) Status() []OperationStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]OperationStatus, 0, len(r.versions))
+	for _, operation := range r.Operations() {
+		versions := append([]string(nil), r.versions[operation]...)
+		_, pinned := r.pins[operation]
+		out = append(out, OperationStatus{
+			Operation: operation,
+			Active:    r.activeLocked(operation),
+			Default:   versions[len(versions)-1],
+			Pinned:    pinned,
+			Versions:  versions,
+		})
+	}
+	return out
+}
+
+// SetPins replaces all pins. Pins naming unknown operations or versions (for
+// example a rollback target removed in a later deploy) are skipped and returned.
+func (r *Registry) SetPins(pins map[string]string) []string {
+	next := make(map[string]string, len(pins))
+	skipped := make([]string, 0)
+	for operation, version := range pins {
+		if !r.HasVersion(operation, version) {
+			skipped = append(skipped, operation+"="+version)
+			continue
+		}
+		next[operation] = version
+	}
+	sort.Strings(skipped)
+
+	r.mu.Lock()
+	r.pins = next
+	r.syncedAt = time.Now()
+	r.mu.Unlock()
+	return skipped
+}
+
+// Sync reloads pins through load when the last sync is older than every, so
+// API and worker processes pick up rollouts without a restart.
+func (r *Registry) Sync(ctx context.Context, every time.Duration, load func(context.Context) (map[string]string, error)) error {
+	r.mu.RLock()
+	fresh := !r.syncedAt.IsZero() && time.Since(r.syncedAt) < every
+	r.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	pins, err := load(ctx)
+	if err != nil {
+		return err
+	}
+	r.SetPins(pins)
+	return nil
+}
+
+func (r *Registry) render(operation string, data any) (ChatPrompt, error) {
+	version := r.Active(operation)
+	tmpl, ok := r.templates[operation][version]
+	if !ok {
+		return ChatPrompt{}, fmt.Errorf("prompt %s has no version %q", operation, version)
+	}
+
+	var system, user bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&system, "system", data); err != nil {
+		return ChatPrompt{}, fmt.Errorf("render prompt %s@%s: %w", operation, version, err)
+	}
+	if err := tmpl.ExecuteTemplate(&user, "user", data); err != nil {
+		return ChatPrompt{}, fmt.Errorf("render prompt %s@%s: %w", operation, version, err)
+	}
+	return ChatPrompt{
+		Operation: operation,
+		Version:   version,
+		System:    system.String(),
+		User:      user.String(),
+	}, nil
+}
+
+func parseTemplateName(name string) (string, string, bool) {
+	base := strings.TrimSuffix(name, ".tmpl")
+	operation, version, found := strings.Cut(base, ".")
+	if !found || operation == "" || versionNumber(version) <= 0 {
+		return "", "", false
+	}
+	return operation, version, true
+}
+
+func versionNumber(version string) int {
+	if !strings.HasPrefix(version, "v") {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
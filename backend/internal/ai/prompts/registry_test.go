@@ -0,0 +1,226 @@
+package prompts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryLoadsEmbeddedTemplates(t *testing.T) {
+	r := MustNewRegistry()
+	for _, operation := range []string{OpPostDraft, OpReply, OpThreadSummary, OpPersonaActivitySummary, OpBattleCoaching, OpPostTranslation, OpPersonaAnswer, OpBattleProposition, OpRoomFit, OpPersonaStyle, OpBattleHighlights, OpBattleContextPack} {
+		if r.Active(operation) == "" {
+			t.Fatalf("expected an active version for %s", operation)
+		}
+	}
+
+	prompt, err := r.Reply(Persona{Name: "Ada", Bio: "Builder", Tone: "calm"}, Post{Content: "Ship weekly?"}, []ReplyItem{{Content: "yes"}, {Content: "no"}})
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if prompt.Operation != OpReply || prompt.Version != r.Active(OpReply) {
+		t.Fatalf("unexpected prompt identity %s@%s", prompt.Operation, prompt.Version)
+	}
+	if !strings.Contains(prompt.User, "Thread: yes\n- no") {
+		t.Fatalf("expected joined thread in user prompt, got %q", prompt.User)
+	}
+	if strings.Contains(prompt.User, "different angle") {
+		t.Fatalf("expected no angle instruction without a reply to avoid, got %q", prompt.User)
+	}
+
+	prompt, err = r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?", AvoidReply: "Run a small experiment."}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(prompt.User, "Another persona already replied: Run a small experiment.") || !strings.Contains(prompt.User, "different angle") {
+		t.Fatalf("expected angle instruction in user prompt, got %q", prompt.User)
+	}
+	if strings.Contains(prompt.User, "Language:") {
+		t.Fatalf("expected no language instruction without a preferred language, got %q", prompt.User)
+	}
+
+	prompt, err = r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?"}, []ReplyItem{{Content: "Measure first.", Own: true}, {Content: "Just ship."}})
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(prompt.User, "Your earlier replies in this thread:\n- Measure first.\n") || !strings.Contains(prompt.User, "don't repeat yourself") {
+		t.Fatalf("expected own replies with continuity instruction, got %q", prompt.User)
+	}
+	if strings.Contains(prompt.User, "- Just ship.\nMaintain") {
+		t.Fatalf("expected other personas' replies outside the own list, got %q", prompt.User)
+	}
+
+	prompt, err = r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?", RepeatedReply: "Measure first."}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if strings.Contains(prompt.User, "Your earlier replies") || !strings.Contains(prompt.User, "Your draft restated what you already said: Measure first.") {
+		t.Fatalf("expected repeat instruction only, got %q", prompt.User)
+	}
+
+	prompt, err = r.Reply(Persona{Name: "Ada", PreferredLanguage: "tr"}, Post{Content: "Ship weekly?"}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(prompt.User, "write the reply only in tr") {
+		t.Fatalf("expected language instruction in user prompt, got %q", prompt.User)
+	}
+
+	thread := []ReplyItem{{Content: "Persona take."}, {Content: "I tried this at work.", Human: true}}
+	prompt, err = r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?"}, thread)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(prompt.User, "Thread: Persona take.\n- (human) I tried this at work.") {
+		t.Fatalf("expected human reply to be labelled in the thread, got %q", prompt.User)
+	}
+	prompt, err = r.ThreadSummary(Post{Content: "Ship weekly?"}, thread)
+	if err != nil {
+		t.Fatalf("render thread summary failed: %v", err)
+	}
+	if !strings.Contains(prompt.User, "- (human) I tried this at work.") {
+		t.Fatalf("expected human reply to be labelled in the summary, got %q", prompt.User)
+	}
+}
+
+func TestRegistryPinsSkipUnknownVersions(t *testing.T) {
+	r := MustNewRegistry()
+	defaultVersion := r.Active(OpReply)
+
+	skipped := r.SetPins(map[string]string{
+		OpReply:   "v999",
+		"unknown": "v1",
+	})
+	if len(skipped) != 2 {
+		t.Fatalf("expected both pins to be skipped, got %v", skipped)
+	}
+	if r.Active(OpReply) != defaultVersion {
+		t.Fatalf("expected default version %s, got %s", defaultVersion, r.Active(OpReply))
+	}
+
+	if skipped := r.SetPins(map[string]string{OpReply: "v1"}); len(skipped) != 0 {
+		t.Fatalf("expected v1 pin to apply, skipped %v", skipped)
+	}
+	if r.Active(OpReply) != "v1" {
+		t.Fatalf("expected pinned v1, got %s", r.Active(OpReply))
+	}
+}
+
+func TestRegistrySyncHonoursInterval(t *testing.T) {
+	r := MustNewRegistry()
+	calls := 0
+	load := func(context.Context) (map[string]string, error) {
+		calls++
+		return map[string]string{}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := r.Sync(context.Background(), time.Minute, load); err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected one load within interval, got %d", calls)
+	}
+
+	failing := MustNewRegistry()
+	if err := failing.Sync(context.Background(), time.Minute, func(context.Context) (map[string]string, error) {
+		return nil, errors.New("db down")
+	}); err == nil {
+		t.Fatalf("expected load error to surface")
+	}
+}
+
+func TestParseTemplateName(t *testing.T) {
+	operation, version, ok := parseTemplateName("post_draft.v2.tmpl")
+	if !ok || operation != "post_draft" || version != "v2" {
+		t.Fatalf("unexpected parse result %q %q %v", operation, version, ok)
+	}
+	for _, name := range []string{"post_draft.tmpl", "post_draft.latest.tmpl", ".v1.tmpl"} {
+		if _, _, ok := parseTemplateName(name); ok {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestPostDraftMoodLine(t *testing.T) {
+	r := MustNewRegistry()
+	if r.Active(OpPostDraft) != "v4" {
+		t.Fatalf("expected post_draft v4 to be active, got %s", r.Active(OpPostDraft))
+	}
+
+	style := Style{Humor: 80, Assertiveness: 30, Technicality: 65, Brevity: 90}
+	plain, err := r.PostDraft(Persona{Name: "Ada", Tone: "calm", Formality: 1, Style: style}, Room{Name: "product"})
+	if err != nil {
+		t.Fatalf("render post draft failed: %v", err)
+	}
+	if strings.Contains(plain.User, "Mood") || !strings.Contains(plain.User, "humor 80, assertiveness 30, technicality 65, brevity 90\nWriting samples:") {
+		t.Fatalf("expected no mood line without a mood, got %q", plain.User)
+	}
+
+	moody, err := r.PostDraft(Persona{Name: "Ada", Tone: "calm", Formality: 1, Style: style, Mood: "playful", MoodHint: "light and witty"}, Room{Name: "product"})
+	if err != nil {
+		t.Fatalf("render post draft failed: %v", err)
+	}
+	if !strings.Contains(moody.User, "brevity 90\nMood for this post: playful (light and witty).") {
+		t.Fatalf("expected mood line after style sliders, got %q", moody.User)
+	}
+}
+
+func TestRoomHintGuidance(t *testing.T) {
+	r := MustNewRegistry()
+
+	plain, err := r.PostDraft(Persona{Name: "Ada"}, Room{Name: "product", Variant: 1})
+	if err != nil {
+		t.Fatalf("render post draft failed: %v", err)
+	}
+	if strings.Contains(plain.User, "moderator guidance") || !strings.Contains(plain.User, "Variant: 1\nOutput rules:") {
+		t.Fatalf("expected no guidance without a hint, got %q", plain.User)
+	}
+
+	hinted, err := r.PostDraft(Persona{Name: "Ada"}, Room{Name: "product", Variant: 1, Hint: RoomHint{Emphasize: "pricing experiments"}})
+	if err != nil {
+		t.Fatalf("render post draft failed: %v", err)
+	}
+	if !strings.Contains(hinted.User, "Room moderator guidance:\n- Themes to emphasize: pricing experiments\nFollow this guidance") || strings.Contains(hinted.User, "Preferred structure") {
+		t.Fatalf("expected emphasis guidance only, got %q", hinted.User)
+	}
+
+	reply, err := r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?", RoomHint: RoomHint{Structure: "claim, evidence, question"}}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(reply.User, "- Preferred structure: claim, evidence, question") || strings.Contains(reply.User, "Themes to emphasize") {
+		t.Fatalf("expected structure guidance in reply, got %q", reply.User)
+	}
+}
+
+func TestContextPackBackground(t *testing.T) {
+	r := MustNewRegistry()
+
+	plain, err := r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?"}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if strings.Contains(plain.User, "Shared background") {
+		t.Fatalf("expected no background without a context pack, got %q", plain.User)
+	}
+
+	packed, err := r.Reply(Persona{Name: "Ada"}, Post{Content: "Ship weekly?", ContextPack: "Post 1 cites a 12% churn drop."}, nil)
+	if err != nil {
+		t.Fatalf("render reply failed: %v", err)
+	}
+	if !strings.Contains(packed.User, "Shared background from this room (both sides see it): Post 1 cites a 12% churn drop.") {
+		t.Fatalf("expected context pack in reply, got %q", packed.User)
+	}
+
+	pack, err := r.BattleContextPack("Ship weekly?", []ContextSource{{PersonaName: "Ada", Content: "Weekly cut churn 12%."}})
+	if err != nil {
+		t.Fatalf("render context pack failed: %v", err)
+	}
+	if pack.Operation != OpBattleContextPack || !strings.Contains(pack.User, "- post 1 | Ada: Weekly cut churn 12%.") {
+		t.Fatalf("expected numbered sources in context pack prompt, got %q", pack.User)
+	}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+const (
+	// RequestIDHeader carries our correlation id to the provider.
+	RequestIDHeader = "X-Request-ID"
+	// providerRequestIDHeader is the id OpenAI-compatible providers assign
+	// to each call; support quotes it when escalating a bad generation.
+	providerRequestIDHeader = "x-request-id"
+	requestIDMaxLen         = 128
+)
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the correlation id of the incoming request or job
+// that triggers LLM calls on ctx. Blank ids leave ctx unchanged.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	requestID = strings.TrimSpace(requestID)
+	if requestID == "" {
+		return ctx
+	}
+	if len(requestID) > requestIDMaxLen {
+		requestID = requestID[:requestIDMaxLen]
+	}
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the correlation id set by WithRequestID.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// newRequestID is used for calls nothing upstream assigned an id to, so
+// every provider call can still be found in the logs.
+func newRequestID() string {
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return ""
+	}
+	return "llm-" + hex.EncodeToString(raw[:])
+}
+
+// providerTrace remembers the provider-assigned id of the last attempt of
+// the call being measured.
+type providerTrace struct {
+	mu        sync.Mutex
+	requestID string
+}
+
+type providerTraceKey struct{}
+
+func withProviderTrace(ctx context.Context) (context.Context, *providerTrace) {
+	trace := &providerTrace{}
+	return context.WithValue(ctx, providerTraceKey{}, trace), trace
+}
+
+func recordProviderRequestID(ctx context.Context, requestID string) {
+	requestID = strings.TrimSpace(requestID)
+	trace, ok := ctx.Value(providerTraceKey{}).(*providerTrace)
+	if !ok || requestID == "" {
+		return
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.requestID = requestID
+}
+
+func (t *providerTrace) providerRequestID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.requestID
+}
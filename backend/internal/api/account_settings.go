@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type AccountSettings struct {
+	Timezone string `json:"timezone"`
+	// EventRetentionDays is how long the user's raw analytics events and
+	// their personas' activity events are kept; 0 keeps them.
+	EventRetentionDays int `json:"event_retention_days"`
+}
+
+func (s *Server) handleGetAccountSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	settings, err := s.loadAccountSettings(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load account settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings":                settings,
+		"event_retention_options": common.EventRetentionOptions,
+	})
+}
+
+func (s *Server) handleUpdateAccountSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Timezone           *string `json:"timezone"`
+		EventRetentionDays *int    `json:"event_retention_days"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	settings, err := s.loadAccountSettings(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load account settings")
+		return
+	}
+	if req.Timezone != nil {
+		timezone, err := common.NormalizeTimezone(*req.Timezone)
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+		settings.Timezone = timezone
+	}
+	previousRetention := settings.EventRetentionDays
+	if req.EventRetentionDays != nil {
+		if err := common.ValidateEventRetentionDays(*req.EventRetentionDays); err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+		settings.EventRetentionDays = *req.EventRetentionDays
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		UPDATE users
+		SET timezone = $2,
+			event_retention_days = NULLIF($3::int, 0),
+			updated_at = NOW()
+		WHERE id = $1
+	`, userID, settings.Timezone, settings.EventRetentionDays); err != nil {
+		writeInternalError(w, "could not save account settings")
+		return
+	}
+	if settings.EventRetentionDays != previousRetention {
+		s.recordAudit(r, userID, auditEventRetentionChanged, map[string]any{
+			"from_days": previousRetention,
+			"to_days":   settings.EventRetentionDays,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"settings": settings,
+	})
+}
+
+func (s *Server) loadAccountSettings(ctx context.Context, userID string) (AccountSettings, error) {
+	settings := AccountSettings{Timezone: "UTC"}
+	err := s.db.QueryRow(ctx, `
+		SELECT timezone, COALESCE(event_retention_days, 0)
+		FROM users
+		WHERE id = $1
+	`, userID).Scan(&settings.Timezone, &settings.EventRetentionDays)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return AccountSettings{}, err
+	}
+	return settings, nil
+}
+
+// userLocation is the location a user's dates are presented in. A missing
+// user or an unloadable stored name falls back to UTC.
+func (s *Server) userLocation(ctx context.Context, userID string) (*time.Location, error) {
+	settings, err := s.loadAccountSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return common.LoadLocation(settings.Timezone), nil
+}
@@ -143,6 +143,32 @@ func TestAnalyticsTrackingAndSummaryIntegration(t *testing.T) {
 		t.Fatalf("expected signup 201, got %d, body: %s", signupRecorder.Code, signupRecorder.Body.String())
 	}
 
+	shareLinkRecorder := doJSONRequest(fixture.server, http.MethodPost, "/battles/"+draft.ID+"/share-link", fixture.token, `{}`)
+	if shareLinkRecorder.Code != http.StatusCreated {
+		t.Fatalf("expected share link 201, got %d, body: %s", shareLinkRecorder.Code, shareLinkRecorder.Body.String())
+	}
+	var shareLink struct {
+		ShareToken string `json:"share_token"`
+	}
+	if err := json.Unmarshal(shareLinkRecorder.Body.Bytes(), &shareLink); err != nil {
+		t.Fatalf("decode share link failed: %v", err)
+	}
+
+	tokenSignupBody := fmt.Sprintf(
+		`{"email":"signup-from-token-%d@example.com","password":"password123","share_token":"%s"}`,
+		time.Now().UnixNano(),
+		shareLink.ShareToken,
+	)
+	tokenSignupRecorder := doJSONRequest(fixture.server, http.MethodPost, "/auth/signup", "", tokenSignupBody)
+	if tokenSignupRecorder.Code != http.StatusCreated {
+		t.Fatalf("expected token signup 201, got %d, body: %s", tokenSignupRecorder.Code, tokenSignupRecorder.Body.String())
+	}
+
+	spoofedRecorder := doJSONRequest(fixture.server, http.MethodPost, "/events", "", `{"event_name":"signup_from_share"}`)
+	if spoofedRecorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected client signup_from_share 400, got %d, body: %s", spoofedRecorder.Code, spoofedRecorder.Body.String())
+	}
+
 	summaryRecorder := doJSONRequest(fixture.server, http.MethodGet, "/admin/analytics/summary", fixture.token, "")
 	if summaryRecorder.Code != http.StatusOK {
 		t.Fatalf("expected summary 200, got %d, body: %s", summaryRecorder.Code, summaryRecorder.Body.String())
@@ -164,6 +190,7 @@ func TestAnalyticsTrackingAndSummaryIntegration(t *testing.T) {
 		eventBattleShared,
 		eventPublicProfileViewed,
 		eventSignupFromShare,
+		eventSignupFromProfileLink,
 	}
 	for _, eventName := range requiredEvents {
 		if summary.Last24h[eventName] < 1 {
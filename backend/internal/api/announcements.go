@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	announcementTitleMaxRunes = 120
+	announcementBodyMaxRunes  = 2000
+	announcementLinkMaxRunes  = 500
+	announcementsListLimit    = 20
+)
+
+type Announcement struct {
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	LinkURL   string     `json:"link_url,omitempty"`
+	Kind      string     `json:"kind"`
+	Segment   string     `json:"segment"`
+	Major     bool       `json:"major"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AdminAnnouncement adds how many users dismissed the announcement.
+type AdminAnnouncement struct {
+	Announcement
+	Dismissals int `json:"dismissals"`
+}
+
+type announcementRequest struct {
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	LinkURL  string     `json:"link_url"`
+	Kind     string     `json:"kind"`
+	Segment  string     `json:"segment"`
+	Major    bool       `json:"major"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// normalize validates an admin-submitted announcement. Kind defaults to
+// changelog, segment to all and starts_at to now.
+func (req announcementRequest) normalize(now time.Time) (Announcement, error) {
+	out := Announcement{
+		Title:   strings.TrimSpace(req.Title),
+		Body:    strings.TrimSpace(req.Body),
+		LinkURL: strings.TrimSpace(req.LinkURL),
+		Major:   req.Major,
+	}
+	if out.Title == "" || len([]rune(out.Title)) > announcementTitleMaxRunes {
+		return Announcement{}, errors.New("title must be 1-120 characters")
+	}
+	if out.Body == "" || len([]rune(out.Body)) > announcementBodyMaxRunes {
+		return Announcement{}, errors.New("body must be 1-2000 characters")
+	}
+	if out.LinkURL != "" && !validAnnouncementLink(out.LinkURL) {
+		return Announcement{}, errors.New("link_url must be an http(s) URL or a path starting with /")
+	}
+
+	switch kind := strings.ToLower(strings.TrimSpace(req.Kind)); kind {
+	case "":
+		out.Kind = common.AnnouncementKindChangelog
+	case common.AnnouncementKindChangelog, common.AnnouncementKindBroadcast:
+		out.Kind = kind
+	default:
+		return Announcement{}, errors.New("kind must be changelog or broadcast")
+	}
+
+	segment, err := common.NormalizeAnnouncementSegment(req.Segment)
+	if err != nil {
+		return Announcement{}, err
+	}
+	out.Segment = segment
+
+	out.StartsAt = now
+	if req.StartsAt != nil {
+		out.StartsAt = req.StartsAt.UTC()
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		if !endsAt.After(out.StartsAt) {
+			return Announcement{}, errors.New("ends_at must be after starts_at")
+		}
+		out.EndsAt = &endsAt
+	}
+	return out, nil
+}
+
+func validAnnouncementLink(raw string) bool {
+	if len([]rune(raw)) > announcementLinkMaxRunes {
+		return false
+	}
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return true
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+func parseAnnouncementID(raw string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid announcement id")
+	}
+	return id, nil
+}
+
+// handleListAnnouncements returns the live announcements the user has not
+// dismissed and is in the audience of, newest first.
+func (s *Server) handleListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT a.id, a.title, a.body, a.link_url, a.kind, a.segment, a.major, a.starts_at, a.ends_at, a.created_at
+		FROM announcements a
+		WHERE `+common.AnnouncementAudienceSQL("$1")+`
+		ORDER BY a.major DESC, a.starts_at DESC, a.id DESC
+		LIMIT $2
+	`, userID, announcementsListLimit)
+	if err != nil {
+		writeInternalError(w, "could not load announcements")
+		return
+	}
+	defer rows.Close()
+
+	announcements := make([]Announcement, 0)
+	for rows.Next() {
+		var item Announcement
+		if err := rows.Scan(&item.ID, &item.Title, &item.Body, &item.LinkURL, &item.Kind, &item.Segment, &item.Major, &item.StartsAt, &item.EndsAt, &item.CreatedAt); err != nil {
+			writeInternalError(w, "could not load announcements")
+			return
+		}
+		announcements = append(announcements, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load announcements")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"announcements": announcements})
+}
+
+func (s *Server) handleDismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	announcementID, err := parseAnnouncementID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		INSERT INTO announcement_dismissals(user_id, announcement_id)
+		SELECT $1, id
+		FROM announcements
+		WHERE id = $2
+		ON CONFLICT (user_id, announcement_id) DO NOTHING
+	`, userID, announcementID)
+	if err != nil {
+		writeInternalError(w, "could not dismiss announcement")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		exists, err := s.announcementExists(r.Context(), announcementID)
+		if err != nil {
+			writeInternalError(w, "could not dismiss announcement")
+			return
+		}
+		if !exists {
+			writeNotFound(w, "announcement not found")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"dismissed": true})
+}
+
+func (s *Server) announcementExists(ctx context.Context, announcementID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM announcements WHERE id = $1)`, announcementID).Scan(&exists)
+	return exists, err
+}
+
+func (s *Server) handleAdminListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			a.id, a.title, a.body, a.link_url, a.kind, a.segment, a.major, a.starts_at, a.ends_at, a.created_at,
+			(SELECT COUNT(*)::int FROM announcement_dismissals ad WHERE ad.announcement_id = a.id)
+		FROM announcements a
+		ORDER BY a.starts_at DESC, a.id DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		writeInternalError(w, "could not load announcements")
+		return
+	}
+	defer rows.Close()
+
+	announcements := make([]AdminAnnouncement, 0)
+	for rows.Next() {
+		var item AdminAnnouncement
+		if err := rows.Scan(&item.ID, &item.Title, &item.Body, &item.LinkURL, &item.Kind, &item.Segment, &item.Major, &item.StartsAt, &item.EndsAt, &item.CreatedAt, &item.Dismissals); err != nil {
+			writeInternalError(w, "could not load announcements")
+			return
+		}
+		announcements = append(announcements, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load announcements")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"announcements": announcements})
+}
+
+func (s *Server) handleCreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req announcementRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	announcement, err := req.normalize(time.Now().UTC())
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO announcements(title, body, link_url, kind, segment, major, starts_at, ends_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, announcement.Title, announcement.Body, announcement.LinkURL, announcement.Kind, announcement.Segment, announcement.Major, announcement.StartsAt, announcement.EndsAt, userID).Scan(&announcement.ID, &announcement.CreatedAt)
+	if err != nil {
+		writeInternalError(w, "could not save announcement")
+		return
+	}
+
+	s.logger.Info("announcement_created", observability.Fields{
+		"announcement_id": announcement.ID,
+		"kind":            announcement.Kind,
+		"segment":         announcement.Segment,
+		"major":           announcement.Major,
+		"user_id":         userID,
+		"request_id":      requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusCreated, map[string]any{"announcement": announcement})
+}
+
+// handleEndAnnouncement stops showing an announcement. The row is kept so
+// digests that already carry it stay consistent.
+func (s *Server) handleEndAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	announcementID, err := parseAnnouncementID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		UPDATE announcements
+		SET starts_at = LEAST(starts_at, NOW() - INTERVAL '1 second'),
+			ends_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+		  AND (ends_at IS NULL OR ends_at > NOW())
+	`, announcementID)
+	if err != nil {
+		writeInternalError(w, "could not end announcement")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		exists, err := s.announcementExists(r.Context(), announcementID)
+		if err != nil {
+			writeInternalError(w, "could not end announcement")
+			return
+		}
+		if !exists {
+			writeNotFound(w, "announcement not found")
+			return
+		}
+	}
+
+	s.logger.Info("announcement_ended", observability.Fields{
+		"announcement_id": announcementID,
+		"user_id":         userID,
+		"request_id":      requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"ended": true})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestAnnouncementRequestNormalize(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := announcementRequest{Title: "  New digests ", Body: " Weekly digests now show local dates. "}.normalize(now)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got.Title != "New digests" || got.Body != "Weekly digests now show local dates." {
+		t.Fatalf("expected trimmed text, got %+v", got)
+	}
+	if got.Kind != common.AnnouncementKindChangelog || got.Segment != common.AnnouncementSegmentAll || !got.StartsAt.Equal(now) || got.EndsAt != nil {
+		t.Fatalf("unexpected defaults %+v", got)
+	}
+
+	startsAt := now.Add(time.Hour)
+	endsAt := now.Add(48 * time.Hour)
+	got, err = announcementRequest{
+		Title:    "Creator week",
+		Body:     "Launch a persona this week.",
+		LinkURL:  "/personas/new",
+		Kind:     "Broadcast",
+		Segment:  " Creators ",
+		Major:    true,
+		StartsAt: &startsAt,
+		EndsAt:   &endsAt,
+	}.normalize(now)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got.Kind != common.AnnouncementKindBroadcast || got.Segment != common.AnnouncementSegmentCreators || !got.Major || got.EndsAt == nil || !got.EndsAt.Equal(endsAt) {
+		t.Fatalf("unexpected announcement %+v", got)
+	}
+
+	earlier := now.Add(-time.Hour)
+	invalid := map[string]announcementRequest{
+		"missing title": {Body: "body"},
+		"long title":    {Title: strings.Repeat("a", 121), Body: "body"},
+		"missing body":  {Title: "title"},
+		"bad kind":      {Title: "title", Body: "body", Kind: "popup"},
+		"bad segment":   {Title: "title", Body: "body", Segment: "pro"},
+		"bad link":      {Title: "title", Body: "body", LinkURL: "javascript:alert(1)"},
+		"protocol link": {Title: "title", Body: "body", LinkURL: "//evil.test/path"},
+		"ends early":    {Title: "title", Body: "body", EndsAt: &earlier},
+	}
+	for name, req := range invalid {
+		if _, err := req.normalize(now); err == nil {
+			t.Fatalf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestAnnouncementAudienceSQLUsesUserParam(t *testing.T) {
+	clause := common.AnnouncementAudienceSQL("$4")
+	if strings.Contains(clause, "$1") || strings.Count(clause, "$4") != 5 {
+		t.Fatalf("expected every audience check to use the given parameter, got %s", clause)
+	}
+	for _, segment := range common.AnnouncementSegments {
+		if !strings.Contains(clause, "'"+segment+"'") {
+			t.Fatalf("expected audience clause to handle segment %s", segment)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	auditLoginSucceeded          = "login_succeeded"
+	auditLoginFailed             = "login_failed"
+	auditPersonaDeleted          = "persona_deleted"
+	auditProfilePublished        = "profile_published"
+	auditProfileUnpublished      = "profile_unpublished"
+	auditEventRetentionChanged   = "event_retention_changed"
+	auditPersonaShareLinkCreated = "persona_share_link_created"
+	auditPersonaShareLinkRevoked = "persona_share_link_revoked"
+)
+
+const (
+	auditLogDefaultPageSize = 20
+	auditLogMaxPageSize     = 100
+	auditUserAgentMaxRunes  = 256
+)
+
+type AuditLogEntry struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Metadata  map[string]any `json:"metadata"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// recordAudit stores a sensitive account action. Failures are logged rather
+// than returned so an audit outage never blocks the action being audited.
+func (s *Server) recordAudit(r *http.Request, userID, action string, metadata map[string]any) {
+	cleanUserID := strings.TrimSpace(userID)
+	if r == nil || cleanUserID == "" {
+		return
+	}
+
+	payload, err := json.Marshal(sanitizeEventMetadata(metadata))
+	if err != nil {
+		payload = []byte("{}")
+	}
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO audit_log(user_id, action, ip, user_agent, metadata)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+	`, cleanUserID, action, requestClientIP(r), common.TruncateRunes(strings.TrimSpace(r.UserAgent()), auditUserAgentMaxRunes), payload); err != nil {
+		s.logger.Error("audit_log_write_failed", observability.Fields{
+			"action": action,
+			"error":  err.Error(),
+		})
+	}
+}
+
+func parseAuditLogPage(cursorRaw, limitRaw string) (int64, int, error) {
+	var cursor int64
+	if clean := strings.TrimSpace(cursorRaw); clean != "" {
+		parsed, err := strconv.ParseInt(clean, 10, 64)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("invalid cursor")
+		}
+		cursor = parsed
+	}
+
+	limit := auditLogDefaultPageSize
+	if clean := strings.TrimSpace(limitRaw); clean != "" {
+		parsed, err := strconv.Atoi(clean)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("limit must be a positive integer")
+		}
+		limit = parsed
+	}
+	if limit > auditLogMaxPageSize {
+		limit = auditLogMaxPageSize
+	}
+	return cursor, limit, nil
+}
+
+func (s *Server) handleListAuditLog(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	cursor, limit, err := parseAuditLogPage(r.URL.Query().Get("cursor"), r.URL.Query().Get("limit"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	entries, err := s.listAuditLog(r.Context(), userID, cursor, limit)
+	if err != nil {
+		writeInternalError(w, "could not load audit log")
+		return
+	}
+
+	nextCursor := ""
+	if len(entries) == limit {
+		nextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"entries":     entries,
+		"next_cursor": nextCursor,
+	})
+}
+
+func (s *Server) listAuditLog(ctx context.Context, userID string, cursor int64, limit int) ([]AuditLogEntry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, action, ip, user_agent, metadata, created_at
+		FROM audit_log
+		WHERE user_id = $1
+		  AND ($2::bigint = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, userID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]AuditLogEntry, 0, limit)
+	for rows.Next() {
+		var (
+			entry       AuditLogEntry
+			metadataRaw []byte
+		)
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.IP, &entry.UserAgent, &metadataRaw, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.Metadata = map[string]any{}
+		_ = json.Unmarshal(metadataRaw, &entry.Metadata)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
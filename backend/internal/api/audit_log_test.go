@@ -0,0 +1,24 @@
+package api
+
+import "testing"
+
+func TestParseAuditLogPage(t *testing.T) {
+	cursor, limit, err := parseAuditLogPage("", "")
+	if err != nil || cursor != 0 || limit != auditLogDefaultPageSize {
+		t.Fatalf("expected first page defaults, got cursor=%d limit=%d err=%v", cursor, limit, err)
+	}
+
+	cursor, limit, err = parseAuditLogPage("42", "500")
+	if err != nil || cursor != 42 || limit != auditLogMaxPageSize {
+		t.Fatalf("expected cursor 42 and capped limit, got cursor=%d limit=%d err=%v", cursor, limit, err)
+	}
+
+	for _, raw := range []string{"abc", "-1", "0"} {
+		if _, _, err := parseAuditLogPage(raw, ""); err == nil {
+			t.Fatalf("expected cursor %q to fail", raw)
+		}
+	}
+	if _, _, err := parseAuditLogPage("", "zero"); err == nil {
+		t.Fatalf("expected invalid limit to fail")
+	}
+}
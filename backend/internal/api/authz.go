@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"personaworlds/backend/internal/authz"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type accessResourceContextKey struct{}
+
+type shareLinkContextKey struct{}
+
+// requireAccess guards a route whose {id} is a resource of the given type:
+// the caller must be signed in and allowed to perform action on it. Handlers
+// behind it can rely on the check and read the id with accessResourceID.
+func (s *Server) requireAccess(resource authz.Resource, action authz.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := s.requireUserID(w, r)
+			if !ok {
+				return
+			}
+			resourceID, err := validateUUID(chi.URLParam(r, "id"), string(resource)+" id")
+			if err != nil {
+				writeBadRequest(w, err.Error())
+				return
+			}
+			if !s.authorize(w, r, userID, resource, action, resourceID) {
+				return
+			}
+			ctx := context.WithValue(r.Context(), accessResourceContextKey{}, resourceID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// accessResourceID returns the id requireAccess validated for the route.
+func accessResourceID(r *http.Request) string {
+	resourceID, _ := r.Context().Value(accessResourceContextKey{}).(string)
+	return resourceID
+}
+
+// requireShareAccess guards a /shared route whose {token} is a persona share
+// link: the link must be live and scoped to action. Nobody needs to be
+// signed in; the owner's own routes stay under /personas/{id}.
+func (s *Server) requireShareAccess(action authz.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			link, facts, err := s.loadShareLink(r.Context(), chi.URLParam(r, "token"))
+			if err != nil {
+				writeInternalError(w, "could not load share link")
+				return
+			}
+			if !writeAccessDecision(w, authz.Decide(authz.Subject{}, authz.Persona, action, facts)) {
+				return
+			}
+			ctx := context.WithValue(r.Context(), shareLinkContextKey{}, link)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// shareLinkFromRequest returns the link requireShareAccess resolved.
+func shareLinkFromRequest(r *http.Request) personaShareLinkAccess {
+	link, _ := r.Context().Value(shareLinkContextKey{}).(personaShareLinkAccess)
+	return link
+}
+
+// loadShareLink resolves a share link token and marks the link used. An
+// unknown, expired or revoked link is reported through Facts.Exists.
+func (s *Server) loadShareLink(ctx context.Context, rawToken string) (personaShareLinkAccess, authz.Facts, error) {
+	rawToken = strings.TrimSpace(rawToken)
+	if rawToken == "" {
+		return personaShareLinkAccess{}, authz.Facts{}, nil
+	}
+	var (
+		link   personaShareLinkAccess
+		scopes []string
+	)
+	err := s.db.QueryRow(ctx, `
+		UPDATE persona_share_links l
+		SET last_used_at = NOW()
+		FROM personas p
+		WHERE p.id = l.persona_id
+		  AND l.token_hash = $1
+		  AND l.revoked_at IS NULL
+		  AND l.expires_at > NOW()
+		RETURNING l.id::text, l.persona_id::text, p.user_id::text, l.scopes, l.expires_at
+	`, hashShareLinkToken(rawToken)).Scan(&link.LinkID, &link.PersonaID, &link.OwnerUserID, &scopes, &link.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return personaShareLinkAccess{}, authz.Facts{}, nil
+		}
+		return personaShareLinkAccess{}, authz.Facts{}, err
+	}
+	for _, scope := range scopes {
+		link.Scopes = append(link.Scopes, authz.Action(scope))
+	}
+	return link, authz.Facts{Exists: true, OwnerUserID: link.OwnerUserID, SharedScopes: link.Scopes}, nil
+}
+
+// authorize checks the policy for action on resource and writes the denial
+// when userID is not allowed.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, userID string, resource authz.Resource, action authz.Action, resourceID string) bool {
+	decision, err := s.decideAccess(r.Context(), userID, resource, action, resourceID)
+	if err != nil {
+		writeInternalError(w, "could not load "+string(resource))
+		return false
+	}
+	return writeAccessDecision(w, decision)
+}
+
+// writeAccessDecision writes the answer for a refused decision and reports
+// whether the request may go on.
+func writeAccessDecision(w http.ResponseWriter, decision authz.Decision) bool {
+	if decision.Allowed {
+		return true
+	}
+	if decision.Status == http.StatusNotFound {
+		writeNotFound(w, decision.Message)
+	} else {
+		writeForbidden(w, decision.Message)
+	}
+	return false
+}
+
+func (s *Server) decideAccess(ctx context.Context, userID string, resource authz.Resource, action authz.Action, resourceID string) (authz.Decision, error) {
+	subject := authz.Subject{UserID: userID}
+	policy, ok := authz.Lookup(resource, action)
+	if !ok {
+		return authz.Decide(subject, resource, action, authz.Facts{}), nil
+	}
+	facts, err := s.loadAccessFacts(ctx, policy, userID, resource, resourceID)
+	if err != nil {
+		return authz.Decision{}, err
+	}
+	decision := authz.Decide(subject, resource, action, facts)
+	// Admin is one more query, so it is only resolved when it can change
+	// the outcome.
+	if !decision.Allowed && facts.Exists && policy.Allows(authz.Admin) {
+		subject.Admin, err = s.userIsAdmin(ctx, userID)
+		if err != nil {
+			return authz.Decision{}, err
+		}
+		decision = authz.Decide(subject, resource, action, facts)
+	}
+	return decision, nil
+}
+
+// loadAccessFacts loads what policy looks at for one resource. A missing
+// resource is reported through Facts.Exists rather than as an error.
+func (s *Server) loadAccessFacts(ctx context.Context, policy authz.Policy, userID string, resource authz.Resource, resourceID string) (authz.Facts, error) {
+	var (
+		facts authz.Facts
+		err   error
+	)
+	switch resource {
+	case authz.Persona:
+		err = s.db.QueryRow(ctx, `
+			SELECT user_id::text
+			FROM personas
+			WHERE id = $1
+		`, resourceID).Scan(&facts.OwnerUserID)
+	case authz.Post:
+		err = s.db.QueryRow(ctx, `
+			SELECT user_id::text
+			FROM posts
+			WHERE id = $1
+		`, resourceID).Scan(&facts.OwnerUserID)
+	case authz.Battle:
+		err = s.db.QueryRow(ctx, `
+			SELECT COALESCE(user_id::text, '')
+			FROM posts
+			WHERE id = $1
+			  AND template_id IS NOT NULL
+		`, resourceID).Scan(&facts.OwnerUserID)
+	case authz.Template:
+		err = s.db.QueryRow(ctx, `
+			SELECT COALESCE(owner_user_id::text, '')
+			FROM templates
+			WHERE id = $1
+		`, resourceID).Scan(&facts.OwnerUserID)
+	case authz.Room:
+		err = s.db.QueryRow(ctx, `
+			SELECT EXISTS(
+				SELECT 1
+				FROM room_moderators m
+				WHERE m.room_id = rm.id AND m.user_id = $2
+			)
+			FROM rooms rm
+			WHERE rm.id = $1
+		`, resourceID, userID).Scan(&facts.Moderator)
+	default:
+		return authz.Facts{}, nil
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return authz.Facts{}, nil
+		}
+		return authz.Facts{}, err
+	}
+	facts.Exists = true
+
+	if resource == authz.Battle && policy.Allows(authz.Participant) && facts.OwnerUserID != userID {
+		facts.Participant, err = s.isChallengeParticipant(ctx, resourceID, userID)
+		if err != nil {
+			return authz.Facts{}, err
+		}
+	}
+	return facts, nil
+}
+
+func (s *Server) userIsAdmin(ctx context.Context, userID string) (bool, error) {
+	var email string
+	err := s.db.QueryRow(ctx, `
+		SELECT email
+		FROM users
+		WHERE id = $1
+	`, userID).Scan(&email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return isAdminEmail(s.cfg.AdminEmails, email), nil
+}
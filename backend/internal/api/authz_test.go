@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/auth"
+	"personaworlds/backend/internal/authz"
+	"personaworlds/backend/internal/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequireAccessRejectsBeforeLoading(t *testing.T) {
+	// The server has no database: every case here must be answered before
+	// the policy needs facts.
+	s := &Server{cfg: config.Config{JWTSecret: "authz-secret"}}
+	token, err := auth.CreateToken(s.cfg.JWTSecret, "7f0c2a52-4a0e-4f0b-9a55-8d2a8a6b9d11")
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(auth.Middleware(s.cfg.JWTSecret))
+		r.With(s.requireAccess(authz.Template, authz.Manage)).Get("/templates/{id}/policy", func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler must not run")
+		})
+	})
+	unauthenticated := chi.NewRouter()
+	unauthenticated.With(s.requireAccess(authz.Template, authz.Manage)).Get("/templates/{id}/policy", func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not run")
+	})
+
+	recorder := httptest.NewRecorder()
+	unauthenticated.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/templates/7f0c2a52-4a0e-4f0b-9a55-8d2a8a6b9d11/policy", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a user, got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/templates/not-a-uuid/policy", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "template id is invalid") {
+		t.Fatalf("expected 400 for an invalid id, got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestWriteAccessDecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision authz.Decision
+		allowed  bool
+		status   int
+	}{
+		{name: "allowed", decision: authz.Decision{Allowed: true}, allowed: true, status: http.StatusOK},
+		{name: "hidden", decision: authz.Decision{Status: http.StatusNotFound, Message: "persona not found"}, status: http.StatusNotFound},
+		{name: "forbidden", decision: authz.Decision{Status: http.StatusForbidden, Message: "not allowed"}, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			if got := writeAccessDecision(recorder, tt.decision); got != tt.allowed {
+				t.Fatalf("expected %v, got %v", tt.allowed, got)
+			}
+			if recorder.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, recorder.Code)
+			}
+			if !tt.allowed && !strings.Contains(recorder.Body.String(), tt.decision.Message) {
+				t.Fatalf("expected message %q, got %s", tt.decision.Message, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BattleArchive describes a battle whose turns the worker moved to cold
+// storage. LatencyWarning is always set for archived battles so clients can
+// expect a slower load than for hot battles.
+type BattleArchive struct {
+	ArchivedAt     time.Time `json:"archived_at"`
+	TurnCount      int       `json:"turn_count"`
+	RehydrateMS    int64     `json:"rehydrate_ms"`
+	LatencyWarning bool      `json:"latency_warning"`
+}
+
+type storedBattle struct {
+	Turns   []Reply
+	Archive *BattleArchive
+}
+
+// getBattleByID loads a battle's turns in order, reading them back from
+// battle_archives when the battle was archived. Turns written after archiving
+// stay in replies and are merged in, so callers never see a partial battle.
+func (s *Server) getBattleByID(ctx context.Context, battleID string) (storedBattle, error) {
+	var archive BattleArchive
+	err := s.db.QueryRow(ctx, `
+		SELECT archived_at, turn_count
+		FROM battle_archives
+		WHERE post_id = $1
+	`, battleID).Scan(&archive.ArchivedAt, &archive.TurnCount)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return storedBattle{}, err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		turns, err := s.listHotBattleTurns(ctx, battleID)
+		return storedBattle{Turns: turns}, err
+	}
+
+	startedAt := time.Now()
+	rows, err := s.db.Query(ctx, `
+		SELECT id, post_id, persona_id, persona_name, authored_by, content, metadata, created_at, updated_at
+		FROM (
+			SELECT r.id::text, r.post_id::text, COALESCE(r.persona_id::text, '') AS persona_id,
+				COALESCE(p.name, '') AS persona_name, r.authored_by::text, r.content, r.metadata, r.created_at, r.updated_at
+			FROM replies r
+			LEFT JOIN personas p ON p.id = r.persona_id
+			WHERE r.post_id = $1
+			UNION ALL
+			SELECT t.id::text, a.post_id::text, COALESCE(t.persona_id::text, ''),
+				COALESCE(p.name, t.persona_name, ''), t.authored_by, t.content, COALESCE(t.metadata, '{}'::jsonb), t.created_at, t.updated_at
+			FROM battle_archives a
+			CROSS JOIN LATERAL jsonb_to_recordset(a.turns) AS t(
+				id UUID,
+				persona_id UUID,
+				persona_name TEXT,
+				authored_by TEXT,
+				content TEXT,
+				metadata JSONB,
+				created_at TIMESTAMPTZ,
+				updated_at TIMESTAMPTZ
+			)
+			LEFT JOIN personas p ON p.id = t.persona_id
+			WHERE a.post_id = $1
+		) turns
+		ORDER BY created_at ASC, id ASC
+	`, battleID)
+	if err != nil {
+		return storedBattle{}, err
+	}
+	turns, err := scanBattleTurns(rows)
+	if err != nil {
+		return storedBattle{}, err
+	}
+
+	archive.RehydrateMS = time.Since(startedAt).Milliseconds()
+	archive.LatencyWarning = true
+	s.logger.Info("battle_rehydrated", observability.Fields{
+		"post_id":      battleID,
+		"turn_count":   len(turns),
+		"rehydrate_ms": archive.RehydrateMS,
+	})
+	return storedBattle{Turns: turns, Archive: &archive}, nil
+}
+
+func (s *Server) listHotBattleTurns(ctx context.Context, battleID string) ([]Reply, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT r.id::text, r.post_id::text, COALESCE(r.persona_id::text, ''), COALESCE(p.name, ''), r.authored_by::text, r.content, r.metadata, r.created_at, r.updated_at
+		FROM replies r
+		LEFT JOIN personas p ON p.id = r.persona_id
+		WHERE r.post_id = $1
+		ORDER BY r.created_at ASC
+	`, battleID)
+	if err != nil {
+		return nil, err
+	}
+	return scanBattleTurns(rows)
+}
+
+func scanBattleTurns(rows pgx.Rows) ([]Reply, error) {
+	defer rows.Close()
+
+	turns := make([]Reply, 0)
+	for rows.Next() {
+		var (
+			reply       Reply
+			metadataRaw []byte
+		)
+		if err := rows.Scan(&reply.ID, &reply.PostID, &reply.PersonaID, &reply.Persona, &reply.AuthoredBy, &reply.Content, &metadataRaw, &reply.CreatedAt, &reply.UpdatedAt); err != nil {
+			return nil, err
+		}
+		reply.Language, reply.Bilingual = parseTurnMetadata(metadataRaw)
+		reply.timing, reply.hasTiming = common.ParseTurnTiming(metadataRaw)
+		reply.usage, reply.hasUsage = common.ParseTurnUsage(metadataRaw)
+		turns = append(turns, reply)
+	}
+	return turns, rows.Err()
+}
+
+// parseTurnMetadata reads the language a turn was written in and, for
+// bilingual battles, its renderings keyed by language. Unreadable metadata
+// reads as a plain turn.
+func parseTurnMetadata(raw []byte) (string, map[string]string) {
+	var metadata struct {
+		Language  string            `json:"language"`
+		Bilingual map[string]string `json:"bilingual"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &metadata) != nil {
+		return "", nil
+	}
+	if len(metadata.Bilingual) == 0 {
+		metadata.Bilingual = nil
+	}
+	return metadata.Language, metadata.Bilingual
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestArchivedBattleRehydratesIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{})
+
+	var postID string
+	err := fixture.pool.QueryRow(fixture.ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, created_at)
+		VALUES ($1, $2, $3, 'AI_DRAFT_APPROVED', 'PUBLISHED', $4, NOW() - INTERVAL '8 months', NOW() - INTERVAL '8 months')
+		RETURNING id::text
+	`, fixture.roomID, fixture.personaID, fixture.userID, "Archive topic: are long threads worth keeping hot?").Scan(&postID)
+	if err != nil {
+		t.Fatalf("insert post failed: %v", err)
+	}
+
+	if _, err := fixture.pool.Exec(fixture.ctx, `
+		INSERT INTO replies(post_id, persona_id, user_id, authored_by, content, created_at, updated_at)
+		VALUES
+			($1, $2, $3, 'AI', 'Cold turns are rarely read.', NOW() - INTERVAL '8 months', NOW() - INTERVAL '8 months'),
+			($1, NULL, $3, 'HUMAN', 'But they must still load.', NOW() - INTERVAL '8 months' + INTERVAL '1 minute', NOW() - INTERVAL '8 months' + INTERVAL '1 minute')
+	`, postID, fixture.personaID, fixture.userID); err != nil {
+		t.Fatalf("insert replies failed: %v", err)
+	}
+
+	// Archive the way the worker does: one JSONB document, then drop the rows.
+	if _, err := fixture.pool.Exec(fixture.ctx, `
+		INSERT INTO battle_archives(post_id, turn_count, last_turn_at, turns)
+		SELECT r.post_id, COUNT(*)::int, MAX(r.created_at),
+			jsonb_agg(jsonb_build_object(
+				'id', r.id, 'persona_id', r.persona_id, 'persona_name', pr.name, 'user_id', r.user_id,
+				'authored_by', r.authored_by, 'content', r.content, 'prompt_version', r.prompt_version,
+				'created_at', r.created_at, 'updated_at', r.updated_at
+			) ORDER BY r.created_at ASC)
+		FROM replies r
+		LEFT JOIN personas pr ON pr.id = r.persona_id
+		WHERE r.post_id = $1
+		GROUP BY r.post_id
+	`, postID); err != nil {
+		t.Fatalf("archive battle failed: %v", err)
+	}
+	if _, err := fixture.pool.Exec(fixture.ctx, `DELETE FROM replies WHERE post_id = $1`, postID); err != nil {
+		t.Fatalf("delete replies failed: %v", err)
+	}
+
+	recorder := doJSONRequest(fixture.server, http.MethodGet, "/posts/"+postID+"/thread", fixture.token, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected thread 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var thread struct {
+		Replies []Reply        `json:"replies"`
+		Archive *BattleArchive `json:"archive"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &thread); err != nil {
+		t.Fatalf("decode thread: %v", err)
+	}
+	if len(thread.Replies) != 2 {
+		t.Fatalf("expected 2 rehydrated turns, got %+v", thread.Replies)
+	}
+	if thread.Replies[0].Content != "Cold turns are rarely read." || thread.Replies[1].AuthoredBy != "HUMAN" {
+		t.Fatalf("unexpected turn order %+v", thread.Replies)
+	}
+	if thread.Replies[0].PersonaID != fixture.personaID || thread.Replies[0].Persona == "" {
+		t.Fatalf("expected persona on archived turn, got %+v", thread.Replies[0])
+	}
+	if thread.Archive == nil || !thread.Archive.LatencyWarning || thread.Archive.TurnCount != 2 {
+		t.Fatalf("expected archive latency warning, got %+v", thread.Archive)
+	}
+
+	card := doJSONRequest(fixture.server, http.MethodGet, "/b/"+postID+"/turns/2/card.png", "", "")
+	if card.Code != http.StatusOK {
+		t.Fatalf("expected archived turn card 200, got %d", card.Code)
+	}
+
+	generate := doJSONRequest(fixture.server, http.MethodPost, "/posts/"+postID+"/generate-replies", fixture.token, `{}`)
+	if generate.Code != http.StatusConflict {
+		t.Fatalf("expected generate-replies on archived battle to conflict, got %d: %s", generate.Code, generate.Body.String())
+	}
+}
@@ -7,20 +7,19 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	imagedraw "image/draw"
 	"image/png"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"personaworlds/backend/internal/cards"
 	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/safety"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
-	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
-	"golang.org/x/image/math/fixed"
 )
 
 const (
@@ -36,8 +35,12 @@ type battleCardData struct {
 	ConPersona string
 	Verdict    string
 	Takeaways  []string
-	URL        string
-	UpdatedAt  time.Time
+	// TakeawayTurns holds, per takeaway, the 1-based turns it was drawn
+	// from. Takeaways from the opening post or a fallback cite none.
+	TakeawayTurns [][]int
+	Language      common.BattleLanguage
+	URL           string
+	UpdatedAt     time.Time
 }
 
 type battleCardReply struct {
@@ -94,12 +97,51 @@ func (c *battleCardCache) set(key string, value []byte) {
 	}
 }
 
+// invalidate drops every cached card of the given battles, including their
+// variants and turn cards.
+func (c *battleCardCache) invalidate(battleIDs ...string) {
+	if len(battleIDs) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.order[:0]
+	for _, key := range c.order {
+		if battleCardCacheKeyMatches(key, battleIDs) {
+			delete(c.items, key)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	c.order = kept
+}
+
+// Card keys start with the battle id, followed by "|" or "-t" for turn cards.
+func battleCardCacheKeyMatches(key string, battleIDs []string) bool {
+	for _, battleID := range battleIDs {
+		if rest, ok := strings.CutPrefix(key, battleID); ok && (strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, "-t")) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) handleGetBattleCardImage(w http.ResponseWriter, r *http.Request) {
 	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
 	if err != nil {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	variant, err := resolveBattleCardVariant(battleID, r.URL.Query().Get("variant"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
 
 	card, err := s.loadBattleCardData(r.Context(), battleID)
 	if err != nil {
@@ -111,13 +153,13 @@ func (s *Server) handleGetBattleCardImage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	cacheKey := fmt.Sprintf("%s|%d", card.BattleID, card.UpdatedAt.UTC().UnixNano())
+	cacheKey := fmt.Sprintf("%s|%s|%d", card.BattleID, variant, card.UpdatedAt.UTC().UnixNano())
 	if cached, ok := s.battleCardCache.get(cacheKey); ok {
 		writeBattleCardPNG(w, cached, cacheKey)
 		return
 	}
 
-	imageBytes, err := renderBattleCardPNG(card)
+	imageBytes, err := renderBattleCardPNG(card, variant)
 	if err != nil {
 		writeInternalError(w, "could not render battle card")
 		return
@@ -148,7 +190,9 @@ func (s *Server) loadBattleCardData(ctx context.Context, battleID string) (battl
 			COALESCE(rm.name, ''),
 			p.content,
 			p.updated_at,
-			COALESCE(pr.name, '')
+			COALESCE(pr.name, ''),
+			p.battle_language,
+			COALESCE(p.battle_language_code, '')
 		FROM posts p
 		JOIN rooms rm ON rm.id = p.room_id
 		LEFT JOIN personas pr ON pr.id = p.persona_id
@@ -160,6 +204,8 @@ func (s *Server) loadBattleCardData(ctx context.Context, battleID string) (battl
 		&postContent,
 		&data.UpdatedAt,
 		&postPersonaName,
+		&data.Language.Mode,
+		&data.Language.Language,
 	)
 	if err != nil {
 		return battleCardData{}, err
@@ -177,38 +223,29 @@ func (s *Server) loadBattleCardData(ctx context.Context, battleID string) (battl
 
 	data.Topic = buildBattleCardTopic(postContent, data.RoomName)
 	data.ProPersona, data.ConPersona = resolveBattleCardPersonaSides(postPersonaName, replies)
-	data.Verdict = buildBattleCardVerdict(replies)
-	data.Takeaways = buildBattleCardTakeaways(postContent, replies)
+	data.Verdict = buildBattleCardVerdict(replies, data.Language)
+	data.Takeaways, data.TakeawayTurns = buildBattleCardTakeaways(postContent, replies)
 	data.URL = fmt.Sprintf("%s/b/%s", strings.TrimRight(s.cfg.FrontendOrigin, "/"), data.BattleID)
 	return data, nil
 }
 
 func (s *Server) listBattleCardReplies(ctx context.Context, battleID string) ([]battleCardReply, error) {
-	rows, err := s.db.Query(ctx, `
-		SELECT
-			COALESCE(p.name, ''),
-			r.content,
-			r.updated_at
-		FROM replies r
-		LEFT JOIN personas p ON p.id = r.persona_id
-		WHERE r.post_id = $1
-		ORDER BY r.created_at ASC
-	`, battleID)
+	battle, err := s.getBattleByID(ctx, battleID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	replies := make([]battleCardReply, 0, 6)
-	for rows.Next() {
-		var reply battleCardReply
-		if err := rows.Scan(&reply.PersonaName, &reply.Content, &reply.UpdatedAt); err != nil {
-			return nil, err
+	replies := make([]battleCardReply, 0, len(battle.Turns))
+	for _, turn := range battle.Turns {
+		name := turn.Persona
+		if turn.AuthoredBy == "HUMAN" && turn.PersonaID == "" {
+			name = common.InteractiveHumanName
 		}
-		replies = append(replies, reply)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		replies = append(replies, battleCardReply{
+			PersonaName: name,
+			Content:     turn.Content,
+			UpdatedAt:   turn.UpdatedAt,
+		})
 	}
 	return replies, nil
 }
@@ -259,39 +296,74 @@ func resolveBattleCardPersonaSides(postPersonaName string, replies []battleCardR
 	return pro, con
 }
 
-func buildBattleCardVerdict(replies []battleCardReply) string {
+// buildBattleCardVerdict picks the verdict line in the battle's language. A
+// bilingual battle gets both lines, English first.
+func buildBattleCardVerdict(replies []battleCardReply, language common.BattleLanguage) string {
+	index := 2
 	switch {
 	case len(replies) >= 2:
-		return "Verdict: The strongest outcome is to run a small test, measure results, and iterate fast."
+		index = 0
 	case len(replies) == 1:
-		return "Verdict: The counterpoint matters, but practical experimentation still wins."
-	default:
-		return "Verdict: Start lean, validate quickly, and scale only what proves useful."
+		index = 1
+	}
+
+	switch language.Mode {
+	case common.BattleLanguageBilingual:
+		return battleCardVerdicts[safety.LanguageEN][index] + " / " + battleCardVerdicts[safety.LanguageTR][index]
+	case common.BattleLanguageA, common.BattleLanguageB:
+		if verdicts, ok := battleCardVerdicts[language.Language]; ok {
+			return verdicts[index]
+		}
 	}
+	return battleCardVerdicts[safety.LanguageEN][index]
 }
 
-func buildBattleCardTakeaways(postContent string, replies []battleCardReply) []string {
+var battleCardVerdicts = map[string][3]string{
+	safety.LanguageEN: {
+		"Verdict: The strongest outcome is to run a small test, measure results, and iterate fast.",
+		"Verdict: The counterpoint matters, but practical experimentation still wins.",
+		"Verdict: Start lean, validate quickly, and scale only what proves useful.",
+	},
+	safety.LanguageTR: {
+		"Karar: En güçlü sonuç küçük bir test yapmak, sonuçları ölçmek ve hızla yinelemek.",
+		"Karar: Karşı görüş önemli, ama pratik deneme yine de kazanıyor.",
+		"Karar: Sade başla, hızlı doğrula ve yalnızca işe yarayanı büyüt.",
+	},
+}
+
+// buildBattleCardTakeaways returns three takeaways and, for each, the turns
+// it cites. A turn repeating an earlier takeaway is cited by that takeaway.
+func buildBattleCardTakeaways(postContent string, replies []battleCardReply) ([]string, [][]int) {
 	takeaways := make([]string, 0, 3)
-	seen := map[string]struct{}{}
-	add := func(value string) {
+	turns := make([][]int, 0, 3)
+	seen := map[string]int{}
+	add := func(value string, turn int) {
 		clean := common.TruncateRunes(extractCardSentence(value, 90), 90)
 		if clean == "" {
 			return
 		}
 		key := strings.ToLower(clean)
-		if _, exists := seen[key]; exists {
+		if idx, exists := seen[key]; exists {
+			if turn > 0 {
+				turns[idx] = append(turns[idx], turn)
+			}
 			return
 		}
-		seen[key] = struct{}{}
+		if len(takeaways) == 3 {
+			return
+		}
+		seen[key] = len(takeaways)
 		takeaways = append(takeaways, clean)
+		cited := []int{}
+		if turn > 0 {
+			cited = append(cited, turn)
+		}
+		turns = append(turns, cited)
 	}
 
-	add(postContent)
-	for _, reply := range replies {
-		add(reply.Content)
-		if len(takeaways) == 3 {
-			break
-		}
+	add(postContent, 0)
+	for idx, reply := range replies {
+		add(reply.Content, idx+1)
 	}
 
 	fallbacks := []string{
@@ -300,20 +372,18 @@ func buildBattleCardTakeaways(postContent string, replies []battleCardReply) []s
 		"Share the result publicly and refine the next iteration.",
 	}
 	for _, fallback := range fallbacks {
-		if len(takeaways) == 3 {
-			break
-		}
-		add(fallback)
+		add(fallback, 0)
 	}
 
 	for len(takeaways) < 3 {
 		takeaways = append(takeaways, fallbacks[len(takeaways)%len(fallbacks)])
+		turns = append(turns, []int{})
 	}
-	return takeaways[:3]
+	return takeaways, turns
 }
 
 func extractCardSentence(value string, maxRunes int) string {
-	clean := normalizeCardText(value)
+	clean := cards.NormalizeText(value)
 	if clean == "" {
 		return ""
 	}
@@ -330,17 +400,24 @@ func extractCardSentence(value string, maxRunes int) string {
 	return common.TruncateRunes(sentence, maxRunes)
 }
 
-func normalizeCardText(value string) string {
-	value = strings.TrimSpace(value)
-	if value == "" {
-		return ""
+// renderBattleCardPNG draws data with the variant's layout, falling back to
+// the classic one.
+func renderBattleCardPNG(data battleCardData, variant string) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, battleCardWidth, battleCardHeight))
+	layout, ok := battleCardLayouts[variant]
+	if !ok {
+		layout = drawBattleCardClassic
 	}
-	return strings.Join(strings.Fields(value), " ")
-}
+	layout(canvas, data)
 
-func renderBattleCardPNG(data battleCardData) ([]byte, error) {
-	canvas := image.NewRGBA(image.Rect(0, 0, battleCardWidth, battleCardHeight))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+func drawBattleCardClassic(canvas *image.RGBA, data battleCardData) {
 	background := color.RGBA{R: 15, G: 23, B: 42, A: 255}
 	panel := color.RGBA{R: 248, G: 250, B: 252, A: 255}
 	panelMuted := color.RGBA{R: 241, G: 245, B: 249, A: 255}
@@ -350,27 +427,27 @@ func renderBattleCardPNG(data battleCardData) ([]byte, error) {
 	accentSoft := color.RGBA{R: 219, G: 234, B: 254, A: 255}
 	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
 
-	fillRect(canvas, canvas.Bounds(), background)
+	cards.FillRect(canvas, canvas.Bounds(), background)
 
 	titleRect := image.Rect(24, 24, battleCardWidth-24, 150)
 	leftRect := image.Rect(24, 166, 644, 440)
 	rightRect := image.Rect(660, 166, battleCardWidth-24, 440)
 	footerRect := image.Rect(24, 456, battleCardWidth-24, battleCardHeight-24)
 
-	fillRect(canvas, titleRect, panel)
-	fillRect(canvas, leftRect, panel)
-	fillRect(canvas, rightRect, panelMuted)
-	fillRect(canvas, footerRect, accentSoft)
-	strokeRect(canvas, titleRect, accent)
-	strokeRect(canvas, leftRect, color.RGBA{R: 148, G: 163, B: 184, A: 255})
-	strokeRect(canvas, rightRect, color.RGBA{R: 148, G: 163, B: 184, A: 255})
-	strokeRect(canvas, footerRect, accent)
+	cards.FillRect(canvas, titleRect, panel)
+	cards.FillRect(canvas, leftRect, panel)
+	cards.FillRect(canvas, rightRect, panelMuted)
+	cards.FillRect(canvas, footerRect, accentSoft)
+	cards.StrokeRect(canvas, titleRect, accent)
+	cards.StrokeRect(canvas, leftRect, color.RGBA{R: 148, G: 163, B: 184, A: 255})
+	cards.StrokeRect(canvas, rightRect, color.RGBA{R: 148, G: 163, B: 184, A: 255})
+	cards.StrokeRect(canvas, footerRect, accent)
 
 	face := basicfont.Face7x13
 	lineHeight := 18
 
-	drawLabel(canvas, face, 40, 46, "BATTLE CARD", accent)
-	drawWrappedText(
+	cards.DrawLabel(canvas, face, 40, 46, "BATTLE CARD", accent)
+	cards.DrawWrappedText(
 		canvas,
 		face,
 		40,
@@ -382,18 +459,18 @@ func renderBattleCardPNG(data battleCardData) ([]byte, error) {
 		ink,
 	)
 
-	drawLabel(canvas, face, 40, 188, "PRO / CON", accent)
+	cards.DrawLabel(canvas, face, 40, 188, "PRO / CON", accent)
 	personaLine := fmt.Sprintf("Pro: %s    Con: %s", data.ProPersona, data.ConPersona)
-	drawWrappedText(canvas, face, 40, 214, leftRect.Dx()-32, lineHeight, 2, personaLine, ink)
+	cards.DrawWrappedText(canvas, face, 40, 214, leftRect.Dx()-32, lineHeight, 2, personaLine, ink)
 
-	drawLabel(canvas, face, 40, 254, "VERDICT", accent)
-	drawWrappedText(canvas, face, 40, 280, leftRect.Dx()-32, lineHeight, 5, data.Verdict, ink)
+	cards.DrawLabel(canvas, face, 40, 254, "VERDICT", accent)
+	cards.DrawWrappedText(canvas, face, 40, 280, leftRect.Dx()-32, lineHeight, 5, data.Verdict, ink)
 
-	drawLabel(canvas, face, 676, 188, "TOP TAKEAWAYS", accent)
+	cards.DrawLabel(canvas, face, 676, 188, "TOP TAKEAWAYS", accent)
 	takeawayY := 214
 	for idx, takeaway := range data.Takeaways {
 		line := fmt.Sprintf("%d. %s", idx+1, takeaway)
-		takeawayY = drawWrappedText(
+		takeawayY = cards.DrawWrappedText(
 			canvas,
 			face,
 			676,
@@ -406,155 +483,9 @@ func renderBattleCardPNG(data battleCardData) ([]byte, error) {
 		) + 4
 	}
 
-	drawLabel(canvas, face, 40, 478, "SHARE LINK", accent)
+	cards.DrawLabel(canvas, face, 40, 478, "SHARE LINK", accent)
 	linkDisplay := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(data.URL), "https://"), "http://")
-	drawWrappedText(canvas, face, 40, 504, footerRect.Dx()-32, lineHeight, 2, linkDisplay, inkMuted)
-	drawLabel(canvas, face, battleCardWidth-210, 478, "OPEN BATTLE", accent)
-	drawWrappedText(canvas, face, battleCardWidth-210, 504, 170, lineHeight, 1, data.BattleID, white)
-
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, canvas); err != nil {
-		return nil, err
-	}
-	return buf.Bytes(), nil
-}
-
-func fillRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
-	imagedraw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, imagedraw.Src)
-}
-
-func strokeRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
-	top := image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1)
-	bottom := image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y)
-	left := image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y)
-	right := image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y)
-	fillRect(img, top, c)
-	fillRect(img, bottom, c)
-	fillRect(img, left, c)
-	fillRect(img, right, c)
-}
-
-func drawLabel(img *image.RGBA, face font.Face, x, y int, label string, c color.Color) {
-	drawText(img, face, x, y, strings.ToUpper(strings.TrimSpace(label)), c)
-}
-
-func drawText(img *image.RGBA, face font.Face, x, y int, text string, c color.Color) {
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return
-	}
-	drawer := font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(c),
-		Face: face,
-		Dot:  fixed.P(x, y),
-	}
-	drawer.DrawString(text)
-}
-
-func drawWrappedText(
-	img *image.RGBA,
-	face font.Face,
-	x int,
-	y int,
-	maxWidth int,
-	lineHeight int,
-	maxLines int,
-	value string,
-	c color.Color,
-) int {
-	if lineHeight <= 0 {
-		lineHeight = 16
-	}
-	if maxLines <= 0 {
-		maxLines = 1
-	}
-
-	lines := wrapCardText(value, face, maxWidth)
-	if len(lines) > maxLines {
-		lines = lines[:maxLines]
-		last := strings.TrimSpace(lines[len(lines)-1])
-		if !strings.HasSuffix(last, "...") {
-			last = common.TruncateRunes(last, 60) + "..."
-		}
-		lines[len(lines)-1] = last
-	}
-
-	currentY := y
-	for _, line := range lines {
-		drawText(img, face, x, currentY, line, c)
-		currentY += lineHeight
-	}
-	return currentY
-}
-
-func wrapCardText(value string, face font.Face, maxWidth int) []string {
-	clean := normalizeCardText(value)
-	if clean == "" {
-		return []string{""}
-	}
-	if maxWidth <= 8 {
-		return []string{clean}
-	}
-
-	words := strings.Fields(clean)
-	if len(words) == 0 {
-		return []string{""}
-	}
-
-	lines := make([]string, 0, 4)
-	current := words[0]
-	for _, word := range words[1:] {
-		candidate := current + " " + word
-		if measureCardTextWidth(face, candidate) <= maxWidth {
-			current = candidate
-			continue
-		}
-		lines = append(lines, current)
-		current = word
-
-		if measureCardTextWidth(face, current) > maxWidth {
-			parts := splitCardLongWord(current, face, maxWidth)
-			lines = append(lines, parts[:len(parts)-1]...)
-			current = parts[len(parts)-1]
-		}
-	}
-	if current != "" {
-		lines = append(lines, current)
-	}
-
-	return lines
-}
-
-func splitCardLongWord(word string, face font.Face, maxWidth int) []string {
-	chars := []rune(strings.TrimSpace(word))
-	if len(chars) == 0 {
-		return []string{""}
-	}
-
-	parts := make([]string, 0, 2)
-	start := 0
-	for start < len(chars) {
-		end := start + 1
-		for end <= len(chars) {
-			chunk := string(chars[start:end])
-			if measureCardTextWidth(face, chunk) > maxWidth {
-				break
-			}
-			end++
-		}
-
-		if end == start+1 {
-			end = start + 1
-		} else {
-			end--
-		}
-		parts = append(parts, string(chars[start:end]))
-		start = end
-	}
-	return parts
-}
-
-func measureCardTextWidth(face font.Face, value string) int {
-	return font.MeasureString(face, value).Ceil()
+	cards.DrawWrappedText(canvas, face, 40, 504, footerRect.Dx()-32, lineHeight, 2, linkDisplay, inkMuted)
+	cards.DrawLabel(canvas, face, battleCardWidth-210, 478, "OPEN BATTLE", accent)
+	cards.DrawWrappedText(canvas, face, battleCardWidth-210, 504, 170, lineHeight, 1, data.BattleID, white)
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -68,3 +69,67 @@ func TestBattleCardImageEndpointIntegration(t *testing.T) {
 		t.Fatalf("second request expected non-empty card body")
 	}
 }
+
+func TestBattleTurnCardAndMetaIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{})
+
+	var postID string
+	err := fixture.pool.QueryRow(fixture.ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at)
+		VALUES ($1, $2, $3, 'AI_DRAFT_APPROVED', 'PUBLISHED', $4, NOW())
+		RETURNING id::text
+	`, fixture.roomID, fixture.personaID, fixture.userID, "Turn card topic: is remote work better for juniors?").Scan(&postID)
+	if err != nil {
+		t.Fatalf("insert post failed: %v", err)
+	}
+
+	for _, content := range []string{
+		"Juniors learn fastest by overhearing real decisions.",
+		"Structured remote mentoring beats accidental office learning.",
+	} {
+		if _, err := fixture.pool.Exec(fixture.ctx, `
+			INSERT INTO replies(post_id, persona_id, user_id, authored_by, content)
+			VALUES ($1, $2, $3, 'AI', $4)
+		`, postID, fixture.personaID, fixture.userID, content); err != nil {
+			t.Fatalf("insert reply failed: %v", err)
+		}
+	}
+
+	card := doJSONRequest(fixture.server, http.MethodGet, fmt.Sprintf("/b/%s/turns/2/card.png", postID), "", "")
+	if card.Code != http.StatusOK {
+		t.Fatalf("expected turn card 200, got %d, body: %s", card.Code, card.Body.String())
+	}
+	if !strings.HasPrefix(card.Header().Get("Content-Type"), "image/png") {
+		t.Fatalf("expected png content type, got %q", card.Header().Get("Content-Type"))
+	}
+
+	missing := doJSONRequest(fixture.server, http.MethodGet, fmt.Sprintf("/b/%s/turns/3/card.png", postID), "", "")
+	if missing.Code != http.StatusNotFound {
+		t.Fatalf("expected out-of-range turn 404, got %d", missing.Code)
+	}
+	invalid := doJSONRequest(fixture.server, http.MethodGet, fmt.Sprintf("/b/%s/turns/0/card.png", postID), "", "")
+	if invalid.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid turn 400, got %d", invalid.Code)
+	}
+
+	meta := doJSONRequest(fixture.server, http.MethodGet, fmt.Sprintf("/b/%s/meta?t=2", postID), "", "")
+	if meta.Code != http.StatusOK {
+		t.Fatalf("expected meta 200, got %d, body: %s", meta.Code, meta.Body.String())
+	}
+	var payload PublicBattleMetaDTO
+	if err := json.Unmarshal(meta.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode meta failed: %v", err)
+	}
+	if payload.Turn == nil || payload.Turn.Index != 2 || payload.Turn.Total != 2 {
+		t.Fatalf("expected turn 2 of 2 in meta, got %+v", payload.Turn)
+	}
+	if !strings.Contains(payload.Turn.Quote, "Structured remote mentoring") {
+		t.Fatalf("unexpected turn quote %q", payload.Turn.Quote)
+	}
+	if !strings.HasSuffix(payload.ShareURL, "?t=2") {
+		t.Fatalf("expected share url with turn query, got %s", payload.ShareURL)
+	}
+	if payload.CardURL != fmt.Sprintf("/b/%s/turns/2/card.png", postID) {
+		t.Fatalf("unexpected turn card url %s", payload.CardURL)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildBattleCardTakeawaysCitesTurns(t *testing.T) {
+	replies := []battleCardReply{
+		{PersonaName: "Ada", Content: "Ship a pilot in one city first."},
+		{PersonaName: "Grace", Content: "Pilots hide the real costs."},
+		{PersonaName: "Ada", Content: "Ship a pilot in one city first."},
+		{PersonaName: "Grace", Content: "Measure costs weekly."},
+	}
+
+	takeaways, turns := buildBattleCardTakeaways("Should cities adopt four-day weeks?", replies)
+	wantTakeaways := []string{
+		"Should cities adopt four-day weeks?",
+		"Ship a pilot in one city first.",
+		"Pilots hide the real costs.",
+	}
+	if !reflect.DeepEqual(takeaways, wantTakeaways) {
+		t.Fatalf("unexpected takeaways %q", takeaways)
+	}
+	wantTurns := [][]int{{}, {1, 3}, {2}}
+	if !reflect.DeepEqual(turns, wantTurns) {
+		t.Fatalf("expected turns %v, got %v", wantTurns, turns)
+	}
+}
+
+func TestBuildBattleCardTakeawaysFallbacksCiteNothing(t *testing.T) {
+	takeaways, turns := buildBattleCardTakeaways("", []battleCardReply{{PersonaName: "Ada", Content: "Start small."}})
+	if len(takeaways) != 3 || len(turns) != 3 {
+		t.Fatalf("expected three takeaways with citations, got %q %v", takeaways, turns)
+	}
+	if !reflect.DeepEqual(turns, [][]int{{1}, {}, {}}) {
+		t.Fatalf("expected only the reply takeaway to cite a turn, got %v", turns)
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"net/url"
+	"strings"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/flags"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	battleCardVariantClassic   = "classic"
+	battleCardVariantSpotlight = "spotlight"
+	battleCardVariantMinimal   = "minimal"
+
+	// battleCardVariantQueryName and battleCardSignatureQueryName carry the
+	// card a visitor clicked through from on /b/:id links.
+	battleCardVariantQueryName   = "cv"
+	battleCardSignatureQueryName = "cs"
+)
+
+var errUnknownBattleCardVariant = errors.New("unknown card variant")
+
+// battleCardVariants lists the layouts under test. Assignment hashes into
+// this slice, so appending a variant reshuffles existing battles.
+var battleCardVariants = []string{
+	battleCardVariantClassic,
+	battleCardVariantSpotlight,
+	battleCardVariantMinimal,
+}
+
+var battleCardLayouts = map[string]func(*image.RGBA, battleCardData){
+	battleCardVariantClassic:   drawBattleCardClassic,
+	battleCardVariantSpotlight: drawBattleCardSpotlight,
+	battleCardVariantMinimal:   drawBattleCardMinimal,
+}
+
+// assignBattleCardVariant picks a battle's layout deterministically so every
+// share of the same battle shows the same card.
+func assignBattleCardVariant(battleID string) string {
+	return battleCardVariants[flags.Bucket("battle_card_layout", battleID)%len(battleCardVariants)]
+}
+
+// resolveBattleCardVariant returns the override when one is given and the
+// battle's assigned variant otherwise.
+func resolveBattleCardVariant(battleID, override string) (string, error) {
+	override = strings.ToLower(strings.TrimSpace(override))
+	if override == "" {
+		return assignBattleCardVariant(battleID), nil
+	}
+	if _, ok := battleCardLayouts[override]; !ok {
+		return "", errUnknownBattleCardVariant
+	}
+	return override, nil
+}
+
+func signBattleCardClick(secret, battleID, variant string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("battle_card\x00"))
+	mac.Write([]byte(battleID + ":" + variant))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// verifyBattleCardClick reports the variant a click-through came from, or ""
+// when the link was not signed for this battle.
+func verifyBattleCardClick(secret, battleID, variant, signature string) string {
+	variant = strings.ToLower(strings.TrimSpace(variant))
+	if _, ok := battleCardLayouts[variant]; !ok {
+		return ""
+	}
+	expected := signBattleCardClick(secret, battleID, variant)
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature))) {
+		return ""
+	}
+	return variant
+}
+
+// battleCardClickQuery is the query string that attributes a visit to the
+// card variant it was shared with.
+func battleCardClickQuery(secret, battleID, variant string) string {
+	values := url.Values{}
+	values.Set(battleCardVariantQueryName, variant)
+	values.Set(battleCardSignatureQueryName, signBattleCardClick(secret, battleID, variant))
+	return values.Encode()
+}
+
+func (s *Server) battleCardShareURL(battleID, variant string) string {
+	return fmt.Sprintf("%s/b/%s?%s", strings.TrimRight(s.cfg.FrontendOrigin, "/"), battleID, battleCardClickQuery(s.cfg.JWTSecret, battleID, variant))
+}
+
+func battleCardImagePath(battleID, variant string) string {
+	return fmt.Sprintf("/b/%s/card.png?variant=%s", battleID, variant)
+}
+
+// drawBattleCardSpotlight gives the topic most of the card and sets the two
+// sides against each other underneath.
+func drawBattleCardSpotlight(canvas *image.RGBA, data battleCardData) {
+	background := color.RGBA{R: 30, G: 27, B: 75, A: 255}
+	panel := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	ink := color.RGBA{R: 30, G: 27, B: 75, A: 255}
+	inkMuted := color.RGBA{R: 100, G: 116, B: 139, A: 255}
+	pro := color.RGBA{R: 16, G: 185, B: 129, A: 255}
+	con := color.RGBA{R: 244, G: 63, B: 94, A: 255}
+	highlight := color.RGBA{R: 250, G: 204, B: 21, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	cards.FillRect(canvas, canvas.Bounds(), background)
+
+	topicRect := image.Rect(24, 24, battleCardWidth-24, 236)
+	proRect := image.Rect(24, 252, battleCardWidth/2-8, 372)
+	conRect := image.Rect(battleCardWidth/2+8, 252, battleCardWidth-24, 372)
+	verdictRect := image.Rect(24, 388, battleCardWidth-24, 470)
+
+	cards.FillRect(canvas, topicRect, panel)
+	cards.FillRect(canvas, proRect, pro)
+	cards.FillRect(canvas, conRect, con)
+	cards.FillRect(canvas, verdictRect, panel)
+	cards.StrokeRect(canvas, topicRect, highlight)
+	cards.StrokeRect(canvas, verdictRect, highlight)
+
+	face := basicfont.Face7x13
+	lineHeight := 22
+
+	cards.DrawLabel(canvas, face, 44, 52, "WHO WINS THIS ONE?", inkMuted)
+	cards.DrawWrappedText(canvas, face, 44, 86, topicRect.Dx()-40, lineHeight, 6, data.Topic, ink)
+
+	cards.DrawLabel(canvas, face, 44, 280, "PRO", white)
+	cards.DrawWrappedText(canvas, face, 44, 310, proRect.Dx()-40, lineHeight, 2, data.ProPersona, white)
+	cards.DrawLabel(canvas, face, conRect.Min.X+20, 280, "CON", white)
+	cards.DrawWrappedText(canvas, face, conRect.Min.X+20, 310, conRect.Dx()-40, lineHeight, 2, data.ConPersona, white)
+	cards.DrawText(canvas, face, battleCardWidth/2-8, 318, "VS", highlight)
+
+	cards.DrawLabel(canvas, face, 44, 412, "VERDICT", inkMuted)
+	cards.DrawWrappedText(canvas, face, 44, 436, verdictRect.Dx()-40, 18, 2, data.Verdict, ink)
+
+	linkDisplay := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(data.URL), "https://"), "http://")
+	cards.DrawLabel(canvas, face, 44, 500, "TAP TO READ THE BATTLE", highlight)
+	cards.DrawWrappedText(canvas, face, 44, 522, battleCardWidth-88, 18, 1, linkDisplay, white)
+}
+
+// drawBattleCardMinimal keeps only the topic, verdict and link on a light
+// background.
+func drawBattleCardMinimal(canvas *image.RGBA, data battleCardData) {
+	background := color.RGBA{R: 250, G: 250, B: 249, A: 255}
+	ink := color.RGBA{R: 28, G: 25, B: 23, A: 255}
+	inkMuted := color.RGBA{R: 120, G: 113, B: 108, A: 255}
+	rule := color.RGBA{R: 214, G: 211, B: 209, A: 255}
+	accent := color.RGBA{R: 234, G: 88, B: 12, A: 255}
+
+	cards.FillRect(canvas, canvas.Bounds(), background)
+	cards.FillRect(canvas, image.Rect(0, 0, 12, battleCardHeight), accent)
+
+	face := basicfont.Face7x13
+	lineHeight := 24
+
+	cards.DrawLabel(canvas, face, 72, 80, data.RoomName, accent)
+	bottom := cards.DrawWrappedText(canvas, face, 72, 130, battleCardWidth-144, lineHeight, 5, data.Topic, ink)
+
+	cards.FillRect(canvas, image.Rect(72, bottom+8, battleCardWidth-72, bottom+9), rule)
+	cards.DrawWrappedText(canvas, face, 72, bottom+48, battleCardWidth-144, 20, 3, data.Verdict, inkMuted)
+
+	linkDisplay := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(data.URL), "https://"), "http://")
+	cards.DrawWrappedText(canvas, face, 72, battleCardHeight-56, battleCardWidth-144, 18, 1, linkDisplay, ink)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestAssignBattleCardVariantIsDeterministic(t *testing.T) {
+	seen := map[string]bool{}
+	for _, battleID := range []string{
+		"4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		"7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+		"11111111-2222-4333-8444-555555555555",
+		"99999999-8888-4777-8666-555555555555",
+		"abcdefab-cdef-4abc-8def-abcdefabcdef",
+	} {
+		variant := assignBattleCardVariant(battleID)
+		if _, ok := battleCardLayouts[variant]; !ok {
+			t.Fatalf("assigned unknown variant %q", variant)
+		}
+		if again := assignBattleCardVariant(battleID); again != variant {
+			t.Fatalf("expected stable assignment for %s, got %s then %s", battleID, variant, again)
+		}
+		seen[variant] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expected battles to spread across variants, got %v", seen)
+	}
+}
+
+func TestResolveBattleCardVariant(t *testing.T) {
+	const battleID = "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f"
+	if got, err := resolveBattleCardVariant(battleID, ""); err != nil || got != assignBattleCardVariant(battleID) {
+		t.Fatalf("expected assigned variant without override, got %q %v", got, err)
+	}
+	if got, err := resolveBattleCardVariant(battleID, " Minimal "); err != nil || got != battleCardVariantMinimal {
+		t.Fatalf("expected override to apply, got %q %v", got, err)
+	}
+	if _, err := resolveBattleCardVariant(battleID, "neon"); !errors.Is(err, errUnknownBattleCardVariant) {
+		t.Fatalf("expected unknown variant error, got %v", err)
+	}
+}
+
+func TestVerifyBattleCardClick(t *testing.T) {
+	const (
+		secret   = "card-secret"
+		battleID = "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f"
+	)
+	query, err := url.ParseQuery(battleCardClickQuery(secret, battleID, battleCardVariantSpotlight))
+	if err != nil {
+		t.Fatalf("parse click query: %v", err)
+	}
+	variant, signature := query.Get(battleCardVariantQueryName), query.Get(battleCardSignatureQueryName)
+	if got := verifyBattleCardClick(secret, battleID, variant, signature); got != battleCardVariantSpotlight {
+		t.Fatalf("expected signed click to verify, got %q", got)
+	}
+
+	cases := map[string][4]string{
+		"other battle":   {secret, "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b", variant, signature},
+		"swapped":        {secret, battleID, battleCardVariantClassic, signature},
+		"wrong secret":   {"other-secret", battleID, variant, signature},
+		"unknown":        {secret, battleID, "neon", signBattleCardClick(secret, battleID, "neon")},
+		"missing sig":    {secret, battleID, variant, ""},
+		"missing params": {secret, battleID, "", ""},
+	}
+	for name, tc := range cases {
+		if got := verifyBattleCardClick(tc[0], tc[1], tc[2], tc[3]); got != "" {
+			t.Fatalf("%s: expected click to be rejected, got %q", name, got)
+		}
+	}
+}
+
+func TestRenderBattleCardVariantsDiffer(t *testing.T) {
+	data := battleCardData{
+		BattleID:   "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		RoomName:   "product",
+		Topic:      "Should we ship weekly?",
+		ProPersona: "Ada",
+		ConPersona: "Linus",
+		Verdict:    "Verdict: Start lean.",
+		Takeaways:  []string{"One", "Two", "Three"},
+		URL:        "https://personaworlds.test/b/4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+	}
+
+	rendered := map[string][]byte{}
+	for _, variant := range battleCardVariants {
+		payload, err := renderBattleCardPNG(data, variant)
+		if err != nil {
+			t.Fatalf("render %s: %v", variant, err)
+		}
+		for other, otherPayload := range rendered {
+			if bytes.Equal(payload, otherPayload) {
+				t.Fatalf("expected %s and %s to render differently", variant, other)
+			}
+		}
+		rendered[variant] = payload
+	}
+
+	fallback, err := renderBattleCardPNG(data, "")
+	if err != nil {
+		t.Fatalf("render fallback: %v", err)
+	}
+	if !bytes.Equal(fallback, rendered[battleCardVariantClassic]) {
+		t.Fatalf("expected unknown variant to fall back to classic")
+	}
+}
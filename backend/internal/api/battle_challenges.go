@@ -0,0 +1,665 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	challengeStatusPending  = "PENDING"
+	challengeStatusAccepted = "ACCEPTED"
+	challengeStatusDeclined = "DECLINED"
+	// challengeStatusExpired is never stored; pending challenges past their
+	// expiry are reported as expired and can no longer be accepted.
+	challengeStatusExpired = "EXPIRED"
+
+	challengeRoleChallenger = "CHALLENGER"
+	challengeRoleOpponent   = "OPPONENT"
+
+	challengeBoxIncoming = "incoming"
+	challengeBoxOutgoing = "outgoing"
+
+	challengeLimitWindow = 24 * time.Hour
+	challengeListLimit   = 50
+)
+
+// ChallengePersona is the public face of a persona in a challenge. It never
+// carries calibration (bio, tone, samples), which stays private to its owner.
+type ChallengePersona struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug,omitempty"`
+}
+
+type BattleChallenge struct {
+	ID          string           `json:"id"`
+	Box         string           `json:"box"`
+	Status      string           `json:"status"`
+	Topic       string           `json:"topic"`
+	RoomID      string           `json:"room_id"`
+	RoomName    string           `json:"room_name"`
+	TemplateID  string           `json:"template_id,omitempty"`
+	Challenger  ChallengePersona `json:"challenger"`
+	Opponent    ChallengePersona `json:"opponent"`
+	BattleID    string           `json:"battle_id,omitempty"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	RespondedAt *time.Time       `json:"responded_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// ChallengeLimit is the state of a user's rolling daily challenge allowance.
+type ChallengeLimit struct {
+	Scope             string    `json:"scope"`
+	Limit             int       `json:"limit"`
+	Used              int       `json:"used"`
+	AvailableAt       time.Time `json:"available_at"`
+	RetryAfterSeconds int       `json:"retry_after_seconds"`
+}
+
+func challengeDisplayStatus(status string, expiresAt, now time.Time) string {
+	if status == challengeStatusPending && !now.Before(expiresAt) {
+		return challengeStatusExpired
+	}
+	return status
+}
+
+func validateChallengeBox(value string) (string, error) {
+	switch clean := strings.ToLower(strings.TrimSpace(value)); clean {
+	case "":
+		return challengeBoxIncoming, nil
+	case challengeBoxIncoming, challengeBoxOutgoing:
+		return clean, nil
+	default:
+		return "", errors.New("box must be incoming or outgoing")
+	}
+}
+
+// challengeLimitState reports whether used challenges in the rolling window
+// reach limit. oldest is the earliest challenge still inside the window; the
+// allowance frees up when it ages out.
+func challengeLimitState(scope string, limit, used int, oldest, now time.Time) (ChallengeLimit, bool) {
+	state := ChallengeLimit{Scope: scope, Limit: limit, Used: used}
+	if limit <= 0 || used < limit {
+		return state, false
+	}
+	state.AvailableAt = oldest.Add(challengeLimitWindow)
+	retry := int(state.AvailableAt.Sub(now).Seconds())
+	if retry < 1 {
+		retry = 1
+	}
+	state.RetryAfterSeconds = retry
+	return state, true
+}
+
+func (s *Server) handleChallengePublicProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
+	if slug == "" {
+		writeNotFound(w, "public profile not found")
+		return
+	}
+
+	var req struct {
+		PersonaID  string `json:"persona_id"`
+		Topic      string `json:"topic"`
+		RoomID     string `json:"room_id"`
+		TemplateID string `json:"template_id"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	personaID, err := validateUUID(req.PersonaID, "persona_id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	topic, err := validateBattleTopic(s.loadSafetyRules(r.Context()), req.Topic)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	templateID := strings.TrimSpace(req.TemplateID)
+	if templateID != "" {
+		if templateID, err = validateUUID(templateID, "template id"); err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+
+	persona, err := s.getPersonaByID(r.Context(), userID, personaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	profile, ownerUserID, err := s.getPublicProfileBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "/challenge") {
+				return
+			}
+			writeNotFound(w, "public profile not found")
+			return
+		}
+		writeInternalError(w, "could not load public profile")
+		return
+	}
+	if ownerUserID == userID {
+		writeConflict(w, "cannot challenge your own persona")
+		return
+	}
+
+	roomID, err := s.resolveAnswerRoom(r.Context(), profile.PersonaID, req.RoomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeBadRequest(w, err.Error())
+		return
+	}
+	template, err := s.resolveBattleTemplate(r.Context(), templateID, userID)
+	if err != nil {
+		writeBattleTemplateError(w, templateID, err)
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Serialize challenges per sender so two concurrent requests cannot both
+	// slip under the daily limit.
+	if _, err := tx.Exec(r.Context(), "SELECT pg_advisory_xact_lock(hashtext($1))", "challenge:"+userID); err != nil {
+		writeInternalError(w, "could not check challenge limit")
+		return
+	}
+	sentLimit := s.cfg.ChallengeDailyLimit
+	if sentLimit > 0 {
+		boost, err := common.ActiveQuotaBoost(r.Context(), tx, userID, common.QuotaBoostBattle)
+		if err != nil {
+			writeInternalError(w, "could not check challenge limit")
+			return
+		}
+		sentLimit += boost
+	}
+	// Personas listed in the marketplace take a limited number of guest
+	// challenges a day across all senders.
+	personaLimit, err := marketplaceDailyLimit(r.Context(), tx, profile.PersonaID)
+	if err != nil {
+		writeInternalError(w, "could not check challenge limit")
+		return
+	}
+	if personaLimit > 0 {
+		if _, err := tx.Exec(r.Context(), "SELECT pg_advisory_xact_lock(hashtext($1))", "challenge-persona:"+profile.PersonaID); err != nil {
+			writeInternalError(w, "could not check challenge limit")
+			return
+		}
+	}
+	now := time.Now().UTC()
+	for _, check := range []struct {
+		scope   string
+		column  string
+		subject string
+		limit   int
+	}{
+		{scope: "sent", column: "challenger_user_id", subject: userID, limit: sentLimit},
+		{scope: "received", column: "opponent_user_id", subject: ownerUserID, limit: s.cfg.ChallengeReceivedLimit},
+		{scope: "persona", column: "opponent_persona_id", subject: profile.PersonaID, limit: personaLimit},
+	} {
+		var (
+			used   int
+			oldest *time.Time
+		)
+		if err := tx.QueryRow(r.Context(), `
+			SELECT COUNT(*)::int, MIN(created_at)
+			FROM battle_challenges
+			WHERE `+check.column+` = $1
+			  AND created_at > $2
+		`, check.subject, now.Add(-challengeLimitWindow)).Scan(&used, &oldest); err != nil {
+			writeInternalError(w, "could not check challenge limit")
+			return
+		}
+		oldestAt := now
+		if oldest != nil {
+			oldestAt = *oldest
+		}
+		if state, limited := challengeLimitState(check.scope, check.limit, used, oldestAt, now); limited {
+			w.Header().Set("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+			message := "daily challenge limit reached"
+			switch check.scope {
+			case "received":
+				message = "this persona's owner has received too many challenges today"
+			case "persona":
+				message = "this persona is fully booked for guest battles today"
+			}
+			writeJSON(w, http.StatusTooManyRequests, map[string]any{
+				"error": message,
+				"code":  "challenge_limit",
+				"limit": state,
+			})
+			return
+		}
+	}
+
+	var challengeID string
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO battle_challenges(
+			challenger_user_id, challenger_persona_id, opponent_user_id, opponent_persona_id,
+			room_id, template_id, topic, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id::text
+	`, userID, persona.ID, ownerUserID, profile.PersonaID, roomID, template.ID, topic, now.Add(s.cfg.ChallengeExpiry)).Scan(&challengeID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			writeConflict(w, "a challenge between these personas is already pending")
+			return
+		}
+		writeInternalError(w, "could not save challenge")
+		return
+	}
+	if err := s.insertChallengeConsent(r, tx, challengeID, userID, persona.ID, challengeRoleChallenger); err != nil {
+		writeInternalError(w, "could not save challenge")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not save challenge")
+		return
+	}
+
+	challenge, err := s.loadBattleChallenge(r.Context(), challengeID, userID)
+	if err != nil {
+		writeInternalError(w, "could not load challenge")
+		return
+	}
+
+	_ = s.notifyBattleChallenge(r.Context(), ownerUserID, userID, challenge)
+	_ = s.logEventFromRequest(r, eventBattleChallengeSent, map[string]any{
+		"challenge_id": challengeID,
+		"slug":         profile.Slug,
+		"persona_id":   persona.ID,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"challenge": challenge,
+	})
+}
+
+func (s *Server) handleListBattleChallenges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	box, err := validateChallengeBox(r.URL.Query().Get("box"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	column := "opponent_user_id"
+	if box == challengeBoxOutgoing {
+		column = "challenger_user_id"
+	}
+	rows, err := s.db.Query(r.Context(), battleChallengeSelect+`
+		WHERE c.`+column+` = $1
+		ORDER BY c.created_at DESC
+		LIMIT $2
+	`, userID, challengeListLimit)
+	if err != nil {
+		writeInternalError(w, "could not load challenges")
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	challenges := make([]BattleChallenge, 0)
+	for rows.Next() {
+		challenge, err := scanBattleChallenge(rows, userID, now)
+		if err != nil {
+			writeInternalError(w, "could not scan challenge")
+			return
+		}
+		challenges = append(challenges, challenge)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load challenges")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"box":        box,
+		"challenges": challenges,
+	})
+}
+
+func (s *Server) handleAcceptBattleChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	challengeID, err := validateUUID(chi.URLParam(r, "id"), "challenge id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var (
+		challengerUserID    string
+		challengerPersonaID string
+		opponentPersonaID   string
+		roomID              string
+		templateID          string
+		topic               string
+		status              string
+		expiresAt           time.Time
+	)
+	err = tx.QueryRow(r.Context(), `
+		SELECT
+			challenger_user_id::text,
+			challenger_persona_id::text,
+			opponent_persona_id::text,
+			room_id::text,
+			COALESCE(template_id::text, ''),
+			topic,
+			status,
+			expires_at
+		FROM battle_challenges
+		WHERE id = $1
+		  AND opponent_user_id = $2
+		FOR UPDATE
+	`, challengeID, userID).Scan(&challengerUserID, &challengerPersonaID, &opponentPersonaID, &roomID, &templateID, &topic, &status, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "challenge not found")
+			return
+		}
+		writeInternalError(w, "could not load challenge")
+		return
+	}
+	if current := challengeDisplayStatus(status, expiresAt, time.Now()); current != challengeStatusPending {
+		writeConflict(w, fmt.Sprintf("challenge is already %s", strings.ToLower(current)))
+		return
+	}
+	if booked, err := marketplaceAcceptBooked(r.Context(), tx, opponentPersonaID); err != nil {
+		writeInternalError(w, "could not check challenge limit")
+		return
+	} else if booked {
+		writeConflict(w, "this persona has already accepted its guest battles for today")
+		return
+	}
+
+	room, err := s.getRoomByID(r.Context(), roomID)
+	if err != nil {
+		writeInternalError(w, "could not load room")
+		return
+	}
+	if room.ArchivedAt != nil {
+		writeConflict(w, "room is archived")
+		return
+	}
+	if err := roomEventWriteError(room.Event); err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
+	// A template deleted since the challenge was sent falls back to the
+	// default rather than failing the accept.
+	template, err := s.resolveBattleTemplate(r.Context(), templateID, challengerUserID)
+	if err != nil && templateID != "" && errors.Is(err, pgx.ErrNoRows) {
+		template, err = s.resolveBattleTemplate(r.Context(), "", challengerUserID)
+	}
+	if err != nil {
+		writeBattleTemplateError(w, "", err)
+		return
+	}
+
+	proStyle, conStyle := normalizeBattleStyles("", "")
+	battle, err := s.insertBattlePost(r.Context(), tx, challengerUserID, room.ID, topic, template, proStyle, conStyle, "", common.BattleLanguage{Mode: common.BattleLanguageAuto})
+	if err != nil {
+		writeInternalError(w, "could not create battle")
+		return
+	}
+	// The challenger's persona opens; each persona's own reply quota applies
+	// when the worker writes its turn.
+	for idx, personaID := range []string{challengerPersonaID, opponentPersonaID} {
+		payloadMap := map[string]any{
+			"post_id":      battle.ID,
+			"persona_id":   personaID,
+			"template_id":  template.ID,
+			"challenge_id": challengeID,
+		}
+		if traceID := strings.TrimSpace(requestIDFromRequest(r)); traceID != "" {
+			payloadMap["trace_id"] = traceID
+		}
+		payload, err := json.Marshal(payloadMap)
+		if err != nil {
+			writeInternalError(w, "could not queue replies")
+			return
+		}
+		if _, err := tx.Exec(r.Context(), `
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ('generate_reply', $1, $2, $3::jsonb, 'PENDING', NOW() + ($4::int * INTERVAL '1 second'))
+		`, battle.ID, personaID, payload, idx); err != nil {
+			writeInternalError(w, "could not queue replies")
+			return
+		}
+	}
+
+	if _, err := tx.Exec(r.Context(), `
+		UPDATE battle_challenges
+		SET status = $2, battle_post_id = $3, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, challengeID, challengeStatusAccepted, battle.ID); err != nil {
+		writeInternalError(w, "could not accept challenge")
+		return
+	}
+	if err := s.insertChallengeConsent(r, tx, challengeID, userID, opponentPersonaID, challengeRoleOpponent); err != nil {
+		writeInternalError(w, "could not accept challenge")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not accept challenge")
+		return
+	}
+
+	challenge, err := s.loadBattleChallenge(r.Context(), challengeID, userID)
+	if err != nil {
+		writeInternalError(w, "could not load challenge")
+		return
+	}
+
+	_ = s.notifyBattleChallengeAccepted(r.Context(), challengerUserID, userID, challenge)
+	_ = s.notifyTemplateUsed(r.Context(), challengerUserID, template, battle.ID)
+	_ = s.logEventFromRequest(r, eventBattleChallengeAccepted, map[string]any{
+		"challenge_id": challengeID,
+		"battle_id":    battle.ID,
+		"room_id":      room.ID,
+		"template_id":  template.ID,
+	})
+	s.logger.Info("battle_challenge_accepted", observability.Fields{
+		"challenge_id": challengeID,
+		"battle_id":    battle.ID,
+		"user_id":      userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"challenge":          challenge,
+		"battle_id":          battle.ID,
+		"suggested_next_url": fmt.Sprintf("/b/%s", battle.ID),
+	})
+}
+
+func (s *Server) handleDeclineBattleChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	challengeID, err := validateUUID(chi.URLParam(r, "id"), "challenge id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		UPDATE battle_challenges
+		SET status = $3, responded_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		  AND opponent_user_id = $2
+		  AND status = $4
+	`, challengeID, userID, challengeStatusDeclined, challengeStatusPending)
+	if err != nil {
+		writeInternalError(w, "could not decline challenge")
+		return
+	}
+
+	challenge, err := s.loadBattleChallenge(r.Context(), challengeID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "challenge not found")
+			return
+		}
+		writeInternalError(w, "could not load challenge")
+		return
+	}
+	if challenge.Box != challengeBoxIncoming {
+		writeNotFound(w, "challenge not found")
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeConflict(w, fmt.Sprintf("challenge is already %s", strings.ToLower(challenge.Status)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"challenge": challenge,
+	})
+}
+
+// insertChallengeConsent records that a user agreed to put their persona in
+// the battle. The client IP is stored as a keyed hash, like view counters.
+func (s *Server) insertChallengeConsent(r *http.Request, executor common.DBExecutor, challengeID, userID, personaID, role string) error {
+	ipHash := viewerKey("", requestClientIP(r), s.cfg.JWTSecret)
+	_, err := executor.Exec(r.Context(), `
+		INSERT INTO battle_challenge_consents(challenge_id, user_id, persona_id, role, ip_hash)
+		VALUES ($1, $2, $3, $4, $5)
+	`, challengeID, userID, personaID, role, ipHash)
+	return err
+}
+
+// isChallengeParticipant reports whether userID owns the opponent side of an
+// accepted challenge battle.
+func (s *Server) isChallengeParticipant(ctx context.Context, battleID, userID string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM battle_challenges
+			WHERE battle_post_id = $1
+			  AND opponent_user_id = $2
+		)
+	`, battleID, userID).Scan(&exists)
+	return exists, err
+}
+
+const battleChallengeSelect = `
+	SELECT
+		c.id::text,
+		c.challenger_user_id::text,
+		c.status,
+		c.topic,
+		c.room_id::text,
+		COALESCE(rm.name, ''),
+		COALESCE(c.template_id::text, ''),
+		cp.id::text,
+		cp.name,
+		COALESCE(cpp.slug, ''),
+		op.id::text,
+		op.name,
+		COALESCE(opp.slug, ''),
+		COALESCE(c.battle_post_id::text, ''),
+		c.expires_at,
+		c.responded_at,
+		c.created_at
+	FROM battle_challenges c
+	JOIN personas cp ON cp.id = c.challenger_persona_id
+	JOIN personas op ON op.id = c.opponent_persona_id
+	LEFT JOIN persona_public_profiles cpp ON cpp.persona_id = cp.id AND cpp.is_public = TRUE
+	LEFT JOIN persona_public_profiles opp ON opp.persona_id = op.id AND opp.is_public = TRUE
+	LEFT JOIN rooms rm ON rm.id = c.room_id
+`
+
+func scanBattleChallenge(row pgx.Row, userID string, now time.Time) (BattleChallenge, error) {
+	var (
+		out              BattleChallenge
+		challengerUserID string
+	)
+	err := row.Scan(
+		&out.ID,
+		&challengerUserID,
+		&out.Status,
+		&out.Topic,
+		&out.RoomID,
+		&out.RoomName,
+		&out.TemplateID,
+		&out.Challenger.ID,
+		&out.Challenger.Name,
+		&out.Challenger.Slug,
+		&out.Opponent.ID,
+		&out.Opponent.Name,
+		&out.Opponent.Slug,
+		&out.BattleID,
+		&out.ExpiresAt,
+		&out.RespondedAt,
+		&out.CreatedAt,
+	)
+	if err != nil {
+		return BattleChallenge{}, err
+	}
+	out.Box = challengeBoxIncoming
+	if challengerUserID == userID {
+		out.Box = challengeBoxOutgoing
+	}
+	out.Status = challengeDisplayStatus(out.Status, out.ExpiresAt, now)
+	return out, nil
+}
+
+// loadBattleChallenge returns a challenge as seen by one of its two users.
+func (s *Server) loadBattleChallenge(ctx context.Context, challengeID, userID string) (BattleChallenge, error) {
+	row := s.db.QueryRow(ctx, battleChallengeSelect+`
+		WHERE c.id = $1
+		  AND (c.challenger_user_id = $2 OR c.opponent_user_id = $2)
+	`, challengeID, userID)
+	return scanBattleChallenge(row, userID, time.Now())
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChallengeDisplayStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if got := challengeDisplayStatus(challengeStatusPending, now.Add(time.Minute), now); got != challengeStatusPending {
+		t.Fatalf("expected pending, got %s", got)
+	}
+	if got := challengeDisplayStatus(challengeStatusPending, now, now); got != challengeStatusExpired {
+		t.Fatalf("expected expired at the deadline, got %s", got)
+	}
+	if got := challengeDisplayStatus(challengeStatusAccepted, now.Add(-time.Hour), now); got != challengeStatusAccepted {
+		t.Fatalf("expected answered challenges to keep their status, got %s", got)
+	}
+}
+
+func TestValidateChallengeBox(t *testing.T) {
+	for raw, want := range map[string]string{"": challengeBoxIncoming, "Outgoing": challengeBoxOutgoing, " incoming ": challengeBoxIncoming} {
+		got, err := validateChallengeBox(raw)
+		if err != nil || got != want {
+			t.Fatalf("validateChallengeBox(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := validateChallengeBox("all"); err == nil {
+		t.Fatal("expected unknown box to be rejected")
+	}
+}
+
+func TestChallengeLimitState(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	oldest := now.Add(-20 * time.Hour)
+
+	if _, limited := challengeLimitState("sent", 5, 4, oldest, now); limited {
+		t.Fatal("expected room for one more challenge")
+	}
+	if _, limited := challengeLimitState("sent", 0, 100, oldest, now); limited {
+		t.Fatal("expected a zero limit to disable the check")
+	}
+
+	state, limited := challengeLimitState("sent", 5, 5, oldest, now)
+	if !limited {
+		t.Fatal("expected limit to be reached")
+	}
+	if !state.AvailableAt.Equal(oldest.Add(24 * time.Hour)) {
+		t.Fatalf("unexpected available_at %s", state.AvailableAt)
+	}
+	if state.RetryAfterSeconds != int((4 * time.Hour).Seconds()) {
+		t.Fatalf("unexpected retry after %d", state.RetryAfterSeconds)
+	}
+}
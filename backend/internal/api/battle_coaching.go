@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BattleCoaching struct {
+	PersonaID   string    `json:"persona_id"`
+	PersonaName string    `json:"persona_name"`
+	Feedback    string    `json:"feedback"`
+	Turns       []int     `json:"turns"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (s *Server) handleGetBattleCoaching(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	// Both sides of a challenge battle see coaching, but only for their own
+	// persona; the query below filters by persona owner.
+	rows, err := s.db.Query(r.Context(), `
+		SELECT c.persona_id::text, p.name, c.feedback, c.turns, c.created_at
+		FROM battle_coaching c
+		JOIN personas p ON p.id = c.persona_id
+		WHERE c.post_id = $1
+		  AND p.user_id = $2
+		ORDER BY p.name ASC
+	`, battleID, userID)
+	if err != nil {
+		writeInternalError(w, "could not load coaching")
+		return
+	}
+	defer rows.Close()
+
+	items := make([]BattleCoaching, 0)
+	for rows.Next() {
+		var item BattleCoaching
+		var turnsRaw []byte
+		if err := rows.Scan(&item.PersonaID, &item.PersonaName, &item.Feedback, &turnsRaw, &item.CreatedAt); err != nil {
+			writeInternalError(w, "could not scan coaching")
+			return
+		}
+		if len(turnsRaw) > 0 {
+			_ = json.Unmarshal(turnsRaw, &item.Turns)
+		}
+		if item.Turns == nil {
+			item.Turns = []int{}
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load coaching")
+		return
+	}
+
+	status := "ready"
+	if len(items) == 0 {
+		status = "pending"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"battle_id": battleID,
+		"status":    status,
+		"coaching":  items,
+	})
+}
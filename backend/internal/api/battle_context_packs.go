@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// BattleContextPack is the shared background both sides of a battle were
+// given, with the room posts it was summarized from.
+type BattleContextPack struct {
+	BattleID      string                           `json:"battle_id"`
+	Topic         string                           `json:"topic"`
+	Status        string                           `json:"status"`
+	Summary       string                           `json:"summary"`
+	Sources       []common.BattleContextPackSource `json:"sources"`
+	PromptVersion string                           `json:"prompt_version,omitempty"`
+	Error         string                           `json:"error,omitempty"`
+	CreatedAt     time.Time                        `json:"created_at"`
+	UpdatedAt     time.Time                        `json:"updated_at"`
+}
+
+// handleGetBattleContextPack shows the owner of a battle the context pack it
+// was created with. Everyone else, and battles created without a pack, get
+// a 404.
+func (s *Server) handleGetBattleContextPack(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var (
+		out        BattleContextPack
+		sourcesRaw []byte
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT
+			c.post_id::text,
+			c.topic,
+			c.status,
+			c.summary,
+			c.sources,
+			COALESCE(c.prompt_version, ''),
+			c.error,
+			c.created_at,
+			c.updated_at
+		FROM battle_context_packs c
+		JOIN posts p ON p.id = c.post_id
+		WHERE c.post_id = $1
+		  AND p.user_id = $2
+	`, battleID, userID).Scan(
+		&out.BattleID,
+		&out.Topic,
+		&out.Status,
+		&out.Summary,
+		&sourcesRaw,
+		&out.PromptVersion,
+		&out.Error,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "context pack not found")
+			return
+		}
+		writeInternalError(w, "could not load context pack")
+		return
+	}
+	if len(sourcesRaw) > 0 {
+		_ = json.Unmarshal(sourcesRaw, &out.Sources)
+	}
+	if out.Sources == nil {
+		out.Sources = []common.BattleContextPackSource{}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// BattleUsageTotals is the LLM work recorded on a battle, including turn
+// attempts that failed and were retried.
+type BattleUsageTotals struct {
+	LLMCalls           int   `json:"llm_calls"`
+	LLMRetries         int   `json:"llm_retries"`
+	PromptTokens       int64 `json:"prompt_tokens"`
+	CompletionTokens   int64 `json:"completion_tokens"`
+	LLMLatencyMS       int64 `json:"llm_latency_ms"`
+	FailedTurnAttempts int   `json:"failed_turn_attempts"`
+}
+
+type BattleTurnDiagnostics struct {
+	Index       int       `json:"index"`
+	ReplyID     string    `json:"reply_id"`
+	PersonaID   string    `json:"persona_id,omitempty"`
+	PersonaName string    `json:"persona_name,omitempty"`
+	AuthoredBy  string    `json:"authored_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	// WaitMS is the time between the previous turn (or the battle start)
+	// and this turn's generation starting: queueing, active-hours deferrals
+	// and job retries. It and GenerationMS are only set for turns whose
+	// timing was recorded.
+	WaitMS       *int64            `json:"wait_ms,omitempty"`
+	GenerationMS *int64            `json:"generation_ms,omitempty"`
+	Usage        *common.TurnUsage `json:"usage,omitempty"`
+}
+
+type BattleDiagnostics struct {
+	BattleID   string                  `json:"battle_id"`
+	StartedAt  time.Time               `json:"started_at"`
+	DurationMS int64                   `json:"duration_ms"`
+	Totals     BattleUsageTotals       `json:"totals"`
+	Turns      []BattleTurnDiagnostics `json:"turns"`
+	// SlowestTurn is the index of the turn that waited plus generated the
+	// longest, 0 when no turn has timing.
+	SlowestTurn int            `json:"slowest_turn"`
+	Archive     *BattleArchive `json:"archive,omitempty"`
+}
+
+// handleGetBattleDiagnostics shows the owner of a battle where its time and
+// tokens went, turn by turn. Everyone else gets a 404.
+func (s *Server) handleGetBattleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var (
+		startedAt time.Time
+		totals    BattleUsageTotals
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT COALESCE(published_at, created_at), llm_calls, llm_retries, llm_prompt_tokens,
+			llm_completion_tokens, llm_latency_ms, failed_turn_attempts
+		FROM posts
+		WHERE id = $1
+		  AND user_id = $2
+		  AND template_id IS NOT NULL
+	`, battleID, userID).Scan(
+		&startedAt,
+		&totals.LLMCalls,
+		&totals.LLMRetries,
+		&totals.PromptTokens,
+		&totals.CompletionTokens,
+		&totals.LLMLatencyMS,
+		&totals.FailedTurnAttempts,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not load battle")
+		return
+	}
+
+	battle, err := s.getBattleByID(r.Context(), battleID)
+	if err != nil {
+		writeInternalError(w, "could not load battle")
+		return
+	}
+
+	diagnostics := buildBattleDiagnostics(battleID, startedAt, totals, battle.Turns)
+	diagnostics.Archive = battle.Archive
+	writeJSON(w, http.StatusOK, diagnostics)
+}
+
+// buildBattleDiagnostics splits each turn's time into waiting and
+// generating. Like the replay, offsets never go backwards, so clock skew
+// between writers cannot produce negative waits.
+func buildBattleDiagnostics(battleID string, startedAt time.Time, totals BattleUsageTotals, turns []Reply) BattleDiagnostics {
+	diagnostics := BattleDiagnostics{
+		BattleID:  battleID,
+		StartedAt: startedAt,
+		Totals:    totals,
+		Turns:     make([]BattleTurnDiagnostics, 0, len(turns)),
+	}
+
+	previous := startedAt
+	var slowestMS int64 = -1
+	for i, turn := range turns {
+		item := BattleTurnDiagnostics{
+			Index:       i + 1,
+			ReplyID:     turn.ID,
+			PersonaID:   turn.PersonaID,
+			PersonaName: turn.Persona,
+			AuthoredBy:  turn.AuthoredBy,
+			CreatedAt:   turn.CreatedAt,
+		}
+		if turn.hasTiming {
+			waitMS := max(turn.timing.StartedAt.Sub(previous).Milliseconds(), 0)
+			generationMS := turn.timing.GenerationMS
+			item.WaitMS = &waitMS
+			item.GenerationMS = &generationMS
+			if waitMS+generationMS > slowestMS {
+				slowestMS = waitMS + generationMS
+				diagnostics.SlowestTurn = item.Index
+			}
+		}
+		if turn.hasUsage {
+			usage := turn.usage
+			item.Usage = &usage
+		}
+		diagnostics.Turns = append(diagnostics.Turns, item)
+		if turn.CreatedAt.After(previous) {
+			previous = turn.CreatedAt
+		}
+	}
+
+	diagnostics.DurationMS = previous.Sub(startedAt).Milliseconds()
+	return diagnostics
+}
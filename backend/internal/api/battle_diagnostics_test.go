@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestBuildBattleDiagnostics(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	turns := []Reply{
+		{
+			ID:        "r1",
+			CreatedAt: start.Add(10 * time.Second),
+			timing:    common.TurnTiming{StartedAt: start.Add(7 * time.Second), GenerationMS: 2500},
+			hasTiming: true,
+			usage:     common.TurnUsage{LLMCalls: 1, PromptTokens: 300, CompletionTokens: 80, LLMLatencyMS: 2400, JobAttempt: 1},
+			hasUsage:  true,
+		},
+		{
+			ID:        "r2",
+			CreatedAt: start.Add(10*time.Minute + 5*time.Second),
+			timing:    common.TurnTiming{StartedAt: start.Add(10 * time.Minute), GenerationMS: 4000},
+			hasTiming: true,
+			usage:     common.TurnUsage{LLMCalls: 2, LLMRetries: 1, PromptTokens: 700, CompletionTokens: 160, LLMLatencyMS: 3900, JobAttempt: 3},
+			hasUsage:  true,
+		},
+		{ID: "r3", AuthoredBy: "HUMAN", CreatedAt: start.Add(5 * time.Minute)},
+	}
+	totals := BattleUsageTotals{LLMCalls: 4, LLMRetries: 1, FailedTurnAttempts: 2}
+
+	diagnostics := buildBattleDiagnostics("battle", start, totals, turns)
+	if len(diagnostics.Turns) != 3 || diagnostics.Totals != totals {
+		t.Fatalf("unexpected diagnostics %+v", diagnostics)
+	}
+	first := diagnostics.Turns[0]
+	if *first.WaitMS != 7000 || *first.GenerationMS != 2500 || first.Usage.PromptTokens != 300 {
+		t.Fatalf("unexpected first turn %+v", first)
+	}
+	second := diagnostics.Turns[1]
+	if *second.WaitMS != 590000 || second.Usage.JobAttempt != 3 || second.Usage.LLMRetries != 1 {
+		t.Fatalf("unexpected second turn %+v", second)
+	}
+	if human := diagnostics.Turns[2]; human.WaitMS != nil || human.Usage != nil {
+		t.Fatalf("expected the human turn without timing or usage, got %+v", human)
+	}
+	if diagnostics.SlowestTurn != 2 {
+		t.Fatalf("expected turn 2 to be the slowest, got %d", diagnostics.SlowestTurn)
+	}
+	if diagnostics.DurationMS != (10*time.Minute + 5*time.Second).Milliseconds() {
+		t.Fatalf("unexpected duration %d", diagnostics.DurationMS)
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	battleDryRunAuthorOpening = "opening"
+	battleDryRunAuthorPersona = "persona"
+	battleDryRunAuthorHuman   = "human"
+)
+
+type BattleDryRunTurn struct {
+	Turn        int    `json:"turn"`
+	Author      string `json:"author"`
+	PersonaID   string `json:"persona_id,omitempty"`
+	PersonaName string `json:"persona_name,omitempty"`
+}
+
+type BattleDryRunPrompt struct {
+	Turn      int    `json:"turn"`
+	PersonaID string `json:"persona_id"`
+	Operation string `json:"operation"`
+	Version   string `json:"version"`
+	System    string `json:"system"`
+	User      string `json:"user"`
+}
+
+// BattleDryRunEstimate is a rough upper bound. Tokens are counted at four
+// characters or 0.75 words per token; MaxDurationSeconds allows one full
+// provider timeout per AI turn and leaves out time spent waiting on humans.
+type BattleDryRunEstimate struct {
+	LLMCalls                int `json:"llm_calls"`
+	PromptTokens            int `json:"prompt_tokens"`
+	CompletionTokens        int `json:"completion_tokens"`
+	MaxDurationSeconds      int `json:"max_duration_seconds"`
+	HumanTurns              int `json:"human_turns,omitempty"`
+	HumanTurnTimeoutSeconds int `json:"human_turn_timeout_seconds,omitempty"`
+}
+
+// battleDryRunSlot is one planned turn after the opening post.
+type battleDryRunSlot struct {
+	Author     string
+	Persona    Persona
+	SkipReason string
+}
+
+func estimateTextTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func estimateReplyTokens(wordLimit int) int {
+	return (wordLimit*4 + 2) / 3
+}
+
+// battleDryRunContextPackPlaceholder stands in for a context pack the worker
+// has not summarized yet.
+const battleDryRunContextPackPlaceholder = "[context pack: summary of the room's recent posts on the topic]"
+
+// battleDryRunPlaceholder stands in for a turn that has not been written yet.
+func battleDryRunPlaceholder(turn int, slot battleDryRunSlot, wordLimit int) prompts.ReplyItem {
+	if slot.Author == battleDryRunAuthorHuman {
+		return prompts.ReplyItem{
+			Content: fmt.Sprintf("[turn %d: your reply, up to %d words]", turn, wordLimit),
+			Human:   true,
+		}
+	}
+	return prompts.ReplyItem{
+		Content: fmt.Sprintf("[turn %d: reply from %s, up to %d words]", turn, slot.Persona.Name, wordLimit),
+	}
+}
+
+// handleDryRunBattle validates a battle creation request and returns the
+// turns, prompt skeletons and cost the battle would have, without writing
+// anything or queueing jobs.
+func (s *Server) handleDryRunBattle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	plan, ok := s.prepareBattleCreate(w, r, userID, roomID)
+	if !ok {
+		return
+	}
+
+	slots, err := s.planBattleDryRunSlots(r, userID, plan)
+	if err != nil {
+		writeInternalError(w, "could not load personas")
+		return
+	}
+	roomHint, err := common.LoadRoomPromptHint(r.Context(), s.db, plan.Room.ID)
+	if err != nil {
+		writeInternalError(w, "could not load room prompt hint")
+		return
+	}
+
+	template := plan.Template
+	// Prompt rules of another user's public template are theirs to keep; the
+	// reply prompts below do not quote them.
+	rulesRedacted := template.OwnerUserID != "" && template.OwnerUserID != userID
+	if rulesRedacted {
+		template.PromptRules = ""
+	}
+
+	opening := s.battleOpeningContent(plan.Topic, plan.Template, plan.ProStyle, plan.ConStyle)
+	post := prompts.Post{
+		Content:  opening,
+		RoomHint: prompts.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure},
+	}
+	replyTokens := estimateReplyTokens(plan.Template.WordLimit)
+	estimate := BattleDryRunEstimate{}
+	if plan.ContextPack {
+		// The pack is one more call before the first turn, over at most
+		// BattleContextPackMaxSources excerpts.
+		post.ContextPack = battleDryRunContextPackPlaceholder
+		estimate.LLMCalls++
+		estimate.PromptTokens += common.BattleContextPackMaxSources * common.BattleContextPackExcerptRunes / 4
+		estimate.CompletionTokens += estimateReplyTokens(common.BattleContextPackWordLimit)
+	}
+
+	turns := []BattleDryRunTurn{{Turn: 0, Author: battleDryRunAuthorOpening}}
+	skeletons := make([]BattleDryRunPrompt, 0, len(slots))
+	thread := make([]prompts.ReplyItem, 0, len(slots))
+	warnings := make([]string, 0)
+	turn := 0
+	for _, slot := range slots {
+		if slot.SkipReason != "" {
+			warnings = append(warnings, fmt.Sprintf("%s will not reply: %s", slot.Persona.Name, slot.SkipReason))
+			continue
+		}
+		turn++
+		turns = append(turns, BattleDryRunTurn{
+			Turn:        turn,
+			Author:      slot.Author,
+			PersonaID:   slot.Persona.ID,
+			PersonaName: slot.Persona.Name,
+		})
+
+		if slot.Author == battleDryRunAuthorPersona {
+			prompt, err := s.llm.Prompts().Reply(prompts.Persona{
+				Name:              slot.Persona.Name,
+				Bio:               slot.Persona.Bio,
+				Tone:              slot.Persona.Tone,
+				PreferredLanguage: plan.Language.TurnLanguage(slot.Persona.PreferredLanguage),
+				Style:             prompts.Style(slot.Persona.Style),
+			}, post, thread)
+			if err != nil {
+				writeInternalError(w, "could not render prompt")
+				return
+			}
+			skeletons = append(skeletons, BattleDryRunPrompt{
+				Turn:      turn,
+				PersonaID: slot.Persona.ID,
+				Operation: prompt.Operation,
+				Version:   prompt.Version,
+				System:    prompt.System,
+				User:      prompt.User,
+			})
+			estimate.LLMCalls++
+			estimate.PromptTokens += estimateTextTokens(prompt.System) + estimateTextTokens(prompt.User) + len(thread)*replyTokens
+			estimate.CompletionTokens += replyTokens
+		} else {
+			estimate.HumanTurns++
+		}
+		thread = append(thread, battleDryRunPlaceholder(turn, slot, plan.Template.WordLimit))
+	}
+
+	// Classic replies are queued together and wait for one worker poll;
+	// interactive AI turns are queued one at a time.
+	calls := time.Duration(estimate.LLMCalls)
+	switch {
+	case estimate.LLMCalls == 0:
+	case plan.Mode == battleModeInteractive:
+		estimate.MaxDurationSeconds = int((calls * (s.cfg.WorkerPollEvery + s.cfg.LLMRequestTimeout())).Seconds())
+	default:
+		estimate.MaxDurationSeconds = int((s.cfg.WorkerPollEvery + calls*s.cfg.LLMRequestTimeout()).Seconds())
+	}
+	if plan.ContextPack && estimate.MaxDurationSeconds > 0 {
+		estimate.MaxDurationSeconds += int(s.cfg.LLMRequestTimeout().Seconds())
+	}
+	if estimate.HumanTurns > 0 {
+		estimate.HumanTurnTimeoutSeconds = int(s.cfg.InteractiveTurnTimeout.Seconds())
+	}
+	if plan.Mode == battleModeClassic && estimate.LLMCalls < 2 {
+		warnings = append(warnings, fmt.Sprintf("only %d persona(s) would reply; battles need two sides", estimate.LLMCalls))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"dry_run":                        true,
+		"room_id":                        plan.Room.ID,
+		"room_name":                      plan.Room.Name,
+		"mode":                           plan.Mode,
+		"topic":                          plan.Topic,
+		"pro_style":                      plan.ProStyle,
+		"con_style":                      plan.ConStyle,
+		"language":                       plan.Language,
+		"template":                       template,
+		"template_prompt_rules_redacted": rulesRedacted,
+		"remix_used":                     plan.RemixUsed,
+		"context_pack":                   plan.ContextPack,
+		"opening":                        opening,
+		"turns":                          turns,
+		"prompts":                        skeletons,
+		"estimate":                       estimate,
+		"warnings":                       warnings,
+	})
+}
+
+// planBattleDryRunSlots lists the turns after the opening post. Classic
+// battles get one reply per picked persona; interactive battles alternate the
+// AI persona and the user, AI first, as startInteractiveBattle sets them up.
+func (s *Server) planBattleDryRunSlots(r *http.Request, userID string, plan battleCreatePlan) ([]battleDryRunSlot, error) {
+	if plan.Mode == battleModeInteractive {
+		persona, err := s.getPersonaByID(r.Context(), userID, plan.AIPersonaID)
+		if err != nil {
+			return nil, err
+		}
+		total := common.InteractiveTurnCount(plan.Template.TurnCount)
+		slots := make([]battleDryRunSlot, 0, total)
+		for i := 0; i < total; i++ {
+			if i%2 == 0 {
+				slots = append(slots, battleDryRunSlot{Author: battleDryRunAuthorPersona, Persona: persona})
+			} else {
+				slots = append(slots, battleDryRunSlot{Author: battleDryRunAuthorHuman})
+			}
+		}
+		return slots, nil
+	}
+
+	replies, err := s.planBattleReplies(r.Context(), userID, plan.Template)
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]battleDryRunSlot, 0, len(replies))
+	for _, reply := range replies {
+		slots = append(slots, battleDryRunSlot{
+			Author:     battleDryRunAuthorPersona,
+			Persona:    reply.Persona,
+			SkipReason: reply.SkipReason,
+		})
+	}
+	return slots, nil
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBattleDryRunEstimates(t *testing.T) {
+	if got := estimateTextTokens("12345678"); got != 2 {
+		t.Fatalf("expected 2 tokens for 8 characters, got %d", got)
+	}
+	if got := estimateTextTokens("ğüşiöç"); got != 2 {
+		t.Fatalf("expected tokens counted per rune, got %d", got)
+	}
+	if got := estimateReplyTokens(120); got != 160 {
+		t.Fatalf("expected 160 tokens for 120 words, got %d", got)
+	}
+}
+
+func TestBattleDryRunPlaceholder(t *testing.T) {
+	human := battleDryRunPlaceholder(2, battleDryRunSlot{Author: battleDryRunAuthorHuman}, 80)
+	if !human.Human || !strings.Contains(human.Content, "turn 2") || !strings.Contains(human.Content, "80 words") {
+		t.Fatalf("unexpected human placeholder %+v", human)
+	}
+
+	persona := battleDryRunPlaceholder(1, battleDryRunSlot{
+		Author:  battleDryRunAuthorPersona,
+		Persona: Persona{ID: "p1", Name: "Builder Bot"},
+	}, 80)
+	if persona.Human || !strings.Contains(persona.Content, "Builder Bot") {
+		t.Fatalf("unexpected persona placeholder %+v", persona)
+	}
+}
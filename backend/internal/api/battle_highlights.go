@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const battleHighlightExcerptRunes = 160
+
+// BattleHighlightTurn is one turn of a battle's highlight reel. Excerpt is
+// the card-sized cut of Content.
+type BattleHighlightTurn struct {
+	Turn        int     `json:"turn"`
+	ReplyID     string  `json:"reply_id"`
+	PersonaID   string  `json:"persona_id"`
+	PersonaName string  `json:"persona_name"`
+	Excerpt     string  `json:"excerpt"`
+	Content     string  `json:"content"`
+	Score       float64 `json:"score"`
+}
+
+type BattleHighlights struct {
+	BattleID    string                `json:"battle_id"`
+	Status      string                `json:"status"`
+	Summary     string                `json:"summary"`
+	Turns       []BattleHighlightTurn `json:"turns"`
+	GeneratedAt *time.Time            `json:"generated_at,omitempty"`
+}
+
+type storedBattleHighlight struct {
+	Turn    int     `json:"turn"`
+	ReplyID string  `json:"reply_id"`
+	Score   float64 `json:"score"`
+}
+
+// handleGetBattleHighlights serves the highlight reel the worker builds once
+// a battle is finished. Until then it answers status pending with no turns.
+func (s *Server) handleGetBattleHighlights(w http.ResponseWriter, r *http.Request) {
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+
+	out := BattleHighlights{BattleID: battleID, Status: "pending", Turns: []BattleHighlightTurn{}}
+	var (
+		raw         []byte
+		generatedAt time.Time
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT highlights, summary, created_at
+		FROM battle_highlights
+		WHERE post_id = $1
+	`, battleID).Scan(&raw, &out.Summary, &generatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusOK, out)
+			return
+		}
+		writeInternalError(w, "could not load highlights")
+		return
+	}
+	var stored []storedBattleHighlight
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		writeInternalError(w, "could not load highlights")
+		return
+	}
+
+	// Turns of archived battles keep their reply ids in battle_archives, so
+	// the reel is matched against the whole battle rather than replies.
+	battle, err := s.getBattleByID(r.Context(), battleID)
+	if err != nil {
+		writeInternalError(w, "could not load highlights")
+		return
+	}
+	replies := make(map[string]BattleHighlightTurn, len(battle.Turns))
+	for _, reply := range battle.Turns {
+		replies[reply.ID] = BattleHighlightTurn{
+			ReplyID:     reply.ID,
+			PersonaID:   reply.PersonaID,
+			PersonaName: reply.Persona,
+			Content:     reply.Content,
+		}
+	}
+
+	// Replies removed since the reel was built drop out of it.
+	for _, item := range stored {
+		turn, ok := replies[item.ReplyID]
+		if !ok {
+			continue
+		}
+		turn.Turn = item.Turn
+		turn.Score = item.Score
+		turn.Excerpt = common.TruncateRunes(cards.NormalizeText(turn.Content), battleHighlightExcerptRunes)
+		out.Turns = append(out.Turns, turn)
+	}
+	out.Status = "ready"
+	out.GeneratedAt = &generatedAt
+	writeJSON(w, http.StatusOK, out)
+}
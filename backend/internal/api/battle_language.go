@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/common"
+)
+
+// resolveNewBattleLanguage pins the language setting of a new battle. Side a
+// is the first persona the battle is generated for and side b the second; an
+// interactive battle only has the AI persona, so b falls back to its language.
+func (s *Server) resolveNewBattleLanguage(ctx context.Context, userID, mode string, sidePersonaIDs []string) (common.BattleLanguage, error) {
+	if mode != common.BattleLanguageA && mode != common.BattleLanguageB {
+		return common.ResolveBattleLanguage(mode, "", ""), nil
+	}
+
+	languages := make([]string, 2)
+	for i, personaID := range sidePersonaIDs {
+		if i >= len(languages) {
+			break
+		}
+		persona, err := s.getPersonaByID(ctx, userID, personaID)
+		if err != nil {
+			return common.BattleLanguage{}, err
+		}
+		languages[i] = persona.PreferredLanguage
+	}
+	return common.ResolveBattleLanguage(mode, languages[0], languages[1]), nil
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestBuildBattleCardVerdictFollowsBattleLanguage(t *testing.T) {
+	replies := []battleCardReply{{PersonaName: "Ada"}, {PersonaName: "Grace"}}
+
+	if got := buildBattleCardVerdict(replies, common.BattleLanguage{Mode: common.BattleLanguageAuto}); !strings.HasPrefix(got, "Verdict:") {
+		t.Fatalf("expected english verdict for auto battles, got %q", got)
+	}
+	if got := buildBattleCardVerdict(replies, common.BattleLanguage{Mode: common.BattleLanguageA, Language: "tr"}); !strings.HasPrefix(got, "Karar:") {
+		t.Fatalf("expected turkish verdict for a tr battle, got %q", got)
+	}
+	got := buildBattleCardVerdict(nil, common.BattleLanguage{Mode: common.BattleLanguageBilingual})
+	if !strings.HasPrefix(got, "Verdict: Start lean") || !strings.Contains(got, " / Karar: Sade başla") {
+		t.Fatalf("expected both verdicts for a bilingual battle, got %q", got)
+	}
+}
+
+func TestParseTurnMetadata(t *testing.T) {
+	language, bilingual := parseTurnMetadata([]byte(`{"language":"tr","bilingual":{"tr":"Merhaba","en":"Hello"}}`))
+	if language != "tr" || bilingual["tr"] != "Merhaba" || bilingual["en"] != "Hello" {
+		t.Fatalf("expected side-by-side renderings, got %q %v", language, bilingual)
+	}
+
+	for _, raw := range []string{``, `{}`, `not json`} {
+		language, bilingual := parseTurnMetadata([]byte(raw))
+		if language != "" || bilingual != nil {
+			t.Fatalf("expected plain turn for %q, got %q %v", raw, language, bilingual)
+		}
+	}
+}
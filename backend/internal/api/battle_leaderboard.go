@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	battleLeaderboardDefaultLimit = 20
+	battleLeaderboardMaxLimit     = 100
+
+	leaderboardWindowWeek  = "week"
+	leaderboardWindowMonth = "month"
+	leaderboardWindowAll   = "all"
+)
+
+// BattleLeaderboardEntry is one persona's record over the public battles
+// audiences voted on. A battle is won by the participant with the most votes
+// alone; participants sharing the top count tie and everyone else loses.
+type BattleLeaderboardEntry struct {
+	Rank        int    `json:"rank"`
+	PersonaID   string `json:"persona_id"`
+	PersonaName string `json:"persona_name"`
+	PublicSlug  string `json:"public_slug,omitempty"`
+	Wins        int    `json:"wins"`
+	Ties        int    `json:"ties"`
+	Losses      int    `json:"losses"`
+	Battles     int    `json:"battles"`
+	Votes       int    `json:"votes"`
+}
+
+// parseLeaderboardWindow returns the window name and the earliest battle
+// creation time it covers; the zero time means no bound.
+func parseLeaderboardWindow(raw string, now time.Time) (string, time.Time, error) {
+	switch window := strings.ToLower(strings.TrimSpace(raw)); window {
+	case leaderboardWindowWeek:
+		return window, now.AddDate(0, 0, -7), nil
+	case "", leaderboardWindowMonth:
+		return leaderboardWindowMonth, now.AddDate(0, 0, -30), nil
+	case leaderboardWindowAll:
+		return window, time.Time{}, nil
+	default:
+		return "", time.Time{}, fmt.Errorf("window must be one of %s, %s, %s", leaderboardWindowWeek, leaderboardWindowMonth, leaderboardWindowAll)
+	}
+}
+
+// rankBattleLeaderboard numbers entries already sorted by wins, then votes.
+// Entries level on both share a rank and the next rank skips past them.
+func rankBattleLeaderboard(entries []BattleLeaderboardEntry) {
+	for i := range entries {
+		if i > 0 && entries[i].Wins == entries[i-1].Wins && entries[i].Votes == entries[i-1].Votes {
+			entries[i].Rank = entries[i-1].Rank
+			continue
+		}
+		entries[i].Rank = i + 1
+	}
+}
+
+// handleGetBattleLeaderboard ranks personas by battles won through audience
+// votes. Only public battles with at least one vote count, and only personas
+// with a win are listed. room_id narrows it to one room.
+func (s *Server) handleGetBattleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	window, since, err := parseLeaderboardWindow(query.Get("window"), time.Now().UTC())
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	limit, err := parsePaginationLimit(query.Get("limit"), battleLeaderboardDefaultLimit, 1, battleLeaderboardMaxLimit)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	roomID := ""
+	if raw := strings.TrimSpace(query.Get("room_id")); raw != "" {
+		roomID, err = validateUUID(raw, "room_id")
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+
+	entries, err := s.listBattleLeaderboard(r.Context(), since, roomID, limit)
+	if err != nil {
+		writeInternalError(w, "could not load leaderboard")
+		return
+	}
+	rankBattleLeaderboard(entries)
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"window":  window,
+		"room_id": roomID,
+		"entries": entries,
+	})
+}
+
+func (s *Server) listBattleLeaderboard(ctx context.Context, since time.Time, roomID string, limit int) ([]BattleLeaderboardEntry, error) {
+	var sinceArg any
+	if !since.IsZero() {
+		sinceArg = since
+	}
+	rows, err := s.db.Query(ctx, `
+		WITH battles AS (
+			SELECT p.id
+			FROM posts p
+			WHERE p.template_id IS NOT NULL
+			  AND p.status = 'PUBLISHED'
+			  AND p.visibility = $1
+			  AND ($2::timestamptz IS NULL OR p.created_at >= $2)
+			  AND ($3 = '' OR p.room_id::text = $3)
+			  AND EXISTS (SELECT 1 FROM battle_votes v WHERE v.post_id = p.id)
+		),
+		participants AS (
+			SELECT b.id AS post_id, pt.persona_id
+			FROM battles b
+			CROSS JOIN LATERAL (`+battleParticipantsQuery("b.id")+`) pt
+		),
+		tallies AS (
+			SELECT v.post_id, v.persona_id, COUNT(*)::int AS votes
+			FROM battle_votes v
+			JOIN battles b ON b.id = v.post_id
+			GROUP BY v.post_id, v.persona_id
+		),
+		scored AS (
+			SELECT
+				pt.post_id,
+				pt.persona_id,
+				COALESCE(t.votes, 0) AS votes,
+				MAX(COALESCE(t.votes, 0)) OVER (PARTITION BY pt.post_id) AS top_votes
+			FROM participants pt
+			LEFT JOIN tallies t ON t.post_id = pt.post_id AND t.persona_id = pt.persona_id
+		),
+		placed AS (
+			SELECT
+				persona_id,
+				votes,
+				votes = top_votes AS leading,
+				COUNT(*) FILTER (WHERE votes = top_votes) OVER (PARTITION BY post_id) AS leaders
+			FROM scored
+		),
+		records AS (
+			SELECT
+				persona_id,
+				COUNT(*) FILTER (WHERE leading AND leaders = 1)::int AS wins,
+				COUNT(*) FILTER (WHERE leading AND leaders > 1)::int AS ties,
+				COUNT(*) FILTER (WHERE NOT leading)::int AS losses,
+				COUNT(*)::int AS battles,
+				SUM(votes)::int AS votes
+			FROM placed
+			GROUP BY persona_id
+		)
+		SELECT
+			pe.id::text,
+			pe.name,
+			COALESCE(pp.slug, ''),
+			rec.wins,
+			rec.ties,
+			rec.losses,
+			rec.battles,
+			rec.votes
+		FROM records rec
+		JOIN personas pe ON pe.id = rec.persona_id
+		LEFT JOIN persona_public_profiles pp ON pp.persona_id = pe.id AND pp.is_public = TRUE
+		WHERE rec.wins > 0
+		ORDER BY rec.wins DESC, rec.votes DESC, rec.battles ASC, pe.id ASC
+		LIMIT $4
+	`, battleVisibilityPublic, sinceArg, roomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]BattleLeaderboardEntry, 0, limit)
+	for rows.Next() {
+		var entry BattleLeaderboardEntry
+		if err := rows.Scan(
+			&entry.PersonaID,
+			&entry.PersonaName,
+			&entry.PublicSlug,
+			&entry.Wins,
+			&entry.Ties,
+			&entry.Losses,
+			&entry.Battles,
+			&entry.Votes,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
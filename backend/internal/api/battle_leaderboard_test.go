@@ -0,0 +1,48 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLeaderboardWindow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		raw        string
+		wantWindow string
+		wantSince  time.Time
+	}{
+		{raw: "", wantWindow: leaderboardWindowMonth, wantSince: now.AddDate(0, 0, -30)},
+		{raw: " Week ", wantWindow: leaderboardWindowWeek, wantSince: now.AddDate(0, 0, -7)},
+		{raw: "all", wantWindow: leaderboardWindowAll},
+	}
+	for _, tc := range cases {
+		window, since, err := parseLeaderboardWindow(tc.raw, now)
+		if err != nil {
+			t.Fatalf("parseLeaderboardWindow(%q) failed: %v", tc.raw, err)
+		}
+		if window != tc.wantWindow || !since.Equal(tc.wantSince) {
+			t.Fatalf("parseLeaderboardWindow(%q) = %q, %v; want %q, %v", tc.raw, window, since, tc.wantWindow, tc.wantSince)
+		}
+	}
+	if _, _, err := parseLeaderboardWindow("year", now); err == nil {
+		t.Fatalf("expected an unknown window to be rejected")
+	}
+}
+
+func TestRankBattleLeaderboard(t *testing.T) {
+	entries := []BattleLeaderboardEntry{
+		{PersonaID: "a", Wins: 5, Votes: 40},
+		{PersonaID: "b", Wins: 3, Votes: 12},
+		{PersonaID: "c", Wins: 3, Votes: 12},
+		{PersonaID: "d", Wins: 3, Votes: 9},
+	}
+	rankBattleLeaderboard(entries)
+
+	want := []int{1, 2, 2, 4}
+	for i, entry := range entries {
+		if entry.Rank != want[i] {
+			t.Fatalf("entry %s: rank %d, want %d", entry.PersonaID, entry.Rank, want[i])
+		}
+	}
+}
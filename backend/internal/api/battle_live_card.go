@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	battleLiveCardTurnRunes = 360
+	// battleLiveCardRetryAfter is how long clients should wait before asking
+	// again for a card that is still being rendered.
+	battleLiveCardRetryAfter = 10
+)
+
+// handleGetBattleLiveCard serves the animated card of a finished battle.
+// Until the worker has rendered it, and when it could not be rendered within
+// the size limit, the request is redirected to the static card so embeds
+// always get an image.
+func (s *Server) handleGetBattleLiveCard(w http.ResponseWriter, r *http.Request) {
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	token, err := s.authorizeBattleRead(r, battleID)
+	if err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+
+	var finished bool
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT EXISTS(SELECT 1 FROM battle_coaching WHERE post_id = $1)
+	`, battleID).Scan(&finished); err != nil {
+		writeInternalError(w, "could not load battle")
+		return
+	}
+	if !finished {
+		writeConflict(w, "live cards are available once the battle is finished")
+		return
+	}
+
+	card, err := s.loadBattleCardData(r.Context(), battleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not build battle card")
+		return
+	}
+
+	var (
+		status          string
+		payload         []byte
+		sourceUpdatedAt time.Time
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT status, gif, source_updated_at
+		FROM battle_live_cards
+		WHERE battle_id = $1
+	`, battleID).Scan(&status, &payload, &sourceUpdatedAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		writeInternalError(w, "could not load live card")
+		return
+	}
+	if err == nil && sourceUpdatedAt.Equal(card.UpdatedAt) {
+		switch status {
+		case "READY":
+			writeBattleLiveCardGIF(w, payload, fmt.Sprintf("%s-%d", battleID, sourceUpdatedAt.UTC().UnixNano()))
+		case "FAILED":
+			redirectToStaticBattleCard(w, r, battleID, token, false)
+		default:
+			redirectToStaticBattleCard(w, r, battleID, token, true)
+		}
+		return
+	}
+
+	// Never rendered, or the battle changed since: queue a fresh snapshot.
+	if err := s.queueBattleLiveCard(r.Context(), card); err != nil {
+		writeInternalError(w, "could not queue live card")
+		return
+	}
+	redirectToStaticBattleCard(w, r, battleID, token, true)
+}
+
+// queueBattleLiveCard stores the snapshot the worker renders, replacing any
+// earlier render of the battle.
+func (s *Server) queueBattleLiveCard(ctx context.Context, card battleCardData) error {
+	replies, err := s.listBattleCardReplies(ctx, card.BattleID)
+	if err != nil {
+		return err
+	}
+	live := cards.LiveCard{
+		RoomName:   card.RoomName,
+		Topic:      card.Topic,
+		ProPersona: card.ProPersona,
+		ConPersona: card.ConPersona,
+		Turns:      make([]cards.LiveTurn, 0, len(replies)),
+		Verdict:    card.Verdict,
+		URL:        card.URL,
+	}
+	for _, reply := range replies {
+		live.Turns = append(live.Turns, cards.LiveTurn{
+			Persona: reply.PersonaName,
+			Text:    common.TruncateRunes(cards.NormalizeText(reply.Content), battleLiveCardTurnRunes),
+		})
+	}
+	snapshot, err := json.Marshal(live)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO battle_live_cards(battle_id, status, source_updated_at, card)
+		VALUES ($1, 'PENDING', $2, $3::jsonb)
+		ON CONFLICT (battle_id)
+		DO UPDATE SET
+			status = 'PENDING',
+			source_updated_at = EXCLUDED.source_updated_at,
+			card = EXCLUDED.card,
+			gif = NULL,
+			frame_count = 0,
+			byte_size = 0,
+			error = '',
+			requested_at = NOW(),
+			rendered_at = NULL
+	`, card.BattleID, card.UpdatedAt, snapshot)
+	return err
+}
+
+func writeBattleLiveCardGIF(w http.ResponseWriter, payload []byte, cacheKey string) {
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("ETag", fmt.Sprintf(`"battle-live-card-%s"`, cacheKey))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(payload)
+}
+
+// redirectToStaticBattleCard sends the caller to the battle's PNG card,
+// passing on an unlisted access token. The redirect is not cached so the
+// animated card is picked up once it is ready.
+func redirectToStaticBattleCard(w http.ResponseWriter, r *http.Request, battleID, token string, pending bool) {
+	location := battleCardImagePath(battleID, assignBattleCardVariant(battleID))
+	if token != "" {
+		location = withUnlistedAccess(location, token)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if pending {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", battleLiveCardRetryAfter))
+	}
+	http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+}
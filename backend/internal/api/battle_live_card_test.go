@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectToStaticBattleCard(t *testing.T) {
+	battleID := "7f0c2a52-4a0e-4f0b-9a55-8d2a8a6b9d11"
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/b/"+battleID+"/card.gif", nil)
+	redirectToStaticBattleCard(recorder, request, battleID, "123.sig", true)
+
+	if recorder.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected 307, got %d", recorder.Code)
+	}
+	location := recorder.Header().Get("Location")
+	if !strings.HasPrefix(location, battleCardImagePath(battleID, assignBattleCardVariant(battleID))) || !strings.Contains(location, "&ua=123.sig") {
+		t.Fatalf("expected the static card with the access token, got %q", location)
+	}
+	if recorder.Header().Get("Cache-Control") != "no-store" || recorder.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected an uncached redirect with Retry-After, got %v", recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	redirectToStaticBattleCard(recorder, request, battleID, "", false)
+	if recorder.Header().Get("Retry-After") != "" || strings.Contains(recorder.Header().Get("Location"), "ua=") {
+		t.Fatalf("expected a plain redirect for a failed render, got %v", recorder.Header())
+	}
+}
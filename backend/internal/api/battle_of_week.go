@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const personaBattlesOfWeekListLimit = 10
+
+// PersonaBattleOfWeek is a featured battle the persona was picked for.
+type PersonaBattleOfWeek struct {
+	BattleID      string    `json:"battle_id"`
+	RoomID        string    `json:"room_id"`
+	RoomName      string    `json:"room_name"`
+	WeekStart     string    `json:"week_start"`
+	Side          string    `json:"side"`
+	OpponentID    string    `json:"opponent_persona_id,omitempty"`
+	OpponentName  string    `json:"opponent_persona_name,omitempty"`
+	Topic         string    `json:"topic"`
+	FeaturedSince time.Time `json:"featured_since"`
+}
+
+// listBattlesOfWeekForFeed returns this week's featured battles across rooms.
+// They are shown to every user, so the list is not personalized.
+func (s *Server) listBattlesOfWeekForFeed(ctx context.Context) ([]feedBattleCandidate, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH event_counts AS (
+			SELECT
+				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
+				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
+			FROM events e
+			WHERE e.created_at >= NOW() - INTERVAL '14 days'
+			  AND e.event_name IN ('battle_shared', 'remix_completed')
+			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '14 days'
+			GROUP BY pr.post_id
+		)
+		SELECT
+			p.id::text,
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			p.content,
+			p.created_at,
+			COALESCE(ec.shares, 0)::int,
+			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
+			COALESCE(p.template_id::text, ''),
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			)
+		FROM room_battles_of_week b
+		JOIN posts p ON p.id = b.post_id
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN templates t ON t.id = p.template_id
+		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
+		WHERE b.status = 'CREATED'
+		  AND b.created_at >= NOW() - INTERVAL '7 days'
+		  AND p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND rm.archived_at IS NULL
+		ORDER BY b.created_at DESC
+		LIMIT $1
+	`, feedBattleCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]feedBattleCandidate, 0)
+	for rows.Next() {
+		item := feedBattleCandidate{Featured: true}
+		if err := rows.Scan(
+			&item.BattleID,
+			&item.RoomID,
+			&item.RoomName,
+			&item.Content,
+			&item.CreatedAt,
+			&item.Shares,
+			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
+			&item.TemplateID,
+			&item.TemplateName,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *Server) handleGetPersonaBattleOfWeek(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var optIn bool
+	err = s.db.QueryRow(r.Context(), `
+		SELECT battle_of_week_opt_in
+		FROM personas
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID).Scan(&optIn)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			p.id::text,
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			b.week_start::text,
+			CASE WHEN b.pro_persona_id = $1 THEN 'pro' ELSE 'con' END,
+			COALESCE(op.id::text, ''),
+			COALESCE(op.name, ''),
+			p.content,
+			b.created_at
+		FROM room_battles_of_week b
+		JOIN posts p ON p.id = b.post_id
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN personas op
+			ON op.id = CASE WHEN b.pro_persona_id = $1 THEN b.con_persona_id ELSE b.pro_persona_id END
+		WHERE b.status = 'CREATED'
+		  AND (b.pro_persona_id = $1 OR b.con_persona_id = $1)
+		ORDER BY b.created_at DESC
+		LIMIT $2
+	`, personaID, personaBattlesOfWeekListLimit)
+	if err != nil {
+		writeInternalError(w, "could not load battles of the week")
+		return
+	}
+	defer rows.Close()
+
+	battles := make([]PersonaBattleOfWeek, 0)
+	for rows.Next() {
+		var (
+			battle  PersonaBattleOfWeek
+			content string
+		)
+		if err := rows.Scan(&battle.BattleID, &battle.RoomID, &battle.RoomName, &battle.WeekStart, &battle.Side, &battle.OpponentID, &battle.OpponentName, &content, &battle.FeaturedSince); err != nil {
+			writeInternalError(w, "could not read battles of the week")
+			return
+		}
+		battle.Topic = buildBattleCardTopic(content, battle.RoomName)
+		battles = append(battles, battle)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not read battles of the week")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"opt_in":  optIn,
+		"battles": battles,
+	})
+}
+
+// handleUpdateBattleOfWeekOptIn records the owner's consent for the persona to
+// be picked for featured battles. Only public personas are ever picked.
+func (s *Server) handleUpdateBattleOfWeekOptIn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if req.Enabled == nil {
+		writeBadRequest(w, "enabled is required")
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		UPDATE personas
+		SET battle_of_week_opt_in = $3,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID, *req.Enabled)
+	if err != nil {
+		writeInternalError(w, "could not update battle of the week opt-in")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeNotFound(w, "persona not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"opt_in": *req.Enabled,
+	})
+}
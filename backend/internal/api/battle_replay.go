@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// replayTypingCharsPerSecond is the typing speed pacing hints assume.
+	replayTypingCharsPerSecond = 40
+	replayMinTypingMS          = 600
+	replayMaxTypingMS          = 6000
+	// Real gaps between turns can be minutes of queueing; playback squeezes
+	// them into a short pause that still keeps their relative order.
+	replayMinPauseMS = 500
+	replayMaxPauseMS = 3000
+)
+
+type BattleReplayTurn struct {
+	Index       int               `json:"index"`
+	ReplyID     string            `json:"reply_id"`
+	PersonaID   string            `json:"persona_id,omitempty"`
+	PersonaName string            `json:"persona_name,omitempty"`
+	AuthoredBy  string            `json:"authored_by"`
+	Content     string            `json:"content"`
+	Language    string            `json:"language,omitempty"`
+	Bilingual   map[string]string `json:"bilingual,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	// OffsetMS is when the turn appeared, relative to the battle start.
+	OffsetMS int64 `json:"offset_ms"`
+	// GenerationStartedOffsetMS and GenerationMS are only set for turns
+	// whose generation timing was recorded.
+	GenerationStartedOffsetMS *int64 `json:"generation_started_offset_ms,omitempty"`
+	GenerationMS              *int64 `json:"generation_ms,omitempty"`
+	TimingRecorded            bool   `json:"timing_recorded"`
+	// Pacing hints for animating the replay: wait PauseMS after the previous
+	// turn finished, then reveal the turn over TypingMS.
+	PauseMS         int64 `json:"pause_ms"`
+	TypingMS        int64 `json:"typing_ms"`
+	PlaybackStartMS int64 `json:"playback_start_ms"`
+	PlaybackEndMS   int64 `json:"playback_end_ms"`
+}
+
+type BattleReplay struct {
+	BattleID   string             `json:"battle_id"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMS int64              `json:"duration_ms"`
+	PlaybackMS int64              `json:"playback_ms"`
+	Turns      []BattleReplayTurn `json:"turns"`
+	Archive    *BattleArchive     `json:"archive,omitempty"`
+}
+
+func (s *Server) handleGetBattleReplay(w http.ResponseWriter, r *http.Request) {
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+
+	var startedAt time.Time
+	err = s.db.QueryRow(r.Context(), `
+		SELECT COALESCE(published_at, created_at)
+		FROM posts
+		WHERE id = $1
+		  AND status = 'PUBLISHED'
+	`, battleID).Scan(&startedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not load battle")
+		return
+	}
+
+	battle, err := s.getBattleByID(r.Context(), battleID)
+	if err != nil {
+		writeInternalError(w, "could not load battle")
+		return
+	}
+
+	replay := buildBattleReplay(battleID, startedAt, battle.Turns)
+	replay.Archive = battle.Archive
+	writeJSON(w, http.StatusOK, replay)
+}
+
+// buildBattleReplay lays turns out on the battle's real timeline and derives
+// a compressed playback schedule from it. Offsets never go backwards, so
+// clock skew between writers cannot reorder the replay.
+func buildBattleReplay(battleID string, startedAt time.Time, turns []Reply) BattleReplay {
+	replay := BattleReplay{
+		BattleID:  battleID,
+		StartedAt: startedAt,
+		Turns:     make([]BattleReplayTurn, 0, len(turns)),
+	}
+
+	var previousOffset, playbackCursor int64
+	for i, turn := range turns {
+		offset := max(turn.CreatedAt.Sub(startedAt).Milliseconds(), previousOffset)
+		item := BattleReplayTurn{
+			Index:       i + 1,
+			ReplyID:     turn.ID,
+			PersonaID:   turn.PersonaID,
+			PersonaName: turn.Persona,
+			AuthoredBy:  turn.AuthoredBy,
+			Content:     turn.Content,
+			Language:    turn.Language,
+			Bilingual:   turn.Bilingual,
+			CreatedAt:   turn.CreatedAt,
+			OffsetMS:    offset,
+			TypingMS:    replayTypingMS(turn.Content),
+		}
+
+		gap := offset - previousOffset
+		if turn.hasTiming {
+			generationMS := turn.timing.GenerationMS
+			started := min(max(turn.timing.StartedAt.Sub(startedAt).Milliseconds(), previousOffset), offset)
+			item.GenerationMS = &generationMS
+			item.GenerationStartedOffsetMS = &started
+			item.TimingRecorded = true
+			gap = started - previousOffset
+		}
+		item.PauseMS = min(max(gap, replayMinPauseMS), replayMaxPauseMS)
+		if i == 0 {
+			item.PauseMS = 0
+		}
+		item.PlaybackStartMS = playbackCursor + item.PauseMS
+		item.PlaybackEndMS = item.PlaybackStartMS + item.TypingMS
+
+		replay.Turns = append(replay.Turns, item)
+		previousOffset = offset
+		playbackCursor = item.PlaybackEndMS
+	}
+
+	replay.DurationMS = previousOffset
+	replay.PlaybackMS = playbackCursor
+	return replay
+}
+
+// replayTypingMS is how long revealing content should take at a steady
+// typing speed, clamped so one-liners still register and long turns do not
+// stall the replay.
+func replayTypingMS(content string) int64 {
+	ms := int64(len([]rune(content))) * 1000 / replayTypingCharsPerSecond
+	return min(max(ms, replayMinTypingMS), replayMaxTypingMS)
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestBuildBattleReplay(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	turns := []Reply{
+		{
+			ID:        "r1",
+			Content:   "short",
+			CreatedAt: start.Add(10 * time.Second),
+			timing:    common.TurnTiming{StartedAt: start.Add(7 * time.Second), GenerationMS: 2500},
+			hasTiming: true,
+		},
+		{
+			ID:        "r2",
+			Content:   strings.Repeat("a", 120),
+			CreatedAt: start.Add(10*time.Minute + time.Second),
+			timing:    common.TurnTiming{StartedAt: start.Add(10 * time.Minute), GenerationMS: 900},
+			hasTiming: true,
+		},
+		// A human turn stamped before the previous one must not rewind time.
+		{ID: "r3", AuthoredBy: "HUMAN", Content: strings.Repeat("b", 1000), CreatedAt: start.Add(5 * time.Minute)},
+	}
+
+	replay := buildBattleReplay("battle", start, turns)
+	if len(replay.Turns) != 3 {
+		t.Fatalf("expected 3 turns, got %d", len(replay.Turns))
+	}
+
+	first := replay.Turns[0]
+	if first.Index != 1 || first.OffsetMS != 10000 || *first.GenerationStartedOffsetMS != 7000 || *first.GenerationMS != 2500 {
+		t.Fatalf("unexpected first turn %+v", first)
+	}
+	if first.PauseMS != 0 || first.TypingMS != replayMinTypingMS || first.PlaybackEndMS != replayMinTypingMS {
+		t.Fatalf("expected first turn to start immediately with minimum typing, got %+v", first)
+	}
+
+	second := replay.Turns[1]
+	if second.PauseMS != replayMaxPauseMS || second.TypingMS != 3000 {
+		t.Fatalf("expected long gap to be compressed, got %+v", second)
+	}
+	if second.PlaybackStartMS != first.PlaybackEndMS+replayMaxPauseMS {
+		t.Fatalf("expected playback to follow the previous turn, got %+v", second)
+	}
+
+	third := replay.Turns[2]
+	if third.OffsetMS != second.OffsetMS || third.TimingRecorded || third.GenerationMS != nil {
+		t.Fatalf("expected untimed turn clamped to the previous offset, got %+v", third)
+	}
+	if third.PauseMS != replayMinPauseMS || third.TypingMS != replayMaxTypingMS {
+		t.Fatalf("expected pacing bounds for an untimed long turn, got %+v", third)
+	}
+	if replay.DurationMS != second.OffsetMS || replay.PlaybackMS != third.PlaybackEndMS {
+		t.Fatalf("unexpected totals %+v", replay)
+	}
+}
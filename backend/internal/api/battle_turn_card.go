@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/image/font/basicfont"
+)
+
+const battleTurnQuoteMaxRunes = 280
+
+var errBattleTurnNotFound = errors.New("battle turn not found")
+
+type battleTurnCardData struct {
+	BattleID    string
+	RoomName    string
+	Topic       string
+	Index       int
+	Total       int
+	PersonaName string
+	Quote       string
+	URL         string
+	UpdatedAt   time.Time
+}
+
+type PublicBattleTurnDTO struct {
+	Index       int    `json:"index"`
+	Total       int    `json:"total"`
+	PersonaName string `json:"persona_name"`
+	Quote       string `json:"quote"`
+	CardURL     string `json:"card_url"`
+}
+
+func parseBattleTurnIndex(raw string) (int, error) {
+	clean := strings.TrimSpace(raw)
+	index, err := strconv.Atoi(clean)
+	if err != nil || index < 1 {
+		return 0, fmt.Errorf("turn index must be a positive integer")
+	}
+	return index, nil
+}
+
+func battleTurnShareURL(origin, battleID string, index int) string {
+	return fmt.Sprintf("%s/b/%s?t=%d", strings.TrimRight(origin, "/"), battleID, index)
+}
+
+func battleTurnCardPath(battleID string, index int) string {
+	return fmt.Sprintf("/b/%s/turns/%d/card.png", battleID, index)
+}
+
+func (s *Server) handleGetBattleTurnCardImage(w http.ResponseWriter, r *http.Request) {
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	index, err := parseBattleTurnIndex(chi.URLParam(r, "index"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+
+	card, err := s.loadBattleTurnCardData(r.Context(), battleID, index)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		if errors.Is(err, errBattleTurnNotFound) {
+			writeNotFound(w, "turn not found")
+			return
+		}
+		writeInternalError(w, "could not build battle card")
+		return
+	}
+
+	cacheKey := fmt.Sprintf("%s-t%d|%d", card.BattleID, card.Index, card.UpdatedAt.UTC().UnixNano())
+	if cached, ok := s.battleCardCache.get(cacheKey); ok {
+		writeBattleCardPNG(w, cached, cacheKey)
+		return
+	}
+
+	imageBytes, err := renderBattleTurnCardPNG(card)
+	if err != nil {
+		writeInternalError(w, "could not render battle card")
+		return
+	}
+
+	s.battleCardCache.set(cacheKey, imageBytes)
+	writeBattleCardPNG(w, imageBytes, cacheKey)
+}
+
+func (s *Server) loadBattleTurnCardData(ctx context.Context, battleID string, index int) (battleTurnCardData, error) {
+	var (
+		data        battleTurnCardData
+		postContent string
+	)
+
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			p.id::text,
+			COALESCE(rm.name, ''),
+			p.content
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.id = $1
+		  AND p.status = 'PUBLISHED'
+	`, battleID).Scan(&data.BattleID, &data.RoomName, &postContent)
+	if err != nil {
+		return battleTurnCardData{}, err
+	}
+
+	replies, err := s.listBattleCardReplies(ctx, battleID)
+	if err != nil {
+		return battleTurnCardData{}, err
+	}
+	if index < 1 || index > len(replies) {
+		return battleTurnCardData{}, errBattleTurnNotFound
+	}
+
+	turn := replies[index-1]
+	data.Topic = buildBattleCardTopic(postContent, data.RoomName)
+	data.Index = index
+	data.Total = len(replies)
+	data.PersonaName = common.TruncateRunes(strings.TrimSpace(turn.PersonaName), 36)
+	if data.PersonaName == "" {
+		data.PersonaName = "Community"
+	}
+	data.Quote = common.TruncateRunes(cards.NormalizeText(turn.Content), battleTurnQuoteMaxRunes)
+	data.URL = battleTurnShareURL(s.cfg.FrontendOrigin, data.BattleID, index)
+	data.UpdatedAt = turn.UpdatedAt
+	return data, nil
+}
+
+func renderBattleTurnCardPNG(data battleTurnCardData) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, battleCardWidth, battleCardHeight))
+
+	background := color.RGBA{R: 15, G: 23, B: 42, A: 255}
+	panel := color.RGBA{R: 248, G: 250, B: 252, A: 255}
+	ink := color.RGBA{R: 15, G: 23, B: 42, A: 255}
+	inkMuted := color.RGBA{R: 71, G: 85, B: 105, A: 255}
+	accent := color.RGBA{R: 59, G: 130, B: 246, A: 255}
+	accentSoft := color.RGBA{R: 219, G: 234, B: 254, A: 255}
+
+	cards.FillRect(canvas, canvas.Bounds(), background)
+
+	quoteRect := image.Rect(24, 24, battleCardWidth-24, 440)
+	barRect := image.Rect(quoteRect.Min.X, quoteRect.Min.Y, quoteRect.Min.X+8, quoteRect.Max.Y)
+	footerRect := image.Rect(24, 456, battleCardWidth-24, battleCardHeight-24)
+
+	cards.FillRect(canvas, quoteRect, panel)
+	cards.FillRect(canvas, barRect, accent)
+	cards.FillRect(canvas, footerRect, accentSoft)
+	cards.StrokeRect(canvas, quoteRect, accent)
+	cards.StrokeRect(canvas, footerRect, accent)
+
+	face := basicfont.Face7x13
+	lineHeight := 18
+	textX := 56
+	textWidth := quoteRect.Max.X - textX - 32
+
+	cards.DrawLabel(canvas, face, textX, 56, fmt.Sprintf("TURN %d OF %d", data.Index, data.Total), accent)
+	cards.DrawWrappedText(canvas, face, textX, 82, textWidth, lineHeight, 2, data.Topic, inkMuted)
+
+	quoteY := cards.DrawWrappedText(canvas, face, textX, 150, textWidth, lineHeight+4, 11, "\""+data.Quote+"\"", ink)
+	cards.DrawText(canvas, face, textX, quoteY+12, "- "+data.PersonaName, accent)
+
+	cards.DrawLabel(canvas, face, 40, 478, "SHARE LINK", accent)
+	linkDisplay := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(data.URL), "https://"), "http://")
+	cards.DrawWrappedText(canvas, face, 40, 504, footerRect.Dx()-32, lineHeight, 2, linkDisplay, inkMuted)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseBattleTurnIndex(t *testing.T) {
+	for _, raw := range []string{"1", " 3 ", "12"} {
+		if _, err := parseBattleTurnIndex(raw); err != nil {
+			t.Fatalf("expected %q to parse, got %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"", "0", "-1", "two", "1.5"} {
+		if _, err := parseBattleTurnIndex(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestBattleTurnShareURL(t *testing.T) {
+	got := battleTurnShareURL("https://personaworlds.test/", "abc", 2)
+	if got != "https://personaworlds.test/b/abc?t=2" {
+		t.Fatalf("unexpected share url %q", got)
+	}
+}
+
+func TestRenderBattleTurnCardPNG(t *testing.T) {
+	payload, err := renderBattleTurnCardPNG(battleTurnCardData{
+		BattleID:    "battle-1",
+		Topic:       "Should we ship weekly?",
+		Index:       2,
+		Total:       4,
+		PersonaName: "Skeptic",
+		Quote:       "Weekly shipping only works when every release has a rollback plan.",
+		URL:         "https://personaworlds.test/b/battle-1?t=2",
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	pngSignature := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	if !bytes.HasPrefix(payload, pngSignature) {
+		t.Fatalf("turn card is not a valid png")
+	}
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// requestedTranslationLanguage is the language a public page should be
+// served in: an explicit ?lang= wins, then the best supported language of
+// the Accept-Language header. Empty means the original.
+func requestedTranslationLanguage(r *http.Request) (string, error) {
+	if raw := r.URL.Query().Get("lang"); strings.TrimSpace(raw) != "" {
+		return validateTranslationLanguage(raw)
+	}
+	return parseAcceptLanguage(r.Header.Get("Accept-Language")), nil
+}
+
+// parseAcceptLanguage picks the supported language with the highest quality
+// from an Accept-Language header, keeping header order on ties. Regional
+// tags count for their base language, so tr-TR means tr.
+func parseAcceptLanguage(header string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		language, err := validateTranslationLanguage(base)
+		if err != nil || language == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > bestQuality {
+			best, bestQuality = language, quality
+		}
+	}
+	return best
+}
+
+// battleVerdictSourceLanguage is the language the share-page verdict is
+// written in. Bilingual battles already show both languages and are never
+// translated, so they get none.
+func battleVerdictSourceLanguage(language common.BattleLanguage) string {
+	switch language.Mode {
+	case common.BattleLanguageBilingual:
+		return ""
+	case common.BattleLanguageA, common.BattleLanguageB:
+		if language.Language == safety.LanguageTR {
+			return safety.LanguageTR
+		}
+	}
+	return safety.LanguageEN
+}
+
+// battleVerdictSourceHash fingerprints the original verdict and takeaways a
+// cached translation was made from.
+func battleVerdictSourceHash(verdict string, takeaways []string) string {
+	sum := sha256.New()
+	sum.Write([]byte(verdict))
+	for _, takeaway := range takeaways {
+		sum.Write([]byte{0})
+		sum.Write([]byte(takeaway))
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// publicBattleVerdict builds the share-page verdict in targetLanguage. A
+// translation that fails is logged and the original served instead, so a
+// share link never breaks on the LLM.
+func (s *Server) publicBattleVerdict(ctx context.Context, card battleCardData, targetLanguage string) *PublicBattleVerdictDTO {
+	sourceLanguage := battleVerdictSourceLanguage(card.Language)
+	out := &PublicBattleVerdictDTO{
+		Verdict:        card.Verdict,
+		Takeaways:      card.Takeaways,
+		TakeawayTurns:  card.TakeawayTurns,
+		Language:       sourceLanguage,
+		SourceLanguage: sourceLanguage,
+	}
+	if sourceLanguage == "" || targetLanguage == "" || targetLanguage == sourceLanguage {
+		return out
+	}
+
+	verdict, takeaways, err := s.translatedBattleVerdict(ctx, card, sourceLanguage, targetLanguage)
+	if err != nil {
+		s.logger.Warn("battle_verdict_translation_failed", observability.Fields{
+			"battle_id": card.BattleID,
+			"language":  targetLanguage,
+			"error":     err.Error(),
+		})
+		return out
+	}
+	out.Original = &PublicBattleVerdictOriginalDTO{Verdict: card.Verdict, Takeaways: card.Takeaways}
+	out.Verdict = verdict
+	out.Takeaways = takeaways
+	out.Language = targetLanguage
+	out.Translated = true
+	return out
+}
+
+// translatedBattleVerdict serves the cached translation while it still
+// matches the original text and otherwise translates the verdict and each
+// takeaway once and stores them next to the original's language.
+func (s *Server) translatedBattleVerdict(ctx context.Context, card battleCardData, sourceLanguage, targetLanguage string) (string, []string, error) {
+	sourceHash := battleVerdictSourceHash(card.Verdict, card.Takeaways)
+
+	var (
+		cachedVerdict   string
+		cachedTakeaways []byte
+	)
+	err := s.db.QueryRow(ctx, `
+		SELECT verdict, takeaways
+		FROM battle_verdict_translations
+		WHERE post_id = $1
+		  AND language = $2
+		  AND source_hash = $3
+	`, card.BattleID, targetLanguage, sourceHash).Scan(&cachedVerdict, &cachedTakeaways)
+	if err == nil {
+		takeaways := make([]string, 0, len(card.Takeaways))
+		if err := json.Unmarshal(cachedTakeaways, &takeaways); err == nil {
+			return cachedVerdict, takeaways, nil
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return "", nil, err
+	}
+
+	s.syncPrompts(ctx)
+	promptVersion := s.llm.Prompts().Active(prompts.OpPostTranslation)
+	rules := s.loadSafetyRules(ctx).ForLanguage(targetLanguage)
+	translate := func(text string) (string, error) {
+		translated, err := s.llm.TranslatePost(ctx, ai.PostContext{ID: card.BattleID, Content: text}, sourceLanguage, targetLanguage)
+		if err != nil {
+			return "", err
+		}
+		translated = strings.TrimSpace(translated)
+		if err := rules.Validate(translated, s.cfg.DraftMaxLen); err != nil {
+			return "", err
+		}
+		return translated, nil
+	}
+
+	verdict, err := translate(card.Verdict)
+	if err != nil {
+		return "", nil, err
+	}
+	takeaways := make([]string, 0, len(card.Takeaways))
+	for _, takeaway := range card.Takeaways {
+		translated, err := translate(takeaway)
+		if err != nil {
+			return "", nil, err
+		}
+		takeaways = append(takeaways, translated)
+	}
+	takeawaysJSON, err := json.Marshal(takeaways)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := s.db.Exec(ctx, `
+		INSERT INTO battle_verdict_translations(post_id, language, source_language, source_hash, verdict, takeaways, prompt_version)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7)
+		ON CONFLICT (post_id, language) DO UPDATE
+		SET source_language = EXCLUDED.source_language,
+			source_hash = EXCLUDED.source_hash,
+			verdict = EXCLUDED.verdict,
+			takeaways = EXCLUDED.takeaways,
+			prompt_version = EXCLUDED.prompt_version,
+			created_at = NOW()
+	`, card.BattleID, targetLanguage, sourceLanguage, sourceHash, verdict, takeawaysJSON, promptVersion); err != nil {
+		return "", nil, err
+	}
+	return verdict, takeaways, nil
+}
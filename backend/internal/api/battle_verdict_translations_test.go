@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                        "",
+		"tr-TR,tr;q=0.9,en;q=0.8": "tr",
+		"en-US,en;q=0.9":          "en",
+		"de-DE,en;q=0.5,tr;q=0.7": "tr",
+		"de, fr;q=0.8":            "",
+		"tr;q=0, en;q=0.1":        "en",
+		"en;q=0.8, tr;q=0.8":      "en",
+		"tr;q=bogus, en;q=0.3":    "en",
+		"  TR  ":                  "tr",
+	}
+	for header, want := range cases {
+		if got := parseAcceptLanguage(header); got != want {
+			t.Fatalf("parseAcceptLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestRequestedTranslationLanguagePrefersQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/b/x/meta?lang=en", nil)
+	req.Header.Set("Accept-Language", "tr-TR")
+	if got, err := requestedTranslationLanguage(req); err != nil || got != "en" {
+		t.Fatalf("expected ?lang= to win, got %q (%v)", got, err)
+	}
+
+	req = httptest.NewRequest("GET", "/b/x/meta", nil)
+	req.Header.Set("Accept-Language", "tr-TR,tr;q=0.9")
+	if got, err := requestedTranslationLanguage(req); err != nil || got != "tr" {
+		t.Fatalf("expected tr from Accept-Language, got %q (%v)", got, err)
+	}
+
+	req = httptest.NewRequest("GET", "/b/x/meta?lang=de", nil)
+	if _, err := requestedTranslationLanguage(req); err == nil {
+		t.Fatalf("expected unsupported ?lang= to fail")
+	}
+}
+
+func TestBattleVerdictSourceLanguage(t *testing.T) {
+	if got := battleVerdictSourceLanguage(common.BattleLanguage{Mode: common.BattleLanguageA, Language: "tr"}); got != "tr" {
+		t.Fatalf("expected tr, got %q", got)
+	}
+	if got := battleVerdictSourceLanguage(common.BattleLanguage{Mode: common.BattleLanguageAuto}); got != "en" {
+		t.Fatalf("expected auto battles to have an English verdict, got %q", got)
+	}
+	if got := battleVerdictSourceLanguage(common.BattleLanguage{Mode: common.BattleLanguageBilingual}); got != "" {
+		t.Fatalf("expected bilingual battles not to be translated, got %q", got)
+	}
+}
+
+func TestBattleVerdictSourceHashChangesWithTakeaways(t *testing.T) {
+	a := battleVerdictSourceHash("Karar: x", []string{"bir", "iki"})
+	if a != battleVerdictSourceHash("Karar: x", []string{"bir", "iki"}) {
+		t.Fatalf("expected hash to be stable")
+	}
+	if a == battleVerdictSourceHash("Karar: x", []string{"biri", "ki"}) {
+		t.Fatalf("expected takeaway boundaries to change the hash")
+	}
+}
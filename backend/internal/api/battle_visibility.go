@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	battleVisibilityPublic   = "public"
+	battleVisibilityUnlisted = "unlisted"
+
+	// unlistedAccessQueryName carries the signed access token on links to
+	// unlisted battles, their meta and their card images.
+	unlistedAccessQueryName = "ua"
+	unlistedLinkMaxTTL      = 365 * 24 * time.Hour
+)
+
+var (
+	errUnlistedAccessInvalid = errors.New("invalid unlisted access token")
+	errUnlistedAccessExpired = errors.New("unlisted access token expired")
+)
+
+type battleVisibilityRequest struct {
+	Visibility string `json:"visibility"`
+	ExpiresAt  string `json:"expires_at"`
+	Rotate     bool   `json:"rotate"`
+}
+
+func newUnlistedKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func signUnlistedAccess(secret, battleID, key string, expiresUnix int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unlisted_battle\x00"))
+	mac.Write([]byte(battleID + ":" + key + ":" + strconv.FormatInt(expiresUnix, 10)))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// createUnlistedAccessToken signs access to one unlisted battle. A zero
+// expiresAt issues a link that works until the battle's key is rotated.
+func createUnlistedAccessToken(secret, battleID, key string, expiresAt time.Time) string {
+	var expiresUnix int64
+	if !expiresAt.IsZero() {
+		expiresUnix = expiresAt.Unix()
+	}
+	return strconv.FormatInt(expiresUnix, 10) + "." + signUnlistedAccess(secret, battleID, key, expiresUnix)
+}
+
+func verifyUnlistedAccessToken(secret, battleID, key, raw string, now time.Time) error {
+	rawExpires, signature, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok || key == "" {
+		return errUnlistedAccessInvalid
+	}
+	expiresUnix, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil || expiresUnix < 0 {
+		return errUnlistedAccessInvalid
+	}
+	expected := signUnlistedAccess(secret, battleID, key, expiresUnix)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errUnlistedAccessInvalid
+	}
+	if expiresUnix > 0 && !now.Before(time.Unix(expiresUnix, 0)) {
+		return errUnlistedAccessExpired
+	}
+	return nil
+}
+
+// withUnlistedAccess appends the access token to a battle, meta or card URL.
+func withUnlistedAccess(rawURL, token string) string {
+	if token == "" {
+		return rawURL
+	}
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + unlistedAccessQueryName + "=" + url.QueryEscape(token)
+}
+
+// authorizeBattleRead checks that the request may read a published battle.
+// Public battles are open to everyone; unlisted ones need a valid access
+// token or the owner's session. It returns the token to pass on to card and
+// share URLs, and pgx.ErrNoRows when the battle is missing or the token does
+// not match, so unlisted battles cannot be probed for.
+func (s *Server) authorizeBattleRead(r *http.Request, battleID string) (string, error) {
+	var visibility, ownerUserID, key string
+	err := s.db.QueryRow(r.Context(), `
+		SELECT visibility, user_id::text, COALESCE(unlisted_key, '')
+		FROM posts
+		WHERE id = $1
+		  AND status = 'PUBLISHED'
+	`, battleID).Scan(&visibility, &ownerUserID, &key)
+	if err != nil {
+		return "", err
+	}
+	if visibility != battleVisibilityUnlisted {
+		return "", nil
+	}
+
+	token := strings.TrimSpace(r.URL.Query().Get(unlistedAccessQueryName))
+	if token != "" {
+		if err := verifyUnlistedAccessToken(s.cfg.JWTSecret, battleID, key, token, time.Now()); err != nil {
+			if errors.Is(err, errUnlistedAccessExpired) {
+				return "", err
+			}
+			return "", pgx.ErrNoRows
+		}
+		return token, nil
+	}
+	if userID, ok := s.optionalUserIDFromRequest(r); ok && userID == ownerUserID {
+		return "", nil
+	}
+	return "", pgx.ErrNoRows
+}
+
+// writeBattleReadError answers an authorizeBattleRead failure.
+func writeBattleReadError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		writeNotFound(w, "battle not found")
+	case errors.Is(err, errUnlistedAccessExpired):
+		writeError(w, http.StatusGone, "share link expired")
+	default:
+		writeInternalError(w, "could not load battle")
+	}
+}
+
+func (r *battleVisibilityRequest) normalize(now time.Time) (time.Time, error) {
+	r.Visibility = strings.ToLower(strings.TrimSpace(r.Visibility))
+	if r.Visibility != battleVisibilityPublic && r.Visibility != battleVisibilityUnlisted {
+		return time.Time{}, errors.New("visibility must be public or unlisted")
+	}
+	rawExpires := strings.TrimSpace(r.ExpiresAt)
+	if rawExpires == "" {
+		return time.Time{}, nil
+	}
+	if r.Visibility != battleVisibilityUnlisted {
+		return time.Time{}, errors.New("expires_at only applies to unlisted battles")
+	}
+	expiresAt, err := time.Parse(time.RFC3339, rawExpires)
+	if err != nil {
+		return time.Time{}, errors.New("expires_at must be an RFC3339 timestamp")
+	}
+	if !expiresAt.After(now) {
+		return time.Time{}, errors.New("expires_at must be in the future")
+	}
+	if expiresAt.After(now.Add(unlistedLinkMaxTTL)) {
+		return time.Time{}, errors.New("expires_at can be at most 365 days away")
+	}
+	return expiresAt.UTC(), nil
+}
+
+// handleUpdateBattleVisibility lets the owner make a battle unlisted or
+// public again. Unlisted responses carry a signed share URL; calling it again
+// issues another link, and rotate revokes every link issued before.
+func (s *Server) handleUpdateBattleVisibility(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req battleVisibilityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	now := time.Now().UTC()
+	expiresAt, err := req.normalize(now)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	key, err := s.setBattleVisibility(r.Context(), userID, battleID, req.Visibility, req.Rotate)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not update battle visibility")
+		return
+	}
+	s.battleCardCache.invalidate(battleID)
+
+	s.logger.Info("battle_visibility_changed", observability.Fields{
+		"battle_id":  battleID,
+		"visibility": req.Visibility,
+		"rotated":    req.Rotate,
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+
+	response := map[string]any{
+		"battle_id":  battleID,
+		"visibility": req.Visibility,
+	}
+	if req.Visibility == battleVisibilityUnlisted {
+		token := createUnlistedAccessToken(s.cfg.JWTSecret, battleID, key, expiresAt)
+		variant := assignBattleCardVariant(battleID)
+		response["share_url"] = withUnlistedAccess(s.battleCardShareURL(battleID, variant), token)
+		response["card_url"] = withUnlistedAccess(battleCardImagePath(battleID, variant), token)
+		if !expiresAt.IsZero() {
+			response["expires_at"] = expiresAt.Format(time.RFC3339)
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// setBattleVisibility updates a published battle owned by userID and returns
+// its unlisted key. Going unlisted keeps the current key unless rotate is
+// set, so links already handed out keep working; going public drops it.
+func (s *Server) setBattleVisibility(ctx context.Context, userID, battleID, visibility string, rotate bool) (string, error) {
+	if visibility == battleVisibilityPublic {
+		tag, err := s.db.Exec(ctx, `
+			UPDATE posts
+			SET visibility = $3, unlisted_key = NULL, updated_at = NOW()
+			WHERE id = $1
+			  AND user_id = $2
+			  AND status = 'PUBLISHED'
+		`, battleID, userID, visibility)
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 0 {
+			return "", pgx.ErrNoRows
+		}
+		return "", nil
+	}
+
+	newKey, err := newUnlistedKey()
+	if err != nil {
+		return "", err
+	}
+	var key string
+	err = s.db.QueryRow(ctx, `
+		UPDATE posts
+		SET visibility = $3,
+			unlisted_key = CASE WHEN $5 OR unlisted_key IS NULL THEN $4 ELSE unlisted_key END,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = 'PUBLISHED'
+		RETURNING unlisted_key
+	`, battleID, userID, visibility, newKey, rotate).Scan(&key)
+	return key, err
+}
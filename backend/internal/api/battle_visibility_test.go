@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnlistedAccessTokenRoundTrip(t *testing.T) {
+	const (
+		secret   = "unlisted-secret"
+		battleID = "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f"
+		key      = "0123456789abcdef0123456789abcdef"
+	)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	expiring := createUnlistedAccessToken(secret, battleID, key, now.Add(24*time.Hour))
+	if err := verifyUnlistedAccessToken(secret, battleID, key, expiring, now.Add(time.Hour)); err != nil {
+		t.Fatalf("verify expiring token: %v", err)
+	}
+	if err := verifyUnlistedAccessToken(secret, battleID, key, expiring, now.Add(24*time.Hour)); !errors.Is(err, errUnlistedAccessExpired) {
+		t.Fatalf("expected expired token, got %v", err)
+	}
+
+	_, signature, _ := strings.Cut(expiring, ".")
+
+	forever := createUnlistedAccessToken(secret, battleID, key, time.Time{})
+	if err := verifyUnlistedAccessToken(secret, battleID, key, forever, now.Add(10*365*24*time.Hour)); err != nil {
+		t.Fatalf("verify token without expiry: %v", err)
+	}
+
+	for name, check := range map[string]error{
+		"rotated key":   verifyUnlistedAccessToken(secret, battleID, "fedcba9876543210fedcba9876543210", forever, now),
+		"other battle":  verifyUnlistedAccessToken(secret, "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b", key, forever, now),
+		"wrong secret":  verifyUnlistedAccessToken("other-secret", battleID, key, forever, now),
+		"no key":        verifyUnlistedAccessToken(secret, battleID, "", forever, now),
+		"edited expiry": verifyUnlistedAccessToken(secret, battleID, key, "0."+signature, now),
+		"malformed":     verifyUnlistedAccessToken(secret, battleID, key, "not-a-token", now),
+	} {
+		if !errors.Is(check, errUnlistedAccessInvalid) {
+			t.Fatalf("%s: expected invalid token, got %v", name, check)
+		}
+	}
+}
+
+func TestWithUnlistedAccess(t *testing.T) {
+	if got := withUnlistedAccess("/b/abc/card.png?variant=classic", "123.ff"); got != "/b/abc/card.png?variant=classic&ua=123.ff" {
+		t.Fatalf("unexpected card url %q", got)
+	}
+	if got := withUnlistedAccess("/b/abc/turns/2/card.png", "0.ff"); got != "/b/abc/turns/2/card.png?ua=0.ff" {
+		t.Fatalf("unexpected turn card url %q", got)
+	}
+	if got := withUnlistedAccess("/b/abc", ""); got != "/b/abc" {
+		t.Fatalf("expected url unchanged without token, got %q", got)
+	}
+}
+
+func TestBattleVisibilityRequestNormalize(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req := battleVisibilityRequest{Visibility: " Unlisted ", ExpiresAt: "2026-03-08T12:00:00Z"}
+	expiresAt, err := req.normalize(now)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if req.Visibility != battleVisibilityUnlisted || !expiresAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Fatalf("unexpected request %+v expiring %s", req, expiresAt)
+	}
+
+	for name, bad := range map[string]battleVisibilityRequest{
+		"unknown visibility": {Visibility: "private"},
+		"expiry when public": {Visibility: "public", ExpiresAt: "2026-03-08T12:00:00Z"},
+		"past expiry":        {Visibility: "unlisted", ExpiresAt: "2026-02-28T12:00:00Z"},
+		"expiry too far":     {Visibility: "unlisted", ExpiresAt: "2027-06-01T12:00:00Z"},
+		"bad timestamp":      {Visibility: "unlisted", ExpiresAt: "next week"},
+	} {
+		if _, err := bad.normalize(now); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
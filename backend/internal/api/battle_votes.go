@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// BattleVoteTally is the audience votes one participant of a battle has.
+// Tallies list every participant, including those without votes.
+type BattleVoteTally struct {
+	PersonaID   string `json:"persona_id"`
+	PersonaName string `json:"persona_name"`
+	Votes       int    `json:"votes"`
+}
+
+var (
+	errBattleVoteNotParticipant = errors.New("persona did not take part in this battle")
+	errBattleVoteOwnBattle      = errors.New("you cannot vote in a battle your persona took part in")
+)
+
+// battleParticipantsSQL lists the personas that spoke in battle $1.
+var battleParticipantsSQL = battleParticipantsQuery("$1")
+
+// battleParticipantsQuery lists the personas that spoke in the battle whose
+// id is battleID, an SQL expression: the opening persona plus every persona
+// that replied, including turns that were moved to battle_archives. Queries
+// over many battles join it LATERAL with a column reference.
+func battleParticipantsQuery(battleID string) string {
+	return `
+	SELECT persona_id FROM posts WHERE id = ` + battleID + ` AND persona_id IS NOT NULL
+	UNION
+	SELECT persona_id FROM replies WHERE post_id = ` + battleID + ` AND persona_id IS NOT NULL
+	UNION
+	SELECT t.persona_id
+	FROM battle_archives a
+	CROSS JOIN LATERAL jsonb_to_recordset(a.turns) AS t(persona_id UUID)
+	WHERE a.post_id = ` + battleID + ` AND t.persona_id IS NOT NULL
+`
+}
+
+// allowBattleVote applies the vote limits: one per client IP, so a shared
+// spectator link cannot be flooded from one address, and one per user.
+func (s *Server) allowBattleVote(w http.ResponseWriter, r *http.Request, userID string) bool {
+	now := time.Now()
+	if !s.ipVoteLimiter.allow(requestClientIP(r), now) {
+		s.writeRateLimitResponse(w, r, "ip", "battle_vote", "vote rate limit exceeded")
+		return false
+	}
+	if !s.userVoteLimiter.allow("vote:"+strings.TrimSpace(userID), now) {
+		s.writeRateLimitResponse(w, r, "user", "battle_vote", "vote rate limit exceeded")
+		return false
+	}
+	return true
+}
+
+// handleCastBattleVote records the viewer's pick for the persona that won a
+// battle. Voting again moves the vote. People cannot vote in battles their own
+// personas fought in.
+func (s *Server) handleCastBattleVote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !s.allowBattleVote(w, r, userID) {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		PersonaID string `json:"persona_id"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	personaID, err := validateUUID(req.PersonaID, "persona_id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+	if err := s.checkBattleVote(r.Context(), battleID, personaID, userID); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			writeNotFound(w, "battle not found")
+		case errors.Is(err, errBattleVoteNotParticipant):
+			writeBadRequest(w, err.Error())
+		case errors.Is(err, errBattleVoteOwnBattle):
+			writeForbidden(w, err.Error())
+		default:
+			writeInternalError(w, "could not load battle")
+		}
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		INSERT INTO battle_votes(post_id, user_id, persona_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id) DO UPDATE
+		SET persona_id = EXCLUDED.persona_id,
+			updated_at = NOW()
+		WHERE battle_votes.persona_id <> EXCLUDED.persona_id
+	`, battleID, userID, personaID)
+	if err != nil {
+		writeInternalError(w, "could not save vote")
+		return
+	}
+	if ct.RowsAffected() > 0 {
+		_ = s.logEventFromRequest(r, eventBattleVoted, map[string]any{
+			"battle_id":  battleID,
+			"persona_id": personaID,
+		})
+	}
+
+	s.writeBattleVotes(w, r, battleID, userID)
+}
+
+// handleRetractBattleVote removes the viewer's vote. Like casting one, it needs
+// read access, so unlisted battles still want their ua token.
+func (s *Server) handleRetractBattleVote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !s.allowBattleVote(w, r, userID) {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+	if _, err := s.db.Exec(r.Context(), `
+		DELETE FROM battle_votes
+		WHERE post_id = $1
+		  AND user_id = $2
+	`, battleID, userID); err != nil {
+		writeInternalError(w, "could not remove vote")
+		return
+	}
+
+	s.writeBattleVotes(w, r, battleID, userID)
+}
+
+// checkBattleVote returns pgx.ErrNoRows when the battle is not published,
+// errBattleVoteNotParticipant when personaID did not fight in it and
+// errBattleVoteOwnBattle when userID owns the battle or one of its personas.
+func (s *Server) checkBattleVote(ctx context.Context, battleID, personaID, userID string) error {
+	var (
+		ownerUserID string
+		participant bool
+		ownsFighter bool
+	)
+	err := s.db.QueryRow(ctx, `
+		WITH participants AS (`+battleParticipantsSQL+`)
+		SELECT
+			p.user_id::text,
+			EXISTS (SELECT 1 FROM participants WHERE persona_id = $2),
+			EXISTS (
+				SELECT 1
+				FROM participants pt
+				JOIN personas pe ON pe.id = pt.persona_id
+				WHERE pe.user_id = $3
+			)
+		FROM posts p
+		WHERE p.id = $1
+		  AND p.status = 'PUBLISHED'
+		  AND p.template_id IS NOT NULL
+	`, battleID, personaID, userID).Scan(&ownerUserID, &participant, &ownsFighter)
+	if err != nil {
+		return err
+	}
+	if !participant {
+		return errBattleVoteNotParticipant
+	}
+	if ownerUserID == userID || ownsFighter {
+		return errBattleVoteOwnBattle
+	}
+	return nil
+}
+
+func (s *Server) writeBattleVotes(w http.ResponseWriter, r *http.Request, battleID, userID string) {
+	tallies, err := s.battleVoteTallies(r.Context(), battleID)
+	if err != nil {
+		writeInternalError(w, "could not load votes")
+		return
+	}
+	myVote, err := s.battleVoteOf(r.Context(), battleID, userID)
+	if err != nil {
+		writeInternalError(w, "could not load votes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"battle_id": battleID,
+		"votes":     tallies,
+		"my_vote":   myVote,
+	})
+}
+
+// battleVoteTallies counts the votes of each participant of battle battleID,
+// most votes first. Posts that are not battles have no tallies.
+func (s *Server) battleVoteTallies(ctx context.Context, battleID string) ([]BattleVoteTally, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH participants AS (`+battleParticipantsSQL+`)
+		SELECT pt.persona_id::text, pe.name, COUNT(v.user_id)::int
+		FROM participants pt
+		JOIN personas pe ON pe.id = pt.persona_id
+		LEFT JOIN battle_votes v ON v.post_id = $1 AND v.persona_id = pt.persona_id
+		WHERE EXISTS (SELECT 1 FROM posts WHERE id = $1 AND template_id IS NOT NULL)
+		GROUP BY pt.persona_id, pe.name
+		ORDER BY COUNT(v.user_id) DESC, pe.name, pt.persona_id
+	`, battleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tallies := make([]BattleVoteTally, 0, 2)
+	for rows.Next() {
+		var tally BattleVoteTally
+		if err := rows.Scan(&tally.PersonaID, &tally.PersonaName, &tally.Votes); err != nil {
+			return nil, err
+		}
+		tallies = append(tallies, tally)
+	}
+	return tallies, rows.Err()
+}
+
+// battleVoteOf returns the persona userID voted for in the battle, or "".
+func (s *Server) battleVoteOf(ctx context.Context, battleID, userID string) (string, error) {
+	var personaID string
+	err := s.db.QueryRow(ctx, `
+		SELECT persona_id::text
+		FROM battle_votes
+		WHERE post_id = $1
+		  AND user_id = $2
+	`, battleID, userID).Scan(&personaID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return personaID, err
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetractBattleVoteOnUnlistedBattleNeedsAccessIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{})
+
+	viewerID, viewerToken, err := createIntegrationUser(fixture, fmt.Sprintf("vote-viewer-%d@example.com", time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("create viewer failed: %v", err)
+	}
+
+	var templateID string
+	err = fixture.pool.QueryRow(fixture.ctx, `
+		INSERT INTO templates(owner_user_id, name, prompt_rules, turn_count, word_limit, is_public)
+		VALUES ($1, $2, $3, $4, $5, TRUE)
+		RETURNING id::text
+	`, fixture.userID, "Vote Template", "Alternate concise arguments.", 4, 120).Scan(&templateID)
+	if err != nil {
+		t.Fatalf("insert template failed: %v", err)
+	}
+
+	var battleID string
+	err = fixture.pool.QueryRow(fixture.ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id, visibility, unlisted_key)
+		VALUES ($1, $2, $3, 'AI_DRAFT_APPROVED', 'PUBLISHED', $4, NOW(), $5, 'unlisted', $6)
+		RETURNING id::text
+	`, fixture.roomID, fixture.personaID, fixture.userID, "Topic: Should votes be anonymous?", templateID, "0123456789abcdef0123456789abcdef").Scan(&battleID)
+	if err != nil {
+		t.Fatalf("insert battle failed: %v", err)
+	}
+	if _, err := fixture.pool.Exec(fixture.ctx, `
+		INSERT INTO battle_votes(post_id, user_id, persona_id)
+		VALUES ($1, $2, $3)
+	`, battleID, viewerID, fixture.personaID); err != nil {
+		t.Fatalf("insert vote failed: %v", err)
+	}
+
+	recorder := doJSONRequest(fixture.server, http.MethodDelete, "/b/"+battleID+"/vote", viewerToken, "")
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a ua token, got %d, body: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var votes int
+	if err := fixture.pool.QueryRow(fixture.ctx, `
+		SELECT COUNT(*) FROM battle_votes WHERE post_id = $1
+	`, battleID).Scan(&votes); err != nil {
+		t.Fatalf("count votes failed: %v", err)
+	}
+	if votes != 1 {
+		t.Fatalf("expected the vote to stay, got %d votes", votes)
+	}
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errWatchNotBattle      = errors.New("post is not a battle")
+	errWatchBattleFinished = errors.New("battle is already finished")
+)
+
+// battleWatchable reports whether a published post can be watched: only
+// battles, and only while their verdict is still to come. Archived battles
+// are long finished.
+func battleWatchable(isBattle, hasVerdict, archived bool) error {
+	if !isBattle {
+		return errWatchNotBattle
+	}
+	if hasVerdict || archived {
+		return errWatchBattleFinished
+	}
+	return nil
+}
+
+func (s *Server) handleWatchBattle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var isBattle, hasVerdict, archived bool
+	err = s.db.QueryRow(r.Context(), `
+		SELECT
+			p.template_id IS NOT NULL,
+			EXISTS(SELECT 1 FROM battle_coaching c WHERE c.post_id = p.id),
+			EXISTS(SELECT 1 FROM battle_archives a WHERE a.post_id = p.id)
+		FROM posts p
+		WHERE p.id = $1
+		  AND p.status = 'PUBLISHED'
+	`, battleID).Scan(&isBattle, &hasVerdict, &archived)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not load battle")
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, battleID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+	if err := battleWatchable(isBattle, hasVerdict, archived); err != nil {
+		if errors.Is(err, errWatchNotBattle) {
+			writeBadRequest(w, err.Error())
+			return
+		}
+		writeConflict(w, err.Error())
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		INSERT INTO battle_watches(user_id, post_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, post_id) DO NOTHING
+	`, userID, battleID)
+	if err != nil {
+		writeInternalError(w, "could not watch battle")
+		return
+	}
+	if ct.RowsAffected() > 0 {
+		s.logger.Info("battle_watched", observability.Fields{
+			"battle_id":  battleID,
+			"user_id":    userID,
+			"request_id": requestIDFromRequest(r),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"watching": true})
+}
+
+func (s *Server) handleUnwatchBattle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		DELETE FROM battle_watches
+		WHERE user_id = $1
+		  AND post_id = $2
+	`, userID, battleID); err != nil {
+		writeInternalError(w, "could not unwatch battle")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"watching": false})
+}
+
+func (s *Server) isWatchingBattle(ctx context.Context, userID, battleID string) (bool, error) {
+	var watching bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM battle_watches
+			WHERE user_id = $1
+			  AND post_id = $2
+		)
+	`, userID, battleID).Scan(&watching)
+	return watching, err
+}
+
+// notifyBattleWatchersOfTurn runs after a turn has committed. Like mentions,
+// it is a side effect, so a failure is logged rather than undoing the turn.
+func (s *Server) notifyBattleWatchersOfTurn(r *http.Request, battleID, authorUserID, authorName string) {
+	if _, err := common.NotifyBattleWatchersOfTurn(r.Context(), s.db, battleID, authorUserID, authorName); err != nil {
+		s.logger.Warn("battle_watchers_notify_failed", observability.Fields{
+			"request_id": requestIDFromRequest(r),
+			"post_id":    battleID,
+			"event":      "turn",
+			"error":      err.Error(),
+		})
+	}
+}
+
+// listWatchedBattlesForFeed returns the battles the user watches, most
+// recently active first. Watches older than two weeks drop out of the feed
+// but keep sending notifications.
+func (s *Server) listWatchedBattlesForFeed(ctx context.Context, userID string) ([]feedBattleCandidate, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH event_counts AS (
+			SELECT
+				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
+				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
+			FROM events e
+			WHERE e.created_at >= NOW() - INTERVAL '14 days'
+			  AND e.event_name IN ('battle_shared', 'remix_completed')
+			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '14 days'
+			GROUP BY pr.post_id
+		)
+		SELECT
+			p.id::text,
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			COALESCE(p.persona_id::text, ''),
+			COALESCE(pr.name, ''),
+			p.content,
+			p.created_at,
+			COALESCE(ec.shares, 0)::int,
+			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
+			COALESCE(p.template_id::text, ''),
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			) AS last_activity_at
+		FROM battle_watches bw
+		JOIN posts p ON p.id = bw.post_id
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN personas pr ON pr.id = p.persona_id
+		LEFT JOIN templates t ON t.id = p.template_id
+		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
+		WHERE bw.user_id = $1::uuid
+		  AND bw.created_at >= NOW() - INTERVAL '14 days'
+		  AND p.status = 'PUBLISHED'
+		ORDER BY last_activity_at DESC
+		LIMIT $2
+	`, userID, feedBattleCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]feedBattleCandidate, 0)
+	for rows.Next() {
+		var item feedBattleCandidate
+		if err := rows.Scan(
+			&item.BattleID,
+			&item.RoomID,
+			&item.RoomName,
+			&item.PersonaID,
+			&item.PersonaName,
+			&item.Content,
+			&item.CreatedAt,
+			&item.Shares,
+			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
+			&item.TemplateID,
+			&item.TemplateName,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
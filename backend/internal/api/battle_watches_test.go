@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBattleWatchable(t *testing.T) {
+	if err := battleWatchable(true, false, false); err != nil {
+		t.Fatalf("expected an in-progress battle to be watchable, got %v", err)
+	}
+	if err := battleWatchable(false, false, false); !errors.Is(err, errWatchNotBattle) {
+		t.Fatalf("expected plain posts to be rejected, got %v", err)
+	}
+	if err := battleWatchable(true, true, false); !errors.Is(err, errWatchBattleFinished) {
+		t.Fatalf("expected battles with a verdict to be rejected, got %v", err)
+	}
+	if err := battleWatchable(true, false, true); !errors.Is(err, errWatchBattleFinished) {
+		t.Fatalf("expected archived battles to be rejected, got %v", err)
+	}
+}
+
+func TestScoreWatchedBattleLeadsWhileActive(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-2 * time.Hour)
+
+	watched := scoreWatchedBattle(now, now.Add(-10*time.Minute), 0, 0, 0)
+	if followed := scoreFollowedBattle(now, createdAt, 2, 1, 4); watched <= followed {
+		t.Fatalf("expected an active watched battle (%v) above a followed one (%v)", watched, followed)
+	}
+	if featured := scoreBattleOfWeek(now, createdAt, 2, 1, 4); watched <= featured {
+		t.Fatalf("expected an active watched battle (%v) above the battle of the week (%v)", watched, featured)
+	}
+	if idle := scoreWatchedBattle(now, now.Add(-72*time.Hour), 0, 0, 0); idle >= watched {
+		t.Fatalf("expected watched score to decay with idle time, got %v then %v", watched, idle)
+	}
+
+	reasons := sortedFeedReasons(map[string]struct{}{"followed_persona": {}, "watched_battle": {}})
+	if reasons[0] != "watched_battle" {
+		t.Fatalf("expected watched_battle to lead the reasons, got %v", reasons)
+	}
+}
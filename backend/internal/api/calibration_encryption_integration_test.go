@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestCalibrationEncryptionIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{
+		calibrationKeys: "test-1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, 32)),
+	})
+
+	body := `{"name":"Sealed Persona","bio":"Keeps its samples private.","tone":"direct",
+		"writing_samples":["Private sample one","Private sample two","Private sample three"],
+		"do_not_say":["my street address"],"catchphrases":["Ship it"],"preferred_language":"en","formality":1}`
+	created := doJSONRequest(fixture.server, http.MethodPost, "/personas", fixture.token, body)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("expected create 201, got %d, body: %s", created.Code, created.Body.String())
+	}
+	var persona Persona
+	if err := json.Unmarshal(created.Body.Bytes(), &persona); err != nil {
+		t.Fatalf("decode persona: %v", err)
+	}
+	if len(persona.WritingSamples) != 3 || persona.DoNotSay[0] != "my street address" {
+		t.Fatalf("expected plaintext calibration in the response, got %+v", persona)
+	}
+
+	var writingSamples, doNotSay []byte
+	if err := fixture.pool.QueryRow(fixture.ctx, `
+		SELECT writing_samples, do_not_say
+		FROM personas
+		WHERE id = $1
+	`, persona.ID).Scan(&writingSamples, &doNotSay); err != nil {
+		t.Fatalf("load stored persona: %v", err)
+	}
+	for _, raw := range [][]byte{writingSamples, doNotSay} {
+		if bytes.Contains(raw, []byte("Private sample")) || bytes.Contains(raw, []byte("street")) {
+			t.Fatalf("calibration stored in plaintext: %s", raw)
+		}
+		if _, sealed := common.SealedCalibrationKeyVersion(raw); !sealed {
+			t.Fatalf("expected a sealed list, got %s", raw)
+		}
+	}
+
+	listed := doJSONRequest(fixture.server, http.MethodGet, "/personas", fixture.token, "")
+	if listed.Code != http.StatusOK {
+		t.Fatalf("expected list 200, got %d", listed.Code)
+	}
+	var payload struct {
+		Personas []Persona `json:"personas"`
+	}
+	if err := json.Unmarshal(listed.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode personas: %v", err)
+	}
+	found := 0
+	for _, item := range payload.Personas {
+		// The fixture persona was inserted in plaintext and still reads.
+		if (item.ID == persona.ID && item.WritingSamples[2] == "Private sample three") ||
+			(item.ID == fixture.personaID && len(item.WritingSamples) == 3) {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("expected both personas to read back, got %+v", payload.Personas)
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/authz"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// Crosspost is a post shared to a connected network. ExternalURL is set once
+// the network accepted it; BacklinkURL is the link the shared text carries.
+type Crosspost struct {
+	PostID      string     `json:"post_id"`
+	Provider    string     `json:"provider"`
+	Status      string     `json:"status"`
+	JobID       *int64     `json:"job_id,omitempty"`
+	BacklinkURL string     `json:"backlink_url"`
+	ExternalURL string     `json:"external_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+const crosspostColumns = `
+	post_id::text,
+	provider,
+	status,
+	job_id,
+	backlink_url,
+	external_url,
+	error,
+	requested_at,
+	published_at
+`
+
+func scanCrosspost(row pgx.Row) (Crosspost, error) {
+	var out Crosspost
+	err := row.Scan(
+		&out.PostID,
+		&out.Provider,
+		&out.Status,
+		&out.JobID,
+		&out.BacklinkURL,
+		&out.ExternalURL,
+		&out.Error,
+		&out.RequestedAt,
+		&out.PublishedAt,
+	)
+	return out, err
+}
+
+// handleCreateCrosspost queues a published persona post for the worker to
+// share on one of the owner's connected networks. A failed cross-post can be
+// requested again; a pending or published one cannot.
+func (s *Server) handleCreateCrosspost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req struct {
+		Provider string `json:"provider"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(req.Provider))
+	if _, ok := s.social[provider]; !ok {
+		writeBadRequest(w, "provider must be a configured network")
+		return
+	}
+
+	var (
+		ownerUserID string
+		personaID   string
+		status      string
+		unpublished bool
+		slug        string
+		connected   bool
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT p.user_id::text, COALESCE(p.persona_id::text, ''), p.status::text, p.unpublished_at IS NOT NULL,
+			COALESCE(pp.slug, ''),
+			EXISTS(SELECT 1 FROM social_accounts sa WHERE sa.user_id = $2 AND sa.provider = $3)
+		FROM posts p
+		LEFT JOIN persona_public_profiles pp ON pp.persona_id = p.persona_id AND pp.is_public
+		WHERE p.id = $1
+	`, postID, userID, provider).Scan(&ownerUserID, &personaID, &status, &unpublished, &slug, &connected)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not load post")
+		return
+	}
+	if !writeAccessDecision(w, authz.Decide(authz.Subject{UserID: userID}, authz.Post, authz.Manage, authz.Facts{Exists: true, OwnerUserID: ownerUserID})) {
+		return
+	}
+	if status != "PUBLISHED" || unpublished {
+		writeConflict(w, "only published posts can be cross-posted")
+		return
+	}
+	if personaID == "" {
+		writeConflict(w, "only persona posts can be cross-posted")
+		return
+	}
+	if slug == "" {
+		writeConflict(w, "the persona needs a public profile to link back to")
+		return
+	}
+	if !connected {
+		writeConflict(w, provider+" account is not connected")
+		return
+	}
+
+	crosspost, err := s.enqueueCrosspost(r, userID, postID, personaID, provider, common.CrosspostBacklink(s.cfg.FrontendOrigin, slug, postID, provider))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeConflict(w, "post is already cross-posted to "+provider)
+			return
+		}
+		writeInternalError(w, "could not queue cross-post")
+		return
+	}
+
+	s.logger.Info("crosspost_requested", observability.Fields{
+		"post_id":    postID,
+		"provider":   provider,
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusAccepted, map[string]any{"crosspost": crosspost})
+}
+
+// enqueueCrosspost claims the (post, provider) slot and queues its job in one
+// transaction. pgx.ErrNoRows means a pending or published cross-post already
+// holds the slot.
+func (s *Server) enqueueCrosspost(r *http.Request, userID, postID, personaID, provider, backlink string) (Crosspost, error) {
+	payload, err := json.Marshal(common.CrosspostJobPayload{
+		UserID:   userID,
+		Provider: provider,
+		TraceID:  strings.TrimSpace(requestIDFromRequest(r)),
+	})
+	if err != nil {
+		return Crosspost{}, err
+	}
+
+	ctx := r.Context()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Crosspost{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `
+		INSERT INTO post_crossposts(post_id, provider, user_id, status, backlink_url)
+		VALUES ($1, $2, $3, 'PENDING', $4)
+		ON CONFLICT (post_id, provider) DO UPDATE
+		SET user_id = EXCLUDED.user_id,
+			status = 'PENDING',
+			backlink_url = EXCLUDED.backlink_url,
+			error = '',
+			requested_at = NOW(),
+			updated_at = NOW()
+		WHERE post_crossposts.status = 'FAILED'
+	`, postID, provider, userID, backlink)
+	if err != nil {
+		return Crosspost{}, err
+	}
+	if ct.RowsAffected() == 0 {
+		return Crosspost{}, pgx.ErrNoRows
+	}
+
+	crosspost, err := scanCrosspost(tx.QueryRow(ctx, `
+		WITH job AS (
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ($1, $2, $3, $4::jsonb, 'PENDING', NOW())
+			RETURNING id
+		)
+		UPDATE post_crossposts
+		SET job_id = (SELECT id FROM job)
+		WHERE post_id = $2
+		  AND provider = $5
+		RETURNING `+crosspostColumns, common.JobCrosspost, postID, personaID, payload, provider))
+	if err != nil {
+		return Crosspost{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Crosspost{}, err
+	}
+	return crosspost, nil
+}
+
+func (s *Server) handleListCrossposts(w http.ResponseWriter, r *http.Request) {
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT `+crosspostColumns+`
+		FROM post_crossposts
+		WHERE post_id = $1
+		ORDER BY provider
+	`, postID)
+	if err != nil {
+		writeInternalError(w, "could not list cross-posts")
+		return
+	}
+	defer rows.Close()
+
+	crossposts := make([]Crosspost, 0)
+	for rows.Next() {
+		crosspost, err := scanCrosspost(rows)
+		if err != nil {
+			writeInternalError(w, "could not scan cross-post")
+			return
+		}
+		crossposts = append(crossposts, crosspost)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list cross-posts")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"crossposts": crossposts})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type DailySummaryPersona struct {
+	PersonaID    string `json:"persona_id"`
+	Name         string `json:"name"`
+	Summary      string `json:"summary"`
+	Posts        int    `json:"posts"`
+	Replies      int    `json:"replies"`
+	HumanReplies int    `json:"human_replies"`
+	Reactions    int    `json:"reactions"`
+	NewFollowers int    `json:"new_followers"`
+}
+
+type DailySummaryDraft struct {
+	PostID      string    `json:"post_id"`
+	PersonaID   string    `json:"persona_id"`
+	PersonaName string    `json:"persona_name"`
+	Excerpt     string    `json:"excerpt"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DailySummaryBattle struct {
+	BattleID   string    `json:"battle_id"`
+	Topic      string    `json:"topic"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// DailySummary is the worker's rollup of one UTC day across all of a user's
+// personas. PendingDrafts holds the newest few of PendingDraftCount.
+type DailySummary struct {
+	Date              string                `json:"date"`
+	Summary           string                `json:"summary"`
+	Personas          []DailySummaryPersona `json:"personas"`
+	PendingDrafts     []DailySummaryDraft   `json:"pending_drafts"`
+	PendingDraftCount int                   `json:"pending_draft_count"`
+	NewFollowers      int                   `json:"new_followers"`
+	FinishedBattles   []DailySummaryBattle  `json:"finished_battles"`
+	GeneratedAt       time.Time             `json:"generated_at"`
+	Window            common.DateWindow     `json:"window"`
+}
+
+// handleGetDailySummary returns the user's latest daily summary, or the one
+// for ?date=YYYY-MM-DD. enabled reports the opt-in, since summaries stop
+// being built once it is turned off.
+func (s *Server) handleGetDailySummary(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	date := strings.TrimSpace(r.URL.Query().Get("date"))
+	if date != "" {
+		if _, err := time.Parse(common.DateLayout, date); err != nil {
+			writeBadRequest(w, "date must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	prefs, err := s.loadNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+	location, err := s.userLocation(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+
+	var (
+		summary                            DailySummary
+		day                                time.Time
+		personasRaw, draftsRaw, battlesRaw []byte
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT date, summary, personas, pending_drafts, finished_battles, pending_draft_count, new_followers, created_at
+		FROM user_daily_summaries
+		WHERE user_id = $1
+		  AND ($2 = '' OR date = NULLIF($2, '')::date)
+		ORDER BY date DESC
+		LIMIT 1
+	`, userID, date).Scan(&day, &summary.Summary, &personasRaw, &draftsRaw, &battlesRaw,
+		&summary.PendingDraftCount, &summary.NewFollowers, &summary.GeneratedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"summary": nil,
+				"exists":  false,
+				"enabled": prefs.DailySummary,
+			})
+			return
+		}
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+	if err := json.Unmarshal(personasRaw, &summary.Personas); err != nil {
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+	if err := json.Unmarshal(draftsRaw, &summary.PendingDrafts); err != nil {
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+	if err := json.Unmarshal(battlesRaw, &summary.FinishedBattles); err != nil {
+		writeInternalError(w, "could not load daily summary")
+		return
+	}
+	summary.Date = day.UTC().Format(common.DateLayout)
+	if window, err := common.UTCDateWindow(summary.Date, 1, location); err == nil {
+		summary.Window = window
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"summary": summary,
+		"exists":  true,
+		"enabled": prefs.DailySummary,
+	})
+}
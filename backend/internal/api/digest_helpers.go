@@ -7,21 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"personaworlds/backend/internal/common"
+
 	"github.com/jackc/pgx/v5"
 )
 
-func (s *Server) personaOwnedByUser(ctx context.Context, userID, personaID string) (bool, error) {
-	var exists bool
-	err := s.db.QueryRow(ctx, `
-		SELECT EXISTS(
-			SELECT 1
-			FROM personas
-			WHERE id = $1 AND user_id = $2
-		)
-	`, personaID, userID).Scan(&exists)
-	return exists, err
-}
-
 func (s *Server) getDigestForDate(ctx context.Context, personaID string, date time.Time) (PersonaDigest, bool, error) {
 	var (
 		digest PersonaDigest
@@ -34,7 +24,7 @@ func (s *Server) getDigestForDate(ctx context.Context, personaID string, date ti
 		FROM persona_digests
 		WHERE persona_id = $1
 		  AND date = $2::date
-	`, personaID, date.Format("2006-01-02")).Scan(&digest.PersonaID, &rawDay, &digest.Summary, &stats, &digest.UpdatedAt)
+	`, personaID, date.Format(common.DateLayout)).Scan(&digest.PersonaID, &rawDay, &digest.Summary, &stats, &digest.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return PersonaDigest{}, false, nil
@@ -42,7 +32,7 @@ func (s *Server) getDigestForDate(ctx context.Context, personaID string, date ti
 		return PersonaDigest{}, false, err
 	}
 
-	digest.Date = rawDay.UTC().Format("2006-01-02")
+	digest.Date = rawDay.UTC().Format(common.DateLayout)
 	if err := hydrateDigestStats(&digest, stats); err != nil {
 		return PersonaDigest{}, false, err
 	}
@@ -70,7 +60,7 @@ func (s *Server) getLatestDigest(ctx context.Context, personaID string) (Persona
 		return PersonaDigest{}, false, err
 	}
 
-	digest.Date = rawDay.UTC().Format("2006-01-02")
+	digest.Date = rawDay.UTC().Format(common.DateLayout)
 	if err := hydrateDigestStats(&digest, stats); err != nil {
 		return PersonaDigest{}, false, err
 	}
@@ -89,17 +79,26 @@ func hydrateDigestStats(digest *PersonaDigest, statsRaw []byte) error {
 	if digest.Stats.TopThreads == nil {
 		digest.Stats.TopThreads = []DigestThread{}
 	}
-	digest.HasActivity = digest.Stats.Posts > 0 || digest.Stats.Replies > 0 || len(digest.Stats.TopThreads) > 0
+	digest.HasActivity = digest.Stats.Posts > 0 || digest.Stats.Replies > 0 || digest.Stats.HumanReplies > 0 || len(digest.Stats.TopThreads) > 0
 	if strings.TrimSpace(digest.Summary) == "" && !digest.HasActivity {
 		digest.Summary = "No activity yet today. Once the persona posts or replies, this digest will update."
 	}
 	return nil
 }
 
+// localizeDigest attaches the local window of the digest's UTC date.
+func localizeDigest(digest *PersonaDigest, location *time.Location) {
+	window, err := common.UTCDateWindow(digest.Date, 1, location)
+	if err != nil {
+		return
+	}
+	digest.Window = window
+}
+
 func emptyDigest(personaID string, date time.Time) PersonaDigest {
 	return PersonaDigest{
 		PersonaID: personaID,
-		Date:      date.UTC().Format("2006-01-02"),
+		Date:      date.UTC().Format(common.DateLayout),
 		Summary:   "No activity yet today. Once the persona posts or replies, this digest will update.",
 		Stats: DigestStats{
 			Posts:      0,
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const digestRegenerateDailyLimit = 3
+
+// DigestSchedule controls when the worker builds a persona's daily digest.
+// Hour is a UTC hour so it lines up with the digest's UTC date.
+type DigestSchedule struct {
+	Enabled               bool       `json:"enabled"`
+	Hour                  int        `json:"hour"`
+	RegenerateRequestedAt *time.Time `json:"regenerate_requested_at,omitempty"`
+}
+
+type digestScheduleRequest struct {
+	Enabled *bool `json:"enabled"`
+	Hour    *int  `json:"hour"`
+}
+
+func (req digestScheduleRequest) apply(schedule DigestSchedule) (DigestSchedule, error) {
+	if req.Enabled == nil && req.Hour == nil {
+		return DigestSchedule{}, fmt.Errorf("enabled or hour is required")
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	if req.Hour != nil {
+		if *req.Hour < 0 || *req.Hour > 23 {
+			return DigestSchedule{}, fmt.Errorf("hour must be between 0 and 23")
+		}
+		schedule.Hour = *req.Hour
+	}
+	return schedule, nil
+}
+
+func (s *Server) handleGetDigestSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	schedule, err := s.getDigestSchedule(r.Context(), userID, personaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load digest schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schedule": schedule,
+	})
+}
+
+func (s *Server) handleUpdateDigestSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req digestScheduleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	current, err := s.getDigestSchedule(r.Context(), userID, personaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load digest schedule")
+		return
+	}
+	schedule, err := req.apply(current)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		UPDATE personas
+		SET digest_enabled = $3,
+			digest_hour = $4,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID, schedule.Enabled, schedule.Hour); err != nil {
+		writeInternalError(w, "could not update digest schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schedule": schedule,
+	})
+}
+
+// handleRegenerateDigest flags today's digest for a rebuild. The worker picks
+// flagged personas first and ignores the schedule for them, so this also works
+// when scheduled digests are disabled.
+func (s *Server) handleRegenerateDigest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	used, err := s.currentQuotaUsage(r.Context(), personaID, "digest_regenerate")
+	if err != nil {
+		writeInternalError(w, "could not check digest quota")
+		return
+	}
+	if used >= digestRegenerateDailyLimit {
+		writeTooManyRequests(w, "daily digest regeneration limit reached")
+		return
+	}
+
+	var requestedAt time.Time
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE personas
+		SET digest_regenerate_requested_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		RETURNING digest_regenerate_requested_at
+	`, personaID, userID).Scan(&requestedAt)
+	if err != nil {
+		writeInternalError(w, "could not request digest regeneration")
+		return
+	}
+	if err := s.insertQuotaEvent(r, personaID, common.QuotaTypeDigestRegenerate, "", nil); err != nil {
+		writeInternalError(w, "could not record digest quota")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"requested_at": requestedAt,
+		"quota": map[string]any{
+			"used":  used + 1,
+			"limit": digestRegenerateDailyLimit,
+		},
+	})
+}
+
+func (s *Server) getDigestSchedule(ctx context.Context, userID, personaID string) (DigestSchedule, error) {
+	var schedule DigestSchedule
+	err := s.db.QueryRow(ctx, `
+		SELECT digest_enabled, digest_hour, digest_regenerate_requested_at
+		FROM personas
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID).Scan(&schedule.Enabled, &schedule.Hour, &schedule.RegenerateRequestedAt)
+	return schedule, err
+}
@@ -0,0 +1,35 @@
+package api
+
+import "testing"
+
+func TestDigestScheduleRequestApply(t *testing.T) {
+	current := DigestSchedule{Enabled: true, Hour: 0}
+	disabled := false
+	hour := 18
+
+	got, err := digestScheduleRequest{Hour: &hour}.apply(current)
+	if err != nil {
+		t.Fatalf("apply hour returned error: %v", err)
+	}
+	if !got.Enabled || got.Hour != 18 {
+		t.Fatalf("expected enabled schedule at 18, got %+v", got)
+	}
+
+	got, err = digestScheduleRequest{Enabled: &disabled}.apply(current)
+	if err != nil {
+		t.Fatalf("apply enabled returned error: %v", err)
+	}
+	if got.Enabled || got.Hour != 0 {
+		t.Fatalf("expected disabled schedule at 0, got %+v", got)
+	}
+
+	if _, err := (digestScheduleRequest{}).apply(current); err == nil {
+		t.Fatal("expected empty request to be rejected")
+	}
+	for _, invalid := range []int{-1, 24} {
+		value := invalid
+		if _, err := (digestScheduleRequest{Hour: &value}).apply(current); err == nil {
+			t.Fatalf("expected hour %d to be rejected", invalid)
+		}
+	}
+}
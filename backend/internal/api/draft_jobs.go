@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	draftJobStatusPending    = "pending"
+	draftJobStatusProcessing = "processing"
+	draftJobStatusReady      = "ready"
+	draftJobStatusFailed     = "failed"
+)
+
+// DraftJob is a draft whose generation missed the request SLA and was handed
+// to the worker. Post is set once the draft exists.
+type DraftJob struct {
+	ID        int64     `json:"job_id"`
+	Status    string    `json:"status"`
+	RoomID    string    `json:"room_id"`
+	PersonaID string    `json:"persona_id"`
+	Error     string    `json:"error,omitempty"`
+	Post      *Post     `json:"post,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// jobMaxAttempts mirrors the worker's retry cap so the API agrees with it on
+// which failed jobs are still going to run.
+func jobMaxAttempts(configured int) int {
+	if configured < 1 {
+		return 5
+	}
+	if configured > 20 {
+		return 20
+	}
+	return configured
+}
+
+func draftJobStatus(status string, attempts, maxAttempts int, hasPost bool) string {
+	switch status {
+	case "PROCESSING":
+		return draftJobStatusProcessing
+	case "DONE":
+		if hasPost {
+			return draftJobStatusReady
+		}
+		return draftJobStatusFailed
+	case "FAILED":
+		if attempts >= maxAttempts {
+			return draftJobStatusFailed
+		}
+		return draftJobStatusPending
+	default:
+		return draftJobStatusPending
+	}
+}
+
+// draftSLAContext bounds synchronous draft generation by DRAFT_SLA. A zero
+// SLA keeps the old behaviour of waiting for the LLM as long as the request
+// allows.
+func (s *Server) draftSLAContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.cfg.DraftSLA <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.cfg.DraftSLA)
+}
+
+// draftSLAExceeded reports whether generation stopped because the SLA ran out
+// while the client was still waiting.
+func draftSLAExceeded(requestCtx, draftCtx context.Context) bool {
+	return requestCtx.Err() == nil && errors.Is(draftCtx.Err(), context.DeadlineExceeded)
+}
+
+// unfinishedDraftJobs counts queued drafts that still count against the
+// persona's daily draft quota.
+func (s *Server) unfinishedDraftJobs(ctx context.Context, personaID string) (int, error) {
+	var count int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM jobs
+		WHERE job_type = $1
+		  AND persona_id = $2
+		  AND (status IN ('PENDING', 'PROCESSING') OR (status = 'FAILED' AND attempts < $3))
+	`, common.JobGenerateDraft, personaID, jobMaxAttempts(s.cfg.JobMaxAttempts)).Scan(&count)
+	return count, err
+}
+
+func (s *Server) enqueueDraftJob(w http.ResponseWriter, r *http.Request, userID, personaID, roomID, mood string) {
+	payload, err := json.Marshal(common.DraftJobPayload{
+		UserID:  userID,
+		RoomID:  roomID,
+		Mood:    mood,
+		TraceID: strings.TrimSpace(requestIDFromRequest(r)),
+	})
+	if err != nil {
+		writeInternalError(w, "could not queue draft")
+		return
+	}
+
+	job := DraftJob{Status: draftJobStatusPending, RoomID: roomID, PersonaID: personaID}
+	// The request context may be close to its own deadline after the SLA ran
+	// out, so the enqueue gets a fresh budget.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+	defer cancel()
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO jobs(job_type, persona_id, payload, status, available_at)
+		VALUES ($1, $2, $3::jsonb, 'PENDING', NOW())
+		RETURNING id, created_at, updated_at
+	`, common.JobGenerateDraft, personaID, payload).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		writeInternalError(w, "could not queue draft")
+		return
+	}
+
+	s.logger.Info("draft_deferred", observability.Fields{
+		"job_id":     job.ID,
+		"persona_id": personaID,
+		"room_id":    roomID,
+		"sla_ms":     s.cfg.DraftSLA.Milliseconds(),
+		"request_id": requestIDFromRequest(r),
+	})
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleGetDraftJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	jobID, err := strconv.ParseInt(strings.TrimSpace(chi.URLParam(r, "id")), 10, 64)
+	if err != nil || jobID <= 0 {
+		writeBadRequest(w, "invalid draft job id")
+		return
+	}
+
+	var (
+		job      DraftJob
+		status   string
+		attempts int
+		postID   string
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT j.id, j.status::text, j.attempts, COALESCE(j.error, ''), COALESCE(j.payload->>'room_id', ''), j.persona_id::text, COALESCE(j.post_id::text, ''), j.created_at, j.updated_at
+		FROM jobs j
+		JOIN personas pe ON pe.id = j.persona_id
+		WHERE j.id = $1
+		  AND j.job_type = $2
+		  AND pe.user_id = $3
+	`, jobID, common.JobGenerateDraft, userID).Scan(&job.ID, &status, &attempts, &job.Error, &job.RoomID, &job.PersonaID, &postID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "draft job not found")
+			return
+		}
+		writeInternalError(w, "could not load draft job")
+		return
+	}
+	job.Status = draftJobStatus(status, attempts, jobMaxAttempts(s.cfg.JobMaxAttempts), postID != "")
+	if job.Status != draftJobStatusFailed {
+		job.Error = ""
+	}
+
+	if postID != "" {
+		var post Post
+		err := s.db.QueryRow(r.Context(), `
+			SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, COALESCE(p.mood, ''), p.created_at, p.updated_at
+			FROM posts p
+			LEFT JOIN personas pr ON pr.id = p.persona_id
+			WHERE p.id = $1
+			  AND p.user_id = $2
+		`, postID, userID).Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.Persona, &post.AuthoredBy, &post.Status, &post.Content, &post.Mood, &post.CreatedAt, &post.UpdatedAt)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			writeInternalError(w, "could not load draft")
+			return
+		}
+		if err == nil {
+			job.Post = &post
+		}
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDraftJobStatus(t *testing.T) {
+	cases := []struct {
+		status   string
+		attempts int
+		hasPost  bool
+		want     string
+	}{
+		{status: "PENDING", want: draftJobStatusPending},
+		{status: "PROCESSING", want: draftJobStatusProcessing},
+		{status: "FAILED", attempts: 2, want: draftJobStatusPending},
+		{status: "FAILED", attempts: 5, want: draftJobStatusFailed},
+		{status: "DONE", hasPost: true, want: draftJobStatusReady},
+		{status: "DONE", want: draftJobStatusFailed},
+	}
+	for _, tc := range cases {
+		if got := draftJobStatus(tc.status, tc.attempts, 5, tc.hasPost); got != tc.want {
+			t.Fatalf("%s/%d/%v: expected %q, got %q", tc.status, tc.attempts, tc.hasPost, tc.want, got)
+		}
+	}
+}
+
+func TestDraftSLAExceeded(t *testing.T) {
+	requestCtx, cancelRequest := context.WithCancel(context.Background())
+	defer cancelRequest()
+
+	draftCtx, cancelDraft := context.WithTimeout(requestCtx, time.Millisecond)
+	defer cancelDraft()
+	<-draftCtx.Done()
+	if !draftSLAExceeded(requestCtx, draftCtx) {
+		t.Fatalf("expected SLA timeout to fall back to a job")
+	}
+
+	cancelRequest()
+	if draftSLAExceeded(requestCtx, draftCtx) {
+		t.Fatalf("expected a gone client not to queue a job")
+	}
+
+	otherCtx, cancelOther := context.WithCancel(context.Background())
+	cancelOther()
+	if draftSLAExceeded(context.Background(), otherCtx) {
+		t.Fatalf("expected plain cancellation not to count as an SLA miss")
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/auth"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	roomEventUpcoming = "upcoming"
+	roomEventLive     = "live"
+	roomEventEnded    = "ended"
+
+	roomEventMaxLength = 30 * 24 * time.Hour
+)
+
+var (
+	errEventNotStarted = errors.New("event has not started yet")
+	errEventEnded      = errors.New("event has ended")
+)
+
+// RoomEvent is the window of an event room as of the request, with the
+// countdowns clients render. Recap is filled in by the worker once the event
+// has closed.
+type RoomEvent struct {
+	StartsAt          time.Time `json:"starts_at"`
+	EndsAt            time.Time `json:"ends_at"`
+	Status            string    `json:"status"`
+	SecondsUntilStart int64     `json:"seconds_until_start"`
+	SecondsUntilEnd   int64     `json:"seconds_until_end"`
+	Recap             string    `json:"recap,omitempty"`
+}
+
+// newRoomEvent returns nil for rooms without an event window.
+func newRoomEvent(startsAt, endsAt *time.Time, recap string, now time.Time) *RoomEvent {
+	if startsAt == nil || endsAt == nil {
+		return nil
+	}
+	event := &RoomEvent{
+		StartsAt: startsAt.UTC(),
+		EndsAt:   endsAt.UTC(),
+		Recap:    strings.TrimSpace(recap),
+	}
+	switch {
+	case now.Before(event.StartsAt):
+		event.Status = roomEventUpcoming
+	case now.Before(event.EndsAt):
+		event.Status = roomEventLive
+	default:
+		event.Status = roomEventEnded
+	}
+	if left := event.StartsAt.Sub(now); left > 0 {
+		event.SecondsUntilStart = int64(left.Seconds())
+	}
+	if left := event.EndsAt.Sub(now); left > 0 {
+		event.SecondsUntilEnd = int64(left.Seconds())
+	}
+	return event
+}
+
+// roomEventWriteError rejects posts and battles outside an event's window.
+// Rooms without an event always accept them.
+func roomEventWriteError(event *RoomEvent) error {
+	if event == nil {
+		return nil
+	}
+	switch event.Status {
+	case roomEventUpcoming:
+		return errEventNotStarted
+	case roomEventEnded:
+		return errEventEnded
+	default:
+		return nil
+	}
+}
+
+// validateEventWindow checks a new or moved event window. It must still be
+// ahead of its end and no longer than roomEventMaxLength.
+func validateEventWindow(startsAt, endsAt, now time.Time) error {
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return errors.New("starts_at and ends_at are required")
+	}
+	if !endsAt.After(startsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if !endsAt.After(now) {
+		return errors.New("ends_at must be in the future")
+	}
+	if endsAt.Sub(startsAt) > roomEventMaxLength {
+		return errors.New("events can last at most 30 days")
+	}
+	return nil
+}
+
+func (s *Server) handleGetRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if s.redirectMergedRoom(w, r, roomID, "") {
+		return
+	}
+
+	room, err := s.getRoomByID(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+	chips, err := s.loadRelatedRoomChips(r.Context(), []string{room.ID})
+	if err != nil {
+		writeInternalError(w, "could not load related rooms")
+		return
+	}
+	room.Related = chips[room.ID]
+	if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+		s.recordFirstRoomVisit(r, userID, room.ID)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"room": room})
+}
+
+func (s *Server) handleCreateEventRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Slug        string    `json:"slug"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		StartsAt    time.Time `json:"starts_at"`
+		EndsAt      time.Time `json:"ends_at"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len([]rune(name)) > 80 {
+		writeBadRequest(w, "name must be 1-80 characters")
+		return
+	}
+	description := strings.TrimSpace(req.Description)
+	if len([]rune(description)) > 500 {
+		writeBadRequest(w, "description must be at most 500 characters")
+		return
+	}
+	slug := s.normalizeSlug(req.Slug)
+	if slug == "" {
+		slug = s.normalizeSlug(name)
+	}
+	if slug == "" {
+		writeBadRequest(w, "slug must contain letters or numbers")
+		return
+	}
+	if err := validateEventWindow(req.StartsAt, req.EndsAt, time.Now()); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var room Room
+	var startsAt, endsAt *time.Time
+	err := s.db.QueryRow(r.Context(), `
+		INSERT INTO rooms(slug, name, description, event_starts_at, event_ends_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id::text, slug, name, description, created_at, event_starts_at, event_ends_at
+	`, slug, name, description, req.StartsAt.UTC(), req.EndsAt.UTC()).Scan(&room.ID, &room.Slug, &room.Name, &room.Description, &room.CreatedAt, &startsAt, &endsAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, "a room with this slug already exists")
+			return
+		}
+		writeInternalError(w, "could not create event room")
+		return
+	}
+	room.Event = newRoomEvent(startsAt, endsAt, "", time.Now())
+
+	s.logger.Info("event_room_created", observability.Fields{
+		"room_id":   room.ID,
+		"starts_at": room.Event.StartsAt,
+		"ends_at":   room.Event.EndsAt,
+		"user_id":   userID,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{"room": room})
+}
+
+// handleSetRoomEvent moves the window of an event that has not closed yet.
+// Closed events keep their window as a record of when they ran.
+func (s *Server) handleSetRoomEvent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		StartsAt time.Time `json:"starts_at"`
+		EndsAt   time.Time `json:"ends_at"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if err := validateEventWindow(req.StartsAt, req.EndsAt, time.Now()); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	room, err := s.getRoomByID(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+	if room.ArchivedAt != nil {
+		writeConflict(w, "room is archived")
+		return
+	}
+
+	var startsAt, endsAt *time.Time
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE rooms
+		SET event_starts_at = $2,
+			event_ends_at = $3
+		WHERE id = $1
+		  AND event_closed_at IS NULL
+		RETURNING event_starts_at, event_ends_at
+	`, roomID, req.StartsAt.UTC(), req.EndsAt.UTC()).Scan(&startsAt, &endsAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeConflict(w, "event has already closed")
+			return
+		}
+		writeInternalError(w, "could not save room event")
+		return
+	}
+	room.Event = newRoomEvent(startsAt, endsAt, "", time.Now())
+
+	s.logger.Info("room_event_changed", observability.Fields{
+		"room_id":   roomID,
+		"starts_at": room.Event.StartsAt,
+		"ends_at":   room.Event.EndsAt,
+		"user_id":   userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"room": room})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewRoomEvent(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if event := newRoomEvent(nil, nil, "", now); event != nil {
+		t.Fatalf("expected no event for a regular room, got %+v", event)
+	}
+
+	starts := now.Add(2 * time.Hour)
+	ends := now.Add(5 * time.Hour)
+	event := newRoomEvent(&starts, &ends, "", now)
+	if event.Status != roomEventUpcoming || event.SecondsUntilStart != 7200 || event.SecondsUntilEnd != 18000 {
+		t.Fatalf("unexpected upcoming event %+v", event)
+	}
+	if !errors.Is(roomEventWriteError(event), errEventNotStarted) {
+		t.Fatalf("expected writes to wait for the start")
+	}
+
+	event = newRoomEvent(&starts, &ends, "", now.Add(3*time.Hour))
+	if event.Status != roomEventLive || event.SecondsUntilStart != 0 || event.SecondsUntilEnd != 7200 {
+		t.Fatalf("unexpected live event %+v", event)
+	}
+	if err := roomEventWriteError(event); err != nil {
+		t.Fatalf("expected live event to accept writes, got %v", err)
+	}
+
+	event = newRoomEvent(&starts, &ends, " Recap. ", ends)
+	if event.Status != roomEventEnded || event.SecondsUntilEnd != 0 || event.Recap != "Recap." {
+		t.Fatalf("unexpected ended event %+v", event)
+	}
+	if !errors.Is(roomEventWriteError(event), errEventEnded) {
+		t.Fatalf("expected writes to stop at the end")
+	}
+	if err := roomEventWriteError(nil); err != nil {
+		t.Fatalf("expected regular rooms to accept writes, got %v", err)
+	}
+}
+
+func TestValidateEventWindow(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		starts time.Time
+		ends   time.Time
+		ok     bool
+	}{
+		{"upcoming", now.Add(time.Hour), now.Add(3 * time.Hour), true},
+		{"already running", now.Add(-time.Hour), now.Add(time.Hour), true},
+		{"missing start", time.Time{}, now.Add(time.Hour), false},
+		{"ends before start", now.Add(2 * time.Hour), now.Add(time.Hour), false},
+		{"already over", now.Add(-3 * time.Hour), now.Add(-time.Hour), false},
+		{"too long", now, now.Add(roomEventMaxLength + time.Hour), false},
+	}
+	for _, tc := range cases {
+		err := validateEventWindow(tc.starts, tc.ends, now)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%s: expected ok=%v, got %v", tc.name, tc.ok, err)
+		}
+	}
+}
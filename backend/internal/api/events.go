@@ -8,52 +8,77 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"personaworlds/backend/internal/common"
 )
 
 const (
-	eventPersonaCreated       = "persona_created"
-	eventPreviewGenerated     = "preview_generated"
-	eventPostApproved         = "post_approved"
-	eventBattleCreated        = "battle_created"
-	eventBattleShared         = "battle_shared"
-	eventPublicProfileViewed  = "public_profile_viewed"
-	eventPublicBattleViewed   = "public_battle_viewed"
-	eventSignupFromShare      = "signup_from_share"
-	eventRemixClick           = "remix_click" // kept for backward compatibility
-	eventRemixClicked         = "remix_clicked"
-	eventRemixStarted         = "remix_started"
-	eventRemixCompleted       = "remix_completed"
-	eventFollowClick          = "follow_click"
-	eventDailyReturn          = "daily_return"
-	eventNotificationClicked  = "notification_clicked"
-	eventTemplateUsedFromFeed = "template_used_from_feed"
+	eventPersonaCreated          = "persona_created"
+	eventPreviewGenerated        = "preview_generated"
+	eventPostApproved            = "post_approved"
+	eventBattleCreated           = "battle_created"
+	eventBattleShared            = "battle_shared"
+	eventPublicProfileViewed     = "public_profile_viewed"
+	eventPublicBattleViewed      = "public_battle_viewed"
+	eventSignupFromShare         = "signup_from_share"
+	eventSignupFromProfileLink   = "signup_from_profile_link"
+	eventSignupFromReferral      = "signup_from_referral"
+	eventGuestIntentCompleted    = "guest_intent_completed"
+	eventRemixClick              = "remix_click" // kept for backward compatibility
+	eventRemixClicked            = "remix_clicked"
+	eventRemixStarted            = "remix_started"
+	eventRemixCompleted          = "remix_completed"
+	eventFollowClick             = "follow_click"
+	eventDailyReturn             = "daily_return"
+	eventNotificationClicked     = "notification_clicked"
+	eventTemplateUsedFromFeed    = "template_used_from_feed"
+	eventPostReacted             = "post_reacted"
+	eventPersonaQuestionAsked    = "persona_question_asked"
+	eventBattleChallengeSent     = "battle_challenge_sent"
+	eventBattleChallengeAccepted = "battle_challenge_accepted"
+	eventBattleCardClicked       = "battle_card_clicked"
+	eventBattleVoted             = "battle_voted"
+	eventExperimentExposure      = "experiment_exposure"
 )
 
 var (
 	errUnsupportedEventName = errors.New("unsupported event_name")
 	supportedEventNames     = map[string]struct{}{
-		eventPersonaCreated:       {},
-		eventPreviewGenerated:     {},
-		eventPostApproved:         {},
-		eventBattleCreated:        {},
-		eventBattleShared:         {},
-		eventPublicProfileViewed:  {},
-		eventPublicBattleViewed:   {},
-		eventSignupFromShare:      {},
-		eventRemixClick:           {},
-		eventRemixClicked:         {},
-		eventRemixStarted:         {},
-		eventRemixCompleted:       {},
-		eventFollowClick:          {},
-		eventDailyReturn:          {},
-		eventNotificationClicked:  {},
-		eventTemplateUsedFromFeed: {},
+		eventPersonaCreated:          {},
+		eventPreviewGenerated:        {},
+		eventPostApproved:            {},
+		eventBattleCreated:           {},
+		eventBattleShared:            {},
+		eventPublicProfileViewed:     {},
+		eventPublicBattleViewed:      {},
+		eventSignupFromShare:         {},
+		eventSignupFromProfileLink:   {},
+		eventSignupFromReferral:      {},
+		eventGuestIntentCompleted:    {},
+		eventRemixClick:              {},
+		eventRemixClicked:            {},
+		eventRemixStarted:            {},
+		eventRemixCompleted:          {},
+		eventFollowClick:             {},
+		eventDailyReturn:             {},
+		eventNotificationClicked:     {},
+		eventTemplateUsedFromFeed:    {},
+		eventPostReacted:             {},
+		eventPersonaQuestionAsked:    {},
+		eventBattleChallengeSent:     {},
+		eventBattleChallengeAccepted: {},
+		eventBattleCardClicked:       {},
+		eventBattleVoted:             {},
+		eventExperimentExposure:      {},
 	}
 	analyticsSummaryEvents = []string{
 		eventBattleShared,
 		eventPublicProfileViewed,
 		eventPublicBattleViewed,
 		eventSignupFromShare,
+		eventSignupFromProfileLink,
+		eventSignupFromReferral,
+		eventGuestIntentCompleted,
 		eventPersonaCreated,
 		eventPreviewGenerated,
 		eventPostApproved,
@@ -66,6 +91,24 @@ var (
 		eventDailyReturn,
 		eventNotificationClicked,
 		eventTemplateUsedFromFeed,
+		eventPostReacted,
+		eventPersonaQuestionAsked,
+		eventBattleChallengeSent,
+		eventBattleChallengeAccepted,
+		eventBattleCardClicked,
+		eventBattleVoted,
+		eventExperimentExposure,
+	}
+	// serverOnlyEventNames are recorded by the server after verification
+	// (signups, signed card click-throughs) and cannot be posted by clients.
+	serverOnlyEventNames = map[string]struct{}{
+		eventSignupFromShare:       {},
+		eventSignupFromProfileLink: {},
+		eventSignupFromReferral:    {},
+		eventGuestIntentCompleted:  {},
+		eventBattleCardClicked:     {},
+		eventBattleVoted:           {},
+		eventExperimentExposure:    {},
 	}
 )
 
@@ -143,6 +186,11 @@ func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := serverOnlyEventNames[strings.ToLower(strings.TrimSpace(req.EventName))]; ok {
+		writeBadRequest(w, "unsupported event_name")
+		return
+	}
+
 	if err := s.logEventFromRequest(r, req.EventName, req.Metadata); err != nil {
 		if errors.Is(err, errUnsupportedEventName) {
 			writeBadRequest(w, "unsupported event_name")
@@ -156,7 +204,13 @@ func (s *Server) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleAnalyticsSummary(w http.ResponseWriter, r *http.Request) {
-	if _, ok := s.requireUserID(w, r); !ok {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	location, err := s.userLocation(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not compute analytics summary")
 		return
 	}
 
@@ -171,11 +225,26 @@ func (s *Server) handleAnalyticsSummary(w http.ResponseWriter, r *http.Request)
 		writeInternalError(w, "could not compute analytics summary")
 		return
 	}
+	uniqueViews7d, err := s.countUniqueViewsSince(r.Context(), now.Add(-6*24*time.Hour))
+	if err != nil {
+		writeInternalError(w, "could not compute analytics summary")
+		return
+	}
+	cardClicks7d, err := s.countBattleCardClicksByVariant(r.Context(), now.Add(-7*24*time.Hour))
+	if err != nil {
+		writeInternalError(w, "could not compute analytics summary")
+		return
+	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"generated_at": now.Format(time.RFC3339),
 		"last_24h":     last24h,
 		"last_7d":      last7d,
+		"windows": map[string]common.DateWindow{
+			"last_24h":        common.NewDateWindow(now.Add(-24*time.Hour), now, location),
+			"last_7d":         common.NewDateWindow(now.Add(-7*24*time.Hour), now, location),
+			"unique_views_7d": utcDaysWindow(now, 7, location),
+		},
 		"funnel_7d": map[string]int{
 			"share":   last7d[eventBattleShared],
 			"view":    last7d[eventPublicProfileViewed],
@@ -189,6 +258,8 @@ func (s *Server) handleAnalyticsSummary(w http.ResponseWriter, r *http.Request)
 			"remix_started":   last7d[eventRemixStarted],
 			"remix_completed": last7d[eventRemixCompleted],
 		},
+		"unique_views_7d": uniqueViews7d,
+		"card_clicks_7d":  cardClicks7d,
 		"retention_7d": map[string]int{
 			"daily_return":            last7d[eventDailyReturn],
 			"notification_clicked":    last7d[eventNotificationClicked],
@@ -235,6 +306,48 @@ func (s *Server) countEventsSince(ctx context.Context, since time.Time) (map[str
 	return counts, nil
 }
 
+// utcDaysWindow is the local view of the last days UTC calendar days up to
+// and including now's, which is how view_date based counts are bucketed.
+func utcDaysWindow(now time.Time, days int, location *time.Location) common.DateWindow {
+	end := common.StartOfDay(now, time.UTC).AddDate(0, 0, 1)
+	return common.NewDateWindow(end.AddDate(0, 0, -days), end, location)
+}
+
+// countBattleCardClicksByVariant counts signed card click-throughs per card
+// layout, with every known variant present.
+func (s *Server) countBattleCardClicksByVariant(ctx context.Context, since time.Time) (map[string]int, error) {
+	counts := make(map[string]int, len(battleCardVariants))
+	for _, variant := range battleCardVariants {
+		counts[variant] = 0
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT COALESCE(metadata->>'variant', ''), COUNT(*)::int
+		FROM events
+		WHERE event_name = $1
+		  AND created_at >= $2
+		GROUP BY 1
+	`, eventBattleCardClicked, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			variant string
+			count   int
+		)
+		if err := rows.Scan(&variant, &count); err != nil {
+			return nil, err
+		}
+		if _, ok := battleCardLayouts[variant]; ok {
+			counts[variant] = count
+		}
+	}
+	return counts, rows.Err()
+}
+
 func sanitizeEventMetadata(metadata map[string]any) map[string]any {
 	if metadata == nil {
 		return map[string]any{}
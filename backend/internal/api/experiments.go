@@ -0,0 +1,451 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/experiments"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+var errExperimentVariantsLocked = errors.New("variants cannot change after users were exposed")
+
+// exposeExperiment returns the variant userID sees in experiment id and logs
+// their first exposure, both in experiment_exposures and as an
+// experiment_exposure event. Users the exposure rules leave out, and anyone
+// once the experiment is not running, get false and should see the default
+// experience. It returns pgx.ErrNoRows for an unknown experiment.
+func (s *Server) exposeExperiment(ctx context.Context, userID, id string) (string, bool, error) {
+	experiment, err := experiments.Load(ctx, s.db, id)
+	if err != nil {
+		return "", false, err
+	}
+	if experiment.Status != experiments.StatusRunning {
+		return "", false, nil
+	}
+
+	var variant string
+	err = s.db.QueryRow(ctx, `
+		SELECT variant
+		FROM experiment_exposures
+		WHERE experiment_id = $1
+		  AND user_id = $2
+	`, id, userID).Scan(&variant)
+	if err == nil {
+		return variant, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", false, err
+	}
+
+	subject := experiments.Subject{UserID: userID}
+	if experiment.NewUsersOnly {
+		if err := s.db.QueryRow(ctx, `
+			SELECT created_at
+			FROM users
+			WHERE id = $1
+		`, userID).Scan(&subject.SignedUpAt); err != nil {
+			return "", false, err
+		}
+	}
+	variant, ok := experiments.Assign(experiment, subject)
+	if !ok {
+		return "", false, nil
+	}
+
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO experiment_exposures(experiment_id, user_id, variant)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (experiment_id, user_id) DO NOTHING
+	`, id, userID, variant)
+	if err != nil {
+		return "", false, err
+	}
+	if tag.RowsAffected() == 0 {
+		// A concurrent request exposed the user first; assignment is
+		// deterministic, so it recorded the same variant.
+		return variant, true, nil
+	}
+	if err := s.insertEvent(ctx, userID, eventExperimentExposure, map[string]any{
+		"experiment_id": id,
+		"variant":       variant,
+	}); err != nil {
+		s.logger.Warn("experiment_exposure_event_failed", observability.Fields{
+			"experiment_id": id,
+			"user_id":       userID,
+			"error":         err.Error(),
+		})
+	}
+	return variant, true, nil
+}
+
+// handleGetExperimentAssignment tells the client which variant to render
+// and counts the call as the user's exposure.
+func (s *Server) handleGetExperimentAssignment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	id, err := experiments.NormalizeID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	variant, enrolled, err := s.exposeExperiment(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "experiment not found")
+			return
+		}
+		writeInternalError(w, "could not assign experiment")
+		return
+	}
+
+	response := map[string]any{
+		"experiment_id": id,
+		"enrolled":      enrolled,
+	}
+	if enrolled {
+		response["variant"] = variant
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleListExperiments(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id
+		FROM experiments
+		ORDER BY created_at DESC, id ASC
+	`)
+	if err != nil {
+		writeInternalError(w, "could not list experiments")
+		return
+	}
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			writeInternalError(w, "could not list experiments")
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list experiments")
+		return
+	}
+
+	list := make([]experiments.Experiment, 0, len(ids))
+	for _, id := range ids {
+		experiment, err := experiments.Load(r.Context(), s.db, id)
+		if err != nil {
+			writeInternalError(w, "could not load experiment")
+			return
+		}
+		list = append(list, experiment)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"experiments": list})
+}
+
+type experimentRequest struct {
+	Description      string                `json:"description"`
+	Variants         []experiments.Variant `json:"variants"`
+	TrafficPercent   *int                  `json:"traffic_percent"`
+	NewUsersOnly     bool                  `json:"new_users_only"`
+	ConversionEvents []string              `json:"conversion_events"`
+	Status           string                `json:"status"`
+}
+
+// experiment validates the request as the definition of experiment id.
+func (req experimentRequest) experiment(id string) (experiments.Experiment, error) {
+	experiment := experiments.Experiment{
+		ID:             id,
+		Description:    strings.TrimSpace(req.Description),
+		Variants:       req.Variants,
+		TrafficPercent: 100,
+		NewUsersOnly:   req.NewUsersOnly,
+		Status:         strings.ToLower(strings.TrimSpace(req.Status)),
+	}
+	if req.TrafficPercent != nil {
+		experiment.TrafficPercent = *req.TrafficPercent
+	}
+	if experiment.Status == "" {
+		experiment.Status = experiments.StatusDraft
+	}
+	if len([]rune(experiment.Description)) > 280 {
+		return experiments.Experiment{}, errors.New("description must be at most 280 characters")
+	}
+	seen := map[string]struct{}{}
+	for _, raw := range req.ConversionEvents {
+		event := strings.ToLower(strings.TrimSpace(raw))
+		if _, ok := supportedEventNames[event]; !ok || event == eventExperimentExposure {
+			return experiments.Experiment{}, errors.New("unsupported conversion event " + event)
+		}
+		if _, ok := seen[event]; ok {
+			continue
+		}
+		seen[event] = struct{}{}
+		experiment.ConversionEvents = append(experiment.ConversionEvents, event)
+	}
+	if err := experiment.Validate(); err != nil {
+		return experiments.Experiment{}, err
+	}
+	return experiment, nil
+}
+
+// handleSetExperiment creates or replaces an experiment. Setting status to
+// running starts it (started_at is kept from the first start) and stopped
+// ends exposures; the report only counts conversions up to stopped_at.
+func (s *Server) handleSetExperiment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id, err := experiments.NormalizeID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req experimentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	experiment, err := req.experiment(id)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if err := s.saveExperiment(r.Context(), userID, experiment); err != nil {
+		if errors.Is(err, errExperimentVariantsLocked) {
+			writeConflict(w, err.Error())
+			return
+		}
+		writeInternalError(w, "could not save experiment")
+		return
+	}
+	saved, err := experiments.Load(r.Context(), s.db, id)
+	if err != nil {
+		writeInternalError(w, "could not load experiment")
+		return
+	}
+
+	s.logger.Info("experiment_changed", observability.Fields{
+		"experiment_id":   id,
+		"status":          saved.Status,
+		"traffic_percent": saved.TrafficPercent,
+		"user_id":         userID,
+		"request_id":      requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"experiment": saved})
+}
+
+func (s *Server) saveExperiment(ctx context.Context, userID string, experiment experiments.Experiment) error {
+	variants, err := json.Marshal(experiment.Variants)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current []byte
+	err = tx.QueryRow(ctx, `
+		SELECT variants
+		FROM experiments
+		WHERE id = $1
+		FOR UPDATE
+	`, experiment.ID).Scan(&current)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		var existing []experiments.Variant
+		if err := json.Unmarshal(current, &existing); err != nil {
+			return err
+		}
+		if !sameExperimentVariants(existing, experiment.Variants) {
+			var exposed bool
+			if err := tx.QueryRow(ctx, `
+				SELECT EXISTS(SELECT 1 FROM experiment_exposures WHERE experiment_id = $1)
+			`, experiment.ID).Scan(&exposed); err != nil {
+				return err
+			}
+			if exposed {
+				return errExperimentVariantsLocked
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO experiments(id, description, variants, traffic_percent, new_users_only, conversion_events, status, started_at, stopped_at, updated_by)
+		VALUES (
+			$1, $2, $3::jsonb, $4, $5, $6, $7,
+			CASE WHEN $7 = 'running' THEN NOW() END,
+			CASE WHEN $7 = 'stopped' THEN NOW() END,
+			$8
+		)
+		ON CONFLICT (id)
+		DO UPDATE SET
+			description = EXCLUDED.description,
+			variants = EXCLUDED.variants,
+			traffic_percent = EXCLUDED.traffic_percent,
+			new_users_only = EXCLUDED.new_users_only,
+			conversion_events = EXCLUDED.conversion_events,
+			status = EXCLUDED.status,
+			started_at = CASE WHEN EXCLUDED.status = 'running' THEN COALESCE(experiments.started_at, NOW()) ELSE experiments.started_at END,
+			stopped_at = CASE
+				WHEN EXCLUDED.status = 'stopped' THEN COALESCE(experiments.stopped_at, NOW())
+				WHEN EXCLUDED.status = 'running' THEN NULL
+				ELSE experiments.stopped_at
+			END,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, experiment.ID, experiment.Description, variants, experiment.TrafficPercent, experiment.NewUsersOnly, experiment.ConversionEvents, experiment.Status, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func sameExperimentVariants(a, b []experiments.Variant) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// handleGetExperimentReport compares each variant's conversion rate on the
+// experiment's funnel events with the control (the first variant). A user
+// converts when they log the event at or after their first exposure, and
+// before the experiment was stopped. ?event= narrows the report to one
+// supported event, which need not be among conversion_events.
+func (s *Server) handleGetExperimentReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	id, err := experiments.NormalizeID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	experiment, err := experiments.Load(r.Context(), s.db, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "experiment not found")
+			return
+		}
+		writeInternalError(w, "could not load experiment")
+		return
+	}
+	events := experiment.ConversionEvents
+	if raw := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("event"))); raw != "" {
+		if _, ok := supportedEventNames[raw]; !ok || raw == eventExperimentExposure {
+			writeBadRequest(w, "unsupported event")
+			return
+		}
+		events = []string{raw}
+	}
+
+	counts, err := s.experimentCounts(r.Context(), experiment, events)
+	if err != nil {
+		writeInternalError(w, "could not compute experiment report")
+		return
+	}
+	exposed := 0
+	for _, count := range counts {
+		exposed += count.Exposed
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"experiment":   experiment,
+		"generated_at": time.Now().UTC().Format(time.RFC3339),
+		"exposed":      exposed,
+		"events":       events,
+		"variants":     experiments.Compare(experiment.Variants, events, counts),
+	})
+}
+
+func (s *Server) experimentCounts(ctx context.Context, experiment experiments.Experiment, events []string) ([]experiments.VariantCounts, error) {
+	byVariant := map[string]*experiments.VariantCounts{}
+	rows, err := s.db.Query(ctx, `
+		SELECT variant, COUNT(*)::int
+		FROM experiment_exposures
+		WHERE experiment_id = $1
+		GROUP BY variant
+	`, experiment.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		count := &experiments.VariantCounts{Conversions: map[string]int{}}
+		if err := rows.Scan(&count.Variant, &count.Exposed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		byVariant[count.Variant] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(ctx, `
+		SELECT x.variant, e.event_name, COUNT(DISTINCT x.user_id)::int
+		FROM experiment_exposures x
+		JOIN events e
+			ON e.user_id = x.user_id
+		   AND e.event_name = ANY($2::text[])
+		   AND e.created_at >= x.exposed_at
+		   AND ($3::timestamptz IS NULL OR e.created_at < $3)
+		WHERE x.experiment_id = $1
+		GROUP BY x.variant, e.event_name
+	`, experiment.ID, events, experiment.StoppedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			variant, event string
+			users          int
+		)
+		if err := rows.Scan(&variant, &event, &users); err != nil {
+			return nil, err
+		}
+		if count, ok := byVariant[variant]; ok {
+			count.Conversions[event] = users
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := make([]experiments.VariantCounts, 0, len(byVariant))
+	for _, count := range byVariant {
+		counts = append(counts, *count)
+	}
+	return counts, nil
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+
+	"personaworlds/backend/internal/experiments"
+)
+
+func TestExperimentRequestDefaults(t *testing.T) {
+	req := experimentRequest{
+		Variants:         []experiments.Variant{{Key: "control", Weight: 50}, {Key: "bold_copy", Weight: 50}},
+		ConversionEvents: []string{" Remix_Clicked ", "remix_clicked", eventRemixCompleted},
+	}
+	experiment, err := req.experiment("remix_cta_copy")
+	if err != nil {
+		t.Fatalf("experiment: %v", err)
+	}
+	if experiment.Status != experiments.StatusDraft || experiment.TrafficPercent != 100 {
+		t.Fatalf("expected a draft at full traffic, got %+v", experiment)
+	}
+	if len(experiment.ConversionEvents) != 2 || experiment.ConversionEvents[0] != eventRemixClicked {
+		t.Fatalf("expected normalized, deduplicated events, got %v", experiment.ConversionEvents)
+	}
+
+	for _, event := range []string{"page_scrolled", eventExperimentExposure} {
+		req.ConversionEvents = []string{event}
+		if _, err := req.experiment("remix_cta_copy"); err == nil {
+			t.Fatalf("expected %q to be rejected as a conversion event", event)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"personaworlds/backend/internal/flags"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// flagEnabled reports whether key is on for subject in this process's
+// environment. A failed reload keeps the last loaded flags, or the defaults.
+func (s *Server) flagEnabled(ctx context.Context, key, subject string) bool {
+	set, err := s.flags.Load(ctx, s.cfg.FlagsSyncEvery, s.db)
+	if err != nil {
+		s.logger.Warn("feature_flags_load_failed", observability.Fields{"error": err.Error()})
+	}
+	return set.Enabled(key, subject)
+}
+
+// flagEnvironment is the environment an admin request edits: the one named
+// in the query, or this process's own.
+func (s *Server) flagEnvironment(r *http.Request) string {
+	if environment := strings.TrimSpace(r.URL.Query().Get("environment")); environment != "" {
+		return environment
+	}
+	return s.flags.Environment()
+}
+
+func (s *Server) handleListFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	environment := s.flagEnvironment(r)
+	set, err := flags.Load(r.Context(), s.db, environment)
+	if err != nil {
+		writeInternalError(w, "could not load feature flags")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"environment": environment,
+		"flags":       set.List(environment),
+	})
+}
+
+func (s *Server) handleSetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	key, err := flags.NormalizeKey(chi.URLParam(r, "key"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Enabled        bool    `json:"enabled"`
+		RolloutPercent *int    `json:"rollout_percent"`
+		Description    *string `json:"description"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	rollout := 100
+	if req.RolloutPercent != nil {
+		rollout = *req.RolloutPercent
+	}
+	if err := flags.ValidateRollout(rollout); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	description := flags.Defaults[key].Description
+	if req.Description != nil {
+		description = strings.TrimSpace(*req.Description)
+	}
+	if len([]rune(description)) > 280 {
+		writeBadRequest(w, "description must be at most 280 characters")
+		return
+	}
+
+	environment := s.flagEnvironment(r)
+	var flag flags.Flag
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO feature_flags(key, environment, enabled, rollout_percent, description, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (key, environment)
+		DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			rollout_percent = EXCLUDED.rollout_percent,
+			description = EXCLUDED.description,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+		RETURNING key, environment, enabled, rollout_percent, description, COALESCE(updated_by::text, ''), updated_at
+	`, key, environment, req.Enabled, rollout, description, userID).Scan(
+		&flag.Key,
+		&flag.Environment,
+		&flag.Enabled,
+		&flag.RolloutPercent,
+		&flag.Description,
+		&flag.UpdatedBy,
+		&flag.UpdatedAt,
+	)
+	if err != nil {
+		writeInternalError(w, "could not save feature flag")
+		return
+	}
+
+	s.logFeatureFlagChange(r, "set", flag.Key, environment, userID, observability.Fields{
+		"enabled":         flag.Enabled,
+		"rollout_percent": flag.RolloutPercent,
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"flag": flag})
+}
+
+// handleResetFeatureFlag deletes the environment's row, so the flag falls
+// back to its default.
+func (s *Server) handleResetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	key, err := flags.NormalizeKey(chi.URLParam(r, "key"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	environment := s.flagEnvironment(r)
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM feature_flags
+		WHERE key = $1
+		  AND environment = $2
+	`, key, environment)
+	if err != nil {
+		writeInternalError(w, "could not reset feature flag")
+		return
+	}
+	if ct.RowsAffected() == 0 {
+		writeNotFound(w, "feature flag not found")
+		return
+	}
+
+	s.logFeatureFlagChange(r, "reset", key, environment, userID, nil)
+	writeJSON(w, http.StatusOK, map[string]any{"reset": true})
+}
+
+// logFeatureFlagChange records an admin toggle and makes this process pick
+// it up immediately; other processes follow within FLAGS_SYNC_EVERY.
+func (s *Server) logFeatureFlagChange(r *http.Request, action, key, environment, userID string, extra observability.Fields) {
+	if environment == s.flags.Environment() {
+		s.flags.Invalidate()
+	}
+	fields := observability.Fields{
+		"action":      action,
+		"key":         key,
+		"environment": environment,
+		"user_id":     userID,
+		"request_id":  requestIDFromRequest(r),
+	}
+	for name, value := range extra {
+		fields[name] = value
+	}
+	s.logger.Info("feature_flag_changed", fields)
+}
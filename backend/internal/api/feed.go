@@ -26,7 +26,9 @@ type FeedBattle struct {
 	CreatedAt   time.Time           `json:"created_at"`
 	Shares      int                 `json:"shares"`
 	Remixes     int                 `json:"remixes"`
+	Reactions   PostReactionCounts  `json:"reactions"`
 	Template    *FeedBattleTemplate `json:"template,omitempty"`
+	Featured    bool                `json:"featured,omitempty"`
 }
 
 type FeedTemplate struct {
@@ -40,19 +42,31 @@ type FeedTemplate struct {
 	IsTrending  bool      `json:"is_trending"`
 }
 
+// FeedItem IDs are stable across requests ("battle:<id>", "template:<id>",
+// "persona:<id>"),
+// so clients can merge pages and since-polls by ID. UpdatedAt moves when the
+// item gains replies, reactions, shares, remixes or template uses.
 type FeedItem struct {
-	ID       string        `json:"id"`
-	Kind     string        `json:"kind"`
-	Reason   string        `json:"reason"`
-	Reasons  []string      `json:"reasons"`
-	Score    float64       `json:"score"`
-	Battle   *FeedBattle   `json:"battle,omitempty"`
-	Template *FeedTemplate `json:"template,omitempty"`
+	ID        string        `json:"id"`
+	Kind      string        `json:"kind"`
+	Reason    string        `json:"reason"`
+	Reasons   []string      `json:"reasons"`
+	Score     float64       `json:"score"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Battle    *FeedBattle   `json:"battle,omitempty"`
+	Template  *FeedTemplate `json:"template,omitempty"`
+	Persona   *FeedPersona  `json:"persona,omitempty"`
 }
 
+// FeedResponse.AsOf is the time the feed was ranked at; clients pass it back
+// as since to poll for changes. ColdStart is set while the feed recommends
+// battles and personas to a user who follows no one.
 type FeedResponse struct {
-	Items             []FeedItem    `json:"items"`
-	HighlightTemplate *FeedTemplate `json:"highlight_template,omitempty"`
+	Items             []FeedItem     `json:"items"`
+	HighlightTemplate *FeedTemplate  `json:"highlight_template,omitempty"`
+	NextCursor        string         `json:"next_cursor,omitempty"`
+	AsOf              time.Time      `json:"as_of"`
+	ColdStart         *FeedColdStart `json:"cold_start,omitempty"`
 }
 
 type feedBattleCandidate struct {
@@ -65,10 +79,32 @@ type feedBattleCandidate struct {
 	CreatedAt    time.Time
 	Shares       int
 	Remixes      int
+	Reactions    PostReactionCounts
 	TemplateID   string
 	TemplateName string
+	UpdatedAt    time.Time
+	Featured     bool
 }
 
+// feedAffinity is one precomputed per-user signal (see the worker's
+// feed_affinity task). Weight is normalized to (0, 1] within its kind.
+type feedAffinity struct {
+	Weight float64
+	Reason string
+}
+
+type feedAffinities struct {
+	Rooms     map[string]feedAffinity
+	Templates map[string]feedAffinity
+	Personas  map[string]feedAffinity
+}
+
+const (
+	feedRoomAffinityBoost     = 12
+	feedTemplateAffinityBoost = 18
+	feedPersonaAffinityBoost  = 10
+)
+
 type feedTemplateCandidate struct {
 	TemplateID  string
 	Name        string
@@ -78,6 +114,7 @@ type feedTemplateCandidate struct {
 	CreatedAt   time.Time
 	UsageCount  int
 	IsTrending  bool
+	UpdatedAt   time.Time
 }
 
 func (s *Server) handleGetFeed(w http.ResponseWriter, r *http.Request) {
@@ -86,7 +123,13 @@ func (s *Server) handleGetFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	feed, err := s.buildFeed(r.Context(), userID)
+	page, err := parseFeedPage(r.URL.Query())
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	feed, err := s.buildFeed(r.Context(), userID, page)
 	if err != nil {
 		writeInternalError(w, "could not build feed")
 		return
@@ -95,22 +138,63 @@ func (s *Server) handleGetFeed(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, feed)
 }
 
-func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, error) {
-	followedBattles, err := s.listFollowedBattlesForFeed(ctx, userID, 20)
+func (s *Server) buildFeed(ctx context.Context, userID string, page feedPage) (FeedResponse, error) {
+	now := page.rankedAt()
+
+	followedBattles, err := s.listFollowedBattlesForFeed(ctx, userID, feedBattleCandidateLimit)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+
+	trendingBattles, err := s.listTrendingBattlesForFeed(ctx, userID, feedBattleCandidateLimit)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+
+	templates, err := s.listNewTemplatesForFeed(ctx, feedTemplateCandidateLimit)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+
+	affinities, err := s.loadFeedAffinities(ctx, userID)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+
+	affinityBattles, err := s.listAffinityBattlesForFeed(ctx, userID, affinities, feedBattleCandidateLimit)
 	if err != nil {
 		return FeedResponse{}, err
 	}
 
-	trendingBattles, err := s.listTrendingBattlesForFeed(ctx, userID, 20)
+	battlesOfWeek, err := s.listBattlesOfWeekForFeed(ctx)
 	if err != nil {
 		return FeedResponse{}, err
 	}
 
-	templates, err := s.listNewTemplatesForFeed(ctx, 12)
+	watchedBattles, err := s.listWatchedBattlesForFeed(ctx, userID)
 	if err != nil {
 		return FeedResponse{}, err
 	}
 
+	coldStart, err := s.loadFeedColdStart(ctx, userID)
+	if err != nil {
+		return FeedResponse{}, err
+	}
+	var (
+		coldStartBattles  []feedBattleCandidate
+		coldStartPersonas []feedPersonaCandidate
+	)
+	if coldStart != nil {
+		coldStartBattles, err = s.listColdStartBattlesForFeed(ctx, userID, coldStart.RoomIDs, feedColdStartBattleLimit)
+		if err != nil {
+			return FeedResponse{}, err
+		}
+		coldStartPersonas, err = s.listColdStartPersonasForFeed(ctx, userID, coldStart.RoomIDs, feedColdStartPersonaLimit)
+		if err != nil {
+			return FeedResponse{}, err
+		}
+	}
+
 	highlightTemplate := selectHighlightTemplate(templates)
 	if highlightTemplate != nil {
 		for idx := range templates {
@@ -141,6 +225,9 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 			return
 		}
 
+		boost, personalReasons := personalizeFeedBattle(candidate, affinities)
+		score += boost
+
 		key := "battle:" + strings.TrimSpace(candidate.BattleID)
 		item, exists := itemsByKey[key]
 		if !exists {
@@ -154,6 +241,8 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 				CreatedAt:   candidate.CreatedAt,
 				Shares:      candidate.Shares,
 				Remixes:     candidate.Remixes,
+				Reactions:   candidate.Reactions,
+				Featured:    candidate.Featured,
 			}
 			if strings.TrimSpace(candidate.TemplateID) != "" {
 				battle.Template = &FeedBattleTemplate{
@@ -162,14 +251,18 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 				}
 			}
 			item = &FeedItem{
-				ID:     key,
-				Kind:   "battle",
-				Reason: strings.TrimSpace(reason),
-				Score:  roundFeedScore(score),
-				Battle: battle,
+				ID:        key,
+				Kind:      "battle",
+				Reason:    strings.TrimSpace(reason),
+				Score:     roundFeedScore(score),
+				UpdatedAt: feedUpdatedAt(candidate.CreatedAt, candidate.UpdatedAt),
+				Battle:    battle,
 			}
 			itemsByKey[key] = item
 		} else {
+			if updatedAt := feedUpdatedAt(candidate.CreatedAt, candidate.UpdatedAt); updatedAt.After(item.UpdatedAt) {
+				item.UpdatedAt = updatedAt
+			}
 			if item.Battle != nil {
 				if candidate.Shares > item.Battle.Shares {
 					item.Battle.Shares = candidate.Shares
@@ -177,6 +270,12 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 				if candidate.Remixes > item.Battle.Remixes {
 					item.Battle.Remixes = candidate.Remixes
 				}
+				if candidate.Reactions.score() > item.Battle.Reactions.score() {
+					item.Battle.Reactions = candidate.Reactions
+				}
+				if candidate.Featured {
+					item.Battle.Featured = true
+				}
 			}
 			if score > item.Score {
 				item.Score = roundFeedScore(score)
@@ -184,6 +283,9 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 			}
 		}
 		addReason(key, reason)
+		for _, personalReason := range personalReasons {
+			addReason(key, personalReason)
+		}
 	}
 
 	addTemplate := func(candidate feedTemplateCandidate, reason string, score float64) {
@@ -204,11 +306,12 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 				IsTrending:  candidate.IsTrending,
 			}
 			item = &FeedItem{
-				ID:       key,
-				Kind:     "template",
-				Reason:   strings.TrimSpace(reason),
-				Score:    roundFeedScore(score),
-				Template: template,
+				ID:        key,
+				Kind:      "template",
+				Reason:    strings.TrimSpace(reason),
+				Score:     roundFeedScore(score),
+				UpdatedAt: feedUpdatedAt(candidate.CreatedAt, candidate.UpdatedAt),
+				Template:  template,
 			}
 			itemsByKey[key] = item
 		} else if score > item.Score {
@@ -218,16 +321,71 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 		addReason(key, reason)
 	}
 
+	addPersona := func(candidate feedPersonaCandidate, reason string, score float64) {
+		if strings.TrimSpace(candidate.PersonaID) == "" {
+			return
+		}
+		key := "persona:" + strings.TrimSpace(candidate.PersonaID)
+		if _, exists := itemsByKey[key]; !exists {
+			itemsByKey[key] = &FeedItem{
+				ID:        key,
+				Kind:      "persona",
+				Reason:    strings.TrimSpace(reason),
+				Score:     roundFeedScore(score),
+				UpdatedAt: candidate.CreatedAt,
+				Persona: &FeedPersona{
+					PersonaID:     candidate.PersonaID,
+					Name:          candidate.Name,
+					Slug:          candidate.Slug,
+					Bio:           common.TruncateRunes(candidate.Bio, 220),
+					Followers:     candidate.Followers,
+					RecentBattles: candidate.RecentBattles,
+					RoomID:        candidate.RoomID,
+					RoomName:      candidate.RoomName,
+					CreatedAt:     candidate.CreatedAt,
+				},
+			}
+		}
+		addReason(key, reason)
+	}
+
 	for _, battle := range followedBattles {
-		addBattle(battle, "followed_persona", scoreFollowedBattle(battle.CreatedAt, battle.Shares, battle.Remixes))
+		addBattle(battle, "followed_persona", scoreFollowedBattle(now, battle.CreatedAt, battle.Shares, battle.Remixes, battle.Reactions.score()))
 	}
 
 	for _, battle := range trendingBattles {
-		addBattle(battle, "trending_battle", scoreTrendingBattle(battle.CreatedAt, battle.Shares, battle.Remixes))
+		addBattle(battle, "trending_battle", scoreTrendingBattle(now, battle.CreatedAt, battle.Shares, battle.Remixes, battle.Reactions.score()))
+	}
+
+	for _, battle := range affinityBattles {
+		_, personalReasons := personalizeFeedBattle(battle, affinities)
+		if len(personalReasons) == 0 {
+			continue
+		}
+		addBattle(battle, personalReasons[0], scoreAffinityBattle(now, battle.CreatedAt, battle.Shares, battle.Remixes, battle.Reactions.score()))
+	}
+
+	for _, battle := range battlesOfWeek {
+		addBattle(battle, "battle_of_the_week", scoreBattleOfWeek(now, battle.CreatedAt, battle.Shares, battle.Remixes, battle.Reactions.score()))
+	}
+
+	for _, battle := range watchedBattles {
+		addBattle(battle, "watched_battle", scoreWatchedBattle(now, battle.UpdatedAt, battle.Shares, battle.Remixes, battle.Reactions.score()))
+	}
+
+	if coldStart != nil {
+		for _, battle := range coldStartBattles {
+			reason := coldStartBattleReason(battle, coldStart.RoomIDs)
+			addBattle(battle, reason, scoreColdStartBattle(now, battle.CreatedAt, battle.Shares, battle.Remixes, battle.Reactions.score(), reason))
+		}
+		for _, persona := range coldStartPersonas {
+			reason := coldStartPersonaReason(persona)
+			addPersona(persona, reason, scoreColdStartPersona(persona, reason))
+		}
 	}
 
 	for _, template := range templates {
-		addTemplate(template, "new_template", scoreNewTemplate(template.CreatedAt, template.UsageCount))
+		addTemplate(template, "new_template", scoreNewTemplate(now, template.CreatedAt, template.UsageCount))
 	}
 
 	items := make([]FeedItem, 0, len(itemsByKey))
@@ -244,17 +402,11 @@ func (s *Server) buildFeed(ctx context.Context, userID string) (FeedResponse, er
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		if items[i].Score == items[j].Score {
-			return feedItemCreatedAt(items[i]).After(feedItemCreatedAt(items[j]))
-		}
-		return items[i].Score > items[j].Score
+		return feedItemLess(items[i], items[j])
 	})
 
-	if len(items) > 50 {
-		items = items[:50]
-	}
-
-	response := FeedResponse{Items: items}
+	items, nextCursor := page.apply(items)
+	response := FeedResponse{Items: items, NextCursor: nextCursor, AsOf: now, ColdStart: coldStart}
 	if response.Items == nil {
 		response.Items = []FeedItem{}
 	}
@@ -279,11 +431,23 @@ func (s *Server) listFollowedBattlesForFeed(ctx context.Context, userID string,
 			SELECT
 				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
 				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
-				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
 			FROM events e
 			WHERE e.created_at >= NOW() - INTERVAL '14 days'
 			  AND e.event_name IN ('battle_shared', 'remix_completed')
 			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '14 days'
+			GROUP BY pr.post_id
 		)
 		SELECT
 			p.id::text,
@@ -295,8 +459,17 @@ func (s *Server) listFollowedBattlesForFeed(ctx context.Context, userID string,
 			p.created_at,
 			COALESCE(ec.shares, 0)::int,
 			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
 			COALESCE(p.template_id::text, ''),
-			COALESCE(t.name, '')
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			)
 		FROM posts p
 		JOIN persona_follows pf
 			ON pf.followed_persona_id = p.persona_id
@@ -305,7 +478,9 @@ func (s *Server) listFollowedBattlesForFeed(ctx context.Context, userID string,
 		LEFT JOIN personas pr ON pr.id = p.persona_id
 		LEFT JOIN templates t ON t.id = p.template_id
 		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
 		WHERE p.status = 'PUBLISHED'
+		  AND ((p.visibility = 'public' AND NOT p.hidden_from_rooms) OR p.user_id = $1::uuid)
 		ORDER BY p.created_at DESC
 		LIMIT $2
 	`, strings.TrimSpace(userID), limit)
@@ -327,8 +502,12 @@ func (s *Server) listFollowedBattlesForFeed(ctx context.Context, userID string,
 			&item.CreatedAt,
 			&item.Shares,
 			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
 			&item.TemplateID,
 			&item.TemplateName,
+			&item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -353,11 +532,23 @@ func (s *Server) listTrendingBattlesForFeed(ctx context.Context, userID string,
 			SELECT
 				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
 				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
-				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
 			FROM events e
 			WHERE e.created_at >= NOW() - INTERVAL '14 days'
 			  AND e.event_name IN ('battle_shared', 'remix_completed')
 			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '14 days'
+			GROUP BY pr.post_id
 		)
 		SELECT
 			p.id::text,
@@ -369,14 +560,26 @@ func (s *Server) listTrendingBattlesForFeed(ctx context.Context, userID string,
 			p.created_at,
 			COALESCE(ec.shares, 0)::int,
 			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
 			COALESCE(p.template_id::text, ''),
-			COALESCE(t.name, '')
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			)
 		FROM posts p
 		JOIN rooms rm ON rm.id = p.room_id
 		LEFT JOIN personas pr ON pr.id = p.persona_id
 		LEFT JOIN templates t ON t.id = p.template_id
 		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
 		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
 		  AND p.user_id <> $1::uuid
 		  AND (COALESCE(ec.shares, 0) > 0 OR COALESCE(ec.remixes, 0) > 0)
 		ORDER BY
@@ -402,8 +605,159 @@ func (s *Server) listTrendingBattlesForFeed(ctx context.Context, userID string,
 			&item.CreatedAt,
 			&item.Shares,
 			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
+			&item.TemplateID,
+			&item.TemplateName,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *Server) loadFeedAffinities(ctx context.Context, userID string) (feedAffinities, error) {
+	affinities := feedAffinities{
+		Rooms:     map[string]feedAffinity{},
+		Templates: map[string]feedAffinity{},
+		Personas:  map[string]feedAffinity{},
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT kind, target_id::text, weight, reason
+		FROM user_feed_affinities
+		WHERE user_id = $1::uuid
+	`, strings.TrimSpace(userID))
+	if err != nil {
+		return affinities, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			kind     string
+			targetID string
+			affinity feedAffinity
+		)
+		if err := rows.Scan(&kind, &targetID, &affinity.Weight, &affinity.Reason); err != nil {
+			return affinities, err
+		}
+		switch kind {
+		case "room":
+			affinities.Rooms[targetID] = affinity
+		case "template":
+			affinities.Templates[targetID] = affinity
+		case "persona":
+			affinities.Personas[targetID] = affinity
+		}
+	}
+	return affinities, rows.Err()
+}
+
+func (s *Server) listAffinityBattlesForFeed(ctx context.Context, userID string, affinities feedAffinities, limit int) ([]feedBattleCandidate, error) {
+	if len(affinities.Rooms) == 0 && len(affinities.Templates) == 0 && len(affinities.Personas) == 0 {
+		return []feedBattleCandidate{}, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(ctx, `
+		WITH event_counts AS (
+			SELECT
+				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
+				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
+			FROM events e
+			WHERE e.created_at >= NOW() - INTERVAL '14 days'
+			  AND e.event_name IN ('battle_shared', 'remix_completed')
+			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '14 days'
+			GROUP BY pr.post_id
+		)
+		SELECT
+			p.id::text,
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			COALESCE(p.persona_id::text, ''),
+			COALESCE(pr.name, ''),
+			p.content,
+			p.created_at,
+			COALESCE(ec.shares, 0)::int,
+			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
+			COALESCE(p.template_id::text, ''),
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			)
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN personas pr ON pr.id = p.persona_id
+		LEFT JOIN templates t ON t.id = p.template_id
+		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
+		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND p.user_id <> $1::uuid
+		  AND p.created_at >= NOW() - INTERVAL '14 days'
+		  AND (
+				p.room_id = ANY($2::uuid[])
+				OR p.template_id = ANY($3::uuid[])
+				OR p.persona_id = ANY($4::uuid[])
+		  )
+		ORDER BY p.created_at DESC
+		LIMIT $5
+	`, strings.TrimSpace(userID), feedAffinityIDs(affinities.Rooms), feedAffinityIDs(affinities.Templates), feedAffinityIDs(affinities.Personas), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]feedBattleCandidate, 0, limit)
+	for rows.Next() {
+		var item feedBattleCandidate
+		if err := rows.Scan(
+			&item.BattleID,
+			&item.RoomID,
+			&item.RoomName,
+			&item.PersonaID,
+			&item.PersonaName,
+			&item.Content,
+			&item.CreatedAt,
+			&item.Shares,
+			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
 			&item.TemplateID,
 			&item.TemplateName,
+			&item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -415,6 +769,50 @@ func (s *Server) listTrendingBattlesForFeed(ctx context.Context, userID string,
 	return items, nil
 }
 
+func feedAffinityIDs(values map[string]feedAffinity) []string {
+	ids := make([]string, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// personalizeFeedBattle returns the score boost a battle earns from the user's
+// affinities and the matching reason labels, strongest contribution first.
+func personalizeFeedBattle(candidate feedBattleCandidate, affinities feedAffinities) (float64, []string) {
+	type contribution struct {
+		boost  float64
+		reason string
+	}
+	contributions := make([]contribution, 0, 3)
+	if affinity, ok := affinities.Templates[strings.TrimSpace(candidate.TemplateID)]; ok {
+		contributions = append(contributions, contribution{boost: affinity.Weight * feedTemplateAffinityBoost, reason: affinity.Reason})
+	}
+	if affinity, ok := affinities.Rooms[strings.TrimSpace(candidate.RoomID)]; ok {
+		contributions = append(contributions, contribution{boost: affinity.Weight * feedRoomAffinityBoost, reason: affinity.Reason})
+	}
+	if affinity, ok := affinities.Personas[strings.TrimSpace(candidate.PersonaID)]; ok {
+		contributions = append(contributions, contribution{boost: affinity.Weight * feedPersonaAffinityBoost, reason: affinity.Reason})
+	}
+	if len(contributions) == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].boost > contributions[j].boost
+	})
+	total := 0.0
+	reasons := make([]string, 0, len(contributions))
+	for _, item := range contributions {
+		total += item.boost
+		if strings.TrimSpace(item.reason) != "" {
+			reasons = append(reasons, strings.TrimSpace(item.reason))
+		}
+	}
+	return total, reasons
+}
+
 func (s *Server) listNewTemplatesForFeed(ctx context.Context, limit int) ([]feedTemplateCandidate, error) {
 	if limit <= 0 {
 		limit = 12
@@ -431,10 +829,11 @@ func (s *Server) listNewTemplatesForFeed(ctx context.Context, limit int) ([]feed
 			t.turn_count,
 			t.word_limit,
 			t.created_at,
-			COALESCE(usage.usage_count, 0)::int
+			COALESCE(usage.usage_count, 0)::int,
+			GREATEST(t.created_at, usage.last_used_at)
 		FROM templates t
 		LEFT JOIN LATERAL (
-			SELECT COUNT(*)::int AS usage_count
+			SELECT COUNT(*)::int AS usage_count, MAX(p.created_at) AS last_used_at
 			FROM posts p
 			WHERE p.template_id = t.id
 			  AND p.status = 'PUBLISHED'
@@ -460,6 +859,7 @@ func (s *Server) listNewTemplatesForFeed(ctx context.Context, limit int) ([]feed
 			&item.WordLimit,
 			&item.CreatedAt,
 			&item.UsageCount,
+			&item.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -498,24 +898,60 @@ func selectHighlightTemplate(candidates []feedTemplateCandidate) *FeedTemplate {
 	}
 }
 
-func scoreFollowedBattle(createdAt time.Time, shares, remixes int) float64 {
-	ageHours := time.Since(createdAt).Hours()
+func scoreFollowedBattle(now, createdAt time.Time, shares, remixes, reactions int) float64 {
+	ageHours := now.Sub(createdAt).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return 95 + float64(shares*2+remixes*4) + reactionScoreBoost(reactions) - ageHours*0.35
+}
+
+func scoreTrendingBattle(now, createdAt time.Time, shares, remixes, reactions int) float64 {
+	ageHours := now.Sub(createdAt).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return 70 + float64(shares*3+remixes*5) + reactionScoreBoost(reactions) - ageHours*0.25
+}
+
+func scoreAffinityBattle(now, createdAt time.Time, shares, remixes, reactions int) float64 {
+	ageHours := now.Sub(createdAt).Hours()
 	if ageHours < 0 {
 		ageHours = 0
 	}
-	return 95 + float64(shares*2+remixes*4) - ageHours*0.35
+	return 65 + float64(shares*2+remixes*4) + reactionScoreBoost(reactions) - ageHours*0.3
 }
 
-func scoreTrendingBattle(createdAt time.Time, shares, remixes int) float64 {
-	ageHours := time.Since(createdAt).Hours()
+// scoreBattleOfWeek keeps a room's featured battle near the top for its week,
+// below battles from followed personas while those are fresh.
+func scoreBattleOfWeek(now, createdAt time.Time, shares, remixes, reactions int) float64 {
+	ageHours := now.Sub(createdAt).Hours()
 	if ageHours < 0 {
 		ageHours = 0
 	}
-	return 70 + float64(shares*3+remixes*5) - ageHours*0.25
+	return 90 + float64(shares*2+remixes*4) + reactionScoreBoost(reactions) - ageHours*0.15
 }
 
-func scoreNewTemplate(createdAt time.Time, usageCount int) float64 {
-	ageHours := time.Since(createdAt).Hours()
+// scoreWatchedBattle puts battles the user chose to watch above everything
+// else while they are active. It decays from the last turn or reaction, not
+// from creation, so a long battle stays up while it is still moving.
+func scoreWatchedBattle(now, lastActivityAt time.Time, shares, remixes, reactions int) float64 {
+	idleHours := now.Sub(lastActivityAt).Hours()
+	if idleHours < 0 {
+		idleHours = 0
+	}
+	return 130 + float64(shares*2+remixes*4) + reactionScoreBoost(reactions) - idleHours*0.5
+}
+
+func reactionScoreBoost(reactions int) float64 {
+	if reactions <= 0 {
+		return 0
+	}
+	return math.Min(float64(reactions)*0.5, 25)
+}
+
+func scoreNewTemplate(now, createdAt time.Time, usageCount int) float64 {
+	ageHours := now.Sub(createdAt).Hours()
 	if ageHours < 0 {
 		ageHours = 0
 	}
@@ -537,6 +973,7 @@ func sortedFeedReasons(reasonSet map[string]struct{}) []string {
 	}
 
 	rank := map[string]int{
+		"watched_battle":   0,
 		"followed_persona": 1,
 		"trending_battle":  2,
 		"new_template":     3,
@@ -562,5 +999,8 @@ func feedItemCreatedAt(item FeedItem) time.Time {
 	if item.Template != nil {
 		return item.Template.CreatedAt
 	}
+	if item.Persona != nil {
+		return item.Persona.CreatedAt
+	}
 	return time.Time{}
 }
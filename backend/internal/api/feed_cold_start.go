@@ -0,0 +1,427 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	feedColdStartBattleLimit  = 30
+	feedColdStartPersonaLimit = 12
+	maxSignupRoomInterests    = 5
+
+	feedReasonColdStartRoom    = "cold_start_room_interest"
+	feedReasonColdStartPopular = "cold_start_popular"
+
+	roomInterestSourceSignup     = "signup"
+	roomInterestSourceFirstVisit = "first_visit"
+	coldStartSourcePopular       = "popular"
+)
+
+// FeedPersona is a public persona recommended to a user who follows no one
+// yet. RoomID is the interest room the persona was active in, if any.
+type FeedPersona struct {
+	PersonaID     string    `json:"persona_id"`
+	Name          string    `json:"name"`
+	Slug          string    `json:"slug"`
+	Bio           string    `json:"bio"`
+	Followers     int       `json:"followers"`
+	RecentBattles int       `json:"recent_battles"`
+	RoomID        string    `json:"room_id,omitempty"`
+	RoomName      string    `json:"room_name,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FeedColdStart tells the client the feed is padded with recommendations and
+// what they are based on: rooms picked at signup, the first room the user
+// opened, or overall popularity when neither is known.
+type FeedColdStart struct {
+	Source  string   `json:"source"`
+	RoomIDs []string `json:"room_ids"`
+}
+
+type feedPersonaCandidate struct {
+	PersonaID     string
+	Name          string
+	Slug          string
+	Bio           string
+	Followers     int
+	RecentBattles int
+	RoomBattles   int
+	RoomID        string
+	RoomName      string
+	CreatedAt     time.Time
+}
+
+type roomInterest struct {
+	RoomID string
+	Source string
+}
+
+// loadFeedColdStart returns nil once the user follows a persona or dismissed
+// the recommendations.
+func (s *Server) loadFeedColdStart(ctx context.Context, userID string) (*FeedColdStart, error) {
+	var dismissed, follows bool
+	if err := s.db.QueryRow(ctx, `
+		SELECT
+			u.cold_start_dismissed_at IS NOT NULL,
+			EXISTS(SELECT 1 FROM persona_follows pf WHERE pf.follower_user_id = u.id)
+		FROM users u
+		WHERE u.id = $1::uuid
+	`, strings.TrimSpace(userID)).Scan(&dismissed, &follows); err != nil {
+		return nil, err
+	}
+	if dismissed || follows {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT i.room_id::text, i.source
+		FROM user_room_interests i
+		JOIN rooms r ON r.id = i.room_id
+		WHERE i.user_id = $1::uuid
+		  AND r.archived_at IS NULL
+		ORDER BY i.created_at ASC, i.room_id ASC
+	`, strings.TrimSpace(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	interests := []roomInterest{}
+	for rows.Next() {
+		var interest roomInterest
+		if err := rows.Scan(&interest.RoomID, &interest.Source); err != nil {
+			return nil, err
+		}
+		interests = append(interests, interest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return buildFeedColdStart(interests), nil
+}
+
+// buildFeedColdStart reports signup as the source when the user picked any
+// room there; a first visit only counts when they did not.
+func buildFeedColdStart(interests []roomInterest) *FeedColdStart {
+	coldStart := &FeedColdStart{Source: coldStartSourcePopular, RoomIDs: []string{}}
+	for _, interest := range interests {
+		coldStart.RoomIDs = append(coldStart.RoomIDs, interest.RoomID)
+		if interest.Source == roomInterestSourceSignup || coldStart.Source == coldStartSourcePopular {
+			coldStart.Source = interest.Source
+		}
+	}
+	return coldStart
+}
+
+func (s *Server) listColdStartBattlesForFeed(ctx context.Context, userID string, roomIDs []string, limit int) ([]feedBattleCandidate, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(ctx, `
+		WITH event_counts AS (
+			SELECT
+				COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', '')) AS battle_id,
+				COUNT(*) FILTER (WHERE e.event_name = 'battle_shared')::int AS shares,
+				COUNT(*) FILTER (WHERE e.event_name = 'remix_completed')::int AS remixes,
+				MAX(e.created_at) AS last_event_at
+			FROM events e
+			WHERE e.created_at >= NOW() - INTERVAL '30 days'
+			  AND e.event_name IN ('battle_shared', 'remix_completed')
+			GROUP BY COALESCE(NULLIF(e.metadata->>'source_battle_id', ''), NULLIF(e.metadata->>'battle_id', ''))
+		),
+		reaction_counts AS (
+			SELECT
+				pr.post_id,
+				COUNT(*) FILTER (WHERE pr.reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE pr.reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE pr.reaction = 'disagree')::int AS disagree,
+				MAX(pr.created_at) AS last_reaction_at
+			FROM post_reactions pr
+			WHERE pr.created_at >= NOW() - INTERVAL '30 days'
+			GROUP BY pr.post_id
+		)
+		SELECT
+			p.id::text,
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			COALESCE(p.persona_id::text, ''),
+			COALESCE(pr.name, ''),
+			p.content,
+			p.created_at,
+			COALESCE(ec.shares, 0)::int,
+			COALESCE(ec.remixes, 0)::int,
+			COALESCE(rc.likes, 0),
+			COALESCE(rc.insightful, 0),
+			COALESCE(rc.disagree, 0),
+			COALESCE(p.template_id::text, ''),
+			COALESCE(t.name, ''),
+			GREATEST(
+				p.updated_at,
+				ec.last_event_at,
+				rc.last_reaction_at,
+				(SELECT MAX(r.created_at) FROM replies r WHERE r.post_id = p.id)
+			)
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN personas pr ON pr.id = p.persona_id
+		LEFT JOIN templates t ON t.id = p.template_id
+		LEFT JOIN event_counts ec ON ec.battle_id = p.id::text
+		LEFT JOIN reaction_counts rc ON rc.post_id = p.id
+		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND p.user_id <> $1::uuid
+		  AND p.created_at >= NOW() - INTERVAL '30 days'
+		ORDER BY
+			(p.room_id = ANY($2::uuid[])) DESC,
+			(
+				COALESCE(ec.shares, 0) * 2 + COALESCE(ec.remixes, 0) * 4 +
+				COALESCE(rc.likes, 0) + COALESCE(rc.insightful, 0) + COALESCE(rc.disagree, 0)
+			) DESC,
+			p.created_at DESC
+		LIMIT $3
+	`, strings.TrimSpace(userID), roomIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]feedBattleCandidate, 0, limit)
+	for rows.Next() {
+		var item feedBattleCandidate
+		if err := rows.Scan(
+			&item.BattleID,
+			&item.RoomID,
+			&item.RoomName,
+			&item.PersonaID,
+			&item.PersonaName,
+			&item.Content,
+			&item.CreatedAt,
+			&item.Shares,
+			&item.Remixes,
+			&item.Reactions.Like,
+			&item.Reactions.Insightful,
+			&item.Reactions.Disagree,
+			&item.TemplateID,
+			&item.TemplateName,
+			&item.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// listColdStartPersonasForFeed ranks public personas that battled in the
+// interest rooms first, then by followers and recent battles overall.
+func (s *Server) listColdStartPersonasForFeed(ctx context.Context, userID string, roomIDs []string, limit int) ([]feedPersonaCandidate, error) {
+	if limit <= 0 {
+		limit = 12
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	rows, err := s.db.Query(ctx, `
+		WITH recent AS (
+			SELECT
+				p.persona_id,
+				COUNT(*)::int AS battles,
+				COUNT(*) FILTER (WHERE p.room_id = ANY($2::uuid[]))::int AS room_battles,
+				(ARRAY_AGG(p.room_id ORDER BY p.created_at DESC) FILTER (WHERE p.room_id = ANY($2::uuid[])))[1] AS room_id
+			FROM posts p
+			WHERE p.status = 'PUBLISHED'
+			  AND p.persona_id IS NOT NULL
+			  AND p.created_at >= NOW() - INTERVAL '30 days'
+			GROUP BY p.persona_id
+		),
+		follower_counts AS (
+			SELECT followed_persona_id, COUNT(*)::int AS followers
+			FROM persona_follows
+			GROUP BY followed_persona_id
+		)
+		SELECT
+			pe.id::text,
+			pe.name,
+			pp.slug,
+			pp.bio,
+			COALESCE(fc.followers, 0),
+			COALESCE(rc.battles, 0),
+			COALESCE(rc.room_battles, 0),
+			COALESCE(rc.room_id::text, ''),
+			COALESCE(rm.name, ''),
+			pp.created_at
+		FROM persona_public_profiles pp
+		JOIN personas pe ON pe.id = pp.persona_id
+		LEFT JOIN recent rc ON rc.persona_id = pe.id
+		LEFT JOIN follower_counts fc ON fc.followed_persona_id = pe.id
+		LEFT JOIN rooms rm ON rm.id = rc.room_id
+		WHERE pp.is_public = TRUE
+		  AND pe.user_id <> $1::uuid
+		ORDER BY
+			COALESCE(rc.room_battles, 0) DESC,
+			COALESCE(fc.followers, 0) DESC,
+			COALESCE(rc.battles, 0) DESC,
+			pp.created_at DESC
+		LIMIT $3
+	`, strings.TrimSpace(userID), roomIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]feedPersonaCandidate, 0, limit)
+	for rows.Next() {
+		var item feedPersonaCandidate
+		if err := rows.Scan(
+			&item.PersonaID,
+			&item.Name,
+			&item.Slug,
+			&item.Bio,
+			&item.Followers,
+			&item.RecentBattles,
+			&item.RoomBattles,
+			&item.RoomID,
+			&item.RoomName,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func coldStartBattleReason(candidate feedBattleCandidate, roomIDs []string) string {
+	for _, roomID := range roomIDs {
+		if strings.TrimSpace(candidate.RoomID) == roomID {
+			return feedReasonColdStartRoom
+		}
+	}
+	return feedReasonColdStartPopular
+}
+
+func coldStartPersonaReason(candidate feedPersonaCandidate) string {
+	if candidate.RoomBattles > 0 {
+		return feedReasonColdStartRoom
+	}
+	return feedReasonColdStartPopular
+}
+
+// scoreColdStartBattle ranks recommendations above trending battles so a new
+// user's first page is not empty, and battles from their rooms above the
+// merely popular ones.
+func scoreColdStartBattle(now, createdAt time.Time, shares, remixes, reactions int, reason string) float64 {
+	ageHours := now.Sub(createdAt).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	base := 74.0
+	if reason == feedReasonColdStartRoom {
+		base = 84
+	}
+	return base + float64(shares*2+remixes*4) + reactionScoreBoost(reactions) - ageHours*0.2
+}
+
+func scoreColdStartPersona(candidate feedPersonaCandidate, reason string) float64 {
+	base := 72.0
+	if reason == feedReasonColdStartRoom {
+		base = 82
+	}
+	return base + math.Min(float64(candidate.Followers)*0.5, 20) + math.Min(float64(candidate.RecentBattles), 10)
+}
+
+func (s *Server) handleDismissColdStart(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		UPDATE users
+		SET cold_start_dismissed_at = COALESCE(cold_start_dismissed_at, NOW())
+		WHERE id = $1::uuid
+	`, userID); err != nil {
+		writeInternalError(w, "could not dismiss recommendations")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"dismissed": true})
+}
+
+// normalizeRoomInterests dedupes the rooms picked at signup, given as room
+// IDs or slugs.
+func normalizeRoomInterests(values []string) ([]string, error) {
+	out := make([]string, 0, len(values))
+	seen := map[string]struct{}{}
+	for _, value := range values {
+		clean := strings.ToLower(strings.TrimSpace(value))
+		if clean == "" {
+			continue
+		}
+		if _, exists := seen[clean]; exists {
+			continue
+		}
+		seen[clean] = struct{}{}
+		out = append(out, clean)
+	}
+	if len(out) > maxSignupRoomInterests {
+		return nil, errors.New("room_interests can list at most 5 rooms")
+	}
+	return out, nil
+}
+
+func (s *Server) saveSignupRoomInterests(ctx context.Context, userID string, rooms []string) error {
+	if len(rooms) == 0 {
+		return nil
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO user_room_interests(user_id, room_id, source)
+		SELECT $1::uuid, r.id, $3
+		FROM rooms r
+		WHERE (r.id::text = ANY($2::text[]) OR r.slug = ANY($2::text[]))
+		  AND r.archived_at IS NULL
+		ON CONFLICT DO NOTHING
+	`, userID, rooms, roomInterestSourceSignup)
+	return err
+}
+
+// recordFirstRoomVisit takes the first room a user opens as their interest
+// when they did not pick any at signup.
+func (s *Server) recordFirstRoomVisit(r *http.Request, userID, roomID string) {
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO user_room_interests(user_id, room_id, source)
+		SELECT $1::uuid, $2::uuid, $3
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM user_room_interests
+			WHERE user_id = $1::uuid
+		)
+		ON CONFLICT DO NOTHING
+	`, userID, roomID, roomInterestSourceFirstVisit); err != nil {
+		s.logger.Warn("room_interest_record_failed", observability.Fields{
+			"request_id": requestIDFromRequest(r),
+			"user_id":    userID,
+			"room_id":    roomID,
+			"error":      err.Error(),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildFeedColdStartSource(t *testing.T) {
+	if got := buildFeedColdStart(nil); got.Source != coldStartSourcePopular || len(got.RoomIDs) != 0 {
+		t.Fatalf("expected popular cold start without interests, got %+v", got)
+	}
+
+	got := buildFeedColdStart([]roomInterest{{RoomID: "room-1", Source: roomInterestSourceFirstVisit}})
+	if got.Source != roomInterestSourceFirstVisit || len(got.RoomIDs) != 1 {
+		t.Fatalf("expected first visit cold start, got %+v", got)
+	}
+
+	got = buildFeedColdStart([]roomInterest{
+		{RoomID: "room-1", Source: roomInterestSourceFirstVisit},
+		{RoomID: "room-2", Source: roomInterestSourceSignup},
+	})
+	if got.Source != roomInterestSourceSignup || len(got.RoomIDs) != 2 {
+		t.Fatalf("expected signup interests to win, got %+v", got)
+	}
+}
+
+func TestScoreColdStartBattleRanksInterestRoomsAboveTrending(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-48 * time.Hour)
+
+	room := scoreColdStartBattle(now, createdAt, 0, 0, 0, feedReasonColdStartRoom)
+	popular := scoreColdStartBattle(now, createdAt, 0, 0, 0, feedReasonColdStartPopular)
+	trending := scoreTrendingBattle(now, createdAt, 0, 0, 0)
+	if room <= popular || popular <= trending {
+		t.Fatalf("expected room (%v) > popular (%v) > trending (%v)", room, popular, trending)
+	}
+	if followed := scoreFollowedBattle(now, now, 0, 0, 0); followed <= scoreColdStartBattle(now, now, 0, 0, 0, feedReasonColdStartRoom) {
+		t.Fatalf("expected a fresh followed battle to outrank recommendations")
+	}
+}
+
+func TestColdStartReasons(t *testing.T) {
+	battle := feedBattleCandidate{RoomID: "room-2"}
+	if got := coldStartBattleReason(battle, []string{"room-1", "room-2"}); got != feedReasonColdStartRoom {
+		t.Fatalf("expected room interest reason, got %q", got)
+	}
+	if got := coldStartBattleReason(battle, nil); got != feedReasonColdStartPopular {
+		t.Fatalf("expected popular reason, got %q", got)
+	}
+	if got := coldStartPersonaReason(feedPersonaCandidate{RoomBattles: 2}); got != feedReasonColdStartRoom {
+		t.Fatalf("expected room interest reason for persona, got %q", got)
+	}
+	if got := scoreColdStartPersona(feedPersonaCandidate{Followers: 1000, RecentBattles: 1000}, feedReasonColdStartPopular); got != 102 {
+		t.Fatalf("expected capped popularity boost, got %v", got)
+	}
+}
+
+func TestNormalizeRoomInterests(t *testing.T) {
+	got, err := normalizeRoomInterests([]string{" Tech ", "tech", "", "science"})
+	if err != nil || len(got) != 2 || got[0] != "tech" || got[1] != "science" {
+		t.Fatalf("unexpected interests %v (%v)", got, err)
+	}
+	if _, err := normalizeRoomInterests([]string{"a", "b", "c", "d", "e", "f"}); err == nil {
+		t.Fatalf("expected too many interests to fail")
+	}
+}
+
+func TestParseFeedCursorAcceptsPersonaItems(t *testing.T) {
+	raw := buildFeedCursor(feedCursor{AsOf: time.Unix(100, 0), Score: 80, CreatedAt: time.Unix(50, 0), ItemID: "persona:abc"})
+	cursor, err := parseFeedCursor(raw)
+	if err != nil || cursor.ItemID != "persona:abc" {
+		t.Fatalf("expected persona cursor to parse, got %+v (%v)", cursor, err)
+	}
+}
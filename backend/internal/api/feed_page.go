@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	feedDefaultPageSize = 50
+	feedMaxPageSize     = 50
+
+	// Candidate pools are larger than one page so cursors have something to
+	// page through; the ranked feed is cut at feedMaxRankedItems.
+	feedBattleCandidateLimit   = 100
+	feedTemplateCandidateLimit = 24
+	feedMaxRankedItems         = 200
+)
+
+// feedPage selects a window of the ranked feed. A cursor pins the ranking time
+// of the first page, so later pages are ranked with the same age decay and
+// items published after it are left for the next since-poll instead of
+// shifting pages. Since keeps only items created or changed after that time.
+type feedPage struct {
+	Limit  int
+	Cursor *feedCursor
+	Since  *time.Time
+	now    time.Time
+}
+
+type feedCursor struct {
+	AsOf      time.Time
+	Score     float64
+	CreatedAt time.Time
+	ItemID    string
+}
+
+func parseFeedPage(query url.Values) (feedPage, error) {
+	page := feedPage{Limit: feedDefaultPageSize, now: time.Now().UTC()}
+
+	if clean := strings.TrimSpace(query.Get("limit")); clean != "" {
+		parsed, err := strconv.Atoi(clean)
+		if err != nil || parsed <= 0 {
+			return feedPage{}, fmt.Errorf("limit must be a positive integer")
+		}
+		page.Limit = parsed
+	}
+	if page.Limit > feedMaxPageSize {
+		page.Limit = feedMaxPageSize
+	}
+
+	if clean := strings.TrimSpace(query.Get("cursor")); clean != "" {
+		cursor, err := parseFeedCursor(clean)
+		if err != nil {
+			return feedPage{}, err
+		}
+		page.Cursor = &cursor
+	}
+
+	if clean := strings.TrimSpace(query.Get("since")); clean != "" {
+		since, err := time.Parse(time.RFC3339Nano, clean)
+		if err != nil {
+			return feedPage{}, fmt.Errorf("since must be an RFC3339 timestamp")
+		}
+		since = since.UTC()
+		page.Since = &since
+	}
+	return page, nil
+}
+
+// rankedAt is the time scores are computed against.
+func (p feedPage) rankedAt() time.Time {
+	if p.Cursor != nil {
+		return p.Cursor.AsOf
+	}
+	if p.now.IsZero() {
+		return time.Now().UTC()
+	}
+	return p.now
+}
+
+// apply filters and slices ranked items (sorted by feedItemLess) and returns
+// the cursor for the following page, empty on the last page.
+func (p feedPage) apply(items []FeedItem) ([]FeedItem, string) {
+	if len(items) > feedMaxRankedItems {
+		items = items[:feedMaxRankedItems]
+	}
+
+	limit := p.Limit
+	if limit <= 0 {
+		limit = feedDefaultPageSize
+	}
+	asOf := p.rankedAt()
+
+	out := make([]FeedItem, 0, limit)
+	hasMore := false
+	for _, item := range items {
+		if p.Cursor != nil {
+			if feedItemCreatedAt(item).After(asOf) {
+				continue
+			}
+			if !feedItemLess(p.Cursor.key(), item) {
+				continue
+			}
+		}
+		if p.Since != nil && !item.UpdatedAt.After(*p.Since) {
+			continue
+		}
+		if len(out) == limit {
+			hasMore = true
+			break
+		}
+		out = append(out, item)
+	}
+
+	if !hasMore || len(out) == 0 {
+		return out, ""
+	}
+	last := out[len(out)-1]
+	return out, buildFeedCursor(feedCursor{
+		AsOf:      asOf,
+		Score:     last.Score,
+		CreatedAt: feedItemCreatedAt(last),
+		ItemID:    last.ID,
+	})
+}
+
+// key is the cursor's position as a comparable feed item.
+func (c feedCursor) key() FeedItem {
+	item := FeedItem{ID: c.ItemID, Score: c.Score}
+	// feedItemCreatedAt reads from the payload, so carry the timestamp there.
+	item.Battle = &FeedBattle{CreatedAt: c.CreatedAt}
+	return item
+}
+
+// feedItemLess orders the feed: higher score first, then newer, then by ID so
+// the order is total and cursors never skip or repeat ties.
+func feedItemLess(a, b FeedItem) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	aCreated, bCreated := feedItemCreatedAt(a), feedItemCreatedAt(b)
+	if !aCreated.Equal(bCreated) {
+		return aCreated.After(bCreated)
+	}
+	return a.ID < b.ID
+}
+
+func buildFeedCursor(cursor feedCursor) string {
+	return fmt.Sprintf(
+		"%d|%s|%d|%s",
+		cursor.AsOf.UTC().UnixNano(),
+		strconv.FormatFloat(cursor.Score, 'f', -1, 64),
+		cursor.CreatedAt.UTC().UnixNano(),
+		cursor.ItemID,
+	)
+}
+
+func parseFeedCursor(raw string) (feedCursor, error) {
+	parts := strings.SplitN(strings.TrimSpace(raw), "|", 4)
+	if len(parts) != 4 {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	asOf, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	score, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	createdAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	itemID := strings.TrimSpace(parts[3])
+	if !strings.HasPrefix(itemID, "battle:") && !strings.HasPrefix(itemID, "template:") && !strings.HasPrefix(itemID, "persona:") {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	return feedCursor{
+		AsOf:      time.Unix(0, asOf).UTC(),
+		Score:     score,
+		CreatedAt: time.Unix(0, createdAt).UTC(),
+		ItemID:    itemID,
+	}, nil
+}
+
+// feedUpdatedAt falls back to the creation time for rows without activity.
+func feedUpdatedAt(createdAt, updatedAt time.Time) time.Time {
+	if updatedAt.After(createdAt) {
+		return updatedAt
+	}
+	return createdAt
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+)
+
+func feedPageTestItems(now time.Time, count int) []FeedItem {
+	items := make([]FeedItem, 0, count)
+	for i := 0; i < count; i++ {
+		createdAt := now.Add(-time.Duration(i) * time.Hour)
+		items = append(items, FeedItem{
+			ID:        fmt.Sprintf("battle:%02d", i),
+			Kind:      "battle",
+			Score:     float64(100 - i/2), // pairs share a score
+			UpdatedAt: createdAt,
+			Battle:    &FeedBattle{CreatedAt: createdAt},
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return feedItemLess(items[i], items[j]) })
+	return items
+}
+
+func TestFeedPageWalksAllItemsOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := feedPageTestItems(now, 7)
+
+	seen := map[string]int{}
+	page := feedPage{Limit: 3, now: now}
+	for pages := 0; pages < 10; pages++ {
+		out, next := page.apply(items)
+		for _, item := range out {
+			seen[item.ID]++
+		}
+		if next == "" {
+			break
+		}
+		cursor, err := parseFeedCursor(next)
+		if err != nil {
+			t.Fatalf("parse cursor %q: %v", next, err)
+		}
+		if !cursor.AsOf.Equal(now) {
+			t.Fatalf("expected cursor to pin ranking time %s, got %s", now, cursor.AsOf)
+		}
+		page = feedPage{Limit: 3, Cursor: &cursor}
+	}
+
+	if len(seen) != len(items) {
+		t.Fatalf("expected %d distinct items, got %v", len(items), seen)
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Fatalf("item %s returned %d times", id, count)
+		}
+	}
+}
+
+func TestFeedPageCursorSkipsItemsNewerThanRanking(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := feedPageTestItems(now, 4)
+	fresh := FeedItem{ID: "battle:fresh", Score: 1, UpdatedAt: now.Add(time.Minute), Battle: &FeedBattle{CreatedAt: now.Add(time.Minute)}}
+	items = append(items, fresh)
+
+	cursor := feedCursor{AsOf: now, Score: items[0].Score, CreatedAt: feedItemCreatedAt(items[0]), ItemID: items[0].ID}
+	out, _ := feedPage{Limit: 10, Cursor: &cursor}.apply(items)
+	for _, item := range out {
+		if item.ID == fresh.ID || item.ID == items[0].ID {
+			t.Fatalf("unexpected item %s on cursor page", item.ID)
+		}
+	}
+	if len(out) != 3 {
+		t.Fatalf("expected 3 items after cursor, got %d", len(out))
+	}
+}
+
+func TestFeedPageSinceKeepsChangedItems(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := feedPageTestItems(now, 5)
+	since := now.Add(-90 * time.Minute)
+	items[len(items)-1].UpdatedAt = now // an old battle that just got a reaction
+
+	out, next := feedPage{Limit: 10, Since: &since, now: now}.apply(items)
+	if next != "" {
+		t.Fatalf("expected no next cursor, got %q", next)
+	}
+	ids := make([]string, 0, len(out))
+	for _, item := range out {
+		ids = append(ids, item.ID)
+	}
+	if len(out) != 3 {
+		t.Fatalf("expected 2 new and 1 changed item, got %v", ids)
+	}
+}
+
+func TestParseFeedPage(t *testing.T) {
+	page, err := parseFeedPage(url.Values{})
+	if err != nil || page.Limit != feedDefaultPageSize || page.Cursor != nil || page.Since != nil {
+		t.Fatalf("unexpected default page %+v, err %v", page, err)
+	}
+
+	page, err = parseFeedPage(url.Values{"limit": {"500"}, "since": {"2026-05-01T10:00:00Z"}})
+	if err != nil {
+		t.Fatalf("parse page: %v", err)
+	}
+	if page.Limit != feedMaxPageSize || page.Since == nil || !page.Since.Equal(time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	for _, query := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"ten"}},
+		{"since": {"yesterday"}},
+		{"cursor": {"garbage"}},
+		{"cursor": {"1|2|3|post:abc"}},
+	} {
+		if _, err := parseFeedPage(query); err == nil {
+			t.Fatalf("expected %v to be rejected", query)
+		}
+	}
+}
+
+func TestFeedCursorRoundTrip(t *testing.T) {
+	want := feedCursor{
+		AsOf:      time.Date(2026, 5, 1, 12, 0, 0, 123, time.UTC),
+		Score:     87.35,
+		CreatedAt: time.Date(2026, 4, 30, 8, 0, 0, 0, time.UTC),
+		ItemID:    "template:4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+	}
+	got, err := parseFeedCursor(buildFeedCursor(want))
+	if err != nil {
+		t.Fatalf("parse cursor: %v", err)
+	}
+	if !got.AsOf.Equal(want.AsOf) || got.Score != want.Score || !got.CreatedAt.Equal(want.CreatedAt) || got.ItemID != want.ItemID {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
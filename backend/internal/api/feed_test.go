@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersonalizeFeedBattle(t *testing.T) {
+	affinities := feedAffinities{
+		Rooms:     map[string]feedAffinity{"room-1": {Weight: 1, Reason: "because_you_post_in_room_room-1"}},
+		Templates: map[string]feedAffinity{"tpl-1": {Weight: 1, Reason: "because_you_use_template_tpl-1"}},
+		Personas:  map[string]feedAffinity{},
+	}
+
+	boost, reasons := personalizeFeedBattle(feedBattleCandidate{RoomID: "room-1", TemplateID: "tpl-1"}, affinities)
+	if boost != feedRoomAffinityBoost+feedTemplateAffinityBoost {
+		t.Fatalf("expected combined boost, got %f", boost)
+	}
+	if len(reasons) != 2 || reasons[0] != "because_you_use_template_tpl-1" {
+		t.Fatalf("expected template reason first, got %v", reasons)
+	}
+
+	boost, reasons = personalizeFeedBattle(feedBattleCandidate{RoomID: "room-2"}, affinities)
+	if boost != 0 || len(reasons) != 0 {
+		t.Fatalf("expected no personalization for unrelated battle, got %f %v", boost, reasons)
+	}
+}
+
+func TestSortedFeedReasonsKeepsSourceReasonsFirst(t *testing.T) {
+	reasons := sortedFeedReasons(map[string]struct{}{
+		"because_you_use_template_tpl-1": {},
+		"trending_battle":                {},
+	})
+	if len(reasons) != 2 || reasons[0] != "trending_battle" {
+		t.Fatalf("expected trending_battle first, got %v", reasons)
+	}
+}
+
+func TestScoreBattleOfWeekRanksAboveTrending(t *testing.T) {
+	now := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-24 * time.Hour)
+
+	featured := scoreBattleOfWeek(now, createdAt, 0, 0, 0)
+	trending := scoreTrendingBattle(now, createdAt, 2, 1, 0)
+	if featured <= trending {
+		t.Fatalf("expected featured battle (%v) above a lightly trending one (%v)", featured, trending)
+	}
+	if later := scoreBattleOfWeek(now.Add(72*time.Hour), createdAt, 0, 0, 0); later >= featured {
+		t.Fatalf("expected featured score to decay, got %v then %v", featured, later)
+	}
+}
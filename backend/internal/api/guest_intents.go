@@ -0,0 +1,384 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	guestIntentVersion    = "v1"
+	guestIntentCookieName = "pw_guest_intent"
+
+	guestIntentKindFollow = "follow"
+	guestIntentKindRemix  = "remix"
+
+	guestIntentViaSignup = "signup"
+	guestIntentViaLogin  = "login"
+)
+
+var (
+	errGuestIntentInvalid = errors.New("invalid guest intent")
+	errGuestIntentExpired = errors.New("guest intent expired")
+)
+
+// guestIntent is an action a signed-out visitor started on a public page. It
+// is signed rather than stored, so issuing one costs nothing; the nonce is
+// only written once the intent runs, which makes every token single-use.
+type guestIntent struct {
+	Kind     string
+	TargetID string
+	IssuedAt time.Time
+	Nonce    string
+}
+
+// GuestIntentResult reports what an intent did when it ran after signup or
+// login. Status is "completed", or why it was skipped.
+type GuestIntentResult struct {
+	Kind        string `json:"kind"`
+	Status      string `json:"status"`
+	TargetID    string `json:"target_id,omitempty"`
+	BattleID    string `json:"battle_id,omitempty"`
+	RedirectURL string `json:"redirect_url,omitempty"`
+}
+
+func signGuestIntent(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("guest_intent\x00"))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func createGuestIntentToken(secret string, intent guestIntent) string {
+	payload := strings.Join([]string{
+		guestIntentVersion,
+		intent.Kind,
+		intent.TargetID,
+		strconv.FormatInt(intent.IssuedAt.Unix(), 10),
+		intent.Nonce,
+	}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signGuestIntent(secret, payload))
+}
+
+func parseGuestIntentToken(secret, raw string, now time.Time, ttl time.Duration) (guestIntent, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	payload := string(payloadBytes)
+	if !hmac.Equal(mac, signGuestIntent(secret, payload)) {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 5 || parts[0] != guestIntentVersion {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	issuedUnix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	intent := guestIntent{
+		Kind:     parts[1],
+		TargetID: parts[2],
+		IssuedAt: time.Unix(issuedUnix, 0).UTC(),
+		Nonce:    parts[4],
+	}
+	if intent.Kind != guestIntentKindFollow && intent.Kind != guestIntentKindRemix {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	if _, err := validateUUID(intent.TargetID, "intent target"); err != nil || intent.Nonce == "" {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	if intent.IssuedAt.After(now.Add(shareTokenMaxSkew)) {
+		return guestIntent{}, errGuestIntentInvalid
+	}
+	if ttl > 0 && now.After(intent.IssuedAt.Add(ttl)) {
+		return guestIntent{}, errGuestIntentExpired
+	}
+	return intent, nil
+}
+
+// issueGuestIntent signs a new intent and also sets it as a cookie, so a
+// client that loses the token in the signup redirect still carries it.
+func (s *Server) issueGuestIntent(w http.ResponseWriter, kind, targetID string) (string, time.Time, error) {
+	nonce, err := newShareTokenNonce()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	issuedAt := time.Now().UTC()
+	token := createGuestIntentToken(s.cfg.JWTSecret, guestIntent{
+		Kind:     kind,
+		TargetID: targetID,
+		IssuedAt: issuedAt,
+		Nonce:    nonce,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     guestIntentCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   int(s.cfg.GuestIntentTTL.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+		Secure:   s.cfg.SecureCookies,
+	})
+	return token, issuedAt.Add(s.cfg.GuestIntentTTL), nil
+}
+
+func (s *Server) clearGuestIntentCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     guestIntentCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   s.cfg.SecureCookies,
+	})
+}
+
+// guestIntentFromRequest prefers the token sent in the body over the cookie.
+func guestIntentFromRequest(r *http.Request, bodyToken string) string {
+	if clean := strings.TrimSpace(bodyToken); clean != "" {
+		return clean
+	}
+	if cookie, err := r.Cookie(guestIntentCookieName); err == nil {
+		return strings.TrimSpace(cookie.Value)
+	}
+	return ""
+}
+
+// completeGuestIntent runs a guest intent for the user who just signed up or
+// logged in. Like share attribution it never fails the auth request: a bad,
+// expired or replayed token is logged and reported as skipped.
+func (s *Server) completeGuestIntent(w http.ResponseWriter, r *http.Request, userID, rawToken, via string) *GuestIntentResult {
+	if rawToken == "" {
+		return nil
+	}
+	s.clearGuestIntentCookie(w)
+
+	intent, err := parseGuestIntentToken(s.cfg.JWTSecret, rawToken, time.Now().UTC(), s.cfg.GuestIntentTTL)
+	if err != nil {
+		reason := "invalid"
+		if errors.Is(err, errGuestIntentExpired) {
+			reason = "expired"
+		}
+		s.logGuestIntentSkipped(r, userID, "", reason)
+		return &GuestIntentResult{Status: reason}
+	}
+
+	result, err := s.executeGuestIntent(r, userID, intent, via)
+	if err != nil {
+		s.logger.Warn("guest_intent_failed", observability.Fields{
+			"request_id": requestIDFromRequest(r),
+			"user_id":    userID,
+			"kind":       intent.Kind,
+			"error":      err.Error(),
+		})
+		return &GuestIntentResult{Kind: intent.Kind, Status: "failed", TargetID: intent.TargetID}
+	}
+	if result.Status != "completed" {
+		s.logGuestIntentSkipped(r, userID, intent.Kind, result.Status)
+	}
+	return &result
+}
+
+func (s *Server) executeGuestIntent(r *http.Request, userID string, intent guestIntent, via string) (GuestIntentResult, error) {
+	ctx := r.Context()
+	result := GuestIntentResult{Kind: intent.Kind, TargetID: intent.TargetID}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Claiming the nonce first, in the same transaction as the action, makes
+	// replays no-ops and lets a failed action release the token again.
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO guest_intents(nonce, kind, target_id, user_id, completed_via, token_issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (nonce) DO NOTHING
+	`, intent.Nonce, intent.Kind, intent.TargetID, userID, via, intent.IssuedAt)
+	if err != nil {
+		return result, err
+	}
+	if tag.RowsAffected() == 0 {
+		result.Status = "replayed"
+		return result, nil
+	}
+
+	var notify func()
+	switch intent.Kind {
+	case guestIntentKindFollow:
+		notify, err = s.runFollowIntent(ctx, tx, userID, intent.TargetID, &result)
+	case guestIntentKindRemix:
+		notify, err = s.runRemixIntent(r, tx, userID, intent.TargetID, &result)
+	}
+	if err != nil || result.Status != "completed" {
+		return result, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE guest_intents
+		SET result_id = NULLIF($2, '')::uuid
+		WHERE nonce = $1
+	`, intent.Nonce, result.BattleID); err != nil {
+		return result, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return result, err
+	}
+	if notify != nil {
+		notify()
+	}
+
+	_ = s.insertEvent(ctx, userID, eventGuestIntentCompleted, map[string]any{
+		"kind":           intent.Kind,
+		"target_id":      intent.TargetID,
+		"via":            via,
+		"intent_age_sec": int(time.Since(intent.IssuedAt).Seconds()),
+	})
+	return result, nil
+}
+
+// runFollowIntent follows the persona the guest tried to follow. The target is
+// the persona id rather than the slug, so a renamed profile still resolves.
+func (s *Server) runFollowIntent(ctx context.Context, tx pgx.Tx, userID, personaID string, result *GuestIntentResult) (func(), error) {
+	var ownerUserID, personaName, slug string
+	err := tx.QueryRow(ctx, `
+		SELECT p.user_id::text, p.name, pp.slug
+		FROM persona_public_profiles pp
+		JOIN personas p ON p.id = pp.persona_id
+		WHERE pp.persona_id = $1
+		  AND pp.is_public = TRUE
+	`, personaID).Scan(&ownerUserID, &personaName, &slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Status = "target_not_found"
+			return nil, nil
+		}
+		return nil, err
+	}
+	if ownerUserID == userID {
+		result.Status = "own_persona"
+		return nil, nil
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO persona_follows(follower_user_id, followed_persona_id)
+		VALUES ($1, $2)
+		ON CONFLICT (follower_user_id, followed_persona_id) DO NOTHING
+	`, userID, personaID)
+	if err != nil {
+		return nil, err
+	}
+	result.Status = "completed"
+	result.RedirectURL = fmt.Sprintf("/p/%s", slug)
+	if tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+	return func() {
+		_ = s.notifyPersonaFollowed(ctx, ownerUserID, userID, personaID, personaName, slug)
+	}, nil
+}
+
+// runRemixIntent creates the remix with the same defaults the remix intent
+// endpoint offers. Reply jobs and notifications are queued after commit.
+func (s *Server) runRemixIntent(r *http.Request, tx pgx.Tx, userID, sourceBattleID string, result *GuestIntentResult) (func(), error) {
+	ctx := r.Context()
+	remix, err := s.loadRemixDefaults(ctx, sourceBattleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.Status = "target_not_found"
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	room, err := s.getRoomByID(ctx, remix.RoomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.ArchivedAt != nil {
+		result.Status = "room_archived"
+		return nil, nil
+	}
+	if roomEventWriteError(room.Event) != nil {
+		result.Status = "event_closed"
+		return nil, nil
+	}
+
+	template, err := s.resolveBattleTemplate(ctx, remix.TemplateID, userID)
+	if errors.Is(err, pgx.ErrNoRows) && remix.TemplateID != "" {
+		template, err = s.resolveBattleTemplate(ctx, "", userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	topic, err := validateTopic(remix.Topic, 3, 180)
+	if err != nil {
+		result.Status = "invalid_topic"
+		return nil, nil
+	}
+	proStyle, conStyle := normalizeBattleStyles(remix.ProStyle, remix.ConStyle)
+	post, err := s.insertBattlePost(ctx, tx, userID, room.ID, topic, template, proStyle, conStyle, "", common.BattleLanguage{Mode: common.BattleLanguageAuto})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Status = "completed"
+	result.BattleID = post.ID
+	result.RedirectURL = fmt.Sprintf("/b/%s", post.ID)
+	return func() {
+		traceID := requestIDFromRequest(r)
+		s.enqueueBattleReplies(ctx, userID, post.ID, template, traceID)
+		_ = s.notifyTemplateUsed(ctx, userID, template, post.ID)
+		_ = s.notifyBattleRemixed(ctx, userID, sourceBattleID, post.ID)
+		_ = s.insertEvent(ctx, userID, eventBattleCreated, map[string]any{
+			"battle_id":   post.ID,
+			"room_id":     room.ID,
+			"template_id": template.ID,
+			"mode":        battleModeClassic,
+		})
+		_ = s.insertEvent(ctx, userID, eventRemixCompleted, map[string]any{
+			"battle_id":        post.ID,
+			"source_battle_id": sourceBattleID,
+			"room_id":          room.ID,
+			"template_id":      template.ID,
+			"source":           "guest_intent",
+		})
+	}, nil
+}
+
+func (s *Server) logGuestIntentSkipped(r *http.Request, userID, kind, reason string) {
+	s.logger.Info("guest_intent_skipped", observability.Fields{
+		"request_id": requestIDFromRequest(r),
+		"user_id":    userID,
+		"kind":       kind,
+		"reason":     reason,
+	})
+}
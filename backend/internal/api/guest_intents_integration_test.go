@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGuestFollowIntentCompletesOnSignupIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{})
+
+	publishRecorder := doJSONRequest(fixture.server, http.MethodPost, "/personas/"+fixture.personaID+"/publish-profile", fixture.token, `{}`)
+	if publishRecorder.Code != http.StatusOK {
+		t.Fatalf("publish profile expected 200, got %d, body: %s", publishRecorder.Code, publishRecorder.Body.String())
+	}
+	var publishResp struct {
+		Slug string `json:"slug"`
+	}
+	if err := json.Unmarshal(publishRecorder.Body.Bytes(), &publishResp); err != nil {
+		t.Fatalf("decode publish response failed: %v", err)
+	}
+
+	guestFollow := doJSONRequest(fixture.server, http.MethodPost, "/p/"+publishResp.Slug+"/follow", "", `{}`)
+	if guestFollow.Code != http.StatusUnauthorized {
+		t.Fatalf("expected guest follow 401, got %d", guestFollow.Code)
+	}
+	var guestResp struct {
+		Error       string `json:"error"`
+		IntentToken string `json:"intent_token"`
+	}
+	if err := json.Unmarshal(guestFollow.Body.Bytes(), &guestResp); err != nil {
+		t.Fatalf("decode guest follow response failed: %v", err)
+	}
+	if guestResp.Error != "signup_required" || guestResp.IntentToken == "" {
+		t.Fatalf("expected signup_required with intent token, got %s", guestFollow.Body.String())
+	}
+
+	email := fmt.Sprintf("guest-intent-%d@example.com", time.Now().UnixNano())
+	signup := doJSONRequest(fixture.server, http.MethodPost, "/auth/signup", "", fmt.Sprintf(
+		`{"email":%q,"password":"password123","intent_token":%q}`, email, guestResp.IntentToken,
+	))
+	if signup.Code != http.StatusCreated {
+		t.Fatalf("expected signup 201, got %d: %s", signup.Code, signup.Body.String())
+	}
+	var signupResp struct {
+		UserID string             `json:"user_id"`
+		Intent *GuestIntentResult `json:"intent"`
+	}
+	if err := json.Unmarshal(signup.Body.Bytes(), &signupResp); err != nil {
+		t.Fatalf("decode signup response failed: %v", err)
+	}
+	if signupResp.Intent == nil || signupResp.Intent.Kind != guestIntentKindFollow || signupResp.Intent.Status != "completed" {
+		t.Fatalf("expected completed follow intent, got %+v", signupResp.Intent)
+	}
+
+	var follows int
+	if err := fixture.pool.QueryRow(fixture.ctx, `
+		SELECT COUNT(*)::int
+		FROM persona_follows
+		WHERE follower_user_id = $1
+		  AND followed_persona_id = $2
+	`, signupResp.UserID, fixture.personaID).Scan(&follows); err != nil {
+		t.Fatalf("count follows failed: %v", err)
+	}
+	if follows != 1 {
+		t.Fatalf("expected follow recorded, got %d", follows)
+	}
+
+	var attributed int
+	if err := fixture.pool.QueryRow(fixture.ctx, `
+		SELECT COUNT(*)::int
+		FROM events
+		WHERE user_id = $1
+		  AND event_name = $2
+	`, signupResp.UserID, eventGuestIntentCompleted).Scan(&attributed); err != nil {
+		t.Fatalf("count events failed: %v", err)
+	}
+	if attributed != 1 {
+		t.Fatalf("expected one %s event, got %d", eventGuestIntentCompleted, attributed)
+	}
+
+	replayEmail := fmt.Sprintf("guest-intent-replay-%d@example.com", time.Now().UnixNano())
+	replay := doJSONRequest(fixture.server, http.MethodPost, "/auth/signup", "", fmt.Sprintf(
+		`{"email":%q,"password":"password123","intent_token":%q}`, replayEmail, guestResp.IntentToken,
+	))
+	if replay.Code != http.StatusCreated {
+		t.Fatalf("expected replayed signup to still succeed, got %d: %s", replay.Code, replay.Body.String())
+	}
+	var replayResp struct {
+		Intent *GuestIntentResult `json:"intent"`
+	}
+	if err := json.Unmarshal(replay.Body.Bytes(), &replayResp); err != nil {
+		t.Fatalf("decode replay response failed: %v", err)
+	}
+	if replayResp.Intent == nil || replayResp.Intent.Status != "replayed" {
+		t.Fatalf("expected replayed intent, got %+v", replayResp.Intent)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGuestIntentTokenRoundTrip(t *testing.T) {
+	const secret = "intent-secret"
+	issuedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	raw := createGuestIntentToken(secret, guestIntent{
+		Kind:     guestIntentKindFollow,
+		TargetID: "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		IssuedAt: issuedAt,
+		Nonce:    "abc123",
+	})
+
+	intent, err := parseGuestIntentToken(secret, raw, issuedAt.Add(10*time.Minute), 30*time.Minute)
+	if err != nil {
+		t.Fatalf("parse guest intent: %v", err)
+	}
+	if intent.Kind != guestIntentKindFollow || intent.TargetID != "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f" {
+		t.Fatalf("unexpected intent %+v", intent)
+	}
+	if !intent.IssuedAt.Equal(issuedAt) || intent.Nonce != "abc123" {
+		t.Fatalf("unexpected intent %+v", intent)
+	}
+
+	if _, err := parseGuestIntentToken(secret, raw, issuedAt.Add(31*time.Minute), 30*time.Minute); !errors.Is(err, errGuestIntentExpired) {
+		t.Fatalf("expected expired intent, got %v", err)
+	}
+	if _, err := parseGuestIntentToken(secret, raw, issuedAt.Add(-time.Hour), 30*time.Minute); !errors.Is(err, errGuestIntentInvalid) {
+		t.Fatalf("expected intent from the future to be rejected, got %v", err)
+	}
+}
+
+func TestParseGuestIntentTokenRejectsTampering(t *testing.T) {
+	const secret = "intent-secret"
+	now := time.Now().UTC()
+	raw := createGuestIntentToken(secret, guestIntent{
+		Kind:     guestIntentKindFollow,
+		TargetID: "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		IssuedAt: now,
+		Nonce:    "abc123",
+	})
+	_, mac, _ := strings.Cut(raw, ".")
+
+	// Same fields, different kind: a follow token must not turn into a remix.
+	payload, _, _ := strings.Cut(raw, ".")
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	remixPayload := base64.RawURLEncoding.EncodeToString([]byte(
+		strings.Replace(string(decoded), ":"+guestIntentKindFollow+":", ":"+guestIntentKindRemix+":", 1),
+	))
+	unknownKind := createGuestIntentToken(secret, guestIntent{
+		Kind:     "delete_account",
+		TargetID: "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		IssuedAt: now,
+		Nonce:    "abc123",
+	})
+	shareSigned := createShareToken(secret, shareToken{
+		BattleID:     "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		SharerUserID: "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		IssuedAt:     now,
+		Nonce:        "abc123",
+	})
+
+	for _, candidate := range []string{"", "garbage", raw + "x", remixPayload + "." + mac, unknownKind, shareSigned} {
+		if _, err := parseGuestIntentToken(secret, candidate, now, time.Hour); !errors.Is(err, errGuestIntentInvalid) {
+			t.Fatalf("expected %q to be rejected, got %v", candidate, err)
+		}
+	}
+	if _, err := parseGuestIntentToken("other-secret", raw, now, time.Hour); !errors.Is(err, errGuestIntentInvalid) {
+		t.Fatalf("expected wrong secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const activityHumanReplyReceived = "human_reply_received"
+
+// replyTarget is the post a reply is about to be added to, with everything
+// that decides whether it still takes replies.
+type replyTarget struct {
+	PostID         string
+	RoomID         string
+	PersonaID      string
+	Content        string
+	Status         string
+	RoomArchived   bool
+	Interactive    bool
+	Challenge      bool
+	BattleArchived bool
+	EventStartsAt  *time.Time
+	EventEndsAt    *time.Time
+}
+
+func (s *Server) loadReplyTarget(ctx context.Context, postID string) (replyTarget, error) {
+	target := replyTarget{PostID: postID}
+	err := s.db.QueryRow(ctx, `
+		SELECT p.room_id::text, COALESCE(p.persona_id::text, ''), p.content, p.status::text,
+			rm.archived_at IS NOT NULL AND rm.merged_into_room_id IS NULL,
+			EXISTS(SELECT 1 FROM interactive_battles ib WHERE ib.post_id = p.id),
+			EXISTS(SELECT 1 FROM battle_challenges bc WHERE bc.battle_post_id = p.id),
+			EXISTS(SELECT 1 FROM battle_archives ba WHERE ba.post_id = p.id),
+			rm.event_starts_at, rm.event_ends_at
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.id=$1
+	`, postID).Scan(&target.RoomID, &target.PersonaID, &target.Content, &target.Status, &target.RoomArchived,
+		&target.Interactive, &target.Challenge, &target.BattleArchived, &target.EventStartsAt, &target.EventEndsAt)
+	return target, err
+}
+
+// conflict explains why a published post takes no new replies, or returns
+// nil when it does. Callers check the post status themselves.
+func (t replyTarget) conflict(now time.Time) error {
+	if t.RoomArchived {
+		return errors.New("room is archived")
+	}
+	if err := roomEventWriteError(newRoomEvent(t.EventStartsAt, t.EventEndsAt, "", now)); err != nil {
+		return err
+	}
+	if t.Interactive {
+		return errors.New("interactive battles only take turns from their two sides")
+	}
+	if t.Challenge {
+		return errors.New("challenge battles only take turns from the two challenged personas")
+	}
+	if t.BattleArchived {
+		return errors.New("battle is archived")
+	}
+	return nil
+}
+
+// enqueueReplyJobs queues a generate_reply job for each of the user's
+// personas that still has reply quota and has not replied to or been queued
+// for the post. extra is merged into the job payload.
+func (s *Server) enqueueReplyJobs(r *http.Request, userID, postID string, personaIDs []string, extra map[string]any) (int, int) {
+	enqueued := 0
+	skipped := 0
+
+	for _, personaID := range personaIDs {
+		persona, err := s.getPersonaByID(r.Context(), userID, personaID)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		used, err := s.currentQuotaUsage(r.Context(), personaID, "reply")
+		if err != nil {
+			skipped++
+			continue
+		}
+		if used >= persona.DailyReplyQuota {
+			skipped++
+			continue
+		}
+
+		var alreadyReplied bool
+		err = s.db.QueryRow(r.Context(), `
+			SELECT EXISTS(
+				SELECT 1 FROM replies WHERE post_id=$1 AND persona_id=$2
+			)
+		`, postID, personaID).Scan(&alreadyReplied)
+		if err != nil || alreadyReplied {
+			skipped++
+			continue
+		}
+
+		var pending bool
+		err = s.db.QueryRow(r.Context(), `
+			SELECT EXISTS(
+				SELECT 1 FROM jobs
+				WHERE post_id=$1 AND persona_id=$2 AND job_type='generate_reply' AND status IN ('PENDING', 'PROCESSING')
+			)
+		`, postID, personaID).Scan(&pending)
+		if err != nil || pending {
+			skipped++
+			continue
+		}
+
+		payloadMap := map[string]any{
+			"post_id":    postID,
+			"persona_id": personaID,
+		}
+		for key, value := range extra {
+			payloadMap[key] = value
+		}
+		if traceID := strings.TrimSpace(requestIDFromRequest(r)); traceID != "" {
+			payloadMap["trace_id"] = traceID
+		}
+		payload, err := json.Marshal(payloadMap)
+		if err != nil {
+			skipped++
+			continue
+		}
+		if _, err := s.db.Exec(r.Context(), `
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ('generate_reply', $1, $2, $3::jsonb, 'PENDING', NOW())
+		`, postID, personaID, payload); err != nil {
+			skipped++
+			continue
+		}
+		enqueued++
+	}
+
+	return enqueued, skipped
+}
+
+// handleCreateReply stores a reply the user wrote themselves. Personas can
+// answer it through the usual reply jobs: the user's own personas listed in
+// persona_ids, and mentioned personas whose owners opted in.
+func (s *Server) handleCreateReply(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !s.userReplyLimiter.allow("reply:"+strings.TrimSpace(userID), time.Now()) {
+		s.writeRateLimitResponse(w, r, "user", "reply_create", "reply rate limit exceeded")
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Content    string   `json:"content"`
+		PersonaIDs []string `json:"persona_ids"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if err := s.loadSafetyRules(r.Context()).Validate(content, s.cfg.ReplyMaxLen); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var personaIDs []string
+	if len(req.PersonaIDs) > 0 {
+		personaIDs, err = s.resolvePersonaIDsForReplyGeneration(r.Context(), userID, req.PersonaIDs)
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+
+	target, err := s.loadReplyTarget(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not load post")
+		return
+	}
+	if target.Status != "PUBLISHED" {
+		writeConflict(w, "replies can be written only for published posts")
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, postID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+	if err := target.conflict(time.Now()); err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var reply Reply
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO replies(post_id, persona_id, user_id, authored_by, content)
+		VALUES ($1, NULL, $2, 'HUMAN', $3)
+		RETURNING id::text, post_id::text, authored_by::text, content, created_at, updated_at
+	`, postID, userID, content).Scan(&reply.ID, &reply.PostID, &reply.AuthoredBy, &reply.Content, &reply.CreatedAt, &reply.UpdatedAt)
+	if err != nil {
+		writeInternalError(w, "could not save reply")
+		return
+	}
+	if target.PersonaID != "" {
+		if err := common.InsertPersonaActivityEvent(r.Context(), tx, target.PersonaID, activityHumanReplyReceived, map[string]any{
+			"post_id":       postID,
+			"room_id":       target.RoomID,
+			"reply_id":      reply.ID,
+			"post_preview":  common.TruncateRunes(target.Content, 200),
+			"reply_preview": common.TruncateRunes(content, 200),
+		}); err != nil {
+			writeInternalError(w, "could not save reply")
+			return
+		}
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not save reply")
+		return
+	}
+
+	s.recordMentions(r, common.MentionSource{
+		PostID:       reply.PostID,
+		ReplyID:      reply.ID,
+		AuthorUserID: userID,
+		Content:      reply.Content,
+	})
+	enqueued, skipped := s.enqueueReplyJobs(r, userID, postID, personaIDs, map[string]any{
+		"source":   "human_reply",
+		"reply_id": reply.ID,
+	})
+
+	s.logger.Info("human_reply_created", observability.Fields{
+		"request_id": requestIDFromRequest(r),
+		"post_id":    postID,
+		"reply_id":   reply.ID,
+		"user_id":    userID,
+		"enqueued":   enqueued,
+	})
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"reply":    reply,
+		"enqueued": enqueued,
+		"skipped":  skipped,
+	})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplyTargetConflict(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := (replyTarget{Status: "PUBLISHED"}).conflict(now); err != nil {
+		t.Fatalf("expected a plain published post to take replies, got %v", err)
+	}
+
+	ended := now.Add(-time.Hour)
+	started := now.Add(-3 * time.Hour)
+	if err := (replyTarget{EventStartsAt: &started, EventEndsAt: &ended}).conflict(now); !errors.Is(err, errEventEnded) {
+		t.Fatalf("expected ended event to reject replies, got %v", err)
+	}
+
+	cases := map[string]replyTarget{
+		"room is archived": {RoomArchived: true},
+		"interactive battles only take turns from their two sides":           {Interactive: true},
+		"challenge battles only take turns from the two challenged personas": {Challenge: true},
+		"battle is archived": {BattleArchived: true},
+	}
+	for want, target := range cases {
+		if err := target.conflict(now); err == nil || err.Error() != want {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	battleModeClassic     = "classic"
+	battleModeInteractive = "interactive"
+)
+
+func normalizeBattleMode(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case "", battleModeClassic:
+		return battleModeClassic, nil
+	case battleModeInteractive:
+		return battleModeInteractive, nil
+	default:
+		return "", errors.New("mode must be classic or interactive")
+	}
+}
+
+// startInteractiveBattle records the turn state for a new interactive battle
+// and queues the AI persona's opening turn.
+func (s *Server) startInteractiveBattle(ctx context.Context, userID, personaID, postID string, template BattleTemplate, traceID string) (common.InteractiveBattle, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return common.InteractiveBattle{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	battle := common.InteractiveBattle{
+		PostID:      postID,
+		UserID:      userID,
+		AIPersonaID: personaID,
+		TotalTurns:  common.InteractiveTurnCount(template.TurnCount),
+		NextSide:    common.InteractiveSideAI,
+		Status:      common.InteractiveStatusActive,
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO interactive_battles(post_id, user_id, ai_persona_id, total_turns, next_side, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, battle.PostID, battle.UserID, battle.AIPersonaID, battle.TotalTurns, battle.NextSide, battle.Status); err != nil {
+		return common.InteractiveBattle{}, err
+	}
+	if err := common.EnqueueInteractiveAITurn(ctx, tx, battle, traceID); err != nil {
+		return common.InteractiveBattle{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return common.InteractiveBattle{}, err
+	}
+	return battle, nil
+}
+
+func (s *Server) handleSubmitBattleTurn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if err := s.loadSafetyRules(r.Context()).Validate(content, s.cfg.ReplyMaxLen); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	battle, err := common.LoadInteractiveBattle(r.Context(), tx, battleID, true)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "interactive battle not found")
+			return
+		}
+		writeInternalError(w, "could not load battle")
+		return
+	}
+	if battle.UserID != userID {
+		writeNotFound(w, "interactive battle not found")
+		return
+	}
+	if battle.Status != common.InteractiveStatusActive {
+		writeConflict(w, "battle is "+strings.ToLower(battle.Status))
+		return
+	}
+	if battle.NextSide != common.InteractiveSideHuman {
+		writeConflict(w, "waiting for the AI turn")
+		return
+	}
+	now := time.Now().UTC()
+	if battle.TurnDeadline != nil && !now.Before(*battle.TurnDeadline) {
+		writeConflict(w, "turn deadline has passed")
+		return
+	}
+
+	var reply Reply
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO replies(post_id, persona_id, user_id, authored_by, content)
+		VALUES ($1, NULL, $2, 'HUMAN', $3)
+		RETURNING id::text, post_id::text, authored_by::text, content, created_at, updated_at
+	`, battleID, userID, content).Scan(&reply.ID, &reply.PostID, &reply.AuthoredBy, &reply.Content, &reply.CreatedAt, &reply.UpdatedAt)
+	if err != nil {
+		writeInternalError(w, "could not save turn")
+		return
+	}
+
+	battle = battle.AfterTurn(now, s.cfg.InteractiveTurnTimeout)
+	if err := common.SaveInteractiveBattle(r.Context(), tx, battle); err != nil {
+		writeInternalError(w, "could not save turn")
+		return
+	}
+	if battle.AwaitingAI() {
+		if err := common.EnqueueInteractiveAITurn(r.Context(), tx, battle, requestIDFromRequest(r)); err != nil {
+			writeInternalError(w, "could not queue AI turn")
+			return
+		}
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not save turn")
+		return
+	}
+	s.recordMentions(r, common.MentionSource{
+		PostID:       reply.PostID,
+		ReplyID:      reply.ID,
+		AuthorUserID: userID,
+		Content:      reply.Content,
+	})
+	s.notifyBattleWatchersOfTurn(r, reply.PostID, userID, common.InteractiveHumanName)
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"reply":       reply,
+		"interactive": battle,
+	})
+}
@@ -0,0 +1,24 @@
+package api
+
+import "testing"
+
+func TestNormalizeBattleMode(t *testing.T) {
+	cases := map[string]string{
+		"":              battleModeClassic,
+		"classic":       battleModeClassic,
+		" Interactive ": battleModeInteractive,
+	}
+	for raw, want := range cases {
+		got, err := normalizeBattleMode(raw)
+		if err != nil {
+			t.Fatalf("normalizeBattleMode(%q) returned error: %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("normalizeBattleMode(%q) = %q, want %q", raw, got, want)
+		}
+	}
+
+	if _, err := normalizeBattleMode("solo"); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+}
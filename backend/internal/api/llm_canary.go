@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	llmCanaryDefaultDays = 7
+	llmCanaryMaxDays     = 90
+	llmCanarySamples     = 10
+	// A comparison needs this many scored runs before it gets a verdict.
+	llmCanaryMinRuns = 20
+	// Overall score differences within this margin count as a tie.
+	llmCanaryTieMargin = 0.05
+	// The candidate regresses when more than this share of its calls fail.
+	llmCanaryMaxErrorRate = 0.05
+	// ...or when its safety failure rate exceeds the primary's by more.
+	llmCanaryMaxSafetyIncrease = 0.02
+)
+
+type LLMCanarySide struct {
+	Overall        float64 `json:"overall"`
+	Tone           float64 `json:"tone"`
+	DoNotSay       float64 `json:"do_not_say"`
+	Formality      float64 `json:"formality"`
+	SafetyFailures int     `json:"safety_failures"`
+	AvgLatencyMS   int64   `json:"avg_latency_ms"`
+}
+
+// LLMCanaryComparison aggregates the finished runs of one candidate model
+// against one primary model for one kind of generation. Scores only cover
+// runs where the candidate produced output.
+type LLMCanaryComparison struct {
+	CandidateModel  string        `json:"candidate_model"`
+	PrimaryModel    string        `json:"primary_model"`
+	Kind            string        `json:"kind"`
+	Runs            int           `json:"runs"`
+	CandidateErrors int           `json:"candidate_errors"`
+	ErrorRate       float64       `json:"error_rate"`
+	Scored          int           `json:"scored"`
+	Primary         LLMCanarySide `json:"primary"`
+	Candidate       LLMCanarySide `json:"candidate"`
+	DeltaOverall    float64       `json:"delta_overall"`
+	Wins            int           `json:"wins"`
+	Ties            int           `json:"ties"`
+	Losses          int           `json:"losses"`
+	// Verdict is insufficient_data, regression, comparable or improvement.
+	Verdict string   `json:"verdict"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type LLMCanarySample struct {
+	ID                   int64     `json:"id"`
+	Kind                 string    `json:"kind"`
+	PersonaID            string    `json:"persona_id"`
+	PrimaryModel         string    `json:"primary_model"`
+	CandidateModel       string    `json:"candidate_model"`
+	PrimaryOutput        string    `json:"primary_output"`
+	CandidateOutput      string    `json:"candidate_output,omitempty"`
+	CandidateError       string    `json:"candidate_error,omitempty"`
+	PrimaryOverall       *float64  `json:"primary_overall,omitempty"`
+	CandidateOverall     *float64  `json:"candidate_overall,omitempty"`
+	PrimarySafetyError   string    `json:"primary_safety_error,omitempty"`
+	CandidateSafetyError string    `json:"candidate_safety_error,omitempty"`
+	CompletedAt          time.Time `json:"completed_at"`
+}
+
+// queueLLMCanary samples a generation for shadowing by the candidate model.
+// Failing to queue never fails the generation it shadows.
+func (s *Server) queueLLMCanary(ctx context.Context, run common.LLMCanaryRun) {
+	if !s.cfg.LLMCanaryEnabled() || !common.SampleLLMCanary(s.cfg.LLMCanaryPct) {
+		return
+	}
+	run.PrimaryModel = ai.ModelName(s.cfg)
+	run.CandidateModel = s.cfg.LLMCanaryModel
+	if err := common.QueueLLMCanaryRun(ctx, s.db, run); err != nil {
+		s.logger.Warn("llm_canary_queue_failed", observability.Fields{
+			"kind":       run.Kind,
+			"persona_id": run.PersonaID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// handleAdminLLMCanaryReport compares candidate model runs against the
+// outputs the primary model produced for the same prompts.
+func (s *Server) handleAdminLLMCanaryReport(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	query := r.URL.Query()
+	days := llmCanaryDefaultDays
+	if raw := strings.TrimSpace(query.Get("days")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > llmCanaryMaxDays {
+			writeBadRequest(w, fmt.Sprintf("days must be between 1 and %d", llmCanaryMaxDays))
+			return
+		}
+		days = value
+	}
+	kind := strings.ToLower(strings.TrimSpace(query.Get("kind")))
+	if kind != "" && kind != common.LLMCanaryKindDraft && kind != common.LLMCanaryKindReply {
+		writeBadRequest(w, "kind must be draft or reply")
+		return
+	}
+	model := strings.TrimSpace(query.Get("model"))
+	since := time.Now().UTC().AddDate(0, 0, -days)
+
+	comparisons, err := s.loadLLMCanaryComparisons(r.Context(), since, model, kind)
+	if err != nil {
+		writeInternalError(w, "could not load canary report")
+		return
+	}
+	samples, err := s.loadLLMCanarySamples(r.Context(), since, model, kind)
+	if err != nil {
+		writeInternalError(w, "could not load canary report")
+		return
+	}
+	var pending int
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT COUNT(*)
+		FROM llm_canary_runs
+		WHERE status = $1
+	`, common.LLMCanaryPending).Scan(&pending); err != nil {
+		writeInternalError(w, "could not load canary report")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"primary_model":   ai.ModelName(s.cfg),
+		"candidate_model": s.cfg.LLMCanaryModel,
+		"percent":         s.cfg.LLMCanaryPct,
+		"enabled":         s.cfg.LLMCanaryEnabled(),
+		"since":           since,
+		"pending":         pending,
+		"comparisons":     comparisons,
+		"samples":         samples,
+	})
+}
+
+func (s *Server) loadLLMCanaryComparisons(ctx context.Context, since time.Time, model, kind string) ([]LLMCanaryComparison, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH scored AS (
+			SELECT *,
+				(primary_score->>'overall')::float8 AS primary_overall,
+				(candidate_score->>'overall')::float8 AS candidate_overall
+			FROM llm_canary_runs
+			WHERE status = 'DONE'
+			  AND completed_at >= $1
+			  AND ($2 = '' OR candidate_model = $2)
+			  AND ($3 = '' OR kind = $3)
+		)
+		SELECT candidate_model, primary_model, kind,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE candidate_error IS NOT NULL),
+			COUNT(candidate_score),
+			COALESCE(AVG(primary_overall) FILTER (WHERE candidate_score IS NOT NULL), 0),
+			COALESCE(AVG((primary_score->>'tone')::float8) FILTER (WHERE candidate_score IS NOT NULL), 0),
+			COALESCE(AVG((primary_score->>'do_not_say')::float8) FILTER (WHERE candidate_score IS NOT NULL), 0),
+			COALESCE(AVG((primary_score->>'formality')::float8) FILTER (WHERE candidate_score IS NOT NULL), 0),
+			COUNT(*) FILTER (WHERE candidate_score IS NOT NULL AND primary_safety_error IS NOT NULL),
+			COALESCE(AVG(primary_latency_ms) FILTER (WHERE candidate_score IS NOT NULL), 0)::bigint,
+			COALESCE(AVG(candidate_overall), 0),
+			COALESCE(AVG((candidate_score->>'tone')::float8), 0),
+			COALESCE(AVG((candidate_score->>'do_not_say')::float8), 0),
+			COALESCE(AVG((candidate_score->>'formality')::float8), 0),
+			COUNT(*) FILTER (WHERE candidate_safety_error IS NOT NULL),
+			COALESCE(AVG(candidate_latency_ms) FILTER (WHERE candidate_score IS NOT NULL), 0)::bigint,
+			COUNT(*) FILTER (WHERE candidate_overall - primary_overall > $4),
+			COUNT(*) FILTER (WHERE primary_overall - candidate_overall > $4)
+		FROM scored
+		GROUP BY candidate_model, primary_model, kind
+		ORDER BY candidate_model, primary_model, kind
+	`, since, model, kind, llmCanaryTieMargin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comparisons := []LLMCanaryComparison{}
+	for rows.Next() {
+		var c LLMCanaryComparison
+		if err := rows.Scan(
+			&c.CandidateModel,
+			&c.PrimaryModel,
+			&c.Kind,
+			&c.Runs,
+			&c.CandidateErrors,
+			&c.Scored,
+			&c.Primary.Overall,
+			&c.Primary.Tone,
+			&c.Primary.DoNotSay,
+			&c.Primary.Formality,
+			&c.Primary.SafetyFailures,
+			&c.Primary.AvgLatencyMS,
+			&c.Candidate.Overall,
+			&c.Candidate.Tone,
+			&c.Candidate.DoNotSay,
+			&c.Candidate.Formality,
+			&c.Candidate.SafetyFailures,
+			&c.Candidate.AvgLatencyMS,
+			&c.Wins,
+			&c.Losses,
+		); err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, finishLLMCanaryComparison(c))
+	}
+	return comparisons, rows.Err()
+}
+
+// finishLLMCanaryComparison rounds the averages and derives the rates,
+// ties and verdict from the raw aggregates.
+func finishLLMCanaryComparison(c LLMCanaryComparison) LLMCanaryComparison {
+	for _, side := range []*LLMCanarySide{&c.Primary, &c.Candidate} {
+		side.Overall = roundFeedScore(side.Overall)
+		side.Tone = roundFeedScore(side.Tone)
+		side.DoNotSay = roundFeedScore(side.DoNotSay)
+		side.Formality = roundFeedScore(side.Formality)
+	}
+	if c.Runs > 0 {
+		c.ErrorRate = roundFeedScore(float64(c.CandidateErrors) / float64(c.Runs))
+	}
+	c.DeltaOverall = roundFeedScore(c.Candidate.Overall - c.Primary.Overall)
+	c.Ties = max(c.Scored-c.Wins-c.Losses, 0)
+	c.Verdict, c.Reasons = llmCanaryVerdict(c)
+	return c
+}
+
+// llmCanaryVerdict is a first read on whether the candidate is safe to
+// switch to. It is a heuristic over the quality scores, not a substitute
+// for reading the samples.
+func llmCanaryVerdict(c LLMCanaryComparison) (string, []string) {
+	if c.Scored < llmCanaryMinRuns {
+		return "insufficient_data", []string{fmt.Sprintf("%d scored runs, need %d", c.Scored, llmCanaryMinRuns)}
+	}
+
+	var reasons []string
+	if c.Runs > 0 && float64(c.CandidateErrors)/float64(c.Runs) > llmCanaryMaxErrorRate {
+		reasons = append(reasons, fmt.Sprintf("candidate failed %d of %d runs", c.CandidateErrors, c.Runs))
+	}
+	primarySafety := float64(c.Primary.SafetyFailures) / float64(c.Scored)
+	candidateSafety := float64(c.Candidate.SafetyFailures) / float64(c.Scored)
+	if candidateSafety-primarySafety > llmCanaryMaxSafetyIncrease {
+		reasons = append(reasons, fmt.Sprintf("candidate failed safety rules %d times, primary %d", c.Candidate.SafetyFailures, c.Primary.SafetyFailures))
+	}
+	if c.DeltaOverall < -llmCanaryTieMargin {
+		reasons = append(reasons, fmt.Sprintf("overall quality dropped by %.2f", -c.DeltaOverall))
+	}
+	if len(reasons) > 0 {
+		return "regression", reasons
+	}
+	if c.DeltaOverall > llmCanaryTieMargin {
+		return "improvement", nil
+	}
+	return "comparable", nil
+}
+
+func (s *Server) loadLLMCanarySamples(ctx context.Context, since time.Time, model, kind string) ([]LLMCanarySample, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, kind, persona_id::text, primary_model, candidate_model, primary_output,
+			COALESCE(candidate_output, ''), COALESCE(candidate_error, ''),
+			(primary_score->>'overall')::float8, (candidate_score->>'overall')::float8,
+			COALESCE(primary_safety_error, ''), COALESCE(candidate_safety_error, ''), completed_at
+		FROM llm_canary_runs
+		WHERE status = 'DONE'
+		  AND completed_at >= $1
+		  AND ($2 = '' OR candidate_model = $2)
+		  AND ($3 = '' OR kind = $3)
+		ORDER BY completed_at DESC, id DESC
+		LIMIT $4
+	`, since, model, kind, llmCanarySamples)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []LLMCanarySample{}
+	for rows.Next() {
+		var sample LLMCanarySample
+		if err := rows.Scan(
+			&sample.ID,
+			&sample.Kind,
+			&sample.PersonaID,
+			&sample.PrimaryModel,
+			&sample.CandidateModel,
+			&sample.PrimaryOutput,
+			&sample.CandidateOutput,
+			&sample.CandidateError,
+			&sample.PrimaryOverall,
+			&sample.CandidateOverall,
+			&sample.PrimarySafetyError,
+			&sample.CandidateSafetyError,
+			&sample.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return samples, rows.Err()
+}
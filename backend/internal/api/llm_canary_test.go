@@ -0,0 +1,54 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFinishLLMCanaryComparison(t *testing.T) {
+	base := LLMCanaryComparison{
+		Runs:      40,
+		Scored:    40,
+		Primary:   LLMCanarySide{Overall: 0.8012},
+		Candidate: LLMCanarySide{Overall: 0.8234},
+		Wins:      10,
+		Losses:    6,
+	}
+
+	got := finishLLMCanaryComparison(base)
+	if got.Verdict != "comparable" || got.Ties != 24 || got.DeltaOverall != 0.02 {
+		t.Fatalf("expected a comparable result with 24 ties, got %+v", got)
+	}
+
+	better := base
+	better.Candidate.Overall = 0.9
+	if got := finishLLMCanaryComparison(better); got.Verdict != "improvement" {
+		t.Fatalf("expected improvement, got %s", got.Verdict)
+	}
+
+	few := base
+	few.Scored = 5
+	if got := finishLLMCanaryComparison(few); got.Verdict != "insufficient_data" {
+		t.Fatalf("expected insufficient_data, got %s", got.Verdict)
+	}
+
+	failing := better
+	failing.Runs, failing.CandidateErrors = 44, 4
+	got = finishLLMCanaryComparison(failing)
+	if got.Verdict != "regression" || got.ErrorRate != 0.09 || len(got.Reasons) != 1 || !strings.Contains(got.Reasons[0], "4 of 44") {
+		t.Fatalf("expected a regression on errors, got %+v", got)
+	}
+
+	unsafe := base
+	unsafe.Primary.SafetyFailures, unsafe.Candidate.SafetyFailures = 1, 3
+	got = finishLLMCanaryComparison(unsafe)
+	if got.Verdict != "regression" || !strings.Contains(got.Reasons[0], "safety") {
+		t.Fatalf("expected a regression on safety, got %+v", got)
+	}
+
+	worse := base
+	worse.Candidate.Overall = 0.7
+	if got := finishLLMCanaryComparison(worse); got.Verdict != "regression" {
+		t.Fatalf("expected a regression on quality, got %s", got.Verdict)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const personaMentionsListLimit = 50
+
+type PersonaMention struct {
+	ID              int64     `json:"id"`
+	PostID          string    `json:"post_id"`
+	ReplyID         string    `json:"reply_id,omitempty"`
+	AuthorPersonaID string    `json:"author_persona_id,omitempty"`
+	AuthorName      string    `json:"author_name,omitempty"`
+	Preview         string    `json:"preview"`
+	ReplyEnqueued   bool      `json:"reply_enqueued"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// recordMentions runs after publish has committed. Mentions are a side effect,
+// so a failure is logged rather than undoing the post or reply.
+func (s *Server) recordMentions(r *http.Request, source common.MentionSource) {
+	source.TraceID = requestIDFromRequest(r)
+	mentions, err := common.RecordMentions(r.Context(), s.db, source)
+	if err != nil {
+		s.logger.Warn("mention_record_failed", observability.Fields{
+			"request_id": source.TraceID,
+			"post_id":    source.PostID,
+			"reply_id":   source.ReplyID,
+			"error":      err.Error(),
+		})
+		return
+	}
+	for _, mention := range mentions {
+		s.logger.Info("persona_mentioned", observability.Fields{
+			"request_id":     source.TraceID,
+			"post_id":        source.PostID,
+			"persona_id":     mention.PersonaID,
+			"reply_enqueued": mention.ReplyEnqueued,
+		})
+	}
+}
+
+func (s *Server) handleListPersonaMentions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var repliesEnabled bool
+	err = s.db.QueryRow(r.Context(), `
+		SELECT mention_replies_enabled
+		FROM personas
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID).Scan(&repliesEnabled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT m.id, m.post_id::text, COALESCE(m.reply_id::text, ''), COALESCE(m.author_persona_id::text, ''),
+			COALESCE(ap.name, ''), COALESCE(rp.content, p.content), m.reply_enqueued, m.created_at
+		FROM mentions m
+		JOIN posts p ON p.id = m.post_id
+		LEFT JOIN replies rp ON rp.id = m.reply_id
+		LEFT JOIN personas ap ON ap.id = m.author_persona_id
+		WHERE m.mentioned_persona_id = $1
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $2
+	`, personaID, personaMentionsListLimit)
+	if err != nil {
+		writeInternalError(w, "could not load mentions")
+		return
+	}
+	defer rows.Close()
+
+	mentions := make([]PersonaMention, 0)
+	for rows.Next() {
+		var mention PersonaMention
+		var content string
+		if err := rows.Scan(&mention.ID, &mention.PostID, &mention.ReplyID, &mention.AuthorPersonaID, &mention.AuthorName, &content, &mention.ReplyEnqueued, &mention.CreatedAt); err != nil {
+			writeInternalError(w, "could not read mentions")
+			return
+		}
+		mention.Preview = common.TruncateRunes(content, 160)
+		mentions = append(mentions, mention)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not read mentions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"mention_replies_enabled": repliesEnabled,
+		"mentions":                mentions,
+	})
+}
+
+// handleUpdateMentionReplies toggles whether mentions of this persona queue a
+// reply from it. Notifications are sent either way.
+func (s *Server) handleUpdateMentionReplies(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if req.Enabled == nil {
+		writeBadRequest(w, "enabled is required")
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		UPDATE personas
+		SET mention_replies_enabled = $3,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+	`, personaID, userID, *req.Enabled)
+	if err != nil {
+		writeInternalError(w, "could not update mention replies")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeNotFound(w, "persona not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"mention_replies_enabled": *req.Enabled,
+	})
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -12,21 +13,31 @@ import (
 	"personaworlds/backend/internal/common"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
 )
 
 const (
-	notificationTypeBattleRemixed = "battle_remixed"
-	notificationTypeTemplateUsed  = "template_used"
-	notificationTypePersonaFollow = "persona_followed"
+	notificationTypeBattleRemixed     = "battle_remixed"
+	notificationTypeTemplateUsed      = "template_used"
+	notificationTypePersonaFollow     = "persona_followed"
+	notificationTypeQuestion          = "persona_question"
+	notificationTypeChallenge         = "battle_challenge"
+	notificationTypeChallengeAccepted = "battle_challenge_accepted"
+	notificationTypeEditSuggested     = "persona_edit_suggested"
 )
 
+// Notification is one entry of a user's notification center. Seq grows per
+// user with every new or rolled-up notification; clients use it to mark
+// everything they have shown as read.
 type Notification struct {
 	ID          int64          `json:"id"`
+	Seq         int64          `json:"seq"`
 	ActorUserID string         `json:"actor_user_id,omitempty"`
 	Type        string         `json:"type"`
 	Title       string         `json:"title"`
 	Body        string         `json:"body"`
 	Metadata    map[string]any `json:"metadata"`
+	Count       int            `json:"count"`
 	ReadAt      *time.Time     `json:"read_at,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
 }
@@ -36,22 +47,153 @@ func (s *Server) insertNotification(ctx context.Context, userID, actorUserID, no
 	if cleanUserID == "" {
 		return nil
 	}
+	cleanType := strings.TrimSpace(notifType)
+	cleanMetadata := sanitizeEventMetadata(metadata)
 
-	payload, err := json.Marshal(sanitizeEventMetadata(metadata))
+	var actorArg any
+	if strings.TrimSpace(actorUserID) != "" {
+		actorArg = strings.TrimSpace(actorUserID)
+	}
+
+	rollupKey := notificationRollupKey(cleanType, cleanMetadata)
+	window := s.cfg.NotificationRollups[cleanType]
+	if rollupKey == "" || window <= 0 {
+		payload, err := json.Marshal(cleanMetadata)
+		if err != nil {
+			return err
+		}
+		_, err = s.db.Exec(ctx, `
+			INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
+			VALUES ($1, $2, $3, $4, $5, $6::jsonb)
+		`, cleanUserID, actorArg, cleanType, common.TruncateRunes(title, 120), common.TruncateRunes(body, 260), payload)
+		return err
+	}
+
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	var actorArg any
-	if strings.TrimSpace(actorUserID) != "" {
-		actorArg = strings.TrimSpace(actorUserID)
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", cleanUserID+":"+cleanType+":"+rollupKey); err != nil {
+		return err
+	}
+
+	var (
+		existingID   int64
+		existingRaw  []byte
+		rollupCount  int
+		existingMeta = map[string]any{}
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT id, metadata, rollup_count
+		FROM notifications
+		WHERE user_id = $1
+		  AND type = $2
+		  AND rollup_key = $3
+		  AND read_at IS NULL
+		  AND rollup_started_at >= NOW() - ($4::double precision * INTERVAL '1 second')
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, cleanUserID, cleanType, rollupKey, window.Seconds()).Scan(&existingID, &existingRaw, &rollupCount)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		payload, err := json.Marshal(cleanMetadata)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata, rollup_key)
+			VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7)
+		`, cleanUserID, actorArg, cleanType, common.TruncateRunes(title, 120), common.TruncateRunes(body, 260), payload, rollupKey); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	if len(existingRaw) > 0 {
+		_ = json.Unmarshal(existingRaw, &existingMeta)
+	}
+	for key, value := range cleanMetadata {
+		existingMeta[key] = value
+	}
+	rollupCount++
+	existingMeta["rollup_count"] = rollupCount
+
+	rolledTitle, rolledBody := rollupNotificationCopy(cleanType, rollupCount, window, existingMeta)
+	payload, err := json.Marshal(existingMeta)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE notifications
+		SET actor_user_id = $2,
+			title = $3,
+			body = $4,
+			metadata = $5::jsonb,
+			rollup_count = $6,
+			created_at = NOW()
+		WHERE id = $1
+	`, existingID, actorArg, common.TruncateRunes(rolledTitle, 120), common.TruncateRunes(rolledBody, 260), payload, rollupCount); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func notificationRollupKey(notifType string, metadata map[string]any) string {
+	field := ""
+	switch notifType {
+	case notificationTypePersonaFollow:
+		field = "persona_id"
+	case notificationTypeBattleRemixed:
+		field = "source_battle_id"
+	case notificationTypeTemplateUsed:
+		field = "template_id"
+	case notificationTypeQuestion:
+		field = "persona_id"
+	default:
+		return ""
+	}
+	value, _ := metadata[field].(string)
+	return strings.TrimSpace(value)
+}
+
+func rollupNotificationCopy(notifType string, count int, window time.Duration, metadata map[string]any) (string, string) {
+	period := ""
+	switch {
+	case window <= 24*time.Hour:
+		period = " today"
+	case window <= 7*24*time.Hour:
+		period = " this week"
 	}
 
-	_, err = s.db.Exec(ctx, `
-		INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
-		VALUES ($1, $2, $3, $4, $5, $6::jsonb)
-	`, cleanUserID, actorArg, strings.TrimSpace(notifType), common.TruncateRunes(title, 120), common.TruncateRunes(body, 260), payload)
-	return err
+	switch notifType {
+	case notificationTypePersonaFollow:
+		name, _ := metadata["persona_name"].(string)
+		if strings.TrimSpace(name) == "" {
+			name = "your persona"
+		}
+		return "New followers", fmt.Sprintf("%d people followed %s%s.", count, strings.TrimSpace(name), period)
+	case notificationTypeBattleRemixed:
+		return "Your battle was remixed", fmt.Sprintf("Your battle was remixed %d times%s.", count, period)
+	case notificationTypeTemplateUsed:
+		name, _ := metadata["template_name"].(string)
+		if strings.TrimSpace(name) == "" {
+			return "Your template was used", fmt.Sprintf("A template you created was used in %d new battles%s.", count, period)
+		}
+		return "Your template was used", fmt.Sprintf("Your template \"%s\" was used in %d new battles%s.", strings.TrimSpace(name), count, period)
+	case notificationTypeQuestion:
+		name, _ := metadata["persona_name"].(string)
+		if strings.TrimSpace(name) == "" {
+			name = "your persona"
+		}
+		return "New questions", fmt.Sprintf("Visitors asked %s %d questions%s.", strings.TrimSpace(name), count, period)
+	default:
+		return "New activity", fmt.Sprintf("%d new updates%s.", count, period)
+	}
 }
 
 func (s *Server) unreadNotificationsCount(ctx context.Context, userID string) (int, error) {
@@ -65,7 +207,7 @@ func (s *Server) unreadNotificationsCount(ctx context.Context, userID string) (i
 	return count, err
 }
 
-func (s *Server) listNotifications(ctx context.Context, userID string, limit int) ([]Notification, int, error) {
+func (s *Server) listNotifications(ctx context.Context, userID string, limit int) ([]Notification, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -76,11 +218,13 @@ func (s *Server) listNotifications(ctx context.Context, userID string, limit int
 	rows, err := s.db.Query(ctx, `
 		SELECT
 			id,
+			seq,
 			COALESCE(actor_user_id::text, ''),
 			type,
 			title,
 			body,
 			metadata,
+			rollup_count,
 			read_at,
 			created_at
 		FROM notifications
@@ -89,7 +233,7 @@ func (s *Server) listNotifications(ctx context.Context, userID string, limit int
 		LIMIT $2
 	`, strings.TrimSpace(userID), limit)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -101,34 +245,30 @@ func (s *Server) listNotifications(ctx context.Context, userID string, limit int
 		)
 		if err := rows.Scan(
 			&notification.ID,
+			&notification.Seq,
 			&notification.ActorUserID,
 			&notification.Type,
 			&notification.Title,
 			&notification.Body,
 			&metadataRaw,
+			&notification.Count,
 			&notification.ReadAt,
 			&notification.CreatedAt,
 		); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		notification.Metadata = map[string]any{}
 		if len(metadataRaw) > 0 {
 			if err := json.Unmarshal(metadataRaw, &notification.Metadata); err != nil {
-				return nil, 0, err
+				return nil, err
 			}
 		}
 		notifications = append(notifications, notification)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, 0, err
-	}
-
-	unreadCount, err := s.unreadNotificationsCount(ctx, userID)
-	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-
-	return notifications, unreadCount, nil
+	return notifications, nil
 }
 
 func (s *Server) handleListNotifications(w http.ResponseWriter, r *http.Request) {
@@ -145,7 +285,20 @@ func (s *Server) handleListNotifications(w http.ResponseWriter, r *http.Request)
 	}
 	limit = parsedLimit
 
-	notifications, unreadCount, err := s.listNotifications(r.Context(), userID, limit)
+	state, err := s.loadNotificationState(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load notifications")
+		return
+	}
+	etag := state.etag(limit)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	notifications, err := s.listNotifications(r.Context(), userID, limit)
 	if err != nil {
 		writeInternalError(w, "could not load notifications")
 		return
@@ -153,10 +306,64 @@ func (s *Server) handleListNotifications(w http.ResponseWriter, r *http.Request)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"notifications": notifications,
-		"unread_count":  unreadCount,
+		"unread_count":  state.Unread,
+		"last_seq":      state.LastSeq,
 	})
 }
 
+// notificationState is what a notification list depends on. Any new or
+// rolled-up notification raises LastSeq; reading one changes Unread and
+// LastReadAt; pruning changes Total.
+type notificationState struct {
+	LastSeq    int64
+	Unread     int
+	Total      int
+	LastReadAt *time.Time
+}
+
+func (s *Server) loadNotificationState(ctx context.Context, userID string) (notificationState, error) {
+	var state notificationState
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT last_seq FROM notification_sequences WHERE user_id = $1), 0),
+			COUNT(*) FILTER (WHERE read_at IS NULL)::int,
+			COUNT(*)::int,
+			MAX(read_at)
+		FROM notifications
+		WHERE user_id = $1
+	`, strings.TrimSpace(userID)).Scan(&state.LastSeq, &state.Unread, &state.Total, &state.LastReadAt)
+	return state, err
+}
+
+// etag identifies one page of the list. It is weak because read_at values
+// are compared at microsecond precision rather than by response bytes.
+func (st notificationState) etag(limit int) string {
+	var lastRead int64
+	if st.LastReadAt != nil {
+		lastRead = st.LastReadAt.UTC().UnixMicro()
+	}
+	return fmt.Sprintf(`W/"notifications-%d-%d-%d-%d-%d"`, st.LastSeq, st.Unread, st.Total, lastRead, limit)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak and
+// strong forms of the same tag match, as RFC 9110 asks for GET.
+func etagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
 	userID, ok := s.requireUserID(w, r)
 	if !ok {
@@ -216,7 +423,48 @@ func (s *Server) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.R
 	})
 }
 
-func (s *Server) notifyPersonaFollowed(ctx context.Context, ownerUserID, actorUserID, personaID, slug string) error {
+// handleMarkNotificationsReadUpTo marks every notification with a sequence
+// number up to seq as read. Clients send the last_seq they displayed, so a
+// notification that arrived or rolled up since stays unread on every device.
+func (s *Server) handleMarkNotificationsReadUpTo(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	seq, err := strconv.ParseInt(strings.TrimSpace(chi.URLParam(r, "seq")), 10, 64)
+	if err != nil || seq <= 0 {
+		writeBadRequest(w, "seq is invalid")
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1
+		  AND seq <= $2
+		  AND read_at IS NULL
+	`, strings.TrimSpace(userID), seq)
+	if err != nil {
+		writeInternalError(w, "could not mark notifications as read")
+		return
+	}
+
+	state, err := s.loadNotificationState(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load unread notifications")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"updated":      ct.RowsAffected(),
+		"read_up_to":   seq,
+		"unread_count": state.Unread,
+		"last_seq":     state.LastSeq,
+	})
+}
+
+func (s *Server) notifyPersonaFollowed(ctx context.Context, ownerUserID, actorUserID, personaID, personaName, slug string) error {
 	cleanOwner := strings.TrimSpace(ownerUserID)
 	cleanActor := strings.TrimSpace(actorUserID)
 	if cleanOwner == "" || cleanOwner == cleanActor {
@@ -227,8 +475,72 @@ func (s *Server) notifyPersonaFollowed(ctx context.Context, ownerUserID, actorUs
 		"New follower",
 		"Your public persona just got a new follower.",
 		map[string]any{
-			"persona_id": strings.TrimSpace(personaID),
-			"slug":       strings.TrimSpace(slug),
+			"persona_id":   strings.TrimSpace(personaID),
+			"persona_name": strings.TrimSpace(personaName),
+			"slug":         strings.TrimSpace(slug),
+		},
+	)
+}
+
+func (s *Server) notifyPersonaQuestion(ctx context.Context, ownerUserID, actorUserID, personaID, personaName, questionID string) error {
+	cleanOwner := strings.TrimSpace(ownerUserID)
+	if cleanOwner == "" || cleanOwner == strings.TrimSpace(actorUserID) {
+		return nil
+	}
+
+	return s.insertNotification(ctx, cleanOwner, actorUserID, notificationTypeQuestion,
+		"New question",
+		fmt.Sprintf("A visitor asked %s a question. Approve it to publish an answer.", strings.TrimSpace(personaName)),
+		map[string]any{
+			"persona_id":   strings.TrimSpace(personaID),
+			"persona_name": strings.TrimSpace(personaName),
+			"question_id":  strings.TrimSpace(questionID),
+		},
+	)
+}
+
+// notifyPersonaEditSuggested tells the owner someone with a share link
+// suggested a change. Link holders may not have an account, so there is no
+// actor.
+func (s *Server) notifyPersonaEditSuggested(ctx context.Context, ownerUserID, personaID, personaName, suggestionID, authorName string) error {
+	author := strings.TrimSpace(authorName)
+	if author == "" {
+		author = "Someone with a share link"
+	}
+	return s.insertNotification(ctx, ownerUserID, "", notificationTypeEditSuggested,
+		"New edit suggestion",
+		fmt.Sprintf("%s suggested a change to %s.", author, strings.TrimSpace(personaName)),
+		map[string]any{
+			"persona_id":    strings.TrimSpace(personaID),
+			"persona_name":  strings.TrimSpace(personaName),
+			"suggestion_id": strings.TrimSpace(suggestionID),
+		},
+	)
+}
+
+func (s *Server) notifyBattleChallenge(ctx context.Context, opponentUserID, actorUserID string, challenge BattleChallenge) error {
+	return s.insertNotification(ctx, opponentUserID, actorUserID, notificationTypeChallenge,
+		"New battle challenge",
+		fmt.Sprintf("%s challenged %s to a battle: \"%s\". Accept to start it.", challenge.Challenger.Name, challenge.Opponent.Name, common.TruncateRunes(challenge.Topic, 120)),
+		map[string]any{
+			"challenge_id":          challenge.ID,
+			"persona_id":            challenge.Opponent.ID,
+			"persona_name":          challenge.Opponent.Name,
+			"challenger_persona_id": challenge.Challenger.ID,
+			"challenger_name":       challenge.Challenger.Name,
+		},
+	)
+}
+
+func (s *Server) notifyBattleChallengeAccepted(ctx context.Context, challengerUserID, actorUserID string, challenge BattleChallenge) error {
+	return s.insertNotification(ctx, challengerUserID, actorUserID, notificationTypeChallengeAccepted,
+		"Challenge accepted",
+		fmt.Sprintf("%s accepted your challenge. The battle has started.", challenge.Opponent.Name),
+		map[string]any{
+			"challenge_id": challenge.ID,
+			"battle_id":    challenge.BattleID,
+			"persona_id":   challenge.Challenger.ID,
+			"persona_name": challenge.Challenger.Name,
 		},
 	)
 }
@@ -286,8 +598,9 @@ func (s *Server) notifyTemplateUsed(ctx context.Context, actorUserID string, tem
 		"Your template was used",
 		body,
 		map[string]any{
-			"template_id": strings.TrimSpace(template.ID),
-			"battle_id":   strings.TrimSpace(battleID),
+			"template_id":   strings.TrimSpace(template.ID),
+			"template_name": strings.TrimSpace(template.Name),
+			"battle_id":     strings.TrimSpace(battleID),
 		},
 	)
 }
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationRollupKeyUsesSubject(t *testing.T) {
+	metadata := map[string]any{"persona_id": "persona-1", "slug": "golge-yazar"}
+	if got := notificationRollupKey(notificationTypePersonaFollow, metadata); got != "persona-1" {
+		t.Fatalf("expected persona-1, got %q", got)
+	}
+	if got := notificationRollupKey("unknown", metadata); got != "" {
+		t.Fatalf("expected unknown type to skip rollup, got %q", got)
+	}
+}
+
+func TestRollupNotificationCopy(t *testing.T) {
+	_, body := rollupNotificationCopy(notificationTypePersonaFollow, 5, 24*time.Hour, map[string]any{"persona_name": "Gölge Yazar"})
+	if body != "5 people followed Gölge Yazar today." {
+		t.Fatalf("unexpected follow rollup body: %q", body)
+	}
+
+	_, body = rollupNotificationCopy(notificationTypeTemplateUsed, 3, 6*time.Hour, map[string]any{"template_name": "Claim/Evidence"})
+	if body != "Your template \"Claim/Evidence\" was used in 3 new battles today." {
+		t.Fatalf("unexpected template rollup body: %q", body)
+	}
+
+	_, body = rollupNotificationCopy(notificationTypeQuestion, 4, 24*time.Hour, map[string]any{"persona_name": "Ada"})
+	if body != "Visitors asked Ada 4 questions today." {
+		t.Fatalf("unexpected question rollup body: %q", body)
+	}
+}
+
+func TestNotificationStateETag(t *testing.T) {
+	readAt := time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC)
+	base := notificationState{LastSeq: 42, Unread: 3, Total: 10, LastReadAt: &readAt}
+	etag := base.etag(20)
+
+	if etag != base.etag(20) {
+		t.Fatalf("expected a stable etag")
+	}
+	if etag == base.etag(50) {
+		t.Fatalf("expected the limit to change the etag")
+	}
+
+	laterRead := readAt.Add(time.Second)
+	changes := []notificationState{
+		{LastSeq: 43, Unread: 3, Total: 10, LastReadAt: &readAt},
+		{LastSeq: 42, Unread: 2, Total: 10, LastReadAt: &laterRead},
+		{LastSeq: 42, Unread: 3, Total: 9, LastReadAt: &readAt},
+		{LastSeq: 42, Unread: 3, Total: 10},
+	}
+	for _, changed := range changes {
+		if changed.etag(20) == etag {
+			t.Fatalf("expected %+v to change the etag", changed)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"notifications-1-0-1-0-20"`
+	cases := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: etag, want: true},
+		{header: `"notifications-1-0-1-0-20"`, want: true},
+		{header: `"other", ` + etag, want: true},
+		{header: "*", want: true},
+		{header: `W/"notifications-2-0-1-0-20"`, want: false},
+	}
+	for _, tc := range cases {
+		if got := etagMatches(tc.header, etag); got != tc.want {
+			t.Fatalf("etagMatches(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
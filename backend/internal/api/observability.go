@@ -5,19 +5,26 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"path/filepath"
 	"runtime/debug"
 	"sort"
 	"strings"
 	"time"
 
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/db"
 	"personaworlds/backend/internal/observability"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+const (
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// queueDepthPushEvery is how often queue depth is refreshed for a
+	// metrics sink; /metrics refreshes it on every scrape instead.
+	queueDepthPushEvery = 15 * time.Second
+)
 
 func (s *Server) requestObservabilityMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -48,6 +55,14 @@ func (s *Server) requestObservabilityMiddleware(next http.Handler) http.Handler
 	})
 }
 
+// llmRequestIDMiddleware hands the request id to LLM calls made while
+// serving the request, so provider logs can be matched to it.
+func llmRequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ai.WithRequestID(r.Context(), requestIDFromRequest(r))))
+	})
+}
+
 func (s *Server) recoverJSONMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -72,7 +87,22 @@ func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
+// handleLivez reports that the process is up. Unlike /readyz it stays green
+// while draining, so orchestrators don't kill the process before in-flight
+// requests finish.
+func (s *Server) handleLivez(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "draining": s.Draining()})
+}
+
 func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.Draining() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"ok":    false,
+			"error": "draining",
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
 
@@ -95,6 +125,7 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			s.logger.Warn("queue_depth_refresh_failed", observability.Fields{"error": err.Error()})
 		}
+		s.refreshDBPoolMetrics()
 	}
 
 	w.Header().Set("Content-Type", metricsContentType)
@@ -136,18 +167,61 @@ func (s *Server) checkReady(ctx context.Context) error {
 	return nil
 }
 
+// BeginDrain fails /readyz from now on so load balancers stop routing new
+// requests here, and ends open thread streams, which would otherwise hold
+// the shutdown open until the grace period runs out.
+func (s *Server) BeginDrain() {
+	if s.draining.Swap(true) {
+		return
+	}
+	s.logger.Info("api_draining", nil)
+	s.threadUpdates.closeAll()
+}
+
+// Draining reports whether BeginDrain was called.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// SetMetricsSink also sends API metrics to sink, next to /metrics.
+func (s *Server) SetMetricsSink(sink observability.MetricsSink) {
+	s.metrics.SetSink(sink)
+}
+
+// RunQueueDepthRefresh keeps queue depth current for a metrics sink when no
+// Prometheus scraper is polling /metrics. It returns when ctx is done.
+func (s *Server) RunQueueDepthRefresh(ctx context.Context) {
+	ticker := time.NewTicker(queueDepthPushEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			err := s.refreshQueueDepthMetrics(refreshCtx)
+			cancel()
+			if err != nil {
+				s.logger.Warn("queue_depth_refresh_failed", observability.Fields{"error": err.Error()})
+			}
+			s.refreshDBPoolMetrics()
+		}
+	}
+}
+
+func (s *Server) refreshDBPoolMetrics() {
+	if s.db == nil {
+		return
+	}
+	s.metrics.SetDBPoolStats("api", db.PoolStats(s.db))
+}
+
 func (s *Server) refreshQueueDepthMetrics(ctx context.Context) error {
 	if s.db == nil {
 		s.metrics.SetQueueDepthSnapshot(map[string]int{})
 		return nil
 	}
-	maxAttempts := s.cfg.JobMaxAttempts
-	if maxAttempts < 1 {
-		maxAttempts = 5
-	}
-	if maxAttempts > 20 {
-		maxAttempts = 20
-	}
+	maxAttempts := jobMaxAttempts(s.cfg.JobMaxAttempts)
 
 	queryStartedAt := time.Now()
 	rows, err := s.db.Query(ctx, `
@@ -193,7 +267,7 @@ func countSQLMigrations(migrationsDir string) (int, error) {
 		if entry.IsDir() {
 			continue
 		}
-		if strings.EqualFold(filepath.Ext(entry.Name()), ".sql") {
+		if db.IsUpMigrationFile(entry.Name()) {
 			files = append(files, entry.Name())
 		}
 	}
@@ -52,3 +52,44 @@ func TestReadyzReturnsServiceUnavailableWithoutDatabase(t *testing.T) {
 		t.Fatalf("expected /readyz 503 without db, got %d", readyRecorder.Code)
 	}
 }
+
+func TestDrainFailsReadyzButKeepsLivez(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWTSecret = "observability-test-secret"
+
+	server := New(cfg, nil, ai.NewMockClient())
+	router := server.Router()
+
+	liveRecorder := httptest.NewRecorder()
+	router.ServeHTTP(liveRecorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if liveRecorder.Code != http.StatusOK {
+		t.Fatalf("expected /livez 200, got %d", liveRecorder.Code)
+	}
+
+	server.BeginDrain()
+	server.BeginDrain()
+	if !server.Draining() {
+		t.Fatalf("expected server to be draining")
+	}
+	if _, _, err := server.threadUpdates.subscribe("post-1", "user-1"); err == nil {
+		t.Fatalf("expected thread streams to be refused while draining")
+	}
+
+	readyRecorder := httptest.NewRecorder()
+	router.ServeHTTP(readyRecorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if readyRecorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected /readyz 503 while draining, got %d", readyRecorder.Code)
+	}
+	if !strings.Contains(readyRecorder.Body.String(), `"error":"draining"`) {
+		t.Fatalf("expected draining error, got %s", readyRecorder.Body.String())
+	}
+
+	liveRecorder = httptest.NewRecorder()
+	router.ServeHTTP(liveRecorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if liveRecorder.Code != http.StatusOK {
+		t.Fatalf("expected /livez 200 while draining, got %d", liveRecorder.Code)
+	}
+	if !strings.Contains(liveRecorder.Body.String(), `"draining":true`) {
+		t.Fatalf("expected livez to report draining, got %s", liveRecorder.Body.String())
+	}
+}
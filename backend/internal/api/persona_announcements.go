@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const notificationTypePersonaAnnounced = "persona_announced"
+
+var (
+	errAnnounceNotPublic = errors.New("publish the persona's profile before announcing it")
+	errAnnounceRepeated  = errors.New("this persona has already been announced")
+)
+
+type NotificationPreferences struct {
+	PersonaAnnouncements bool `json:"persona_announcements"`
+	// DailySummary opts into the daily rollup of all the user's personas.
+	DailySummary bool `json:"daily_summary"`
+}
+
+// announceRetryAfter checks whether a persona can be announced now. A
+// persona is announced once, only while its profile is public, and an owner
+// waits cooldown between announcements. The returned duration is how long
+// is left of the cooldown.
+func announceRetryAfter(isPublic, announced bool, lastAnnouncedAt *time.Time, cooldown time.Duration, now time.Time) (time.Duration, error) {
+	if !isPublic {
+		return 0, errAnnounceNotPublic
+	}
+	if announced {
+		return 0, errAnnounceRepeated
+	}
+	if lastAnnouncedAt == nil || cooldown <= 0 {
+		return 0, nil
+	}
+	if left := lastAnnouncedAt.Add(cooldown).Sub(now); left > 0 {
+		return left, nil
+	}
+	return 0, nil
+}
+
+// handleAnnouncePersona tells the followers of the owner's other personas
+// that this persona's profile launched. Followers who already follow it or
+// turned persona announcements off are skipped.
+func (s *Server) handleAnnouncePersona(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Serialize one owner's announcements so two requests cannot both pass
+	// the cooldown check.
+	if _, err := tx.Exec(r.Context(), "SELECT pg_advisory_xact_lock(hashtext($1))", "announce:"+userID); err != nil {
+		writeInternalError(w, "could not announce persona")
+		return
+	}
+
+	var (
+		personaName, slug string
+		isPublic          bool
+		announced         bool
+		lastAnnouncedAt   *time.Time
+	)
+	err = tx.QueryRow(r.Context(), `
+		SELECT
+			p.name,
+			COALESCE(pp.slug, ''),
+			COALESCE(pp.is_public, FALSE),
+			EXISTS(SELECT 1 FROM persona_announcements pa WHERE pa.persona_id = p.id),
+			(SELECT MAX(pa.created_at) FROM persona_announcements pa WHERE pa.user_id = p.user_id)
+		FROM personas p
+		LEFT JOIN persona_public_profiles pp ON pp.persona_id = p.id
+		WHERE p.id = $1
+		  AND p.user_id = $2
+	`, personaID, userID).Scan(&personaName, &slug, &isPublic, &announced, &lastAnnouncedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	now := time.Now().UTC()
+	retryAfter, err := announceRetryAfter(isPublic, announced, lastAnnouncedAt, s.cfg.AnnounceCooldown, now)
+	if err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeJSON(w, http.StatusTooManyRequests, map[string]any{
+			"error": "you announced a persona recently",
+			"code":  "announce_cooldown",
+		})
+		return
+	}
+
+	ct, err := tx.Exec(r.Context(), `
+		INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
+		SELECT
+			f.follower_user_id,
+			$2::uuid,
+			$5,
+			'New persona to follow',
+			$6,
+			jsonb_build_object('persona_id', $1::text, 'persona_name', $3::text, 'slug', $4::text)
+		FROM (
+			SELECT DISTINCT pf.follower_user_id
+			FROM persona_follows pf
+			JOIN personas op ON op.id = pf.followed_persona_id
+			WHERE op.user_id = $2
+			  AND op.id <> $1
+			  AND pf.follower_user_id <> $2
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM persona_follows already
+				WHERE already.follower_user_id = pf.follower_user_id
+				  AND already.followed_persona_id = $1
+			  )
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM notification_preferences np
+				WHERE np.user_id = pf.follower_user_id
+				  AND np.persona_announcements = FALSE
+			  )
+			LIMIT $7
+		) f
+	`, personaID, userID, personaName, slug, notificationTypePersonaAnnounced,
+		fmt.Sprintf("A creator you follow launched %s. Follow it to see its posts.", personaName),
+		s.cfg.AnnounceMaxRecipients)
+	if err != nil {
+		writeInternalError(w, "could not notify followers")
+		return
+	}
+	recipients := ct.RowsAffected()
+
+	if _, err := tx.Exec(r.Context(), `
+		INSERT INTO persona_announcements(persona_id, user_id, recipients, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, personaID, userID, recipients, now); err != nil {
+		writeInternalError(w, "could not record announcement")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not announce persona")
+		return
+	}
+
+	s.logger.Info("persona_announced", observability.Fields{
+		"persona_id": personaID,
+		"user_id":    userID,
+		"recipients": recipients,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"announced":  true,
+		"recipients": recipients,
+	})
+}
+
+func (s *Server) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	prefs, err := s.loadNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load notification preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (s *Server) handleUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PersonaAnnouncements *bool `json:"persona_announcements"`
+		DailySummary         *bool `json:"daily_summary"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	prefs, err := s.loadNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load notification preferences")
+		return
+	}
+	if req.PersonaAnnouncements != nil {
+		prefs.PersonaAnnouncements = *req.PersonaAnnouncements
+	}
+	if req.DailySummary != nil {
+		prefs.DailySummary = *req.DailySummary
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO notification_preferences(user_id, persona_announcements, daily_summary)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			persona_announcements = EXCLUDED.persona_announcements,
+			daily_summary = EXCLUDED.daily_summary,
+			updated_at = NOW()
+	`, userID, prefs.PersonaAnnouncements, prefs.DailySummary); err != nil {
+		writeInternalError(w, "could not save notification preferences")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (s *Server) loadNotificationPreferences(ctx context.Context, userID string) (NotificationPreferences, error) {
+	prefs := NotificationPreferences{PersonaAnnouncements: true}
+	err := s.db.QueryRow(ctx, `
+		SELECT persona_announcements, daily_summary
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID).Scan(&prefs.PersonaAnnouncements, &prefs.DailySummary)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return NotificationPreferences{}, err
+	}
+	return prefs, nil
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnnounceRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-2 * time.Hour)
+	old := now.Add(-48 * time.Hour)
+
+	if _, err := announceRetryAfter(false, false, nil, 24*time.Hour, now); !errors.Is(err, errAnnounceNotPublic) {
+		t.Fatalf("expected private profile to be rejected, got %v", err)
+	}
+	if _, err := announceRetryAfter(true, true, &old, 24*time.Hour, now); !errors.Is(err, errAnnounceRepeated) {
+		t.Fatalf("expected second announcement to be rejected, got %v", err)
+	}
+	if left, err := announceRetryAfter(true, false, nil, 24*time.Hour, now); err != nil || left != 0 {
+		t.Fatalf("expected first announcement to pass, got %s (%v)", left, err)
+	}
+	if left, err := announceRetryAfter(true, false, &recent, 24*time.Hour, now); err != nil || left != 22*time.Hour {
+		t.Fatalf("expected 22h cooldown left, got %s (%v)", left, err)
+	}
+	if left, err := announceRetryAfter(true, false, &old, 24*time.Hour, now); err != nil || left != 0 {
+		t.Fatalf("expected elapsed cooldown to pass, got %s (%v)", left, err)
+	}
+	if left, err := announceRetryAfter(true, false, &recent, 0, now); err != nil || left != 0 {
+		t.Fatalf("expected disabled cooldown to pass, got %s (%v)", left, err)
+	}
+}
@@ -0,0 +1,361 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/quality"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	evaluationTaskPost       = "post"
+	evaluationTaskReply      = "reply"
+	evaluationTaskBattleTurn = "battle_turn"
+
+	personaEvaluationDailyLimit = 3
+	personaEvaluationHistory    = 20
+)
+
+// evaluationRoomSlugs are the seeded rooms every persona drafts a post in.
+var evaluationRoomSlugs = []string{"go-backend", "cybersecurity", "game-dev", "ai-builders"}
+
+var evaluationCannedPosts = []string{
+	"We shipped a feature without tests and nothing broke. Are tests overrated for small teams?",
+	"Our team is debating a full rewrite of a five-year-old service. Rewrite or refactor?",
+	"Is it worth adding AI features to a product whose users never asked for them?",
+}
+
+const (
+	evaluationBattleOpening  = "Topic: Remote-first teams ship faster than co-located teams\nPro style: Bold and practical\nCon style: Skeptical and evidence-first\n\nBattle opening: keep arguments concise and evidence-based."
+	evaluationBattleRebuttal = "Co-located teams resolve blockers in minutes; remote teams wait a day for answers. Speed comes from proximity."
+)
+
+type PersonaEvaluationTask struct {
+	Kind        string         `json:"kind"`
+	Label       string         `json:"label"`
+	Output      string         `json:"output,omitempty"`
+	Score       *quality.Score `json:"score,omitempty"`
+	SafetyError string         `json:"safety_error,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type PersonaEvaluationReport struct {
+	Summary        quality.Score           `json:"summary"`
+	Tasks          []PersonaEvaluationTask `json:"tasks"`
+	PromptVersions map[string]string       `json:"prompt_versions"`
+}
+
+type PersonaEvaluation struct {
+	ID        string                   `json:"id"`
+	PersonaID string                   `json:"persona_id"`
+	Overall   float64                  `json:"overall_score"`
+	Tone      float64                  `json:"tone_score"`
+	DoNotSay  float64                  `json:"do_not_say_score"`
+	Formality float64                  `json:"formality_score"`
+	Report    *PersonaEvaluationReport `json:"report,omitempty"`
+	CreatedAt time.Time                `json:"created_at"`
+}
+
+type personaEvaluationDelta struct {
+	PreviousID string  `json:"previous_id"`
+	Overall    float64 `json:"overall"`
+	Tone       float64 `json:"tone"`
+	DoNotSay   float64 `json:"do_not_say"`
+	Formality  float64 `json:"formality"`
+}
+
+func (s *Server) handleEvaluatePersona(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	persona, err := s.getPersonaByID(r.Context(), userID, personaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	used, err := s.currentQuotaUsage(r.Context(), personaID, "evaluation")
+	if err != nil {
+		writeInternalError(w, "could not check evaluation quota")
+		return
+	}
+	if used >= personaEvaluationDailyLimit {
+		writeTooManyRequests(w, "daily evaluation quota reached")
+		return
+	}
+
+	rooms, err := s.listEvaluationRooms(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not load rooms")
+		return
+	}
+
+	s.syncPrompts(r.Context())
+	tasks := s.runPersonaEvaluation(r.Context(), persona, rooms)
+	summary, scored := summarizeEvaluationTasks(tasks)
+	if scored == 0 {
+		writeBadGateway(w, "llm evaluation failed: no task produced output")
+		return
+	}
+	report := PersonaEvaluationReport{
+		Summary: summary,
+		Tasks:   tasks,
+		PromptVersions: map[string]string{
+			prompts.OpPostDraft: s.llm.Prompts().Active(prompts.OpPostDraft),
+			prompts.OpReply:     s.llm.Prompts().Active(prompts.OpReply),
+		},
+	}
+
+	previous, hasPrevious, err := s.latestPersonaEvaluation(r.Context(), personaID)
+	if err != nil {
+		writeInternalError(w, "could not load evaluation history")
+		return
+	}
+
+	evaluation, err := s.insertPersonaEvaluation(r.Context(), personaID, report)
+	if err != nil {
+		writeInternalError(w, "could not save evaluation")
+		return
+	}
+	if err := s.insertQuotaEvent(r, personaID, common.QuotaTypeEvaluation, evaluation.ID, nil); err != nil {
+		writeInternalError(w, "could not record evaluation quota")
+		return
+	}
+
+	var delta *personaEvaluationDelta
+	if hasPrevious {
+		computed := compareEvaluations(evaluation, previous)
+		delta = &computed
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"evaluation": evaluation,
+		"delta":      delta,
+		"quota": map[string]any{
+			"used":  used + 1,
+			"limit": personaEvaluationDailyLimit,
+		},
+	})
+}
+
+func (s *Server) handleListPersonaEvaluations(w http.ResponseWriter, r *http.Request) {
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id::text, persona_id::text, overall_score, tone_score, do_not_say_score, formality_score, created_at
+		FROM persona_evaluations
+		WHERE persona_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, personaID, personaEvaluationHistory)
+	if err != nil {
+		writeInternalError(w, "could not load evaluations")
+		return
+	}
+	defer rows.Close()
+
+	evaluations := make([]PersonaEvaluation, 0)
+	for rows.Next() {
+		var evaluation PersonaEvaluation
+		if err := rows.Scan(
+			&evaluation.ID,
+			&evaluation.PersonaID,
+			&evaluation.Overall,
+			&evaluation.Tone,
+			&evaluation.DoNotSay,
+			&evaluation.Formality,
+			&evaluation.CreatedAt,
+		); err != nil {
+			writeInternalError(w, "could not scan evaluation")
+			return
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load evaluations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"evaluations": evaluations,
+	})
+}
+
+// runPersonaEvaluation drives the persona through the fixed battery: one post
+// per seeded room, a reply to each canned post and two battle turns. A failed
+// task is recorded with its error so one flaky call does not void the report.
+func (s *Server) runPersonaEvaluation(ctx context.Context, persona Persona, rooms []Room) []PersonaEvaluationTask {
+	personaCtx := personaToAIContext(persona)
+	profile := quality.Profile{
+		Tone:      persona.Tone,
+		DoNotSay:  persona.DoNotSay,
+		Formality: persona.Formality,
+	}
+	rules := s.loadSafetyRules(ctx).ForLanguage(persona.PreferredLanguage)
+	tasks := make([]PersonaEvaluationTask, 0, len(rooms)+len(evaluationCannedPosts)+2)
+
+	for _, room := range rooms {
+		output, err := s.llm.GeneratePostDraft(ctx, personaCtx, ai.RoomContext{
+			ID:          room.ID,
+			Name:        room.Name,
+			Description: room.Description,
+			Variant:     1,
+		})
+		tasks = append(tasks, s.scoreEvaluationTask(rules, profile, evaluationTaskPost, room.Slug, output, err))
+	}
+
+	for i, content := range evaluationCannedPosts {
+		output, err := s.llm.GenerateReply(ctx, personaCtx, ai.PostContext{Content: content}, nil)
+		tasks = append(tasks, s.scoreEvaluationTask(rules, profile, evaluationTaskReply, fmt.Sprintf("canned_post_%d", i+1), output, err))
+	}
+
+	opening := ai.PostContext{Content: evaluationBattleOpening}
+	firstTurn, err := s.llm.GenerateReply(ctx, personaCtx, opening, nil)
+	tasks = append(tasks, s.scoreEvaluationTask(rules, profile, evaluationTaskBattleTurn, "turn_1", firstTurn, err))
+	thread := []ai.ReplyContext{{Content: evaluationBattleRebuttal}}
+	if err == nil {
+		thread = []ai.ReplyContext{{Content: firstTurn}, {Content: evaluationBattleRebuttal}}
+	}
+	secondTurn, err := s.llm.GenerateReply(ctx, personaCtx, opening, thread)
+	tasks = append(tasks, s.scoreEvaluationTask(rules, profile, evaluationTaskBattleTurn, "turn_2", secondTurn, err))
+
+	return tasks
+}
+
+func (s *Server) scoreEvaluationTask(rules safety.Rules, profile quality.Profile, kind, label, output string, err error) PersonaEvaluationTask {
+	task := PersonaEvaluationTask{Kind: kind, Label: label}
+	if err != nil {
+		task.Error = err.Error()
+		return task
+	}
+	task.Output = output
+	score := quality.Evaluate(profile, output)
+	task.Score = &score
+	maxLen := s.cfg.ReplyMaxLen
+	if kind == evaluationTaskPost {
+		maxLen = s.cfg.DraftMaxLen
+	}
+	if err := rules.Validate(output, maxLen); err != nil {
+		task.SafetyError = err.Error()
+	}
+	return task
+}
+
+func summarizeEvaluationTasks(tasks []PersonaEvaluationTask) (quality.Score, int) {
+	scores := make([]quality.Score, 0, len(tasks))
+	for _, task := range tasks {
+		if task.Score != nil {
+			scores = append(scores, *task.Score)
+		}
+	}
+	return quality.Average(scores), len(scores)
+}
+
+func compareEvaluations(current, previous PersonaEvaluation) personaEvaluationDelta {
+	diff := func(a, b float64) float64 {
+		return roundFeedScore(a - b)
+	}
+	return personaEvaluationDelta{
+		PreviousID: previous.ID,
+		Overall:    diff(current.Overall, previous.Overall),
+		Tone:       diff(current.Tone, previous.Tone),
+		DoNotSay:   diff(current.DoNotSay, previous.DoNotSay),
+		Formality:  diff(current.Formality, previous.Formality),
+	}
+}
+
+func (s *Server) listEvaluationRooms(ctx context.Context) ([]Room, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id::text, slug, name, description, created_at
+		FROM rooms
+		WHERE slug = ANY($1)
+		ORDER BY array_position($1, slug)
+	`, evaluationRoomSlugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := make([]Room, 0, len(evaluationRoomSlugs))
+	for rows.Next() {
+		var room Room
+		if err := rows.Scan(&room.ID, &room.Slug, &room.Name, &room.Description, &room.CreatedAt); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func (s *Server) latestPersonaEvaluation(ctx context.Context, personaID string) (PersonaEvaluation, bool, error) {
+	var evaluation PersonaEvaluation
+	err := s.db.QueryRow(ctx, `
+		SELECT id::text, persona_id::text, overall_score, tone_score, do_not_say_score, formality_score, created_at
+		FROM persona_evaluations
+		WHERE persona_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, personaID).Scan(
+		&evaluation.ID,
+		&evaluation.PersonaID,
+		&evaluation.Overall,
+		&evaluation.Tone,
+		&evaluation.DoNotSay,
+		&evaluation.Formality,
+		&evaluation.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PersonaEvaluation{}, false, nil
+		}
+		return PersonaEvaluation{}, false, err
+	}
+	return evaluation, true, nil
+}
+
+func (s *Server) insertPersonaEvaluation(ctx context.Context, personaID string, report PersonaEvaluationReport) (PersonaEvaluation, error) {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return PersonaEvaluation{}, err
+	}
+	evaluation := PersonaEvaluation{
+		PersonaID: personaID,
+		Overall:   report.Summary.Overall,
+		Tone:      report.Summary.Tone,
+		DoNotSay:  report.Summary.DoNotSay,
+		Formality: report.Summary.Formality,
+		Report:    &report,
+	}
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO persona_evaluations(persona_id, overall_score, tone_score, do_not_say_score, formality_score, report)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb)
+		RETURNING id::text, created_at
+	`, personaID, evaluation.Overall, evaluation.Tone, evaluation.DoNotSay, evaluation.Formality, payload).Scan(&evaluation.ID, &evaluation.CreatedAt)
+	return evaluation, err
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+
+	"personaworlds/backend/internal/quality"
+)
+
+func TestSummarizeEvaluationTasksSkipsFailedTasks(t *testing.T) {
+	tasks := []PersonaEvaluationTask{
+		{Kind: evaluationTaskPost, Label: "go-backend", Score: &quality.Score{Tone: 1, DoNotSay: 1, Formality: 1, Overall: 1}},
+		{Kind: evaluationTaskReply, Label: "canned_post_1", Error: "llm down"},
+		{Kind: evaluationTaskBattleTurn, Label: "turn_1", Score: &quality.Score{Tone: 0, DoNotSay: 1, Formality: 0.5, Overall: 0.55}},
+	}
+	summary, scored := summarizeEvaluationTasks(tasks)
+	if scored != 2 {
+		t.Fatalf("expected two scored tasks, got %d", scored)
+	}
+	if summary.Tone != 0.5 || summary.Overall != 0.78 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
+
+func TestCompareEvaluations(t *testing.T) {
+	delta := compareEvaluations(
+		PersonaEvaluation{Overall: 0.8, Tone: 0.7, DoNotSay: 1, Formality: 0.66},
+		PersonaEvaluation{ID: "prev", Overall: 0.65, Tone: 0.9, DoNotSay: 0.5, Formality: 0.66},
+	)
+	if delta.PreviousID != "prev" || delta.Overall != 0.15 || delta.Tone != -0.2 || delta.DoNotSay != 0.5 || delta.Formality != 0 {
+		t.Fatalf("unexpected delta %+v", delta)
+	}
+}
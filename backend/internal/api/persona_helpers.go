@@ -2,17 +2,20 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/safety"
 )
 
 func (s *Server) getPersonaByID(ctx context.Context, userID, personaID string) (Persona, error) {
 	var p Persona
-	err := scanPersona(s.db.QueryRow(ctx, `
-		SELECT id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, created_at, updated_at
+	err := s.scanPersona(ctx, s.db.QueryRow(ctx, `
+		SELECT id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source, created_at, updated_at
 		FROM personas
 		WHERE id = $1 AND user_id = $2
 	`, personaID, userID), &p)
@@ -21,11 +24,16 @@ func (s *Server) getPersonaByID(ctx context.Context, userID, personaID string) (
 
 func (s *Server) getRoomByID(ctx context.Context, roomID string) (Room, error) {
 	var rm Room
+	var eventStartsAt, eventEndsAt *time.Time
+	var eventRecap string
 	err := s.db.QueryRow(ctx, `
-		SELECT id::text, slug, name, description, created_at
+		SELECT id::text, slug, name, description, created_at, archived_at, COALESCE(merged_into_room_id::text, ''),
+			event_starts_at, event_ends_at, event_recap
 		FROM rooms
 		WHERE id = $1
-	`, roomID).Scan(&rm.ID, &rm.Slug, &rm.Name, &rm.Description, &rm.CreatedAt)
+	`, roomID).Scan(&rm.ID, &rm.Slug, &rm.Name, &rm.Description, &rm.CreatedAt, &rm.ArchivedAt, &rm.MergedIntoRoomID,
+		&eventStartsAt, &eventEndsAt, &eventRecap)
+	rm.Event = newRoomEvent(eventStartsAt, eventEndsAt, eventRecap, time.Now())
 	return rm, err
 }
 
@@ -41,6 +49,22 @@ func (s *Server) currentQuotaUsage(ctx context.Context, personaID, quotaType str
 	return used, err
 }
 
+// insertQuotaEvent records quota spent by an API request, with the route
+// pattern as its source and the request id in its metadata.
+func (s *Server) insertQuotaEvent(r *http.Request, personaID, quotaType, contentID string, metadata map[string]any) error {
+	merged := map[string]any{"request_id": requestIDFromRequest(r)}
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	return common.InsertQuotaEvent(r.Context(), s.db, common.QuotaEvent{
+		PersonaID: personaID,
+		Type:      quotaType,
+		Source:    r.Method + " " + routePatternFromRequest(r),
+		ContentID: contentID,
+		Metadata:  merged,
+	})
+}
+
 func (s *Server) resolvePersonaIDsForReplyGeneration(ctx context.Context, userID string, provided []string) ([]string, error) {
 	if len(provided) > 0 {
 		ids := make([]string, 0, len(provided))
@@ -112,7 +136,9 @@ type personaInput struct {
 	Formality         int
 }
 
-func scanPersona(row rowScanner, p *Persona) error {
+// scanPersona reads a persona row, opening calibration lists that were
+// stored encrypted.
+func (s *Server) scanPersona(ctx context.Context, row rowScanner, p *Persona) error {
 	var writingSamplesRaw []byte
 	var doNotSayRaw []byte
 	var catchphrasesRaw []byte
@@ -129,36 +155,29 @@ func scanPersona(row rowScanner, p *Persona) error {
 		&p.Formality,
 		&p.DailyDraftQuota,
 		&p.DailyReplyQuota,
+		&p.ActiveHoursStart,
+		&p.ActiveHoursEnd,
+		&p.Timezone,
+		&p.Style.Humor,
+		&p.Style.Assertiveness,
+		&p.Style.Technicality,
+		&p.Style.Brevity,
+		&p.StyleSource,
 		&p.CreatedAt,
 		&p.UpdatedAt,
 	); err != nil {
 		return err
 	}
 
-	if len(writingSamplesRaw) > 0 {
-		if err := json.Unmarshal(writingSamplesRaw, &p.WritingSamples); err != nil {
-			return err
-		}
-	}
-	if len(doNotSayRaw) > 0 {
-		if err := json.Unmarshal(doNotSayRaw, &p.DoNotSay); err != nil {
-			return err
-		}
-	}
-	if len(catchphrasesRaw) > 0 {
-		if err := json.Unmarshal(catchphrasesRaw, &p.Catchphrases); err != nil {
-			return err
-		}
-	}
-
-	if p.WritingSamples == nil {
-		p.WritingSamples = []string{}
+	var err error
+	if p.WritingSamples, err = s.calibration.Decode(ctx, s.db, common.WritingSamplesField, writingSamplesRaw); err != nil {
+		return err
 	}
-	if p.DoNotSay == nil {
-		p.DoNotSay = []string{}
+	if p.DoNotSay, err = s.calibration.Decode(ctx, s.db, common.DoNotSayField, doNotSayRaw); err != nil {
+		return err
 	}
-	if p.Catchphrases == nil {
-		p.Catchphrases = []string{}
+	if p.Catchphrases, err = s.calibration.Decode(ctx, s.db, common.CatchphrasesField, catchphrasesRaw); err != nil {
+		return err
 	}
 	return nil
 }
@@ -169,28 +188,17 @@ func normalizePersonaInput(name, bio, tone string, writingSamples, doNotSay, cat
 		return personaInput{}, fmt.Errorf("name is required")
 	}
 
-	cleanWritingSamples := normalizeStringSlice(writingSamples)
-	if len(cleanWritingSamples) != 3 {
-		return personaInput{}, fmt.Errorf("writing_samples must contain exactly 3 short examples")
-	}
-	for _, sample := range cleanWritingSamples {
-		if len([]rune(sample)) > 180 {
-			return personaInput{}, fmt.Errorf("writing_samples items must be <= 180 chars")
-		}
+	cleanWritingSamples, err := common.WritingSamplesField.Validate(writingSamples)
+	if err != nil {
+		return personaInput{}, err
 	}
-
-	cleanDoNotSay := normalizeStringSlice(doNotSay)
-	for _, item := range cleanDoNotSay {
-		if len([]rune(item)) > 120 {
-			return personaInput{}, fmt.Errorf("do_not_say items must be <= 120 chars")
-		}
+	cleanDoNotSay, err := common.DoNotSayField.Validate(doNotSay)
+	if err != nil {
+		return personaInput{}, err
 	}
-
-	cleanCatchphrases := normalizeStringSlice(catchphrases)
-	for _, item := range cleanCatchphrases {
-		if len([]rune(item)) > 80 {
-			return personaInput{}, fmt.Errorf("catchphrases items must be <= 80 chars")
-		}
+	cleanCatchphrases, err := common.CatchphrasesField.Validate(catchphrases)
+	if err != nil {
+		return personaInput{}, err
 	}
 
 	language := strings.ToLower(strings.TrimSpace(preferredLanguage))
@@ -214,15 +222,34 @@ func normalizePersonaInput(name, bio, tone string, writingSamples, doNotSay, cat
 	}, nil
 }
 
-func normalizeStringSlice(items []string) []string {
-	cleaned := make([]string, 0, len(items))
-	for _, item := range items {
-		trimmed := strings.TrimSpace(item)
-		if trimmed != "" {
-			cleaned = append(cleaned, trimmed)
+// personaSafetyMaxLen only bounds the safety check; persona fields have
+// their own limits where they need one.
+const personaSafetyMaxLen = 4000
+
+// checkPersonaInputSafety runs the persona's own words through the safety
+// rules of its language. do_not_say is skipped: it lists what to avoid.
+func checkPersonaInputSafety(rules safety.Rules, input personaInput) error {
+	rules = rules.ForLanguage(input.PreferredLanguage)
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{name: "name", values: []string{input.Name}},
+		{name: "bio", values: []string{input.Bio}},
+		{name: "tone", values: []string{input.Tone}},
+		{name: "writing_samples", values: input.WritingSamples},
+		{name: "catchphrases", values: input.Catchphrases},
+	} {
+		for _, value := range field.values {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			if err := rules.Validate(value, personaSafetyMaxLen); err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
 		}
 	}
-	return cleaned
+	return nil
 }
 
 func personaToAIContext(persona Persona) ai.PersonaContext {
@@ -236,5 +263,6 @@ func personaToAIContext(persona Persona) ai.PersonaContext {
 		Catchphrases:      persona.Catchphrases,
 		PreferredLanguage: persona.PreferredLanguage,
 		Formality:         persona.Formality,
+		Style:             persona.Style.toAI(),
 	}
 }
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// personaImportListSeparator splits list columns (writing_samples,
+// do_not_say, catchphrases) in CSV imports.
+const personaImportListSeparator = "|"
+
+type PersonaImportBatch struct {
+	ID          int64                        `json:"id"`
+	Source      string                       `json:"source"`
+	Status      string                       `json:"status"`
+	TotalRows   int                          `json:"total_rows"`
+	Counts      map[string]int               `json:"counts"`
+	Results     []common.PersonaImportResult `json:"results"`
+	CreatedAt   time.Time                    `json:"created_at"`
+	UpdatedAt   time.Time                    `json:"updated_at"`
+	CompletedAt *time.Time                   `json:"completed_at,omitempty"`
+}
+
+type personaImportRequest struct {
+	Personas []personaUpsertRequest `json:"personas"`
+}
+
+// personaImportEntry is one row of an import as parsed. err is set when the
+// row could not be read into a request, for example a CSV number column
+// that is not a number.
+type personaImportEntry struct {
+	req personaUpsertRequest
+	err error
+}
+
+var personaImportCSVColumns = map[string]struct{}{
+	"name":               {},
+	"bio":                {},
+	"tone":               {},
+	"writing_samples":    {},
+	"do_not_say":         {},
+	"catchphrases":       {},
+	"preferred_language": {},
+	"formality":          {},
+	"daily_draft_quota":  {},
+	"daily_reply_quota":  {},
+	"active_hours_start": {},
+	"active_hours_end":   {},
+	"timezone":           {},
+	"humor":              {},
+	"assertiveness":      {},
+	"technicality":       {},
+	"brevity":            {},
+}
+
+// parsePersonaImportJSON reads {"personas": [...]}, each item shaped like the
+// body of POST /personas.
+func parsePersonaImportJSON(r *http.Request) ([]personaImportEntry, error) {
+	var req personaImportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		return nil, err
+	}
+	entries := make([]personaImportEntry, 0, len(req.Personas))
+	for _, item := range req.Personas {
+		entries = append(entries, personaImportEntry{req: item})
+	}
+	return entries, nil
+}
+
+// parsePersonaImportCSV reads a CSV file whose first line names the columns.
+// Only name is required; unknown columns are rejected so a typo does not
+// silently drop a field. A row with the wrong number of fields, or a number
+// column that does not parse, becomes an error for that row only.
+func parsePersonaImportCSV(body io.Reader) ([]personaImportEntry, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv must start with a header row")
+		}
+		return nil, normalizeCSVError(err)
+	}
+	columns := make([]string, len(header))
+	seen := map[string]struct{}{}
+	for i, raw := range header {
+		column := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff")))
+		if _, ok := personaImportCSVColumns[column]; !ok {
+			return nil, fmt.Errorf("unknown csv column %q", raw)
+		}
+		if _, dup := seen[column]; dup {
+			return nil, fmt.Errorf("csv column %q appears twice", column)
+		}
+		seen[column] = struct{}{}
+		columns[i] = column
+	}
+	if _, ok := seen["name"]; !ok {
+		return nil, errors.New("csv must have a name column")
+	}
+
+	entries := []personaImportEntry{}
+	// Stop reading past the limit; the caller rejects the import anyway.
+	for len(entries) <= common.PersonaImportMaxRows {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, csv.ErrFieldCount) {
+				entries = append(entries, personaImportEntry{err: fmt.Errorf("expected %d fields, got %d", len(columns), len(record))})
+				continue
+			}
+			return nil, normalizeCSVError(err)
+		}
+		req, err := personaRequestFromCSV(columns, record)
+		entries = append(entries, personaImportEntry{req: req, err: err})
+	}
+	return entries, nil
+}
+
+func normalizeCSVError(err error) error {
+	if decodeErr := normalizeDecodeError(err); decodeErr != nil && decodeErr.Error() == "request body too large" {
+		return decodeErr
+	}
+	return fmt.Errorf("invalid csv: %v", err)
+}
+
+func personaRequestFromCSV(columns, record []string) (personaUpsertRequest, error) {
+	var (
+		req      personaUpsertRequest
+		style    = defaultPersonaStyle()
+		hasStyle bool
+	)
+	for i, column := range columns {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+		switch column {
+		case "name":
+			req.Name = value
+		case "bio":
+			req.Bio = value
+		case "tone":
+			req.Tone = value
+		case "writing_samples":
+			req.WritingSamples = strings.Split(value, personaImportListSeparator)
+		case "do_not_say":
+			req.DoNotSay = strings.Split(value, personaImportListSeparator)
+		case "catchphrases":
+			req.Catchphrases = strings.Split(value, personaImportListSeparator)
+		case "preferred_language":
+			req.PreferredLanguage = value
+		case "timezone":
+			req.Timezone = value
+		default:
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return personaUpsertRequest{}, fmt.Errorf("%s must be a whole number", column)
+			}
+			switch column {
+			case "formality":
+				req.Formality = number
+			case "daily_draft_quota":
+				req.DailyDraftQuota = number
+			case "daily_reply_quota":
+				req.DailyReplyQuota = number
+			case "active_hours_start":
+				req.ActiveHoursStart = &number
+			case "active_hours_end":
+				req.ActiveHoursEnd = &number
+			case "humor":
+				style.Humor, hasStyle = number, true
+			case "assertiveness":
+				style.Assertiveness, hasStyle = number, true
+			case "technicality":
+				style.Technicality, hasStyle = number, true
+			case "brevity":
+				style.Brevity, hasStyle = number, true
+			}
+		}
+	}
+	if hasStyle {
+		req.Style = &style
+	}
+	return req, nil
+}
+
+// handleBulkCreatePersonas creates up to common.PersonaImportMaxRows
+// personas from a JSON array or a CSV file. Every row is validated like a
+// single create and gets its own result; invalid rows are reported, not
+// fatal. Imports of up to common.PersonaImportChunkSize valid rows are
+// created in the request (200); larger ones, or background=true, are queued
+// for the worker (202) and polled with GET /personas/bulk/{batchID}.
+func (s *Server) handleBulkCreatePersonas(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	background := false
+	if raw := strings.TrimSpace(r.URL.Query().Get("background")); raw != "" {
+		var err error
+		background, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeBadRequest(w, "background must be true or false")
+			return
+		}
+	}
+
+	source := "json"
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		source = "csv"
+	}
+	var (
+		entries []personaImportEntry
+		err     error
+	)
+	if source == "csv" {
+		entries, err = parsePersonaImportCSV(r.Body)
+	} else {
+		entries, err = parsePersonaImportJSON(r)
+	}
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		writeBadRequest(w, "import must define at least one persona")
+		return
+	}
+	if len(entries) > common.PersonaImportMaxRows {
+		writeBadRequest(w, fmt.Sprintf("import can define at most %d personas", common.PersonaImportMaxRows))
+		return
+	}
+
+	results, rows, err := s.preparePersonaImport(r, userID, entries)
+	if err != nil {
+		writeInternalError(w, "could not encrypt persona calibration")
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	status, batchStatus := http.StatusOK, common.PersonaImportDone
+	pending := []common.PersonaImportRow{}
+	switch {
+	case len(rows) == 0:
+	case background || len(rows) > common.PersonaImportChunkSize:
+		status, batchStatus, pending = http.StatusAccepted, common.PersonaImportPending, rows
+	default:
+		created, err := common.CreateImportedPersonas(r.Context(), tx, userID, rows)
+		if err != nil {
+			writeInternalError(w, "could not create personas")
+			return
+		}
+		results = common.MergePersonaImportResults(results, created)
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		writeInternalError(w, "could not record import")
+		return
+	}
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		writeInternalError(w, "could not record import")
+		return
+	}
+
+	batch := PersonaImportBatch{
+		Source:    source,
+		Status:    batchStatus,
+		TotalRows: len(entries),
+		Results:   results,
+		Counts:    common.PersonaImportCounts(results),
+	}
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO persona_import_batches(user_id, source, status, total_rows, results, pending, completed_at)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, CASE WHEN $3 = 'DONE' THEN NOW() END)
+		RETURNING id, created_at, updated_at, completed_at
+	`, userID, source, batchStatus, len(entries), resultsJSON, pendingJSON).Scan(
+		&batch.ID,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&batch.CompletedAt,
+	)
+	if err != nil {
+		writeInternalError(w, "could not record import")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not commit import")
+		return
+	}
+
+	s.logger.Info("persona_import_batch_created", observability.Fields{
+		"batch_id":   batch.ID,
+		"source":     source,
+		"status":     batch.Status,
+		"total_rows": batch.TotalRows,
+		"created":    batch.Counts[common.PersonaImportRowCreated],
+		"pending":    batch.Counts[common.PersonaImportRowPending],
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+
+	writeJSON(w, status, map[string]any{"batch": batch})
+}
+
+// preparePersonaImport validates entries the way handleCreatePersona
+// validates one persona and returns a result per entry plus the rows that
+// passed, encoded and sealed for storage. Rows that passed are reported as
+// pending until they are created. A later row whose name repeats an earlier
+// valid row is skipped. Only a sealing failure is returned as an error.
+func (s *Server) preparePersonaImport(r *http.Request, userID string, entries []personaImportEntry) ([]common.PersonaImportResult, []common.PersonaImportRow, error) {
+	rules := s.loadSafetyRules(r.Context())
+	results := make([]common.PersonaImportResult, 0, len(entries))
+	rows := []common.PersonaImportRow{}
+	names := map[string]int{}
+
+	for i, entry := range entries {
+		result := common.PersonaImportResult{Row: i + 1, Name: strings.TrimSpace(entry.req.Name)}
+		row, err := s.preparePersonaImportRow(entry, rules)
+		if err != nil {
+			result.Status = common.PersonaImportRowError
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Name = row.Name
+
+		key := strings.ToLower(row.Name)
+		if first, dup := names[key]; dup {
+			result.Status = common.PersonaImportRowSkipped
+			result.Error = fmt.Sprintf("duplicate of row %d", first)
+			results = append(results, result)
+			continue
+		}
+		names[key] = result.Row
+
+		ws, dns, cp := []byte(row.WritingSamples), []byte(row.DoNotSay), []byte(row.Catchphrases)
+		ws, dns, cp, err = s.sealPersonaJSONFields(r.Context(), userID, ws, dns, cp)
+		if err != nil {
+			return nil, nil, err
+		}
+		row.WritingSamples, row.DoNotSay, row.Catchphrases = ws, dns, cp
+		row.Row = result.Row
+
+		result.Status = common.PersonaImportRowPending
+		results = append(results, result)
+		rows = append(rows, row)
+	}
+	return results, rows, nil
+}
+
+// preparePersonaImportRow runs the checks of a single create on one entry
+// and returns the row to insert, with unsealed calibration lists.
+func (s *Server) preparePersonaImportRow(entry personaImportEntry, rules safety.Rules) (common.PersonaImportRow, error) {
+	if entry.err != nil {
+		return common.PersonaImportRow{}, entry.err
+	}
+	req := entry.req
+	input, err := req.normalizedPersonaInput()
+	if err != nil {
+		return common.PersonaImportRow{}, err
+	}
+	if err := checkPersonaInputSafety(rules, input); err != nil {
+		return common.PersonaImportRow{}, err
+	}
+	style, err := req.normalizedStyle()
+	if err != nil {
+		return common.PersonaImportRow{}, err
+	}
+	if style == nil {
+		defaults := defaultPersonaStyle()
+		style = &defaults
+	}
+	req.applyDefaultQuotas(s.cfg.DefaultDraftQuota, s.cfg.DefaultReplyQuota)
+	timezone, err := req.normalizedActiveHours()
+	if err != nil {
+		return common.PersonaImportRow{}, err
+	}
+	writingSamplesJSON, doNotSayJSON, catchphrasesJSON, err := encodePersonaJSONFields(input)
+	if err != nil {
+		return common.PersonaImportRow{}, err
+	}
+
+	return common.PersonaImportRow{
+		Name:               input.Name,
+		Bio:                input.Bio,
+		Tone:               input.Tone,
+		WritingSamples:     writingSamplesJSON,
+		DoNotSay:           doNotSayJSON,
+		Catchphrases:       catchphrasesJSON,
+		PreferredLanguage:  input.PreferredLanguage,
+		Formality:          input.Formality,
+		DailyDraftQuota:    req.DailyDraftQuota,
+		DailyReplyQuota:    req.DailyReplyQuota,
+		ActiveHoursStart:   req.ActiveHoursStart,
+		ActiveHoursEnd:     req.ActiveHoursEnd,
+		Timezone:           timezone,
+		StyleHumor:         style.Humor,
+		StyleAssertiveness: style.Assertiveness,
+		StyleTechnicality:  style.Technicality,
+		StyleBrevity:       style.Brevity,
+	}, nil
+}
+
+func (s *Server) handleGetPersonaImportBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	batchID, err := strconv.ParseInt(strings.TrimSpace(chi.URLParam(r, "batchID")), 10, 64)
+	if err != nil || batchID <= 0 {
+		writeBadRequest(w, "invalid batch id")
+		return
+	}
+
+	var (
+		batch       PersonaImportBatch
+		resultsJSON []byte
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT id, source, status, total_rows, results, created_at, updated_at, completed_at
+		FROM persona_import_batches
+		WHERE id = $1
+		  AND user_id = $2
+	`, batchID, userID).Scan(
+		&batch.ID,
+		&batch.Source,
+		&batch.Status,
+		&batch.TotalRows,
+		&resultsJSON,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&batch.CompletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "batch not found")
+			return
+		}
+		writeInternalError(w, "could not load batch")
+		return
+	}
+	if err := json.Unmarshal(resultsJSON, &batch.Results); err != nil {
+		writeInternalError(w, "could not load batch")
+		return
+	}
+	batch.Counts = common.PersonaImportCounts(batch.Results)
+
+	writeJSON(w, http.StatusOK, map[string]any{"batch": batch})
+}
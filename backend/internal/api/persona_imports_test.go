@@ -0,0 +1,72 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePersonaImportCSV(t *testing.T) {
+	body := "\ufeffName,bio,writing_samples,formality,humor,active_hours_start,active_hours_end,timezone\n" +
+		"Ada,Builds things,\"ship it|test it\",2,4,9,17,Europe/Istanbul\n" +
+		"Bob,,,high,,,,\n" +
+		"Cem,too,many,fields,1,2,3,4,5\n"
+	entries, err := parsePersonaImportCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	ada := entries[0]
+	if ada.err != nil || ada.req.Name != "Ada" || ada.req.Formality != 2 {
+		t.Fatalf("unexpected first entry: %+v", ada)
+	}
+	if len(ada.req.WritingSamples) != 2 || ada.req.WritingSamples[1] != "test it" {
+		t.Fatalf("expected list column split on |, got %v", ada.req.WritingSamples)
+	}
+	if ada.req.Style == nil || ada.req.Style.Humor != 4 || ada.req.Style.Brevity != defaultPersonaStyle().Brevity {
+		t.Fatalf("expected humor set over default style, got %+v", ada.req.Style)
+	}
+	if ada.req.ActiveHoursStart == nil || *ada.req.ActiveHoursStart != 9 || ada.req.Timezone != "Europe/Istanbul" {
+		t.Fatalf("expected active hours, got %+v", ada.req)
+	}
+
+	if entries[1].err == nil || !strings.Contains(entries[1].err.Error(), "formality") {
+		t.Fatalf("expected a formality error on row 2, got %v", entries[1].err)
+	}
+	if entries[1].req.Style != nil {
+		t.Fatalf("expected no style when no slider column is set")
+	}
+	if entries[2].err == nil {
+		t.Fatalf("expected a field count error on row 3")
+	}
+}
+
+func TestParsePersonaImportCSVRejectsBadHeader(t *testing.T) {
+	for _, body := range []string{
+		"",
+		"bio\nx\n",
+		"name,nickname\nAda,A\n",
+		"name,name\nAda,Ada\n",
+	} {
+		if _, err := parsePersonaImportCSV(strings.NewReader(body)); err == nil {
+			t.Fatalf("expected %q to be rejected", body)
+		}
+	}
+}
+
+func TestParsePersonaImportCSVStopsPastLimit(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("name\n")
+	for i := 0; i < 200; i++ {
+		b.WriteString("p\n")
+	}
+	entries, err := parsePersonaImportCSV(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(entries) != 51 {
+		t.Fatalf("expected parsing to stop at 51 rows, got %d", len(entries))
+	}
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	marketplaceMaxTopics         = 5
+	marketplaceTopicMinRunes     = 2
+	marketplaceTopicMaxRunes     = 40
+	marketplaceDefaultDailyLimit = 3
+	marketplaceMaxDailyLimit     = 20
+)
+
+// MarketplaceListing is a public persona offered for guest battles. Like
+// ChallengePersona it only carries what the public profile already shows.
+type MarketplaceListing struct {
+	PersonaID         string    `json:"persona_id"`
+	Slug              string    `json:"slug"`
+	Name              string    `json:"name"`
+	Bio               string    `json:"bio"`
+	PreferredLanguage string    `json:"preferred_language"`
+	Topics            []string  `json:"topics"`
+	Followers         int       `json:"followers"`
+	DailyBattleLimit  int       `json:"daily_battle_limit"`
+	ChallengesToday   int       `json:"challenges_today"`
+	Available         bool      `json:"available"`
+	ChallengeRoute    string    `json:"challenge_route"`
+	ListedAt          time.Time `json:"listed_at"`
+}
+
+// normalizeMarketplaceTopics lowercases, trims and de-duplicates listing
+// topics. At least one topic is required.
+func normalizeMarketplaceTopics(raw []string) ([]string, error) {
+	topics := make([]string, 0, len(raw))
+	seen := map[string]struct{}{}
+	for _, item := range raw {
+		topic := strings.ToLower(strings.Join(strings.Fields(item), " "))
+		if topic == "" {
+			continue
+		}
+		if n := utf8.RuneCountInString(topic); n < marketplaceTopicMinRunes || n > marketplaceTopicMaxRunes {
+			return nil, fmt.Errorf("topics must be %d-%d characters", marketplaceTopicMinRunes, marketplaceTopicMaxRunes)
+		}
+		if _, ok := seen[topic]; ok {
+			continue
+		}
+		seen[topic] = struct{}{}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		return nil, errors.New("at least one topic is required")
+	}
+	if len(topics) > marketplaceMaxTopics {
+		return nil, fmt.Errorf("at most %d topics are allowed", marketplaceMaxTopics)
+	}
+	return topics, nil
+}
+
+// handleUpsertMarketplaceListing lists one of the user's public personas as
+// available for guest battles, or updates its topics and daily limit.
+func (s *Server) handleUpsertMarketplaceListing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Topics           []string `json:"topics"`
+		DailyBattleLimit *int     `json:"daily_battle_limit"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	topics, err := normalizeMarketplaceTopics(req.Topics)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	dailyLimit := marketplaceDefaultDailyLimit
+	if req.DailyBattleLimit != nil {
+		dailyLimit = *req.DailyBattleLimit
+	}
+	if dailyLimit < 1 || dailyLimit > marketplaceMaxDailyLimit {
+		writeBadRequest(w, fmt.Sprintf("daily_battle_limit must be between 1 and %d", marketplaceMaxDailyLimit))
+		return
+	}
+
+	var isPublic bool
+	err = s.db.QueryRow(r.Context(), `
+		SELECT COALESCE(pp.is_public, FALSE)
+		FROM personas p
+		LEFT JOIN persona_public_profiles pp ON pp.persona_id = p.id
+		WHERE p.id = $1
+		  AND p.user_id = $2
+	`, personaID, userID).Scan(&isPublic)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+	if !isPublic {
+		writeConflict(w, "publish the persona's profile before listing it")
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO persona_marketplace_listings(persona_id, user_id, topics, daily_battle_limit)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (persona_id) DO UPDATE
+		SET topics = EXCLUDED.topics,
+			daily_battle_limit = EXCLUDED.daily_battle_limit,
+			updated_at = NOW()
+	`, personaID, userID, topics, dailyLimit); err != nil {
+		writeInternalError(w, "could not save listing")
+		return
+	}
+
+	listing, err := s.loadMarketplaceListing(r.Context(), personaID)
+	if err != nil {
+		writeInternalError(w, "could not load listing")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"listing": listing,
+	})
+}
+
+func (s *Server) handleDeleteMarketplaceListing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM persona_marketplace_listings
+		WHERE persona_id = $1
+		  AND user_id = $2
+	`, personaID, userID)
+	if err != nil {
+		writeInternalError(w, "could not remove listing")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"persona_id": personaID,
+		"removed":    ct.RowsAffected() > 0,
+	})
+}
+
+// marketplaceListingSelect reads listings with their use of the rolling
+// daily window ($1). Only personas with a published profile are included.
+const marketplaceListingSelect = `
+	SELECT
+		p.id::text,
+		pp.slug,
+		p.name,
+		COALESCE(NULLIF(pp.bio, ''), p.bio),
+		p.preferred_language,
+		l.topics,
+		COALESCE((SELECT COUNT(*)::int FROM persona_follows f WHERE f.followed_persona_id = p.id), 0) AS followers,
+		l.daily_battle_limit,
+		COALESCE((
+			SELECT COUNT(*)::int
+			FROM battle_challenges c
+			WHERE c.opponent_persona_id = p.id
+			  AND c.created_at > $1
+		), 0) AS challenges_today,
+		l.listed_at
+	FROM persona_marketplace_listings l
+	JOIN personas p ON p.id = l.persona_id
+	JOIN persona_public_profiles pp ON pp.persona_id = p.id AND pp.is_public = TRUE
+`
+
+func scanMarketplaceListing(row pgx.Row) (MarketplaceListing, error) {
+	var listing MarketplaceListing
+	err := row.Scan(
+		&listing.PersonaID,
+		&listing.Slug,
+		&listing.Name,
+		&listing.Bio,
+		&listing.PreferredLanguage,
+		&listing.Topics,
+		&listing.Followers,
+		&listing.DailyBattleLimit,
+		&listing.ChallengesToday,
+		&listing.ListedAt,
+	)
+	if err != nil {
+		return MarketplaceListing{}, err
+	}
+	listing.Available = listing.ChallengesToday < listing.DailyBattleLimit
+	listing.ChallengeRoute = fmt.Sprintf("/p/%s/challenge", listing.Slug)
+	return listing, nil
+}
+
+func (s *Server) loadMarketplaceListing(ctx context.Context, personaID string) (MarketplaceListing, error) {
+	return scanMarketplaceListing(s.db.QueryRow(ctx, marketplaceListingSelect+`
+		WHERE l.persona_id = $2
+	`, time.Now().UTC().Add(-challengeLimitWindow), personaID))
+}
+
+// handleListMarketplacePersonas browses listed personas, most followed first.
+// Filters: topic (exact listed topic), language (tr or en) and available
+// (only personas that can still take a challenge today). Signed-in users do
+// not see their own personas.
+func (s *Server) handleListMarketplacePersonas(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, err := parsePaginationLimit(query.Get("limit"), 20, 1, 50)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	topic := strings.ToLower(strings.Join(strings.Fields(query.Get("topic")), " "))
+	language := strings.ToLower(strings.TrimSpace(query.Get("language")))
+	if language != "" && language != "tr" && language != "en" {
+		writeBadRequest(w, "language must be tr or en")
+		return
+	}
+	availableOnly := false
+	if raw := strings.TrimSpace(query.Get("available")); raw != "" {
+		availableOnly, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeBadRequest(w, "available must be true or false")
+			return
+		}
+	}
+	viewerID, _ := s.optionalUserIDFromRequest(r)
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT *
+		FROM (`+marketplaceListingSelect+`
+			WHERE ($2 = '' OR $2 = ANY(l.topics))
+			  AND ($3 = '' OR p.preferred_language = $3)
+			  AND ($4 = '' OR l.user_id::text <> $4)
+		) listings
+		WHERE NOT $5 OR listings.challenges_today < listings.daily_battle_limit
+		ORDER BY listings.followers DESC, listings.listed_at DESC
+		LIMIT $6
+	`, time.Now().UTC().Add(-challengeLimitWindow), topic, language, viewerID, availableOnly, limit)
+	if err != nil {
+		writeInternalError(w, "could not load marketplace")
+		return
+	}
+	defer rows.Close()
+
+	listings := make([]MarketplaceListing, 0, limit)
+	for rows.Next() {
+		listing, err := scanMarketplaceListing(rows)
+		if err != nil {
+			writeInternalError(w, "could not read marketplace")
+			return
+		}
+		listings = append(listings, listing)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not read marketplace")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"personas": listings,
+	})
+}
+
+// marketplaceDailyLimit returns the listed persona's daily guest battle
+// limit, or 0 when the persona is not listed.
+func marketplaceDailyLimit(ctx context.Context, querier common.DBQuerier, personaID string) (int, error) {
+	var limit int
+	err := querier.QueryRow(ctx, `
+		SELECT daily_battle_limit
+		FROM persona_marketplace_listings
+		WHERE persona_id = $1
+	`, personaID).Scan(&limit)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return limit, err
+}
+
+// marketplaceAcceptBooked reports whether a listed persona has accepted as
+// many challenges in the rolling window as its daily limit allows. Challenges
+// sent on earlier days can otherwise all be accepted at once.
+func marketplaceAcceptBooked(ctx context.Context, querier common.DBQuerier, personaID string) (bool, error) {
+	var booked bool
+	err := querier.QueryRow(ctx, `
+		SELECT COUNT(c.id) >= l.daily_battle_limit
+		FROM persona_marketplace_listings l
+		LEFT JOIN battle_challenges c
+		  ON c.opponent_persona_id = l.persona_id
+		 AND c.status = $2
+		 AND c.responded_at > $3
+		WHERE l.persona_id = $1
+		GROUP BY l.daily_battle_limit
+	`, personaID, challengeStatusAccepted, time.Now().UTC().Add(-challengeLimitWindow)).Scan(&booked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return booked, err
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMarketplaceTopics(t *testing.T) {
+	topics, err := normalizeMarketplaceTopics([]string{"  Climate   Policy ", "go", "climate policy", "", "GO"})
+	if err != nil {
+		t.Fatalf("normalize topics: %v", err)
+	}
+	if want := []string{"climate policy", "go"}; !reflect.DeepEqual(topics, want) {
+		t.Fatalf("expected %v, got %v", want, topics)
+	}
+
+	for _, raw := range [][]string{
+		nil,
+		{" ", ""},
+		{"x"},
+		{strings.Repeat("a", marketplaceTopicMaxRunes+1)},
+		{"one", "two", "three", "four", "five", "six"},
+	} {
+		if _, err := normalizeMarketplaceTopics(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
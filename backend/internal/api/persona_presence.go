@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const publicPresenceLimit = 10
+
+// RoomPresence is a persona's activity in one room over the presence window.
+type RoomPresence struct {
+	RoomID       string    `json:"room_id"`
+	RoomSlug     string    `json:"room_slug"`
+	RoomName     string    `json:"room_name"`
+	PostCount    int       `json:"post_count"`
+	ReplyCount   int       `json:"reply_count"`
+	LastActiveAt time.Time `json:"last_active_at"`
+}
+
+func (s *Server) handleGetPersonaPresence(w http.ResponseWriter, r *http.Request) {
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	presence, err := s.listPersonaPresence(r.Context(), personaID, 0)
+	if err != nil {
+		writeInternalError(w, "could not load presence")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"presence":    presence,
+		"window_days": common.PresenceWindowDays,
+	})
+}
+
+// listPersonaPresence reads the rooms a persona was active in over the
+// presence window from the daily rollup, most recently active first. A
+// limit of 0 returns every room.
+func (s *Server) listPersonaPresence(ctx context.Context, personaID string, limit int) ([]RoomPresence, error) {
+	var limitArg any
+	if limit > 0 {
+		limitArg = limit
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			r.id::text,
+			r.slug,
+			r.name,
+			SUM(d.post_count)::int,
+			SUM(d.reply_count)::int,
+			MAX(d.last_active_at)
+		FROM persona_room_presence_days d
+		JOIN rooms r ON r.id = d.room_id
+		WHERE d.persona_id = $1
+		  AND d.day >= (NOW() AT TIME ZONE 'UTC')::date - ($2::int - 1)
+		GROUP BY r.id, r.slug, r.name
+		ORDER BY MAX(d.last_active_at) DESC, r.name ASC
+		LIMIT $3
+	`, personaID, common.PresenceWindowDays, limitArg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	presence := make([]RoomPresence, 0)
+	for rows.Next() {
+		var item RoomPresence
+		if err := rows.Scan(&item.RoomID, &item.RoomSlug, &item.RoomName, &item.PostCount, &item.ReplyCount, &item.LastActiveAt); err != nil {
+			return nil, err
+		}
+		presence = append(presence, item)
+	}
+	return presence, rows.Err()
+}
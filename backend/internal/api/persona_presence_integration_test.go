@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestPersonaPresenceIntegration(t *testing.T) {
+	fixture := newIntegrationFixture(t, integrationFixtureOptions{})
+
+	for _, eventType := range []string{"post_created", "thread_participated", "reply_generated", "reply_generated"} {
+		if err := common.InsertPersonaActivityEvent(fixture.ctx, fixture.pool, fixture.personaID, eventType, map[string]any{
+			"room_id": fixture.roomID,
+		}); err != nil {
+			t.Fatalf("insert activity failed: %v", err)
+		}
+	}
+
+	recorder := doJSONRequest(fixture.server, http.MethodGet, "/personas/"+fixture.personaID+"/presence", fixture.token, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected presence 200, got %d, body: %s", recorder.Code, recorder.Body.String())
+	}
+	var payload struct {
+		Presence   []RoomPresence `json:"presence"`
+		WindowDays int            `json:"window_days"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode presence: %v", err)
+	}
+	if payload.WindowDays != common.PresenceWindowDays || len(payload.Presence) != 1 {
+		t.Fatalf("unexpected presence payload %+v", payload)
+	}
+	room := payload.Presence[0]
+	if room.RoomID != fixture.roomID || room.PostCount != 1 || room.ReplyCount != 2 || room.LastActiveAt.IsZero() {
+		t.Fatalf("unexpected room presence %+v", room)
+	}
+
+	anonymous := doJSONRequest(fixture.server, http.MethodGet, "/personas/"+fixture.personaID+"/presence", "", "")
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Fatalf("expected anonymous presence request to be rejected, got %d", anonymous.Code)
+	}
+}
@@ -0,0 +1,360 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/flags"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	questionStatusPending  = "PENDING"
+	questionStatusApproved = "APPROVED"
+	questionStatusAnswered = "ANSWERED"
+	questionStatusRejected = "REJECTED"
+
+	questionMaxLen  = 300
+	askerNameMaxLen = 40
+)
+
+type PersonaQuestion struct {
+	ID           string     `json:"id"`
+	PersonaID    string     `json:"persona_id"`
+	AskerName    string     `json:"asker_name,omitempty"`
+	Question     string     `json:"question"`
+	Status       string     `json:"status"`
+	RoomID       string     `json:"room_id,omitempty"`
+	AnswerPostID string     `json:"answer_post_id,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	ApprovedAt   *time.Time `json:"approved_at,omitempty"`
+	AnsweredAt   *time.Time `json:"answered_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func validateQuestionStatus(value string) (string, error) {
+	clean := strings.ToUpper(strings.TrimSpace(value))
+	switch clean {
+	case "":
+		return questionStatusPending, nil
+	case questionStatusPending, questionStatusApproved, questionStatusAnswered, questionStatusRejected:
+		return clean, nil
+	default:
+		return "", fmt.Errorf("status must be pending, approved, answered or rejected")
+	}
+}
+
+func validateQuestionInput(rules safety.Rules, question, askerName string) (string, string, error) {
+	cleanQuestion := strings.TrimSpace(question)
+	if err := rules.Validate(cleanQuestion, questionMaxLen); err != nil {
+		return "", "", fmt.Errorf("question: %w", err)
+	}
+	cleanName := strings.Join(strings.Fields(askerName), " ")
+	if len([]rune(cleanName)) > askerNameMaxLen {
+		return "", "", fmt.Errorf("name must be at most %d characters", askerNameMaxLen)
+	}
+	if cleanName != "" {
+		if err := rules.Validate(cleanName, askerNameMaxLen); err != nil {
+			return "", "", fmt.Errorf("name: %w", err)
+		}
+	}
+	return cleanQuestion, cleanName, nil
+}
+
+func (s *Server) handleAskPublicProfile(w http.ResponseWriter, r *http.Request) {
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
+	if slug == "" {
+		writeNotFound(w, "public profile not found")
+		return
+	}
+	if !s.publicAskLimiter.allow("ask:"+requestClientIP(r), time.Now()) {
+		s.writeRateLimitResponse(w, r, "ip", "public_ask", "question rate limit exceeded")
+		return
+	}
+
+	var req struct {
+		Question string `json:"question"`
+		Name     string `json:"name"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	question, askerName, err := validateQuestionInput(s.loadSafetyRules(r.Context()), req.Question, req.Name)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	profile, ownerUserID, err := s.getPublicProfileBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "/ask") {
+				return
+			}
+			writeNotFound(w, "public profile not found")
+			return
+		}
+		writeInternalError(w, "could not load public profile")
+		return
+	}
+	// Public Q&A rolls out by profile owner, so a profile takes questions
+	// from every visitor or from none.
+	if !s.flagEnabled(r.Context(), flags.PublicQA, ownerUserID) {
+		writeForbidden(w, "this persona is not taking questions")
+		return
+	}
+
+	askerUserID, _ := s.optionalUserIDFromRequest(r)
+	if askerUserID != "" && askerUserID == ownerUserID {
+		writeConflict(w, "cannot ask your own persona")
+		return
+	}
+	var askerArg any
+	if askerUserID != "" {
+		askerArg = askerUserID
+	}
+
+	var out PersonaQuestion
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO persona_questions(persona_id, asker_user_id, asker_name, question)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id::text, persona_id::text, asker_name, question, status, created_at
+	`, profile.PersonaID, askerArg, askerName, question).Scan(&out.ID, &out.PersonaID, &out.AskerName, &out.Question, &out.Status, &out.CreatedAt)
+	if err != nil {
+		writeInternalError(w, "could not save question")
+		return
+	}
+
+	_ = s.notifyPersonaQuestion(r.Context(), ownerUserID, askerUserID, profile.PersonaID, profile.Name, out.ID)
+	_ = s.logEventFromRequest(r, eventPersonaQuestionAsked, map[string]any{
+		"slug":        profile.Slug,
+		"persona_id":  profile.PersonaID,
+		"question_id": out.ID,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"question": out,
+	})
+}
+
+func (s *Server) handleListPersonaQuestions(w http.ResponseWriter, r *http.Request) {
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	status, err := validateQuestionStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			id::text,
+			persona_id::text,
+			asker_name,
+			question,
+			status,
+			COALESCE(room_id::text, ''),
+			COALESCE(answer_post_id::text, ''),
+			COALESCE(error, ''),
+			approved_at,
+			answered_at,
+			created_at
+		FROM persona_questions
+		WHERE persona_id = $1
+		  AND status = $2
+		ORDER BY created_at DESC
+		LIMIT 50
+	`, personaID, status)
+	if err != nil {
+		writeInternalError(w, "could not load questions")
+		return
+	}
+	defer rows.Close()
+
+	questions := make([]PersonaQuestion, 0)
+	for rows.Next() {
+		var question PersonaQuestion
+		if err := rows.Scan(
+			&question.ID,
+			&question.PersonaID,
+			&question.AskerName,
+			&question.Question,
+			&question.Status,
+			&question.RoomID,
+			&question.AnswerPostID,
+			&question.Error,
+			&question.ApprovedAt,
+			&question.AnsweredAt,
+			&question.CreatedAt,
+		); err != nil {
+			writeInternalError(w, "could not scan question")
+			return
+		}
+		questions = append(questions, question)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load questions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":    status,
+		"questions": questions,
+	})
+}
+
+func (s *Server) handleApprovePersonaQuestion(w http.ResponseWriter, r *http.Request) {
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	questionID, err := validateUUID(chi.URLParam(r, "questionID"), "question id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := decodeJSONAllowEmpty(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	roomID, err := s.resolveAnswerRoom(r.Context(), personaID, req.RoomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	s.transitionPersonaQuestion(w, r, personaID, questionID, questionStatusApproved, roomID)
+}
+
+func (s *Server) handleRejectPersonaQuestion(w http.ResponseWriter, r *http.Request) {
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	questionID, err := validateUUID(chi.URLParam(r, "questionID"), "question id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	s.transitionPersonaQuestion(w, r, personaID, questionID, questionStatusRejected, "")
+}
+
+// transitionPersonaQuestion moves a pending question to approved or rejected.
+// Only pending questions can change state, so an approval cannot be replayed
+// into a second answer post.
+func (s *Server) transitionPersonaQuestion(w http.ResponseWriter, r *http.Request, personaID, questionID, status, roomID string) {
+	var roomArg any
+	if roomID != "" {
+		roomArg = roomID
+	}
+
+	var out PersonaQuestion
+	err := s.db.QueryRow(r.Context(), `
+		UPDATE persona_questions
+		SET status = $3,
+			room_id = $4,
+			approved_at = CASE WHEN $3 = 'APPROVED' THEN NOW() ELSE approved_at END,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND persona_id = $2
+		  AND status = 'PENDING'
+		RETURNING id::text, persona_id::text, asker_name, question, status, COALESCE(room_id::text, ''), approved_at, created_at
+	`, questionID, personaID, status, roomArg).Scan(&out.ID, &out.PersonaID, &out.AskerName, &out.Question, &out.Status, &out.RoomID, &out.ApprovedAt, &out.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			writeInternalError(w, "could not update question")
+			return
+		}
+		var current string
+		lookupErr := s.db.QueryRow(r.Context(), `
+			SELECT status
+			FROM persona_questions
+			WHERE id = $1
+			  AND persona_id = $2
+		`, questionID, personaID).Scan(&current)
+		if lookupErr != nil {
+			if errors.Is(lookupErr, pgx.ErrNoRows) {
+				writeNotFound(w, "question not found")
+				return
+			}
+			writeInternalError(w, "could not load question")
+			return
+		}
+		writeConflict(w, fmt.Sprintf("question is already %s", strings.ToLower(current)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"question": out,
+	})
+}
+
+// resolveAnswerRoom picks the room an approved answer is posted in: the
+// owner's explicit choice, else the persona's most active room, else the
+// oldest room. Archived rooms are never picked.
+func (s *Server) resolveAnswerRoom(ctx context.Context, personaID, requested string) (string, error) {
+	if strings.TrimSpace(requested) != "" {
+		roomID, err := validateUUID(requested, "room id")
+		if err != nil {
+			return "", err
+		}
+		return s.activeRoomID(ctx, roomID)
+	}
+
+	topRooms, err := s.listTopRoomsForPersona(ctx, personaID, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(topRooms) > 0 {
+		roomID, err := s.activeRoomID(ctx, topRooms[0].RoomID)
+		if err == nil {
+			return roomID, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", err
+		}
+	}
+
+	var roomID string
+	err = s.db.QueryRow(ctx, `
+		SELECT id::text
+		FROM rooms
+		WHERE archived_at IS NULL
+		ORDER BY created_at ASC, id ASC
+		LIMIT 1
+	`).Scan(&roomID)
+	return roomID, err
+}
+
+func (s *Server) activeRoomID(ctx context.Context, roomID string) (string, error) {
+	var activeID string
+	err := s.db.QueryRow(ctx, `
+		SELECT id::text
+		FROM rooms
+		WHERE id = $1
+		  AND archived_at IS NULL
+	`, roomID).Scan(&activeID)
+	return activeID, err
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/safety"
+)
+
+func TestValidateQuestionInput(t *testing.T) {
+	question, name, err := validateQuestionInput(safety.DefaultRules(), "  How do you pick experiments?  ", "  Deniz   K. ")
+	if err != nil {
+		t.Fatalf("expected valid question, got %v", err)
+	}
+	if question != "How do you pick experiments?" || name != "Deniz K." {
+		t.Fatalf("unexpected normalized input %q / %q", question, name)
+	}
+
+	if _, _, err := validateQuestionInput(safety.DefaultRules(), "   ", ""); err == nil {
+		t.Fatalf("expected empty question to fail")
+	}
+	if _, _, err := validateQuestionInput(safety.DefaultRules(), strings.Repeat("a", questionMaxLen+1), ""); err == nil {
+		t.Fatalf("expected long question to fail")
+	}
+	if _, _, err := validateQuestionInput(safety.DefaultRules(), "Fair question?", strings.Repeat("n", askerNameMaxLen+1)); err == nil {
+		t.Fatalf("expected long name to fail")
+	}
+}
+
+func TestValidateQuestionStatus(t *testing.T) {
+	if got, err := validateQuestionStatus(""); err != nil || got != questionStatusPending {
+		t.Fatalf("expected default pending, got %q (%v)", got, err)
+	}
+	if got, err := validateQuestionStatus("answered"); err != nil || got != questionStatusAnswered {
+		t.Fatalf("expected answered, got %q (%v)", got, err)
+	}
+	if _, err := validateQuestionStatus("archived"); err == nil {
+		t.Fatalf("expected unknown status to fail")
+	}
+}
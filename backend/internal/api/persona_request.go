@@ -1,8 +1,10 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+
+	"personaworlds/backend/internal/common"
 )
 
 type personaUpsertRequest struct {
@@ -16,6 +18,11 @@ type personaUpsertRequest struct {
 	Formality         int      `json:"formality"`
 	DailyDraftQuota   int      `json:"daily_draft_quota"`
 	DailyReplyQuota   int      `json:"daily_reply_quota"`
+	ActiveHoursStart  *int     `json:"active_hours_start"`
+	ActiveHoursEnd    *int     `json:"active_hours_end"`
+	Timezone          string   `json:"timezone"`
+	// Style is optional; see normalizedStyle.
+	Style *PersonaStyle `json:"style"`
 }
 
 func (r *personaUpsertRequest) applyDefaultQuotas(defaultDraft, defaultReply int) {
@@ -34,6 +41,23 @@ func (r personaUpsertRequest) validatePositiveQuotas() error {
 	return nil
 }
 
+func (r personaUpsertRequest) normalizedActiveHours() (string, error) {
+	return common.ValidateActiveHours(r.ActiveHoursStart, r.ActiveHoursEnd, r.Timezone)
+}
+
+// normalizedStyle validates the sliders when they were sent and returns nil
+// when they were not.
+func (r personaUpsertRequest) normalizedStyle() (*PersonaStyle, error) {
+	if r.Style == nil {
+		return nil, nil
+	}
+	if err := r.Style.validate(); err != nil {
+		return nil, err
+	}
+	style := *r.Style
+	return &style, nil
+}
+
 func (r personaUpsertRequest) normalizedPersonaInput() (personaInput, error) {
 	return normalizePersonaInput(
 		r.Name,
@@ -47,9 +71,33 @@ func (r personaUpsertRequest) normalizedPersonaInput() (personaInput, error) {
 	)
 }
 
-func marshalPersonaJSONFields(input personaInput) (writingSamplesJSON, doNotSayJSON, catchphrasesJSON []byte) {
-	writingSamplesJSON, _ = json.Marshal(input.WritingSamples)
-	doNotSayJSON, _ = json.Marshal(input.DoNotSay)
-	catchphrasesJSON, _ = json.Marshal(input.Catchphrases)
-	return writingSamplesJSON, doNotSayJSON, catchphrasesJSON
+// encodePersonaJSONFields returns the jsonb documents for the calibration
+// lists of a normalized input.
+func encodePersonaJSONFields(input personaInput) (writingSamplesJSON, doNotSayJSON, catchphrasesJSON []byte, err error) {
+	if writingSamplesJSON, err = common.WritingSamplesField.Encode(input.WritingSamples); err != nil {
+		return nil, nil, nil, err
+	}
+	if doNotSayJSON, err = common.DoNotSayField.Encode(input.DoNotSay); err != nil {
+		return nil, nil, nil, err
+	}
+	if catchphrasesJSON, err = common.CatchphrasesField.Encode(input.Catchphrases); err != nil {
+		return nil, nil, nil, err
+	}
+	return writingSamplesJSON, doNotSayJSON, catchphrasesJSON, nil
+}
+
+// sealPersonaJSONFields encrypts encoded calibration lists with the owner's
+// data key when calibration encryption is configured.
+func (s *Server) sealPersonaJSONFields(ctx context.Context, userID string, writingSamplesJSON, doNotSayJSON, catchphrasesJSON []byte) ([]byte, []byte, []byte, error) {
+	var err error
+	if writingSamplesJSON, err = s.calibration.Seal(ctx, s.db, userID, common.WritingSamplesField, writingSamplesJSON); err != nil {
+		return nil, nil, nil, err
+	}
+	if doNotSayJSON, err = s.calibration.Seal(ctx, s.db, userID, common.DoNotSayField, doNotSayJSON); err != nil {
+		return nil, nil, nil, err
+	}
+	if catchphrasesJSON, err = s.calibration.Seal(ctx, s.db, userID, common.CatchphrasesField, catchphrasesJSON); err != nil {
+		return nil, nil, nil, err
+	}
+	return writingSamplesJSON, doNotSayJSON, catchphrasesJSON, nil
 }
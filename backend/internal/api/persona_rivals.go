@@ -0,0 +1,341 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	personaRivalsLimit       = 20
+	personaRivalMeetingLimit = 500
+
+	headToHeadWin  = "win"
+	headToHeadLoss = "loss"
+	headToHeadTie  = "tie"
+
+	rematchKindBattle    = "battle"
+	rematchKindChallenge = "challenge"
+)
+
+type PersonaRival struct {
+	PersonaID    string          `json:"persona_id"`
+	Name         string          `json:"name"`
+	PublicSlug   string          `json:"public_slug,omitempty"`
+	Battles      int             `json:"battles"`
+	Wins         int             `json:"wins"`
+	Losses       int             `json:"losses"`
+	Ties         int             `json:"ties"`
+	LastBattleID string          `json:"last_battle_id"`
+	LastBattleAt time.Time       `json:"last_battle_at"`
+	LastResult   string          `json:"last_result"`
+	LastTopic    string          `json:"last_topic"`
+	Rematch      *PersonaRematch `json:"rematch,omitempty"`
+	ownerUserID  string
+	lastRoomID   string
+	lastRoomName string
+	lastContent  string
+	lastTemplate string
+}
+
+// PersonaRematch pre-fills battle creation against the rival with a remix of
+// their last meeting. Rivals the user owns are rematched through a regular
+// battle with remix_token; public rivals of other users through a challenge.
+type PersonaRematch struct {
+	Kind              string `json:"kind"`
+	TargetRoute       string `json:"target_route"`
+	SourceBattleID    string `json:"source_battle_id"`
+	RoomID            string `json:"room_id"`
+	Topic             string `json:"topic"`
+	TemplateID        string `json:"template_id,omitempty"`
+	PersonaID         string `json:"persona_id"`
+	RivalPersonaID    string `json:"rival_persona_id"`
+	RemixToken        string `json:"remix_token,omitempty"`
+	RemixTokenExpires string `json:"remix_token_expires,omitempty"`
+}
+
+// rivalMeeting is one finished battle between the persona and a rival, with
+// the audience votes each side received and each side's verdict score: the
+// summed weight of its highlighted turns.
+type rivalMeeting struct {
+	BattleID          string
+	RoomID            string
+	RoomName          string
+	Content           string
+	TemplateID        string
+	CreatedAt         time.Time
+	RivalID           string
+	RivalName         string
+	RivalUserID       string
+	RivalPublicSlug   string
+	MyVotes           int
+	RivalVotes        int
+	MyVerdictScore    float64
+	RivalVerdictScore float64
+}
+
+// headToHeadResult decides a meeting from the persona's side. The audience
+// picks the winner; an even split, including no votes at all, is a tie.
+func headToHeadResult(myVotes, rivalVotes int) string {
+	switch {
+	case myVotes > rivalVotes:
+		return headToHeadWin
+	case myVotes < rivalVotes:
+		return headToHeadLoss
+	default:
+		return headToHeadTie
+	}
+}
+
+// result decides the meeting from the persona's side. Audience votes decide
+// when there are any; an unvoted battle falls back to the verdict, and one
+// without highlights either is a tie.
+func (m rivalMeeting) result() string {
+	if m.MyVotes > 0 || m.RivalVotes > 0 {
+		return headToHeadResult(m.MyVotes, m.RivalVotes)
+	}
+	switch {
+	case m.MyVerdictScore > m.RivalVerdictScore:
+		return headToHeadWin
+	case m.MyVerdictScore < m.RivalVerdictScore:
+		return headToHeadLoss
+	default:
+		return headToHeadTie
+	}
+}
+
+// aggregatePersonaRivals folds meetings, newest first, into one record per
+// rival. Rivals are ordered by meetings played, then by the latest meeting.
+func aggregatePersonaRivals(meetings []rivalMeeting, limit int) []PersonaRival {
+	byRival := map[string]*PersonaRival{}
+	order := make([]string, 0)
+	for _, meeting := range meetings {
+		rival, ok := byRival[meeting.RivalID]
+		if !ok {
+			rival = &PersonaRival{
+				PersonaID:    meeting.RivalID,
+				Name:         meeting.RivalName,
+				PublicSlug:   meeting.RivalPublicSlug,
+				LastBattleID: meeting.BattleID,
+				LastBattleAt: meeting.CreatedAt,
+				LastResult:   meeting.result(),
+				LastTopic:    buildBattleCardTopic(meeting.Content, meeting.RoomName),
+				ownerUserID:  meeting.RivalUserID,
+				lastRoomID:   meeting.RoomID,
+				lastRoomName: meeting.RoomName,
+				lastContent:  meeting.Content,
+				lastTemplate: meeting.TemplateID,
+			}
+			byRival[meeting.RivalID] = rival
+			order = append(order, meeting.RivalID)
+		}
+		rival.Battles++
+		switch meeting.result() {
+		case headToHeadWin:
+			rival.Wins++
+		case headToHeadLoss:
+			rival.Losses++
+		default:
+			rival.Ties++
+		}
+	}
+
+	rivals := make([]PersonaRival, 0, len(order))
+	for _, id := range order {
+		rivals = append(rivals, *byRival[id])
+	}
+	sort.SliceStable(rivals, func(i, j int) bool {
+		return rivals[i].Battles > rivals[j].Battles
+	})
+	if limit > 0 && len(rivals) > limit {
+		rivals = rivals[:limit]
+	}
+	return rivals
+}
+
+// handleListPersonaRivals returns the persona's head-to-head records, archived
+// battles included. A battle counts once its verdict is in, or once it is past
+// the week coaching covers, and is scored by audience votes between the two
+// personas, falling back to the verdict when nobody voted.
+func (s *Server) handleListPersonaRivals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if _, err := s.getPersonaByID(r.Context(), userID, personaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		WITH finished AS (
+			SELECT p.id, p.room_id, p.content, p.template_id, p.created_at
+			FROM posts p
+			WHERE p.template_id IS NOT NULL
+			  AND p.status = 'PUBLISHED'
+			  AND (
+				EXISTS (SELECT 1 FROM battle_coaching bc WHERE bc.post_id = p.id)
+				OR p.created_at < NOW() - INTERVAL '7 days'
+			  )
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM interactive_battles ib
+				WHERE ib.post_id = p.id
+				  AND ib.status = 'ACTIVE'
+			  )
+			  AND EXISTS (
+				SELECT 1
+				FROM (`+battleParticipantsQuery("p.id")+`) pt
+				WHERE pt.persona_id = $1
+			  )
+		),
+		participants AS (
+			SELECT f.id AS post_id, pt.persona_id
+			FROM finished f
+			CROSS JOIN LATERAL (`+battleParticipantsQuery("f.id")+`) pt
+		),
+		tallies AS (
+			SELECT v.post_id, v.persona_id, COUNT(*)::int AS votes
+			FROM battle_votes v
+			JOIN finished f ON f.id = v.post_id
+			GROUP BY v.post_id, v.persona_id
+		),
+		verdicts AS (
+			SELECT
+				bh.post_id,
+				(h.value->>'persona_id')::uuid AS persona_id,
+				SUM(
+					COALESCE((h.value->>'score')::float8, 0)
+					+ $3 * COALESCE((h.value->>'verdict_refs')::int, 0)
+				) AS score
+			FROM battle_highlights bh
+			JOIN finished f ON f.id = bh.post_id
+			CROSS JOIN LATERAL jsonb_array_elements(bh.highlights) AS h(value)
+			WHERE h.value->>'persona_id' IS NOT NULL
+			GROUP BY bh.post_id, (h.value->>'persona_id')::uuid
+		)
+		SELECT
+			f.id::text,
+			f.room_id::text,
+			COALESCE(rm.name, ''),
+			f.content,
+			COALESCE(f.template_id::text, ''),
+			f.created_at,
+			rival.id::text,
+			rival.name,
+			rival.user_id::text,
+			COALESCE(pp.slug, ''),
+			COALESCE(mine.votes, 0),
+			COALESCE(theirs.votes, 0),
+			COALESCE(my_verdict.score, 0),
+			COALESCE(their_verdict.score, 0)
+		FROM finished f
+		JOIN rooms rm ON rm.id = f.room_id
+		JOIN participants pt ON pt.post_id = f.id AND pt.persona_id <> $1
+		JOIN personas rival ON rival.id = pt.persona_id
+		LEFT JOIN persona_public_profiles pp ON pp.persona_id = rival.id AND pp.is_public = TRUE
+		LEFT JOIN tallies mine ON mine.post_id = f.id AND mine.persona_id = $1
+		LEFT JOIN tallies theirs ON theirs.post_id = f.id AND theirs.persona_id = rival.id
+		LEFT JOIN verdicts my_verdict ON my_verdict.post_id = f.id AND my_verdict.persona_id = $1
+		LEFT JOIN verdicts their_verdict ON their_verdict.post_id = f.id AND their_verdict.persona_id = rival.id
+		ORDER BY f.created_at DESC, f.id, rival.id
+		LIMIT $2
+	`, personaID, personaRivalMeetingLimit, common.HighlightVerdictRefWeight)
+	if err != nil {
+		writeInternalError(w, "could not load rivals")
+		return
+	}
+	defer rows.Close()
+
+	meetings := make([]rivalMeeting, 0)
+	for rows.Next() {
+		var m rivalMeeting
+		if err := rows.Scan(
+			&m.BattleID,
+			&m.RoomID,
+			&m.RoomName,
+			&m.Content,
+			&m.TemplateID,
+			&m.CreatedAt,
+			&m.RivalID,
+			&m.RivalName,
+			&m.RivalUserID,
+			&m.RivalPublicSlug,
+			&m.MyVotes,
+			&m.RivalVotes,
+			&m.MyVerdictScore,
+			&m.RivalVerdictScore,
+		); err != nil {
+			writeInternalError(w, "could not read rivals")
+			return
+		}
+		meetings = append(meetings, m)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not read rivals")
+		return
+	}
+
+	rivals := aggregatePersonaRivals(meetings, personaRivalsLimit)
+	for i := range rivals {
+		rematch, err := s.buildPersonaRematch(userID, personaID, rivals[i])
+		if err != nil {
+			writeInternalError(w, "could not create rematch")
+			return
+		}
+		rivals[i].Rematch = rematch
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"persona_id": personaID,
+		"rivals":     rivals,
+	})
+}
+
+// buildPersonaRematch returns nil when the rival belongs to another user who
+// has no public profile to challenge.
+func (s *Server) buildPersonaRematch(userID, personaID string, rival PersonaRival) (*PersonaRematch, error) {
+	remix := newRemixDefaults(rival.LastBattleID, rival.lastRoomID, rival.lastRoomName, rival.lastContent, rival.lastTemplate)
+	rematch := &PersonaRematch{
+		SourceBattleID: rival.LastBattleID,
+		RoomID:         remix.RoomID,
+		Topic:          remix.Topic,
+		TemplateID:     remix.TemplateID,
+		PersonaID:      personaID,
+		RivalPersonaID: rival.PersonaID,
+	}
+
+	switch {
+	case rival.ownerUserID == userID:
+		token, expiresAt, err := createRemixIntentToken(s.cfg.JWTSecret, remix, 30*time.Minute)
+		if err != nil {
+			return nil, err
+		}
+		rematch.Kind = rematchKindBattle
+		rematch.TargetRoute = fmt.Sprintf("/rooms/%s/battles", remix.RoomID)
+		rematch.RemixToken = token
+		rematch.RemixTokenExpires = expiresAt.UTC().Format(time.RFC3339)
+	case rival.PublicSlug != "":
+		rematch.Kind = rematchKindChallenge
+		rematch.TargetRoute = fmt.Sprintf("/p/%s/challenge", rival.PublicSlug)
+	default:
+		return nil, nil
+	}
+	return rematch, nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/config"
+)
+
+func TestHeadToHeadResult(t *testing.T) {
+	cases := []struct {
+		mine, theirs int
+		want         string
+	}{
+		{mine: 3, theirs: 1, want: headToHeadWin},
+		{mine: 0, theirs: 2, want: headToHeadLoss},
+		{mine: 2, theirs: 2, want: headToHeadTie},
+		{mine: 0, theirs: 0, want: headToHeadTie},
+	}
+	for _, tc := range cases {
+		if got := headToHeadResult(tc.mine, tc.theirs); got != tc.want {
+			t.Fatalf("headToHeadResult(%d, %d) = %q, want %q", tc.mine, tc.theirs, got, tc.want)
+		}
+	}
+}
+
+func TestRivalMeetingResultFallsBackToVerdict(t *testing.T) {
+	cases := []struct {
+		name    string
+		meeting rivalMeeting
+		want    string
+	}{
+		{name: "votes decide", meeting: rivalMeeting{MyVotes: 1, RivalVotes: 2, MyVerdictScore: 3.2, RivalVerdictScore: 0.4}, want: headToHeadLoss},
+		{name: "verdict win", meeting: rivalMeeting{MyVerdictScore: 1.85, RivalVerdictScore: 0.9}, want: headToHeadWin},
+		{name: "verdict loss", meeting: rivalMeeting{RivalVerdictScore: 0.7}, want: headToHeadLoss},
+		{name: "no votes or highlights", meeting: rivalMeeting{}, want: headToHeadTie},
+	}
+	for _, tc := range cases {
+		if got := tc.meeting.result(); got != tc.want {
+			t.Fatalf("%s: result() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAggregatePersonaRivals(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	meetings := []rivalMeeting{
+		{BattleID: "b4", RoomName: "Tech", Content: "Rematch topic", CreatedAt: now, RivalID: "solo", RivalName: "Solo", MyVotes: 1},
+		{BattleID: "b3", RoomName: "Tech", Content: "Latest topic", CreatedAt: now.Add(-time.Hour), RivalID: "nemesis", RivalName: "Nemesis", MyVotes: 2, RivalVotes: 5},
+		{BattleID: "b2", CreatedAt: now.Add(-2 * time.Hour), RivalID: "nemesis", RivalName: "Nemesis", MyVotes: 4, RivalVotes: 1},
+		{BattleID: "b1", CreatedAt: now.Add(-3 * time.Hour), RivalID: "nemesis", RivalName: "Nemesis"},
+	}
+
+	rivals := aggregatePersonaRivals(meetings, 10)
+	if len(rivals) != 2 {
+		t.Fatalf("expected 2 rivals, got %d", len(rivals))
+	}
+	nemesis := rivals[0]
+	if nemesis.PersonaID != "nemesis" || nemesis.Battles != 3 {
+		t.Fatalf("expected most-played rival first, got %+v", nemesis)
+	}
+	if nemesis.Wins != 1 || nemesis.Losses != 1 || nemesis.Ties != 1 {
+		t.Fatalf("unexpected record %d-%d-%d", nemesis.Wins, nemesis.Losses, nemesis.Ties)
+	}
+	if nemesis.LastBattleID != "b3" || nemesis.LastResult != headToHeadLoss || nemesis.LastTopic != buildBattleCardTopic("Latest topic", "Tech") {
+		t.Fatalf("expected last meeting b3 as a loss, got %+v", nemesis)
+	}
+	if rivals[1].PersonaID != "solo" || rivals[1].Wins != 1 {
+		t.Fatalf("unexpected second rival %+v", rivals[1])
+	}
+
+	if limited := aggregatePersonaRivals(meetings, 1); len(limited) != 1 || limited[0].PersonaID != "nemesis" {
+		t.Fatalf("expected limit to keep the top rival, got %+v", limited)
+	}
+}
+
+func TestBuildPersonaRematch(t *testing.T) {
+	s := &Server{cfg: config.Config{JWTSecret: "rematch-secret"}}
+	base := PersonaRival{
+		PersonaID:    "rival-persona",
+		LastBattleID: "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		lastRoomID:   "room-1",
+		lastRoomName: "Tech",
+		lastContent:  "Should AI write tests?",
+		lastTemplate: "template-1",
+	}
+
+	own := base
+	own.ownerUserID = "user-1"
+	rematch, err := s.buildPersonaRematch("user-1", "my-persona", own)
+	if err != nil {
+		t.Fatalf("build rematch: %v", err)
+	}
+	if rematch == nil || rematch.Kind != rematchKindBattle || rematch.TargetRoute != "/rooms/room-1/battles" {
+		t.Fatalf("expected battle rematch, got %+v", rematch)
+	}
+	intent, err := parseRemixIntentToken(s.cfg.JWTSecret, rematch.RemixToken)
+	if err != nil {
+		t.Fatalf("parse remix token: %v", err)
+	}
+	if intent.BattleID != base.LastBattleID || intent.RoomID != "room-1" || intent.TemplateID != "template-1" {
+		t.Fatalf("unexpected remix intent %+v", intent)
+	}
+
+	public := base
+	public.ownerUserID = "user-2"
+	public.PublicSlug = "rival"
+	rematch, err = s.buildPersonaRematch("user-1", "my-persona", public)
+	if err != nil {
+		t.Fatalf("build challenge rematch: %v", err)
+	}
+	if rematch == nil || rematch.Kind != rematchKindChallenge || rematch.TargetRoute != "/p/rival/challenge" || rematch.RemixToken != "" {
+		t.Fatalf("expected challenge rematch, got %+v", rematch)
+	}
+	if rematch.PersonaID != "my-persona" || rematch.Topic == "" {
+		t.Fatalf("expected challenge prefill, got %+v", rematch)
+	}
+
+	private := base
+	private.ownerUserID = "user-2"
+	if rematch, err := s.buildPersonaRematch("user-1", "my-persona", private); err != nil || rematch != nil {
+		t.Fatalf("expected no rematch for private rival, got %+v, %v", rematch, err)
+	}
+}
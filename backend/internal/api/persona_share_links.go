@@ -0,0 +1,621 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/authz"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	shareLinkStatusActive  = "active"
+	shareLinkStatusExpired = "expired"
+	shareLinkStatusRevoked = "revoked"
+
+	shareLinkDefaultHours  = 7 * 24
+	shareLinkMaxHours      = 30 * 24
+	shareLinkLabelMaxLen   = 60
+	shareLinkMaxActive     = 20
+	shareLinkPreviewRooms  = 30
+	shareLinkTokenBytes    = 32
+	shareLinkDailySuggests = 20
+
+	suggestionStatusPending   = "PENDING"
+	suggestionStatusAccepted  = "ACCEPTED"
+	suggestionStatusDismissed = "DISMISSED"
+
+	suggestionMaxLen       = 1000
+	suggestionAuthorMaxLen = 40
+)
+
+// suggestionFields are the parts of a persona an edit suggestion can be
+// about; general is for anything else.
+var suggestionFields = []string{"bio", "tone", "writing_samples", "do_not_say", "catchphrases", "formality", "preferred_language", "general"}
+
+// personaShareLinkAccess is the live share link a /shared request was made
+// with.
+type personaShareLinkAccess struct {
+	LinkID      string
+	PersonaID   string
+	OwnerUserID string
+	Scopes      []authz.Action
+	ExpiresAt   time.Time
+}
+
+type PersonaShareLink struct {
+	ID                 string     `json:"id"`
+	PersonaID          string     `json:"persona_id"`
+	Label              string     `json:"label"`
+	Scopes             []string   `json:"scopes"`
+	Status             string     `json:"status"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	PendingSuggestions int        `json:"pending_suggestions"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type PersonaEditSuggestion struct {
+	ID             string     `json:"id"`
+	PersonaID      string     `json:"persona_id"`
+	ShareLinkID    string     `json:"share_link_id"`
+	ShareLinkLabel string     `json:"share_link_label,omitempty"`
+	Field          string     `json:"field"`
+	Suggestion     string     `json:"suggestion"`
+	AuthorName     string     `json:"author_name,omitempty"`
+	Status         string     `json:"status"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// SharedPersonaCalibration is the read-only view of a persona's calibration
+// given to view_calibration links. Quotas and active hours stay private.
+type SharedPersonaCalibration struct {
+	Bio               string       `json:"bio"`
+	Tone              string       `json:"tone"`
+	WritingSamples    []string     `json:"writing_samples"`
+	DoNotSay          []string     `json:"do_not_say"`
+	Catchphrases      []string     `json:"catchphrases"`
+	PreferredLanguage string       `json:"preferred_language"`
+	Formality         int          `json:"formality"`
+	Style             PersonaStyle `json:"style"`
+}
+
+type sharedPreviewRoom struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type shareLinkRequest struct {
+	Scopes         []string `json:"scopes"`
+	ExpiresInHours int      `json:"expires_in_hours"`
+	Label          string   `json:"label"`
+}
+
+// normalize validates the scopes, dropping duplicates, and returns the
+// cleaned label and the link lifetime.
+func (req shareLinkRequest) normalize() ([]string, string, time.Duration, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := map[string]struct{}{}
+	for _, raw := range req.Scopes {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		if !authz.IsShareScope(authz.Action(scope)) {
+			return nil, "", 0, fmt.Errorf("scopes must be view_calibration, suggest_edits or run_preview")
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, "", 0, fmt.Errorf("at least one scope is required")
+	}
+
+	hours := req.ExpiresInHours
+	if hours == 0 {
+		hours = shareLinkDefaultHours
+	}
+	if hours < 1 || hours > shareLinkMaxHours {
+		return nil, "", 0, fmt.Errorf("expires_in_hours must be between 1 and %d", shareLinkMaxHours)
+	}
+
+	label := strings.Join(strings.Fields(req.Label), " ")
+	if len([]rune(label)) > shareLinkLabelMaxLen {
+		return nil, "", 0, fmt.Errorf("label must be at most %d characters", shareLinkLabelMaxLen)
+	}
+	return scopes, label, time.Duration(hours) * time.Hour, nil
+}
+
+func newShareLinkToken() (string, error) {
+	buf := make([]byte, shareLinkTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashShareLinkToken is what is stored for a token, so a database leak does
+// not hand out working links.
+func hashShareLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func shareLinkStatus(expiresAt time.Time, revokedAt *time.Time, now time.Time) string {
+	switch {
+	case revokedAt != nil:
+		return shareLinkStatusRevoked
+	case !now.Before(expiresAt):
+		return shareLinkStatusExpired
+	default:
+		return shareLinkStatusActive
+	}
+}
+
+func validateSuggestionStatus(value string) (string, error) {
+	clean := strings.ToUpper(strings.TrimSpace(value))
+	switch clean {
+	case "":
+		return suggestionStatusPending, nil
+	case suggestionStatusPending, suggestionStatusAccepted, suggestionStatusDismissed:
+		return clean, nil
+	default:
+		return "", fmt.Errorf("status must be pending, accepted or dismissed")
+	}
+}
+
+func validateSuggestionInput(rules safety.Rules, field, suggestion, authorName string) (string, string, string, error) {
+	cleanField := strings.ToLower(strings.TrimSpace(field))
+	if cleanField == "" {
+		cleanField = "general"
+	}
+	known := false
+	for _, candidate := range suggestionFields {
+		if candidate == cleanField {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", "", "", fmt.Errorf("field must be one of %s", strings.Join(suggestionFields, ", "))
+	}
+	cleanSuggestion := strings.TrimSpace(suggestion)
+	if err := rules.Validate(cleanSuggestion, suggestionMaxLen); err != nil {
+		return "", "", "", fmt.Errorf("suggestion: %w", err)
+	}
+	cleanName := strings.Join(strings.Fields(authorName), " ")
+	if len([]rune(cleanName)) > suggestionAuthorMaxLen {
+		return "", "", "", fmt.Errorf("author_name must be at most %d characters", suggestionAuthorMaxLen)
+	}
+	if cleanName != "" {
+		if err := rules.Validate(cleanName, suggestionAuthorMaxLen); err != nil {
+			return "", "", "", fmt.Errorf("author_name: %w", err)
+		}
+	}
+	return cleanField, cleanSuggestion, cleanName, nil
+}
+
+func (s *Server) sharedPersonaURL(token string) string {
+	return fmt.Sprintf("%s/shared/personas/%s", strings.TrimRight(s.cfg.FrontendOrigin, "/"), token)
+}
+
+// handleCreatePersonaShareLink issues a scoped link. The token is only
+// returned here; the owner revokes the link rather than retrieving it again.
+func (s *Server) handleCreatePersonaShareLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID := accessResourceID(r)
+
+	var req shareLinkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	scopes, label, lifetime, err := req.normalize()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var active int
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT COUNT(*)::int
+		FROM persona_share_links
+		WHERE persona_id = $1
+		  AND revoked_at IS NULL
+		  AND expires_at > NOW()
+	`, personaID).Scan(&active); err != nil {
+		writeInternalError(w, "could not count share links")
+		return
+	}
+	if active >= shareLinkMaxActive {
+		writeConflict(w, fmt.Sprintf("a persona can have at most %d active share links; revoke one first", shareLinkMaxActive))
+		return
+	}
+
+	token, err := newShareLinkToken()
+	if err != nil {
+		writeInternalError(w, "could not create share link")
+		return
+	}
+	link := PersonaShareLink{PersonaID: personaID, Label: label, Scopes: scopes, Status: shareLinkStatusActive}
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO persona_share_links(persona_id, created_by_user_id, label, scopes, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + ($6::bigint * INTERVAL '1 second'))
+		RETURNING id::text, expires_at, created_at
+	`, personaID, userID, label, scopes, hashShareLinkToken(token), int64(lifetime.Seconds())).Scan(&link.ID, &link.ExpiresAt, &link.CreatedAt)
+	if err != nil {
+		writeInternalError(w, "could not create share link")
+		return
+	}
+
+	s.recordAudit(r, userID, auditPersonaShareLinkCreated, map[string]any{
+		"persona_id":    personaID,
+		"share_link_id": link.ID,
+		"scopes":        scopes,
+		"expires_at":    link.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"link":        link,
+		"share_token": token,
+		"share_url":   s.sharedPersonaURL(token),
+	})
+}
+
+func (s *Server) handleListPersonaShareLinks(w http.ResponseWriter, r *http.Request) {
+	personaID := accessResourceID(r)
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			l.id::text,
+			l.persona_id::text,
+			l.label,
+			l.scopes,
+			l.expires_at,
+			l.revoked_at,
+			l.last_used_at,
+			(
+				SELECT COUNT(*)::int
+				FROM persona_edit_suggestions es
+				WHERE es.share_link_id = l.id
+				  AND es.status = 'PENDING'
+			),
+			l.created_at
+		FROM persona_share_links l
+		WHERE l.persona_id = $1
+		ORDER BY l.created_at DESC
+		LIMIT 50
+	`, personaID)
+	if err != nil {
+		writeInternalError(w, "could not load share links")
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	links := make([]PersonaShareLink, 0)
+	for rows.Next() {
+		var link PersonaShareLink
+		if err := rows.Scan(&link.ID, &link.PersonaID, &link.Label, &link.Scopes, &link.ExpiresAt, &link.RevokedAt, &link.LastUsedAt, &link.PendingSuggestions, &link.CreatedAt); err != nil {
+			writeInternalError(w, "could not scan share link")
+			return
+		}
+		link.Status = shareLinkStatus(link.ExpiresAt, link.RevokedAt, now)
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load share links")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"links": links})
+}
+
+// handleRevokePersonaShareLink stops a link working at once. Suggestions
+// already sent through it are kept.
+func (s *Server) handleRevokePersonaShareLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID := accessResourceID(r)
+	linkID, err := validateUUID(chi.URLParam(r, "linkID"), "share link id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var revokedNow bool
+	err = s.db.QueryRow(r.Context(), `
+		WITH revoked AS (
+			UPDATE persona_share_links
+			SET revoked_at = NOW()
+			WHERE id = $1
+			  AND persona_id = $2
+			  AND revoked_at IS NULL
+			RETURNING id
+		)
+		SELECT EXISTS(SELECT 1 FROM revoked)
+		FROM persona_share_links
+		WHERE id = $1
+		  AND persona_id = $2
+	`, linkID, personaID).Scan(&revokedNow)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "share link not found")
+			return
+		}
+		writeInternalError(w, "could not revoke share link")
+		return
+	}
+	if revokedNow {
+		s.recordAudit(r, userID, auditPersonaShareLinkRevoked, map[string]any{
+			"persona_id":    personaID,
+			"share_link_id": linkID,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"revoked": true})
+}
+
+func (s *Server) handleListPersonaEditSuggestions(w http.ResponseWriter, r *http.Request) {
+	personaID := accessResourceID(r)
+	status, err := validateSuggestionStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT es.id::text, es.persona_id::text, es.share_link_id::text, l.label, es.field, es.suggestion, es.author_name, es.status, es.reviewed_at, es.created_at
+		FROM persona_edit_suggestions es
+		JOIN persona_share_links l ON l.id = es.share_link_id
+		WHERE es.persona_id = $1
+		  AND es.status = $2
+		ORDER BY es.created_at DESC
+		LIMIT 50
+	`, personaID, status)
+	if err != nil {
+		writeInternalError(w, "could not load suggestions")
+		return
+	}
+	defer rows.Close()
+
+	suggestions := make([]PersonaEditSuggestion, 0)
+	for rows.Next() {
+		var suggestion PersonaEditSuggestion
+		if err := rows.Scan(&suggestion.ID, &suggestion.PersonaID, &suggestion.ShareLinkID, &suggestion.ShareLinkLabel, &suggestion.Field, &suggestion.Suggestion, &suggestion.AuthorName, &suggestion.Status, &suggestion.ReviewedAt, &suggestion.CreatedAt); err != nil {
+			writeInternalError(w, "could not scan suggestion")
+			return
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load suggestions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":      status,
+		"suggestions": suggestions,
+	})
+}
+
+func (s *Server) handleAcceptPersonaEditSuggestion(w http.ResponseWriter, r *http.Request) {
+	s.reviewPersonaEditSuggestion(w, r, suggestionStatusAccepted)
+}
+
+func (s *Server) handleDismissPersonaEditSuggestion(w http.ResponseWriter, r *http.Request) {
+	s.reviewPersonaEditSuggestion(w, r, suggestionStatusDismissed)
+}
+
+// reviewPersonaEditSuggestion records the owner's decision on a pending
+// suggestion. Accepting does not edit the persona: the owner applies the
+// change through PUT /personas/{id}, where it is validated like any edit.
+func (s *Server) reviewPersonaEditSuggestion(w http.ResponseWriter, r *http.Request, status string) {
+	personaID := accessResourceID(r)
+	suggestionID, err := validateUUID(chi.URLParam(r, "suggestionID"), "suggestion id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var out PersonaEditSuggestion
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE persona_edit_suggestions
+		SET status = $3, reviewed_at = NOW()
+		WHERE id = $1
+		  AND persona_id = $2
+		  AND status = 'PENDING'
+		RETURNING id::text, persona_id::text, share_link_id::text, field, suggestion, author_name, status, reviewed_at, created_at
+	`, suggestionID, personaID, status).Scan(&out.ID, &out.PersonaID, &out.ShareLinkID, &out.Field, &out.Suggestion, &out.AuthorName, &out.Status, &out.ReviewedAt, &out.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			writeInternalError(w, "could not update suggestion")
+			return
+		}
+		var current string
+		lookupErr := s.db.QueryRow(r.Context(), `
+			SELECT status
+			FROM persona_edit_suggestions
+			WHERE id = $1
+			  AND persona_id = $2
+		`, suggestionID, personaID).Scan(&current)
+		if lookupErr != nil {
+			if errors.Is(lookupErr, pgx.ErrNoRows) {
+				writeNotFound(w, "suggestion not found")
+				return
+			}
+			writeInternalError(w, "could not load suggestion")
+			return
+		}
+		writeConflict(w, fmt.Sprintf("suggestion is already %s", strings.ToLower(current)))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"suggestion": out})
+}
+
+// handleGetSharedPersona is the landing view of a share link. It always
+// names the persona and lists the link's scopes; calibration and preview
+// rooms are only included when the link's policy allows them.
+func (s *Server) handleGetSharedPersona(w http.ResponseWriter, r *http.Request) {
+	link, facts, err := s.loadShareLink(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		writeInternalError(w, "could not load share link")
+		return
+	}
+	if !facts.Exists {
+		writeNotFound(w, "persona not found")
+		return
+	}
+
+	persona, err := s.getPersonaByID(r.Context(), link.OwnerUserID, link.PersonaID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	response := map[string]any{
+		"persona":    map[string]any{"id": persona.ID, "name": persona.Name},
+		"scopes":     link.Scopes,
+		"expires_at": link.ExpiresAt,
+	}
+	if authz.Decide(authz.Subject{}, authz.Persona, authz.ViewCalibration, facts).Allowed {
+		response["calibration"] = SharedPersonaCalibration{
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			WritingSamples:    persona.WritingSamples,
+			DoNotSay:          persona.DoNotSay,
+			Catchphrases:      persona.Catchphrases,
+			PreferredLanguage: persona.PreferredLanguage,
+			Formality:         persona.Formality,
+			Style:             persona.Style,
+		}
+	}
+	if authz.Decide(authz.Subject{}, authz.Persona, authz.RunPreview, facts).Allowed {
+		rooms, err := s.listSharedPreviewRooms(r)
+		if err != nil {
+			writeInternalError(w, "could not load rooms")
+			return
+		}
+		response["preview_rooms"] = rooms
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) listSharedPreviewRooms(r *http.Request) ([]sharedPreviewRoom, error) {
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id::text, name
+		FROM rooms
+		WHERE archived_at IS NULL
+		ORDER BY name ASC
+		LIMIT $1
+	`, shareLinkPreviewRooms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := make([]sharedPreviewRoom, 0)
+	for rows.Next() {
+		var room sharedPreviewRoom
+		if err := rows.Scan(&room.ID, &room.Name); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func (s *Server) handleSuggestSharedPersonaEdit(w http.ResponseWriter, r *http.Request) {
+	link := shareLinkFromRequest(r)
+
+	var req struct {
+		Field      string `json:"field"`
+		Suggestion string `json:"suggestion"`
+		AuthorName string `json:"author_name"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	field, suggestion, authorName, err := validateSuggestionInput(s.loadSafetyRules(r.Context()), req.Field, req.Suggestion, req.AuthorName)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var sentToday int
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT COUNT(*)::int
+		FROM persona_edit_suggestions
+		WHERE share_link_id = $1
+		  AND created_at >= NOW() - INTERVAL '1 day'
+	`, link.LinkID).Scan(&sentToday); err != nil {
+		writeInternalError(w, "could not check suggestion limit")
+		return
+	}
+	if sentToday >= shareLinkDailySuggests {
+		writeTooManyRequests(w, "suggestion limit reached for this link, try again tomorrow")
+		return
+	}
+
+	out := PersonaEditSuggestion{
+		PersonaID:   link.PersonaID,
+		ShareLinkID: link.LinkID,
+		Field:       field,
+		Suggestion:  suggestion,
+		AuthorName:  authorName,
+		Status:      suggestionStatusPending,
+	}
+	var personaName string
+	err = s.db.QueryRow(r.Context(), `
+		WITH inserted AS (
+			INSERT INTO persona_edit_suggestions(persona_id, share_link_id, field, suggestion, author_name)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		)
+		SELECT inserted.id::text, inserted.created_at, p.name
+		FROM inserted
+		JOIN personas p ON p.id = $1
+	`, link.PersonaID, link.LinkID, field, suggestion, authorName).Scan(&out.ID, &out.CreatedAt, &personaName)
+	if err != nil {
+		writeInternalError(w, "could not save suggestion")
+		return
+	}
+
+	_ = s.notifyPersonaEditSuggested(r.Context(), link.OwnerUserID, link.PersonaID, personaName, out.ID, authorName)
+
+	writeJSON(w, http.StatusCreated, map[string]any{"suggestion": out})
+}
+
+// handlePreviewSharedPersona runs the owner's preview for a link holder. It
+// spends the persona's own preview quota, so a link cannot preview more
+// than the owner could.
+func (s *Server) handlePreviewSharedPersona(w http.ResponseWriter, r *http.Request) {
+	link := shareLinkFromRequest(r)
+	s.writePersonaPreview(w, r, link.OwnerUserID, link.PersonaID, map[string]any{
+		"share_link_id": link.LinkID,
+	})
+}
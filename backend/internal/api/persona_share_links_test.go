@@ -0,0 +1,105 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/safety"
+)
+
+func TestShareLinkRequestNormalize(t *testing.T) {
+	scopes, label, lifetime, err := shareLinkRequest{
+		Scopes: []string{" View_Calibration ", "run_preview", "view_calibration"},
+		Label:  "  For   Ana ",
+	}.normalize()
+	if err != nil {
+		t.Fatalf("expected valid request, got %v", err)
+	}
+	if strings.Join(scopes, ",") != "view_calibration,run_preview" {
+		t.Fatalf("unexpected scopes %v", scopes)
+	}
+	if label != "For Ana" || lifetime != shareLinkDefaultHours*time.Hour {
+		t.Fatalf("unexpected label %q or lifetime %s", label, lifetime)
+	}
+
+	invalid := []shareLinkRequest{
+		{},
+		{Scopes: []string{"manage"}},
+		{Scopes: []string{"suggest_edits"}, ExpiresInHours: -1},
+		{Scopes: []string{"suggest_edits"}, ExpiresInHours: shareLinkMaxHours + 1},
+		{Scopes: []string{"suggest_edits"}, Label: strings.Repeat("l", shareLinkLabelMaxLen+1)},
+	}
+	for _, req := range invalid {
+		if _, _, _, err := req.normalize(); err == nil {
+			t.Fatalf("expected %+v to be rejected", req)
+		}
+	}
+}
+
+func TestShareLinkTokensAreHashed(t *testing.T) {
+	token, err := newShareLinkToken()
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	other, _ := newShareLinkToken()
+	if token == other || len(token) < 40 {
+		t.Fatalf("expected distinct random tokens, got %q and %q", token, other)
+	}
+	hash := hashShareLinkToken(token)
+	if hash == token || hash != hashShareLinkToken(token) || len(hash) != 64 {
+		t.Fatalf("unexpected token hash %q", hash)
+	}
+}
+
+func TestShareLinkStatus(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Hour)
+	if got := shareLinkStatus(now.Add(time.Hour), nil, now); got != shareLinkStatusActive {
+		t.Fatalf("expected active, got %s", got)
+	}
+	if got := shareLinkStatus(now, nil, now); got != shareLinkStatusExpired {
+		t.Fatalf("expected expired, got %s", got)
+	}
+	if got := shareLinkStatus(now.Add(time.Hour), &revokedAt, now); got != shareLinkStatusRevoked {
+		t.Fatalf("expected revoked, got %s", got)
+	}
+}
+
+func TestValidateSuggestionInput(t *testing.T) {
+	field, suggestion, name, err := validateSuggestionInput(safety.DefaultRules(), " Tone ", "  Warmer openings, fewer questions.  ", "  Ana   K. ")
+	if err != nil {
+		t.Fatalf("expected valid suggestion, got %v", err)
+	}
+	if field != "tone" || suggestion != "Warmer openings, fewer questions." || name != "Ana K." {
+		t.Fatalf("unexpected normalized input %q / %q / %q", field, suggestion, name)
+	}
+	if field, _, _, err := validateSuggestionInput(safety.DefaultRules(), "", "Looks good overall.", ""); err != nil || field != "general" {
+		t.Fatalf("expected general field, got %q (%v)", field, err)
+	}
+
+	if _, _, _, err := validateSuggestionInput(safety.DefaultRules(), "daily_draft_quota", "More drafts", ""); err == nil {
+		t.Fatalf("expected unknown field to fail")
+	}
+	if _, _, _, err := validateSuggestionInput(safety.DefaultRules(), "bio", "   ", ""); err == nil {
+		t.Fatalf("expected empty suggestion to fail")
+	}
+	if _, _, _, err := validateSuggestionInput(safety.DefaultRules(), "bio", strings.Repeat("a", suggestionMaxLen+1), ""); err == nil {
+		t.Fatalf("expected long suggestion to fail")
+	}
+	if _, _, _, err := validateSuggestionInput(safety.DefaultRules(), "bio", "Shorter bio", strings.Repeat("n", suggestionAuthorMaxLen+1)); err == nil {
+		t.Fatalf("expected long author name to fail")
+	}
+}
+
+func TestValidateSuggestionStatus(t *testing.T) {
+	if got, err := validateSuggestionStatus(""); err != nil || got != suggestionStatusPending {
+		t.Fatalf("expected default pending, got %q (%v)", got, err)
+	}
+	if got, err := validateSuggestionStatus("dismissed"); err != nil || got != suggestionStatusDismissed {
+		t.Fatalf("expected dismissed, got %q (%v)", got, err)
+	}
+	if _, err := validateSuggestionStatus("applied"); err == nil {
+		t.Fatalf("expected unknown status to fail")
+	}
+}
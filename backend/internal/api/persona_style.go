@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+
+	"personaworlds/backend/internal/ai"
+)
+
+const (
+	personaStyleMin     = 0
+	personaStyleMax     = 100
+	personaStyleDefault = 50
+)
+
+// PersonaStyle is the persona's continuous style vector. Each slider runs from
+// 0 (none) to 100 and complements the coarser formality level.
+type PersonaStyle struct {
+	Humor         int `json:"humor"`
+	Assertiveness int `json:"assertiveness"`
+	Technicality  int `json:"technicality"`
+	Brevity       int `json:"brevity"`
+}
+
+func defaultPersonaStyle() PersonaStyle {
+	return PersonaStyle{
+		Humor:         personaStyleDefault,
+		Assertiveness: personaStyleDefault,
+		Technicality:  personaStyleDefault,
+		Brevity:       personaStyleDefault,
+	}
+}
+
+func (s PersonaStyle) validate() error {
+	for _, slider := range []struct {
+		name  string
+		value int
+	}{
+		{"humor", s.Humor},
+		{"assertiveness", s.Assertiveness},
+		{"technicality", s.Technicality},
+		{"brevity", s.Brevity},
+	} {
+		if slider.value < personaStyleMin || slider.value > personaStyleMax {
+			return fmt.Errorf("style.%s must be between %d and %d", slider.name, personaStyleMin, personaStyleMax)
+		}
+	}
+	return nil
+}
+
+func (s PersonaStyle) toAI() ai.PersonaStyle {
+	return ai.PersonaStyle{
+		Humor:         s.Humor,
+		Assertiveness: s.Assertiveness,
+		Technicality:  s.Technicality,
+		Brevity:       s.Brevity,
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import "testing"
+
+func TestPersonaUpsertRequestNormalizedStyle(t *testing.T) {
+	style, err := personaUpsertRequest{}.normalizedStyle()
+	if err != nil || style != nil {
+		t.Fatalf("expected omitted style to stay nil, got %+v err=%v", style, err)
+	}
+
+	req := personaUpsertRequest{Style: &PersonaStyle{Humor: 0, Assertiveness: 100, Technicality: 55, Brevity: 20}}
+	style, err = req.normalizedStyle()
+	if err != nil || style == nil || *style != *req.Style {
+		t.Fatalf("expected valid style to pass through, got %+v err=%v", style, err)
+	}
+
+	for _, invalid := range []PersonaStyle{
+		{Humor: -1, Assertiveness: 50, Technicality: 50, Brevity: 50},
+		{Humor: 50, Assertiveness: 101, Technicality: 50, Brevity: 50},
+		{Humor: 50, Assertiveness: 50, Technicality: 50, Brevity: 500},
+	} {
+		if _, err := (personaUpsertRequest{Style: &invalid}).normalizedStyle(); err == nil {
+			t.Fatalf("expected %+v to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	battlePropositionMaxLen   = 180
+	linkedBattlesPerPostLimit = 10
+)
+
+type LinkedBattle struct {
+	BattleID  string    `json:"battle_id"`
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// normalizeBattleProposition cleans an LLM proposition down to one line that
+// fits the battle topic limits. An empty result means the caller should fall
+// back to a topic derived from the post itself.
+func normalizeBattleProposition(raw string) string {
+	clean := strings.Trim(strings.Join(strings.Fields(raw), " "), "\"'“”` ")
+	if strings.HasPrefix(strings.ToLower(clean), "topic:") {
+		clean = strings.Trim(clean[len("topic:"):], "\"'“”` ")
+	}
+	clean = common.TruncateRunes(clean, battlePropositionMaxLen)
+	if len([]rune(clean)) < 3 {
+		return ""
+	}
+	return clean
+}
+
+func (s *Server) handleCreateBattleFromPost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !s.userBattleLimiter.allow("battle:"+strings.TrimSpace(userID), time.Now()) {
+		s.writeRateLimitResponse(w, r, "user", "battle_create", "battle creation rate limit exceeded")
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		TemplateID string `json:"template_id"`
+		ProStyle   string `json:"pro_style"`
+		ConStyle   string `json:"con_style"`
+	}
+	if err := decodeJSONAllowEmpty(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	templateID := strings.TrimSpace(req.TemplateID)
+	if templateID != "" {
+		templateID, err = validateUUID(templateID, "template id")
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+	proStyle, conStyle := normalizeBattleStyles(req.ProStyle, req.ConStyle)
+	limitState, limited, err := s.battleLimit(r.Context(), userID, time.Now().UTC())
+	if err != nil {
+		writeInternalError(w, "could not check battle limit")
+		return
+	}
+	if limited {
+		// Escalations have no queue option; they start from a live thread.
+		s.recordRateLimited(r, "user", "battle_create_daily")
+		writeBattleLimitResponse(w, limitState)
+		return
+	}
+
+	var (
+		sourceRoomID  string
+		sourceStatus  string
+		sourceContent string
+	)
+	err = s.db.QueryRow(r.Context(), `
+		SELECT room_id::text, status::text, content
+		FROM posts
+		WHERE id = $1
+	`, postID).Scan(&sourceRoomID, &sourceStatus, &sourceContent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not load post")
+		return
+	}
+	if sourceStatus != "PUBLISHED" {
+		writeConflict(w, "only published posts can start a battle")
+		return
+	}
+	if _, err := s.authorizeBattleRead(r, postID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+
+	room, err := s.getRoomByID(r.Context(), sourceRoomID)
+	if err == nil && room.MergedIntoRoomID != "" {
+		// Posts still waiting to be moved by a merge start their battle in
+		// the room they are moving to.
+		room, err = s.getRoomByID(r.Context(), room.MergedIntoRoomID)
+	}
+	if err != nil {
+		writeInternalError(w, "could not load room")
+		return
+	}
+	if room.ArchivedAt != nil {
+		writeConflict(w, "room is archived")
+		return
+	}
+	if err := roomEventWriteError(room.Event); err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
+
+	template, err := s.resolveBattleTemplate(r.Context(), templateID, userID)
+	if err != nil {
+		writeBattleTemplateError(w, templateID, err)
+		return
+	}
+
+	topic, topicSource := s.proposeBattleTopic(r.Context(), postID, sourceContent, room)
+	topic, err = validateTopic(topic, 3, battlePropositionMaxLen)
+	if err != nil {
+		writeBadRequest(w, "could not derive a battle topic from this post")
+		return
+	}
+
+	out, err := s.insertBattlePost(r.Context(), s.db, userID, room.ID, topic, template, proStyle, conStyle, postID, common.BattleLanguage{Mode: common.BattleLanguageAuto})
+	if err != nil {
+		writeInternalError(w, "could not create battle")
+		return
+	}
+
+	enqueuedReplies := s.enqueueBattleReplies(r.Context(), userID, out.ID, template, requestIDFromRequest(r))
+	_ = s.notifyTemplateUsed(r.Context(), userID, template, out.ID)
+	_ = s.logEventFromRequest(r, eventBattleCreated, map[string]any{
+		"battle_id":      out.ID,
+		"room_id":        room.ID,
+		"template_id":    template.ID,
+		"source_post_id": postID,
+		"topic_source":   topicSource,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"battle_id":          out.ID,
+		"post":               out,
+		"topic":              topic,
+		"topic_source":       topicSource,
+		"source_post_id":     postID,
+		"room_name":          room.Name,
+		"template":           template,
+		"enqueued_replies":   enqueuedReplies,
+		"suggested_next_url": fmt.Sprintf("/b/%s", out.ID),
+	})
+}
+
+// proposeBattleTopic asks the LLM for a debatable proposition and falls back
+// to the post's first sentence so an LLM outage never blocks escalation.
+func (s *Server) proposeBattleTopic(ctx context.Context, postID, content string, room Room) (string, string) {
+	s.syncPrompts(ctx)
+	raw, err := s.llm.ProposeBattleTopic(ctx, ai.PostContext{ID: postID, Content: content}, ai.RoomContext{
+		ID:          room.ID,
+		Name:        room.Name,
+		Description: room.Description,
+	})
+	if err == nil {
+		if topic := normalizeBattleProposition(raw); topic != "" {
+			return topic, "llm"
+		}
+	}
+	return buildBattleCardTopic(content, ""), "fallback"
+}
+
+func (s *Server) listLinkedBattles(ctx context.Context, postID string) ([]LinkedBattle, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			id::text,
+			regexp_replace(split_part(content, E'\n', 1), '^Topic:\s*', ''),
+			created_at
+		FROM posts
+		WHERE source_post_id = $1
+		  AND status = 'PUBLISHED'
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, postID, linkedBattlesPerPostLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	battles := make([]LinkedBattle, 0)
+	for rows.Next() {
+		var battle LinkedBattle
+		if err := rows.Scan(&battle.BattleID, &battle.Topic, &battle.CreatedAt); err != nil {
+			return nil, err
+		}
+		battles = append(battles, battle)
+	}
+	return battles, rows.Err()
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeBattleProposition(t *testing.T) {
+	if got := normalizeBattleProposition("  \"Topic: Remote work beats\n the office.\"  "); got != "Remote work beats the office." {
+		t.Fatalf("expected cleaned proposition, got %q", got)
+	}
+	if got := normalizeBattleProposition(" '' "); got != "" {
+		t.Fatalf("expected empty proposition to signal fallback, got %q", got)
+	}
+	long := normalizeBattleProposition(strings.Repeat("debate ", 60))
+	if len([]rune(long)) > battlePropositionMaxLen {
+		t.Fatalf("expected proposition capped at %d runes, got %d", battlePropositionMaxLen, len([]rune(long)))
+	}
+}
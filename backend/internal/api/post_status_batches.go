@@ -0,0 +1,297 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Batches up to this size are applied inside the request; larger ones
+	// are queued for the worker.
+	postStatusSyncMaxPosts = 25
+	postStatusMaxPosts     = 1000
+)
+
+type PostStatusBatch struct {
+	ID             int64      `json:"id"`
+	PersonaID      string     `json:"persona_id"`
+	Action         string     `json:"action"`
+	Status         string     `json:"status"`
+	TotalPosts     int        `json:"total_posts"`
+	ProcessedPosts int        `json:"processed_posts"`
+	ChangedPosts   int        `json:"changed_posts"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+type postStatusRequest struct {
+	Action  string   `json:"action"`
+	PostIDs []string `json:"post_ids"`
+	All     bool     `json:"all"`
+}
+
+// normalize checks the action and dedupes post_ids. With all set, post_ids
+// must be empty; the posts are picked when the batch is created.
+func (r *postStatusRequest) normalize() error {
+	r.Action = strings.ToLower(strings.TrimSpace(r.Action))
+	if r.Action != common.PostStatusUnpublish && r.Action != common.PostStatusRepublish {
+		return errors.New("action must be unpublish or republish")
+	}
+	if r.All {
+		if len(r.PostIDs) > 0 {
+			return errors.New("send either post_ids or all, not both")
+		}
+		return nil
+	}
+
+	postIDs := make([]string, 0, len(r.PostIDs))
+	seen := map[string]struct{}{}
+	for _, raw := range r.PostIDs {
+		postID, err := validateUUID(raw, "post id")
+		if err != nil {
+			return err
+		}
+		// Postgres prints uuids in lowercase; compare them the same way.
+		postID = strings.ToLower(postID)
+		if _, exists := seen[postID]; exists {
+			continue
+		}
+		seen[postID] = struct{}{}
+		postIDs = append(postIDs, postID)
+	}
+	if len(postIDs) == 0 {
+		return errors.New("post_ids is required")
+	}
+	if len(postIDs) > postStatusMaxPosts {
+		return errors.New("post_ids can list at most 1000 posts")
+	}
+	r.PostIDs = postIDs
+	return nil
+}
+
+func (s *Server) handleBulkUpdatePostStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req postStatusRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if err := req.normalize(); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.getPersonaByID(r.Context(), userID, personaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+
+	postIDs, err := s.eligibleStatusChangePosts(r.Context(), personaID, req)
+	if err != nil {
+		writeInternalError(w, "could not load posts")
+		return
+	}
+	if !req.All && len(postIDs) != len(req.PostIDs) {
+		writeBadRequest(w, "post_ids must be posts of this persona")
+		return
+	}
+	if len(postIDs) == 0 {
+		writeBadRequest(w, "no posts to "+req.Action)
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var batch PostStatusBatch
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO post_status_batches(persona_id, requested_by, action, post_ids, total_posts)
+		VALUES ($1, $2, $3, $4::uuid[], $5)
+		RETURNING id, persona_id::text, action, status, total_posts, processed_posts, changed_posts, created_at, updated_at, completed_at
+	`, personaID, userID, req.Action, postIDs, len(postIDs)).Scan(
+		&batch.ID,
+		&batch.PersonaID,
+		&batch.Action,
+		&batch.Status,
+		&batch.TotalPosts,
+		&batch.ProcessedPosts,
+		&batch.ChangedPosts,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&batch.CompletedAt,
+	)
+	if err != nil {
+		writeInternalError(w, "could not queue post status change")
+		return
+	}
+
+	status := http.StatusAccepted
+	if len(postIDs) <= postStatusSyncMaxPosts {
+		changed, err := common.ApplyPostStatusChange(r.Context(), tx, personaID, req.Action, batch.ID, postIDs)
+		if err != nil {
+			writeInternalError(w, "could not change post status")
+			return
+		}
+		err = tx.QueryRow(r.Context(), `
+			UPDATE post_status_batches
+			SET processed_posts = total_posts,
+				changed_posts = $2,
+				status = $3,
+				completed_at = NOW(),
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING status, processed_posts, changed_posts, updated_at, completed_at
+		`, batch.ID, changed, common.PostStatusBatchDone).Scan(
+			&batch.Status,
+			&batch.ProcessedPosts,
+			&batch.ChangedPosts,
+			&batch.UpdatedAt,
+			&batch.CompletedAt,
+		)
+		if err != nil {
+			writeInternalError(w, "could not change post status")
+			return
+		}
+		status = http.StatusOK
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not commit post status change")
+		return
+	}
+	if batch.Status == common.PostStatusBatchDone {
+		s.battleCardCache.invalidate(postIDs...)
+	}
+
+	s.logger.Info("post_status_batch_created", observability.Fields{
+		"batch_id":    batch.ID,
+		"persona_id":  personaID,
+		"action":      batch.Action,
+		"total_posts": batch.TotalPosts,
+		"status":      batch.Status,
+		"user_id":     userID,
+		"request_id":  requestIDFromRequest(r),
+	})
+
+	writeJSON(w, status, map[string]any{"batch": batch})
+}
+
+// eligibleStatusChangePosts returns the posts of personaID the request can
+// address. For post_ids that is every listed post of the persona, whatever
+// its state, so a mistyped id is reported instead of silently skipped. With
+// all it is the oldest postStatusMaxPosts posts the action would change.
+func (s *Server) eligibleStatusChangePosts(ctx context.Context, personaID string, req postStatusRequest) ([]string, error) {
+	query := `
+		SELECT id::text
+		FROM posts
+		WHERE persona_id = $1
+		  AND id = ANY($2::uuid[])
+		ORDER BY created_at ASC, id ASC
+	`
+	args := []any{personaID, req.PostIDs}
+	if req.All {
+		query = `
+			SELECT id::text
+			FROM posts
+			WHERE persona_id = $1
+			  AND (
+				($2 = 'unpublish' AND status = 'PUBLISHED')
+				OR ($2 = 'republish' AND status = 'DRAFT' AND unpublished_at IS NOT NULL)
+			  )
+			ORDER BY created_at ASC, id ASC
+			LIMIT $3
+		`
+		args = []any{personaID, req.Action, postStatusMaxPosts}
+	}
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	postIDs := []string{}
+	for rows.Next() {
+		var postID string
+		if err := rows.Scan(&postID); err != nil {
+			return nil, err
+		}
+		postIDs = append(postIDs, postID)
+	}
+	return postIDs, rows.Err()
+}
+
+func (s *Server) handleGetPostStatusBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	batchID, err := strconv.ParseInt(strings.TrimSpace(chi.URLParam(r, "batchID")), 10, 64)
+	if err != nil || batchID <= 0 {
+		writeBadRequest(w, "invalid batch id")
+		return
+	}
+
+	var batch PostStatusBatch
+	err = s.db.QueryRow(r.Context(), `
+		SELECT b.id, b.persona_id::text, b.action, b.status, b.total_posts, b.processed_posts, b.changed_posts, b.created_at, b.updated_at, b.completed_at
+		FROM post_status_batches b
+		JOIN personas p ON p.id = b.persona_id
+		WHERE b.id = $1
+		  AND b.persona_id = $2
+		  AND p.user_id = $3
+	`, batchID, personaID, userID).Scan(
+		&batch.ID,
+		&batch.PersonaID,
+		&batch.Action,
+		&batch.Status,
+		&batch.TotalPosts,
+		&batch.ProcessedPosts,
+		&batch.ChangedPosts,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&batch.CompletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "batch not found")
+			return
+		}
+		writeInternalError(w, "could not load batch")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"batch": batch})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostStatusRequestNormalize(t *testing.T) {
+	postID := "6f1f7c7e-4a4b-4f55-9d4a-0c8f2b5d1e11"
+	req := postStatusRequest{Action: " Unpublish ", PostIDs: []string{postID, strings.ToUpper(postID), postID}}
+	if err := req.normalize(); err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if req.Action != "unpublish" || len(req.PostIDs) != 1 {
+		t.Fatalf("expected one deduped unpublish, got %+v", req)
+	}
+
+	for _, bad := range []postStatusRequest{
+		{Action: "delete", PostIDs: []string{postID}},
+		{Action: "republish"},
+		{Action: "republish", PostIDs: []string{"not-a-uuid"}},
+		{Action: "republish", PostIDs: []string{postID}, All: true},
+	} {
+		if err := bad.normalize(); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+
+	all := postStatusRequest{Action: "republish", All: true}
+	if err := all.normalize(); err != nil {
+		t.Fatalf("expected all to be accepted, got %v", err)
+	}
+}
+
+func TestBattleCardCacheInvalidate(t *testing.T) {
+	cache := newBattleCardCache(10)
+	cache.set("battle-1|classic|1", []byte("a"))
+	cache.set("battle-1-t2|1", []byte("b"))
+	cache.set("battle-10|classic|1", []byte("c"))
+	cache.set("battle-2|classic|1", []byte("d"))
+
+	cache.invalidate("battle-1")
+
+	for _, key := range []string{"battle-1|classic|1", "battle-1-t2|1"} {
+		if _, ok := cache.get(key); ok {
+			t.Fatalf("expected %s to be dropped", key)
+		}
+	}
+	for _, key := range []string{"battle-10|classic|1", "battle-2|classic|1"} {
+		if _, ok := cache.get(key); !ok {
+			t.Fatalf("expected %s to stay cached", key)
+		}
+	}
+	if len(cache.order) != 2 {
+		t.Fatalf("expected eviction order to drop invalidated keys, got %v", cache.order)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// postVisibilityRequest changes where a published post is listed. Omitted
+// fields keep their current value.
+type postVisibilityRequest struct {
+	HiddenFromProfile *bool `json:"hidden_from_profile"`
+	HiddenFromRooms   *bool `json:"hidden_from_rooms"`
+}
+
+func (r postVisibilityRequest) validate() error {
+	if r.HiddenFromProfile == nil && r.HiddenFromRooms == nil {
+		return errors.New("hidden_from_profile or hidden_from_rooms is required")
+	}
+	return nil
+}
+
+type PostVisibility struct {
+	PostID            string `json:"post_id"`
+	HiddenFromProfile bool   `json:"hidden_from_profile"`
+	HiddenFromRooms   bool   `json:"hidden_from_rooms"`
+}
+
+// handleUpdatePostVisibility lets the owner keep a published post off the
+// persona's public profile, off room listings and feeds, or both. The post
+// itself stays readable by id either way.
+func (s *Server) handleUpdatePostVisibility(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req postVisibilityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	visibility, err := s.setPostVisibility(r.Context(), userID, postID, req)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not update post visibility")
+		return
+	}
+
+	s.logger.Info("post_visibility_changed", observability.Fields{
+		"post_id":             postID,
+		"hidden_from_profile": visibility.HiddenFromProfile,
+		"hidden_from_rooms":   visibility.HiddenFromRooms,
+		"user_id":             userID,
+		"request_id":          requestIDFromRequest(r),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"visibility": visibility})
+}
+
+// setPostVisibility updates a published post owned by userID and returns
+// the resulting flags.
+func (s *Server) setPostVisibility(ctx context.Context, userID, postID string, req postVisibilityRequest) (PostVisibility, error) {
+	visibility := PostVisibility{PostID: postID}
+	err := s.db.QueryRow(ctx, `
+		UPDATE posts
+		SET hidden_from_profile = COALESCE($3, hidden_from_profile),
+			hidden_from_rooms = COALESCE($4, hidden_from_rooms),
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = 'PUBLISHED'
+		RETURNING hidden_from_profile, hidden_from_rooms
+	`, postID, userID, req.HiddenFromProfile, req.HiddenFromRooms).Scan(&visibility.HiddenFromProfile, &visibility.HiddenFromRooms)
+	return visibility, err
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPostVisibilityRequestValidate(t *testing.T) {
+	var req postVisibilityRequest
+	if err := json.Unmarshal([]byte(`{"hidden_from_profile":false}`), &req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if err := req.validate(); err != nil {
+		t.Fatalf("expected an explicit false to be accepted, got %v", err)
+	}
+	if req.HiddenFromRooms != nil {
+		t.Fatalf("expected omitted hidden_from_rooms to stay unset")
+	}
+
+	if err := (postVisibilityRequest{}).validate(); err == nil {
+		t.Fatalf("expected an error when no flag is given")
+	}
+}
@@ -23,6 +23,7 @@ type integrationFixtureOptions struct {
 	defaultPreviewQuota int
 	dailyDraftQuota     int
 	dailyReplyQuota     int
+	calibrationKeys     string
 }
 
 type integrationFixture struct {
@@ -69,6 +70,7 @@ func newIntegrationFixture(t *testing.T, opts integrationFixtureOptions) integra
 	cfg.JWTSecret = "privacy-quota-test-secret"
 	cfg.DefaultPreviewQuota = previewQuota
 	cfg.MigrationsDir = migrationDirForTests(t)
+	cfg.CalibrationKeys = opts.calibrationKeys
 
 	if err := db.RunMigrations(ctx, pool, cfg.MigrationsDir); err != nil {
 		t.Fatalf("migrations failed: %v", err)
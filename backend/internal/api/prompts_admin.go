@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return "", false
+	}
+
+	admin, err := s.userIsAdmin(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load user")
+		return "", false
+	}
+	if !admin {
+		writeForbidden(w, "admin access required")
+		return "", false
+	}
+	return userID, true
+}
+
+func isAdminEmail(adminEmails []string, email string) bool {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return false
+	}
+	for _, admin := range adminEmails {
+		if strings.EqualFold(strings.TrimSpace(admin), email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) loadPromptPins(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT operation, version
+		FROM prompt_versions
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := map[string]string{}
+	for rows.Next() {
+		var operation, version string
+		if err := rows.Scan(&operation, &version); err != nil {
+			return nil, err
+		}
+		pins[operation] = version
+	}
+	return pins, rows.Err()
+}
+
+func (s *Server) syncPrompts(ctx context.Context) {
+	if err := s.llm.Prompts().Sync(ctx, s.cfg.PromptSyncEvery, s.loadPromptPins); err != nil {
+		s.logger.Warn("prompt_sync_failed", observability.Fields{"error": err.Error()})
+	}
+}
+
+func (s *Server) handleListPromptVersions(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+
+	pins, err := s.loadPromptPins(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not load prompt versions")
+		return
+	}
+	skipped := s.llm.Prompts().SetPins(pins)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"prompts":      s.llm.Prompts().Status(),
+		"ignored_pins": skipped,
+	})
+}
+
+func (s *Server) handleSetPromptVersion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	operation := strings.TrimSpace(chi.URLParam(r, "operation"))
+
+	var req struct {
+		Version string `json:"version"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	version := strings.TrimSpace(req.Version)
+	registry := s.llm.Prompts()
+	if registry.Active(operation) == "" {
+		writeNotFound(w, "prompt operation not found")
+		return
+	}
+	if !registry.HasVersion(operation, version) {
+		writeBadRequest(w, "unknown prompt version")
+		return
+	}
+
+	previous := registry.Active(operation)
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO prompt_versions(operation, version, updated_by, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (operation)
+		DO UPDATE SET
+			version = EXCLUDED.version,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, operation, version, userID); err != nil {
+		writeInternalError(w, "could not save prompt version")
+		return
+	}
+
+	s.writePromptVersionChange(w, r, operation, previous, userID)
+}
+
+func (s *Server) handleResetPromptVersion(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	operation := strings.TrimSpace(chi.URLParam(r, "operation"))
+	registry := s.llm.Prompts()
+	if registry.Active(operation) == "" {
+		writeNotFound(w, "prompt operation not found")
+		return
+	}
+
+	previous := registry.Active(operation)
+	if _, err := s.db.Exec(r.Context(), `
+		DELETE FROM prompt_versions
+		WHERE operation = $1
+	`, operation); err != nil {
+		writeInternalError(w, "could not reset prompt version")
+		return
+	}
+
+	s.writePromptVersionChange(w, r, operation, previous, userID)
+}
+
+func (s *Server) writePromptVersionChange(w http.ResponseWriter, r *http.Request, operation, previous, userID string) {
+	pins, err := s.loadPromptPins(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not load prompt versions")
+		return
+	}
+	registry := s.llm.Prompts()
+	registry.SetPins(pins)
+	active := registry.Active(operation)
+
+	s.logger.Info("prompt_version_changed", observability.Fields{
+		"operation":        operation,
+		"previous_version": previous,
+		"active_version":   active,
+		"user_id":          userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"operation":        operation,
+		"previous_version": previous,
+		"active_version":   active,
+	})
+}
@@ -13,6 +13,7 @@ type PublicPersonaProfileDTO struct {
 	IsPublic          bool      `json:"is_public"`
 	Followers         int       `json:"followers"`
 	PostsCount        int       `json:"posts_count"`
+	ViewCount         int64     `json:"view_count"`
 	Badges            []string  `json:"badges"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -23,6 +24,7 @@ type PublicPostDTO struct {
 	RoomName   string    `json:"room_name"`
 	AuthoredBy string    `json:"authored_by"`
 	Content    string    `json:"content"`
+	Question   string    `json:"question,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -33,14 +35,40 @@ type PublicRoomStatDTO struct {
 }
 
 type PublicBattleMetaDTO struct {
-	BattleID  string `json:"battle_id"`
-	RoomID    string `json:"room_id"`
-	RoomName  string `json:"room_name"`
-	Topic     string `json:"topic"`
-	CreatedAt string `json:"created_at"`
-	Template  any    `json:"template,omitempty"`
-	ShareURL  string `json:"share_url"`
-	CardURL   string `json:"card_url"`
+	BattleID    string                  `json:"battle_id"`
+	RoomID      string                  `json:"room_id"`
+	RoomName    string                  `json:"room_name"`
+	Topic       string                  `json:"topic"`
+	CreatedAt   string                  `json:"created_at"`
+	ViewCount   int64                   `json:"view_count"`
+	Visibility  string                  `json:"visibility"`
+	Template    any                     `json:"template,omitempty"`
+	ShareURL    string                  `json:"share_url"`
+	CardURL     string                  `json:"card_url"`
+	CardVariant string                  `json:"card_variant"`
+	Turn        *PublicBattleTurnDTO    `json:"turn,omitempty"`
+	Verdict     *PublicBattleVerdictDTO `json:"verdict,omitempty"`
+	Votes       []BattleVoteTally       `json:"votes"`
+	MyVote      string                  `json:"my_vote,omitempty"`
+}
+
+// PublicBattleVerdictDTO is the verdict and takeaways a share page shows.
+// Language is what Verdict and Takeaways are written in; when they were
+// translated, Original holds the text in SourceLanguage. TakeawayTurns
+// lines up with Takeaways and lists the turns each one cites.
+type PublicBattleVerdictDTO struct {
+	Verdict        string                          `json:"verdict"`
+	Takeaways      []string                        `json:"takeaways"`
+	TakeawayTurns  [][]int                         `json:"takeaway_turns"`
+	Language       string                          `json:"language"`
+	SourceLanguage string                          `json:"source_language"`
+	Translated     bool                            `json:"translated"`
+	Original       *PublicBattleVerdictOriginalDTO `json:"original,omitempty"`
+}
+
+type PublicBattleVerdictOriginalDTO struct {
+	Verdict   string   `json:"verdict"`
+	Takeaways []string `json:"takeaways"`
 }
 
 func mapPublicProfileDTO(profile PublicPersonaProfile) PublicPersonaProfileDTO {
@@ -57,6 +85,7 @@ func mapPublicProfileDTO(profile PublicPersonaProfile) PublicPersonaProfileDTO {
 		IsPublic:          profile.IsPublic,
 		Followers:         profile.Followers,
 		PostsCount:        profile.PostsCount,
+		ViewCount:         profile.ViewCount,
 		Badges:            badges,
 		CreatedAt:         profile.CreatedAt,
 	}
@@ -71,6 +100,7 @@ func mapPublicPostsDTO(posts []PublicPost) []PublicPostDTO {
 			RoomName:   post.RoomName,
 			AuthoredBy: post.AuthoredBy,
 			Content:    post.Content,
+			Question:   post.Question,
 			CreatedAt:  post.CreatedAt,
 		})
 	}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	publicEmbedPostLimit    = 3
+	publicEmbedSnippetRunes = 160
+	publicEmbedBioRunes     = 280
+	publicEmbedMaxAge       = 300
+)
+
+// PublicProfileEmbedDTO is the trimmed profile third-party sites render in a
+// profile widget. It carries nothing the public profile page does not show.
+type PublicProfileEmbedDTO struct {
+	Slug        string                  `json:"slug"`
+	Name        string                  `json:"name"`
+	Bio         string                  `json:"bio"`
+	Badges      []string                `json:"badges"`
+	Followers   int                     `json:"followers"`
+	ProfileURL  string                  `json:"profile_url"`
+	LatestPosts []PublicEmbedPostDTO    `json:"latest_posts"`
+	PoweredBy   PublicEmbedPoweredByDTO `json:"powered_by"`
+}
+
+type PublicEmbedPostDTO struct {
+	ID        string    `json:"id"`
+	RoomName  string    `json:"room_name"`
+	Snippet   string    `json:"snippet"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PublicEmbedPoweredByDTO struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// publicEmbedCORSMiddleware lets any site read the response. The global CORS
+// handler only answers configured origins and allows credentials, so the
+// embed drops credentials and opens the origin instead; the payload never
+// depends on who asks.
+func publicEmbedCORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", "*")
+		header.Del("Access-Control-Allow-Credentials")
+		header.Set("Access-Control-Expose-Headers", "ETag")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func buildPublicProfileEmbed(profile PublicPersonaProfile, posts []PublicPost, frontendOrigin string) PublicProfileEmbedDTO {
+	origin := strings.TrimRight(frontendOrigin, "/")
+	badges := make([]string, len(profile.Badges))
+	copy(badges, profile.Badges)
+
+	latest := make([]PublicEmbedPostDTO, 0, publicEmbedPostLimit)
+	for _, post := range posts {
+		if len(latest) == publicEmbedPostLimit {
+			break
+		}
+		latest = append(latest, PublicEmbedPostDTO{
+			ID:        post.ID,
+			RoomName:  post.RoomName,
+			Snippet:   common.TruncateRunes(cards.NormalizeText(post.Content), publicEmbedSnippetRunes),
+			CreatedAt: post.CreatedAt.UTC(),
+		})
+	}
+
+	return PublicProfileEmbedDTO{
+		Slug:        profile.Slug,
+		Name:        profile.Name,
+		Bio:         common.TruncateRunes(strings.TrimSpace(profile.Bio), publicEmbedBioRunes),
+		Badges:      badges,
+		Followers:   profile.Followers,
+		ProfileURL:  fmt.Sprintf("%s/p/%s", origin, url.PathEscape(profile.Slug)),
+		LatestPosts: latest,
+		PoweredBy: PublicEmbedPoweredByDTO{
+			Name: "Persona Worlds",
+			URL:  origin,
+		},
+	}
+}
+
+// publicEmbedETag is a strong validator over the encoded payload, so any
+// change to the widget's content changes the tag.
+func publicEmbedETag(payload PublicProfileEmbedDTO) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf(`"embed-%s"`, hex.EncodeToString(sum[:12])), nil
+}
+
+// handleGetPublicProfileEmbed serves the widget payload of a public profile.
+// Embeds are not counted as profile views.
+func (s *Server) handleGetPublicProfileEmbed(w http.ResponseWriter, r *http.Request) {
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
+	if slug == "" {
+		writeNotFound(w, "public profile not found")
+		return
+	}
+
+	profile, _, err := s.getPublicProfileBySlug(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "/embed.json") {
+				return
+			}
+			writeNotFound(w, "public profile not found")
+			return
+		}
+		writeInternalError(w, "could not load public profile")
+		return
+	}
+
+	posts, _, err := s.listPublishedPostsForPersona(r.Context(), profile.PersonaID, "", publicEmbedPostLimit)
+	if err != nil {
+		writeInternalError(w, "could not load public posts")
+		return
+	}
+
+	payload := buildPublicProfileEmbed(profile, posts, s.cfg.FrontendOrigin)
+	etag, err := publicEmbedETag(payload)
+	if err != nil {
+		writeInternalError(w, "could not build embed")
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", publicEmbedMaxAge))
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
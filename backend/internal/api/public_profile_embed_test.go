@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/config"
+)
+
+func TestBuildPublicProfileEmbedTrimsPayload(t *testing.T) {
+	profile := PublicPersonaProfile{
+		PersonaID: "persona-1",
+		Slug:      "ada",
+		Name:      "Ada",
+		Bio:       "  " + strings.Repeat("b", 400) + "  ",
+		Tone:      "dry",
+		Followers: 42,
+		ViewCount: 1000,
+		Badges:    []string{"Public Persona", "EN"},
+	}
+	posts := make([]PublicPost, 0, 5)
+	for i := 0; i < 5; i++ {
+		posts = append(posts, PublicPost{
+			ID:        string(rune('a' + i)),
+			RoomName:  "Tech",
+			Content:   "First line\n\n" + strings.Repeat("word ", 80),
+			CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600)),
+		})
+	}
+
+	embed := buildPublicProfileEmbed(profile, posts, "https://personaworlds.example/")
+	if embed.ProfileURL != "https://personaworlds.example/p/ada" {
+		t.Fatalf("unexpected profile url %q", embed.ProfileURL)
+	}
+	if embed.PoweredBy.URL != "https://personaworlds.example" {
+		t.Fatalf("unexpected powered-by url %q", embed.PoweredBy.URL)
+	}
+	if len(embed.LatestPosts) != publicEmbedPostLimit {
+		t.Fatalf("expected %d posts, got %d", publicEmbedPostLimit, len(embed.LatestPosts))
+	}
+	snippet := embed.LatestPosts[0].Snippet
+	if strings.Contains(snippet, "\n") || len([]rune(snippet)) > publicEmbedSnippetRunes {
+		t.Fatalf("expected a flattened, truncated snippet, got %q", snippet)
+	}
+	if embed.LatestPosts[0].CreatedAt.Location() != time.UTC {
+		t.Fatalf("expected UTC timestamps")
+	}
+	if len([]rune(embed.Bio)) > publicEmbedBioRunes || strings.HasPrefix(embed.Bio, " ") {
+		t.Fatalf("expected trimmed bio, got %d runes", len([]rune(embed.Bio)))
+	}
+	if embed.Followers != 42 || len(embed.Badges) != 2 {
+		t.Fatalf("unexpected embed %+v", embed)
+	}
+
+	etag, err := publicEmbedETag(embed)
+	if err != nil {
+		t.Fatalf("etag: %v", err)
+	}
+	embed.Followers++
+	changed, err := publicEmbedETag(embed)
+	if err != nil {
+		t.Fatalf("etag: %v", err)
+	}
+	if etag == changed {
+		t.Fatalf("expected a follower change to change the etag")
+	}
+}
+
+func TestPublicProfileEmbedAllowsAnyOrigin(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWTSecret = "embed-test-secret"
+	cfg.CORSAllowedOrigins = []string{"https://personaworlds.example"}
+
+	server := New(cfg, nil, ai.NewMockClient())
+	router := server.Router()
+
+	for _, origin := range []string{"https://blog.example", "https://personaworlds.example"} {
+		req := httptest.NewRequest(http.MethodGet, "/p/---/embed.json", nil)
+		req.Header.Set("Origin", origin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for an empty slug, got %d", recorder.Code)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Fatalf("expected any origin to be allowed for %s, got %q", origin, got)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Fatalf("expected no credentials for %s, got %q", origin, got)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -52,7 +53,8 @@ func (s *Server) getPublicProfileBySlug(ctx context.Context, slug string) (Publi
 			pp.is_public,
 			pp.created_at,
 			COALESCE((SELECT COUNT(*)::int FROM persona_follows f WHERE f.followed_persona_id = p.id), 0),
-			COALESCE((SELECT COUNT(*)::int FROM posts ps WHERE ps.persona_id = p.id AND ps.status = 'PUBLISHED'), 0)
+			COALESCE((SELECT COUNT(*)::int FROM posts ps WHERE ps.persona_id = p.id AND ps.status = 'PUBLISHED' AND ps.visibility = 'public' AND NOT ps.hidden_from_profile), 0),
+			pp.view_count
 		FROM persona_public_profiles pp
 		JOIN personas p ON p.id = pp.persona_id
 		WHERE pp.slug = $1
@@ -70,6 +72,7 @@ func (s *Server) getPublicProfileBySlug(ctx context.Context, slug string) (Publi
 		&profile.CreatedAt,
 		&profile.Followers,
 		&profile.PostsCount,
+		&profile.ViewCount,
 	)
 	if err != nil {
 		return PublicPersonaProfile{}, "", err
@@ -92,11 +95,14 @@ func (s *Server) listPublishedPostsForPersona(ctx context.Context, personaID, cu
 	)
 	if strings.TrimSpace(cursor) == "" {
 		rows, err = s.db.Query(ctx, `
-			SELECT p.id::text, p.room_id::text, COALESCE(r.name, ''), p.authored_by::text, p.content, p.created_at
+			SELECT p.id::text, p.room_id::text, COALESCE(r.name, ''), p.authored_by::text, p.content, COALESCE(q.question, ''), p.created_at
 			FROM posts p
 			LEFT JOIN rooms r ON r.id = p.room_id
+			LEFT JOIN persona_questions q ON q.answer_post_id = p.id
 			WHERE p.persona_id = $1
 			  AND p.status = 'PUBLISHED'
+			  AND p.visibility = 'public'
+			  AND NOT p.hidden_from_profile
 			ORDER BY p.created_at DESC, p.id DESC
 			LIMIT $2
 		`, personaID, limit)
@@ -106,11 +112,14 @@ func (s *Server) listPublishedPostsForPersona(ctx context.Context, personaID, cu
 			return nil, "", fmt.Errorf("invalid cursor")
 		}
 		rows, err = s.db.Query(ctx, `
-			SELECT p.id::text, p.room_id::text, COALESCE(r.name, ''), p.authored_by::text, p.content, p.created_at
+			SELECT p.id::text, p.room_id::text, COALESCE(r.name, ''), p.authored_by::text, p.content, COALESCE(q.question, ''), p.created_at
 			FROM posts p
 			LEFT JOIN rooms r ON r.id = p.room_id
+			LEFT JOIN persona_questions q ON q.answer_post_id = p.id
 			WHERE p.persona_id = $1
 			  AND p.status = 'PUBLISHED'
+			  AND p.visibility = 'public'
+			  AND NOT p.hidden_from_profile
 			  AND (p.created_at < $2 OR (p.created_at = $2 AND p.id < $3::uuid))
 			ORDER BY p.created_at DESC, p.id DESC
 			LIMIT $4
@@ -124,7 +133,7 @@ func (s *Server) listPublishedPostsForPersona(ctx context.Context, personaID, cu
 	posts := make([]PublicPost, 0, limit)
 	for rows.Next() {
 		var post PublicPost
-		if err := rows.Scan(&post.ID, &post.RoomID, &post.RoomName, &post.AuthoredBy, &post.Content, &post.CreatedAt); err != nil {
+		if err := rows.Scan(&post.ID, &post.RoomID, &post.RoomName, &post.AuthoredBy, &post.Content, &post.Question, &post.CreatedAt); err != nil {
 			return nil, "", err
 		}
 		posts = append(posts, post)
@@ -155,6 +164,8 @@ func (s *Server) listTopRoomsForPersona(ctx context.Context, personaID string, l
 		JOIN rooms r ON r.id = p.room_id
 		WHERE p.persona_id = $1
 		  AND p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_profile
 		GROUP BY r.id, r.name
 		ORDER BY post_count DESC, r.name ASC
 		LIMIT $2
@@ -179,7 +190,7 @@ func (s *Server) listTopRoomsForPersona(ctx context.Context, personaID string, l
 }
 
 func (s *Server) ensureUniquePublicProfileSlug(ctx context.Context, baseSlug, personaID string) (string, error) {
-	base := normalizePublicSlug(baseSlug)
+	base := s.normalizeSlug(baseSlug)
 	if base == "" {
 		base = "persona"
 	}
@@ -206,8 +217,31 @@ func (s *Server) ensureUniquePublicProfileSlug(ctx context.Context, baseSlug, pe
 	return "", fmt.Errorf("could not allocate slug")
 }
 
+var slugTransliterations = map[rune]string{
+	'ç': "c", 'ğ': "g", 'ı': "i", 'ö': "o", 'ş': "s", 'ü': "u",
+	'â': "a", 'î': "i", 'û': "u", 'ä': "a", 'à': "a", 'á': "a",
+	'å': "a", 'æ': "ae", 'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'ï': "i", 'ñ': "n", 'ó': "o", 'ò': "o",
+	'ô': "o", 'ø': "o", 'œ': "oe", 'ú': "u", 'ù': "u", 'ß': "ss",
+}
+
+func (s *Server) normalizeSlug(value string) string {
+	if s.cfg.UnicodeSlugs {
+		return normalizeUnicodeSlug(value)
+	}
+	return normalizePublicSlug(value)
+}
+
 func normalizePublicSlug(value string) string {
-	raw := strings.ToLower(strings.TrimSpace(value))
+	return normalizeSlugValue(value, false)
+}
+
+func normalizeUnicodeSlug(value string) string {
+	return normalizeSlugValue(value, true)
+}
+
+func normalizeSlugValue(value string, allowUnicode bool) string {
+	raw := strings.TrimSpace(value)
 	if raw == "" {
 		return ""
 	}
@@ -215,6 +249,7 @@ func normalizePublicSlug(value string) string {
 	var b strings.Builder
 	prevDash := false
 	for _, r := range raw {
+		r = unicode.ToLower(r)
 		isASCIIAlpha := r >= 'a' && r <= 'z'
 		isASCIIDigit := r >= '0' && r <= '9'
 		if isASCIIAlpha || isASCIIDigit {
@@ -223,6 +258,17 @@ func normalizePublicSlug(value string) string {
 			continue
 		}
 
+		if allowUnicode && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			prevDash = false
+			continue
+		}
+		if replacement, ok := slugTransliterations[r]; ok {
+			b.WriteString(replacement)
+			prevDash = false
+			continue
+		}
+
 		if unicode.IsSpace(r) || r == '-' || r == '_' {
 			if !prevDash && b.Len() > 0 {
 				b.WriteRune('-')
@@ -232,12 +278,47 @@ func normalizePublicSlug(value string) string {
 	}
 
 	slug := strings.Trim(b.String(), "-")
-	if len(slug) > 64 {
-		slug = strings.Trim(slug[:64], "-")
+	if runes := []rune(slug); len(runes) > 64 {
+		slug = strings.Trim(string(runes[:64]), "-")
 	}
 	return slug
 }
 
+func (s *Server) resolvePublicSlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	var currentSlug string
+	err := s.db.QueryRow(ctx, `
+		SELECT pp.slug
+		FROM persona_slug_redirects r
+		JOIN persona_public_profiles pp ON pp.persona_id = r.persona_id
+		WHERE r.old_slug = $1
+		  AND pp.is_public = TRUE
+		  AND pp.slug <> r.old_slug
+	`, oldSlug).Scan(&currentSlug)
+	return strings.TrimSpace(currentSlug), err
+}
+
+func (s *Server) redirectRenamedPublicProfile(w http.ResponseWriter, r *http.Request, oldSlug, suffix string) bool {
+	currentSlug, err := s.resolvePublicSlugRedirect(r.Context(), oldSlug)
+	if err != nil || currentSlug == "" {
+		return false
+	}
+
+	location := "/p/" + url.PathEscape(currentSlug) + suffix
+	if rawQuery := strings.TrimSpace(r.URL.RawQuery); rawQuery != "" {
+		location += "?" + rawQuery
+	}
+	status := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		status = http.StatusPermanentRedirect
+	}
+	w.Header().Set("Location", location)
+	writeJSON(w, status, map[string]any{
+		"error": "public profile moved",
+		"slug":  currentSlug,
+	})
+	return true
+}
+
 func buildPublicProfileBadges(profile PublicPersonaProfile) []string {
 	badges := []string{"Public Persona"}
 
@@ -0,0 +1,39 @@
+package api
+
+import "testing"
+
+func TestNormalizePublicSlugTransliteratesTurkish(t *testing.T) {
+	cases := map[string]string{
+		"Gölge Yazar":         "golge-yazar",
+		"İstanbul Şehir":      "istanbul-sehir",
+		"Çağrı_Işık":          "cagri-isik",
+		"  Straße -- Müller ": "strasse-muller",
+		"東京":                  "",
+	}
+	for input, want := range cases {
+		if got := normalizePublicSlug(input); got != want {
+			t.Fatalf("normalizePublicSlug(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeUnicodeSlugKeepsLetters(t *testing.T) {
+	if got := normalizeUnicodeSlug("Gölge Yazar"); got != "gölge-yazar" {
+		t.Fatalf("expected gölge-yazar, got %q", got)
+	}
+	if got := normalizeUnicodeSlug("東京 Room"); got != "東京-room" {
+		t.Fatalf("expected 東京-room, got %q", got)
+	}
+}
+
+func TestValidateSlugRespectsUnicodeMode(t *testing.T) {
+	if got, err := validateSlug("Gölge Yazar", false); err != nil || got != "golge-yazar" {
+		t.Fatalf("expected golge-yazar, got %q (%v)", got, err)
+	}
+	if got, err := validateSlug("Gölge Yazar", true); err != nil || got != "gölge-yazar" {
+		t.Fatalf("expected gölge-yazar, got %q (%v)", got, err)
+	}
+	if _, err := validateSlug("東京", false); err == nil {
+		t.Fatalf("expected ascii mode to reject non-latin slug")
+	}
+}
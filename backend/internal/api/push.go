@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/webpush"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const pushEndpointMaxLen = 2048
+
+type PushPreferences struct {
+	BattleCompleted bool `json:"battle_completed"`
+	NewFollower     bool `json:"new_follower"`
+}
+
+func validatePushEndpoint(raw string) (string, error) {
+	clean := strings.TrimSpace(raw)
+	if clean == "" {
+		return "", errors.New("endpoint is required")
+	}
+	if len(clean) > pushEndpointMaxLen {
+		return "", errors.New("endpoint is too long")
+	}
+	parsed, err := url.Parse(clean)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", errors.New("endpoint must be an https URL")
+	}
+	return clean, nil
+}
+
+func (s *Server) requireWebPush(w http.ResponseWriter) bool {
+	if !s.cfg.WebPushEnabled() {
+		writeError(w, http.StatusServiceUnavailable, "web push is not configured")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handlePushPublicKey(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireUserID(w, r); !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"enabled":    s.cfg.WebPushEnabled(),
+		"public_key": s.cfg.VAPIDPublicKey,
+	})
+}
+
+func (s *Server) handlePushSubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !s.requireWebPush(w) {
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	endpoint, err := validatePushEndpoint(req.Endpoint)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	p256dh := strings.TrimSpace(req.Keys.P256dh)
+	auth := strings.TrimSpace(req.Keys.Auth)
+	if err := webpush.ValidateSubscriptionKeys(p256dh, auth); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	// A browser endpoint belongs to whoever subscribed last, so a shared
+	// device that switches accounts stops receiving the previous user's pushes.
+	var subscriptionID string
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO push_subscriptions(user_id, endpoint, p256dh, auth, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (endpoint)
+		DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			user_agent = EXCLUDED.user_agent,
+			failure_count = 0,
+			updated_at = NOW()
+		RETURNING id::text
+	`, userID, endpoint, p256dh, auth, common.TruncateRunes(strings.TrimSpace(r.UserAgent()), auditUserAgentMaxRunes)).Scan(&subscriptionID)
+	if err != nil {
+		writeInternalError(w, "could not save push subscription")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"subscription_id": subscriptionID,
+	})
+}
+
+func (s *Server) handlePushUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	endpoint, err := validatePushEndpoint(req.Endpoint)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM push_subscriptions
+		WHERE endpoint = $1
+		  AND user_id = $2
+	`, endpoint, userID)
+	if err != nil {
+		writeInternalError(w, "could not delete push subscription")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deleted": ct.RowsAffected() > 0,
+	})
+}
+
+func (s *Server) handleGetPushPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	prefs, err := s.loadPushPreferences(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load push preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (s *Server) handleUpdatePushPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		BattleCompleted *bool `json:"battle_completed"`
+		NewFollower     *bool `json:"new_follower"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	prefs, err := s.loadPushPreferences(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load push preferences")
+		return
+	}
+	if req.BattleCompleted != nil {
+		prefs.BattleCompleted = *req.BattleCompleted
+	}
+	if req.NewFollower != nil {
+		prefs.NewFollower = *req.NewFollower
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO push_preferences(user_id, battle_completed, new_follower)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			battle_completed = EXCLUDED.battle_completed,
+			new_follower = EXCLUDED.new_follower,
+			updated_at = NOW()
+	`, userID, prefs.BattleCompleted, prefs.NewFollower); err != nil {
+		writeInternalError(w, "could not save push preferences")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"preferences": prefs,
+	})
+}
+
+func (s *Server) loadPushPreferences(ctx context.Context, userID string) (PushPreferences, error) {
+	prefs := PushPreferences{BattleCompleted: true, NewFollower: true}
+	err := s.db.QueryRow(ctx, `
+		SELECT battle_completed, new_follower
+		FROM push_preferences
+		WHERE user_id = $1
+	`, userID).Scan(&prefs.BattleCompleted, &prefs.NewFollower)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return PushPreferences{}, err
+	}
+	return prefs, nil
+}
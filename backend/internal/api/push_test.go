@@ -0,0 +1,17 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePushEndpoint(t *testing.T) {
+	if got, err := validatePushEndpoint(" https://fcm.googleapis.com/fcm/send/abc "); err != nil || got != "https://fcm.googleapis.com/fcm/send/abc" {
+		t.Fatalf("expected trimmed endpoint, got %q (%v)", got, err)
+	}
+	for _, endpoint := range []string{"", "http://push.example/abc", "https://", "https://push.example/" + strings.Repeat("a", pushEndpointMaxLen)} {
+		if _, err := validatePushEndpoint(endpoint); err == nil {
+			t.Fatalf("expected %q to be rejected", endpoint)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	errBattleQueueFull      = errors.New("battle queue is full")
+	errBattleQueueNoPersona = errors.New("create a persona before queueing battles")
+)
+
+// BattleLimit is the state of a user's daily battle limit. AvailableAt is when
+// the window resets; it is only set once the limit is reached.
+type BattleLimit struct {
+	Limit             int        `json:"limit"`
+	Used              int        `json:"used"`
+	AvailableAt       *time.Time `json:"available_at,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+}
+
+// QueuedBattle is a battle request waiting for the next daily window.
+// Position counts from 1 across the user's queue; StartsAt estimates when the
+// worker creates it, assuming the queue gets each new window's full limit.
+// Created battles carry BattleID, failed ones Error.
+type QueuedBattle struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	RoomID      string     `json:"room_id"`
+	RoomName    string     `json:"room_name"`
+	Topic       string     `json:"topic"`
+	Mode        string     `json:"mode"`
+	TemplateID  string     `json:"template_id,omitempty"`
+	Position    int        `json:"position,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	BattleID    string     `json:"battle_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+}
+
+func battleLimitState(limit, used int, now time.Time) (BattleLimit, bool) {
+	state := BattleLimit{Limit: limit, Used: used}
+	if limit <= 0 || used < limit {
+		return state, false
+	}
+	reset := common.NextBattleWindowReset(now)
+	state.AvailableAt = &reset
+	retry := int(reset.Sub(now).Seconds())
+	if retry < 1 {
+		retry = 1
+	}
+	state.RetryAfterSeconds = retry
+	return state, true
+}
+
+// queuedBattleStartsAt estimates when the battle at position runs: each
+// window after now's takes the next limit battles of the queue.
+func queuedBattleStartsAt(now time.Time, position, limit int) time.Time {
+	reset := common.NextBattleWindowReset(now)
+	if position <= 0 || limit <= 0 {
+		return reset
+	}
+	return reset.AddDate(0, 0, (position-1)/limit)
+}
+
+func (s *Server) battleLimit(ctx context.Context, userID string, now time.Time) (BattleLimit, bool, error) {
+	limit, err := common.BattleDailyLimit(ctx, s.db, userID, s.cfg.BattleDailyLimit)
+	if err != nil || limit <= 0 {
+		return BattleLimit{}, false, err
+	}
+	used, err := common.BattlesCreatedToday(ctx, s.db, userID)
+	if err != nil {
+		return BattleLimit{}, false, err
+	}
+	state, limited := battleLimitState(limit, used, now)
+	return state, limited, nil
+}
+
+func writeBattleLimitResponse(w http.ResponseWriter, state BattleLimit) {
+	w.Header().Set("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{
+		"error": "daily battle limit reached",
+		"code":  "battle_limit",
+		"limit": state,
+	})
+}
+
+// queueBattle stores a validated battle request and schedules its
+// create_battle job for the next daily window. The queue holds at most one
+// day's limit.
+func (s *Server) queueBattle(r *http.Request, userID string, plan battleCreatePlan, limit int, now time.Time) (QueuedBattle, error) {
+	jobPersonaID := plan.AIPersonaID
+	if jobPersonaID == "" && len(plan.SidePersonaIDs) > 0 {
+		jobPersonaID = plan.SidePersonaIDs[0]
+	}
+	if jobPersonaID == "" {
+		return QueuedBattle{}, errBattleQueueNoPersona
+	}
+
+	ctx := r.Context()
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return QueuedBattle{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize queueing per user so two requests cannot both take the last
+	// queue slot.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "battle-queue:"+userID); err != nil {
+		return QueuedBattle{}, err
+	}
+	var queued int
+	if err := tx.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM queued_battles
+		WHERE user_id = $1
+		  AND status = $2
+	`, userID, common.QueuedBattleQueued).Scan(&queued); err != nil {
+		return QueuedBattle{}, err
+	}
+	if queued >= limit {
+		return QueuedBattle{}, errBattleQueueFull
+	}
+
+	var sourceBattleID any
+	if plan.SourceBattleID != "" {
+		sourceBattleID = plan.SourceBattleID
+	}
+	out := QueuedBattle{
+		Status:     common.QueuedBattleQueued,
+		RoomID:     plan.Room.ID,
+		RoomName:   plan.Room.Name,
+		Topic:      plan.Topic,
+		Mode:       plan.Mode,
+		TemplateID: plan.Template.ID,
+		Position:   queued + 1,
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO queued_battles(
+			user_id, room_id, template_id, mode, ai_persona_id, topic, pro_style, con_style,
+			battle_language, battle_language_code, source_battle_id, context_pack
+		)
+		VALUES ($1, $2, $3::uuid, $4, NULLIF($5, '')::uuid, $6, $7, $8, $9, NULLIF($10, ''), $11::uuid, $12)
+		RETURNING id, created_at
+	`, userID, plan.Room.ID, plan.Template.ID, plan.Mode, plan.AIPersonaID, plan.Topic, plan.ProStyle, plan.ConStyle,
+		plan.Language.Mode, plan.Language.Language, sourceBattleID, plan.ContextPack).Scan(&out.ID, &out.RequestedAt)
+	if err != nil {
+		return QueuedBattle{}, err
+	}
+
+	payload, err := json.Marshal(common.QueuedBattleJobPayload{
+		QueuedBattleID: out.ID,
+		TraceID:        strings.TrimSpace(requestIDFromRequest(r)),
+	})
+	if err != nil {
+		return QueuedBattle{}, err
+	}
+	startsAt := queuedBattleStartsAt(now, out.Position, limit)
+	if _, err := tx.Exec(ctx, `
+		WITH job AS (
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ($1, NULL, $2, $3::jsonb, 'PENDING', $4)
+			RETURNING id
+		)
+		UPDATE queued_battles
+		SET job_id = (SELECT id FROM job)
+		WHERE id = $5
+	`, common.JobCreateBattle, jobPersonaID, payload, startsAt, out.ID); err != nil {
+		return QueuedBattle{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return QueuedBattle{}, err
+	}
+	out.StartsAt = &startsAt
+	return out, nil
+}
+
+// handleQueueLimitedBattle answers a battle request over the daily limit:
+// queued when the request asked for it, rejected otherwise.
+func (s *Server) handleQueueLimitedBattle(w http.ResponseWriter, r *http.Request, userID string, plan battleCreatePlan, state BattleLimit, now time.Time) {
+	if !plan.Queue {
+		s.recordRateLimited(r, "user", "battle_create_daily")
+		writeBattleLimitResponse(w, state)
+		return
+	}
+	queued, err := s.queueBattle(r, userID, plan, state.Limit, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, errBattleQueueFull):
+			w.Header().Set("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+			writeJSON(w, http.StatusTooManyRequests, map[string]any{
+				"error": err.Error(),
+				"code":  "battle_queue_full",
+				"limit": state,
+			})
+		case errors.Is(err, errBattleQueueNoPersona):
+			writeConflict(w, err.Error())
+		default:
+			writeInternalError(w, "could not queue battle")
+		}
+		return
+	}
+
+	s.logger.Info("battle_queued", observability.Fields{
+		"queued_battle_id": queued.ID,
+		"user_id":          userID,
+		"room_id":          plan.Room.ID,
+		"position":         queued.Position,
+		"request_id":       requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"queued":        true,
+		"queued_battle": queued,
+		"limit":         state,
+	})
+}
+
+// handleListQueuedBattles returns the queue in order, with the battles it
+// created or failed to create in the last day.
+func (s *Server) handleListQueuedBattles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	state, _, err := s.battleLimit(r.Context(), userID, now)
+	if err != nil {
+		writeInternalError(w, "could not load battle queue")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			qb.id,
+			qb.status,
+			qb.room_id::text,
+			COALESCE(rm.name, ''),
+			qb.topic,
+			qb.mode,
+			COALESCE(qb.template_id::text, ''),
+			COALESCE(qb.battle_id::text, ''),
+			qb.error,
+			qb.created_at
+		FROM queued_battles qb
+		LEFT JOIN rooms rm ON rm.id = qb.room_id
+		WHERE qb.user_id = $1
+		  AND (
+				qb.status = $2
+				OR (qb.status IN ($3, $4) AND qb.updated_at >= NOW() - INTERVAL '24 hours')
+		  )
+		ORDER BY qb.created_at ASC, qb.id ASC
+	`, userID, common.QueuedBattleQueued, common.QueuedBattleCreated, common.QueuedBattleFailed)
+	if err != nil {
+		writeInternalError(w, "could not load battle queue")
+		return
+	}
+	defer rows.Close()
+
+	queue := make([]QueuedBattle, 0)
+	position := 0
+	for rows.Next() {
+		var item QueuedBattle
+		if err := rows.Scan(&item.ID, &item.Status, &item.RoomID, &item.RoomName, &item.Topic, &item.Mode, &item.TemplateID, &item.BattleID, &item.Error, &item.RequestedAt); err != nil {
+			writeInternalError(w, "could not scan queued battle")
+			return
+		}
+		if item.Status == common.QueuedBattleQueued {
+			position++
+			item.Position = position
+			startsAt := queuedBattleStartsAt(now, position, state.Limit)
+			item.StartsAt = &startsAt
+		}
+		queue = append(queue, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load battle queue")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"queue": queue,
+		"limit": state,
+	})
+}
+
+// handleCancelQueuedBattle takes a battle out of the queue before the worker
+// creates it. Battles behind it move up one position.
+func (s *Server) handleCancelQueuedBattle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	queuedID, err := strconv.ParseInt(strings.TrimSpace(chi.URLParam(r, "id")), 10, 64)
+	if err != nil || queuedID <= 0 {
+		writeBadRequest(w, "invalid queued battle id")
+		return
+	}
+
+	var jobID *int64
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE queued_battles
+		SET status = $3,
+			updated_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = $4
+		RETURNING job_id
+	`, queuedID, userID, common.QueuedBattleCanceled, common.QueuedBattleQueued).Scan(&jobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"queued_battle_id": queuedID,
+				"removed":          false,
+			})
+			return
+		}
+		writeInternalError(w, "could not cancel queued battle")
+		return
+	}
+	if jobID != nil {
+		// A job the worker already claimed sees the cancellation and stops.
+		if _, err := s.db.Exec(r.Context(), `
+			DELETE FROM jobs
+			WHERE id = $1
+			  AND status IN ('PENDING', 'FAILED')
+		`, *jobID); err != nil {
+			writeInternalError(w, "could not cancel queued battle")
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"queued_battle_id": queuedID,
+		"removed":          true,
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBattleLimitState(t *testing.T) {
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	if _, limited := battleLimitState(10, 9, now); limited {
+		t.Fatal("expected the tenth battle to be allowed")
+	}
+	if _, limited := battleLimitState(0, 50, now); limited {
+		t.Fatal("expected a zero limit to disable the check")
+	}
+	state, limited := battleLimitState(10, 10, now)
+	if !limited {
+		t.Fatal("expected the eleventh battle to be limited")
+	}
+	reset := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	if state.AvailableAt == nil || !state.AvailableAt.Equal(reset) {
+		t.Fatalf("expected reset at %s, got %v", reset, state.AvailableAt)
+	}
+	if state.RetryAfterSeconds != 2*60*60 {
+		t.Fatalf("expected two hours until reset, got %d", state.RetryAfterSeconds)
+	}
+}
+
+func TestQueuedBattleStartsAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	reset := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		position int
+		limit    int
+		want     time.Time
+	}{
+		{position: 1, limit: 10, want: reset},
+		{position: 10, limit: 10, want: reset},
+		{position: 11, limit: 10, want: reset.AddDate(0, 0, 1)},
+		{position: 3, limit: 0, want: reset},
+	}
+	for _, tc := range cases {
+		if got := queuedBattleStartsAt(now, tc.position, tc.limit); !got.Equal(tc.want) {
+			t.Fatalf("position %d of limit %d: expected %s, got %s", tc.position, tc.limit, tc.want, got)
+		}
+	}
+}
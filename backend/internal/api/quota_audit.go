@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const quotaReconciliationHistory = 14
+
+type QuotaEventRecord struct {
+	ID        int64          `json:"id"`
+	QuotaType string         `json:"quota_type"`
+	Source    string         `json:"source"`
+	ContentID string         `json:"content_id,omitempty"`
+	Metadata  map[string]any `json:"metadata"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type QuotaReconciliation struct {
+	Day           string     `json:"day"`
+	Discrepancies int        `json:"discrepancies"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+}
+
+type QuotaDiscrepancy struct {
+	PersonaID   string `json:"persona_id"`
+	PersonaName string `json:"persona_name"`
+	QuotaType   string `json:"quota_type"`
+	Recorded    int    `json:"recorded"`
+	Actual      int    `json:"actual"`
+}
+
+// handleAdminListQuotaEvents pages through a persona's quota events, newest
+// first, with the source and content each one was spent on.
+func (s *Server) handleAdminListQuotaEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "id"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	cursor, limit, err := parseAuditLogPage(r.URL.Query().Get("cursor"), r.URL.Query().Get("limit"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	quotaType := strings.TrimSpace(r.URL.Query().Get("type"))
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id, quota_type, source, content_id, metadata, created_at
+		FROM quota_events
+		WHERE persona_id = $1
+		  AND ($2 = '' OR quota_type = $2)
+		  AND ($3::bigint = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, personaID, quotaType, cursor, limit)
+	if err != nil {
+		writeInternalError(w, "could not list quota events")
+		return
+	}
+	defer rows.Close()
+
+	events := make([]QuotaEventRecord, 0, limit)
+	for rows.Next() {
+		var (
+			event       QuotaEventRecord
+			metadataRaw []byte
+		)
+		if err := rows.Scan(&event.ID, &event.QuotaType, &event.Source, &event.ContentID, &metadataRaw, &event.CreatedAt); err != nil {
+			writeInternalError(w, "could not scan quota event")
+			return
+		}
+		event.Metadata = map[string]any{}
+		_ = json.Unmarshal(metadataRaw, &event.Metadata)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list quota events")
+		return
+	}
+
+	nextCursor := ""
+	if len(events) == limit {
+		nextCursor = strconv.FormatInt(events[len(events)-1].ID, 10)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"persona_id":  personaID,
+		"events":      events,
+		"next_cursor": nextCursor,
+	})
+}
+
+// handleAdminGetQuotaReconciliation reports the recent nightly
+// reconciliations and the discrepancies flagged for one day, by default the
+// latest one.
+func (s *Server) handleAdminGetQuotaReconciliation(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	day := strings.TrimSpace(r.URL.Query().Get("day"))
+	if day != "" {
+		if _, err := time.Parse(common.DateLayout, day); err != nil {
+			writeBadRequest(w, "day must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT to_char(day, 'YYYY-MM-DD'), discrepancies, started_at, completed_at
+		FROM quota_reconciliations
+		ORDER BY day DESC
+		LIMIT $1
+	`, quotaReconciliationHistory)
+	if err != nil {
+		writeInternalError(w, "could not load reconciliations")
+		return
+	}
+	defer rows.Close()
+
+	runs := make([]QuotaReconciliation, 0, quotaReconciliationHistory)
+	for rows.Next() {
+		var run QuotaReconciliation
+		if err := rows.Scan(&run.Day, &run.Discrepancies, &run.StartedAt, &run.CompletedAt); err != nil {
+			writeInternalError(w, "could not scan reconciliation")
+			return
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load reconciliations")
+		return
+	}
+	if day == "" && len(runs) > 0 {
+		day = runs[0].Day
+	}
+
+	discrepancies := make([]QuotaDiscrepancy, 0)
+	if day != "" {
+		discrepancies, err = s.listQuotaDiscrepancies(r, day)
+		if err != nil {
+			writeInternalError(w, "could not load discrepancies")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"reconciliations": runs,
+		"day":             day,
+		"discrepancies":   discrepancies,
+	})
+}
+
+func (s *Server) listQuotaDiscrepancies(r *http.Request, day string) ([]QuotaDiscrepancy, error) {
+	rows, err := s.db.Query(r.Context(), `
+		SELECT d.persona_id::text, COALESCE(p.name, ''), d.quota_type, d.recorded, d.actual
+		FROM quota_discrepancies d
+		LEFT JOIN personas p ON p.id = d.persona_id
+		WHERE d.day = $1::date
+		ORDER BY ABS(d.recorded - d.actual) DESC, d.persona_id, d.quota_type
+	`, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discrepancies := make([]QuotaDiscrepancy, 0)
+	for rows.Next() {
+		var d QuotaDiscrepancy
+		if err := rows.Scan(&d.PersonaID, &d.PersonaName, &d.QuotaType, &d.Recorded, &d.Actual); err != nil {
+			return nil, err
+		}
+		discrepancies = append(discrepancies, d)
+	}
+	return discrepancies, rows.Err()
+}
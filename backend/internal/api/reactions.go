@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	reactionLike       = "like"
+	reactionInsightful = "insightful"
+	reactionDisagree   = "disagree"
+)
+
+type PostReactionCounts struct {
+	Like       int `json:"like"`
+	Insightful int `json:"insightful"`
+	Disagree   int `json:"disagree"`
+}
+
+// Weighted engagement used by feed scoring; insightful counts double because
+// it signals substance rather than agreement.
+func (c PostReactionCounts) score() int {
+	return c.Like + c.Insightful*2 + c.Disagree
+}
+
+func validateReaction(value string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case reactionLike, reactionInsightful, reactionDisagree:
+		return clean, nil
+	default:
+		return "", fmt.Errorf("reaction must be like, insightful or disagree")
+	}
+}
+
+func (s *Server) handleCreatePostReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Reaction string `json:"reaction"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	reaction, err := validateReaction(req.Reaction)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if !s.ensureReactablePost(w, r, postID) {
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		INSERT INTO post_reactions(post_id, user_id, reaction)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id, user_id, reaction) DO NOTHING
+	`, postID, userID, reaction)
+	if err != nil {
+		writeInternalError(w, "could not save reaction")
+		return
+	}
+	if ct.RowsAffected() > 0 {
+		_ = s.logEventFromRequest(r, eventPostReacted, map[string]any{
+			"post_id":  postID,
+			"reaction": reaction,
+		})
+	}
+
+	s.writePostReactions(w, r, postID, userID, ct.RowsAffected() > 0)
+}
+
+func (s *Server) handleDeletePostReaction(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	reaction, err := validateReaction(chi.URLParam(r, "reaction"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM post_reactions
+		WHERE post_id = $1
+		  AND user_id = $2
+		  AND reaction = $3
+	`, postID, userID, reaction)
+	if err != nil {
+		writeInternalError(w, "could not remove reaction")
+		return
+	}
+
+	s.writePostReactions(w, r, postID, userID, ct.RowsAffected() > 0)
+}
+
+func (s *Server) ensureReactablePost(w http.ResponseWriter, r *http.Request, postID string) bool {
+	var status string
+	err := s.db.QueryRow(r.Context(), `
+		SELECT status::text
+		FROM posts
+		WHERE id = $1
+	`, postID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return false
+		}
+		writeInternalError(w, "could not load post")
+		return false
+	}
+	if status != "PUBLISHED" {
+		writeConflict(w, "only published posts can receive reactions")
+		return false
+	}
+	if _, err := s.authorizeBattleRead(r, postID); err != nil {
+		writeBattleReadError(w, err)
+		return false
+	}
+	return true
+}
+
+func (s *Server) writePostReactions(w http.ResponseWriter, r *http.Request, postID, userID string, changed bool) {
+	counts, err := s.postReactionCounts(r.Context(), postID)
+	if err != nil {
+		writeInternalError(w, "could not load reactions")
+		return
+	}
+	viewerReactions, err := s.viewerPostReactions(r.Context(), postID, userID)
+	if err != nil {
+		writeInternalError(w, "could not load reactions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"post_id":          postID,
+		"changed":          changed,
+		"reactions":        counts,
+		"viewer_reactions": viewerReactions,
+	})
+}
+
+func (s *Server) postReactionCounts(ctx context.Context, postID string) (PostReactionCounts, error) {
+	var counts PostReactionCounts
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE reaction = 'like')::int,
+			COUNT(*) FILTER (WHERE reaction = 'insightful')::int,
+			COUNT(*) FILTER (WHERE reaction = 'disagree')::int
+		FROM post_reactions
+		WHERE post_id = $1
+	`, postID).Scan(&counts.Like, &counts.Insightful, &counts.Disagree)
+	return counts, err
+}
+
+func (s *Server) viewerPostReactions(ctx context.Context, postID, userID string) ([]string, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT reaction
+		FROM post_reactions
+		WHERE post_id = $1
+		  AND user_id = $2
+		ORDER BY reaction ASC
+	`, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make([]string, 0, 3)
+	for rows.Next() {
+		var reaction string
+		if err := rows.Scan(&reaction); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
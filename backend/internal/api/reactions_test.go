@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateReaction(t *testing.T) {
+	if got, err := validateReaction(" Insightful "); err != nil || got != reactionInsightful {
+		t.Fatalf("expected insightful, got %q (%v)", got, err)
+	}
+	if _, err := validateReaction("love"); err == nil {
+		t.Fatalf("expected unsupported reaction to fail")
+	}
+}
+
+func TestReactionsBoostFeedScore(t *testing.T) {
+	now := time.Now()
+	createdAt := now.Add(-2 * time.Hour)
+	counts := PostReactionCounts{Like: 4, Insightful: 3, Disagree: 1}
+	if counts.score() != 11 {
+		t.Fatalf("expected weighted score 11, got %d", counts.score())
+	}
+
+	base := scoreTrendingBattle(now, createdAt, 1, 1, 0)
+	boosted := scoreTrendingBattle(now, createdAt, 1, 1, counts.score())
+	if boosted <= base {
+		t.Fatalf("expected reactions to raise score: base=%f boosted=%f", base, boosted)
+	}
+	if capped := reactionScoreBoost(10_000); capped != 25 {
+		t.Fatalf("expected reaction boost cap of 25, got %f", capped)
+	}
+}
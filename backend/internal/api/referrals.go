@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// referralCodeAlphabet leaves out 0/O and 1/I so codes survive being read
+	// aloud or copied by hand.
+	referralCodeAlphabet     = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referralCodeLength       = 8
+	referralCodeAttempts     = 5
+	referralRewardWindow     = 30 * 24 * time.Hour
+	referralSourceReferrer   = "referrer"
+	referralSourceReferred   = "referred"
+	referralCodeQueryName    = "ref"
+	referralBoostListMaxRows = 50
+)
+
+type QuotaBoost struct {
+	QuotaType string    `json:"quota_type"`
+	Amount    int       `json:"amount"`
+	Source    string    `json:"source"`
+	StartsAt  time.Time `json:"starts_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ReferralSummary struct {
+	Code          string         `json:"code"`
+	Link          string         `json:"link"`
+	ReferredCount int            `json:"referred_count"`
+	RewardedCount int            `json:"rewarded_count"`
+	ActiveBoosts  map[string]int `json:"active_boosts"`
+	Boosts        []QuotaBoost   `json:"boosts"`
+}
+
+func newReferralCode() (string, error) {
+	raw := make([]byte, referralCodeLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := make([]byte, referralCodeLength)
+	for i, b := range raw {
+		// 256 is a multiple of the 32-letter alphabet, so this is unbiased.
+		code[i] = referralCodeAlphabet[int(b)%len(referralCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// normalizeReferralCode accepts codes typed with lowercase letters, spaces or
+// dashes. Anything that cannot be a code normalizes to "".
+func normalizeReferralCode(raw string) string {
+	code := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(raw)))
+	if len(code) != referralCodeLength {
+		return ""
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(referralCodeAlphabet, r) {
+			return ""
+		}
+	}
+	return code
+}
+
+// ensureReferralCode returns the user's referral code, assigning one the
+// first time it is asked for.
+func (s *Server) ensureReferralCode(ctx context.Context, userID string) (string, error) {
+	for attempt := 0; attempt < referralCodeAttempts; attempt++ {
+		var existing *string
+		if err := s.db.QueryRow(ctx, `
+			SELECT referral_code
+			FROM users
+			WHERE id = $1
+		`, userID).Scan(&existing); err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return *existing, nil
+		}
+
+		code, err := newReferralCode()
+		if err != nil {
+			return "", err
+		}
+		// A concurrent request may assign a code first; the next attempt
+		// then reads it back.
+		_, err = s.db.Exec(ctx, `
+			UPDATE users
+			SET referral_code = $2
+			WHERE id = $1
+			  AND referral_code IS NULL
+		`, userID, code)
+		if err != nil && !isUniqueViolation(err) {
+			return "", err
+		}
+	}
+	return "", errors.New("could not assign a unique referral code")
+}
+
+func (s *Server) referralLink(code string) string {
+	return strings.TrimRight(s.cfg.FrontendOrigin, "/") + "/signup?" + referralCodeQueryName + "=" + url.QueryEscape(code)
+}
+
+// attributeReferral records that userID signed up with a referral code and
+// grants both users their quota boosts. It returns the reason when no boosts
+// were granted; signup itself never fails because of the code.
+func (s *Server) attributeReferral(r *http.Request, userID, rawCode string) (string, error) {
+	code := normalizeReferralCode(rawCode)
+	if code == "" {
+		return "invalid", nil
+	}
+
+	ctx := r.Context()
+	var referrerUserID string
+	err := s.db.QueryRow(ctx, `
+		SELECT id::text
+		FROM users
+		WHERE referral_code = $1
+	`, code).Scan(&referrerUserID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "invalid", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if referrerUserID == userID {
+		return "invalid", nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize rewards per referrer so concurrent signups cannot both slip
+	// under the reward cap.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "referral:"+referrerUserID); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	rewarded := s.cfg.ReferralBoostTTL > 0
+	if rewarded && s.cfg.ReferralMaxRewards > 0 {
+		var recent int
+		if err := tx.QueryRow(ctx, `
+			SELECT COUNT(*)::int
+			FROM referrals
+			WHERE referrer_user_id = $1
+			  AND rewarded
+			  AND created_at > $2
+		`, referrerUserID, now.Add(-referralRewardWindow)).Scan(&recent); err != nil {
+			return "", err
+		}
+		rewarded = recent < s.cfg.ReferralMaxRewards
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO referrals(referred_user_id, referrer_user_id, code, ip_hash, rewarded, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+	`, userID, referrerUserID, code, viewerKey("", requestClientIP(r), s.cfg.JWTSecret), rewarded, now)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "duplicate_ip", nil
+	}
+
+	if rewarded {
+		if err := s.grantReferralBoosts(ctx, tx, referrerUserID, userID, now); err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+
+	_ = s.insertEvent(ctx, userID, eventSignupFromReferral, map[string]any{
+		"source":           "referral_code",
+		"referrer_user_id": referrerUserID,
+		"rewarded":         rewarded,
+	})
+	if !rewarded {
+		return "reward_cap_reached", nil
+	}
+	return "", nil
+}
+
+func (s *Server) grantReferralBoosts(ctx context.Context, tx pgx.Tx, referrerUserID, referredUserID string, now time.Time) error {
+	for _, recipient := range []struct {
+		userID string
+		source string
+	}{
+		{userID: referrerUserID, source: referralSourceReferrer},
+		{userID: referredUserID, source: referralSourceReferred},
+	} {
+		for quotaType, amount := range map[string]int{
+			common.QuotaBoostDraft:  s.cfg.ReferralBoostDrafts,
+			common.QuotaBoostBattle: s.cfg.ReferralBoostBattles,
+		} {
+			if amount <= 0 {
+				continue
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO quota_boosts(user_id, quota_type, amount, source, referred_user_id, starts_at, expires_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, recipient.userID, quotaType, amount, recipient.source, referredUserID, now, now.Add(s.cfg.ReferralBoostTTL)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) logReferralNotRewarded(r *http.Request, userID, reason string) {
+	s.logger.Info("referral_not_rewarded", observability.Fields{
+		"request_id": requestIDFromRequest(r),
+		"user_id":    userID,
+		"reason":     reason,
+	})
+}
+
+func (s *Server) handleGetMyReferrals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	code, err := s.ensureReferralCode(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load referral code")
+		return
+	}
+	summary := ReferralSummary{
+		Code: code,
+		Link: s.referralLink(code),
+		ActiveBoosts: map[string]int{
+			common.QuotaBoostDraft:  0,
+			common.QuotaBoostBattle: 0,
+		},
+		Boosts: []QuotaBoost{},
+	}
+
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT COUNT(*)::int, COUNT(*) FILTER (WHERE rewarded)::int
+		FROM referrals
+		WHERE referrer_user_id = $1
+	`, userID).Scan(&summary.ReferredCount, &summary.RewardedCount); err != nil {
+		writeInternalError(w, "could not load referrals")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT quota_type, amount, source, starts_at, expires_at
+		FROM quota_boosts
+		WHERE user_id = $1
+		  AND expires_at > NOW()
+		ORDER BY expires_at ASC, id ASC
+		LIMIT $2
+	`, userID, referralBoostListMaxRows)
+	if err != nil {
+		writeInternalError(w, "could not load quota boosts")
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var boost QuotaBoost
+		if err := rows.Scan(&boost.QuotaType, &boost.Amount, &boost.Source, &boost.StartsAt, &boost.ExpiresAt); err != nil {
+			writeInternalError(w, "could not load quota boosts")
+			return
+		}
+		if !boost.StartsAt.After(now) {
+			summary.ActiveBoosts[boost.QuotaType] += boost.Amount
+		}
+		summary.Boosts = append(summary.Boosts, boost)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load quota boosts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
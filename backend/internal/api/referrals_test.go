@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/config"
+)
+
+func TestNewReferralCodeRoundTrips(t *testing.T) {
+	seen := map[string]struct{}{}
+	for i := 0; i < 50; i++ {
+		code, err := newReferralCode()
+		if err != nil {
+			t.Fatalf("newReferralCode failed: %v", err)
+		}
+		if normalizeReferralCode(code) != code {
+			t.Fatalf("expected generated code %q to normalize to itself", code)
+		}
+		seen[code] = struct{}{}
+	}
+	if len(seen) < 45 {
+		t.Fatalf("expected distinct codes, got %d unique of 50", len(seen))
+	}
+}
+
+func TestNormalizeReferralCode(t *testing.T) {
+	cases := map[string]string{
+		"ABCD2345":              "ABCD2345",
+		" abcd-2345 ":           "ABCD2345",
+		"ab cd 23 45":           "ABCD2345",
+		"ABCD234":               "",
+		"ABCD23456":             "",
+		"ABCD0345":              "",
+		"ABCDI345":              "",
+		"ABCD$345":              "",
+		"":                      "",
+		strings.Repeat("A", 64): "",
+	}
+	for input, want := range cases {
+		if got := normalizeReferralCode(input); got != want {
+			t.Fatalf("normalizeReferralCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestReferralLink(t *testing.T) {
+	s := &Server{cfg: config.Config{FrontendOrigin: "https://personaworlds.test/"}}
+	if got := s.referralLink("ABCD2345"); got != "https://personaworlds.test/signup?ref=ABCD2345" {
+		t.Fatalf("unexpected referral link %q", got)
+	}
+}
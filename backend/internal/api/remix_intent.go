@@ -18,6 +18,7 @@ import (
 type remixIntentToken struct {
 	BattleID   string
 	RoomID     string
+	RoomName   string
 	Topic      string
 	ProStyle   string
 	ConStyle   string
@@ -38,12 +39,31 @@ func (s *Server) handleGetPublicBattleMeta(w http.ResponseWriter, r *http.Reques
 		writeBadRequest(w, err.Error())
 		return
 	}
+	turnIndex := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("t")); raw != "" {
+		turnIndex, err = parseBattleTurnIndex(raw)
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+	lang, err := requestedTranslationLanguage(r)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	accessToken, err := s.authorizeBattleRead(r, battleID)
+	if err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
 
 	var (
 		out          PublicBattleMetaDTO
 		content      string
 		templateID   string
 		templateName string
+		ownerUserID  string
 		createdAt    time.Time
 	)
 
@@ -55,7 +75,10 @@ func (s *Server) handleGetPublicBattleMeta(w http.ResponseWriter, r *http.Reques
 			p.content,
 			COALESCE(p.template_id::text, ''),
 			COALESCE(t.name, ''),
-			p.created_at
+			p.user_id::text,
+			p.view_count,
+			p.created_at,
+			p.visibility
 		FROM posts p
 		JOIN rooms rm ON rm.id = p.room_id
 		LEFT JOIN templates t ON t.id = p.template_id
@@ -68,7 +91,10 @@ func (s *Server) handleGetPublicBattleMeta(w http.ResponseWriter, r *http.Reques
 		&content,
 		&templateID,
 		&templateName,
+		&ownerUserID,
+		&out.ViewCount,
 		&createdAt,
+		&out.Visibility,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -81,8 +107,9 @@ func (s *Server) handleGetPublicBattleMeta(w http.ResponseWriter, r *http.Reques
 
 	out.Topic = buildBattleCardTopic(content, out.RoomName)
 	out.CreatedAt = createdAt.UTC().Format(time.RFC3339)
-	out.ShareURL = fmt.Sprintf("%s/b/%s", strings.TrimRight(s.cfg.FrontendOrigin, "/"), out.BattleID)
-	out.CardURL = fmt.Sprintf("/b/%s/card.png", out.BattleID)
+	out.CardVariant = assignBattleCardVariant(out.BattleID)
+	out.ShareURL = s.battleCardShareURL(out.BattleID, out.CardVariant)
+	out.CardURL = battleCardImagePath(out.BattleID, out.CardVariant)
 	if strings.TrimSpace(templateID) != "" {
 		out.Template = map[string]any{
 			"id":   strings.TrimSpace(templateID),
@@ -90,11 +117,73 @@ func (s *Server) handleGetPublicBattleMeta(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	_ = s.logEventFromRequest(r, eventPublicBattleViewed, map[string]any{
+	card, err := s.loadBattleCardData(r.Context(), out.BattleID)
+	if err != nil {
+		writeInternalError(w, "could not load battle verdict")
+		return
+	}
+	out.Verdict = s.publicBattleVerdict(r.Context(), card, lang)
+	out.Votes, err = s.battleVoteTallies(r.Context(), out.BattleID)
+	if err != nil {
+		writeInternalError(w, "could not load votes")
+		return
+	}
+	if viewerID, ok := s.optionalUserIDFromRequest(r); ok {
+		out.MyVote, err = s.battleVoteOf(r.Context(), out.BattleID, viewerID)
+		if err != nil {
+			writeInternalError(w, "could not load votes")
+			return
+		}
+	}
+
+	viewedMetadata := map[string]any{
 		"battle_id": out.BattleID,
 		"room_id":   out.RoomID,
-	})
+	}
+	if turnIndex > 0 {
+		turn, err := s.loadBattleTurnCardData(r.Context(), out.BattleID, turnIndex)
+		if err != nil {
+			if errors.Is(err, errBattleTurnNotFound) || errors.Is(err, pgx.ErrNoRows) {
+				writeNotFound(w, "turn not found")
+				return
+			}
+			writeInternalError(w, "could not load battle turn")
+			return
+		}
+		out.ShareURL = turn.URL
+		out.CardURL = battleTurnCardPath(out.BattleID, turn.Index)
+		out.Turn = &PublicBattleTurnDTO{
+			Index:       turn.Index,
+			Total:       turn.Total,
+			PersonaName: turn.PersonaName,
+			Quote:       turn.Quote,
+			CardURL:     out.CardURL,
+		}
+		viewedMetadata["turn"] = turn.Index
+	}
+	if accessToken != "" {
+		out.ShareURL = withUnlistedAccess(out.ShareURL, accessToken)
+		out.CardURL = withUnlistedAccess(out.CardURL, accessToken)
+		if out.Turn != nil {
+			out.Turn.CardURL = out.CardURL
+		}
+	}
 
+	query := r.URL.Query()
+	if clicked := verifyBattleCardClick(s.cfg.JWTSecret, out.BattleID, query.Get(battleCardVariantQueryName), query.Get(battleCardSignatureQueryName)); clicked != "" {
+		viewedMetadata["card_variant"] = clicked
+		_ = s.logEventFromRequest(r, eventBattleCardClicked, map[string]any{
+			"battle_id": out.BattleID,
+			"variant":   clicked,
+		})
+	}
+
+	if s.recordUniqueView(r, viewSubjectBattle, out.BattleID, ownerUserID) {
+		out.ViewCount++
+	}
+	_ = s.logEventFromRequest(r, eventPublicBattleViewed, viewedMetadata)
+
+	w.Header().Add("Vary", "Accept-Language")
 	writeJSON(w, http.StatusOK, out)
 }
 
@@ -105,23 +194,7 @@ func (s *Server) handleCreateBattleRemixIntent(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	var (
-		roomID      string
-		roomName    string
-		postContent string
-		templateID  string
-	)
-	err = s.db.QueryRow(r.Context(), `
-		SELECT
-			p.room_id::text,
-			COALESCE(rm.name, ''),
-			p.content,
-			COALESCE(p.template_id::text, '')
-		FROM posts p
-		JOIN rooms rm ON rm.id = p.room_id
-		WHERE p.id = $1
-		  AND p.status = 'PUBLISHED'
-	`, battleID).Scan(&roomID, &roomName, &postContent, &templateID)
+	remix, err := s.loadRemixDefaults(r.Context(), battleID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "battle not found")
@@ -131,62 +204,92 @@ func (s *Server) handleCreateBattleRemixIntent(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	topic := buildBattleCardTopic(postContent, roomName)
-	proStyle := "Bold, practical, concise"
-	conStyle := "Skeptical, evidence-first, concise"
-
-	suggestedTemplates, err := s.listSuggestedRemixTemplates(r.Context(), templateID, 4)
+	suggestedTemplates, err := s.listSuggestedRemixTemplates(r.Context(), remix.TemplateID, 4)
 	if err != nil {
 		writeInternalError(w, "could not load templates")
 		return
 	}
 
-	preferredTemplateID := strings.TrimSpace(templateID)
+	preferredTemplateID := remix.TemplateID
 	if preferredTemplateID == "" && len(suggestedTemplates) > 0 {
 		preferredTemplateID = suggestedTemplates[0].ID
 	}
+	remix.TemplateID = preferredTemplateID
 
-	token, expiresAt, err := createRemixIntentToken(s.cfg.JWTSecret, remixIntentToken{
-		BattleID:   battleID,
-		RoomID:     roomID,
-		Topic:      topic,
-		ProStyle:   proStyle,
-		ConStyle:   conStyle,
-		TemplateID: preferredTemplateID,
-	}, 30*time.Minute)
+	token, expiresAt, err := createRemixIntentToken(s.cfg.JWTSecret, remix, 30*time.Minute)
 	if err != nil {
 		writeInternalError(w, "could not create remix intent")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "pw_remix_intent",
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		MaxAge:   int((30 * time.Minute).Seconds()),
-		SameSite: http.SameSiteLaxMode,
-		Secure:   s.cfg.SecureCookies,
-	})
+	// Signed-out visitors also get a guest intent, so signing up can finish
+	// the remix server-side instead of relying on client storage.
+	var intentToken, intentExpiresAt string
+	if _, signedIn := s.optionalUserIDFromRequest(r); !signedIn {
+		token, expiresAt, err := s.issueGuestIntent(w, guestIntentKindRemix, battleID)
+		if err != nil {
+			writeInternalError(w, "could not create remix intent")
+			return
+		}
+		intentToken, intentExpiresAt = token, expiresAt.Format(time.RFC3339)
+	}
 
 	_ = s.logEventFromRequest(r, eventRemixStarted, map[string]any{
 		"battle_id": battleID,
-		"room_id":   roomID,
+		"room_id":   remix.RoomID,
 	})
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	response := map[string]any{
 		"battle_id":            battleID,
-		"room_id":              roomID,
-		"room_name":            roomName,
-		"topic":                topic,
-		"pro_style":            proStyle,
-		"con_style":            conStyle,
+		"room_id":              remix.RoomID,
+		"room_name":            remix.RoomName,
+		"topic":                remix.Topic,
+		"pro_style":            remix.ProStyle,
+		"con_style":            remix.ConStyle,
 		"suggested_templates":  suggestedTemplates,
 		"remix_token":          token,
 		"remix_token_expires":  expiresAt.UTC().Format(time.RFC3339),
-		"target_create_route":  fmt.Sprintf("/rooms/%s/battles", roomID),
+		"target_create_route":  fmt.Sprintf("/rooms/%s/battles", remix.RoomID),
 		"target_battle_source": battleID,
-	})
+	}
+	if intentToken != "" {
+		response["intent_token"] = intentToken
+		response["intent_expires_at"] = intentExpiresAt
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// loadRemixDefaults returns the topic, styles and template a remix of the
+// battle starts from. RoomName is only used for display.
+func (s *Server) loadRemixDefaults(ctx context.Context, battleID string) (remixIntentToken, error) {
+	var roomID, roomName, postContent, templateID string
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			p.room_id::text,
+			COALESCE(rm.name, ''),
+			p.content,
+			COALESCE(p.template_id::text, '')
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.id = $1
+		  AND p.status = 'PUBLISHED'
+	`, battleID).Scan(&roomID, &roomName, &postContent, &templateID)
+	if err != nil {
+		return remixIntentToken{}, err
+	}
+	return newRemixDefaults(battleID, roomID, roomName, postContent, templateID), nil
+}
+
+func newRemixDefaults(battleID, roomID, roomName, postContent, templateID string) remixIntentToken {
+	return remixIntentToken{
+		BattleID:   battleID,
+		RoomID:     roomID,
+		RoomName:   roomName,
+		Topic:      buildBattleCardTopic(postContent, roomName),
+		ProStyle:   "Bold, practical, concise",
+		ConStyle:   "Skeptical, evidence-first, concise",
+		TemplateID: strings.TrimSpace(templateID),
+	}
 }
 
 func (s *Server) listSuggestedRemixTemplates(ctx context.Context, preferredTemplateID string, limit int) ([]remixIntentTemplateSummary, error) {
@@ -2,18 +2,27 @@ package api
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"strings"
 
 	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
+type rawBodyContextKey struct{}
+
 func (s *Server) requestContextTimeoutMiddleware(next http.Handler) http.Handler {
 	timeout := s.cfg.APIRequestTimeout
 	if timeout <= 0 {
 		return next
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isThreadStreamRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel()
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -32,14 +41,39 @@ func (s *Server) maxBodyBytesMiddleware(limit int64) func(http.Handler) http.Han
 			}
 			method := strings.ToUpper(strings.TrimSpace(r.Method))
 			if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
-				r.Body = http.MaxBytesReader(w, r.Body, limit)
+				// Route-level limits replace the router-wide default instead of nesting
+				// inside it, so a route may allow a larger body than the default.
+				body, ok := r.Context().Value(rawBodyContextKey{}).(io.ReadCloser)
+				if !ok || body == nil {
+					body = r.Body
+					r = r.WithContext(context.WithValue(r.Context(), rawBodyContextKey{}, body))
+				}
+				r.Body = http.MaxBytesReader(w, body, limit)
 			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+func (s *Server) compressJSONMiddleware(next http.Handler) http.Handler {
+	level := s.cfg.CompressionLevel
+	if level <= 0 {
+		return next
+	}
+	if level > 9 {
+		level = 9
+	}
+	return middleware.NewCompressor(level, "application/json").Handler(next)
+}
+
 func (s *Server) writeRateLimitResponse(w http.ResponseWriter, r *http.Request, scope, endpoint, message string) {
+	s.recordRateLimited(r, scope, endpoint)
+	writeTooManyRequests(w, message)
+}
+
+// recordRateLimited counts and logs a rejected request, for callers that
+// write their own 429 body.
+func (s *Server) recordRateLimited(r *http.Request, scope, endpoint string) {
 	if strings.TrimSpace(endpoint) == "" {
 		endpoint = routePatternFromRequest(r)
 	}
@@ -57,5 +91,4 @@ func (s *Server) writeRateLimitResponse(w http.ResponseWriter, r *http.Request,
 		fields["user_id"] = userID
 	}
 	s.logger.Warn("rate_limited", fields)
-	writeTooManyRequests(w, message)
 }
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/config"
+)
+
+func TestRouteBodyLimitReplacesDefaultLimit(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWTSecret = "request-limits-test-secret"
+	server := New(cfg, nil, ai.NewMockClient())
+
+	var readErr error
+	var readBytes int
+	handler := server.maxBodyBytesMiddleware(16)(
+		server.maxBodyBytesMiddleware(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, err := io.ReadAll(r.Body)
+			readErr = err
+			readBytes = len(payload)
+		})),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(strings.Repeat("a", 48)))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if readErr != nil {
+		t.Fatalf("expected route limit to allow 48 bytes, got error: %v", readErr)
+	}
+	if readBytes != 48 {
+		t.Fatalf("expected 48 bytes read, got %d", readBytes)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(strings.Repeat("a", 80)))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if readErr == nil {
+		t.Fatalf("expected route limit to reject 80 bytes")
+	}
+}
+
+func TestEventsEndpointRejectsOversizedBody(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWTSecret = "request-limits-test-secret"
+	cfg.EventsBodyMaxBytes = 64
+	server := New(cfg, nil, ai.NewMockClient())
+
+	body := `{"event_name":"daily_return","metadata":{"slug":"` + strings.Repeat("x", 128) + `"}}`
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	server.Router().ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "request body too large") {
+		t.Fatalf("expected body too large error, got %s", recorder.Body.String())
+	}
+}
+
+func TestCompressJSONMiddlewareGzipsJSONResponses(t *testing.T) {
+	cfg := config.Load()
+	cfg.JWTSecret = "request-limits-test-secret"
+	cfg.CompressionLevel = 5
+	server := New(cfg, nil, ai.NewMockClient())
+
+	payload := strings.Repeat("battle transcript ", 200)
+	handler := server.compressJSONMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"transcript": payload})
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/feed", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if got := recorder.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("expected gzip content-encoding, got %q", got)
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("gzip reader failed: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("gzip read failed: %v", err)
+	}
+	if !strings.Contains(string(decoded), "battle transcript") {
+		t.Fatalf("decoded body mismatch: %s", string(decoded))
+	}
+}
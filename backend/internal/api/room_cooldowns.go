@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const roomCooldownMaxMinutes = 7 * 24 * 60
+
+func (s *Server) loadRoomCooldown(ctx context.Context, roomID, personaID string) (common.RoomCooldown, error) {
+	return common.LoadRoomCooldown(ctx, s.db, roomID, personaID, s.cfg.RoomPostCooldown, time.Now())
+}
+
+// rejectRoomCooldown answers 429 with the cooldown state when the persona
+// posted in the room too recently, so clients can show when to come back.
+func (s *Server) rejectRoomCooldown(w http.ResponseWriter, r *http.Request, roomID, personaID string) bool {
+	cooldown, err := s.loadRoomCooldown(r.Context(), roomID, personaID)
+	if err != nil {
+		writeInternalError(w, "could not check room cooldown")
+		return true
+	}
+	if !cooldown.Active {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(cooldown.RetryAfterSeconds))
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{
+		"error":    "persona posted in this room recently",
+		"code":     "room_cooldown",
+		"cooldown": cooldown,
+	})
+	return true
+}
+
+func (s *Server) handleSetRoomCooldown(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	// A null value drops the override and goes back to ROOM_POST_COOLDOWN.
+	var req struct {
+		PostCooldownMinutes *int `json:"post_cooldown_minutes"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if req.PostCooldownMinutes != nil && (*req.PostCooldownMinutes < 0 || *req.PostCooldownMinutes > roomCooldownMaxMinutes) {
+		writeBadRequest(w, "post_cooldown_minutes must be between 0 and "+strconv.Itoa(roomCooldownMaxMinutes))
+		return
+	}
+
+	var override *int
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE rooms
+		SET post_cooldown_minutes = $2
+		WHERE id = $1
+		RETURNING post_cooldown_minutes
+	`, roomID, req.PostCooldownMinutes).Scan(&override)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not save room cooldown")
+		return
+	}
+
+	effective := common.EffectiveRoomCooldown(override, s.cfg.RoomPostCooldown)
+	s.logger.Info("room_cooldown_changed", observability.Fields{
+		"room_id":           roomID,
+		"effective_minutes": int(effective / time.Minute),
+		"user_id":           userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id":                    roomID,
+		"post_cooldown_minutes":      override,
+		"effective_cooldown_minutes": int(effective / time.Minute),
+	})
+}
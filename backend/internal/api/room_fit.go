@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	roomTopicCheckOff      = "off"
+	roomTopicCheckAdvisory = "advisory"
+	roomTopicCheckBlocking = "blocking"
+
+	roomFitMaxSuggestions = 3
+)
+
+type RoomFitSuggestion struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// RoomFit is the pre-publish verdict on whether a draft belongs in its room.
+type RoomFit struct {
+	Fits           bool                `json:"fits"`
+	Reason         string              `json:"reason,omitempty"`
+	Mode           string              `json:"mode"`
+	SuggestedRooms []RoomFitSuggestion `json:"suggested_rooms"`
+}
+
+func normalizeRoomTopicCheck(raw string) (string, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(raw)); mode {
+	case roomTopicCheckOff, roomTopicCheckAdvisory, roomTopicCheckBlocking:
+		return mode, nil
+	default:
+		return "", errors.New("mode must be off, advisory or blocking")
+	}
+}
+
+// parseRoomFitVerdict reads the LLM's JSON verdict. Suggestions are kept only
+// when they name one of the candidate rooms. ok is false when the output is
+// not a verdict at all, in which case callers let the draft through.
+func parseRoomFitVerdict(raw string, candidates []Room) (RoomFit, bool) {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return RoomFit{}, false
+	}
+
+	var verdict struct {
+		Fits           *bool    `json:"fits"`
+		Reason         string   `json:"reason"`
+		SuggestedRooms []string `json:"suggested_rooms"`
+	}
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &verdict); err != nil || verdict.Fits == nil {
+		return RoomFit{}, false
+	}
+
+	fit := RoomFit{
+		Fits:           *verdict.Fits,
+		Reason:         strings.TrimSpace(verdict.Reason),
+		SuggestedRooms: []RoomFitSuggestion{},
+	}
+	if fit.Fits {
+		return fit, true
+	}
+
+	bySlug := make(map[string]Room, len(candidates))
+	for _, candidate := range candidates {
+		bySlug[strings.ToLower(candidate.Slug)] = candidate
+	}
+	for _, slug := range verdict.SuggestedRooms {
+		key := strings.ToLower(strings.TrimSpace(slug))
+		candidate, ok := bySlug[key]
+		if !ok {
+			continue
+		}
+		delete(bySlug, key)
+		fit.SuggestedRooms = append(fit.SuggestedRooms, RoomFitSuggestion{
+			ID:   candidate.ID,
+			Slug: candidate.Slug,
+			Name: candidate.Name,
+		})
+		if len(fit.SuggestedRooms) == roomFitMaxSuggestions {
+			break
+		}
+	}
+	return fit, true
+}
+
+// checkRoomFit asks the LLM whether content belongs in room. LLM failures and
+// unreadable verdicts count as a fit so the check never blocks publishing on
+// its own errors.
+func (s *Server) checkRoomFit(ctx context.Context, room Room, mode, content string) (RoomFit, error) {
+	candidates, err := s.listRoomFitCandidates(ctx, room.ID)
+	if err != nil {
+		return RoomFit{}, err
+	}
+
+	s.syncPrompts(ctx)
+	aiCandidates := make([]ai.RoomContext, 0, len(candidates))
+	for _, candidate := range candidates {
+		aiCandidates = append(aiCandidates, ai.RoomContext{
+			ID:          candidate.ID,
+			Slug:        candidate.Slug,
+			Name:        candidate.Name,
+			Description: candidate.Description,
+		})
+	}
+	raw, err := s.llm.CheckRoomFit(ctx, ai.PostContext{Content: content}, ai.RoomContext{
+		ID:          room.ID,
+		Slug:        room.Slug,
+		Name:        room.Name,
+		Description: room.Description,
+	}, aiCandidates)
+
+	fit := RoomFit{Fits: true, SuggestedRooms: []RoomFitSuggestion{}}
+	if err != nil {
+		s.logger.Warn("room_fit_check_failed", observability.Fields{"room_id": room.ID, "error": err.Error()})
+	} else if parsed, ok := parseRoomFitVerdict(raw, candidates); ok {
+		fit = parsed
+	} else {
+		s.logger.Warn("room_fit_check_failed", observability.Fields{"room_id": room.ID, "error": "unreadable verdict"})
+	}
+	fit.Mode = mode
+	return fit, nil
+}
+
+func (s *Server) listRoomFitCandidates(ctx context.Context, roomID string) ([]Room, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id::text, slug, name, description
+		FROM rooms
+		WHERE archived_at IS NULL
+		  AND id <> $1
+		ORDER BY name ASC
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := []Room{}
+	for rows.Next() {
+		var rm Room
+		if err := rows.Scan(&rm.ID, &rm.Slug, &rm.Name, &rm.Description); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, rm)
+	}
+	return rooms, rows.Err()
+}
+
+// rejectOffTopicDraft runs the room's topic check before a draft is
+// published. Blocking rooms refuse off-topic drafts; advisory rooms answer 409
+// until the owner confirms with publish_off_topic.
+func (s *Server) rejectOffTopicDraft(w http.ResponseWriter, r *http.Request, room Room, mode, content string, confirmed bool) bool {
+	if mode == roomTopicCheckOff || (mode == roomTopicCheckAdvisory && confirmed) {
+		return false
+	}
+
+	fit, err := s.checkRoomFit(r.Context(), room, mode, content)
+	if err != nil {
+		writeInternalError(w, "could not check room fit")
+		return true
+	}
+	if fit.Fits {
+		return false
+	}
+
+	s.logger.Info("room_fit_flagged", observability.Fields{
+		"room_id":     room.ID,
+		"mode":        mode,
+		"suggestions": len(fit.SuggestedRooms),
+	})
+	message := "draft looks off-topic for this room; resend with publish_off_topic to publish anyway"
+	if mode == roomTopicCheckBlocking {
+		message = "draft is off-topic for this room"
+	}
+	writeJSON(w, http.StatusConflict, map[string]any{
+		"error":    message,
+		"code":     "off_topic",
+		"room_fit": fit,
+	})
+	return true
+}
+
+func (s *Server) handleSetRoomTopicCheck(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	mode, err := normalizeRoomTopicCheck(req.Mode)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE rooms
+		SET topic_check = $2
+		WHERE id = $1
+		RETURNING topic_check
+	`, roomID, mode).Scan(&mode)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not save room topic check")
+		return
+	}
+
+	s.logger.Info("room_topic_check_changed", observability.Fields{
+		"room_id": roomID,
+		"mode":    mode,
+		"user_id": userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id":     roomID,
+		"topic_check": mode,
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import "testing"
+
+func TestParseRoomFitVerdict(t *testing.T) {
+	candidates := []Room{
+		{ID: "room-go", Slug: "go-backend", Name: "Go Backend"},
+		{ID: "room-sec", Slug: "cybersecurity", Name: "Cybersecurity"},
+	}
+
+	fit, ok := parseRoomFitVerdict("```json\n{\"fits\": false, \"reason\": \"About TLS.\", \"suggested_rooms\": [\"Cybersecurity\", \"unknown\", \"cybersecurity\"]}\n```", candidates)
+	if !ok {
+		t.Fatal("expected verdict to parse")
+	}
+	if fit.Fits || fit.Reason != "About TLS." {
+		t.Fatalf("unexpected verdict %+v", fit)
+	}
+	if len(fit.SuggestedRooms) != 1 || fit.SuggestedRooms[0].ID != "room-sec" {
+		t.Fatalf("expected only the known room once, got %+v", fit.SuggestedRooms)
+	}
+
+	fit, ok = parseRoomFitVerdict(`{"fits": true, "suggested_rooms": ["go-backend"]}`, candidates)
+	if !ok || !fit.Fits || len(fit.SuggestedRooms) != 0 {
+		t.Fatalf("expected fitting verdict without suggestions, got %+v ok=%v", fit, ok)
+	}
+
+	for _, raw := range []string{"", "fits", `{"reason": "missing fits"}`, `{"fits": "maybe"}`} {
+		if _, ok := parseRoomFitVerdict(raw, candidates); ok {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestNormalizeRoomTopicCheck(t *testing.T) {
+	if mode, err := normalizeRoomTopicCheck(" Blocking "); err != nil || mode != roomTopicCheckBlocking {
+		t.Fatalf("expected blocking, got %q err=%v", mode, err)
+	}
+	if _, err := normalizeRoomTopicCheck("strict"); err == nil {
+		t.Fatal("expected unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const roomPromptHintMaxRunes = 500
+
+var errRoomPromptHintEmpty = errors.New("emphasize or structure is required")
+
+// RoomPromptHint is one saved version of a room's prompt guidance. PostCount
+// is how many posts were drafted with it.
+type RoomPromptHint struct {
+	ID        int64     `json:"id"`
+	Version   int       `json:"version"`
+	Emphasize string    `json:"emphasize"`
+	Structure string    `json:"structure"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Active    bool      `json:"active"`
+	PostCount int       `json:"post_count"`
+}
+
+type RoomModerator struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type roomPromptHintRequest struct {
+	Emphasize string `json:"emphasize"`
+	Structure string `json:"structure"`
+}
+
+func (req roomPromptHintRequest) normalize() (roomPromptHintRequest, error) {
+	out := roomPromptHintRequest{
+		Emphasize: strings.TrimSpace(req.Emphasize),
+		Structure: strings.TrimSpace(req.Structure),
+	}
+	if out.Emphasize == "" && out.Structure == "" {
+		return roomPromptHintRequest{}, errRoomPromptHintEmpty
+	}
+	if len([]rune(out.Emphasize)) > roomPromptHintMaxRunes {
+		return roomPromptHintRequest{}, errors.New("emphasize must be at most 500 characters")
+	}
+	if len([]rune(out.Structure)) > roomPromptHintMaxRunes {
+		return roomPromptHintRequest{}, errors.New("structure must be at most 500 characters")
+	}
+	return out, nil
+}
+
+func (s *Server) handleListRoomPromptHints(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	hints, err := s.listRoomPromptHints(r.Context(), roomID)
+	if err != nil {
+		writeInternalError(w, "could not load room prompt hints")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id": roomID,
+		"hints":   hints,
+	})
+}
+
+func (s *Server) listRoomPromptHints(ctx context.Context, roomID string) ([]RoomPromptHint, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			h.id, h.version, h.emphasize, h.structure, COALESCE(h.created_by::text, ''), h.created_at,
+			COALESCE(h.id = rm.prompt_hint_id, FALSE),
+			(SELECT COUNT(*)::int FROM posts p WHERE p.prompt_hint_id = h.id)
+		FROM room_prompt_hints h
+		JOIN rooms rm ON rm.id = h.room_id
+		WHERE h.room_id = $1
+		ORDER BY h.version DESC
+	`, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hints := make([]RoomPromptHint, 0)
+	for rows.Next() {
+		var hint RoomPromptHint
+		if err := rows.Scan(&hint.ID, &hint.Version, &hint.Emphasize, &hint.Structure, &hint.CreatedBy, &hint.CreatedAt, &hint.Active, &hint.PostCount); err != nil {
+			return nil, err
+		}
+		hints = append(hints, hint)
+	}
+	return hints, rows.Err()
+}
+
+// handleCreateRoomPromptHint saves the next hint version and makes it the
+// room's active hint.
+func (s *Server) handleCreateRoomPromptHint(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	var req roomPromptHintRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	req, err = req.normalize()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not save room prompt hint")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	// Locking the room serializes version numbers per room.
+	if err := tx.QueryRow(r.Context(), `SELECT id::text FROM rooms WHERE id = $1 FOR UPDATE`, roomID).Scan(&roomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not save room prompt hint")
+		return
+	}
+
+	hint := RoomPromptHint{Emphasize: req.Emphasize, Structure: req.Structure, CreatedBy: userID, Active: true}
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO room_prompt_hints(room_id, version, emphasize, structure, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+		FROM room_prompt_hints
+		WHERE room_id = $1
+		RETURNING id, version, created_at
+	`, roomID, hint.Emphasize, hint.Structure, userID).Scan(&hint.ID, &hint.Version, &hint.CreatedAt)
+	if err != nil {
+		writeInternalError(w, "could not save room prompt hint")
+		return
+	}
+	if _, err := tx.Exec(r.Context(), `UPDATE rooms SET prompt_hint_id = $2 WHERE id = $1`, roomID, hint.ID); err != nil {
+		writeInternalError(w, "could not save room prompt hint")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not save room prompt hint")
+		return
+	}
+
+	s.logger.Info("room_prompt_hint_created", observability.Fields{
+		"room_id":    roomID,
+		"hint_id":    hint.ID,
+		"version":    hint.Version,
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusCreated, map[string]any{"hint": hint})
+}
+
+// handleActivateRoomPromptHint switches the room back to an earlier version.
+func (s *Server) handleActivateRoomPromptHint(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(chi.URLParam(r, "version")))
+	if err != nil || version <= 0 {
+		writeBadRequest(w, "invalid hint version")
+		return
+	}
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var hintID int64
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE rooms rm
+		SET prompt_hint_id = h.id
+		FROM room_prompt_hints h
+		WHERE rm.id = $1
+		  AND h.room_id = rm.id
+		  AND h.version = $2
+		RETURNING h.id
+	`, roomID, version).Scan(&hintID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room prompt hint not found")
+			return
+		}
+		writeInternalError(w, "could not activate room prompt hint")
+		return
+	}
+
+	s.logger.Info("room_prompt_hint_activated", observability.Fields{
+		"room_id":    roomID,
+		"hint_id":    hintID,
+		"version":    version,
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id":        roomID,
+		"active_version": version,
+	})
+}
+
+// handleClearRoomPromptHint stops applying a hint to the room. Saved versions
+// are kept for the audit trail.
+func (s *Server) handleClearRoomPromptHint(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `UPDATE rooms SET prompt_hint_id = NULL WHERE id = $1`, roomID)
+	if err != nil {
+		writeInternalError(w, "could not clear room prompt hint")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeNotFound(w, "room not found")
+		return
+	}
+
+	s.logger.Info("room_prompt_hint_cleared", observability.Fields{
+		"room_id":    roomID,
+		"user_id":    userID,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id":        roomID,
+		"active_version": nil,
+	})
+}
+
+func (s *Server) handleListRoomModerators(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT m.user_id::text, u.email, m.created_at
+		FROM room_moderators m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.room_id = $1
+		ORDER BY m.created_at ASC
+	`, roomID)
+	if err != nil {
+		writeInternalError(w, "could not load room moderators")
+		return
+	}
+	defer rows.Close()
+
+	moderators := make([]RoomModerator, 0)
+	for rows.Next() {
+		var moderator RoomModerator
+		if err := rows.Scan(&moderator.UserID, &moderator.Email, &moderator.CreatedAt); err != nil {
+			writeInternalError(w, "could not load room moderators")
+			return
+		}
+		moderators = append(moderators, moderator)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load room moderators")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"moderators": moderators})
+}
+
+func (s *Server) handleAddRoomModerator(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	moderatorID, err := validateUUID(chi.URLParam(r, "userID"), "user id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		INSERT INTO room_moderators(room_id, user_id)
+		SELECT rm.id, u.id
+		FROM rooms rm, users u
+		WHERE rm.id = $1 AND u.id = $2
+		ON CONFLICT (room_id, user_id) DO NOTHING
+	`, roomID, moderatorID)
+	if err != nil {
+		writeInternalError(w, "could not add room moderator")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		var found bool
+		if err := s.db.QueryRow(r.Context(), `
+			SELECT EXISTS(SELECT 1 FROM room_moderators WHERE room_id = $1 AND user_id = $2)
+		`, roomID, moderatorID).Scan(&found); err != nil {
+			writeInternalError(w, "could not add room moderator")
+			return
+		}
+		if !found {
+			writeNotFound(w, "room or user not found")
+			return
+		}
+	}
+
+	s.logger.Info("room_moderator_added", observability.Fields{
+		"room_id":      roomID,
+		"moderator_id": moderatorID,
+		"user_id":      adminID,
+		"request_id":   requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"room_id": roomID, "user_id": moderatorID, "moderator": true})
+}
+
+func (s *Server) handleRemoveRoomModerator(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	moderatorID, err := validateUUID(chi.URLParam(r, "userID"), "user id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		DELETE FROM room_moderators
+		WHERE room_id = $1 AND user_id = $2
+	`, roomID, moderatorID); err != nil {
+		writeInternalError(w, "could not remove room moderator")
+		return
+	}
+
+	s.logger.Info("room_moderator_removed", observability.Fields{
+		"room_id":      roomID,
+		"moderator_id": moderatorID,
+		"user_id":      adminID,
+		"request_id":   requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"room_id": roomID, "user_id": moderatorID, "moderator": false})
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRoomPromptHintRequestNormalize(t *testing.T) {
+	got, err := roomPromptHintRequest{Emphasize: "  pricing experiments ", Structure: " "}.normalize()
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got.Emphasize != "pricing experiments" || got.Structure != "" {
+		t.Fatalf("expected trimmed hint, got %+v", got)
+	}
+
+	if _, err := (roomPromptHintRequest{Emphasize: " ", Structure: "\n"}).normalize(); !errors.Is(err, errRoomPromptHintEmpty) {
+		t.Fatalf("expected empty hint to be rejected, got %v", err)
+	}
+	if _, err := (roomPromptHintRequest{Structure: strings.Repeat("ş", roomPromptHintMaxRunes+1)}).normalize(); err == nil {
+		t.Fatalf("expected overlong structure to be rejected")
+	}
+	if _, err := (roomPromptHintRequest{Structure: strings.Repeat("ş", roomPromptHintMaxRunes)}).normalize(); err != nil {
+		t.Fatalf("expected limit to count runes, got %v", err)
+	}
+}
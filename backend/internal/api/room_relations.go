@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// roomChipsPerRoom is how many related rooms a room payload links to. The
+// full list is on GET /rooms/{id}/related.
+const roomChipsPerRoom = 3
+
+// RelatedRoomChip is the short link to a related room shown with a room.
+type RelatedRoomChip struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type RelatedRoom struct {
+	ID             string    `json:"id"`
+	Slug           string    `json:"slug"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Score          float64   `json:"score"`
+	SharedPersonas int       `json:"shared_personas"`
+	SharedKeywords []string  `json:"shared_keywords"`
+	ComputedAt     time.Time `json:"computed_at"`
+}
+
+func (s *Server) handleListRelatedRooms(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if s.redirectMergedRoom(w, r, roomID, "/related") {
+		return
+	}
+	if _, err := s.getRoomByID(r.Context(), roomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT rm.id::text, rm.slug, rm.name, rm.description, rr.score, rr.shared_personas, rr.shared_keywords, rr.computed_at
+		FROM room_relations rr
+		JOIN rooms rm ON rm.id = rr.related_room_id
+		WHERE rr.room_id = $1
+		  AND rm.archived_at IS NULL
+		ORDER BY rr.score DESC, rm.name ASC
+	`, roomID)
+	if err != nil {
+		writeInternalError(w, "could not list related rooms")
+		return
+	}
+	defer rows.Close()
+
+	related := make([]RelatedRoom, 0)
+	for rows.Next() {
+		var room RelatedRoom
+		if err := rows.Scan(&room.ID, &room.Slug, &room.Name, &room.Description, &room.Score, &room.SharedPersonas, &room.SharedKeywords, &room.ComputedAt); err != nil {
+			writeInternalError(w, "could not scan related room")
+			return
+		}
+		if room.SharedKeywords == nil {
+			room.SharedKeywords = []string{}
+		}
+		related = append(related, room)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list related rooms")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"related": related})
+}
+
+// loadRelatedRoomChips returns up to roomChipsPerRoom related rooms for each
+// of roomIDs, best first. Rooms without relations are missing from the map.
+func (s *Server) loadRelatedRoomChips(ctx context.Context, roomIDs []string) (map[string][]RelatedRoomChip, error) {
+	chips := map[string][]RelatedRoomChip{}
+	if len(roomIDs) == 0 {
+		return chips, nil
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT room_id, id, slug, name
+		FROM (
+			SELECT rr.room_id::text AS room_id, rm.id::text AS id, rm.slug, rm.name,
+				ROW_NUMBER() OVER (PARTITION BY rr.room_id ORDER BY rr.score DESC, rm.name ASC) AS rank
+			FROM room_relations rr
+			JOIN rooms rm ON rm.id = rr.related_room_id
+			WHERE rr.room_id = ANY($1::uuid[])
+			  AND rm.archived_at IS NULL
+		) ranked
+		WHERE rank <= $2
+		ORDER BY room_id, rank
+	`, roomIDs, roomChipsPerRoom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roomID string
+		var chip RelatedRoomChip
+		if err := rows.Scan(&roomID, &chip.ID, &chip.Slug, &chip.Name); err != nil {
+			return nil, err
+		}
+		chips[roomID] = append(chips[roomID], chip)
+	}
+	return chips, rows.Err()
+}
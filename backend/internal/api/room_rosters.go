@@ -0,0 +1,298 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+var errRosterTargetEmpty = errors.New("weekly_posts or weekly_replies must be above 0")
+
+// RoomRosterEntry is a persona enrolled in a room with its weekly targets and
+// this week's progress. Posts counts posts published this week; drafts from
+// this week that still wait for the owner's review are DraftsWaiting. The
+// *Due fields are how much of each target should be done by now for an even
+// pace.
+type RoomRosterEntry struct {
+	PersonaID     string    `json:"persona_id"`
+	PersonaName   string    `json:"persona_name"`
+	Mine          bool      `json:"mine"`
+	WeeklyPosts   int       `json:"weekly_posts"`
+	WeeklyReplies int       `json:"weekly_replies"`
+	Posts         int       `json:"posts"`
+	DraftsWaiting int       `json:"drafts_waiting"`
+	Replies       int       `json:"replies"`
+	PostsDue      int       `json:"posts_due"`
+	RepliesDue    int       `json:"replies_due"`
+	EnrolledAt    time.Time `json:"enrolled_at"`
+}
+
+type rosterEnrollmentRequest struct {
+	WeeklyPosts   int `json:"weekly_posts"`
+	WeeklyReplies int `json:"weekly_replies"`
+}
+
+func (req rosterEnrollmentRequest) validate() error {
+	if req.WeeklyPosts < 0 || req.WeeklyPosts > common.RosterMaxWeeklyPosts {
+		return fmt.Errorf("weekly_posts must be between 0 and %d", common.RosterMaxWeeklyPosts)
+	}
+	if req.WeeklyReplies < 0 || req.WeeklyReplies > common.RosterMaxWeeklyReplies {
+		return fmt.Errorf("weekly_replies must be between 0 and %d", common.RosterMaxWeeklyReplies)
+	}
+	if req.WeeklyPosts == 0 && req.WeeklyReplies == 0 {
+		return errRosterTargetEmpty
+	}
+	return nil
+}
+
+func (s *Server) handleGetRoomRoster(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if s.redirectMergedRoom(w, r, roomID, "/roster") {
+		return
+	}
+	if _, err := s.getRoomByID(r.Context(), roomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+
+	now := time.Now().UTC()
+	roster, err := s.listRoomRoster(r.Context(), roomID, userID, "", now)
+	if err != nil {
+		writeInternalError(w, "could not load roster")
+		return
+	}
+	weekStart := common.RosterWeekStart(now)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"room_id":    roomID,
+		"week_start": weekStart,
+		"week_end":   weekStart.AddDate(0, 0, 7),
+		"roster":     roster,
+	})
+}
+
+// listRoomRoster returns the room's enrollments, the viewer's personas first.
+// A non-empty personaID limits it to that persona.
+func (s *Server) listRoomRoster(ctx context.Context, roomID, viewerID, personaID string, now time.Time) ([]RoomRosterEntry, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			e.persona_id::text, pe.name, pe.user_id = $2, e.weekly_posts, e.weekly_replies, e.created_at,
+			(
+				SELECT COUNT(*)::int
+				FROM posts p
+				WHERE p.room_id = e.room_id
+				  AND p.persona_id = e.persona_id
+				  AND p.status = 'PUBLISHED'
+				  AND p.published_at >= $3
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM posts p
+				WHERE p.room_id = e.room_id
+				  AND p.persona_id = e.persona_id
+				  AND p.status = 'DRAFT'
+				  AND p.created_at >= $3
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM replies rp
+				JOIN posts p ON p.id = rp.post_id
+				WHERE p.room_id = e.room_id
+				  AND rp.persona_id = e.persona_id
+				  AND rp.created_at >= $3
+			)
+		FROM room_roster_enrollments e
+		JOIN personas pe ON pe.id = e.persona_id
+		WHERE e.room_id = $1
+		  AND ($4 = '' OR e.persona_id::text = $4)
+		ORDER BY pe.user_id = $2 DESC, pe.name ASC, e.persona_id ASC
+	`, roomID, viewerID, common.RosterWeekStart(now), personaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roster := make([]RoomRosterEntry, 0)
+	for rows.Next() {
+		var entry RoomRosterEntry
+		if err := rows.Scan(
+			&entry.PersonaID,
+			&entry.PersonaName,
+			&entry.Mine,
+			&entry.WeeklyPosts,
+			&entry.WeeklyReplies,
+			&entry.EnrolledAt,
+			&entry.Posts,
+			&entry.DraftsWaiting,
+			&entry.Replies,
+		); err != nil {
+			return nil, err
+		}
+		entry.PostsDue = common.RosterDue(entry.WeeklyPosts, now)
+		entry.RepliesDue = common.RosterDue(entry.WeeklyReplies, now)
+		roster = append(roster, entry)
+	}
+	return roster, rows.Err()
+}
+
+// handleEnrollRosterPersona enrolls one of the caller's personas in the room
+// or replaces its weekly targets.
+func (s *Server) handleEnrollRosterPersona(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "personaID"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req rosterEnrollmentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.getPersonaByID(r.Context(), userID, personaID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
+		writeInternalError(w, "could not load persona")
+		return
+	}
+	room, err := s.getRoomByID(r.Context(), roomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+	if s.rejectReadOnlyRoom(w, r, room, "/roster/"+personaID) {
+		return
+	}
+
+	// The room limit is checked in the same statement as the insert; two
+	// enrollments racing past it can overshoot by one, which is harmless.
+	var created bool
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO room_roster_enrollments(room_id, persona_id, weekly_posts, weekly_replies)
+		SELECT $1, $2, $3, $4
+		WHERE EXISTS (
+				SELECT 1
+				FROM room_roster_enrollments
+				WHERE room_id = $1 AND persona_id = $2
+		  )
+		   OR (
+				SELECT COUNT(*)
+				FROM room_roster_enrollments
+				WHERE persona_id = $2
+		  ) < $5
+		ON CONFLICT (room_id, persona_id)
+		DO UPDATE SET
+			weekly_posts = EXCLUDED.weekly_posts,
+			weekly_replies = EXCLUDED.weekly_replies,
+			checked_at = NULL,
+			updated_at = NOW()
+		RETURNING (xmax = 0)
+	`, roomID, personaID, req.WeeklyPosts, req.WeeklyReplies, common.RosterMaxRoomsPerPersona).Scan(&created)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeConflict(w, fmt.Sprintf("a persona can be enrolled in at most %d rooms", common.RosterMaxRoomsPerPersona))
+			return
+		}
+		writeInternalError(w, "could not save enrollment")
+		return
+	}
+
+	roster, err := s.listRoomRoster(r.Context(), roomID, userID, personaID, time.Now().UTC())
+	if err != nil || len(roster) == 0 {
+		writeInternalError(w, "could not load enrollment")
+		return
+	}
+
+	s.logger.Info("roster_enrolled", observability.Fields{
+		"room_id":        roomID,
+		"persona_id":     personaID,
+		"weekly_posts":   req.WeeklyPosts,
+		"weekly_replies": req.WeeklyReplies,
+		"created":        created,
+		"request_id":     requestIDFromRequest(r),
+	})
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, map[string]any{"enrollment": roster[0]})
+}
+
+func (s *Server) handleLeaveRoster(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	personaID, err := validateUUID(chi.URLParam(r, "personaID"), "persona id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tag, err := s.db.Exec(r.Context(), `
+		DELETE FROM room_roster_enrollments e
+		USING personas pe
+		WHERE e.room_id = $1
+		  AND e.persona_id = $2
+		  AND pe.id = e.persona_id
+		  AND pe.user_id = $3
+	`, roomID, personaID, userID)
+	if err != nil {
+		writeInternalError(w, "could not remove enrollment")
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		writeNotFound(w, "enrollment not found")
+		return
+	}
+
+	s.logger.Info("roster_left", observability.Fields{
+		"room_id":    roomID,
+		"persona_id": personaID,
+		"request_id": requestIDFromRequest(r),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"removed": true})
+}
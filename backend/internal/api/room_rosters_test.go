@@ -0,0 +1,26 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestRosterEnrollmentRequestValidate(t *testing.T) {
+	if err := (rosterEnrollmentRequest{WeeklyPosts: 3, WeeklyReplies: 10}).validate(); err != nil {
+		t.Fatalf("expected 3 posts and 10 replies to be valid, got %v", err)
+	}
+	if err := (rosterEnrollmentRequest{WeeklyReplies: common.RosterMaxWeeklyReplies}).validate(); err != nil {
+		t.Fatalf("expected a replies-only target at the cap to be valid, got %v", err)
+	}
+	if err := (rosterEnrollmentRequest{}).validate(); !errors.Is(err, errRosterTargetEmpty) {
+		t.Fatalf("expected an empty target to be rejected, got %v", err)
+	}
+	if err := (rosterEnrollmentRequest{WeeklyPosts: common.RosterMaxWeeklyPosts + 1}).validate(); err == nil {
+		t.Fatalf("expected posts above the cap to be rejected")
+	}
+	if err := (rosterEnrollmentRequest{WeeklyPosts: 1, WeeklyReplies: -1}).validate(); err == nil {
+		t.Fatalf("expected negative replies to be rejected")
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type TrendingTopicBattle struct {
+	ID        string    `json:"id"`
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TrendingTopic struct {
+	Rank       int                   `json:"rank"`
+	Label      string                `json:"label"`
+	Keywords   []string              `json:"keywords"`
+	Battles    int                   `json:"battles"`
+	Posts      int                   `json:"posts"`
+	Examples   []TrendingTopicBattle `json:"examples"`
+	ComputedAt time.Time             `json:"computed_at"`
+}
+
+// handleListRoomTrendingTopics returns the topics the worker clustered from
+// the room's recent battles and posts. Example battles are checked again
+// here, so one unlisted or unpublished since the last run is dropped.
+func (s *Server) handleListRoomTrendingTopics(w http.ResponseWriter, r *http.Request) {
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if s.redirectMergedRoom(w, r, roomID, "/trending-topics") {
+		return
+	}
+	if _, err := s.getRoomByID(r.Context(), roomID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not load room")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT rank, label, keywords, battles, posts, example_battle_ids::text[], computed_at
+		FROM room_trending_topics
+		WHERE room_id = $1
+		ORDER BY rank ASC
+	`, roomID)
+	if err != nil {
+		writeInternalError(w, "could not list trending topics")
+		return
+	}
+	defer rows.Close()
+
+	topics := make([]TrendingTopic, 0)
+	exampleIDs := []string{}
+	examplesByTopic := [][]string{}
+	for rows.Next() {
+		var (
+			topic TrendingTopic
+			ids   []string
+		)
+		if err := rows.Scan(&topic.Rank, &topic.Label, &topic.Keywords, &topic.Battles, &topic.Posts, &ids, &topic.ComputedAt); err != nil {
+			writeInternalError(w, "could not scan trending topic")
+			return
+		}
+		if topic.Keywords == nil {
+			topic.Keywords = []string{}
+		}
+		topic.Examples = []TrendingTopicBattle{}
+		topics = append(topics, topic)
+		examplesByTopic = append(examplesByTopic, ids)
+		exampleIDs = append(exampleIDs, ids...)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list trending topics")
+		return
+	}
+	rows.Close()
+
+	if len(exampleIDs) > 0 {
+		battles := map[string]TrendingTopicBattle{}
+		exampleRows, err := s.db.Query(r.Context(), `
+			SELECT id::text, content, created_at
+			FROM posts
+			WHERE id = ANY($1::uuid[])
+			  AND status = 'PUBLISHED'
+			  AND visibility = 'public'
+			  AND NOT hidden_from_rooms
+		`, exampleIDs)
+		if err != nil {
+			writeInternalError(w, "could not load trending battles")
+			return
+		}
+		defer exampleRows.Close()
+		for exampleRows.Next() {
+			var (
+				battle  TrendingTopicBattle
+				content string
+			)
+			if err := exampleRows.Scan(&battle.ID, &content, &battle.CreatedAt); err != nil {
+				writeInternalError(w, "could not scan trending battle")
+				return
+			}
+			battle.Topic = buildBattleCardTopic(content, "")
+			battles[battle.ID] = battle
+		}
+		if err := exampleRows.Err(); err != nil {
+			writeInternalError(w, "could not load trending battles")
+			return
+		}
+		for i, ids := range examplesByTopic {
+			for _, id := range ids {
+				if battle, ok := battles[id]; ok {
+					topics[i].Examples = append(topics[i].Examples, battle)
+				}
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"topics": topics})
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	roomMergeStatusPending = "PENDING"
+	roomMergeStatusDone    = "DONE"
+)
+
+var (
+	errRoomMergeSelf           = errors.New("a room cannot be merged into itself")
+	errRoomAlreadyMerged       = errors.New("room has already been merged")
+	errRoomMergeTargetArchived = errors.New("target room is archived")
+)
+
+type RoomMerge struct {
+	ID           int64      `json:"id"`
+	SourceRoomID string     `json:"source_room_id"`
+	TargetRoomID string     `json:"target_room_id"`
+	Status       string     `json:"status"`
+	MovedPosts   int        `json:"moved_posts"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// validateRoomMerge checks a merge against the current state of both rooms.
+// Merged rooms are always archived, so requiring an active target also rules
+// out merging into a room that is itself being forwarded somewhere else.
+func validateRoomMerge(source, target Room) error {
+	if source.ID == target.ID {
+		return errRoomMergeSelf
+	}
+	if source.MergedIntoRoomID != "" {
+		return errRoomAlreadyMerged
+	}
+	if target.ArchivedAt != nil {
+		return errRoomMergeTargetArchived
+	}
+	return nil
+}
+
+func roomRedirectLocation(roomID, suffix, rawQuery string) string {
+	location := "/rooms/" + url.PathEscape(roomID) + suffix
+	if rawQuery = strings.TrimSpace(rawQuery); rawQuery != "" {
+		location += "?" + rawQuery
+	}
+	return location
+}
+
+func (s *Server) writeRoomMoved(w http.ResponseWriter, r *http.Request, targetRoomID, suffix string) {
+	status := http.StatusMovedPermanently
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		status = http.StatusPermanentRedirect
+	}
+	w.Header().Set("Location", roomRedirectLocation(targetRoomID, suffix, r.URL.RawQuery))
+	writeJSON(w, status, map[string]any{
+		"error":   "room merged",
+		"room_id": targetRoomID,
+	})
+}
+
+// redirectMergedRoom answers requests for a merged room with a redirect to
+// the room it was merged into. Merges flatten earlier redirects, so a single
+// hop always lands on a room that is not itself merged.
+func (s *Server) redirectMergedRoom(w http.ResponseWriter, r *http.Request, roomID, suffix string) bool {
+	var targetRoomID string
+	err := s.db.QueryRow(r.Context(), `
+		SELECT COALESCE(merged_into_room_id::text, '')
+		FROM rooms
+		WHERE id = $1
+	`, roomID).Scan(&targetRoomID)
+	if err != nil || targetRoomID == "" {
+		return false
+	}
+	s.writeRoomMoved(w, r, targetRoomID, suffix)
+	return true
+}
+
+// rejectReadOnlyRoom stops writes to archived rooms and to event rooms
+// outside their window. Writes to a merged room are redirected to the merge
+// target instead of failing.
+func (s *Server) rejectReadOnlyRoom(w http.ResponseWriter, r *http.Request, room Room, suffix string) bool {
+	if room.ArchivedAt == nil {
+		if err := roomEventWriteError(room.Event); err != nil {
+			writeConflict(w, err.Error())
+			return true
+		}
+		return false
+	}
+	if room.MergedIntoRoomID != "" {
+		s.writeRoomMoved(w, r, room.MergedIntoRoomID, suffix)
+		return true
+	}
+	writeConflict(w, "room is archived")
+	return true
+}
+
+func (s *Server) handleArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var room Room
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE rooms
+		SET archived_at = COALESCE(archived_at, NOW())
+		WHERE id = $1
+		RETURNING id::text, slug, name, description, created_at, archived_at, COALESCE(merged_into_room_id::text, '')
+	`, roomID).Scan(&room.ID, &room.Slug, &room.Name, &room.Description, &room.CreatedAt, &room.ArchivedAt, &room.MergedIntoRoomID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room not found")
+			return
+		}
+		writeInternalError(w, "could not archive room")
+		return
+	}
+
+	s.logger.Info("room_archived", observability.Fields{
+		"room_id": room.ID,
+		"user_id": userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"room": room})
+}
+
+func (s *Server) handleMergeRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	sourceRoomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		TargetRoomID string `json:"target_room_id"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	targetRoomID, err := validateUUID(req.TargetRoomID, "target_room_id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	rooms, err := lockRoomsForMerge(r.Context(), tx, sourceRoomID, targetRoomID)
+	if err != nil {
+		writeInternalError(w, "could not load rooms")
+		return
+	}
+	source, ok := rooms[sourceRoomID]
+	if !ok {
+		writeNotFound(w, "room not found")
+		return
+	}
+	target, ok := rooms[targetRoomID]
+	if !ok {
+		writeNotFound(w, "target room not found")
+		return
+	}
+	if err := validateRoomMerge(source, target); err != nil {
+		if errors.Is(err, errRoomMergeSelf) {
+			writeBadRequest(w, err.Error())
+			return
+		}
+		writeConflict(w, err.Error())
+		return
+	}
+
+	// The source goes read-only and starts redirecting straight away; the
+	// worker moves its posts over in batches. Anything already forwarded to
+	// the source, including merges still in flight, is re-pointed at the new
+	// target so redirects never chain.
+	if _, err := tx.Exec(r.Context(), `
+		UPDATE rooms
+		SET archived_at = COALESCE(archived_at, NOW()),
+			merged_into_room_id = $2
+		WHERE id = $1
+	`, sourceRoomID, targetRoomID); err != nil {
+		writeInternalError(w, "could not merge room")
+		return
+	}
+	if _, err := tx.Exec(r.Context(), `
+		UPDATE rooms
+		SET merged_into_room_id = $2
+		WHERE merged_into_room_id = $1
+	`, sourceRoomID, targetRoomID); err != nil {
+		writeInternalError(w, "could not merge room")
+		return
+	}
+	if _, err := tx.Exec(r.Context(), `
+		UPDATE room_merges
+		SET target_room_id = $2, updated_at = NOW()
+		WHERE target_room_id = $1
+		  AND status = $3
+	`, sourceRoomID, targetRoomID, roomMergeStatusPending); err != nil {
+		writeInternalError(w, "could not merge room")
+		return
+	}
+
+	var merge RoomMerge
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO room_merges(source_room_id, target_room_id, requested_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, source_room_id::text, target_room_id::text, status, moved_posts, created_at, updated_at, completed_at
+	`, sourceRoomID, targetRoomID, userID).Scan(
+		&merge.ID,
+		&merge.SourceRoomID,
+		&merge.TargetRoomID,
+		&merge.Status,
+		&merge.MovedPosts,
+		&merge.CreatedAt,
+		&merge.UpdatedAt,
+		&merge.CompletedAt,
+	)
+	if err != nil {
+		writeInternalError(w, "could not queue room merge")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not commit room merge")
+		return
+	}
+
+	s.logger.Info("room_merge_queued", observability.Fields{
+		"merge_id":       merge.ID,
+		"source_room_id": sourceRoomID,
+		"target_room_id": targetRoomID,
+		"user_id":        userID,
+	})
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"merge": merge})
+}
+
+func (s *Server) handleGetRoomMerge(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	roomID, err := validateUUID(chi.URLParam(r, "id"), "room id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var merge RoomMerge
+	err = s.db.QueryRow(r.Context(), `
+		SELECT id, source_room_id::text, target_room_id::text, status, moved_posts, created_at, updated_at, completed_at
+		FROM room_merges
+		WHERE source_room_id = $1
+	`, roomID).Scan(
+		&merge.ID,
+		&merge.SourceRoomID,
+		&merge.TargetRoomID,
+		&merge.Status,
+		&merge.MovedPosts,
+		&merge.CreatedAt,
+		&merge.UpdatedAt,
+		&merge.CompletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "room merge not found")
+			return
+		}
+		writeInternalError(w, "could not load room merge")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"merge": merge})
+}
+
+func lockRoomsForMerge(ctx context.Context, tx pgx.Tx, roomIDs ...string) (map[string]Room, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id::text, slug, name, description, created_at, archived_at, COALESCE(merged_into_room_id::text, '')
+		FROM rooms
+		WHERE id = ANY($1::uuid[])
+		ORDER BY id
+		FOR UPDATE
+	`, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rooms := make(map[string]Room, len(roomIDs))
+	for rows.Next() {
+		var room Room
+		if err := rows.Scan(&room.ID, &room.Slug, &room.Name, &room.Description, &room.CreatedAt, &room.ArchivedAt, &room.MergedIntoRoomID); err != nil {
+			return nil, err
+		}
+		rooms[room.ID] = room
+	}
+	return rooms, rows.Err()
+}
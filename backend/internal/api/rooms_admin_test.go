@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateRoomMerge(t *testing.T) {
+	archivedAt := time.Now()
+	active := Room{ID: "room-a"}
+	target := Room{ID: "room-b"}
+
+	if err := validateRoomMerge(active, target); err != nil {
+		t.Fatalf("expected merge to be allowed, got %v", err)
+	}
+	if err := validateRoomMerge(Room{ID: "room-a", ArchivedAt: &archivedAt}, target); err != nil {
+		t.Fatalf("expected archived source to be mergeable, got %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		source Room
+		target Room
+		want   error
+	}{
+		{name: "self", source: active, target: active, want: errRoomMergeSelf},
+		{name: "already merged", source: Room{ID: "room-a", ArchivedAt: &archivedAt, MergedIntoRoomID: "room-c"}, target: target, want: errRoomAlreadyMerged},
+		{name: "archived target", source: active, target: Room{ID: "room-b", ArchivedAt: &archivedAt}, want: errRoomMergeTargetArchived},
+	}
+	for _, tc := range cases {
+		if err := validateRoomMerge(tc.source, tc.target); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestRoomRedirectLocation(t *testing.T) {
+	if got := roomRedirectLocation("room-b", "/posts", ""); got != "/rooms/room-b/posts" {
+		t.Fatalf("unexpected location %q", got)
+	}
+	if got := roomRedirectLocation("room-b", "/battles", "limit=10"); got != "/rooms/room-b/battles?limit=10" {
+		t.Fatalf("unexpected location %q", got)
+	}
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const safetyLexiconMaxTerms = 2000
+
+type SafetyLexiconTerm struct {
+	ID        int64     `json:"id"`
+	Term      string    `json:"term"`
+	Language  string    `json:"language"`
+	Severity  string    `json:"severity"`
+	Category  string    `json:"category"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type safetyLexiconRequest struct {
+	Term     string `json:"term"`
+	Language string `json:"language"`
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+}
+
+// normalizeLexiconTerm validates an admin-submitted lexicon entry. Category
+// defaults to profanity; severity and language are required.
+func normalizeLexiconTerm(req safetyLexiconRequest) (safety.LexiconTerm, error) {
+	terms := safety.NormalizeTerms([]string{req.Term})
+	if len(terms) == 0 {
+		return safety.LexiconTerm{}, errors.New("term is required")
+	}
+	if len([]rune(terms[0])) > safetyRuleTermMaxRunes {
+		return safety.LexiconTerm{}, errors.New("term is too long")
+	}
+	language := safety.NormalizeLanguage(req.Language)
+	if language == "" {
+		return safety.LexiconTerm{}, errors.New("language must be tr or en")
+	}
+	severity, err := validateLexiconSeverity(req.Severity)
+	if err != nil {
+		return safety.LexiconTerm{}, err
+	}
+	if severity == "" {
+		return safety.LexiconTerm{}, errors.New("severity must be warn or block")
+	}
+	category, err := validateLexiconCategory(req.Category)
+	if err != nil {
+		return safety.LexiconTerm{}, err
+	}
+	if category == "" {
+		category = safety.CategoryProfanity
+	}
+	return safety.LexiconTerm{
+		Term:     terms[0],
+		Language: language,
+		Severity: severity,
+		Category: category,
+	}, nil
+}
+
+func validateLexiconSeverity(value string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case "", safety.SeverityWarn, safety.SeverityBlock:
+		return clean, nil
+	default:
+		return "", errors.New("severity must be warn or block")
+	}
+}
+
+func validateLexiconCategory(value string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case "", safety.CategoryProfanity, safety.CategoryBrandSafety:
+		return clean, nil
+	default:
+		return "", errors.New("category must be profanity or brand_safety")
+	}
+}
+
+func parseLexiconTermID(raw string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid lexicon term id")
+	}
+	return id, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s *Server) handleListSafetyLexicon(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	query := r.URL.Query()
+	language := ""
+	if raw := strings.TrimSpace(query.Get("language")); raw != "" {
+		language = safety.NormalizeLanguage(raw)
+		if language == "" {
+			writeBadRequest(w, "language must be tr or en")
+			return
+		}
+	}
+	severity, err := validateLexiconSeverity(query.Get("severity"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	category, err := validateLexiconCategory(query.Get("category"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id, term, language, severity, category, created_at, updated_at
+		FROM safety_lexicon_terms
+		WHERE ($1 = '' OR language = $1)
+		  AND ($2 = '' OR severity = $2)
+		  AND ($3 = '' OR category = $3)
+		ORDER BY language, term
+	`, language, severity, category)
+	if err != nil {
+		writeInternalError(w, "could not load safety lexicon")
+		return
+	}
+	defer rows.Close()
+
+	terms := make([]SafetyLexiconTerm, 0)
+	for rows.Next() {
+		var term SafetyLexiconTerm
+		if err := rows.Scan(&term.ID, &term.Term, &term.Language, &term.Severity, &term.Category, &term.CreatedAt, &term.UpdatedAt); err != nil {
+			writeInternalError(w, "could not scan safety lexicon")
+			return
+		}
+		terms = append(terms, term)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load safety lexicon")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"terms": terms})
+}
+
+func (s *Server) handleCreateSafetyLexiconTerm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req safetyLexiconRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	entry, err := normalizeLexiconTerm(req)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var count int
+	if err := s.db.QueryRow(r.Context(), `SELECT COUNT(*)::int FROM safety_lexicon_terms`).Scan(&count); err != nil {
+		writeInternalError(w, "could not count safety lexicon")
+		return
+	}
+	if count >= safetyLexiconMaxTerms {
+		writeBadRequest(w, "too many terms")
+		return
+	}
+
+	term := SafetyLexiconTerm{Term: entry.Term, Language: entry.Language, Severity: entry.Severity, Category: entry.Category}
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO safety_lexicon_terms(term, language, severity, category, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id, created_at, updated_at
+	`, entry.Term, entry.Language, entry.Severity, entry.Category, userID).Scan(&term.ID, &term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, "term already exists for this language")
+			return
+		}
+		writeInternalError(w, "could not save lexicon term")
+		return
+	}
+
+	s.logSafetyLexiconChange("created", term, userID)
+	writeJSON(w, http.StatusCreated, map[string]any{"term": term})
+}
+
+func (s *Server) handleUpdateSafetyLexiconTerm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseLexiconTermID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req safetyLexiconRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	entry, err := normalizeLexiconTerm(req)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	term := SafetyLexiconTerm{ID: id, Term: entry.Term, Language: entry.Language, Severity: entry.Severity, Category: entry.Category}
+	err = s.db.QueryRow(r.Context(), `
+		UPDATE safety_lexicon_terms
+		SET term = $2,
+			language = $3,
+			severity = $4,
+			category = $5,
+			updated_by = $6,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, id, entry.Term, entry.Language, entry.Severity, entry.Category, userID).Scan(&term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "lexicon term not found")
+			return
+		}
+		if isUniqueViolation(err) {
+			writeConflict(w, "term already exists for this language")
+			return
+		}
+		writeInternalError(w, "could not update lexicon term")
+		return
+	}
+
+	s.logSafetyLexiconChange("updated", term, userID)
+	writeJSON(w, http.StatusOK, map[string]any{"term": term})
+}
+
+func (s *Server) handleDeleteSafetyLexiconTerm(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseLexiconTermID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	term := SafetyLexiconTerm{ID: id}
+	err = s.db.QueryRow(r.Context(), `
+		DELETE FROM safety_lexicon_terms
+		WHERE id = $1
+		RETURNING term, language, severity, category, created_at, updated_at
+	`, id).Scan(&term.Term, &term.Language, &term.Severity, &term.Category, &term.CreatedAt, &term.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "lexicon term not found")
+			return
+		}
+		writeInternalError(w, "could not delete lexicon term")
+		return
+	}
+
+	s.logSafetyLexiconChange("deleted", term, userID)
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": true})
+}
+
+// logSafetyLexiconChange records the edit and drops this process's cached
+// rules; other API and worker processes pick it up on their next sync.
+func (s *Server) logSafetyLexiconChange(action string, term SafetyLexiconTerm, userID string) {
+	s.safetyRules.Invalidate()
+	s.logger.Info("safety_lexicon_changed", observability.Fields{
+		"action":   action,
+		"term_id":  term.ID,
+		"language": term.Language,
+		"severity": term.Severity,
+		"category": term.Category,
+		"user_id":  userID,
+	})
+}
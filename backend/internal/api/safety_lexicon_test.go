@@ -0,0 +1,35 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/safety"
+)
+
+func TestNormalizeLexiconTerm(t *testing.T) {
+	term, err := normalizeLexiconTerm(safetyLexiconRequest{Term: "  Rakip   MARKA ", Language: "TR", Severity: "Block"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if term.Term != "rakip marka" || term.Language != safety.LanguageTR || term.Severity != safety.SeverityBlock {
+		t.Fatalf("unexpected normalized term %+v", term)
+	}
+	if term.Category != safety.CategoryProfanity {
+		t.Fatalf("expected default profanity category, got %q", term.Category)
+	}
+
+	invalid := []safetyLexiconRequest{
+		{Term: "   ", Language: "en", Severity: "warn"},
+		{Term: strings.Repeat("a", safetyRuleTermMaxRunes+1), Language: "en", Severity: "warn"},
+		{Term: "casino", Language: "de", Severity: "warn"},
+		{Term: "casino", Language: "en"},
+		{Term: "casino", Language: "en", Severity: "mute"},
+		{Term: "casino", Language: "en", Severity: "warn", Category: "politics"},
+	}
+	for _, req := range invalid {
+		if _, err := normalizeLexiconTerm(req); err == nil {
+			t.Fatalf("expected %+v to be rejected", req)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/safety"
+)
+
+// redTeamCheck routes a red-team case through the validation its surface
+// gets in the API, with the given rules and length limits.
+func redTeamCheck(rules safety.Rules, cfg config.Config) func(safety.RedTeamCase) error {
+	return func(c safety.RedTeamCase) error {
+		switch c.Surface {
+		case safety.RedTeamSurfacePersona:
+			return redTeamPersonaCheck(rules, c)
+		case safety.RedTeamSurfacePreview:
+			return rules.ForLanguage(c.Language).Validate(c.Input, cfg.DraftMaxLen)
+		case safety.RedTeamSurfaceBattleTopic:
+			_, err := validateBattleTopic(rules, c.Input)
+			return err
+		case safety.RedTeamSurfaceTemplateRules:
+			return checkTemplatePromptRules(rules, c.Input)
+		default:
+			return fmt.Errorf("unknown surface %q", c.Surface)
+		}
+	}
+}
+
+// redTeamPersonaCheck puts the input into one field of an otherwise harmless
+// persona and runs persona creation's validation and safety check.
+func redTeamPersonaCheck(rules safety.Rules, c safety.RedTeamCase) error {
+	language := c.Language
+	if language == "" {
+		language = "en"
+	}
+	name, bio, tone := "Red Team", "", ""
+	writingSamples := []string{"Short answers first.", "Examples beat theory.", "Measure before tuning."}
+	var catchphrases []string
+	switch c.Field {
+	case "name":
+		name = c.Input
+	case "", "bio":
+		bio = c.Input
+	case "tone":
+		tone = c.Input
+	case "writing_sample":
+		writingSamples[0] = c.Input
+	case "catchphrase":
+		catchphrases = []string{c.Input}
+	default:
+		return fmt.Errorf("unknown persona field %q", c.Field)
+	}
+	input, err := normalizePersonaInput(name, bio, tone, writingSamples, nil, catchphrases, language, 1)
+	if err != nil {
+		return err
+	}
+	return checkPersonaInputSafety(rules, input)
+}
+
+// handleRunSafetyRedTeam replays the shipped red-team corpus, plus any cases
+// in the request body, through the live safety rules and reports which
+// expectations no longer hold.
+func (s *Server) handleRunSafetyRedTeam(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	var req struct {
+		Cases      []safety.RedTeamCase `json:"cases"`
+		SkipCorpus bool                 `json:"skip_corpus"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeBadRequest(w, err.Error())
+			return
+		}
+	}
+
+	cases := make([]safety.RedTeamCase, 0)
+	if !req.SkipCorpus {
+		corpus, err := safety.RedTeamCorpus()
+		if err != nil {
+			writeInternalError(w, "could not load red-team corpus")
+			return
+		}
+		cases = append(cases, corpus...)
+	}
+	cases = append(cases, req.Cases...)
+	if err := safety.ValidateRedTeamCases(cases); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	report := safety.RunRedTeam(cases, redTeamCheck(s.loadSafetyRules(r.Context()), s.cfg))
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"testing"
+
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/safety"
+)
+
+// TestSafetyRedTeamCorpus is the release gate for the safety pipeline: every
+// shipped red-team case must still be blocked or allowed as expected.
+func TestSafetyRedTeamCorpus(t *testing.T) {
+	cases, err := safety.RedTeamCorpus()
+	if err != nil {
+		t.Fatalf("load corpus: %v", err)
+	}
+	report := safety.RunRedTeam(cases, redTeamCheck(safety.DefaultRules(), config.Config{DraftMaxLen: 500}))
+	for _, failure := range report.Failures {
+		t.Errorf("%s (%s): expected %s, got %s %s", failure.ID, failure.Surface, failure.Expect, failure.Outcome, failure.Reason)
+	}
+	for _, surface := range []string{
+		safety.RedTeamSurfacePersona,
+		safety.RedTeamSurfacePreview,
+		safety.RedTeamSurfaceBattleTopic,
+		safety.RedTeamSurfaceTemplateRules,
+	} {
+		if report.Surfaces[surface].Total == 0 {
+			t.Errorf("expected corpus cases for surface %s", surface)
+		}
+	}
+}
+
+func TestCheckPersonaInputSafetySkipsDoNotSay(t *testing.T) {
+	input, err := normalizePersonaInput("Calm", "Keeps it civil.", "", []string{"One.", "Two.", "Three."}, []string{"shit"}, nil, "en", 1)
+	if err != nil {
+		t.Fatalf("normalize persona: %v", err)
+	}
+	if err := checkPersonaInputSafety(safety.DefaultRules(), input); err != nil {
+		t.Fatalf("expected do_not_say to be skipped, got %v", err)
+	}
+	input.Bio = "Says shit a lot."
+	if err := checkPersonaInputSafety(safety.DefaultRules(), input); err == nil || safety.RuleOf(err) != safety.RuleProfanity {
+		t.Fatalf("expected profanity in bio to be blocked, got %v", err)
+	}
+}
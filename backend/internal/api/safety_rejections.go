@@ -0,0 +1,726 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	safetyStatusRejected = "REJECTED"
+	safetyStatusAppealed = "APPEALED"
+	safetyStatusApproved = "APPROVED"
+	safetyStatusDenied   = "DENIED"
+
+	safetyNoteMaxRunes      = 500
+	safetyRuleTermsMax      = 200
+	safetyRuleTermMaxRunes  = 80
+	safetyRuleMaxLinksLimit = 20
+	safetyReplaySampleSize  = 200
+	safetyReplayListedIDs   = 50
+)
+
+type SafetyRejection struct {
+	ID             int64          `json:"id"`
+	UserID         string         `json:"user_id,omitempty"`
+	PersonaID      string         `json:"persona_id,omitempty"`
+	RoomID         string         `json:"room_id,omitempty"`
+	PostID         string         `json:"post_id,omitempty"`
+	Source         string         `json:"source"`
+	Rule           string         `json:"rule"`
+	Reason         string         `json:"reason"`
+	Content        string         `json:"content"`
+	Metadata       map[string]any `json:"metadata"`
+	Status         string         `json:"status"`
+	AppealNote     string         `json:"appeal_note"`
+	AppealedAt     *time.Time     `json:"appealed_at,omitempty"`
+	ReviewNote     string         `json:"review_note"`
+	ReviewedAt     *time.Time     `json:"reviewed_at,omitempty"`
+	ResolvedPostID string         `json:"resolved_post_id,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type safetySample struct {
+	ID      int64
+	Rule    string
+	Content string
+	MaxLen  int
+}
+
+// SafetyRulesReplay summarizes how proposed rules would have treated recent
+// rejections, so admins can tune rules against real samples before saving.
+type SafetyRulesReplay struct {
+	Samples        int            `json:"samples"`
+	Cleared        int            `json:"cleared"`
+	StillRejected  int            `json:"still_rejected"`
+	ClearedByRule  map[string]int `json:"cleared_by_rule"`
+	RejectedByRule map[string]int `json:"rejected_by_rule"`
+	ClearedIDs     []int64        `json:"cleared_ids"`
+}
+
+func validateSafetyStatus(value string) (string, error) {
+	clean := strings.ToUpper(strings.TrimSpace(value))
+	switch clean {
+	case "", safetyStatusRejected, safetyStatusAppealed, safetyStatusApproved, safetyStatusDenied:
+		return clean, nil
+	default:
+		return "", errors.New("invalid status")
+	}
+}
+
+func normalizeSafetyNote(value string) (string, error) {
+	clean := strings.TrimSpace(value)
+	if len([]rune(clean)) > safetyNoteMaxRunes {
+		return "", errors.New("note is too long")
+	}
+	return clean, nil
+}
+
+func normalizeSafetyRules(maxLinks *int, allowedTerms, blockedTerms []string) (safety.Rules, error) {
+	rules := safety.DefaultRules()
+	if maxLinks != nil {
+		if *maxLinks < 0 || *maxLinks > safetyRuleMaxLinksLimit {
+			return safety.Rules{}, errors.New("max_links must be between 0 and " + strconv.Itoa(safetyRuleMaxLinksLimit))
+		}
+		rules.MaxLinks = *maxLinks
+	}
+	rules.AllowedTerms = safety.NormalizeTerms(allowedTerms)
+	rules.BlockedTerms = safety.NormalizeTerms(blockedTerms)
+	for _, terms := range [][]string{rules.AllowedTerms, rules.BlockedTerms} {
+		if len(terms) > safetyRuleTermsMax {
+			return safety.Rules{}, errors.New("too many terms")
+		}
+		for _, term := range terms {
+			if len([]rune(term)) > safetyRuleTermMaxRunes {
+				return safety.Rules{}, errors.New("term is too long")
+			}
+		}
+	}
+	return rules, nil
+}
+
+func replaySafetyRules(rules safety.Rules, samples []safetySample) SafetyRulesReplay {
+	replay := SafetyRulesReplay{
+		Samples:        len(samples),
+		ClearedByRule:  map[string]int{},
+		RejectedByRule: map[string]int{},
+		ClearedIDs:     make([]int64, 0),
+	}
+	for _, sample := range samples {
+		err := rules.Validate(sample.Content, sample.MaxLen)
+		if err == nil {
+			replay.Cleared++
+			replay.ClearedByRule[sample.Rule]++
+			if len(replay.ClearedIDs) < safetyReplayListedIDs {
+				replay.ClearedIDs = append(replay.ClearedIDs, sample.ID)
+			}
+			continue
+		}
+		replay.StillRejected++
+		replay.RejectedByRule[safety.RuleOf(err)]++
+	}
+	return replay
+}
+
+func (s *Server) loadSafetyRules(ctx context.Context) safety.Rules {
+	rules, err := s.safetyRules.Load(ctx, s.cfg.SafetySyncEvery, s.db)
+	if err != nil {
+		s.logger.Warn("safety_rules_load_failed", observability.Fields{"error": err.Error()})
+	}
+	return rules
+}
+
+// checkGeneratedContent runs the safety rules over generated content and
+// stores a rejection the owner can review and appeal. The returned id is 0
+// when the content passed or the rejection could not be stored.
+func (s *Server) checkGeneratedContent(ctx context.Context, rejection common.SafetyRejection) (int64, error) {
+	rules := s.loadSafetyRules(ctx).ForLanguage(rejection.Language)
+	violation := rules.Validate(rejection.Content, rejection.MaxLen)
+	if violation == nil {
+		if warnings := rules.Warnings(rejection.Content); len(warnings) > 0 {
+			s.logger.Warn("safety_lexicon_warned", common.SafetyWarningFields(rejection, warnings))
+		}
+		return 0, nil
+	}
+	rejectionID, err := common.RecordSafetyRejection(ctx, s.db, rejection, violation)
+	if err != nil {
+		s.logger.Error("safety_rejection_write_failed", observability.Fields{
+			"source": rejection.Source,
+			"error":  err.Error(),
+		})
+		return 0, violation
+	}
+	return rejectionID, violation
+}
+
+func writeSafetyRejected(w http.ResponseWriter, rejectionID int64, violation error) {
+	payload := map[string]any{"error": violation.Error()}
+	if rejectionID > 0 {
+		payload["rejection_id"] = rejectionID
+	}
+	writeJSON(w, http.StatusBadRequest, payload)
+}
+
+func (s *Server) handleListSafetyRejections(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	status, err := validateSafetyStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	s.writeSafetyRejectionPage(w, r, userID, status)
+}
+
+func (s *Server) handleAppealSafetyRejection(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	rejectionID, err := parseSafetyRejectionID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := decodeJSONAllowEmpty(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	note, err := normalizeSafetyNote(req.Note)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rejection, err := scanSafetyRejection(s.db.QueryRow(r.Context(), `
+		UPDATE safety_rejections
+		SET status = $3, appeal_note = $4, appealed_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = $5
+		RETURNING `+safetyRejectionColumns+`
+	`, rejectionID, userID, safetyStatusAppealed, note, safetyStatusRejected))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			writeInternalError(w, "could not appeal rejection")
+			return
+		}
+		var exists bool
+		if err := s.db.QueryRow(r.Context(), `
+			SELECT EXISTS(SELECT 1 FROM safety_rejections WHERE id = $1 AND user_id = $2)
+		`, rejectionID, userID).Scan(&exists); err != nil {
+			writeInternalError(w, "could not load rejection")
+			return
+		}
+		if !exists {
+			writeNotFound(w, "rejection not found")
+			return
+		}
+		writeConflict(w, "rejection has already been appealed")
+		return
+	}
+	rejection.UserID = ""
+
+	writeJSON(w, http.StatusOK, map[string]any{"rejection": rejection})
+}
+
+func (s *Server) handleAdminListSafetyRejections(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	status, err := validateSafetyStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if status == "" {
+		status = safetyStatusAppealed
+	}
+	s.writeSafetyRejectionPage(w, r, "", status)
+}
+
+// writeSafetyRejectionPage lists rejections newest first. An empty userID
+// lists every user's rejections and is only reachable by admins.
+func (s *Server) writeSafetyRejectionPage(w http.ResponseWriter, r *http.Request, userID, status string) {
+	cursor, limit, err := parseAuditLogPage(r.URL.Query().Get("cursor"), r.URL.Query().Get("limit"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT `+safetyRejectionColumns+`
+		FROM safety_rejections
+		WHERE ($1 = '' OR user_id::text = $1)
+		  AND ($2 = '' OR status = $2)
+		  AND ($3::bigint = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, userID, status, cursor, limit)
+	if err != nil {
+		writeInternalError(w, "could not list rejections")
+		return
+	}
+	defer rows.Close()
+
+	rejections := make([]SafetyRejection, 0, limit)
+	for rows.Next() {
+		rejection, err := scanSafetyRejection(rows)
+		if err != nil {
+			writeInternalError(w, "could not scan rejection")
+			return
+		}
+		if userID != "" {
+			rejection.UserID = ""
+		}
+		rejections = append(rejections, rejection)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list rejections")
+		return
+	}
+
+	nextCursor := ""
+	if len(rejections) == limit {
+		nextCursor = strconv.FormatInt(rejections[len(rejections)-1].ID, 10)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"rejections":  rejections,
+		"next_cursor": nextCursor,
+	})
+}
+
+func (s *Server) handleApproveSafetyRejection(w http.ResponseWriter, r *http.Request) {
+	s.reviewSafetyRejection(w, r, safetyStatusApproved)
+}
+
+func (s *Server) handleDenySafetyRejection(w http.ResponseWriter, r *http.Request) {
+	s.reviewSafetyRejection(w, r, safetyStatusDenied)
+}
+
+// reviewSafetyRejection settles an appeal. Approving publishes the content
+// the way it would have been published had it passed: drafts come back as a
+// draft, replies are added to the thread and persona answers are posted.
+// Previews were never stored, so approving one only records the decision.
+func (s *Server) reviewSafetyRejection(w http.ResponseWriter, r *http.Request, decision string) {
+	adminID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	rejectionID, err := parseSafetyRejectionID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := decodeJSONAllowEmpty(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	note, err := normalizeSafetyNote(req.Note)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	rejection, err := scanSafetyRejection(tx.QueryRow(r.Context(), `
+		SELECT `+safetyRejectionColumns+`
+		FROM safety_rejections
+		WHERE id = $1
+		FOR UPDATE
+	`, rejectionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "rejection not found")
+			return
+		}
+		writeInternalError(w, "could not load rejection")
+		return
+	}
+	if rejection.Status != safetyStatusAppealed {
+		writeConflict(w, "only appealed rejections can be reviewed")
+		return
+	}
+
+	resolvedPostID := ""
+	if decision == safetyStatusApproved {
+		resolvedPostID, err = publishApprovedRejection(r.Context(), tx, rejection)
+		if err != nil {
+			var conflict safetyPublishConflict
+			if errors.As(err, &conflict) {
+				writeConflict(w, conflict.Error())
+				return
+			}
+			writeInternalError(w, "could not publish approved content")
+			return
+		}
+	}
+
+	rejection, err = scanSafetyRejection(tx.QueryRow(r.Context(), `
+		UPDATE safety_rejections
+		SET status = $2,
+			review_note = $3,
+			reviewed_by = $4,
+			reviewed_at = NOW(),
+			resolved_post_id = NULLIF($5, '')::uuid
+		WHERE id = $1
+		RETURNING `+safetyRejectionColumns+`
+	`, rejectionID, decision, note, adminID, resolvedPostID))
+	if err != nil {
+		writeInternalError(w, "could not save review")
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not commit review")
+		return
+	}
+
+	s.logger.Info("safety_rejection_reviewed", observability.Fields{
+		"rejection_id": rejectionID,
+		"decision":     decision,
+		"rule":         rejection.Rule,
+		"source":       rejection.Source,
+		"user_id":      adminID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"rejection": rejection})
+}
+
+type safetyPublishConflict struct {
+	message string
+}
+
+func (e safetyPublishConflict) Error() string {
+	return e.message
+}
+
+func publishApprovedRejection(ctx context.Context, tx pgx.Tx, rejection SafetyRejection) (string, error) {
+	content := strings.TrimSpace(rejection.Content)
+	if content == "" {
+		return "", safetyPublishConflict{message: "rejected content is empty"}
+	}
+
+	switch rejection.Source {
+	case common.SafetySourceDraft:
+		if rejection.PersonaID == "" || rejection.RoomID == "" {
+			return "", safetyPublishConflict{message: "persona or room no longer exists"}
+		}
+		var postID string
+		err := tx.QueryRow(ctx, `
+			INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content)
+			SELECT COALESCE(rm.merged_into_room_id, rm.id), $2, $3, 'AI', 'DRAFT', $4
+			FROM rooms rm
+			WHERE rm.id = $1
+			RETURNING id::text
+		`, rejection.RoomID, rejection.PersonaID, rejection.UserID, content).Scan(&postID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", safetyPublishConflict{message: "persona or room no longer exists"}
+		}
+		return postID, err
+
+	case common.SafetySourceReply:
+		if rejection.PersonaID == "" || rejection.PostID == "" {
+			return "", safetyPublishConflict{message: "persona or post no longer exists"}
+		}
+		var roomID, postContent string
+		if err := tx.QueryRow(ctx, `
+			SELECT room_id::text, content
+			FROM posts
+			WHERE id = $1
+		`, rejection.PostID).Scan(&roomID, &postContent); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO replies(post_id, persona_id, authored_by, content)
+			VALUES ($1, $2, 'AI', $3)
+		`, rejection.PostID, rejection.PersonaID, content); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return "", safetyPublishConflict{message: "persona has already replied to this post"}
+			}
+			return "", err
+		}
+		metadata := map[string]any{
+			"post_id":       rejection.PostID,
+			"room_id":       roomID,
+			"post_preview":  common.TruncateRunes(postContent, 200),
+			"reply_preview": common.TruncateRunes(content, 200),
+		}
+		if err := common.InsertPersonaActivityEvent(ctx, tx, rejection.PersonaID, "reply_generated", metadata); err != nil {
+			return "", err
+		}
+		if err := common.InsertPersonaActivityEvent(ctx, tx, rejection.PersonaID, "thread_participated", metadata); err != nil {
+			return "", err
+		}
+		return rejection.PostID, nil
+
+	case common.SafetySourcePersonaAnswer:
+		questionID, _ := rejection.Metadata["question_id"].(string)
+		if rejection.PersonaID == "" || rejection.RoomID == "" || strings.TrimSpace(questionID) == "" {
+			return "", safetyPublishConflict{message: "question or room no longer exists"}
+		}
+		var questionStatus string
+		if err := tx.QueryRow(ctx, `
+			SELECT status
+			FROM persona_questions
+			WHERE id = $1
+			FOR UPDATE
+		`, questionID).Scan(&questionStatus); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", safetyPublishConflict{message: "question or room no longer exists"}
+			}
+			return "", err
+		}
+		if questionStatus != questionStatusApproved {
+			return "", safetyPublishConflict{message: "question is no longer waiting for an answer"}
+		}
+		var postID string
+		if err := tx.QueryRow(ctx, `
+			INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at)
+			VALUES ($1, $2, $3, 'AI', 'PUBLISHED', $4, NOW())
+			RETURNING id::text
+		`, rejection.RoomID, rejection.PersonaID, rejection.UserID, content).Scan(&postID); err != nil {
+			return "", err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE persona_questions
+			SET status = 'ANSWERED',
+				answer_post_id = $2,
+				answered_at = NOW(),
+				error = NULL,
+				updated_at = NOW()
+			WHERE id = $1
+		`, questionID, postID); err != nil {
+			return "", err
+		}
+		metadata := map[string]any{
+			"post_id":      postID,
+			"room_id":      rejection.RoomID,
+			"question_id":  questionID,
+			"post_preview": common.TruncateRunes(content, 220),
+		}
+		if err := common.InsertPersonaActivityEvent(ctx, tx, rejection.PersonaID, "post_created", metadata); err != nil {
+			return "", err
+		}
+		return postID, nil
+
+	default:
+		return "", nil
+	}
+}
+
+func (s *Server) handleGetSafetyRules(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	rules, err := common.LoadSafetyRules(r.Context(), s.db)
+	if err != nil {
+		writeInternalError(w, "could not load safety rules")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+type safetyRulesRequest struct {
+	MaxLinks     *int     `json:"max_links"`
+	AllowedTerms []string `json:"allowed_terms"`
+	BlockedTerms []string `json:"blocked_terms"`
+}
+
+func (s *Server) handleUpdateSafetyRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	var req safetyRulesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	rules, err := normalizeSafetyRules(req.MaxLinks, req.AllowedTerms, req.BlockedTerms)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := s.db.Exec(r.Context(), `
+		INSERT INTO safety_rules(id, max_links, allowed_terms, blocked_terms, updated_by, updated_at)
+		VALUES (1, $1, $2, $3, $4, NOW())
+		ON CONFLICT (id)
+		DO UPDATE SET
+			max_links = EXCLUDED.max_links,
+			allowed_terms = EXCLUDED.allowed_terms,
+			blocked_terms = EXCLUDED.blocked_terms,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, rules.MaxLinks, rules.AllowedTerms, rules.BlockedTerms, userID); err != nil {
+		writeInternalError(w, "could not save safety rules")
+		return
+	}
+	s.safetyRules.Invalidate()
+
+	s.logger.Info("safety_rules_changed", observability.Fields{
+		"max_links":     rules.MaxLinks,
+		"allowed_terms": len(rules.AllowedTerms),
+		"blocked_terms": len(rules.BlockedTerms),
+		"user_id":       userID,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+func (s *Server) handleReplaySafetyRules(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	var req safetyRulesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	rules, err := normalizeSafetyRules(req.MaxLinks, req.AllowedTerms, req.BlockedTerms)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	rules.Lexicon, err = common.LoadSafetyLexicon(r.Context(), s.db)
+	if err != nil {
+		writeInternalError(w, "could not load safety lexicon")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT id, rule, content, max_len
+		FROM safety_rejections
+		ORDER BY id DESC
+		LIMIT $1
+	`, safetyReplaySampleSize)
+	if err != nil {
+		writeInternalError(w, "could not load rejection samples")
+		return
+	}
+	defer rows.Close()
+
+	samples := make([]safetySample, 0, safetyReplaySampleSize)
+	for rows.Next() {
+		var sample safetySample
+		if err := rows.Scan(&sample.ID, &sample.Rule, &sample.Content, &sample.MaxLen); err != nil {
+			writeInternalError(w, "could not scan rejection sample")
+			return
+		}
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load rejection samples")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"rules":  rules,
+		"replay": replaySafetyRules(rules, samples),
+	})
+}
+
+// approvedDraftContent reports whether content is exactly what an admin
+// approved for this draft, in which case publishing it skips the rule check.
+func (s *Server) approvedDraftContent(ctx context.Context, postID, content string) (bool, error) {
+	var approved bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1
+			FROM safety_rejections
+			WHERE resolved_post_id = $1
+			  AND source = $2
+			  AND status = $3
+			  AND btrim(content) = btrim($4)
+		)
+	`, postID, common.SafetySourceDraft, safetyStatusApproved, content).Scan(&approved)
+	return approved, err
+}
+
+func parseSafetyRejectionID(raw string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid rejection id")
+	}
+	return id, nil
+}
+
+const safetyRejectionColumns = `
+	id,
+	user_id::text,
+	COALESCE(persona_id::text, ''),
+	COALESCE(room_id::text, ''),
+	COALESCE(post_id::text, ''),
+	source,
+	rule,
+	reason,
+	content,
+	metadata,
+	status,
+	appeal_note,
+	appealed_at,
+	review_note,
+	reviewed_at,
+	COALESCE(resolved_post_id::text, ''),
+	created_at`
+
+func scanSafetyRejection(row pgx.Row) (SafetyRejection, error) {
+	var (
+		rejection   SafetyRejection
+		metadataRaw []byte
+	)
+	err := row.Scan(
+		&rejection.ID,
+		&rejection.UserID,
+		&rejection.PersonaID,
+		&rejection.RoomID,
+		&rejection.PostID,
+		&rejection.Source,
+		&rejection.Rule,
+		&rejection.Reason,
+		&rejection.Content,
+		&metadataRaw,
+		&rejection.Status,
+		&rejection.AppealNote,
+		&rejection.AppealedAt,
+		&rejection.ReviewNote,
+		&rejection.ReviewedAt,
+		&rejection.ResolvedPostID,
+		&rejection.CreatedAt,
+	)
+	if err != nil {
+		return SafetyRejection{}, err
+	}
+	rejection.Metadata = map[string]any{}
+	_ = json.Unmarshal(metadataRaw, &rejection.Metadata)
+	return rejection, nil
+}
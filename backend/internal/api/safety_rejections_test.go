@@ -0,0 +1,71 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"personaworlds/backend/internal/safety"
+)
+
+func TestNormalizeSafetyRules(t *testing.T) {
+	rules, err := normalizeSafetyRules(nil, []string{" Dick ", "dick"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules.MaxLinks != safety.DefaultMaxLinks {
+		t.Fatalf("expected default max links, got %d", rules.MaxLinks)
+	}
+	if len(rules.AllowedTerms) != 1 || rules.AllowedTerms[0] != "dick" {
+		t.Fatalf("unexpected allowed terms %v", rules.AllowedTerms)
+	}
+
+	negative := -1
+	if _, err := normalizeSafetyRules(&negative, nil, nil); err == nil {
+		t.Fatalf("expected negative max_links to be rejected")
+	}
+	tooMany := make([]string, 0, safetyRuleTermsMax+1)
+	for i := 0; i <= safetyRuleTermsMax; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("term %d", i))
+	}
+	if _, err := normalizeSafetyRules(nil, nil, tooMany); err == nil {
+		t.Fatalf("expected too many terms to be rejected")
+	}
+}
+
+func TestReplaySafetyRules(t *testing.T) {
+	samples := []safetySample{
+		{ID: 3, Rule: safety.RuleProfanity, Content: "Moby Dick is long", MaxLen: 100},
+		{ID: 2, Rule: safety.RuleLinkSpam, Content: "https://a.example https://b.example https://c.example", MaxLen: 100},
+		{ID: 1, Rule: safety.RuleProfanity, Content: "this is shit", MaxLen: 100},
+	}
+	rules := safety.DefaultRules()
+	rules.AllowedTerms = []string{"dick"}
+
+	replay := replaySafetyRules(rules, samples)
+	if replay.Samples != 3 || replay.Cleared != 1 || replay.StillRejected != 2 {
+		t.Fatalf("unexpected replay totals %+v", replay)
+	}
+	if replay.ClearedByRule[safety.RuleProfanity] != 1 || len(replay.ClearedIDs) != 1 || replay.ClearedIDs[0] != 3 {
+		t.Fatalf("unexpected cleared samples %+v", replay)
+	}
+	if replay.RejectedByRule[safety.RuleLinkSpam] != 1 || replay.RejectedByRule[safety.RuleProfanity] != 1 {
+		t.Fatalf("unexpected rejected samples %+v", replay.RejectedByRule)
+	}
+}
+
+func TestSafetyRequestParsing(t *testing.T) {
+	if status, err := validateSafetyStatus(" appealed "); err != nil || status != safetyStatusAppealed {
+		t.Fatalf("expected APPEALED, got %q (%v)", status, err)
+	}
+	if _, err := validateSafetyStatus("pending"); err == nil {
+		t.Fatalf("expected unknown status to be rejected")
+	}
+	if id, err := parseSafetyRejectionID("42"); err != nil || id != 42 {
+		t.Fatalf("expected id 42, got %d (%v)", id, err)
+	}
+	for _, raw := range []string{"", "0", "-3", "abc"} {
+		if _, err := parseSafetyRejectionID(raw); err == nil {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
@@ -1,21 +1,27 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
 	"personaworlds/backend/internal/auth"
+	"personaworlds/backend/internal/authz"
 	"personaworlds/backend/internal/common"
 	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/envelope"
+	"personaworlds/backend/internal/flags"
 	"personaworlds/backend/internal/observability"
 	"personaworlds/backend/internal/safety"
+	"personaworlds/backend/internal/social"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -33,62 +39,100 @@ type Server struct {
 	metrics             *observability.APIMetrics
 	publicReadLimiter   *ipRateLimiter
 	publicWriteLimiter  *ipRateLimiter
+	publicAskLimiter    *ipRateLimiter
 	userBattleLimiter   *ipRateLimiter
 	userTemplateLimiter *ipRateLimiter
+	userReplyLimiter    *ipRateLimiter
+	userVoteLimiter     *ipRateLimiter
+	ipVoteLimiter       *ipRateLimiter
 	battleCardCache     *battleCardCache
+	safetyRules         *common.SafetyRulesCache
+	calibration         *common.CalibrationCipher
+	flags               *flags.Cache
+	social              map[string]social.Provider
+	threadUpdates       *threadUpdates
+	draining            atomic.Bool
 }
 
 type Persona struct {
-	ID                string    `json:"id"`
-	Name              string    `json:"name"`
-	Bio               string    `json:"bio"`
-	Tone              string    `json:"tone"`
-	WritingSamples    []string  `json:"writing_samples"`
-	DoNotSay          []string  `json:"do_not_say"`
-	Catchphrases      []string  `json:"catchphrases"`
-	PreferredLanguage string    `json:"preferred_language"`
-	Formality         int       `json:"formality"`
-	DailyDraftQuota   int       `json:"daily_draft_quota"`
-	DailyReplyQuota   int       `json:"daily_reply_quota"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                string       `json:"id"`
+	Name              string       `json:"name"`
+	Bio               string       `json:"bio"`
+	Tone              string       `json:"tone"`
+	WritingSamples    []string     `json:"writing_samples"`
+	DoNotSay          []string     `json:"do_not_say"`
+	Catchphrases      []string     `json:"catchphrases"`
+	PreferredLanguage string       `json:"preferred_language"`
+	Formality         int          `json:"formality"`
+	DailyDraftQuota   int          `json:"daily_draft_quota"`
+	DailyReplyQuota   int          `json:"daily_reply_quota"`
+	ActiveHoursStart  *int         `json:"active_hours_start"`
+	ActiveHoursEnd    *int         `json:"active_hours_end"`
+	Timezone          string       `json:"timezone"`
+	Style             PersonaStyle `json:"style"`
+	// StyleSource is manual, classified (estimated once from the tone text),
+	// pending (not estimated yet) or default (estimation gave up).
+	StyleSource string    `json:"style_source"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type Room struct {
-	ID          string    `json:"id"`
-	Slug        string    `json:"slug"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID               string     `json:"id"`
+	Slug             string     `json:"slug"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	MergedIntoRoomID string     `json:"merged_into_room_id,omitempty"`
+	Event            *RoomEvent `json:"event,omitempty"`
+	// Related is filled by the room list and room detail handlers only.
+	Related []RelatedRoomChip `json:"related,omitempty"`
 }
 
 type Post struct {
-	ID         string    `json:"id"`
-	RoomID     string    `json:"room_id"`
-	PersonaID  string    `json:"persona_id,omitempty"`
-	Persona    string    `json:"persona_name,omitempty"`
-	AuthoredBy string    `json:"authored_by"`
-	Status     string    `json:"status"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID           string                 `json:"id"`
+	RoomID       string                 `json:"room_id"`
+	PersonaID    string                 `json:"persona_id,omitempty"`
+	Persona      string                 `json:"persona_name,omitempty"`
+	AuthoredBy   string                 `json:"authored_by"`
+	Status       string                 `json:"status"`
+	Content      string                 `json:"content"`
+	SourcePostID string                 `json:"source_post_id,omitempty"`
+	Mood         string                 `json:"mood,omitempty"`
+	Battle       *common.BattleLanguage `json:"battle_language,omitempty"`
+	Reactions    PostReactionCounts     `json:"reactions"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
 }
 
 type Reply struct {
-	ID         string    `json:"id"`
-	PostID     string    `json:"post_id"`
-	PersonaID  string    `json:"persona_id,omitempty"`
-	Persona    string    `json:"persona_name,omitempty"`
-	AuthoredBy string    `json:"authored_by"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         string            `json:"id"`
+	PostID     string            `json:"post_id"`
+	PersonaID  string            `json:"persona_id,omitempty"`
+	Persona    string            `json:"persona_name,omitempty"`
+	AuthoredBy string            `json:"authored_by"`
+	Content    string            `json:"content"`
+	Language   string            `json:"language,omitempty"`
+	Bilingual  map[string]string `json:"bilingual,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+
+	// timing is the recorded generation timing, set for battle turns only.
+	timing    common.TurnTiming
+	hasTiming bool
+	// usage is the recorded LLM usage, set for AI battle turns only.
+	usage    common.TurnUsage
+	hasUsage bool
 }
 
 type PreviewDraft struct {
-	Label      string `json:"label"`
-	Content    string `json:"content"`
-	AuthoredBy string `json:"authored_by"`
+	Label             string `json:"label"`
+	Content           string `json:"content"`
+	AuthoredBy        string `json:"authored_by"`
+	PromptVersion     string `json:"prompt_version"`
+	Mood              string `json:"mood,omitempty"`
+	PromptHintVersion int    `json:"prompt_hint_version,omitempty"`
 }
 
 type DigestThread struct {
@@ -101,18 +145,21 @@ type DigestThread struct {
 }
 
 type DigestStats struct {
-	Posts      int            `json:"posts"`
-	Replies    int            `json:"replies"`
-	TopThreads []DigestThread `json:"top_threads"`
+	Posts        int            `json:"posts"`
+	Replies      int            `json:"replies"`
+	HumanReplies int            `json:"human_replies"`
+	Reactions    int            `json:"reactions"`
+	TopThreads   []DigestThread `json:"top_threads"`
 }
 
 type PersonaDigest struct {
-	PersonaID   string      `json:"persona_id"`
-	Date        string      `json:"date"`
-	Summary     string      `json:"summary"`
-	Stats       DigestStats `json:"stats"`
-	HasActivity bool        `json:"has_activity"`
-	UpdatedAt   time.Time   `json:"updated_at"`
+	PersonaID   string            `json:"persona_id"`
+	Date        string            `json:"date"`
+	Summary     string            `json:"summary"`
+	Stats       DigestStats       `json:"stats"`
+	HasActivity bool              `json:"has_activity"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Window      common.DateWindow `json:"window"`
 }
 
 type ipRateLimiter struct {
@@ -138,6 +185,7 @@ type PublicPersonaProfile struct {
 	IsPublic          bool      `json:"is_public"`
 	Followers         int       `json:"followers"`
 	PostsCount        int       `json:"posts_count"`
+	ViewCount         int64     `json:"view_count"`
 	Badges            []string  `json:"badges"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -148,6 +196,7 @@ type PublicPost struct {
 	RoomName   string    `json:"room_name"`
 	AuthoredBy string    `json:"authored_by"`
 	Content    string    `json:"content"`
+	Question   string    `json:"question,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -166,20 +215,33 @@ type BattleTemplate struct {
 	WordLimit   int       `json:"word_limit"`
 	CreatedAt   time.Time `json:"created_at"`
 	IsPublic    bool      `json:"is_public"`
+	// Policy is only set in the create response, when a policy was requested.
+	Policy *TemplatePolicyRecord `json:"policy,omitempty"`
 }
 
 func New(cfg config.Config, db *pgxpool.Pool, llm ai.LLMClient) *Server {
+	metrics := observability.NewAPIMetrics()
+	logger := observability.NewLogger("api")
 	return &Server{
 		cfg:                 cfg,
 		db:                  db,
-		llm:                 llm,
-		logger:              observability.NewLogger("api"),
-		metrics:             observability.NewAPIMetrics(),
+		llm:                 ai.Instrument(llm, ai.ProviderName(cfg), metrics, logger),
+		logger:              logger,
+		metrics:             metrics,
 		publicReadLimiter:   newIPRateLimiter(120, time.Minute),
 		publicWriteLimiter:  newIPRateLimiter(30, time.Minute),
+		publicAskLimiter:    newIPRateLimiter(5, 10*time.Minute),
 		userBattleLimiter:   newIPRateLimiter(20, time.Minute),
 		userTemplateLimiter: newIPRateLimiter(10, time.Minute),
+		userReplyLimiter:    newIPRateLimiter(10, time.Minute),
+		userVoteLimiter:     newIPRateLimiter(20, time.Minute),
+		ipVoteLimiter:       newIPRateLimiter(60, time.Minute),
 		battleCardCache:     newBattleCardCache(256),
+		safetyRules:         common.NewSafetyRulesCache(),
+		calibration:         common.NewCalibrationCipher(envelope.MustKeyProvider(cfg)),
+		flags:               flags.NewCache(cfg.AppEnv),
+		social:              social.NewProviders(cfg, nil),
+		threadUpdates:       newThreadUpdates(),
 	}
 }
 
@@ -257,6 +319,7 @@ func requestClientIP(r *http.Request) string {
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
+	r.Use(llmRequestIDMiddleware)
 	r.Use(middleware.RealIP)
 	r.Use(s.requestContextTimeoutMiddleware)
 	r.Use(s.maxBodyBytesMiddleware(s.cfg.RequestBodyMaxBytes))
@@ -267,16 +330,18 @@ func (s *Server) Router() http.Handler {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   s.cfg.CORSAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
+		ExposedHeaders:   []string{"ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	r.Get("/healthz", s.handleHealthz)
+	r.Get("/livez", s.handleLivez)
 	r.Get("/readyz", s.handleReadyz)
 	r.Get("/metrics", s.handleMetrics)
 
-	r.With(s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes)).Post("/events", s.handleCreateEvent)
+	r.With(s.maxBodyBytesMiddleware(s.cfg.EventsBodyMaxBytes)).Post("/events", s.handleCreateEvent)
 
 	r.Route("/auth", func(r chi.Router) {
 		r.Use(s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes))
@@ -285,52 +350,210 @@ func (s *Server) Router() http.Handler {
 	})
 
 	r.Route("/p/{slug}", func(r chi.Router) {
-		r.With(s.publicReadRateLimitMiddleware).Get("/", s.handleGetPublicProfile)
-		r.With(s.publicReadRateLimitMiddleware).Get("/posts", s.handleGetPublicProfilePosts)
+		r.With(s.publicReadRateLimitMiddleware, s.compressJSONMiddleware).Get("/", s.handleGetPublicProfile)
+		r.With(s.publicReadRateLimitMiddleware, s.compressJSONMiddleware).Get("/posts", s.handleGetPublicProfilePosts)
+		r.With(publicEmbedCORSMiddleware, s.publicReadRateLimitMiddleware, s.compressJSONMiddleware).Get("/embed.json", s.handleGetPublicProfileEmbed)
 		r.With(
 			s.publicWriteRateLimitMiddleware,
 			s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes),
 		).Post("/follow", s.handleFollowPublicProfile)
+		r.With(
+			s.publicWriteRateLimitMiddleware,
+			s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes),
+		).Post("/ask", s.handleAskPublicProfile)
+		r.With(
+			auth.Middleware(s.cfg.JWTSecret),
+			s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes),
+		).Post("/challenge", s.handleChallengePublicProfile)
 	})
 
 	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/card.png", s.handleGetBattleCardImage)
+	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/card.gif", s.handleGetBattleLiveCard)
+	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/turns/{index}/card.png", s.handleGetBattleTurnCardImage)
 	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/meta", s.handleGetPublicBattleMeta)
+	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/replay", s.handleGetBattleReplay)
+	r.With(s.publicReadRateLimitMiddleware).Get("/b/{id}/highlights", s.handleGetBattleHighlights)
+	r.With(s.publicReadRateLimitMiddleware, s.compressJSONMiddleware).Get("/leaderboard/battles", s.handleGetBattleLeaderboard)
 	r.With(
 		s.publicReadRateLimitMiddleware,
 		s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes),
 	).Post("/battles/{id}/remix-intent", s.handleCreateBattleRemixIntent)
 	r.With(s.publicReadRateLimitMiddleware).Get("/templates", s.handleListPublicTemplates)
+	r.Route("/shared/personas/{token}", func(r chi.Router) {
+		r.With(s.publicReadRateLimitMiddleware).Get("/", s.handleGetSharedPersona)
+		r.With(
+			s.publicWriteRateLimitMiddleware,
+			s.maxBodyBytesMiddleware(s.cfg.PublicBodyMaxBytes),
+			s.requireShareAccess(authz.SuggestEdits),
+		).Post("/suggestions", s.handleSuggestSharedPersonaEdit)
+		r.With(
+			s.publicWriteRateLimitMiddleware,
+			s.requireShareAccess(authz.RunPreview),
+		).Post("/preview", s.handlePreviewSharedPersona)
+	})
+	r.With(s.publicReadRateLimitMiddleware, s.compressJSONMiddleware).Get("/marketplace/personas", s.handleListMarketplacePersonas)
 
 	r.Group(func(r chi.Router) {
 		r.Use(auth.Middleware(s.cfg.JWTSecret))
 
-		r.Get("/feed", s.handleGetFeed)
+		r.With(s.compressJSONMiddleware).Get("/feed", s.handleGetFeed)
+		r.Post("/feed/cold-start/dismiss", s.handleDismissColdStart)
 		r.Get("/notifications", s.handleListNotifications)
 		r.Post("/notifications/{id}/read", s.handleMarkNotificationRead)
 		r.Post("/notifications/read-all", s.handleMarkAllNotificationsRead)
+		r.Post("/notifications/read-up-to/{seq}", s.handleMarkNotificationsReadUpTo)
+		r.Get("/notifications/push/public-key", s.handlePushPublicKey)
+		r.Post("/notifications/push/subscribe", s.handlePushSubscribe)
+		r.Post("/notifications/push/unsubscribe", s.handlePushUnsubscribe)
+		r.Get("/notifications/push/preferences", s.handleGetPushPreferences)
+		r.Put("/notifications/push/preferences", s.handleUpdatePushPreferences)
+		r.Get("/notifications/preferences", s.handleGetNotificationPreferences)
+		r.Put("/notifications/preferences", s.handleUpdateNotificationPreferences)
+		r.Get("/digest/daily", s.handleGetDailySummary)
 		r.Get("/digest/weekly", s.handleGetWeeklyDigest)
+		r.Get("/account/audit-log", s.handleListAuditLog)
+		r.Get("/announcements", s.handleListAnnouncements)
+		r.Post("/announcements/{id}/dismiss", s.handleDismissAnnouncement)
+		r.Get("/account/settings", s.handleGetAccountSettings)
+		r.Put("/account/settings", s.handleUpdateAccountSettings)
+		r.Get("/social/accounts", s.handleListSocialAccounts)
+		r.Post("/social/accounts/{provider}/connect", s.handleConnectSocialAccount)
+		r.Post("/social/accounts/{provider}/callback", s.handleSocialAccountCallback)
+		r.Delete("/social/accounts/{provider}", s.handleDeleteSocialAccount)
+		r.Get("/analytics/views", s.handleGetViewAnalytics)
+		r.Get("/analytics/experiments/{id}", s.handleGetExperimentReport)
+		r.Get("/experiments/{id}", s.handleGetExperimentAssignment)
+		r.Get("/referrals/me", s.handleGetMyReferrals)
+		r.Get("/safety/rejections", s.handleListSafetyRejections)
+		r.Post("/safety/rejections/{id}/appeal", s.handleAppealSafetyRejection)
 
 		r.Get("/personas", s.handleListPersonas)
 		r.Post("/personas", s.handleCreatePersona)
+		r.With(s.maxBodyBytesMiddleware(s.cfg.ImportBodyMaxBytes)).Post("/personas/bulk", s.handleBulkCreatePersonas)
+		r.Get("/personas/bulk/{batchID}", s.handleGetPersonaImportBatch)
 		r.Get("/personas/{id}", s.handleGetPersona)
 		r.Put("/personas/{id}", s.handleUpdatePersona)
 		r.Delete("/personas/{id}", s.handleDeletePersona)
-		r.Post("/personas/{id}/preview", s.handlePreviewPersona)
-		r.Get("/personas/{id}/digest/today", s.handleGetTodayDigest)
-		r.Get("/personas/{id}/digest/latest", s.handleGetLatestDigest)
+		r.With(s.requireAccess(authz.Persona, authz.RunPreview)).Post("/personas/{id}/preview", s.handlePreviewPersona)
+		r.Post("/personas/{id}/evaluate", s.handleEvaluatePersona)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/evaluations", s.handleListPersonaEvaluations)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/presence", s.handleGetPersonaPresence)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/digest/today", s.handleGetTodayDigest)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/digest/latest", s.handleGetLatestDigest)
+		r.Get("/personas/{id}/digest/schedule", s.handleGetDigestSchedule)
+		r.Put("/personas/{id}/digest/schedule", s.handleUpdateDigestSchedule)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/digest/regenerate", s.handleRegenerateDigest)
 		r.Post("/personas/{id}/publish-profile", s.handlePublishPersonaProfile)
 		r.Post("/personas/{id}/unpublish-profile", s.handleUnpublishPersonaProfile)
+		r.Post("/personas/{id}/announce", s.handleAnnouncePersona)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/questions", s.handleListPersonaQuestions)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/questions/{questionID}/approve", s.handleApprovePersonaQuestion)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/questions/{questionID}/reject", s.handleRejectPersonaQuestion)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/share-links", s.handleCreatePersonaShareLink)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/share-links", s.handleListPersonaShareLinks)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Delete("/personas/{id}/share-links/{linkID}", s.handleRevokePersonaShareLink)
+		r.With(s.requireAccess(authz.Persona, authz.Read)).Get("/personas/{id}/suggestions", s.handleListPersonaEditSuggestions)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/suggestions/{suggestionID}/accept", s.handleAcceptPersonaEditSuggestion)
+		r.With(s.requireAccess(authz.Persona, authz.Manage)).Post("/personas/{id}/suggestions/{suggestionID}/dismiss", s.handleDismissPersonaEditSuggestion)
+		r.Get("/personas/{id}/mentions", s.handleListPersonaMentions)
+		r.Put("/personas/{id}/mention-replies", s.handleUpdateMentionReplies)
+		r.Get("/personas/{id}/battle-of-week", s.handleGetPersonaBattleOfWeek)
+		r.Put("/personas/{id}/battle-of-week", s.handleUpdateBattleOfWeekOptIn)
+		r.Get("/personas/{id}/rivals", s.handleListPersonaRivals)
+		r.Put("/personas/{id}/marketplace", s.handleUpsertMarketplaceListing)
+		r.Delete("/personas/{id}/marketplace", s.handleDeleteMarketplaceListing)
+		r.Post("/personas/{id}/posts/bulk-status", s.handleBulkUpdatePostStatus)
+		r.Get("/personas/{id}/posts/bulk-status/{batchID}", s.handleGetPostStatusBatch)
 
 		r.Get("/rooms", s.handleListRooms)
-		r.Get("/rooms/{id}/posts", s.handleListRoomPosts)
+		r.Get("/rooms/{id}", s.handleGetRoom)
+		r.Get("/rooms/{id}/related", s.handleListRelatedRooms)
+		r.Get("/rooms/{id}/trending-topics", s.handleListRoomTrendingTopics)
+		r.Get("/rooms/{id}/roster", s.handleGetRoomRoster)
+		r.Put("/rooms/{id}/roster/{personaID}", s.handleEnrollRosterPersona)
+		r.Delete("/rooms/{id}/roster/{personaID}", s.handleLeaveRoster)
+		r.With(s.compressJSONMiddleware).Get("/rooms/{id}/posts", s.handleListRoomPosts)
 		r.Post("/rooms/{id}/posts/draft", s.handleCreateDraft)
+		r.With(s.requireAccess(authz.Room, authz.Moderate)).Get("/rooms/{id}/prompt-hints", s.handleListRoomPromptHints)
+		r.With(s.requireAccess(authz.Room, authz.Moderate)).Post("/rooms/{id}/prompt-hints", s.handleCreateRoomPromptHint)
+		r.With(s.requireAccess(authz.Room, authz.Moderate)).Post("/rooms/{id}/prompt-hints/{version}/activate", s.handleActivateRoomPromptHint)
+		r.With(s.requireAccess(authz.Room, authz.Moderate)).Delete("/rooms/{id}/prompt-hints", s.handleClearRoomPromptHint)
+		r.Get("/drafts/jobs/{id}", s.handleGetDraftJob)
 		r.Post("/rooms/{id}/battles", s.handleCreateBattle)
+		r.Post("/rooms/{id}/battles/dry-run", s.handleDryRunBattle)
+		r.Get("/battles/queue", s.handleListQueuedBattles)
+		r.Delete("/battles/queue/{id}", s.handleCancelQueuedBattle)
+		r.With(s.requireAccess(authz.Battle, authz.Read)).Get("/battles/{id}/coaching", s.handleGetBattleCoaching)
+		r.Get("/battles/{id}/context-pack", s.handleGetBattleContextPack)
+		r.Get("/battles/{id}/diagnostics", s.handleGetBattleDiagnostics)
+		r.Post("/battles/{id}/share-link", s.handleCreateBattleShareLink)
+		r.Post("/battles/{id}/visibility", s.handleUpdateBattleVisibility)
+		r.Get("/challenges", s.handleListBattleChallenges)
+		r.Post("/challenges/{id}/accept", s.handleAcceptBattleChallenge)
+		r.Post("/challenges/{id}/decline", s.handleDeclineBattleChallenge)
+		r.Post("/battles/{id}/my-turn", s.handleSubmitBattleTurn)
+		r.With(s.compressJSONMiddleware).Get("/posts/{id}", s.handleGetPost)
 		r.Post("/posts/{id}/approve", s.handleApprovePost)
+		r.Post("/posts/{id}/visibility", s.handleUpdatePostVisibility)
 		r.Post("/posts/{id}/generate-replies", s.handleGenerateReplies)
-		r.Get("/posts/{id}/thread", s.handleGetThread)
-		r.Get("/b/{id}", s.handleGetThread)
+		r.Post("/posts/{id}/replies", s.handleCreateReply)
+		r.Post("/posts/{id}/battle", s.handleCreateBattleFromPost)
+		r.Post("/posts/{id}/crosspost", s.handleCreateCrosspost)
+		r.With(s.requireAccess(authz.Post, authz.Manage)).Get("/posts/{id}/crossposts", s.handleListCrossposts)
+		r.Post("/posts/{id}/reactions", s.handleCreatePostReaction)
+		r.Delete("/posts/{id}/reactions/{reaction}", s.handleDeletePostReaction)
+		r.With(s.compressJSONMiddleware).Get("/posts/{id}/thread", s.handleGetThread)
+		r.Get("/posts/{id}/stream", s.handleStreamThread)
+		r.With(s.compressJSONMiddleware).Get("/b/{id}", s.handleGetThread)
+		r.Post("/b/{id}/watch", s.handleWatchBattle)
+		r.Delete("/b/{id}/watch", s.handleUnwatchBattle)
+		r.Post("/b/{id}/vote", s.handleCastBattleVote)
+		r.Delete("/b/{id}/vote", s.handleRetractBattleVote)
 		r.Post("/templates", s.handleCreateTemplate)
+		r.With(s.requireAccess(authz.Template, authz.Manage)).Get("/templates/{id}/policy", s.handleGetTemplatePolicy)
+		r.With(s.requireAccess(authz.Template, authz.Manage)).Put("/templates/{id}/policy", s.handlePutTemplatePolicy)
+		r.With(s.requireAccess(authz.Template, authz.Manage)).Delete("/templates/{id}/policy", s.handleDeleteTemplatePolicy)
 		r.Get("/admin/analytics/summary", s.handleAnalyticsSummary)
+		r.Get("/admin/announcements", s.handleAdminListAnnouncements)
+		r.Post("/admin/announcements", s.handleCreateAnnouncement)
+		r.Delete("/admin/announcements/{id}", s.handleEndAnnouncement)
+		r.Get("/admin/experiments", s.handleListExperiments)
+		r.Put("/admin/experiments/{id}", s.handleSetExperiment)
+		r.Get("/admin/flags", s.handleListFeatureFlags)
+		r.Put("/admin/flags/{key}", s.handleSetFeatureFlag)
+		r.Delete("/admin/flags/{key}", s.handleResetFeatureFlag)
+		r.Get("/admin/llm-canary", s.handleAdminLLMCanaryReport)
+		r.Get("/admin/personas/{id}/quota-events", s.handleAdminListQuotaEvents)
+		r.Get("/admin/prompts", s.handleListPromptVersions)
+		r.Put("/admin/prompts/{operation}", s.handleSetPromptVersion)
+		r.Delete("/admin/prompts/{operation}", s.handleResetPromptVersion)
+		r.Post("/admin/rooms/{id}/archive", s.handleArchiveRoom)
+		r.Post("/admin/rooms/{id}/merge", s.handleMergeRoom)
+		r.Get("/admin/rooms/{id}/merge", s.handleGetRoomMerge)
+		r.Put("/admin/rooms/{id}/cooldown", s.handleSetRoomCooldown)
+		r.Put("/admin/rooms/{id}/topic-check", s.handleSetRoomTopicCheck)
+		r.Put("/admin/rooms/{id}/event", s.handleSetRoomEvent)
+		r.Get("/admin/rooms/{id}/moderators", s.handleListRoomModerators)
+		r.Put("/admin/rooms/{id}/moderators/{userID}", s.handleAddRoomModerator)
+		r.Delete("/admin/rooms/{id}/moderators/{userID}", s.handleRemoveRoomModerator)
+		r.Post("/admin/events", s.handleCreateEventRoom)
+		r.Get("/admin/safety/lexicon", s.handleListSafetyLexicon)
+		r.Post("/admin/safety/lexicon", s.handleCreateSafetyLexiconTerm)
+		r.Put("/admin/safety/lexicon/{id}", s.handleUpdateSafetyLexiconTerm)
+		r.Delete("/admin/safety/lexicon/{id}", s.handleDeleteSafetyLexiconTerm)
+		r.Get("/admin/safety/rejections", s.handleAdminListSafetyRejections)
+		r.Post("/admin/safety/rejections/{id}/approve", s.handleApproveSafetyRejection)
+		r.Post("/admin/safety/rejections/{id}/deny", s.handleDenySafetyRejection)
+		r.Get("/admin/safety/rules", s.handleGetSafetyRules)
+		r.Put("/admin/safety/rules", s.handleUpdateSafetyRules)
+		r.Post("/admin/safety/rules/replay", s.handleReplaySafetyRules)
+		r.With(s.maxBodyBytesMiddleware(s.cfg.ImportBodyMaxBytes)).Post("/admin/safety/redteam", s.handleRunSafetyRedTeam)
+		r.Get("/admin/quota/reconciliation", s.handleAdminGetQuotaReconciliation)
+		r.Get("/admin/template-policies", s.handleAdminListTemplatePolicies)
+		r.Post("/admin/template-policies/{id}/approve", s.handleApproveTemplatePolicy)
+		r.Post("/admin/template-policies/{id}/reject", s.handleRejectTemplatePolicy)
+		r.Get("/admin/slow-queries", s.handleListSlowQueries)
+		r.Delete("/admin/slow-queries", s.handleResetSlowQueries)
 	})
 
 	return r
@@ -338,9 +561,13 @@ func (s *Server) Router() http.Handler {
 
 func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Email     string `json:"email"`
-		Password  string `json:"password"`
-		ShareSlug string `json:"share_slug"`
+		Email         string   `json:"email"`
+		Password      string   `json:"password"`
+		ShareSlug     string   `json:"share_slug"`
+		ShareToken    string   `json:"share_token"`
+		IntentToken   string   `json:"intent_token"`
+		ReferralCode  string   `json:"referral_code"`
+		RoomInterests []string `json:"room_interests"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
@@ -352,6 +579,11 @@ func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, "invalid email or password")
 		return
 	}
+	roomInterests, err := normalizeRoomInterests(req.RoomInterests)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 
 	hash, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -381,21 +613,62 @@ func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shareSlug := normalizePublicSlug(req.ShareSlug)
+	if shareToken := strings.TrimSpace(req.ShareToken); shareToken != "" {
+		reason, err := s.attributeShareSignup(r, userID, shareToken)
+		if err != nil {
+			s.logger.Warn("share_attribution_failed", observability.Fields{
+				"request_id": requestIDFromRequest(r),
+				"user_id":    userID,
+				"error":      err.Error(),
+			})
+		} else if reason != "" {
+			s.logShareAttributionRejected(r, userID, reason)
+		}
+	}
+
+	if err := s.saveSignupRoomInterests(r.Context(), userID, roomInterests); err != nil {
+		s.logger.Warn("room_interest_record_failed", observability.Fields{
+			"request_id": requestIDFromRequest(r),
+			"user_id":    userID,
+			"error":      err.Error(),
+		})
+	}
+
+	if referralCode := strings.TrimSpace(req.ReferralCode); referralCode != "" {
+		reason, err := s.attributeReferral(r, userID, referralCode)
+		if err != nil {
+			s.logger.Warn("referral_attribution_failed", observability.Fields{
+				"request_id": requestIDFromRequest(r),
+				"user_id":    userID,
+				"error":      err.Error(),
+			})
+		} else if reason != "" {
+			s.logReferralNotRewarded(r, userID, reason)
+		}
+	}
+
+	// Profile slugs are public and unsigned, so they are tracked separately
+	// from verified share signups.
+	shareSlug := s.normalizeSlug(req.ShareSlug)
 	if shareSlug != "" {
-		_ = s.insertEvent(r.Context(), userID, eventSignupFromShare, map[string]any{
+		_ = s.insertEvent(r.Context(), userID, eventSignupFromProfileLink, map[string]any{
 			"share_slug": shareSlug,
 			"source":     "public_profile",
 		})
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]any{"token": token, "user_id": userID})
+	response := map[string]any{"token": token, "user_id": userID}
+	if intent := s.completeGuestIntent(w, r, userID, guestIntentFromRequest(r, req.IntentToken), guestIntentViaSignup); intent != nil {
+		response["intent"] = intent
+	}
+	writeJSON(w, http.StatusCreated, response)
 }
 
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string `json:"email"`
+		Password    string `json:"password"`
+		IntentToken string `json:"intent_token"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
@@ -420,6 +693,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !auth.VerifyPassword(hash, req.Password) {
+		s.recordAudit(r, userID, auditLoginFailed, nil)
 		writeUnauthorized(w, "invalid credentials")
 		return
 	}
@@ -429,20 +703,28 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		writeInternalError(w, "could not create token")
 		return
 	}
+	s.recordAudit(r, userID, auditLoginSucceeded, nil)
 
-	writeJSON(w, http.StatusOK, map[string]any{"token": token, "user_id": userID})
+	response := map[string]any{"token": token, "user_id": userID}
+	if intent := s.completeGuestIntent(w, r, userID, guestIntentFromRequest(r, req.IntentToken), guestIntentViaLogin); intent != nil {
+		response["intent"] = intent
+	}
+	writeJSON(w, http.StatusOK, response)
 }
 
 func (s *Server) handleGetPublicProfile(w http.ResponseWriter, r *http.Request) {
-	slug := normalizePublicSlug(chi.URLParam(r, "slug"))
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
 	if slug == "" {
 		writeNotFound(w, "public profile not found")
 		return
 	}
 
-	profile, _, err := s.getPublicProfileBySlug(r.Context(), slug)
+	profile, ownerUserID, err := s.getPublicProfileBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "") {
+				return
+			}
 			writeNotFound(w, "public profile not found")
 			return
 		}
@@ -461,22 +743,32 @@ func (s *Server) handleGetPublicProfile(w http.ResponseWriter, r *http.Request)
 		writeInternalError(w, "could not load top rooms")
 		return
 	}
+	presence, err := s.listPersonaPresence(r.Context(), profile.PersonaID, publicPresenceLimit)
+	if err != nil {
+		writeInternalError(w, "could not load presence")
+		return
+	}
 
+	if s.recordUniqueView(r, viewSubjectProfile, profile.PersonaID, ownerUserID) {
+		profile.ViewCount++
+	}
 	_ = s.logEventFromRequest(r, eventPublicProfileViewed, map[string]any{
 		"slug":       profile.Slug,
 		"persona_id": profile.PersonaID,
 	})
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"profile":      mapPublicProfileDTO(profile),
-		"latest_posts": mapPublicPostsDTO(latestPosts),
-		"top_rooms":    mapPublicRoomStatsDTO(topRooms),
-		"next_cursor":  nextCursor,
+		"profile":           mapPublicProfileDTO(profile),
+		"latest_posts":      mapPublicPostsDTO(latestPosts),
+		"top_rooms":         mapPublicRoomStatsDTO(topRooms),
+		"presence":          presence,
+		"next_cursor":       nextCursor,
+		"questions_enabled": s.flagEnabled(r.Context(), flags.PublicQA, ownerUserID),
 	})
 }
 
 func (s *Server) handleGetPublicProfilePosts(w http.ResponseWriter, r *http.Request) {
-	slug := normalizePublicSlug(chi.URLParam(r, "slug"))
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
 	if slug == "" {
 		writeNotFound(w, "public profile not found")
 		return
@@ -485,6 +777,9 @@ func (s *Server) handleGetPublicProfilePosts(w http.ResponseWriter, r *http.Requ
 	profile, _, err := s.getPublicProfileBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "/posts") {
+				return
+			}
 			writeNotFound(w, "public profile not found")
 			return
 		}
@@ -506,7 +801,7 @@ func (s *Server) handleGetPublicProfilePosts(w http.ResponseWriter, r *http.Requ
 }
 
 func (s *Server) handleFollowPublicProfile(w http.ResponseWriter, r *http.Request) {
-	slug := normalizePublicSlug(chi.URLParam(r, "slug"))
+	slug := s.normalizeSlug(chi.URLParam(r, "slug"))
 	if slug == "" {
 		writeNotFound(w, "public profile not found")
 		return
@@ -515,6 +810,9 @@ func (s *Server) handleFollowPublicProfile(w http.ResponseWriter, r *http.Reques
 	profile, ownerUserID, err := s.getPublicProfileBySlug(r.Context(), slug)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
+			if s.redirectRenamedPublicProfile(w, r, slug, "/follow") {
+				return
+			}
 			writeNotFound(w, "public profile not found")
 			return
 		}
@@ -524,8 +822,17 @@ func (s *Server) handleFollowPublicProfile(w http.ResponseWriter, r *http.Reques
 
 	followerUserID, ok := s.optionalUserIDFromRequest(r)
 	if !ok {
+		// The intent token lets signup or login finish this follow, so the
+		// visitor does not have to come back and click again.
+		intentToken, expiresAt, err := s.issueGuestIntent(w, guestIntentKindFollow, profile.PersonaID)
+		if err != nil {
+			writeInternalError(w, "could not create follow intent")
+			return
+		}
 		writeJSON(w, http.StatusUnauthorized, map[string]any{
-			"error": "signup_required",
+			"error":             "signup_required",
+			"intent_token":      intentToken,
+			"intent_expires_at": expiresAt.Format(time.RFC3339),
 		})
 		return
 	}
@@ -556,7 +863,7 @@ func (s *Server) handleFollowPublicProfile(w http.ResponseWriter, r *http.Reques
 	}
 
 	if ct.RowsAffected() > 0 {
-		_ = s.notifyPersonaFollowed(r.Context(), ownerUserID, followerUserID, profile.PersonaID, slug)
+		_ = s.notifyPersonaFollowed(r.Context(), ownerUserID, followerUserID, profile.PersonaID, profile.Name, slug)
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -606,7 +913,7 @@ func (s *Server) handlePublishPersonaProfile(w http.ResponseWriter, r *http.Requ
 	requestedSlug := strings.TrimSpace(req.Slug)
 	normalizedRequestedSlug := ""
 	if requestedSlug != "" {
-		validatedSlug, validateErr := validateSlug(requestedSlug)
+		validatedSlug, validateErr := validateSlug(requestedSlug, s.cfg.UnicodeSlugs)
 		if validateErr != nil {
 			writeBadRequest(w, "slug must contain only letters, numbers, spaces, hyphen or underscore")
 			return
@@ -630,7 +937,7 @@ func (s *Server) handlePublishPersonaProfile(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	baseSlug := normalizePublicSlug(persona.Name)
+	baseSlug := s.normalizeSlug(persona.Name)
 	if baseSlug == "" {
 		baseSlug = "persona"
 	}
@@ -646,13 +953,20 @@ func (s *Server) handlePublishPersonaProfile(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not publish profile")
+		return
+	}
+	defer tx.Rollback(r.Context())
+
 	var out struct {
 		Slug      string
 		IsPublic  bool
 		Bio       string
 		CreatedAt time.Time
 	}
-	err = s.db.QueryRow(r.Context(), `
+	err = tx.QueryRow(r.Context(), `
 		INSERT INTO persona_public_profiles(persona_id, slug, is_public, bio)
 		VALUES ($1, $2, TRUE, $3)
 		ON CONFLICT (persona_id)
@@ -672,6 +986,37 @@ func (s *Server) handlePublishPersonaProfile(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	previousSlug := strings.TrimSpace(currentSlug)
+	if previousSlug != "" && previousSlug != out.Slug {
+		if _, err := tx.Exec(r.Context(), `
+			INSERT INTO persona_slug_redirects(old_slug, persona_id)
+			VALUES ($1, $2)
+			ON CONFLICT (old_slug)
+			DO UPDATE SET persona_id = EXCLUDED.persona_id, created_at = NOW()
+		`, previousSlug, personaID); err != nil {
+			writeInternalError(w, "could not record slug redirect")
+			return
+		}
+	}
+	if _, err := tx.Exec(r.Context(), `
+		DELETE FROM persona_slug_redirects
+		WHERE old_slug = $1
+	`, out.Slug); err != nil {
+		writeInternalError(w, "could not record slug redirect")
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not publish profile")
+		return
+	}
+
+	s.recordAudit(r, userID, auditProfilePublished, map[string]any{
+		"persona_id":    personaID,
+		"slug":          out.Slug,
+		"previous_slug": previousSlug,
+	})
+
 	shareURL := fmt.Sprintf("%s/p/%s", strings.TrimRight(s.cfg.FrontendOrigin, "/"), out.Slug)
 	writeJSON(w, http.StatusOK, map[string]any{
 		"persona_id": personaID,
@@ -730,6 +1075,10 @@ func (s *Server) handleUnpublishPersonaProfile(w http.ResponseWriter, r *http.Re
 			writeInternalError(w, "could not unpublish profile")
 			return
 		}
+		s.recordAudit(r, userID, auditProfileUnpublished, map[string]any{
+			"persona_id": personaID,
+			"slug":       strings.TrimSpace(slug),
+		})
 	}
 
 	shareURL := ""
@@ -751,7 +1100,7 @@ func (s *Server) handleListPersonas(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rows, err := s.db.Query(r.Context(), `
-		SELECT id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, created_at, updated_at
+		SELECT id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source, created_at, updated_at
 		FROM personas
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -765,7 +1114,7 @@ func (s *Server) handleListPersonas(w http.ResponseWriter, r *http.Request) {
 	personas := make([]Persona, 0)
 	for rows.Next() {
 		var p Persona
-		if err := scanPersona(rows, &p); err != nil {
+		if err := s.scanPersona(r.Context(), rows, &p); err != nil {
 			writeInternalError(w, "could not scan persona")
 			return
 		}
@@ -792,17 +1141,44 @@ func (s *Server) handleCreatePersona(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	if err := checkPersonaInputSafety(s.loadSafetyRules(r.Context()), input); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	style, err := req.normalizedStyle()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if style == nil {
+		defaults := defaultPersonaStyle()
+		style = &defaults
+	}
 
 	req.applyDefaultQuotas(s.cfg.DefaultDraftQuota, s.cfg.DefaultReplyQuota)
+	timezone, err := req.normalizedActiveHours()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 
 	var p Persona
-	writingSamplesJSON, doNotSayJSON, catchphrasesJSON := marshalPersonaJSONFields(input)
+	writingSamplesJSON, doNotSayJSON, catchphrasesJSON, err := encodePersonaJSONFields(input)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	writingSamplesJSON, doNotSayJSON, catchphrasesJSON, err = s.sealPersonaJSONFields(r.Context(), userID, writingSamplesJSON, doNotSayJSON, catchphrasesJSON)
+	if err != nil {
+		writeInternalError(w, "could not encrypt persona calibration")
+		return
+	}
 
-	err = scanPersona(s.db.QueryRow(r.Context(), `
-		INSERT INTO personas(user_id, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota)
-		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb, $8, $9, $10, $11)
-		RETURNING id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, created_at, updated_at
-	`, userID, input.Name, input.Bio, input.Tone, writingSamplesJSON, doNotSayJSON, catchphrasesJSON, input.PreferredLanguage, input.Formality, req.DailyDraftQuota, req.DailyReplyQuota), &p)
+	err = s.scanPersona(r.Context(), s.db.QueryRow(r.Context(), `
+		INSERT INTO personas(user_id, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, 'manual')
+		RETURNING id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source, created_at, updated_at
+	`, userID, input.Name, input.Bio, input.Tone, writingSamplesJSON, doNotSayJSON, catchphrasesJSON, input.PreferredLanguage, input.Formality, req.DailyDraftQuota, req.DailyReplyQuota, req.ActiveHoursStart, req.ActiveHoursEnd, timezone, style.Humor, style.Assertiveness, style.Technicality, style.Brevity), &p)
 	if err != nil {
 		writeInternalError(w, "could not create persona")
 		return
@@ -861,20 +1237,55 @@ func (s *Server) handleUpdatePersona(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	if err := checkPersonaInputSafety(s.loadSafetyRules(r.Context()), input); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 	if err := req.validatePositiveQuotas(); err != nil {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	style, err := req.normalizedStyle()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	timezone, err := req.normalizedActiveHours()
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 
 	var p Persona
-	writingSamplesJSON, doNotSayJSON, catchphrasesJSON := marshalPersonaJSONFields(input)
+	writingSamplesJSON, doNotSayJSON, catchphrasesJSON, err := encodePersonaJSONFields(input)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	writingSamplesJSON, doNotSayJSON, catchphrasesJSON, err = s.sealPersonaJSONFields(r.Context(), userID, writingSamplesJSON, doNotSayJSON, catchphrasesJSON)
+	if err != nil {
+		writeInternalError(w, "could not encrypt persona calibration")
+		return
+	}
+
+	// Omitting style keeps the stored sliders, so older clients do not reset them.
+	var styleHumor, styleAssertiveness, styleTechnicality, styleBrevity *int
+	if style != nil {
+		styleHumor, styleAssertiveness, styleTechnicality, styleBrevity = &style.Humor, &style.Assertiveness, &style.Technicality, &style.Brevity
+	}
 
-	err = scanPersona(s.db.QueryRow(r.Context(), `
+	err = s.scanPersona(r.Context(), s.db.QueryRow(r.Context(), `
 		UPDATE personas
-		SET name=$1, bio=$2, tone=$3, writing_samples=$4::jsonb, do_not_say=$5::jsonb, catchphrases=$6::jsonb, preferred_language=$7, formality=$8, daily_draft_quota=$9, daily_reply_quota=$10, updated_at=NOW()
-		WHERE id=$11 AND user_id=$12
-		RETURNING id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, created_at, updated_at
-	`, input.Name, input.Bio, input.Tone, writingSamplesJSON, doNotSayJSON, catchphrasesJSON, input.PreferredLanguage, input.Formality, req.DailyDraftQuota, req.DailyReplyQuota, personaID, userID), &p)
+		SET name=$1, bio=$2, tone=$3, writing_samples=$4::jsonb, do_not_say=$5::jsonb, catchphrases=$6::jsonb, preferred_language=$7, formality=$8, daily_draft_quota=$9, daily_reply_quota=$10, active_hours_start=$11, active_hours_end=$12, timezone=$13,
+			style_humor=COALESCE($16, style_humor),
+			style_assertiveness=COALESCE($17, style_assertiveness),
+			style_technicality=COALESCE($18, style_technicality),
+			style_brevity=COALESCE($19, style_brevity),
+			style_source=CASE WHEN $16::int IS NULL THEN style_source ELSE 'manual' END,
+			updated_at=NOW()
+		WHERE id=$14 AND user_id=$15
+		RETURNING id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source, created_at, updated_at
+	`, input.Name, input.Bio, input.Tone, writingSamplesJSON, doNotSayJSON, catchphrasesJSON, input.PreferredLanguage, input.Formality, req.DailyDraftQuota, req.DailyReplyQuota, req.ActiveHoursStart, req.ActiveHoursEnd, timezone, personaID, userID, styleHumor, styleAssertiveness, styleTechnicality, styleBrevity), &p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "persona not found")
@@ -898,15 +1309,20 @@ func (s *Server) handleDeletePersona(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ct, err := s.db.Exec(r.Context(), "DELETE FROM personas WHERE id=$1 AND user_id=$2", personaID, userID)
+	var personaName string
+	err = s.db.QueryRow(r.Context(), "DELETE FROM personas WHERE id=$1 AND user_id=$2 RETURNING name", personaID, userID).Scan(&personaName)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "persona not found")
+			return
+		}
 		writeInternalError(w, "could not delete persona")
 		return
 	}
-	if ct.RowsAffected() == 0 {
-		writeNotFound(w, "persona not found")
-		return
-	}
+	s.recordAudit(r, userID, auditPersonaDeleted, map[string]any{
+		"persona_id":   personaID,
+		"persona_name": personaName,
+	})
 
 	writeJSON(w, http.StatusOK, map[string]any{"deleted": true})
 }
@@ -922,13 +1338,26 @@ func (s *Server) handlePreviewPersona(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	s.writePersonaPreview(w, r, userID, personaID, nil)
+}
+
+// writePersonaPreview generates two draft previews of a persona in the room
+// given by ?room_id. Previews spend the persona's preview quota and are
+// safety-checked on behalf of ownerUserID, whoever asked for them; metadata
+// is added to the quota event and the preview_generated event.
+func (s *Server) writePersonaPreview(w http.ResponseWriter, r *http.Request, ownerUserID, personaID string, metadata map[string]any) {
 	roomID, err := validateUUID(r.URL.Query().Get("room_id"), "room_id")
 	if err != nil {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	mood, err := ai.NormalizeMood(r.URL.Query().Get("mood"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 
-	persona, err := s.getPersonaByID(r.Context(), userID, personaID)
+	persona, err := s.getPersonaByID(r.Context(), ownerUserID, personaID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "persona not found")
@@ -958,41 +1387,71 @@ func (s *Server) handlePreviewPersona(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	roomHint, err := common.LoadRoomPromptHint(r.Context(), s.db, room.ID)
+	if err != nil {
+		writeInternalError(w, "could not load room prompt hint")
+		return
+	}
+
+	s.syncPrompts(r.Context())
+	promptVersion := s.llm.Prompts().Active(prompts.OpPostDraft)
+	personaCtx := personaToAIContext(persona)
+	personaCtx.Mood = mood
 	drafts := make([]PreviewDraft, 0, 2)
 	for variant := 1; variant <= 2; variant++ {
-		draft, err := s.llm.GeneratePostDraft(r.Context(), personaToAIContext(persona), ai.RoomContext{
+		draft, err := s.llm.GeneratePostDraft(r.Context(), personaCtx, ai.RoomContext{
 			ID:          room.ID,
 			Name:        room.Name,
 			Description: room.Description,
 			Variant:     variant,
+			Hint:        ai.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure},
 		})
 		if err != nil {
 			writeBadGateway(w, fmt.Sprintf("llm preview failed: %v", err))
 			return
 		}
-		if err := safety.ValidateContent(draft, s.cfg.DraftMaxLen); err != nil {
-			writeBadRequest(w, err.Error())
+		if rejectionID, err := s.checkGeneratedContent(r.Context(), common.SafetyRejection{
+			UserID:    ownerUserID,
+			PersonaID: personaID,
+			RoomID:    room.ID,
+			Source:    common.SafetySourcePreview,
+			Language:  persona.PreferredLanguage,
+			Content:   draft,
+			MaxLen:    s.cfg.DraftMaxLen,
+			Metadata:  map[string]any{"variant": variant, "prompt_version": promptVersion, "mood": mood, "prompt_hint_version": roomHint.Version},
+		}); err != nil {
+			writeSafetyRejected(w, rejectionID, err)
 			return
 		}
 		drafts = append(drafts, PreviewDraft{
-			Label:      fmt.Sprintf("AI Preview %d", variant),
-			Content:    draft,
-			AuthoredBy: "AI",
+			Label:             fmt.Sprintf("AI Preview %d", variant),
+			Content:           draft,
+			AuthoredBy:        "AI",
+			PromptVersion:     promptVersion,
+			Mood:              mood,
+			PromptHintVersion: roomHint.Version,
 		})
 	}
 
-	if _, err := s.db.Exec(r.Context(), `
-		INSERT INTO quota_events(persona_id, quota_type)
-		VALUES ($1, 'preview')
-	`, personaID); err != nil {
+	quotaMetadata := map[string]any{
+		"room_id": roomID,
+		"drafts":  len(drafts),
+	}
+	eventMetadata := map[string]any{
+		"persona_id": personaID,
+		"room_id":    roomID,
+		"mood":       mood,
+	}
+	for key, value := range metadata {
+		quotaMetadata[key] = value
+		eventMetadata[key] = value
+	}
+	if err := s.insertQuotaEvent(r, personaID, common.QuotaTypePreview, "", quotaMetadata); err != nil {
 		writeInternalError(w, "could not record preview quota")
 		return
 	}
 
-	_ = s.logEventFromRequest(r, eventPreviewGenerated, map[string]any{
-		"persona_id": personaID,
-		"room_id":    roomID,
-	})
+	_ = s.logEventFromRequest(r, eventPreviewGenerated, eventMetadata)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"drafts": drafts,
@@ -1014,16 +1473,11 @@ func (s *Server) handleGetTodayDigest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	owned, err := s.personaOwnedByUser(r.Context(), userID, personaID)
+	location, err := s.userLocation(r.Context(), userID)
 	if err != nil {
-		writeInternalError(w, "could not check persona")
-		return
-	}
-	if !owned {
-		writeNotFound(w, "persona not found")
+		writeInternalError(w, "could not load digest")
 		return
 	}
-
 	digest, exists, err := s.getDigestForDate(r.Context(), personaID, time.Now().UTC())
 	if err != nil {
 		writeInternalError(w, "could not load digest")
@@ -1032,6 +1486,7 @@ func (s *Server) handleGetTodayDigest(w http.ResponseWriter, r *http.Request) {
 	if !exists {
 		digest = emptyDigest(personaID, time.Now().UTC())
 	}
+	localizeDigest(&digest, location)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"digest": digest,
@@ -1050,16 +1505,11 @@ func (s *Server) handleGetLatestDigest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	owned, err := s.personaOwnedByUser(r.Context(), userID, personaID)
+	location, err := s.userLocation(r.Context(), userID)
 	if err != nil {
-		writeInternalError(w, "could not check persona")
-		return
-	}
-	if !owned {
-		writeNotFound(w, "persona not found")
+		writeInternalError(w, "could not load digest")
 		return
 	}
-
 	digest, exists, err := s.getLatestDigest(r.Context(), personaID)
 	if err != nil {
 		writeInternalError(w, "could not load digest")
@@ -1068,6 +1518,7 @@ func (s *Server) handleGetLatestDigest(w http.ResponseWriter, r *http.Request) {
 	if !exists {
 		digest = emptyDigest(personaID, time.Now().UTC())
 	}
+	localizeDigest(&digest, location)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"digest": digest,
@@ -1077,8 +1528,9 @@ func (s *Server) handleGetLatestDigest(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleListRooms(w http.ResponseWriter, r *http.Request) {
 	rows, err := s.db.Query(r.Context(), `
-		SELECT id::text, slug, name, description, created_at
+		SELECT id::text, slug, name, description, created_at, event_starts_at, event_ends_at
 		FROM rooms
+		WHERE archived_at IS NULL
 		ORDER BY name ASC
 	`)
 	if err != nil {
@@ -1087,15 +1539,32 @@ func (s *Server) handleListRooms(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
+	now := time.Now()
 	rooms := make([]Room, 0)
 	for rows.Next() {
 		var rm Room
-		if err := rows.Scan(&rm.ID, &rm.Slug, &rm.Name, &rm.Description, &rm.CreatedAt); err != nil {
+		var eventStartsAt, eventEndsAt *time.Time
+		if err := rows.Scan(&rm.ID, &rm.Slug, &rm.Name, &rm.Description, &rm.CreatedAt, &eventStartsAt, &eventEndsAt); err != nil {
 			writeInternalError(w, "could not scan room")
 			return
 		}
+		rm.Event = newRoomEvent(eventStartsAt, eventEndsAt, "", now)
 		rooms = append(rooms, rm)
 	}
+	rows.Close()
+
+	roomIDs := make([]string, len(rooms))
+	for i, rm := range rooms {
+		roomIDs[i] = rm.ID
+	}
+	chips, err := s.loadRelatedRoomChips(r.Context(), roomIDs)
+	if err != nil {
+		writeInternalError(w, "could not load related rooms")
+		return
+	}
+	for i := range rooms {
+		rooms[i].Related = chips[rooms[i].ID]
+	}
 
 	writeJSON(w, http.StatusOK, map[string]any{"rooms": rooms})
 }
@@ -1110,13 +1579,26 @@ func (s *Server) handleListRoomPosts(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	if s.redirectMergedRoom(w, r, roomID, "/posts") {
+		return
+	}
 
 	rows, err := s.db.Query(r.Context(), `
-		SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, p.created_at, p.updated_at
+		SELECT
+			p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, COALESCE(p.mood, ''), p.created_at, p.updated_at,
+			COALESCE(rc.likes, 0), COALESCE(rc.insightful, 0), COALESCE(rc.disagree, 0)
 		FROM posts p
 		LEFT JOIN personas pr ON pr.id = p.persona_id
+		LEFT JOIN LATERAL (
+			SELECT
+				COUNT(*) FILTER (WHERE reaction = 'like')::int AS likes,
+				COUNT(*) FILTER (WHERE reaction = 'insightful')::int AS insightful,
+				COUNT(*) FILTER (WHERE reaction = 'disagree')::int AS disagree
+			FROM post_reactions
+			WHERE post_id = p.id
+		) rc ON TRUE
 		WHERE p.room_id = $1
-		  AND (p.status = 'PUBLISHED' OR p.user_id = $2)
+		  AND ((p.status = 'PUBLISHED' AND p.visibility = 'public' AND NOT p.hidden_from_rooms) OR p.user_id = $2)
 		ORDER BY p.created_at DESC
 		LIMIT 100
 	`, roomID, userID)
@@ -1129,7 +1611,7 @@ func (s *Server) handleListRoomPosts(w http.ResponseWriter, r *http.Request) {
 	posts := make([]Post, 0)
 	for rows.Next() {
 		var p Post
-		if err := rows.Scan(&p.ID, &p.RoomID, &p.PersonaID, &p.Persona, &p.AuthoredBy, &p.Status, &p.Content, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.RoomID, &p.PersonaID, &p.Persona, &p.AuthoredBy, &p.Status, &p.Content, &p.Mood, &p.CreatedAt, &p.UpdatedAt, &p.Reactions.Like, &p.Reactions.Insightful, &p.Reactions.Disagree); err != nil {
 			writeInternalError(w, "could not scan post")
 			return
 		}
@@ -1152,6 +1634,7 @@ func (s *Server) handleCreateDraft(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		PersonaID string `json:"persona_id"`
+		Mood      string `json:"mood"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
@@ -1162,6 +1645,11 @@ func (s *Server) handleCreateDraft(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	req.Mood, err = ai.NormalizeMood(req.Mood)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
 
 	persona, err := s.getPersonaByID(r.Context(), userID, req.PersonaID)
 	if err != nil {
@@ -1182,53 +1670,105 @@ func (s *Server) handleCreateDraft(w http.ResponseWriter, r *http.Request) {
 		writeInternalError(w, "could not load room")
 		return
 	}
+	if s.rejectReadOnlyRoom(w, r, room, "/posts/draft") {
+		return
+	}
 
 	used, err := s.currentQuotaUsage(r.Context(), req.PersonaID, "draft")
 	if err != nil {
 		writeInternalError(w, "could not check quota")
 		return
 	}
-	if used >= persona.DailyDraftQuota {
+	queued, err := s.unfinishedDraftJobs(r.Context(), req.PersonaID)
+	if err != nil {
+		writeInternalError(w, "could not check quota")
+		return
+	}
+	boost, err := common.ActiveQuotaBoost(r.Context(), s.db, userID, common.QuotaBoostDraft)
+	if err != nil {
+		writeInternalError(w, "could not check quota")
+		return
+	}
+	if used+queued >= persona.DailyDraftQuota+boost {
 		writeTooManyRequests(w, "daily draft quota reached")
 		return
 	}
+	if s.rejectRoomCooldown(w, r, room.ID, req.PersonaID) {
+		return
+	}
 
-	draft, err := s.llm.GeneratePostDraft(r.Context(), personaToAIContext(persona), ai.RoomContext{
+	roomHint, err := common.LoadRoomPromptHint(r.Context(), s.db, room.ID)
+	if err != nil {
+		writeInternalError(w, "could not load room prompt hint")
+		return
+	}
+
+	s.syncPrompts(r.Context())
+	promptVersion := s.llm.Prompts().Active(prompts.OpPostDraft)
+	personaCtx := personaToAIContext(persona)
+	personaCtx.Mood = req.Mood
+	draftCtx, cancelDraft := s.draftSLAContext(r.Context())
+	defer cancelDraft()
+	generationStartedAt := time.Now()
+	draft, err := s.llm.GeneratePostDraft(draftCtx, personaCtx, ai.RoomContext{
 		ID:          room.ID,
 		Name:        room.Name,
 		Description: room.Description,
 		Variant:     1,
+		Hint:        ai.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure},
 	})
+	generationElapsed := time.Since(generationStartedAt)
 	if err != nil {
+		if draftSLAExceeded(r.Context(), draftCtx) {
+			s.enqueueDraftJob(w, r, userID, req.PersonaID, room.ID, req.Mood)
+			return
+		}
 		writeBadGateway(w, fmt.Sprintf("llm draft failed: %v", err))
 		return
 	}
 
-	if err := safety.ValidateContent(draft, s.cfg.DraftMaxLen); err != nil {
-		writeBadRequest(w, err.Error())
+	if rejectionID, err := s.checkGeneratedContent(r.Context(), common.SafetyRejection{
+		UserID:    userID,
+		PersonaID: req.PersonaID,
+		RoomID:    room.ID,
+		Source:    common.SafetySourceDraft,
+		Language:  persona.PreferredLanguage,
+		Content:   draft,
+		MaxLen:    s.cfg.DraftMaxLen,
+		Metadata:  map[string]any{"prompt_version": promptVersion, "mood": req.Mood, "prompt_hint_version": roomHint.Version},
+	}); err != nil {
+		writeSafetyRejected(w, rejectionID, err)
 		return
 	}
 
 	var post Post
 	err = s.db.QueryRow(r.Context(), `
-		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content)
-		VALUES ($1, $2, $3, 'AI', 'DRAFT', $4)
-		RETURNING id::text, room_id::text, COALESCE(persona_id::text, ''), authored_by::text, status::text, content, created_at, updated_at
-	`, roomID, req.PersonaID, userID, draft).
-		Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.AuthoredBy, &post.Status, &post.Content, &post.CreatedAt, &post.UpdatedAt)
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, prompt_version, mood, prompt_hint_id)
+		VALUES ($1, $2, $3, 'AI', 'DRAFT', $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, 0))
+		RETURNING id::text, room_id::text, COALESCE(persona_id::text, ''), authored_by::text, status::text, content, COALESCE(mood, ''), created_at, updated_at
+	`, roomID, req.PersonaID, userID, draft, promptVersion, req.Mood, roomHint.ID).
+		Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.AuthoredBy, &post.Status, &post.Content, &post.Mood, &post.CreatedAt, &post.UpdatedAt)
 	if err != nil {
 		writeInternalError(w, "could not create draft")
 		return
 	}
 	post.Persona = persona.Name
 
-	if _, err := s.db.Exec(r.Context(), `
-		INSERT INTO quota_events(persona_id, quota_type)
-		VALUES ($1, 'draft')
-	`, req.PersonaID); err != nil {
+	if err := s.insertQuotaEvent(r, req.PersonaID, common.QuotaTypeDraft, post.ID, map[string]any{
+		"room_id": roomID,
+	}); err != nil {
 		writeInternalError(w, "could not record quota")
 		return
 	}
+	s.queueLLMCanary(r.Context(), common.LLMCanaryRun{
+		Kind:           common.LLMCanaryKindDraft,
+		PersonaID:      req.PersonaID,
+		RoomID:         roomID,
+		PostID:         post.ID,
+		Mood:           req.Mood,
+		PrimaryOutput:  draft,
+		PrimaryLatency: generationElapsed,
+	})
 
 	writeJSON(w, http.StatusCreated, post)
 }
@@ -1246,11 +1786,20 @@ func (s *Server) handleApprovePost(w http.ResponseWriter, r *http.Request) {
 
 	var current Post
 	var ownerUserID string
+	var roomArchived, unpublished bool
+	var room Room
+	var topicCheck string
+	var eventStartsAt, eventEndsAt *time.Time
 	err = s.db.QueryRow(r.Context(), `
-		SELECT id::text, room_id::text, COALESCE(persona_id::text, ''), authored_by::text, status::text, content, created_at, updated_at, user_id::text
-		FROM posts
-		WHERE id = $1
-	`, postID).Scan(&current.ID, &current.RoomID, &current.PersonaID, &current.AuthoredBy, &current.Status, &current.Content, &current.CreatedAt, &current.UpdatedAt, &ownerUserID)
+		SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), p.authored_by::text, p.status::text, p.content, p.created_at, p.updated_at, p.user_id::text,
+			rm.archived_at IS NOT NULL AND rm.merged_into_room_id IS NULL,
+			rm.slug, rm.name, rm.description, rm.topic_check, rm.event_starts_at, rm.event_ends_at,
+			p.unpublished_at IS NOT NULL
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.id = $1
+	`, postID).Scan(&current.ID, &current.RoomID, &current.PersonaID, &current.AuthoredBy, &current.Status, &current.Content, &current.CreatedAt, &current.UpdatedAt, &ownerUserID, &roomArchived,
+		&room.Slug, &room.Name, &room.Description, &topicCheck, &eventStartsAt, &eventEndsAt, &unpublished)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "post not found")
@@ -1260,17 +1809,34 @@ func (s *Server) handleApprovePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ownerUserID != userID {
-		writeForbidden(w, "not allowed")
+	if !writeAccessDecision(w, authz.Decide(authz.Subject{UserID: userID}, authz.Post, authz.Manage, authz.Facts{Exists: true, OwnerUserID: ownerUserID})) {
 		return
 	}
 	if current.Status != "DRAFT" {
 		writeConflict(w, "only drafts can be approved")
 		return
 	}
+	// Unpublished posts come back through bulk-status republish, which keeps
+	// their original publish time and records the change.
+	if unpublished {
+		writeConflict(w, "unpublished posts are republished with /personas/:id/posts/bulk-status")
+		return
+	}
+	if roomArchived {
+		writeConflict(w, "room is archived")
+		return
+	}
+	if err := roomEventWriteError(newRoomEvent(eventStartsAt, eventEndsAt, "", time.Now())); err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
+	if current.PersonaID != "" && s.rejectRoomCooldown(w, r, current.RoomID, current.PersonaID) {
+		return
+	}
 
 	var req struct {
-		Content string `json:"content"`
+		Content         string `json:"content"`
+		PublishOffTopic bool   `json:"publish_off_topic"`
 	}
 	if err := decodeJSONAllowEmpty(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
@@ -1282,10 +1848,24 @@ func (s *Server) handleApprovePost(w http.ResponseWriter, r *http.Request) {
 		content = req.Content
 	}
 
-	if err := safety.ValidateContent(content, s.cfg.DraftMaxLen); err != nil {
+	approvedByAdmin, err := s.approvedDraftContent(r.Context(), postID, content)
+	if err != nil {
+		writeInternalError(w, "could not check draft review")
+		return
+	}
+	if approvedByAdmin {
+		err = safety.ValidateShape(content, s.cfg.DraftMaxLen)
+	} else {
+		err = s.loadSafetyRules(r.Context()).Validate(content, s.cfg.DraftMaxLen)
+	}
+	if err != nil {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	room.ID = current.RoomID
+	if s.rejectOffTopicDraft(w, r, room, topicCheck, content, req.PublishOffTopic) {
+		return
+	}
 
 	tx, err := s.db.Begin(r.Context())
 	if err != nil {
@@ -1333,6 +1913,12 @@ func (s *Server) handleApprovePost(w http.ResponseWriter, r *http.Request) {
 		"room_id":    out.RoomID,
 		"persona_id": strings.TrimSpace(out.PersonaID),
 	})
+	s.recordMentions(r, common.MentionSource{
+		PostID:          out.ID,
+		AuthorUserID:    userID,
+		AuthorPersonaID: strings.TrimSpace(out.PersonaID),
+		Content:         out.Content,
+	})
 
 	writeJSON(w, http.StatusOK, out)
 }
@@ -1348,12 +1934,7 @@ func (s *Server) handleGenerateReplies(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var postStatus string
-	err = s.db.QueryRow(r.Context(), `
-		SELECT status::text
-		FROM posts
-		WHERE id=$1
-	`, postID).Scan(&postStatus)
+	target, err := s.loadReplyTarget(r.Context(), postID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "post not found")
@@ -1362,10 +1943,18 @@ func (s *Server) handleGenerateReplies(w http.ResponseWriter, r *http.Request) {
 		writeInternalError(w, "could not load post")
 		return
 	}
-	if postStatus != "PUBLISHED" {
+	if target.Status != "PUBLISHED" {
 		writeConflict(w, "replies can be generated only for published posts")
 		return
 	}
+	if _, err := s.authorizeBattleRead(r, postID); err != nil {
+		writeBattleReadError(w, err)
+		return
+	}
+	if err := target.conflict(time.Now()); err != nil {
+		writeConflict(w, err.Error())
+		return
+	}
 
 	var req struct {
 		PersonaIDs []string `json:"persona_ids"`
@@ -1381,70 +1970,7 @@ func (s *Server) handleGenerateReplies(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	enqueued := 0
-	skipped := 0
-
-	for _, personaID := range personaIDs {
-		persona, err := s.getPersonaByID(r.Context(), userID, personaID)
-		if err != nil {
-			skipped++
-			continue
-		}
-
-		used, err := s.currentQuotaUsage(r.Context(), personaID, "reply")
-		if err != nil {
-			skipped++
-			continue
-		}
-		if used >= persona.DailyReplyQuota {
-			skipped++
-			continue
-		}
-
-		var alreadyReplied bool
-		err = s.db.QueryRow(r.Context(), `
-			SELECT EXISTS(
-				SELECT 1 FROM replies WHERE post_id=$1 AND persona_id=$2
-			)
-		`, postID, personaID).Scan(&alreadyReplied)
-		if err != nil || alreadyReplied {
-			skipped++
-			continue
-		}
-
-		var pending bool
-		err = s.db.QueryRow(r.Context(), `
-			SELECT EXISTS(
-				SELECT 1 FROM jobs
-				WHERE post_id=$1 AND persona_id=$2 AND job_type='generate_reply' AND status IN ('PENDING', 'PROCESSING')
-			)
-		`, postID, personaID).Scan(&pending)
-		if err != nil || pending {
-			skipped++
-			continue
-		}
-
-		payloadMap := map[string]any{
-			"post_id":    postID,
-			"persona_id": personaID,
-		}
-		if traceID := strings.TrimSpace(requestIDFromRequest(r)); traceID != "" {
-			payloadMap["trace_id"] = traceID
-		}
-		payload, err := json.Marshal(payloadMap)
-		if err != nil {
-			skipped++
-			continue
-		}
-		if _, err := s.db.Exec(r.Context(), `
-			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
-			VALUES ('generate_reply', $1, $2, $3::jsonb, 'PENDING', NOW())
-		`, postID, personaID, payload); err != nil {
-			skipped++
-			continue
-		}
-		enqueued++
-	}
+	enqueued, skipped := s.enqueueReplyJobs(r, userID, postID, personaIDs, nil)
 
 	if enqueued > 0 {
 		_ = s.logEventFromRequest(r, eventBattleCreated, map[string]any{
@@ -1459,6 +1985,25 @@ func (s *Server) handleGenerateReplies(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// summarizeThread is the thread's ai_summary, also pushed by thread streams.
+func (s *Server) summarizeThread(ctx context.Context, postID, postContent string, replies []Reply) string {
+	thread := make([]ai.ReplyContext, 0, len(replies))
+	for _, reply := range replies {
+		thread = append(thread, ai.ReplyContext{ID: reply.ID, Content: reply.Content, Human: reply.AuthoredBy == "HUMAN"})
+	}
+
+	s.syncPrompts(ctx)
+	summary, err := s.llm.SummarizeThread(ctx, ai.PostContext{ID: postID, Content: postContent}, thread)
+	if err != nil {
+		summary = "Thread summary unavailable right now."
+	}
+	if len([]rune(summary)) > s.cfg.SummaryMaxLen {
+		runes := []rune(summary)
+		summary = string(runes[:s.cfg.SummaryMaxLen])
+	}
+	return summary
+}
+
 func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
 	userID, ok := s.requireUserID(w, r)
 	if !ok {
@@ -1472,12 +2017,16 @@ func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
 
 	var post Post
 	var postOwner string
+	var visibility string
+	var isBattle bool
+	var language common.BattleLanguage
 	err = s.db.QueryRow(r.Context(), `
-		SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, p.created_at, p.updated_at, p.user_id::text
+		SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, COALESCE(p.source_post_id::text, ''), COALESCE(p.mood, ''), p.created_at, p.updated_at, p.user_id::text, p.visibility,
+			p.template_id IS NOT NULL, p.battle_language, COALESCE(p.battle_language_code, '')
 		FROM posts p
 		LEFT JOIN personas pr ON pr.id = p.persona_id
 		WHERE p.id = $1
-	`, postID).Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.Persona, &post.AuthoredBy, &post.Status, &post.Content, &post.CreatedAt, &post.UpdatedAt, &postOwner)
+	`, postID).Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.Persona, &post.AuthoredBy, &post.Status, &post.Content, &post.SourcePostID, &post.Mood, &post.CreatedAt, &post.UpdatedAt, &postOwner, &visibility, &isBattle, &language.Mode, &language.Language)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "post not found")
@@ -1491,44 +2040,69 @@ func (s *Server) handleGetThread(w http.ResponseWriter, r *http.Request) {
 		writeForbidden(w, "not allowed")
 		return
 	}
+	if visibility == battleVisibilityUnlisted && postOwner != userID {
+		if _, err := s.authorizeBattleRead(r, post.ID); err != nil {
+			writeBattleReadError(w, err)
+			return
+		}
+	}
+	if isBattle {
+		post.Battle = &language
+	}
 
-	rows, err := s.db.Query(r.Context(), `
-		SELECT r.id::text, r.post_id::text, COALESCE(r.persona_id::text, ''), COALESCE(p.name, ''), r.authored_by::text, r.content, r.created_at, r.updated_at
-		FROM replies r
-		LEFT JOIN personas p ON p.id = r.persona_id
-		WHERE r.post_id = $1
-		ORDER BY r.created_at ASC
-	`, postID)
+	post.Reactions, err = s.postReactionCounts(r.Context(), post.ID)
 	if err != nil {
-		writeInternalError(w, "could not load replies")
+		writeInternalError(w, "could not load reactions")
 		return
 	}
-	defer rows.Close()
-
-	replies := make([]Reply, 0)
-	thread := make([]ai.ReplyContext, 0)
-	for rows.Next() {
-		var reply Reply
-		if err := rows.Scan(&reply.ID, &reply.PostID, &reply.PersonaID, &reply.Persona, &reply.AuthoredBy, &reply.Content, &reply.CreatedAt, &reply.UpdatedAt); err != nil {
-			writeInternalError(w, "could not scan reply")
-			return
-		}
-		replies = append(replies, reply)
-		thread = append(thread, ai.ReplyContext{ID: reply.ID, Content: reply.Content})
+	battles, err := s.listLinkedBattles(r.Context(), post.ID)
+	if err != nil {
+		writeInternalError(w, "could not load battles")
+		return
 	}
 
-	summary, err := s.llm.SummarizeThread(r.Context(), ai.PostContext{ID: post.ID, Content: post.Content}, thread)
+	battle, err := s.getBattleByID(r.Context(), postID)
 	if err != nil {
-		summary = "Thread summary unavailable right now."
-	}
-	if len([]rune(summary)) > s.cfg.SummaryMaxLen {
-		runes := []rune(summary)
-		summary = string(runes[:s.cfg.SummaryMaxLen])
+		writeInternalError(w, "could not load replies")
+		return
 	}
+	replies := battle.Turns
+	summary := s.summarizeThread(r.Context(), post.ID, post.Content, replies)
 
-	writeJSON(w, http.StatusOK, map[string]any{
+	out := map[string]any{
 		"post":       post,
 		"replies":    replies,
+		"battles":    battles,
 		"ai_summary": summary,
-	})
+	}
+	if battle.Archive != nil {
+		out["archive"] = battle.Archive
+	}
+	if isBattle {
+		watching, err := s.isWatchingBattle(r.Context(), userID, post.ID)
+		if err != nil {
+			writeInternalError(w, "could not load watch state")
+			return
+		}
+		out["watching"] = watching
+		votes, err := s.battleVoteTallies(r.Context(), post.ID)
+		if err != nil {
+			writeInternalError(w, "could not load votes")
+			return
+		}
+		myVote, err := s.battleVoteOf(r.Context(), post.ID, userID)
+		if err != nil {
+			writeInternalError(w, "could not load votes")
+			return
+		}
+		out["votes"] = votes
+		out["my_vote"] = myVote
+	}
+	if interactive, err := common.LoadInteractiveBattle(r.Context(), s.db, post.ID, false); err == nil {
+		out["interactive"] = interactive
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		writeInternalError(w, "could not load interactive battle")
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
 }
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	shareTokenVersion   = "v1"
+	shareTokenMaxSkew   = 5 * time.Minute
+	shareTokenQueryName = "st"
+)
+
+var (
+	errShareTokenInvalid = errors.New("invalid share token")
+	errShareTokenExpired = errors.New("share token expired")
+)
+
+// shareToken is the signed payload embedded in battle share URLs. The nonce
+// makes every issued link distinct so redemptions can be counted per link.
+type shareToken struct {
+	BattleID     string
+	SharerUserID string
+	IssuedAt     time.Time
+	Nonce        string
+}
+
+func signShareToken(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("share_token\x00"))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func createShareToken(secret string, token shareToken) string {
+	payload := strings.Join([]string{
+		shareTokenVersion,
+		token.BattleID,
+		token.SharerUserID,
+		strconv.FormatInt(token.IssuedAt.Unix(), 10),
+		token.Nonce,
+	}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signShareToken(secret, payload))
+}
+
+func parseShareToken(secret, raw string, now time.Time, ttl time.Duration) (shareToken, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok {
+		return shareToken{}, errShareTokenInvalid
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return shareToken{}, errShareTokenInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return shareToken{}, errShareTokenInvalid
+	}
+	payload := string(payloadBytes)
+	if !hmac.Equal(mac, signShareToken(secret, payload)) {
+		return shareToken{}, errShareTokenInvalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 5 || parts[0] != shareTokenVersion {
+		return shareToken{}, errShareTokenInvalid
+	}
+	issuedUnix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return shareToken{}, errShareTokenInvalid
+	}
+	token := shareToken{
+		BattleID:     parts[1],
+		SharerUserID: parts[2],
+		IssuedAt:     time.Unix(issuedUnix, 0).UTC(),
+		Nonce:        parts[4],
+	}
+	if _, err := validateUUID(token.BattleID, "battle id"); err != nil || token.Nonce == "" {
+		return shareToken{}, errShareTokenInvalid
+	}
+	if token.IssuedAt.After(now.Add(shareTokenMaxSkew)) {
+		return shareToken{}, errShareTokenInvalid
+	}
+	if ttl > 0 && now.After(token.IssuedAt.Add(ttl)) {
+		return shareToken{}, errShareTokenExpired
+	}
+	return token, nil
+}
+
+func newShareTokenNonce() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// handleCreateBattleShareLink issues a signed share URL for a published
+// battle. Signups that arrive with its token count as share-driven.
+func (s *Server) handleCreateBattleShareLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	battleID, err := validateUUID(chi.URLParam(r, "id"), "battle id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var visibility string
+	err = s.db.QueryRow(r.Context(), `
+		SELECT visibility
+		FROM posts
+		WHERE id = $1
+		  AND status = 'PUBLISHED'
+	`, battleID).Scan(&visibility)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "battle not found")
+			return
+		}
+		writeInternalError(w, "could not load battle")
+		return
+	}
+	if visibility == battleVisibilityUnlisted {
+		writeConflict(w, "unlisted battles are shared through POST /battles/{id}/visibility")
+		return
+	}
+
+	nonce, err := newShareTokenNonce()
+	if err != nil {
+		writeInternalError(w, "could not create share link")
+		return
+	}
+	issuedAt := time.Now().UTC()
+	token := createShareToken(s.cfg.JWTSecret, shareToken{
+		BattleID:     battleID,
+		SharerUserID: userID,
+		IssuedAt:     issuedAt,
+		Nonce:        nonce,
+	})
+
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"battle_id":   battleID,
+		"share_token": token,
+		"share_url":   fmt.Sprintf("%s&%s=%s", s.battleCardShareURL(battleID, assignBattleCardVariant(battleID)), shareTokenQueryName, token),
+		"expires_at":  issuedAt.Add(s.cfg.ShareTokenTTL).Format(time.RFC3339),
+	})
+}
+
+// attributeShareSignup verifies a share token sent with a signup and records
+// the attribution. It returns the reason when the token is not credited;
+// signup itself never fails because of the token.
+func (s *Server) attributeShareSignup(r *http.Request, userID, rawToken string) (string, error) {
+	token, err := parseShareToken(s.cfg.JWTSecret, rawToken, time.Now().UTC(), s.cfg.ShareTokenTTL)
+	if err != nil {
+		if errors.Is(err, errShareTokenExpired) {
+			return "expired", nil
+		}
+		return "invalid", nil
+	}
+	if token.SharerUserID == userID {
+		return "invalid", nil
+	}
+
+	redemptions, err := s.countShareTokenRedemptions(r.Context(), token.Nonce)
+	if err != nil {
+		return "", err
+	}
+	if s.cfg.ShareTokenMaxSignups > 0 && redemptions >= s.cfg.ShareTokenMaxSignups {
+		return "exhausted", nil
+	}
+
+	var published bool
+	if err := s.db.QueryRow(r.Context(), `
+		SELECT EXISTS(
+			SELECT 1
+			FROM posts
+			WHERE id = $1
+			  AND status = 'PUBLISHED'
+		)
+	`, token.BattleID).Scan(&published); err != nil {
+		return "", err
+	}
+	if !published {
+		return "battle_not_found", nil
+	}
+
+	// The sharer may have deleted their account since the link was issued.
+	tag, err := s.db.Exec(r.Context(), `
+		INSERT INTO share_signups(user_id, token_nonce, battle_id, sharer_user_id, ip_hash, token_issued_at)
+		VALUES ($1, $2, $3, (SELECT id FROM users WHERE id::text = $4), $5, $6)
+		ON CONFLICT (token_nonce, ip_hash) DO NOTHING
+	`, userID, token.Nonce, token.BattleID, token.SharerUserID, viewerKey("", requestClientIP(r), s.cfg.JWTSecret), token.IssuedAt)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "replayed", nil
+	}
+
+	_ = s.insertEvent(r.Context(), userID, eventSignupFromShare, map[string]any{
+		"source":         "share_token",
+		"battle_id":      token.BattleID,
+		"sharer_user_id": token.SharerUserID,
+		"token_age_sec":  int(time.Since(token.IssuedAt).Seconds()),
+	})
+	return "", nil
+}
+
+func (s *Server) countShareTokenRedemptions(ctx context.Context, nonce string) (int, error) {
+	var count int
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM share_signups
+		WHERE token_nonce = $1
+	`, nonce).Scan(&count)
+	return count, err
+}
+
+func (s *Server) logShareAttributionRejected(r *http.Request, userID, reason string) {
+	s.logger.Info("share_attribution_rejected", observability.Fields{
+		"request_id": requestIDFromRequest(r),
+		"user_id":    userID,
+		"reason":     reason,
+	})
+}
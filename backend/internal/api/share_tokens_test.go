@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShareTokenRoundTrip(t *testing.T) {
+	const secret = "share-secret"
+	issuedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	raw := createShareToken(secret, shareToken{
+		BattleID:     "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		SharerUserID: "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		IssuedAt:     issuedAt,
+		Nonce:        "abc123",
+	})
+
+	token, err := parseShareToken(secret, raw, issuedAt.Add(time.Hour), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("parse share token: %v", err)
+	}
+	if token.BattleID != "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f" || token.SharerUserID != "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+	if !token.IssuedAt.Equal(issuedAt) || token.Nonce != "abc123" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+
+	if _, err := parseShareToken(secret, raw, issuedAt.Add(25*time.Hour), 24*time.Hour); !errors.Is(err, errShareTokenExpired) {
+		t.Fatalf("expected expired token, got %v", err)
+	}
+	if _, err := parseShareToken(secret, raw, issuedAt.Add(-time.Hour), 24*time.Hour); !errors.Is(err, errShareTokenInvalid) {
+		t.Fatalf("expected token from the future to be rejected, got %v", err)
+	}
+	if _, err := parseShareToken("other-secret", raw, issuedAt, 24*time.Hour); !errors.Is(err, errShareTokenInvalid) {
+		t.Fatalf("expected wrong secret to be rejected, got %v", err)
+	}
+}
+
+func TestParseShareTokenRejectsTampering(t *testing.T) {
+	const secret = "share-secret"
+	now := time.Now().UTC()
+	raw := createShareToken(secret, shareToken{
+		BattleID:     "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		SharerUserID: "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		IssuedAt:     now,
+		Nonce:        "abc123",
+	})
+	_, mac, _ := strings.Cut(raw, ".")
+
+	forged := createShareToken("guessed", shareToken{
+		BattleID:     "9d0e1f2a-3b4c-4d5e-a6f7-8091a2b3c4d5",
+		SharerUserID: "7b8e9f10-2c3d-4e5f-8a9b-0c1d2e3f4a5b",
+		IssuedAt:     now,
+		Nonce:        "abc123",
+	})
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for _, candidate := range []string{"", "garbage", raw + "x", forgedPayload + "." + mac, "." + mac} {
+		if _, err := parseShareToken(secret, candidate, now, time.Hour); !errors.Is(err, errShareTokenInvalid) {
+			t.Fatalf("expected %q to be rejected, got %v", candidate, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	slowQueriesDefaultLimit = 20
+	slowQueriesMaxLimit     = 100
+)
+
+type SlowQuery struct {
+	Fingerprint string     `json:"fingerprint"`
+	Query       string     `json:"query"`
+	Service     string     `json:"service"`
+	Calls       int64      `json:"calls"`
+	MaxMs       float64    `json:"max_ms"`
+	AvgMs       float64    `json:"avg_ms"`
+	LastMs      float64    `json:"last_ms"`
+	ExplainPlan string     `json:"explain_plan,omitempty"`
+	ExplainedAt *time.Time `json:"explained_at,omitempty"`
+	FirstSeenAt time.Time  `json:"first_seen_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+}
+
+// slowQueryOrderBy maps the sort query parameter to an ORDER BY clause. Only
+// these fixed strings are ever interpolated into the query.
+func slowQueryOrderBy(sort string) (string, bool) {
+	switch sort {
+	case "", "max":
+		return "max_ms DESC", true
+	case "total":
+		return "total_ms DESC", true
+	case "calls":
+		return "calls DESC, max_ms DESC", true
+	case "recent":
+		return "last_seen_at DESC", true
+	default:
+		return "", false
+	}
+}
+
+func (s *Server) handleListSlowQueries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	limit, err := parsePaginationLimit(r.URL.Query().Get("limit"), slowQueriesDefaultLimit, 1, slowQueriesMaxLimit)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	orderBy, ok := slowQueryOrderBy(r.URL.Query().Get("sort"))
+	if !ok {
+		writeBadRequest(w, "sort must be max, total, calls or recent")
+		return
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT
+			fingerprint,
+			query,
+			service,
+			calls,
+			max_ms,
+			CASE WHEN calls > 0 THEN total_ms / calls ELSE 0 END,
+			last_ms,
+			COALESCE(explain_plan, ''),
+			explained_at,
+			first_seen_at,
+			last_seen_at
+		FROM slow_queries
+		ORDER BY `+orderBy+`
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		writeInternalError(w, "could not load slow queries")
+		return
+	}
+	defer rows.Close()
+
+	queries := make([]SlowQuery, 0, limit)
+	for rows.Next() {
+		var item SlowQuery
+		if err := rows.Scan(
+			&item.Fingerprint,
+			&item.Query,
+			&item.Service,
+			&item.Calls,
+			&item.MaxMs,
+			&item.AvgMs,
+			&item.LastMs,
+			&item.ExplainPlan,
+			&item.ExplainedAt,
+			&item.FirstSeenAt,
+			&item.LastSeenAt,
+		); err != nil {
+			writeInternalError(w, "could not load slow queries")
+			return
+		}
+		queries = append(queries, item)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not load slow queries")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"slow_queries":    queries,
+		"threshold_ms":    s.cfg.SlowQueryThreshold.Milliseconds(),
+		"explain_percent": s.cfg.SlowQueryExplainPct,
+	})
+}
+
+// handleResetSlowQueries clears the table, typically after shipping a fix so
+// the list only shows what is still slow.
+func (s *Server) handleResetSlowQueries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	ct, err := s.db.Exec(r.Context(), `DELETE FROM slow_queries`)
+	if err != nil {
+		writeInternalError(w, "could not reset slow queries")
+		return
+	}
+
+	s.logger.Info("slow_queries_reset", observability.Fields{
+		"user_id": userID,
+		"deleted": ct.RowsAffected(),
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": ct.RowsAffected()})
+}
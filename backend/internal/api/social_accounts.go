@@ -0,0 +1,316 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/social"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const socialStateVersion = "v1"
+
+var (
+	errSocialStateInvalid = errors.New("invalid connect state")
+	errSocialStateExpired = errors.New("connect state expired")
+)
+
+// SocialAccount is a connected network account. Tokens are never returned.
+type SocialAccount struct {
+	Provider    string     `json:"provider"`
+	Handle      string     `json:"handle"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ConnectedAt time.Time  `json:"connected_at"`
+}
+
+// socialState is the OAuth state of one connect attempt. It is bound to the
+// user who started it, so a callback cannot attach an account to anyone else.
+type socialState struct {
+	Provider string
+	UserID   string
+	IssuedAt time.Time
+	Nonce    string
+}
+
+func signSocialState(secret, label, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(label + "\x00"))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func socialStatePayload(state socialState) string {
+	return strings.Join([]string{
+		socialStateVersion,
+		state.Provider,
+		state.UserID,
+		strconv.FormatInt(state.IssuedAt.Unix(), 10),
+		state.Nonce,
+	}, ":")
+}
+
+func createSocialState(secret string, state socialState) string {
+	payload := socialStatePayload(state)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signSocialState(secret, "social_state", payload))
+}
+
+func parseSocialState(secret, raw string, now time.Time, ttl time.Duration) (socialState, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok {
+		return socialState{}, errSocialStateInvalid
+	}
+	payloadBytes, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return socialState{}, errSocialStateInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return socialState{}, errSocialStateInvalid
+	}
+	payload := string(payloadBytes)
+	if !hmac.Equal(mac, signSocialState(secret, "social_state", payload)) {
+		return socialState{}, errSocialStateInvalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 5 || parts[0] != socialStateVersion || parts[4] == "" {
+		return socialState{}, errSocialStateInvalid
+	}
+	issuedUnix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return socialState{}, errSocialStateInvalid
+	}
+	state := socialState{
+		Provider: parts[1],
+		UserID:   parts[2],
+		IssuedAt: time.Unix(issuedUnix, 0).UTC(),
+		Nonce:    parts[4],
+	}
+	if state.IssuedAt.After(now.Add(shareTokenMaxSkew)) {
+		return socialState{}, errSocialStateInvalid
+	}
+	if ttl > 0 && now.After(state.IssuedAt.Add(ttl)) {
+		return socialState{}, errSocialStateExpired
+	}
+	return state, nil
+}
+
+// socialPKCE derives the PKCE verifier from the signed state, so the
+// callback can rebuild it without storing anything between the two steps.
+func socialPKCE(secret, rawState string) (verifier, challenge string) {
+	verifier = base64.RawURLEncoding.EncodeToString(signSocialState(secret, "social_pkce", rawState))
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// requireSocialProvider returns the configured provider named by the route.
+func (s *Server) requireSocialProvider(w http.ResponseWriter, r *http.Request) (social.Provider, bool) {
+	name := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "provider")))
+	if name != social.ProviderX && name != social.ProviderLinkedIn {
+		writeBadRequest(w, "provider must be x or linkedin")
+		return nil, false
+	}
+	provider, ok := s.social[name]
+	if !ok {
+		writeError(w, http.StatusServiceUnavailable, name+" is not configured")
+		return nil, false
+	}
+	return provider, true
+}
+
+func (s *Server) logSocialFailure(r *http.Request, provider, step string, err error) {
+	s.logger.Warn("social_connect_failed", observability.Fields{
+		"provider":   provider,
+		"step":       step,
+		"error":      err.Error(),
+		"request_id": requestIDFromRequest(r),
+	})
+}
+
+func (s *Server) handleListSocialAccounts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	rows, err := s.db.Query(r.Context(), `
+		SELECT provider, handle, expires_at, connected_at
+		FROM social_accounts
+		WHERE user_id = $1
+		ORDER BY provider
+	`, userID)
+	if err != nil {
+		writeInternalError(w, "could not list social accounts")
+		return
+	}
+	defer rows.Close()
+
+	accounts := make([]SocialAccount, 0)
+	for rows.Next() {
+		var account SocialAccount
+		if err := rows.Scan(&account.Provider, &account.Handle, &account.ExpiresAt, &account.ConnectedAt); err != nil {
+			writeInternalError(w, "could not scan social account")
+			return
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list social accounts")
+		return
+	}
+
+	available := make([]string, 0, len(s.social))
+	for _, name := range []string{social.ProviderLinkedIn, social.ProviderX} {
+		if _, ok := s.social[name]; ok {
+			available = append(available, name)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"accounts":  accounts,
+		"providers": available,
+	})
+}
+
+// handleConnectSocialAccount starts the OAuth flow. The frontend sends the
+// user to authorize_url and posts the code and state it gets back to the
+// callback endpoint.
+func (s *Server) handleConnectSocialAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	provider, ok := s.requireSocialProvider(w, r)
+	if !ok {
+		return
+	}
+	nonce, err := newShareTokenNonce()
+	if err != nil {
+		writeInternalError(w, "could not start connection")
+		return
+	}
+	issuedAt := time.Now().UTC()
+	state := createSocialState(s.cfg.JWTSecret, socialState{
+		Provider: provider.Name(),
+		UserID:   userID,
+		IssuedAt: issuedAt,
+		Nonce:    nonce,
+	})
+	_, challenge := socialPKCE(s.cfg.JWTSecret, state)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"provider":      provider.Name(),
+		"authorize_url": provider.AuthorizeURL(state, challenge),
+		"expires_at":    issuedAt.Add(s.cfg.SocialConnectTTL),
+	})
+}
+
+func (s *Server) handleSocialAccountCallback(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	provider, ok := s.requireSocialProvider(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		writeBadRequest(w, "code is required")
+		return
+	}
+	state, err := parseSocialState(s.cfg.JWTSecret, req.State, time.Now().UTC(), s.cfg.SocialConnectTTL)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if state.UserID != userID || state.Provider != provider.Name() {
+		writeForbidden(w, "connect state belongs to another request")
+		return
+	}
+
+	verifier, _ := socialPKCE(s.cfg.JWTSecret, strings.TrimSpace(req.State))
+	token, err := provider.Exchange(r.Context(), code, verifier)
+	if err != nil {
+		s.logSocialFailure(r, provider.Name(), "exchange", err)
+		writeBadGateway(w, "could not connect "+provider.Name())
+		return
+	}
+	account, err := provider.Account(r.Context(), token.AccessToken)
+	if err != nil {
+		s.logSocialFailure(r, provider.Name(), "account", err)
+		writeBadGateway(w, "could not connect "+provider.Name())
+		return
+	}
+
+	var expiresAt *time.Time
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = &token.ExpiresAt
+	}
+	out := SocialAccount{Provider: provider.Name()}
+	err = s.db.QueryRow(r.Context(), `
+		INSERT INTO social_accounts(user_id, provider, external_user_id, handle, access_token, refresh_token, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, provider) DO UPDATE
+		SET external_user_id = EXCLUDED.external_user_id,
+			handle = EXCLUDED.handle,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			expires_at = EXCLUDED.expires_at,
+			connected_at = NOW(),
+			updated_at = NOW()
+		RETURNING handle, expires_at, connected_at
+	`, userID, provider.Name(), account.ExternalID, account.Handle, token.AccessToken, token.RefreshToken, expiresAt).
+		Scan(&out.Handle, &out.ExpiresAt, &out.ConnectedAt)
+	if err != nil {
+		writeInternalError(w, "could not save social account")
+		return
+	}
+
+	s.logger.Info("social_account_connected", observability.Fields{
+		"user_id":  userID,
+		"provider": provider.Name(),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"account": out})
+}
+
+// handleDeleteSocialAccount forgets the tokens. Cross-posts already
+// published stay on the network.
+func (s *Server) handleDeleteSocialAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(chi.URLParam(r, "provider")))
+	if name != social.ProviderX && name != social.ProviderLinkedIn {
+		writeBadRequest(w, "provider must be x or linkedin")
+		return
+	}
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM social_accounts
+		WHERE user_id = $1
+		  AND provider = $2
+	`, userID, name)
+	if err != nil {
+		writeInternalError(w, "could not disconnect social account")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"provider": name,
+		"removed":  ct.RowsAffected() > 0,
+	})
+}
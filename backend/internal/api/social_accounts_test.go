@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSocialStateRoundTrip(t *testing.T) {
+	const secret = "social-secret"
+	issuedAt := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	raw := createSocialState(secret, socialState{
+		Provider: "x",
+		UserID:   "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f",
+		IssuedAt: issuedAt,
+		Nonce:    "n0nce",
+	})
+
+	state, err := parseSocialState(secret, raw, issuedAt.Add(5*time.Minute), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("parse state: %v", err)
+	}
+	if state.Provider != "x" || state.UserID != "4f1c2d9e-8a6b-4c3d-9e2f-1a2b3c4d5e6f" || state.Nonce != "n0nce" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+	if _, err := parseSocialState(secret, raw, issuedAt.Add(11*time.Minute), 10*time.Minute); !errors.Is(err, errSocialStateExpired) {
+		t.Fatalf("expected expired state, got %v", err)
+	}
+	if _, err := parseSocialState("other-secret", raw, issuedAt, 10*time.Minute); !errors.Is(err, errSocialStateInvalid) {
+		t.Fatalf("expected state signed with another secret to be rejected, got %v", err)
+	}
+
+	payload, mac, _ := strings.Cut(raw, ".")
+	decoded, _ := base64.RawURLEncoding.DecodeString(payload)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(decoded), ":x:", ":linkedin:", 1))) + "." + mac
+	if _, err := parseSocialState(secret, forged, issuedAt, 10*time.Minute); !errors.Is(err, errSocialStateInvalid) {
+		t.Fatalf("expected tampered state to be rejected, got %v", err)
+	}
+}
+
+func TestSocialPKCEIsDerivedFromState(t *testing.T) {
+	verifier, challenge := socialPKCE("social-secret", "state-a")
+	again, _ := socialPKCE("social-secret", "state-a")
+	other, _ := socialPKCE("social-secret", "state-b")
+	if verifier != again || verifier == other {
+		t.Fatalf("verifier must be stable per state")
+	}
+	if len(verifier) < 43 || len(verifier) > 128 {
+		t.Fatalf("verifier length %d outside RFC 7636 bounds", len(verifier))
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("challenge is not the S256 of the verifier")
+	}
+}
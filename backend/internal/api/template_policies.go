@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	templatePolicyStatusPending  = "PENDING"
+	templatePolicyStatusApproved = "APPROVED"
+	templatePolicyStatusRejected = "REJECTED"
+
+	templatePolicyListLimit = 100
+)
+
+// TemplatePolicyRecord is a template's policy request. ApprovedPolicy is
+// what the worker applies; it stays in force while a revised request waits
+// for review and is nil until a first approval.
+type TemplatePolicyRecord struct {
+	TemplateID      string                 `json:"template_id"`
+	TemplateName    string                 `json:"template_name"`
+	OwnerUserID     string                 `json:"owner_user_id,omitempty"`
+	Status          string                 `json:"status"`
+	RequestedPolicy common.TemplatePolicy  `json:"requested_policy"`
+	ApprovedPolicy  *common.TemplatePolicy `json:"approved_policy"`
+	ReviewNote      string                 `json:"review_note,omitempty"`
+	RequestedAt     time.Time              `json:"requested_at"`
+	ReviewedAt      *time.Time             `json:"reviewed_at,omitempty"`
+}
+
+const templatePolicyColumns = `
+	tp.template_id::text,
+	t.name,
+	COALESCE(t.owner_user_id::text, ''),
+	tp.status,
+	tp.requested_policy,
+	tp.approved_policy,
+	tp.review_note,
+	tp.requested_at,
+	tp.reviewed_at
+`
+
+func scanTemplatePolicy(row pgx.Row) (TemplatePolicyRecord, error) {
+	var (
+		record       TemplatePolicyRecord
+		requestedRaw []byte
+		approvedRaw  []byte
+	)
+	if err := row.Scan(
+		&record.TemplateID,
+		&record.TemplateName,
+		&record.OwnerUserID,
+		&record.Status,
+		&requestedRaw,
+		&approvedRaw,
+		&record.ReviewNote,
+		&record.RequestedAt,
+		&record.ReviewedAt,
+	); err != nil {
+		return TemplatePolicyRecord{}, err
+	}
+	if err := json.Unmarshal(requestedRaw, &record.RequestedPolicy); err != nil {
+		return TemplatePolicyRecord{}, err
+	}
+	if approvedRaw != nil {
+		var approved common.TemplatePolicy
+		if err := json.Unmarshal(approvedRaw, &approved); err != nil {
+			return TemplatePolicyRecord{}, err
+		}
+		record.ApprovedPolicy = &approved
+	}
+	return record, nil
+}
+
+// normalizeTemplatePolicyRequest validates a policy an author asks for. An
+// empty policy is refused; authors clear a policy by deleting it.
+func normalizeTemplatePolicyRequest(policy common.TemplatePolicy) (common.TemplatePolicy, error) {
+	normalized, err := common.NormalizeTemplatePolicy(policy)
+	if err != nil {
+		return common.TemplatePolicy{}, err
+	}
+	if normalized.IsZero() {
+		return common.TemplatePolicy{}, errors.New("policy must set at least one field")
+	}
+	return normalized, nil
+}
+
+func validateTemplatePolicyStatus(value string) (string, error) {
+	clean := strings.ToUpper(strings.TrimSpace(value))
+	switch clean {
+	case "", templatePolicyStatusPending, templatePolicyStatusApproved, templatePolicyStatusRejected:
+		return clean, nil
+	default:
+		return "", errors.New("status must be PENDING, APPROVED or REJECTED")
+	}
+}
+
+// requestTemplatePolicy stores policy as templateID's pending request. The
+// approved policy, if any, is kept until an admin reviews the new one.
+func requestTemplatePolicy(ctx context.Context, querier common.DBQuerier, templateID, userID string, policy common.TemplatePolicy) (TemplatePolicyRecord, error) {
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		return TemplatePolicyRecord{}, err
+	}
+	return scanTemplatePolicy(querier.QueryRow(ctx, `
+		WITH upserted AS (
+			INSERT INTO template_policies(template_id, requested_policy, status, requested_by, requested_at)
+			VALUES ($1, $2::jsonb, 'PENDING', $3, NOW())
+			ON CONFLICT (template_id) DO UPDATE
+			SET requested_policy = EXCLUDED.requested_policy,
+				status = 'PENDING',
+				requested_by = EXCLUDED.requested_by,
+				requested_at = NOW(),
+				review_note = '',
+				reviewed_by = NULL,
+				reviewed_at = NULL
+			RETURNING *
+		)
+		SELECT `+templatePolicyColumns+`
+		FROM upserted tp
+		JOIN templates t ON t.id = tp.template_id
+	`, templateID, raw, userID))
+}
+
+func (s *Server) handleGetTemplatePolicy(w http.ResponseWriter, r *http.Request) {
+	templateID := accessResourceID(r)
+
+	record, err := scanTemplatePolicy(s.db.QueryRow(r.Context(), `
+		SELECT `+templatePolicyColumns+`
+		FROM template_policies tp
+		JOIN templates t ON t.id = tp.template_id
+		WHERE tp.template_id = $1
+	`, templateID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "template has no policy")
+			return
+		}
+		writeInternalError(w, "could not load template policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"policy": record})
+}
+
+func (s *Server) handlePutTemplatePolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	templateID := accessResourceID(r)
+	var req common.TemplatePolicy
+	if err := decodeJSON(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	policy, err := normalizeTemplatePolicyRequest(req)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	record, err := requestTemplatePolicy(r.Context(), s.db, templateID, userID, policy)
+	if err != nil {
+		writeInternalError(w, "could not save template policy")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"policy": record})
+}
+
+// handleDeleteTemplatePolicy drops the request and the approved policy, so
+// the template's battles go back to the global rules right away.
+func (s *Server) handleDeleteTemplatePolicy(w http.ResponseWriter, r *http.Request) {
+	templateID := accessResourceID(r)
+	ct, err := s.db.Exec(r.Context(), `
+		DELETE FROM template_policies
+		WHERE template_id = $1
+	`, templateID)
+	if err != nil {
+		writeInternalError(w, "could not delete template policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"template_id": templateID,
+		"removed":     ct.RowsAffected() > 0,
+	})
+}
+
+func (s *Server) handleAdminListTemplatePolicies(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.requireAdmin(w, r); !ok {
+		return
+	}
+	status, err := validateTemplatePolicyStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	if status == "" {
+		status = templatePolicyStatusPending
+	}
+
+	rows, err := s.db.Query(r.Context(), `
+		SELECT `+templatePolicyColumns+`
+		FROM template_policies tp
+		JOIN templates t ON t.id = tp.template_id
+		WHERE tp.status = $1
+		ORDER BY tp.requested_at ASC
+		LIMIT $2
+	`, status, templatePolicyListLimit)
+	if err != nil {
+		writeInternalError(w, "could not list template policies")
+		return
+	}
+	defer rows.Close()
+
+	policies := make([]TemplatePolicyRecord, 0)
+	for rows.Next() {
+		record, err := scanTemplatePolicy(rows)
+		if err != nil {
+			writeInternalError(w, "could not scan template policy")
+			return
+		}
+		policies = append(policies, record)
+	}
+	if err := rows.Err(); err != nil {
+		writeInternalError(w, "could not list template policies")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"policies": policies})
+}
+
+func (s *Server) handleApproveTemplatePolicy(w http.ResponseWriter, r *http.Request) {
+	s.reviewTemplatePolicy(w, r, templatePolicyStatusApproved)
+}
+
+func (s *Server) handleRejectTemplatePolicy(w http.ResponseWriter, r *http.Request) {
+	s.reviewTemplatePolicy(w, r, templatePolicyStatusRejected)
+}
+
+// reviewTemplatePolicy settles a pending request. Approving makes the
+// requested policy the one the worker applies; rejecting keeps whatever was
+// approved before.
+func (s *Server) reviewTemplatePolicy(w http.ResponseWriter, r *http.Request, decision string) {
+	adminID, ok := s.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	templateID, err := validateUUID(chi.URLParam(r, "id"), "template id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := decodeJSONAllowEmpty(r, &req); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	note, err := normalizeSafetyNote(req.Note)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	record, err := scanTemplatePolicy(s.db.QueryRow(r.Context(), `
+		WITH reviewed AS (
+			UPDATE template_policies
+			SET status = $2,
+				approved_policy = CASE WHEN $2 = 'APPROVED' THEN requested_policy ELSE approved_policy END,
+				review_note = $3,
+				reviewed_by = $4,
+				reviewed_at = NOW()
+			WHERE template_id = $1
+			  AND status = 'PENDING'
+			RETURNING *
+		)
+		SELECT `+templatePolicyColumns+`
+		FROM reviewed tp
+		JOIN templates t ON t.id = tp.template_id
+	`, templateID, decision, note, adminID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			var exists bool
+			if err := s.db.QueryRow(r.Context(), `
+				SELECT EXISTS(SELECT 1 FROM template_policies WHERE template_id = $1)
+			`, templateID).Scan(&exists); err == nil && exists {
+				writeConflict(w, "only pending policies can be reviewed")
+				return
+			}
+			writeNotFound(w, "template policy not found")
+			return
+		}
+		writeInternalError(w, "could not review template policy")
+		return
+	}
+
+	s.logger.Info("template_policy_reviewed", observability.Fields{
+		"template_id": templateID,
+		"decision":    decision,
+		"user_id":     adminID,
+	})
+	writeJSON(w, http.StatusOK, map[string]any{"policy": record})
+}
@@ -10,11 +10,17 @@ import (
 	"time"
 
 	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/safety"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	templatePromptRulesMaxLen = 1200
+	battleTopicMaxLen         = 180
+)
+
 func (s *Server) handleListPublicTemplates(w http.ResponseWriter, r *http.Request) {
 	templates, err := s.listPublicTemplates(r.Context(), 100)
 	if err != nil {
@@ -93,6 +99,8 @@ func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 		TurnCount   int    `json:"turn_count"`
 		WordLimit   int    `json:"word_limit"`
 		IsPublic    bool   `json:"is_public"`
+		// Policy is optional; it is stored as a pending request.
+		Policy *common.TemplatePolicy `json:"policy"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
@@ -103,9 +111,29 @@ func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 		writeBadRequest(w, err.Error())
 		return
 	}
+	if err := checkTemplatePromptRules(s.loadSafetyRules(r.Context()), req.PromptRules); err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	var policy common.TemplatePolicy
+	if req.Policy != nil {
+		var err error
+		policy, err = normalizeTemplatePolicyRequest(*req.Policy)
+		if err != nil {
+			writeBadRequest(w, fmt.Sprintf("policy: %v", err))
+			return
+		}
+	}
+
+	tx, err := s.db.Begin(r.Context())
+	if err != nil {
+		writeInternalError(w, "could not start transaction")
+		return
+	}
+	defer tx.Rollback(r.Context())
 
 	var out BattleTemplate
-	err := s.db.QueryRow(r.Context(), `
+	err = tx.QueryRow(r.Context(), `
 		INSERT INTO templates(owner_user_id, name, prompt_rules, turn_count, word_limit, is_public)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id::text, owner_user_id::text, name, prompt_rules, turn_count, word_limit, created_at, is_public
@@ -123,6 +151,18 @@ func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
 		writeInternalError(w, "could not create template")
 		return
 	}
+	if req.Policy != nil {
+		record, err := requestTemplatePolicy(r.Context(), tx, out.ID, userID, policy)
+		if err != nil {
+			writeInternalError(w, "could not save template policy")
+			return
+		}
+		out.Policy = &record
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		writeInternalError(w, "could not create template")
+		return
+	}
 
 	writeJSON(w, http.StatusCreated, out)
 }
@@ -149,13 +189,38 @@ func validateTemplateInput(name, promptRules string, turnCount, wordLimit int) e
 	return nil
 }
 
+// checkTemplatePromptRules applies the instruction checks and then the
+// content safety rules to a template's prompt_rules.
+func checkTemplatePromptRules(rules safety.Rules, value string) error {
+	if err := validateTemplatePromptRules(value); err != nil {
+		return err
+	}
+	if err := rules.Validate(value, templatePromptRulesMaxLen); err != nil {
+		return fmt.Errorf("prompt_rules: %w", err)
+	}
+	return nil
+}
+
+// validateBattleTopic checks a battle or challenge topic's length and runs it
+// through the safety rules.
+func validateBattleTopic(rules safety.Rules, value string) (string, error) {
+	topic, err := validateTopic(value, 3, battleTopicMaxLen)
+	if err != nil {
+		return "", err
+	}
+	if err := rules.Validate(topic, battleTopicMaxLen); err != nil {
+		return "", fmt.Errorf("topic: %w", err)
+	}
+	return topic, nil
+}
+
 func validateTemplatePromptRules(value string) error {
 	clean := strings.TrimSpace(value)
 	if clean == "" {
 		return fmt.Errorf("prompt_rules is required")
 	}
-	if len([]rune(clean)) > 1200 {
-		return fmt.Errorf("prompt_rules must be <= 1200 chars")
+	if len([]rune(clean)) > templatePromptRulesMaxLen {
+		return fmt.Errorf("prompt_rules must be <= %d chars", templatePromptRulesMaxLen)
 	}
 
 	lower := strings.ToLower(clean)
@@ -255,26 +320,174 @@ func (s *Server) handleCreateBattle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	plan, ok := s.prepareBattleCreate(w, r, userID, roomID)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	limitState, limited, err := s.battleLimit(r.Context(), userID, now)
+	if err != nil {
+		writeInternalError(w, "could not check battle limit")
+		return
+	}
+	if limited {
+		s.handleQueueLimitedBattle(w, r, userID, plan, limitState, now)
+		return
+	}
+
+	out, err := s.insertBattlePost(r.Context(), s.db, userID, plan.Room.ID, plan.Topic, plan.Template, plan.ProStyle, plan.ConStyle, "", plan.Language)
+	if err != nil {
+		writeInternalError(w, "could not create battle")
+		return
+	}
+	if plan.ContextPack {
+		if err := common.RequestBattleContextPack(r.Context(), s.db, out.ID, plan.Room.ID, plan.Topic); err != nil {
+			writeInternalError(w, "could not request context pack")
+			return
+		}
+	}
+
+	var interactive *common.InteractiveBattle
+	enqueuedReplies := 0
+	if plan.Mode == battleModeInteractive {
+		battle, err := s.startInteractiveBattle(r.Context(), userID, plan.AIPersonaID, out.ID, plan.Template, requestIDFromRequest(r))
+		if err != nil {
+			writeInternalError(w, "could not start interactive battle")
+			return
+		}
+		interactive = &battle
+		enqueuedReplies = 1
+	} else {
+		enqueuedReplies = s.enqueueBattleReplies(r.Context(), userID, out.ID, plan.Template, requestIDFromRequest(r))
+	}
+
+	_ = s.notifyTemplateUsed(r.Context(), userID, plan.Template, out.ID)
+	if plan.RemixUsed {
+		_ = s.notifyBattleRemixed(r.Context(), userID, plan.SourceBattleID, out.ID)
+	}
+
+	_ = s.logEventFromRequest(r, eventBattleCreated, map[string]any{
+		"battle_id":    out.ID,
+		"room_id":      plan.Room.ID,
+		"template_id":  plan.Template.ID,
+		"mode":         plan.Mode,
+		"language":     plan.Language.Mode,
+		"context_pack": plan.ContextPack,
+	})
+	if plan.RemixUsed {
+		_ = s.logEventFromRequest(r, eventRemixCompleted, map[string]any{
+			"battle_id":        out.ID,
+			"source_battle_id": plan.SourceBattleID,
+			"room_id":          plan.Room.ID,
+			"template_id":      plan.Template.ID,
+		})
+		s.clearGuestIntentCookie(w)
+	}
+
+	response := map[string]any{
+		"battle_id":          out.ID,
+		"post":               out,
+		"room_name":          plan.Room.Name,
+		"template":           plan.Template,
+		"enqueued_replies":   enqueuedReplies,
+		"remix_used":         plan.RemixUsed,
+		"suggested_next_url": fmt.Sprintf("/b/%s", out.ID),
+	}
+	if interactive != nil {
+		response["interactive"] = interactive
+	}
+	if plan.ContextPack {
+		response["context_pack"] = map[string]any{
+			"status": common.BattleContextPackPending,
+			"url":    fmt.Sprintf("/battles/%s/context-pack", out.ID),
+		}
+	}
+	if limitState.Limit > 0 {
+		limitState.Used++
+		response["limit"] = limitState
+	}
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// battleCreatePlan is a validated battle creation request: everything
+// handleCreateBattle needs before it writes the battle.
+type battleCreatePlan struct {
+	Room           Room
+	Mode           string
+	AIPersonaID    string
+	Topic          string
+	Template       BattleTemplate
+	ProStyle       string
+	ConStyle       string
+	Language       common.BattleLanguage
+	SidePersonaIDs []string
+	RemixUsed      bool
+	SourceBattleID string
+	// Queue asks for the battle to be queued for the next daily window
+	// instead of rejected when the daily limit is reached.
+	Queue bool
+	// ContextPack asks the worker to summarize the room's recent posts on
+	// the topic as shared background for both sides.
+	ContextPack bool
+}
+
+// prepareBattleCreate decodes and validates a battle creation request for the
+// room, answering the request itself when it returns false.
+func (s *Server) prepareBattleCreate(w http.ResponseWriter, r *http.Request, userID, roomID string) (battleCreatePlan, bool) {
 	room, err := s.getRoomByID(r.Context(), roomID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			writeNotFound(w, "room not found")
-			return
+			return battleCreatePlan{}, false
 		}
 		writeInternalError(w, "could not load room")
-		return
+		return battleCreatePlan{}, false
+	}
+	if s.rejectReadOnlyRoom(w, r, room, "/battles") {
+		return battleCreatePlan{}, false
 	}
 
 	var req struct {
-		Topic      string `json:"topic"`
-		TemplateID string `json:"template_id"`
-		RemixToken string `json:"remix_token"`
-		ProStyle   string `json:"pro_style"`
-		ConStyle   string `json:"con_style"`
+		Topic       string `json:"topic"`
+		TemplateID  string `json:"template_id"`
+		RemixToken  string `json:"remix_token"`
+		ProStyle    string `json:"pro_style"`
+		ConStyle    string `json:"con_style"`
+		Mode        string `json:"mode"`
+		PersonaID   string `json:"persona_id"`
+		Language    string `json:"language"`
+		Queue       bool   `json:"queue"`
+		ContextPack bool   `json:"context_pack"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeBadRequest(w, err.Error())
-		return
+		return battleCreatePlan{}, false
+	}
+	mode, err := normalizeBattleMode(req.Mode)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return battleCreatePlan{}, false
+	}
+	languageMode, err := common.NormalizeBattleLanguage(req.Language)
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return battleCreatePlan{}, false
+	}
+	aiPersonaID := ""
+	if mode == battleModeInteractive {
+		aiPersonaID, err = validateUUID(req.PersonaID, "persona_id")
+		if err != nil {
+			writeBadRequest(w, err.Error())
+			return battleCreatePlan{}, false
+		}
+		if _, err := s.getPersonaByID(r.Context(), userID, aiPersonaID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				writeNotFound(w, "persona not found")
+				return battleCreatePlan{}, false
+			}
+			writeInternalError(w, "could not load persona")
+			return battleCreatePlan{}, false
+		}
 	}
 
 	topic := strings.TrimSpace(req.Topic)
@@ -283,7 +496,7 @@ func (s *Server) handleCreateBattle(w http.ResponseWriter, r *http.Request) {
 		cleanTemplateID, validateErr := validateUUID(templateID, "template id")
 		if validateErr != nil {
 			writeBadRequest(w, validateErr.Error())
-			return
+			return battleCreatePlan{}, false
 		}
 		templateID = cleanTemplateID
 	}
@@ -296,11 +509,11 @@ func (s *Server) handleCreateBattle(w http.ResponseWriter, r *http.Request) {
 		intent, err := parseRemixIntentToken(s.cfg.JWTSecret, req.RemixToken)
 		if err != nil {
 			writeBadRequest(w, "invalid remix_token")
-			return
+			return battleCreatePlan{}, false
 		}
 		if strings.TrimSpace(intent.RoomID) != roomID {
 			writeBadRequest(w, "remix_token does not match room")
-			return
+			return battleCreatePlan{}, false
 		}
 		if topic == "" {
 			topic = strings.TrimSpace(intent.Topic)
@@ -318,54 +531,109 @@ func (s *Server) handleCreateBattle(w http.ResponseWriter, r *http.Request) {
 		remixUsed = true
 	}
 
-	topic, err = validateTopic(topic, 3, 180)
+	topic, err = validateBattleTopic(s.loadSafetyRules(r.Context()), topic)
 	if err != nil {
 		writeBadRequest(w, err.Error())
-		return
+		return battleCreatePlan{}, false
+	}
+	proStyle, conStyle = normalizeBattleStyles(proStyle, conStyle)
+
+	template, err := s.resolveBattleTemplate(r.Context(), templateID, userID)
+	if err != nil {
+		writeBattleTemplateError(w, templateID, err)
+		return battleCreatePlan{}, false
+	}
+
+	sidePersonaIDs := []string{aiPersonaID}
+	if mode != battleModeInteractive {
+		sidePersonaIDs, err = s.resolvePersonaIDsForReplyGeneration(r.Context(), userID, nil)
+		if err != nil {
+			writeInternalError(w, "could not load personas")
+			return battleCreatePlan{}, false
+		}
 	}
+	language, err := s.resolveNewBattleLanguage(r.Context(), userID, languageMode, sidePersonaIDs)
+	if err != nil {
+		writeInternalError(w, "could not resolve battle language")
+		return battleCreatePlan{}, false
+	}
+
+	return battleCreatePlan{
+		Room:           room,
+		Mode:           mode,
+		AIPersonaID:    aiPersonaID,
+		Topic:          topic,
+		Template:       template,
+		ProStyle:       proStyle,
+		ConStyle:       conStyle,
+		Language:       language,
+		SidePersonaIDs: sidePersonaIDs,
+		RemixUsed:      remixUsed,
+		SourceBattleID: sourceBattleID,
+		Queue:          req.Queue,
+		ContextPack:    req.ContextPack,
+	}, true
+}
+
+func normalizeBattleStyles(proStyle, conStyle string) (string, string) {
+	proStyle = strings.TrimSpace(proStyle)
+	conStyle = strings.TrimSpace(conStyle)
 	if proStyle == "" {
 		proStyle = "Bold and practical"
 	}
 	if conStyle == "" {
 		conStyle = "Skeptical and evidence-first"
 	}
-	proStyle = common.TruncateRunes(proStyle, 80)
-	conStyle = common.TruncateRunes(conStyle, 80)
+	return common.TruncateRunes(proStyle, 80), common.TruncateRunes(conStyle, 80)
+}
 
-	var template BattleTemplate
+func (s *Server) resolveBattleTemplate(ctx context.Context, templateID, userID string) (BattleTemplate, error) {
 	if templateID == "" {
-		template, err = s.loadDefaultTemplate(r.Context())
-		if err != nil {
-			writeInternalError(w, "could not load default template")
-			return
-		}
-	} else {
-		template, err = s.loadTemplateForUser(r.Context(), templateID, userID)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				writeNotFound(w, "template not found")
-				return
-			}
-			writeInternalError(w, "could not load template")
-			return
-		}
+		return s.loadDefaultTemplate(ctx)
 	}
+	return s.loadTemplateForUser(ctx, templateID, userID)
+}
 
-	content := fmt.Sprintf(
-		"Topic: %s\nTemplate: %s\nPro style: %s\nCon style: %s\n\nBattle opening: keep arguments concise and evidence-based.",
-		topic,
-		template.Name,
-		proStyle,
-		conStyle,
-	)
-	content = common.TruncateRunes(content, s.cfg.DraftMaxLen)
-
-	var out Post
-	err = s.db.QueryRow(r.Context(), `
-		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id)
-		VALUES ($1, NULL, $2, 'HUMAN', 'PUBLISHED', $3, NOW(), $4::uuid)
-		RETURNING id::text, room_id::text, '', '', authored_by::text, status::text, content, created_at, updated_at
-	`, room.ID, userID, content, template.ID).Scan(
+func writeBattleTemplateError(w http.ResponseWriter, templateID string, err error) {
+	if templateID == "" {
+		writeInternalError(w, "could not load default template")
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeNotFound(w, "template not found")
+		return
+	}
+	writeInternalError(w, "could not load template")
+}
+
+// battleOpeningContent is the text of a battle's opening post, which reply
+// prompts quote as the post being answered.
+func (s *Server) battleOpeningContent(topic string, template BattleTemplate, proStyle, conStyle string) string {
+	return common.BattleOpeningContent(topic, template.Name, proStyle, conStyle, s.cfg.DraftMaxLen)
+}
+
+// insertBattlePost writes the opening post of a battle. sourcePostID links a
+// battle escalated from an existing thread back to that post, and language is
+// the battle's resolved language setting. Pass a transaction as querier when
+// the battle is created alongside other writes.
+func (s *Server) insertBattlePost(ctx context.Context, querier common.DBQuerier, userID, roomID, topic string, template BattleTemplate, proStyle, conStyle, sourcePostID string, language common.BattleLanguage) (Post, error) {
+	content := s.battleOpeningContent(topic, template, proStyle, conStyle)
+
+	var sourceArg any
+	if sourcePostID != "" {
+		sourceArg = sourcePostID
+	}
+
+	if language.Mode == "" {
+		language.Mode = common.BattleLanguageAuto
+	}
+
+	out := Post{Battle: &language}
+	err := querier.QueryRow(ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id, source_post_id, battle_language, battle_language_code)
+		VALUES ($1, NULL, $2, 'HUMAN', 'PUBLISHED', $3, NOW(), $4::uuid, $5::uuid, $6, NULLIF($7, ''))
+		RETURNING id::text, room_id::text, '', '', authored_by::text, status::text, content, COALESCE(source_post_id::text, ''), created_at, updated_at
+	`, roomID, userID, content, template.ID, sourceArg, language.Mode, language.Language).Scan(
 		&out.ID,
 		&out.RoomID,
 		&out.PersonaID,
@@ -373,60 +641,27 @@ func (s *Server) handleCreateBattle(w http.ResponseWriter, r *http.Request) {
 		&out.AuthoredBy,
 		&out.Status,
 		&out.Content,
+		&out.SourcePostID,
 		&out.CreatedAt,
 		&out.UpdatedAt,
 	)
-	if err != nil {
-		writeInternalError(w, "could not create battle")
-		return
-	}
-
-	enqueuedReplies := s.enqueueBattleReplies(r.Context(), userID, out.ID, template, requestIDFromRequest(r))
-
-	_ = s.notifyTemplateUsed(r.Context(), userID, template, out.ID)
-	if remixUsed {
-		_ = s.notifyBattleRemixed(r.Context(), userID, sourceBattleID, out.ID)
-	}
-
-	_ = s.logEventFromRequest(r, eventBattleCreated, map[string]any{
-		"battle_id":   out.ID,
-		"room_id":     room.ID,
-		"template_id": template.ID,
-	})
-	if remixUsed {
-		_ = s.logEventFromRequest(r, eventRemixCompleted, map[string]any{
-			"battle_id":        out.ID,
-			"source_battle_id": sourceBattleID,
-			"room_id":          room.ID,
-			"template_id":      template.ID,
-		})
-
-		http.SetCookie(w, &http.Cookie{
-			Name:     "pw_remix_intent",
-			Value:    "",
-			Path:     "/",
-			HttpOnly: true,
-			MaxAge:   -1,
-			SameSite: http.SameSiteLaxMode,
-			Secure:   s.cfg.SecureCookies,
-		})
-	}
+	return out, err
+}
 
-	writeJSON(w, http.StatusCreated, map[string]any{
-		"battle_id":          out.ID,
-		"post":               out,
-		"room_name":          room.Name,
-		"template":           template,
-		"enqueued_replies":   enqueuedReplies,
-		"remix_used":         remixUsed,
-		"suggested_next_url": fmt.Sprintf("/b/%s", out.ID),
-	})
+// battleReplyPlan is one persona picked to reply to a new classic battle.
+// SkipReason is set when the persona is picked but no job will be queued.
+type battleReplyPlan struct {
+	Persona    Persona
+	SkipReason string
 }
 
-func (s *Server) enqueueBattleReplies(ctx context.Context, userID, postID string, template BattleTemplate, traceID string) int {
+// planBattleReplies picks the personas that answer a new classic battle: the
+// first two of the user's personas, or three for templates of 8+ turns.
+// Personas out of reply quota are kept with a SkipReason.
+func (s *Server) planBattleReplies(ctx context.Context, userID string, template BattleTemplate) ([]battleReplyPlan, error) {
 	personaIDs, err := s.resolvePersonaIDsForReplyGeneration(ctx, userID, nil)
-	if err != nil || len(personaIDs) == 0 {
-		return 0
+	if err != nil {
+		return nil, err
 	}
 
 	maxReplies := 2
@@ -437,17 +672,37 @@ func (s *Server) enqueueBattleReplies(ctx context.Context, userID, postID string
 		maxReplies = len(personaIDs)
 	}
 
-	enqueued := 0
+	plans := make([]battleReplyPlan, 0, maxReplies)
 	for _, personaID := range personaIDs[:maxReplies] {
 		persona, err := s.getPersonaByID(ctx, userID, personaID)
 		if err != nil {
 			continue
 		}
+		plan := battleReplyPlan{Persona: persona}
 		used, err := s.currentQuotaUsage(ctx, personaID, "reply")
-		if err != nil || used >= persona.DailyReplyQuota {
+		if err != nil {
 			continue
 		}
+		if used >= persona.DailyReplyQuota {
+			plan.SkipReason = "daily reply quota reached"
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func (s *Server) enqueueBattleReplies(ctx context.Context, userID, postID string, template BattleTemplate, traceID string) int {
+	plans, err := s.planBattleReplies(ctx, userID, template)
+	if err != nil || len(plans) == 0 {
+		return 0
+	}
 
+	enqueued := 0
+	for _, plan := range plans {
+		if plan.SkipReason != "" {
+			continue
+		}
+		personaID := plan.Persona.ID
 		payloadMap := map[string]any{
 			"post_id":     postID,
 			"persona_id":  personaID,
@@ -0,0 +1,389 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// threadUpdatesChannel is the Postgres channel the replies trigger
+	// notifies on every insert.
+	threadUpdatesChannel = "thread_updates"
+
+	threadStreamsPerUser = 5
+	// threadStreamTick paces heartbeats and summary refreshes: a stream sends
+	// at most one summary per tick, so a burst of turns costs one LLM call.
+	threadStreamTick = 15 * time.Second
+	// threadStreamMaxAge closes streams now and then so clients reconnect,
+	// which also spreads them across API instances after a deploy.
+	threadStreamMaxAge   = 30 * time.Minute
+	threadStreamWriteMax = 10 * time.Second
+
+	threadListenRetryBase = time.Second
+	threadListenRetryMax  = 30 * time.Second
+)
+
+var (
+	errTooManyThreadStreams = errors.New("too many open thread streams")
+	errThreadStreamsClosed  = errors.New("thread streams are shutting down")
+)
+
+// threadUpdates fans reply notifications out to the streams open on each
+// post. A subscriber's channel holds one pending signal, so updates that
+// arrive while it is busy collapse into a single reload.
+type threadUpdates struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+	perUser     map[string]int
+	closed      bool
+}
+
+func newThreadUpdates() *threadUpdates {
+	return &threadUpdates{
+		subscribers: map[string]map[chan struct{}]struct{}{},
+		perUser:     map[string]int{},
+	}
+}
+
+func (h *threadUpdates) subscribe(postID, userID string) (<-chan struct{}, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, errThreadStreamsClosed
+	}
+	if h.perUser[userID] >= threadStreamsPerUser {
+		return nil, nil, errTooManyThreadStreams
+	}
+	updates := make(chan struct{}, 1)
+	if h.subscribers[postID] == nil {
+		h.subscribers[postID] = map[chan struct{}]struct{}{}
+	}
+	h.subscribers[postID][updates] = struct{}{}
+	h.perUser[userID]++
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.perUser[userID]--; h.perUser[userID] <= 0 {
+				delete(h.perUser, userID)
+			}
+			if _, ok := h.subscribers[postID][updates]; !ok {
+				return
+			}
+			delete(h.subscribers[postID], updates)
+			if len(h.subscribers[postID]) == 0 {
+				delete(h.subscribers, postID)
+			}
+			close(updates)
+		})
+	}
+	return updates, unsubscribe, nil
+}
+
+func (h *threadUpdates) publish(postID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for updates := range h.subscribers[postID] {
+		signalThreadStream(updates)
+	}
+}
+
+// publishAll wakes every stream, for when notifications may have been missed.
+func (h *threadUpdates) publishAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, subscribers := range h.subscribers {
+		for updates := range subscribers {
+			signalThreadStream(updates)
+		}
+	}
+}
+
+// closeAll ends every stream and refuses new ones.
+func (h *threadUpdates) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for postID, subscribers := range h.subscribers {
+		for updates := range subscribers {
+			close(updates)
+		}
+		delete(h.subscribers, postID)
+	}
+}
+
+func signalThreadStream(updates chan struct{}) {
+	select {
+	case updates <- struct{}{}:
+	default:
+	}
+}
+
+type threadUpdateNotification struct {
+	PostID  string `json:"post_id"`
+	ReplyID string `json:"reply_id"`
+}
+
+func parseThreadUpdate(payload string) (threadUpdateNotification, error) {
+	var update threadUpdateNotification
+	if err := json.Unmarshal([]byte(payload), &update); err != nil {
+		return threadUpdateNotification{}, err
+	}
+	if strings.TrimSpace(update.PostID) == "" {
+		return threadUpdateNotification{}, errors.New("thread update without post_id")
+	}
+	return update, nil
+}
+
+// RunThreadUpdates holds one connection listening for reply notifications
+// and relays them to open thread streams until ctx is done, then closes the
+// streams so shutdown does not wait on them.
+func (s *Server) RunThreadUpdates(ctx context.Context) {
+	defer s.threadUpdates.closeAll()
+	if s.db == nil {
+		<-ctx.Done()
+		return
+	}
+
+	delay := threadListenRetryBase
+	for {
+		err := s.listenThreadUpdates(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warn("thread_updates_listen_failed", observability.Fields{"error": err.Error()})
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, threadListenRetryMax)
+	}
+}
+
+func (s *Server) listenThreadUpdates(ctx context.Context) error {
+	pooled, err := s.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The listening connection is taken out of the pool and closed when done,
+	// so no other query inherits the LISTEN.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+threadUpdatesChannel); err != nil {
+		return err
+	}
+	// Replies written while no connection was listening were missed.
+	s.threadUpdates.publishAll()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		update, err := parseThreadUpdate(notification.Payload)
+		if err != nil {
+			s.logger.Warn("thread_update_invalid", observability.Fields{"error": err.Error()})
+			continue
+		}
+		s.threadUpdates.publish(update.PostID)
+	}
+}
+
+// isThreadStreamRequest matches GET /posts/{id}/stream, which outlives the
+// per-request timeout.
+func isThreadStreamRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, "/posts/")
+	if !ok {
+		return false
+	}
+	postID, ok := strings.CutSuffix(rest, "/stream")
+	return ok && postID != "" && !strings.Contains(postID, "/")
+}
+
+// handleStreamThread pushes a thread's new replies as server-sent events,
+// followed by a refreshed ai_summary. Events:
+//   - ready: the stream is live; replies written from here on will follow
+//   - reply: one new reply, shaped like the replies of GET /posts/{id}/thread
+//   - summary: {"ai_summary": "..."}
+func (s *Server) handleStreamThread(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var postOwner, status, visibility, postContent string
+	err = s.db.QueryRow(r.Context(), `
+		SELECT user_id::text, status::text, visibility, content
+		FROM posts
+		WHERE id = $1
+	`, postID).Scan(&postOwner, &status, &visibility, &postContent)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not load post")
+		return
+	}
+	if status != "PUBLISHED" && postOwner != userID {
+		writeForbidden(w, "not allowed")
+		return
+	}
+	if visibility == battleVisibilityUnlisted && postOwner != userID {
+		if _, err := s.authorizeBattleRead(r, postID); err != nil {
+			writeBattleReadError(w, err)
+			return
+		}
+	}
+
+	battle, err := s.getBattleByID(r.Context(), postID)
+	if err != nil {
+		writeInternalError(w, "could not load replies")
+		return
+	}
+	sent := make(map[string]struct{}, len(battle.Turns))
+	for _, reply := range battle.Turns {
+		sent[reply.ID] = struct{}{}
+	}
+
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		writeInternalError(w, "streaming is not supported")
+		return
+	}
+	updates, unsubscribe, err := s.threadUpdates.subscribe(postID, userID)
+	if err != nil {
+		if errors.Is(err, errTooManyThreadStreams) {
+			writeTooManyRequests(w, err.Error())
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(r.Context(), threadStreamMaxAge)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, payload any) bool {
+		if err := controller.SetWriteDeadline(time.Now().Add(threadStreamWriteMax)); err != nil {
+			return false
+		}
+		if err := writeSSE(w, event, payload); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+	if !send("ready", map[string]any{"post_id": postID, "replies": len(battle.Turns)}) {
+		return
+	}
+
+	ticker := time.NewTicker(threadStreamTick)
+	defer ticker.Stop()
+	var (
+		summaryDirty bool
+		lastSummary  time.Time
+	)
+	sendSummary := func() bool {
+		summaryDirty = false
+		lastSummary = time.Now()
+		summaryCtx := ctx
+		if s.cfg.APIRequestTimeout > 0 {
+			var cancelSummary context.CancelFunc
+			summaryCtx, cancelSummary = context.WithTimeout(ctx, s.cfg.APIRequestTimeout)
+			defer cancelSummary()
+		}
+		return send("summary", map[string]any{"ai_summary": s.summarizeThread(summaryCtx, postID, postContent, battle.Turns)})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, open := <-updates:
+			if !open {
+				return
+			}
+			battle, err = s.getBattleByID(ctx, postID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				s.logger.Warn("thread_stream_reload_failed", observability.Fields{"post_id": postID, "error": err.Error()})
+				continue
+			}
+			for _, reply := range battle.Turns {
+				if _, seen := sent[reply.ID]; seen {
+					continue
+				}
+				sent[reply.ID] = struct{}{}
+				summaryDirty = true
+				if !send("reply", reply) {
+					return
+				}
+			}
+			if summaryDirty && time.Since(lastSummary) >= threadStreamTick && !sendSummary() {
+				return
+			}
+		case <-ticker.C:
+			if summaryDirty {
+				if !sendSummary() {
+					return
+				}
+				continue
+			}
+			if !writeSSEComment(w, controller, "ping") {
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w io.Writer, event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func writeSSEComment(w io.Writer, controller *http.ResponseController, comment string) bool {
+	if err := controller.SetWriteDeadline(time.Now().Add(threadStreamWriteMax)); err != nil {
+		return false
+	}
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return false
+	}
+	return controller.Flush() == nil
+}
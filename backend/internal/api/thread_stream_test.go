@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThreadUpdatesCollapseSignalsPerSubscriber(t *testing.T) {
+	hub := newThreadUpdates()
+	updates, unsubscribe, err := hub.subscribe("post-1", "user-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribe()
+	other, unsubscribeOther, err := hub.subscribe("post-2", "user-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer unsubscribeOther()
+
+	hub.publish("post-1")
+	hub.publish("post-1")
+	if len(updates) != 1 {
+		t.Fatalf("expected one pending signal, got %d", len(updates))
+	}
+	if len(other) != 0 {
+		t.Fatalf("expected no signal for another post, got %d", len(other))
+	}
+
+	<-updates
+	hub.publishAll()
+	if len(updates) != 1 || len(other) != 1 {
+		t.Fatalf("expected publishAll to wake every stream, got %d and %d", len(updates), len(other))
+	}
+}
+
+func TestThreadUpdatesLimitStreamsPerUser(t *testing.T) {
+	hub := newThreadUpdates()
+	unsubscribes := []func(){}
+	for i := 0; i < threadStreamsPerUser; i++ {
+		_, unsubscribe, err := hub.subscribe("post-1", "user-1")
+		if err != nil {
+			t.Fatalf("subscribe %d: %v", i, err)
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+	if _, _, err := hub.subscribe("post-2", "user-1"); !errors.Is(err, errTooManyThreadStreams) {
+		t.Fatalf("expected errTooManyThreadStreams, got %v", err)
+	}
+	if _, unsubscribe, err := hub.subscribe("post-1", "user-2"); err != nil {
+		t.Fatalf("expected another user to subscribe, got %v", err)
+	} else {
+		unsubscribe()
+	}
+
+	unsubscribes[0]()
+	unsubscribes[0]()
+	if _, _, err := hub.subscribe("post-2", "user-1"); err != nil {
+		t.Fatalf("expected a freed slot after unsubscribe, got %v", err)
+	}
+}
+
+func TestThreadUpdatesCloseAllEndsStreams(t *testing.T) {
+	hub := newThreadUpdates()
+	updates, unsubscribe, err := hub.subscribe("post-1", "user-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	hub.closeAll()
+	if _, open := <-updates; open {
+		t.Fatalf("expected the stream channel to be closed")
+	}
+	unsubscribe()
+	if _, _, err := hub.subscribe("post-1", "user-1"); !errors.Is(err, errThreadStreamsClosed) {
+		t.Fatalf("expected errThreadStreamsClosed, got %v", err)
+	}
+}
+
+func TestParseThreadUpdate(t *testing.T) {
+	update, err := parseThreadUpdate(`{"post_id":"p1","reply_id":"r1"}`)
+	if err != nil || update.PostID != "p1" || update.ReplyID != "r1" {
+		t.Fatalf("unexpected update %+v, err %v", update, err)
+	}
+	if _, err := parseThreadUpdate(`{"reply_id":"r1"}`); err == nil {
+		t.Fatalf("expected an error without post_id")
+	}
+	if _, err := parseThreadUpdate(`not json`); err == nil {
+		t.Fatalf("expected an error for invalid json")
+	}
+}
+
+func TestIsThreadStreamRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{"GET", "/posts/0b7d/stream", true},
+		{"POST", "/posts/0b7d/stream", false},
+		{"GET", "/posts/0b7d/thread", false},
+		{"GET", "/posts//stream", false},
+		{"GET", "/posts/a/b/stream", false},
+		{"GET", "/rooms/0b7d/stream", false},
+	}
+	for _, tt := range tests {
+		if got := isThreadStreamRequest(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Fatalf("%s %s: expected %v, got %v", tt.method, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestWriteSSE(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSSE(&buf, "summary", map[string]any{"ai_summary": "line one\nline two"}); err != nil {
+		t.Fatalf("writeSSE: %v", err)
+	}
+	want := "event: summary\ndata: {\"ai_summary\":\"line one\\nline two\"}\n\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+}
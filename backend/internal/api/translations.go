@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+type PostTranslation struct {
+	Language        string `json:"language"`
+	SourceLanguage  string `json:"source_language"`
+	Translated      bool   `json:"translated"`
+	OriginalContent string `json:"original_content,omitempty"`
+}
+
+func validateTranslationLanguage(value string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case "":
+		return "", nil
+	case "en", "tr":
+		return clean, nil
+	default:
+		return "", fmt.Errorf("lang must be en or tr")
+	}
+}
+
+func (s *Server) handleGetPost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+	postID, err := validateUUID(chi.URLParam(r, "id"), "post id")
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+	lang, err := validateTranslationLanguage(r.URL.Query().Get("lang"))
+	if err != nil {
+		writeBadRequest(w, err.Error())
+		return
+	}
+
+	var post Post
+	var postOwner string
+	var visibility string
+	var sourceLanguage string
+	err = s.db.QueryRow(r.Context(), `
+		SELECT p.id::text, p.room_id::text, COALESCE(p.persona_id::text, ''), COALESCE(pr.name, ''), p.authored_by::text, p.status::text, p.content, COALESCE(p.mood, ''), p.created_at, p.updated_at, p.user_id::text, p.visibility, COALESCE(pr.preferred_language, 'en')
+		FROM posts p
+		LEFT JOIN personas pr ON pr.id = p.persona_id
+		WHERE p.id = $1
+	`, postID).Scan(&post.ID, &post.RoomID, &post.PersonaID, &post.Persona, &post.AuthoredBy, &post.Status, &post.Content, &post.Mood, &post.CreatedAt, &post.UpdatedAt, &postOwner, &visibility, &sourceLanguage)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			writeNotFound(w, "post not found")
+			return
+		}
+		writeInternalError(w, "could not load post")
+		return
+	}
+	if post.Status != "PUBLISHED" && postOwner != userID {
+		writeForbidden(w, "not allowed")
+		return
+	}
+	if visibility == battleVisibilityUnlisted && postOwner != userID {
+		if _, err := s.authorizeBattleRead(r, post.ID); err != nil {
+			writeBattleReadError(w, err)
+			return
+		}
+	}
+
+	post.Reactions, err = s.postReactionCounts(r.Context(), post.ID)
+	if err != nil {
+		writeInternalError(w, "could not load reactions")
+		return
+	}
+
+	translation := PostTranslation{
+		Language:       sourceLanguage,
+		SourceLanguage: sourceLanguage,
+	}
+	if lang != "" && lang != sourceLanguage {
+		if post.Status != "PUBLISHED" {
+			writeConflict(w, "only published posts can be translated")
+			return
+		}
+		content, err := s.translatedPostContent(r.Context(), post, sourceLanguage, lang)
+		if err != nil {
+			writeBadGateway(w, fmt.Sprintf("llm translation failed: %v", err))
+			return
+		}
+		translation.Language = lang
+		translation.Translated = true
+		translation.OriginalContent = post.Content
+		post.Content = content
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"post":        post,
+		"translation": translation,
+	})
+}
+
+// translatedPostContent serves a cached translation when one exists and
+// otherwise asks the LLM once and stores the result. Published posts are never
+// edited, so cached rows stay valid for the lifetime of the post.
+func (s *Server) translatedPostContent(ctx context.Context, post Post, sourceLanguage, targetLanguage string) (string, error) {
+	var cached string
+	err := s.db.QueryRow(ctx, `
+		SELECT content
+		FROM post_translations
+		WHERE post_id = $1
+		  AND language = $2
+	`, post.ID, targetLanguage).Scan(&cached)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+
+	s.syncPrompts(ctx)
+	promptVersion := s.llm.Prompts().Active(prompts.OpPostTranslation)
+	translated, err := s.llm.TranslatePost(ctx, ai.PostContext{ID: post.ID, Content: post.Content}, sourceLanguage, targetLanguage)
+	if err != nil {
+		return "", err
+	}
+	translated = strings.TrimSpace(translated)
+	if err := s.loadSafetyRules(ctx).ForLanguage(targetLanguage).Validate(translated, s.cfg.DraftMaxLen); err != nil {
+		return "", err
+	}
+
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO post_translations(post_id, language, source_language, content, prompt_version)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (post_id, language) DO UPDATE
+		SET post_id = post_translations.post_id
+		RETURNING content
+	`, post.ID, targetLanguage, sourceLanguage, translated, promptVersion).Scan(&translated)
+	if err != nil {
+		return "", err
+	}
+	return translated, nil
+}
@@ -0,0 +1,15 @@
+package api
+
+import "testing"
+
+func TestValidateTranslationLanguage(t *testing.T) {
+	if got, err := validateTranslationLanguage(" TR "); err != nil || got != "tr" {
+		t.Fatalf("expected tr, got %q (%v)", got, err)
+	}
+	if got, err := validateTranslationLanguage(""); err != nil || got != "" {
+		t.Fatalf("expected empty lang to mean original, got %q (%v)", got, err)
+	}
+	if _, err := validateTranslationLanguage("de"); err == nil {
+		t.Fatalf("expected unsupported language to fail")
+	}
+}
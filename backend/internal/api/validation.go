@@ -8,8 +8,9 @@ import (
 )
 
 var (
-	uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[1-5][0-9a-fA-F]{3}-[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$`)
-	slugRegex = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	uuidRegex        = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[1-5][0-9a-fA-F]{3}-[89abAB][0-9a-fA-F]{3}-[0-9a-fA-F]{12}$`)
+	slugRegex        = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	unicodeSlugRegex = regexp.MustCompile(`^[\p{L}\p{N}]+(?:-[\p{L}\p{N}]+)*$`)
 )
 
 func validateUUID(value, fieldName string) (string, error) {
@@ -35,15 +36,19 @@ func validateTopic(value string, min, max int) (string, error) {
 	return clean, nil
 }
 
-func validateSlug(value string) (string, error) {
-	normalized := normalizePublicSlug(value)
+func validateSlug(value string, allowUnicode bool) (string, error) {
+	normalized := normalizeSlugValue(value, allowUnicode)
 	if normalized == "" {
 		return "", fmt.Errorf("slug is invalid")
 	}
 	if len([]rune(normalized)) > 64 {
 		return "", fmt.Errorf("slug is invalid")
 	}
-	if !slugRegex.MatchString(normalized) {
+	pattern := slugRegex
+	if allowUnicode {
+		pattern = unicodeSlugRegex
+	}
+	if !pattern.MatchString(normalized) {
 		return "", fmt.Errorf("slug is invalid")
 	}
 	return normalized, nil
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	viewSubjectProfile = "profile"
+	viewSubjectBattle  = "battle"
+
+	ownerViewBattlesLimit = 20
+)
+
+type ProfileViewStats struct {
+	PersonaID string `json:"persona_id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	IsPublic  bool   `json:"is_public"`
+	ViewCount int64  `json:"view_count"`
+	Views7d   int    `json:"views_7d"`
+	Views30d  int    `json:"views_30d"`
+}
+
+type BattleViewStats struct {
+	BattleID  string    `json:"battle_id"`
+	Topic     string    `json:"topic"`
+	ViewCount int64     `json:"view_count"`
+	Views7d   int       `json:"views_7d"`
+	Views30d  int       `json:"views_30d"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// viewerKey identifies a viewer for daily de-duplication: signed-in users by
+// id, everyone else by a keyed hash of their IP so raw addresses are never
+// stored.
+func viewerKey(userID, clientIP, secret string) string {
+	if clean := strings.TrimSpace(userID); clean != "" {
+		return "u:" + clean
+	}
+	ip := strings.TrimSpace(clientIP)
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ip))
+	return "ip:" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// recordUniqueView counts a view at most once per viewer per UTC day and
+// bumps the rolled-up counter on the profile or battle row. Owners viewing
+// their own content are not counted. It reports whether the view counted.
+func (s *Server) recordUniqueView(r *http.Request, subjectType, subjectID, ownerUserID string) bool {
+	userID, _ := s.optionalUserIDFromRequest(r)
+	if userID != "" && userID == ownerUserID {
+		return false
+	}
+	key := viewerKey(userID, requestClientIP(r), s.cfg.JWTSecret)
+	if key == "" {
+		return false
+	}
+
+	var query string
+	switch subjectType {
+	case viewSubjectProfile:
+		query = `
+			WITH inserted AS (
+				INSERT INTO content_view_dedup(subject_type, subject_id, viewer_key, view_date)
+				VALUES ($1, $2, $3, (NOW() AT TIME ZONE 'UTC')::date)
+				ON CONFLICT DO NOTHING
+				RETURNING 1
+			)
+			UPDATE persona_public_profiles
+			SET view_count = view_count + 1
+			WHERE persona_id = $2
+			  AND EXISTS (SELECT 1 FROM inserted)
+		`
+	case viewSubjectBattle:
+		query = `
+			WITH inserted AS (
+				INSERT INTO content_view_dedup(subject_type, subject_id, viewer_key, view_date)
+				VALUES ($1, $2, $3, (NOW() AT TIME ZONE 'UTC')::date)
+				ON CONFLICT DO NOTHING
+				RETURNING 1
+			)
+			UPDATE posts
+			SET view_count = view_count + 1
+			WHERE id = $2
+			  AND EXISTS (SELECT 1 FROM inserted)
+		`
+	default:
+		return false
+	}
+
+	ct, err := s.db.Exec(r.Context(), query, subjectType, subjectID, key)
+	if err != nil {
+		s.logger.Warn("view_count_failed", observability.Fields{
+			"subject_type": subjectType,
+			"subject_id":   subjectID,
+			"error":        err.Error(),
+		})
+		return false
+	}
+	return ct.RowsAffected() > 0
+}
+
+func (s *Server) handleGetViewAnalytics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	profiles, err := s.listProfileViewStats(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load profile views")
+		return
+	}
+	battles, err := s.listBattleViewStats(r.Context(), userID, ownerViewBattlesLimit)
+	if err != nil {
+		writeInternalError(w, "could not load battle views")
+		return
+	}
+
+	location, err := s.userLocation(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load view analytics")
+		return
+	}
+	now := time.Now().UTC()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"profiles": profiles,
+		"battles":  battles,
+		"windows": map[string]common.DateWindow{
+			"views_7d":  utcDaysWindow(now, 7, location),
+			"views_30d": utcDaysWindow(now, 30, location),
+		},
+	})
+}
+
+func (s *Server) listProfileViewStats(ctx context.Context, userID string) ([]ProfileViewStats, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			p.id::text,
+			pp.slug,
+			p.name,
+			pp.is_public,
+			pp.view_count,
+			COUNT(v.viewer_key) FILTER (WHERE v.view_date >= CURRENT_DATE - 6)::int,
+			COUNT(v.viewer_key)::int
+		FROM personas p
+		JOIN persona_public_profiles pp ON pp.persona_id = p.id
+		LEFT JOIN content_view_dedup v
+			ON v.subject_type = $2
+			AND v.subject_id = p.id
+			AND v.view_date >= CURRENT_DATE - 29
+		WHERE p.user_id = $1
+		GROUP BY p.id, pp.slug, p.name, pp.is_public, pp.view_count
+		ORDER BY pp.view_count DESC, p.name ASC
+	`, userID, viewSubjectProfile)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]ProfileViewStats, 0)
+	for rows.Next() {
+		var item ProfileViewStats
+		if err := rows.Scan(&item.PersonaID, &item.Slug, &item.Name, &item.IsPublic, &item.ViewCount, &item.Views7d, &item.Views30d); err != nil {
+			return nil, err
+		}
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+func (s *Server) listBattleViewStats(ctx context.Context, userID string, limit int) ([]BattleViewStats, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			p.id::text,
+			p.content,
+			COALESCE(rm.name, ''),
+			p.view_count,
+			COUNT(v.viewer_key) FILTER (WHERE v.view_date >= CURRENT_DATE - 6)::int,
+			COUNT(v.viewer_key)::int,
+			p.created_at
+		FROM posts p
+		JOIN rooms rm ON rm.id = p.room_id
+		LEFT JOIN content_view_dedup v
+			ON v.subject_type = $2
+			AND v.subject_id = p.id
+			AND v.view_date >= CURRENT_DATE - 29
+		WHERE p.user_id = $1
+		  AND p.status = 'PUBLISHED'
+		  AND p.view_count > 0
+		GROUP BY p.id, p.content, rm.name, p.view_count, p.created_at
+		ORDER BY p.view_count DESC, p.created_at DESC
+		LIMIT $3
+	`, userID, viewSubjectBattle, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]BattleViewStats, 0, limit)
+	for rows.Next() {
+		var (
+			item     BattleViewStats
+			content  string
+			roomName string
+		)
+		if err := rows.Scan(&item.BattleID, &content, &roomName, &item.ViewCount, &item.Views7d, &item.Views30d, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Topic = buildBattleCardTopic(content, roomName)
+		stats = append(stats, item)
+	}
+	return stats, rows.Err()
+}
+
+func (s *Server) countUniqueViewsSince(ctx context.Context, since time.Time) (map[string]int, error) {
+	counts := map[string]int{
+		viewSubjectProfile: 0,
+		viewSubjectBattle:  0,
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT subject_type, COUNT(*)::int
+		FROM content_view_dedup
+		WHERE view_date >= ($1::timestamptz AT TIME ZONE 'UTC')::date
+		GROUP BY subject_type
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			subjectType string
+			count       int
+		)
+		if err := rows.Scan(&subjectType, &count); err != nil {
+			return nil, err
+		}
+		counts[subjectType] = count
+	}
+	return counts, rows.Err()
+}
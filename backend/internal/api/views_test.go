@@ -0,0 +1,38 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestViewerKeyPrefersUserID(t *testing.T) {
+	key := viewerKey(" user-1 ", "203.0.113.7", "secret")
+	if key != "u:user-1" {
+		t.Fatalf("expected user key, got %q", key)
+	}
+}
+
+func TestViewerKeyHashesClientIP(t *testing.T) {
+	key := viewerKey("", "203.0.113.7", "secret")
+	if !strings.HasPrefix(key, "ip:") || len(key) != len("ip:")+32 {
+		t.Fatalf("expected hashed ip key, got %q", key)
+	}
+	if strings.Contains(key, "203.0.113.7") {
+		t.Fatalf("expected raw ip not to be stored, got %q", key)
+	}
+	if again := viewerKey("", "203.0.113.7", "secret"); again != key {
+		t.Fatalf("expected stable key, got %q and %q", key, again)
+	}
+	if other := viewerKey("", "203.0.113.8", "secret"); other == key {
+		t.Fatalf("expected different ips to produce different keys")
+	}
+	if rotated := viewerKey("", "203.0.113.7", "other-secret"); rotated == key {
+		t.Fatalf("expected key to depend on the secret")
+	}
+}
+
+func TestViewerKeyEmptyWithoutIdentity(t *testing.T) {
+	if key := viewerKey("", "  ", "secret"); key != "" {
+		t.Fatalf("expected empty key, got %q", key)
+	}
+}
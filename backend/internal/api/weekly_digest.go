@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"time"
 
+	"personaworlds/backend/internal/common"
+
 	"github.com/jackc/pgx/v5"
 )
 
@@ -20,10 +22,22 @@ type WeeklyDigestItem struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// WeeklyDigestAnnouncement is a major announcement published during the
+// digest's week.
+type WeeklyDigestAnnouncement struct {
+	ID       int64     `json:"id"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	LinkURL  string    `json:"link_url,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+}
+
 type WeeklyDigest struct {
-	WeekStart   string             `json:"week_start"`
-	GeneratedAt time.Time          `json:"generated_at"`
-	Items       []WeeklyDigestItem `json:"items"`
+	WeekStart     string                     `json:"week_start"`
+	GeneratedAt   time.Time                  `json:"generated_at"`
+	Items         []WeeklyDigestItem         `json:"items"`
+	Announcements []WeeklyDigestAnnouncement `json:"announcements"`
+	Window        common.DateWindow          `json:"window"`
 }
 
 func (s *Server) handleGetWeeklyDigest(w http.ResponseWriter, r *http.Request) {
@@ -32,6 +46,11 @@ func (s *Server) handleGetWeeklyDigest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	location, err := s.userLocation(r.Context(), userID)
+	if err != nil {
+		writeInternalError(w, "could not load weekly digest")
+		return
+	}
 	now := time.Now().UTC()
 	digest, exists, isCurrentWeek, err := s.getWeeklyDigest(r.Context(), userID, now)
 	if err != nil {
@@ -41,6 +60,9 @@ func (s *Server) handleGetWeeklyDigest(w http.ResponseWriter, r *http.Request) {
 	if !exists {
 		digest = emptyWeeklyDigest(now)
 	}
+	if window, err := common.UTCDateWindow(digest.WeekStart, 7, location); err == nil {
+		digest.Window = window
+	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"digest":          digest,
@@ -72,17 +94,18 @@ func (s *Server) getWeeklyDigest(ctx context.Context, userID string, now time.Ti
 
 func (s *Server) getWeeklyDigestByWeek(ctx context.Context, userID string, weekStart time.Time) (WeeklyDigest, bool, error) {
 	var (
-		rowWeekStart time.Time
-		itemsRaw     []byte
-		updatedAt    time.Time
+		rowWeekStart     time.Time
+		itemsRaw         []byte
+		announcementsRaw []byte
+		updatedAt        time.Time
 	)
 	err := s.db.QueryRow(ctx, `
-		SELECT week_start, items, updated_at
+		SELECT week_start, items, announcements, updated_at
 		FROM weekly_digests
 		WHERE user_id = $1
 		  AND week_start = $2::date
 		LIMIT 1
-	`, userID, weekStart.Format("2006-01-02")).Scan(&rowWeekStart, &itemsRaw, &updatedAt)
+	`, userID, weekStart.Format(common.DateLayout)).Scan(&rowWeekStart, &itemsRaw, &announcementsRaw, &updatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return WeeklyDigest{}, false, nil
@@ -94,27 +117,33 @@ func (s *Server) getWeeklyDigestByWeek(ctx context.Context, userID string, weekS
 	if err != nil {
 		return WeeklyDigest{}, false, err
 	}
+	announcements, err := decodeWeeklyDigestAnnouncements(announcementsRaw)
+	if err != nil {
+		return WeeklyDigest{}, false, err
+	}
 
 	return WeeklyDigest{
-		WeekStart:   rowWeekStart.UTC().Format("2006-01-02"),
-		GeneratedAt: updatedAt,
-		Items:       items,
+		WeekStart:     rowWeekStart.UTC().Format(common.DateLayout),
+		GeneratedAt:   updatedAt,
+		Items:         items,
+		Announcements: announcements,
 	}, true, nil
 }
 
 func (s *Server) getLatestWeeklyDigest(ctx context.Context, userID string) (WeeklyDigest, bool, error) {
 	var (
-		rowWeekStart time.Time
-		itemsRaw     []byte
-		updatedAt    time.Time
+		rowWeekStart     time.Time
+		itemsRaw         []byte
+		announcementsRaw []byte
+		updatedAt        time.Time
 	)
 	err := s.db.QueryRow(ctx, `
-		SELECT week_start, items, updated_at
+		SELECT week_start, items, announcements, updated_at
 		FROM weekly_digests
 		WHERE user_id = $1
 		ORDER BY week_start DESC
 		LIMIT 1
-	`, userID).Scan(&rowWeekStart, &itemsRaw, &updatedAt)
+	`, userID).Scan(&rowWeekStart, &itemsRaw, &announcementsRaw, &updatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return WeeklyDigest{}, false, nil
@@ -126,11 +155,16 @@ func (s *Server) getLatestWeeklyDigest(ctx context.Context, userID string) (Week
 	if err != nil {
 		return WeeklyDigest{}, false, err
 	}
+	announcements, err := decodeWeeklyDigestAnnouncements(announcementsRaw)
+	if err != nil {
+		return WeeklyDigest{}, false, err
+	}
 
 	return WeeklyDigest{
-		WeekStart:   rowWeekStart.UTC().Format("2006-01-02"),
-		GeneratedAt: updatedAt,
-		Items:       items,
+		WeekStart:     rowWeekStart.UTC().Format(common.DateLayout),
+		GeneratedAt:   updatedAt,
+		Items:         items,
+		Announcements: announcements,
 	}, true, nil
 }
 
@@ -148,20 +182,30 @@ func decodeWeeklyDigestItems(raw []byte) ([]WeeklyDigestItem, error) {
 	return items, nil
 }
 
+func decodeWeeklyDigestAnnouncements(raw []byte) ([]WeeklyDigestAnnouncement, error) {
+	announcements := []WeeklyDigestAnnouncement{}
+	if len(raw) == 0 {
+		return announcements, nil
+	}
+	if err := json.Unmarshal(raw, &announcements); err != nil {
+		return nil, err
+	}
+	if announcements == nil {
+		return []WeeklyDigestAnnouncement{}, nil
+	}
+	return announcements, nil
+}
+
 func emptyWeeklyDigest(now time.Time) WeeklyDigest {
 	return WeeklyDigest{
-		WeekStart:   startOfWeekUTC(now).Format("2006-01-02"),
-		GeneratedAt: now,
-		Items:       []WeeklyDigestItem{},
+		WeekStart:     startOfWeekUTC(now).Format(common.DateLayout),
+		GeneratedAt:   now,
+		Items:         []WeeklyDigestItem{},
+		Announcements: []WeeklyDigestAnnouncement{},
 	}
 }
 
+// startOfWeekUTC is the Monday weekly digests are keyed by.
 func startOfWeekUTC(value time.Time) time.Time {
-	utc := value.UTC()
-	midnight := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
-	weekday := int(midnight.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-	return midnight.AddDate(0, 0, -(weekday - 1))
+	return common.StartOfWeek(value, time.UTC)
 }
@@ -0,0 +1,205 @@
+package authz
+
+import "net/http"
+
+// Resource is a kind of object a route acts on.
+type Resource string
+
+const (
+	Persona  Resource = "persona"
+	Post     Resource = "post"
+	Battle   Resource = "battle"
+	Template Resource = "template"
+	Room     Resource = "room"
+)
+
+// Action is what a route does to a resource.
+type Action string
+
+const (
+	// Read covers owner-only views such as digests, evaluations and
+	// coaching; public reads do not go through policies.
+	Read Action = "read"
+	// Manage covers changing a resource or its settings.
+	Manage Action = "manage"
+	// Moderate covers room-level tools shared with moderators.
+	Moderate Action = "moderate"
+
+	// The persona actions a share link can be scoped to. A link's scopes are
+	// these action names.
+	ViewCalibration Action = "view_calibration"
+	SuggestEdits    Action = "suggest_edits"
+	RunPreview      Action = "run_preview"
+)
+
+// ShareScopes lists the actions a persona share link can grant.
+var ShareScopes = []Action{ViewCalibration, SuggestEdits, RunPreview}
+
+// IsShareScope reports whether action can be granted by a share link.
+func IsShareScope(action Action) bool {
+	for _, scope := range ShareScopes {
+		if scope == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is one way a subject can be allowed to act on a resource.
+type Grant string
+
+const (
+	Owner       Grant = "owner"
+	Moderator   Grant = "moderator"
+	Participant Grant = "participant"
+	Admin       Grant = "admin"
+	// ShareLink allows holders of a live share link scoped to the action,
+	// whether or not they are signed in.
+	ShareLink Grant = "share_link"
+)
+
+// Denial is how a refused request is answered. NotFound hides that the
+// resource exists, which is what private resources such as personas use.
+type Denial int
+
+const (
+	DenyForbidden Denial = iota
+	DenyNotFound
+)
+
+// Subject is the user a request is made for. Admin is only known when a
+// policy grants admins access; callers resolve it lazily.
+type Subject struct {
+	UserID string
+	Admin  bool
+}
+
+// Facts are what the caller loaded about one resource for the subject.
+type Facts struct {
+	Exists      bool
+	OwnerUserID string
+	// Moderator is set when the subject moderates the room.
+	Moderator bool
+	// Participant is set when the subject is the opponent of a challenge
+	// battle.
+	Participant bool
+	// SharedScopes are the scopes of the unexpired, unrevoked share link
+	// the request was made with.
+	SharedScopes []Action
+}
+
+// Policy lists the grants that allow an action and how denials look.
+type Policy struct {
+	Grants  []Grant
+	Deny    Denial
+	Message string
+}
+
+// Allows reports whether grant is one of p's grants, so callers only load
+// the facts a policy looks at.
+func (p Policy) Allows(grant Grant) bool {
+	for _, candidate := range p.Grants {
+		if candidate == grant {
+			return true
+		}
+	}
+	return false
+}
+
+type key struct {
+	resource Resource
+	action   Action
+}
+
+// policies is the single place access rules live. New endpoints pick an
+// existing resource and action or add a row here.
+var policies = map[key]Policy{
+	{Persona, Read}:   {Grants: []Grant{Owner}, Deny: DenyNotFound},
+	{Persona, Manage}: {Grants: []Grant{Owner}, Deny: DenyNotFound},
+	// Share links cannot be probed either: a missing scope looks like a
+	// missing persona.
+	{Persona, ViewCalibration}: {Grants: []Grant{Owner, ShareLink}, Deny: DenyNotFound},
+	{Persona, SuggestEdits}:    {Grants: []Grant{Owner, ShareLink}, Deny: DenyNotFound},
+	{Persona, RunPreview}:      {Grants: []Grant{Owner, ShareLink}, Deny: DenyNotFound},
+	{Post, Manage}:             {Grants: []Grant{Owner}, Deny: DenyForbidden, Message: "not allowed"},
+	{Battle, Read}:             {Grants: []Grant{Owner, Participant}, Deny: DenyNotFound},
+	{Template, Manage}: {
+		Grants:  []Grant{Owner},
+		Deny:    DenyForbidden,
+		Message: "only the template owner can change its policy",
+	},
+	{Room, Moderate}: {
+		Grants:  []Grant{Moderator, Admin},
+		Deny:    DenyForbidden,
+		Message: "room moderator access required",
+	},
+}
+
+// Lookup returns the policy for action on resource.
+func Lookup(resource Resource, action Action) (Policy, bool) {
+	policy, ok := policies[key{resource, action}]
+	return policy, ok
+}
+
+// Decision is the outcome of a check. Status is 0 when allowed and
+// otherwise the HTTP status to answer with.
+type Decision struct {
+	Allowed bool
+	Status  int
+	Message string
+}
+
+// Decide applies the policy for action on resource. Pairs without a policy
+// are refused, so a route cannot be opened by a missing row.
+func Decide(subject Subject, resource Resource, action Action, facts Facts) Decision {
+	notFound := Decision{Status: http.StatusNotFound, Message: string(resource) + " not found"}
+	policy, ok := Lookup(resource, action)
+	if !ok {
+		return Decision{Status: http.StatusForbidden, Message: "not allowed"}
+	}
+	if !facts.Exists {
+		return notFound
+	}
+	if granted(policy, subject, action, facts) {
+		return Decision{Allowed: true}
+	}
+	if policy.Deny == DenyNotFound {
+		return notFound
+	}
+	message := policy.Message
+	if message == "" {
+		message = "not allowed"
+	}
+	return Decision{Status: http.StatusForbidden, Message: message}
+}
+
+func granted(policy Policy, subject Subject, action Action, facts Facts) bool {
+	signedIn := subject.UserID != ""
+	for _, grant := range policy.Grants {
+		switch grant {
+		case Owner:
+			if signedIn && facts.OwnerUserID == subject.UserID {
+				return true
+			}
+		case Moderator:
+			if signedIn && facts.Moderator {
+				return true
+			}
+		case Participant:
+			if signedIn && facts.Participant {
+				return true
+			}
+		case Admin:
+			if signedIn && subject.Admin {
+				return true
+			}
+		case ShareLink:
+			for _, scope := range facts.SharedScopes {
+				if scope == action {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
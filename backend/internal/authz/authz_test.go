@@ -0,0 +1,88 @@
+package authz
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	owner := Subject{UserID: "user-1"}
+	other := Subject{UserID: "user-2"}
+	admin := Subject{UserID: "user-2", Admin: true}
+	owned := Facts{Exists: true, OwnerUserID: "user-1"}
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		resource Resource
+		action   Action
+		facts    Facts
+		status   int
+		message  string
+	}{
+		{name: "persona owner reads", subject: owner, resource: Persona, action: Read, facts: owned},
+		{name: "persona owner manages", subject: owner, resource: Persona, action: Manage, facts: owned},
+		{name: "persona hidden from others", subject: other, resource: Persona, action: Read, facts: owned, status: http.StatusNotFound, message: "persona not found"},
+		{name: "persona not hidden by admin rights", subject: admin, resource: Persona, action: Manage, facts: owned, status: http.StatusNotFound, message: "persona not found"},
+		{name: "missing persona", subject: owner, resource: Persona, action: Read, facts: Facts{}, status: http.StatusNotFound, message: "persona not found"},
+		{name: "post owner manages", subject: owner, resource: Post, action: Manage, facts: owned},
+		{name: "post forbidden to others", subject: other, resource: Post, action: Manage, facts: owned, status: http.StatusForbidden, message: "not allowed"},
+		{name: "missing post", subject: other, resource: Post, action: Manage, facts: Facts{}, status: http.StatusNotFound, message: "post not found"},
+		{name: "battle owner reads", subject: owner, resource: Battle, action: Read, facts: owned},
+		{name: "battle participant reads", subject: other, resource: Battle, action: Read, facts: Facts{Exists: true, OwnerUserID: "user-1", Participant: true}},
+		{name: "battle hidden from others", subject: other, resource: Battle, action: Read, facts: owned, status: http.StatusNotFound, message: "battle not found"},
+		{name: "template owner manages", subject: owner, resource: Template, action: Manage, facts: owned},
+		{name: "template forbidden to others", subject: other, resource: Template, action: Manage, facts: owned, status: http.StatusForbidden, message: "only the template owner can change its policy"},
+		{name: "system template has no owner", subject: owner, resource: Template, action: Manage, facts: Facts{Exists: true}, status: http.StatusForbidden, message: "only the template owner can change its policy"},
+		{name: "room moderator moderates", subject: other, resource: Room, action: Moderate, facts: Facts{Exists: true, Moderator: true}},
+		{name: "admin moderates any room", subject: admin, resource: Room, action: Moderate, facts: Facts{Exists: true}},
+		{name: "room forbidden to members", subject: other, resource: Room, action: Moderate, facts: Facts{Exists: true}, status: http.StatusForbidden, message: "room moderator access required"},
+		{name: "missing room", subject: admin, resource: Room, action: Moderate, facts: Facts{}, status: http.StatusNotFound, message: "room not found"},
+		{name: "anonymous subject", subject: Subject{}, resource: Template, action: Manage, facts: Facts{Exists: true}, status: http.StatusForbidden, message: "only the template owner can change its policy"},
+		{name: "share link grants its scope", subject: Subject{}, resource: Persona, action: RunPreview, facts: Facts{Exists: true, OwnerUserID: "user-1", SharedScopes: []Action{RunPreview}}},
+		{name: "share link does not grant other scopes", subject: Subject{}, resource: Persona, action: ViewCalibration, facts: Facts{Exists: true, OwnerUserID: "user-1", SharedScopes: []Action{RunPreview}}, status: http.StatusNotFound, message: "persona not found"},
+		{name: "share link does not open owner views", subject: Subject{}, resource: Persona, action: Read, facts: Facts{Exists: true, OwnerUserID: "user-1", SharedScopes: ShareScopes}, status: http.StatusNotFound, message: "persona not found"},
+		{name: "persona owner runs previews", subject: owner, resource: Persona, action: RunPreview, facts: owned},
+		{name: "pair without policy", subject: owner, resource: Room, action: Manage, facts: Facts{Exists: true, OwnerUserID: "user-1"}, status: http.StatusForbidden, message: "not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Decide(tt.subject, tt.resource, tt.action, tt.facts)
+			if tt.status == 0 {
+				if !decision.Allowed {
+					t.Fatalf("expected access, got %+v", decision)
+				}
+				return
+			}
+			if decision.Allowed || decision.Status != tt.status || decision.Message != tt.message {
+				t.Fatalf("expected %d %q, got %+v", tt.status, tt.message, decision)
+			}
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy, ok := Lookup(Room, Moderate)
+	if !ok {
+		t.Fatal("expected a room moderation policy")
+	}
+	if !policy.Allows(Admin) || !policy.Allows(Moderator) || policy.Allows(Owner) {
+		t.Fatalf("unexpected room moderation grants %v", policy.Grants)
+	}
+	if _, ok := Lookup(Persona, Moderate); ok {
+		t.Fatal("expected no persona moderation policy")
+	}
+}
+
+func TestShareScopesHavePersonaPolicies(t *testing.T) {
+	for _, scope := range ShareScopes {
+		policy, ok := Lookup(Persona, scope)
+		if !ok || !policy.Allows(ShareLink) {
+			t.Fatalf("expected share link policy for %s", scope)
+		}
+	}
+	if IsShareScope(Manage) || !IsShareScope(SuggestEdits) {
+		t.Fatal("unexpected share scope check")
+	}
+}
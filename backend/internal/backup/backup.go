@@ -0,0 +1,303 @@
+// Package backup exports user content to a versioned archive and restores it
+// into another database with the same schema.
+//
+// An archive is a gzipped tar holding manifest.json followed by one
+// tables/<name>.jsonl file per table, in dependency order. Each line is the
+// row as produced by Postgres' to_jsonb, so the archive carries every column
+// without this package listing them.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	FormatName    = "personaworlds-backup"
+	FormatVersion = 1
+
+	ScopeFull = "full"
+	ScopeUser = "user"
+
+	manifestName = "manifest.json"
+	tablesDir    = "tables/"
+)
+
+type ConflictPolicy string
+
+const (
+	// PolicySkip keeps the existing row and points references at it.
+	PolicySkip ConflictPolicy = "skip"
+	// PolicyOverwrite replaces the existing row with the archived one.
+	PolicyOverwrite ConflictPolicy = "overwrite"
+	// PolicyRename restores the archived row under a new id (and a suffixed
+	// slug where one is unique) next to the existing one.
+	PolicyRename ConflictPolicy = "rename"
+)
+
+func ParseConflictPolicy(raw string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(strings.ToLower(strings.TrimSpace(raw))); policy {
+	case PolicySkip, PolicyOverwrite, PolicyRename:
+		return policy, nil
+	case "":
+		return PolicySkip, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (want skip, overwrite or rename)", raw)
+	}
+}
+
+type TableCount struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+}
+
+type Manifest struct {
+	Format        string       `json:"format"`
+	FormatVersion int          `json:"format_version"`
+	SchemaVersion string       `json:"schema_version"`
+	Scope         string       `json:"scope"`
+	UserEmail     string       `json:"user_email,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	Tables        []TableCount `json:"tables"`
+}
+
+func (m Manifest) validate() error {
+	if m.Format != FormatName {
+		return fmt.Errorf("not a %s archive", FormatName)
+	}
+	if m.FormatVersion < 1 || m.FormatVersion > FormatVersion {
+		return fmt.Errorf("unsupported archive format version %d (this build reads up to %d)", m.FormatVersion, FormatVersion)
+	}
+	if m.Scope != ScopeFull && m.Scope != ScopeUser {
+		return fmt.Errorf("unknown archive scope %q", m.Scope)
+	}
+	return nil
+}
+
+// tableSpec describes how one table is exported and stitched back together on
+// restore. Tables are listed parents first so references can be remapped.
+type tableSpec struct {
+	name string
+	// key is the primary key. Only single "id" keys can be renamed.
+	key []string
+	// refs maps foreign key columns to the table they point at.
+	refs map[string]string
+	// unique is a text column with a unique index that rename suffixes.
+	unique string
+	// match finds an existing row that stands for the archived one ($1 is the
+	// row as jsonb). Matched rows are reused and never written.
+	match string
+	// userFilter limits a per-user export; $1 is the user id.
+	userFilter string
+}
+
+var tableSpecs = []tableSpec{
+	{
+		name:       "users",
+		key:        []string{"id"},
+		match:      `SELECT id::text FROM users WHERE LOWER(email) = LOWER($1::jsonb->>'email')`,
+		userFilter: `id = $1`,
+	},
+	{
+		name:       "rooms",
+		key:        []string{"id"},
+		refs:       map[string]string{"merged_into_room_id": "rooms"},
+		match:      `SELECT id::text FROM rooms WHERE slug = $1::jsonb->>'slug'`,
+		userFilter: `id IN (SELECT room_id FROM posts WHERE user_id = $1)`,
+	},
+	{
+		name: "templates",
+		key:  []string{"id"},
+		refs: map[string]string{"owner_user_id": "users"},
+		// System templates are seeded by migrations, so they are matched by
+		// name instead of being copied.
+		match: `SELECT id::text FROM templates
+			WHERE owner_user_id IS NULL
+			  AND $1::jsonb->>'owner_user_id' IS NULL
+			  AND LOWER(name) = LOWER($1::jsonb->>'name')
+			ORDER BY created_at ASC
+			LIMIT 1`,
+		userFilter: `owner_user_id = $1
+			OR (owner_user_id IS NULL AND id IN (SELECT template_id FROM posts WHERE user_id = $1))`,
+	},
+	{
+		// Sealed persona calibration lists name their owner and data key
+		// version, so the wrapped keys travel with the personas. They open
+		// again wherever the same CALIBRATION_KEYS are configured.
+		name:       "user_data_keys",
+		key:        []string{"user_id", "version"},
+		refs:       map[string]string{"user_id": "users"},
+		userFilter: `user_id = $1`,
+	},
+	{
+		name:       "personas",
+		key:        []string{"id"},
+		refs:       map[string]string{"user_id": "users"},
+		userFilter: `user_id = $1`,
+	},
+	{
+		name:       "persona_public_profiles",
+		key:        []string{"persona_id"},
+		refs:       map[string]string{"persona_id": "personas"},
+		unique:     "slug",
+		userFilter: `persona_id IN (SELECT id FROM personas WHERE user_id = $1)`,
+	},
+	{
+		name: "posts",
+		key:  []string{"id"},
+		refs: map[string]string{
+			"room_id":        "rooms",
+			"persona_id":     "personas",
+			"user_id":        "users",
+			"template_id":    "templates",
+			"source_post_id": "posts",
+		},
+		userFilter: `user_id = $1`,
+	},
+	{
+		name: "replies",
+		key:  []string{"id"},
+		refs: map[string]string{
+			"post_id":    "posts",
+			"persona_id": "personas",
+			"user_id":    "users",
+		},
+		userFilter: `user_id = $1 OR persona_id IN (SELECT id FROM personas WHERE user_id = $1)`,
+	},
+	{
+		name:       "battle_archives",
+		key:        []string{"post_id"},
+		refs:       map[string]string{"post_id": "posts"},
+		userFilter: `post_id IN (SELECT id FROM posts WHERE user_id = $1)`,
+	},
+	{
+		name: "interactive_battles",
+		key:  []string{"post_id"},
+		refs: map[string]string{
+			"post_id":       "posts",
+			"user_id":       "users",
+			"ai_persona_id": "personas",
+		},
+		userFilter: `user_id = $1`,
+	},
+	{
+		name: "battle_coaching",
+		key:  []string{"post_id", "persona_id"},
+		refs: map[string]string{
+			"post_id":    "posts",
+			"persona_id": "personas",
+		},
+		userFilter: `persona_id IN (SELECT id FROM personas WHERE user_id = $1)`,
+	},
+}
+
+func specIndex(name string) int {
+	for i, spec := range tableSpecs {
+		if spec.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeArchive writes the manifest and then each table's JSONL rows in
+// manifest order.
+func writeArchive(w io.Writer, manifest Manifest, tables map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, manifestName, manifestJSON, manifest.CreatedAt); err != nil {
+		return err
+	}
+	for _, table := range manifest.Tables {
+		if err := writeTarFile(tw, tablesDir+table.Name+".jsonl", tables[table.Name], manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// archiveReader streams an archive: the manifest first, then tables in order.
+type archiveReader struct {
+	gz *gzip.Reader
+	tr *tar.Reader
+}
+
+func openArchive(r io.Reader) (*archiveReader, Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, Manifest{}, fmt.Errorf("open archive: %w", err)
+	}
+	ar := &archiveReader{gz: gz, tr: tar.NewReader(gz)}
+
+	header, err := ar.tr.Next()
+	if err != nil {
+		gz.Close()
+		return nil, Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+	if header.Name != manifestName {
+		gz.Close()
+		return nil, Manifest{}, fmt.Errorf("archive must start with %s, found %s", manifestName, header.Name)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(ar.tr).Decode(&manifest); err != nil {
+		gz.Close()
+		return nil, Manifest{}, fmt.Errorf("decode manifest: %w", err)
+	}
+	if err := manifest.validate(); err != nil {
+		gz.Close()
+		return nil, Manifest{}, err
+	}
+	return ar, manifest, nil
+}
+
+// next returns the next table name and a reader over its JSONL rows, or
+// io.EOF after the last table.
+func (ar *archiveReader) next() (string, io.Reader, error) {
+	for {
+		header, err := ar.tr.Next()
+		if err != nil {
+			return "", nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(header.Name, tablesDir) {
+			continue
+		}
+		name := strings.TrimSuffix(path.Base(header.Name), ".jsonl")
+		if name == "" {
+			return "", nil, errors.New("archive has a table file without a name")
+		}
+		return name, ar.tr, nil
+	}
+}
+
+func (ar *archiveReader) Close() error {
+	return ar.gz.Close()
+}
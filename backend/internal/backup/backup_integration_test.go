@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+	"personaworlds/backend/internal/envelope"
+)
+
+// TestRestoreOpensSealedCalibrationIntegration exports a user whose persona
+// has a sealed calibration list, deletes the user and restores the archive.
+// The list must open again with the same CALIBRATION_KEYS.
+func TestRestoreOpensSealedCalibrationIntegration(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+	if err := db.RunMigrations(ctx, pool, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	cfg := config.Config{CalibrationKeys: "k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{9}, envelope.DataKeySize))}
+	cipher := common.NewCalibrationCipher(envelope.MustKeyProvider(cfg))
+
+	email := fmt.Sprintf("backup-sealed-%d@example.com", time.Now().UnixNano())
+	var userID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO users(email, password_hash)
+		VALUES ($1, 'x')
+		RETURNING id::text
+	`, email).Scan(&userID); err != nil {
+		t.Fatalf("insert user failed: %v", err)
+	}
+	defer pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID)
+
+	doNotSay := []string{"my home address", "my employer"}
+	plain, err := common.DoNotSayField.Encode(doNotSay)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	sealed, err := cipher.Seal(ctx, pool, userID, common.DoNotSayField, plain)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	writingSamples, _ := json.Marshal([]string{"Ship small", "Measure outcomes", "Ask one sharp question"})
+
+	var personaID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO personas(user_id, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality)
+		VALUES ($1, 'Sealed Persona', 'Keeps secrets.', 'direct', $2::jsonb, $3::jsonb, '[]'::jsonb, 'en', 1)
+		RETURNING id::text
+	`, userID, writingSamples, sealed).Scan(&personaID); err != nil {
+		t.Fatalf("insert persona failed: %v", err)
+	}
+
+	var archive bytes.Buffer
+	if _, err := Export(ctx, pool, ExportOptions{UserEmail: email}, &archive); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	if _, err := Restore(ctx, pool, &archive, RestoreOptions{Policy: PolicySkip}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	var raw []byte
+	if err := pool.QueryRow(ctx, `SELECT do_not_say FROM personas WHERE id = $1`, personaID).Scan(&raw); err != nil {
+		t.Fatalf("load restored persona: %v", err)
+	}
+	// A fresh cipher has no cached keys, so the data key is read back from
+	// the restored user_data_keys.
+	opened, err := common.NewCalibrationCipher(envelope.MustKeyProvider(cfg)).Decode(ctx, pool, common.DoNotSayField, raw)
+	if err != nil {
+		t.Fatalf("open restored list: %v", err)
+	}
+	if !slices.Equal(opened, doNotSay) {
+		t.Fatalf("restored list = %v, want %v", opened, doNotSay)
+	}
+}
@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	manifest := Manifest{
+		Format:        FormatName,
+		FormatVersion: FormatVersion,
+		SchemaVersion: "030_room_topic_check.sql",
+		Scope:         ScopeUser,
+		UserEmail:     "ada@example.com",
+		CreatedAt:     time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Tables:        []TableCount{{Name: "users", Rows: 1}, {Name: "personas", Rows: 2}},
+	}
+	tables := map[string][]byte{
+		"users":    []byte(`{"id":"u1","email":"ada@example.com"}` + "\n"),
+		"personas": []byte(`{"id":"p1"}` + "\n" + `{"id":"p2"}` + "\n"),
+	}
+
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, manifest, tables); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+
+	ar, got, err := openArchive(&buf)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer ar.Close()
+	if got.SchemaVersion != manifest.SchemaVersion || got.UserEmail != manifest.UserEmail || len(got.Tables) != 2 {
+		t.Fatalf("unexpected manifest %+v", got)
+	}
+
+	for _, want := range []string{"users", "personas"} {
+		name, rows, err := ar.next()
+		if err != nil {
+			t.Fatalf("next table: %v", err)
+		}
+		if name != want {
+			t.Fatalf("expected table %s, got %s", want, name)
+		}
+		data, err := io.ReadAll(rows)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if !bytes.Equal(data, tables[want]) {
+			t.Fatalf("table %s rows changed: %q", name, data)
+		}
+	}
+	if _, _, err := ar.next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF after last table, got %v", err)
+	}
+}
+
+func TestOpenArchiveRejectsNewerFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeArchive(&buf, Manifest{
+		Format:        FormatName,
+		FormatVersion: FormatVersion + 1,
+		Scope:         ScopeFull,
+		CreatedAt:     time.Now().UTC(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if _, _, err := openArchive(&buf); err == nil || !strings.Contains(err.Error(), "format version") {
+		t.Fatalf("expected format version error, got %v", err)
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	cases := map[string]ConflictPolicy{
+		"":            PolicySkip,
+		"skip":        PolicySkip,
+		" Overwrite ": PolicyOverwrite,
+		"RENAME":      PolicyRename,
+	}
+	for raw, want := range cases {
+		got, err := ParseConflictPolicy(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseConflictPolicy(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseConflictPolicy("merge"); err == nil {
+		t.Fatal("expected unknown policy to be rejected")
+	}
+}
+
+func TestTableSpecsListParentsFirst(t *testing.T) {
+	for i, spec := range tableSpecs {
+		if spec.userFilter == "" {
+			t.Fatalf("%s has no per-user filter", spec.name)
+		}
+		for column, parent := range spec.refs {
+			index := specIndex(parent)
+			if index < 0 || index > i {
+				t.Fatalf("%s.%s points at %s, which is not restored before it", spec.name, column, parent)
+			}
+		}
+	}
+}
+
+func TestRenamedValue(t *testing.T) {
+	if got := renamedValue("ada", 1); got != "ada-restored" {
+		t.Fatalf("unexpected first rename %q", got)
+	}
+	if got := renamedValue("ada", 3); got != "ada-restored-3" {
+		t.Fatalf("unexpected third rename %q", got)
+	}
+}
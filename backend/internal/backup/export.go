@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExportOptions struct {
+	// UserEmail limits the export to one user's content. Empty exports the
+	// whole instance.
+	UserEmail string
+}
+
+// Export writes an archive of the selected content to w. All tables are read
+// from one repeatable-read snapshot so references stay consistent.
+func Export(ctx context.Context, pool *pgxpool.Pool, opts ExportOptions, w io.Writer) (Manifest, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	schemaVersion, err := currentSchemaVersion(ctx, tx)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		Format:        FormatName,
+		FormatVersion: FormatVersion,
+		SchemaVersion: schemaVersion,
+		Scope:         ScopeFull,
+		CreatedAt:     time.Now().UTC(),
+		Tables:        make([]TableCount, 0, len(tableSpecs)),
+	}
+
+	userID := ""
+	if email := strings.TrimSpace(opts.UserEmail); email != "" {
+		err := tx.QueryRow(ctx, `SELECT id::text, email FROM users WHERE LOWER(email) = LOWER($1)`, email).Scan(&userID, &manifest.UserEmail)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return Manifest{}, fmt.Errorf("user %s not found", email)
+			}
+			return Manifest{}, err
+		}
+		manifest.Scope = ScopeUser
+	}
+
+	tables := make(map[string][]byte, len(tableSpecs))
+	for _, spec := range tableSpecs {
+		data, count, err := exportTable(ctx, tx, spec, userID)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("export %s: %w", spec.name, err)
+		}
+		tables[spec.name] = data
+		manifest.Tables = append(manifest.Tables, TableCount{Name: spec.name, Rows: count})
+	}
+
+	if err := writeArchive(w, manifest, tables); err != nil {
+		return Manifest{}, err
+	}
+	return manifest, nil
+}
+
+func exportTable(ctx context.Context, tx pgx.Tx, spec tableSpec, userID string) ([]byte, int, error) {
+	table := pgx.Identifier{spec.name}.Sanitize()
+	query := fmt.Sprintf(`SELECT to_jsonb(t)::text FROM %s t`, table)
+	args := []any{}
+	if userID != "" {
+		query += ` WHERE ` + spec.userFilter
+		args = append(args, userID)
+	}
+	query += ` ORDER BY t.created_at ASC, ` + qualifiedKey("t", spec.key)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	count := 0
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, 0, err
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), count, nil
+}
+
+func qualifiedKey(alias string, key []string) string {
+	parts := make([]string, 0, len(key))
+	for _, column := range key {
+		parts = append(parts, alias+"."+pgx.Identifier{column}.Sanitize())
+	}
+	return strings.Join(parts, ", ")
+}
+
+func currentSchemaVersion(ctx context.Context, q common.DBQuerier) (string, error) {
+	var version string
+	if err := q.QueryRow(ctx, `SELECT COALESCE(MAX(version), '') FROM schema_migrations`).Scan(&version); err != nil {
+		return "", fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,441 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxRowBytes = 16 << 20
+
+type RestoreOptions struct {
+	Policy ConflictPolicy
+	// DryRun runs the whole restore and rolls it back, so the report shows
+	// what would change.
+	DryRun bool
+}
+
+type TableReport struct {
+	Name        string `json:"name"`
+	Inserted    int    `json:"inserted"`
+	Overwritten int    `json:"overwritten"`
+	Renamed     int    `json:"renamed"`
+	Matched     int    `json:"matched"`
+	Skipped     int    `json:"skipped"`
+}
+
+type Report struct {
+	Manifest Manifest      `json:"manifest"`
+	Policy   string        `json:"policy"`
+	DryRun   bool          `json:"dry_run"`
+	Tables   []TableReport `json:"tables"`
+}
+
+type rowOutcome int
+
+const (
+	rowInserted rowOutcome = iota
+	rowOverwritten
+	rowRenamed
+	rowMatched
+	rowSkipped
+)
+
+// Restore loads an archive in a single transaction. The target must be on the
+// same schema version as the source; run cmd/migrate first if it is not.
+func Restore(ctx context.Context, pool *pgxpool.Pool, r io.Reader, opts RestoreOptions) (Report, error) {
+	policy := opts.Policy
+	if policy == "" {
+		policy = PolicySkip
+	}
+
+	ar, manifest, err := openArchive(r)
+	if err != nil {
+		return Report{}, err
+	}
+	defer ar.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	schemaVersion, err := currentSchemaVersion(ctx, tx)
+	if err != nil {
+		return Report{}, err
+	}
+	if schemaVersion != manifest.SchemaVersion {
+		return Report{}, fmt.Errorf("archive schema %q does not match database schema %q; migrate both to the same version first", manifest.SchemaVersion, schemaVersion)
+	}
+
+	report := Report{
+		Manifest: manifest,
+		Policy:   string(policy),
+		DryRun:   opts.DryRun,
+		Tables:   []TableReport{},
+	}
+	rs := &restorer{
+		tx:      tx,
+		policy:  policy,
+		ids:     map[string]map[string]string{},
+		present: map[string]map[string]bool{},
+	}
+
+	last := -1
+	for {
+		name, rows, err := ar.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Report{}, fmt.Errorf("read archive: %w", err)
+		}
+		index := specIndex(name)
+		if index < 0 {
+			return Report{}, fmt.Errorf("archive has unknown table %q", name)
+		}
+		if index <= last {
+			return Report{}, fmt.Errorf("archive table %q is out of order", name)
+		}
+		last = index
+
+		tableReport, err := rs.restoreTable(ctx, tableSpecs[index], rows)
+		if err != nil {
+			return Report{}, fmt.Errorf("restore %s: %w", name, err)
+		}
+		report.Tables = append(report.Tables, tableReport)
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+type restorer struct {
+	tx     pgx.Tx
+	policy ConflictPolicy
+	// ids maps archived ids to the ids they were restored (or matched) as.
+	ids map[string]map[string]string
+	// present caches whether an id not in the archive exists in the target.
+	present map[string]map[string]bool
+}
+
+func (rs *restorer) restoreTable(ctx context.Context, spec tableSpec, rows io.Reader) (TableReport, error) {
+	report := TableReport{Name: spec.name}
+	nullable, err := rs.loadColumns(ctx, spec.name)
+	if err != nil {
+		return report, err
+	}
+	if rs.ids[spec.name] == nil {
+		rs.ids[spec.name] = map[string]string{}
+	}
+
+	scanner := bufio.NewScanner(rows)
+	scanner.Buffer(make([]byte, 64*1024), maxRowBytes)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		var row map[string]any
+		if err := decoder.Decode(&row); err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		outcome, err := rs.restoreRow(ctx, spec, nullable, row)
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch outcome {
+		case rowInserted:
+			report.Inserted++
+		case rowOverwritten:
+			report.Overwritten++
+		case rowRenamed:
+			report.Renamed++
+		case rowMatched:
+			report.Matched++
+		default:
+			report.Skipped++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+func (rs *restorer) restoreRow(ctx context.Context, spec tableSpec, nullable map[string]bool, row map[string]any) (rowOutcome, error) {
+	oldID, _ := row["id"].(string)
+
+	// Point references at restored or existing parents. A missing parent
+	// clears optional references and drops rows that cannot live without it.
+	for _, column := range sortedKeys(spec.refs) {
+		value, ok := row[column].(string)
+		if !ok {
+			continue
+		}
+		resolved, found, err := rs.resolve(ctx, spec.refs[column], value)
+		if err != nil {
+			return rowSkipped, err
+		}
+		if found {
+			row[column] = resolved
+			continue
+		}
+		if !nullable[column] {
+			return rowSkipped, nil
+		}
+		row[column] = nil
+	}
+
+	rowJSON, err := json.Marshal(row)
+	if err != nil {
+		return rowSkipped, err
+	}
+
+	anchored := spec.match != ""
+	if anchored {
+		var existing string
+		err := rs.tx.QueryRow(ctx, spec.match, rowJSON).Scan(&existing)
+		if err == nil {
+			rs.remember(spec, oldID, existing)
+			return rowMatched, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return rowSkipped, err
+		}
+	}
+
+	outcome := rowInserted
+	conflict, err := rs.keyTaken(ctx, spec, rowJSON)
+	if err != nil {
+		return rowSkipped, err
+	}
+	if conflict {
+		switch {
+		case anchored || rs.policy == PolicyRename:
+			// Only generated ids can move; composite and parent-keyed rows
+			// follow their parents instead.
+			if oldID == "" || len(spec.key) != 1 {
+				return rowSkipped, nil
+			}
+			var newID string
+			if err := rs.tx.QueryRow(ctx, `SELECT gen_random_uuid()::text`).Scan(&newID); err != nil {
+				return rowSkipped, err
+			}
+			row["id"] = newID
+			outcome = rowRenamed
+		case rs.policy == PolicyOverwrite:
+			outcome = rowOverwritten
+		default:
+			rs.remember(spec, oldID, oldID)
+			return rowSkipped, nil
+		}
+	}
+
+	if spec.unique != "" {
+		if rowJSON, err = json.Marshal(row); err != nil {
+			return rowSkipped, err
+		}
+		taken, err := rs.uniqueTaken(ctx, spec, rowJSON)
+		if err != nil {
+			return rowSkipped, err
+		}
+		if taken {
+			if rs.policy != PolicyRename {
+				return rowSkipped, nil
+			}
+			value, _ := row[spec.unique].(string)
+			free, err := rs.freeUniqueValue(ctx, spec, value)
+			if err != nil {
+				return rowSkipped, err
+			}
+			row[spec.unique] = free
+			outcome = rowRenamed
+		}
+	}
+
+	if rowJSON, err = json.Marshal(row); err != nil {
+		return rowSkipped, err
+	}
+	if err := rs.write(ctx, spec, nullable, rowJSON, outcome == rowOverwritten); err != nil {
+		return rowSkipped, err
+	}
+	newID, _ := row["id"].(string)
+	rs.remember(spec, oldID, newID)
+	return outcome, nil
+}
+
+func (rs *restorer) resolve(ctx context.Context, table, id string) (string, bool, error) {
+	if mapped, ok := rs.ids[table][id]; ok {
+		return mapped, true, nil
+	}
+	if rs.present[table] == nil {
+		rs.present[table] = map[string]bool{}
+	}
+	if exists, ok := rs.present[table][id]; ok {
+		return id, exists, nil
+	}
+
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE id::text = $1)`, pgx.Identifier{table}.Sanitize())
+	if err := rs.tx.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return "", false, err
+	}
+	rs.present[table][id] = exists
+	return id, exists, nil
+}
+
+func (rs *restorer) remember(spec tableSpec, oldID, newID string) {
+	if oldID == "" || newID == "" || len(spec.key) != 1 || spec.key[0] != "id" {
+		return
+	}
+	rs.ids[spec.name][oldID] = newID
+}
+
+func (rs *restorer) keyTaken(ctx context.Context, spec tableSpec, rowJSON []byte) (bool, error) {
+	table := pgx.Identifier{spec.name}.Sanitize()
+	conditions := make([]string, 0, len(spec.key))
+	for _, column := range spec.key {
+		quoted := pgx.Identifier{column}.Sanitize()
+		conditions = append(conditions, fmt.Sprintf("t.%s = r.%s", quoted, quoted))
+	}
+	query := fmt.Sprintf(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM %s t, jsonb_populate_record(NULL::%s, $1::jsonb) r
+			WHERE %s
+		)
+	`, table, table, strings.Join(conditions, " AND "))
+
+	var taken bool
+	err := rs.tx.QueryRow(ctx, query, rowJSON).Scan(&taken)
+	return taken, err
+}
+
+// uniqueTaken reports whether another row already holds the row's unique
+// value. The row itself does not count, so overwrites keep their slug.
+func (rs *restorer) uniqueTaken(ctx context.Context, spec tableSpec, rowJSON []byte) (bool, error) {
+	table := pgx.Identifier{spec.name}.Sanitize()
+	unique := pgx.Identifier{spec.unique}.Sanitize()
+	query := fmt.Sprintf(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM %s t, jsonb_populate_record(NULL::%s, $1::jsonb) r
+			WHERE t.%s = r.%s
+			  AND (%s) IS DISTINCT FROM (%s)
+		)
+	`, table, table, unique, unique, qualifiedKey("t", spec.key), qualifiedKey("r", spec.key))
+
+	var taken bool
+	err := rs.tx.QueryRow(ctx, query, rowJSON).Scan(&taken)
+	return taken, err
+}
+
+func (rs *restorer) freeUniqueValue(ctx context.Context, spec tableSpec, value string) (string, error) {
+	query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s WHERE %s = $1)`,
+		pgx.Identifier{spec.name}.Sanitize(), pgx.Identifier{spec.unique}.Sanitize())
+	for attempt := 1; attempt <= 100; attempt++ {
+		candidate := renamedValue(value, attempt)
+		var taken bool
+		if err := rs.tx.QueryRow(ctx, query, candidate).Scan(&taken); err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free %s for %q", spec.unique, value)
+}
+
+func renamedValue(value string, attempt int) string {
+	if attempt <= 1 {
+		return value + "-restored"
+	}
+	return fmt.Sprintf("%s-restored-%d", value, attempt)
+}
+
+func (rs *restorer) write(ctx context.Context, spec tableSpec, nullable map[string]bool, rowJSON []byte, overwrite bool) error {
+	table := pgx.Identifier{spec.name}.Sanitize()
+	query := fmt.Sprintf(`INSERT INTO %s SELECT * FROM jsonb_populate_record(NULL::%s, $1::jsonb)`, table, table)
+	if overwrite {
+		keys := map[string]struct{}{}
+		quotedKey := make([]string, 0, len(spec.key))
+		for _, column := range spec.key {
+			keys[column] = struct{}{}
+			quotedKey = append(quotedKey, pgx.Identifier{column}.Sanitize())
+		}
+		updates := []string{}
+		for _, column := range sortedKeys(nullable) {
+			if _, isKey := keys[column]; isKey {
+				continue
+			}
+			quoted := pgx.Identifier{column}.Sanitize()
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted))
+		}
+		query += fmt.Sprintf(` ON CONFLICT (%s) DO UPDATE SET %s`, strings.Join(quotedKey, ", "), strings.Join(updates, ", "))
+	}
+	_, err := rs.tx.Exec(ctx, query, rowJSON)
+	return err
+}
+
+// loadColumns returns the table's columns and whether each accepts NULL.
+func (rs *restorer) loadColumns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := rs.tx.Query(ctx, `
+		SELECT column_name, is_nullable = 'YES'
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		  AND table_name = $1
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var (
+			name     string
+			nullable bool
+		)
+		if err := rows.Scan(&name, &nullable); err != nil {
+			return nil, err
+		}
+		columns[name] = nullable
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+	return columns, nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
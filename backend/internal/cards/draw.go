@@ -0,0 +1,175 @@
+// Package cards holds the drawing primitives shared by the battle card
+// images the API serves and the animated cards the worker renders.
+package cards
+
+import (
+	"image"
+	"image/color"
+	imagedraw "image/draw"
+	"strings"
+
+	"personaworlds/backend/internal/common"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// NormalizeText trims value and collapses every run of whitespace into one
+// space.
+func NormalizeText(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// FillRect paints rect with c.
+func FillRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	imagedraw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, imagedraw.Src)
+}
+
+// StrokeRect draws a one pixel border just inside rect.
+func StrokeRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	top := image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1)
+	bottom := image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y)
+	left := image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y)
+	right := image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y)
+	FillRect(img, top, c)
+	FillRect(img, bottom, c)
+	FillRect(img, left, c)
+	FillRect(img, right, c)
+}
+
+// DrawLabel draws label upper-cased with its baseline at y.
+func DrawLabel(img *image.RGBA, face font.Face, x, y int, label string, c color.Color) {
+	DrawText(img, face, x, y, strings.ToUpper(strings.TrimSpace(label)), c)
+}
+
+// DrawText draws one line of text with its baseline at y.
+func DrawText(img *image.RGBA, face font.Face, x, y int, text string, c color.Color) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// DrawWrappedText wraps value to maxWidth and draws at most maxLines lines,
+// ending a cut-off text with "...". It returns the baseline below the last
+// line drawn.
+func DrawWrappedText(
+	img *image.RGBA,
+	face font.Face,
+	x int,
+	y int,
+	maxWidth int,
+	lineHeight int,
+	maxLines int,
+	value string,
+	c color.Color,
+) int {
+	if lineHeight <= 0 {
+		lineHeight = 16
+	}
+	if maxLines <= 0 {
+		maxLines = 1
+	}
+
+	lines := WrapText(value, face, maxWidth)
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if !strings.HasSuffix(last, "...") {
+			last = common.TruncateRunes(last, 60) + "..."
+		}
+		lines[len(lines)-1] = last
+	}
+
+	currentY := y
+	for _, line := range lines {
+		DrawText(img, face, x, currentY, line, c)
+		currentY += lineHeight
+	}
+	return currentY
+}
+
+// WrapText splits value into lines no wider than maxWidth, breaking words
+// that do not fit on a line of their own.
+func WrapText(value string, face font.Face, maxWidth int) []string {
+	clean := NormalizeText(value)
+	if clean == "" {
+		return []string{""}
+	}
+	if maxWidth <= 8 {
+		return []string{clean}
+	}
+
+	words := strings.Fields(clean)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	lines := make([]string, 0, 4)
+	current := words[0]
+	for _, word := range words[1:] {
+		candidate := current + " " + word
+		if MeasureText(face, candidate) <= maxWidth {
+			current = candidate
+			continue
+		}
+		lines = append(lines, current)
+		current = word
+
+		if MeasureText(face, current) > maxWidth {
+			parts := splitLongWord(current, face, maxWidth)
+			lines = append(lines, parts[:len(parts)-1]...)
+			current = parts[len(parts)-1]
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	return lines
+}
+
+func splitLongWord(word string, face font.Face, maxWidth int) []string {
+	chars := []rune(strings.TrimSpace(word))
+	if len(chars) == 0 {
+		return []string{""}
+	}
+
+	parts := make([]string, 0, 2)
+	start := 0
+	for start < len(chars) {
+		end := start + 1
+		for end <= len(chars) {
+			chunk := string(chars[start:end])
+			if MeasureText(face, chunk) > maxWidth {
+				break
+			}
+			end++
+		}
+
+		if end == start+1 {
+			end = start + 1
+		} else {
+			end--
+		}
+		parts = append(parts, string(chars[start:end]))
+		start = end
+	}
+	return parts
+}
+
+// MeasureText returns the width of value in pixels.
+func MeasureText(face font.Face, value string) int {
+	return font.MeasureString(face, value).Ceil()
+}
@@ -0,0 +1,219 @@
+package cards
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	imagedraw "image/draw"
+	"image/gif"
+	"strings"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	LiveWidth  = 960
+	LiveHeight = 540
+
+	// LiveMaxTurns is how many turns a live card reveals one by one; the
+	// verdict frame mentions the rest.
+	LiveMaxTurns = 12
+	// LiveMaxBytes is the default size limit of an encoded live card.
+	LiveMaxBytes = 3 << 20
+
+	liveIntroDelay   = 150 // hundredths of a second
+	liveTurnDelay    = 250
+	liveVerdictDelay = 500
+	liveVisibleTurns = 3
+)
+
+var ErrLiveCardTooLarge = errors.New("live card exceeds the size limit")
+
+// LiveCard is everything a live card shows. The API builds it from the same
+// data as the static card, so both agree on sides and verdict.
+type LiveCard struct {
+	RoomName   string     `json:"room_name"`
+	Topic      string     `json:"topic"`
+	ProPersona string     `json:"pro_persona"`
+	ConPersona string     `json:"con_persona"`
+	Turns      []LiveTurn `json:"turns"`
+	Verdict    string     `json:"verdict"`
+	URL        string     `json:"url"`
+}
+
+type LiveTurn struct {
+	Persona string `json:"persona"`
+	Text    string `json:"text"`
+}
+
+var (
+	liveBackground = color.RGBA{R: 15, G: 23, B: 42, A: 255}
+	livePanel      = color.RGBA{R: 248, G: 250, B: 252, A: 255}
+	livePanelMuted = color.RGBA{R: 241, G: 245, B: 249, A: 255}
+	liveInk        = color.RGBA{R: 15, G: 23, B: 42, A: 255}
+	liveInkMuted   = color.RGBA{R: 71, G: 85, B: 105, A: 255}
+	liveBorder     = color.RGBA{R: 148, G: 163, B: 184, A: 255}
+	liveAccent     = color.RGBA{R: 59, G: 130, B: 246, A: 255}
+	liveAccentSoft = color.RGBA{R: 219, G: 234, B: 254, A: 255}
+	livePro        = color.RGBA{R: 16, G: 185, B: 129, A: 255}
+	liveCon        = color.RGBA{R: 244, G: 63, B: 94, A: 255}
+	liveWhite      = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	// livePalette holds every color the frames use. The bitmap font draws
+	// without anti-aliasing, so frames map onto it exactly.
+	livePalette = color.Palette{
+		liveBackground, livePanel, livePanelMuted, liveInk, liveInkMuted, liveBorder,
+		liveAccent, liveAccentSoft, livePro, liveCon, liveWhite,
+	}
+)
+
+// LiveFrameCount is the number of frames RenderLiveGIF draws for a battle
+// with turns turns.
+func LiveFrameCount(turns int) int {
+	return min(turns, LiveMaxTurns) + 2
+}
+
+// RenderLiveGIF renders card as a looping GIF: an intro frame with the
+// topic and sides, one frame per turn revealing it under the previous ones,
+// and a closing verdict frame. It returns ErrLiveCardTooLarge when the
+// encoding exceeds maxBytes.
+func RenderLiveGIF(card LiveCard, maxBytes int) ([]byte, error) {
+	turns := card.Turns
+	hidden := 0
+	if len(turns) > LiveMaxTurns {
+		hidden = len(turns) - LiveMaxTurns
+		turns = turns[:LiveMaxTurns]
+	}
+
+	animation := &gif.GIF{}
+	canvas := image.NewRGBA(image.Rect(0, 0, LiveWidth, LiveHeight))
+	addFrame := func(delay int) {
+		frame := image.NewPaletted(canvas.Bounds(), livePalette)
+		imagedraw.Draw(frame, frame.Bounds(), canvas, image.Point{}, imagedraw.Src)
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, delay)
+	}
+
+	drawLiveFrame(canvas, card, turns, 0, "")
+	addFrame(liveIntroDelay)
+	for revealed := 1; revealed <= len(turns); revealed++ {
+		drawLiveFrame(canvas, card, turns, revealed, "")
+		addFrame(liveTurnDelay)
+	}
+	verdict := card.Verdict
+	if hidden > 0 {
+		verdict = fmt.Sprintf("%s (+%d more turns in the full battle)", verdict, hidden)
+	}
+	drawLiveFrame(canvas, card, turns, len(turns), verdict)
+	addFrame(liveVerdictDelay)
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return nil, err
+	}
+	if maxBytes > 0 && buf.Len() > maxBytes {
+		return nil, ErrLiveCardTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLiveFrame draws the card with the first revealed turns shown, the
+// newest at the bottom. A non-empty verdict replaces the turns.
+func drawLiveFrame(canvas *image.RGBA, card LiveCard, turns []LiveTurn, revealed int, verdict string) {
+	face := basicfont.Face7x13
+	lineHeight := 18
+
+	FillRect(canvas, canvas.Bounds(), liveBackground)
+
+	headerRect := image.Rect(24, 24, LiveWidth-24, 136)
+	bodyRect := image.Rect(24, 152, LiveWidth-24, 440)
+	footerRect := image.Rect(24, 456, LiveWidth-24, LiveHeight-24)
+
+	FillRect(canvas, headerRect, livePanel)
+	StrokeRect(canvas, headerRect, liveAccent)
+	DrawLabel(canvas, face, 40, 46, "LIVE BATTLE  "+card.RoomName, liveAccent)
+	DrawWrappedText(canvas, face, 40, 72, headerRect.Dx()-32, lineHeight, 2, card.Topic, liveInk)
+	DrawText(canvas, face, 40, 120, "Pro: "+card.ProPersona, livePro)
+	DrawText(canvas, face, headerRect.Dx()/2, 120, "Con: "+card.ConPersona, liveCon)
+
+	if verdict != "" {
+		FillRect(canvas, bodyRect, livePanel)
+		StrokeRect(canvas, bodyRect, liveAccent)
+		DrawLabel(canvas, face, 40, 184, "VERDICT", liveAccent)
+		DrawWrappedText(canvas, face, 40, 216, bodyRect.Dx()-32, lineHeight+4, 10, verdict, liveInk)
+	} else if revealed == 0 {
+		FillRect(canvas, bodyRect, livePanelMuted)
+		StrokeRect(canvas, bodyRect, liveBorder)
+		DrawLabel(canvas, face, 40, 184, fmt.Sprintf("%d TURNS", len(turns)), liveAccent)
+		DrawWrappedText(canvas, face, 40, 216, bodyRect.Dx()-32, lineHeight, 2, "The battle is about to begin.", liveInkMuted)
+	} else {
+		drawLiveTurns(canvas, card, turns, revealed, bodyRect)
+	}
+
+	FillRect(canvas, footerRect, liveAccentSoft)
+	StrokeRect(canvas, footerRect, liveAccent)
+	drawLiveProgress(canvas, turns, revealed, verdict != "", image.Rect(40, 470, LiveWidth-40, 478))
+	linkDisplay := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(card.URL), "https://"), "http://")
+	DrawWrappedText(canvas, face, 40, 504, footerRect.Dx()-32, lineHeight, 1, linkDisplay, liveInkMuted)
+}
+
+func drawLiveTurns(canvas *image.RGBA, card LiveCard, turns []LiveTurn, revealed int, area image.Rectangle) {
+	face := basicfont.Face7x13
+	first := revealed - liveVisibleTurns
+	if first < 0 {
+		first = 0
+	}
+	gap := 8
+	height := (area.Dy() - gap*(liveVisibleTurns-1)) / liveVisibleTurns
+	y := area.Min.Y
+	for index := first; index < revealed; index++ {
+		turn := turns[index]
+		rect := image.Rect(area.Min.X, y, area.Max.X, y+height)
+		side := liveSideColor(card, turn.Persona)
+		if index == revealed-1 {
+			FillRect(canvas, rect, livePanel)
+			StrokeRect(canvas, rect, liveAccent)
+		} else {
+			FillRect(canvas, rect, livePanelMuted)
+			StrokeRect(canvas, rect, liveBorder)
+		}
+		FillRect(canvas, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+6, rect.Max.Y), side)
+		DrawLabel(canvas, face, rect.Min.X+20, rect.Min.Y+22, fmt.Sprintf("TURN %d  %s", index+1, turn.Persona), side)
+		DrawWrappedText(canvas, face, rect.Min.X+20, rect.Min.Y+44, rect.Dx()-40, 18, 3, turn.Text, liveInk)
+		y += height + gap
+	}
+}
+
+// drawLiveProgress draws one segment per turn plus one for the verdict,
+// filled up to the current frame.
+func drawLiveProgress(canvas *image.RGBA, turns []LiveTurn, revealed int, done bool, area image.Rectangle) {
+	segments := len(turns) + 1
+	gap := 4
+	width := (area.Dx() - gap*(segments-1)) / segments
+	if width < 1 {
+		width = 1
+	}
+	for index := 0; index < segments; index++ {
+		x := area.Min.X + index*(width+gap)
+		rect := image.Rect(x, area.Min.Y, x+width, area.Max.Y)
+		filled := index < revealed || (index == segments-1 && done)
+		if filled {
+			FillRect(canvas, rect, liveAccent)
+		} else {
+			FillRect(canvas, rect, liveWhite)
+		}
+	}
+}
+
+func liveSideColor(card LiveCard, persona string) color.RGBA {
+	switch {
+	case persona != "" && persona == card.ProPersona:
+		return livePro
+	case persona != "" && persona == card.ConPersona:
+		return liveCon
+	default:
+		return liveAccent
+	}
+}
@@ -0,0 +1,67 @@
+package cards
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/gif"
+	"testing"
+)
+
+func testLiveCard(turns int) LiveCard {
+	card := LiveCard{
+		RoomName:   "Startups",
+		Topic:      "Should early teams ship weekly?",
+		ProPersona: "Ada",
+		ConPersona: "Grace",
+		Verdict:    "Ada wins on evidence.",
+		URL:        "https://example.com/b/123",
+	}
+	for i := 0; i < turns; i++ {
+		persona := "Ada"
+		if i%2 == 1 {
+			persona = "Grace"
+		}
+		card.Turns = append(card.Turns, LiveTurn{Persona: persona, Text: fmt.Sprintf("Turn %d argues its side with a short example.", i+1)})
+	}
+	return card
+}
+
+func TestRenderLiveGIF(t *testing.T) {
+	payload, err := RenderLiveGIF(testLiveCard(4), LiveMaxBytes)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	animation, err := gif.DecodeAll(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// intro + one frame per turn + verdict
+	if len(animation.Image) != 6 || LiveFrameCount(4) != 6 {
+		t.Fatalf("expected 6 frames, got %d", len(animation.Image))
+	}
+	if bounds := animation.Image[0].Bounds(); bounds.Dx() != LiveWidth || bounds.Dy() != LiveHeight {
+		t.Fatalf("unexpected frame size %v", bounds)
+	}
+	if animation.Delay[len(animation.Delay)-1] != liveVerdictDelay {
+		t.Fatalf("expected the verdict frame to hold longest, got delays %v", animation.Delay)
+	}
+}
+
+func TestRenderLiveGIFLimits(t *testing.T) {
+	payload, err := RenderLiveGIF(testLiveCard(LiveMaxTurns+5), LiveMaxBytes)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	animation, err := gif.DecodeAll(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(animation.Image) != LiveFrameCount(LiveMaxTurns+5) {
+		t.Fatalf("expected turns capped at %d, got %d frames", LiveMaxTurns, len(animation.Image))
+	}
+
+	if _, err := RenderLiveGIF(testLiveCard(2), 1024); !errors.Is(err, ErrLiveCardTooLarge) {
+		t.Fatalf("expected ErrLiveCardTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+func ValidateActiveHours(start, end *int, timezone string) (string, error) {
+	location, err := NormalizeTimezone(timezone)
+	if err != nil {
+		return "", err
+	}
+
+	if start == nil && end == nil {
+		return location, nil
+	}
+	if start == nil || end == nil {
+		return "", fmt.Errorf("active_hours_start and active_hours_end must be set together")
+	}
+	if *start < 0 || *start > 23 || *end < 0 || *end > 23 {
+		return "", fmt.Errorf("active hours must be between 0 and 23")
+	}
+	if *start == *end {
+		return "", fmt.Errorf("active_hours_start and active_hours_end must differ")
+	}
+	return location, nil
+}
+
+// NextActiveTime returns now when it falls inside the [start, end) local-hour
+// window, otherwise the next moment the window opens. Windows with start > end
+// wrap past midnight.
+func NextActiveTime(now time.Time, start, end *int, timezone string) time.Time {
+	if start == nil || end == nil || *start == *end {
+		return now
+	}
+	location := LoadLocation(timezone)
+
+	local := now.In(location)
+	hour := local.Hour()
+	if *start < *end {
+		if hour >= *start && hour < *end {
+			return now
+		}
+	} else if hour >= *start || hour < *end {
+		return now
+	}
+
+	opensAt := time.Date(local.Year(), local.Month(), local.Day(), *start, 0, 0, 0, location)
+	if !opensAt.After(local) {
+		opensAt = time.Date(local.Year(), local.Month(), local.Day()+1, *start, 0, 0, 0, location)
+	}
+	return opensAt
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestNextActiveTimeInsideWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	got := NextActiveTime(now, intPtr(9), intPtr(22), "UTC")
+	if !got.Equal(now) {
+		t.Fatalf("expected now, got %s", got)
+	}
+}
+
+func TestNextActiveTimeDefersToWindowStart(t *testing.T) {
+	now := time.Date(2026, 3, 10, 0, 30, 0, 0, time.UTC)
+	got := NextActiveTime(now, intPtr(9), intPtr(22), "Europe/Istanbul")
+
+	location, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	want := time.Date(2026, 3, 10, 9, 0, 0, 0, location)
+	if !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestNextActiveTimeWrapsPastMidnight(t *testing.T) {
+	inside := time.Date(2026, 3, 10, 1, 0, 0, 0, time.UTC)
+	if got := NextActiveTime(inside, intPtr(20), intPtr(2), "UTC"); !got.Equal(inside) {
+		t.Fatalf("expected 01:00 to be inside 20-02 window, got %s", got)
+	}
+
+	outside := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	if got := NextActiveTime(outside, intPtr(20), intPtr(2), "UTC"); !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestValidateActiveHours(t *testing.T) {
+	if _, err := ValidateActiveHours(intPtr(9), nil, "UTC"); err == nil {
+		t.Fatalf("expected error when only start is set")
+	}
+	if _, err := ValidateActiveHours(intPtr(9), intPtr(9), "UTC"); err == nil {
+		t.Fatalf("expected error for empty window")
+	}
+	if _, err := ValidateActiveHours(intPtr(9), intPtr(24), "UTC"); err == nil {
+		t.Fatalf("expected error for out of range hour")
+	}
+	if _, err := ValidateActiveHours(nil, nil, "Mars/Olympus"); err == nil {
+		t.Fatalf("expected error for unknown timezone")
+	}
+	if tz, err := ValidateActiveHours(nil, nil, ""); err != nil || tz != "UTC" {
+		t.Fatalf("expected UTC default, got %q (%v)", tz, err)
+	}
+}
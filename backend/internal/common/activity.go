@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgconn"
 )
@@ -11,6 +12,9 @@ type DBExecutor interface {
 	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
 }
 
+// PresenceWindowDays is how far back a persona's room presence reaches.
+const PresenceWindowDays = 30
+
 func InsertPersonaActivityEvent(ctx context.Context, executor DBExecutor, personaID, eventType string, metadata map[string]any) error {
 	if metadata == nil {
 		metadata = map[string]any{}
@@ -25,5 +29,46 @@ func InsertPersonaActivityEvent(ctx context.Context, executor DBExecutor, person
 		INSERT INTO persona_activity_events(persona_id, type, metadata)
 		VALUES ($1, $2, $3::jsonb)
 	`, personaID, eventType, raw)
+	if err != nil {
+		return err
+	}
+	return recordRoomPresence(ctx, executor, personaID, eventType, metadata)
+}
+
+// PresenceDelta is what an activity event adds to its room's presence row.
+// Only the persona's own activity counts; a thread_participated event marks
+// the room active without counting a post or reply twice.
+func PresenceDelta(eventType string, metadata map[string]any) (roomID string, posts, replies int, ok bool) {
+	roomID, _ = metadata["room_id"].(string)
+	roomID = strings.TrimSpace(roomID)
+	if roomID == "" {
+		return "", 0, 0, false
+	}
+	switch eventType {
+	case "post_created":
+		return roomID, 1, 0, true
+	case "reply_generated":
+		return roomID, 0, 1, true
+	case "thread_participated":
+		return roomID, 0, 0, true
+	default:
+		return "", 0, 0, false
+	}
+}
+
+func recordRoomPresence(ctx context.Context, executor DBExecutor, personaID, eventType string, metadata map[string]any) error {
+	roomID, posts, replies, ok := PresenceDelta(eventType, metadata)
+	if !ok {
+		return nil
+	}
+	_, err := executor.Exec(ctx, `
+		INSERT INTO persona_room_presence_days(persona_id, room_id, day, post_count, reply_count, last_active_at)
+		VALUES ($1, $2, (NOW() AT TIME ZONE 'UTC')::date, $3, $4, NOW())
+		ON CONFLICT (persona_id, room_id, day)
+		DO UPDATE SET
+			post_count = persona_room_presence_days.post_count + EXCLUDED.post_count,
+			reply_count = persona_room_presence_days.reply_count + EXCLUDED.reply_count,
+			last_active_at = GREATEST(persona_room_presence_days.last_active_at, EXCLUDED.last_active_at)
+	`, personaID, roomID, posts, replies)
 	return err
 }
@@ -0,0 +1,34 @@
+package common
+
+import "testing"
+
+func TestPresenceDelta(t *testing.T) {
+	metadata := map[string]any{"room_id": " room-1 ", "post_id": "post-1"}
+
+	cases := []struct {
+		eventType      string
+		posts, replies int
+		ok             bool
+	}{
+		{"post_created", 1, 0, true},
+		{"reply_generated", 0, 1, true},
+		{"thread_participated", 0, 0, true},
+		{"human_reply_received", 0, 0, false},
+	}
+	for _, tc := range cases {
+		roomID, posts, replies, ok := PresenceDelta(tc.eventType, metadata)
+		if ok != tc.ok || posts != tc.posts || replies != tc.replies {
+			t.Fatalf("%s: got posts=%d replies=%d ok=%v", tc.eventType, posts, replies, ok)
+		}
+		if ok && roomID != "room-1" {
+			t.Fatalf("%s: expected trimmed room id, got %q", tc.eventType, roomID)
+		}
+	}
+
+	if _, _, _, ok := PresenceDelta("post_created", map[string]any{"post_id": "post-1"}); ok {
+		t.Fatalf("expected events without a room to be skipped")
+	}
+	if _, _, _, ok := PresenceDelta("post_created", map[string]any{"room_id": 42}); ok {
+		t.Fatalf("expected non-string room ids to be skipped")
+	}
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	AnnouncementKindChangelog = "changelog"
+	AnnouncementKindBroadcast = "broadcast"
+
+	AnnouncementSegmentAll      = "all"
+	AnnouncementSegmentNew      = "new"
+	AnnouncementSegmentActive   = "active"
+	AnnouncementSegmentDormant  = "dormant"
+	AnnouncementSegmentCreators = "creators"
+)
+
+// AnnouncementSegments lists the audiences an announcement can target:
+//   - new: accounts created in the last 14 days
+//   - active: recorded an event in the last 7 days
+//   - dormant: no event in the last 30 days
+//   - creators: own at least one persona
+var AnnouncementSegments = []string{
+	AnnouncementSegmentAll,
+	AnnouncementSegmentNew,
+	AnnouncementSegmentActive,
+	AnnouncementSegmentDormant,
+	AnnouncementSegmentCreators,
+}
+
+// NormalizeAnnouncementSegment lowercases segment, defaulting to all, and
+// rejects unknown audiences.
+func NormalizeAnnouncementSegment(segment string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(segment))
+	if clean == "" {
+		return AnnouncementSegmentAll, nil
+	}
+	for _, known := range AnnouncementSegments {
+		if clean == known {
+			return clean, nil
+		}
+	}
+	return "", fmt.Errorf("segment must be one of %s", strings.Join(AnnouncementSegments, ", "))
+}
+
+// AnnouncementAudienceSQL is the condition under which announcement a is
+// live and meant for the user with id userParam ($N). The API's unseen list
+// and the worker's weekly digest both use it so they agree on who sees what.
+func AnnouncementAudienceSQL(userParam string) string {
+	return `a.starts_at <= NOW()
+		  AND (a.ends_at IS NULL OR a.ends_at > NOW())
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM announcement_dismissals ad
+			WHERE ad.announcement_id = a.id
+			  AND ad.user_id = ` + userParam + `
+		  )
+		  AND (
+			a.segment = 'all'
+			OR (a.segment = 'new' AND EXISTS (
+				SELECT 1 FROM users u WHERE u.id = ` + userParam + ` AND u.created_at >= NOW() - INTERVAL '14 days'
+			))
+			OR (a.segment = 'active' AND EXISTS (
+				SELECT 1 FROM events e WHERE e.user_id = ` + userParam + ` AND e.created_at >= NOW() - INTERVAL '7 days'
+			))
+			OR (a.segment = 'dormant' AND NOT EXISTS (
+				SELECT 1 FROM events e WHERE e.user_id = ` + userParam + ` AND e.created_at >= NOW() - INTERVAL '30 days'
+			))
+			OR (a.segment = 'creators' AND EXISTS (
+				SELECT 1 FROM personas p WHERE p.user_id = ` + userParam + `
+			))
+		  )`
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	BattleContextPackPending = "PENDING"
+	BattleContextPackReady   = "READY"
+	BattleContextPackEmpty   = "EMPTY"
+	BattleContextPackFailed  = "FAILED"
+
+	// A pack is summarized from at most BattleContextPackMaxSources room
+	// posts, each cut to BattleContextPackExcerptRunes, into a summary of
+	// about BattleContextPackWordLimit words and at most
+	// BattleContextPackMaxRunes runes.
+	BattleContextPackMaxSources   = 5
+	BattleContextPackExcerptRunes = 400
+	BattleContextPackWordLimit    = 120
+	BattleContextPackMaxRunes     = 1000
+)
+
+// BattleContextPackSource is one room post a context pack was summarized
+// from. Number is how the summary cites it ("post 1").
+type BattleContextPackSource struct {
+	Number      int     `json:"number"`
+	PostID      string  `json:"post_id"`
+	PersonaName string  `json:"persona_name"`
+	Excerpt     string  `json:"excerpt"`
+	Score       float64 `json:"score"`
+}
+
+// RequestBattleContextPack records that battle postID wants a context pack.
+// It has to run before the battle's reply jobs are queued so they find the
+// pending pack and wait for it.
+func RequestBattleContextPack(ctx context.Context, db DBExecutor, postID, roomID, topic string) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO battle_context_packs(post_id, room_id, topic)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (post_id) DO NOTHING
+	`, postID, roomID, topic)
+	return err
+}
+
+// LoadBattleContextPack returns the summary of battle postID's context pack
+// once it is READY. Battles without one, or whose pack is still pending,
+// came up empty or failed, read as "".
+func LoadBattleContextPack(ctx context.Context, db DBQuerier, postID string) (string, error) {
+	var summary string
+	err := db.QueryRow(ctx, `
+		SELECT summary
+		FROM battle_context_packs
+		WHERE post_id = $1
+		  AND status = $2
+	`, postID, BattleContextPackReady).Scan(&summary)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return summary, err
+}
@@ -0,0 +1,7 @@
+package common
+
+// HighlightVerdictRefWeight is what each coaching citation of a turn adds to
+// its quality score, so a turn the verdict leans on beats a slightly cleaner
+// one nobody mentioned. The worker ranks highlights by it and head-to-head
+// records fall back to it when a battle has no votes.
+const HighlightVerdictRefWeight = 0.15
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"personaworlds/backend/internal/safety"
+)
+
+const (
+	BattleLanguageAuto      = "auto"
+	BattleLanguageA         = "a"
+	BattleLanguageB         = "b"
+	BattleLanguageBilingual = "bilingual"
+)
+
+// BattleLanguage is a battle's language setting. Language is the side's
+// language an a or b setting resolved to when the battle was created, so a
+// persona changing its preferred language later does not switch a running
+// battle.
+type BattleLanguage struct {
+	Mode     string `json:"mode"`
+	Language string `json:"language,omitempty"`
+}
+
+// NormalizeBattleLanguage validates the language setting of a new battle.
+// Empty means auto, where every persona writes in its own language.
+func NormalizeBattleLanguage(value string) (string, error) {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case "":
+		return BattleLanguageAuto, nil
+	case BattleLanguageAuto, BattleLanguageA, BattleLanguageB, BattleLanguageBilingual:
+		return clean, nil
+	default:
+		return "", errors.New("language must be auto, a, b or bilingual")
+	}
+}
+
+// ResolveBattleLanguage pins an a or b setting to the preferred language of
+// that side's persona. A side without a known language falls back to the
+// other side, then to English.
+func ResolveBattleLanguage(mode, languageA, languageB string) BattleLanguage {
+	languageA = safety.NormalizeLanguage(languageA)
+	languageB = safety.NormalizeLanguage(languageB)
+	out := BattleLanguage{Mode: mode}
+	switch mode {
+	case BattleLanguageA:
+		out.Language = firstNonEmpty(languageA, languageB, safety.LanguageEN)
+	case BattleLanguageB:
+		out.Language = firstNonEmpty(languageB, languageA, safety.LanguageEN)
+	case BattleLanguageBilingual:
+	default:
+		out.Mode = BattleLanguageAuto
+	}
+	return out
+}
+
+// TurnLanguage is the language a persona whose own language is
+// personaLanguage must write its turn in. Empty leaves it to the persona, as
+// battles did before the setting existed.
+func (b BattleLanguage) TurnLanguage(personaLanguage string) string {
+	switch b.Mode {
+	case BattleLanguageA, BattleLanguageB:
+		return b.Language
+	case BattleLanguageBilingual:
+		return firstNonEmpty(safety.NormalizeLanguage(personaLanguage), safety.LanguageEN)
+	default:
+		return ""
+	}
+}
+
+// TranslationLanguage is the second language a bilingual battle renders a
+// turn written in turnLanguage into, or empty when the battle is not
+// bilingual.
+func (b BattleLanguage) TranslationLanguage(turnLanguage string) string {
+	if b.Mode != BattleLanguageBilingual {
+		return ""
+	}
+	if safety.NormalizeLanguage(turnLanguage) == safety.LanguageTR {
+		return safety.LanguageEN
+	}
+	return safety.LanguageTR
+}
+
+// BilingualTurnMetadata is the replies.metadata document for a bilingual
+// turn: the original and its translation side by side, keyed by language.
+func BilingualTurnMetadata(language, content, translationLanguage, translation string) map[string]any {
+	return map[string]any{
+		"language": language,
+		"bilingual": map[string]string{
+			language:            content,
+			translationLanguage: translation,
+		},
+	}
+}
+
+// LoadBattleLanguage reads the language setting of the battle opened by
+// postID. Posts that are not battles read as auto.
+func LoadBattleLanguage(ctx context.Context, db DBQuerier, postID string) (BattleLanguage, error) {
+	var out BattleLanguage
+	err := db.QueryRow(ctx, `
+		SELECT battle_language, COALESCE(battle_language_code, '')
+		FROM posts
+		WHERE id = $1
+	`, postID).Scan(&out.Mode, &out.Language)
+	return out, err
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package common
+
+import "testing"
+
+func TestNormalizeBattleLanguage(t *testing.T) {
+	cases := map[string]string{"": BattleLanguageAuto, " A ": BattleLanguageA, "b": BattleLanguageB, "Bilingual": BattleLanguageBilingual}
+	for input, want := range cases {
+		got, err := NormalizeBattleLanguage(input)
+		if err != nil || got != want {
+			t.Fatalf("NormalizeBattleLanguage(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeBattleLanguage("tr"); err == nil {
+		t.Fatal("expected a language code to be rejected as a battle setting")
+	}
+}
+
+func TestResolveBattleLanguagePinsSides(t *testing.T) {
+	if got := ResolveBattleLanguage(BattleLanguageA, "tr", "en"); got.Language != "tr" {
+		t.Fatalf("expected side a to pin tr, got %+v", got)
+	}
+	if got := ResolveBattleLanguage(BattleLanguageB, "tr", "en"); got.Language != "en" {
+		t.Fatalf("expected side b to pin en, got %+v", got)
+	}
+	if got := ResolveBattleLanguage(BattleLanguageB, "tr", ""); got.Language != "tr" {
+		t.Fatalf("expected a missing side b to fall back to side a, got %+v", got)
+	}
+	if got := ResolveBattleLanguage(BattleLanguageBilingual, "tr", "en"); got.Language != "" {
+		t.Fatalf("expected bilingual to pin no language, got %+v", got)
+	}
+}
+
+func TestBattleLanguageTurnLanguage(t *testing.T) {
+	pinned := BattleLanguage{Mode: BattleLanguageA, Language: "tr"}
+	if got := pinned.TurnLanguage("en"); got != "tr" {
+		t.Fatalf("expected pinned battle to force tr, got %q", got)
+	}
+	if got := pinned.TranslationLanguage("tr"); got != "" {
+		t.Fatalf("expected no translation outside bilingual battles, got %q", got)
+	}
+
+	auto := BattleLanguage{Mode: BattleLanguageAuto}
+	if got := auto.TurnLanguage("tr"); got != "" {
+		t.Fatalf("expected auto to leave the language to the persona, got %q", got)
+	}
+
+	bilingual := BattleLanguage{Mode: BattleLanguageBilingual}
+	if got := bilingual.TurnLanguage("tr"); got != "tr" {
+		t.Fatalf("expected bilingual turn in the persona's language, got %q", got)
+	}
+	if got := bilingual.TranslationLanguage("tr"); got != "en" {
+		t.Fatalf("expected tr turn to be translated to en, got %q", got)
+	}
+	if got := bilingual.TranslationLanguage("en"); got != "tr" {
+		t.Fatalf("expected en turn to be translated to tr, got %q", got)
+	}
+}
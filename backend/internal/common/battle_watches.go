@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	NotificationWatchedBattleTurn    = "watched_battle_turn"
+	NotificationWatchedBattleVerdict = "watched_battle_verdict"
+)
+
+// NotifyBattleWatchersOfTurn tells everyone watching the battle that a new
+// turn landed. A watcher who has not read the last turn notification for the
+// battle gets that one bumped instead of a new one, so a busy battle leaves a
+// single unread notification per watcher. authorUserID, whose persona or self
+// wrote the turn, is skipped.
+func NotifyBattleWatchersOfTurn(ctx context.Context, db DBExecutor, postID, authorUserID, authorName string) (int64, error) {
+	name := strings.TrimSpace(authorName)
+	if name == "" {
+		name = "Someone"
+	}
+	payload, err := json.Marshal(map[string]any{"battle_id": postID})
+	if err != nil {
+		return 0, err
+	}
+	body := TruncateRunes(fmt.Sprintf("%s took a turn in a battle you watch.", name), 260)
+
+	tag, err := db.Exec(ctx, `
+		WITH watchers AS (
+			SELECT bw.user_id
+			FROM battle_watches bw
+			WHERE bw.post_id = $1
+			  AND bw.user_id::text <> $2
+		),
+		bumped AS (
+			UPDATE notifications n
+			SET rollup_count = n.rollup_count + 1,
+				body = format('%s new turns in a battle you watch.', n.rollup_count + 1),
+				metadata = n.metadata || jsonb_build_object('rollup_count', n.rollup_count + 1),
+				created_at = NOW()
+			FROM watchers w
+			WHERE n.user_id = w.user_id
+			  AND n.type = $3
+			  AND n.rollup_key = $1::text
+			  AND n.read_at IS NULL
+			RETURNING n.user_id
+		)
+		INSERT INTO notifications(user_id, type, title, body, metadata, rollup_key)
+		SELECT w.user_id, $3, 'New turn in a watched battle', $4, $5::jsonb, $1::text
+		FROM watchers w
+		WHERE w.user_id NOT IN (SELECT user_id FROM bumped)
+	`, postID, strings.TrimSpace(authorUserID), NotificationWatchedBattleTurn, body, payload)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// NotifyBattleWatchersOfVerdict tells watchers the battle is finished and its
+// verdict can be read. The battle's own participants are skipped; they get
+// the battle_completed notification instead.
+func NotifyBattleWatchersOfVerdict(ctx context.Context, db DBExecutor, postID, topic string, participantUserIDs []string) (int64, error) {
+	body := "The verdict is in for a battle you watch."
+	if strings.TrimSpace(topic) != "" {
+		body = fmt.Sprintf("The verdict is in for \"%s\".", TruncateRunes(strings.TrimSpace(topic), 120))
+	}
+	payload, err := json.Marshal(map[string]any{"battle_id": postID})
+	if err != nil {
+		return 0, err
+	}
+	if participantUserIDs == nil {
+		participantUserIDs = []string{}
+	}
+
+	tag, err := db.Exec(ctx, `
+		INSERT INTO notifications(user_id, type, title, body, metadata)
+		SELECT bw.user_id, $2, 'A watched battle has a verdict', $3, $4::jsonb
+		FROM battle_watches bw
+		WHERE bw.post_id = $1
+		  AND NOT (bw.user_id::text = ANY($5::text[]))
+	`, postID, NotificationWatchedBattleVerdict, TruncateRunes(body, 260), payload, participantUserIDs)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
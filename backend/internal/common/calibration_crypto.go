@@ -0,0 +1,269 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"personaworlds/backend/internal/envelope"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// calibrationKeyCacheSize caps the unwrapped data keys a process keeps; the
+// cache starts over once it is full.
+const calibrationKeyCacheSize = 10000
+
+var ErrCalibrationKeysMissing = errors.New("persona calibration is encrypted but CALIBRATION_KEYS is not set")
+
+// CalibrationKeyStore reads and writes the user_data_keys table.
+type CalibrationKeyStore interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+}
+
+// sealedCalibration is how an encrypted calibration list is stored in place
+// of its jsonb array. It names the owner and data key version it was sealed
+// with, so it opens without knowing which persona row it came from.
+type sealedCalibration struct {
+	Version    int    `json:"v"`
+	UserID     string `json:"u"`
+	KeyVersion int    `json:"k"`
+	Nonce      []byte `json:"n"`
+	Ciphertext []byte `json:"c"`
+}
+
+// CalibrationCipher seals persona calibration lists with their owner's data
+// key and opens them again. Without a key provider it stores plaintext;
+// plaintext rows always read back as they are, so encryption can be turned
+// on before cmd/rotatekeys has sealed existing rows.
+type CalibrationCipher struct {
+	provider envelope.KeyProvider
+
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func NewCalibrationCipher(provider envelope.KeyProvider) *CalibrationCipher {
+	return &CalibrationCipher{provider: provider, keys: map[string][]byte{}}
+}
+
+// Enabled reports whether new writes are sealed.
+func (c *CalibrationCipher) Enabled() bool {
+	return c != nil && c.provider != nil
+}
+
+// Seal encrypts the encoded list plain of field for userID with the user's
+// newest data key, creating the first one when needed.
+func (c *CalibrationCipher) Seal(ctx context.Context, db CalibrationKeyStore, userID string, field PersonaListField, plain []byte) ([]byte, error) {
+	if !c.Enabled() {
+		return plain, nil
+	}
+	version, key, err := c.activeDataKey(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	nonce, ciphertext, err := envelope.Seal(key, plain, calibrationAAD(userID, field))
+	if err != nil {
+		return nil, fmt.Errorf("seal %s: %w", field.Name, err)
+	}
+	return json.Marshal(sealedCalibration{
+		Version:    1,
+		UserID:     userID,
+		KeyVersion: version,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	})
+}
+
+// Open returns the encoded list stored in raw, decrypting it when sealed.
+func (c *CalibrationCipher) Open(ctx context.Context, db CalibrationKeyStore, field PersonaListField, raw []byte) ([]byte, error) {
+	sealed, ok := parseSealedCalibration(raw)
+	if !ok {
+		return raw, nil
+	}
+	if !c.Enabled() {
+		return nil, ErrCalibrationKeysMissing
+	}
+	key, err := c.dataKey(ctx, db, sealed.UserID, sealed.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := envelope.Open(key, sealed.Nonce, sealed.Ciphertext, calibrationAAD(sealed.UserID, field))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", field.Name, err)
+	}
+	return plain, nil
+}
+
+// Decode is Open followed by field.Decode.
+func (c *CalibrationCipher) Decode(ctx context.Context, db CalibrationKeyStore, field PersonaListField, raw []byte) ([]string, error) {
+	plain, err := c.Open(ctx, db, field, raw)
+	if err != nil {
+		return nil, err
+	}
+	return field.Decode(plain)
+}
+
+// RotateDataKey gives userID a new data key version, which Seal uses from
+// then on. Rows sealed with older versions still open until resealed.
+func (c *CalibrationCipher) RotateDataKey(ctx context.Context, db CalibrationKeyStore, userID string) (int, error) {
+	if !c.Enabled() {
+		return 0, ErrCalibrationKeysMissing
+	}
+	key, err := envelope.GenerateDataKey()
+	if err != nil {
+		return 0, err
+	}
+	masterKeyID := c.provider.ActiveKeyID()
+	wrapped, err := c.provider.Wrap(ctx, masterKeyID, key)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRow(ctx, `
+		INSERT INTO user_data_keys(user_id, version, master_key_id, wrapped_key)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3
+		FROM user_data_keys
+		WHERE user_id = $1
+		RETURNING version
+	`, userID, masterKeyID, wrapped).Scan(&version); err != nil {
+		return 0, err
+	}
+	c.remember(userID, version, key)
+	return version, nil
+}
+
+// SealedCalibrationKeyVersion reports the data key version raw was sealed
+// with; ok is false for a plaintext list.
+func SealedCalibrationKeyVersion(raw []byte) (version int, ok bool) {
+	sealed, ok := parseSealedCalibration(raw)
+	if !ok {
+		return 0, false
+	}
+	return sealed.KeyVersion, true
+}
+
+func (c *CalibrationCipher) activeDataKey(ctx context.Context, db CalibrationKeyStore, userID string) (int, []byte, error) {
+	var (
+		version     int
+		masterKeyID string
+		wrapped     []byte
+	)
+	err := db.QueryRow(ctx, `
+		SELECT version, master_key_id, wrapped_key
+		FROM user_data_keys
+		WHERE user_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`, userID).Scan(&version, &masterKeyID, &wrapped)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.createFirstDataKey(ctx, db, userID)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	key, err := c.unwrap(ctx, userID, version, masterKeyID, wrapped)
+	return version, key, err
+}
+
+// createFirstDataKey writes version 1 of userID's data key. Two requests
+// racing to create it both end up with the stored one.
+func (c *CalibrationCipher) createFirstDataKey(ctx context.Context, db CalibrationKeyStore, userID string) (int, []byte, error) {
+	key, err := envelope.GenerateDataKey()
+	if err != nil {
+		return 0, nil, err
+	}
+	masterKeyID := c.provider.ActiveKeyID()
+	wrapped, err := c.provider.Wrap(ctx, masterKeyID, key)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := db.Exec(ctx, `
+		INSERT INTO user_data_keys(user_id, version, master_key_id, wrapped_key)
+		VALUES ($1, 1, $2, $3)
+		ON CONFLICT (user_id, version) DO NOTHING
+	`, userID, masterKeyID, wrapped); err != nil {
+		return 0, nil, err
+	}
+	key, err = c.dataKey(ctx, db, userID, 1)
+	return 1, key, err
+}
+
+func (c *CalibrationCipher) dataKey(ctx context.Context, db CalibrationKeyStore, userID string, version int) ([]byte, error) {
+	if key, ok := c.cached(userID, version); ok {
+		return key, nil
+	}
+	var (
+		masterKeyID string
+		wrapped     []byte
+	)
+	err := db.QueryRow(ctx, `
+		SELECT master_key_id, wrapped_key
+		FROM user_data_keys
+		WHERE user_id = $1
+		  AND version = $2
+	`, userID, version).Scan(&masterKeyID, &wrapped)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("data key %d of user %s not found", version, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.unwrap(ctx, userID, version, masterKeyID, wrapped)
+}
+
+func (c *CalibrationCipher) unwrap(ctx context.Context, userID string, version int, masterKeyID string, wrapped []byte) ([]byte, error) {
+	if key, ok := c.cached(userID, version); ok {
+		return key, nil
+	}
+	key, err := c.provider.Unwrap(ctx, masterKeyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key %d of user %s with %q: %w", version, userID, masterKeyID, err)
+	}
+	c.remember(userID, version, key)
+	return key, nil
+}
+
+func (c *CalibrationCipher) cached(userID string, version int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.keys[dataKeyCacheKey(userID, version)]
+	return key, ok
+}
+
+func (c *CalibrationCipher) remember(userID string, version int, key []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.keys) >= calibrationKeyCacheSize {
+		c.keys = map[string][]byte{}
+	}
+	c.keys[dataKeyCacheKey(userID, version)] = key
+}
+
+func dataKeyCacheKey(userID string, version int) string {
+	return userID + "/" + strconv.Itoa(version)
+}
+
+// calibrationAAD binds a sealed list to its owner and field, so it cannot be
+// copied into another user's persona or another column.
+func calibrationAAD(userID string, field PersonaListField) []byte {
+	return []byte("persona-calibration/" + userID + "/" + field.Name)
+}
+
+func parseSealedCalibration(raw []byte) (sealedCalibration, bool) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return sealedCalibration{}, false
+	}
+	var sealed sealedCalibration
+	if err := json.Unmarshal(trimmed, &sealed); err != nil || len(sealed.Ciphertext) == 0 || sealed.UserID == "" {
+		return sealedCalibration{}, false
+	}
+	return sealed, true
+}
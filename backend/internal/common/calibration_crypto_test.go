@@ -0,0 +1,175 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/envelope"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type fakeDataKey struct {
+	masterKeyID string
+	wrapped     []byte
+}
+
+// fakeKeyStore keeps user_data_keys in memory, answering the few queries
+// CalibrationCipher sends.
+type fakeKeyStore struct {
+	keys map[string][]fakeDataKey
+}
+
+type fakeRow struct {
+	values []any
+	err    error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i, value := range r.values {
+		switch target := dest[i].(type) {
+		case *int:
+			*target = value.(int)
+		case *string:
+			*target = value.(string)
+		case *[]byte:
+			*target = value.([]byte)
+		}
+	}
+	return nil
+}
+
+func (s *fakeKeyStore) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	userID := args[0].(string)
+	versions := s.keys[userID]
+	switch {
+	case strings.Contains(sql, "RETURNING version"):
+		s.keys[userID] = append(versions, fakeDataKey{masterKeyID: args[1].(string), wrapped: args[2].([]byte)})
+		return fakeRow{values: []any{len(s.keys[userID])}}
+	case strings.Contains(sql, "ORDER BY version DESC"):
+		if len(versions) == 0 {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		latest := versions[len(versions)-1]
+		return fakeRow{values: []any{len(versions), latest.masterKeyID, latest.wrapped}}
+	default:
+		version := args[1].(int)
+		if version < 1 || version > len(versions) {
+			return fakeRow{err: pgx.ErrNoRows}
+		}
+		key := versions[version-1]
+		return fakeRow{values: []any{key.masterKeyID, key.wrapped}}
+	}
+}
+
+func (s *fakeKeyStore) Exec(_ context.Context, _ string, args ...any) (pgconn.CommandTag, error) {
+	userID := args[0].(string)
+	if len(s.keys[userID]) == 0 {
+		s.keys[userID] = []fakeDataKey{{masterKeyID: args[1].(string), wrapped: args[2].([]byte)}}
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func testKeyProvider(t *testing.T) envelope.KeyProvider {
+	t.Helper()
+	provider, err := envelope.ParseLocalKeys("k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, envelope.DataKeySize)))
+	if err != nil {
+		t.Fatalf("parse keys: %v", err)
+	}
+	return provider
+}
+
+func TestCalibrationCipherRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeKeyStore{keys: map[string][]fakeDataKey{}}
+	cipher := NewCalibrationCipher(testKeyProvider(t))
+
+	plain, err := DoNotSayField.Encode([]string{"my home address", "my employer"})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	sealed, err := cipher.Seal(ctx, store, "user-1", DoNotSayField, plain)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("employer")) {
+		t.Fatalf("sealed list leaks plaintext: %s", sealed)
+	}
+	if version, ok := SealedCalibrationKeyVersion(sealed); !ok || version != 1 {
+		t.Fatalf("expected data key version 1, got %d %v", version, ok)
+	}
+
+	// A fresh cipher has to unwrap the stored key instead of using its cache.
+	items, err := NewCalibrationCipher(testKeyProvider(t)).Decode(ctx, store, DoNotSayField, sealed)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(items) != 2 || items[1] != "my employer" {
+		t.Fatalf("unexpected items %v", items)
+	}
+
+	if _, err := cipher.Open(ctx, store, CatchphrasesField, sealed); err == nil {
+		t.Fatalf("expected a list sealed for do_not_say not to open as catchphrases")
+	}
+}
+
+func TestCalibrationCipherRotateDataKey(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeKeyStore{keys: map[string][]fakeDataKey{}}
+	cipher := NewCalibrationCipher(testKeyProvider(t))
+	plain := []byte(`["Ship small"]`)
+
+	before, err := cipher.Seal(ctx, store, "user-1", CatchphrasesField, plain)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	version, err := cipher.RotateDataKey(ctx, store, "user-1")
+	if err != nil || version != 2 {
+		t.Fatalf("rotate: version %d, %v", version, err)
+	}
+	after, err := cipher.Seal(ctx, store, "user-1", CatchphrasesField, plain)
+	if err != nil {
+		t.Fatalf("seal after rotation: %v", err)
+	}
+	if got, _ := SealedCalibrationKeyVersion(after); got != 2 {
+		t.Fatalf("expected new writes to use version 2, got %d", got)
+	}
+	for _, raw := range [][]byte{before, after} {
+		opened, err := cipher.Open(ctx, store, CatchphrasesField, raw)
+		if err != nil || !bytes.Equal(opened, plain) {
+			t.Fatalf("open: %q, %v", opened, err)
+		}
+	}
+}
+
+func TestCalibrationCipherWithoutKeys(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeKeyStore{keys: map[string][]fakeDataKey{}}
+	disabled := NewCalibrationCipher(nil)
+	plain := []byte(`["Ship small","Measure outcomes","Ask one sharp question"]`)
+
+	stored, err := disabled.Seal(ctx, store, "user-1", WritingSamplesField, plain)
+	if err != nil || !bytes.Equal(stored, plain) {
+		t.Fatalf("expected plaintext to be stored as is, got %s, %v", stored, err)
+	}
+	items, err := disabled.Decode(ctx, store, WritingSamplesField, stored)
+	if err != nil || len(items) != 3 {
+		t.Fatalf("decode plaintext: %v, %v", items, err)
+	}
+
+	sealed, err := NewCalibrationCipher(testKeyProvider(t)).Seal(ctx, store, "user-1", WritingSamplesField, plain)
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, err := disabled.Open(ctx, store, WritingSamplesField, sealed); !errors.Is(err, ErrCalibrationKeysMissing) {
+		t.Fatalf("expected missing keys error, got %v", err)
+	}
+}
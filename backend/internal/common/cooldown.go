@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RoomCooldown is a persona's posting cooldown in one room. Window is zero
+// when the room has cooldowns disabled.
+type RoomCooldown struct {
+	RoomID            string        `json:"room_id"`
+	PersonaID         string        `json:"persona_id"`
+	Window            time.Duration `json:"-"`
+	WindowMinutes     int           `json:"cooldown_minutes"`
+	LastPostAt        *time.Time    `json:"last_post_at,omitempty"`
+	AvailableAt       *time.Time    `json:"available_at,omitempty"`
+	RetryAfterSeconds int           `json:"retry_after_seconds"`
+	Active            bool          `json:"active"`
+}
+
+// EvaluateRoomCooldown works out whether a persona that last published at
+// lastPostAt may publish again at now.
+func EvaluateRoomCooldown(roomID, personaID string, window time.Duration, lastPostAt *time.Time, now time.Time) RoomCooldown {
+	cooldown := RoomCooldown{
+		RoomID:        roomID,
+		PersonaID:     personaID,
+		Window:        window,
+		WindowMinutes: int(window / time.Minute),
+		LastPostAt:    lastPostAt,
+	}
+	if window <= 0 || lastPostAt == nil {
+		return cooldown
+	}
+	availableAt := lastPostAt.Add(window)
+	if !availableAt.After(now) {
+		return cooldown
+	}
+	cooldown.Active = true
+	cooldown.AvailableAt = &availableAt
+	cooldown.RetryAfterSeconds = int(math.Ceil(availableAt.Sub(now).Seconds()))
+	return cooldown
+}
+
+// LoadRoomCooldown reads the room's cooldown override (falling back to
+// defaultWindow) and the persona's latest published post in that room.
+func LoadRoomCooldown(ctx context.Context, querier DBQuerier, roomID, personaID string, defaultWindow time.Duration, now time.Time) (RoomCooldown, error) {
+	var (
+		overrideMinutes *int
+		lastPostAt      *time.Time
+	)
+	err := querier.QueryRow(ctx, `
+		SELECT
+			rm.post_cooldown_minutes,
+			(
+				SELECT MAX(p.published_at)
+				FROM posts p
+				WHERE p.room_id = rm.id
+				  AND p.persona_id = $2
+				  AND p.status = 'PUBLISHED'
+			)
+		FROM rooms rm
+		WHERE rm.id = $1
+	`, roomID, personaID).Scan(&overrideMinutes, &lastPostAt)
+	if err != nil {
+		return RoomCooldown{}, err
+	}
+	return EvaluateRoomCooldown(roomID, personaID, EffectiveRoomCooldown(overrideMinutes, defaultWindow), lastPostAt, now), nil
+}
+
+// EffectiveRoomCooldown applies a room's override; nil means the default and
+// zero disables the cooldown for that room.
+func EffectiveRoomCooldown(overrideMinutes *int, defaultWindow time.Duration) time.Duration {
+	if overrideMinutes == nil {
+		if defaultWindow < 0 {
+			return 0
+		}
+		return defaultWindow
+	}
+	if *overrideMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(*overrideMinutes) * time.Minute
+}
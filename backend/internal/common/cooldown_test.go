@@ -0,0 +1,44 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateRoomCooldown(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-90 * time.Minute)
+
+	cooldown := EvaluateRoomCooldown("room", "persona", 4*time.Hour, &recent, now)
+	if !cooldown.Active || cooldown.AvailableAt == nil || !cooldown.AvailableAt.Equal(recent.Add(4*time.Hour)) {
+		t.Fatalf("expected active cooldown until %s, got %+v", recent.Add(4*time.Hour), cooldown)
+	}
+	if cooldown.RetryAfterSeconds != int((150*time.Minute).Seconds()) || cooldown.WindowMinutes != 240 {
+		t.Fatalf("unexpected cooldown timing %+v", cooldown)
+	}
+
+	old := now.Add(-4 * time.Hour)
+	if cooldown := EvaluateRoomCooldown("room", "persona", 4*time.Hour, &old, now); cooldown.Active {
+		t.Fatalf("expected cooldown to end exactly at the window, got %+v", cooldown)
+	}
+	if cooldown := EvaluateRoomCooldown("room", "persona", 4*time.Hour, nil, now); cooldown.Active {
+		t.Fatalf("expected no cooldown without previous posts")
+	}
+	if cooldown := EvaluateRoomCooldown("room", "persona", 0, &recent, now); cooldown.Active {
+		t.Fatalf("expected disabled cooldown to be inactive")
+	}
+}
+
+func TestEffectiveRoomCooldown(t *testing.T) {
+	zero := 0
+	thirty := 30
+	if got := EffectiveRoomCooldown(nil, 4*time.Hour); got != 4*time.Hour {
+		t.Fatalf("expected default window, got %s", got)
+	}
+	if got := EffectiveRoomCooldown(&zero, 4*time.Hour); got != 0 {
+		t.Fatalf("expected override 0 to disable, got %s", got)
+	}
+	if got := EffectiveRoomCooldown(&thirty, 0); got != 30*time.Minute {
+		t.Fatalf("expected room override to apply with default disabled, got %s", got)
+	}
+}
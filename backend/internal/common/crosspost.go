@@ -0,0 +1,27 @@
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const JobCrosspost = "crosspost"
+
+// CrosspostJobPayload is what the API stores on a crosspost job. The post
+// and persona are the job's own columns.
+type CrosspostJobPayload struct {
+	UserID   string `json:"user_id"`
+	Provider string `json:"provider"`
+	TraceID  string `json:"trace_id,omitempty"`
+}
+
+// CrosspostBacklink links a cross-posted post back to its place on the
+// persona's public profile, tagged with the network it was shared to.
+func CrosspostBacklink(frontendOrigin, slug, postID, provider string) string {
+	query := url.Values{
+		"utm_source": {provider},
+		"utm_medium": {"crosspost"},
+	}
+	return fmt.Sprintf("%s/p/%s?%s#post-%s", strings.TrimRight(frontendOrigin, "/"), url.PathEscape(slug), query.Encode(), postID)
+}
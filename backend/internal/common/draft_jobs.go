@@ -0,0 +1,15 @@
+package common
+
+const JobGenerateDraft = "generate_draft"
+
+// DraftJobPayload is what the API stores on a generate_draft job so the
+// worker can produce the same draft the request would have. The worker's
+// roster scheduler queues the same jobs.
+type DraftJobPayload struct {
+	UserID  string `json:"user_id"`
+	RoomID  string `json:"room_id"`
+	Mood    string `json:"mood,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+	// Source is JobSourceRoster for drafts the worker queued itself.
+	Source string `json:"source,omitempty"`
+}
@@ -0,0 +1,19 @@
+package common
+
+import (
+	"errors"
+	"slices"
+)
+
+// EventRetentionOptions are the windows, in days, users can keep their raw
+// analytics events and persona activity events for. 0 keeps them until the
+// account or persona is deleted.
+var EventRetentionOptions = []int{30, 90, 365}
+
+// ValidateEventRetentionDays checks a retention preference.
+func ValidateEventRetentionDays(days int) error {
+	if days == 0 || slices.Contains(EventRetentionOptions, days) {
+		return nil
+	}
+	return errors.New("event_retention_days must be 0, 30, 90 or 365")
+}
@@ -0,0 +1,16 @@
+package common
+
+import "testing"
+
+func TestValidateEventRetentionDays(t *testing.T) {
+	for _, days := range []int{0, 30, 90, 365} {
+		if err := ValidateEventRetentionDays(days); err != nil {
+			t.Fatalf("expected %d to be valid, got %v", days, err)
+		}
+	}
+	for _, days := range []int{-1, 1, 7, 60, 366} {
+		if err := ValidateEventRetentionDays(days); err == nil {
+			t.Fatalf("expected %d to be rejected", days)
+		}
+	}
+}
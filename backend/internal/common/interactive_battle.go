@@ -0,0 +1,142 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	JobInteractiveBattleTurn = "interactive_battle_turn"
+
+	InteractiveSideAI    = "AI"
+	InteractiveSideHuman = "HUMAN"
+
+	InteractiveStatusActive    = "ACTIVE"
+	InteractiveStatusCompleted = "COMPLETED"
+	InteractiveStatusExpired   = "EXPIRED"
+	InteractiveStatusFailed    = "FAILED"
+
+	// InteractiveHumanName labels the owner's turns wherever a persona name
+	// would be shown.
+	InteractiveHumanName = "Human"
+
+	minInteractiveTurns = 2
+	maxInteractiveTurns = 8
+)
+
+// InteractiveBattle is the turn state of a battle where an AI persona argues
+// one side and the owner writes the other.
+type InteractiveBattle struct {
+	PostID       string     `json:"battle_id"`
+	UserID       string     `json:"-"`
+	AIPersonaID  string     `json:"ai_persona_id"`
+	TotalTurns   int        `json:"total_turns"`
+	TurnsTaken   int        `json:"turns_taken"`
+	NextSide     string     `json:"next_side"`
+	Status       string     `json:"status"`
+	TurnDeadline *time.Time `json:"turn_deadline,omitempty"`
+}
+
+// InteractiveTurnCount derives the number of turns from the template's turn
+// count: capped so a human is not asked for too many turns, and even so both
+// sides get the same number of turns.
+func InteractiveTurnCount(templateTurns int) int {
+	turns := templateTurns
+	if turns < minInteractiveTurns {
+		turns = minInteractiveTurns
+	}
+	if turns > maxInteractiveTurns {
+		turns = maxInteractiveTurns
+	}
+	if turns%2 != 0 {
+		turns++
+	}
+	return turns
+}
+
+// AfterTurn returns the state after the side in NextSide has taken its turn.
+// Human turns get a deadline of humanTimeout from now.
+func (b InteractiveBattle) AfterTurn(now time.Time, humanTimeout time.Duration) InteractiveBattle {
+	next := b
+	next.TurnsTaken++
+	next.TurnDeadline = nil
+	if next.TurnsTaken >= next.TotalTurns {
+		next.Status = InteractiveStatusCompleted
+		return next
+	}
+	if b.NextSide == InteractiveSideAI {
+		next.NextSide = InteractiveSideHuman
+		deadline := now.Add(humanTimeout)
+		next.TurnDeadline = &deadline
+		return next
+	}
+	next.NextSide = InteractiveSideAI
+	return next
+}
+
+// AwaitingAI reports whether the worker should write the next turn.
+func (b InteractiveBattle) AwaitingAI() bool {
+	return b.Status == InteractiveStatusActive && b.NextSide == InteractiveSideAI
+}
+
+// LoadInteractiveBattle reads a battle's turn state. Pass a transaction and
+// forUpdate to lock the row while advancing it.
+func LoadInteractiveBattle(ctx context.Context, querier DBQuerier, postID string, forUpdate bool) (InteractiveBattle, error) {
+	query := `
+		SELECT post_id::text, user_id::text, COALESCE(ai_persona_id::text, ''), total_turns, turns_taken, next_side, status, turn_deadline
+		FROM interactive_battles
+		WHERE post_id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	var battle InteractiveBattle
+	err := querier.QueryRow(ctx, query, postID).Scan(
+		&battle.PostID,
+		&battle.UserID,
+		&battle.AIPersonaID,
+		&battle.TotalTurns,
+		&battle.TurnsTaken,
+		&battle.NextSide,
+		&battle.Status,
+		&battle.TurnDeadline,
+	)
+	return battle, err
+}
+
+func SaveInteractiveBattle(ctx context.Context, executor DBExecutor, battle InteractiveBattle) error {
+	_, err := executor.Exec(ctx, `
+		UPDATE interactive_battles
+		SET turns_taken = $2,
+			next_side = $3,
+			status = $4,
+			turn_deadline = $5,
+			updated_at = NOW()
+		WHERE post_id = $1
+	`, battle.PostID, battle.TurnsTaken, battle.NextSide, battle.Status, battle.TurnDeadline)
+	return err
+}
+
+// EnqueueInteractiveAITurn queues the job that writes the AI persona's next
+// turn.
+func EnqueueInteractiveAITurn(ctx context.Context, executor DBExecutor, battle InteractiveBattle, traceID string) error {
+	payloadMap := map[string]any{
+		"post_id":    battle.PostID,
+		"persona_id": battle.AIPersonaID,
+		"turn":       battle.TurnsTaken + 1,
+	}
+	if clean := strings.TrimSpace(traceID); clean != "" {
+		payloadMap["trace_id"] = clean
+	}
+	payload, err := json.Marshal(payloadMap)
+	if err != nil {
+		return err
+	}
+	_, err = executor.Exec(ctx, `
+		INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+		VALUES ($1, $2, $3, $4::jsonb, 'PENDING', NOW())
+	`, JobInteractiveBattleTurn, battle.PostID, battle.AIPersonaID, payload)
+	return err
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInteractiveTurnCount(t *testing.T) {
+	cases := map[int]int{0: 2, 2: 2, 3: 4, 6: 6, 7: 8, 12: 8}
+	for templateTurns, want := range cases {
+		if got := InteractiveTurnCount(templateTurns); got != want {
+			t.Fatalf("InteractiveTurnCount(%d) = %d, want %d", templateTurns, got, want)
+		}
+	}
+}
+
+func TestInteractiveBattleAlternatesSides(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	battle := InteractiveBattle{
+		TotalTurns: 4,
+		NextSide:   InteractiveSideAI,
+		Status:     InteractiveStatusActive,
+	}
+	if !battle.AwaitingAI() {
+		t.Fatal("expected a new battle to wait for the AI opening")
+	}
+
+	battle = battle.AfterTurn(now, time.Hour)
+	if battle.NextSide != InteractiveSideHuman || battle.TurnsTaken != 1 || battle.AwaitingAI() {
+		t.Fatalf("expected human turn after AI opening, got %+v", battle)
+	}
+	if battle.TurnDeadline == nil || !battle.TurnDeadline.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected human deadline one hour out, got %v", battle.TurnDeadline)
+	}
+
+	battle = battle.AfterTurn(now, time.Hour)
+	if battle.NextSide != InteractiveSideAI || battle.TurnDeadline != nil || !battle.AwaitingAI() {
+		t.Fatalf("expected AI turn without deadline, got %+v", battle)
+	}
+
+	battle = battle.AfterTurn(now, time.Hour).AfterTurn(now, time.Hour)
+	if battle.Status != InteractiveStatusCompleted || battle.TurnsTaken != 4 || battle.TurnDeadline != nil {
+		t.Fatalf("expected completed battle after four turns, got %+v", battle)
+	}
+	if battle.AwaitingAI() {
+		t.Fatal("expected completed battle not to wait for the AI")
+	}
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	LLMCanaryKindDraft = "draft"
+	LLMCanaryKindReply = "reply"
+
+	LLMCanaryPending = "PENDING"
+	LLMCanaryDone    = "DONE"
+)
+
+// LLMCanaryRun is a generation picked for shadowing by the candidate model.
+// Drafts set RoomID and PostID (the stored draft); replies set PostID and
+// ReplyID. PrimaryOutput is the text the primary model returned on its
+// first call, before any regeneration.
+type LLMCanaryRun struct {
+	Kind           string
+	PersonaID      string
+	RoomID         string
+	PostID         string
+	ReplyID        string
+	Mood           string
+	PrimaryModel   string
+	CandidateModel string
+	PrimaryOutput  string
+	PrimaryLatency time.Duration
+}
+
+// SampleLLMCanary picks percent out of every 100 calls at random.
+func SampleLLMCanary(percent int) bool {
+	return percent > 0 && rand.Intn(100) < percent
+}
+
+// QueueLLMCanaryRun stores run for the worker to shadow.
+func QueueLLMCanaryRun(ctx context.Context, db DBExecutor, run LLMCanaryRun) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO llm_canary_runs(kind, persona_id, room_id, post_id, reply_id, mood, primary_model, candidate_model, primary_output, primary_latency_ms)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6, $7, $8, $9, $10)
+	`, run.Kind, run.PersonaID, run.RoomID, run.PostID, run.ReplyID, run.Mood, run.PrimaryModel, run.CandidateModel, run.PrimaryOutput, run.PrimaryLatency.Milliseconds())
+	return err
+}
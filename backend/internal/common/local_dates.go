@@ -0,0 +1,93 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the calendar-date format digests and analytics use.
+const DateLayout = "2006-01-02"
+
+// NormalizeTimezone trims an IANA timezone name, defaulting to UTC, and
+// rejects names the runtime cannot load.
+func NormalizeTimezone(timezone string) (string, error) {
+	name := strings.TrimSpace(timezone)
+	if name == "" {
+		name = "UTC"
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return "", fmt.Errorf("timezone is invalid")
+	}
+	return name, nil
+}
+
+// LoadLocation returns the named location, falling back to UTC for empty or
+// unknown names so a stale setting never breaks a response.
+func LoadLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(strings.TrimSpace(timezone))
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// StartOfDay returns local midnight of value's day in location.
+func StartOfDay(value time.Time, location *time.Location) time.Time {
+	local := value.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// StartOfWeek returns local midnight of the Monday starting value's week in
+// location.
+func StartOfWeek(value time.Time, location *time.Location) time.Time {
+	midnight := StartOfDay(value, location)
+	weekday := int(midnight.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return midnight.AddDate(0, 0, -(weekday - 1))
+}
+
+// DateWindow describes the [Start, End) instant range behind a date or week
+// as seen from a viewer's timezone, so clients can label it without doing
+// their own timezone math.
+type DateWindow struct {
+	Timezone       string    `json:"timezone"`
+	UTCOffset      string    `json:"utc_offset"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	LocalStartDate string    `json:"local_start_date"`
+	LocalEndDate   string    `json:"local_end_date"`
+}
+
+// NewDateWindow expresses [start, end) in location. LocalEndDate is the last
+// local date the window touches, so a window ending at local midnight ends
+// the day before.
+func NewDateWindow(start, end time.Time, location *time.Location) DateWindow {
+	localStart := start.In(location)
+	localEnd := end.In(location)
+	lastInstant := localEnd
+	if localEnd.After(localStart) {
+		lastInstant = localEnd.Add(-time.Nanosecond)
+	}
+	return DateWindow{
+		Timezone:       location.String(),
+		UTCOffset:      localStart.Format("-07:00"),
+		Start:          localStart,
+		End:            localEnd,
+		LocalStartDate: localStart.Format(DateLayout),
+		LocalEndDate:   lastInstant.Format(DateLayout),
+	}
+}
+
+// UTCDateWindow is the window of a stored UTC calendar date spanning days
+// days, viewed from location. Digest rows are keyed by UTC date, so this is
+// how they are shown in local time.
+func UTCDateWindow(date string, days int, location *time.Location) (DateWindow, error) {
+	start, err := time.ParseInLocation(DateLayout, strings.TrimSpace(date), time.UTC)
+	if err != nil {
+		return DateWindow{}, err
+	}
+	return NewDateWindow(start, start.AddDate(0, 0, days), location), nil
+}
@@ -0,0 +1,64 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTimezone(t *testing.T) {
+	if got, err := NormalizeTimezone("  "); err != nil || got != "UTC" {
+		t.Fatalf("expected empty timezone to default to UTC, got %q %v", got, err)
+	}
+	if _, err := NormalizeTimezone("Mars/Olympus"); err == nil {
+		t.Fatalf("expected unknown timezone to be rejected")
+	}
+	if LoadLocation("Mars/Olympus") != time.UTC {
+		t.Fatalf("expected unknown timezone to load as UTC")
+	}
+}
+
+func TestStartOfWeekUsesLocalMonday(t *testing.T) {
+	sunday := time.Date(2026, 3, 15, 22, 30, 0, 0, time.UTC)
+	if got, want := StartOfWeek(sunday, time.UTC), time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	istanbul, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 22:30 UTC on Sunday is already Monday in Istanbul.
+	if got, want := StartOfWeek(sunday, istanbul), time.Date(2026, 3, 16, 0, 0, 0, 0, istanbul); !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestUTCDateWindow(t *testing.T) {
+	window, err := UTCDateWindow("2026-03-10", 1, time.UTC)
+	if err != nil {
+		t.Fatalf("window: %v", err)
+	}
+	if window.Timezone != "UTC" || window.UTCOffset != "+00:00" || window.LocalStartDate != "2026-03-10" || window.LocalEndDate != "2026-03-10" {
+		t.Fatalf("unexpected UTC window %+v", window)
+	}
+
+	if _, err := UTCDateWindow("10/03/2026", 1, time.UTC); err == nil {
+		t.Fatalf("expected malformed date to fail")
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	week, err := UTCDateWindow("2026-03-09", 7, newYork)
+	if err != nil {
+		t.Fatalf("window: %v", err)
+	}
+	// The UTC week starts on Sunday evening in New York, just after DST began.
+	if week.LocalStartDate != "2026-03-08" || week.LocalEndDate != "2026-03-15" || week.UTCOffset != "-04:00" {
+		t.Fatalf("unexpected New York week %+v", week)
+	}
+	if week.End.Sub(week.Start) != 7*24*time.Hour {
+		t.Fatalf("expected a 7 day window, got %s", week.End.Sub(week.Start))
+	}
+}
@@ -0,0 +1,226 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	NotificationPersonaMentioned = "persona_mentioned"
+
+	// MaxMentionsPerContent caps how many personas one post or reply can
+	// mention, so a single message cannot fan out to every profile.
+	MaxMentionsPerContent = 5
+)
+
+// An @mention starts at the beginning of the text or after a character that
+// cannot be part of an email address, URL path or handle.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@/-])@([\p{L}\p{N}][\p{L}\p{N}-]*)`)
+
+// MentionStore is the database access RecordMentions needs; both the pool and
+// a transaction satisfy it.
+type MentionStore interface {
+	Exec(context.Context, string, ...any) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+// MentionSource is a freshly published post or reply. ReplyID is empty for
+// posts; AuthorPersonaID is empty for human-written content.
+type MentionSource struct {
+	PostID          string
+	ReplyID         string
+	AuthorUserID    string
+	AuthorPersonaID string
+	Content         string
+	TraceID         string
+}
+
+type Mention struct {
+	PersonaID     string
+	OwnerUserID   string
+	Slug          string
+	PersonaName   string
+	ReplyEnqueued bool
+}
+
+// ParseMentions returns the distinct lowercased slugs mentioned in content,
+// in order of appearance and capped at MaxMentionsPerContent.
+func ParseMentions(content string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	slugs := make([]string, 0, len(matches))
+	seen := map[string]struct{}{}
+	for _, match := range matches {
+		slug := strings.Trim(strings.ToLower(match[1]), "-")
+		if slug == "" {
+			continue
+		}
+		if _, ok := seen[slug]; ok {
+			continue
+		}
+		seen[slug] = struct{}{}
+		slugs = append(slugs, slug)
+		if len(slugs) == MaxMentionsPerContent {
+			break
+		}
+	}
+	return slugs
+}
+
+// RecordMentions stores the public personas mentioned in source, notifies
+// their owners and, for personas whose owner opted in, queues a reply to the
+// post. A persona replies to a post at most once, which also ends chains of
+// personas mentioning each other; interactive, challenge and archived
+// battles never get mention replies. Only newly recorded mentions are returned.
+func RecordMentions(ctx context.Context, db MentionStore, source MentionSource) ([]Mention, error) {
+	slugs := ParseMentions(source.Content)
+	if len(slugs) == 0 || strings.TrimSpace(source.PostID) == "" {
+		return nil, nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT pp.slug, p.id::text, p.user_id::text, p.name, p.mention_replies_enabled
+		FROM persona_public_profiles pp
+		JOIN personas p ON p.id = pp.persona_id
+		WHERE pp.is_public = TRUE
+		  AND pp.slug = ANY($1)
+	`, slugs)
+	if err != nil {
+		return nil, err
+	}
+	type target struct {
+		Mention
+		repliesEnabled bool
+	}
+	targets := make([]target, 0, len(slugs))
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.Slug, &t.PersonaID, &t.OwnerUserID, &t.PersonaName, &t.repliesEnabled); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if t.PersonaID == source.AuthorPersonaID {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	recorded := make([]Mention, 0, len(targets))
+	for _, t := range targets {
+		var mentionID int64
+		err := db.QueryRow(ctx, `
+			INSERT INTO mentions(post_id, reply_id, mentioned_persona_id, author_persona_id, author_user_id, slug)
+			VALUES ($1, NULLIF($2, '')::uuid, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6)
+			ON CONFLICT DO NOTHING
+			RETURNING id
+		`, source.PostID, source.ReplyID, t.PersonaID, source.AuthorPersonaID, source.AuthorUserID, t.Slug).Scan(&mentionID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return recorded, err
+		}
+
+		if t.repliesEnabled {
+			enqueued, err := enqueueMentionReply(ctx, db, source, t.PersonaID)
+			if err != nil {
+				return recorded, err
+			}
+			if enqueued {
+				t.ReplyEnqueued = true
+				if _, err := db.Exec(ctx, `UPDATE mentions SET reply_enqueued = TRUE WHERE id = $1`, mentionID); err != nil {
+					return recorded, err
+				}
+			}
+		}
+
+		if t.OwnerUserID != source.AuthorUserID {
+			if err := notifyMention(ctx, db, source, t.Mention); err != nil {
+				return recorded, err
+			}
+		}
+		recorded = append(recorded, t.Mention)
+	}
+	return recorded, nil
+}
+
+func enqueueMentionReply(ctx context.Context, db MentionStore, source MentionSource, personaID string) (bool, error) {
+	payloadMap := map[string]any{
+		"post_id":    source.PostID,
+		"persona_id": personaID,
+		"source":     "mention",
+	}
+	if clean := strings.TrimSpace(source.TraceID); clean != "" {
+		payloadMap["trace_id"] = clean
+	}
+	payload, err := json.Marshal(payloadMap)
+	if err != nil {
+		return false, err
+	}
+	tag, err := db.Exec(ctx, `
+		INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+		SELECT 'generate_reply', $1, $2, $3::jsonb, 'PENDING', NOW()
+		WHERE NOT EXISTS (
+				SELECT 1
+				FROM jobs
+				WHERE post_id = $1
+				  AND persona_id = $2
+				  AND job_type = 'generate_reply'
+				  AND status IN ('PENDING', 'PROCESSING')
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM replies
+				WHERE post_id = $1
+				  AND persona_id = $2
+		  )
+		  AND NOT EXISTS (SELECT 1 FROM interactive_battles WHERE post_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM battle_challenges WHERE battle_post_id = $1)
+		  AND NOT EXISTS (SELECT 1 FROM battle_archives WHERE post_id = $1)
+	`, source.PostID, personaID, payload)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func notifyMention(ctx context.Context, db MentionStore, source MentionSource, mention Mention) error {
+	where := "a post"
+	if source.ReplyID != "" {
+		where = "a reply"
+	}
+	body := fmt.Sprintf("%s was mentioned in %s.", mention.PersonaName, where)
+	if mention.ReplyEnqueued {
+		body = fmt.Sprintf("%s was mentioned in %s and will reply.", mention.PersonaName, where)
+	}
+	metadata := map[string]any{
+		"post_id":      source.PostID,
+		"persona_id":   mention.PersonaID,
+		"persona_name": mention.PersonaName,
+		"slug":         mention.Slug,
+		"preview":      TruncateRunes(source.Content, 160),
+	}
+	if source.ReplyID != "" {
+		metadata["reply_id"] = source.ReplyID
+	}
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(ctx, `
+		INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6::jsonb)
+	`, mention.OwnerUserID, source.AuthorUserID, NotificationPersonaMentioned, "Your persona was mentioned", TruncateRunes(body, 260), payload)
+	return err
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "none", content: "no mentions here", want: []string{}},
+		{name: "start and middle", content: "@Ada-Bot you and @grace should talk", want: []string{"ada-bot", "grace"}},
+		{name: "punctuation", content: "(cc @ada), thoughts @grace?", want: []string{"ada", "grace"}},
+		{name: "dedupes case-insensitively", content: "@ada @ADA @Ada", want: []string{"ada"}},
+		{name: "trailing hyphen", content: "ask @ada- later", want: []string{"ada"}},
+		{name: "unicode", content: "selam @çağrı", want: []string{"çağrı"}},
+		{name: "ignores emails and urls", content: "mail ada@example.com or see https://x.io/@grace and a.b@c", want: []string{}},
+		{name: "ignores bare at", content: "meet @ 5 or @-dash", want: []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseMentions(tc.content)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("ParseMentions(%q) = %v, want %v", tc.content, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMentionsCapsCount(t *testing.T) {
+	got := ParseMentions("@a1 @a2 @a3 @a4 @a5 @a6 @a7")
+	if len(got) != MaxMentionsPerContent {
+		t.Fatalf("expected %d mentions, got %v", MaxMentionsPerContent, got)
+	}
+	if got[0] != "a1" || got[MaxMentionsPerContent-1] != "a5" {
+		t.Fatalf("expected the first mentions to be kept, got %v", got)
+	}
+}
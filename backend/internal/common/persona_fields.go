@@ -0,0 +1,90 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PersonaListField describes one of the persona calibration lists stored as
+// a jsonb array of strings. Migration 044 applies the same canonical form to
+// rows written before these rules existed.
+type PersonaListField struct {
+	Name     string
+	MinItems int
+	MaxItems int
+	MaxRunes int
+}
+
+var (
+	WritingSamplesField = PersonaListField{Name: "writing_samples", MinItems: 3, MaxItems: 3, MaxRunes: 180}
+	DoNotSayField       = PersonaListField{Name: "do_not_say", MaxItems: 20, MaxRunes: 120}
+	CatchphrasesField   = PersonaListField{Name: "catchphrases", MaxItems: 10, MaxRunes: 80}
+)
+
+// Canonicalize trims items, collapses inner whitespace, drops empty items and
+// case-insensitive duplicates, keeping the first spelling in order.
+func (f PersonaListField) Canonicalize(items []string) []string {
+	out := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		clean := strings.Join(strings.Fields(item), " ")
+		if clean == "" {
+			continue
+		}
+		key := strings.ToLower(clean)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, clean)
+	}
+	return out
+}
+
+// Validate canonicalizes items and checks the item count and length limits.
+func (f PersonaListField) Validate(items []string) ([]string, error) {
+	clean := f.Canonicalize(items)
+	if f.MinItems > 0 && f.MinItems == f.MaxItems && len(clean) != f.MinItems {
+		return nil, fmt.Errorf("%s must contain exactly %d distinct items", f.Name, f.MinItems)
+	}
+	if len(clean) < f.MinItems {
+		return nil, fmt.Errorf("%s must contain at least %d distinct items", f.Name, f.MinItems)
+	}
+	if f.MaxItems > 0 && len(clean) > f.MaxItems {
+		return nil, fmt.Errorf("%s must contain at most %d items", f.Name, f.MaxItems)
+	}
+	for _, item := range clean {
+		if len([]rune(item)) > f.MaxRunes {
+			return nil, fmt.Errorf("%s items must be <= %d chars", f.Name, f.MaxRunes)
+		}
+	}
+	return clean, nil
+}
+
+// Encode validates items and returns the jsonb document to store.
+func (f PersonaListField) Encode(items []string) ([]byte, error) {
+	clean, err := f.Validate(items)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(clean)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", f.Name, err)
+	}
+	return raw, nil
+}
+
+// Decode reads a stored list. Anything but an array of strings is an error;
+// the items come back canonicalized but are not held to the count limits, so
+// a persona whose stored samples predate them still loads.
+func (f PersonaListField) Decode(raw []byte) ([]string, error) {
+	if len(raw) == 0 {
+		return []string{}, nil
+	}
+	var items []string
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", f.Name, err)
+	}
+	return f.Canonicalize(items), nil
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPersonaListFieldCanonicalize(t *testing.T) {
+	got := DoNotSayField.Canonicalize([]string{"  hype   words ", "", "Hype Words", "\tgrind\n", "   "})
+	want := []string{"hype words", "grind"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPersonaListFieldValidate(t *testing.T) {
+	if _, err := WritingSamplesField.Validate([]string{"one", "two", "One"}); err == nil || !strings.Contains(err.Error(), "exactly 3 distinct") {
+		t.Fatalf("expected duplicate samples to be rejected, got %v", err)
+	}
+	if got, err := WritingSamplesField.Validate([]string{" one ", "two", "three"}); err != nil || got[0] != "one" {
+		t.Fatalf("expected trimmed samples, got %v %v", got, err)
+	}
+
+	tooMany := make([]string, CatchphrasesField.MaxItems+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("x", i+1)
+	}
+	if _, err := CatchphrasesField.Validate(tooMany); err == nil {
+		t.Fatalf("expected too many catchphrases to be rejected")
+	}
+	if _, err := CatchphrasesField.Validate([]string{strings.Repeat("é", CatchphrasesField.MaxRunes+1)}); err == nil {
+		t.Fatalf("expected long catchphrase to be rejected")
+	}
+	if got, err := CatchphrasesField.Validate(nil); err != nil || len(got) != 0 {
+		t.Fatalf("expected empty catchphrases to be valid, got %v %v", got, err)
+	}
+}
+
+func TestPersonaListFieldEncodeDecode(t *testing.T) {
+	raw, err := DoNotSayField.Encode([]string{"a", "A", " b "})
+	if err != nil || string(raw) != `["a","b"]` {
+		t.Fatalf("unexpected encoding %s %v", raw, err)
+	}
+	if raw, err := DoNotSayField.Encode(nil); err != nil || string(raw) != `[]` {
+		t.Fatalf("expected empty list to encode as [], got %s %v", raw, err)
+	}
+
+	got, err := DoNotSayField.Decode([]byte(`["x"," x ","y"]`))
+	if err != nil || !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Fatalf("unexpected decode %v %v", got, err)
+	}
+	if got, err := DoNotSayField.Decode(nil); err != nil || len(got) != 0 {
+		t.Fatalf("expected empty decode, got %v %v", got, err)
+	}
+	for _, raw := range []string{`{"a":1}`, `[1,2]`, `"x"`} {
+		if _, err := DoNotSayField.Decode([]byte(raw)); err == nil {
+			t.Fatalf("expected %s to be rejected", raw)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PersonaImportPending = "PENDING"
+	PersonaImportDone    = "DONE"
+
+	PersonaImportRowCreated = "created"
+	PersonaImportRowSkipped = "skipped"
+	PersonaImportRowError   = "error"
+	PersonaImportRowPending = "pending"
+
+	// PersonaImportMaxRows is the most personas one import can define.
+	PersonaImportMaxRows = 50
+	// PersonaImportChunkSize is how many personas are created per
+	// transaction, in the request and in the worker.
+	PersonaImportChunkSize = 10
+)
+
+// PersonaImportResult is the outcome of one row of an import. Row numbers
+// start at 1 and follow the order of the request (CSV data rows, not
+// counting the header).
+type PersonaImportResult struct {
+	Row       int    `json:"row"`
+	Name      string `json:"name,omitempty"`
+	Status    string `json:"status"`
+	PersonaID string `json:"persona_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PersonaImportRow is a validated persona waiting to be created. The
+// calibration lists are the jsonb documents to store, already sealed when
+// calibration encryption is on.
+type PersonaImportRow struct {
+	Row                int             `json:"row"`
+	Name               string          `json:"name"`
+	Bio                string          `json:"bio"`
+	Tone               string          `json:"tone"`
+	WritingSamples     json.RawMessage `json:"writing_samples"`
+	DoNotSay           json.RawMessage `json:"do_not_say"`
+	Catchphrases       json.RawMessage `json:"catchphrases"`
+	PreferredLanguage  string          `json:"preferred_language"`
+	Formality          int             `json:"formality"`
+	DailyDraftQuota    int             `json:"daily_draft_quota"`
+	DailyReplyQuota    int             `json:"daily_reply_quota"`
+	ActiveHoursStart   *int            `json:"active_hours_start,omitempty"`
+	ActiveHoursEnd     *int            `json:"active_hours_end,omitempty"`
+	Timezone           string          `json:"timezone"`
+	StyleHumor         int             `json:"style_humor"`
+	StyleAssertiveness int             `json:"style_assertiveness"`
+	StyleTechnicality  int             `json:"style_technicality"`
+	StyleBrevity       int             `json:"style_brevity"`
+}
+
+// PersonaImportCounts tallies results by status.
+func PersonaImportCounts(results []PersonaImportResult) map[string]int {
+	counts := map[string]int{
+		PersonaImportRowCreated: 0,
+		PersonaImportRowSkipped: 0,
+		PersonaImportRowError:   0,
+		PersonaImportRowPending: 0,
+	}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// CreateImportedPersonas creates rows for userID inside tx and returns one
+// result per row. A row is skipped when the user already has a persona with
+// the same name, compared case-insensitively. Each row runs in its own
+// savepoint, so one failing insert marks that row as an error without
+// losing the rest of the chunk. Created personas log persona_created.
+func CreateImportedPersonas(ctx context.Context, tx pgx.Tx, userID string, rows []PersonaImportRow) ([]PersonaImportResult, error) {
+	results := make([]PersonaImportResult, 0, len(rows))
+	for _, row := range rows {
+		result := PersonaImportResult{Row: row.Row, Name: row.Name}
+
+		var exists bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS(
+				SELECT 1
+				FROM personas
+				WHERE user_id = $1
+				  AND LOWER(name) = LOWER($2)
+			)
+		`, userID, strings.TrimSpace(row.Name)).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			result.Status = PersonaImportRowSkipped
+			result.Error = fmt.Sprintf("a persona named %q already exists", row.Name)
+			results = append(results, result)
+			continue
+		}
+
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = savepoint.QueryRow(ctx, `
+			INSERT INTO personas(user_id, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone, style_humor, style_assertiveness, style_technicality, style_brevity, style_source)
+			VALUES ($1, $2, $3, $4, $5::jsonb, $6::jsonb, $7::jsonb, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, 'manual')
+			RETURNING id::text
+		`, userID, row.Name, row.Bio, row.Tone, []byte(row.WritingSamples), []byte(row.DoNotSay), []byte(row.Catchphrases), row.PreferredLanguage, row.Formality, row.DailyDraftQuota, row.DailyReplyQuota, row.ActiveHoursStart, row.ActiveHoursEnd, row.Timezone, row.StyleHumor, row.StyleAssertiveness, row.StyleTechnicality, row.StyleBrevity).Scan(&result.PersonaID)
+		if err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+			result.Status = PersonaImportRowError
+			result.Error = "could not create persona"
+			results = append(results, result)
+			continue
+		}
+		// The same analytics event a single create logs.
+		if _, err := savepoint.Exec(ctx, `
+			INSERT INTO events(user_id, event_name, metadata)
+			VALUES ($1, 'persona_created', jsonb_build_object('persona_id', $2::text))
+		`, userID, result.PersonaID); err != nil {
+			return nil, err
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, err
+		}
+		result.Status = PersonaImportRowCreated
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// MergePersonaImportResults replaces the results in all that have the same
+// row number as one of updates.
+func MergePersonaImportResults(all, updates []PersonaImportResult) []PersonaImportResult {
+	byRow := make(map[int]PersonaImportResult, len(updates))
+	for _, update := range updates {
+		byRow[update.Row] = update
+	}
+	merged := make([]PersonaImportResult, len(all))
+	for i, result := range all {
+		if update, ok := byRow[result.Row]; ok {
+			result = update
+		}
+		merged[i] = result
+	}
+	return merged
+}
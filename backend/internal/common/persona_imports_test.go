@@ -0,0 +1,26 @@
+package common
+
+import "testing"
+
+func TestMergePersonaImportResults(t *testing.T) {
+	all := []PersonaImportResult{
+		{Row: 1, Name: "Ada", Status: PersonaImportRowPending},
+		{Row: 2, Name: "Bob", Status: PersonaImportRowError, Error: "name is required"},
+		{Row: 3, Name: "Cem", Status: PersonaImportRowPending},
+	}
+	merged := MergePersonaImportResults(all, []PersonaImportResult{
+		{Row: 3, Name: "Cem", Status: PersonaImportRowSkipped, Error: "exists"},
+		{Row: 1, Name: "Ada", Status: PersonaImportRowCreated, PersonaID: "p1"},
+	})
+	if merged[0].PersonaID != "p1" || merged[1].Status != PersonaImportRowError || merged[2].Status != PersonaImportRowSkipped {
+		t.Fatalf("unexpected merge: %+v", merged)
+	}
+	if all[0].Status != PersonaImportRowPending {
+		t.Fatalf("expected merge to leave its input alone")
+	}
+
+	counts := PersonaImportCounts(merged)
+	if counts[PersonaImportRowCreated] != 1 || counts[PersonaImportRowSkipped] != 1 || counts[PersonaImportRowError] != 1 || counts[PersonaImportRowPending] != 0 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	PostStatusUnpublish = "unpublish"
+	PostStatusRepublish = "republish"
+
+	PostStatusBatchPending = "PENDING"
+	PostStatusBatchDone    = "DONE"
+
+	// PostStatusBatchChunkSize is how many posts the worker changes per
+	// transaction when it applies a large batch.
+	PostStatusBatchChunkSize = 100
+)
+
+// ApplyPostStatusChange unpublishes or republishes the posts in postIDs that
+// belong to personaID and records an activity event for each post it
+// changes. Posts already in the requested state, drafts that were never
+// published, and posts in archived rooms are skipped. Bumping updated_at
+// also invalidates cached battle cards, whose keys include it.
+func ApplyPostStatusChange(ctx context.Context, tx pgx.Tx, personaID, action string, batchID int64, postIDs []string) (int, error) {
+	query := `
+		UPDATE posts p
+		SET status = 'DRAFT', unpublished_at = NOW(), updated_at = NOW()
+		WHERE p.id = ANY($1::uuid[])
+		  AND p.persona_id = $2
+		  AND p.status = 'PUBLISHED'
+		RETURNING p.id::text, p.room_id::text
+	`
+	eventType := "post_unpublished"
+	if action == PostStatusRepublish {
+		query = `
+			UPDATE posts p
+			SET status = 'PUBLISHED', unpublished_at = NULL, updated_at = NOW()
+			FROM rooms rm
+			WHERE rm.id = p.room_id
+			  AND p.id = ANY($1::uuid[])
+			  AND p.persona_id = $2
+			  AND p.status = 'DRAFT'
+			  AND p.unpublished_at IS NOT NULL
+			  AND rm.archived_at IS NULL
+			RETURNING p.id::text, p.room_id::text
+		`
+		eventType = "post_republished"
+	}
+
+	rows, err := tx.Query(ctx, query, postIDs, personaID)
+	if err != nil {
+		return 0, err
+	}
+	type changedPost struct {
+		id     string
+		roomID string
+	}
+	changed := make([]changedPost, 0, len(postIDs))
+	for rows.Next() {
+		var post changedPost
+		if err := rows.Scan(&post.id, &post.roomID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		changed = append(changed, post)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, post := range changed {
+		if err := InsertPersonaActivityEvent(ctx, tx, personaID, eventType, map[string]any{
+			"post_id":  post.id,
+			"room_id":  post.roomID,
+			"batch_id": batchID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
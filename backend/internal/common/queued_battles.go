@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	JobCreateBattle = "create_battle"
+
+	QueuedBattleQueued   = "QUEUED"
+	QueuedBattleCreated  = "CREATED"
+	QueuedBattleCanceled = "CANCELED"
+	QueuedBattleFailed   = "FAILED"
+)
+
+// QueuedBattleJobPayload points a create_battle job at its queued_battles
+// row, which holds the validated request.
+type QueuedBattleJobPayload struct {
+	QueuedBattleID int64  `json:"queued_battle_id"`
+	TraceID        string `json:"trace_id,omitempty"`
+}
+
+// BattlesCreatedToday counts the battles userID opened in the current daily
+// window, which resets at midnight UTC like the persona quotas.
+func BattlesCreatedToday(ctx context.Context, db DBQuerier, userID string) (int, error) {
+	var used int
+	err := db.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM posts
+		WHERE user_id = $1
+		  AND template_id IS NOT NULL
+		  AND created_at >= date_trunc('day', NOW())
+	`, userID).Scan(&used)
+	return used, err
+}
+
+// BattleDailyLimit is userID's battle limit for today: the configured base
+// plus active battle boosts. Zero means unlimited.
+func BattleDailyLimit(ctx context.Context, db DBQuerier, userID string, base int) (int, error) {
+	if base <= 0 {
+		return 0, nil
+	}
+	boost, err := ActiveQuotaBoost(ctx, db, userID, QuotaBoostBattle)
+	if err != nil {
+		return 0, err
+	}
+	return base + boost, nil
+}
+
+// NextBattleWindowReset is the start of the daily window after now's.
+func NextBattleWindowReset(now time.Time) time.Time {
+	return StartOfDay(now.UTC(), time.UTC).AddDate(0, 0, 1)
+}
+
+// BattleOpeningContent is the text of a battle's opening post, which reply
+// prompts quote as the post being answered.
+func BattleOpeningContent(topic, templateName, proStyle, conStyle string, maxLen int) string {
+	content := fmt.Sprintf(
+		"Topic: %s\nTemplate: %s\nPro style: %s\nCon style: %s\n\nBattle opening: keep arguments concise and evidence-based.",
+		topic,
+		templateName,
+		proStyle,
+		conStyle,
+	)
+	return TruncateRunes(content, maxLen)
+}
@@ -0,0 +1,24 @@
+package common
+
+import "context"
+
+// Quota types a quota_boosts row can raise.
+const (
+	QuotaBoostDraft  = "draft"
+	QuotaBoostBattle = "battle"
+)
+
+// ActiveQuotaBoost is how much userID's active boosts of quotaType add to
+// the base daily limit. Users without boosts read as zero.
+func ActiveQuotaBoost(ctx context.Context, db DBQuerier, userID, quotaType string) (int, error) {
+	var total int
+	err := db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)::int
+		FROM quota_boosts
+		WHERE user_id = $1
+		  AND quota_type = $2
+		  AND starts_at <= NOW()
+		  AND expires_at > NOW()
+	`, userID, quotaType).Scan(&total)
+	return total, err
+}
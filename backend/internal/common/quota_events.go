@@ -0,0 +1,47 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	QuotaTypeDraft            = "draft"
+	QuotaTypeReply            = "reply"
+	QuotaTypePreview          = "preview"
+	QuotaTypeEvaluation       = "evaluation"
+	QuotaTypeDigestRegenerate = "digest_regenerate"
+)
+
+// QuotaEvent is one use of a persona's daily quota. Source is the route
+// pattern or job type that spent it and ContentID the post, reply or
+// evaluation it produced, so a disputed count can be traced to what was
+// created. Metadata carries the request or trace id and other context.
+type QuotaEvent struct {
+	PersonaID string
+	Type      string
+	Source    string
+	ContentID string
+	Metadata  map[string]any
+}
+
+func InsertQuotaEvent(ctx context.Context, executor DBExecutor, event QuotaEvent) error {
+	metadata := map[string]any{}
+	for key, value := range event.Metadata {
+		if text, ok := value.(string); ok && strings.TrimSpace(text) == "" {
+			continue
+		}
+		metadata[key] = value
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = executor.Exec(ctx, `
+		INSERT INTO quota_events(persona_id, quota_type, source, content_id, metadata)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+	`, event.PersonaID, event.Type, strings.TrimSpace(event.Source), strings.TrimSpace(event.ContentID), raw)
+	return err
+}
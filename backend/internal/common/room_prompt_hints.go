@@ -0,0 +1,44 @@
+package common
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RoomPromptHint is the moderator guidance a room adds to draft and reply
+// prompts. ID is zero when the room has no active hint.
+type RoomPromptHint struct {
+	ID        int64
+	Version   int
+	Emphasize string
+	Structure string
+}
+
+// LoadRoomPromptHint reads the active prompt hint of roomID. Rooms without
+// one, including unknown rooms, read as the zero hint.
+func LoadRoomPromptHint(ctx context.Context, db DBQuerier, roomID string) (RoomPromptHint, error) {
+	var hint RoomPromptHint
+	err := db.QueryRow(ctx, `
+		SELECT h.id, h.version, h.emphasize, h.structure
+		FROM rooms r
+		JOIN room_prompt_hints h ON h.id = r.prompt_hint_id
+		WHERE r.id = $1
+	`, roomID).Scan(&hint.ID, &hint.Version, &hint.Emphasize, &hint.Structure)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return RoomPromptHint{}, nil
+	}
+	return hint, err
+}
+
+// Metadata is how a generated reply records the hint it was written with.
+func (h RoomPromptHint) Metadata() map[string]any {
+	if h.ID == 0 {
+		return nil
+	}
+	return map[string]any{
+		"prompt_hint_id":      h.ID,
+		"prompt_hint_version": h.Version,
+	}
+}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+// Limits of a room roster enrollment. The weekly caps match the checks on
+// room_roster_enrollments.
+const (
+	RosterMaxWeeklyPosts     = 21
+	RosterMaxWeeklyReplies   = 70
+	RosterMaxRoomsPerPersona = 10
+
+	// JobSourceRoster marks jobs the worker queued to meet a roster target.
+	JobSourceRoster = "roster"
+)
+
+const rosterWeek = 7 * 24 * time.Hour
+
+// RosterWeekStart is the start of the UTC week roster targets count in.
+func RosterWeekStart(now time.Time) time.Time {
+	return StartOfWeek(now, time.UTC)
+}
+
+// RosterDue is how much of a weekly target should be done by now when the
+// target is spread evenly over the week. It rounds up, so the first item of
+// any target is due as soon as the week starts.
+func RosterDue(target int, now time.Time) int {
+	if target <= 0 {
+		return 0
+	}
+	elapsed := now.Sub(RosterWeekStart(now))
+	due := int(math.Ceil(float64(target) * elapsed.Seconds() / rosterWeek.Seconds()))
+	return min(max(due, 1), target)
+}
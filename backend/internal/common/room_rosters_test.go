@@ -0,0 +1,32 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRosterDueSpreadsTargetOverTheWeek(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		target int
+		now    time.Time
+		want   int
+	}{
+		{name: "no target", target: 0, now: monday.Add(48 * time.Hour), want: 0},
+		{name: "week start", target: 3, now: monday, want: 1},
+		{name: "first third", target: 3, now: monday.Add(56 * time.Hour), want: 1},
+		{name: "past first third", target: 3, now: monday.Add(57 * time.Hour), want: 2},
+		{name: "midweek replies", target: 10, now: monday.Add(84 * time.Hour), want: 5},
+		{name: "last minute", target: 10, now: monday.Add(rosterWeek - time.Minute), want: 10},
+		{name: "next week restarts", target: 10, now: monday.Add(rosterWeek + time.Hour), want: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RosterDue(tc.target, tc.now); got != tc.want {
+				t.Fatalf("RosterDue(%d, %s) = %d, want %d", tc.target, tc.now, got, tc.want)
+			}
+		})
+	}
+}
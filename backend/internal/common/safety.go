@@ -0,0 +1,190 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"personaworlds/backend/internal/safety"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	SafetySourcePreview       = "preview"
+	SafetySourceDraft         = "draft"
+	SafetySourceReply         = "reply"
+	SafetySourcePersonaAnswer = "persona_answer"
+
+	safetyRejectionMaxRunes = 8000
+)
+
+type DBQuerier interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+type SafetyRulesStore interface {
+	DBQuerier
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}
+
+// SafetyRejection is generated content that failed the safety check. It is
+// kept for its owner to review and appeal, and as a sample for rule tuning.
+// Language is the persona's preferred language and picks the lexicon list.
+type SafetyRejection struct {
+	UserID    string
+	PersonaID string
+	RoomID    string
+	PostID    string
+	Source    string
+	Language  string
+	Content   string
+	MaxLen    int
+	Metadata  map[string]any
+}
+
+// LoadSafetyRules returns the admin-tuned rules and the deployment lexicon,
+// or the defaults when none have been saved.
+func LoadSafetyRules(ctx context.Context, store SafetyRulesStore) (safety.Rules, error) {
+	rules := safety.DefaultRules()
+	err := store.QueryRow(ctx, `
+		SELECT max_links, allowed_terms, blocked_terms
+		FROM safety_rules
+		WHERE id = 1
+	`).Scan(&rules.MaxLinks, &rules.AllowedTerms, &rules.BlockedTerms)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return safety.DefaultRules(), err
+		}
+		rules = safety.DefaultRules()
+	}
+
+	lexicon, err := LoadSafetyLexicon(ctx, store)
+	if err != nil {
+		return safety.DefaultRules(), err
+	}
+	rules.Lexicon = lexicon
+	return rules, nil
+}
+
+func LoadSafetyLexicon(ctx context.Context, store SafetyRulesStore) ([]safety.LexiconTerm, error) {
+	rows, err := store.Query(ctx, `
+		SELECT term, language, severity, category
+		FROM safety_lexicon_terms
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lexicon := make([]safety.LexiconTerm, 0)
+	for rows.Next() {
+		var term safety.LexiconTerm
+		if err := rows.Scan(&term.Term, &term.Language, &term.Severity, &term.Category); err != nil {
+			return nil, err
+		}
+		lexicon = append(lexicon, term)
+	}
+	return lexicon, rows.Err()
+}
+
+// SafetyRulesCache keeps the loaded rules in memory and reloads them at most
+// once per interval, so lexicon and rule edits reach API and worker
+// processes without a restart.
+type SafetyRulesCache struct {
+	mu       sync.RWMutex
+	rules    safety.Rules
+	loaded   bool
+	syncedAt time.Time
+}
+
+func NewSafetyRulesCache() *SafetyRulesCache {
+	return &SafetyRulesCache{}
+}
+
+// Load returns the cached rules, reloading them through store when the last
+// sync is older than every. When a reload fails the last loaded rules (or the
+// defaults) are returned together with the error.
+func (c *SafetyRulesCache) Load(ctx context.Context, every time.Duration, store SafetyRulesStore) (safety.Rules, error) {
+	c.mu.RLock()
+	rules, loaded := c.rules, c.loaded
+	fresh := loaded && time.Since(c.syncedAt) < every
+	c.mu.RUnlock()
+	if fresh {
+		return rules, nil
+	}
+
+	next, err := LoadSafetyRules(ctx, store)
+	if err != nil {
+		if loaded {
+			return rules, err
+		}
+		return next, err
+	}
+	c.mu.Lock()
+	c.rules = next
+	c.loaded = true
+	c.syncedAt = time.Now()
+	c.mu.Unlock()
+	return next, nil
+}
+
+// Invalidate makes the next Load read from the database.
+func (c *SafetyRulesCache) Invalidate() {
+	c.mu.Lock()
+	c.syncedAt = time.Time{}
+	c.mu.Unlock()
+}
+
+// SafetyWarningFields describes content that passed the check but matched
+// warn-severity lexicon terms, for the safety_lexicon_warned log line.
+func SafetyWarningFields(rejection SafetyRejection, warnings []safety.LexiconTerm) map[string]any {
+	terms := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		terms = append(terms, warning.Category+":"+warning.Term)
+	}
+	return map[string]any{
+		"source":     rejection.Source,
+		"persona_id": rejection.PersonaID,
+		"language":   rejection.Language,
+		"terms":      strings.Join(terms, ","),
+	}
+}
+
+func RecordSafetyRejection(ctx context.Context, querier DBQuerier, rejection SafetyRejection, violation error) (int64, error) {
+	metadata := rejection.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return 0, err
+	}
+	rule := safety.RuleOf(violation)
+	if rule == "" {
+		rule = "unknown"
+	}
+
+	var id int64
+	err = querier.QueryRow(ctx, `
+		INSERT INTO safety_rejections(user_id, persona_id, room_id, post_id, source, rule, reason, content, max_len, metadata)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10::jsonb)
+		RETURNING id
+	`,
+		rejection.UserID,
+		strings.TrimSpace(rejection.PersonaID),
+		strings.TrimSpace(rejection.RoomID),
+		strings.TrimSpace(rejection.PostID),
+		rejection.Source,
+		rule,
+		violation.Error(),
+		TruncateRunes(rejection.Content, safetyRejectionMaxRunes),
+		rejection.MaxLen,
+		raw,
+	).Scan(&id)
+	return id, err
+}
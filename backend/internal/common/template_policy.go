@@ -0,0 +1,180 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personaworlds/backend/internal/quality"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Bounds a template policy must stay within. An admin still approves every
+// policy before the worker applies it.
+const (
+	TemplatePolicyMaxLinks         = 5
+	TemplatePolicyTermsMax         = 20
+	TemplatePolicyTermMaxRunes     = 40
+	TemplatePolicyMaxQuestions     = 5
+	TemplatePolicyMaxExclamations  = 5
+	TemplatePolicyMinSentenceWords = 8
+	TemplatePolicyMaxSentenceWords = 60
+)
+
+// TemplatePolicy adjusts how battle turns of one template are checked.
+// MaxLinks replaces the global link limit, BlockedTerms add to the global
+// list and AllowedTerms exempt profanity lexicon terms (never brand-safety
+// ones). The embedded turn limits tighten the quality heuristics.
+type TemplatePolicy struct {
+	MaxLinks     *int     `json:"max_links,omitempty"`
+	BlockedTerms []string `json:"blocked_terms,omitempty"`
+	AllowedTerms []string `json:"allowed_terms,omitempty"`
+	quality.TurnLimits
+}
+
+// IsZero reports whether the policy changes nothing.
+func (p TemplatePolicy) IsZero() bool {
+	return p.MaxLinks == nil &&
+		len(p.BlockedTerms) == 0 &&
+		len(p.AllowedTerms) == 0 &&
+		p.MaxQuestions == nil &&
+		p.MaxExclamations == nil &&
+		p.MaxSentenceWords == nil
+}
+
+// NormalizeTemplatePolicy checks a requested policy against the bounds and
+// normalizes its term lists.
+func NormalizeTemplatePolicy(policy TemplatePolicy) (TemplatePolicy, error) {
+	if err := checkPolicyRange("max_links", policy.MaxLinks, 0, TemplatePolicyMaxLinks); err != nil {
+		return TemplatePolicy{}, err
+	}
+	if err := checkPolicyRange("max_questions", policy.MaxQuestions, 0, TemplatePolicyMaxQuestions); err != nil {
+		return TemplatePolicy{}, err
+	}
+	if err := checkPolicyRange("max_exclamations", policy.MaxExclamations, 0, TemplatePolicyMaxExclamations); err != nil {
+		return TemplatePolicy{}, err
+	}
+	if err := checkPolicyRange("max_sentence_words", policy.MaxSentenceWords, TemplatePolicyMinSentenceWords, TemplatePolicyMaxSentenceWords); err != nil {
+		return TemplatePolicy{}, err
+	}
+
+	policy.BlockedTerms = safety.NormalizeTerms(policy.BlockedTerms)
+	policy.AllowedTerms = safety.NormalizeTerms(policy.AllowedTerms)
+	for _, list := range []struct {
+		field string
+		terms []string
+	}{{"blocked_terms", policy.BlockedTerms}, {"allowed_terms", policy.AllowedTerms}} {
+		if len(list.terms) > TemplatePolicyTermsMax {
+			return TemplatePolicy{}, fmt.Errorf("%s must have at most %d terms", list.field, TemplatePolicyTermsMax)
+		}
+		for _, term := range list.terms {
+			if len([]rune(term)) > TemplatePolicyTermMaxRunes {
+				return TemplatePolicy{}, fmt.Errorf("%s terms must be <= %d chars", list.field, TemplatePolicyTermMaxRunes)
+			}
+		}
+	}
+	if len(policy.BlockedTerms) == 0 {
+		policy.BlockedTerms = nil
+	}
+	if len(policy.AllowedTerms) == 0 {
+		policy.AllowedTerms = nil
+	}
+	return policy, nil
+}
+
+func checkPolicyRange(field string, value *int, min, max int) error {
+	if value != nil && (*value < min || *value > max) {
+		return fmt.Errorf("%s must be between %d and %d", field, min, max)
+	}
+	return nil
+}
+
+// ApplySafety returns a copy of rules with the policy's overrides. Allowed
+// terms that name a brand-safety lexicon term are dropped, so a template can
+// loosen profanity but never brand safety.
+func (p TemplatePolicy) ApplySafety(rules safety.Rules) safety.Rules {
+	if p.MaxLinks != nil {
+		rules.MaxLinks = *p.MaxLinks
+	}
+	if len(p.BlockedTerms) > 0 {
+		rules.BlockedTerms = append(append([]string{}, rules.BlockedTerms...), p.BlockedTerms...)
+	}
+	if len(p.AllowedTerms) > 0 {
+		brandSafety := map[string]struct{}{}
+		for _, term := range rules.Lexicon {
+			if term.Category == safety.CategoryBrandSafety {
+				for _, clean := range safety.NormalizeTerms([]string{term.Term}) {
+					brandSafety[clean] = struct{}{}
+				}
+			}
+		}
+		allowed := append([]string{}, rules.AllowedTerms...)
+		for _, term := range p.AllowedTerms {
+			if _, ok := brandSafety[term]; ok {
+				continue
+			}
+			allowed = append(allowed, term)
+		}
+		rules.AllowedTerms = allowed
+	}
+	return rules
+}
+
+// CheckTurn returns a template_policy violation when content breaks the
+// policy's turn limits.
+func (p TemplatePolicy) CheckTurn(content string) error {
+	broken := quality.CheckTurn(p.TurnLimits, content)
+	if len(broken) == 0 {
+		return nil
+	}
+	return &safety.Violation{
+		Rule:    safety.RuleTemplatePolicy,
+		Message: "content broke the template policy: " + strings.Join(broken, ", "),
+	}
+}
+
+// BattleTemplatePolicy is the approved policy of a battle's template.
+// TemplateID is empty when the battle has no approved policy.
+type BattleTemplatePolicy struct {
+	TemplateID string
+	Policy     TemplatePolicy
+}
+
+// LoadBattleTemplatePolicy reads the approved policy of postID's template.
+// Posts without a template or without an approved policy read as the zero
+// value.
+func LoadBattleTemplatePolicy(ctx context.Context, db DBQuerier, postID string) (BattleTemplatePolicy, error) {
+	var (
+		out BattleTemplatePolicy
+		raw []byte
+	)
+	err := db.QueryRow(ctx, `
+		SELECT tp.template_id::text, tp.approved_policy
+		FROM posts p
+		JOIN template_policies tp ON tp.template_id = p.template_id
+		WHERE p.id = $1
+		  AND tp.approved_policy IS NOT NULL
+	`, postID).Scan(&out.TemplateID, &raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BattleTemplatePolicy{}, nil
+	}
+	if err != nil {
+		return BattleTemplatePolicy{}, err
+	}
+	if err := json.Unmarshal(raw, &out.Policy); err != nil {
+		return BattleTemplatePolicy{}, err
+	}
+	return out, nil
+}
+
+// Metadata is how a generated turn records the policy it was checked with.
+func (b BattleTemplatePolicy) Metadata() map[string]any {
+	if b.TemplateID == "" {
+		return nil
+	}
+	return map[string]any{"template_policy_id": b.TemplateID}
+}
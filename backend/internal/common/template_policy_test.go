@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"personaworlds/backend/internal/safety"
+)
+
+func TestNormalizeTemplatePolicyBounds(t *testing.T) {
+	tooMany, zero, short := 9, 0, 3
+	if _, err := NormalizeTemplatePolicy(TemplatePolicy{MaxLinks: &tooMany}); err == nil {
+		t.Fatalf("expected max_links above the bound to fail")
+	}
+	policy := TemplatePolicy{}
+	policy.MaxSentenceWords = &short
+	if _, err := NormalizeTemplatePolicy(policy); err == nil {
+		t.Fatalf("expected max_sentence_words below the bound to fail")
+	}
+
+	policy = TemplatePolicy{BlockedTerms: []string{" Obviously ", "obviously", ""}}
+	policy.MaxQuestions = &zero
+	normalized, err := NormalizeTemplatePolicy(policy)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if len(normalized.BlockedTerms) != 1 || normalized.BlockedTerms[0] != "obviously" || normalized.AllowedTerms != nil {
+		t.Fatalf("unexpected terms %+v", normalized)
+	}
+	if normalized.IsZero() || !(TemplatePolicy{}).IsZero() {
+		t.Fatalf("unexpected IsZero results")
+	}
+
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(raw) != `{"blocked_terms":["obviously"],"max_questions":0}` {
+		t.Fatalf("expected flat JSON, got %s", raw)
+	}
+}
+
+func TestTemplatePolicyApplySafety(t *testing.T) {
+	base := safety.DefaultRules()
+	base.Lexicon = append(base.Lexicon, safety.LexiconTerm{Term: "casino", Language: safety.LanguageEN, Severity: safety.SeverityBlock, Category: safety.CategoryBrandSafety})
+	one := 1
+	policy := TemplatePolicy{
+		MaxLinks:     &one,
+		BlockedTerms: []string{"obviously"},
+		AllowedTerms: []string{"shit", "casino"},
+	}
+
+	rules := policy.ApplySafety(base).ForLanguage(safety.LanguageEN)
+	if len(base.BlockedTerms) != 0 || len(base.AllowedTerms) != 0 {
+		t.Fatalf("expected the shared rules to stay untouched, got %+v", base)
+	}
+	if err := rules.Validate("Well, shit happens.", 200); err != nil {
+		t.Fatalf("expected allowed profanity to pass, got %v", err)
+	}
+	if safety.RuleOf(rules.Validate("Go to the casino.", 200)) != safety.RuleBrandSafety {
+		t.Fatalf("expected brand safety terms to stay blocked")
+	}
+	if safety.RuleOf(rules.Validate("Obviously right.", 200)) != safety.RuleBlockedTerm {
+		t.Fatalf("expected the template's blocked term to apply")
+	}
+	if safety.RuleOf(rules.Validate("https://a.example https://b.example", 200)) != safety.RuleLinkSpam {
+		t.Fatalf("expected the template's link limit to apply")
+	}
+}
+
+func TestTemplatePolicyCheckTurn(t *testing.T) {
+	zero := 0
+	policy := TemplatePolicy{}
+	policy.MaxQuestions = &zero
+	if err := policy.CheckTurn("A plain claim. With evidence."); err != nil {
+		t.Fatalf("expected turn without questions to pass, got %v", err)
+	}
+	if err := policy.CheckTurn("Isn't it obvious?"); safety.RuleOf(err) != safety.RuleTemplatePolicy {
+		t.Fatalf("expected template_policy violation, got %v", err)
+	}
+	if err := (TemplatePolicy{}).CheckTurn("Why? Why not?"); err != nil {
+		t.Fatalf("expected zero policy to pass, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TurnTiming is when the worker started generating a turn and how long the
+// model took. It lives in replies.metadata so archived turns keep it.
+type TurnTiming struct {
+	StartedAt    time.Time `json:"generation_started_at"`
+	GenerationMS int64     `json:"generation_ms"`
+}
+
+// WithTurnTiming adds the generation timing of a turn to its replies.metadata
+// document, keeping whatever the document already holds.
+func WithTurnTiming(metadata []byte, startedAt time.Time, elapsed time.Duration) ([]byte, error) {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return MergeTurnMetadata(metadata, map[string]any{
+		"generation_started_at": startedAt.UTC().Format(time.RFC3339Nano),
+		"generation_ms":         elapsed.Milliseconds(),
+	})
+}
+
+// MergeTurnMetadata sets fields on a replies.metadata document, overwriting
+// keys it already has.
+func MergeTurnMetadata(metadata []byte, fields map[string]any) ([]byte, error) {
+	doc := map[string]any{}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &doc); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range fields {
+		doc[key] = value
+	}
+	return json.Marshal(doc)
+}
+
+// ParseTurnTiming reads the timing WithTurnTiming stored. Turns written before
+// timing was recorded, and human turns, report false.
+func ParseTurnTiming(metadata []byte) (TurnTiming, bool) {
+	var timing TurnTiming
+	if len(metadata) == 0 || json.Unmarshal(metadata, &timing) != nil {
+		return TurnTiming{}, false
+	}
+	if timing.StartedAt.IsZero() || timing.GenerationMS < 0 {
+		return TurnTiming{}, false
+	}
+	return timing, true
+}
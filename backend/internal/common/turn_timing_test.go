@@ -0,0 +1,29 @@
+package common
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTurnTimingRoundTrip(t *testing.T) {
+	startedAt := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	raw, err := WithTurnTiming([]byte(`{"language":"tr"}`), startedAt, 1500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("with timing: %v", err)
+	}
+
+	timing, ok := ParseTurnTiming(raw)
+	if !ok || !timing.StartedAt.Equal(startedAt) || timing.GenerationMS != 1500 {
+		t.Fatalf("unexpected timing %+v ok=%v", timing, ok)
+	}
+	if !strings.Contains(string(raw), `"language":"tr"`) {
+		t.Fatalf("expected existing metadata to be kept, got %s", raw)
+	}
+
+	for _, raw := range []string{``, `{}`, `not json`, `{"generation_ms":10}`} {
+		if _, ok := ParseTurnTiming([]byte(raw)); ok {
+			t.Fatalf("expected no timing for %q", raw)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// TurnUsage is the LLM work behind one battle turn: every call it took,
+// including regenerations and translations, the provider retries inside
+// those calls, tokens, summed call latency and the job attempt that
+// finally produced it.
+type TurnUsage struct {
+	LLMCalls         int   `json:"llm_calls"`
+	LLMRetries       int   `json:"llm_retries"`
+	PromptTokens     int   `json:"prompt_tokens"`
+	CompletionTokens int   `json:"completion_tokens"`
+	LLMLatencyMS     int64 `json:"llm_latency_ms"`
+	JobAttempt       int   `json:"job_attempt,omitempty"`
+}
+
+// WithTurnUsage stores usage under "usage" in a replies.metadata document.
+func WithTurnUsage(metadata []byte, usage TurnUsage) ([]byte, error) {
+	return MergeTurnMetadata(metadata, map[string]any{"usage": usage})
+}
+
+// ParseTurnUsage reads the usage WithTurnUsage stored. Turns written before
+// usage was recorded, and human turns, report false.
+func ParseTurnUsage(metadata []byte) (TurnUsage, bool) {
+	var doc struct {
+		Usage *TurnUsage `json:"usage"`
+	}
+	if len(metadata) == 0 || json.Unmarshal(metadata, &doc) != nil || doc.Usage == nil {
+		return TurnUsage{}, false
+	}
+	return *doc.Usage, true
+}
+
+// AddBattleUsage adds a turn's usage to the running totals on its battle.
+// A failed attempt also bumps failed_turn_attempts. Posts that are not
+// battles are left alone.
+func AddBattleUsage(ctx context.Context, db DBExecutor, postID string, usage TurnUsage, failed bool) error {
+	failedAttempts := 0
+	if failed {
+		failedAttempts = 1
+	}
+	_, err := db.Exec(ctx, `
+		UPDATE posts
+		SET llm_calls = llm_calls + $2,
+			llm_retries = llm_retries + $3,
+			llm_prompt_tokens = llm_prompt_tokens + $4,
+			llm_completion_tokens = llm_completion_tokens + $5,
+			llm_latency_ms = llm_latency_ms + $6,
+			failed_turn_attempts = failed_turn_attempts + $7
+		WHERE id = $1
+		  AND template_id IS NOT NULL
+	`, postID, usage.LLMCalls, usage.LLMRetries, usage.PromptTokens, usage.CompletionTokens, usage.LLMLatencyMS, failedAttempts)
+	return err
+}
@@ -0,0 +1,20 @@
+package common
+
+import "testing"
+
+func TestTurnUsageRoundTrip(t *testing.T) {
+	usage := TurnUsage{LLMCalls: 2, LLMRetries: 1, PromptTokens: 500, CompletionTokens: 120, LLMLatencyMS: 3100, JobAttempt: 2}
+	metadata, err := WithTurnUsage([]byte(`{"language":"en","generation_ms":3000}`), usage)
+	if err != nil {
+		t.Fatalf("with turn usage: %v", err)
+	}
+	got, ok := ParseTurnUsage(metadata)
+	if !ok || got != usage {
+		t.Fatalf("expected %+v, got %+v (ok=%v)", usage, got, ok)
+	}
+	for _, raw := range []string{"", `{"language":"en"}`, `not json`} {
+		if _, ok := ParseTurnUsage([]byte(raw)); ok {
+			t.Fatalf("expected %q to have no usage", raw)
+		}
+	}
+}
@@ -19,29 +19,95 @@ type Config struct {
 	OpenAIRequestTimeout    time.Duration
 	OpenAIMaxRetries        int
 	OpenAIRetryBase         time.Duration
+	AnthropicBaseURL        string
+	AnthropicAPIKey         string
+	AnthropicModel          string
+	AnthropicMaxTokens      int
+	AnthropicRequestTimeout time.Duration
+	AnthropicMaxRetries     int
+	AnthropicRetryBase      time.Duration
+	LLMCanaryModel          string
+	LLMCanaryPct            int
 	MigrationsDir           string
 	DraftMaxLen             int
+	DraftSLA                time.Duration
 	ReplyMaxLen             int
 	SummaryMaxLen           int
 	DefaultDraftQuota       int
 	DefaultReplyQuota       int
 	DefaultPreviewQuota     int
+	RoomPostCooldown        time.Duration
+	InteractiveTurnTimeout  time.Duration
+	BattleDailyLimit        int
+	ChallengeDailyLimit     int
+	ChallengeReceivedLimit  int
+	ChallengeExpiry         time.Duration
+	AnnounceCooldown        time.Duration
+	AnnounceMaxRecipients   int
+	ShareTokenTTL           time.Duration
+	ShareTokenMaxSignups    int
+	ReferralBoostDrafts     int
+	ReferralBoostBattles    int
+	ReferralBoostTTL        time.Duration
+	ReferralMaxRewards      int
+	GuestIntentTTL          time.Duration
+	BattleArchiveMonths     int
 	FrontendOrigin          string
 	CORSAllowedOrigins      []string
 	RequestBodyMaxBytes     int64
 	PublicBodyMaxBytes      int64
+	EventsBodyMaxBytes      int64
+	ImportBodyMaxBytes      int64
+	CompressionLevel        int
 	APIRequestTimeout       time.Duration
 	APIReadTimeout          time.Duration
 	APIWriteTimeout         time.Duration
 	APIIdleTimeout          time.Duration
+	APIDrainDelay           time.Duration
+	APIShutdownGrace        time.Duration
 	DBQueryTimeout          time.Duration
+	DBAPIPool               DBPoolConfig
+	DBWorkerPool            DBPoolConfig
+	SlowQueryThreshold      time.Duration
+	SlowQueryExplainPct     int
 	WorkerPollEvery         time.Duration
 	WorkerTaskTimeout       time.Duration
 	WorkerObservabilityPort string
+	WorkerJobConcurrency    int
+	DigestBatchSize         int
+	DigestConcurrency       int
+	WorkerShardIndex        int
+	WorkerShardCount        int
+	WorkerShardStealAfter   time.Duration
+	LLMBackoffErrorPct      int
+	LLMBackoffMaxDelay      time.Duration
 	SecureCookies           bool
+	UnicodeSlugs            bool
+	NotificationRollups     map[string]time.Duration
+	PromptSyncEvery         time.Duration
+	SafetySyncEvery         time.Duration
+	FlagsSyncEvery          time.Duration
+	AdminEmails             []string
 	JobMaxAttempts          int
 	JobRetryBase            time.Duration
 	JobRetryMax             time.Duration
+	VAPIDPublicKey          string
+	VAPIDPrivateKey         string
+	VAPIDSubject            string
+	CalibrationKeys         string
+	XClientID               string
+	XClientSecret           string
+	LinkedInClientID        string
+	LinkedInClientSecret    string
+	SocialConnectTTL        time.Duration
+	StatsDAddr              string
+	StatsDPrefix            string
+	StatsDFormat            string
+	StatsDTags              []string
+	StatsDFlushEvery        time.Duration
+	EventBus                string
+	EventBusURL             string
+	EventBusTopicPrefix     string
 }
 
 func Load() Config {
@@ -57,6 +123,13 @@ func Load() Config {
 		}
 	}
 
+	notificationRollups := parseDurationMapEnv("NOTIFICATION_ROLLUP_WINDOWS", map[string]time.Duration{
+		"persona_followed": 24 * time.Hour,
+		"battle_remixed":   6 * time.Hour,
+		"template_used":    6 * time.Hour,
+		"persona_question": 24 * time.Hour,
+	})
+
 	return Config{
 		AppEnv:                  appEnv,
 		Port:                    getEnv("PORT", "8080"),
@@ -69,29 +142,147 @@ func Load() Config {
 		OpenAIRequestTimeout:    getEnvDuration("OPENAI_REQUEST_TIMEOUT", 20*time.Second),
 		OpenAIMaxRetries:        getEnvInt("OPENAI_MAX_RETRIES", 2),
 		OpenAIRetryBase:         getEnvDuration("OPENAI_RETRY_BASE", 400*time.Millisecond),
+		AnthropicBaseURL:        getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+		AnthropicAPIKey:         os.Getenv("ANTHROPIC_API_KEY"),
+		AnthropicModel:          getEnv("ANTHROPIC_MODEL", "claude-3-5-haiku-latest"),
+		AnthropicMaxTokens:      getEnvInt("ANTHROPIC_MAX_TOKENS", 1024),
+		AnthropicRequestTimeout: getEnvDuration("ANTHROPIC_REQUEST_TIMEOUT", 30*time.Second),
+		AnthropicMaxRetries:     getEnvInt("ANTHROPIC_MAX_RETRIES", 2),
+		AnthropicRetryBase:      getEnvDuration("ANTHROPIC_RETRY_BASE", 400*time.Millisecond),
+		LLMCanaryModel:          strings.TrimSpace(os.Getenv("LLM_CANARY_MODEL")),
+		LLMCanaryPct:            getEnvInt("LLM_CANARY_PCT", 0),
 		MigrationsDir:           getEnv("MIGRATIONS_DIR", "./migrations"),
 		DraftMaxLen:             getEnvInt("DRAFT_MAX_LEN", 500),
+		DraftSLA:                getEnvDuration("DRAFT_SLA", 8*time.Second),
 		ReplyMaxLen:             getEnvInt("REPLY_MAX_LEN", 280),
 		SummaryMaxLen:           getEnvInt("SUMMARY_MAX_LEN", 400),
 		DefaultDraftQuota:       getEnvInt("DEFAULT_DRAFT_QUOTA", 5),
 		DefaultReplyQuota:       getEnvInt("DEFAULT_REPLY_QUOTA", 25),
 		DefaultPreviewQuota:     getEnvInt("DEFAULT_PREVIEW_QUOTA", 5),
+		RoomPostCooldown:        getEnvDuration("ROOM_POST_COOLDOWN", 4*time.Hour),
+		InteractiveTurnTimeout:  getEnvDuration("INTERACTIVE_BATTLE_TURN_TIMEOUT", 24*time.Hour),
+		BattleDailyLimit:        getEnvInt("BATTLE_DAILY_LIMIT", 10),
+		ChallengeDailyLimit:     getEnvInt("CHALLENGE_DAILY_LIMIT", 5),
+		ChallengeReceivedLimit:  getEnvInt("CHALLENGE_DAILY_RECEIVED_LIMIT", 20),
+		ChallengeExpiry:         getEnvDuration("CHALLENGE_EXPIRY", 72*time.Hour),
+		AnnounceCooldown:        getEnvDuration("PERSONA_ANNOUNCE_COOLDOWN", 24*time.Hour),
+		AnnounceMaxRecipients:   getEnvInt("PERSONA_ANNOUNCE_MAX_RECIPIENTS", 1000),
+		ShareTokenTTL:           getEnvDuration("SHARE_TOKEN_TTL", 7*24*time.Hour),
+		ShareTokenMaxSignups:    getEnvInt("SHARE_TOKEN_MAX_SIGNUPS", 25),
+		ReferralBoostDrafts:     getEnvInt("REFERRAL_BOOST_DRAFTS", 3),
+		ReferralBoostBattles:    getEnvInt("REFERRAL_BOOST_BATTLES", 2),
+		ReferralBoostTTL:        getEnvDuration("REFERRAL_BOOST_TTL", 7*24*time.Hour),
+		ReferralMaxRewards:      getEnvInt("REFERRAL_MAX_REWARDS", 10),
+		GuestIntentTTL:          getEnvDuration("GUEST_INTENT_TTL", 30*time.Minute),
+		BattleArchiveMonths:     getEnvInt("BATTLE_ARCHIVE_AFTER_MONTHS", 6),
 		FrontendOrigin:          frontendOrigin,
 		CORSAllowedOrigins:      corsAllowedOrigins,
 		RequestBodyMaxBytes:     int64(getEnvInt("REQUEST_BODY_MAX_BYTES", 1<<20)),
 		PublicBodyMaxBytes:      int64(getEnvInt("PUBLIC_BODY_MAX_BYTES", 64<<10)),
+		EventsBodyMaxBytes:      int64(getEnvInt("EVENTS_BODY_MAX_BYTES", 16<<10)),
+		ImportBodyMaxBytes:      int64(getEnvInt("IMPORT_BODY_MAX_BYTES", 8<<20)),
+		CompressionLevel:        getEnvInt("RESPONSE_COMPRESSION_LEVEL", 5),
 		APIRequestTimeout:       getEnvDuration("API_REQUEST_TIMEOUT", 15*time.Second),
 		APIReadTimeout:          getEnvDuration("API_READ_TIMEOUT", 15*time.Second),
 		APIWriteTimeout:         getEnvDuration("API_WRITE_TIMEOUT", 30*time.Second),
 		APIIdleTimeout:          getEnvDuration("API_IDLE_TIMEOUT", 60*time.Second),
+		APIDrainDelay:           getEnvDuration("API_DRAIN_DELAY", 5*time.Second),
+		APIShutdownGrace:        getEnvDuration("API_SHUTDOWN_GRACE", 25*time.Second),
 		DBQueryTimeout:          getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+		DBAPIPool:               loadDBPoolConfig("DB_API", 10, 2, getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second)),
+		DBWorkerPool:            loadDBPoolConfig("DB_WORKER", 8, 1, 30*time.Second),
+		SlowQueryThreshold:      getEnvDuration("SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+		SlowQueryExplainPct:     getEnvInt("SLOW_QUERY_EXPLAIN_PCT", 0),
 		WorkerPollEvery:         getEnvDuration("WORKER_POLL_EVERY", 3*time.Second),
 		WorkerTaskTimeout:       getEnvDuration("WORKER_TASK_TIMEOUT", 15*time.Second),
 		WorkerObservabilityPort: getEnv("WORKER_OBSERVABILITY_PORT", "9091"),
+		WorkerJobConcurrency:    getEnvInt("WORKER_JOB_CONCURRENCY", 4),
+		DigestBatchSize:         getEnvInt("DIGEST_BATCH_SIZE", 20),
+		DigestConcurrency:       getEnvInt("DIGEST_CONCURRENCY", 4),
+		WorkerShardIndex:        getEnvInt("WORKER_SHARD_INDEX", 0),
+		WorkerShardCount:        getEnvInt("WORKER_SHARD_COUNT", 1),
+		WorkerShardStealAfter:   getEnvDuration("WORKER_SHARD_STEAL_AFTER", 2*time.Minute),
+		LLMBackoffErrorPct:      getEnvInt("LLM_BACKOFF_ERROR_PCT", 50),
+		LLMBackoffMaxDelay:      getEnvDuration("LLM_BACKOFF_MAX_DELAY", 2*time.Minute),
 		SecureCookies:           secureCookies,
+		UnicodeSlugs:            getEnvBool("UNICODE_SLUGS", false),
+		NotificationRollups:     notificationRollups,
+		PromptSyncEvery:         getEnvDuration("PROMPT_SYNC_EVERY", 30*time.Second),
+		SafetySyncEvery:         getEnvDuration("SAFETY_SYNC_EVERY", 30*time.Second),
+		FlagsSyncEvery:          getEnvDuration("FLAGS_SYNC_EVERY", 30*time.Second),
+		AdminEmails:             parseCSVEnv("ADMIN_EMAILS"),
 		JobMaxAttempts:          getEnvInt("JOB_MAX_ATTEMPTS", 5),
 		JobRetryBase:            getEnvDuration("JOB_RETRY_BASE", 30*time.Second),
 		JobRetryMax:             getEnvDuration("JOB_RETRY_MAX", 10*time.Minute),
+		VAPIDPublicKey:          strings.TrimSpace(os.Getenv("VAPID_PUBLIC_KEY")),
+		VAPIDPrivateKey:         strings.TrimSpace(os.Getenv("VAPID_PRIVATE_KEY")),
+		VAPIDSubject:            strings.TrimSpace(os.Getenv("VAPID_SUBJECT")),
+		CalibrationKeys:         strings.TrimSpace(os.Getenv("CALIBRATION_KEYS")),
+		XClientID:               strings.TrimSpace(os.Getenv("X_CLIENT_ID")),
+		XClientSecret:           strings.TrimSpace(os.Getenv("X_CLIENT_SECRET")),
+		LinkedInClientID:        strings.TrimSpace(os.Getenv("LINKEDIN_CLIENT_ID")),
+		LinkedInClientSecret:    strings.TrimSpace(os.Getenv("LINKEDIN_CLIENT_SECRET")),
+		SocialConnectTTL:        getEnvDuration("SOCIAL_CONNECT_TTL", 10*time.Minute),
+		StatsDAddr:              strings.TrimSpace(os.Getenv("STATSD_ADDR")),
+		StatsDPrefix:            getEnv("STATSD_PREFIX", "personaworlds."),
+		StatsDFormat:            strings.ToLower(getEnv("STATSD_FORMAT", "dogstatsd")),
+		StatsDTags:              parseCSVEnv("STATSD_TAGS"),
+		StatsDFlushEvery:        getEnvDuration("STATSD_FLUSH_EVERY", time.Second),
+		EventBus:                strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_BUS"))),
+		EventBusURL:             strings.TrimSpace(os.Getenv("EVENT_BUS_URL")),
+		EventBusTopicPrefix:     getEnv("EVENT_BUS_TOPIC_PREFIX", "personaworlds."),
+	}
+}
+
+// StatsDEnabled reports whether metrics are also pushed to a StatsD agent.
+func (c Config) StatsDEnabled() bool {
+	return c.StatsDAddr != ""
+}
+
+// EventBusEnabled reports whether product events are mirrored to Kafka or
+// NATS (EVENT_BUS) through the outbox.
+func (c Config) EventBusEnabled() bool {
+	return c.EventBus != "" && c.EventBusURL != ""
+}
+
+// LLMRequestTimeout is the per-call timeout of the configured LLM provider.
+func (c Config) LLMRequestTimeout() time.Duration {
+	if c.LLMProvider == "anthropic" {
+		return c.AnthropicRequestTimeout
+	}
+	return c.OpenAIRequestTimeout
+}
+
+// LLMCanaryEnabled reports whether some generations are shadowed by the
+// candidate model in LLM_CANARY_MODEL.
+func (c Config) LLMCanaryEnabled() bool {
+	return c.LLMCanaryModel != "" && c.LLMCanaryPct > 0
+}
+
+// WebPushEnabled reports whether VAPID keys are configured. Without them the
+// API refuses push subscriptions and the worker skips push dispatch.
+func (c Config) WebPushEnabled() bool {
+	return c.VAPIDPublicKey != "" && c.VAPIDPrivateKey != ""
+}
+
+// DBPoolConfig sizes the connection pool of one component. The API keeps a
+// short statement timeout for interactive reads; the worker allows longer
+// transactions.
+type DBPoolConfig struct {
+	MaxConns         int
+	MinConns         int
+	MaxConnLifetime  time.Duration
+	MaxConnIdleTime  time.Duration
+	StatementTimeout time.Duration
+}
+
+func loadDBPoolConfig(prefix string, maxConns, minConns int, statementTimeout time.Duration) DBPoolConfig {
+	return DBPoolConfig{
+		MaxConns:         getEnvInt(prefix+"_MAX_CONNS", maxConns),
+		MinConns:         getEnvInt(prefix+"_MIN_CONNS", minConns),
+		MaxConnLifetime:  getEnvDuration("DB_MAX_CONN_LIFETIME", time.Hour),
+		MaxConnIdleTime:  getEnvDuration("DB_MAX_CONN_IDLE_TIME", 30*time.Minute),
+		StatementTimeout: getEnvDuration(prefix+"_STATEMENT_TIMEOUT", statementTimeout),
 	}
 }
 
@@ -163,3 +354,24 @@ func parseCSVEnv(key string) []string {
 	}
 	return items
 }
+
+func parseDurationMapEnv(key string, fallback map[string]time.Duration) map[string]time.Duration {
+	out := make(map[string]time.Duration, len(fallback))
+	for name, value := range fallback {
+		out[name] = value
+	}
+
+	for _, item := range parseCSVEnv(key) {
+		name, rawValue, found := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(strings.TrimSpace(rawValue))
+		if err != nil || parsed < 0 {
+			continue
+		}
+		out[name] = parsed
+	}
+	return out
+}
@@ -2,30 +2,69 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"os"
-	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/observability"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const migrationLockKey int64 = 82458324711
 
+// PoolOptions sizes a connection pool for one component. Zero values keep the
+// pgxpool defaults, except StatementTimeout where zero disables the timeout.
+type PoolOptions struct {
+	MaxConns         int32
+	MinConns         int32
+	MaxConnLifetime  time.Duration
+	MaxConnIdleTime  time.Duration
+	StatementTimeout time.Duration
+	SlowQuery        SlowQueryOptions
+}
+
+// PoolOptionsFromConfig builds the options for a component's pool from its
+// configured settings.
+func PoolOptionsFromConfig(cfg config.DBPoolConfig, slowQuery SlowQueryOptions) PoolOptions {
+	return PoolOptions{
+		MaxConns:         int32(max(cfg.MaxConns, 0)),
+		MinConns:         int32(max(cfg.MinConns, 0)),
+		MaxConnLifetime:  cfg.MaxConnLifetime,
+		MaxConnIdleTime:  cfg.MaxConnIdleTime,
+		StatementTimeout: cfg.StatementTimeout,
+		SlowQuery:        slowQuery,
+	}
+}
+
 func Connect(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
+	return ConnectWithSlowQueryLog(ctx, databaseURL, SlowQueryOptions{})
+}
+
+// ConnectWithSlowQueryLog connects like Connect and, when opts.Threshold is
+// positive, traces every query on the pool for slow query logging.
+func ConnectWithSlowQueryLog(ctx context.Context, databaseURL string, opts SlowQueryOptions) (*pgxpool.Pool, error) {
+	return ConnectPool(ctx, databaseURL, PoolOptions{
+		StatementTimeout: statementTimeoutFromEnv(),
+		SlowQuery:        opts,
+	})
+}
+
+// ConnectPool connects with the pool sizing and statement timeout in opts, so
+// the API and the worker can each get a pool shaped for their workload.
+func ConnectPool(ctx context.Context, databaseURL string, opts PoolOptions) (*pgxpool.Pool, error) {
 	poolConfig, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, err
 	}
-	statementTimeout := statementTimeoutFromEnv()
-	if statementTimeout > 0 {
-		if poolConfig.ConnConfig.RuntimeParams == nil {
-			poolConfig.ConnConfig.RuntimeParams = map[string]string{}
-		}
-		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	applyPoolOptions(poolConfig, opts)
+
+	var tracer *slowQueryTracer
+	if opts.SlowQuery.Threshold > 0 {
+		tracer = newSlowQueryTracer(opts.SlowQuery)
+		poolConfig.ConnConfig.Tracer = tracer
 	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
@@ -36,9 +75,50 @@ func Connect(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 		pool.Close()
 		return nil, err
 	}
+	if tracer != nil {
+		tracer.start(pool)
+	}
 	return pool, nil
 }
 
+func applyPoolOptions(poolConfig *pgxpool.Config, opts PoolOptions) {
+	if opts.MaxConns > 0 {
+		poolConfig.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		poolConfig.MinConns = min(opts.MinConns, poolConfig.MaxConns)
+	}
+	if opts.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	if opts.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+	if opts.StatementTimeout > 0 {
+		if poolConfig.ConnConfig.RuntimeParams == nil {
+			poolConfig.ConnConfig.RuntimeParams = map[string]string{}
+		}
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10)
+	}
+}
+
+// PoolStats snapshots pool for the db_pool_* gauges.
+func PoolStats(pool *pgxpool.Pool) observability.DBPoolStats {
+	if pool == nil {
+		return observability.DBPoolStats{}
+	}
+	stat := pool.Stat()
+	return observability.DBPoolStats{
+		MaxConns:             stat.MaxConns(),
+		TotalConns:           stat.TotalConns(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+	}
+}
+
 func statementTimeoutFromEnv() time.Duration {
 	raw := strings.TrimSpace(os.Getenv("DB_QUERY_TIMEOUT"))
 	if raw == "" {
@@ -50,71 +130,3 @@ func statementTimeoutFromEnv() time.Duration {
 	}
 	return parsed
 }
-
-func RunMigrations(ctx context.Context, pool *pgxpool.Pool, migrationsDir string) error {
-	if _, err := pool.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
-		return fmt.Errorf("acquire migration lock: %w", err)
-	}
-	defer func() {
-		_, _ = pool.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
-	}()
-
-	if _, err := pool.Exec(ctx, `
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			version TEXT PRIMARY KEY,
-			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		)
-	`); err != nil {
-		return fmt.Errorf("create schema_migrations: %w", err)
-	}
-
-	entries, err := os.ReadDir(migrationsDir)
-	if err != nil {
-		return fmt.Errorf("read migrations dir: %w", err)
-	}
-
-	var files []string
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		if filepath.Ext(entry.Name()) == ".sql" {
-			files = append(files, entry.Name())
-		}
-	}
-	sort.Strings(files)
-
-	for _, fileName := range files {
-		var exists bool
-		if err := pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version=$1)", fileName).Scan(&exists); err != nil {
-			return fmt.Errorf("check migration %s: %w", fileName, err)
-		}
-		if exists {
-			continue
-		}
-
-		sqlBytes, err := os.ReadFile(filepath.Join(migrationsDir, fileName))
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", fileName, err)
-		}
-
-		tx, err := pool.Begin(ctx)
-		if err != nil {
-			return fmt.Errorf("begin migration tx: %w", err)
-		}
-
-		if _, err := tx.Exec(ctx, string(sqlBytes)); err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("apply migration %s: %w", fileName, err)
-		}
-		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations(version) VALUES ($1)", fileName); err != nil {
-			tx.Rollback(ctx)
-			return fmt.Errorf("record migration %s: %w", fileName, err)
-		}
-		if err := tx.Commit(ctx); err != nil {
-			return fmt.Errorf("commit migration %s: %w", fileName, err)
-		}
-	}
-
-	return nil
-}
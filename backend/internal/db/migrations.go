@@ -0,0 +1,431 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const downMigrationSuffix = ".down.sql"
+
+type Migration struct {
+	Version  string
+	Path     string
+	DownPath string
+	Checksum string
+}
+
+type MigrateOptions struct {
+	ToVersion string
+	DryRun    bool
+	AppliedBy string
+	Logf      func(format string, args ...any)
+}
+
+type MigrationStatus struct {
+	Version   string
+	Applied   bool
+	Drifted   bool
+	HasDown   bool
+	AppliedAt time.Time
+}
+
+func LoadMigrations(migrationsDir string) ([]Migration, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations dir: %w", err)
+	}
+
+	names := map[string]struct{}{}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		names[entry.Name()] = struct{}{}
+		if IsUpMigrationFile(entry.Name()) {
+			files = append(files, entry.Name())
+		}
+	}
+	sort.Strings(files)
+
+	migrations := make([]Migration, 0, len(files))
+	for _, fileName := range files {
+		path := filepath.Join(migrationsDir, fileName)
+		sqlBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", fileName, err)
+		}
+
+		migration := Migration{
+			Version:  fileName,
+			Path:     path,
+			Checksum: migrationChecksum(sqlBytes),
+		}
+		downName := strings.TrimSuffix(fileName, ".sql") + downMigrationSuffix
+		if _, exists := names[downName]; exists {
+			migration.DownPath = filepath.Join(migrationsDir, downName)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func IsUpMigrationFile(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".sql") && !strings.HasSuffix(lower, downMigrationSuffix)
+}
+
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool, migrationsDir string) error {
+	return Migrate(ctx, pool, migrationsDir, MigrateOptions{})
+}
+
+func Migrate(ctx context.Context, pool *pgxpool.Pool, migrationsDir string, opts MigrateOptions) error {
+	if _, err := pool.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		_, _ = pool.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}()
+
+	// A dry run only reads: missing bookkeeping tables mean nothing is applied
+	// yet and missing checksums are filled in memory.
+	if !opts.DryRun {
+		if err := ensureMigrationTables(ctx, pool); err != nil {
+			return err
+		}
+	}
+
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return err
+	}
+	applied, err := appliedMigrationChecksums(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if err := backfillMigrationChecksums(ctx, pool, migrations, applied, opts.DryRun); err != nil {
+		return err
+	}
+	if err := verifyMigrationChecksums(migrations, applied); err != nil {
+		return err
+	}
+
+	target, err := resolveTargetVersion(migrations, opts.ToVersion)
+	if err != nil {
+		return err
+	}
+	appliedBy := strings.TrimSpace(opts.AppliedBy)
+	if appliedBy == "" {
+		appliedBy = defaultAppliedBy()
+	}
+	logf := opts.Logf
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if _, isApplied := applied[migration.Version]; !isApplied || migration.Version <= target {
+			continue
+		}
+		if migration.DownPath == "" {
+			return fmt.Errorf("migration %s has no down migration", migration.Version)
+		}
+		if opts.DryRun {
+			logf("would revert %s", migration.Version)
+			continue
+		}
+		if err := revertMigration(ctx, pool, migration, appliedBy); err != nil {
+			return err
+		}
+		logf("reverted %s", migration.Version)
+	}
+
+	for _, migration := range migrations {
+		if _, isApplied := applied[migration.Version]; isApplied || migration.Version > target {
+			continue
+		}
+		if opts.DryRun {
+			logf("would apply %s", migration.Version)
+			continue
+		}
+		if err := applyMigration(ctx, pool, migration, appliedBy); err != nil {
+			return err
+		}
+		logf("applied %s", migration.Version)
+	}
+
+	return nil
+}
+
+// MigrationStatuses only reads, so it is safe against a database that was
+// never migrated.
+func MigrationStatuses(ctx context.Context, pool *pgxpool.Pool, migrationsDir string) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{
+			Version: migration.Version,
+			HasDown: migration.DownPath != "",
+		}
+		if row, exists := applied[migration.Version]; exists {
+			status.Applied = true
+			status.AppliedAt = row.appliedAt
+			status.Drifted = row.checksum != "" && row.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func ensureMigrationTables(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	if _, err := pool.Exec(ctx, `
+		ALTER TABLE schema_migrations
+			ADD COLUMN IF NOT EXISTS checksum TEXT
+	`); err != nil {
+		return fmt.Errorf("alter schema_migrations: %w", err)
+	}
+	if _, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migration_history (
+			id BIGSERIAL PRIMARY KEY,
+			version TEXT NOT NULL,
+			direction TEXT NOT NULL CHECK (direction IN ('up', 'down')),
+			checksum TEXT NOT NULL,
+			applied_by TEXT NOT NULL DEFAULT '',
+			duration_ms BIGINT NOT NULL DEFAULT 0,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return fmt.Errorf("create schema_migration_history: %w", err)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// appliedMigrations reads schema_migrations without creating or altering it:
+// a missing table means nothing is applied and a missing checksum column
+// reads as empty checksums.
+func appliedMigrations(ctx context.Context, pool *pgxpool.Pool) (map[string]appliedMigration, error) {
+	var tableExists, hasChecksum bool
+	if err := pool.QueryRow(ctx, `
+		SELECT
+			to_regclass('schema_migrations') IS NOT NULL,
+			EXISTS (
+				SELECT 1
+				FROM pg_attribute
+				WHERE attrelid = to_regclass('schema_migrations')
+				  AND attname = 'checksum'
+				  AND NOT attisdropped
+			)
+	`).Scan(&tableExists, &hasChecksum); err != nil {
+		return nil, fmt.Errorf("inspect schema_migrations: %w", err)
+	}
+	applied := map[string]appliedMigration{}
+	if !tableExists {
+		return applied, nil
+	}
+
+	checksumColumn := "''"
+	if hasChecksum {
+		checksumColumn = "COALESCE(checksum, '')"
+	}
+	rows, err := pool.Query(ctx, `
+		SELECT version, `+checksumColumn+`, applied_at
+		FROM schema_migrations
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version string
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		applied[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func appliedMigrationChecksums(ctx context.Context, pool *pgxpool.Pool) (map[string]string, error) {
+	rows, err := appliedMigrations(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]string, len(rows))
+	for version, row := range rows {
+		applied[version] = row.checksum
+	}
+	return applied, nil
+}
+
+// Rows recorded before checksums existed are trusted once and stamped with the
+// checksum of the file currently on disk. A dry run stamps applied only.
+func backfillMigrationChecksums(ctx context.Context, pool *pgxpool.Pool, migrations []Migration, applied map[string]string, dryRun bool) error {
+	for _, migration := range migrations {
+		checksum, exists := applied[migration.Version]
+		if !exists || checksum != "" {
+			continue
+		}
+		if dryRun {
+			applied[migration.Version] = migration.Checksum
+			continue
+		}
+		if _, err := pool.Exec(ctx, `
+			UPDATE schema_migrations
+			SET checksum = $2
+			WHERE version = $1 AND checksum IS NULL
+		`, migration.Version, migration.Checksum); err != nil {
+			return fmt.Errorf("backfill checksum %s: %w", migration.Version, err)
+		}
+		applied[migration.Version] = migration.Checksum
+	}
+	return nil
+}
+
+func verifyMigrationChecksums(migrations []Migration, applied map[string]string) error {
+	var drifted []string
+	for _, migration := range migrations {
+		checksum, exists := applied[migration.Version]
+		if !exists || checksum == "" {
+			continue
+		}
+		if checksum != migration.Checksum {
+			drifted = append(drifted, migration.Version)
+		}
+	}
+	if len(drifted) > 0 {
+		return fmt.Errorf("migration checksum drift detected: %s", strings.Join(drifted, ", "))
+	}
+	return nil
+}
+
+func resolveTargetVersion(migrations []Migration, toVersion string) (string, error) {
+	clean := strings.TrimSpace(toVersion)
+	if clean == "" {
+		if len(migrations) == 0 {
+			return "", nil
+		}
+		return migrations[len(migrations)-1].Version, nil
+	}
+	if clean == "0" {
+		return "", nil
+	}
+	for _, migration := range migrations {
+		if migration.Version == clean || strings.HasPrefix(migration.Version, clean+"_") {
+			return migration.Version, nil
+		}
+	}
+	return "", fmt.Errorf("unknown migration version %q", clean)
+}
+
+func applyMigration(ctx context.Context, pool *pgxpool.Pool, migration Migration, appliedBy string) error {
+	sqlBytes, err := os.ReadFile(migration.Path)
+	if err != nil {
+		return fmt.Errorf("read migration %s: %w", migration.Version, err)
+	}
+
+	startedAt := time.Now()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin migration tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, string(sqlBytes)); err != nil {
+		return fmt.Errorf("apply migration %s: %w", migration.Version, err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations(version, checksum) VALUES ($1, $2)", migration.Version, migration.Checksum); err != nil {
+		return fmt.Errorf("record migration %s: %w", migration.Version, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO schema_migration_history(version, direction, checksum, applied_by, duration_ms)
+		VALUES ($1, 'up', $2, $3, $4)
+	`, migration.Version, migration.Checksum, appliedBy, time.Since(startedAt).Milliseconds()); err != nil {
+		return fmt.Errorf("record migration history %s: %w", migration.Version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit migration %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func revertMigration(ctx context.Context, pool *pgxpool.Pool, migration Migration, appliedBy string) error {
+	sqlBytes, err := os.ReadFile(migration.DownPath)
+	if err != nil {
+		return fmt.Errorf("read down migration %s: %w", migration.Version, err)
+	}
+
+	startedAt := time.Now()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin migration tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, string(sqlBytes)); err != nil {
+		return fmt.Errorf("revert migration %s: %w", migration.Version, err)
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+		return fmt.Errorf("unrecord migration %s: %w", migration.Version, err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO schema_migration_history(version, direction, checksum, applied_by, duration_ms)
+		VALUES ($1, 'down', $2, $3, $4)
+	`, migration.Version, migration.Checksum, appliedBy, time.Since(startedAt).Milliseconds()); err != nil {
+		return fmt.Errorf("record migration history %s: %w", migration.Version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit migration %s: %w", migration.Version, err)
+	}
+	return nil
+}
+
+func migrationChecksum(sqlBytes []byte) string {
+	sum := sha256.Sum256(sqlBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+func defaultAppliedBy() string {
+	if user := strings.TrimSpace(os.Getenv("USER")); user != "" {
+		return user
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return strings.TrimSpace(hostname)
+	}
+	return "unknown"
+}
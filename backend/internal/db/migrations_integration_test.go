@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestMigrateDryRunWritesNothingIntegration runs a dry run and a status check
+// in an empty schema and in one with a pre-checksum schema_migrations table.
+// Neither may create, alter or update anything.
+func TestMigrateDryRunWritesNothingIntegration(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	admin, err := Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("db connect failed: %v", err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("migrate_dry_run_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema failed: %v", err)
+	}
+	defer func() {
+		_, _ = admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	}()
+
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatalf("parse database url failed: %v", err)
+	}
+	poolConfig.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"001_first.sql":  "CREATE TABLE dry_run_first (id INT);",
+		"002_second.sql": "CREATE TABLE dry_run_second (id INT);",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	relationExists := func(name string) bool {
+		t.Helper()
+		var exists bool
+		if err := pool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+			t.Fatalf("check %s failed: %v", name, err)
+		}
+		return exists
+	}
+	dryRun := func() []string {
+		t.Helper()
+		var planned []string
+		err := Migrate(ctx, pool, dir, MigrateOptions{
+			DryRun: true,
+			Logf: func(format string, args ...any) {
+				planned = append(planned, fmt.Sprintf(format, args...))
+			},
+		})
+		if err != nil {
+			t.Fatalf("dry run failed: %v", err)
+		}
+		return planned
+	}
+
+	if planned := dryRun(); len(planned) != 2 || planned[0] != "would apply 001_first.sql" {
+		t.Fatalf("unexpected plan on an empty schema: %v", planned)
+	}
+	statuses, err := MigrationStatuses(ctx, pool, dir)
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Applied || statuses[1].Applied {
+		t.Fatalf("expected both migrations pending, got %+v", statuses)
+	}
+	for _, name := range []string{"schema_migrations", "schema_migration_history", "dry_run_first"} {
+		if relationExists(name) {
+			t.Fatalf("dry run or status created %s", name)
+		}
+	}
+
+	if _, err := pool.Exec(ctx, `
+		CREATE TABLE schema_migrations (
+			version TEXT PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		INSERT INTO schema_migrations(version) VALUES ('001_first.sql');
+	`); err != nil {
+		t.Fatalf("create legacy schema_migrations failed: %v", err)
+	}
+
+	if planned := dryRun(); len(planned) != 1 || planned[0] != "would apply 002_second.sql" {
+		t.Fatalf("unexpected plan over a legacy table: %v", planned)
+	}
+	statuses, err = MigrationStatuses(ctx, pool, dir)
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if !statuses[0].Applied || statuses[0].Drifted || statuses[1].Applied {
+		t.Fatalf("expected only 001 applied, got %+v", statuses)
+	}
+
+	var hasChecksum bool
+	if err := pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM pg_attribute
+			WHERE attrelid = to_regclass('schema_migrations') AND attname = 'checksum'
+		)
+	`).Scan(&hasChecksum); err != nil {
+		t.Fatalf("check checksum column failed: %v", err)
+	}
+	if hasChecksum {
+		t.Fatalf("dry run added the checksum column")
+	}
+	for _, name := range []string{"schema_migration_history", "dry_run_second"} {
+		if relationExists(name) {
+			t.Fatalf("dry run or status created %s", name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMigrationsPairsDownFilesAndChecksums(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"001_init.sql":        "CREATE TABLE a (id INT);",
+		"002_more.sql":        "CREATE TABLE b (id INT);",
+		"002_more.down.sql":   "DROP TABLE b;",
+		"README.md":           "not a migration",
+		"003_latest.sql":      "CREATE TABLE c (id INT);",
+		"003_latest.down.sql": "DROP TABLE c;",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 up migrations, got %d", len(migrations))
+	}
+	if migrations[0].DownPath != "" {
+		t.Fatalf("expected 001 to have no down migration")
+	}
+	if filepath.Base(migrations[1].DownPath) != "002_more.down.sql" {
+		t.Fatalf("expected 002 down migration, got %q", migrations[1].DownPath)
+	}
+	if migrations[0].Checksum == migrations[1].Checksum || len(migrations[0].Checksum) != 64 {
+		t.Fatalf("expected distinct sha256 checksums")
+	}
+
+	target, err := resolveTargetVersion(migrations, "002")
+	if err != nil || target != "002_more.sql" {
+		t.Fatalf("expected 002_more.sql target, got %q (%v)", target, err)
+	}
+	if _, err := resolveTargetVersion(migrations, "009"); err == nil {
+		t.Fatalf("expected unknown version error")
+	}
+
+	applied := map[string]string{
+		"001_init.sql": migrations[0].Checksum,
+		"002_more.sql": "stale",
+	}
+	if err := verifyMigrationChecksums(migrations, applied); err == nil {
+		t.Fatalf("expected checksum drift error")
+	}
+}
@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	slowQueryMaxSQLLen     = 4000
+	slowQueryLogSQLLen     = 500
+	slowQueryQueueSize     = 256
+	slowQueryRecordTimeout = 5 * time.Second
+)
+
+var sqlWhitespacePattern = regexp.MustCompile(`\s+`)
+
+// SlowQueryOptions enables slow query logging on a pool. Queries slower than
+// Threshold are logged with their parameters redacted and aggregated in the
+// slow_queries table; ExplainPct percent of slow reads are re-run under
+// EXPLAIN (ANALYZE, BUFFERS) in a read-only transaction that is rolled back.
+type SlowQueryOptions struct {
+	Service    string
+	Threshold  time.Duration
+	ExplainPct int
+	Logger     *observability.Logger
+}
+
+type slowQuery struct {
+	fingerprint string
+	sql         string
+	args        []any
+	duration    time.Duration
+	explain     bool
+}
+
+type queryStart struct {
+	sql       string
+	args      []any
+	startedAt time.Time
+}
+
+type queryStartKey struct{}
+
+type untracedKey struct{}
+
+// slowQueryTracer implements pgx.QueryTracer. Recording happens on a
+// background goroutine so the traced query never waits on it; when the queue
+// is full slow queries are still logged but not recorded.
+type slowQueryTracer struct {
+	opts    SlowQueryOptions
+	pool    atomic.Pointer[pgxpool.Pool]
+	pending chan slowQuery
+}
+
+func newSlowQueryTracer(opts SlowQueryOptions) *slowQueryTracer {
+	if opts.ExplainPct < 0 {
+		opts.ExplainPct = 0
+	}
+	if opts.ExplainPct > 100 {
+		opts.ExplainPct = 100
+	}
+	if opts.Logger == nil {
+		opts.Logger = observability.NewLogger(opts.Service)
+	}
+	return &slowQueryTracer{
+		opts:    opts,
+		pending: make(chan slowQuery, slowQueryQueueSize),
+	}
+}
+
+func (t *slowQueryTracer) start(pool *pgxpool.Pool) {
+	t.pool.Store(pool)
+	go t.recordLoop()
+}
+
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if ctx.Value(untracedKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, queryStart{
+		sql:       data.SQL,
+		args:      data.Args,
+		startedAt: time.Now(),
+	})
+}
+
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	started, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(started.startedAt)
+	if duration < t.opts.Threshold {
+		return
+	}
+
+	normalized := normalizeSQL(started.sql)
+	query := slowQuery{
+		fingerprint: fingerprintSQL(normalized),
+		sql:         normalized,
+		args:        started.args,
+		duration:    duration,
+		explain:     data.Err == nil && isExplainable(normalized) && rand.Intn(100) < t.opts.ExplainPct,
+	}
+
+	fields := observability.Fields{
+		"fingerprint": query.fingerprint,
+		"duration_ms": duration.Milliseconds(),
+		"sql":         truncateSQL(normalized, slowQueryLogSQLLen),
+		"args":        redactArgs(started.args),
+		"command":     data.CommandTag.String(),
+	}
+	if data.Err != nil {
+		fields["error"] = data.Err.Error()
+	}
+	t.opts.Logger.Warn("slow_query", fields)
+
+	select {
+	case t.pending <- query:
+	default:
+	}
+}
+
+func (t *slowQueryTracer) recordLoop() {
+	for query := range t.pending {
+		t.record(query)
+	}
+}
+
+func (t *slowQueryTracer) record(query slowQuery) {
+	pool := t.pool.Load()
+	if pool == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), untracedKey{}, true), slowQueryRecordTimeout)
+	defer cancel()
+
+	ms := float64(query.duration) / float64(time.Millisecond)
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO slow_queries(fingerprint, query, service, calls, total_ms, max_ms, last_ms)
+		VALUES ($1, $2, $3, 1, $4, $4, $4)
+		ON CONFLICT (fingerprint) DO UPDATE
+		SET calls = slow_queries.calls + 1,
+			total_ms = slow_queries.total_ms + EXCLUDED.total_ms,
+			max_ms = GREATEST(slow_queries.max_ms, EXCLUDED.max_ms),
+			last_ms = EXCLUDED.last_ms,
+			service = EXCLUDED.service,
+			last_seen_at = NOW()
+	`, query.fingerprint, truncateSQL(query.sql, slowQueryMaxSQLLen), t.opts.Service, ms); err != nil {
+		t.opts.Logger.Warn("slow_query_record_failed", observability.Fields{
+			"fingerprint": query.fingerprint,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	if !query.explain {
+		return
+	}
+	plan, err := explainQuery(ctx, pool, query.sql, query.args)
+	if err != nil {
+		t.opts.Logger.Warn("slow_query_explain_failed", observability.Fields{
+			"fingerprint": query.fingerprint,
+			"error":       err.Error(),
+		})
+		return
+	}
+	if _, err := pool.Exec(ctx, `
+		UPDATE slow_queries
+		SET explain_plan = $2, explained_at = NOW()
+		WHERE fingerprint = $1
+	`, query.fingerprint, plan); err != nil {
+		t.opts.Logger.Warn("slow_query_record_failed", observability.Fields{
+			"fingerprint": query.fingerprint,
+			"error":       err.Error(),
+		})
+	}
+}
+
+// explainQuery re-runs a read with EXPLAIN ANALYZE. The read-only transaction
+// is always rolled back, so a statement that turns out to write fails instead
+// of running twice.
+func explainQuery(ctx context.Context, pool *pgxpool.Pool, sql string, args []any) (string, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+sql, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	lines := make([]string, 0, 16)
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func normalizeSQL(sql string) string {
+	return strings.TrimSpace(sqlWhitespacePattern.ReplaceAllString(sql, " "))
+}
+
+func fingerprintSQL(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isExplainable limits EXPLAIN sampling to plain reads. Row locks would fail
+// in the read-only transaction anyway, so they are skipped up front.
+func isExplainable(normalized string) bool {
+	lower := strings.ToLower(normalized)
+	if !strings.HasPrefix(lower, "select ") && !strings.HasPrefix(lower, "with ") {
+		return false
+	}
+	for _, keyword := range []string{"insert ", "update ", "delete ", " for update", " for share"} {
+		if strings.Contains(lower, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// redactArgs describes query parameters by type only, so values such as
+// emails or message text never reach the logs.
+func redactArgs(args []any) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == nil {
+			out = append(out, "nil")
+			continue
+		}
+		value := reflect.ValueOf(arg)
+		switch value.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			out = append(out, fmt.Sprintf("%T(len=%d)", arg, value.Len()))
+		default:
+			out = append(out, fmt.Sprintf("%T", arg))
+		}
+	}
+	return out
+}
+
+func truncateSQL(sql string, maxLen int) string {
+	if len(sql) <= maxLen {
+		return sql
+	}
+	return strings.ToValidUTF8(sql[:maxLen], "") + "..."
+}
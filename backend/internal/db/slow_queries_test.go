@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestNormalizeSQLAndFingerprint(t *testing.T) {
+	a := normalizeSQL("\n\t\tSELECT id\n\t\tFROM posts\n\t\tWHERE id = $1\n\t")
+	b := normalizeSQL("SELECT id FROM posts WHERE id = $1")
+	if a != b {
+		t.Fatalf("normalizeSQL mismatch: %q vs %q", a, b)
+	}
+	if fingerprintSQL(a) != fingerprintSQL(b) {
+		t.Fatal("expected equal fingerprints for equivalent SQL")
+	}
+	if fingerprintSQL(a) == fingerprintSQL("SELECT id FROM rooms WHERE id = $1") {
+		t.Fatal("expected different fingerprints for different SQL")
+	}
+}
+
+func TestIsExplainable(t *testing.T) {
+	cases := map[string]bool{
+		"SELECT id FROM posts":                                   true,
+		"WITH recent AS (SELECT 1) SELECT * FROM recent":         true,
+		"SELECT id FROM jobs FOR UPDATE SKIP LOCKED":             false,
+		"UPDATE posts SET status = 'PUBLISHED'":                  false,
+		"WITH moved AS (UPDATE posts SET room_id = $1) SELECT 1": false,
+		"INSERT INTO posts(id) VALUES ($1)":                      false,
+	}
+	for sql, want := range cases {
+		if got := isExplainable(sql); got != want {
+			t.Fatalf("isExplainable(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
+
+func TestRedactArgsHidesValues(t *testing.T) {
+	got := redactArgs([]any{"alice@example.com", 42, nil, []string{"a", "b"}})
+	want := []string{"string(len=17)", "int", "nil", "[]string(len=2)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("redactArgs = %v, want %v", got, want)
+	}
+	if strings.Contains(strings.Join(got, " "), "alice") {
+		t.Fatal("redacted args leaked a value")
+	}
+}
+
+func TestSlowQueryTracerQueuesOnlySlowQueries(t *testing.T) {
+	tracer := newSlowQueryTracer(SlowQueryOptions{Service: "test", Threshold: 50 * time.Millisecond})
+
+	fast := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1"})
+	tracer.TraceQueryEnd(fast, nil, pgx.TraceQueryEndData{})
+	if len(tracer.pending) != 0 {
+		t.Fatalf("expected fast query to be ignored, queued %d", len(tracer.pending))
+	}
+
+	slow := context.WithValue(context.Background(), queryStartKey{}, queryStart{
+		sql:       "SELECT  id\n FROM posts",
+		startedAt: time.Now().Add(-time.Second),
+	})
+	tracer.TraceQueryEnd(slow, nil, pgx.TraceQueryEndData{})
+	if len(tracer.pending) != 1 {
+		t.Fatalf("expected slow query to be queued, queued %d", len(tracer.pending))
+	}
+	queued := <-tracer.pending
+	if queued.sql != "SELECT id FROM posts" || queued.explain {
+		t.Fatalf("unexpected queued query: %+v", queued)
+	}
+
+	untraced := context.WithValue(context.Background(), untracedKey{}, true)
+	if ctx := tracer.TraceQueryStart(untraced, nil, pgx.TraceQueryStartData{SQL: "SELECT 1"}); ctx.Value(queryStartKey{}) != nil {
+		t.Fatal("expected recorder queries to skip tracing")
+	}
+}
@@ -0,0 +1,184 @@
+// Package envelope encrypts small records with per-record-owner data keys
+// that are themselves wrapped by master keys held by a KeyProvider. Only
+// wrapped data keys are stored; rotating a master key rewraps them without
+// touching the records.
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personaworlds/backend/internal/config"
+)
+
+// DataKeySize is the length of generated data keys (AES-256).
+const DataKeySize = 32
+
+var (
+	ErrUnknownKey = errors.New("envelope: unknown master key")
+	ErrDecrypt    = errors.New("envelope: message authentication failed")
+)
+
+// KeyProvider wraps and unwraps data keys under master keys it never hands
+// out, the way a KMS does. LocalKeyProvider keeps the master keys in
+// process; a KMS-backed provider implements the same methods.
+type KeyProvider interface {
+	// ActiveKeyID names the master key new data keys are wrapped with.
+	ActiveKeyID() string
+	Wrap(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	// Unwrap returns ErrUnknownKey when keyID is not (or no longer) held.
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyProvider wraps data keys with AES-256-GCM master keys from the
+// CALIBRATION_KEYS setting.
+type LocalKeyProvider struct {
+	active string
+	keys   map[string]cipher.AEAD
+}
+
+// NewKeyProvider builds the provider configured by CALIBRATION_KEYS, a
+// comma-separated list of id:base64 AES-256 keys with the active key first.
+// Keys after the first are only used to unwrap, so a rotated-out key stays
+// listed until cmd/rotatekeys has rewrapped everything under the new one.
+// It returns nil, and no error, when no keys are configured.
+func NewKeyProvider(cfg config.Config) (KeyProvider, error) {
+	if strings.TrimSpace(cfg.CalibrationKeys) == "" {
+		return nil, nil
+	}
+	return ParseLocalKeys(cfg.CalibrationKeys)
+}
+
+// MustKeyProvider is NewKeyProvider for callers whose configuration was
+// already checked at startup.
+func MustKeyProvider(cfg config.Config) KeyProvider {
+	provider, err := NewKeyProvider(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return provider
+}
+
+// ParseLocalKeys reads a CALIBRATION_KEYS value.
+func ParseLocalKeys(spec string) (*LocalKeyProvider, error) {
+	provider := &LocalKeyProvider{keys: map[string]cipher.AEAD{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("envelope: key %q must be id:base64", part)
+		}
+		if _, exists := provider.keys[id]; exists {
+			return nil, fmt.Errorf("envelope: duplicate key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != DataKeySize {
+			return nil, fmt.Errorf("envelope: key %q must be %d base64-encoded bytes", id, DataKeySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		provider.keys[id] = aead
+		if provider.active == "" {
+			provider.active = id
+		}
+	}
+	if provider.active == "" {
+		return nil, errors.New("envelope: no keys configured")
+	}
+	return provider, nil
+}
+
+func (p *LocalKeyProvider) ActiveKeyID() string {
+	return p.active
+}
+
+func (p *LocalKeyProvider) Wrap(_ context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	nonce, ciphertext, err := seal(aead, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+func (p *LocalKeyProvider) Unwrap(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	return open(aead, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
+
+// GenerateDataKey returns a fresh random data key.
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with dataKey. aad is authenticated but not
+// encrypted; Open needs the same aad, which binds a record to its owner
+// and field.
+func Seal(dataKey, plaintext, aad []byte) (nonce, ciphertext []byte, err error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return seal(aead, plaintext, aad)
+}
+
+// Open decrypts what Seal returned.
+func Open(dataKey, nonce, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	return open(aead, nonce, ciphertext, aad)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, nonce, ciphertext, aad []byte) ([]byte, error) {
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
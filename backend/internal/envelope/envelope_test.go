@@ -0,0 +1,83 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, DataKeySize))
+}
+
+func TestParseLocalKeysUsesFirstKeyAsActive(t *testing.T) {
+	provider, err := ParseLocalKeys("k2:" + testKey(2) + ", k1:" + testKey(1))
+	if err != nil {
+		t.Fatalf("parse keys: %v", err)
+	}
+	if provider.ActiveKeyID() != "k2" {
+		t.Fatalf("expected k2 active, got %q", provider.ActiveKeyID())
+	}
+
+	for _, spec := range []string{"", "k1", "k1:short", "k1:" + testKey(1) + ",k1:" + testKey(2)} {
+		if _, err := ParseLocalKeys(spec); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestWrapUnwrapRoundTripsAcrossKeys(t *testing.T) {
+	ctx := context.Background()
+	provider, err := ParseLocalKeys("k2:" + testKey(2) + ",k1:" + testKey(1))
+	if err != nil {
+		t.Fatalf("parse keys: %v", err)
+	}
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("generate data key: %v", err)
+	}
+
+	wrapped, err := provider.Wrap(ctx, "k1", dataKey)
+	if err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	if bytes.Contains(wrapped, dataKey) {
+		t.Fatalf("wrapped key contains the data key")
+	}
+	unwrapped, err := provider.Unwrap(ctx, "k1", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("unwrap: %v", err)
+	}
+	if _, err := provider.Unwrap(ctx, "k2", wrapped); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected unwrap under the wrong key to fail, got %v", err)
+	}
+	if _, err := provider.Unwrap(ctx, "k3", wrapped); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+}
+
+func TestSealBindsAssociatedData(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("generate data key: %v", err)
+	}
+	plaintext := []byte(`["never say guaranteed"]`)
+	nonce, ciphertext, err := Seal(dataKey, plaintext, []byte("user-1/do_not_say"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if strings.Contains(string(ciphertext), "guaranteed") {
+		t.Fatalf("ciphertext leaks plaintext")
+	}
+
+	opened, err := Open(dataKey, nonce, ciphertext, []byte("user-1/do_not_say"))
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("open: %q, %v", opened, err)
+	}
+	if _, err := Open(dataKey, nonce, ciphertext, []byte("user-2/do_not_say")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected other associated data to fail, got %v", err)
+	}
+}
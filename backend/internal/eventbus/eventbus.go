@@ -0,0 +1,94 @@
+// Package eventbus mirrors product events to an external bus for analytics
+// consumers. Messages come from the event_outbox table and are delivered at
+// least once, so consumers de-duplicate by Message.ID.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	KindNATS  = "nats"
+	KindKafka = "kafka"
+)
+
+// Message types and the schema version each is currently written with. A
+// breaking change to a payload bumps its version rather than its type.
+const (
+	TypeAnalyticsEvent  = "analytics.event"
+	TypePostPublished   = "post.published"
+	TypeBattleCompleted = "battle.completed"
+)
+
+// Message is the envelope every type is published in.
+type Message struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// NewMessage wraps an outbox row. The outbox id is the message id, so a
+// message relayed twice keeps its id.
+func NewMessage(outboxID int64, messageType string, schemaVersion int, occurredAt time.Time, data json.RawMessage) Message {
+	return Message{
+		ID:            strconv.FormatInt(outboxID, 10),
+		Type:          messageType,
+		SchemaVersion: schemaVersion,
+		OccurredAt:    occurredAt.UTC(),
+		Data:          data,
+	}
+}
+
+// Publisher delivers a batch of messages. It returns an error unless every
+// message was accepted by the bus.
+type Publisher interface {
+	Publish(ctx context.Context, messages []Message) error
+	Close() error
+}
+
+type Config struct {
+	Kind string
+	// URL is nats://[user:pass@]host:port for NATS and the Kafka REST Proxy
+	// base URL for Kafka.
+	URL         string
+	TopicPrefix string
+	Timeout     time.Duration
+}
+
+// New returns the publisher for cfg.Kind.
+func New(cfg Config) (Publisher, error) {
+	if strings.TrimSpace(cfg.URL) == "" {
+		return nil, fmt.Errorf("event bus %q needs a url", cfg.Kind)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	switch cfg.Kind {
+	case KindNATS:
+		publisher, err := newNATSPublisher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return publisher, nil
+	case KindKafka:
+		publisher, err := newKafkaPublisher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return publisher, nil
+	default:
+		return nil, fmt.Errorf("unknown event bus %q", cfg.Kind)
+	}
+}
+
+// Topic is the NATS subject or Kafka topic messageType is published to.
+func Topic(prefix, messageType string) string {
+	return prefix + messageType
+}
@@ -0,0 +1,207 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessages() []Message {
+	occurredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []Message{
+		NewMessage(7, TypeAnalyticsEvent, 1, occurredAt, json.RawMessage(`{"event_name":"signup"}`)),
+		NewMessage(8, TypePostPublished, 1, occurredAt, json.RawMessage(`{"post_id":"p1"}`)),
+		NewMessage(9, TypeAnalyticsEvent, 1, occurredAt, json.RawMessage(`{"event_name":"login"}`)),
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	cases := []Config{
+		{Kind: KindNATS},
+		{Kind: "redis", URL: "redis://localhost"},
+		{Kind: KindNATS, URL: "http://localhost:4222"},
+		{Kind: KindKafka, URL: "kafka://localhost:9092"},
+	}
+	for _, cfg := range cases {
+		if _, err := New(cfg); err == nil {
+			t.Fatalf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+type natsPub struct {
+	Subject string
+	Payload Message
+}
+
+// fakeNATSServer accepts one connection and records its PUBs. reject makes
+// it answer the first PING after a PUB with -ERR.
+func fakeNATSServer(t *testing.T, reject bool) (string, <-chan natsPub, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	pubs := make(chan natsPub, 16)
+	connects := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576}` + "\r\n"))
+		reader := bufio.NewReader(conn)
+		published := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connects <- strings.TrimPrefix(line, "CONNECT ")
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				size, _ := strconv.Atoi(fields[2])
+				body := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, body); err != nil {
+					return
+				}
+				var message Message
+				json.Unmarshal(body[:size], &message)
+				pubs <- natsPub{Subject: fields[1], Payload: message}
+				published = true
+			case line == "PING":
+				if reject && published {
+					conn.Write([]byte("-ERR 'Permissions Violation for Publish'\r\n"))
+					continue
+				}
+				conn.Write([]byte("PONG\r\n"))
+			}
+		}
+	}()
+	return listener.Addr().String(), pubs, connects
+}
+
+func TestNATSPublisherPublishesToPrefixedSubjects(t *testing.T) {
+	address, pubs, connects := fakeNATSServer(t, false)
+	publisher, err := New(Config{Kind: KindNATS, URL: "nats://analytics:secret@" + address, TopicPrefix: "pw.", Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer publisher.Close()
+
+	if err := publisher.Publish(context.Background(), testMessages()); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	var options map[string]any
+	if err := json.Unmarshal([]byte(<-connects), &options); err != nil {
+		t.Fatalf("connect options: %v", err)
+	}
+	if options["user"] != "analytics" || options["pass"] != "secret" || options["verbose"] != false {
+		t.Fatalf("unexpected connect options %v", options)
+	}
+
+	want := []natsPub{
+		{Subject: "pw.analytics.event"},
+		{Subject: "pw.post.published"},
+		{Subject: "pw.analytics.event"},
+	}
+	for i, expected := range want {
+		got := <-pubs
+		if got.Subject != expected.Subject {
+			t.Fatalf("pub %d subject = %q, want %q", i, got.Subject, expected.Subject)
+		}
+		if got.Payload.ID != testMessages()[i].ID || got.Payload.SchemaVersion != 1 {
+			t.Fatalf("pub %d payload = %+v", i, got.Payload)
+		}
+	}
+}
+
+func TestNATSPublisherReportsServerErrors(t *testing.T) {
+	address, _, _ := fakeNATSServer(t, true)
+	publisher, err := New(Config{Kind: KindNATS, URL: "nats://" + address, TopicPrefix: "pw.", Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.Publish(context.Background(), testMessages())
+	if err == nil || !strings.Contains(err.Error(), "Permissions Violation") {
+		t.Fatalf("expected permissions error, got %v", err)
+	}
+}
+
+func TestKafkaPublisherGroupsRecordsByTopic(t *testing.T) {
+	type produceRequest struct {
+		Records []kafkaRecord `json:"records"`
+	}
+	requests := map[string]produceRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != kafkaJSONContentType {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		var body produceRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		requests[r.URL.Path] = body
+		offsets := []map[string]any{}
+		for i := range body.Records {
+			offsets = append(offsets, map[string]any{"partition": 0, "offset": i})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"offsets": offsets})
+	}))
+	defer server.Close()
+
+	publisher, err := New(Config{Kind: KindKafka, URL: server.URL + "/", TopicPrefix: "pw."})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	if err := publisher.Publish(context.Background(), testMessages()); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	events := requests["/topics/pw.analytics.event"]
+	if len(events.Records) != 2 || events.Records[0].Key != "7" || events.Records[1].Key != "9" {
+		t.Fatalf("unexpected analytics records %+v", events.Records)
+	}
+	posts := requests["/topics/pw.post.published"]
+	if len(posts.Records) != 1 || posts.Records[0].Value.Type != TypePostPublished {
+		t.Fatalf("unexpected post records %+v", posts.Records)
+	}
+}
+
+func TestKafkaPublisherFailsOnRecordErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"offsets": []map[string]any{
+			{"partition": 0, "offset": 1},
+			{"error_code": 50002, "error": "leader not available"},
+		}})
+	}))
+	defer server.Close()
+
+	publisher, err := New(Config{Kind: KindKafka, URL: server.URL})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	messages := testMessages()
+	messages[1].Type = TypeAnalyticsEvent
+	err = publisher.Publish(context.Background(), messages[:2])
+	if err == nil || !strings.Contains(err.Error(), "leader not available") {
+		t.Fatalf("expected record error, got %v", err)
+	}
+}
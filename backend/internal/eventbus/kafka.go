@@ -0,0 +1,111 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+
+// kafkaPublisher produces through a Kafka REST Proxy (v2 API), one request
+// per topic in the batch. Records are keyed by message id so retries of the
+// same message land on the same partition.
+type kafkaPublisher struct {
+	baseURL     string
+	topicPrefix string
+	client      *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string  `json:"key"`
+	Value Message `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition *int   `json:"partition"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+func newKafkaPublisher(cfg Config) (*kafkaPublisher, error) {
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("kafka url must be the http(s) address of a Kafka REST Proxy")
+	}
+	return &kafkaPublisher{
+		baseURL:     strings.TrimRight(cfg.URL, "/"),
+		topicPrefix: cfg.TopicPrefix,
+		client:      &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, messages []Message) error {
+	topics := []string{}
+	byTopic := map[string][]kafkaRecord{}
+	for _, message := range messages {
+		topic := Topic(p.topicPrefix, message.Type)
+		if _, ok := byTopic[topic]; !ok {
+			topics = append(topics, topic)
+		}
+		byTopic[topic] = append(byTopic[topic], kafkaRecord{Key: message.ID, Value: message})
+	}
+	for _, topic := range topics {
+		if err := p.produce(ctx, topic, byTopic[topic]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) produce(ctx context.Context, topic string, records []kafkaRecord) error {
+	body, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/topics/"+url.PathEscape(topic), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka rest proxy returned %d for %s: %s", resp.StatusCode, topic, strings.TrimSpace(string(raw)))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(raw, &produced); err != nil {
+		return fmt.Errorf("invalid kafka rest proxy response: %w", err)
+	}
+	if len(produced.Offsets) != len(records) {
+		return fmt.Errorf("kafka rest proxy acknowledged %d of %d records for %s", len(produced.Offsets), len(records), topic)
+	}
+	for i, offset := range produced.Offsets {
+		if offset.ErrorCode != nil || offset.Error != "" {
+			return fmt.Errorf("kafka rejected message %s on %s: %s", records[i].Key, topic, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,239 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsPublisher speaks the core NATS text protocol: CONNECT once, then PUB
+// each message and PING, treating the server's PONG as confirmation that
+// everything before it was processed. Subjects are not persisted by core
+// NATS; point consumers at a JetStream stream bound to the subjects for
+// durable delivery.
+type natsPublisher struct {
+	address     string
+	useTLS      bool
+	user        string
+	password    string
+	token       string
+	topicPrefix string
+	timeout     time.Duration
+
+	mu         sync.Mutex
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int
+}
+
+type natsInfo struct {
+	MaxPayload int `json:"max_payload"`
+}
+
+func newNATSPublisher(cfg Config) (*natsPublisher, error) {
+	parsed, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats url: %w", err)
+	}
+	publisher := &natsPublisher{
+		address:     parsed.Host,
+		topicPrefix: cfg.TopicPrefix,
+		timeout:     cfg.Timeout,
+	}
+	switch parsed.Scheme {
+	case "nats":
+	case "tls":
+		publisher.useTLS = true
+	default:
+		return nil, fmt.Errorf("nats url must use nats:// or tls://")
+	}
+	if parsed.Port() == "" {
+		publisher.address = net.JoinHostPort(parsed.Hostname(), "4222")
+	}
+	if parsed.User != nil {
+		if password, ok := parsed.User.Password(); ok {
+			publisher.user = parsed.User.Username()
+			publisher.password = password
+		} else {
+			publisher.token = parsed.User.Username()
+		}
+	}
+	return publisher, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, messages); err != nil {
+		// The connection state is unknown after a failure; start over on
+		// the next batch.
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, messages []Message) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := p.conn.SetDeadline(p.deadline(ctx)); err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	for _, message := range messages {
+		payload, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		if p.maxPayload > 0 && len(payload) > p.maxPayload {
+			return fmt.Errorf("message %s is %d bytes, over the server limit of %d", message.ID, len(payload), p.maxPayload)
+		}
+		fmt.Fprintf(&buf, "PUB %s %d\r\n", Topic(p.topicPrefix, message.Type), len(payload))
+		buf.Write(payload)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("PING\r\n")
+	if _, err := p.conn.Write([]byte(buf.String())); err != nil {
+		return err
+	}
+	return p.awaitPong()
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(p.deadline(ctx)); err != nil {
+		conn.Close()
+		return err
+	}
+	reader := bufio.NewReader(conn)
+
+	line, err := readNATSLine(reader)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	rawInfo, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		conn.Close()
+		return fmt.Errorf("unexpected nats greeting %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(rawInfo), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("invalid nats info: %w", err)
+	}
+
+	if p.useTLS {
+		host, _, _ := net.SplitHostPort(p.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"lang":     "go",
+		"name":     "personaworlds-event-relay",
+	}
+	if p.token != "" {
+		options["auth_token"] = p.token
+	}
+	if p.user != "" {
+		options["user"] = p.user
+		options["pass"] = p.password
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.reader = reader
+	p.maxPayload = info.MaxPayload
+	return p.awaitPong()
+}
+
+// awaitPong reads until the server answers the last PING, replying to its
+// own PINGs and failing on -ERR.
+func (p *natsPublisher) awaitPong() error {
+	for {
+		line, err := readNATSLine(p.reader)
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *natsPublisher) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(p.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+	return deadline
+}
+
+func (p *natsPublisher) closeConn() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.reader = nil
+}
+
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
+
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, net.ErrClosed) {
+			return "", errors.New("nats connection closed")
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,267 @@
+package experiments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/flags"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	StatusDraft   = "draft"
+	StatusRunning = "running"
+	StatusStopped = "stopped"
+
+	MaxVariants         = 5
+	MaxConversionEvents = 10
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,63}$`)
+
+// Variant is one arm of an experiment. Users are split between variants in
+// proportion to their weights. The first variant is the control the others
+// are compared against.
+type Variant struct {
+	Key    string `json:"key"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment is a test definition plus its exposure rules: only running
+// experiments expose anyone, TrafficPercent of users take part, and
+// NewUsersOnly limits it to users who signed up after it started.
+type Experiment struct {
+	ID               string     `json:"id"`
+	Description      string     `json:"description"`
+	Variants         []Variant  `json:"variants"`
+	TrafficPercent   int        `json:"traffic_percent"`
+	NewUsersOnly     bool       `json:"new_users_only"`
+	ConversionEvents []string   `json:"conversion_events"`
+	Status           string     `json:"status"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	StoppedAt        *time.Time `json:"stopped_at,omitempty"`
+	UpdatedBy        string     `json:"updated_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Subject is the user an assignment is made for.
+type Subject struct {
+	UserID     string
+	SignedUpAt time.Time
+}
+
+// NormalizeID validates an experiment or variant key: lowercase snake case,
+// like flag keys.
+func NormalizeID(value string) (string, error) {
+	id := strings.ToLower(strings.TrimSpace(value))
+	if !keyPattern.MatchString(id) {
+		return "", errors.New("experiment id must be 2-64 lowercase letters, digits or underscores")
+	}
+	return id, nil
+}
+
+// Validate checks the variants and rules of e and normalizes variant keys.
+// Conversion event names are checked by the caller, which owns the list of
+// supported events.
+func (e *Experiment) Validate() error {
+	if len(e.Variants) < 2 || len(e.Variants) > MaxVariants {
+		return fmt.Errorf("experiments need 2-%d variants", MaxVariants)
+	}
+	seen := map[string]struct{}{}
+	for i, variant := range e.Variants {
+		key := strings.ToLower(strings.TrimSpace(variant.Key))
+		if !keyPattern.MatchString(key) {
+			return errors.New("variant keys must be 2-64 lowercase letters, digits or underscores")
+		}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate variant %q", key)
+		}
+		seen[key] = struct{}{}
+		if variant.Weight < 1 || variant.Weight > 100 {
+			return errors.New("variant weights must be between 1 and 100")
+		}
+		e.Variants[i].Key = key
+	}
+	if e.TrafficPercent < 0 || e.TrafficPercent > 100 {
+		return errors.New("traffic_percent must be between 0 and 100")
+	}
+	if len(e.ConversionEvents) == 0 || len(e.ConversionEvents) > MaxConversionEvents {
+		return fmt.Errorf("experiments need 1-%d conversion_events", MaxConversionEvents)
+	}
+	switch e.Status {
+	case StatusDraft, StatusRunning, StatusStopped:
+	default:
+		return errors.New("status must be draft, running or stopped")
+	}
+	return nil
+}
+
+// Assign returns the variant subject gets, or false when the exposure rules
+// leave them out. Traffic and variant use separate buckets, so raising
+// TrafficPercent adds users without moving anyone between variants.
+func Assign(e Experiment, subject Subject) (string, bool) {
+	if e.Status != StatusRunning || subject.UserID == "" || len(e.Variants) == 0 {
+		return "", false
+	}
+	if e.NewUsersOnly && (e.StartedAt == nil || subject.SignedUpAt.Before(*e.StartedAt)) {
+		return "", false
+	}
+	if flags.Bucket("experiment:"+e.ID, subject.UserID) >= e.TrafficPercent {
+		return "", false
+	}
+
+	total := 0
+	for _, variant := range e.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return "", false
+	}
+	point := flags.Bucket("experiment_variant:"+e.ID, subject.UserID) * total / 100
+	for _, variant := range e.Variants {
+		if point < variant.Weight {
+			return variant.Key, true
+		}
+		point -= variant.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Key, true
+}
+
+type Store interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+}
+
+// Load reads experiment id. It returns pgx.ErrNoRows when there is none.
+func Load(ctx context.Context, store Store, id string) (Experiment, error) {
+	var (
+		experiment Experiment
+		variants   []byte
+	)
+	err := store.QueryRow(ctx, `
+		SELECT id, description, variants, traffic_percent, new_users_only, conversion_events, status, started_at, stopped_at, COALESCE(updated_by::text, ''), created_at, updated_at
+		FROM experiments
+		WHERE id = $1
+	`, id).Scan(
+		&experiment.ID,
+		&experiment.Description,
+		&variants,
+		&experiment.TrafficPercent,
+		&experiment.NewUsersOnly,
+		&experiment.ConversionEvents,
+		&experiment.Status,
+		&experiment.StartedAt,
+		&experiment.StoppedAt,
+		&experiment.UpdatedBy,
+		&experiment.CreatedAt,
+		&experiment.UpdatedAt,
+	)
+	if err != nil {
+		return Experiment{}, err
+	}
+	if err := json.Unmarshal(variants, &experiment.Variants); err != nil {
+		return Experiment{}, err
+	}
+	return experiment, nil
+}
+
+// VariantCounts is what a report is computed from: users exposed to a
+// variant and, per conversion event, how many of them logged it after
+// their exposure.
+type VariantCounts struct {
+	Variant     string
+	Exposed     int
+	Conversions map[string]int
+}
+
+type EventResult struct {
+	Event string  `json:"event"`
+	Users int     `json:"users"`
+	Rate  float64 `json:"rate"`
+	// Delta is the difference in rate from the control, in rate units.
+	Delta float64 `json:"delta"`
+	// Lift is Delta relative to the control's rate; 0 when the control
+	// has not converted yet.
+	Lift float64 `json:"lift"`
+	// ZScore is the two-proportion z statistic against the control.
+	// |z| >= 1.96 is roughly 95% confidence.
+	ZScore float64 `json:"z_score"`
+}
+
+type VariantResult struct {
+	Variant     string        `json:"variant"`
+	Control     bool          `json:"control"`
+	Exposed     int           `json:"exposed"`
+	Conversions []EventResult `json:"conversions"`
+}
+
+// Compare turns raw counts into per-variant conversion rates and deltas
+// against the first variant in variants. Variants without exposures are
+// reported with zeros.
+func Compare(variants []Variant, events []string, counts []VariantCounts) []VariantResult {
+	byVariant := make(map[string]VariantCounts, len(counts))
+	for _, count := range counts {
+		byVariant[count.Variant] = count
+	}
+
+	results := make([]VariantResult, 0, len(variants))
+	var control VariantCounts
+	for i, variant := range variants {
+		count := byVariant[variant.Key]
+		if i == 0 {
+			control = count
+		}
+		result := VariantResult{
+			Variant:     variant.Key,
+			Control:     i == 0,
+			Exposed:     count.Exposed,
+			Conversions: make([]EventResult, 0, len(events)),
+		}
+		for _, event := range events {
+			users := count.Conversions[event]
+			rate := conversionRate(users, count.Exposed)
+			controlRate := conversionRate(control.Conversions[event], control.Exposed)
+			eventResult := EventResult{Event: event, Users: users, Rate: round4(rate)}
+			if i > 0 {
+				eventResult.Delta = round4(rate - controlRate)
+				if controlRate > 0 {
+					eventResult.Lift = round4((rate - controlRate) / controlRate)
+				}
+				eventResult.ZScore = round4(zScore(control.Conversions[event], control.Exposed, users, count.Exposed))
+			}
+			result.Conversions = append(result.Conversions, eventResult)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func conversionRate(users, exposed int) float64 {
+	if exposed <= 0 {
+		return 0
+	}
+	return float64(users) / float64(exposed)
+}
+
+func zScore(controlUsers, controlExposed, users, exposed int) float64 {
+	if controlExposed <= 0 || exposed <= 0 {
+		return 0
+	}
+	pooled := float64(controlUsers+users) / float64(controlExposed+exposed)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(controlExposed) + 1/float64(exposed)))
+	if se == 0 {
+		return 0
+	}
+	return (conversionRate(users, exposed) - conversionRate(controlUsers, controlExposed)) / se
+}
+
+func round4(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
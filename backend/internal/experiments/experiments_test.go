@@ -0,0 +1,121 @@
+package experiments
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func runningExperiment() Experiment {
+	started := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	return Experiment{
+		ID:               "remix_cta_copy",
+		Variants:         []Variant{{Key: "control", Weight: 50}, {Key: "try_it", Weight: 50}},
+		TrafficPercent:   100,
+		ConversionEvents: []string{"remix_clicked"},
+		Status:           StatusRunning,
+		StartedAt:        &started,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	experiment := runningExperiment()
+	experiment.Variants[1].Key = " Try_It "
+	if err := experiment.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if experiment.Variants[1].Key != "try_it" {
+		t.Fatalf("expected variant key to be normalized, got %q", experiment.Variants[1].Key)
+	}
+
+	for name, mutate := range map[string]func(*Experiment){
+		"one variant":       func(e *Experiment) { e.Variants = e.Variants[:1] },
+		"duplicate variant": func(e *Experiment) { e.Variants[1].Key = "control" },
+		"zero weight":       func(e *Experiment) { e.Variants[0].Weight = 0 },
+		"traffic over 100":  func(e *Experiment) { e.TrafficPercent = 120 },
+		"no events":         func(e *Experiment) { e.ConversionEvents = nil },
+		"unknown status":    func(e *Experiment) { e.Status = "paused" },
+	} {
+		bad := runningExperiment()
+		bad.Variants = append([]Variant(nil), bad.Variants...)
+		mutate(&bad)
+		if err := bad.Validate(); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAssignIsDeterministicAndWeighted(t *testing.T) {
+	experiment := runningExperiment()
+	experiment.Variants = []Variant{{Key: "control", Weight: 80}, {Key: "try_it", Weight: 20}}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		subject := Subject{UserID: fmt.Sprintf("user-%d", i)}
+		variant, ok := Assign(experiment, subject)
+		if !ok {
+			t.Fatalf("expected full traffic to enroll %s", subject.UserID)
+		}
+		if again, _ := Assign(experiment, subject); again != variant {
+			t.Fatalf("expected a stable variant for %s", subject.UserID)
+		}
+		counts[variant]++
+	}
+	if share := float64(counts["try_it"]) / 4000; share < 0.15 || share > 0.25 {
+		t.Fatalf("expected about 20%% in try_it, got %.2f", share)
+	}
+}
+
+func TestAssignExposureRules(t *testing.T) {
+	experiment := runningExperiment()
+	subject := Subject{UserID: "user-1", SignedUpAt: experiment.StartedAt.Add(-time.Hour)}
+
+	draft := experiment
+	draft.Status = StatusDraft
+	if _, ok := Assign(draft, subject); ok {
+		t.Fatal("expected draft experiments to enroll nobody")
+	}
+	if _, ok := Assign(experiment, Subject{}); ok {
+		t.Fatal("expected no enrollment without a user")
+	}
+
+	newUsers := experiment
+	newUsers.NewUsersOnly = true
+	if _, ok := Assign(newUsers, subject); ok {
+		t.Fatal("expected users from before the start to be left out")
+	}
+	subject.SignedUpAt = experiment.StartedAt.Add(time.Hour)
+	if _, ok := Assign(newUsers, subject); !ok {
+		t.Fatal("expected a new user to be enrolled")
+	}
+
+	closed := experiment
+	closed.TrafficPercent = 0
+	if _, ok := Assign(closed, subject); ok {
+		t.Fatal("expected zero traffic to enroll nobody")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	variants := []Variant{{Key: "control", Weight: 50}, {Key: "try_it", Weight: 50}, {Key: "unused", Weight: 10}}
+	results := Compare(variants, []string{"remix_clicked"}, []VariantCounts{
+		{Variant: "try_it", Exposed: 1000, Conversions: map[string]int{"remix_clicked": 150}},
+		{Variant: "control", Exposed: 1000, Conversions: map[string]int{"remix_clicked": 100}},
+	})
+	if len(results) != 3 || !results[0].Control || results[0].Variant != "control" {
+		t.Fatalf("expected control first, got %+v", results)
+	}
+	treatment := results[1].Conversions[0]
+	if treatment.Rate != 0.15 || treatment.Delta != 0.05 || treatment.Lift != 0.5 {
+		t.Fatalf("unexpected treatment result %+v", treatment)
+	}
+	if treatment.ZScore < 1.96 {
+		t.Fatalf("expected a significant z score, got %.2f", treatment.ZScore)
+	}
+	if results[0].Conversions[0].Delta != 0 || results[0].Conversions[0].ZScore != 0 {
+		t.Fatalf("expected no delta for the control, got %+v", results[0].Conversions[0])
+	}
+	if unused := results[2]; unused.Exposed != 0 || unused.Conversions[0].Rate != 0 {
+		t.Fatalf("expected zeros for an unexposed variant, got %+v", unused)
+	}
+}
@@ -0,0 +1,207 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// PublicQA gates asking personas questions on their public profile.
+const PublicQA = "public_qa"
+
+// Defaults are the flags code checks, as they behave in an environment that
+// has no feature_flags row for them. Features that already shipped default
+// on so adding a flag does not switch them off; a new risky feature should
+// register its key here defaulting off and be rolled out per environment.
+var Defaults = map[string]Flag{
+	PublicQA: {
+		Key:            PublicQA,
+		Enabled:        true,
+		RolloutPercent: 100,
+		Description:    "Visitors can ask personas questions on public profiles.",
+	},
+}
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,63}$`)
+
+// Flag is a feature switch for one environment. An enabled flag with a
+// rollout below 100 is on only for the share of subjects whose bucket falls
+// under RolloutPercent.
+type Flag struct {
+	Key            string    `json:"key"`
+	Environment    string    `json:"environment"`
+	Enabled        bool      `json:"enabled"`
+	RolloutPercent int       `json:"rollout_percent"`
+	Description    string    `json:"description"`
+	UpdatedBy      string    `json:"updated_by,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+	Default        bool      `json:"default"`
+}
+
+// NormalizeKey validates a flag key: lowercase snake case, like the event
+// and notification names.
+func NormalizeKey(value string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(value))
+	if !keyPattern.MatchString(key) {
+		return "", errors.New("flag key must be 2-64 lowercase letters, digits or underscores")
+	}
+	return key, nil
+}
+
+// ValidateRollout checks a rollout percentage.
+func ValidateRollout(percent int) error {
+	if percent < 0 || percent > 100 {
+		return errors.New("rollout_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// Bucket places subject in one of 100 buckets for key. Hashing the key with
+// the subject keeps a user's bucket stable as a rollout grows, while
+// different flags reach different users first.
+func Bucket(key, subject string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(subject))
+	return int(h.Sum32() % 100)
+}
+
+// Set is the flags of one environment, keyed by flag key.
+type Set map[string]Flag
+
+// Enabled reports whether key is on for subject, usually a user id. Unknown
+// keys are off. A partial rollout needs a subject to bucket; without one the
+// flag is off until the rollout reaches 100.
+func (s Set) Enabled(key, subject string) bool {
+	flag, ok := s[key]
+	if !ok {
+		flag, ok = Defaults[key]
+	}
+	if !ok || !flag.Enabled || flag.RolloutPercent <= 0 {
+		return false
+	}
+	if flag.RolloutPercent >= 100 {
+		return true
+	}
+	if subject == "" {
+		return false
+	}
+	return Bucket(key, subject) < flag.RolloutPercent
+}
+
+// List returns every flag of the set plus the defaults it does not
+// override, sorted by key.
+func (s Set) List(environment string) []Flag {
+	out := make([]Flag, 0, len(s)+len(Defaults))
+	for _, flag := range s {
+		out = append(out, flag)
+	}
+	for key, flag := range Defaults {
+		if _, ok := s[key]; ok {
+			continue
+		}
+		flag.Environment = environment
+		flag.Default = true
+		out = append(out, flag)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+type Store interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}
+
+// Load reads the flags stored for environment.
+func Load(ctx context.Context, store Store, environment string) (Set, error) {
+	rows, err := store.Query(ctx, `
+		SELECT key, environment, enabled, rollout_percent, description, COALESCE(updated_by::text, ''), updated_at
+		FROM feature_flags
+		WHERE environment = $1
+	`, environment)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := Set{}
+	for rows.Next() {
+		var flag Flag
+		if err := rows.Scan(
+			&flag.Key,
+			&flag.Environment,
+			&flag.Enabled,
+			&flag.RolloutPercent,
+			&flag.Description,
+			&flag.UpdatedBy,
+			&flag.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		out[flag.Key] = flag
+	}
+	return out, rows.Err()
+}
+
+// Cache keeps the flags of the process's environment in memory and reloads
+// them at most once per interval, so admin toggles reach API and worker
+// processes without a restart.
+type Cache struct {
+	environment string
+
+	mu       sync.RWMutex
+	flags    Set
+	loaded   bool
+	syncedAt time.Time
+}
+
+func NewCache(environment string) *Cache {
+	return &Cache{environment: environment}
+}
+
+func (c *Cache) Environment() string {
+	return c.environment
+}
+
+// Load returns the cached flags, reloading them through store when the last
+// sync is older than every. When a reload fails the last loaded flags (or
+// an empty set, which leaves every flag at its default) are returned
+// together with the error.
+func (c *Cache) Load(ctx context.Context, every time.Duration, store Store) (Set, error) {
+	c.mu.RLock()
+	flags, loaded := c.flags, c.loaded
+	fresh := loaded && time.Since(c.syncedAt) < every
+	c.mu.RUnlock()
+	if fresh {
+		return flags, nil
+	}
+
+	next, err := Load(ctx, store, c.environment)
+	if err != nil {
+		if loaded {
+			return flags, err
+		}
+		return Set{}, err
+	}
+	c.mu.Lock()
+	c.flags = next
+	c.loaded = true
+	c.syncedAt = time.Now()
+	c.mu.Unlock()
+	return next, nil
+}
+
+// Invalidate makes the next Load read from the database.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.syncedAt = time.Time{}
+	c.mu.Unlock()
+}
@@ -0,0 +1,100 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSetEnabled(t *testing.T) {
+	set := Set{
+		"off":     {Key: "off", Enabled: false, RolloutPercent: 100},
+		"on":      {Key: "on", Enabled: true, RolloutPercent: 100},
+		"zero":    {Key: "zero", Enabled: true, RolloutPercent: 0},
+		"partial": {Key: "partial", Enabled: true, RolloutPercent: 30},
+	}
+
+	if set.Enabled("off", "user-1") {
+		t.Fatal("expected disabled flag to be off")
+	}
+	if !set.Enabled("on", "") {
+		t.Fatal("expected full rollout to be on without a subject")
+	}
+	if set.Enabled("zero", "user-1") {
+		t.Fatal("expected zero rollout to be off")
+	}
+	if set.Enabled("partial", "") {
+		t.Fatal("expected partial rollout to be off without a subject")
+	}
+	if set.Enabled("missing", "user-1") {
+		t.Fatal("expected unknown flag to be off")
+	}
+}
+
+func TestSetEnabledFallsBackToDefaults(t *testing.T) {
+	if !(Set{}).Enabled(PublicQA, "") {
+		t.Fatal("expected public Q&A to default on")
+	}
+	set := Set{PublicQA: {Key: PublicQA, Enabled: false, RolloutPercent: 100}}
+	if set.Enabled(PublicQA, "user-1") {
+		t.Fatal("expected stored row to override default")
+	}
+}
+
+func TestPartialRolloutIsStableAndProportional(t *testing.T) {
+	set := Set{"partial": {Key: "partial", Enabled: true, RolloutPercent: 25}}
+	wider := Set{"partial": {Key: "partial", Enabled: true, RolloutPercent: 60}}
+
+	on := 0
+	for i := 0; i < 2000; i++ {
+		subject := fmt.Sprintf("user-%d", i)
+		enabled := set.Enabled("partial", subject)
+		if enabled != set.Enabled("partial", subject) {
+			t.Fatalf("expected stable result for %s", subject)
+		}
+		if enabled && !wider.Enabled("partial", subject) {
+			t.Fatalf("expected %s to stay enabled as the rollout grows", subject)
+		}
+		if enabled {
+			on++
+		}
+	}
+	if on < 400 || on > 600 {
+		t.Fatalf("expected roughly 25%% of subjects enabled, got %d of 2000", on)
+	}
+}
+
+func TestBucketDependsOnKey(t *testing.T) {
+	differ := false
+	for i := 0; i < 20; i++ {
+		subject := fmt.Sprintf("user-%d", i)
+		if Bucket("a_flag", subject) != Bucket("b_flag", subject) {
+			differ = true
+			break
+		}
+	}
+	if !differ {
+		t.Fatal("expected different flags to bucket users differently")
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	key, err := NormalizeKey("  Public_QA ")
+	if err != nil || key != "public_qa" {
+		t.Fatalf("expected public_qa, got %q (%v)", key, err)
+	}
+	for _, value := range []string{"", "a", "1flag", "has-dash", "has space"} {
+		if _, err := NormalizeKey(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestListIncludesDefaults(t *testing.T) {
+	list := Set{"beta": {Key: "beta", Environment: "prod"}}.List("prod")
+	if len(list) != 2 || list[0].Key != "beta" || list[1].Key != PublicQA {
+		t.Fatalf("unexpected list %+v", list)
+	}
+	if !list[1].Default || list[1].Environment != "prod" {
+		t.Fatalf("expected default public_qa for prod, got %+v", list[1])
+	}
+}
@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// battleTurnMetrics holds the per-turn LLM series WorkerMetrics exports for
+// battles. The owner's mutex guards it.
+type battleTurnMetrics struct {
+	latency map[string]*histogram
+	calls   map[string]uint64
+	retries map[string]uint64
+}
+
+func newBattleTurnMetrics() battleTurnMetrics {
+	return battleTurnMetrics{
+		latency: map[string]*histogram{},
+		calls:   map[string]uint64{},
+		retries: map[string]uint64{},
+	}
+}
+
+func (b *battleTurnMetrics) observe(outcome string, calls, retries int, latency time.Duration, sink MetricsSink) {
+	cleanOutcome := normalizeMetricValue(outcome, "unknown")
+	h, exists := b.latency[cleanOutcome]
+	if !exists {
+		h = newHistogram(llmDurationBuckets)
+		b.latency[cleanOutcome] = h
+	}
+	h.observe(latency.Seconds())
+	b.calls[cleanOutcome] += uint64(max(calls, 0))
+	b.retries[cleanOutcome] += uint64(max(retries, 0))
+	if sink != nil {
+		tags := map[string]string{"outcome": cleanOutcome}
+		sink.Timing("battle_turn_llm", latency, tags)
+		sink.Count("battle_turn_llm_calls", int64(max(calls, 0)), tags)
+		sink.Count("battle_turn_llm_retries", int64(max(retries, 0)), tags)
+	}
+}
+
+func (b *battleTurnMetrics) render(sb *strings.Builder) {
+	outcomes := make([]string, 0, len(b.latency))
+	for outcome := range b.latency {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+
+	sb.WriteString("# HELP battle_turn_llm_seconds Summed LLM call time per battle turn attempt by outcome (done, failed).\n")
+	sb.WriteString("# TYPE battle_turn_llm_seconds histogram\n")
+	for _, outcome := range outcomes {
+		renderHistogramSeries(sb, "battle_turn_llm_seconds", map[string]string{"outcome": outcome}, b.latency[outcome])
+	}
+	for _, series := range []struct {
+		name, help string
+		values     map[string]uint64
+	}{
+		{"battle_turn_llm_calls_total", "LLM calls made for battle turns, including regenerations and translations.", b.calls},
+		{"battle_turn_llm_retries_total", "Provider retries inside battle turn LLM calls.", b.retries},
+	} {
+		sb.WriteString("# HELP " + series.name + " " + series.help + "\n")
+		sb.WriteString("# TYPE " + series.name + " counter\n")
+		for _, outcome := range outcomes {
+			sb.WriteString(series.name)
+			sb.WriteString(formatLabels(map[string]string{"outcome": outcome}))
+			sb.WriteString(" ")
+			sb.WriteString(strconv.FormatUint(series.values[outcome], 10))
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// ObserveBattleTurn records the LLM work of one battle turn attempt.
+func (m *WorkerMetrics) ObserveBattleTurn(outcome string, calls, retries int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.battleTurns.observe(outcome, calls, retries, latency, m.sink)
+}
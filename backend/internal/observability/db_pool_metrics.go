@@ -0,0 +1,84 @@
+package observability
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DBPoolStats is a point-in-time snapshot of a pgx connection pool.
+// EmptyAcquireCount and CanceledAcquireCount are cumulative since the pool
+// was opened.
+type DBPoolStats struct {
+	MaxConns             int32
+	TotalConns           int32
+	AcquiredConns        int32
+	IdleConns            int32
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	AcquireDuration      time.Duration
+}
+
+// Saturation is the share of the pool's connections checked out, 0 to 1.
+func (s DBPoolStats) Saturation() float64 {
+	if s.MaxConns <= 0 {
+		return 0
+	}
+	return float64(s.AcquiredConns) / float64(s.MaxConns)
+}
+
+// dbPoolMetrics holds the latest snapshot per named pool for both APIMetrics
+// and WorkerMetrics. The owner's mutex guards it.
+type dbPoolMetrics map[string]DBPoolStats
+
+func (p dbPoolMetrics) set(pool string, stats DBPoolStats, sink MetricsSink) {
+	pool = normalizeMetricValue(pool, "unknown")
+	p[pool] = stats
+	if sink == nil {
+		return
+	}
+	tags := map[string]string{"pool": pool}
+	sink.Gauge("db_pool_max_conns", float64(stats.MaxConns), tags)
+	sink.Gauge("db_pool_total_conns", float64(stats.TotalConns), tags)
+	sink.Gauge("db_pool_acquired_conns", float64(stats.AcquiredConns), tags)
+	sink.Gauge("db_pool_idle_conns", float64(stats.IdleConns), tags)
+	sink.Gauge("db_pool_saturation", stats.Saturation(), tags)
+	sink.Gauge("db_pool_empty_acquires", float64(stats.EmptyAcquireCount), tags)
+	sink.Gauge("db_pool_canceled_acquires", float64(stats.CanceledAcquireCount), tags)
+}
+
+func (p dbPoolMetrics) render(sb *strings.Builder) {
+	pools := make([]string, 0, len(p))
+	for pool := range p {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+
+	series := []struct {
+		name  string
+		help  string
+		kind  string
+		value func(DBPoolStats) float64
+	}{
+		{"db_pool_max_conns", "Configured maximum connections per pool.", "gauge", func(s DBPoolStats) float64 { return float64(s.MaxConns) }},
+		{"db_pool_total_conns", "Open connections per pool.", "gauge", func(s DBPoolStats) float64 { return float64(s.TotalConns) }},
+		{"db_pool_acquired_conns", "Connections currently checked out per pool.", "gauge", func(s DBPoolStats) float64 { return float64(s.AcquiredConns) }},
+		{"db_pool_idle_conns", "Idle connections per pool.", "gauge", func(s DBPoolStats) float64 { return float64(s.IdleConns) }},
+		{"db_pool_saturation", "Share of the pool's maximum connections checked out.", "gauge", func(s DBPoolStats) float64 { return s.Saturation() }},
+		{"db_pool_empty_acquires_total", "Acquires that had to wait because no idle connection was available.", "counter", func(s DBPoolStats) float64 { return float64(s.EmptyAcquireCount) }},
+		{"db_pool_canceled_acquires_total", "Acquires canceled by their context while waiting for a connection.", "counter", func(s DBPoolStats) float64 { return float64(s.CanceledAcquireCount) }},
+		{"db_pool_acquire_wait_seconds_total", "Total time spent waiting to acquire connections.", "counter", func(s DBPoolStats) float64 { return s.AcquireDuration.Seconds() }},
+	}
+	for _, metric := range series {
+		sb.WriteString("# HELP " + metric.name + " " + metric.help + "\n")
+		sb.WriteString("# TYPE " + metric.name + " " + metric.kind + "\n")
+		for _, pool := range pools {
+			sb.WriteString(metric.name)
+			sb.WriteString(formatLabels(map[string]string{"pool": pool}))
+			sb.WriteString(" ")
+			sb.WriteString(strconv.FormatFloat(metric.value(p[pool]), 'g', -1, 64))
+			sb.WriteString("\n")
+		}
+	}
+}
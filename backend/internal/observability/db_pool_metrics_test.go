@@ -0,0 +1,43 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDBPoolMetricsRender(t *testing.T) {
+	metrics := NewAPIMetrics()
+	metrics.SetDBPoolStats("api", DBPoolStats{
+		MaxConns:          10,
+		TotalConns:        6,
+		AcquiredConns:     4,
+		IdleConns:         2,
+		EmptyAcquireCount: 7,
+		AcquireDuration:   1500 * time.Millisecond,
+	})
+
+	out := metrics.Render()
+	for _, want := range []string{
+		`db_pool_max_conns{pool="api"} 10`,
+		`db_pool_acquired_conns{pool="api"} 4`,
+		`db_pool_idle_conns{pool="api"} 2`,
+		`db_pool_saturation{pool="api"} 0.4`,
+		`db_pool_empty_acquires_total{pool="api"} 7`,
+		`db_pool_canceled_acquires_total{pool="api"} 0`,
+		`db_pool_acquire_wait_seconds_total{pool="api"} 1.5`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in api metrics, got:\n%s", want, out)
+		}
+	}
+
+	worker := NewWorkerMetrics()
+	worker.SetDBPoolStats("worker", DBPoolStats{MaxConns: 8, AcquiredConns: 8})
+	if out := worker.Render(); !strings.Contains(out, `db_pool_saturation{pool="worker"} 1`) {
+		t.Fatalf("expected saturated worker pool, got:\n%s", out)
+	}
+	if (DBPoolStats{}).Saturation() != 0 {
+		t.Fatal("expected zero saturation for an empty pool")
+	}
+}
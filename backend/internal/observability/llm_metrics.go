@@ -0,0 +1,163 @@
+package observability
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LLM calls run from well under a second to the provider's request timeout,
+// so their buckets reach further than defaultDurationBuckets.
+var llmDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60}
+
+type llmRequestKey struct {
+	operation string
+	provider  string
+	status    string
+}
+
+type llmSeriesKey struct {
+	operation string
+	provider  string
+}
+
+type llmTokenKey struct {
+	operation string
+	provider  string
+	kind      string
+}
+
+// llmRequestMetrics holds the per-call LLM series both APIMetrics and
+// WorkerMetrics export. The owner's mutex guards it.
+type llmRequestMetrics struct {
+	requests map[llmRequestKey]uint64
+	latency  map[llmSeriesKey]*histogram
+	tokens   map[llmTokenKey]uint64
+}
+
+func newLLMRequestMetrics() llmRequestMetrics {
+	return llmRequestMetrics{
+		requests: map[llmRequestKey]uint64{},
+		latency:  map[llmSeriesKey]*histogram{},
+		tokens:   map[llmTokenKey]uint64{},
+	}
+}
+
+func (l *llmRequestMetrics) observe(operation, provider, status string, duration time.Duration, promptTokens, completionTokens int, sink MetricsSink) {
+	key := llmRequestKey{
+		operation: normalizeMetricValue(operation, "unknown"),
+		provider:  normalizeMetricValue(provider, "unknown"),
+		status:    normalizeMetricValue(status, "unknown"),
+	}
+	series := llmSeriesKey{operation: key.operation, provider: key.provider}
+
+	l.requests[key]++
+	h, exists := l.latency[series]
+	if !exists {
+		h = newHistogram(llmDurationBuckets)
+		l.latency[series] = h
+	}
+	h.observe(duration.Seconds())
+	if sink != nil {
+		sink.Count("llm_requests", 1, map[string]string{"operation": key.operation, "provider": key.provider, "status": key.status})
+		sink.Timing("llm_latency", duration, map[string]string{"operation": key.operation, "provider": key.provider})
+	}
+
+	l.addTokens(series, "prompt", promptTokens, sink)
+	l.addTokens(series, "completion", completionTokens, sink)
+}
+
+func (l *llmRequestMetrics) addTokens(series llmSeriesKey, kind string, count int, sink MetricsSink) {
+	if count <= 0 {
+		return
+	}
+	l.tokens[llmTokenKey{operation: series.operation, provider: series.provider, kind: kind}] += uint64(count)
+	if sink != nil {
+		sink.Count("llm_tokens", int64(count), map[string]string{"operation": series.operation, "provider": series.provider, "type": kind})
+	}
+}
+
+func (l *llmRequestMetrics) render(sb *strings.Builder) {
+	sb.WriteString("# HELP llm_requests_total LLM provider calls by operation, provider and status (ok, error, unavailable).\n")
+	sb.WriteString("# TYPE llm_requests_total counter\n")
+	requestKeys := make([]llmRequestKey, 0, len(l.requests))
+	for key := range l.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		if requestKeys[i].operation != requestKeys[j].operation {
+			return requestKeys[i].operation < requestKeys[j].operation
+		}
+		if requestKeys[i].provider != requestKeys[j].provider {
+			return requestKeys[i].provider < requestKeys[j].provider
+		}
+		return requestKeys[i].status < requestKeys[j].status
+	})
+	for _, key := range requestKeys {
+		sb.WriteString("llm_requests_total")
+		sb.WriteString(formatLabels(map[string]string{"operation": key.operation, "provider": key.provider, "status": key.status}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(l.requests[key], 10))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# HELP llm_latency_seconds LLM call latency in seconds, including provider retries.\n")
+	sb.WriteString("# TYPE llm_latency_seconds histogram\n")
+	seriesKeys := make([]llmSeriesKey, 0, len(l.latency))
+	for key := range l.latency {
+		seriesKeys = append(seriesKeys, key)
+	}
+	sort.Slice(seriesKeys, func(i, j int) bool {
+		if seriesKeys[i].operation != seriesKeys[j].operation {
+			return seriesKeys[i].operation < seriesKeys[j].operation
+		}
+		return seriesKeys[i].provider < seriesKeys[j].provider
+	})
+	for _, key := range seriesKeys {
+		renderHistogramSeries(sb, "llm_latency_seconds", map[string]string{"operation": key.operation, "provider": key.provider}, l.latency[key])
+	}
+
+	sb.WriteString("# HELP llm_tokens_total Tokens reported by the LLM provider by operation, provider and type (prompt, completion).\n")
+	sb.WriteString("# TYPE llm_tokens_total counter\n")
+	tokenKeys := make([]llmTokenKey, 0, len(l.tokens))
+	for key := range l.tokens {
+		tokenKeys = append(tokenKeys, key)
+	}
+	sort.Slice(tokenKeys, func(i, j int) bool {
+		if tokenKeys[i].operation != tokenKeys[j].operation {
+			return tokenKeys[i].operation < tokenKeys[j].operation
+		}
+		if tokenKeys[i].provider != tokenKeys[j].provider {
+			return tokenKeys[i].provider < tokenKeys[j].provider
+		}
+		return tokenKeys[i].kind < tokenKeys[j].kind
+	})
+	for _, key := range tokenKeys {
+		sb.WriteString("llm_tokens_total")
+		sb.WriteString(formatLabels(map[string]string{"operation": key.operation, "provider": key.provider, "type": key.kind}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(l.tokens[key], 10))
+		sb.WriteString("\n")
+	}
+}
+
+// ObserveLLMRequest records one LLM call made by the API.
+func (m *APIMetrics) ObserveLLMRequest(operation, provider, status string, duration time.Duration, promptTokens, completionTokens int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.llm.observe(operation, provider, status, duration, promptTokens, completionTokens, m.sink)
+}
+
+// ObserveLLMRequest records one LLM call made by the worker.
+func (m *WorkerMetrics) ObserveLLMRequest(operation, provider, status string, duration time.Duration, promptTokens, completionTokens int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.llm.observe(operation, provider, status, duration, promptTokens, completionTokens, m.sink)
+}
@@ -0,0 +1,38 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLLMRequestMetricsRender(t *testing.T) {
+	metrics := NewWorkerMetrics()
+	metrics.ObserveLLMRequest("reply", "openai", "ok", 1500*time.Millisecond, 120, 30)
+	metrics.ObserveLLMRequest("reply", "openai", "unavailable", 20*time.Second, 0, 0)
+
+	out := metrics.Render()
+	for _, want := range []string{
+		`llm_requests_total{operation="reply",provider="openai",status="ok"} 1`,
+		`llm_requests_total{operation="reply",provider="openai",status="unavailable"} 1`,
+		`llm_latency_seconds_bucket{le="2",operation="reply",provider="openai"} 1`,
+		`llm_latency_seconds_bucket{le="30",operation="reply",provider="openai"} 2`,
+		`llm_latency_seconds_count{operation="reply",provider="openai"} 2`,
+		`llm_tokens_total{operation="reply",provider="openai",type="completion"} 30`,
+		`llm_tokens_total{operation="reply",provider="openai",type="prompt"} 120`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in worker metrics, got:\n%s", want, out)
+		}
+	}
+
+	api := NewAPIMetrics()
+	api.ObserveLLMRequest("post_translation", "mock", "ok", time.Millisecond, 0, 0)
+	out = api.Render()
+	if !strings.Contains(out, `llm_requests_total{operation="post_translation",provider="mock",status="ok"} 1`) {
+		t.Fatalf("expected llm request in api metrics, got:\n%s", out)
+	}
+	if strings.Contains(out, `llm_tokens_total{operation="post_translation"`) {
+		t.Fatalf("expected no token series without reported usage")
+	}
+}
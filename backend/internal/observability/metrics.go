@@ -61,6 +61,9 @@ type APIMetrics struct {
 	dbQuery       *histogram
 	queueDepth    map[string]float64
 	rateLimited   map[rateLimitKey]uint64
+	llm           llmRequestMetrics
+	dbPools       dbPoolMetrics
+	sink          MetricsSink
 }
 
 func NewAPIMetrics() *APIMetrics {
@@ -70,6 +73,8 @@ func NewAPIMetrics() *APIMetrics {
 		dbQuery:       newHistogram(defaultDurationBuckets),
 		queueDepth:    map[string]float64{},
 		rateLimited:   map[rateLimitKey]uint64{},
+		llm:           newLLMRequestMetrics(),
+		dbPools:       dbPoolMetrics{},
 	}
 }
 
@@ -78,6 +83,16 @@ type rateLimitKey struct {
 	endpoint string
 }
 
+// SetSink forwards every update to sink as well, for example a StatsD agent.
+func (m *APIMetrics) SetSink(sink MetricsSink) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sink = sink
+}
+
 func (m *APIMetrics) ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
 	if m == nil {
 		return
@@ -98,6 +113,12 @@ func (m *APIMetrics) ObserveHTTPRequest(route, method string, status int, durati
 		m.httpDurations[durationKey] = h
 	}
 	h.observe(duration.Seconds())
+
+	if m.sink != nil {
+		tags := map[string]string{"route": key.route, "method": key.method, "status": key.status}
+		m.sink.Count("http_requests", 1, tags)
+		m.sink.Timing("http_request_duration", duration, map[string]string{"route": key.route, "method": key.method})
+	}
 }
 
 func (m *APIMetrics) ObserveDBQuery(duration time.Duration) {
@@ -107,6 +128,9 @@ func (m *APIMetrics) ObserveDBQuery(duration time.Duration) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.dbQuery.observe(duration.Seconds())
+	if m.sink != nil {
+		m.sink.Timing("db_query_duration", duration, nil)
+	}
 }
 
 func (m *APIMetrics) SetQueueDepthSnapshot(values map[string]int) {
@@ -125,6 +149,21 @@ func (m *APIMetrics) SetQueueDepthSnapshot(values map[string]int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.queueDepth = snapshot
+	if m.sink != nil {
+		for jobType, depth := range snapshot {
+			m.sink.Gauge("queue_depth", depth, map[string]string{"type": jobType})
+		}
+	}
+}
+
+// SetDBPoolStats records the latest snapshot of the named connection pool.
+func (m *APIMetrics) SetDBPoolStats(pool string, stats DBPoolStats) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dbPools.set(pool, stats, m.sink)
 }
 
 func (m *APIMetrics) IncRateLimited(scope, endpoint string) {
@@ -138,6 +177,9 @@ func (m *APIMetrics) IncRateLimited(scope, endpoint string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.rateLimited[key]++
+	if m.sink != nil {
+		m.sink.Count("rate_limit_events", 1, map[string]string{"scope": key.scope, "endpoint": key.endpoint})
+	}
 }
 
 func (m *APIMetrics) Render() string {
@@ -201,6 +243,7 @@ func (m *APIMetrics) Render() string {
 	sb.WriteString("# HELP db_query_duration_seconds Database query duration in seconds.\n")
 	sb.WriteString("# TYPE db_query_duration_seconds histogram\n")
 	renderHistogramSeries(&sb, "db_query_duration_seconds", map[string]string{}, m.dbQuery)
+	m.dbPools.render(&sb)
 
 	sb.WriteString("# HELP queue_depth Pending or in-flight jobs by type.\n")
 	sb.WriteString("# TYPE queue_depth gauge\n")
@@ -242,6 +285,8 @@ func (m *APIMetrics) Render() string {
 		sb.WriteString("\n")
 	}
 
+	m.llm.render(&sb)
+
 	return sb.String()
 }
 
@@ -256,22 +301,221 @@ type workerTraceKey struct {
 	trace   string
 }
 
+type jobClaimKey struct {
+	shard  string
+	source string
+}
+
+type jobFailureKey struct {
+	jobType string
+	class   string
+	outcome string
+}
+
+type llmCallKey struct {
+	operation string
+	outcome   string
+}
+
 type WorkerMetrics struct {
-	mu            sync.RWMutex
-	jobsProcessed map[workerProcessedKey]uint64
-	jobDurations  map[string]*histogram
-	jobRetries    map[string]uint64
-	jobsTrace     map[workerTraceKey]uint64
-	dbQuery       *histogram
+	mu              sync.RWMutex
+	jobsProcessed   map[workerProcessedKey]uint64
+	jobDurations    map[string]*histogram
+	jobRetries      map[string]uint64
+	jobFailures     map[jobFailureKey]uint64
+	jobsTrace       map[workerTraceKey]uint64
+	dbQuery         *histogram
+	llmCalls        map[llmCallKey]uint64
+	llmErrorRate    map[string]float64
+	llmBackpressure map[string]float64
+	llmConcurrency  map[string]float64
+	pollIntervals   map[string]float64
+	digestBacklog   float64
+	quotaMismatches map[string]float64
+	pushDeliveries  map[string]uint64
+	jobClaims       map[jobClaimKey]uint64
+	llm             llmRequestMetrics
+	battleTurns     battleTurnMetrics
+	dbPools         dbPoolMetrics
+	shardIndex      int
+	shardCount      int
+	sink            MetricsSink
 }
 
 func NewWorkerMetrics() *WorkerMetrics {
 	return &WorkerMetrics{
-		jobsProcessed: map[workerProcessedKey]uint64{},
-		jobDurations:  map[string]*histogram{},
-		jobRetries:    map[string]uint64{},
-		jobsTrace:     map[workerTraceKey]uint64{},
-		dbQuery:       newHistogram(defaultDurationBuckets),
+		jobsProcessed:   map[workerProcessedKey]uint64{},
+		jobDurations:    map[string]*histogram{},
+		jobRetries:      map[string]uint64{},
+		jobFailures:     map[jobFailureKey]uint64{},
+		jobsTrace:       map[workerTraceKey]uint64{},
+		dbQuery:         newHistogram(defaultDurationBuckets),
+		llmCalls:        map[llmCallKey]uint64{},
+		llmErrorRate:    map[string]float64{},
+		llmBackpressure: map[string]float64{},
+		llmConcurrency:  map[string]float64{},
+		pollIntervals:   map[string]float64{},
+		pushDeliveries:  map[string]uint64{},
+		jobClaims:       map[jobClaimKey]uint64{},
+		quotaMismatches: map[string]float64{},
+		llm:             newLLMRequestMetrics(),
+		battleTurns:     newBattleTurnMetrics(),
+		dbPools:         dbPoolMetrics{},
+		shardCount:      1,
+	}
+}
+
+// SetSink forwards every update to sink as well, for example a StatsD agent.
+func (m *WorkerMetrics) SetSink(sink MetricsSink) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sink = sink
+	if sink != nil {
+		sink.Gauge("worker_shard_info", 1, m.shardTags())
+	}
+}
+
+func (m *WorkerMetrics) shardTags() map[string]string {
+	return map[string]string{
+		"index": strconv.Itoa(m.shardIndex),
+		"count": strconv.Itoa(m.shardCount),
+	}
+}
+
+func (m *WorkerMetrics) IncLLMCall(operation, outcome string) {
+	if m == nil {
+		return
+	}
+	key := llmCallKey{
+		operation: normalizeMetricValue(operation, "unknown"),
+		outcome:   normalizeMetricValue(outcome, "unknown"),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.llmCalls[key]++
+	if m.sink != nil {
+		m.sink.Count("llm_calls", 1, map[string]string{"operation": key.operation, "outcome": key.outcome})
+	}
+}
+
+func (m *WorkerMetrics) SetLLMHealth(operation string, errorRate float64, level, concurrency int) {
+	if m == nil {
+		return
+	}
+	cleanOperation := normalizeMetricValue(operation, "unknown")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.llmErrorRate[cleanOperation] = errorRate
+	m.llmBackpressure[cleanOperation] = float64(level)
+	m.llmConcurrency[cleanOperation] = float64(concurrency)
+	if m.sink != nil {
+		tags := map[string]string{"operation": cleanOperation}
+		m.sink.Gauge("llm_error_rate", errorRate, tags)
+		m.sink.Gauge("llm_backpressure_level", float64(level), tags)
+		m.sink.Gauge("worker_llm_concurrency", float64(concurrency), tags)
+	}
+}
+
+func (m *WorkerMetrics) IncPushDelivery(outcome string) {
+	if m == nil {
+		return
+	}
+	cleanOutcome := normalizeMetricValue(outcome, "unknown")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pushDeliveries[cleanOutcome]++
+	if m.sink != nil {
+		m.sink.Count("push_deliveries", 1, map[string]string{"outcome": cleanOutcome})
+	}
+}
+
+// SetShard records the job shard this worker claims from.
+func (m *WorkerMetrics) SetShard(index, count int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shardIndex = index
+	m.shardCount = count
+	if m.sink != nil {
+		m.sink.Gauge("worker_shard_info", 1, m.shardTags())
+	}
+}
+
+// IncJobClaimed counts a claimed job. source is "own" for jobs in the worker's
+// shard and "stolen" for stale jobs picked up from another shard.
+func (m *WorkerMetrics) IncJobClaimed(shard, source string) {
+	if m == nil {
+		return
+	}
+	key := jobClaimKey{
+		shard:  normalizeMetricValue(shard, "unknown"),
+		source: normalizeMetricValue(source, "unknown"),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobClaims[key]++
+	if m.sink != nil {
+		m.sink.Count("worker_jobs_claimed", 1, map[string]string{"shard": key.shard, "source": key.source})
+	}
+}
+
+// SetPollInterval records how often tasks of an LLM operation run after
+// backpressure.
+func (m *WorkerMetrics) SetPollInterval(operation string, interval time.Duration) {
+	if m == nil {
+		return
+	}
+	cleanOperation := normalizeMetricValue(operation, "unknown")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pollIntervals[cleanOperation] = interval.Seconds()
+	if m.sink != nil {
+		m.sink.Gauge("worker_poll_interval_seconds", interval.Seconds(), map[string]string{"operation": cleanOperation})
+	}
+}
+
+// SetDBPoolStats records the latest snapshot of the named connection pool.
+func (m *WorkerMetrics) SetDBPoolStats(pool string, stats DBPoolStats) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dbPools.set(pool, stats, m.sink)
+}
+
+// SetDigestBacklog records how many personas have a daily digest due.
+func (m *WorkerMetrics) SetDigestBacklog(count int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digestBacklog = float64(max(count, 0))
+	if m.sink != nil {
+		m.sink.Gauge("worker_digest_backlog", m.digestBacklog, nil)
+	}
+}
+
+// SetQuotaDiscrepancies records, per quota type, how many personas the last
+// quota reconciliation flagged.
+func (m *WorkerMetrics) SetQuotaDiscrepancies(counts map[string]int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for quotaType, count := range counts {
+		cleanType := normalizeMetricValue(quotaType, "unknown")
+		m.quotaMismatches[cleanType] = float64(max(count, 0))
+		if m.sink != nil {
+			m.sink.Gauge("worker_quota_discrepancies", m.quotaMismatches[cleanType], map[string]string{"quota_type": cleanType})
+		}
 	}
 }
 
@@ -296,6 +540,11 @@ func (m *WorkerMetrics) ObserveJobProcessed(jobType, status, traceID string, dur
 	}
 	h.observe(duration.Seconds())
 	m.jobsTrace[workerTraceKey{jobType: cleanType, status: cleanStatus, trace: traceState}]++
+	if m.sink != nil {
+		m.sink.Count("jobs_processed", 1, map[string]string{"type": cleanType, "status": cleanStatus})
+		m.sink.Timing("job_duration", duration, map[string]string{"type": cleanType})
+		m.sink.Count("jobs_trace", 1, map[string]string{"type": cleanType, "status": cleanStatus, "trace": traceState})
+	}
 }
 
 func (m *WorkerMetrics) IncrementJobRetry(jobType string) {
@@ -306,6 +555,28 @@ func (m *WorkerMetrics) IncrementJobRetry(jobType string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.jobRetries[cleanType]++
+	if m.sink != nil {
+		m.sink.Count("job_retries", 1, map[string]string{"type": cleanType})
+	}
+}
+
+// IncJobFailure counts a failed job attempt by retry class and whether it was
+// retried or given up.
+func (m *WorkerMetrics) IncJobFailure(jobType, class, outcome string) {
+	if m == nil {
+		return
+	}
+	key := jobFailureKey{
+		jobType: normalizeMetricValue(jobType, "unknown"),
+		class:   normalizeMetricValue(class, "unknown"),
+		outcome: normalizeMetricValue(outcome, "unknown"),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobFailures[key]++
+	if m.sink != nil {
+		m.sink.Count("job_failures", 1, map[string]string{"type": key.jobType, "class": key.class, "outcome": key.outcome})
+	}
 }
 
 func (m *WorkerMetrics) ObserveDBQuery(duration time.Duration) {
@@ -315,6 +586,9 @@ func (m *WorkerMetrics) ObserveDBQuery(duration time.Duration) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.dbQuery.observe(duration.Seconds())
+	if m.sink != nil {
+		m.sink.Timing("db_query_duration", duration, nil)
+	}
 }
 
 func (m *WorkerMetrics) Render() string {
@@ -376,6 +650,30 @@ func (m *WorkerMetrics) Render() string {
 		sb.WriteString("\n")
 	}
 
+	sb.WriteString("# HELP job_failures_total Failed job attempts by type, retry class and outcome.\n")
+	sb.WriteString("# TYPE job_failures_total counter\n")
+	failureKeys := make([]jobFailureKey, 0, len(m.jobFailures))
+	for key := range m.jobFailures {
+		failureKeys = append(failureKeys, key)
+	}
+	sort.Slice(failureKeys, func(i, j int) bool {
+		if failureKeys[i].jobType != failureKeys[j].jobType {
+			return failureKeys[i].jobType < failureKeys[j].jobType
+		}
+		if failureKeys[i].class != failureKeys[j].class {
+			return failureKeys[i].class < failureKeys[j].class
+		}
+		return failureKeys[i].outcome < failureKeys[j].outcome
+	})
+	for _, key := range failureKeys {
+		labels := map[string]string{"type": key.jobType, "class": key.class, "outcome": key.outcome}
+		sb.WriteString("job_failures_total")
+		sb.WriteString(formatLabels(labels))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(m.jobFailures[key], 10))
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("# HELP jobs_trace_total Processed jobs grouped by trace availability.\n")
 	sb.WriteString("# TYPE jobs_trace_total counter\n")
 	traceKeys := make([]workerTraceKey, 0, len(m.jobsTrace))
@@ -407,10 +705,123 @@ func (m *WorkerMetrics) Render() string {
 	sb.WriteString("# HELP db_query_duration_seconds Database query duration in seconds.\n")
 	sb.WriteString("# TYPE db_query_duration_seconds histogram\n")
 	renderHistogramSeries(&sb, "db_query_duration_seconds", map[string]string{}, m.dbQuery)
+	m.dbPools.render(&sb)
+
+	sb.WriteString("# HELP llm_calls_total LLM calls made by the worker by operation and outcome.\n")
+	sb.WriteString("# TYPE llm_calls_total counter\n")
+	callKeys := make([]llmCallKey, 0, len(m.llmCalls))
+	for key := range m.llmCalls {
+		callKeys = append(callKeys, key)
+	}
+	sort.Slice(callKeys, func(i, j int) bool {
+		if callKeys[i].operation != callKeys[j].operation {
+			return callKeys[i].operation < callKeys[j].operation
+		}
+		return callKeys[i].outcome < callKeys[j].outcome
+	})
+	for _, key := range callKeys {
+		labels := map[string]string{"operation": key.operation, "outcome": key.outcome}
+		sb.WriteString("llm_calls_total")
+		sb.WriteString(formatLabels(labels))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(m.llmCalls[key], 10))
+		sb.WriteString("\n")
+	}
+
+	m.llm.render(&sb)
+	m.battleTurns.render(&sb)
+
+	renderOperationGauge(&sb, "llm_error_rate", "Share of recent LLM calls that hit 429/5xx/timeouts.", m.llmErrorRate)
+	renderOperationGauge(&sb, "llm_backpressure_level", "Current backpressure level per LLM operation (0 means unthrottled).", m.llmBackpressure)
+	renderOperationGauge(&sb, "worker_llm_concurrency", "Effective job concurrency per LLM operation.", m.llmConcurrency)
+
+	renderOperationGauge(&sb, "worker_poll_interval_seconds", "Current poll interval per LLM operation after backpressure.", m.pollIntervals)
+
+	sb.WriteString("# HELP worker_digest_backlog Personas whose daily digest is due, as of the last digest batch.\n")
+	sb.WriteString("# TYPE worker_digest_backlog gauge\n")
+	sb.WriteString("worker_digest_backlog ")
+	sb.WriteString(strconv.FormatFloat(m.digestBacklog, 'g', -1, 64))
+	sb.WriteString("\n")
+
+	sb.WriteString("# HELP worker_quota_discrepancies Personas whose quota events did not match created content in the last reconciled day.\n")
+	sb.WriteString("# TYPE worker_quota_discrepancies gauge\n")
+	quotaTypes := make([]string, 0, len(m.quotaMismatches))
+	for quotaType := range m.quotaMismatches {
+		quotaTypes = append(quotaTypes, quotaType)
+	}
+	sort.Strings(quotaTypes)
+	for _, quotaType := range quotaTypes {
+		sb.WriteString("worker_quota_discrepancies")
+		sb.WriteString(formatLabels(map[string]string{"quota_type": quotaType}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatFloat(m.quotaMismatches[quotaType], 'g', -1, 64))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# HELP push_deliveries_total Web Push deliveries by outcome (sent, gone, failed).\n")
+	sb.WriteString("# TYPE push_deliveries_total counter\n")
+	outcomes := make([]string, 0, len(m.pushDeliveries))
+	for outcome := range m.pushDeliveries {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	for _, outcome := range outcomes {
+		sb.WriteString("push_deliveries_total")
+		sb.WriteString(formatLabels(map[string]string{"outcome": outcome}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(m.pushDeliveries[outcome], 10))
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# HELP worker_shard_info Job shard claimed by this worker.\n")
+	sb.WriteString("# TYPE worker_shard_info gauge\n")
+	sb.WriteString("worker_shard_info")
+	sb.WriteString(formatLabels(map[string]string{
+		"index": strconv.Itoa(m.shardIndex),
+		"count": strconv.Itoa(m.shardCount),
+	}))
+	sb.WriteString(" 1\n")
+
+	sb.WriteString("# HELP worker_jobs_claimed_total Jobs claimed by shard and source (own, stolen).\n")
+	sb.WriteString("# TYPE worker_jobs_claimed_total counter\n")
+	claimKeys := make([]jobClaimKey, 0, len(m.jobClaims))
+	for key := range m.jobClaims {
+		claimKeys = append(claimKeys, key)
+	}
+	sort.Slice(claimKeys, func(i, j int) bool {
+		if claimKeys[i].shard != claimKeys[j].shard {
+			return claimKeys[i].shard < claimKeys[j].shard
+		}
+		return claimKeys[i].source < claimKeys[j].source
+	})
+	for _, key := range claimKeys {
+		sb.WriteString("worker_jobs_claimed_total")
+		sb.WriteString(formatLabels(map[string]string{"shard": key.shard, "source": key.source}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatUint(m.jobClaims[key], 10))
+		sb.WriteString("\n")
+	}
 
 	return sb.String()
 }
 
+func renderOperationGauge(sb *strings.Builder, metricName, help string, values map[string]float64) {
+	sb.WriteString("# HELP " + metricName + " " + help + "\n")
+	sb.WriteString("# TYPE " + metricName + " gauge\n")
+	operations := make([]string, 0, len(values))
+	for operation := range values {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		sb.WriteString(metricName)
+		sb.WriteString(formatLabels(map[string]string{"operation": operation}))
+		sb.WriteString(" ")
+		sb.WriteString(strconv.FormatFloat(values[operation], 'g', -1, 64))
+		sb.WriteString("\n")
+	}
+}
+
 func renderHistogramSeries(sb *strings.Builder, metricName string, labels map[string]string, h *histogram) {
 	if sb == nil || h == nil {
 		return
@@ -0,0 +1,13 @@
+package observability
+
+import "time"
+
+// MetricsSink receives metric updates as they are recorded, in addition to
+// the in-memory series rendered on /metrics. Names match the Prometheus
+// series without the `_total` suffix on counters and the `_seconds` suffix on
+// timings; tags carry the same labels.
+type MetricsSink interface {
+	Count(name string, value int64, tags map[string]string)
+	Gauge(name string, value float64, tags map[string]string)
+	Timing(name string, value time.Duration, tags map[string]string)
+}
@@ -0,0 +1,232 @@
+package observability
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatsDFormatDogStatsD = "dogstatsd"
+	StatsDFormatStatsD    = "statsd"
+
+	// statsdMaxPacketSize keeps packets under a typical 1500 byte MTU.
+	statsdMaxPacketSize     = 1432
+	statsdQueueSize         = 4096
+	defaultStatsDFlushEvery = time.Second
+)
+
+var (
+	statsdNamePattern     = regexp.MustCompile(`[^A-Za-z0-9_.]+`)
+	statsdTagPattern      = regexp.MustCompile(`[|,#\s]+`)
+	statsdNameTagsPattern = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+type StatsDConfig struct {
+	Addr       string
+	Prefix     string
+	Format     string
+	Tags       []string
+	FlushEvery time.Duration
+}
+
+// StatsDSink emits metrics to a StatsD or DogStatsD agent over UDP. Lines are
+// queued without blocking and sent in batched packets by a background loop;
+// when the queue is full new lines are dropped rather than slowing requests.
+type StatsDSink struct {
+	conn       net.Conn
+	prefix     string
+	dogstatsd  bool
+	globalTags []string
+	lines      chan string
+	done       chan struct{}
+	stopped    chan struct{}
+	closeOnce  sync.Once
+	dropped    atomic.Uint64
+}
+
+func NewStatsDSink(cfg StatsDConfig) (*StatsDSink, error) {
+	addr := strings.TrimSpace(cfg.Addr)
+	if addr == "" {
+		return nil, errors.New("statsd address is required")
+	}
+	format := strings.ToLower(strings.TrimSpace(cfg.Format))
+	switch format {
+	case "", StatsDFormatDogStatsD:
+		format = StatsDFormatDogStatsD
+	case StatsDFormatStatsD:
+	default:
+		return nil, errors.New("statsd format must be dogstatsd or statsd")
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	flushEvery := cfg.FlushEvery
+	if flushEvery <= 0 {
+		flushEvery = defaultStatsDFlushEvery
+	}
+
+	globalTags := make([]string, 0, len(cfg.Tags))
+	for _, tag := range cfg.Tags {
+		if clean := sanitizeStatsDTag(tag); clean != "" {
+			globalTags = append(globalTags, clean)
+		}
+	}
+
+	s := &StatsDSink{
+		conn:       conn,
+		prefix:     strings.TrimSpace(cfg.Prefix),
+		dogstatsd:  format == StatsDFormatDogStatsD,
+		globalTags: globalTags,
+		lines:      make(chan string, statsdQueueSize),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go s.loop(flushEvery)
+	return s, nil
+}
+
+func (s *StatsDSink) Count(name string, value int64, tags map[string]string) {
+	s.enqueue(name, strconv.FormatInt(value, 10), "c", tags)
+}
+
+func (s *StatsDSink) Gauge(name string, value float64, tags map[string]string) {
+	s.enqueue(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+func (s *StatsDSink) Timing(name string, value time.Duration, tags map[string]string) {
+	ms := float64(value) / float64(time.Millisecond)
+	s.enqueue(name, strconv.FormatFloat(ms, 'f', -1, 64), "ms", tags)
+}
+
+// Dropped reports how many lines were discarded because the queue was full.
+func (s *StatsDSink) Dropped() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.dropped.Load()
+}
+
+// Close flushes queued lines and closes the connection.
+func (s *StatsDSink) Close() error {
+	if s == nil {
+		return nil
+	}
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		<-s.stopped
+		err = s.conn.Close()
+	})
+	return err
+}
+
+func (s *StatsDSink) enqueue(name, value, kind string, tags map[string]string) {
+	if s == nil {
+		return
+	}
+	line := formatStatsDLine(s.prefix, name, value, kind, tags, s.globalTags, s.dogstatsd)
+	select {
+	case s.lines <- line:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *StatsDSink) loop(flushEvery time.Duration) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+
+	packet := make([]byte, 0, statsdMaxPacketSize)
+	flush := func() {
+		if len(packet) == 0 {
+			return
+		}
+		// Delivery is best effort; a missing agent must not affect the app.
+		_, _ = s.conn.Write(packet)
+		packet = packet[:0]
+	}
+	add := func(line string) {
+		if len(packet) > 0 && len(packet)+1+len(line) > statsdMaxPacketSize {
+			flush()
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+
+	for {
+		select {
+		case line := <-s.lines:
+			add(line)
+		case <-ticker.C:
+			flush()
+		case <-s.done:
+			for {
+				select {
+				case line := <-s.lines:
+					add(line)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// formatStatsDLine renders one metric line. DogStatsD carries tags natively;
+// plain StatsD has no tags, so tag values are appended to the metric name in
+// key order instead (http_requests.GET.200.posts).
+func formatStatsDLine(prefix, name, value, kind string, tags map[string]string, globalTags []string, dogstatsd bool) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(statsdNamePattern.ReplaceAllString(name, "_"))
+	if !dogstatsd {
+		for _, key := range keys {
+			part := strings.Trim(statsdNameTagsPattern.ReplaceAllString(tags[key], "_"), "_")
+			if part == "" {
+				part = "none"
+			}
+			sb.WriteString(".")
+			sb.WriteString(part)
+		}
+	}
+	sb.WriteString(":")
+	sb.WriteString(value)
+	sb.WriteString("|")
+	sb.WriteString(kind)
+	if !dogstatsd {
+		return sb.String()
+	}
+
+	allTags := make([]string, 0, len(globalTags)+len(keys))
+	allTags = append(allTags, globalTags...)
+	for _, key := range keys {
+		allTags = append(allTags, sanitizeStatsDTag(key+":"+tags[key]))
+	}
+	if len(allTags) > 0 {
+		sb.WriteString("|#")
+		sb.WriteString(strings.Join(allTags, ","))
+	}
+	return sb.String()
+}
+
+func sanitizeStatsDTag(tag string) string {
+	return strings.Trim(statsdTagPattern.ReplaceAllString(strings.TrimSpace(tag), "_"), "_")
+}
@@ -0,0 +1,85 @@
+package observability
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatStatsDLine(t *testing.T) {
+	tags := map[string]string{"route": "/rooms/{id}/posts", "method": "GET", "status": "200"}
+
+	dog := formatStatsDLine("pw.", "http_requests", "1", "c", tags, []string{"service:api"}, true)
+	want := "pw.http_requests:1|c|#service:api,method:GET,route:/rooms/{id}/posts,status:200"
+	if dog != want {
+		t.Fatalf("dogstatsd line = %q, want %q", dog, want)
+	}
+
+	plain := formatStatsDLine("pw.", "http_requests", "1", "c", tags, []string{"service:api"}, false)
+	want = "pw.http_requests.GET.rooms_id_posts.200:1|c"
+	if plain != want {
+		t.Fatalf("statsd line = %q, want %q", plain, want)
+	}
+
+	if got := formatStatsDLine("", "queue_depth", "3", "g", nil, nil, true); got != "queue_depth:3|g" {
+		t.Fatalf("untagged line = %q", got)
+	}
+	if got := formatStatsDLine("", "jobs", "1", "c", map[string]string{"type": "a|b,c"}, nil, true); got != "jobs:1|c|#type:a_b_c" {
+		t.Fatalf("tag separators not sanitized: %q", got)
+	}
+}
+
+func TestNewStatsDSinkRejectsBadConfig(t *testing.T) {
+	if _, err := NewStatsDSink(StatsDConfig{}); err == nil {
+		t.Fatal("expected missing address to be rejected")
+	}
+	if _, err := NewStatsDSink(StatsDConfig{Addr: "127.0.0.1:8125", Format: "graphite"}); err == nil {
+		t.Fatal("expected unknown format to be rejected")
+	}
+}
+
+func TestStatsDSinkSendsBatchedPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	sink, err := NewStatsDSink(StatsDConfig{
+		Addr:       conn.LocalAddr().String(),
+		Prefix:     "pw.",
+		Tags:       []string{"env:test"},
+		FlushEvery: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("new sink: %v", err)
+	}
+
+	metrics := NewAPIMetrics()
+	metrics.SetSink(sink)
+	metrics.ObserveHTTPRequest("/feed", "get", 200, 25*time.Millisecond)
+	metrics.IncRateLimited("ip", "public_read")
+	if err := sink.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	buf := make([]byte, statsdMaxPacketSize)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read packet: %v", err)
+	}
+	lines := strings.Split(string(buf[:n]), "\n")
+	want := []string{
+		"pw.http_requests:1|c|#env:test,method:GET,route:/feed,status:200",
+		"pw.http_request_duration:25|ms|#env:test,method:GET,route:/feed",
+		"pw.rate_limit_events:1|c|#env:test,endpoint:public_read,scope:ip",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("packet lines = %q, want %q", lines, want)
+	}
+	if !strings.Contains(metrics.Render(), `http_requests_total{method="GET",route="/feed",status="200"} 1`) {
+		t.Fatal("expected /metrics output to keep the request series")
+	}
+}
@@ -0,0 +1,300 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Profile is the slice of a persona the heuristics check generated text
+// against.
+type Profile struct {
+	Tone      string
+	DoNotSay  []string
+	Formality int
+}
+
+type Score struct {
+	Tone               float64  `json:"tone"`
+	DoNotSay           float64  `json:"do_not_say"`
+	Formality          float64  `json:"formality"`
+	Overall            float64  `json:"overall"`
+	EstimatedFormality int      `json:"estimated_formality"`
+	ToneTraits         []string `json:"tone_traits,omitempty"`
+	Violations         []string `json:"violations,omitempty"`
+}
+
+var (
+	wordPattern        = regexp.MustCompile(`[\p{L}']+`)
+	contractionPattern = regexp.MustCompile(`(?i)\b\w+'(s|re|ve|ll|d|m|t)\b`)
+	informalWords      = map[string]struct{}{
+		"lol": {}, "gonna": {}, "wanna": {}, "gotta": {}, "hey": {}, "yeah": {}, "yep": {},
+		"nope": {}, "kinda": {}, "sorta": {}, "awesome": {}, "cool": {}, "btw": {}, "tbh": {},
+		"imo": {}, "ok": {}, "okay": {}, "stuff": {}, "guys": {}, "super": {},
+	}
+	formalWords = map[string]struct{}{
+		"therefore": {}, "furthermore": {}, "however": {}, "consequently": {}, "moreover": {},
+		"accordingly": {}, "nevertheless": {}, "thus": {}, "regarding": {}, "additionally": {},
+		"hence": {}, "whereas": {}, "subsequently": {}, "notably": {},
+	}
+	hedgeWords = map[string]struct{}{
+		"evidence": {}, "data": {}, "unclear": {}, "might": {}, "may": {}, "assume": {},
+		"prove": {}, "risk": {}, "doubt": {}, "depends": {}, "measure": {}, "however": {},
+	}
+	warmWords = map[string]struct{}{
+		"you": {}, "your": {}, "we": {}, "together": {}, "thanks": {}, "glad": {}, "happy": {},
+		"love": {}, "welcome": {}, "help": {},
+	}
+)
+
+// toneTraits maps tone words a persona owner is likely to write to a check on
+// the generated text. Tone words without a check are ignored rather than
+// counted against the persona.
+var toneTraits = map[string]func(textStats) bool{
+	"calm":         func(s textStats) bool { return s.exclamations == 0 && s.shouting == 0 },
+	"measured":     func(s textStats) bool { return s.exclamations == 0 && s.shouting == 0 },
+	"enthusiastic": func(s textStats) bool { return s.exclamations > 0 },
+	"energetic":    func(s textStats) bool { return s.exclamations > 0 },
+	"excited":      func(s textStats) bool { return s.exclamations > 0 },
+	"bold":         func(s textStats) bool { return s.exclamations > 0 || s.avgSentenceWords <= 14 },
+	"concise":      func(s textStats) bool { return s.avgSentenceWords <= 16 },
+	"direct":       func(s textStats) bool { return s.avgSentenceWords <= 16 },
+	"practical":    func(s textStats) bool { return s.avgSentenceWords <= 20 },
+	"detailed":     func(s textStats) bool { return s.words >= 40 },
+	"thoughtful":   func(s textStats) bool { return s.words >= 25 },
+	"curious":      func(s textStats) bool { return s.questions > 0 },
+	"inquisitive":  func(s textStats) bool { return s.questions > 0 },
+	"skeptical":    func(s textStats) bool { return s.hedges > 0 || s.questions > 0 },
+	"analytical":   func(s textStats) bool { return s.hedges > 0 },
+	"friendly":     func(s textStats) bool { return s.warm > 0 },
+	"warm":         func(s textStats) bool { return s.warm > 0 },
+	"supportive":   func(s textStats) bool { return s.warm > 0 },
+}
+
+type textStats struct {
+	words                int
+	sentences            int
+	avgSentenceWords     float64
+	avgWordLength        float64
+	longestSentenceWords int
+	exclamations         int
+	questions            int
+	shouting             int
+	contractions         int
+	informal             int
+	formal               int
+	hedges               int
+	warm                 int
+}
+
+// Evaluate scores text against a persona profile. Each component is in
+// [0, 1]; Overall weights do_not_say heaviest because a violation is the
+// failure owners notice first.
+func Evaluate(profile Profile, text string) Score {
+	stats := analyze(text)
+
+	out := Score{
+		DoNotSay:           1,
+		Tone:               1,
+		EstimatedFormality: estimateFormality(stats),
+	}
+
+	lower := strings.ToLower(text)
+	for _, phrase := range profile.DoNotSay {
+		clean := strings.ToLower(strings.TrimSpace(phrase))
+		if clean == "" {
+			continue
+		}
+		if strings.Contains(lower, clean) {
+			out.Violations = append(out.Violations, strings.TrimSpace(phrase))
+		}
+	}
+	out.DoNotSay = math.Max(0, 1-0.5*float64(len(out.Violations)))
+
+	matched := 0
+	for _, word := range wordPattern.FindAllString(strings.ToLower(profile.Tone), -1) {
+		check, ok := toneTraits[word]
+		if !ok {
+			continue
+		}
+		out.ToneTraits = append(out.ToneTraits, word)
+		if check(stats) {
+			matched++
+		}
+	}
+	if len(out.ToneTraits) > 0 {
+		out.Tone = float64(matched) / float64(len(out.ToneTraits))
+	}
+
+	target := profile.Formality
+	if target < 0 {
+		target = 0
+	}
+	if target > 3 {
+		target = 3
+	}
+	out.Formality = 1 - math.Abs(float64(out.EstimatedFormality-target))/3
+
+	out.Tone = round(out.Tone)
+	out.DoNotSay = round(out.DoNotSay)
+	out.Formality = round(out.Formality)
+	out.Overall = round(0.4*out.DoNotSay + 0.3*out.Tone + 0.3*out.Formality)
+	return out
+}
+
+// Average folds several scores into one, keeping the union of violations so a
+// report can list every phrase that slipped through.
+func Average(scores []Score) Score {
+	if len(scores) == 0 {
+		return Score{}
+	}
+	var out Score
+	seen := map[string]struct{}{}
+	formality := 0
+	for _, score := range scores {
+		out.Tone += score.Tone
+		out.DoNotSay += score.DoNotSay
+		out.Formality += score.Formality
+		out.Overall += score.Overall
+		formality += score.EstimatedFormality
+		for _, violation := range score.Violations {
+			key := strings.ToLower(violation)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			out.Violations = append(out.Violations, violation)
+		}
+	}
+	count := float64(len(scores))
+	out.Tone = round(out.Tone / count)
+	out.DoNotSay = round(out.DoNotSay / count)
+	out.Formality = round(out.Formality / count)
+	out.Overall = round(out.Overall / count)
+	out.EstimatedFormality = int(math.Round(float64(formality) / count))
+	out.ToneTraits = scores[0].ToneTraits
+	return out
+}
+
+// TurnLimits are per-template constraints on a single battle turn. Nil
+// limits are not checked; MaxQuestions of 0 rules out rhetorical questions.
+type TurnLimits struct {
+	MaxQuestions     *int `json:"max_questions,omitempty"`
+	MaxExclamations  *int `json:"max_exclamations,omitempty"`
+	MaxSentenceWords *int `json:"max_sentence_words,omitempty"`
+}
+
+// CheckTurn lists the limits text breaks, in a stable order.
+func CheckTurn(limits TurnLimits, text string) []string {
+	stats := analyze(text)
+	var broken []string
+	if limits.MaxQuestions != nil && stats.questions > *limits.MaxQuestions {
+		broken = append(broken, fmt.Sprintf("%d questions (max %d)", stats.questions, *limits.MaxQuestions))
+	}
+	if limits.MaxExclamations != nil && stats.exclamations > *limits.MaxExclamations {
+		broken = append(broken, fmt.Sprintf("%d exclamations (max %d)", stats.exclamations, *limits.MaxExclamations))
+	}
+	if limits.MaxSentenceWords != nil && stats.longestSentenceWords > *limits.MaxSentenceWords {
+		broken = append(broken, fmt.Sprintf("a %d-word sentence (max %d)", stats.longestSentenceWords, *limits.MaxSentenceWords))
+	}
+	return broken
+}
+
+func analyze(text string) textStats {
+	var stats textStats
+	words := wordPattern.FindAllString(text, -1)
+	stats.words = len(words)
+
+	letters := 0
+	for _, word := range words {
+		letters += len([]rune(word))
+		lower := strings.ToLower(word)
+		if _, ok := informalWords[lower]; ok {
+			stats.informal++
+		}
+		if _, ok := formalWords[lower]; ok {
+			stats.formal++
+		}
+		if _, ok := hedgeWords[lower]; ok {
+			stats.hedges++
+		}
+		if _, ok := warmWords[lower]; ok {
+			stats.warm++
+		}
+		if len([]rune(word)) >= 3 && isUpper(word) {
+			stats.shouting++
+		}
+	}
+	if stats.words > 0 {
+		stats.avgWordLength = float64(letters) / float64(stats.words)
+	}
+
+	stats.exclamations = strings.Count(text, "!")
+	stats.questions = strings.Count(text, "?")
+	stats.contractions = len(contractionPattern.FindAllString(text, -1))
+	sentences := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == '\n'
+	})
+	stats.sentences = len(sentences)
+	for _, sentence := range sentences {
+		if count := len(wordPattern.FindAllString(sentence, -1)); count > stats.longestSentenceWords {
+			stats.longestSentenceWords = count
+		}
+	}
+	if stats.sentences == 0 && stats.words > 0 {
+		stats.sentences = 1
+	}
+	if stats.sentences > 0 {
+		stats.avgSentenceWords = float64(stats.words) / float64(stats.sentences)
+	}
+	return stats
+}
+
+// estimateFormality places text on the persona formality scale (0 casual to
+// 3 formal) from marker words, contractions, punctuation and word length.
+func estimateFormality(stats textStats) int {
+	if stats.words == 0 {
+		return 1
+	}
+	score := 1.5
+	score += 0.5 * float64(stats.formal)
+	score -= 0.5 * float64(stats.informal)
+	score -= 0.25 * float64(stats.contractions)
+	score -= 0.25 * float64(stats.exclamations)
+	switch {
+	case stats.avgWordLength >= 5.5:
+		score += 0.75
+	case stats.avgWordLength < 4.2:
+		score -= 0.5
+	}
+	if stats.avgSentenceWords >= 20 {
+		score += 0.5
+	}
+	estimate := int(math.Round(score))
+	if estimate < 0 {
+		return 0
+	}
+	if estimate > 3 {
+		return 3
+	}
+	return estimate
+}
+
+func isUpper(word string) bool {
+	hasLetter := false
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			if !unicode.IsUpper(r) {
+				return false
+			}
+		}
+	}
+	return hasLetter
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,69 @@
+package quality
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateFlagsDoNotSayPhrases(t *testing.T) {
+	score := Evaluate(Profile{DoNotSay: []string{"Synergy", " "}, Formality: 1}, "We need more synergy across teams.")
+	if len(score.Violations) != 1 || score.Violations[0] != "Synergy" {
+		t.Fatalf("expected one synergy violation, got %v", score.Violations)
+	}
+	if score.DoNotSay != 0.5 {
+		t.Fatalf("expected do_not_say score 0.5, got %v", score.DoNotSay)
+	}
+}
+
+func TestEvaluateToneTraits(t *testing.T) {
+	calm := Evaluate(Profile{Tone: "calm, curious"}, "What would change if we shipped weekly? I would measure it first.")
+	if calm.Tone != 1 || len(calm.ToneTraits) != 2 {
+		t.Fatalf("expected calm and curious traits to match, got %+v", calm)
+	}
+
+	loud := Evaluate(Profile{Tone: "calm"}, "SHIP IT NOW!")
+	if loud.Tone != 0 {
+		t.Fatalf("expected shouting to fail calm tone, got %v", loud.Tone)
+	}
+
+	unknown := Evaluate(Profile{Tone: "whimsical"}, "Anything goes.")
+	if unknown.Tone != 1 || len(unknown.ToneTraits) != 0 {
+		t.Fatalf("expected unchecked tone to stay neutral, got %+v", unknown)
+	}
+}
+
+func TestEstimateFormalityOrdersRegisters(t *testing.T) {
+	casual := Evaluate(Profile{Formality: 0}, "hey guys, we're gonna ship it, it's awesome lol!")
+	formal := Evaluate(Profile{Formality: 3}, "Furthermore, the organisation should therefore evaluate architectural consequences systematically.")
+	if casual.EstimatedFormality >= formal.EstimatedFormality {
+		t.Fatalf("expected casual < formal, got %d vs %d", casual.EstimatedFormality, formal.EstimatedFormality)
+	}
+	if casual.Formality < 0.66 || formal.Formality < 0.66 {
+		t.Fatalf("expected both samples to sit near their target, got %v and %v", casual.Formality, formal.Formality)
+	}
+}
+
+func TestAverageKeepsUniqueViolations(t *testing.T) {
+	avg := Average([]Score{
+		{Tone: 1, DoNotSay: 0.5, Formality: 1, Overall: 0.8, EstimatedFormality: 1, Violations: []string{"synergy"}},
+		{Tone: 0, DoNotSay: 0.5, Formality: 0.5, Overall: 0.4, EstimatedFormality: 2, Violations: []string{"Synergy"}},
+	})
+	if avg.Tone != 0.5 || avg.Overall != 0.6 || len(avg.Violations) != 1 {
+		t.Fatalf("unexpected average %+v", avg)
+	}
+	if empty := Average(nil); empty.Overall != 0 {
+		t.Fatalf("expected zero score for no samples, got %+v", empty)
+	}
+}
+
+func TestCheckTurnOnlyChecksSetLimits(t *testing.T) {
+	zero, three := 0, 3
+	text := "Is that really true? Who measured it? Ship it now!"
+	if broken := CheckTurn(TurnLimits{}, text); len(broken) != 0 {
+		t.Fatalf("expected no limits to pass, got %v", broken)
+	}
+	broken := CheckTurn(TurnLimits{MaxQuestions: &zero, MaxExclamations: &three, MaxSentenceWords: &three}, text)
+	if len(broken) != 2 || !strings.Contains(broken[0], "2 questions") || !strings.Contains(broken[1], "4-word sentence") {
+		t.Fatalf("unexpected broken limits %v", broken)
+	}
+}
@@ -0,0 +1,80 @@
+package safety
+
+import "strings"
+
+const (
+	SeverityWarn  = "warn"
+	SeverityBlock = "block"
+
+	CategoryProfanity   = "profanity"
+	CategoryBrandSafety = "brand_safety"
+
+	LanguageEN = "en"
+	LanguageTR = "tr"
+)
+
+// LexiconTerm is a word or phrase checked in content of one language. Block
+// terms reject the content; warn terms only flag it.
+type LexiconTerm struct {
+	Term     string `json:"term"`
+	Language string `json:"language"`
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+}
+
+// DefaultLexicon is used until a deployment's lexicon can be loaded. It
+// matches the seed rows of the safety_lexicon_terms table.
+func DefaultLexicon() []LexiconTerm {
+	terms := make([]LexiconTerm, 0, 10)
+	for _, term := range []string{"fuck", "shit", "bitch", "asshole", "dick"} {
+		terms = append(terms, LexiconTerm{Term: term, Language: LanguageEN, Severity: SeverityBlock, Category: CategoryProfanity})
+	}
+	for _, term := range []string{"siktir", "orospu", "piç", "yavşak", "amk"} {
+		terms = append(terms, LexiconTerm{Term: term, Language: LanguageTR, Severity: SeverityBlock, Category: CategoryProfanity})
+	}
+	return terms
+}
+
+// NormalizeLanguage returns "tr" or "en", or "" for anything else.
+func NormalizeLanguage(value string) string {
+	clean := strings.ToLower(strings.TrimSpace(value))
+	switch clean {
+	case LanguageEN, LanguageTR:
+		return clean
+	default:
+		return ""
+	}
+}
+
+// Warnings returns the warn-severity lexicon terms found in content. They do
+// not fail the check but are worth surfacing to reviewers.
+func (r Rules) Warnings(content string) []LexiconTerm {
+	return r.lexiconMatches(strings.TrimSpace(content), SeverityWarn)
+}
+
+func (r Rules) lexiconMatches(content, severity string) []LexiconTerm {
+	if len(r.Lexicon) == 0 {
+		return nil
+	}
+	allowed := map[string]struct{}{}
+	for _, term := range NormalizeTerms(r.AllowedTerms) {
+		allowed[term] = struct{}{}
+	}
+	padded := paddedWords(content)
+	var matches []LexiconTerm
+	for _, term := range r.Lexicon {
+		if term.Severity != severity {
+			continue
+		}
+		if r.Language != "" && term.Language != r.Language {
+			continue
+		}
+		if _, ok := allowed[foldCase(strings.TrimSpace(term.Term))]; ok {
+			continue
+		}
+		if containsPhrase(padded, term.Term) {
+			matches = append(matches, term)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,163 @@
+package safety
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Surfaces a red-team case can target. Each one is checked by the same code
+// the API runs for it.
+const (
+	RedTeamSurfacePersona       = "persona"
+	RedTeamSurfacePreview       = "preview"
+	RedTeamSurfaceBattleTopic   = "battle_topic"
+	RedTeamSurfaceTemplateRules = "template_prompt_rules"
+
+	RedTeamExpectBlock = "block"
+	RedTeamExpectAllow = "allow"
+
+	redTeamOutcomeBlocked = "blocked"
+	redTeamOutcomeAllowed = "allowed"
+)
+
+//go:embed redteam_corpus.jsonl
+var redTeamCorpus []byte
+
+// RedTeamCase is one adversarial (or deliberately harmless) input. Field
+// picks the persona field the input goes into; Language is the persona or
+// content language.
+type RedTeamCase struct {
+	ID       string `json:"id"`
+	Surface  string `json:"surface"`
+	Field    string `json:"field,omitempty"`
+	Language string `json:"language,omitempty"`
+	Input    string `json:"input"`
+	Expect   string `json:"expect"`
+	Note     string `json:"note,omitempty"`
+}
+
+type RedTeamResult struct {
+	ID      string `json:"id"`
+	Surface string `json:"surface"`
+	Field   string `json:"field,omitempty"`
+	Expect  string `json:"expect"`
+	Outcome string `json:"outcome"`
+	Rule    string `json:"rule,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Passed  bool   `json:"passed"`
+}
+
+type RedTeamSurfaceTally struct {
+	Total  int `json:"total"`
+	Failed int `json:"failed"`
+}
+
+type RedTeamReport struct {
+	Passed   bool                           `json:"passed"`
+	Total    int                            `json:"total"`
+	Failed   int                            `json:"failed"`
+	Surfaces map[string]RedTeamSurfaceTally `json:"surfaces"`
+	Failures []RedTeamResult                `json:"failures"`
+	Results  []RedTeamResult                `json:"results"`
+}
+
+// RedTeamCorpus returns the curated cases shipped with the server.
+func RedTeamCorpus() ([]RedTeamCase, error) {
+	return ParseRedTeamCorpus(bytes.NewReader(redTeamCorpus))
+}
+
+// ParseRedTeamCorpus reads one JSON case per line. Blank lines and lines
+// starting with # are skipped.
+func ParseRedTeamCorpus(r io.Reader) ([]RedTeamCase, error) {
+	cases := make([]RedTeamCase, 0)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		var c RedTeamCase
+		if err := json.Unmarshal([]byte(raw), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := ValidateRedTeamCases(cases); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+// ValidateRedTeamCases checks every case has a unique id, a known surface and
+// an expectation.
+func ValidateRedTeamCases(cases []RedTeamCase) error {
+	seen := map[string]struct{}{}
+	for i, c := range cases {
+		id := strings.TrimSpace(c.ID)
+		if id == "" {
+			return fmt.Errorf("case %d: id is required", i+1)
+		}
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("case %s: duplicate id", id)
+		}
+		seen[id] = struct{}{}
+		switch c.Surface {
+		case RedTeamSurfacePersona, RedTeamSurfacePreview, RedTeamSurfaceBattleTopic, RedTeamSurfaceTemplateRules:
+		default:
+			return fmt.Errorf("case %s: unknown surface %q", id, c.Surface)
+		}
+		if c.Expect != RedTeamExpectBlock && c.Expect != RedTeamExpectAllow {
+			return fmt.Errorf("case %s: expect must be block or allow", id)
+		}
+	}
+	return nil
+}
+
+// RunRedTeam replays cases through check, which returns the error the
+// surface's validation produced, or nil when the input got through. A case
+// passes when the outcome matches its expectation.
+func RunRedTeam(cases []RedTeamCase, check func(RedTeamCase) error) RedTeamReport {
+	report := RedTeamReport{
+		Total:    len(cases),
+		Surfaces: map[string]RedTeamSurfaceTally{},
+		Failures: make([]RedTeamResult, 0),
+		Results:  make([]RedTeamResult, 0, len(cases)),
+	}
+	for _, c := range cases {
+		result := RedTeamResult{
+			ID:      c.ID,
+			Surface: c.Surface,
+			Field:   c.Field,
+			Expect:  c.Expect,
+			Outcome: redTeamOutcomeAllowed,
+		}
+		if err := check(c); err != nil {
+			result.Outcome = redTeamOutcomeBlocked
+			result.Rule = RuleOf(err)
+			result.Reason = err.Error()
+		}
+		result.Passed = (result.Outcome == redTeamOutcomeBlocked) == (c.Expect == RedTeamExpectBlock)
+
+		tally := report.Surfaces[c.Surface]
+		tally.Total++
+		if !result.Passed {
+			tally.Failed++
+			report.Failed++
+			report.Failures = append(report.Failures, result)
+		}
+		report.Surfaces[c.Surface] = tally
+		report.Results = append(report.Results, result)
+	}
+	report.Passed = report.Failed == 0
+	return report
+}
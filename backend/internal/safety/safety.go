@@ -6,24 +6,160 @@ import (
 	"strings"
 )
 
+const (
+	RuleEmpty       = "empty"
+	RuleMaxLength   = "max_length"
+	RuleProfanity   = "profanity"
+	RuleLinkSpam    = "link_spam"
+	RuleBlockedTerm = "blocked_term"
+	RuleBrandSafety = "brand_safety"
+	// RuleTemplatePolicy is a battle turn that broke its template's approved
+	// turn limits.
+	RuleTemplatePolicy = "template_policy"
+
+	DefaultMaxLinks = 2
+)
+
 var (
-	profanityPattern = regexp.MustCompile(`(?i)\b(fuck|shit|bitch|asshole|dick)\b`)
-	linkPattern      = regexp.MustCompile(`(?i)https?://|www\.`)
+	linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+	wordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+	// Lowercasing "İ" yields "i" plus a combining dot, which would split
+	// Turkish words in two; fold it to a plain "i" first.
+	dottedCapitalI = strings.NewReplacer("İ", "i")
 )
 
+// Violation is the error returned when content breaks a rule. Rule is one of
+// the Rule* constants so rejections can be grouped and replayed.
+type Violation struct {
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// RuleOf returns the rule behind a validation error, or "" for other errors.
+func RuleOf(err error) string {
+	var violation *Violation
+	if errors.As(err, &violation) {
+		return violation.Rule
+	}
+	return ""
+}
+
+// Rules are the tunable parts of the content check. AllowedTerms exempt
+// words the lexicon would otherwise catch (names, quoted titles);
+// BlockedTerms add whole words or phrases on top of the lexicon. Language
+// picks the lexicon list to apply; when empty every list applies.
+type Rules struct {
+	MaxLinks     int           `json:"max_links"`
+	AllowedTerms []string      `json:"allowed_terms"`
+	BlockedTerms []string      `json:"blocked_terms"`
+	Lexicon      []LexiconTerm `json:"-"`
+	Language     string        `json:"-"`
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		MaxLinks:     DefaultMaxLinks,
+		AllowedTerms: []string{},
+		BlockedTerms: []string{},
+		Lexicon:      DefaultLexicon(),
+	}
+}
+
+// ForLanguage returns a copy of the rules that only applies the lexicon list
+// of language. Unknown languages fall back to every list.
+func (r Rules) ForLanguage(language string) Rules {
+	r.Language = NormalizeLanguage(language)
+	return r
+}
+
+// NormalizeTerms lowercases, trims and de-duplicates a term list.
+func NormalizeTerms(terms []string) []string {
+	out := make([]string, 0, len(terms))
+	seen := map[string]struct{}{}
+	for _, term := range terms {
+		clean := foldCase(strings.Join(strings.Fields(term), " "))
+		if clean == "" {
+			continue
+		}
+		if _, ok := seen[clean]; ok {
+			continue
+		}
+		seen[clean] = struct{}{}
+		out = append(out, clean)
+	}
+	return out
+}
+
 func ValidateContent(content string, maxLen int) error {
+	return DefaultRules().Validate(content, maxLen)
+}
+
+// ValidateShape only checks content is non-empty and fits maxLen. It is used
+// for content an admin has already reviewed and approved.
+func ValidateShape(content string, maxLen int) error {
 	trimmed := strings.TrimSpace(content)
 	if trimmed == "" {
-		return errors.New("content cannot be empty")
+		return &Violation{Rule: RuleEmpty, Message: "content cannot be empty"}
 	}
 	if len([]rune(trimmed)) > maxLen {
-		return errors.New("content exceeds max length")
+		return &Violation{Rule: RuleMaxLength, Message: "content exceeds max length"}
+	}
+	return nil
+}
+
+func (r Rules) Validate(content string, maxLen int) error {
+	if err := ValidateShape(content, maxLen); err != nil {
+		return err
 	}
-	if profanityPattern.MatchString(trimmed) {
-		return errors.New("content failed profanity check")
+	trimmed := strings.TrimSpace(content)
+	if matches := r.lexiconMatches(trimmed, SeverityBlock); len(matches) > 0 {
+		if matches[0].Category == CategoryBrandSafety {
+			return &Violation{Rule: RuleBrandSafety, Message: "content failed brand safety check"}
+		}
+		return &Violation{Rule: RuleProfanity, Message: "content failed profanity check"}
 	}
-	if len(linkPattern.FindAllStringIndex(trimmed, -1)) > 2 {
-		return errors.New("content failed link spam check")
+	if r.hasBlockedTerm(trimmed) {
+		return &Violation{Rule: RuleBlockedTerm, Message: "content failed blocked term check"}
+	}
+	if len(linkPattern.FindAllStringIndex(trimmed, -1)) > r.MaxLinks {
+		return &Violation{Rule: RuleLinkSpam, Message: "content failed link spam check"}
 	}
 	return nil
 }
+
+func (r Rules) hasBlockedTerm(content string) bool {
+	terms := NormalizeTerms(r.BlockedTerms)
+	if len(terms) == 0 {
+		return false
+	}
+	padded := paddedWords(content)
+	for _, term := range terms {
+		if containsPhrase(padded, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func foldCase(value string) string {
+	return strings.ToLower(dottedCapitalI.Replace(value))
+}
+
+// paddedWords reduces content to its lowercased words separated and
+// surrounded by single spaces, so phrases only match on word boundaries.
+func paddedWords(content string) string {
+	return " " + strings.Join(wordPattern.FindAllString(foldCase(content), -1), " ") + " "
+}
+
+func containsPhrase(padded, term string) bool {
+	words := wordPattern.FindAllString(foldCase(term), -1)
+	if len(words) == 0 {
+		return false
+	}
+	return strings.Contains(padded, " "+strings.Join(words, " ")+" ")
+}
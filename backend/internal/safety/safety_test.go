@@ -0,0 +1,118 @@
+package safety
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateContentRules(t *testing.T) {
+	cases := []struct {
+		content string
+		rule    string
+	}{
+		{content: "   ", rule: RuleEmpty},
+		{content: "this is shit", rule: RuleProfanity},
+		{content: "see https://a.example https://b.example https://c.example", rule: RuleLinkSpam},
+		{content: "a calm and useful post", rule: ""},
+	}
+	for _, tc := range cases {
+		if got := RuleOf(ValidateContent(tc.content, 100)); got != tc.rule {
+			t.Fatalf("%q: expected rule %q, got %q", tc.content, tc.rule, got)
+		}
+	}
+	if got := RuleOf(ValidateContent("too long", 3)); got != RuleMaxLength {
+		t.Fatalf("expected max_length, got %q", got)
+	}
+}
+
+func TestRulesAllowedAndBlockedTerms(t *testing.T) {
+	rules := Rules{
+		MaxLinks:     0,
+		AllowedTerms: []string{" Dick "},
+		BlockedTerms: []string{"Crypto  Giveaway", "crypto giveaway"},
+	}
+	if err := rules.Validate("Moby Dick is a long book", 100); err != nil {
+		t.Fatalf("expected allowed term to pass, got %v", err)
+	}
+	if got := RuleOf(rules.Validate("Join the crypto giveaway!", 100)); got != RuleBlockedTerm {
+		t.Fatalf("expected blocked_term, got %q", got)
+	}
+	if err := rules.Validate("cryptography giveaways are different words", 100); err != nil {
+		t.Fatalf("expected partial words to pass, got %v", err)
+	}
+	if got := RuleOf(rules.Validate("read www.example.com", 100)); got != RuleLinkSpam {
+		t.Fatalf("expected link_spam with zero links allowed, got %q", got)
+	}
+	if got := NormalizeTerms(rules.BlockedTerms); len(got) != 1 || got[0] != "crypto giveaway" {
+		t.Fatalf("unexpected normalized terms %v", got)
+	}
+}
+
+func TestRulesLexiconLanguagesAndSeverity(t *testing.T) {
+	rules := DefaultRules()
+	rules.Lexicon = append(rules.Lexicon,
+		LexiconTerm{Term: "Rakip Marka", Language: LanguageTR, Severity: SeverityBlock, Category: CategoryBrandSafety},
+		LexiconTerm{Term: "gambling", Language: LanguageEN, Severity: SeverityWarn, Category: CategoryBrandSafety},
+	)
+
+	if got := RuleOf(rules.Validate("SİKTİR git", 100)); got != RuleProfanity {
+		t.Fatalf("expected Turkish profanity with dotted capital I, got %q", got)
+	}
+	if got := RuleOf(rules.Validate("bugün rakip marka indirimde", 100)); got != RuleBrandSafety {
+		t.Fatalf("expected brand_safety, got %q", got)
+	}
+	if err := rules.ForLanguage("en").Validate("bugün rakip marka indirimde", 100); err != nil {
+		t.Fatalf("expected Turkish list to be skipped for en content, got %v", err)
+	}
+	if got := RuleOf(rules.ForLanguage("fr").Validate("this is shit", 100)); got != RuleProfanity {
+		t.Fatalf("expected unknown language to apply every list, got %q", got)
+	}
+
+	if err := rules.Validate("a post about gambling odds", 100); err != nil {
+		t.Fatalf("expected warn term to pass, got %v", err)
+	}
+	warnings := rules.Warnings("a post about gambling odds")
+	if len(warnings) != 1 || warnings[0].Term != "gambling" {
+		t.Fatalf("expected one gambling warning, got %+v", warnings)
+	}
+	if got := rules.ForLanguage("tr").Warnings("a post about gambling odds"); len(got) != 0 {
+		t.Fatalf("expected no warnings for tr content, got %+v", got)
+	}
+}
+
+func TestParseRedTeamCorpusRejectsBadCases(t *testing.T) {
+	for _, raw := range []string{
+		`{"id":"a","surface":"nowhere","input":"x","expect":"block"}`,
+		`{"id":"a","surface":"preview","input":"x","expect":"maybe"}`,
+		`{"surface":"preview","input":"x","expect":"block"}`,
+		"{\"id\":\"a\",\"surface\":\"preview\",\"input\":\"x\",\"expect\":\"block\"}\n{\"id\":\"a\",\"surface\":\"preview\",\"input\":\"y\",\"expect\":\"allow\"}",
+		`not json`,
+	} {
+		if _, err := ParseRedTeamCorpus(strings.NewReader(raw)); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestRunRedTeamReportsMismatches(t *testing.T) {
+	cases := []RedTeamCase{
+		{ID: "blocked", Surface: RedTeamSurfacePreview, Input: "shit", Expect: RedTeamExpectBlock},
+		{ID: "missed", Surface: RedTeamSurfacePreview, Input: "sh1t", Expect: RedTeamExpectBlock},
+		{ID: "clean", Surface: RedTeamSurfaceBattleTopic, Input: "fine", Expect: RedTeamExpectAllow},
+	}
+	report := RunRedTeam(cases, func(c RedTeamCase) error {
+		return DefaultRules().Validate(c.Input, 100)
+	})
+	if report.Passed || report.Total != 3 || report.Failed != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].ID != "missed" || report.Failures[0].Outcome != redTeamOutcomeAllowed {
+		t.Fatalf("expected the missed case as the only failure, got %+v", report.Failures)
+	}
+	if report.Results[0].Rule != RuleProfanity {
+		t.Fatalf("expected the blocking rule in the result, got %+v", report.Results[0])
+	}
+	if tally := report.Surfaces[RedTeamSurfacePreview]; tally.Total != 2 || tally.Failed != 1 {
+		t.Fatalf("unexpected preview tally %+v", tally)
+	}
+}
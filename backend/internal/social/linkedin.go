@@ -0,0 +1,148 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const linkedInPostLimit = 3000
+
+const linkedInScopes = "openid profile w_member_social"
+
+// LinkedIn publishes member shares through the UGC posts API. LinkedIn does
+// not use PKCE for web apps, so the challenge and verifier are ignored.
+type LinkedIn struct {
+	creds       Credentials
+	client      *http.Client
+	authBaseURL string
+	apiBaseURL  string
+	webBaseURL  string
+}
+
+func NewLinkedIn(creds Credentials, client *http.Client) *LinkedIn {
+	return &LinkedIn{
+		creds:       creds,
+		client:      client,
+		authBaseURL: "https://www.linkedin.com/oauth/v2",
+		apiBaseURL:  "https://api.linkedin.com",
+		webBaseURL:  "https://www.linkedin.com",
+	}
+}
+
+func (p *LinkedIn) Name() string { return ProviderLinkedIn }
+
+func (p *LinkedIn) Limit() int { return linkedInPostLimit }
+
+func (p *LinkedIn) LinkLength(link string) int { return len([]rune(link)) }
+
+func (p *LinkedIn) AuthorizeURL(state, _ string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.creds.ClientID},
+		"redirect_uri":  {p.creds.RedirectURL},
+		"scope":         {linkedInScopes},
+		"state":         {state},
+	}
+	return p.authBaseURL + "/authorization?" + query.Encode()
+}
+
+func (p *LinkedIn) Exchange(ctx context.Context, code, _ string) (Token, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {p.creds.RedirectURL},
+	})
+}
+
+// Refresh only works for apps LinkedIn enabled refresh tokens for; other
+// members reconnect once their token expires.
+func (p *LinkedIn) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	if refreshToken == "" {
+		return Token{}, ErrUnauthorized
+	}
+	return p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (p *LinkedIn) token(ctx context.Context, form url.Values) (Token, error) {
+	form.Set("client_id", p.creds.ClientID)
+	form.Set("client_secret", p.creds.ClientSecret)
+	req, err := formRequest(ctx, p.authBaseURL+"/accessToken", form)
+	if err != nil {
+		return Token{}, err
+	}
+	var out oauthTokenResponse
+	if _, err := do(p.client, ProviderLinkedIn, req, &out); err != nil {
+		return Token{}, err
+	}
+	return out.token(time.Now().UTC())
+}
+
+func (p *LinkedIn) Account(ctx context.Context, accessToken string) (Account, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiBaseURL+"/v2/userinfo", nil)
+	if err != nil {
+		return Account{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	var out struct {
+		Sub  string `json:"sub"`
+		Name string `json:"name"`
+	}
+	if _, err := do(p.client, ProviderLinkedIn, req, &out); err != nil {
+		return Account{}, err
+	}
+	if out.Sub == "" {
+		return Account{}, errors.New("linkedin returned no member id")
+	}
+	return Account{ExternalID: out.Sub, Handle: out.Name}, nil
+}
+
+func (p *LinkedIn) Publish(ctx context.Context, accessToken string, account Account, text string) (Published, error) {
+	body, err := json.Marshal(map[string]any{
+		"author":         "urn:li:person:" + account.ExternalID,
+		"lifecycleState": "PUBLISHED",
+		"specificContent": map[string]any{
+			"com.linkedin.ugc.ShareContent": map[string]any{
+				"shareCommentary":    map[string]string{"text": text},
+				"shareMediaCategory": "NONE",
+			},
+		},
+		"visibility": map[string]string{"com.linkedin.ugc.MemberNetworkVisibility": "PUBLIC"},
+	})
+	if err != nil {
+		return Published{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiBaseURL+"/v2/ugcPosts", bytes.NewReader(body))
+	if err != nil {
+		return Published{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Restli-Protocol-Version", "2.0.0")
+	var out struct {
+		ID string `json:"id"`
+	}
+	resp, err := do(p.client, ProviderLinkedIn, req, &out)
+	if err != nil {
+		return Published{}, err
+	}
+	urn := strings.TrimSpace(resp.Header.Get("X-RestLi-Id"))
+	if urn == "" {
+		urn = out.ID
+	}
+	if urn == "" {
+		return Published{}, errors.New("linkedin returned no post id")
+	}
+	return Published{
+		ExternalID: urn,
+		URL:        p.webBaseURL + "/feed/update/" + url.PathEscape(urn) + "/",
+	}, nil
+}
@@ -0,0 +1,205 @@
+// Package social connects user accounts on external networks over OAuth 2.0
+// and publishes posts to them.
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"personaworlds/backend/internal/config"
+)
+
+const (
+	ProviderX        = "x"
+	ProviderLinkedIn = "linkedin"
+
+	maxErrorBodyBytes = 512
+)
+
+// ErrUnauthorized means the network refused the access token; the account
+// has to be refreshed or connected again.
+var ErrUnauthorized = errors.New("social account authorization was refused")
+
+// Token is an OAuth token pair. ExpiresAt is zero when the network did not
+// say when the access token expires.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// Expired reports whether the access token should be refreshed before use.
+func (t Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Add(time.Minute).Before(t.ExpiresAt)
+}
+
+// Account identifies the connected member on the network.
+type Account struct {
+	ExternalID string
+	Handle     string
+}
+
+// Published is a post created on the network.
+type Published struct {
+	ExternalID string
+	URL        string
+}
+
+// Provider is one network. AuthorizeURL and Exchange take the PKCE
+// challenge and verifier; networks without PKCE ignore them.
+type Provider interface {
+	Name() string
+	// Limit is the longest post the network accepts and LinkLength what a
+	// link counts against it.
+	Limit() int
+	LinkLength(link string) int
+	AuthorizeURL(state, challenge string) string
+	Exchange(ctx context.Context, code, verifier string) (Token, error)
+	Refresh(ctx context.Context, refreshToken string) (Token, error)
+	Account(ctx context.Context, accessToken string) (Account, error)
+	Publish(ctx context.Context, accessToken string, account Account, text string) (Published, error)
+}
+
+// APIError is a non-2xx response from a network.
+type APIError struct {
+	Provider string
+	Status   int
+	Body     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.Provider, e.Status, e.Body)
+}
+
+// Temporary reports whether the same request may succeed later.
+func (e *APIError) Temporary() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// NewProviders builds the networks that have client credentials configured.
+// Redirects go back to the frontend, which hands code and state to the API.
+func NewProviders(cfg config.Config, client *http.Client) map[string]Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	origin := strings.TrimRight(cfg.FrontendOrigin, "/")
+	providers := map[string]Provider{}
+	if cfg.XClientID != "" && cfg.XClientSecret != "" {
+		providers[ProviderX] = NewX(Credentials{
+			ClientID:     cfg.XClientID,
+			ClientSecret: cfg.XClientSecret,
+			RedirectURL:  origin + "/connect/" + ProviderX,
+		}, client)
+	}
+	if cfg.LinkedInClientID != "" && cfg.LinkedInClientSecret != "" {
+		providers[ProviderLinkedIn] = NewLinkedIn(Credentials{
+			ClientID:     cfg.LinkedInClientID,
+			ClientSecret: cfg.LinkedInClientSecret,
+			RedirectURL:  origin + "/connect/" + ProviderLinkedIn,
+		}, client)
+	}
+	return providers
+}
+
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// FormatPost fits content and the backlink into the provider's limit. Long
+// content is cut at a word boundary and marked with an ellipsis; the
+// backlink always survives.
+func FormatPost(provider Provider, content, backlink string) string {
+	content = strings.TrimSpace(content)
+	budget := provider.Limit() - provider.LinkLength(backlink) - 2
+	runes := []rune(content)
+	if len(runes) > budget {
+		cut := budget - 1
+		if cut < 0 {
+			cut = 0
+		}
+		runes = runes[:cut]
+		if i := lastSpace(runes); i > cut/2 {
+			runes = runes[:i]
+		}
+		content = strings.TrimRightFunc(string(runes), func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		}) + "…"
+	}
+	if content == "" || content == "…" {
+		return backlink
+	}
+	return content + "\n\n" + backlink
+}
+
+func lastSpace(runes []rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (r oauthTokenResponse) token(now time.Time) (Token, error) {
+	if r.AccessToken == "" {
+		return Token{}, errors.New("token response has no access token")
+	}
+	token := Token{AccessToken: r.AccessToken, RefreshToken: r.RefreshToken}
+	if r.ExpiresIn > 0 {
+		token.ExpiresAt = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// do sends req and decodes a JSON body into out when out is not nil. Refused
+// tokens come back as ErrUnauthorized, other failures as *APIError.
+func do(client *http.Client, provider string, req *http.Request, out any) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		io.Copy(io.Discard, resp.Body)
+		return resp, ErrUnauthorized
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return resp, &APIError{Provider: provider, Status: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return resp, fmt.Errorf("decode %s response: %w", provider, err)
+	}
+	return resp, nil
+}
+
+func formRequest(ctx context.Context, endpoint string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
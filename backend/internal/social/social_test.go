@@ -0,0 +1,125 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const testBacklink = "https://personaworlds.test/p/ada?utm_medium=crosspost&utm_source=x#post-1"
+
+func TestFormatPostKeepsShortContent(t *testing.T) {
+	x := NewX(Credentials{}, nil)
+	got := FormatPost(x, "  Ship small, ship often.  ", testBacklink)
+	if got != "Ship small, ship often.\n\n"+testBacklink {
+		t.Fatalf("unexpected post %q", got)
+	}
+}
+
+func TestFormatPostTruncatesAtWordBoundary(t *testing.T) {
+	x := NewX(Credentials{}, nil)
+	content := strings.Repeat("persona worlds ", 40)
+	got := FormatPost(x, content, testBacklink)
+
+	text, link, ok := strings.Cut(got, "\n\n")
+	if !ok || link != testBacklink {
+		t.Fatalf("expected backlink to survive, got %q", got)
+	}
+	if !strings.HasSuffix(text, "worlds…") && !strings.HasSuffix(text, "persona…") {
+		t.Fatalf("expected cut at a word with an ellipsis, got %q", text)
+	}
+	if counted := utf8.RuneCountInString(text) + 2 + xLinkLength; counted > xPostLimit {
+		t.Fatalf("post counts %d chars, limit %d", counted, xPostLimit)
+	}
+}
+
+func TestFormatPostCountsLinkedInLinksInFull(t *testing.T) {
+	linkedIn := NewLinkedIn(Credentials{}, nil)
+	got := FormatPost(linkedIn, strings.Repeat("a", linkedInPostLimit), testBacklink)
+	if n := utf8.RuneCountInString(got); n > linkedInPostLimit {
+		t.Fatalf("post has %d runes, limit %d", n, linkedInPostLimit)
+	}
+}
+
+func TestXPublishBuildsStatusURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2/tweets" || r.Header.Get("Authorization") != "Bearer access" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["text"] != "hello" {
+			t.Errorf("unexpected body %v (%v)", body, err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":"1799","text":"hello"}}`))
+	}))
+	defer server.Close()
+
+	x := NewX(Credentials{}, server.Client())
+	x.apiBaseURL = server.URL
+	published, err := x.Publish(context.Background(), "access", Account{ExternalID: "42", Handle: "ada"}, "hello")
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if published.ExternalID != "1799" || published.URL != "https://x.com/ada/status/1799" {
+		t.Fatalf("unexpected result %+v", published)
+	}
+}
+
+func TestLinkedInPublishReadsShareURNFromHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["author"] != "urn:li:person:abc" {
+			t.Errorf("unexpected body %v (%v)", body, err)
+		}
+		w.Header().Set("X-RestLi-Id", "urn:li:share:7001")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	linkedIn := NewLinkedIn(Credentials{}, server.Client())
+	linkedIn.apiBaseURL = server.URL
+	published, err := linkedIn.Publish(context.Background(), "access", Account{ExternalID: "abc"}, "hello")
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if published.URL != "https://www.linkedin.com/feed/update/urn:li:share:7001/" {
+		t.Fatalf("unexpected url %q", published.URL)
+	}
+}
+
+func TestPublishClassifiesFailures(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"title":"nope"}`))
+	}))
+	defer server.Close()
+
+	x := NewX(Credentials{}, server.Client())
+	x.apiBaseURL = server.URL
+	if _, err := x.Publish(context.Background(), "access", Account{}, "hello"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		status    int
+		temporary bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusBadGateway, true},
+		{http.StatusForbidden, false},
+	} {
+		status = tc.status
+		_, err := x.Publish(context.Background(), "access", Account{}, "hello")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Status != tc.status || apiErr.Temporary() != tc.temporary {
+			t.Fatalf("status %d: unexpected error %v", tc.status, err)
+		}
+	}
+}
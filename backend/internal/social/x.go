@@ -0,0 +1,142 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	xPostLimit = 280
+	// xLinkLength is what X counts for any link once it is wrapped in t.co.
+	xLinkLength = 23
+)
+
+const xScopes = "tweet.read tweet.write users.read offline.access"
+
+// X publishes through the X API v2 with OAuth 2.0 and PKCE.
+type X struct {
+	creds        Credentials
+	client       *http.Client
+	authorizeURL string
+	apiBaseURL   string
+	webBaseURL   string
+}
+
+func NewX(creds Credentials, client *http.Client) *X {
+	return &X{
+		creds:        creds,
+		client:       client,
+		authorizeURL: "https://x.com/i/oauth2/authorize",
+		apiBaseURL:   "https://api.x.com",
+		webBaseURL:   "https://x.com",
+	}
+}
+
+func (p *X) Name() string { return ProviderX }
+
+func (p *X) Limit() int { return xPostLimit }
+
+func (p *X) LinkLength(string) int { return xLinkLength }
+
+func (p *X) AuthorizeURL(state, challenge string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.creds.ClientID},
+		"redirect_uri":          {p.creds.RedirectURL},
+		"scope":                 {xScopes},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return p.authorizeURL + "?" + query.Encode()
+}
+
+func (p *X) Exchange(ctx context.Context, code, verifier string) (Token, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.creds.RedirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+func (p *X) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	if refreshToken == "" {
+		return Token{}, ErrUnauthorized
+	}
+	return p.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (p *X) token(ctx context.Context, form url.Values) (Token, error) {
+	req, err := formRequest(ctx, p.apiBaseURL+"/2/oauth2/token", form)
+	if err != nil {
+		return Token{}, err
+	}
+	req.SetBasicAuth(p.creds.ClientID, p.creds.ClientSecret)
+	var out oauthTokenResponse
+	if _, err := do(p.client, ProviderX, req, &out); err != nil {
+		return Token{}, err
+	}
+	return out.token(time.Now().UTC())
+}
+
+func (p *X) Account(ctx context.Context, accessToken string) (Account, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.apiBaseURL+"/2/users/me", nil)
+	if err != nil {
+		return Account{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	var out struct {
+		Data struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"data"`
+	}
+	if _, err := do(p.client, ProviderX, req, &out); err != nil {
+		return Account{}, err
+	}
+	if out.Data.ID == "" {
+		return Account{}, errors.New("x returned no user id")
+	}
+	return Account{ExternalID: out.Data.ID, Handle: out.Data.Username}, nil
+}
+
+func (p *X) Publish(ctx context.Context, accessToken string, account Account, text string) (Published, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return Published{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiBaseURL+"/2/tweets", bytes.NewReader(body))
+	if err != nil {
+		return Published{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	var out struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if _, err := do(p.client, ProviderX, req, &out); err != nil {
+		return Published{}, err
+	}
+	if out.Data.ID == "" {
+		return Published{}, errors.New("x returned no post id")
+	}
+	owner := "i/web"
+	if account.Handle != "" {
+		owner = url.PathEscape(account.Handle)
+	}
+	return Published{
+		ExternalID: out.Data.ID,
+		URL:        p.webBaseURL + "/" + owner + "/status/" + out.Data.ID,
+	}, nil
+}
@@ -0,0 +1,282 @@
+// Package webpush sends Web Push messages: payloads are encrypted with
+// aes128gcm (RFC 8291) and requests are authorized with VAPID (RFC 8292).
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	recordSize      = 4096
+	MaxPayloadBytes = 3800
+	defaultTTL      = 24 * time.Hour
+	vapidTokenTTL   = 12 * time.Hour
+)
+
+var (
+	ErrSubscriptionGone = errors.New("push subscription expired")
+	ErrPayloadTooLarge  = errors.New("push payload too large")
+)
+
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Keys holds a VAPID key pair as base64url strings: the public key is the
+// 65-byte uncompressed P-256 point browsers expect as applicationServerKey,
+// the private key is the raw 32-byte scalar.
+type Keys struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+func GenerateKeys() (Keys, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return Keys{}, err
+	}
+	return Keys{
+		PublicKey:  encode(key.PublicKey().Bytes()),
+		PrivateKey: encode(key.Bytes()),
+	}, nil
+}
+
+type Sender struct {
+	publicKey  string
+	signingKey *ecdsa.PrivateKey
+	subject    string
+	client     *http.Client
+	ttl        time.Duration
+}
+
+// NewSender validates the VAPID configuration. The public key must match the
+// private key, otherwise push services reject every request.
+func NewSender(publicKey, privateKey, subject string, client *http.Client) (*Sender, error) {
+	rawPrivate, err := decode(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(rawPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("vapid private key: %w", err)
+	}
+	rawPublic := ecdhKey.PublicKey().Bytes()
+	if encode(rawPublic) != strings.TrimRight(strings.TrimSpace(publicKey), "=") {
+		return nil, errors.New("vapid public key does not match private key")
+	}
+	cleanSubject := strings.TrimSpace(subject)
+	if !strings.HasPrefix(cleanSubject, "mailto:") && !strings.HasPrefix(cleanSubject, "https://") {
+		return nil, errors.New("vapid subject must be a mailto: or https:// URL")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Sender{
+		publicKey: encode(rawPublic),
+		signingKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(rawPublic[1:33]),
+				Y:     new(big.Int).SetBytes(rawPublic[33:]),
+			},
+			D: new(big.Int).SetBytes(rawPrivate),
+		},
+		subject: cleanSubject,
+		client:  client,
+		ttl:     defaultTTL,
+	}, nil
+}
+
+func (s *Sender) PublicKey() string {
+	return s.publicKey
+}
+
+// Send encrypts payload for the subscription and posts it to the push
+// service. ErrSubscriptionGone means the browser unsubscribed and the
+// subscription should be deleted.
+func (s *Sender) Send(ctx context.Context, sub Subscription, payload []byte) error {
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return errors.New("push endpoint must be an https URL")
+	}
+	body, err := Encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+	token, err := s.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(s.ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, s.publicKey))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	default:
+		return fmt.Errorf("push service returned %d", resp.StatusCode)
+	}
+}
+
+func (s *Sender) vapidToken(audience string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": audience,
+		"exp": time.Now().Add(vapidTokenTTL).Unix(),
+		"sub": s.subject,
+	})
+	return token.SignedString(s.signingKey)
+}
+
+// ValidateSubscriptionKeys checks the browser-provided keys decode to a P-256
+// point and a 16-byte auth secret before they are stored.
+func ValidateSubscriptionKeys(p256dh, auth string) error {
+	rawPublic, err := decode(p256dh)
+	if err != nil {
+		return errors.New("p256dh key is not base64url")
+	}
+	if _, err := ecdh.P256().NewPublicKey(rawPublic); err != nil {
+		return errors.New("p256dh key is not a P-256 public key")
+	}
+	rawAuth, err := decode(auth)
+	if err != nil {
+		return errors.New("auth secret is not base64url")
+	}
+	if len(rawAuth) != 16 {
+		return errors.New("auth secret must be 16 bytes")
+	}
+	return nil
+}
+
+// Encrypt builds a single-record aes128gcm body: salt, record size, the
+// ephemeral sender key and the ciphertext.
+func Encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadBytes {
+		return nil, ErrPayloadTooLarge
+	}
+	rawUserKey, err := decode(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	userKey, err := ecdh.P256().NewPublicKey(rawUserKey)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	authSecret, err := decode(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptWith(serverKey, userKey, authSecret, salt, payload)
+}
+
+func encryptWith(serverKey *ecdh.PrivateKey, userKey *ecdh.PublicKey, authSecret, salt, payload []byte) ([]byte, error) {
+	sharedSecret, err := serverKey.ECDH(userKey)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	cek, nonce, err := deriveContentKeys(sharedSecret, authSecret, salt, userKey.Bytes(), serverPublic)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 marks the last (and only) record; no extra padding is added.
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+	return append(header, ciphertext...), nil
+}
+
+func deriveContentKeys(sharedSecret, authSecret, salt, userPublic, serverPublic []byte) ([]byte, []byte, error) {
+	keyInfo := append([]byte("WebPush: info\x00"), userPublic...)
+	keyInfo = append(keyInfo, serverPublic...)
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, authSecret, keyInfo), ikm); err != nil {
+		return nil, nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: aes128gcm\x00")), cek); err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: nonce\x00")), nonce); err != nil {
+		return nil, nil, err
+	}
+	return cek, nonce, nil
+}
+
+func encode(raw []byte) string {
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decode accepts base64url with or without padding, which is how browsers
+// and key generators variously serialize push keys.
+func decode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimSpace(value), "="))
+}
@@ -0,0 +1,154 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test vector from RFC 8291 Appendix A.
+func TestEncryptMatchesRFC8291Vector(t *testing.T) {
+	rawServerPrivate, _ := decode("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw")
+	serverKey, err := ecdh.P256().NewPrivateKey(rawServerPrivate)
+	if err != nil {
+		t.Fatalf("server key: %v", err)
+	}
+	rawUserPublic, _ := decode("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4")
+	userKey, err := ecdh.P256().NewPublicKey(rawUserPublic)
+	if err != nil {
+		t.Fatalf("user key: %v", err)
+	}
+	authSecret, _ := decode("BTBZMqHH6r4Tts7J_aSIgg")
+	salt, _ := decode("DGv6ra1nlYgDCS1FRnbzlw")
+
+	body, err := encryptWith(serverKey, userKey, authSecret, salt, []byte("When I grow up, I want to be a watermelon"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := encode(body); got != want {
+		t.Fatalf("unexpected body\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	userKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := []byte("0123456789abcdef")
+	sub := Subscription{Endpoint: "https://push.example", P256dh: encode(userKey.PublicKey().Bytes()), Auth: encode(authSecret)}
+
+	body, err := Encrypt(sub, []byte(`{"title":"Battle done"}`))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if got := decryptForTest(t, userKey, authSecret, body); got != `{"title":"Battle done"}` {
+		t.Fatalf("unexpected plaintext %q", got)
+	}
+
+	if _, err := Encrypt(sub, make([]byte, MaxPayloadBytes+1)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Fatalf("expected oversized payload to fail, got %v", err)
+	}
+}
+
+func TestNewSenderValidatesKeys(t *testing.T) {
+	keys, err := GenerateKeys()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if _, err := NewSender(keys.PublicKey, keys.PrivateKey, "mailto:ops@example.com", nil); err != nil {
+		t.Fatalf("expected generated keys to be valid: %v", err)
+	}
+	other, _ := GenerateKeys()
+	if _, err := NewSender(other.PublicKey, keys.PrivateKey, "mailto:ops@example.com", nil); err == nil {
+		t.Fatalf("expected mismatched public key to fail")
+	}
+	if _, err := NewSender(keys.PublicKey, keys.PrivateKey, "ops@example.com", nil); err == nil {
+		t.Fatalf("expected subject without scheme to fail")
+	}
+}
+
+func TestSendSetsVAPIDHeadersAndMapsGone(t *testing.T) {
+	status := http.StatusCreated
+	var authHeader, encoding string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		encoding = r.Header.Get("Content-Encoding")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	keys, _ := GenerateKeys()
+	sender, err := NewSender(keys.PublicKey, keys.PrivateKey, "mailto:ops@example.com", server.Client())
+	if err != nil {
+		t.Fatalf("sender: %v", err)
+	}
+	userKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	sub := Subscription{Endpoint: server.URL + "/push/abc", P256dh: encode(userKey.PublicKey().Bytes()), Auth: encode([]byte("0123456789abcdef"))}
+
+	if err := sender.Send(context.Background(), sub, []byte("hi")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !strings.HasPrefix(authHeader, "vapid t=") || !strings.HasSuffix(authHeader, "k="+keys.PublicKey) {
+		t.Fatalf("unexpected authorization header %q", authHeader)
+	}
+	if encoding != "aes128gcm" {
+		t.Fatalf("unexpected content encoding %q", encoding)
+	}
+
+	status = http.StatusGone
+	if err := sender.Send(context.Background(), sub, []byte("hi")); !errors.Is(err, ErrSubscriptionGone) {
+		t.Fatalf("expected gone subscription, got %v", err)
+	}
+}
+
+func TestValidateSubscriptionKeys(t *testing.T) {
+	userKey, _ := ecdh.P256().GenerateKey(rand.Reader)
+	if err := ValidateSubscriptionKeys(encode(userKey.PublicKey().Bytes()), encode([]byte("0123456789abcdef"))); err != nil {
+		t.Fatalf("expected valid keys: %v", err)
+	}
+	if err := ValidateSubscriptionKeys("not-a-key", encode([]byte("0123456789abcdef"))); err == nil {
+		t.Fatalf("expected invalid p256dh to fail")
+	}
+	if err := ValidateSubscriptionKeys(encode(userKey.PublicKey().Bytes()), encode([]byte("short"))); err == nil {
+		t.Fatalf("expected short auth secret to fail")
+	}
+}
+
+func decryptForTest(t *testing.T, userKey *ecdh.PrivateKey, authSecret, body []byte) string {
+	t.Helper()
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Fatalf("unexpected record size %d", rs)
+	}
+	keyLen := int(body[20])
+	serverPublic := body[21 : 21+keyLen]
+	serverKey, err := ecdh.P256().NewPublicKey(serverPublic)
+	if err != nil {
+		t.Fatalf("server public: %v", err)
+	}
+	shared, err := userKey.ECDH(serverKey)
+	if err != nil {
+		t.Fatalf("ecdh: %v", err)
+	}
+	cek, nonce, err := deriveContentKeys(shared, authSecret, salt, userKey.PublicKey().Bytes(), serverPublic)
+	if err != nil {
+		t.Fatalf("derive: %v", err)
+	}
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+keyLen:], nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatalf("expected last-record delimiter")
+	}
+	return string(plaintext[:len(plaintext)-1])
+}
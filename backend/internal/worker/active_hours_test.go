@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/auth"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+)
+
+// TestDeferOutsideActiveHoursSkipsBattleTurns queues replies for a persona
+// outside its active hours. A reply to a regular post waits for the window;
+// a battle turn runs right away.
+func TestDeferOutsideActiveHoursSkipsBattleTurns(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	cfg := config.Load()
+	cfg.DatabaseURL = databaseURL
+	cfg.MigrationsDir = migrationDirForTests(t)
+	if err := db.RunMigrations(ctx, pool, cfg.MigrationsDir); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	unique := time.Now().UnixNano()
+	hash, err := auth.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("password hash failed: %v", err)
+	}
+	var userID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO users(email, password_hash)
+		VALUES ($1, $2)
+		RETURNING id::text
+	`, fmt.Sprintf("active-hours-%d@example.com", unique), hash).Scan(&userID); err != nil {
+		t.Fatalf("insert user failed: %v", err)
+	}
+	var roomID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO rooms(slug, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id::text
+	`, fmt.Sprintf("active-hours-room-%d", unique), "active-hours-room", "Active hours worker room").Scan(&roomID); err != nil {
+		t.Fatalf("insert room failed: %v", err)
+	}
+
+	// A one-hour window starting two hours from now is closed right now.
+	hour := time.Now().UTC().Hour()
+	var personaID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO personas(user_id, name, bio, tone, daily_draft_quota, daily_reply_quota, active_hours_start, active_hours_end, timezone)
+		VALUES ($1, $2, $3, $4, 5, 25, $5, $6, 'UTC')
+		RETURNING id::text
+	`, userID, "Night Owl", "Only replies late.", "direct", (hour+2)%24, (hour+3)%24).Scan(&personaID); err != nil {
+		t.Fatalf("insert persona failed: %v", err)
+	}
+	var templateID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO templates(owner_user_id, name, prompt_rules, turn_count, word_limit, is_public)
+		VALUES ($1, $2, $3, $4, $5, FALSE)
+		RETURNING id::text
+	`, userID, "Active Hours Template", "Alternate concise arguments.", 4, 120).Scan(&templateID); err != nil {
+		t.Fatalf("insert template failed: %v", err)
+	}
+
+	insertPost := func(templateID any) string {
+		t.Helper()
+		var postID string
+		if err := pool.QueryRow(ctx, `
+			INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id)
+			VALUES ($1, $2, $3, 'AI_DRAFT_APPROVED', 'PUBLISHED', $4, NOW(), $5)
+			RETURNING id::text
+		`, roomID, personaID, userID, "Topic: Are night owls more creative?", templateID).Scan(&postID); err != nil {
+			t.Fatalf("insert post failed: %v", err)
+		}
+		return postID
+	}
+	deferReply := func(postID string) time.Time {
+		t.Helper()
+		var jobID int64
+		if err := pool.QueryRow(ctx, `
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ('generate_reply', $1, $2, '{}'::jsonb, 'PENDING', NOW())
+			RETURNING id
+		`, postID, personaID).Scan(&jobID); err != nil {
+			t.Fatalf("insert job failed: %v", err)
+		}
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatalf("begin failed: %v", err)
+		}
+		defer tx.Rollback(ctx)
+		deferred, err := New(cfg, pool, ai.NewMockClient()).deferOutsideActiveHours(ctx, tx, jobID, personaID, postID)
+		if err != nil {
+			t.Fatalf("defer failed: %v", err)
+		}
+		return deferred
+	}
+
+	if deferred := deferReply(insertPost(nil)); deferred.IsZero() {
+		t.Fatalf("expected a reply outside active hours to be deferred")
+	}
+	if deferred := deferReply(insertPost(templateID)); !deferred.IsZero() {
+		t.Fatalf("expected a battle turn to run outside active hours, deferred to %s", deferred)
+	}
+}
@@ -0,0 +1,357 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	backpressureWindow       = 20
+	backpressureMinSamples   = 5
+	backpressureMaxLevel     = 5
+	backpressureRecoverAfter = 5
+)
+
+type llmOutcome string
+
+const (
+	llmOutcomeOK          llmOutcome = "ok"
+	llmOutcomeError       llmOutcome = "error"
+	llmOutcomeUnavailable llmOutcome = "unavailable"
+)
+
+type operationHealth struct {
+	outcomes    []bool
+	level       int
+	pausedUntil time.Time
+	successRun  int
+}
+
+// llmBackpressure tracks provider health per LLM operation. When the share of
+// 429/5xx/timeout outcomes in the recent window crosses the threshold, the
+// operation is paused for an exponentially growing delay, its poll interval
+// widens and its job concurrency is halved per level; consecutive successes
+// walk the level back. Other operations and non-LLM work are not slowed.
+type llmBackpressure struct {
+	mu          sync.Mutex
+	ops         map[string]*operationHealth
+	threshold   float64
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	concurrency int
+	metrics     *observability.WorkerMetrics
+	logger      *observability.Logger
+	now         func() time.Time
+}
+
+func newLLMBackpressure(errorPct int, baseDelay, maxDelay time.Duration, concurrency int, metrics *observability.WorkerMetrics, logger *observability.Logger) *llmBackpressure {
+	if errorPct <= 0 || errorPct > 100 {
+		errorPct = 50
+	}
+	if baseDelay <= 0 {
+		baseDelay = 3 * time.Second
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &llmBackpressure{
+		ops:         map[string]*operationHealth{},
+		threshold:   float64(errorPct) / 100,
+		baseDelay:   baseDelay,
+		maxDelay:    maxDelay,
+		concurrency: concurrency,
+		metrics:     metrics,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+func (b *llmBackpressure) record(operation string, err error) {
+	outcome := llmOutcomeOK
+	switch {
+	case ai.IsProviderUnavailable(err):
+		outcome = llmOutcomeUnavailable
+	case err != nil:
+		outcome = llmOutcomeError
+	}
+	b.metrics.IncLLMCall(operation, string(outcome))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	health := b.healthLocked(operation)
+	unavailable := outcome == llmOutcomeUnavailable
+	health.outcomes = append(health.outcomes, unavailable)
+	if len(health.outcomes) > backpressureWindow {
+		health.outcomes = health.outcomes[len(health.outcomes)-backpressureWindow:]
+	}
+
+	now := b.now()
+	rate := errorRate(health.outcomes)
+	switch {
+	case unavailable:
+		health.successRun = 0
+		if len(health.outcomes) >= backpressureMinSamples && rate >= b.threshold {
+			if health.level < backpressureMaxLevel {
+				health.level++
+			}
+			health.pausedUntil = now.Add(b.delayForLevel(health.level))
+			health.outcomes = health.outcomes[:0]
+			b.logger.Warn("llm_backpressure_engaged", observability.Fields{
+				"operation":    operation,
+				"level":        health.level,
+				"error_rate":   rate,
+				"paused_until": health.pausedUntil.UTC().Format(time.RFC3339),
+			})
+		}
+	default:
+		health.successRun++
+		if health.level > 0 && health.successRun >= backpressureRecoverAfter {
+			health.level--
+			health.successRun = 0
+			b.logger.Info("llm_backpressure_relaxed", observability.Fields{
+				"operation": operation,
+				"level":     health.level,
+			})
+		}
+	}
+	b.publishLocked(operation, health)
+}
+
+func (b *llmBackpressure) allow(operation string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health, ok := b.ops[operation]
+	if !ok {
+		return true
+	}
+	return !b.now().Before(health.pausedUntil)
+}
+
+func (b *llmBackpressure) jobConcurrency(operation string) int {
+	return b.concurrencyFor(operation, b.concurrency)
+}
+
+// concurrencyFor halves base once per backpressure level of operation, for
+// tasks that size their own concurrency.
+func (b *llmBackpressure) concurrencyFor(operation string, base int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	level := 0
+	if health, ok := b.ops[operation]; ok {
+		level = health.level
+	}
+	concurrency := base >> level
+	if concurrency < 1 {
+		return 1
+	}
+	return concurrency
+}
+
+// pollInterval is how often tasks of operation run: the worker's poll interval,
+// doubled per backpressure level.
+func (b *llmBackpressure) pollInterval(operation string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	level := 0
+	if health, ok := b.ops[operation]; ok {
+		level = health.level
+	}
+	return b.delayForLevel(level)
+}
+
+func (b *llmBackpressure) delayForLevel(level int) time.Duration {
+	delay := b.baseDelay
+	for i := 0; i < level; i++ {
+		delay *= 2
+		if delay >= b.maxDelay {
+			return b.maxDelay
+		}
+	}
+	return delay
+}
+
+func (b *llmBackpressure) healthLocked(operation string) *operationHealth {
+	health, ok := b.ops[operation]
+	if !ok {
+		health = &operationHealth{outcomes: make([]bool, 0, backpressureWindow)}
+		b.ops[operation] = health
+	}
+	return health
+}
+
+func (b *llmBackpressure) publishLocked(operation string, health *operationHealth) {
+	concurrency := b.concurrency >> health.level
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	b.metrics.SetLLMHealth(operation, errorRate(health.outcomes), health.level, concurrency)
+	b.metrics.SetPollInterval(operation, b.delayForLevel(health.level))
+}
+
+// llmJobTypes are the job types that call the LLM; every other job type is
+// claimed regardless of backpressure.
+var llmJobTypes = []string{"generate_reply", common.JobInteractiveBattleTurn, common.JobGenerateDraft}
+
+// jobOperation is the LLM operation a job type spends its calls on, or "" for
+// job types that do not call the LLM.
+func jobOperation(jobType string) string {
+	switch jobType {
+	case "generate_reply", common.JobInteractiveBattleTurn:
+		return prompts.OpReply
+	case common.JobGenerateDraft:
+		return prompts.OpPostDraft
+	default:
+		return ""
+	}
+}
+
+// jobGate applies backpressure to job claims per operation: job types whose
+// operation is paused, or already runs at its backpressure concurrency, are
+// left in the queue while the rest are claimed as usual.
+type jobGate struct {
+	mu           sync.Mutex
+	backpressure *llmBackpressure
+	running      map[string]int
+}
+
+func newJobGate(backpressure *llmBackpressure) *jobGate {
+	return &jobGate{backpressure: backpressure, running: map[string]int{}}
+}
+
+// claim calls claimFn with the job types that must not be claimed now and
+// counts the claimed job against its operation until release is called.
+// Claims are serialized so concurrent slots cannot overshoot a limit.
+func (g *jobGate) claim(claimFn func(blocked []string) (string, error)) (release func(), err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	jobType, err := claimFn(g.blockedLocked())
+	if err != nil {
+		return func() {}, err
+	}
+	operation := jobOperation(jobType)
+	if operation == "" {
+		return func() {}, nil
+	}
+	g.running[operation]++
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.running[operation]--
+	}, nil
+}
+
+func (g *jobGate) blockedLocked() []string {
+	blocked := make([]string, 0, len(llmJobTypes))
+	for _, jobType := range llmJobTypes {
+		operation := jobOperation(jobType)
+		if !g.backpressure.allow(operation) || g.running[operation] >= g.backpressure.jobConcurrency(operation) {
+			blocked = append(blocked, jobType)
+		}
+	}
+	return blocked
+}
+
+func errorRate(outcomes []bool) float64 {
+	if len(outcomes) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, failed := range outcomes {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(outcomes))
+}
+
+type healthTrackingClient struct {
+	next         ai.LLMClient
+	backpressure *llmBackpressure
+}
+
+func (c *healthTrackingClient) GeneratePostDraft(ctx context.Context, persona ai.PersonaContext, room ai.RoomContext) (string, error) {
+	out, err := c.next.GeneratePostDraft(ctx, persona, room)
+	c.backpressure.record(prompts.OpPostDraft, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) GenerateReply(ctx context.Context, persona ai.PersonaContext, post ai.PostContext, thread []ai.ReplyContext) (string, error) {
+	out, err := c.next.GenerateReply(ctx, persona, post, thread)
+	c.backpressure.record(prompts.OpReply, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) SummarizeThread(ctx context.Context, post ai.PostContext, replies []ai.ReplyContext) (string, error) {
+	out, err := c.next.SummarizeThread(ctx, post, replies)
+	c.backpressure.record(prompts.OpThreadSummary, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) SummarizePersonaActivity(ctx context.Context, persona ai.PersonaContext, stats ai.DigestStats, threads []ai.DigestThreadContext) (string, error) {
+	out, err := c.next.SummarizePersonaActivity(ctx, persona, stats, threads)
+	c.backpressure.record(prompts.OpPersonaActivitySummary, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) CoachBattlePersona(ctx context.Context, persona ai.PersonaContext, topic string, turns []ai.BattleTurnContext) (string, error) {
+	out, err := c.next.CoachBattlePersona(ctx, persona, topic, turns)
+	c.backpressure.record(prompts.OpBattleCoaching, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) SummarizeBattleHighlights(ctx context.Context, topic string, turns []ai.BattleTurnContext) (string, error) {
+	out, err := c.next.SummarizeBattleHighlights(ctx, topic, turns)
+	c.backpressure.record(prompts.OpBattleHighlights, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) SummarizeContextPack(ctx context.Context, topic string, sources []ai.ContextSourceContext) (string, error) {
+	out, err := c.next.SummarizeContextPack(ctx, topic, sources)
+	c.backpressure.record(prompts.OpBattleContextPack, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) TranslatePost(ctx context.Context, post ai.PostContext, sourceLanguage, targetLanguage string) (string, error) {
+	out, err := c.next.TranslatePost(ctx, post, sourceLanguage, targetLanguage)
+	c.backpressure.record(prompts.OpPostTranslation, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) AnswerQuestion(ctx context.Context, persona ai.PersonaContext, question string) (string, error) {
+	out, err := c.next.AnswerQuestion(ctx, persona, question)
+	c.backpressure.record(prompts.OpPersonaAnswer, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) ProposeBattleTopic(ctx context.Context, post ai.PostContext, room ai.RoomContext) (string, error) {
+	out, err := c.next.ProposeBattleTopic(ctx, post, room)
+	c.backpressure.record(prompts.OpBattleProposition, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) CheckRoomFit(ctx context.Context, post ai.PostContext, room ai.RoomContext, candidates []ai.RoomContext) (string, error) {
+	out, err := c.next.CheckRoomFit(ctx, post, room, candidates)
+	c.backpressure.record(prompts.OpRoomFit, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) ClassifyPersonaStyle(ctx context.Context, persona ai.PersonaContext) (string, error) {
+	out, err := c.next.ClassifyPersonaStyle(ctx, persona)
+	c.backpressure.record(prompts.OpPersonaStyle, err)
+	return out, err
+}
+
+func (c *healthTrackingClient) Prompts() *prompts.Registry {
+	return c.next.Prompts()
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+func TestLLMBackpressureEngagesAndRecovers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newLLMBackpressure(50, 3*time.Second, 20*time.Second, 4, observability.NewWorkerMetrics(), observability.NewLogger("test"))
+	b.now = func() time.Time { return now }
+
+	overloaded := &ai.ProviderError{Provider: "openai", StatusCode: 429, Body: "slow down"}
+	for i := 0; i < backpressureMinSamples; i++ {
+		b.record(prompts.OpReply, overloaded)
+	}
+
+	if b.allow(prompts.OpReply) {
+		t.Fatalf("expected reply operation to be paused")
+	}
+	if !b.allow(prompts.OpThreadSummary) {
+		t.Fatalf("expected other operations to stay unthrottled")
+	}
+	if got := b.jobConcurrency(prompts.OpReply); got != 2 {
+		t.Fatalf("expected concurrency to halve to 2, got %d", got)
+	}
+	if got := b.concurrencyFor(prompts.OpReply, 8); got != 4 {
+		t.Fatalf("expected task concurrency to halve to 4, got %d", got)
+	}
+	if got := b.concurrencyFor(prompts.OpReply, 1); got != 1 {
+		t.Fatalf("expected task concurrency to stay at least 1, got %d", got)
+	}
+	if got := b.pollInterval(prompts.OpReply); got != 6*time.Second {
+		t.Fatalf("expected poll interval 6s, got %s", got)
+	}
+	if got := b.pollInterval(prompts.OpThreadSummary); got != 3*time.Second {
+		t.Fatalf("expected other operations to keep the 3s poll interval, got %s", got)
+	}
+
+	now = now.Add(7 * time.Second)
+	if !b.allow(prompts.OpReply) {
+		t.Fatalf("expected reply operation to resume after pause")
+	}
+
+	for i := 0; i < backpressureRecoverAfter; i++ {
+		b.record(prompts.OpReply, nil)
+	}
+	if got := b.jobConcurrency(prompts.OpReply); got != 4 {
+		t.Fatalf("expected concurrency to recover to 4, got %d", got)
+	}
+	if got := b.pollInterval(prompts.OpReply); got != 3*time.Second {
+		t.Fatalf("expected poll interval back at 3s, got %s", got)
+	}
+}
+
+func TestLLMBackpressureIgnoresRequestErrors(t *testing.T) {
+	b := newLLMBackpressure(50, time.Second, time.Minute, 4, nil, observability.NewLogger("test"))
+
+	badRequest := &ai.ProviderError{Provider: "openai", StatusCode: 400, Body: "invalid"}
+	for i := 0; i < backpressureWindow; i++ {
+		b.record(prompts.OpReply, badRequest)
+		b.record(prompts.OpReply, errors.New("empty completion"))
+	}
+
+	if !b.allow(prompts.OpReply) {
+		t.Fatalf("expected non-overload errors not to pause the operation")
+	}
+	if got := b.jobConcurrency(prompts.OpReply); got != 4 {
+		t.Fatalf("expected full concurrency, got %d", got)
+	}
+}
+
+func TestJobGateHoldsBackOnlyThrottledJobTypes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newLLMBackpressure(50, 3*time.Second, 20*time.Second, 2, observability.NewWorkerMetrics(), observability.NewLogger("test"))
+	b.now = func() time.Time { return now }
+	gate := newJobGate(b)
+
+	claim := func(jobType string) ([]string, func()) {
+		t.Helper()
+		var blocked []string
+		release, err := gate.claim(func(skip []string) (string, error) {
+			blocked = skip
+			return jobType, nil
+		})
+		if err != nil {
+			t.Fatalf("claim %s: %v", jobType, err)
+		}
+		return blocked, release
+	}
+
+	if blocked, _ := claim(common.JobCrosspost); len(blocked) != 0 {
+		t.Fatalf("expected nothing held back while healthy, got %v", blocked)
+	}
+
+	overloaded := &ai.ProviderError{Provider: "openai", StatusCode: 503, Body: "unavailable"}
+	for i := 0; i < backpressureMinSamples; i++ {
+		b.record(prompts.OpReply, overloaded)
+	}
+	blocked, _ := claim(common.JobGenerateDraft)
+	if !slices.Equal(blocked, []string{"generate_reply", common.JobInteractiveBattleTurn}) {
+		t.Fatalf("expected only reply job types held back while replies are paused, got %v", blocked)
+	}
+
+	// Drafts run at full concurrency (2): the one claimed above is still
+	// running, so a second fills the operation and a third claim skips drafts.
+	_, release := claim(common.JobGenerateDraft)
+	if blocked, _ := claim(common.JobCreateBattle); !slices.Contains(blocked, common.JobGenerateDraft) {
+		t.Fatalf("expected drafts held back at their concurrency, got %v", blocked)
+	}
+	release()
+	if blocked, _ := claim(common.JobCreateBattle); slices.Contains(blocked, common.JobGenerateDraft) {
+		t.Fatalf("expected drafts claimable after a release, got %v", blocked)
+	}
+}
@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// archiveOneBattle moves the turns of the oldest completed battle past the
+// archive age into battle_archives. A battle counts as completed when nothing
+// can still add a turn to it: no queued reply jobs, no active interactive
+// battle and no open challenge. The post row stays where it is, so listings
+// and feeds never notice; the API reads archived turns back on demand.
+func (w *Worker) archiveOneBattle(ctx context.Context) error {
+	months := w.cfg.BattleArchiveMonths
+	if months <= 0 {
+		return nil
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var postID string
+	err = tx.QueryRow(ctx, `
+		SELECT p.id::text
+		FROM posts p
+		WHERE p.status = 'PUBLISHED'
+		  AND p.created_at < NOW() - make_interval(months => $1)
+		  AND NOT EXISTS (SELECT 1 FROM battle_archives a WHERE a.post_id = p.id)
+		  AND EXISTS (SELECT 1 FROM replies r WHERE r.post_id = p.id)
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM replies r
+				WHERE r.post_id = p.id
+				  AND r.created_at >= NOW() - make_interval(months => $1)
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM jobs j
+				WHERE j.post_id = p.id
+				  AND j.status IN ('PENDING', 'PROCESSING')
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM interactive_battles ib
+				WHERE ib.post_id = p.id
+				  AND ib.status = $2
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM battle_challenges bc
+				WHERE bc.battle_post_id = p.id
+				  AND bc.status = 'PENDING'
+		  )
+		ORDER BY p.created_at ASC
+		LIMIT 1
+		FOR UPDATE OF p SKIP LOCKED
+	`, months, common.InteractiveStatusActive).Scan(&postID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	// persona_name is kept alongside persona_id so an archived turn still reads
+	// correctly after the persona is renamed, deleted or restored elsewhere.
+	var turnCount int
+	err = tx.QueryRow(ctx, `
+		INSERT INTO battle_archives(post_id, turn_count, last_turn_at, turns)
+		SELECT
+			r.post_id,
+			COUNT(*)::int,
+			MAX(r.created_at),
+			jsonb_agg(jsonb_build_object(
+				'id', r.id,
+				'persona_id', r.persona_id,
+				'persona_name', pr.name,
+				'user_id', r.user_id,
+				'authored_by', r.authored_by,
+				'content', r.content,
+				'prompt_version', r.prompt_version,
+				'metadata', r.metadata,
+				'created_at', r.created_at,
+				'updated_at', r.updated_at
+			) ORDER BY r.created_at ASC, r.id ASC)
+		FROM replies r
+		LEFT JOIN personas pr ON pr.id = r.persona_id
+		WHERE r.post_id = $1
+		GROUP BY r.post_id
+		RETURNING turn_count
+	`, postID).Scan(&turnCount)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM replies WHERE post_id = $1`, postID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.logger.Info("battle_archived", observability.Fields{
+		"post_id":    postID,
+		"turn_count": turnCount,
+	})
+	return nil
+}
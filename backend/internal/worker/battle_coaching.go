@@ -0,0 +1,241 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type coachingPersona struct {
+	ID                string
+	Name              string
+	Bio               string
+	Tone              string
+	PreferredLanguage string
+}
+
+func (w *Worker) generateCoachingForOneBattle(ctx context.Context) error {
+	var battle struct {
+		ID          string
+		OwnerUserID string
+		Content     string
+		RoomName    string
+	}
+	err := w.db.QueryRow(ctx, `
+		SELECT p.id::text, p.user_id::text, p.content, COALESCE(rm.name, '')
+		FROM posts p
+		LEFT JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.template_id IS NOT NULL
+		  AND p.status = 'PUBLISHED'
+		  AND p.created_at >= NOW() - INTERVAL '7 days'
+		  AND EXISTS (
+				SELECT 1
+				FROM replies r
+				WHERE r.post_id = p.id
+				  AND r.persona_id IS NOT NULL
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM jobs j
+				WHERE j.post_id = p.id
+				  AND (
+						j.status IN ('PENDING', 'PROCESSING')
+						OR (j.status = 'FAILED' AND j.attempts < $1 AND j.available_at > NOW())
+				  )
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM battle_coaching c
+				WHERE c.post_id = p.id
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM interactive_battles ib
+				WHERE ib.post_id = p.id
+				  AND ib.status = 'ACTIVE'
+		  )
+		ORDER BY p.created_at ASC
+		LIMIT 1
+	`, maxJobAttempts(w.cfg.JobMaxAttempts)).Scan(&battle.ID, &battle.OwnerUserID, &battle.Content, &battle.RoomName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	turns, personas, err := w.loadBattleTurns(ctx, battle.ID)
+	if err != nil {
+		return err
+	}
+	if len(personas) == 0 {
+		return nil
+	}
+
+	topic := extractWeeklyDigestTopic(battle.Content, battle.RoomName)
+	type coachingRow struct {
+		personaID     string
+		feedback      string
+		turnsJSON     []byte
+		promptVersion string
+	}
+	// Feedback is generated before the transaction opens so it is not held
+	// across LLM calls; all personas' rows then land together or not at all.
+	coaching := make([]coachingRow, 0, len(personas))
+	for _, persona := range personas {
+		personaCtx := ai.PersonaContext{
+			ID:                persona.ID,
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			PreferredLanguage: persona.PreferredLanguage,
+		}
+
+		promptVersion := w.llm.Prompts().Active(prompts.OpBattleCoaching)
+		feedback, aiErr := w.llm.CoachBattlePersona(ctx, personaCtx, topic, turns)
+		feedback = strings.TrimSpace(feedback)
+		if aiErr != nil || feedback == "" {
+			feedback = fallbackBattleCoaching(persona.Name, persona.ID, turns)
+			promptVersion = ""
+		}
+		feedback = common.TruncateRunes(feedback, w.cfg.SummaryMaxLen)
+
+		ownTurns := make([]int, 0)
+		for _, turn := range turns {
+			if turn.PersonaID == persona.ID {
+				ownTurns = append(ownTurns, turn.Turn)
+			}
+		}
+		turnsJSON, err := json.Marshal(ownTurns)
+		if err != nil {
+			return err
+		}
+
+		coaching = append(coaching, coachingRow{
+			personaID:     persona.ID,
+			feedback:      feedback,
+			turnsJSON:     turnsJSON,
+			promptVersion: promptVersion,
+		})
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, row := range coaching {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO battle_coaching(post_id, persona_id, feedback, turns, prompt_version)
+			VALUES ($1, $2, $3, $4::jsonb, NULLIF($5, ''))
+			ON CONFLICT (post_id, persona_id)
+			DO UPDATE SET
+				feedback = EXCLUDED.feedback,
+				turns = EXCLUDED.turns,
+				prompt_version = EXCLUDED.prompt_version,
+				created_at = NOW()
+		`, battle.ID, row.personaID, row.feedback, row.turnsJSON, row.promptVersion); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if err := w.notifyBattleCompleted(ctx, battle.OwnerUserID, battle.ID, topic); err != nil {
+		return err
+	}
+
+	// In a challenge battle the other side belongs to a different account.
+	participants := []string{battle.OwnerUserID}
+	var opponentUserID string
+	err = w.db.QueryRow(ctx, `
+		SELECT opponent_user_id::text
+		FROM battle_challenges
+		WHERE battle_post_id = $1
+	`, battle.ID).Scan(&opponentUserID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		if err := w.notifyBattleCompleted(ctx, opponentUserID, battle.ID, topic); err != nil {
+			return err
+		}
+		participants = append(participants, opponentUserID)
+	}
+
+	w.notifyBattleWatchersOfVerdict(ctx, battle.ID, topic, participants)
+	return nil
+}
+
+func (w *Worker) loadBattleTurns(ctx context.Context, postID string) ([]ai.BattleTurnContext, []coachingPersona, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT
+			COALESCE(p.id::text, ''),
+			COALESCE(p.name, $2),
+			COALESCE(p.bio, ''),
+			COALESCE(p.tone, ''),
+			COALESCE(p.preferred_language, ''),
+			r.content
+		FROM replies r
+		LEFT JOIN personas p ON p.id = r.persona_id
+		WHERE r.post_id = $1
+		  AND (r.persona_id IS NOT NULL OR r.authored_by = 'HUMAN')
+		ORDER BY r.created_at ASC, r.id ASC
+	`, postID, common.InteractiveHumanName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	turns := make([]ai.BattleTurnContext, 0)
+	personas := make([]coachingPersona, 0)
+	seen := map[string]struct{}{}
+	for rows.Next() {
+		var persona coachingPersona
+		var content string
+		if err := rows.Scan(&persona.ID, &persona.Name, &persona.Bio, &persona.Tone, &persona.PreferredLanguage, &content); err != nil {
+			return nil, nil, err
+		}
+		turns = append(turns, ai.BattleTurnContext{
+			Turn:        len(turns) + 1,
+			PersonaID:   persona.ID,
+			PersonaName: persona.Name,
+			Content:     content,
+		})
+		// Owner-written turns in interactive battles are part of the
+		// transcript but get no coaching of their own.
+		if persona.ID == "" {
+			continue
+		}
+		if _, exists := seen[persona.ID]; !exists {
+			seen[persona.ID] = struct{}{}
+			personas = append(personas, persona)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return turns, personas, nil
+}
+
+func fallbackBattleCoaching(personaName, personaID string, turns []ai.BattleTurnContext) string {
+	ownTurns := make([]string, 0)
+	for _, turn := range turns {
+		if turn.PersonaID == personaID {
+			ownTurns = append(ownTurns, fmt.Sprintf("%d", turn.Turn))
+		}
+	}
+	if len(ownTurns) == 0 {
+		return fmt.Sprintf("%s did not take a turn in this battle.", personaName)
+	}
+	return fmt.Sprintf("%s spoke in turns %s. Review those turns and back each claim with one concrete example.", personaName, strings.Join(ownTurns, ", "))
+}
@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+)
+
+func TestBattleCoachingCitesPersonaTurns(t *testing.T) {
+	turns := []ai.BattleTurnContext{
+		{Turn: 1, PersonaID: "a", PersonaName: "Pro", Content: "Ship weekly and measure retention with a cohort chart."},
+		{Turn: 2, PersonaID: "b", PersonaName: "Con", Content: "Weekly is too fast."},
+		{Turn: 3, PersonaID: "a", PersonaName: "Pro", Content: "Trust me."},
+	}
+
+	feedback, err := ai.NewMockClient().CoachBattlePersona(context.Background(), ai.PersonaContext{ID: "a", Name: "Pro"}, "Ship weekly?", turns)
+	if err != nil {
+		t.Fatalf("coach battle failed: %v", err)
+	}
+	if !strings.Contains(feedback, "turn 1") || !strings.Contains(feedback, "turn 3") {
+		t.Fatalf("expected feedback to cite turns 1 and 3, got %q", feedback)
+	}
+
+	fallback := fallbackBattleCoaching("Pro", "a", turns)
+	if !strings.Contains(fallback, "turns 1, 3") {
+		t.Fatalf("expected fallback to list own turns, got %q", fallback)
+	}
+}
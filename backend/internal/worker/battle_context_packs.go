@@ -0,0 +1,275 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Reply jobs of a battle wait for its pending context pack, polling
+	// every contextPackDeferStep, for at most contextPackMaxWait after the
+	// pack was requested; after that they go ahead without it.
+	contextPackDeferStep = 10 * time.Second
+	contextPackMaxWait   = 2 * time.Minute
+	// Candidates are the room's newest contextPackCandidateLimit posts from
+	// the last contextPackLookbackDays days.
+	contextPackCandidateLimit = 50
+	contextPackLookbackDays   = 30
+	contextPackFallbackRunes  = 160
+)
+
+type contextPackCandidate struct {
+	PostID      string
+	PersonaName string
+	Content     string
+	Reactions   int
+	Score       float64
+}
+
+// deferForContextPack pushes a battle reply job back while the battle's
+// context pack is still being summarized, so both sides get the same
+// background. It runs before the job is locked, like
+// deferOutsideActiveHours, so waiting costs no attempt.
+func (w *Worker) deferForContextPack(ctx context.Context, tx pgx.Tx, jobID int64, postID string) (time.Time, error) {
+	var requestedAt time.Time
+	err := tx.QueryRow(ctx, `
+		SELECT created_at
+		FROM battle_context_packs
+		WHERE post_id = $1
+		  AND status = $2
+	`, postID, common.BattleContextPackPending).Scan(&requestedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	deadline := requestedAt.Add(contextPackMaxWait)
+	if !now.Before(deadline) {
+		return time.Time{}, nil
+	}
+	next := now.Add(contextPackDeferStep)
+	if next.After(deadline) {
+		next = deadline
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE jobs
+		SET available_at = $2, updated_at = NOW()
+		WHERE id = $1
+	`, jobID, next); err != nil {
+		return time.Time{}, err
+	}
+	return next, nil
+}
+
+// generateOneBattleContextPack summarizes the oldest pending context pack
+// from the room's recent posts on the battle topic. A room with nothing on
+// the topic leaves the pack EMPTY; a summary that fails the safety rules is
+// replaced by a list of excerpts.
+func (w *Worker) generateOneBattleContextPack(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var pack struct {
+		PostID   string
+		RoomID   string
+		Topic    string
+		Language string
+	}
+	err = tx.QueryRow(ctx, `
+		SELECT c.post_id::text, c.room_id::text, c.topic, COALESCE(p.battle_language_code, '')
+		FROM battle_context_packs c
+		JOIN posts p ON p.id = c.post_id
+		WHERE c.status = $1
+		ORDER BY c.created_at ASC
+		LIMIT 1
+		FOR UPDATE OF c SKIP LOCKED
+	`, common.BattleContextPackPending).Scan(&pack.PostID, &pack.RoomID, &pack.Topic, &pack.Language)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	candidates, err := w.loadContextPackCandidates(ctx, tx, pack.RoomID, pack.PostID)
+	if err != nil {
+		return err
+	}
+	selected := selectContextPackSources(pack.Topic, candidates, common.BattleContextPackMaxSources)
+
+	status := common.BattleContextPackEmpty
+	summary := ""
+	promptVersion := ""
+	failure := ""
+	if len(selected) > 0 {
+		sources := make([]ai.ContextSourceContext, 0, len(selected))
+		for _, candidate := range selected {
+			sources = append(sources, ai.ContextSourceContext{
+				PostID:      candidate.PostID,
+				PersonaName: candidate.PersonaName,
+				Content:     common.TruncateRunes(candidate.Content, common.BattleContextPackExcerptRunes),
+			})
+		}
+		status = common.BattleContextPackReady
+		promptVersion = w.llm.Prompts().Active(prompts.OpBattleContextPack)
+		generated, aiErr := w.llm.SummarizeContextPack(ctx, pack.Topic, sources)
+		summary = common.TruncateRunes(strings.TrimSpace(generated), common.BattleContextPackMaxRunes)
+		if aiErr == nil && summary != "" {
+			aiErr = w.checkContextPackSummary(ctx, summary, pack.Language)
+		}
+		if aiErr != nil || summary == "" {
+			promptVersion = ""
+			summary = fallbackContextPack(pack.Topic, sources)
+			if err := w.checkContextPackSummary(ctx, summary, pack.Language); err != nil {
+				status = common.BattleContextPackFailed
+				summary = ""
+				failure = err.Error()
+			}
+		}
+	}
+
+	stored := make([]common.BattleContextPackSource, 0, len(selected))
+	for i, candidate := range selected {
+		stored = append(stored, common.BattleContextPackSource{
+			Number:      i + 1,
+			PostID:      candidate.PostID,
+			PersonaName: candidate.PersonaName,
+			Excerpt:     common.TruncateRunes(candidate.Content, common.BattleContextPackExcerptRunes),
+			Score:       math.Round(candidate.Score*1000) / 1000,
+		})
+	}
+	sourcesJSON, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE battle_context_packs
+		SET status = $2,
+			summary = $3,
+			sources = $4::jsonb,
+			prompt_version = NULLIF($5, ''),
+			error = $6,
+			updated_at = NOW()
+		WHERE post_id = $1
+	`, pack.PostID, status, summary, sourcesJSON, promptVersion, failure); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("battle_context_pack_generated", observability.Fields{
+		"battle_id": pack.PostID,
+		"status":    status,
+		"sources":   len(stored),
+	})
+	return nil
+}
+
+func (w *Worker) loadContextPackCandidates(ctx context.Context, tx pgx.Tx, roomID, battleID string) ([]contextPackCandidate, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT
+			p.id::text,
+			COALESCE(pe.name, 'A member'),
+			p.content,
+			(SELECT COUNT(*)::int FROM post_reactions r WHERE r.post_id = p.id)
+		FROM posts p
+		LEFT JOIN personas pe ON pe.id = p.persona_id
+		WHERE p.room_id = $1
+		  AND p.id <> $2
+		  AND p.status = 'PUBLISHED'
+		  AND p.template_id IS NULL
+		  AND p.visibility = 'public'
+		  AND p.published_at >= NOW() - make_interval(days => $3)
+		ORDER BY p.published_at DESC
+		LIMIT $4
+	`, roomID, battleID, contextPackLookbackDays, contextPackCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]contextPackCandidate, 0)
+	for rows.Next() {
+		var candidate contextPackCandidate
+		if err := rows.Scan(&candidate.PostID, &candidate.PersonaName, &candidate.Content, &candidate.Reactions); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// selectContextPackSources keeps the limit posts most on topic, scored by the
+// share of topic words they use. Posts sharing no topic word are dropped.
+// Reactions break ties; candidates come newest first and keep that order
+// after that.
+func selectContextPackSources(topic string, candidates []contextPackCandidate, limit int) []contextPackCandidate {
+	topicWords := replyWordSet(topic)
+	if len(topicWords) == 0 || limit <= 0 {
+		return nil
+	}
+	selected := make([]contextPackCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		overlap := 0
+		for word := range replyWordSet(candidate.Content) {
+			if _, ok := topicWords[word]; ok {
+				overlap++
+			}
+		}
+		if overlap == 0 {
+			continue
+		}
+		candidate.Score = float64(overlap) / float64(len(topicWords))
+		selected = append(selected, candidate)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].Score != selected[j].Score {
+			return selected[i].Score > selected[j].Score
+		}
+		return selected[i].Reactions > selected[j].Reactions
+	})
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
+// checkContextPackSummary runs the public-content safety rules over a pack
+// summary; it is shown to the owner and quoted to both personas.
+func (w *Worker) checkContextPackSummary(ctx context.Context, summary, language string) error {
+	rules, err := w.safetyRules.Load(ctx, w.cfg.SafetySyncEvery, w.db)
+	if err != nil {
+		w.logger.Warn("safety_rules_load_failed", observability.Fields{"error": err.Error()})
+	}
+	return rules.ForLanguage(language).Validate(summary, common.BattleContextPackMaxRunes)
+}
+
+// fallbackContextPack lists short excerpts of the sources when no usable
+// summary came back.
+func fallbackContextPack(topic string, sources []ai.ContextSourceContext) string {
+	parts := make([]string, 0, len(sources))
+	for i, source := range sources {
+		parts = append(parts, fmt.Sprintf("post %d (%s): %s", i+1, source.PersonaName, common.TruncateRunes(strings.TrimSpace(source.Content), contextPackFallbackRunes)))
+	}
+	return common.TruncateRunes(fmt.Sprintf("Room posts on \"%s\": %s", topic, strings.Join(parts, "; ")), common.BattleContextPackMaxRunes)
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+)
+
+func contextPackPostIDs(candidates []contextPackCandidate) []string {
+	ids := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.PostID)
+	}
+	return ids
+}
+
+func TestSelectContextPackSourcesRanksByTopicOverlap(t *testing.T) {
+	candidates := []contextPackCandidate{
+		{PostID: "lunch", Content: "Where should we get lunch on Friday?"},
+		{PostID: "weekly", Content: "Weekly releases kept our churn low."},
+		{PostID: "both", Content: "Weekly releases beat monthly releases for churn."},
+		{PostID: "popular", Content: "Monthly is calmer.", Reactions: 40},
+	}
+
+	selected := selectContextPackSources("Weekly releases beat monthly ones", candidates, 5)
+	if got := contextPackPostIDs(selected); !reflect.DeepEqual(got, []string{"both", "weekly", "popular"}) {
+		t.Fatalf("expected on-topic posts by overlap, reactions not outranking it, got %v", got)
+	}
+
+	selected = selectContextPackSources("Weekly releases beat monthly ones", candidates, 1)
+	if got := contextPackPostIDs(selected); !reflect.DeepEqual(got, []string{"both"}) {
+		t.Fatalf("expected the limit to apply, got %v", got)
+	}
+}
+
+func TestSelectContextPackSourcesBreaksTiesByReactionsThenRecency(t *testing.T) {
+	candidates := []contextPackCandidate{
+		{PostID: "newest", Content: "Remote teams ship."},
+		{PostID: "liked", Content: "Remote teams ship.", Reactions: 3},
+		{PostID: "oldest", Content: "Remote teams ship."},
+	}
+	selected := selectContextPackSources("Remote teams", candidates, 3)
+	if got := contextPackPostIDs(selected); !reflect.DeepEqual(got, []string{"liked", "newest", "oldest"}) {
+		t.Fatalf("unexpected order %v", got)
+	}
+}
+
+func TestSelectContextPackSourcesNeedsTopicWords(t *testing.T) {
+	candidates := []contextPackCandidate{{PostID: "a", Content: "Is it ok?"}}
+	if selected := selectContextPackSources("Is it ok?", candidates, 5); len(selected) != 0 {
+		t.Fatalf("expected no sources for a topic without usable words, got %v", contextPackPostIDs(selected))
+	}
+}
+
+func TestFallbackContextPackCitesPostsByNumber(t *testing.T) {
+	summary := fallbackContextPack("Ship weekly?", []ai.ContextSourceContext{
+		{PersonaName: "Ada", Content: "Weekly cut churn 12%."},
+		{PersonaName: "Lin", Content: "Monthly gives QA room."},
+	})
+	if !strings.Contains(summary, "post 1 (Ada): Weekly cut churn 12%.") || !strings.Contains(summary, "post 2 (Lin): Monthly gives QA room.") {
+		t.Fatalf("expected numbered excerpts, got %q", summary)
+	}
+}
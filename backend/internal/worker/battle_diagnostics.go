@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+// runBattleTurn measures the LLM calls behind one turn attempt. Successful
+// turns store their usage themselves, in the same transaction as the reply;
+// a failed attempt's calls are added to the battle totals here so the
+// diagnostics show what retries cost.
+func (w *Worker) runBattleTurn(ctx context.Context, postID string, attempt int, fn func(context.Context) error) error {
+	ctx, stats := ai.WithCallStats(ctx)
+	err := fn(ctx)
+
+	usage := battleTurnUsage(stats.Snapshot(), attempt)
+	if usage.LLMCalls == 0 {
+		return err
+	}
+	outcome := "done"
+	if err != nil {
+		outcome = "failed"
+	}
+	w.metrics.ObserveBattleTurn(outcome, usage.LLMCalls, usage.LLMRetries, time.Duration(usage.LLMLatencyMS)*time.Millisecond)
+	if err != nil {
+		if recordErr := common.AddBattleUsage(ctx, w.db, postID, usage, true); recordErr != nil {
+			w.logger.Warn("battle_usage_record_failed", observability.Fields{
+				"post_id": postID,
+				"error":   recordErr.Error(),
+			})
+		}
+	}
+	return err
+}
+
+// currentTurnUsage is the usage of the turn attempt ctx is measuring.
+func currentTurnUsage(ctx context.Context, attempt int) common.TurnUsage {
+	return battleTurnUsage(ai.CallStatsFromContext(ctx).Snapshot(), attempt)
+}
+
+func battleTurnUsage(stats ai.CallStatsSnapshot, attempt int) common.TurnUsage {
+	return common.TurnUsage{
+		LLMCalls:         stats.Calls,
+		LLMRetries:       stats.Retries,
+		PromptTokens:     stats.PromptTokens,
+		CompletionTokens: stats.CompletionTokens,
+		LLMLatencyMS:     stats.Latency.Milliseconds(),
+		JobAttempt:       attempt,
+	}
+}
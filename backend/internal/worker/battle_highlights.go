@@ -0,0 +1,302 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/quality"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// Battles with at least highlightLongBattleTurns turns get three
+	// highlights, shorter ones two.
+	highlightLongBattleTurns = 6
+	highlightFallbackRunes   = 80
+)
+
+var coachingTurnRefPattern = regexp.MustCompile(`(?i)\bturns?\s+(\d+(?:\s*(?:,|and|&)\s*\d+)*)`)
+
+type highlightCandidate struct {
+	ReplyID     string
+	Turn        int
+	PersonaID   string
+	PersonaName string
+	Content     string
+	Score       float64
+	VerdictRefs int
+	Words       int
+}
+
+// battleHighlight is one stored highlight; the API joins it back to the
+// reply so a removed reply drops out of the reel.
+type battleHighlight struct {
+	Turn        int     `json:"turn"`
+	ReplyID     string  `json:"reply_id"`
+	PersonaID   string  `json:"persona_id"`
+	Score       float64 `json:"score"`
+	VerdictRefs int     `json:"verdict_refs"`
+}
+
+// generateHighlightsForOneBattle builds the highlight reel of one finished
+// battle. A battle counts as finished once its coaching is written, which
+// also supplies the verdict references.
+func (w *Worker) generateHighlightsForOneBattle(ctx context.Context) error {
+	var battle struct {
+		ID       string
+		Content  string
+		RoomName string
+		Language string
+	}
+	err := w.db.QueryRow(ctx, `
+		SELECT p.id::text, p.content, COALESCE(rm.name, ''), COALESCE(p.battle_language_code, '')
+		FROM posts p
+		LEFT JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.template_id IS NOT NULL
+		  AND p.status = 'PUBLISHED'
+		  AND EXISTS (
+				SELECT 1
+				FROM battle_coaching c
+				WHERE c.post_id = p.id
+		  )
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM battle_highlights h
+				WHERE h.post_id = p.id
+		  )
+		ORDER BY p.created_at ASC
+		LIMIT 1
+	`).Scan(&battle.ID, &battle.Content, &battle.RoomName, &battle.Language)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	candidates, err := w.loadHighlightCandidates(ctx, battle.ID)
+	if err != nil {
+		return err
+	}
+	refs, err := w.loadCoachingTurnRefs(ctx, battle.ID)
+	if err != nil {
+		return err
+	}
+	for i := range candidates {
+		candidates[i].VerdictRefs = refs[candidates[i].Turn]
+	}
+	selected := selectHighlightTurns(candidates, battleTurnCount(candidates))
+
+	topic := extractWeeklyDigestTopic(battle.Content, battle.RoomName)
+	turns := make([]ai.BattleTurnContext, 0, len(selected))
+	for _, candidate := range selected {
+		turns = append(turns, ai.BattleTurnContext{
+			Turn:        candidate.Turn,
+			PersonaID:   candidate.PersonaID,
+			PersonaName: candidate.PersonaName,
+			Content:     candidate.Content,
+		})
+	}
+
+	promptVersion := ""
+	summary := ""
+	if len(turns) > 0 {
+		promptVersion = w.llm.Prompts().Active(prompts.OpBattleHighlights)
+		generated, aiErr := w.llm.SummarizeBattleHighlights(ctx, topic, turns)
+		summary = strings.TrimSpace(generated)
+		if aiErr == nil && summary != "" {
+			summary, aiErr = w.checkHighlightSummary(ctx, summary, battle.Language)
+		}
+		if aiErr != nil || summary == "" {
+			summary = fallbackBattleHighlights(turns)
+			promptVersion = ""
+		}
+	}
+	summary = common.TruncateRunes(summary, w.cfg.SummaryMaxLen)
+
+	highlights := make([]battleHighlight, 0, len(selected))
+	for _, candidate := range selected {
+		highlights = append(highlights, battleHighlight{
+			Turn:        candidate.Turn,
+			ReplyID:     candidate.ReplyID,
+			PersonaID:   candidate.PersonaID,
+			Score:       candidate.Score,
+			VerdictRefs: candidate.VerdictRefs,
+		})
+	}
+	highlightsJSON, err := json.Marshal(highlights)
+	if err != nil {
+		return err
+	}
+	_, err = w.db.Exec(ctx, `
+		INSERT INTO battle_highlights(post_id, highlights, summary, prompt_version)
+		VALUES ($1, $2::jsonb, $3, NULLIF($4, ''))
+		ON CONFLICT (post_id)
+		DO UPDATE SET
+			highlights = EXCLUDED.highlights,
+			summary = EXCLUDED.summary,
+			prompt_version = EXCLUDED.prompt_version,
+			created_at = NOW()
+	`, battle.ID, highlightsJSON, summary, promptVersion)
+	return err
+}
+
+// checkHighlightSummary runs the public-content safety rules over a
+// generated recap. Highlights have no owner to appeal, so a failing recap
+// falls back to the deterministic one instead of being recorded.
+func (w *Worker) checkHighlightSummary(ctx context.Context, summary, language string) (string, error) {
+	rules, err := w.safetyRules.Load(ctx, w.cfg.SafetySyncEvery, w.db)
+	if err != nil {
+		w.logger.Warn("safety_rules_load_failed", observability.Fields{"error": err.Error()})
+	}
+	if err := rules.ForLanguage(language).Validate(summary, w.cfg.SummaryMaxLen); err != nil {
+		return "", err
+	}
+	return summary, nil
+}
+
+func (w *Worker) loadHighlightCandidates(ctx context.Context, postID string) ([]highlightCandidate, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT
+			r.id::text,
+			COALESCE(p.id::text, ''),
+			COALESCE(p.name, $2),
+			COALESCE(p.tone, ''),
+			COALESCE(p.do_not_say, '[]'::jsonb),
+			COALESCE(p.formality, 1),
+			r.content
+		FROM replies r
+		LEFT JOIN personas p ON p.id = r.persona_id
+		WHERE r.post_id = $1
+		  AND (r.persona_id IS NOT NULL OR r.authored_by = 'HUMAN')
+		ORDER BY r.created_at ASC, r.id ASC
+	`, postID, common.InteractiveHumanName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]highlightCandidate, 0)
+	turn := 0
+	for rows.Next() {
+		var (
+			candidate   highlightCandidate
+			profile     quality.Profile
+			doNotSayRaw []byte
+		)
+		if err := rows.Scan(&candidate.ReplyID, &candidate.PersonaID, &candidate.PersonaName, &profile.Tone, &doNotSayRaw, &profile.Formality, &candidate.Content); err != nil {
+			return nil, err
+		}
+		// Turn numbers match loadBattleTurns, which counts owner-written
+		// turns too; only persona turns can be highlighted.
+		turn++
+		if candidate.PersonaID == "" {
+			continue
+		}
+		if profile.DoNotSay, err = w.calibration.Decode(ctx, w.db, common.DoNotSayField, doNotSayRaw); err != nil {
+			return nil, err
+		}
+		candidate.Turn = turn
+		candidate.Score = quality.Evaluate(profile, candidate.Content).Overall
+		candidate.Words = len(strings.Fields(candidate.Content))
+		candidates = append(candidates, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// loadCoachingTurnRefs counts how often the battle's coaching cites each
+// turn number.
+func (w *Worker) loadCoachingTurnRefs(ctx context.Context, postID string) (map[int]int, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT feedback
+		FROM battle_coaching
+		WHERE post_id = $1
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := map[int]int{}
+	for rows.Next() {
+		var feedback string
+		if err := rows.Scan(&feedback); err != nil {
+			return nil, err
+		}
+		for turn, count := range coachingTurnRefs(feedback) {
+			refs[turn] += count
+		}
+	}
+	return refs, rows.Err()
+}
+
+// coachingTurnRefs reads turn citations like "turn 3" or "turns 3 and 5"
+// out of coaching feedback.
+func coachingTurnRefs(feedback string) map[int]int {
+	refs := map[int]int{}
+	for _, match := range coachingTurnRefPattern.FindAllStringSubmatch(feedback, -1) {
+		for _, field := range strings.FieldsFunc(match[1], func(r rune) bool { return r < '0' || r > '9' }) {
+			if turn, err := strconv.Atoi(field); err == nil && turn > 0 {
+				refs[turn]++
+			}
+		}
+	}
+	return refs
+}
+
+func battleTurnCount(candidates []highlightCandidate) int {
+	if len(candidates) == 0 {
+		return 0
+	}
+	return candidates[len(candidates)-1].Turn
+}
+
+// selectHighlightTurns ranks persona turns by quality score plus verdict
+// references and keeps the best two or three in battle order. Ties go to the
+// longer turn, then the earlier one.
+func selectHighlightTurns(candidates []highlightCandidate, totalTurns int) []highlightCandidate {
+	limit := 2
+	if totalTurns >= highlightLongBattleTurns {
+		limit = 3
+	}
+	ranked := append([]highlightCandidate(nil), candidates...)
+	rank := func(c highlightCandidate) float64 {
+		return c.Score + common.HighlightVerdictRefWeight*float64(c.VerdictRefs)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ri, rj := rank(ranked[i]), rank(ranked[j]); ri != rj {
+			return ri > rj
+		}
+		if ranked[i].Words != ranked[j].Words {
+			return ranked[i].Words > ranked[j].Words
+		}
+		return ranked[i].Turn < ranked[j].Turn
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Turn < ranked[j].Turn })
+	return ranked
+}
+
+func fallbackBattleHighlights(turns []ai.BattleTurnContext) string {
+	parts := make([]string, 0, len(turns))
+	for _, turn := range turns {
+		parts = append(parts, fmt.Sprintf("%s (turn %d): %s", turn.PersonaName, turn.Turn, common.TruncateRunes(turn.Content, highlightFallbackRunes)))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+)
+
+func TestCoachingTurnRefsReadsCitedTurns(t *testing.T) {
+	refs := coachingTurnRefs("Strongest in turn 3. Evidence was vague in turns 3 and 5; compare Turns 1, 5 & 7.")
+	want := map[int]int{1: 1, 3: 2, 5: 2, 7: 1}
+	if !reflect.DeepEqual(refs, want) {
+		t.Fatalf("unexpected refs %v, want %v", refs, want)
+	}
+}
+
+func TestSelectHighlightTurnsRanksByScoreAndVerdictRefs(t *testing.T) {
+	candidates := []highlightCandidate{
+		{Turn: 1, Score: 0.9, Words: 10},
+		{Turn: 2, Score: 0.8, VerdictRefs: 2, Words: 10},
+		{Turn: 3, Score: 0.95, Words: 10},
+		{Turn: 4, Score: 0.7, Words: 30},
+	}
+
+	selected := selectHighlightTurns(candidates, 4)
+	if got := highlightTurns(selected); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Fatalf("expected turns 2 and 3 for a short battle, got %v", got)
+	}
+
+	selected = selectHighlightTurns(candidates, 6)
+	if got := highlightTurns(selected); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Fatalf("expected three turns in battle order for a long battle, got %v", got)
+	}
+}
+
+func TestSelectHighlightTurnsBreaksTiesByLength(t *testing.T) {
+	candidates := []highlightCandidate{
+		{Turn: 1, Score: 0.8, Words: 5},
+		{Turn: 2, Score: 0.8, Words: 25},
+		{Turn: 3, Score: 0.8, Words: 25},
+	}
+	if got := highlightTurns(selectHighlightTurns(candidates, 3)); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Fatalf("expected the longer turns, got %v", got)
+	}
+}
+
+func TestBattleHighlightSummaryCitesSelectedTurns(t *testing.T) {
+	turns := []ai.BattleTurnContext{
+		{Turn: 2, PersonaID: "a", PersonaName: "Pro", Content: "Ship weekly and measure retention with a cohort chart."},
+		{Turn: 5, PersonaID: "b", PersonaName: "Con", Content: "Weekly releases burn the team out before the data is in."},
+	}
+	summary, err := ai.NewMockClient().SummarizeBattleHighlights(context.Background(), "Ship weekly?", turns)
+	if err != nil {
+		t.Fatalf("summarize highlights: %v", err)
+	}
+	if !strings.Contains(summary, "Pro in turn 2") || !strings.Contains(summary, "Con in turn 5") {
+		t.Fatalf("expected summary to cite both turns, got %q", summary)
+	}
+
+	fallback := fallbackBattleHighlights(turns)
+	if !strings.Contains(fallback, "Pro (turn 2):") || !strings.Contains(fallback, "Con (turn 5):") {
+		t.Fatalf("expected fallback to name both turns, got %q", fallback)
+	}
+}
+
+func highlightTurns(candidates []highlightCandidate) []int {
+	turns := make([]int, 0, len(candidates))
+	for _, candidate := range candidates {
+		turns = append(turns, candidate.Turn)
+	}
+	return turns
+}
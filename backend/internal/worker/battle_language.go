@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+)
+
+// renderBilingualTurn translates a turn of a bilingual battle into the
+// battle's second language and returns the replies.metadata document that
+// keeps both renderings side by side. turn is the safety check the original
+// already passed; the translation goes through the same check in its own
+// language. Turns of other battles get an empty document.
+func (w *Worker) renderBilingualTurn(ctx context.Context, battle common.BattleLanguage, turnLanguage string, turn common.SafetyRejection) ([]byte, error) {
+	target := battle.TranslationLanguage(turnLanguage)
+	if target == "" {
+		return []byte("{}"), nil
+	}
+
+	translated, err := w.llm.TranslatePost(ctx, ai.PostContext{ID: turn.PostID, Content: turn.Content}, turnLanguage, target)
+	if err != nil {
+		return nil, err
+	}
+	translated = strings.TrimSpace(translated)
+
+	metadata := map[string]any{"translated_from": turnLanguage}
+	for key, value := range turn.Metadata {
+		metadata[key] = value
+	}
+	check := turn
+	check.Language = target
+	check.Content = translated
+	check.Metadata = metadata
+	if err := w.checkGeneratedContent(ctx, check); err != nil {
+		return nil, permanentError{message: err.Error()}
+	}
+
+	return json.Marshal(common.BilingualTurnMetadata(turnLanguage, turn.Content, target, translated))
+}
+
+// turnSafetyLanguage is the lexicon a turn is checked against: the language
+// the battle forced, or the persona's own.
+func turnSafetyLanguage(turnLanguage, personaLanguage string) string {
+	if turnLanguage != "" {
+		return turnLanguage
+	}
+	return personaLanguage
+}
@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"personaworlds/backend/internal/cards"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// renderOneBattleLiveCard renders the oldest queued live card. Rendering
+// only depends on the stored snapshot, so a failure (in practice a card over
+// the size limit) is final until the battle changes and the API queues it
+// again.
+func (w *Worker) renderOneBattleLiveCard(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		battleID string
+		cardJSON []byte
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT battle_id::text, card
+		FROM battle_live_cards
+		WHERE status = 'PENDING'
+		ORDER BY requested_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`).Scan(&battleID, &cardJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var card cards.LiveCard
+	if err := json.Unmarshal(cardJSON, &card); err != nil {
+		return err
+	}
+	payload, renderErr := cards.RenderLiveGIF(card, cards.LiveMaxBytes)
+	if renderErr != nil {
+		if _, err := tx.Exec(ctx, `
+			UPDATE battle_live_cards
+			SET status = 'FAILED', gif = NULL, frame_count = 0, byte_size = 0, error = $2, rendered_at = NOW()
+			WHERE battle_id = $1
+		`, battleID, renderErr.Error()); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		w.logger.Warn("battle_live_card_failed", observability.Fields{
+			"battle_id": battleID,
+			"error":     renderErr.Error(),
+		})
+		return nil
+	}
+
+	frames := cards.LiveFrameCount(len(card.Turns))
+	if _, err := tx.Exec(ctx, `
+		UPDATE battle_live_cards
+		SET status = 'READY', gif = $2, frame_count = $3, byte_size = $4, error = '', rendered_at = NOW()
+		WHERE battle_id = $1
+	`, battleID, payload, frames, len(payload)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("battle_live_card_rendered", observability.Fields{
+		"battle_id": battleID,
+		"frames":    frames,
+		"bytes":     len(payload),
+	})
+	return nil
+}
@@ -0,0 +1,359 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	notificationTypeBattleOfWeek = "battle_of_week"
+
+	battleOfWeekStatusCreated = "CREATED"
+	battleOfWeekStatusSkipped = "SKIPPED"
+
+	battleOfWeekPersonaCandidates = 10
+	battleOfWeekNotifyLimit       = 500
+)
+
+type battleOfWeekPersona struct {
+	ID          string
+	OwnerUserID string
+	Name        string
+	Score       int
+}
+
+type battleOfWeekTopic struct {
+	PostID  string
+	Content string
+	Shares  int
+	Remixes int
+}
+
+// createBattleOfWeekForOneRoom features one battle per room and week. The
+// topic comes from the room's most remixed or shared battle of the last seven
+// days; the two best performing opted-in public personas of the room, owned
+// by different users, argue it. Rooms without a topic or a pair are recorded
+// as skipped so they are not re-evaluated until the next week.
+func (w *Worker) createBattleOfWeekForOneRoom(ctx context.Context) error {
+	weekStart := startOfWeekUTC(time.Now().UTC()).Format(common.DateLayout)
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var roomID, roomName string
+	err = tx.QueryRow(ctx, `
+		SELECT r.id::text, r.name
+		FROM rooms r
+		WHERE r.archived_at IS NULL
+		  AND r.event_ends_at IS NULL
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM room_battles_of_week b
+				WHERE b.room_id = r.id
+				  AND b.week_start = $1::date
+		  )
+		ORDER BY r.created_at ASC
+		LIMIT 1
+		FOR UPDATE OF r SKIP LOCKED
+	`, weekStart).Scan(&roomID, &roomName)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	topic, err := loadBattleOfWeekTopic(ctx, tx, roomID)
+	if err != nil {
+		return err
+	}
+	if topic == nil {
+		return w.skipBattleOfWeek(ctx, tx, roomID, weekStart, "no_topic")
+	}
+
+	candidates, err := listBattleOfWeekPersonas(ctx, tx, roomID)
+	if err != nil {
+		return err
+	}
+	pro, con, ok := pickBattleOfWeekPair(candidates)
+	if !ok {
+		return w.skipBattleOfWeek(ctx, tx, roomID, weekStart, "no_persona_pair")
+	}
+
+	templateID, templateName, err := loadBattleOfWeekTemplate(ctx, tx, topic.PostID)
+	if err != nil {
+		return err
+	}
+
+	topicText := extractWeeklyDigestTopic(topic.Content, roomName)
+	content := common.TruncateRunes(fmt.Sprintf(
+		"Topic: %s\nTemplate: %s\nPro style: %s\nCon style: %s\n\nBattle opening: keep arguments concise and evidence-based.",
+		topicText,
+		templateName,
+		"Bold and practical",
+		"Skeptical and evidence-first",
+	), w.cfg.DraftMaxLen)
+
+	// Like an accepted challenge, the battle belongs to the pro persona's owner
+	// and each persona's own reply quota applies when its turn is written.
+	var battleID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id)
+		VALUES ($1, NULL, $2, 'HUMAN', 'PUBLISHED', $3, NOW(), NULLIF($4, '')::uuid)
+		RETURNING id::text
+	`, roomID, pro.OwnerUserID, content, templateID).Scan(&battleID)
+	if err != nil {
+		return err
+	}
+
+	for idx, persona := range []battleOfWeekPersona{pro, con} {
+		payload, err := json.Marshal(map[string]any{
+			"post_id":        battleID,
+			"persona_id":     persona.ID,
+			"template_id":    templateID,
+			"battle_of_week": true,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+			VALUES ('generate_reply', $1, $2, $3::jsonb, 'PENDING', NOW() + ($4::int * INTERVAL '1 second'))
+		`, battleID, persona.ID, payload, idx); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO room_battles_of_week(room_id, week_start, status, post_id, source_post_id, pro_persona_id, con_persona_id)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7)
+	`, roomID, weekStart, battleOfWeekStatusCreated, battleID, topic.PostID, pro.ID, con.ID); err != nil {
+		return err
+	}
+
+	notified, err := notifyBattleOfWeek(ctx, tx, battleID, roomID, roomName, topicText, pro, con)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.logger.Info("battle_of_week_created", observability.Fields{
+		"room_id":        roomID,
+		"battle_id":      battleID,
+		"source_post_id": topic.PostID,
+		"pro_persona_id": pro.ID,
+		"con_persona_id": con.ID,
+		"notified":       notified,
+	})
+	return nil
+}
+
+func (w *Worker) skipBattleOfWeek(ctx context.Context, tx pgx.Tx, roomID, weekStart, reason string) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO room_battles_of_week(room_id, week_start, status, skip_reason)
+		VALUES ($1, $2::date, $3, $4)
+	`, roomID, weekStart, battleOfWeekStatusSkipped, reason); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.logger.Info("battle_of_week_skipped", observability.Fields{
+		"room_id": roomID,
+		"reason":  reason,
+	})
+	return nil
+}
+
+// loadBattleOfWeekTopic weighs remixes above shares, as the weekly digest does.
+// Featured battles and earlier sources are skipped, so a topic is featured once.
+func loadBattleOfWeekTopic(ctx context.Context, tx pgx.Tx, roomID string) (*battleOfWeekTopic, error) {
+	var topic battleOfWeekTopic
+	err := tx.QueryRow(ctx, `
+		WITH engagement AS (
+			SELECT
+				battle_id,
+				COUNT(*) FILTER (WHERE event_name = 'battle_shared')::int AS shares,
+				COUNT(*) FILTER (WHERE event_name = 'remix_completed')::int AS remixes
+			FROM (
+				SELECT
+					event_name,
+					COALESCE(NULLIF(metadata->>'source_battle_id', ''), NULLIF(metadata->>'battle_id', '')) AS battle_id
+				FROM events
+				WHERE created_at >= NOW() - INTERVAL '7 days'
+				  AND event_name IN ('battle_shared', 'remix_completed')
+			) counts
+			WHERE COALESCE(battle_id, '') <> ''
+			GROUP BY battle_id
+		)
+		SELECT p.id::text, p.content, eng.shares, eng.remixes
+		FROM posts p
+		JOIN engagement eng ON eng.battle_id = p.id::text
+		WHERE p.room_id = $1
+		  AND p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM room_battles_of_week b
+				WHERE b.post_id = p.id
+				   OR b.source_post_id = p.id
+		  )
+		ORDER BY (eng.remixes * 3 + eng.shares * 2) DESC, p.created_at DESC
+		LIMIT 1
+	`, roomID).Scan(&topic.PostID, &topic.Content, &topic.Shares, &topic.Remixes)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &topic, nil
+}
+
+// listBattleOfWeekPersonas ranks opted-in public personas by their activity in
+// the room over the last 30 days: turns written plus reactions to their posts.
+func listBattleOfWeekPersonas(ctx context.Context, tx pgx.Tx, roomID string) ([]battleOfWeekPersona, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, owner_user_id, name, score
+		FROM (
+			SELECT
+				pe.id::text AS id,
+				pe.user_id::text AS owner_user_id,
+				pe.name,
+				pe.created_at,
+				(
+					(
+						SELECT COUNT(*)
+						FROM replies r
+						JOIN posts p ON p.id = r.post_id
+						WHERE r.persona_id = pe.id
+						  AND p.room_id = $1
+						  AND r.created_at >= NOW() - INTERVAL '30 days'
+					) + (
+						SELECT COUNT(*)
+						FROM post_reactions pr
+						JOIN posts p ON p.id = pr.post_id
+						WHERE p.persona_id = pe.id
+						  AND p.room_id = $1
+						  AND pr.created_at >= NOW() - INTERVAL '30 days'
+					)
+				)::int AS score
+			FROM personas pe
+			JOIN persona_public_profiles pp ON pp.persona_id = pe.id
+			WHERE pe.battle_of_week_opt_in = TRUE
+			  AND pp.is_public = TRUE
+		) ranked
+		WHERE score > 0
+		ORDER BY score DESC, created_at ASC
+		LIMIT $2
+	`, roomID, battleOfWeekPersonaCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]battleOfWeekPersona, 0, battleOfWeekPersonaCandidates)
+	for rows.Next() {
+		var persona battleOfWeekPersona
+		if err := rows.Scan(&persona.ID, &persona.OwnerUserID, &persona.Name, &persona.Score); err != nil {
+			return nil, err
+		}
+		out = append(out, persona)
+	}
+	return out, rows.Err()
+}
+
+// pickBattleOfWeekPair takes the best ranked persona and the best one owned by
+// someone else; a user never argues against themselves.
+func pickBattleOfWeekPair(ranked []battleOfWeekPersona) (battleOfWeekPersona, battleOfWeekPersona, bool) {
+	if len(ranked) < 2 {
+		return battleOfWeekPersona{}, battleOfWeekPersona{}, false
+	}
+	pro := ranked[0]
+	for _, candidate := range ranked[1:] {
+		if candidate.OwnerUserID != pro.OwnerUserID {
+			return pro, candidate, true
+		}
+	}
+	return battleOfWeekPersona{}, battleOfWeekPersona{}, false
+}
+
+// loadBattleOfWeekTemplate reuses the source battle's template when it is
+// public and falls back to the default public template otherwise.
+func loadBattleOfWeekTemplate(ctx context.Context, tx pgx.Tx, sourcePostID string) (string, string, error) {
+	var id, name string
+	err := tx.QueryRow(ctx, `
+		SELECT t.id::text, t.name
+		FROM templates t
+		WHERE t.is_public = TRUE
+		ORDER BY
+			CASE WHEN t.id = (SELECT template_id FROM posts WHERE id = $1) THEN 0 ELSE 1 END,
+			CASE WHEN LOWER(t.name) = LOWER('Claim/Evidence 6 turns') THEN 0 ELSE 1 END,
+			t.created_at ASC
+		LIMIT 1
+	`, sourcePostID).Scan(&id, &name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "Default", nil
+	}
+	return id, name, err
+}
+
+// notifyBattleOfWeek tells both owners and the followers of either persona.
+// Followers are capped so one popular persona cannot flood the table.
+func notifyBattleOfWeek(ctx context.Context, tx pgx.Tx, battleID, roomID, roomName, topic string, pro, con battleOfWeekPersona) (int, error) {
+	payload, err := json.Marshal(map[string]any{
+		"battle_id":        battleID,
+		"room_id":          roomID,
+		"room_name":        roomName,
+		"topic":            topic,
+		"pro_persona_id":   pro.ID,
+		"pro_persona_name": pro.Name,
+		"con_persona_id":   con.ID,
+		"con_persona_name": con.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ownerBody := common.TruncateRunes(fmt.Sprintf("%s vs %s in %s: %s", pro.Name, con.Name, roomName, topic), 260)
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO notifications(user_id, type, title, body, metadata)
+		SELECT DISTINCT owner_id, $2, 'Your persona is in the battle of the week', $3, $4::jsonb
+		FROM unnest($1::uuid[]) AS owner_id
+	`, []string{pro.OwnerUserID, con.OwnerUserID}, notificationTypeBattleOfWeek, ownerBody, payload)
+	if err != nil {
+		return 0, err
+	}
+	notified := int(tag.RowsAffected())
+
+	followerBody := common.TruncateRunes(fmt.Sprintf("A persona you follow is in the %s battle of the week: %s", roomName, topic), 260)
+	tag, err = tx.Exec(ctx, `
+		INSERT INTO notifications(user_id, type, title, body, metadata)
+		SELECT follower_user_id, $3, 'Battle of the week', $4, $5::jsonb
+		FROM (
+			SELECT DISTINCT pf.follower_user_id
+			FROM persona_follows pf
+			WHERE pf.followed_persona_id = ANY($1::uuid[])
+			  AND pf.follower_user_id <> ALL($2::uuid[])
+			LIMIT $6
+		) followers
+	`, []string{pro.ID, con.ID}, []string{pro.OwnerUserID, con.OwnerUserID}, notificationTypeBattleOfWeek, followerBody, payload, battleOfWeekNotifyLimit)
+	if err != nil {
+		return 0, err
+	}
+	return notified + int(tag.RowsAffected()), nil
+}
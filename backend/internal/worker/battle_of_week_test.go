@@ -0,0 +1,32 @@
+package worker
+
+import "testing"
+
+func TestPickBattleOfWeekPair(t *testing.T) {
+	ranked := []battleOfWeekPersona{
+		{ID: "a", OwnerUserID: "u1", Score: 9},
+		{ID: "b", OwnerUserID: "u1", Score: 7},
+		{ID: "c", OwnerUserID: "u2", Score: 5},
+		{ID: "d", OwnerUserID: "u3", Score: 4},
+	}
+	pro, con, ok := pickBattleOfWeekPair(ranked)
+	if !ok {
+		t.Fatalf("expected a pair")
+	}
+	if pro.ID != "a" || con.ID != "c" {
+		t.Fatalf("expected a vs c (different owners), got %s vs %s", pro.ID, con.ID)
+	}
+}
+
+func TestPickBattleOfWeekPairNeedsTwoOwners(t *testing.T) {
+	cases := [][]battleOfWeekPersona{
+		nil,
+		{{ID: "a", OwnerUserID: "u1"}},
+		{{ID: "a", OwnerUserID: "u1"}, {ID: "b", OwnerUserID: "u1"}},
+	}
+	for _, ranked := range cases {
+		if _, _, ok := pickBattleOfWeekPair(ranked); ok {
+			t.Fatalf("expected no pair for %+v", ranked)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+// notifyBattleWatchersOfTurn runs once a turn has been committed. A failure
+// only costs the watchers' notification, so it is logged and not retried.
+func (w *Worker) notifyBattleWatchersOfTurn(ctx context.Context, postID, authorUserID, authorName string) {
+	if _, err := common.NotifyBattleWatchersOfTurn(ctx, w.db, postID, authorUserID, authorName); err != nil {
+		w.logger.Warn("battle_watchers_notify_failed", observability.Fields{
+			"post_id": postID,
+			"event":   "turn",
+			"error":   err.Error(),
+		})
+	}
+}
+
+func (w *Worker) notifyBattleWatchersOfVerdict(ctx context.Context, postID, topic string, participantUserIDs []string) {
+	notified, err := common.NotifyBattleWatchersOfVerdict(ctx, w.db, postID, topic, participantUserIDs)
+	if err != nil {
+		w.logger.Warn("battle_watchers_notify_failed", observability.Fields{
+			"post_id": postID,
+			"event":   "verdict",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if notified > 0 {
+		w.logger.Info("battle_watchers_notified", observability.Fields{
+			"post_id":  postID,
+			"watchers": notified,
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/social"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// executeCrosspost publishes a queued cross-post. Network outages and rate
+// limits are retried with the job's backoff; refused tokens and rejected
+// posts fail the cross-post right away.
+func (w *Worker) executeCrosspost(ctx context.Context, postID string, payloadRaw []byte, attempts int) (err error) {
+	var payload common.CrosspostJobPayload
+	if err := json.Unmarshal(payloadRaw, &payload); err != nil {
+		return permanentError{message: "invalid crosspost payload"}
+	}
+	defer func() {
+		if err != nil {
+			w.recordCrosspostFailure(ctx, postID, payload.Provider, err, w.jobGivesUp(err, attempts))
+		}
+	}()
+
+	provider, ok := w.social[payload.Provider]
+	if !ok {
+		return permanentError{message: payload.Provider + " is not configured"}
+	}
+
+	var (
+		content        string
+		postStatus     string
+		unpublished    bool
+		status         string
+		backlink       string
+		account        social.Account
+		token          social.Token
+		accessToken    *string
+		tokenExpiresAt *time.Time
+	)
+	err = w.db.QueryRow(ctx, `
+		SELECT p.content, p.status::text, p.unpublished_at IS NOT NULL, pc.status, pc.backlink_url,
+			COALESCE(sa.external_user_id, ''), COALESCE(sa.handle, ''), sa.access_token, COALESCE(sa.refresh_token, ''), sa.expires_at
+		FROM post_crossposts pc
+		JOIN posts p ON p.id = pc.post_id
+		LEFT JOIN social_accounts sa ON sa.user_id = pc.user_id AND sa.provider = pc.provider
+		WHERE pc.post_id = $1
+		  AND pc.provider = $2
+	`, postID, provider.Name()).Scan(&content, &postStatus, &unpublished, &status, &backlink,
+		&account.ExternalID, &account.Handle, &accessToken, &token.RefreshToken, &tokenExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "crosspost not found"}
+		}
+		return err
+	}
+	if status == "PUBLISHED" {
+		return nil
+	}
+	if postStatus != "PUBLISHED" || unpublished {
+		return permanentError{message: "post is no longer published"}
+	}
+	if accessToken == nil {
+		return permanentError{message: provider.Name() + " account was disconnected"}
+	}
+	token.AccessToken = *accessToken
+	if tokenExpiresAt != nil {
+		token.ExpiresAt = *tokenExpiresAt
+	}
+
+	refreshed := false
+	if token.Expired(time.Now()) {
+		if token, err = w.refreshSocialToken(ctx, provider, payload.UserID, token); err != nil {
+			return crosspostError(provider, err)
+		}
+		refreshed = true
+	}
+
+	text := social.FormatPost(provider, content, backlink)
+	published, err := provider.Publish(ctx, token.AccessToken, account, text)
+	if errors.Is(err, social.ErrUnauthorized) && !refreshed && token.RefreshToken != "" {
+		if token, err = w.refreshSocialToken(ctx, provider, payload.UserID, token); err != nil {
+			return crosspostError(provider, err)
+		}
+		published, err = provider.Publish(ctx, token.AccessToken, account, text)
+	}
+	if err != nil {
+		return crosspostError(provider, err)
+	}
+
+	if _, err := w.db.Exec(ctx, `
+		UPDATE post_crossposts
+		SET status = 'PUBLISHED',
+			external_id = $3,
+			external_url = $4,
+			error = '',
+			published_at = NOW(),
+			updated_at = NOW()
+		WHERE post_id = $1
+		  AND provider = $2
+	`, postID, provider.Name(), published.ExternalID, published.URL); err != nil {
+		return err
+	}
+	w.logger.Info("crosspost_published", observability.Fields{
+		"post_id":      postID,
+		"provider":     provider.Name(),
+		"external_url": published.URL,
+	})
+	return nil
+}
+
+// refreshSocialToken swaps an expired token and stores the new pair. Networks
+// that do not rotate refresh tokens keep the old one.
+func (w *Worker) refreshSocialToken(ctx context.Context, provider social.Provider, userID string, token social.Token) (social.Token, error) {
+	next, err := provider.Refresh(ctx, token.RefreshToken)
+	if err != nil {
+		return social.Token{}, err
+	}
+	if next.RefreshToken == "" {
+		next.RefreshToken = token.RefreshToken
+	}
+	var expiresAt *time.Time
+	if !next.ExpiresAt.IsZero() {
+		expiresAt = &next.ExpiresAt
+	}
+	if _, err := w.db.Exec(ctx, `
+		UPDATE social_accounts
+		SET access_token = $3,
+			refresh_token = $4,
+			expires_at = $5,
+			updated_at = NOW()
+		WHERE user_id = $1
+		  AND provider = $2
+	`, userID, provider.Name(), next.AccessToken, next.RefreshToken, expiresAt); err != nil {
+		return social.Token{}, err
+	}
+	return next, nil
+}
+
+// crosspostError marks failures a retry cannot fix as permanent.
+func crosspostError(provider social.Provider, err error) error {
+	if errors.Is(err, social.ErrUnauthorized) {
+		return permanentError{message: provider.Name() + " account needs to be reconnected"}
+	}
+	var apiErr *social.APIError
+	if errors.As(err, &apiErr) && !apiErr.Temporary() {
+		return permanentError{message: apiErr.Error()}
+	}
+	return err
+}
+
+// recordCrosspostFailure keeps the last error on the cross-post and fails it
+// once the job gives up, so the owner can request it again.
+func (w *Worker) recordCrosspostFailure(ctx context.Context, postID, provider string, cause error, givesUp bool) {
+	status := "PENDING"
+	if givesUp {
+		status = "FAILED"
+	}
+	if _, err := w.db.Exec(ctx, `
+		UPDATE post_crossposts
+		SET status = $3,
+			error = $4,
+			updated_at = NOW()
+		WHERE post_id = $1
+		  AND provider = $2
+		  AND status = 'PENDING'
+	`, postID, provider, status, common.TruncateRunes(cause.Error(), 500)); err != nil {
+		w.logger.Error("crosspost_failure_record_failed", observability.Fields{
+			"post_id":  postID,
+			"provider": provider,
+			"error":    err.Error(),
+		})
+	}
+}
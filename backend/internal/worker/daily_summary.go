@@ -0,0 +1,325 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	notificationTypeDailySummary = "daily_summary"
+
+	dailySummaryDraftLimit   = 5
+	dailySummaryBattleLimit  = 5
+	dailySummaryExcerptRunes = 140
+)
+
+// dailySummaryPersona is one persona's line in a user's daily summary: its
+// digest for the day plus the followers it gained.
+type dailySummaryPersona struct {
+	PersonaID    string `json:"persona_id"`
+	Name         string `json:"name"`
+	Summary      string `json:"summary"`
+	Posts        int    `json:"posts"`
+	Replies      int    `json:"replies"`
+	HumanReplies int    `json:"human_replies"`
+	Reactions    int    `json:"reactions"`
+	NewFollowers int    `json:"new_followers"`
+}
+
+type dailySummaryDraft struct {
+	PostID      string    `json:"post_id"`
+	PersonaID   string    `json:"persona_id"`
+	PersonaName string    `json:"persona_name"`
+	Excerpt     string    `json:"excerpt"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type dailySummaryBattle struct {
+	BattleID   string    `json:"battle_id"`
+	Topic      string    `json:"topic"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type dailySummary struct {
+	Personas          []dailySummaryPersona
+	PendingDrafts     []dailySummaryDraft
+	PendingDraftCount int
+	FinishedBattles   []dailySummaryBattle
+}
+
+func (s dailySummary) newFollowers() int {
+	total := 0
+	for _, persona := range s.Personas {
+		total += persona.NewFollowers
+	}
+	return total
+}
+
+// generateDailySummaryForOneUser rolls yesterday's (UTC) persona digests,
+// drafts awaiting approval, new followers and finished battles into one
+// summary for a user who opted in, and notifies them unless nothing
+// happened. The day matches the persona digests it is built from.
+func (w *Worker) generateDailySummaryForOneUser(ctx context.Context) error {
+	day := common.StartOfDay(time.Now().UTC(), time.UTC).AddDate(0, 0, -1)
+	date := day.Format(common.DateLayout)
+
+	var userID string
+	err := w.db.QueryRow(ctx, `
+		SELECT np.user_id::text
+		FROM notification_preferences np
+		WHERE np.daily_summary
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM user_daily_summaries s
+				WHERE s.user_id = np.user_id
+				  AND s.date = $1::date
+		  )
+		ORDER BY np.updated_at ASC, np.user_id ASC
+		LIMIT 1
+	`, date).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	summary, err := w.collectDailySummary(ctx, userID, day)
+	if err != nil {
+		return err
+	}
+	text := dailySummaryText(summary)
+
+	personasJSON, err := json.Marshal(summary.Personas)
+	if err != nil {
+		return err
+	}
+	draftsJSON, err := json.Marshal(summary.PendingDrafts)
+	if err != nil {
+		return err
+	}
+	battlesJSON, err := json.Marshal(summary.FinishedBattles)
+	if err != nil {
+		return err
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `
+		INSERT INTO user_daily_summaries(user_id, date, summary, personas, pending_drafts, finished_battles, pending_draft_count, new_followers)
+		VALUES ($1, $2::date, $3, $4::jsonb, $5::jsonb, $6::jsonb, $7, $8)
+		ON CONFLICT (user_id, date) DO NOTHING
+	`, userID, date, text, personasJSON, draftsJSON, battlesJSON, summary.PendingDraftCount, summary.newFollowers())
+	if err != nil {
+		return err
+	}
+	// Another worker got there first, or the day was empty: either way there
+	// is nothing to announce.
+	if ct.RowsAffected() > 0 && text != "" {
+		metadata, err := json.Marshal(map[string]any{"date": date})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO notifications(user_id, type, title, body, metadata)
+			VALUES ($1, $2, 'Your daily summary', $3, $4::jsonb)
+		`, userID, notificationTypeDailySummary, common.TruncateRunes(text, 260), metadata); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("daily_summary_generated", observability.Fields{
+		"user_id":          userID,
+		"date":             date,
+		"personas":         len(summary.Personas),
+		"pending_drafts":   summary.PendingDraftCount,
+		"finished_battles": len(summary.FinishedBattles),
+	})
+	return nil
+}
+
+func (w *Worker) collectDailySummary(ctx context.Context, userID string, day time.Time) (dailySummary, error) {
+	summary := dailySummary{
+		Personas:        make([]dailySummaryPersona, 0),
+		PendingDrafts:   make([]dailySummaryDraft, 0),
+		FinishedBattles: make([]dailySummaryBattle, 0),
+	}
+	start, end := day, day.AddDate(0, 0, 1)
+
+	rows, err := w.db.Query(ctx, `
+		SELECT
+			p.id::text,
+			p.name,
+			COALESCE(d.summary, ''),
+			COALESCE(d.stats, '{}'::jsonb),
+			(
+				SELECT COUNT(*)::int
+				FROM persona_follows pf
+				WHERE pf.followed_persona_id = p.id
+				  AND pf.created_at >= $3
+				  AND pf.created_at < $4
+			)
+		FROM personas p
+		LEFT JOIN persona_digests d
+			ON d.persona_id = p.id
+		   AND d.date = $2::date
+		WHERE p.user_id = $1
+		ORDER BY p.created_at ASC, p.id ASC
+	`, userID, day.Format(common.DateLayout), start, end)
+	if err != nil {
+		return dailySummary{}, err
+	}
+	for rows.Next() {
+		var (
+			persona  dailySummaryPersona
+			statsRaw []byte
+			stats    digestStats
+		)
+		if err := rows.Scan(&persona.PersonaID, &persona.Name, &persona.Summary, &statsRaw, &persona.NewFollowers); err != nil {
+			rows.Close()
+			return dailySummary{}, err
+		}
+		if err := json.Unmarshal(statsRaw, &stats); err != nil {
+			rows.Close()
+			return dailySummary{}, err
+		}
+		persona.Posts = stats.Posts
+		persona.Replies = stats.Replies
+		persona.HumanReplies = stats.HumanReplies
+		persona.Reactions = stats.Reactions
+		if persona.Posts+persona.Replies+persona.Reactions+persona.NewFollowers == 0 {
+			continue
+		}
+		summary.Personas = append(summary.Personas, persona)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return dailySummary{}, err
+	}
+
+	// Drafts are a standing queue rather than the day's activity, so every
+	// draft still waiting counts, not only the ones written that day.
+	rows, err = w.db.Query(ctx, `
+		SELECT
+			p.id::text,
+			pe.id::text,
+			pe.name,
+			p.content,
+			p.created_at,
+			COUNT(*) OVER ()::int
+		FROM posts p
+		JOIN personas pe ON pe.id = p.persona_id
+		WHERE pe.user_id = $1
+		  AND p.status = 'DRAFT'
+		  AND p.authored_by = 'AI'
+		  AND p.unpublished_at IS NULL
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $2
+	`, userID, dailySummaryDraftLimit)
+	if err != nil {
+		return dailySummary{}, err
+	}
+	for rows.Next() {
+		var (
+			draft   dailySummaryDraft
+			content string
+		)
+		if err := rows.Scan(&draft.PostID, &draft.PersonaID, &draft.PersonaName, &content, &draft.CreatedAt, &summary.PendingDraftCount); err != nil {
+			rows.Close()
+			return dailySummary{}, err
+		}
+		draft.Excerpt = common.TruncateRunes(strings.Join(strings.Fields(content), " "), dailySummaryExcerptRunes)
+		summary.PendingDrafts = append(summary.PendingDrafts, draft)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return dailySummary{}, err
+	}
+
+	// A battle counts as finished once its coaching is written.
+	rows, err = w.db.Query(ctx, `
+		SELECT p.id::text, p.content, COALESCE(rm.name, ''), MIN(c.created_at)
+		FROM posts p
+		JOIN battle_coaching c ON c.post_id = p.id
+		LEFT JOIN rooms rm ON rm.id = p.room_id
+		WHERE p.user_id = $1
+		GROUP BY p.id, p.content, rm.name
+		HAVING MIN(c.created_at) >= $2
+		   AND MIN(c.created_at) < $3
+		ORDER BY MIN(c.created_at) ASC
+		LIMIT $4
+	`, userID, start, end, dailySummaryBattleLimit)
+	if err != nil {
+		return dailySummary{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			battle   dailySummaryBattle
+			content  string
+			roomName string
+		)
+		if err := rows.Scan(&battle.BattleID, &content, &roomName, &battle.FinishedAt); err != nil {
+			return dailySummary{}, err
+		}
+		battle.Topic = extractWeeklyDigestTopic(content, roomName)
+		summary.FinishedBattles = append(summary.FinishedBattles, battle)
+	}
+	return summary, rows.Err()
+}
+
+// dailySummaryText is the one-paragraph rollup used as the summary and the
+// notification body. It is empty when there is nothing to report.
+func dailySummaryText(summary dailySummary) string {
+	posts, replies := 0, 0
+	for _, persona := range summary.Personas {
+		posts += persona.Posts
+		replies += persona.Replies
+	}
+
+	sentences := make([]string, 0, 4)
+	if posts+replies > 0 {
+		label := "Your personas"
+		if len(summary.Personas) == 1 {
+			label = summary.Personas[0].Name
+		}
+		wrote := make([]string, 0, 2)
+		if posts > 0 {
+			wrote = append(wrote, pluralize(posts, "post"))
+		}
+		if replies > 0 {
+			wrote = append(wrote, pluralize(replies, "reply"))
+		}
+		sentences = append(sentences, label+" wrote "+strings.Join(wrote, " and ")+".")
+	}
+	if followers := summary.newFollowers(); followers > 0 {
+		sentences = append(sentences, "You gained "+pluralize(followers, "new follower")+".")
+	}
+	if len(summary.FinishedBattles) == 1 {
+		sentences = append(sentences, "\""+common.TruncateRunes(summary.FinishedBattles[0].Topic, 80)+"\" finished.")
+	} else if len(summary.FinishedBattles) > 1 {
+		sentences = append(sentences, pluralize(len(summary.FinishedBattles), "battle")+" finished.")
+	}
+	switch {
+	case summary.PendingDraftCount == 1:
+		sentences = append(sentences, "1 draft is waiting for your approval.")
+	case summary.PendingDraftCount > 1:
+		sentences = append(sentences, pluralize(summary.PendingDraftCount, "draft")+" are waiting for your approval.")
+	}
+	return strings.Join(sentences, " ")
+}
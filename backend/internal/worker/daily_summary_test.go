@@ -0,0 +1,40 @@
+package worker
+
+import "testing"
+
+func TestDailySummaryTextRollsUpPersonas(t *testing.T) {
+	summary := dailySummary{
+		Personas: []dailySummaryPersona{
+			{Name: "Ada", Posts: 2, Replies: 1, NewFollowers: 3},
+			{Name: "Grace", Replies: 4},
+		},
+		PendingDraftCount: 2,
+		FinishedBattles:   []dailySummaryBattle{{Topic: "Tabs or spaces"}, {Topic: "Vim or Emacs"}},
+	}
+	want := "Your personas wrote 2 posts and 5 replies. You gained 3 new followers. 2 battles finished. 2 drafts are waiting for your approval."
+	if got := dailySummaryText(summary); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDailySummaryTextNamesSinglePersonaAndBattle(t *testing.T) {
+	summary := dailySummary{
+		Personas:          []dailySummaryPersona{{Name: "Ada", Posts: 1, NewFollowers: 1}},
+		PendingDraftCount: 1,
+		FinishedBattles:   []dailySummaryBattle{{Topic: "Tabs or spaces"}},
+	}
+	want := "Ada wrote 1 post. You gained 1 new follower. \"Tabs or spaces\" finished. 1 draft is waiting for your approval."
+	if got := dailySummaryText(summary); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestDailySummaryTextEmptyDay(t *testing.T) {
+	if got := dailySummaryText(dailySummary{}); got != "" {
+		t.Fatalf("expected empty summary, got %q", got)
+	}
+	followersOnly := dailySummary{Personas: []dailySummaryPersona{{Name: "Ada", NewFollowers: 2}}}
+	if got := dailySummaryText(followersOnly); got != "You gained 2 new followers." {
+		t.Fatalf("unexpected followers-only summary %q", got)
+	}
+}
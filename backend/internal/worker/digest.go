@@ -6,12 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
 	"personaworlds/backend/internal/common"
-
-	"github.com/jackc/pgx/v5"
+	"personaworlds/backend/internal/observability"
 )
 
 type digestThread struct {
@@ -24,25 +25,102 @@ type digestThread struct {
 }
 
 type digestStats struct {
-	Posts      int            `json:"posts"`
-	Replies    int            `json:"replies"`
-	TopThreads []digestThread `json:"top_threads"`
+	Posts        int            `json:"posts"`
+	Replies      int            `json:"replies"`
+	HumanReplies int            `json:"human_replies"`
+	Reactions    int            `json:"reactions"`
+	TopThreads   []digestThread `json:"top_threads"`
 }
 
-func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
-	var persona struct {
-		ID                string
-		Name              string
-		Bio               string
-		Tone              string
-		WritingSamplesRaw []byte
-		DoNotSayRaw       []byte
-		CatchphrasesRaw   []byte
-		PreferredLanguage string
-		Formality         int
+// digestDueFrom selects the personas whose digest for today is due.
+// Regeneration requests skip the schedule; otherwise a persona is due once
+// its digest_hour (UTC) has passed, and again whenever new activity lands.
+const digestDueFrom = `
+	FROM personas p
+	LEFT JOIN persona_digests d
+		ON d.persona_id = p.id
+	   AND d.date = CURRENT_DATE
+	WHERE p.digest_regenerate_requested_at > COALESCE(d.updated_at, TO_TIMESTAMP(0))
+	   OR (
+			p.digest_enabled
+			AND NOW() >= date_trunc('day', NOW()) + make_interval(hours => p.digest_hour)
+			AND (
+				d.id IS NULL
+				OR EXISTS (
+					SELECT 1
+					FROM persona_activity_events e
+					WHERE e.persona_id = p.id
+					  AND e.created_at >= date_trunc('day', NOW())
+					  AND e.created_at > COALESCE(d.updated_at, TO_TIMESTAMP(0))
+				)
+			)
+	   )
+`
+
+type digestPersona struct {
+	ID                string
+	Name              string
+	Bio               string
+	Tone              string
+	WritingSamplesRaw []byte
+	DoNotSayRaw       []byte
+	CatchphrasesRaw   []byte
+	PreferredLanguage string
+	Formality         int
+}
+
+// generateDigestBatch builds or refreshes today's digest for up to
+// DIGEST_BATCH_SIZE due personas, regeneration requests first. Summaries are
+// generated DIGEST_CONCURRENCY at a time, halved per backpressure level.
+// Personas not started before the task times out stay due for the next poll.
+func (w *Worker) generateDigestBatch(ctx context.Context) error {
+	batchSize := max(w.cfg.DigestBatchSize, 1)
+	personas, err := w.selectDueDigestPersonas(ctx, batchSize)
+	if err != nil {
+		return err
 	}
 
-	err := w.db.QueryRow(ctx, `
+	backlog := len(personas)
+	if backlog == batchSize {
+		if err := w.db.QueryRow(ctx, `SELECT COUNT(*)::int`+digestDueFrom).Scan(&backlog); err != nil {
+			return err
+		}
+	}
+	w.metrics.SetDigestBacklog(backlog)
+	if len(personas) == 0 {
+		return nil
+	}
+
+	concurrency := w.backpressure.concurrencyFor(prompts.OpPersonaActivitySummary, w.cfg.DigestConcurrency)
+	next := make(chan digestPersona)
+	errs := make([]error, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(slot int) {
+			defer wg.Done()
+			for persona := range next {
+				if err := w.generateDigest(ctx, persona); err != nil {
+					errs[slot] = errors.Join(errs[slot], fmt.Errorf("persona %s: %w", persona.ID, err))
+				}
+			}
+		}(i)
+	}
+feed:
+	for _, persona := range personas {
+		select {
+		case next <- persona:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (w *Worker) selectDueDigestPersonas(ctx context.Context, limit int) ([]digestPersona, error) {
+	rows, err := w.db.Query(ctx, `
 		SELECT
 			p.id::text,
 			p.name,
@@ -53,46 +131,50 @@ func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
 			p.catchphrases,
 			p.preferred_language,
 			p.formality
-		FROM personas p
-		LEFT JOIN persona_digests d
-			ON d.persona_id = p.id
-		   AND d.date = CURRENT_DATE
-		WHERE d.id IS NULL
-		   OR EXISTS (
-				SELECT 1
-				FROM persona_activity_events e
-				WHERE e.persona_id = p.id
-				  AND e.created_at >= date_trunc('day', NOW())
-				  AND e.created_at > COALESCE(d.updated_at, TO_TIMESTAMP(0))
-		   )
-		ORDER BY COALESCE(d.updated_at, TO_TIMESTAMP(0)) ASC, p.created_at ASC
-		LIMIT 1
-	`).Scan(
-		&persona.ID,
-		&persona.Name,
-		&persona.Bio,
-		&persona.Tone,
-		&persona.WritingSamplesRaw,
-		&persona.DoNotSayRaw,
-		&persona.CatchphrasesRaw,
-		&persona.PreferredLanguage,
-		&persona.Formality,
-	)
+	`+digestDueFrom+`
+		ORDER BY
+			(p.digest_regenerate_requested_at > COALESCE(d.updated_at, TO_TIMESTAMP(0))) IS TRUE DESC,
+			COALESCE(d.updated_at, TO_TIMESTAMP(0)) ASC,
+			p.created_at ASC
+		LIMIT $1
+	`, limit)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil
+		return nil, err
+	}
+	defer rows.Close()
+
+	personas := make([]digestPersona, 0, limit)
+	for rows.Next() {
+		var persona digestPersona
+		if err := rows.Scan(
+			&persona.ID,
+			&persona.Name,
+			&persona.Bio,
+			&persona.Tone,
+			&persona.WritingSamplesRaw,
+			&persona.DoNotSayRaw,
+			&persona.CatchphrasesRaw,
+			&persona.PreferredLanguage,
+			&persona.Formality,
+		); err != nil {
+			return nil, err
 		}
-		return err
+		personas = append(personas, persona)
 	}
+	return personas, rows.Err()
+}
 
+// generateDigest summarizes today's activity of one persona and stores it.
+// When the LLM call fails the digest falls back to a plain stats summary.
+func (w *Worker) generateDigest(ctx context.Context, persona digestPersona) error {
 	personaCtx := ai.PersonaContext{
 		ID:                persona.ID,
 		Name:              persona.Name,
 		Bio:               persona.Bio,
 		Tone:              persona.Tone,
-		WritingSamples:    parseJSONStringSlice(persona.WritingSamplesRaw),
-		DoNotSay:          parseJSONStringSlice(persona.DoNotSayRaw),
-		Catchphrases:      parseJSONStringSlice(persona.CatchphrasesRaw),
+		WritingSamples:    w.parsePersonaList(ctx, common.WritingSamplesField, persona.WritingSamplesRaw),
+		DoNotSay:          w.parsePersonaList(ctx, common.DoNotSayField, persona.DoNotSayRaw),
+		Catchphrases:      w.parsePersonaList(ctx, common.CatchphrasesField, persona.CatchphrasesRaw),
 		PreferredLanguage: strings.TrimSpace(persona.PreferredLanguage),
 		Formality:         persona.Formality,
 	}
@@ -106,7 +188,8 @@ func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
 	}
 
 	summary := noActivityDigestSummary(personaCtx)
-	if stats.Posts > 0 || stats.Replies > 0 || len(stats.TopThreads) > 0 {
+	promptVersion := ""
+	if stats.Posts > 0 || stats.Replies > 0 || stats.HumanReplies > 0 || len(stats.TopThreads) > 0 {
 		aiThreads := make([]ai.DigestThreadContext, 0, len(stats.TopThreads))
 		for _, thread := range stats.TopThreads {
 			aiThreads = append(aiThreads, ai.DigestThreadContext{
@@ -117,6 +200,7 @@ func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
 			})
 		}
 
+		activePrompt := w.llm.Prompts().Active(prompts.OpPersonaActivitySummary)
 		aiSummary, aiErr := w.llm.SummarizePersonaActivity(ctx, personaCtx, ai.DigestStats{
 			Posts:   stats.Posts,
 			Replies: stats.Replies,
@@ -125,11 +209,13 @@ func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
 			summary = fallbackDigestSummary(personaCtx, stats)
 		} else {
 			summary = strings.TrimSpace(aiSummary)
+			promptVersion = activePrompt
 		}
 	}
 
 	if summary == "" {
 		summary = fallbackDigestSummary(personaCtx, stats)
+		promptVersion = ""
 	}
 	summary = common.TruncateRunes(summary, w.cfg.SummaryMaxLen)
 
@@ -139,14 +225,15 @@ func (w *Worker) generateDigestForOnePersona(ctx context.Context) error {
 	}
 
 	_, err = w.db.Exec(ctx, `
-		INSERT INTO persona_digests(persona_id, date, summary, stats, created_at, updated_at)
-		VALUES ($1, CURRENT_DATE, $2, $3::jsonb, NOW(), NOW())
+		INSERT INTO persona_digests(persona_id, date, summary, stats, prompt_version, created_at, updated_at)
+		VALUES ($1, CURRENT_DATE, $2, $3::jsonb, NULLIF($4, ''), NOW(), NOW())
 		ON CONFLICT (persona_id, date)
 		DO UPDATE SET
 			summary = EXCLUDED.summary,
 			stats = EXCLUDED.stats,
+			prompt_version = EXCLUDED.prompt_version,
 			updated_at = NOW()
-	`, persona.ID, summary, statsJSON)
+	`, persona.ID, summary, statsJSON, promptVersion)
 	if err != nil {
 		return err
 	}
@@ -162,11 +249,22 @@ func (w *Worker) collectDigestStats(ctx context.Context, personaID string) (dige
 	if err := w.db.QueryRow(ctx, `
 		SELECT
 			COALESCE(SUM(CASE WHEN type = 'post_created' THEN 1 ELSE 0 END), 0)::int AS posts,
-			COALESCE(SUM(CASE WHEN type = 'reply_generated' THEN 1 ELSE 0 END), 0)::int AS replies
+			COALESCE(SUM(CASE WHEN type = 'reply_generated' THEN 1 ELSE 0 END), 0)::int AS replies,
+			COALESCE(SUM(CASE WHEN type = 'human_reply_received' THEN 1 ELSE 0 END), 0)::int AS human_replies
 		FROM persona_activity_events
 		WHERE persona_id = $1
 		  AND created_at >= date_trunc('day', NOW())
-	`, personaID).Scan(&stats.Posts, &stats.Replies); err != nil {
+	`, personaID).Scan(&stats.Posts, &stats.Replies, &stats.HumanReplies); err != nil {
+		return digestStats{}, err
+	}
+
+	if err := w.db.QueryRow(ctx, `
+		SELECT COUNT(*)::int
+		FROM post_reactions r
+		JOIN posts p ON p.id = r.post_id
+		WHERE p.persona_id = $1
+		  AND r.created_at >= date_trunc('day', NOW())
+	`, personaID).Scan(&stats.Reactions); err != nil {
 		return digestStats{}, err
 	}
 
@@ -182,7 +280,7 @@ func (w *Worker) collectDigestStats(ctx context.Context, personaID string) (dige
 		LEFT JOIN posts p ON p.id::text = e.metadata->>'post_id'
 		LEFT JOIN rooms r ON r.id = p.room_id
 		WHERE e.persona_id = $1
-		  AND e.type = 'thread_participated'
+		  AND e.type IN ('thread_participated', 'human_reply_received')
 		  AND e.created_at >= date_trunc('day', NOW())
 		  AND COALESCE(e.metadata->>'post_id', '') <> ''
 		GROUP BY e.metadata->>'post_id'
@@ -216,13 +314,16 @@ func (w *Worker) collectDigestStats(ctx context.Context, personaID string) (dige
 	return stats, nil
 }
 
-func parseJSONStringSlice(raw []byte) []string {
-	if len(raw) == 0 {
-		return []string{}
-	}
-
-	var values []string
-	if err := json.Unmarshal(raw, &values); err != nil {
+// parsePersonaList reads a stored persona calibration list, opening it when
+// it was stored encrypted. A malformed or unreadable document only costs the
+// prompt that list, so it is logged and reads as empty.
+func (w *Worker) parsePersonaList(ctx context.Context, field common.PersonaListField, raw []byte) []string {
+	values, err := w.calibration.Decode(ctx, w.db, field, raw)
+	if err != nil {
+		w.logger.Warn("persona_calibration_unreadable", observability.Fields{
+			"field": field.Name,
+			"error": err.Error(),
+		})
 		return []string{}
 	}
 	return values
@@ -236,7 +337,7 @@ func noActivityDigestSummary(persona ai.PersonaContext) string {
 }
 
 func fallbackDigestSummary(persona ai.PersonaContext, stats digestStats) string {
-	if stats.Posts == 0 && stats.Replies == 0 {
+	if stats.Posts == 0 && stats.Replies == 0 && stats.HumanReplies == 0 {
 		return noActivityDigestSummary(persona)
 	}
 
@@ -255,7 +356,15 @@ func fallbackDigestSummary(persona ai.PersonaContext, stats digestStats) string
 	}
 
 	if strings.ToLower(strings.TrimSpace(persona.PreferredLanguage)) == "tr" {
-		return fmt.Sprintf("Bugün %d gönderi ve %d yanıt üretildi. Öne çıkan tartışmalar: %s.", stats.Posts, stats.Replies, topThreadText)
+		summary := fmt.Sprintf("Bugün %d gönderi ve %d yanıt üretildi. Öne çıkan tartışmalar: %s.", stats.Posts, stats.Replies, topThreadText)
+		if stats.HumanReplies > 0 {
+			summary += fmt.Sprintf(" Okurlar gönderilerine %d yanıt yazdı.", stats.HumanReplies)
+		}
+		return summary
+	}
+	summary := fmt.Sprintf("Today there were %d posts and %d replies. The most active threads were: %s.", stats.Posts, stats.Replies, topThreadText)
+	if stats.HumanReplies > 0 {
+		summary += fmt.Sprintf(" People wrote %d replies to its posts.", stats.HumanReplies)
 	}
-	return fmt.Sprintf("Today there were %d posts and %d replies. The most active threads were: %s.", stats.Posts, stats.Replies, topThreadText)
+	return summary
 }
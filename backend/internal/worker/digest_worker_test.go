@@ -114,7 +114,7 @@ func TestGenerateDigestForOnePersona(t *testing.T) {
 	worker := New(cfg, pool, ai.NewMockClient())
 	generated := false
 	for i := 0; i < 20; i++ {
-		if err := worker.generateDigestForOnePersona(ctx); err != nil {
+		if err := worker.generateDigestBatch(ctx); err != nil {
 			t.Fatalf("generate digest failed: %v", err)
 		}
 
@@ -0,0 +1,203 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const notificationTypeDraftReady = "draft_ready"
+
+// executeGenerateDraft finishes a draft the API gave up waiting for. The
+// checks the request already passed (quota, archived room) are repeated
+// because the job may run much later.
+func (w *Worker) executeGenerateDraft(ctx context.Context, jobID int64, personaID string, payloadRaw []byte) error {
+	var payload common.DraftJobPayload
+	if err := json.Unmarshal(payloadRaw, &payload); err != nil || strings.TrimSpace(payload.RoomID) == "" {
+		return permanentError{message: "invalid draft job payload"}
+	}
+
+	var (
+		persona                                         ai.PersonaContext
+		ownerUserID                                     string
+		dailyDraftQuota                                 int
+		writingSamplesRaw, doNotSayRaw, catchphrasesRaw []byte
+	)
+	err := w.db.QueryRow(ctx, `
+		SELECT user_id::text, name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality,
+			style_humor, style_assertiveness, style_technicality, style_brevity, daily_draft_quota
+		FROM personas
+		WHERE id = $1
+	`, personaID).Scan(
+		&ownerUserID,
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&writingSamplesRaw,
+		&doNotSayRaw,
+		&catchphrasesRaw,
+		&persona.PreferredLanguage,
+		&persona.Formality,
+		&persona.Style.Humor,
+		&persona.Style.Assertiveness,
+		&persona.Style.Technicality,
+		&persona.Style.Brevity,
+		&dailyDraftQuota,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "persona not found"}
+		}
+		return err
+	}
+	if ownerUserID != payload.UserID {
+		return permanentError{message: "persona owner changed"}
+	}
+	persona.ID = personaID
+	persona.WritingSamples = w.parsePersonaList(ctx, common.WritingSamplesField, writingSamplesRaw)
+	persona.DoNotSay = w.parsePersonaList(ctx, common.DoNotSayField, doNotSayRaw)
+	persona.Catchphrases = w.parsePersonaList(ctx, common.CatchphrasesField, catchphrasesRaw)
+	persona.Mood = payload.Mood
+
+	room := ai.RoomContext{ID: payload.RoomID, Variant: 1}
+	var archived bool
+	err = w.db.QueryRow(ctx, `
+		SELECT slug, name, description, archived_at IS NOT NULL
+		FROM rooms
+		WHERE id = $1
+	`, payload.RoomID).Scan(&room.Slug, &room.Name, &room.Description, &archived)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "room not found"}
+		}
+		return err
+	}
+	if archived {
+		return permanentError{message: "room is archived"}
+	}
+	roomHint, err := common.LoadRoomPromptHint(ctx, w.db, room.ID)
+	if err != nil {
+		return err
+	}
+	room.Hint = ai.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure}
+
+	var used int
+	if err := w.db.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM quota_events
+		WHERE persona_id = $1
+		  AND quota_type = 'draft'
+		  AND created_at >= date_trunc('day', NOW())
+	`, personaID).Scan(&used); err != nil {
+		return err
+	}
+	boost, err := common.ActiveQuotaBoost(ctx, w.db, ownerUserID, common.QuotaBoostDraft)
+	if err != nil {
+		return err
+	}
+	if used >= dailyDraftQuota+boost {
+		return permanentError{message: "daily draft quota reached"}
+	}
+
+	promptVersion := w.llm.Prompts().Active(prompts.OpPostDraft)
+	generationStartedAt := time.Now()
+	draft, err := w.llm.GeneratePostDraft(ctx, persona, room)
+	if err != nil {
+		return err
+	}
+	generationElapsed := time.Since(generationStartedAt)
+	if err := w.checkGeneratedContent(ctx, common.SafetyRejection{
+		UserID:    ownerUserID,
+		PersonaID: personaID,
+		RoomID:    room.ID,
+		Source:    common.SafetySourceDraft,
+		Language:  persona.PreferredLanguage,
+		Content:   draft,
+		MaxLen:    w.cfg.DraftMaxLen,
+		Metadata:  map[string]any{"prompt_version": promptVersion, "mood": payload.Mood, "draft_job_id": jobID, "prompt_hint_version": roomHint.Version},
+	}); err != nil {
+		return permanentError{message: err.Error()}
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var postID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, prompt_version, mood, prompt_hint_id)
+		VALUES ($1, $2, $3, 'AI', 'DRAFT', $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, 0))
+		RETURNING id::text
+	`, room.ID, personaID, ownerUserID, draft, promptVersion, payload.Mood, roomHint.ID).Scan(&postID); err != nil {
+		return err
+	}
+	if err := common.InsertQuotaEvent(ctx, tx, common.QuotaEvent{
+		PersonaID: personaID,
+		Type:      common.QuotaTypeDraft,
+		Source:    common.JobGenerateDraft,
+		ContentID: postID,
+		Metadata: map[string]any{
+			"job_id":     jobID,
+			"room_id":    room.ID,
+			"request_id": ai.RequestIDFromContext(ctx),
+		},
+	}); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE jobs
+		SET post_id = $2, updated_at = NOW()
+		WHERE id = $1
+	`, jobID, postID); err != nil {
+		return err
+	}
+
+	metadata, err := json.Marshal(map[string]any{
+		"post_id":      postID,
+		"room_id":      room.ID,
+		"persona_id":   personaID,
+		"draft_job_id": jobID,
+	})
+	if err != nil {
+		return err
+	}
+	body := common.TruncateRunes(fmt.Sprintf("%s's draft for %s is ready to review.", persona.Name, room.Name), 260)
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO notifications(user_id, type, title, body, metadata)
+		VALUES ($1, $2, 'Your draft is ready', $3, $4::jsonb)
+	`, ownerUserID, notificationTypeDraftReady, body, metadata); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.queueLLMCanary(ctx, common.LLMCanaryRun{
+		Kind:           common.LLMCanaryKindDraft,
+		PersonaID:      personaID,
+		RoomID:         room.ID,
+		PostID:         postID,
+		Mood:           payload.Mood,
+		PrimaryOutput:  draft,
+		PrimaryLatency: generationElapsed,
+	})
+
+	w.logger.Info("draft_job_completed", observability.Fields{
+		"job_id":     jobID,
+		"post_id":    postID,
+		"persona_id": personaID,
+		"trace_id":   payload.TraceID,
+	})
+	return nil
+}
@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/eventbus"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	eventOutboxBatchSize  = 100
+	eventOutboxRetryBase  = 5 * time.Second
+	eventOutboxRetryMax   = 10 * time.Minute
+	eventOutboxKeep       = 7 * 24 * time.Hour
+	eventOutboxPruneBatch = 1000
+)
+
+func newEventBus(cfg config.Config, logger *observability.Logger) eventbus.Publisher {
+	if !cfg.EventBusEnabled() {
+		return nil
+	}
+	publisher, err := eventbus.New(eventbus.Config{
+		Kind:        cfg.EventBus,
+		URL:         cfg.EventBusURL,
+		TopicPrefix: cfg.EventBusTopicPrefix,
+	})
+	if err != nil {
+		logger.Error("event_bus_disabled", observability.Fields{"error": err.Error()})
+		return nil
+	}
+	return publisher
+}
+
+// relayEventOutbox publishes one batch of pending outbox rows in id order.
+// A failed batch is retried as a whole with backoff and is never dropped,
+// so consumers see every message at least once and de-duplicate by id.
+func (w *Worker) relayEventOutbox(ctx context.Context) error {
+	// The capture triggers only write while this is on, so turning the bus
+	// off in config stops the outbox from growing.
+	if _, err := w.db.Exec(ctx, `
+		UPDATE event_bus_state
+		SET enabled = $1, updated_at = NOW()
+		WHERE enabled <> $1
+	`, w.bus != nil); err != nil {
+		return err
+	}
+	if w.bus == nil {
+		return nil
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, type, schema_version, payload, created_at, attempts
+		FROM event_outbox
+		WHERE published_at IS NULL
+		  AND available_at <= NOW()
+		ORDER BY id ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, eventOutboxBatchSize)
+	if err != nil {
+		return err
+	}
+	ids := []int64{}
+	messages := []eventbus.Message{}
+	attempts := 0
+	for rows.Next() {
+		var (
+			id            int64
+			messageType   string
+			schemaVersion int
+			payload       json.RawMessage
+			createdAt     time.Time
+			rowAttempts   int
+		)
+		if err := rows.Scan(&id, &messageType, &schemaVersion, &payload, &createdAt, &rowAttempts); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		messages = append(messages, eventbus.NewMessage(id, messageType, schemaVersion, createdAt, payload))
+		attempts = max(attempts, rowAttempts)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if publishErr := w.bus.Publish(ctx, messages); publishErr != nil {
+		if _, err := tx.Exec(ctx, `
+			UPDATE event_outbox
+			SET attempts = attempts + 1,
+				available_at = NOW() + ($2::bigint * INTERVAL '1 millisecond'),
+				last_error = $3
+			WHERE id = ANY($1)
+		`, ids, eventOutboxRetryDelay(attempts+1).Milliseconds(), common.TruncateRunes(publishErr.Error(), 500)); err != nil {
+			return err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+		return publishErr
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE event_outbox
+		SET published_at = NOW(), last_error = NULL
+		WHERE id = ANY($1)
+	`, ids); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.logger.Info("event_outbox_relayed", observability.Fields{
+		"messages": len(messages),
+		"first_id": ids[0],
+		"last_id":  ids[len(ids)-1],
+	})
+	return nil
+}
+
+// pruneEventOutbox deletes rows published more than eventOutboxKeep ago.
+// Pending rows are kept however old they are.
+func (w *Worker) pruneEventOutbox(ctx context.Context) error {
+	_, err := w.db.Exec(ctx, `
+		DELETE FROM event_outbox
+		WHERE ctid IN (
+			SELECT ctid
+			FROM event_outbox
+			WHERE published_at IS NOT NULL
+			  AND published_at < NOW() - ($1::bigint * INTERVAL '1 second')
+			LIMIT $2
+		)
+	`, int64(eventOutboxKeep.Seconds()), eventOutboxPruneBatch)
+	return err
+}
+
+// eventOutboxRetryDelay doubles from eventOutboxRetryBase with each failed
+// attempt, up to eventOutboxRetryMax.
+func eventOutboxRetryDelay(attempt int) time.Duration {
+	delay := eventOutboxRetryBase
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= eventOutboxRetryMax {
+			return eventOutboxRetryMax
+		}
+	}
+	return delay
+}
@@ -0,0 +1,21 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventOutboxRetryDelayDoublesUpToMax(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		4:  40 * time.Second,
+		8:  eventOutboxRetryMax,
+		40: eventOutboxRetryMax,
+	}
+	for attempt, want := range cases {
+		if got := eventOutboxRetryDelay(attempt); got != want {
+			t.Fatalf("attempt %d: got %s, want %s", attempt, got, want)
+		}
+	}
+}
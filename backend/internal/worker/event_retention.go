@@ -0,0 +1,38 @@
+package worker
+
+import "context"
+
+const eventRetentionPruneBatch = 1000
+
+// pruneRetainedEvents deletes raw analytics events and persona activity
+// events older than their owner's event_retention_days. Users without a
+// preference keep everything. Digests, counters and reports already built
+// from the events are unaffected.
+func (w *Worker) pruneRetainedEvents(ctx context.Context) error {
+	if _, err := w.db.Exec(ctx, `
+		DELETE FROM events
+		WHERE ctid IN (
+			SELECT e.ctid
+			FROM users u
+			JOIN events e ON e.user_id = u.id
+			WHERE u.event_retention_days IS NOT NULL
+			  AND e.created_at < NOW() - make_interval(days => u.event_retention_days)
+			LIMIT $1
+		)
+	`, eventRetentionPruneBatch); err != nil {
+		return err
+	}
+	_, err := w.db.Exec(ctx, `
+		DELETE FROM persona_activity_events
+		WHERE ctid IN (
+			SELECT a.ctid
+			FROM users u
+			JOIN personas p ON p.user_id = u.id
+			JOIN persona_activity_events a ON a.persona_id = p.id
+			WHERE u.event_retention_days IS NOT NULL
+			  AND a.created_at < NOW() - make_interval(days => u.event_retention_days)
+			LIMIT $1
+		)
+	`, eventRetentionPruneBatch)
+	return err
+}
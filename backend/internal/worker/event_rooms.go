@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const eventRecapTopPosts = 5
+
+type eventRecapStats struct {
+	Posts   int
+	Battles int
+	Replies int
+}
+
+// closeOneEventRoom closes the event room whose window ended first: it
+// writes the recap digest and archives the room so it drops out of room
+// listings while its posts stay readable.
+func (w *Worker) closeOneEventRoom(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var roomID, roomName, roomDescription string
+	err = tx.QueryRow(ctx, `
+		SELECT id::text, name, description
+		FROM rooms
+		WHERE event_ends_at <= NOW()
+		  AND event_closed_at IS NULL
+		ORDER BY event_ends_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`).Scan(&roomID, &roomName, &roomDescription)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var stats eventRecapStats
+	if err := tx.QueryRow(ctx, `
+		SELECT
+			COUNT(*)::int,
+			COUNT(*) FILTER (WHERE p.template_id IS NOT NULL)::int,
+			COALESCE(SUM((SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id)), 0)::int
+		FROM posts p
+		WHERE p.room_id = $1
+		  AND p.status = 'PUBLISHED'
+	`, roomID).Scan(&stats.Posts, &stats.Battles, &stats.Replies); err != nil {
+		return err
+	}
+
+	highlights, err := listEventHighlights(ctx, tx, roomID, eventRecapTopPosts)
+	if err != nil {
+		return err
+	}
+	recap := w.summarizeEvent(ctx, roomID, roomName, roomDescription, stats, highlights)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE rooms
+		SET event_recap = $2,
+			event_closed_at = NOW(),
+			archived_at = COALESCE(archived_at, NOW())
+		WHERE id = $1
+	`, roomID, recap); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("event_room_closed", observability.Fields{
+		"room_id": roomID,
+		"posts":   stats.Posts,
+		"battles": stats.Battles,
+		"replies": stats.Replies,
+	})
+	return nil
+}
+
+// listEventHighlights returns the event's most reacted and replied posts,
+// the material the recap summarizes.
+func listEventHighlights(ctx context.Context, tx pgx.Tx, roomID string, limit int) ([]ai.ReplyContext, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT p.id::text, p.content
+		FROM posts p
+		WHERE p.room_id = $1
+		  AND p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		ORDER BY
+			(SELECT COUNT(*) FROM post_reactions pr WHERE pr.post_id = p.id)
+			+ (SELECT COUNT(*) FROM replies r WHERE r.post_id = p.id) DESC,
+			p.created_at ASC
+		LIMIT $2
+	`, roomID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	highlights := make([]ai.ReplyContext, 0, limit)
+	for rows.Next() {
+		var item ai.ReplyContext
+		if err := rows.Scan(&item.ID, &item.Content); err != nil {
+			return nil, err
+		}
+		highlights = append(highlights, item)
+	}
+	return highlights, rows.Err()
+}
+
+// summarizeEvent leads with the event's numbers and follows with an LLM
+// summary of its highlights when one can be had.
+func (w *Worker) summarizeEvent(ctx context.Context, roomID, name, description string, stats eventRecapStats, highlights []ai.ReplyContext) string {
+	recap := eventRecapHeadline(name, stats)
+	if len(highlights) == 0 {
+		return recap
+	}
+
+	topic := strings.TrimSpace(name)
+	if description = strings.TrimSpace(description); description != "" {
+		topic += ": " + description
+	}
+	summary, err := w.llm.SummarizeThread(ctx, ai.PostContext{ID: roomID, Content: topic}, highlights)
+	if err != nil {
+		w.logger.Warn("event_recap_summary_failed", observability.Fields{
+			"room_id": roomID,
+			"error":   err.Error(),
+		})
+		return recap
+	}
+	if summary = normalizeWeeklyOneSentence(summary, 400); summary != "" {
+		recap += " " + summary
+	}
+	return recap
+}
+
+func eventRecapHeadline(name string, stats eventRecapStats) string {
+	label := strings.TrimSpace(name)
+	if label == "" {
+		label = "The event"
+	}
+	if stats.Posts == 0 {
+		return fmt.Sprintf("%s has ended without any posts.", label)
+	}
+	return fmt.Sprintf("%s has ended with %s, %s and %s.", label,
+		pluralize(stats.Posts, "post"),
+		pluralize(stats.Battles, "battle"),
+		pluralize(stats.Replies, "reply"))
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", count, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
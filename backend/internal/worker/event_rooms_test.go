@@ -0,0 +1,16 @@
+package worker
+
+import "testing"
+
+func TestEventRecapHeadline(t *testing.T) {
+	if got := eventRecapHeadline("Launch Week", eventRecapStats{}); got != "Launch Week has ended without any posts." {
+		t.Fatalf("unexpected empty recap %q", got)
+	}
+	got := eventRecapHeadline("Launch Week", eventRecapStats{Posts: 12, Battles: 1, Replies: 30})
+	if got != "Launch Week has ended with 12 posts, 1 battle and 30 replies." {
+		t.Fatalf("unexpected recap %q", got)
+	}
+	if got := eventRecapHeadline(" ", eventRecapStats{Posts: 1, Replies: 1}); got != "The event has ended with 1 post, 0 battles and 1 reply." {
+		t.Fatalf("unexpected unnamed recap %q", got)
+	}
+}
@@ -0,0 +1,235 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	feedAffinityWindowDays   = 30
+	feedAffinityMaxPerKind   = 10
+	feedAffinityViewWeight   = 0.5
+	feedAffinityRefreshHours = 6
+)
+
+type feedAffinitySignal struct {
+	Kind     string
+	TargetID string
+	Source   string
+	Count    int
+}
+
+type feedAffinity struct {
+	Kind     string
+	TargetID string
+	Weight   float64
+	Reason   string
+}
+
+// refreshFeedAffinityForOneUser rebuilds the affinity vector of the user whose
+// vector is oldest. The API only reads these rows, so feed requests never pay
+// for the event scans below.
+func (w *Worker) refreshFeedAffinityForOneUser(ctx context.Context) error {
+	var userID string
+	err := w.db.QueryRow(ctx, `
+		SELECT u.id::text
+		FROM users u
+		LEFT JOIN user_feed_affinity_runs ar ON ar.user_id = u.id
+		WHERE ar.user_id IS NULL
+		   OR ar.computed_at <= NOW() - ($1::int * INTERVAL '1 hour')
+		ORDER BY COALESCE(ar.computed_at, TO_TIMESTAMP(0)) ASC, u.created_at ASC
+		LIMIT 1
+	`, feedAffinityRefreshHours).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	signals, err := w.collectFeedAffinitySignals(ctx, userID)
+	if err != nil {
+		return err
+	}
+	affinities := buildFeedAffinities(signals)
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_feed_affinities WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, affinity := range affinities {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_feed_affinities(user_id, kind, target_id, weight, reason)
+			VALUES ($1, $2, $3, $4, $5)
+		`, userID, affinity.Kind, affinity.TargetID, affinity.Weight, affinity.Reason); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO user_feed_affinity_runs(user_id, computed_at)
+		VALUES ($1, NOW())
+		ON CONFLICT (user_id)
+		DO UPDATE SET computed_at = EXCLUDED.computed_at
+	`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (w *Worker) collectFeedAffinitySignals(ctx context.Context, userID string) ([]feedAffinitySignal, error) {
+	rows, err := w.db.Query(ctx, `
+		WITH authored AS (
+			SELECT p.room_id, p.template_id
+			FROM posts p
+			WHERE p.user_id = $1
+			  AND p.created_at >= NOW() - ($2::int * INTERVAL '1 day')
+		),
+		viewed AS (
+			SELECT b.room_id, b.template_id, b.persona_id
+			FROM events e
+			JOIN posts b ON b.id::text = e.metadata->>'battle_id'
+			WHERE e.user_id = $1
+			  AND e.event_name = 'public_battle_viewed'
+			  AND e.created_at >= NOW() - ($2::int * INTERVAL '1 day')
+			  AND b.user_id <> $1
+		)
+		SELECT 'room', room_id::text, 'post', COUNT(*)::int
+		FROM authored
+		GROUP BY room_id
+		UNION ALL
+		SELECT 'template', template_id::text, 'post', COUNT(*)::int
+		FROM authored
+		WHERE template_id IS NOT NULL
+		GROUP BY template_id
+		UNION ALL
+		SELECT 'room', room_id::text, 'view', COUNT(*)::int
+		FROM viewed
+		GROUP BY room_id
+		UNION ALL
+		SELECT 'template', template_id::text, 'view', COUNT(*)::int
+		FROM viewed
+		WHERE template_id IS NOT NULL
+		GROUP BY template_id
+		UNION ALL
+		SELECT 'persona', persona_id::text, 'view', COUNT(*)::int
+		FROM viewed
+		WHERE persona_id IS NOT NULL
+		GROUP BY persona_id
+	`, userID, feedAffinityWindowDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals := make([]feedAffinitySignal, 0)
+	for rows.Next() {
+		var signal feedAffinitySignal
+		if err := rows.Scan(&signal.Kind, &signal.TargetID, &signal.Source, &signal.Count); err != nil {
+			return nil, err
+		}
+		signals = append(signals, signal)
+	}
+	return signals, rows.Err()
+}
+
+// buildFeedAffinities folds raw signals into one weight per target, normalized
+// to (0, 1] within each kind. Authoring counts fully, viewing counts half, and
+// the reason label names the strongest signal so the feed can explain itself.
+func buildFeedAffinities(signals []feedAffinitySignal) []feedAffinity {
+	type accumulator struct {
+		score     float64
+		postScore float64
+	}
+	byKind := map[string]map[string]*accumulator{}
+	for _, signal := range signals {
+		if signal.Count <= 0 || signal.TargetID == "" {
+			continue
+		}
+		targets, ok := byKind[signal.Kind]
+		if !ok {
+			targets = map[string]*accumulator{}
+			byKind[signal.Kind] = targets
+		}
+		acc, ok := targets[signal.TargetID]
+		if !ok {
+			acc = &accumulator{}
+			targets[signal.TargetID] = acc
+		}
+		if signal.Source == "post" {
+			acc.score += float64(signal.Count)
+			acc.postScore += float64(signal.Count)
+		} else {
+			acc.score += float64(signal.Count) * feedAffinityViewWeight
+		}
+	}
+
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	out := make([]feedAffinity, 0)
+	for _, kind := range kinds {
+		targets := byKind[kind]
+		ranked := make([]feedAffinity, 0, len(targets))
+		maxScore := 0.0
+		for _, acc := range targets {
+			if acc.score > maxScore {
+				maxScore = acc.score
+			}
+		}
+		for targetID, acc := range targets {
+			ranked = append(ranked, feedAffinity{
+				Kind:     kind,
+				TargetID: targetID,
+				Weight:   roundAffinityWeight(acc.score / maxScore),
+				Reason:   feedAffinityReason(kind, targetID, acc.postScore > 0),
+			})
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if ranked[i].Weight == ranked[j].Weight {
+				return ranked[i].TargetID < ranked[j].TargetID
+			}
+			return ranked[i].Weight > ranked[j].Weight
+		})
+		if len(ranked) > feedAffinityMaxPerKind {
+			ranked = ranked[:feedAffinityMaxPerKind]
+		}
+		out = append(out, ranked...)
+	}
+	return out
+}
+
+func roundAffinityWeight(value float64) float64 {
+	rounded := roundWeeklyScore(value)
+	if rounded < 0.01 {
+		return 0.01
+	}
+	return rounded
+}
+
+func feedAffinityReason(kind, targetID string, authored bool) string {
+	switch kind {
+	case "room":
+		if authored {
+			return "because_you_post_in_room_" + targetID
+		}
+		return "because_you_viewed_room_" + targetID
+	case "template":
+		if authored {
+			return "because_you_use_template_" + targetID
+		}
+		return "because_you_viewed_template_" + targetID
+	default:
+		return "because_you_viewed_persona_" + targetID
+	}
+}
@@ -0,0 +1,30 @@
+package worker
+
+import "testing"
+
+func TestBuildFeedAffinitiesNormalizesAndLabels(t *testing.T) {
+	affinities := buildFeedAffinities([]feedAffinitySignal{
+		{Kind: "room", TargetID: "room-a", Source: "post", Count: 4},
+		{Kind: "room", TargetID: "room-b", Source: "view", Count: 4},
+		{Kind: "template", TargetID: "tpl-1", Source: "view", Count: 2},
+		{Kind: "template", TargetID: "tpl-1", Source: "post", Count: 1},
+		{Kind: "persona", TargetID: "persona-1", Source: "view", Count: 0},
+	})
+
+	byTarget := map[string]feedAffinity{}
+	for _, affinity := range affinities {
+		byTarget[affinity.TargetID] = affinity
+	}
+	if len(byTarget) != 3 {
+		t.Fatalf("expected 3 affinities (zero-count signals dropped), got %v", affinities)
+	}
+	if got := byTarget["room-a"]; got.Weight != 1 || got.Reason != "because_you_post_in_room_room-a" {
+		t.Fatalf("unexpected authored room affinity: %+v", got)
+	}
+	if got := byTarget["room-b"]; got.Weight != 0.5 || got.Reason != "because_you_viewed_room_room-b" {
+		t.Fatalf("expected viewed room at half weight, got %+v", got)
+	}
+	if got := byTarget["tpl-1"]; got.Weight != 1 || got.Reason != "because_you_use_template_tpl-1" {
+		t.Fatalf("expected used template label to win over views, got %+v", got)
+	}
+}
@@ -0,0 +1,282 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// executeInteractiveBattleTurn writes the AI persona's next turn in an
+// interactive battle and hands the turn to the owner. The reply is generated
+// outside the transaction; the battle row is then locked and re-checked so a
+// duplicate job cannot write the same turn twice.
+func (w *Worker) executeInteractiveBattleTurn(ctx context.Context, postID, personaID string, attempt int) error {
+	battle, err := common.LoadInteractiveBattle(ctx, w.db, postID, false)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "interactive battle not found"}
+		}
+		return err
+	}
+	if !battle.AwaitingAI() || battle.AIPersonaID != personaID {
+		return nil
+	}
+
+	var persona struct {
+		UserID   string
+		Name     string
+		Bio      string
+		Tone     string
+		Style    ai.PersonaStyle
+		Language string
+	}
+	err = w.db.QueryRow(ctx, `
+		SELECT user_id::text, name, bio, tone, style_humor, style_assertiveness, style_technicality, style_brevity, preferred_language
+		FROM personas
+		WHERE id = $1
+	`, personaID).Scan(
+		&persona.UserID,
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&persona.Style.Humor,
+		&persona.Style.Assertiveness,
+		&persona.Style.Technicality,
+		&persona.Style.Brevity,
+		&persona.Language,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "persona not found"}
+		}
+		return err
+	}
+
+	var postContent, roomID string
+	var battleLanguage common.BattleLanguage
+	if err := w.db.QueryRow(ctx, `
+		SELECT content, room_id::text, battle_language, COALESCE(battle_language_code, '')
+		FROM posts
+		WHERE id = $1
+	`, postID).Scan(&postContent, &roomID, &battleLanguage.Mode, &battleLanguage.Language); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "post not found"}
+		}
+		return err
+	}
+
+	rows, err := w.db.Query(ctx, `
+		SELECT id::text, COALESCE(persona_id::text, ''), content, authored_by = 'HUMAN'
+		FROM replies
+		WHERE post_id = $1
+		ORDER BY created_at ASC
+	`, postID)
+	if err != nil {
+		return err
+	}
+	thread := make([]ai.ReplyContext, 0, battle.TurnsTaken)
+	for rows.Next() {
+		var reply ai.ReplyContext
+		if err := rows.Scan(&reply.ID, &reply.PersonaID, &reply.Content, &reply.Human); err != nil {
+			rows.Close()
+			return err
+		}
+		thread = append(thread, reply)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	promptVersion := w.llm.Prompts().Active(prompts.OpReply)
+	turnLanguage := battleLanguage.TurnLanguage(persona.Language)
+	personaCtx := ai.PersonaContext{
+		ID:                personaID,
+		Name:              persona.Name,
+		Bio:               persona.Bio,
+		Tone:              persona.Tone,
+		PreferredLanguage: turnLanguage,
+		Style:             persona.Style,
+	}
+	roomHint, err := common.LoadRoomPromptHint(ctx, w.db, roomID)
+	if err != nil {
+		return err
+	}
+	contextPack, err := common.LoadBattleContextPack(ctx, w.db, postID)
+	if err != nil {
+		return err
+	}
+	post := ai.PostContext{
+		ID:          postID,
+		Content:     postContent,
+		RoomHint:    ai.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure},
+		ContextPack: contextPack,
+	}
+	generationStartedAt := time.Now().UTC()
+	generated, err := w.llm.GenerateReply(ctx, personaCtx, post, thread)
+	if err != nil {
+		return err
+	}
+	generated = w.regenerateRepetitiveTurn(ctx, personaCtx, post, thread, generated)
+	generationElapsed := time.Since(generationStartedAt)
+
+	turn := common.SafetyRejection{
+		UserID:    persona.UserID,
+		PersonaID: personaID,
+		RoomID:    roomID,
+		PostID:    postID,
+		Source:    common.SafetySourceReply,
+		Language:  turnSafetyLanguage(turnLanguage, persona.Language),
+		Content:   generated,
+		MaxLen:    w.cfg.ReplyMaxLen,
+		Metadata:  map[string]any{"prompt_version": promptVersion, "interactive_turn": battle.TurnsTaken + 1},
+	}
+	templatePolicy, err := common.LoadBattleTemplatePolicy(ctx, w.db, postID)
+	if err != nil {
+		return err
+	}
+	for key, value := range templatePolicy.Metadata() {
+		turn.Metadata[key] = value
+	}
+	if err := w.checkBattleTurn(ctx, turn, templatePolicy.Policy); err != nil {
+		return permanentError{message: err.Error()}
+	}
+	replyMetadata, err := w.renderBilingualTurn(ctx, battleLanguage, turnLanguage, turn)
+	if err != nil {
+		return err
+	}
+	replyMetadata, err = common.WithTurnTiming(replyMetadata, generationStartedAt, generationElapsed)
+	if err != nil {
+		return err
+	}
+	usage := currentTurnUsage(ctx, attempt)
+	replyMetadata, err = common.WithTurnUsage(replyMetadata, usage)
+	if err != nil {
+		return err
+	}
+	if hintMetadata := roomHint.Metadata(); hintMetadata != nil {
+		replyMetadata, err = common.MergeTurnMetadata(replyMetadata, hintMetadata)
+		if err != nil {
+			return err
+		}
+	}
+	if policyMetadata := templatePolicy.Metadata(); policyMetadata != nil {
+		replyMetadata, err = common.MergeTurnMetadata(replyMetadata, policyMetadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := common.LoadInteractiveBattle(ctx, tx, postID, true)
+	if err != nil {
+		return err
+	}
+	if !locked.AwaitingAI() || locked.TurnsTaken != battle.TurnsTaken {
+		return nil
+	}
+
+	var replyID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO replies(post_id, persona_id, authored_by, content, prompt_version, metadata)
+		VALUES ($1, $2, 'AI', $3, NULLIF($4, ''), $5::jsonb)
+		RETURNING id::text
+	`, postID, personaID, generated, promptVersion, replyMetadata).Scan(&replyID); err != nil {
+		return err
+	}
+	if err := common.AddBattleUsage(ctx, tx, postID, usage, false); err != nil {
+		return err
+	}
+
+	next := locked.AfterTurn(time.Now().UTC(), w.cfg.InteractiveTurnTimeout)
+	if err := common.SaveInteractiveBattle(ctx, tx, next); err != nil {
+		return err
+	}
+
+	if err := common.InsertPersonaActivityEvent(ctx, tx, personaID, "thread_participated", map[string]any{
+		"post_id":       postID,
+		"room_id":       roomID,
+		"post_preview":  common.TruncateRunes(postContent, 200),
+		"reply_preview": common.TruncateRunes(generated, 200),
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.recordMentions(ctx, common.MentionSource{
+		PostID:          postID,
+		ReplyID:         replyID,
+		AuthorUserID:    persona.UserID,
+		AuthorPersonaID: personaID,
+		Content:         generated,
+	})
+	w.notifyBattleWatchersOfTurn(ctx, postID, persona.UserID, persona.Name)
+
+	fields := observability.Fields{
+		"post_id":     postID,
+		"persona_id":  personaID,
+		"turns_taken": next.TurnsTaken,
+		"total_turns": next.TotalTurns,
+		"status":      next.Status,
+	}
+	if next.TurnDeadline != nil {
+		fields["turn_deadline"] = next.TurnDeadline.Format(time.RFC3339)
+	}
+	w.logger.Info("interactive_battle_ai_turn", fields)
+	return nil
+}
+
+// failInteractiveBattle ends a battle whose AI turn can no longer be written,
+// so the owner is not left waiting on a turn that will never come.
+func (w *Worker) failInteractiveBattle(ctx context.Context, postID string, cause error) {
+	if _, err := w.db.Exec(ctx, `
+		UPDATE interactive_battles
+		SET status = $2, turn_deadline = NULL, updated_at = NOW()
+		WHERE post_id = $1
+		  AND status = $3
+		  AND next_side = $4
+	`, postID, common.InteractiveStatusFailed, common.InteractiveStatusActive, common.InteractiveSideAI); err != nil {
+		w.logger.Error("interactive_battle_fail_write_failed", observability.Fields{
+			"post_id": postID,
+			"error":   err.Error(),
+		})
+		return
+	}
+	w.logger.Warn("interactive_battle_failed", observability.Fields{
+		"post_id": postID,
+		"error":   truncateJobError(cause.Error(), 200),
+	})
+}
+
+// expireInteractiveBattleTurns ends battles whose owner missed the deadline
+// for their turn. The turns written so far stay and are judged as usual.
+func (w *Worker) expireInteractiveBattleTurns(ctx context.Context) error {
+	ct, err := w.db.Exec(ctx, `
+		UPDATE interactive_battles
+		SET status = $1, turn_deadline = NULL, updated_at = NOW()
+		WHERE status = $2
+		  AND next_side = $3
+		  AND turn_deadline <= NOW()
+	`, common.InteractiveStatusExpired, common.InteractiveStatusActive, common.InteractiveSideHuman)
+	if err != nil {
+		return err
+	}
+	if expired := ct.RowsAffected(); expired > 0 {
+		w.logger.Info("interactive_battle_turns_expired", observability.Fields{"count": expired})
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/social"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// retryClass groups job failures that call for the same retry policy.
+type retryClass string
+
+const (
+	retryClassLLM       retryClass = "llm_transient"
+	retryClassDB        retryClass = "db_transient"
+	retryClassUpstream  retryClass = "upstream_transient"
+	retryClassPermanent retryClass = "permanent"
+	retryClassOther     retryClass = "other"
+)
+
+// Unclassified errors are usually bugs or bad data that fail the same way
+// every time, so they get a few attempts rather than the whole budget.
+const otherRetryAttempts = 3
+
+// retryPolicy is the backoff curve and attempt budget of one retry class.
+type retryPolicy struct {
+	base     time.Duration
+	max      time.Duration
+	attempts int
+}
+
+// classifyJobError decides how a failed job is retried. Database errors are
+// checked first so a dropped Postgres connection is not mistaken for the LLM
+// provider being unreachable.
+func classifyJobError(err error) retryClass {
+	if err == nil {
+		return retryClassOther
+	}
+	var permanent permanentError
+	if errors.As(err, &permanent) {
+		return retryClassPermanent
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case strings.HasPrefix(pgErr.Code, "08"), // connection exception
+			strings.HasPrefix(pgErr.Code, "53"), // insufficient resources
+			pgErr.Code == "40001",               // serialization failure
+			pgErr.Code == "40P01",               // deadlock detected
+			pgErr.Code == "55P03",               // lock not available
+			pgErr.Code == "57014",               // query canceled (statement timeout)
+			pgErr.Code == "57P01",               // admin shutdown
+			pgErr.Code == "57P03":               // cannot connect now
+			return retryClassDB
+		case strings.HasPrefix(pgErr.Code, "22"), // data exception
+			strings.HasPrefix(pgErr.Code, "23"): // integrity constraint violation
+			return retryClassPermanent
+		}
+		return retryClassOther
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.Timeout(err) {
+		return retryClassDB
+	}
+
+	var providerErr *ai.ProviderError
+	if errors.As(err, &providerErr) {
+		if providerErr.Overloaded() || providerErr.StatusCode == http.StatusRequestTimeout {
+			return retryClassLLM
+		}
+		if providerErr.StatusCode == http.StatusBadRequest || providerErr.StatusCode == http.StatusUnprocessableEntity {
+			return retryClassPermanent
+		}
+		return retryClassOther
+	}
+	var socialErr *social.APIError
+	if errors.As(err, &socialErr) && socialErr.Temporary() {
+		return retryClassUpstream
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return retryClassLLM
+	}
+	return retryClassOther
+}
+
+// retryPolicyFor returns the policy of class. LLM and social network failures
+// back off on the JOB_RETRY_* curve with the full attempt budget; database
+// blips are retried quickly since they usually clear within seconds.
+func (w *Worker) retryPolicyFor(class retryClass) retryPolicy {
+	maxAttempts := maxJobAttempts(w.cfg.JobMaxAttempts)
+	switch class {
+	case retryClassPermanent:
+		return retryPolicy{}
+	case retryClassDB:
+		return retryPolicy{base: 2 * time.Second, max: time.Minute, attempts: maxAttempts}
+	case retryClassLLM, retryClassUpstream:
+		return retryPolicy{base: w.cfg.JobRetryBase, max: w.cfg.JobRetryMax, attempts: maxAttempts}
+	default:
+		return retryPolicy{base: w.cfg.JobRetryBase, max: w.cfg.JobRetryMax, attempts: min(otherRetryAttempts, maxAttempts)}
+	}
+}
+
+// givesUp reports whether a job that has now failed attempt times is out of
+// retries under p.
+func (p retryPolicy) givesUp(attempt int) bool {
+	return attempt >= p.attempts
+}
+
+// backoff is the wait before retry number attempt.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	return retryBackoff(p.base, p.max, attempt)
+}
+
+// retryBackoff doubles base per attempt up to maxDelay and keeps a random
+// point in the upper half of that delay, so jobs that failed together on a
+// flapping dependency do not all come back at the same moment.
+func retryBackoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = 30 * time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Minute
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	jittered := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	if jittered < time.Second {
+		return time.Second
+	}
+	return jittered
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/social"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyJobError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want retryClass
+	}{
+		{"permanent", permanentError{message: "invalid payload"}, retryClassPermanent},
+		{"wrapped permanent", fmt.Errorf("reply: %w", permanentError{message: "gone"}), retryClassPermanent},
+		{"rate limited", &ai.ProviderError{Provider: "openai", StatusCode: 429}, retryClassLLM},
+		{"provider 503", fmt.Errorf("generate: %w", &ai.ProviderError{Provider: "openai", StatusCode: 503}), retryClassLLM},
+		{"provider 400", &ai.ProviderError{Provider: "openai", StatusCode: 400}, retryClassPermanent},
+		{"provider 401", &ai.ProviderError{Provider: "openai", StatusCode: 401}, retryClassOther},
+		{"timeout", context.DeadlineExceeded, retryClassLLM},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, retryClassDB},
+		{"connection lost", &pgconn.PgError{Code: "08006"}, retryClassDB},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, retryClassPermanent},
+		{"undefined column", &pgconn.PgError{Code: "42703"}, retryClassOther},
+		{"social 502", &social.APIError{Provider: "x", Status: 502}, retryClassUpstream},
+		{"plain", errors.New("boom"), retryClassOther},
+	}
+	for _, tc := range cases {
+		if got := classifyJobError(tc.err); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestRetryPolicyBudgets(t *testing.T) {
+	w := &Worker{cfg: config.Config{JobMaxAttempts: 5, JobRetryBase: 30 * time.Second, JobRetryMax: 10 * time.Minute}}
+
+	if !w.retryPolicyFor(retryClassPermanent).givesUp(1) {
+		t.Fatalf("expected permanent failures to give up right away")
+	}
+	other := w.retryPolicyFor(retryClassOther)
+	if other.givesUp(2) || !other.givesUp(3) {
+		t.Fatalf("expected unclassified failures to get %d attempts", otherRetryAttempts)
+	}
+	llm := w.retryPolicyFor(retryClassLLM)
+	if llm.givesUp(4) || !llm.givesUp(5) {
+		t.Fatalf("expected LLM failures to get the full budget")
+	}
+	if !w.jobGivesUp(errors.New("boom"), 2) || w.jobGivesUp(&ai.ProviderError{StatusCode: 429}, 2) {
+		t.Fatalf("expected jobGivesUp to follow the failure's class")
+	}
+
+	db := w.retryPolicyFor(retryClassDB)
+	if got := db.backoff(10); got > time.Minute {
+		t.Fatalf("expected database retries capped at 1m, got %s", got)
+	}
+}
+
+func TestRetryBackoffJitter(t *testing.T) {
+	for attempt := 1; attempt <= 8; attempt++ {
+		ceiling := min(30*time.Second<<(attempt-1), 10*time.Minute)
+		seen := map[time.Duration]bool{}
+		for i := 0; i < 50; i++ {
+			got := retryBackoff(30*time.Second, 10*time.Minute, attempt)
+			if got < ceiling/2 || got > ceiling {
+				t.Fatalf("attempt %d: backoff %s outside [%s, %s]", attempt, got, ceiling/2, ceiling)
+			}
+			seen[got] = true
+		}
+		if len(seen) < 2 {
+			t.Fatalf("attempt %d: expected jittered delays, got %v", attempt, seen)
+		}
+	}
+	if got := retryBackoff(time.Millisecond, time.Millisecond, 1); got != time.Second {
+		t.Fatalf("expected a 1s floor, got %s", got)
+	}
+	if got := retryBackoff(time.Hour, 2*time.Hour, 100); got > 2*time.Hour {
+		t.Fatalf("expected a large attempt to stay capped, got %s", got)
+	}
+}
+
+func TestWorkerMetricsRenderJobFailures(t *testing.T) {
+	metrics := observability.NewWorkerMetrics()
+	metrics.IncJobFailure("generate_reply", string(retryClassLLM), "retry")
+	metrics.IncJobFailure("generate_reply", string(retryClassLLM), "retry")
+	metrics.IncJobFailure("generate_reply", string(retryClassPermanent), "gave_up")
+
+	rendered := metrics.Render()
+	for _, want := range []string{
+		`job_failures_total{class="llm_transient",outcome="retry",type="generate_reply"} 2`,
+		`job_failures_total{class="permanent",outcome="gave_up",type="generate_reply"} 1`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Fatalf("expected %q in metrics:\n%s", want, rendered)
+		}
+	}
+}
@@ -5,19 +5,39 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
 	"strings"
 	"time"
 
 	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
 	"personaworlds/backend/internal/common"
 	"personaworlds/backend/internal/observability"
-	"personaworlds/backend/internal/safety"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// claimJobQuery picks the oldest runnable job for this worker's shard. Shard
+// membership is recomputed from the persona owner on every claim, so changing
+// WORKER_SHARD_COUNT needs no data migration; while instances disagree about
+// the count, row locks and SKIP LOCKED still keep a job on a single worker, and
+// jobs nobody claims are picked up by any shard once they are older than
+// WORKER_SHARD_STEAL_AFTER.
+// Job types in $5 are skipped; jobGate lists those held back by backpressure.
+var claimJobQuery = fmt.Sprintf(`
+	SELECT j.id, j.job_type, COALESCE(j.post_id::text, ''), j.persona_id::text, j.payload, j.attempts, %[1]s AS owned
+	FROM jobs j
+	JOIN personas pe ON pe.id = j.persona_id
+	WHERE j.status IN ('PENDING', 'FAILED')
+	  AND j.attempts < $1
+	  AND j.available_at <= NOW()
+	  AND j.job_type <> ALL($5::text[])
+	  AND (%[1]s OR j.available_at <= NOW() - make_interval(secs => $4::double precision))
+	ORDER BY owned DESC, j.created_at ASC
+	LIMIT 1
+	FOR UPDATE OF j SKIP LOCKED
+`, shardOwnedSQL("pe.user_id", "$2", "$3"))
+
 func (w *Worker) processOne(ctx context.Context) error {
 	tx, err := w.db.Begin(ctx)
 	if err != nil {
@@ -32,20 +52,17 @@ func (w *Worker) processOne(ctx context.Context) error {
 		personaID  string
 		payloadRaw []byte
 		attempts   int
+		owned      bool
 	)
 
-	selectStartedAt := time.Now()
-	err = tx.QueryRow(ctx, `
-		SELECT id, job_type, post_id::text, persona_id::text, payload, attempts
-		FROM jobs
-		WHERE status IN ('PENDING', 'FAILED')
-		  AND attempts < $1
-		  AND available_at <= NOW()
-		ORDER BY created_at ASC
-		LIMIT 1
-		FOR UPDATE SKIP LOCKED
-	`, maxJobAttempts(w.cfg.JobMaxAttempts)).Scan(&jobID, &jobType, &postID, &personaID, &payloadRaw, &attempts)
-	w.metrics.ObserveDBQuery(time.Since(selectStartedAt))
+	release, err := w.jobGate.claim(func(blocked []string) (string, error) {
+		selectStartedAt := time.Now()
+		err := tx.QueryRow(ctx, claimJobQuery, maxJobAttempts(w.cfg.JobMaxAttempts), w.shard.count, w.shard.index, w.shard.stealAfter.Seconds(), blocked).
+			Scan(&jobID, &jobType, &postID, &personaID, &payloadRaw, &attempts, &owned)
+		w.metrics.ObserveDBQuery(time.Since(selectStartedAt))
+		return jobType, err
+	})
+	defer release()
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil
@@ -53,6 +70,74 @@ func (w *Worker) processOne(ctx context.Context) error {
 		return err
 	}
 	traceID := extractTraceID(payloadRaw)
+	claimSource := "own"
+	if !owned {
+		claimSource = "stolen"
+	}
+	w.metrics.IncJobClaimed(w.shard.label(), claimSource)
+
+	if jobType == "generate_reply" {
+		deferred, err := w.deferOutsideActiveHours(ctx, tx, jobID, personaID, postID)
+		if err != nil {
+			return err
+		}
+		if !deferred.IsZero() {
+			if err := tx.Commit(ctx); err != nil {
+				return err
+			}
+			w.logger.Info("job_deferred", observability.Fields{
+				"job_id":       jobID,
+				"job_type":     strings.TrimSpace(jobType),
+				"available_at": deferred.UTC().Format(time.RFC3339),
+				"reason":       "persona_inactive_hours",
+				"trace_id":     traceID,
+				"request_id":   traceID,
+			})
+			return nil
+		}
+	}
+
+	if jobType == "generate_reply" || jobType == common.JobInteractiveBattleTurn {
+		deferred, err := w.deferForContextPack(ctx, tx, jobID, postID)
+		if err != nil {
+			return err
+		}
+		if !deferred.IsZero() {
+			if err := tx.Commit(ctx); err != nil {
+				return err
+			}
+			w.logger.Info("job_deferred", observability.Fields{
+				"job_id":       jobID,
+				"job_type":     strings.TrimSpace(jobType),
+				"available_at": deferred.UTC().Format(time.RFC3339),
+				"reason":       "battle_context_pack",
+				"trace_id":     traceID,
+				"request_id":   traceID,
+			})
+			return nil
+		}
+	}
+
+	if jobType == common.JobCreateBattle {
+		deferred, err := w.deferQueuedBattle(ctx, tx, jobID, payloadRaw)
+		if err != nil {
+			return err
+		}
+		if !deferred.IsZero() {
+			if err := tx.Commit(ctx); err != nil {
+				return err
+			}
+			w.logger.Info("job_deferred", observability.Fields{
+				"job_id":       jobID,
+				"job_type":     strings.TrimSpace(jobType),
+				"available_at": deferred.UTC().Format(time.RFC3339),
+				"reason":       "battle_daily_limit",
+				"trace_id":     traceID,
+				"request_id":   traceID,
+			})
+			return nil
+		}
+	}
 
 	lockStartedAt := time.Now()
 	if _, err := tx.Exec(ctx, `
@@ -68,6 +153,7 @@ func (w *Worker) processOne(ctx context.Context) error {
 	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
+	ctx = ai.WithRequestID(ctx, jobRequestID(jobID, traceID))
 	startedAt := time.Now()
 	w.logger.Info("job_started", observability.Fields{
 		"job_id":     jobID,
@@ -76,11 +162,27 @@ func (w *Worker) processOne(ctx context.Context) error {
 		"request_id": traceID,
 	})
 
-	if jobType != "generate_reply" {
+	switch jobType {
+	case "generate_reply":
+		err = w.runBattleTurn(ctx, postID, attempts+1, func(ctx context.Context) error {
+			return w.executeGenerateReply(ctx, postID, personaID, attempts+1)
+		})
+	case common.JobGenerateDraft:
+		err = w.executeGenerateDraft(ctx, jobID, personaID, payloadRaw)
+	case common.JobInteractiveBattleTurn:
+		err = w.runBattleTurn(ctx, postID, attempts+1, func(ctx context.Context) error {
+			return w.executeInteractiveBattleTurn(ctx, postID, personaID, attempts+1)
+		})
+		if err != nil && w.jobGivesUp(err, attempts) {
+			w.failInteractiveBattle(ctx, postID, err)
+		}
+	case common.JobCrosspost:
+		err = w.executeCrosspost(ctx, postID, payloadRaw, attempts)
+	case common.JobCreateBattle:
+		err = w.executeCreateBattle(ctx, payloadRaw)
+	default:
 		return w.markJobFailed(ctx, jobID, jobType, traceID, attempts, permanentError{message: fmt.Sprintf("unsupported job type: %s", jobType)}, time.Since(startedAt))
 	}
-
-	err = w.executeGenerateReply(ctx, postID, personaID)
 	if err == nil {
 		return w.markJobDone(ctx, jobID, jobType, traceID, time.Since(startedAt))
 	}
@@ -88,18 +190,83 @@ func (w *Worker) processOne(ctx context.Context) error {
 	return w.markJobFailed(ctx, jobID, jobType, traceID, attempts, err, time.Since(startedAt))
 }
 
-func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID string) error {
+// deferOutsideActiveHours holds a reply until the persona's active hours.
+// Battle turns, classic or interactive, are exempt: waiting on each side's
+// window would stall a battle for up to a day per turn.
+func (w *Worker) deferOutsideActiveHours(ctx context.Context, tx pgx.Tx, jobID int64, personaID, postID string) (time.Time, error) {
+	var (
+		start    *int
+		end      *int
+		timezone string
+	)
+	queryStartedAt := time.Now()
+	err := tx.QueryRow(ctx, `
+		SELECT pe.active_hours_start, pe.active_hours_end, pe.timezone
+		FROM personas pe
+		WHERE pe.id = $1
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM posts p
+				WHERE p.id = NULLIF($2, '')::uuid
+				  AND (
+						p.template_id IS NOT NULL
+						OR EXISTS (SELECT 1 FROM interactive_battles ib WHERE ib.post_id = p.id)
+				  )
+		  )
+	`, personaID, postID).Scan(&start, &end, &timezone)
+	w.metrics.ObserveDBQuery(time.Since(queryStartedAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	next := common.NextActiveTime(now, start, end, timezone)
+	if !next.After(now) {
+		return time.Time{}, nil
+	}
+
+	queryStartedAt = time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE jobs
+		SET available_at=$2, updated_at=NOW()
+		WHERE id=$1
+	`, jobID, next)
+	w.metrics.ObserveDBQuery(time.Since(queryStartedAt))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next, nil
+}
+
+func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID string, attempt int) error {
 	var persona struct {
+		UserID          string
 		Name            string
 		Bio             string
 		Tone            string
+		Style           ai.PersonaStyle
+		Language        string
 		DailyReplyQuota int
 	}
 	err := w.db.QueryRow(ctx, `
-		SELECT name, bio, tone, daily_reply_quota
+		SELECT user_id::text, name, bio, tone, style_humor, style_assertiveness, style_technicality, style_brevity, preferred_language, daily_reply_quota
 		FROM personas
 		WHERE id = $1
-	`, personaID).Scan(&persona.Name, &persona.Bio, &persona.Tone, &persona.DailyReplyQuota)
+	`, personaID).Scan(
+		&persona.UserID,
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&persona.Style.Humor,
+		&persona.Style.Assertiveness,
+		&persona.Style.Technicality,
+		&persona.Style.Brevity,
+		&persona.Language,
+		&persona.DailyReplyQuota,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return permanentError{message: "persona not found"}
@@ -122,11 +289,12 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 	}
 
 	var postContent, postStatus, roomID string
+	var battleLanguage common.BattleLanguage
 	err = w.db.QueryRow(ctx, `
-		SELECT content, status::text, room_id::text
+		SELECT content, status::text, room_id::text, battle_language, COALESCE(battle_language_code, '')
 		FROM posts
 		WHERE id = $1
-	`, postID).Scan(&postContent, &postStatus, &roomID)
+	`, postID).Scan(&postContent, &postStatus, &roomID, &battleLanguage.Mode, &battleLanguage.Language)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return permanentError{message: "post not found"}
@@ -137,22 +305,27 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 		return permanentError{message: "post is not published"}
 	}
 
-	var alreadyExists bool
+	var alreadyExists, archived bool
 	if err := w.db.QueryRow(ctx, `
-		SELECT EXISTS(
-			SELECT 1
-			FROM replies
-			WHERE post_id = $1 AND persona_id = $2
-		)
-	`, postID, personaID).Scan(&alreadyExists); err != nil {
+		SELECT
+			EXISTS(
+				SELECT 1
+				FROM replies
+				WHERE post_id = $1 AND persona_id = $2
+			),
+			EXISTS(SELECT 1 FROM battle_archives WHERE post_id = $1)
+	`, postID, personaID).Scan(&alreadyExists, &archived); err != nil {
 		return err
 	}
+	if archived {
+		return permanentError{message: "battle is archived"}
+	}
 	if alreadyExists {
 		return nil
 	}
 
 	rows, err := w.db.Query(ctx, `
-		SELECT id::text, content
+		SELECT id::text, COALESCE(persona_id::text, ''), content, authored_by = 'HUMAN'
 		FROM replies
 		WHERE post_id = $1
 		ORDER BY created_at ASC
@@ -165,28 +338,91 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 	thread := make([]ai.ReplyContext, 0)
 	for rows.Next() {
 		var reply ai.ReplyContext
-		if err := rows.Scan(&reply.ID, &reply.Content); err != nil {
+		if err := rows.Scan(&reply.ID, &reply.PersonaID, &reply.Content, &reply.Human); err != nil {
 			return err
 		}
 		thread = append(thread, reply)
 	}
 
-	generated, err := w.llm.GenerateReply(ctx, ai.PersonaContext{
-		ID:   personaID,
-		Name: persona.Name,
-		Bio:  persona.Bio,
-		Tone: persona.Tone,
-	}, ai.PostContext{
-		ID:      postID,
-		Content: postContent,
-	}, thread)
+	promptVersion := w.llm.Prompts().Active(prompts.OpReply)
+	turnLanguage := battleLanguage.TurnLanguage(persona.Language)
+	personaCtx := ai.PersonaContext{
+		ID:                personaID,
+		Name:              persona.Name,
+		Bio:               persona.Bio,
+		Tone:              persona.Tone,
+		PreferredLanguage: turnLanguage,
+		Style:             persona.Style,
+	}
+	roomHint, err := common.LoadRoomPromptHint(ctx, w.db, roomID)
 	if err != nil {
 		return err
 	}
-
-	if err := safety.ValidateContent(generated, w.cfg.ReplyMaxLen); err != nil {
+	contextPack, err := common.LoadBattleContextPack(ctx, w.db, postID)
+	if err != nil {
+		return err
+	}
+	post := ai.PostContext{
+		ID:          postID,
+		Content:     postContent,
+		RoomHint:    ai.RoomHint{Emphasize: roomHint.Emphasize, Structure: roomHint.Structure},
+		ContextPack: contextPack,
+	}
+	generationStartedAt := time.Now().UTC()
+	generated, err := w.llm.GenerateReply(ctx, personaCtx, post, thread)
+	if err != nil {
+		return err
+	}
+	firstOutput, firstElapsed := generated, time.Since(generationStartedAt)
+	generated = w.regenerateNearDuplicateReply(ctx, personaCtx, post, thread, generated)
+	generationElapsed := time.Since(generationStartedAt)
+
+	turn := common.SafetyRejection{
+		UserID:    persona.UserID,
+		PersonaID: personaID,
+		RoomID:    roomID,
+		PostID:    postID,
+		Source:    common.SafetySourceReply,
+		Language:  turnSafetyLanguage(turnLanguage, persona.Language),
+		Content:   generated,
+		MaxLen:    w.cfg.ReplyMaxLen,
+		Metadata:  map[string]any{"prompt_version": promptVersion},
+	}
+	templatePolicy, err := common.LoadBattleTemplatePolicy(ctx, w.db, postID)
+	if err != nil {
+		return err
+	}
+	for key, value := range templatePolicy.Metadata() {
+		turn.Metadata[key] = value
+	}
+	if err := w.checkBattleTurn(ctx, turn, templatePolicy.Policy); err != nil {
 		return permanentError{message: err.Error()}
 	}
+	replyMetadata, err := w.renderBilingualTurn(ctx, battleLanguage, turnLanguage, turn)
+	if err != nil {
+		return err
+	}
+	replyMetadata, err = common.WithTurnTiming(replyMetadata, generationStartedAt, generationElapsed)
+	if err != nil {
+		return err
+	}
+	usage := currentTurnUsage(ctx, attempt)
+	replyMetadata, err = common.WithTurnUsage(replyMetadata, usage)
+	if err != nil {
+		return err
+	}
+	if hintMetadata := roomHint.Metadata(); hintMetadata != nil {
+		replyMetadata, err = common.MergeTurnMetadata(replyMetadata, hintMetadata)
+		if err != nil {
+			return err
+		}
+	}
+	if policyMetadata := templatePolicy.Metadata(); policyMetadata != nil {
+		replyMetadata, err = common.MergeTurnMetadata(replyMetadata, policyMetadata)
+		if err != nil {
+			return err
+		}
+	}
 
 	tx, err := w.db.Begin(ctx)
 	if err != nil {
@@ -194,10 +430,12 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO replies(post_id, persona_id, authored_by, content)
-		VALUES ($1, $2, 'AI', $3)
-	`, postID, personaID, generated)
+	var replyID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO replies(post_id, persona_id, authored_by, content, prompt_version, metadata)
+		VALUES ($1, $2, 'AI', $3, NULLIF($4, ''), $5::jsonb)
+		RETURNING id::text
+	`, postID, personaID, generated, promptVersion, replyMetadata).Scan(&replyID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -205,11 +443,21 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 		}
 		return err
 	}
+	if err := common.AddBattleUsage(ctx, tx, postID, usage, false); err != nil {
+		return err
+	}
 
-	if _, err := tx.Exec(ctx, `
-		INSERT INTO quota_events(persona_id, quota_type)
-		VALUES ($1, 'reply')
-	`, personaID); err != nil {
+	if err := common.InsertQuotaEvent(ctx, tx, common.QuotaEvent{
+		PersonaID: personaID,
+		Type:      common.QuotaTypeReply,
+		Source:    "generate_reply",
+		ContentID: replyID,
+		Metadata: map[string]any{
+			"post_id":    postID,
+			"room_id":    roomID,
+			"request_id": ai.RequestIDFromContext(ctx),
+		},
+	}); err != nil {
 		return err
 	}
 
@@ -226,7 +474,26 @@ func (w *Worker) executeGenerateReply(ctx context.Context, postID, personaID str
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.queueLLMCanary(ctx, common.LLMCanaryRun{
+		Kind:           common.LLMCanaryKindReply,
+		PersonaID:      personaID,
+		PostID:         postID,
+		ReplyID:        replyID,
+		PrimaryOutput:  firstOutput,
+		PrimaryLatency: firstElapsed,
+	})
+	w.recordMentions(ctx, common.MentionSource{
+		PostID:          postID,
+		ReplyID:         replyID,
+		AuthorUserID:    persona.UserID,
+		AuthorPersonaID: personaID,
+		Content:         generated,
+	})
+	w.notifyBattleWatchersOfTurn(ctx, postID, persona.UserID, persona.Name)
+	return nil
 }
 
 func (w *Worker) markJobDone(ctx context.Context, jobID int64, jobType, traceID string, duration time.Duration) error {
@@ -253,6 +520,14 @@ func (w *Worker) markJobDone(ctx context.Context, jobID int64, jobType, traceID
 	return nil
 }
 
+// jobGivesUp reports whether a failed job will not be retried.
+func (w *Worker) jobGivesUp(failure error, attempts int) bool {
+	return w.retryPolicyFor(classifyJobError(failure)).givesUp(attempts + 1)
+}
+
+// markJobFailed schedules the retry the failure's class allows, or fails the
+// job for good once its class is out of attempts. Given-up jobs store the
+// global maximum so the claim query and API both treat them as final.
 func (w *Worker) markJobFailed(ctx context.Context, jobID int64, jobType, traceID string, attempts int, failure error, duration time.Duration) error {
 	maxAttempts := maxJobAttempts(w.cfg.JobMaxAttempts)
 	nextAttempt := attempts + 1
@@ -263,34 +538,38 @@ func (w *Worker) markJobFailed(ctx context.Context, jobID int64, jobType, traceI
 	if persistedAttempt > maxAttempts {
 		persistedAttempt = maxAttempts
 	}
+	class := classifyJobError(failure)
+	policy := w.retryPolicyFor(class)
 	safeError := truncateJobError(failure.Error(), 500)
-	_, isPermanent := failure.(permanentError)
-	if isPermanent || nextAttempt >= maxAttempts {
+	if policy.givesUp(nextAttempt) {
 		queryStartedAt := time.Now()
 		_, err := w.db.Exec(ctx, `
 			UPDATE jobs
 			SET status='FAILED', attempts=$2, error=$3, locked_at=NULL, updated_at=NOW()
 			WHERE id=$1
-		`, jobID, persistedAttempt, safeError)
+		`, jobID, maxAttempts, safeError)
 		w.metrics.ObserveDBQuery(time.Since(queryStartedAt))
 		if err != nil {
 			return err
 		}
 
 		w.metrics.ObserveJobProcessed(jobType, "failed", traceID, duration)
+		w.metrics.IncJobFailure(jobType, string(class), "gave_up")
 		w.logger.Error("job_failed_permanently", observability.Fields{
-			"job_id":     jobID,
-			"job_type":   strings.TrimSpace(jobType),
-			"status":     "failed",
-			"latency_ms": duration.Milliseconds(),
-			"error":      safeError,
-			"trace_id":   traceID,
-			"request_id": traceID,
+			"job_id":      jobID,
+			"job_type":    strings.TrimSpace(jobType),
+			"status":      "failed",
+			"retry_class": string(class),
+			"attempt":     nextAttempt,
+			"latency_ms":  duration.Milliseconds(),
+			"error":       safeError,
+			"trace_id":    traceID,
+			"request_id":  traceID,
 		})
 		return nil
 	}
 
-	backoff := retryBackoff(w.cfg.JobRetryBase, w.cfg.JobRetryMax, nextAttempt)
+	backoff := policy.backoff(nextAttempt)
 
 	queryStartedAt := time.Now()
 	_, err := w.db.Exec(ctx, `
@@ -305,18 +584,31 @@ func (w *Worker) markJobFailed(ctx context.Context, jobID int64, jobType, traceI
 
 	w.metrics.ObserveJobProcessed(jobType, "retry", traceID, duration)
 	w.metrics.IncrementJobRetry(jobType)
+	w.metrics.IncJobFailure(jobType, string(class), "retry")
 	w.logger.Error("job_failed_retrying", observability.Fields{
-		"job_id":     jobID,
-		"job_type":   strings.TrimSpace(jobType),
-		"status":     "retry",
-		"latency_ms": duration.Milliseconds(),
-		"error":      safeError,
-		"trace_id":   traceID,
-		"request_id": traceID,
+		"job_id":      jobID,
+		"job_type":    strings.TrimSpace(jobType),
+		"status":      "retry",
+		"retry_class": string(class),
+		"attempt":     nextAttempt,
+		"backoff_ms":  backoff.Milliseconds(),
+		"latency_ms":  duration.Milliseconds(),
+		"error":       safeError,
+		"trace_id":    traceID,
+		"request_id":  traceID,
 	})
 	return nil
 }
 
+// jobRequestID is the correlation id a job's LLM calls carry: the id of the
+// request that enqueued it, or the job id for jobs without one.
+func jobRequestID(jobID int64, traceID string) string {
+	if traceID = strings.TrimSpace(traceID); traceID != "" {
+		return traceID
+	}
+	return fmt.Sprintf("job-%d", jobID)
+}
+
 func extractTraceID(payloadRaw []byte) string {
 	if len(payloadRaw) == 0 {
 		return ""
@@ -341,29 +633,6 @@ func maxJobAttempts(configured int) int {
 	return configured
 }
 
-func retryBackoff(base, maxDelay time.Duration, attempt int) time.Duration {
-	if base <= 0 {
-		base = 30 * time.Second
-	}
-	if maxDelay <= 0 {
-		maxDelay = 10 * time.Minute
-	}
-	if attempt < 1 {
-		attempt = 1
-	}
-
-	delay := base * time.Duration(1<<(attempt-1))
-	if delay > maxDelay {
-		delay = maxDelay
-	}
-	jitter := 0.8 + (rand.Float64() * 0.4)
-	jittered := time.Duration(float64(delay) * jitter)
-	if jittered < time.Second {
-		return time.Second
-	}
-	return jittered
-}
-
 func truncateJobError(value string, limit int) string {
 	clean := strings.TrimSpace(value)
 	if limit <= 0 {
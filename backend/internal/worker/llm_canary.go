@@ -0,0 +1,326 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/quality"
+	"personaworlds/backend/internal/safety"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// newCanaryClient instruments the candidate model's client under its own
+// provider label, so its calls do not skew the primary model's metrics.
+func newCanaryClient(cfg config.Config, metrics *observability.WorkerMetrics, logger *observability.Logger) ai.LLMClient {
+	canary := ai.NewCanaryFromConfig(cfg)
+	if canary == nil {
+		return nil
+	}
+	return ai.Instrument(canary, ai.ProviderName(cfg)+"_canary", metrics, logger)
+}
+
+// queueLLMCanary samples a generation for shadowing by the candidate model.
+// Failing to queue never fails the job it shadows.
+func (w *Worker) queueLLMCanary(ctx context.Context, run common.LLMCanaryRun) {
+	if w.canary == nil || !common.SampleLLMCanary(w.cfg.LLMCanaryPct) {
+		return
+	}
+	run.PrimaryModel = ai.ModelName(w.cfg)
+	run.CandidateModel = w.cfg.LLMCanaryModel
+	if err := common.QueueLLMCanaryRun(ctx, w.db, run); err != nil {
+		w.logger.Warn("llm_canary_queue_failed", observability.Fields{
+			"kind":       run.Kind,
+			"persona_id": run.PersonaID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// canaryRun is a queued llm_canary_runs row with the prompt context rebuilt
+// from the database.
+type canaryRun struct {
+	id            int64
+	kind          string
+	personaID     string
+	roomID        string
+	postID        string
+	replyID       string
+	mood          string
+	primaryOutput string
+}
+
+// runOneLLMCanary generates the oldest queued canary run with the candidate
+// model and scores both outputs with the same heuristics persona
+// evaluations use. The candidate's output is only stored.
+func (w *Worker) runOneLLMCanary(ctx context.Context) error {
+	if w.canary == nil {
+		return nil
+	}
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var run canaryRun
+	err = tx.QueryRow(ctx, `
+		SELECT id, kind, persona_id::text, COALESCE(room_id::text, ''), COALESCE(post_id::text, ''), COALESCE(reply_id::text, ''), mood, primary_output
+		FROM llm_canary_runs
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, common.LLMCanaryPending).Scan(&run.id, &run.kind, &run.personaID, &run.roomID, &run.postID, &run.replyID, &run.mood, &run.primaryOutput)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	persona, err := w.loadCanaryPersona(ctx, run.personaID)
+	if err != nil {
+		return w.dropCanaryRunIfGone(ctx, tx, run.id, err)
+	}
+	profile := quality.Profile{Tone: persona.Tone, DoNotSay: persona.DoNotSay, Formality: persona.Formality}
+	language := persona.PreferredLanguage
+	maxLen := w.cfg.DraftMaxLen
+
+	var generate func(context.Context) (string, error)
+	switch run.kind {
+	case common.LLMCanaryKindDraft:
+		room, err := w.loadCanaryRoom(ctx, run.roomID)
+		if err != nil {
+			return w.dropCanaryRunIfGone(ctx, tx, run.id, err)
+		}
+		persona.Mood = run.mood
+		generate = func(ctx context.Context) (string, error) {
+			return w.canary.GeneratePostDraft(ctx, persona, room)
+		}
+	default:
+		post, thread, turnLanguage, err := w.loadCanaryReplyContext(ctx, run, persona.PreferredLanguage)
+		if err != nil {
+			return w.dropCanaryRunIfGone(ctx, tx, run.id, err)
+		}
+		// Reply prompts only carry these persona fields; see
+		// executeGenerateReply.
+		replyPersona := ai.PersonaContext{
+			ID:                persona.ID,
+			Name:              persona.Name,
+			Bio:               persona.Bio,
+			Tone:              persona.Tone,
+			PreferredLanguage: turnLanguage,
+			Style:             persona.Style,
+		}
+		language = turnSafetyLanguage(turnLanguage, persona.PreferredLanguage)
+		maxLen = w.cfg.ReplyMaxLen
+		generate = func(ctx context.Context) (string, error) {
+			return w.canary.GenerateReply(ctx, replyPersona, post, thread)
+		}
+	}
+
+	// A fresh stats scope keeps candidate calls out of any outer usage.
+	callCtx, _ := ai.WithCallStats(ctx)
+	startedAt := time.Now()
+	candidateOutput, candidateErr := generate(callCtx)
+	candidateLatency := time.Since(startedAt)
+
+	rules, err := w.safetyRules.Load(ctx, w.cfg.SafetySyncEvery, w.db)
+	if err != nil {
+		w.logger.Warn("safety_rules_load_failed", observability.Fields{"error": err.Error()})
+	}
+	rules = rules.ForLanguage(language)
+	primaryScore, primarySafety := scoreCanaryOutput(rules, profile, maxLen, run.primaryOutput)
+	primaryScoreJSON, err := json.Marshal(primaryScore)
+	if err != nil {
+		return err
+	}
+
+	var (
+		candidateScoreJSON []byte
+		candidateSafety    *string
+		candidateError     *string
+		candidateOverall   float64
+	)
+	if candidateErr != nil {
+		message := common.TruncateRunes(candidateErr.Error(), 500)
+		candidateError = &message
+	} else {
+		var score quality.Score
+		score, candidateSafety = scoreCanaryOutput(rules, profile, maxLen, candidateOutput)
+		if candidateScoreJSON, err = json.Marshal(score); err != nil {
+			return err
+		}
+		candidateOverall = score.Overall
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE llm_canary_runs
+		SET status = $2,
+			candidate_model = $3,
+			candidate_output = NULLIF($4, ''),
+			candidate_error = $5,
+			candidate_latency_ms = $6,
+			primary_score = $7::jsonb,
+			primary_safety_error = $8,
+			candidate_score = $9::jsonb,
+			candidate_safety_error = $10,
+			completed_at = NOW()
+		WHERE id = $1
+	`, run.id, common.LLMCanaryDone, w.cfg.LLMCanaryModel, candidateOutput, candidateError, candidateLatency.Milliseconds(),
+		primaryScoreJSON, primarySafety, candidateScoreJSON, candidateSafety); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	fields := observability.Fields{
+		"run_id":               run.id,
+		"kind":                 run.kind,
+		"candidate_model":      w.cfg.LLMCanaryModel,
+		"candidate_latency_ms": candidateLatency.Milliseconds(),
+		"primary_overall":      primaryScore.Overall,
+	}
+	if candidateErr != nil {
+		fields["error"] = candidateErr.Error()
+		w.logger.Warn("llm_canary_run_failed", fields)
+		return nil
+	}
+	fields["candidate_overall"] = candidateOverall
+	w.logger.Info("llm_canary_run_completed", fields)
+	return nil
+}
+
+// scoreCanaryOutput scores output against the persona and checks it
+// against the safety rules without recording a rejection: canary output is
+// never shown to anyone.
+func scoreCanaryOutput(rules safety.Rules, profile quality.Profile, maxLen int, output string) (quality.Score, *string) {
+	score := quality.Evaluate(profile, output)
+	if err := rules.Validate(output, maxLen); err != nil {
+		message := err.Error()
+		return score, &message
+	}
+	return score, nil
+}
+
+// dropCanaryRunIfGone deletes a run whose persona, room or post no longer
+// exists and returns any other error.
+func (w *Worker) dropCanaryRunIfGone(ctx context.Context, tx pgx.Tx, runID int64, err error) error {
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM llm_canary_runs WHERE id = $1`, runID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (w *Worker) loadCanaryPersona(ctx context.Context, personaID string) (ai.PersonaContext, error) {
+	persona := ai.PersonaContext{ID: personaID}
+	var writingSamplesRaw, doNotSayRaw, catchphrasesRaw []byte
+	err := w.db.QueryRow(ctx, `
+		SELECT name, bio, tone, writing_samples, do_not_say, catchphrases, preferred_language, formality,
+			style_humor, style_assertiveness, style_technicality, style_brevity
+		FROM personas
+		WHERE id = $1
+	`, personaID).Scan(
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&writingSamplesRaw,
+		&doNotSayRaw,
+		&catchphrasesRaw,
+		&persona.PreferredLanguage,
+		&persona.Formality,
+		&persona.Style.Humor,
+		&persona.Style.Assertiveness,
+		&persona.Style.Technicality,
+		&persona.Style.Brevity,
+	)
+	if err != nil {
+		return ai.PersonaContext{}, err
+	}
+	persona.WritingSamples = w.parsePersonaList(ctx, common.WritingSamplesField, writingSamplesRaw)
+	persona.DoNotSay = w.parsePersonaList(ctx, common.DoNotSayField, doNotSayRaw)
+	persona.Catchphrases = w.parsePersonaList(ctx, common.CatchphrasesField, catchphrasesRaw)
+	return persona, nil
+}
+
+func (w *Worker) loadCanaryRoom(ctx context.Context, roomID string) (ai.RoomContext, error) {
+	room := ai.RoomContext{ID: roomID, Variant: 1}
+	if err := w.db.QueryRow(ctx, `
+		SELECT slug, name, description
+		FROM rooms
+		WHERE id = $1
+	`, roomID).Scan(&room.Slug, &room.Name, &room.Description); err != nil {
+		return ai.RoomContext{}, err
+	}
+	hint, err := common.LoadRoomPromptHint(ctx, w.db, roomID)
+	if err != nil {
+		return ai.RoomContext{}, err
+	}
+	room.Hint = ai.RoomHint{Emphasize: hint.Emphasize, Structure: hint.Structure}
+	return room, nil
+}
+
+// loadCanaryReplyContext rebuilds the post and thread the primary reply was
+// generated from: every reply posted before it.
+func (w *Worker) loadCanaryReplyContext(ctx context.Context, run canaryRun, personaLanguage string) (ai.PostContext, []ai.ReplyContext, string, error) {
+	post := ai.PostContext{ID: run.postID}
+	var (
+		roomID         string
+		battleLanguage common.BattleLanguage
+		replyCreatedAt time.Time
+	)
+	err := w.db.QueryRow(ctx, `
+		SELECT p.content, p.room_id::text, p.battle_language, COALESCE(p.battle_language_code, ''), r.created_at
+		FROM posts p
+		JOIN replies r ON r.post_id = p.id
+		WHERE p.id = $1
+		  AND r.id = $2
+	`, run.postID, run.replyID).Scan(&post.Content, &roomID, &battleLanguage.Mode, &battleLanguage.Language, &replyCreatedAt)
+	if err != nil {
+		return ai.PostContext{}, nil, "", err
+	}
+
+	rows, err := w.db.Query(ctx, `
+		SELECT id::text, COALESCE(persona_id::text, ''), content, authored_by = 'HUMAN'
+		FROM replies
+		WHERE post_id = $1
+		  AND created_at < $2
+		ORDER BY created_at ASC
+	`, run.postID, replyCreatedAt)
+	if err != nil {
+		return ai.PostContext{}, nil, "", err
+	}
+	defer rows.Close()
+	thread := make([]ai.ReplyContext, 0)
+	for rows.Next() {
+		var reply ai.ReplyContext
+		if err := rows.Scan(&reply.ID, &reply.PersonaID, &reply.Content, &reply.Human); err != nil {
+			return ai.PostContext{}, nil, "", err
+		}
+		thread = append(thread, reply)
+	}
+	if err := rows.Err(); err != nil {
+		return ai.PostContext{}, nil, "", err
+	}
+
+	hint, err := common.LoadRoomPromptHint(ctx, w.db, roomID)
+	if err != nil {
+		return ai.PostContext{}, nil, "", err
+	}
+	post.RoomHint = ai.RoomHint{Emphasize: hint.Emphasize, Structure: hint.Structure}
+	if post.ContextPack, err = common.LoadBattleContextPack(ctx, w.db, run.postID); err != nil {
+		return ai.PostContext{}, nil, "", err
+	}
+	return post, thread, battleLanguage.TurnLanguage(personaLanguage), nil
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/quality"
+	"personaworlds/backend/internal/safety"
+)
+
+func TestNewCanaryClientNeedsModelAndPercent(t *testing.T) {
+	metrics := observability.NewWorkerMetrics()
+	logger := observability.NewLogger("test")
+	for _, cfg := range []config.Config{
+		{LLMCanaryModel: "gpt-next"},
+		{LLMCanaryPct: 10},
+	} {
+		if client := newCanaryClient(cfg, metrics, logger); client != nil {
+			t.Fatalf("expected no canary for %+v", cfg)
+		}
+	}
+	cfg := config.Config{LLMCanaryModel: "gpt-next", LLMCanaryPct: 10}
+	if newCanaryClient(cfg, metrics, logger) == nil {
+		t.Fatalf("expected a canary client")
+	}
+	if ai.ModelName(cfg) != "mock" {
+		t.Fatalf("expected the mock provider to report its model as mock")
+	}
+}
+
+func TestScoreCanaryOutput(t *testing.T) {
+	profile := quality.Profile{Tone: "calm", DoNotSay: []string{"synergy"}, Formality: 1}
+
+	score, safetyErr := scoreCanaryOutput(safety.Rules{}, profile, 280, "Let us measure it first and decide next week.")
+	if safetyErr != nil || score.DoNotSay != 1 {
+		t.Fatalf("expected a clean score, got %+v, %v", score, safetyErr)
+	}
+
+	score, safetyErr = scoreCanaryOutput(safety.Rules{}, profile, 10, "Pure synergy, this is way too long!")
+	if score.DoNotSay >= 1 || len(score.Violations) != 1 {
+		t.Fatalf("expected the do_not_say violation scored, got %+v", score)
+	}
+	if safetyErr == nil {
+		t.Fatalf("expected output over the length limit to fail safety rules")
+	}
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+// recordMentions runs once generated content has been committed. A failure
+// only costs the mention notifications, so it is logged and not retried.
+func (w *Worker) recordMentions(ctx context.Context, source common.MentionSource) {
+	mentions, err := common.RecordMentions(ctx, w.db, source)
+	if err != nil {
+		w.logger.Warn("mention_record_failed", observability.Fields{
+			"post_id":  source.PostID,
+			"reply_id": source.ReplyID,
+			"error":    err.Error(),
+		})
+		return
+	}
+	for _, mention := range mentions {
+		w.logger.Info("persona_mentioned", observability.Fields{
+			"post_id":        source.PostID,
+			"persona_id":     mention.PersonaID,
+			"reply_enqueued": mention.ReplyEnqueued,
+		})
+	}
+}
@@ -2,6 +2,9 @@ package worker
 
 import (
 	"net/http"
+
+	"personaworlds/backend/internal/db"
+	"personaworlds/backend/internal/observability"
 )
 
 func (w *Worker) ObservabilityHandler() http.Handler {
@@ -12,8 +15,21 @@ func (w *Worker) ObservabilityHandler() http.Handler {
 		_, _ = rw.Write([]byte(`{"ok":true}` + "\n"))
 	})
 	mux.HandleFunc("/metrics", func(rw http.ResponseWriter, _ *http.Request) {
+		w.refreshDBPoolMetrics()
 		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 		_, _ = rw.Write([]byte(w.metrics.Render()))
 	})
 	return mux
 }
+
+// SetMetricsSink also sends worker metrics to sink, next to /metrics.
+func (w *Worker) SetMetricsSink(sink observability.MetricsSink) {
+	w.metrics.SetSink(sink)
+}
+
+func (w *Worker) refreshDBPoolMetrics() {
+	if w.db == nil {
+		return
+	}
+	w.metrics.SetDBPoolStats("worker", db.PoolStats(w.db))
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// createOnePersonaImportChunk creates the next chunk of the oldest pending
+// bulk persona import. Like post status batches, each chunk commits on its
+// own so results can be polled while the import runs.
+func (w *Worker) createOnePersonaImportChunk(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		batchID     int64
+		userID      string
+		resultsJSON []byte
+		pendingJSON []byte
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id::text, results, pending
+		FROM persona_import_batches
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, common.PersonaImportPending).Scan(&batchID, &userID, &resultsJSON, &pendingJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	var (
+		results []common.PersonaImportResult
+		pending []common.PersonaImportRow
+	)
+	if err := json.Unmarshal(resultsJSON, &results); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(pendingJSON, &pending); err != nil {
+		return err
+	}
+
+	end := min(common.PersonaImportChunkSize, len(pending))
+	created, err := common.CreateImportedPersonas(ctx, tx, userID, pending[:end])
+	if err != nil {
+		return err
+	}
+	results = common.MergePersonaImportResults(results, created)
+	pending = pending[end:]
+
+	if resultsJSON, err = json.Marshal(results); err != nil {
+		return err
+	}
+	if pendingJSON, err = json.Marshal(pending); err != nil {
+		return err
+	}
+	done := len(pending) == 0
+	if _, err := tx.Exec(ctx, `
+		UPDATE persona_import_batches
+		SET results = $2::jsonb,
+			pending = $3::jsonb,
+			status = CASE WHEN $4 THEN $5 ELSE status END,
+			completed_at = CASE WHEN $4 THEN NOW() ELSE completed_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, batchID, resultsJSON, pendingJSON, done, common.PersonaImportDone); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	counts := common.PersonaImportCounts(results)
+	fields := observability.Fields{
+		"batch_id": batchID,
+		"user_id":  userID,
+		"created":  counts[common.PersonaImportRowCreated],
+		"skipped":  counts[common.PersonaImportRowSkipped],
+		"errors":   counts[common.PersonaImportRowError],
+		"pending":  counts[common.PersonaImportRowPending],
+	}
+	if done {
+		w.logger.Info("persona_import_batch_completed", fields)
+	} else {
+		w.logger.Info("persona_import_batch_chunk", fields)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// questionLease keeps other workers off a claimed question while its answer is
+// being generated; a crashed worker simply lets the lease expire.
+const questionLease = 2 * time.Minute
+
+type approvedQuestion struct {
+	ID          string
+	PersonaID   string
+	OwnerUserID string
+	RoomID      string
+	Question    string
+	Attempts    int
+}
+
+func (w *Worker) answerOneApprovedQuestion(ctx context.Context) error {
+	question, persona, err := w.claimApprovedQuestion(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if deferred, err := w.deferQuestionForRoomCooldown(ctx, w.db, question); err != nil || deferred {
+		return err
+	}
+
+	promptVersion := w.llm.Prompts().Active(prompts.OpPersonaAnswer)
+	answer, err := w.llm.AnswerQuestion(ctx, persona, question.Question)
+	if err == nil {
+		answer = strings.TrimSpace(answer)
+		err = w.checkGeneratedContent(ctx, common.SafetyRejection{
+			UserID:    question.OwnerUserID,
+			PersonaID: question.PersonaID,
+			RoomID:    question.RoomID,
+			Source:    common.SafetySourcePersonaAnswer,
+			Language:  persona.PreferredLanguage,
+			Content:   answer,
+			MaxLen:    w.cfg.DraftMaxLen,
+			Metadata:  map[string]any{"question_id": question.ID, "prompt_version": promptVersion},
+		})
+	}
+	if err != nil {
+		return w.markQuestionFailed(ctx, question, err)
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Another post may have landed in the room while the answer was being
+	// generated; the cooldown is enforced again at publish time.
+	deferred, err := w.deferQuestionForRoomCooldown(ctx, tx, question)
+	if err != nil {
+		return err
+	}
+	if deferred {
+		return tx.Commit(ctx)
+	}
+
+	var postID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, prompt_version)
+		VALUES ($1, $2, $3, 'AI', 'PUBLISHED', $4, NOW(), NULLIF($5, ''))
+		RETURNING id::text
+	`, question.RoomID, question.PersonaID, question.OwnerUserID, answer, promptVersion).Scan(&postID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE persona_questions
+		SET status = 'ANSWERED',
+			answer_post_id = $2,
+			answered_at = NOW(),
+			error = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+	`, question.ID, postID); err != nil {
+		return err
+	}
+
+	metadata := map[string]any{
+		"post_id":      postID,
+		"room_id":      question.RoomID,
+		"question_id":  question.ID,
+		"post_preview": common.TruncateRunes(answer, 220),
+	}
+	if err := common.InsertPersonaActivityEvent(ctx, tx, question.PersonaID, "post_created", metadata); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.recordMentions(ctx, common.MentionSource{
+		PostID:          postID,
+		AuthorUserID:    question.OwnerUserID,
+		AuthorPersonaID: question.PersonaID,
+		Content:         answer,
+	})
+	w.logger.Info("persona_question_answered", observability.Fields{
+		"question_id": question.ID,
+		"persona_id":  question.PersonaID,
+		"post_id":     postID,
+	})
+	return nil
+}
+
+type questionStore interface {
+	common.DBExecutor
+	common.DBQuerier
+}
+
+// deferQuestionForRoomCooldown pushes a question back until the persona's
+// room cooldown ends. Deferring does not count as a failed attempt.
+func (w *Worker) deferQuestionForRoomCooldown(ctx context.Context, db questionStore, question approvedQuestion) (bool, error) {
+	cooldown, err := common.LoadRoomCooldown(ctx, db, question.RoomID, question.PersonaID, w.cfg.RoomPostCooldown, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !cooldown.Active {
+		return false, nil
+	}
+	if _, err := db.Exec(ctx, `
+		UPDATE persona_questions
+		SET available_at = $2, updated_at = NOW()
+		WHERE id = $1
+	`, question.ID, *cooldown.AvailableAt); err != nil {
+		return false, err
+	}
+	w.logger.Info("persona_question_deferred", observability.Fields{
+		"question_id":  question.ID,
+		"persona_id":   question.PersonaID,
+		"room_id":      question.RoomID,
+		"available_at": cooldown.AvailableAt.UTC().Format(time.RFC3339),
+		"reason":       "room_cooldown",
+	})
+	return true, nil
+}
+
+func (w *Worker) claimApprovedQuestion(ctx context.Context) (approvedQuestion, ai.PersonaContext, error) {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return approvedQuestion{}, ai.PersonaContext{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		question          approvedQuestion
+		persona           ai.PersonaContext
+		writingSamplesRaw []byte
+		doNotSayRaw       []byte
+		catchphrasesRaw   []byte
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT
+			q.id::text,
+			q.persona_id::text,
+			p.user_id::text,
+			q.room_id::text,
+			q.question,
+			q.attempts,
+			p.name,
+			p.bio,
+			p.tone,
+			p.writing_samples,
+			p.do_not_say,
+			p.catchphrases,
+			p.preferred_language,
+			p.formality,
+			p.style_humor,
+			p.style_assertiveness,
+			p.style_technicality,
+			p.style_brevity
+		FROM persona_questions q
+		JOIN personas p ON p.id = q.persona_id
+		WHERE q.status = 'APPROVED'
+		  AND q.room_id IS NOT NULL
+		  AND q.attempts < $1
+		  AND q.available_at <= NOW()
+		ORDER BY q.available_at ASC, q.approved_at ASC
+		LIMIT 1
+		FOR UPDATE OF q SKIP LOCKED
+	`, maxJobAttempts(w.cfg.JobMaxAttempts)).Scan(
+		&question.ID,
+		&question.PersonaID,
+		&question.OwnerUserID,
+		&question.RoomID,
+		&question.Question,
+		&question.Attempts,
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&writingSamplesRaw,
+		&doNotSayRaw,
+		&catchphrasesRaw,
+		&persona.PreferredLanguage,
+		&persona.Formality,
+		&persona.Style.Humor,
+		&persona.Style.Assertiveness,
+		&persona.Style.Technicality,
+		&persona.Style.Brevity,
+	)
+	if err != nil {
+		return approvedQuestion{}, ai.PersonaContext{}, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE persona_questions
+		SET available_at = NOW() + ($2::double precision * INTERVAL '1 second'), updated_at = NOW()
+		WHERE id = $1
+	`, question.ID, questionLease.Seconds()); err != nil {
+		return approvedQuestion{}, ai.PersonaContext{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return approvedQuestion{}, ai.PersonaContext{}, err
+	}
+
+	persona.ID = question.PersonaID
+	persona.WritingSamples = w.parsePersonaList(ctx, common.WritingSamplesField, writingSamplesRaw)
+	persona.DoNotSay = w.parsePersonaList(ctx, common.DoNotSayField, doNotSayRaw)
+	persona.Catchphrases = w.parsePersonaList(ctx, common.CatchphrasesField, catchphrasesRaw)
+	persona.PreferredLanguage = strings.TrimSpace(persona.PreferredLanguage)
+	if persona.PreferredLanguage == "" {
+		persona.PreferredLanguage = "en"
+	}
+	return question, persona, nil
+}
+
+func (w *Worker) markQuestionFailed(ctx context.Context, question approvedQuestion, failure error) error {
+	nextAttempt := question.Attempts + 1
+	class := classifyJobError(failure)
+	policy := w.retryPolicyFor(class)
+	backoff := policy.backoff(nextAttempt)
+	persistedAttempt := nextAttempt
+	if policy.givesUp(nextAttempt) {
+		persistedAttempt = max(nextAttempt, maxJobAttempts(w.cfg.JobMaxAttempts))
+	}
+	safeError := truncateJobError(failure.Error(), 500)
+
+	if _, err := w.db.Exec(ctx, `
+		UPDATE persona_questions
+		SET attempts = $2,
+			error = $3,
+			available_at = NOW() + ($4::double precision * INTERVAL '1 second'),
+			updated_at = NOW()
+		WHERE id = $1
+	`, question.ID, persistedAttempt, safeError, backoff.Seconds()); err != nil {
+		return err
+	}
+
+	w.logger.Error("persona_question_failed", observability.Fields{
+		"question_id": question.ID,
+		"persona_id":  question.PersonaID,
+		"attempt":     nextAttempt,
+		"retry_class": string(class),
+		"error":       safeError,
+	})
+	return nil
+}
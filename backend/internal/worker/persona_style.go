@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// classifyStyleForOnePersona backfills the style sliders of one persona that
+// predates them, estimating the values from its tone text. It only ever
+// touches rows still marked pending, so an owner who moves a slider first
+// keeps their values. After repeated failures the neutral defaults stay.
+func (w *Worker) classifyStyleForOnePersona(ctx context.Context) error {
+	var (
+		persona           ai.PersonaContext
+		attempts          int
+		writingSamplesRaw []byte
+		catchphrasesRaw   []byte
+	)
+	err := w.db.QueryRow(ctx, `
+		SELECT id::text, name, bio, tone, writing_samples, catchphrases, formality, style_classify_attempts
+		FROM personas
+		WHERE style_source = 'pending'
+		ORDER BY style_classify_attempts ASC, created_at ASC
+		LIMIT 1
+	`).Scan(
+		&persona.ID,
+		&persona.Name,
+		&persona.Bio,
+		&persona.Tone,
+		&writingSamplesRaw,
+		&catchphrasesRaw,
+		&persona.Formality,
+		&attempts,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	persona.WritingSamples = w.parsePersonaList(ctx, common.WritingSamplesField, writingSamplesRaw)
+	persona.Catchphrases = w.parsePersonaList(ctx, common.CatchphrasesField, catchphrasesRaw)
+
+	raw, aiErr := w.llm.ClassifyPersonaStyle(ctx, persona)
+	style, ok := parsePersonaStyle(raw)
+	if aiErr != nil || !ok {
+		attempts++
+		source := "pending"
+		if attempts >= maxJobAttempts(w.cfg.JobMaxAttempts) {
+			source = "default"
+		}
+		if _, err := w.db.Exec(ctx, `
+			UPDATE personas
+			SET style_classify_attempts = $2, style_source = $3
+			WHERE id = $1
+			  AND style_source = 'pending'
+		`, persona.ID, attempts, source); err != nil {
+			return err
+		}
+		if aiErr != nil {
+			return aiErr
+		}
+		w.logger.Warn("persona_style_unreadable", observability.Fields{
+			"persona_id": persona.ID,
+			"attempts":   attempts,
+		})
+		return nil
+	}
+
+	_, err = w.db.Exec(ctx, `
+		UPDATE personas
+		SET style_humor = $2,
+			style_assertiveness = $3,
+			style_technicality = $4,
+			style_brevity = $5,
+			style_source = 'classified',
+			style_classify_attempts = style_classify_attempts + 1
+		WHERE id = $1
+		  AND style_source = 'pending'
+	`, persona.ID, style.Humor, style.Assertiveness, style.Technicality, style.Brevity)
+	return err
+}
+
+// parsePersonaStyle reads the classifier's JSON, tolerating code fences and
+// surrounding prose. Every slider must be present; values are rounded and
+// clamped to 0-100.
+func parsePersonaStyle(raw string) (ai.PersonaStyle, bool) {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end <= start {
+		return ai.PersonaStyle{}, false
+	}
+
+	var values map[string]*float64
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &values); err != nil {
+		return ai.PersonaStyle{}, false
+	}
+
+	slider := func(name string) (int, bool) {
+		value, ok := values[name]
+		if !ok || value == nil || math.IsNaN(*value) {
+			return 0, false
+		}
+		return int(math.Max(0, math.Min(100, math.Round(*value)))), true
+	}
+
+	var style ai.PersonaStyle
+	var ok bool
+	if style.Humor, ok = slider("humor"); !ok {
+		return ai.PersonaStyle{}, false
+	}
+	if style.Assertiveness, ok = slider("assertiveness"); !ok {
+		return ai.PersonaStyle{}, false
+	}
+	if style.Technicality, ok = slider("technicality"); !ok {
+		return ai.PersonaStyle{}, false
+	}
+	if style.Brevity, ok = slider("brevity"); !ok {
+		return ai.PersonaStyle{}, false
+	}
+	return style, true
+}
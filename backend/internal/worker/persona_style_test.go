@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+)
+
+func TestParsePersonaStyle(t *testing.T) {
+	style, ok := parsePersonaStyle("```json\n{\"humor\": 72.6, \"assertiveness\": 140, \"technicality\": -5, \"brevity\": 40}\n```")
+	if !ok {
+		t.Fatal("expected style to parse")
+	}
+	if style != (ai.PersonaStyle{Humor: 73, Assertiveness: 100, Technicality: 0, Brevity: 40}) {
+		t.Fatalf("unexpected style %+v", style)
+	}
+
+	for _, raw := range []string{"", "humor: 50", `{"humor": 50, "assertiveness": 50, "technicality": 50}`, `{"humor": "high", "assertiveness": 50, "technicality": 50, "brevity": 50}`, `{"humor": null, "assertiveness": 50, "technicality": 50, "brevity": 50}`} {
+		if _, ok := parsePersonaStyle(raw); ok {
+			t.Fatalf("expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestMockStyleClassificationParses(t *testing.T) {
+	raw, err := ai.NewMockClient().ClassifyPersonaStyle(context.Background(), ai.PersonaContext{
+		Name: "Ada",
+		Tone: "witty, direct and concise",
+		Bio:  "Backend engineer",
+	})
+	if err != nil {
+		t.Fatalf("classify failed: %v", err)
+	}
+	style, ok := parsePersonaStyle(raw)
+	if !ok {
+		t.Fatalf("expected mock output to parse, got %q", raw)
+	}
+	if style.Humor <= 50 || style.Assertiveness <= 50 || style.Technicality <= 50 || style.Brevity <= 50 {
+		t.Fatalf("expected tone cues to raise every slider, got %+v", style)
+	}
+}
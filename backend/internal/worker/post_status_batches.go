@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// applyOnePostStatusChunk applies the next chunk of the oldest pending bulk
+// unpublish/republish. Each chunk commits on its own, so progress is visible
+// while a large batch runs and a failure only retries that chunk.
+func (w *Worker) applyOnePostStatusChunk(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		batchID   int64
+		personaID string
+		action    string
+		postIDs   []string
+		processed int
+		changed   int
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT id, persona_id::text, action, post_ids::text[], processed_posts, changed_posts
+		FROM post_status_batches
+		WHERE status = $1
+		ORDER BY created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, common.PostStatusBatchPending).Scan(&batchID, &personaID, &action, &postIDs, &processed, &changed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	start := min(processed, len(postIDs))
+	end := min(start+common.PostStatusBatchChunkSize, len(postIDs))
+	chunkChanged, err := common.ApplyPostStatusChange(ctx, tx, personaID, action, batchID, postIDs[start:end])
+	if err != nil {
+		return err
+	}
+
+	done := end >= len(postIDs)
+	if _, err := tx.Exec(ctx, `
+		UPDATE post_status_batches
+		SET processed_posts = $2,
+			changed_posts = changed_posts + $3,
+			status = CASE WHEN $4 THEN $5 ELSE status END,
+			completed_at = CASE WHEN $4 THEN NOW() ELSE completed_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, batchID, end, chunkChanged, done, common.PostStatusBatchDone); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	fields := observability.Fields{
+		"batch_id":        batchID,
+		"persona_id":      personaID,
+		"action":          action,
+		"processed_posts": end,
+		"changed_posts":   changed + chunkChanged,
+		"total_posts":     len(postIDs),
+	}
+	if done {
+		w.logger.Info("post_status_batch_completed", fields)
+	} else {
+		w.logger.Info("post_status_batch_chunk", fields)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package worker
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/common"
+)
+
+const presencePruneBatch = 1000
+
+// prunePersonaPresence drops presence days that have left the window room
+// presence reports on.
+func (w *Worker) prunePersonaPresence(ctx context.Context) error {
+	_, err := w.db.Exec(ctx, `
+		DELETE FROM persona_room_presence_days
+		WHERE ctid IN (
+			SELECT ctid
+			FROM persona_room_presence_days
+			WHERE day < (NOW() AT TIME ZONE 'UTC')::date - $1::int
+			LIMIT $2
+		)
+	`, common.PresenceWindowDays, presencePruneBatch)
+	return err
+}
@@ -0,0 +1,28 @@
+package worker
+
+import "context"
+
+func (w *Worker) syncPromptVersions(ctx context.Context) error {
+	return w.llm.Prompts().Sync(ctx, w.cfg.PromptSyncEvery, w.loadPromptPins)
+}
+
+func (w *Worker) loadPromptPins(ctx context.Context) (map[string]string, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT operation, version
+		FROM prompt_versions
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := map[string]string{}
+	for rows.Next() {
+		var operation, version string
+		if err := rows.Scan(&operation, &version); err != nil {
+			return nil, err
+		}
+		pins[operation] = version
+	}
+	return pins, rows.Err()
+}
@@ -0,0 +1,275 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/webpush"
+)
+
+const (
+	notificationTypeBattleCompleted = "battle_completed"
+	notificationTypePersonaFollowed = "persona_followed"
+
+	pushDispatchBatchSize = 25
+	pushMaxFailures       = 5
+	pushMaxAge            = 6 * time.Hour
+)
+
+type pushSender interface {
+	Send(ctx context.Context, sub webpush.Subscription, payload []byte) error
+}
+
+type pushNotification struct {
+	ID              int64
+	UserID          string
+	Type            string
+	Title           string
+	Body            string
+	Metadata        map[string]any
+	CreatedAt       time.Time
+	BattleCompleted bool
+	NewFollower     bool
+}
+
+type pushPayload struct {
+	NotificationID int64  `json:"notification_id"`
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	URL            string `json:"url"`
+}
+
+func newPushSender(cfg config.Config, logger *observability.Logger) pushSender {
+	if !cfg.WebPushEnabled() {
+		return nil
+	}
+	sender, err := webpush.NewSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject, nil)
+	if err != nil {
+		logger.Error("web_push_disabled", observability.Fields{"error": err.Error()})
+		return nil
+	}
+	return sender
+}
+
+// notifyBattleCompleted tells the battle owner every persona has replied.
+// Battles finish in the worker, so this notification is written here rather
+// than through the API's notification helpers.
+func (w *Worker) notifyBattleCompleted(ctx context.Context, ownerUserID, battleID, topic string) error {
+	if strings.TrimSpace(ownerUserID) == "" {
+		return nil
+	}
+	body := "All personas have replied. See how your battle played out."
+	if strings.TrimSpace(topic) != "" {
+		body = fmt.Sprintf("All personas have replied in \"%s\".", common.TruncateRunes(topic, 120))
+	}
+	payload, err := json.Marshal(map[string]any{"battle_id": battleID})
+	if err != nil {
+		return err
+	}
+	_, err = w.db.Exec(ctx, `
+		INSERT INTO notifications(user_id, type, title, body, metadata)
+		VALUES ($1, $2, $3, $4, $5::jsonb)
+	`, ownerUserID, notificationTypeBattleCompleted, "Your battle is done", common.TruncateRunes(body, 260), payload)
+	return err
+}
+
+// dispatchPushNotifications claims notifications that have not been through
+// push delivery yet and pushes the high-value ones to every subscribed
+// browser. Claiming marks rows first, so a crash drops a push instead of
+// sending it twice, and rolled-up notifications are only pushed once.
+func (w *Worker) dispatchPushNotifications(ctx context.Context) error {
+	if w.push == nil {
+		return nil
+	}
+
+	rows, err := w.db.Query(ctx, `
+		WITH due AS (
+			SELECT id, user_id
+			FROM notifications
+			WHERE push_dispatched_at IS NULL
+			ORDER BY created_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE notifications n
+		SET push_dispatched_at = NOW()
+		FROM due
+		LEFT JOIN push_preferences pp ON pp.user_id = due.user_id
+		WHERE n.id = due.id
+		RETURNING
+			n.id,
+			n.user_id::text,
+			n.type,
+			n.title,
+			n.body,
+			n.metadata,
+			n.created_at,
+			COALESCE(pp.battle_completed, TRUE),
+			COALESCE(pp.new_follower, TRUE)
+	`, pushDispatchBatchSize)
+	if err != nil {
+		return err
+	}
+
+	claimed := make([]pushNotification, 0)
+	for rows.Next() {
+		var (
+			notification pushNotification
+			metadataRaw  []byte
+		)
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.Title,
+			&notification.Body,
+			&metadataRaw,
+			&notification.CreatedAt,
+			&notification.BattleCompleted,
+			&notification.NewFollower,
+		); err != nil {
+			rows.Close()
+			return err
+		}
+		notification.Metadata = map[string]any{}
+		_ = json.Unmarshal(metadataRaw, &notification.Metadata)
+		claimed = append(claimed, notification)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, notification := range claimed {
+		if !shouldPushNotification(notification, now) {
+			continue
+		}
+		payload, err := json.Marshal(buildPushPayload(notification))
+		if err != nil {
+			return err
+		}
+		if err := w.pushToUser(ctx, notification.UserID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) pushToUser(ctx context.Context, userID string, payload []byte) error {
+	rows, err := w.db.Query(ctx, `
+		SELECT id::text, endpoint, p256dh, auth
+		FROM push_subscriptions
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return err
+	}
+	type subscriptionRow struct {
+		id  string
+		sub webpush.Subscription
+	}
+	subscriptions := make([]subscriptionRow, 0)
+	for rows.Next() {
+		var row subscriptionRow
+		if err := rows.Scan(&row.id, &row.sub.Endpoint, &row.sub.P256dh, &row.sub.Auth); err != nil {
+			rows.Close()
+			return err
+		}
+		subscriptions = append(subscriptions, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, row := range subscriptions {
+		sendErr := w.push.Send(ctx, row.sub, payload)
+		switch {
+		case sendErr == nil:
+			w.metrics.IncPushDelivery("sent")
+			if _, err := w.db.Exec(ctx, `
+				UPDATE push_subscriptions
+				SET failure_count = 0, last_success_at = NOW(), updated_at = NOW()
+				WHERE id = $1
+			`, row.id); err != nil {
+				return err
+			}
+		case errors.Is(sendErr, webpush.ErrSubscriptionGone):
+			w.metrics.IncPushDelivery("gone")
+			if _, err := w.db.Exec(ctx, `DELETE FROM push_subscriptions WHERE id = $1`, row.id); err != nil {
+				return err
+			}
+		default:
+			w.metrics.IncPushDelivery("failed")
+			w.logger.Warn("web_push_failed", observability.Fields{
+				"subscription_id": row.id,
+				"error":           sendErr.Error(),
+			})
+			if _, err := w.db.Exec(ctx, `
+				WITH bumped AS (
+					UPDATE push_subscriptions
+					SET failure_count = failure_count + 1, updated_at = NOW()
+					WHERE id = $1
+					RETURNING id, failure_count
+				)
+				DELETE FROM push_subscriptions ps
+				USING bumped
+				WHERE ps.id = bumped.id
+				  AND bumped.failure_count >= $2
+			`, row.id, pushMaxFailures); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shouldPushNotification keeps push to the notifications worth interrupting
+// someone for, honours the user's per-type preference and drops anything
+// that waited too long (for example while push was not configured).
+func shouldPushNotification(notification pushNotification, now time.Time) bool {
+	if now.Sub(notification.CreatedAt) > pushMaxAge {
+		return false
+	}
+	switch notification.Type {
+	case notificationTypeBattleCompleted, common.NotificationWatchedBattleVerdict:
+		return notification.BattleCompleted
+	case notificationTypePersonaFollowed:
+		return notification.NewFollower
+	case notificationTypeDailySummary:
+		// Daily summaries are opt-in already.
+		return true
+	default:
+		return false
+	}
+}
+
+func buildPushPayload(notification pushNotification) pushPayload {
+	url := "/"
+	switch notification.Type {
+	case notificationTypeBattleCompleted, common.NotificationWatchedBattleVerdict:
+		if battleID, _ := notification.Metadata["battle_id"].(string); strings.TrimSpace(battleID) != "" {
+			url = "/b/" + strings.TrimSpace(battleID)
+		}
+	case notificationTypePersonaFollowed:
+		if slug, _ := notification.Metadata["slug"].(string); strings.TrimSpace(slug) != "" {
+			url = "/p/" + strings.TrimSpace(slug)
+		}
+	}
+	return pushPayload{
+		NotificationID: notification.ID,
+		Type:           notification.Type,
+		Title:          notification.Title,
+		Body:           notification.Body,
+		URL:            url,
+	}
+}
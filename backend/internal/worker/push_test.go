@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/common"
+)
+
+func TestShouldPushNotificationHonoursTypeAndPreferences(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	fresh := now.Add(-time.Minute)
+
+	cases := []struct {
+		name         string
+		notification pushNotification
+		want         bool
+	}{
+		{"battle completed enabled", pushNotification{Type: notificationTypeBattleCompleted, CreatedAt: fresh, BattleCompleted: true}, true},
+		{"battle completed muted", pushNotification{Type: notificationTypeBattleCompleted, CreatedAt: fresh, NewFollower: true}, false},
+		{"watched battle verdict follows battle completed", pushNotification{Type: common.NotificationWatchedBattleVerdict, CreatedAt: fresh, BattleCompleted: true}, true},
+		{"watched battle turn", pushNotification{Type: common.NotificationWatchedBattleTurn, CreatedAt: fresh, BattleCompleted: true, NewFollower: true}, false},
+		{"new follower enabled", pushNotification{Type: notificationTypePersonaFollowed, CreatedAt: fresh, NewFollower: true}, true},
+		{"daily summary is opt-in already", pushNotification{Type: notificationTypeDailySummary, CreatedAt: fresh}, true},
+		{"low value type", pushNotification{Type: "template_used", CreatedAt: fresh, BattleCompleted: true, NewFollower: true}, false},
+		{"stale", pushNotification{Type: notificationTypeBattleCompleted, CreatedAt: now.Add(-pushMaxAge - time.Minute), BattleCompleted: true}, false},
+	}
+	for _, tc := range cases {
+		if got := shouldPushNotification(tc.notification, now); got != tc.want {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestBuildPushPayloadLinksTarget(t *testing.T) {
+	battle := buildPushPayload(pushNotification{ID: 7, Type: notificationTypeBattleCompleted, Title: "Your battle is done", Metadata: map[string]any{"battle_id": "b-1"}})
+	if battle.URL != "/b/b-1" || battle.NotificationID != 7 {
+		t.Fatalf("unexpected battle payload %+v", battle)
+	}
+	follow := buildPushPayload(pushNotification{Type: notificationTypePersonaFollowed, Metadata: map[string]any{"slug": "ada"}})
+	if follow.URL != "/p/ada" {
+		t.Fatalf("unexpected follow payload %+v", follow)
+	}
+	if fallback := buildPushPayload(pushNotification{Type: notificationTypeBattleCompleted, Metadata: map[string]any{}}); fallback.URL != "/" {
+		t.Fatalf("expected root url without metadata, got %q", fallback.URL)
+	}
+}
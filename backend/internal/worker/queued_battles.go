@@ -0,0 +1,323 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const queuedBattleModeInteractive = "interactive"
+
+type queuedBattle struct {
+	ID               int64
+	UserID           string
+	RoomID           string
+	RoomArchived     bool
+	TemplateID       string
+	TemplateName     string
+	TemplateTurns    int
+	TemplateOwnerID  string
+	Mode             string
+	AIPersonaID      string
+	Topic            string
+	ProStyle         string
+	ConStyle         string
+	LanguageMode     string
+	LanguageCode     string
+	SourceBattleID   string
+	SourceBattleUser string
+	ContextPack      bool
+	Status           string
+}
+
+// deferQueuedBattle pushes a create_battle job to the next daily window while
+// its owner is still at the daily battle limit, for example because they
+// created battles by hand since the reset. It runs before the job is locked,
+// like deferOutsideActiveHours, so waiting costs no attempt.
+func (w *Worker) deferQueuedBattle(ctx context.Context, tx pgx.Tx, jobID int64, payloadRaw []byte) (time.Time, error) {
+	var payload common.QueuedBattleJobPayload
+	if err := json.Unmarshal(payloadRaw, &payload); err != nil {
+		return time.Time{}, nil
+	}
+	var userID string
+	err := tx.QueryRow(ctx, `
+		SELECT user_id::text
+		FROM queued_battles
+		WHERE id = $1
+		  AND status = $2
+	`, payload.QueuedBattleID, common.QueuedBattleQueued).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	limit, err := common.BattleDailyLimit(ctx, tx, userID, w.cfg.BattleDailyLimit)
+	if err != nil || limit <= 0 {
+		return time.Time{}, err
+	}
+	used, err := common.BattlesCreatedToday(ctx, tx, userID)
+	if err != nil || used < limit {
+		return time.Time{}, err
+	}
+
+	next := common.NextBattleWindowReset(time.Now())
+	if _, err := tx.Exec(ctx, `
+		UPDATE jobs
+		SET available_at = $2, updated_at = NOW()
+		WHERE id = $1
+	`, jobID, next); err != nil {
+		return time.Time{}, err
+	}
+	return next, nil
+}
+
+// executeCreateBattle creates a battle that was queued over the daily limit.
+// The checks the request passed are repeated where they can change while the
+// battle waits (room archived, template deleted); a battle that can no longer
+// be created is marked FAILED on its queue row rather than retried.
+func (w *Worker) executeCreateBattle(ctx context.Context, payloadRaw []byte) error {
+	var payload common.QueuedBattleJobPayload
+	if err := json.Unmarshal(payloadRaw, &payload); err != nil || payload.QueuedBattleID <= 0 {
+		return permanentError{message: "invalid create battle payload"}
+	}
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var battle queuedBattle
+	err = tx.QueryRow(ctx, `
+		SELECT
+			qb.id,
+			qb.user_id::text,
+			COALESCE(rm.merged_into_room_id, rm.id)::text,
+			rm.archived_at IS NOT NULL,
+			COALESCE(t.id::text, ''),
+			COALESCE(t.name, ''),
+			COALESCE(t.turn_count, 0),
+			COALESCE(t.owner_user_id::text, ''),
+			qb.mode,
+			COALESCE(qb.ai_persona_id::text, ''),
+			qb.topic,
+			qb.pro_style,
+			qb.con_style,
+			qb.battle_language,
+			COALESCE(qb.battle_language_code, ''),
+			COALESCE(qb.source_battle_id::text, ''),
+			COALESCE(src.user_id::text, ''),
+			qb.context_pack,
+			qb.status
+		FROM queued_battles qb
+		JOIN rooms rm ON rm.id = qb.room_id
+		LEFT JOIN templates t ON t.id = qb.template_id
+		LEFT JOIN posts src ON src.id = qb.source_battle_id
+		WHERE qb.id = $1
+		FOR UPDATE OF qb
+	`, payload.QueuedBattleID).Scan(
+		&battle.ID,
+		&battle.UserID,
+		&battle.RoomID,
+		&battle.RoomArchived,
+		&battle.TemplateID,
+		&battle.TemplateName,
+		&battle.TemplateTurns,
+		&battle.TemplateOwnerID,
+		&battle.Mode,
+		&battle.AIPersonaID,
+		&battle.Topic,
+		&battle.ProStyle,
+		&battle.ConStyle,
+		&battle.LanguageMode,
+		&battle.LanguageCode,
+		&battle.SourceBattleID,
+		&battle.SourceBattleUser,
+		&battle.ContextPack,
+		&battle.Status,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return permanentError{message: "queued battle not found"}
+		}
+		return err
+	}
+	// Cancelled while the job waited.
+	if battle.Status != common.QueuedBattleQueued {
+		return nil
+	}
+	switch {
+	case battle.RoomArchived:
+		return w.failQueuedBattle(ctx, tx, battle.ID, "room is archived")
+	case battle.TemplateID == "":
+		return w.failQueuedBattle(ctx, tx, battle.ID, "template no longer exists")
+	case battle.Mode == queuedBattleModeInteractive && battle.AIPersonaID == "":
+		return w.failQueuedBattle(ctx, tx, battle.ID, "persona no longer exists")
+	}
+
+	var battleID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO posts(room_id, persona_id, user_id, authored_by, status, content, published_at, template_id, battle_language, battle_language_code)
+		VALUES ($1, NULL, $2, 'HUMAN', 'PUBLISHED', $3, NOW(), $4::uuid, $5, NULLIF($6, ''))
+		RETURNING id::text
+	`, battle.RoomID, battle.UserID,
+		common.BattleOpeningContent(battle.Topic, battle.TemplateName, battle.ProStyle, battle.ConStyle, w.cfg.DraftMaxLen),
+		battle.TemplateID, battle.LanguageMode, battle.LanguageCode).Scan(&battleID)
+	if err != nil {
+		return err
+	}
+
+	if battle.ContextPack {
+		if err := common.RequestBattleContextPack(ctx, tx, battleID, battle.RoomID, battle.Topic); err != nil {
+			return err
+		}
+	}
+
+	if battle.Mode == queuedBattleModeInteractive {
+		interactive := common.InteractiveBattle{
+			PostID:      battleID,
+			UserID:      battle.UserID,
+			AIPersonaID: battle.AIPersonaID,
+			TotalTurns:  common.InteractiveTurnCount(battle.TemplateTurns),
+			NextSide:    common.InteractiveSideAI,
+			Status:      common.InteractiveStatusActive,
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO interactive_battles(post_id, user_id, ai_persona_id, total_turns, next_side, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, interactive.PostID, interactive.UserID, interactive.AIPersonaID, interactive.TotalTurns, interactive.NextSide, interactive.Status); err != nil {
+			return err
+		}
+		if err := common.EnqueueInteractiveAITurn(ctx, tx, interactive, payload.TraceID); err != nil {
+			return err
+		}
+	} else {
+		if err := w.enqueueQueuedBattleReplies(ctx, tx, battle, battleID, payload.TraceID); err != nil {
+			return err
+		}
+	}
+
+	if err := w.notifyQueuedBattleCreated(ctx, tx, battle, battleID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE queued_battles
+		SET status = $2,
+			battle_id = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`, battle.ID, common.QueuedBattleCreated, battleID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("queued_battle_created", observability.Fields{
+		"queued_battle_id": battle.ID,
+		"battle_id":        battleID,
+		"user_id":          battle.UserID,
+		"trace_id":         payload.TraceID,
+	})
+	return nil
+}
+
+// enqueueQueuedBattleReplies queues the opening replies the API would have:
+// the owner's first personas, two of them or three for long templates. Reply
+// jobs enforce each persona's daily quota when they run.
+func (w *Worker) enqueueQueuedBattleReplies(ctx context.Context, tx pgx.Tx, battle queuedBattle, battleID, traceID string) error {
+	maxReplies := 2
+	if battle.TemplateTurns >= 8 {
+		maxReplies = 3
+	}
+	payloadMap := map[string]any{
+		"post_id":     battleID,
+		"template_id": battle.TemplateID,
+	}
+	if clean := strings.TrimSpace(traceID); clean != "" {
+		payloadMap["trace_id"] = clean
+	}
+	payload, err := json.Marshal(payloadMap)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+		SELECT 'generate_reply', $1::uuid, p.id, $3::jsonb || jsonb_build_object('persona_id', p.id::text), 'PENDING', NOW()
+		FROM (
+			SELECT id
+			FROM personas
+			WHERE user_id = $2
+			ORDER BY created_at ASC
+			LIMIT $4
+		) p
+	`, battleID, battle.UserID, payload, maxReplies)
+	return err
+}
+
+// notifyQueuedBattleCreated sends the template-used and remix notifications
+// the API sends for battles it creates directly.
+func (w *Worker) notifyQueuedBattleCreated(ctx context.Context, tx pgx.Tx, battle queuedBattle, battleID string) error {
+	if battle.TemplateOwnerID != "" && battle.TemplateOwnerID != battle.UserID {
+		metadata, err := json.Marshal(map[string]any{
+			"template_id":   battle.TemplateID,
+			"template_name": battle.TemplateName,
+			"battle_id":     battleID,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
+			VALUES ($1, $2, 'template_used', 'Your template was used', $3, $4::jsonb)
+		`, battle.TemplateOwnerID, battle.UserID,
+			common.TruncateRunes(fmt.Sprintf("Your template \"%s\" was used in a new battle.", battle.TemplateName), 260), metadata); err != nil {
+			return err
+		}
+	}
+	if battle.SourceBattleUser != "" && battle.SourceBattleUser != battle.UserID {
+		metadata, err := json.Marshal(map[string]any{
+			"source_battle_id": battle.SourceBattleID,
+			"battle_id":        battleID,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO notifications(user_id, actor_user_id, type, title, body, metadata)
+			VALUES ($1, $2, 'battle_remixed', 'Your battle was remixed', 'Someone remixed one of your battles.', $3::jsonb)
+		`, battle.SourceBattleUser, battle.UserID, metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Worker) failQueuedBattle(ctx context.Context, tx pgx.Tx, queuedBattleID int64, reason string) error {
+	if _, err := tx.Exec(ctx, `
+		UPDATE queued_battles
+		SET status = $2,
+			error = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`, queuedBattleID, common.QueuedBattleFailed, reason); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	w.logger.Warn("queued_battle_failed", observability.Fields{
+		"queued_battle_id": queuedBattleID,
+		"reason":           reason,
+	})
+	return nil
+}
@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type quotaCountKey struct {
+	PersonaID string
+	QuotaType string
+}
+
+type quotaDiscrepancy struct {
+	PersonaID string
+	QuotaType string
+	Recorded  int
+	Actual    int
+}
+
+// quotaRecordedCountsSQL counts the quota events of day $1 for the quota
+// types that leave content behind.
+const quotaRecordedCountsSQL = `
+	SELECT q.persona_id::text, q.quota_type, COUNT(*)::int
+	FROM quota_events q
+	WHERE q.created_at >= $1::date
+	  AND q.created_at < $1::date + 1
+	  AND q.quota_type IN ('draft', 'reply', 'preview')
+	GROUP BY q.persona_id, q.quota_type
+`
+
+// quotaActualCountsSQL counts what day $1 actually produced for each quota
+// type. Content that never spends quota is left out: battle openings, answers
+// to questions, interactive battle turns and content published after a
+// safety appeal (approved replies carry no prompt version). Previews are not
+// stored, so their preview_generated events stand in for them.
+const quotaActualCountsSQL = `
+	SELECT p.persona_id::text, 'draft', COUNT(*)::int
+	FROM posts p
+	WHERE p.created_at >= $1::date
+	  AND p.created_at < $1::date + 1
+	  AND p.authored_by = 'AI'
+	  AND p.persona_id IS NOT NULL
+	  AND p.template_id IS NULL
+	  AND NOT EXISTS (SELECT 1 FROM persona_questions q WHERE q.answer_post_id = p.id)
+	  AND NOT EXISTS (SELECT 1 FROM safety_rejections sr WHERE sr.resolved_post_id = p.id)
+	GROUP BY p.persona_id
+	UNION ALL
+	SELECT r.persona_id::text, 'reply', COUNT(*)::int
+	FROM replies r
+	WHERE r.created_at >= $1::date
+	  AND r.created_at < $1::date + 1
+	  AND r.authored_by = 'AI'
+	  AND r.persona_id IS NOT NULL
+	  AND r.prompt_version IS NOT NULL
+	  AND NOT EXISTS (SELECT 1 FROM interactive_battles ib WHERE ib.post_id = r.post_id)
+	GROUP BY r.persona_id
+	UNION ALL
+	SELECT pe.id::text, 'preview', COUNT(*)::int
+	FROM events e
+	JOIN personas pe ON pe.id::text = e.metadata->>'persona_id'
+	WHERE e.created_at >= $1::date
+	  AND e.created_at < $1::date + 1
+	  AND e.event_name = 'preview_generated'
+	GROUP BY pe.id
+`
+
+// diffQuotaCounts lists every persona and quota type whose recorded quota
+// use differs from what was created, ordered by persona then type.
+func diffQuotaCounts(recorded, actual map[quotaCountKey]int) []quotaDiscrepancy {
+	keys := make(map[quotaCountKey]struct{}, len(recorded)+len(actual))
+	for key := range recorded {
+		keys[key] = struct{}{}
+	}
+	for key := range actual {
+		keys[key] = struct{}{}
+	}
+
+	discrepancies := make([]quotaDiscrepancy, 0)
+	for key := range keys {
+		if recorded[key] == actual[key] {
+			continue
+		}
+		discrepancies = append(discrepancies, quotaDiscrepancy{
+			PersonaID: key.PersonaID,
+			QuotaType: key.QuotaType,
+			Recorded:  recorded[key],
+			Actual:    actual[key],
+		})
+	}
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].PersonaID != discrepancies[j].PersonaID {
+			return discrepancies[i].PersonaID < discrepancies[j].PersonaID
+		}
+		return discrepancies[i].QuotaType < discrepancies[j].QuotaType
+	})
+	return discrepancies
+}
+
+// reconcileQuotaEvents checks the previous UTC day once: quota events are
+// compared with the drafts, replies and previews that were actually created,
+// and every mismatch is stored in quota_discrepancies for admins to review.
+func (w *Worker) reconcileQuotaEvents(ctx context.Context) error {
+	day := time.Now().UTC().AddDate(0, 0, -1).Format(common.DateLayout)
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	claimed, err := tx.Exec(ctx, `
+		INSERT INTO quota_reconciliations(day)
+		VALUES ($1::date)
+		ON CONFLICT (day) DO NOTHING
+	`, day)
+	if err != nil {
+		return err
+	}
+	if claimed.RowsAffected() == 0 {
+		return nil
+	}
+
+	recorded, err := loadQuotaCounts(ctx, tx, quotaRecordedCountsSQL, day)
+	if err != nil {
+		return err
+	}
+	actual, err := loadQuotaCounts(ctx, tx, quotaActualCountsSQL, day)
+	if err != nil {
+		return err
+	}
+
+	discrepancies := diffQuotaCounts(recorded, actual)
+	byType := map[string]int{
+		common.QuotaTypeDraft:   0,
+		common.QuotaTypeReply:   0,
+		common.QuotaTypePreview: 0,
+	}
+	for _, d := range discrepancies {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO quota_discrepancies(day, persona_id, quota_type, recorded, actual)
+			VALUES ($1::date, $2, $3, $4, $5)
+		`, day, d.PersonaID, d.QuotaType, d.Recorded, d.Actual); err != nil {
+			return err
+		}
+		byType[d.QuotaType]++
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE quota_reconciliations
+		SET discrepancies = $2, completed_at = NOW()
+		WHERE day = $1::date
+	`, day, len(discrepancies)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.metrics.SetQuotaDiscrepancies(byType)
+	fields := observability.Fields{
+		"day":           day,
+		"discrepancies": len(discrepancies),
+	}
+	if len(discrepancies) > 0 {
+		w.logger.Warn("quota_reconciliation_mismatch", fields)
+	} else {
+		w.logger.Info("quota_reconciliation_clean", fields)
+	}
+	return nil
+}
+
+func loadQuotaCounts(ctx context.Context, tx pgx.Tx, query, day string) (map[quotaCountKey]int, error) {
+	rows, err := tx.Query(ctx, query, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[quotaCountKey]int{}
+	for rows.Next() {
+		var (
+			key   quotaCountKey
+			count int
+		)
+		if err := rows.Scan(&key.PersonaID, &key.QuotaType, &count); err != nil {
+			return nil, err
+		}
+		counts[key] += count
+	}
+	return counts, rows.Err()
+}
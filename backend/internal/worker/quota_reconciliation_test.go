@@ -0,0 +1,35 @@
+package worker
+
+import "testing"
+
+func TestDiffQuotaCounts(t *testing.T) {
+	recorded := map[quotaCountKey]int{
+		{PersonaID: "a", QuotaType: "draft"}: 3,
+		{PersonaID: "a", QuotaType: "reply"}: 2,
+		{PersonaID: "b", QuotaType: "reply"}: 1,
+	}
+	actual := map[quotaCountKey]int{
+		{PersonaID: "a", QuotaType: "draft"}:   3,
+		{PersonaID: "a", QuotaType: "reply"}:   4,
+		{PersonaID: "c", QuotaType: "preview"}: 1,
+	}
+
+	got := diffQuotaCounts(recorded, actual)
+	want := []quotaDiscrepancy{
+		{PersonaID: "a", QuotaType: "reply", Recorded: 2, Actual: 4},
+		{PersonaID: "b", QuotaType: "reply", Recorded: 1, Actual: 0},
+		{PersonaID: "c", QuotaType: "preview", Recorded: 0, Actual: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d discrepancies, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("discrepancy %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	if clean := diffQuotaCounts(recorded, recorded); len(clean) != 0 {
+		t.Fatalf("expected no discrepancies for matching counts, got %+v", clean)
+	}
+}
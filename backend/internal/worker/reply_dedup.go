@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	// replyDuplicateThreshold is the word overlap above which a new reply is
+	// treated as restating an existing reply on the same post.
+	replyDuplicateThreshold = 0.6
+	// turnRepeatThreshold is the lower bar for a persona's own earlier
+	// replies: restating yourself reads worse than echoing someone else.
+	turnRepeatThreshold    = 0.5
+	replyDedupMinWordRunes = 3
+)
+
+// replySimilarity is the Jaccard overlap of the distinct words (three letters
+// or more) in a and b, from 0 (nothing shared) to 1 (same word set).
+func replySimilarity(a, b string) float64 {
+	wordsA := replyWordSet(a)
+	wordsB := replyWordSet(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range wordsA {
+		if _, ok := wordsB[word]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+func replyWordSet(content string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < replyDedupMinWordRunes {
+			continue
+		}
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// closestReply returns the existing reply most similar to candidate and its
+// score. ok is false when the thread is empty.
+func closestReply(candidate string, thread []ai.ReplyContext) (ai.ReplyContext, float64, bool) {
+	var (
+		best      ai.ReplyContext
+		bestScore float64
+		found     bool
+	)
+	for _, reply := range thread {
+		score := replySimilarity(candidate, reply.Content)
+		if !found || score > bestScore {
+			best, bestScore, found = reply, score, true
+		}
+	}
+	return best, bestScore, found
+}
+
+// regenerateNearDuplicateReply asks once for a different angle when generated
+// restates another persona's reply on the post. The second attempt is kept
+// whatever its score; if it fails, the first reply is kept.
+func (w *Worker) regenerateNearDuplicateReply(ctx context.Context, persona ai.PersonaContext, post ai.PostContext, thread []ai.ReplyContext, generated string) string {
+	match, score, ok := closestReply(generated, thread)
+	if !ok || score < replyDuplicateThreshold {
+		return generated
+	}
+
+	post.AvoidReply = match.Content
+	retry, err := w.llm.GenerateReply(ctx, persona, post, thread)
+	fields := observability.Fields{
+		"post_id":          post.ID,
+		"persona_id":       persona.ID,
+		"similar_reply_id": match.ID,
+		"similarity":       math.Round(score*100) / 100,
+	}
+	if err != nil || strings.TrimSpace(retry) == "" {
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		fields["regenerated"] = false
+		w.logger.Warn("reply_near_duplicate", fields)
+		return generated
+	}
+
+	_, retryScore, _ := closestReply(retry, thread)
+	fields["regenerated"] = true
+	fields["retry_similarity"] = math.Round(retryScore*100) / 100
+	w.logger.Info("reply_near_duplicate", fields)
+	return retry
+}
+
+// ownReplies returns the replies personaID already wrote in the thread.
+func ownReplies(thread []ai.ReplyContext, personaID string) []ai.ReplyContext {
+	own := make([]ai.ReplyContext, 0)
+	for _, reply := range thread {
+		if personaID != "" && reply.PersonaID == personaID {
+			own = append(own, reply)
+		}
+	}
+	return own
+}
+
+// isRepetitiveTurn reports whether candidate restates one of the persona's
+// own earlier replies, and which one.
+func isRepetitiveTurn(candidate string, own []ai.ReplyContext) (ai.ReplyContext, float64, bool) {
+	match, score, ok := closestReply(candidate, own)
+	return match, score, ok && score >= turnRepeatThreshold
+}
+
+// regenerateRepetitiveTurn asks once for a new point when generated restates
+// the persona's own earlier reply in a long thread. Like the near-duplicate
+// retry, the second attempt is kept whatever its score and a failed retry
+// keeps the first reply.
+func (w *Worker) regenerateRepetitiveTurn(ctx context.Context, persona ai.PersonaContext, post ai.PostContext, thread []ai.ReplyContext, generated string) string {
+	own := ownReplies(thread, persona.ID)
+	match, score, repetitive := isRepetitiveTurn(generated, own)
+	if !repetitive {
+		return generated
+	}
+
+	post.RepeatedReply = match.Content
+	retry, err := w.llm.GenerateReply(ctx, persona, post, thread)
+	fields := observability.Fields{
+		"post_id":           post.ID,
+		"persona_id":        persona.ID,
+		"repeated_reply_id": match.ID,
+		"similarity":        math.Round(score*100) / 100,
+		"own_replies":       len(own),
+	}
+	if err != nil || strings.TrimSpace(retry) == "" {
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		fields["regenerated"] = false
+		w.logger.Warn("reply_repetitive_turn", fields)
+		return generated
+	}
+
+	_, retryScore, _ := closestReply(retry, own)
+	fields["regenerated"] = true
+	fields["retry_similarity"] = math.Round(retryScore*100) / 100
+	w.logger.Info("reply_repetitive_turn", fields)
+	return retry
+}
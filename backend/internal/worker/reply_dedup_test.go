@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"testing"
+
+	"personaworlds/backend/internal/ai"
+)
+
+func TestReplySimilarity(t *testing.T) {
+	a := "Ada reply (calm): I agree with the direction of the post. Run a small experiment, measure outcomes, and share findings."
+	b := "Grace reply (bold): I agree with the direction of the post. Run a small experiment, measure outcomes, and share findings."
+	if got := replySimilarity(a, b); got < replyDuplicateThreshold {
+		t.Fatalf("expected near-identical replies above threshold, got %.2f", got)
+	}
+
+	different := "Before experimenting, name the one risk that would make this fail and who carries it."
+	if got := replySimilarity(a, different); got >= replyDuplicateThreshold {
+		t.Fatalf("expected different angle below threshold, got %.2f", got)
+	}
+	if got := replySimilarity("", a); got != 0 {
+		t.Fatalf("expected empty reply to score 0, got %.2f", got)
+	}
+	if got := replySimilarity("Ship it, ship it now", "now SHIP it"); got != 1 {
+		t.Fatalf("expected case and repetition to be ignored, got %.2f", got)
+	}
+}
+
+func TestClosestReply(t *testing.T) {
+	if _, _, ok := closestReply("anything", nil); ok {
+		t.Fatalf("expected no match for an empty thread")
+	}
+	thread := []ai.ReplyContext{
+		{ID: "r1", Content: "Pricing pages should show annual discounts up front."},
+		{ID: "r2", Content: "Weekly releases keep the feedback loop short and honest."},
+	}
+	match, score, ok := closestReply("Short weekly releases keep feedback honest.", thread)
+	if !ok || match.ID != "r2" || score <= 0 {
+		t.Fatalf("expected r2 as closest reply, got %s (%.2f, %v)", match.ID, score, ok)
+	}
+}
+
+func TestIsRepetitiveTurn(t *testing.T) {
+	thread := []ai.ReplyContext{
+		{ID: "r1", PersonaID: "p1", Content: "Weekly releases keep the feedback loop short and honest."},
+		{ID: "r2", PersonaID: "", Content: "Weekly releases keep the feedback loop short and honest, agreed."},
+		{ID: "r3", PersonaID: "p2", Content: "Pricing pages should show annual discounts up front."},
+	}
+	own := ownReplies(thread, "p1")
+	if len(own) != 1 || own[0].ID != "r1" {
+		t.Fatalf("expected only p1's reply, got %+v", own)
+	}
+	if got := ownReplies(thread, ""); len(got) != 0 {
+		t.Fatalf("expected no own replies without a persona, got %+v", got)
+	}
+
+	match, _, repetitive := isRepetitiveTurn("Short weekly releases keep the feedback loop honest.", own)
+	if !repetitive || match.ID != "r1" {
+		t.Fatalf("expected restated point to match r1, got %s (%v)", match.ID, repetitive)
+	}
+	if _, _, repetitive := isRepetitiveTurn("Pricing pages should show annual discounts up front.", own); repetitive {
+		t.Fatalf("expected another persona's point not to count as repeating yourself")
+	}
+	if _, _, repetitive := isRepetitiveTurn("anything", nil); repetitive {
+		t.Fatalf("expected no repetition without earlier replies")
+	}
+}
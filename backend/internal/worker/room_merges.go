@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"errors"
+
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const roomMergeBatchSize = 200
+
+// mergeOneRoomBatch moves the next batch of posts of the oldest pending room
+// merge into its target room. Large rooms are merged across several worker
+// ticks; each batch commits on its own so a failure only retries that batch.
+func (w *Worker) mergeOneRoomBatch(ctx context.Context) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		mergeID      int64
+		sourceRoomID string
+		targetRoomID string
+		movedBefore  int
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT id, source_room_id::text, target_room_id::text, moved_posts
+		FROM room_merges
+		WHERE status = 'PENDING'
+		ORDER BY created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`).Scan(&mergeID, &sourceRoomID, &targetRoomID, &movedBefore)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH batch AS (
+			SELECT id
+			FROM posts
+			WHERE room_id = $1
+			ORDER BY created_at ASC, id ASC
+			LIMIT $3
+			FOR UPDATE
+		)
+		UPDATE posts p
+		SET room_id = $2
+		FROM batch
+		WHERE p.id = batch.id
+		RETURNING p.id::text
+	`, sourceRoomID, targetRoomID, roomMergeBatchSize)
+	if err != nil {
+		return err
+	}
+	movedPostIDs := make([]string, 0, roomMergeBatchSize)
+	for rows.Next() {
+		var postID string
+		if err := rows.Scan(&postID); err != nil {
+			rows.Close()
+			return err
+		}
+		movedPostIDs = append(movedPostIDs, postID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Activity events carry the room a post was in; keep them pointing at
+	// the room the post now lives in so digests group it correctly.
+	if len(movedPostIDs) > 0 {
+		if _, err := tx.Exec(ctx, `
+			UPDATE persona_activity_events
+			SET metadata = jsonb_set(metadata, '{room_id}', to_jsonb($2::text))
+			WHERE metadata->>'post_id' = ANY($3::text[])
+			  AND metadata->>'room_id' = $1
+		`, sourceRoomID, targetRoomID, movedPostIDs); err != nil {
+			return err
+		}
+	}
+
+	done := len(movedPostIDs) < roomMergeBatchSize
+	if done {
+		if _, err := tx.Exec(ctx, `
+			UPDATE persona_questions
+			SET room_id = $2
+			WHERE room_id = $1
+		`, sourceRoomID, targetRoomID); err != nil {
+			return err
+		}
+		// Fold the source room's presence into the target so personas keep
+		// their activity history in the room the posts now live in.
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO persona_room_presence_days(persona_id, room_id, day, post_count, reply_count, last_active_at)
+			SELECT persona_id, $2, day, post_count, reply_count, last_active_at
+			FROM persona_room_presence_days
+			WHERE room_id = $1
+			ON CONFLICT (persona_id, room_id, day)
+			DO UPDATE SET
+				post_count = persona_room_presence_days.post_count + EXCLUDED.post_count,
+				reply_count = persona_room_presence_days.reply_count + EXCLUDED.reply_count,
+				last_active_at = GREATEST(persona_room_presence_days.last_active_at, EXCLUDED.last_active_at)
+		`, sourceRoomID, targetRoomID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM persona_room_presence_days
+			WHERE room_id = $1
+		`, sourceRoomID); err != nil {
+			return err
+		}
+		// Roster enrollments follow the room; a persona enrolled in both keeps
+		// the target room's targets.
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO room_roster_enrollments(room_id, persona_id, weekly_posts, weekly_replies, created_at)
+			SELECT $2, persona_id, weekly_posts, weekly_replies, created_at
+			FROM room_roster_enrollments
+			WHERE room_id = $1
+			ON CONFLICT (room_id, persona_id) DO NOTHING
+		`, sourceRoomID, targetRoomID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM room_roster_enrollments
+			WHERE room_id = $1
+		`, sourceRoomID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE room_merges
+		SET moved_posts = moved_posts + $2,
+			status = CASE WHEN $3 THEN 'DONE' ELSE status END,
+			completed_at = CASE WHEN $3 THEN NOW() ELSE completed_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, mergeID, len(movedPostIDs), done); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	fields := observability.Fields{
+		"merge_id":       mergeID,
+		"source_room_id": sourceRoomID,
+		"target_room_id": targetRoomID,
+		"moved_posts":    movedBefore + len(movedPostIDs),
+	}
+	if done {
+		w.logger.Info("room_merge_completed", fields)
+	} else {
+		w.logger.Info("room_merge_batch", fields)
+	}
+	return nil
+}
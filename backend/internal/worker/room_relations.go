@@ -0,0 +1,338 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	roomRelationsWindowDays   = 30
+	roomRelationsPostsPerRoom = 100
+	roomRelationsMaxPerRoom   = 5
+	roomRelationsMinScore     = 0.05
+	roomKeywordsPerRoom       = 25
+	roomKeywordMinRunes       = 4
+	// Shared personas are the stronger signal: a persona active in both
+	// rooms is direct evidence the audiences overlap.
+	roomRelationPersonaWeight = 0.6
+	roomRelationKeywordWeight = 0.4
+)
+
+// roomStopWords are frequent English and Turkish words of four letters or
+// more that say nothing about a room's topic.
+var roomStopWords = map[string]struct{}{
+	"about": {}, "after": {}, "also": {}, "because": {}, "been": {}, "before": {}, "being": {},
+	"could": {}, "does": {}, "doing": {}, "each": {}, "even": {}, "every": {}, "from": {},
+	"have": {}, "here": {}, "into": {}, "just": {}, "like": {}, "make": {}, "more": {},
+	"most": {}, "much": {}, "need": {}, "only": {}, "other": {}, "over": {}, "really": {},
+	"same": {}, "should": {}, "some": {}, "than": {}, "that": {}, "their": {}, "them": {},
+	"then": {}, "there": {}, "these": {}, "they": {}, "thing": {}, "think": {}, "this": {},
+	"those": {}, "through": {}, "very": {}, "want": {}, "what": {}, "when": {}, "where": {},
+	"which": {}, "while": {}, "will": {}, "with": {}, "would": {}, "your": {}, "thread": {},
+	"replies": {}, "reply": {}, "bana": {}, "bence": {}, "bile": {}, "daha": {}, "değil": {},
+	"gibi": {}, "için": {}, "kadar": {}, "olan": {}, "olarak": {}, "sonra": {}, "şimdi": {},
+	"yani": {}, "çünkü": {},
+}
+
+type roomSignals struct {
+	ID       string
+	Personas map[string]struct{}
+	// Keywords is the room's most frequent topic words.
+	Keywords []string
+}
+
+type roomRelation struct {
+	RoomID         string
+	RelatedRoomID  string
+	Score          float64
+	SharedPersonas int
+	SharedKeywords []string
+}
+
+// refreshRoomRelations rebuilds room_relations once per UTC day. Signals
+// come from the last roomRelationsWindowDays of public posts and replies, so
+// rooms drift together or apart as their communities do.
+func (w *Worker) refreshRoomRelations(ctx context.Context) error {
+	day := time.Now().UTC().Format(common.DateLayout)
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	claimed, err := tx.Exec(ctx, `
+		INSERT INTO room_relation_runs(day)
+		VALUES ($1::date)
+		ON CONFLICT (day) DO NOTHING
+	`, day)
+	if err != nil {
+		return err
+	}
+	if claimed.RowsAffected() == 0 {
+		return nil
+	}
+
+	rooms, err := w.loadRoomSignals(ctx)
+	if err != nil {
+		return err
+	}
+	relations := buildRoomRelations(rooms)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM room_relations`); err != nil {
+		return err
+	}
+	for _, relation := range relations {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO room_relations(room_id, related_room_id, score, shared_personas, shared_keywords)
+			VALUES ($1, $2, $3, $4, $5)
+		`, relation.RoomID, relation.RelatedRoomID, relation.Score, relation.SharedPersonas, relation.SharedKeywords); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE room_relation_runs
+		SET rooms = $2, relations = $3, completed_at = NOW()
+		WHERE day = $1::date
+	`, day, len(rooms), len(relations)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("room_relations_refreshed", observability.Fields{
+		"day":       day,
+		"rooms":     len(rooms),
+		"relations": len(relations),
+	})
+	return nil
+}
+
+// loadRoomSignals collects, for every active room, the personas that posted
+// or replied in it recently and the keywords of its name, description and
+// recent public posts. Unlisted battles, posts hidden from rooms and drafts
+// are left out so shared keywords never reveal content that is not listed.
+func (w *Worker) loadRoomSignals(ctx context.Context) ([]roomSignals, error) {
+	rooms := []roomSignals{}
+	byID := map[string]int{}
+	text := map[string]*strings.Builder{}
+
+	rows, err := w.db.Query(ctx, `
+		SELECT id::text, name, description
+		FROM rooms
+		WHERE archived_at IS NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, name, description string
+		if err := rows.Scan(&id, &name, &description); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		byID[id] = len(rooms)
+		rooms = append(rooms, roomSignals{ID: id, Personas: map[string]struct{}{}})
+		text[id] = &strings.Builder{}
+		text[id].WriteString(name + " " + description)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = w.db.Query(ctx, `
+		SELECT p.room_id::text, p.persona_id::text
+		FROM posts p
+		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND p.persona_id IS NOT NULL
+		  AND p.created_at >= NOW() - ($1::int * INTERVAL '1 day')
+		UNION
+		SELECT p.room_id::text, r.persona_id::text
+		FROM replies r
+		JOIN posts p ON p.id = r.post_id
+		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND NOT p.hidden_from_rooms
+		  AND r.persona_id IS NOT NULL
+		  AND r.created_at >= NOW() - ($1::int * INTERVAL '1 day')
+	`, roomRelationsWindowDays)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var roomID, personaID string
+		if err := rows.Scan(&roomID, &personaID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if i, ok := byID[roomID]; ok {
+			rooms[i].Personas[personaID] = struct{}{}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = w.db.Query(ctx, `
+		SELECT room_id::text, content
+		FROM (
+			SELECT room_id, content,
+				ROW_NUMBER() OVER (PARTITION BY room_id ORDER BY created_at DESC) AS rank
+			FROM posts
+			WHERE status = 'PUBLISHED'
+			  AND visibility = 'public'
+			  AND NOT hidden_from_rooms
+			  AND created_at >= NOW() - ($1::int * INTERVAL '1 day')
+		) recent
+		WHERE rank <= $2
+	`, roomRelationsWindowDays, roomRelationsPostsPerRoom)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var roomID, content string
+		if err := rows.Scan(&roomID, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if builder, ok := text[roomID]; ok {
+			builder.WriteString(" " + content)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range rooms {
+		rooms[i].Keywords = roomKeywords(text[rooms[i].ID].String())
+	}
+	return rooms, nil
+}
+
+// roomKeywords returns the roomKeywordsPerRoom most frequent words of text,
+// skipping short words and stop words. Ties break alphabetically so the
+// result is stable.
+func roomKeywords(text string) []string {
+	counts := map[string]int{}
+	for _, word := range topicWords(text) {
+		counts[word]++
+	}
+	keywords := make([]string, 0, len(counts))
+	for word := range counts {
+		keywords = append(keywords, word)
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if counts[keywords[i]] != counts[keywords[j]] {
+			return counts[keywords[i]] > counts[keywords[j]]
+		}
+		return keywords[i] < keywords[j]
+	})
+	if len(keywords) > roomKeywordsPerRoom {
+		keywords = keywords[:roomKeywordsPerRoom]
+	}
+	return keywords
+}
+
+// topicWords splits text into lowercase words, dropping short words and
+// stop words.
+func topicWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) < roomKeywordMinRunes {
+			continue
+		}
+		if _, stop := roomStopWords[word]; stop {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	return kept
+}
+
+// buildRoomRelations scores every pair of rooms by the Jaccard overlap of
+// their active personas and of their keywords, and keeps each room's best
+// roomRelationsMaxPerRoom relations above roomRelationsMinScore.
+func buildRoomRelations(rooms []roomSignals) []roomRelation {
+	keywordSets := make([]map[string]struct{}, len(rooms))
+	for i, room := range rooms {
+		keywordSets[i] = make(map[string]struct{}, len(room.Keywords))
+		for _, keyword := range room.Keywords {
+			keywordSets[i][keyword] = struct{}{}
+		}
+	}
+
+	relations := []roomRelation{}
+	for i, room := range rooms {
+		candidates := []roomRelation{}
+		for j, other := range rooms {
+			if i == j {
+				continue
+			}
+			sharedPersonas := countShared(room.Personas, other.Personas)
+			sharedKeywords := []string{}
+			for _, keyword := range room.Keywords {
+				if _, ok := keywordSets[j][keyword]; ok {
+					sharedKeywords = append(sharedKeywords, keyword)
+				}
+			}
+			score := roomRelationPersonaWeight*jaccard(sharedPersonas, len(room.Personas), len(other.Personas)) +
+				roomRelationKeywordWeight*jaccard(len(sharedKeywords), len(keywordSets[i]), len(keywordSets[j]))
+			score = float64(int(score*1000+0.5)) / 1000
+			if score < roomRelationsMinScore {
+				continue
+			}
+			candidates = append(candidates, roomRelation{
+				RoomID:         room.ID,
+				RelatedRoomID:  other.ID,
+				Score:          score,
+				SharedPersonas: sharedPersonas,
+				SharedKeywords: sharedKeywords,
+			})
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			if candidates[a].Score != candidates[b].Score {
+				return candidates[a].Score > candidates[b].Score
+			}
+			return candidates[a].RelatedRoomID < candidates[b].RelatedRoomID
+		})
+		if len(candidates) > roomRelationsMaxPerRoom {
+			candidates = candidates[:roomRelationsMaxPerRoom]
+		}
+		relations = append(relations, candidates...)
+	}
+	return relations
+}
+
+func countShared(a, b map[string]struct{}) int {
+	shared := 0
+	for key := range a {
+		if _, ok := b[key]; ok {
+			shared++
+		}
+	}
+	return shared
+}
+
+func jaccard(shared, sizeA, sizeB int) float64 {
+	union := sizeA + sizeB - shared
+	if union <= 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
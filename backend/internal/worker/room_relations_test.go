@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoomKeywords(t *testing.T) {
+	got := roomKeywords("Startups: pricing, PRICING and pricing. Startups need growth; this is about growth, not ads.")
+	want := []string{"pricing", "growth", "startups"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := roomKeywords("bu ve ama için daha"); len(got) != 0 {
+		t.Fatalf("expected short and stop words to be dropped, got %v", got)
+	}
+}
+
+func TestBuildRoomRelations(t *testing.T) {
+	personas := func(ids ...string) map[string]struct{} {
+		set := map[string]struct{}{}
+		for _, id := range ids {
+			set[id] = struct{}{}
+		}
+		return set
+	}
+	rooms := []roomSignals{
+		{ID: "startups", Personas: personas("p1", "p2", "p3"), Keywords: []string{"pricing", "growth", "funding"}},
+		{ID: "marketing", Personas: personas("p2", "p3"), Keywords: []string{"growth", "pricing", "brand"}},
+		{ID: "poetry", Personas: personas("p9"), Keywords: []string{"verse", "meter"}},
+	}
+
+	relations := buildRoomRelations(rooms)
+	if len(relations) != 2 {
+		t.Fatalf("expected startups and marketing to relate both ways only, got %+v", relations)
+	}
+	first := relations[0]
+	if first.RoomID != "startups" || first.RelatedRoomID != "marketing" {
+		t.Fatalf("unexpected first relation %+v", first)
+	}
+	if first.SharedPersonas != 2 || !reflect.DeepEqual(first.SharedKeywords, []string{"pricing", "growth"}) {
+		t.Fatalf("unexpected shared signals %+v", first)
+	}
+	// 0.6 * 2/3 personas + 0.4 * 2/4 keywords
+	if first.Score != 0.6 {
+		t.Fatalf("expected score 0.6, got %v", first.Score)
+	}
+	if relations[1].RoomID != "marketing" || relations[1].Score != first.Score {
+		t.Fatalf("expected a symmetric relation, got %+v", relations[1])
+	}
+}
+
+func TestBuildRoomRelationsKeepsBestPerRoom(t *testing.T) {
+	rooms := []roomSignals{{ID: "hub", Personas: map[string]struct{}{"p0": {}}, Keywords: []string{"shared"}}}
+	for _, id := range []string{"r1", "r2", "r3", "r4", "r5", "r6", "r7"} {
+		rooms = append(rooms, roomSignals{ID: id, Personas: map[string]struct{}{}, Keywords: []string{"shared", id}})
+	}
+
+	count := 0
+	for _, relation := range buildRoomRelations(rooms) {
+		if relation.RoomID == "hub" {
+			count++
+		}
+	}
+	if count != roomRelationsMaxPerRoom {
+		t.Fatalf("expected %d relations for hub, got %d", roomRelationsMaxPerRoom, count)
+	}
+}
@@ -0,0 +1,281 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// rosterCheckEvery is how long an enrollment rests between checks. Each
+	// check queues at most one draft and one reply, so this also caps how
+	// fast a persona that fell behind catches up.
+	rosterCheckEvery = 10 * time.Minute
+	// rosterReplyWindow limits replies to posts published this recently.
+	rosterReplyWindow = 7 * 24 * time.Hour
+)
+
+type rosterEnrollment struct {
+	RoomID          string
+	PersonaID       string
+	OwnerUserID     string
+	WeeklyPosts     int
+	WeeklyReplies   int
+	DailyDraftQuota int
+	DailyReplyQuota int
+}
+
+// rosterProgress counts this week's activity of an enrollment. Posts are
+// published this week or still waiting as drafts; pending counts are queued
+// jobs that will add to them.
+type rosterProgress struct {
+	Posts          int
+	PendingPosts   int
+	Replies        int
+	PendingReplies int
+}
+
+// rosterBehind reports whether done plus queued work is short of what the
+// weekly target asks for by now.
+func rosterBehind(target, done, pending int, now time.Time) bool {
+	return done+pending < common.RosterDue(target, now)
+}
+
+// scheduleOneRosterEnrollment checks the enrollment that has waited longest
+// and queues a draft and a reply if its weekly targets are behind pace,
+// within the persona's daily quotas. Drafts still go to the owner for
+// review; replies publish like any other generated reply.
+func (w *Worker) scheduleOneRosterEnrollment(ctx context.Context) error {
+	var enrollment rosterEnrollment
+	err := w.db.QueryRow(ctx, `
+		UPDATE room_roster_enrollments e
+		SET checked_at = NOW()
+		FROM personas pe
+		WHERE (e.room_id, e.persona_id) = (
+				SELECT c.room_id, c.persona_id
+				FROM room_roster_enrollments c
+				JOIN rooms rm ON rm.id = c.room_id
+				WHERE (c.checked_at IS NULL OR c.checked_at <= NOW() - make_interval(secs => $1::double precision))
+				  AND rm.archived_at IS NULL
+				  AND (rm.event_starts_at IS NULL OR rm.event_starts_at <= NOW())
+				  AND (rm.event_ends_at IS NULL OR rm.event_ends_at > NOW())
+				ORDER BY c.checked_at ASC NULLS FIRST
+				LIMIT 1
+				FOR UPDATE OF c SKIP LOCKED
+		  )
+		  AND pe.id = e.persona_id
+		RETURNING e.room_id::text, e.persona_id::text, pe.user_id::text, e.weekly_posts, e.weekly_replies,
+			pe.daily_draft_quota, pe.daily_reply_quota
+	`, rosterCheckEvery.Seconds()).Scan(
+		&enrollment.RoomID,
+		&enrollment.PersonaID,
+		&enrollment.OwnerUserID,
+		&enrollment.WeeklyPosts,
+		&enrollment.WeeklyReplies,
+		&enrollment.DailyDraftQuota,
+		&enrollment.DailyReplyQuota,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now().UTC()
+	progress, err := w.loadRosterProgress(ctx, enrollment, common.RosterWeekStart(now))
+	if err != nil {
+		return err
+	}
+
+	var draftJobID, replyJobID int64
+	if rosterBehind(enrollment.WeeklyPosts, progress.Posts, progress.PendingPosts, now) {
+		draftJobID, err = w.queueRosterDraft(ctx, enrollment)
+		if err != nil {
+			return err
+		}
+	}
+	if rosterBehind(enrollment.WeeklyReplies, progress.Replies, progress.PendingReplies, now) {
+		replyJobID, err = w.queueRosterReply(ctx, enrollment)
+		if err != nil {
+			return err
+		}
+	}
+	if draftJobID == 0 && replyJobID == 0 {
+		return nil
+	}
+
+	w.logger.Info("roster_scheduled", observability.Fields{
+		"room_id":        enrollment.RoomID,
+		"persona_id":     enrollment.PersonaID,
+		"draft_job_id":   draftJobID,
+		"reply_job_id":   replyJobID,
+		"posts":          progress.Posts,
+		"weekly_posts":   enrollment.WeeklyPosts,
+		"replies":        progress.Replies,
+		"weekly_replies": enrollment.WeeklyReplies,
+	})
+	return nil
+}
+
+func (w *Worker) loadRosterProgress(ctx context.Context, enrollment rosterEnrollment, weekStart time.Time) (rosterProgress, error) {
+	var progress rosterProgress
+	err := w.db.QueryRow(ctx, `
+		SELECT
+			(
+				SELECT COUNT(*)::int
+				FROM posts
+				WHERE room_id = $1
+				  AND persona_id = $2
+				  AND (published_at >= $3 OR (status = 'DRAFT' AND created_at >= $3))
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM jobs
+				WHERE job_type = $4
+				  AND persona_id = $2
+				  AND payload->>'room_id' = $1::text
+				  AND (status IN ('PENDING', 'PROCESSING') OR (status = 'FAILED' AND attempts < $5))
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM replies rp
+				JOIN posts p ON p.id = rp.post_id
+				WHERE p.room_id = $1
+				  AND rp.persona_id = $2
+				  AND rp.created_at >= $3
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM jobs j
+				JOIN posts p ON p.id = j.post_id
+				WHERE j.job_type = 'generate_reply'
+				  AND j.persona_id = $2
+				  AND p.room_id = $1
+				  AND (j.status IN ('PENDING', 'PROCESSING') OR (j.status = 'FAILED' AND j.attempts < $5))
+			)
+	`, enrollment.RoomID, enrollment.PersonaID, weekStart, common.JobGenerateDraft, maxJobAttempts(w.cfg.JobMaxAttempts)).Scan(
+		&progress.Posts,
+		&progress.PendingPosts,
+		&progress.Replies,
+		&progress.PendingReplies,
+	)
+	return progress, err
+}
+
+// queueRosterDraft queues a generate_draft job unless today's draft quota,
+// counting drafts already queued in any room, is used up. It returns 0 when
+// nothing was queued.
+func (w *Worker) queueRosterDraft(ctx context.Context, enrollment rosterEnrollment) (int64, error) {
+	var used, queued int
+	if err := w.db.QueryRow(ctx, `
+		SELECT
+			(
+				SELECT COUNT(*)::int
+				FROM quota_events
+				WHERE persona_id = $1
+				  AND quota_type = 'draft'
+				  AND created_at >= date_trunc('day', NOW())
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM jobs
+				WHERE job_type = $2
+				  AND persona_id = $1
+				  AND (status IN ('PENDING', 'PROCESSING') OR (status = 'FAILED' AND attempts < $3))
+			)
+	`, enrollment.PersonaID, common.JobGenerateDraft, maxJobAttempts(w.cfg.JobMaxAttempts)).Scan(&used, &queued); err != nil {
+		return 0, err
+	}
+	boost, err := common.ActiveQuotaBoost(ctx, w.db, enrollment.OwnerUserID, common.QuotaBoostDraft)
+	if err != nil {
+		return 0, err
+	}
+	if used+queued >= enrollment.DailyDraftQuota+boost {
+		return 0, nil
+	}
+
+	payload, err := json.Marshal(common.DraftJobPayload{
+		UserID: enrollment.OwnerUserID,
+		RoomID: enrollment.RoomID,
+		Source: common.JobSourceRoster,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var jobID int64
+	err = w.db.QueryRow(ctx, `
+		INSERT INTO jobs(job_type, persona_id, payload, status, available_at)
+		VALUES ($1, $2, $3::jsonb, 'PENDING', NOW())
+		RETURNING id
+	`, common.JobGenerateDraft, enrollment.PersonaID, payload).Scan(&jobID)
+	return jobID, err
+}
+
+// queueRosterReply queues a generate_reply job on the room's quietest recent
+// post the persona has not replied to, unless today's reply quota, counting
+// replies already queued, is used up. Battles that take turns in a fixed
+// order are left alone. It returns 0 when nothing was queued.
+func (w *Worker) queueRosterReply(ctx context.Context, enrollment rosterEnrollment) (int64, error) {
+	var used, queued int
+	if err := w.db.QueryRow(ctx, `
+		SELECT
+			(
+				SELECT COUNT(*)::int
+				FROM quota_events
+				WHERE persona_id = $1
+				  AND quota_type = 'reply'
+				  AND created_at >= date_trunc('day', NOW())
+			),
+			(
+				SELECT COUNT(*)::int
+				FROM jobs
+				WHERE job_type = 'generate_reply'
+				  AND persona_id = $1
+				  AND status IN ('PENDING', 'PROCESSING')
+			)
+	`, enrollment.PersonaID).Scan(&used, &queued); err != nil {
+		return 0, err
+	}
+	if used+queued >= enrollment.DailyReplyQuota {
+		return 0, nil
+	}
+
+	var jobID int64
+	err := w.db.QueryRow(ctx, `
+		INSERT INTO jobs(job_type, post_id, persona_id, payload, status, available_at)
+		SELECT 'generate_reply', p.id, $2,
+			jsonb_build_object('post_id', p.id::text, 'persona_id', $2::text, 'source', $4::text), 'PENDING', NOW()
+		FROM posts p
+		WHERE p.room_id = $1
+		  AND p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
+		  AND p.persona_id IS DISTINCT FROM $2
+		  AND p.published_at >= NOW() - make_interval(secs => $3::double precision)
+		  AND NOT EXISTS (SELECT 1 FROM replies rp WHERE rp.post_id = p.id AND rp.persona_id = $2)
+		  AND NOT EXISTS (
+				SELECT 1
+				FROM jobs j
+				WHERE j.post_id = p.id
+				  AND j.persona_id = $2
+				  AND j.job_type = 'generate_reply'
+				  AND j.status IN ('PENDING', 'PROCESSING')
+		  )
+		  AND NOT EXISTS (SELECT 1 FROM interactive_battles WHERE post_id = p.id)
+		  AND NOT EXISTS (SELECT 1 FROM battle_challenges WHERE battle_post_id = p.id)
+		  AND NOT EXISTS (SELECT 1 FROM battle_archives WHERE post_id = p.id)
+		ORDER BY (SELECT COUNT(*) FROM replies rp WHERE rp.post_id = p.id) ASC, p.published_at DESC
+		LIMIT 1
+		RETURNING id
+	`, enrollment.RoomID, enrollment.PersonaID, rosterReplyWindow.Seconds(), common.JobSourceRoster).Scan(&jobID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return jobID, err
+}
@@ -0,0 +1,25 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRosterBehindCountsQueuedWork(t *testing.T) {
+	// Thursday noon of a week starting Monday 2 March 2026: 3.5 days in, so
+	// half of a target is due.
+	now := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+
+	if !rosterBehind(10, 3, 1, now) {
+		t.Fatalf("expected 4 of 5 due replies to be behind")
+	}
+	if rosterBehind(10, 3, 2, now) {
+		t.Fatalf("expected queued replies to count toward the pace")
+	}
+	if rosterBehind(0, 0, 0, now) {
+		t.Fatalf("expected an empty target never to be behind")
+	}
+	if rosterBehind(3, 3, 0, now) {
+		t.Fatalf("expected a met target not to be behind")
+	}
+}
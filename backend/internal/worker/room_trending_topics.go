@@ -0,0 +1,257 @@
+package worker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+const (
+	trendingTopicsWindowDays   = 14
+	trendingTopicsItemsPerRoom = 200
+	trendingTopicsPerRoom      = 5
+	// trendingTopicMinItems is how many battles and posts must share a
+	// keyword before it counts as a topic.
+	trendingTopicMinItems    = 2
+	trendingTopicMaxKeywords = 4
+	trendingTopicMaxExamples = 3
+	trendingTopicLabelWords  = 2
+)
+
+// trendingItem is one recent battle or post of a room. BattleID is empty
+// for posts.
+type trendingItem struct {
+	BattleID string
+	Words    map[string]struct{}
+}
+
+type trendingTopic struct {
+	Label            string
+	Keywords         []string
+	Battles          int
+	Posts            int
+	ExampleBattleIDs []string
+}
+
+// refreshRoomTrendingTopics rebuilds room_trending_topics once per UTC day
+// from the last trendingTopicsWindowDays of battles and public posts.
+func (w *Worker) refreshRoomTrendingTopics(ctx context.Context) error {
+	day := time.Now().UTC().Format(common.DateLayout)
+
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	claimed, err := tx.Exec(ctx, `
+		INSERT INTO room_trending_topic_runs(day)
+		VALUES ($1::date)
+		ON CONFLICT (day) DO NOTHING
+	`, day)
+	if err != nil {
+		return err
+	}
+	if claimed.RowsAffected() == 0 {
+		return nil
+	}
+
+	itemsByRoom, err := w.loadTrendingItems(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM room_trending_topics`); err != nil {
+		return err
+	}
+	total := 0
+	for roomID, items := range itemsByRoom {
+		for i, topic := range buildTrendingTopics(items) {
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO room_trending_topics(room_id, rank, label, keywords, battles, posts, example_battle_ids)
+				VALUES ($1, $2, $3, $4, $5, $6, $7::uuid[])
+			`, roomID, i+1, topic.Label, topic.Keywords, topic.Battles, topic.Posts, topic.ExampleBattleIDs); err != nil {
+				return err
+			}
+			total++
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE room_trending_topic_runs
+		SET rooms = $2, topics = $3, completed_at = NOW()
+		WHERE day = $1::date
+	`, day, len(itemsByRoom), total); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	w.logger.Info("room_trending_topics_refreshed", observability.Fields{
+		"day":    day,
+		"rooms":  len(itemsByRoom),
+		"topics": total,
+	})
+	return nil
+}
+
+// loadTrendingItems returns, per active room, its most recent battles and
+// posts, newest first. Battles contribute only their topic line so template
+// boilerplate does not drown the topics; unlisted battles, posts hidden from
+// rooms and drafts are left out.
+func (w *Worker) loadTrendingItems(ctx context.Context) (map[string][]trendingItem, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT room_id::text, id::text, content, is_battle
+		FROM (
+			SELECT p.room_id, p.id, p.content, p.template_id IS NOT NULL AS is_battle, p.created_at,
+				ROW_NUMBER() OVER (PARTITION BY p.room_id ORDER BY p.created_at DESC) AS rank
+			FROM posts p
+			JOIN rooms rm ON rm.id = p.room_id
+			WHERE rm.archived_at IS NULL
+			  AND p.status = 'PUBLISHED'
+			  AND p.visibility = 'public'
+			  AND NOT p.hidden_from_rooms
+			  AND p.created_at >= NOW() - ($1::int * INTERVAL '1 day')
+		) recent
+		WHERE rank <= $2
+		ORDER BY room_id, created_at DESC
+	`, trendingTopicsWindowDays, trendingTopicsItemsPerRoom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itemsByRoom := map[string][]trendingItem{}
+	for rows.Next() {
+		var (
+			roomID, postID, content string
+			isBattle                bool
+		)
+		if err := rows.Scan(&roomID, &postID, &content, &isBattle); err != nil {
+			return nil, err
+		}
+		item := trendingItem{Words: map[string]struct{}{}}
+		if isBattle {
+			item.BattleID = postID
+			content = battleTopicLine(content)
+		}
+		for _, word := range topicWords(content) {
+			item.Words[word] = struct{}{}
+		}
+		if len(item.Words) > 0 {
+			itemsByRoom[roomID] = append(itemsByRoom[roomID], item)
+		}
+	}
+	return itemsByRoom, rows.Err()
+}
+
+// battleTopicLine returns the topic of a battle opening, which starts with a
+// "Topic: ..." line, or the first line of other content.
+func battleTopicLine(content string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	line = strings.TrimSpace(line)
+	if rest, ok := strings.CutPrefix(line, "Topic:"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return line
+}
+
+// buildTrendingTopics clusters items greedily: the keyword most items share
+// seeds a cluster of every item that mentions it, the keywords at least half
+// of the cluster also mention describe it, and clustered items are set aside
+// before the next seed is picked. items are newest first, so example battles
+// are the latest ones.
+func buildTrendingTopics(items []trendingItem) []trendingTopic {
+	remaining := items
+	topics := []trendingTopic{}
+	for len(topics) < trendingTopicsPerRoom {
+		seed, count := mostSharedWord(remaining)
+		if count < trendingTopicMinItems {
+			break
+		}
+
+		cluster := []trendingItem{}
+		rest := []trendingItem{}
+		for _, item := range remaining {
+			if _, ok := item.Words[seed]; ok {
+				cluster = append(cluster, item)
+			} else {
+				rest = append(rest, item)
+			}
+		}
+		remaining = rest
+
+		topic := trendingTopic{Keywords: []string{seed}, ExampleBattleIDs: []string{}}
+		for _, word := range coWords(cluster, seed) {
+			if len(topic.Keywords) >= trendingTopicMaxKeywords {
+				break
+			}
+			topic.Keywords = append(topic.Keywords, word)
+		}
+		labelWords := topic.Keywords
+		if len(labelWords) > trendingTopicLabelWords {
+			labelWords = labelWords[:trendingTopicLabelWords]
+		}
+		topic.Label = strings.Join(labelWords, " ")
+		for _, item := range cluster {
+			if item.BattleID == "" {
+				topic.Posts++
+				continue
+			}
+			topic.Battles++
+			if len(topic.ExampleBattleIDs) < trendingTopicMaxExamples {
+				topic.ExampleBattleIDs = append(topic.ExampleBattleIDs, item.BattleID)
+			}
+		}
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// mostSharedWord returns the word most items contain and how many do. Ties
+// break alphabetically so clusters are stable.
+func mostSharedWord(items []trendingItem) (string, int) {
+	counts := map[string]int{}
+	for _, item := range items {
+		for word := range item.Words {
+			counts[word]++
+		}
+	}
+	best, bestCount := "", 0
+	for word, count := range counts {
+		if count > bestCount || (count == bestCount && word < best) {
+			best, bestCount = word, count
+		}
+	}
+	return best, bestCount
+}
+
+// coWords returns the words other than seed that at least half of cluster,
+// and at least trendingTopicMinItems items, mention, most frequent first.
+func coWords(cluster []trendingItem, seed string) []string {
+	counts := map[string]int{}
+	for _, item := range cluster {
+		for word := range item.Words {
+			if word != seed {
+				counts[word]++
+			}
+		}
+	}
+	words := []string{}
+	for word, count := range counts {
+		if count >= trendingTopicMinItems && count*2 >= len(cluster) {
+			words = append(words, word)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBattleTopicLine(t *testing.T) {
+	content := "Topic: Should startups raise prices early?\nTemplate: Classic\nPro style: Bold"
+	if got := battleTopicLine(content); got != "Should startups raise prices early?" {
+		t.Fatalf("unexpected topic %q", got)
+	}
+	if got := battleTopicLine("  Pricing is hard.\nMore text"); got != "Pricing is hard." {
+		t.Fatalf("expected the first line, got %q", got)
+	}
+}
+
+func TestBuildTrendingTopics(t *testing.T) {
+	item := func(battleID, text string) trendingItem {
+		words := map[string]struct{}{}
+		for _, word := range topicWords(text) {
+			words[word] = struct{}{}
+		}
+		return trendingItem{BattleID: battleID, Words: words}
+	}
+	items := []trendingItem{
+		item("b1", "Should startups raise pricing early?"),
+		item("", "Our pricing experiment doubled startups revenue"),
+		item("b2", "Pricing tiers for startups"),
+		item("b3", "Remote work hurts mentoring"),
+		item("", "Remote mentoring works with pairing"),
+		item("b4", "Pricing for enterprise"),
+		item("b5", "Poetry meter"),
+	}
+
+	topics := buildTrendingTopics(items)
+	if len(topics) != 2 {
+		t.Fatalf("expected pricing and remote work topics, got %+v", topics)
+	}
+
+	pricing := topics[0]
+	if pricing.Label != "pricing startups" || !reflect.DeepEqual(pricing.Keywords, []string{"pricing", "startups"}) {
+		t.Fatalf("unexpected pricing topic %+v", pricing)
+	}
+	if pricing.Battles != 3 || pricing.Posts != 1 {
+		t.Fatalf("expected 3 battles and 1 post, got %+v", pricing)
+	}
+	if !reflect.DeepEqual(pricing.ExampleBattleIDs, []string{"b1", "b2", "b4"}) {
+		t.Fatalf("expected the newest battles as examples, got %v", pricing.ExampleBattleIDs)
+	}
+
+	remote := topics[1]
+	if remote.Label != "mentoring remote" || remote.Battles != 1 || remote.Posts != 1 {
+		t.Fatalf("unexpected remote topic %+v", remote)
+	}
+
+	if got := buildTrendingTopics(items[6:]); len(got) != 0 {
+		t.Fatalf("expected no topic from a single item, got %+v", got)
+	}
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+
+	"personaworlds/backend/internal/common"
+	"personaworlds/backend/internal/observability"
+)
+
+// checkGeneratedContent applies the admin-tuned safety rules to generated
+// content and stores a rejection for the persona owner when it fails.
+func (w *Worker) checkGeneratedContent(ctx context.Context, rejection common.SafetyRejection) error {
+	return w.checkBattleTurn(ctx, rejection, common.TemplatePolicy{})
+}
+
+// checkBattleTurn is checkGeneratedContent for a battle turn whose template
+// has an approved policy: the policy adjusts the safety rules and then its
+// turn limits are checked too.
+func (w *Worker) checkBattleTurn(ctx context.Context, rejection common.SafetyRejection, policy common.TemplatePolicy) error {
+	rules, err := w.safetyRules.Load(ctx, w.cfg.SafetySyncEvery, w.db)
+	if err != nil {
+		w.logger.Warn("safety_rules_load_failed", observability.Fields{"error": err.Error()})
+	}
+	rules = policy.ApplySafety(rules).ForLanguage(rejection.Language)
+	violation := rules.Validate(rejection.Content, rejection.MaxLen)
+	if violation == nil {
+		violation = policy.CheckTurn(rejection.Content)
+	}
+	if violation == nil {
+		if warnings := rules.Warnings(rejection.Content); len(warnings) > 0 {
+			w.logger.Warn("safety_lexicon_warned", common.SafetyWarningFields(rejection, warnings))
+		}
+		return nil
+	}
+	if _, err := common.RecordSafetyRejection(ctx, w.db, rejection, violation); err != nil {
+		w.logger.Error("safety_rejection_write_failed", observability.Fields{
+			"source": rejection.Source,
+			"error":  err.Error(),
+		})
+	}
+	return violation
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// shardHashSQL maps a user id to an unsigned 32-bit hash in SQL. It must stay
+// in step with shardHash so tests and tooling can tell which shard owns a user.
+const shardHashSQL = `('x' || substr(md5(%s::text), 1, 8))::bit(32)::bigint`
+
+// shardConfig is the slice of the job queue this worker claims. Jobs are
+// assigned by a hash of the persona owner's user id, so one user's jobs stay on
+// one worker. Jobs left waiting longer than stealAfter can be claimed by any
+// shard, which keeps the queue draining while instances are added or removed.
+type shardConfig struct {
+	index      int
+	count      int
+	stealAfter time.Duration
+}
+
+func newShardConfig(index, count int, stealAfter time.Duration) shardConfig {
+	if count < 1 {
+		count = 1
+	}
+	index %= count
+	if index < 0 {
+		index += count
+	}
+	if stealAfter < 0 {
+		stealAfter = 0
+	}
+	return shardConfig{index: index, count: count, stealAfter: stealAfter}
+}
+
+// shardOwnedSQL is the claim predicate matching shardConfig.owns for the
+// given user id column and shard count/index placeholders.
+func shardOwnedSQL(userIDColumn, countParam, indexParam string) string {
+	return fmt.Sprintf(
+		"(%[2]s::int <= 1 OR mod(%[1]s, %[2]s::int) = %[3]s::int)",
+		fmt.Sprintf(shardHashSQL, userIDColumn),
+		countParam,
+		indexParam,
+	)
+}
+
+func (s shardConfig) label() string {
+	return fmt.Sprintf("%d/%d", s.index, s.count)
+}
+
+func (s shardConfig) owns(userID string) bool {
+	return shardFor(userID, s.count) == s.index
+}
+
+func shardHash(userID string) uint32 {
+	sum := md5.Sum([]byte(userID))
+	return binary.BigEndian.Uint32(sum[:4])
+}
+
+func shardFor(userID string, count int) int {
+	if count <= 1 {
+		return 0
+	}
+	return int(shardHash(userID) % uint32(count))
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewShardConfigNormalizes(t *testing.T) {
+	cases := []struct {
+		index, count         int
+		wantIndex, wantCount int
+	}{
+		{index: 0, count: 0, wantIndex: 0, wantCount: 1},
+		{index: 3, count: 1, wantIndex: 0, wantCount: 1},
+		{index: 5, count: 4, wantIndex: 1, wantCount: 4},
+		{index: -1, count: 4, wantIndex: 3, wantCount: 4},
+		{index: 2, count: 4, wantIndex: 2, wantCount: 4},
+	}
+	for _, tc := range cases {
+		shard := newShardConfig(tc.index, tc.count, time.Minute)
+		if shard.index != tc.wantIndex || shard.count != tc.wantCount {
+			t.Fatalf("newShardConfig(%d, %d) = %s, want %d/%d", tc.index, tc.count, shard.label(), tc.wantIndex, tc.wantCount)
+		}
+	}
+	if shard := newShardConfig(0, 2, -time.Second); shard.stealAfter != 0 {
+		t.Fatalf("expected negative steal delay to clamp to zero, got %s", shard.stealAfter)
+	}
+}
+
+func TestShardOwnedSQL(t *testing.T) {
+	got := shardOwnedSQL("pe.user_id", "$2", "$3")
+	want := "($2::int <= 1 OR mod(('x' || substr(md5(pe.user_id::text), 1, 8))::bit(32)::bigint, $2::int) = $3::int)"
+	if got != want {
+		t.Fatalf("unexpected predicate:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestShardHashMatchesSQLPrefix(t *testing.T) {
+	// md5("abc") = 900150983cd24fb0...; the SQL takes the first 8 hex digits.
+	if got := shardHash("abc"); got != 0x90015098 {
+		t.Fatalf("expected 0x90015098, got %#x", got)
+	}
+}
+
+func TestShardForCoversEveryUserExactlyOnce(t *testing.T) {
+	const count = 4
+	shards := make([]shardConfig, count)
+	for i := range shards {
+		shards[i] = newShardConfig(i, count, 0)
+	}
+
+	perShard := make([]int, count)
+	for i := 0; i < 400; i++ {
+		userID := fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+		owners := 0
+		for _, shard := range shards {
+			if shard.owns(userID) {
+				owners++
+				perShard[shard.index]++
+			}
+		}
+		if owners != 1 {
+			t.Fatalf("expected user %s to have exactly one owner, got %d", userID, owners)
+		}
+	}
+	for index, n := range perShard {
+		if n == 0 {
+			t.Fatalf("expected shard %d to own some users, got none (%v)", index, perShard)
+		}
+	}
+}
+
+func TestSingleShardOwnsEverything(t *testing.T) {
+	shard := newShardConfig(0, 1, 0)
+	if !shard.owns("any-user") || shard.label() != "0/1" {
+		t.Fatalf("expected single shard to own all users")
+	}
+}
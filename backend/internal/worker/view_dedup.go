@@ -0,0 +1,24 @@
+package worker
+
+import "context"
+
+const (
+	viewDedupRetentionDays = 31
+	viewDedupPruneBatch    = 1000
+)
+
+// pruneViewDedup drops daily view de-duplication rows once they fall outside
+// the 30-day window the owner analytics report on. The rolled-up counters on
+// profiles and posts are unaffected.
+func (w *Worker) pruneViewDedup(ctx context.Context) error {
+	_, err := w.db.Exec(ctx, `
+		DELETE FROM content_view_dedup
+		WHERE ctid IN (
+			SELECT ctid
+			FROM content_view_dedup
+			WHERE view_date < CURRENT_DATE - $1::int
+			LIMIT $2
+		)
+	`, viewDedupRetentionDays, viewDedupPruneBatch)
+	return err
+}
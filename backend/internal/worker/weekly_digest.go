@@ -37,6 +37,16 @@ type weeklyDigestItem struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// weeklyDigestAnnouncement is a major announcement carried in the digest of
+// the week it was published.
+type weeklyDigestAnnouncement struct {
+	ID       int64     `json:"id"`
+	Title    string    `json:"title"`
+	Body     string    `json:"body"`
+	LinkURL  string    `json:"link_url,omitempty"`
+	StartsAt time.Time `json:"starts_at"`
+}
+
 func (w *Worker) generateWeeklyDigestForOneUser(ctx context.Context) error {
 	var userID string
 	err := w.db.QueryRow(ctx, `
@@ -89,18 +99,56 @@ func (w *Worker) generateWeeklyDigestForOneUser(ctx context.Context) error {
 		return err
 	}
 
+	announcements, err := w.collectWeeklyDigestAnnouncements(ctx, userID)
+	if err != nil {
+		return err
+	}
+	announcementsPayload, err := json.Marshal(announcements)
+	if err != nil {
+		return err
+	}
+
 	weekStart := startOfWeekUTC(time.Now().UTC())
 	_, err = w.db.Exec(ctx, `
-		INSERT INTO weekly_digests(user_id, week_start, items, created_at, updated_at)
-		VALUES ($1, $2::date, $3::jsonb, NOW(), NOW())
+		INSERT INTO weekly_digests(user_id, week_start, items, announcements, created_at, updated_at)
+		VALUES ($1, $2::date, $3::jsonb, $4::jsonb, NOW(), NOW())
 		ON CONFLICT (user_id, week_start)
 		DO UPDATE SET
 			items = EXCLUDED.items,
+			announcements = EXCLUDED.announcements,
 			updated_at = NOW()
-	`, strings.TrimSpace(userID), weekStart.Format("2006-01-02"), payload)
+	`, strings.TrimSpace(userID), weekStart.Format(common.DateLayout), payload, announcementsPayload)
 	return err
 }
 
+// collectWeeklyDigestAnnouncements picks the major announcements published
+// in the last week that the user is targeted by and has not dismissed.
+func (w *Worker) collectWeeklyDigestAnnouncements(ctx context.Context, userID string) ([]weeklyDigestAnnouncement, error) {
+	rows, err := w.db.Query(ctx, `
+		SELECT a.id, a.title, a.body, a.link_url, a.starts_at
+		FROM announcements a
+		WHERE a.major
+		  AND a.starts_at >= NOW() - INTERVAL '7 days'
+		  AND `+common.AnnouncementAudienceSQL("$1")+`
+		ORDER BY a.starts_at DESC, a.id DESC
+		LIMIT 3
+	`, strings.TrimSpace(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := make([]weeklyDigestAnnouncement, 0, 3)
+	for rows.Next() {
+		var item weeklyDigestAnnouncement
+		if err := rows.Scan(&item.ID, &item.Title, &item.Body, &item.LinkURL, &item.StartsAt); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, item)
+	}
+	return announcements, rows.Err()
+}
+
 func (w *Worker) collectWeeklyDigestCandidates(ctx context.Context, userID string, limit int) ([]weeklyDigestCandidate, error) {
 	if limit <= 0 {
 		limit = 3
@@ -161,6 +209,7 @@ func (w *Worker) collectWeeklyDigestCandidates(ctx context.Context, userID strin
 		   AND pf.follower_user_id = $1::uuid
 		LEFT JOIN seen s ON s.battle_id = p.id::text
 		WHERE p.status = 'PUBLISHED'
+		  AND p.visibility = 'public'
 		  AND p.user_id <> $1::uuid
 		  AND p.created_at >= NOW() - INTERVAL '7 days'
 		  AND s.battle_id IS NULL
@@ -220,7 +269,7 @@ func (w *Worker) listWeeklyDigestReplies(ctx context.Context, battleID string, l
 	}
 
 	rows, err := w.db.Query(ctx, `
-		SELECT id::text, content
+		SELECT id::text, content, authored_by = 'HUMAN'
 		FROM replies
 		WHERE post_id = $1
 		ORDER BY created_at ASC
@@ -234,7 +283,7 @@ func (w *Worker) listWeeklyDigestReplies(ctx context.Context, battleID string, l
 	thread := make([]ai.ReplyContext, 0, limit)
 	for rows.Next() {
 		var reply ai.ReplyContext
-		if err := rows.Scan(&reply.ID, &reply.Content); err != nil {
+		if err := rows.Scan(&reply.ID, &reply.Content, &reply.Human); err != nil {
 			return nil, err
 		}
 		thread = append(thread, reply)
@@ -335,12 +384,7 @@ func roundWeeklyScore(value float64) float64 {
 	return float64(int(value*100+0.5)) / 100
 }
 
+// startOfWeekUTC is the Monday weekly digests are keyed by.
 func startOfWeekUTC(value time.Time) time.Time {
-	utc := value.UTC()
-	midnight := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
-	weekday := int(midnight.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-	return midnight.AddDate(0, 0, -(weekday - 1))
+	return common.StartOfWeek(value, time.UTC)
 }
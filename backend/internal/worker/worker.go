@@ -2,21 +2,37 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/ai/prompts"
+	"personaworlds/backend/internal/common"
 	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/envelope"
+	"personaworlds/backend/internal/eventbus"
 	"personaworlds/backend/internal/observability"
+	"personaworlds/backend/internal/social"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Worker struct {
-	cfg     config.Config
-	db      *pgxpool.Pool
-	llm     ai.LLMClient
-	logger  *observability.Logger
-	metrics *observability.WorkerMetrics
+	cfg          config.Config
+	db           *pgxpool.Pool
+	llm          ai.LLMClient
+	canary       ai.LLMClient
+	logger       *observability.Logger
+	metrics      *observability.WorkerMetrics
+	backpressure *llmBackpressure
+	jobGate      *jobGate
+	push         pushSender
+	bus          eventbus.Publisher
+	shard        shardConfig
+	safetyRules  *common.SafetyRulesCache
+	calibration  *common.CalibrationCipher
+	social       map[string]social.Provider
 }
 
 type permanentError struct {
@@ -28,19 +44,30 @@ func (e permanentError) Error() string {
 }
 
 func New(cfg config.Config, db *pgxpool.Pool, llm ai.LLMClient) *Worker {
+	logger := observability.NewLogger("worker")
+	metrics := observability.NewWorkerMetrics()
+	shard := newShardConfig(cfg.WorkerShardIndex, cfg.WorkerShardCount, cfg.WorkerShardStealAfter)
+	metrics.SetShard(shard.index, shard.count)
+	backpressure := newLLMBackpressure(cfg.LLMBackoffErrorPct, cfg.WorkerPollEvery, cfg.LLMBackoffMaxDelay, cfg.WorkerJobConcurrency, metrics, logger)
 	return &Worker{
-		cfg:     cfg,
-		db:      db,
-		llm:     llm,
-		logger:  observability.NewLogger("worker"),
-		metrics: observability.NewWorkerMetrics(),
+		cfg:          cfg,
+		db:           db,
+		llm:          &healthTrackingClient{next: ai.Instrument(llm, ai.ProviderName(cfg), metrics, logger), backpressure: backpressure},
+		canary:       newCanaryClient(cfg, metrics, logger),
+		logger:       logger,
+		metrics:      metrics,
+		backpressure: backpressure,
+		jobGate:      newJobGate(backpressure),
+		push:         newPushSender(cfg, logger),
+		bus:          newEventBus(cfg, logger),
+		shard:        shard,
+		safetyRules:  common.NewSafetyRulesCache(),
+		calibration:  common.NewCalibrationCipher(envelope.MustKeyProvider(cfg)),
+		social:       social.NewProviders(cfg, nil),
 	}
 }
 
 func (w *Worker) Run(ctx context.Context) {
-	ticker := time.NewTicker(w.cfg.WorkerPollEvery)
-	defer ticker.Stop()
-
 	runTask := func(name string, fn func(context.Context) error) {
 		taskCtx, cancel := context.WithTimeout(ctx, w.cfg.WorkerTaskTimeout)
 		defer cancel()
@@ -53,15 +80,82 @@ func (w *Worker) Run(ctx context.Context) {
 		}
 	}
 
+	// LLM tasks are skipped while their operation is paused and otherwise run
+	// once per the operation's own widened interval, so a throttled operation
+	// does not slow the rest of the loop.
+	lastRun := map[string]time.Time{}
+	runLLMTask := func(name, operation string, fn func(context.Context) error) {
+		if !w.backpressure.allow(operation) {
+			return
+		}
+		if last, ok := lastRun[name]; ok && time.Since(last) < w.backpressure.pollInterval(operation) {
+			return
+		}
+		lastRun[name] = time.Now()
+		runTask(name, fn)
+	}
+
 	for {
-		runTask("digest_daily", w.generateDigestForOnePersona)
-		runTask("digest_weekly", w.generateWeeklyDigestForOneUser)
-		runTask("jobs", w.processOne)
+		runTask("prompt_sync", w.syncPromptVersions)
+		runLLMTask("digest_daily", prompts.OpPersonaActivitySummary, w.generateDigestBatch)
+		runTask("digest_user_daily", w.generateDailySummaryForOneUser)
+		runLLMTask("digest_weekly", prompts.OpThreadSummary, w.generateWeeklyDigestForOneUser)
+		runLLMTask("battle_context_packs", prompts.OpBattleContextPack, w.generateOneBattleContextPack)
+		runTask("jobs", w.processJobs)
+		runLLMTask("battle_coaching", prompts.OpBattleCoaching, w.generateCoachingForOneBattle)
+		runLLMTask("battle_highlights", prompts.OpBattleHighlights, w.generateHighlightsForOneBattle)
+		runLLMTask("persona_answers", prompts.OpPersonaAnswer, w.answerOneApprovedQuestion)
+		runLLMTask("persona_style", prompts.OpPersonaStyle, w.classifyStyleForOnePersona)
+		runLLMTask("event_rooms", prompts.OpThreadSummary, w.closeOneEventRoom)
+		runLLMTask("llm_canary", prompts.OpReply, w.runOneLLMCanary)
+		runTask("feed_affinity", w.refreshFeedAffinityForOneUser)
+		runTask("push_dispatch", w.dispatchPushNotifications)
+		runTask("room_merges", w.mergeOneRoomBatch)
+		runTask("post_status_batches", w.applyOnePostStatusChunk)
+		runTask("persona_imports", w.createOnePersonaImportChunk)
+		runTask("view_dedup_prune", w.pruneViewDedup)
+		runTask("persona_presence_prune", w.prunePersonaPresence)
+		runTask("event_retention_prune", w.pruneRetainedEvents)
+		runTask("interactive_battle_expiry", w.expireInteractiveBattleTurns)
+		runTask("battle_archive", w.archiveOneBattle)
+		runTask("battle_live_cards", w.renderOneBattleLiveCard)
+		runTask("battle_of_week", w.createBattleOfWeekForOneRoom)
+		runTask("room_rosters", w.scheduleOneRosterEnrollment)
+		runTask("quota_reconciliation", w.reconcileQuotaEvents)
+		runTask("room_relations", w.refreshRoomRelations)
+		runTask("room_trending_topics", w.refreshRoomTrendingTopics)
+		runTask("event_outbox_relay", w.relayEventOutbox)
+		runTask("event_outbox_prune", w.pruneEventOutbox)
 
+		w.refreshDBPoolMetrics()
+		timer := time.NewTimer(w.backpressure.baseDelay)
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
+		case <-timer.C:
 		}
 	}
 }
+
+// processJobs claims up to WORKER_JOB_CONCURRENCY jobs at once. Backpressure
+// applies per job type inside processOne, so a paused reply operation leaves
+// drafts, crossposts and queued battles running.
+func (w *Worker) processJobs(ctx context.Context) error {
+	concurrency := w.cfg.WorkerJobConcurrency
+	if concurrency <= 1 {
+		return w.processOne(ctx)
+	}
+
+	errs := make([]error, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(slot int) {
+			defer wg.Done()
+			errs[slot] = w.processOne(ctx)
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
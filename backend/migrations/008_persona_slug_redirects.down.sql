@@ -0,0 +1 @@
+DROP TABLE IF EXISTS persona_slug_redirects;
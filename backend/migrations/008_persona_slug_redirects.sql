@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS persona_slug_redirects (
+    old_slug TEXT PRIMARY KEY,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_slug_redirects_persona_id
+    ON persona_slug_redirects(persona_id);
@@ -0,0 +1,7 @@
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_active_hours_check;
+
+ALTER TABLE personas
+    DROP COLUMN IF EXISTS active_hours_start,
+    DROP COLUMN IF EXISTS active_hours_end,
+    DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,25 @@
+ALTER TABLE personas
+    ADD COLUMN IF NOT EXISTS active_hours_start INT,
+    ADD COLUMN IF NOT EXISTS active_hours_end INT,
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'personas_active_hours_check'
+    ) THEN
+        ALTER TABLE personas
+            ADD CONSTRAINT personas_active_hours_check
+            CHECK (
+                (active_hours_start IS NULL AND active_hours_end IS NULL)
+                OR (
+                    active_hours_start BETWEEN 0 AND 23
+                    AND active_hours_end BETWEEN 0 AND 23
+                    AND active_hours_start <> active_hours_end
+                )
+            );
+    END IF;
+END
+$$;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS battle_coaching (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    feedback TEXT NOT NULL,
+    turns JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, persona_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_coaching_created_at
+    ON battle_coaching(created_at DESC);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_unread_rollup;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS rollup_key,
+    DROP COLUMN IF EXISTS rollup_count,
+    DROP COLUMN IF EXISTS rollup_started_at;
@@ -0,0 +1,8 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS rollup_key TEXT,
+    ADD COLUMN IF NOT EXISTS rollup_count INT NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS rollup_started_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_notifications_unread_rollup
+    ON notifications(user_id, type, rollup_key, created_at DESC)
+    WHERE read_at IS NULL AND rollup_key IS NOT NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reaction TEXT NOT NULL CHECK (reaction IN ('like', 'insightful', 'disagree')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id, reaction)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_post_reaction
+    ON post_reactions(post_id, reaction);
+
+CREATE INDEX IF NOT EXISTS idx_post_reactions_created_at
+    ON post_reactions(created_at DESC);
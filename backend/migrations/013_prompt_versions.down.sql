@@ -0,0 +1,13 @@
+ALTER TABLE battle_coaching
+    DROP COLUMN IF EXISTS prompt_version;
+
+ALTER TABLE persona_digests
+    DROP COLUMN IF EXISTS prompt_version;
+
+ALTER TABLE replies
+    DROP COLUMN IF EXISTS prompt_version;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS prompt_version;
+
+DROP TABLE IF EXISTS prompt_versions;
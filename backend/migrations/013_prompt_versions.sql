@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    operation TEXT PRIMARY KEY,
+    version TEXT NOT NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+ALTER TABLE replies
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+ALTER TABLE persona_digests
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
+
+ALTER TABLE battle_coaching
+    ADD COLUMN IF NOT EXISTS prompt_version TEXT;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS post_translations (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    language TEXT NOT NULL CHECK (language IN ('tr', 'en')),
+    source_language TEXT NOT NULL CHECK (source_language IN ('tr', 'en')),
+    content TEXT NOT NULL,
+    prompt_version TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, language)
+);
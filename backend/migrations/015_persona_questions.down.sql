@@ -0,0 +1,11 @@
+DELETE FROM notifications
+WHERE type = 'persona_question';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed'));
+
+DROP TABLE IF EXISTS persona_questions;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS persona_questions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    asker_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    asker_name TEXT NOT NULL DEFAULT '',
+    question TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'ANSWERED', 'REJECTED')),
+    room_id UUID REFERENCES rooms(id) ON DELETE SET NULL,
+    answer_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    error TEXT,
+    approved_at TIMESTAMPTZ,
+    answered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_questions_persona_status
+    ON persona_questions(persona_id, status, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_persona_questions_approved
+    ON persona_questions(available_at ASC)
+    WHERE status = 'APPROVED';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_persona_questions_answer_post
+    ON persona_questions(answer_post_id)
+    WHERE answer_post_id IS NOT NULL;
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question'));
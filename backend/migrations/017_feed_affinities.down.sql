@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_feed_affinity_runs;
+
+DROP TABLE IF EXISTS user_feed_affinities;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_feed_affinities (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('room', 'template', 'persona')),
+    target_id UUID NOT NULL,
+    weight DOUBLE PRECISION NOT NULL CHECK (weight > 0 AND weight <= 1),
+    reason TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind, target_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_feed_affinity_runs (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
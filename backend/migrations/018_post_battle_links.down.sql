@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_source_post_id;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS source_post_id;
@@ -0,0 +1,6 @@
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS source_post_id UUID REFERENCES posts(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_source_post_id
+    ON posts(source_post_id, created_at DESC)
+    WHERE source_post_id IS NOT NULL;
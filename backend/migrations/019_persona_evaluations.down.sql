@@ -0,0 +1,10 @@
+DELETE FROM quota_events WHERE quota_type = 'evaluation';
+
+ALTER TABLE quota_events
+    DROP CONSTRAINT IF EXISTS quota_events_quota_type_check;
+
+ALTER TABLE quota_events
+    ADD CONSTRAINT quota_events_quota_type_check
+    CHECK (quota_type IN ('draft', 'reply', 'preview'));
+
+DROP TABLE IF EXISTS persona_evaluations;
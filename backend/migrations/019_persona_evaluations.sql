@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS persona_evaluations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    overall_score DOUBLE PRECISION NOT NULL,
+    tone_score DOUBLE PRECISION NOT NULL,
+    do_not_say_score DOUBLE PRECISION NOT NULL,
+    formality_score DOUBLE PRECISION NOT NULL,
+    report JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_evaluations_persona_created_at
+    ON persona_evaluations(persona_id, created_at DESC);
+
+ALTER TABLE quota_events
+    DROP CONSTRAINT IF EXISTS quota_events_quota_type_check;
+
+ALTER TABLE quota_events
+    ADD CONSTRAINT quota_events_quota_type_check
+    CHECK (quota_type IN ('draft', 'reply', 'preview', 'evaluation'));
@@ -0,0 +1,17 @@
+DELETE FROM notifications
+WHERE type = 'battle_completed';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question'));
+
+DROP INDEX IF EXISTS idx_notifications_push_pending;
+
+ALTER TABLE notifications DROP COLUMN IF EXISTS push_dispatched_at;
+
+DROP TABLE IF EXISTS push_preferences;
+
+DROP TABLE IF EXISTS push_subscriptions;
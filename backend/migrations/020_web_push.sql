@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL UNIQUE,
+    p256dh TEXT NOT NULL,
+    auth TEXT NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    failure_count INT NOT NULL DEFAULT 0,
+    last_success_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user
+    ON push_subscriptions(user_id);
+
+CREATE TABLE IF NOT EXISTS push_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    battle_completed BOOLEAN NOT NULL DEFAULT TRUE,
+    new_follower BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS push_dispatched_at TIMESTAMPTZ;
+
+-- Existing notifications predate push delivery and must never be pushed.
+UPDATE notifications
+SET push_dispatched_at = created_at
+WHERE push_dispatched_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_push_pending
+    ON notifications(created_at)
+    WHERE push_dispatched_at IS NULL;
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed'));
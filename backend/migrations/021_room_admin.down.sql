@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS room_merges;
+
+DROP INDEX IF EXISTS idx_rooms_merged_into;
+
+ALTER TABLE rooms
+    DROP COLUMN IF EXISTS merged_into_room_id,
+    DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,27 @@
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS merged_into_room_id UUID REFERENCES rooms(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_rooms_merged_into
+    ON rooms(merged_into_room_id)
+    WHERE merged_into_room_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS room_merges (
+    id BIGSERIAL PRIMARY KEY,
+    source_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    target_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE')),
+    moved_posts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    CHECK (source_room_id <> target_room_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_room_merges_source
+    ON room_merges(source_room_id);
+
+CREATE INDEX IF NOT EXISTS idx_room_merges_pending
+    ON room_merges(created_at)
+    WHERE status = 'PENDING';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS safety_rules;
+
+DROP TABLE IF EXISTS safety_rejections;
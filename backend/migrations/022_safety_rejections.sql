@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS safety_rejections (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    persona_id UUID REFERENCES personas(id) ON DELETE CASCADE,
+    room_id UUID REFERENCES rooms(id) ON DELETE SET NULL,
+    post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('preview', 'draft', 'reply', 'persona_answer')),
+    rule TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    content TEXT NOT NULL,
+    max_len INT NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'REJECTED' CHECK (status IN ('REJECTED', 'APPEALED', 'APPROVED', 'DENIED')),
+    appeal_note TEXT NOT NULL DEFAULT '',
+    appealed_at TIMESTAMPTZ,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ,
+    resolved_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_safety_rejections_user_id
+    ON safety_rejections(user_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_safety_rejections_status_id
+    ON safety_rejections(status, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_safety_rejections_resolved_post
+    ON safety_rejections(resolved_post_id)
+    WHERE resolved_post_id IS NOT NULL;
+
+-- A single row of admin-tuned rule overrides; without it the built-in
+-- defaults apply.
+CREATE TABLE IF NOT EXISTS safety_rules (
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    max_links INT NOT NULL DEFAULT 2 CHECK (max_links >= 0),
+    allowed_terms TEXT[] NOT NULL DEFAULT '{}',
+    blocked_terms TEXT[] NOT NULL DEFAULT '{}',
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
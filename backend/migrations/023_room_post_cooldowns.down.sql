@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_room_persona_published;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS post_cooldown_minutes;
@@ -0,0 +1,7 @@
+-- NULL uses ROOM_POST_COOLDOWN, 0 disables the cooldown for the room.
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS post_cooldown_minutes INT CHECK (post_cooldown_minutes >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_posts_room_persona_published
+    ON posts(room_id, persona_id, published_at DESC)
+    WHERE status = 'PUBLISHED' AND persona_id IS NOT NULL;
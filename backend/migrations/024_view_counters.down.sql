@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS content_view_dedup;
+
+ALTER TABLE posts DROP COLUMN IF EXISTS view_count;
+
+ALTER TABLE persona_public_profiles DROP COLUMN IF EXISTS view_count;
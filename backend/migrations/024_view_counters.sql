@@ -0,0 +1,18 @@
+ALTER TABLE persona_public_profiles
+    ADD COLUMN IF NOT EXISTS view_count BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS view_count BIGINT NOT NULL DEFAULT 0;
+
+-- One row per viewer per subject per UTC day. viewer_key is "u:<user id>" for
+-- signed-in viewers and a keyed hash of the client IP otherwise.
+CREATE TABLE IF NOT EXISTS content_view_dedup (
+    subject_type TEXT NOT NULL CHECK (subject_type IN ('profile', 'battle')),
+    subject_id UUID NOT NULL,
+    viewer_key TEXT NOT NULL,
+    view_date DATE NOT NULL,
+    PRIMARY KEY (subject_type, subject_id, view_date, viewer_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_view_dedup_view_date
+    ON content_view_dedup(view_date);
@@ -0,0 +1,4 @@
+-- Mood the draft was generated in (playful, contrarian, ...). NULL means the
+-- persona's base tone only.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS mood TEXT;
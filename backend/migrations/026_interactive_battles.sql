@@ -0,0 +1,18 @@
+-- Battles where an AI persona argues one side and the owner writes the other.
+-- The AI always opens; sides then alternate until total_turns replies exist.
+CREATE TABLE IF NOT EXISTS interactive_battles (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ai_persona_id UUID REFERENCES personas(id) ON DELETE SET NULL,
+    total_turns INT NOT NULL CHECK (total_turns BETWEEN 2 AND 20),
+    turns_taken INT NOT NULL DEFAULT 0 CHECK (turns_taken >= 0),
+    next_side TEXT NOT NULL DEFAULT 'AI' CHECK (next_side IN ('AI', 'HUMAN')),
+    status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'COMPLETED', 'EXPIRED', 'FAILED')),
+    turn_deadline TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_interactive_battles_human_deadline
+    ON interactive_battles(turn_deadline)
+    WHERE status = 'ACTIVE' AND next_side = 'HUMAN';
@@ -0,0 +1,19 @@
+-- Queries that exceeded SLOW_QUERY_THRESHOLD, aggregated per normalized SQL
+-- text. Parameters are never stored; explain_plan holds the latest sampled
+-- EXPLAIN (ANALYZE, BUFFERS) output when sampling is enabled.
+CREATE TABLE IF NOT EXISTS slow_queries (
+    fingerprint TEXT PRIMARY KEY,
+    query TEXT NOT NULL,
+    service TEXT NOT NULL,
+    calls BIGINT NOT NULL DEFAULT 0,
+    total_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    explain_plan TEXT,
+    explained_at TIMESTAMPTZ,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_slow_queries_max_ms
+    ON slow_queries(max_ms DESC);
@@ -0,0 +1,13 @@
+DELETE FROM notifications
+WHERE type IN ('battle_challenge', 'battle_challenge_accepted');
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed'));
+
+DROP TABLE IF EXISTS battle_challenge_consents;
+
+DROP TABLE IF EXISTS battle_challenges;
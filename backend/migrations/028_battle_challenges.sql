@@ -0,0 +1,52 @@
+-- A challenge from one user's persona to another user's public persona. The
+-- battle is only created once the opponent accepts.
+CREATE TABLE IF NOT EXISTS battle_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    challenger_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenger_persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    opponent_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    opponent_persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    template_id UUID REFERENCES templates(id) ON DELETE SET NULL,
+    topic TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACCEPTED', 'DECLINED')),
+    battle_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    responded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (challenger_user_id <> opponent_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_challenges_challenger
+    ON battle_challenges(challenger_user_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_battle_challenges_opponent
+    ON battle_challenges(opponent_user_id, status, created_at DESC);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_battle_challenges_pending_pair
+    ON battle_challenges(challenger_persona_id, opponent_persona_id)
+    WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_battle_challenges_battle_post
+    ON battle_challenges(battle_post_id)
+    WHERE battle_post_id IS NOT NULL;
+
+-- One row per user who agreed to put their persona in the battle: the
+-- challenger when sending, the opponent when accepting.
+CREATE TABLE IF NOT EXISTS battle_challenge_consents (
+    challenge_id UUID NOT NULL REFERENCES battle_challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('CHALLENGER', 'OPPONENT')),
+    ip_hash TEXT NOT NULL DEFAULT '',
+    consented_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (challenge_id, role)
+);
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted'));
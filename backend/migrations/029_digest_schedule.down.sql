@@ -0,0 +1,16 @@
+DELETE FROM quota_events WHERE quota_type = 'digest_regenerate';
+
+ALTER TABLE quota_events
+    DROP CONSTRAINT IF EXISTS quota_events_quota_type_check;
+
+ALTER TABLE quota_events
+    ADD CONSTRAINT quota_events_quota_type_check
+    CHECK (quota_type IN ('draft', 'reply', 'preview', 'evaluation'));
+
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_digest_hour_check;
+
+ALTER TABLE personas
+    DROP COLUMN IF EXISTS digest_regenerate_requested_at,
+    DROP COLUMN IF EXISTS digest_hour,
+    DROP COLUMN IF EXISTS digest_enabled;
@@ -0,0 +1,27 @@
+-- Daily digest schedule. digest_hour is the UTC hour after which the worker
+-- builds the day's digest; disabled personas only get digests on demand.
+ALTER TABLE personas
+    ADD COLUMN IF NOT EXISTS digest_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS digest_hour INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS digest_regenerate_requested_at TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'personas_digest_hour_check'
+    ) THEN
+        ALTER TABLE personas
+            ADD CONSTRAINT personas_digest_hour_check
+            CHECK (digest_hour BETWEEN 0 AND 23);
+    END IF;
+END
+$$;
+
+ALTER TABLE quota_events
+    DROP CONSTRAINT IF EXISTS quota_events_quota_type_check;
+
+ALTER TABLE quota_events
+    ADD CONSTRAINT quota_events_quota_type_check
+    CHECK (quota_type IN ('draft', 'reply', 'preview', 'evaluation', 'digest_regenerate'));
@@ -0,0 +1,5 @@
+-- How approving a draft treats posts the LLM judges off-topic for the room:
+-- off skips the check, advisory asks for confirmation, blocking refuses.
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS topic_check TEXT NOT NULL DEFAULT 'off'
+    CHECK (topic_check IN ('off', 'advisory', 'blocking'));
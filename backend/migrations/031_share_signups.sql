@@ -0,0 +1,17 @@
+-- Signups attributed to a signed share link, one row per new user. A token can
+-- be redeemed once per client (keyed IP hash), which catches replayed signups.
+CREATE TABLE IF NOT EXISTS share_signups (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_nonce TEXT NOT NULL,
+    battle_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    sharer_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_hash TEXT NOT NULL,
+    token_issued_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_share_signups_nonce_ip
+    ON share_signups(token_nonce, ip_hash);
+
+CREATE INDEX IF NOT EXISTS idx_share_signups_battle_created_at
+    ON share_signups(battle_id, created_at DESC);
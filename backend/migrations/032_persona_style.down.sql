@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_personas_style_pending;
+
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_style_check;
+
+ALTER TABLE personas
+    DROP COLUMN IF EXISTS style_classify_attempts,
+    DROP COLUMN IF EXISTS style_source,
+    DROP COLUMN IF EXISTS style_brevity,
+    DROP COLUMN IF EXISTS style_technicality,
+    DROP COLUMN IF EXISTS style_assertiveness,
+    DROP COLUMN IF EXISTS style_humor;
@@ -0,0 +1,37 @@
+-- Continuous style sliders (0-100). Personas that existed before the sliders
+-- start as 'pending' so the worker can estimate them once from their tone
+-- text; new personas default to 'manual' with neutral values.
+ALTER TABLE personas
+    ADD COLUMN IF NOT EXISTS style_humor INT NOT NULL DEFAULT 50,
+    ADD COLUMN IF NOT EXISTS style_assertiveness INT NOT NULL DEFAULT 50,
+    ADD COLUMN IF NOT EXISTS style_technicality INT NOT NULL DEFAULT 50,
+    ADD COLUMN IF NOT EXISTS style_brevity INT NOT NULL DEFAULT 50,
+    ADD COLUMN IF NOT EXISTS style_source TEXT NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS style_classify_attempts INT NOT NULL DEFAULT 0;
+
+ALTER TABLE personas
+    ALTER COLUMN style_source SET DEFAULT 'manual';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'personas_style_check'
+    ) THEN
+        ALTER TABLE personas
+            ADD CONSTRAINT personas_style_check
+            CHECK (
+                style_humor BETWEEN 0 AND 100
+                AND style_assertiveness BETWEEN 0 AND 100
+                AND style_technicality BETWEEN 0 AND 100
+                AND style_brevity BETWEEN 0 AND 100
+                AND style_source IN ('pending', 'classified', 'default', 'manual')
+            );
+    END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_personas_style_pending
+    ON personas(created_at)
+    WHERE style_source = 'pending';
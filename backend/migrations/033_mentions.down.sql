@@ -0,0 +1,13 @@
+DELETE FROM notifications WHERE type = 'persona_mentioned';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted'));
+
+DROP TABLE IF EXISTS mentions;
+
+ALTER TABLE personas
+    DROP COLUMN IF EXISTS mention_replies_enabled;
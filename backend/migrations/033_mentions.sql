@@ -0,0 +1,35 @@
+-- @mentions of public personas in published posts and replies. Each piece of
+-- content records a persona at most once, so re-publishing does not notify
+-- twice. mention_replies_enabled is the owner's opt-in for automatic replies.
+ALTER TABLE personas
+    ADD COLUMN IF NOT EXISTS mention_replies_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS mentions (
+    id BIGSERIAL PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    reply_id UUID REFERENCES replies(id) ON DELETE CASCADE,
+    mentioned_persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    author_persona_id UUID REFERENCES personas(id) ON DELETE SET NULL,
+    author_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    slug TEXT NOT NULL,
+    reply_enqueued BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_post_persona
+    ON mentions(post_id, mentioned_persona_id)
+    WHERE reply_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentions_reply_persona
+    ON mentions(reply_id, mentioned_persona_id)
+    WHERE reply_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_mentions_persona_created_at
+    ON mentions(mentioned_persona_id, created_at DESC);
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned'));
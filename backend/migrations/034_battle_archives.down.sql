@@ -0,0 +1,28 @@
+-- Put archived turns back into replies before dropping the archive. Personas
+-- or users deleted since archiving come back as NULL, as ON DELETE SET NULL
+-- would have left them.
+INSERT INTO replies(id, post_id, persona_id, user_id, authored_by, content, prompt_version, created_at, updated_at)
+SELECT
+    t.id,
+    a.post_id,
+    (SELECT p.id FROM personas p WHERE p.id = t.persona_id),
+    (SELECT u.id FROM users u WHERE u.id = t.user_id),
+    t.authored_by::authored_by_enum,
+    t.content,
+    t.prompt_version,
+    t.created_at,
+    t.updated_at
+FROM battle_archives a
+CROSS JOIN LATERAL jsonb_to_recordset(a.turns) AS t(
+    id UUID,
+    persona_id UUID,
+    user_id UUID,
+    authored_by TEXT,
+    content TEXT,
+    prompt_version TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+)
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS battle_archives;
@@ -0,0 +1,17 @@
+-- Completed battles past the archive age keep their post row hot while their
+-- turns move out of replies into one JSONB document per battle. Postgres
+-- compresses large documents out of line (TOAST), so an archived battle costs
+-- one compressed row instead of a reply row and index entries per turn.
+CREATE TABLE IF NOT EXISTS battle_archives (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    turn_count INT NOT NULL,
+    last_turn_at TIMESTAMPTZ NOT NULL,
+    turns JSONB NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE battle_archives
+    ALTER COLUMN turns SET STORAGE EXTENDED;
+
+CREATE INDEX IF NOT EXISTS idx_battle_archives_archived_at
+    ON battle_archives(archived_at DESC);
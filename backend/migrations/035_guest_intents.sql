@@ -0,0 +1,16 @@
+-- Signed guest intents (follow or remix started while signed out) that were
+-- executed after signup or login. The nonce is the primary key, so a token
+-- runs at most once no matter how often it is replayed.
+CREATE TABLE IF NOT EXISTS guest_intents (
+    nonce TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('follow', 'remix')),
+    target_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    completed_via TEXT NOT NULL CHECK (completed_via IN ('signup', 'login')),
+    result_id UUID,
+    token_issued_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_guest_intents_kind_created_at
+    ON guest_intents(kind, created_at DESC);
@@ -0,0 +1,13 @@
+DELETE FROM notifications WHERE type = 'battle_of_week';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned'));
+
+DROP TABLE IF EXISTS room_battles_of_week;
+
+ALTER TABLE personas
+    DROP COLUMN IF EXISTS battle_of_week_opt_in;
@@ -0,0 +1,31 @@
+-- Weekly featured battle per room. The worker picks the room's most remixed
+-- or shared topic of the week and pits its two top public personas against
+-- each other. Personas only take part when their owner opted in.
+ALTER TABLE personas
+    ADD COLUMN IF NOT EXISTS battle_of_week_opt_in BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- One row per room and week, including weeks that were skipped, so the
+-- worker does not re-evaluate a room until the next week starts.
+CREATE TABLE IF NOT EXISTS room_battles_of_week (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    week_start DATE NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('CREATED', 'SKIPPED')),
+    skip_reason TEXT NOT NULL DEFAULT '',
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    source_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    pro_persona_id UUID REFERENCES personas(id) ON DELETE SET NULL,
+    con_persona_id UUID REFERENCES personas(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, week_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_battles_of_week_post
+    ON room_battles_of_week(post_id)
+    WHERE post_id IS NOT NULL;
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week'));
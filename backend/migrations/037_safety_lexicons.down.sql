@@ -0,0 +1 @@
+DROP TABLE IF EXISTS safety_lexicon_terms;
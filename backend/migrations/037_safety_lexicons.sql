@@ -0,0 +1,29 @@
+-- Per-deployment profanity and brand-safety lexicons. Block terms reject
+-- content, warn terms only flag it. The seed rows match the list that used to
+-- be built into the safety package.
+CREATE TABLE IF NOT EXISTS safety_lexicon_terms (
+    id BIGSERIAL PRIMARY KEY,
+    term TEXT NOT NULL CHECK (term <> ''),
+    language TEXT NOT NULL CHECK (language IN ('tr', 'en')),
+    severity TEXT NOT NULL CHECK (severity IN ('warn', 'block')),
+    category TEXT NOT NULL DEFAULT 'profanity' CHECK (category IN ('profanity', 'brand_safety')),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (language, term)
+);
+
+INSERT INTO safety_lexicon_terms(term, language, severity, category)
+VALUES
+    ('fuck', 'en', 'block', 'profanity'),
+    ('shit', 'en', 'block', 'profanity'),
+    ('bitch', 'en', 'block', 'profanity'),
+    ('asshole', 'en', 'block', 'profanity'),
+    ('dick', 'en', 'block', 'profanity'),
+    ('siktir', 'tr', 'block', 'profanity'),
+    ('orospu', 'tr', 'block', 'profanity'),
+    ('piç', 'tr', 'block', 'profanity'),
+    ('yavşak', 'tr', 'block', 'profanity'),
+    ('amk', 'tr', 'block', 'profanity')
+ON CONFLICT (language, term) DO NOTHING;
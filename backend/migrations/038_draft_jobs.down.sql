@@ -0,0 +1,15 @@
+DELETE FROM notifications WHERE type = 'draft_ready';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week'));
+
+DROP INDEX IF EXISTS idx_jobs_generate_draft_persona;
+
+DELETE FROM jobs WHERE post_id IS NULL;
+
+ALTER TABLE jobs
+    ALTER COLUMN post_id SET NOT NULL;
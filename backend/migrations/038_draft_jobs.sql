@@ -0,0 +1,16 @@
+-- Drafts that miss the API latency SLA are generated by a generate_draft job.
+-- The job has no post until the draft exists; the worker then sets post_id to
+-- the new draft so clients polling the job can load it.
+ALTER TABLE jobs
+    ALTER COLUMN post_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_generate_draft_persona
+    ON jobs(persona_id, created_at DESC)
+    WHERE job_type = 'generate_draft';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready'));
@@ -0,0 +1,8 @@
+ALTER TABLE replies
+    DROP COLUMN IF EXISTS metadata;
+
+ALTER TABLE posts
+    DROP CONSTRAINT IF EXISTS posts_battle_language_code_check,
+    DROP CONSTRAINT IF EXISTS posts_battle_language_check,
+    DROP COLUMN IF EXISTS battle_language_code,
+    DROP COLUMN IF EXISTS battle_language;
@@ -0,0 +1,33 @@
+-- battle_language is the battle-level setting (auto, a, b, bilingual);
+-- battle_language_code is the language an a or b setting resolved to when
+-- the battle was created. Bilingual turns keep both renderings in
+-- replies.metadata.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS battle_language TEXT NOT NULL DEFAULT 'auto',
+    ADD COLUMN IF NOT EXISTS battle_language_code TEXT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'posts_battle_language_check'
+    ) THEN
+        ALTER TABLE posts
+            ADD CONSTRAINT posts_battle_language_check
+            CHECK (battle_language IN ('auto', 'a', 'b', 'bilingual'));
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'posts_battle_language_code_check'
+    ) THEN
+        ALTER TABLE posts
+            ADD CONSTRAINT posts_battle_language_code_check
+            CHECK (battle_language_code IS NULL OR battle_language_code IN ('tr', 'en'));
+    END IF;
+END
+$$;
+
+ALTER TABLE replies
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
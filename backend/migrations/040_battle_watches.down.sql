@@ -0,0 +1,10 @@
+DELETE FROM notifications WHERE type IN ('watched_battle_turn', 'watched_battle_verdict');
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready'));
+
+DROP TABLE IF EXISTS battle_watches;
@@ -0,0 +1,24 @@
+-- A persona can be announced once, to the followers of its owner's other
+-- personas. The row doubles as the owner's announcement cooldown.
+CREATE TABLE IF NOT EXISTS persona_announcements (
+    persona_id UUID PRIMARY KEY REFERENCES personas(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipients INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_announcements_user_created
+    ON persona_announcements(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    persona_announcements BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready', 'watched_battle_turn', 'watched_battle_verdict', 'persona_announced'));
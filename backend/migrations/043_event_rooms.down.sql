@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_rooms_event_pending_close;
+
+ALTER TABLE rooms
+    DROP CONSTRAINT IF EXISTS rooms_event_window_check;
+
+ALTER TABLE rooms
+    DROP COLUMN IF EXISTS event_closed_at,
+    DROP COLUMN IF EXISTS event_recap,
+    DROP COLUMN IF EXISTS event_ends_at,
+    DROP COLUMN IF EXISTS event_starts_at;
@@ -0,0 +1,22 @@
+-- Event rooms only take posts and battles between event_starts_at and
+-- event_ends_at. Once the window has passed the worker writes a recap and
+-- archives the room; event_closed_at marks that it has done so.
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS event_starts_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS event_ends_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS event_recap TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS event_closed_at TIMESTAMPTZ;
+
+ALTER TABLE rooms
+    DROP CONSTRAINT IF EXISTS rooms_event_window_check;
+
+ALTER TABLE rooms
+    ADD CONSTRAINT rooms_event_window_check
+    CHECK (
+        (event_starts_at IS NULL AND event_ends_at IS NULL)
+        OR (event_starts_at IS NOT NULL AND event_ends_at IS NOT NULL AND event_ends_at > event_starts_at)
+    );
+
+CREATE INDEX IF NOT EXISTS idx_rooms_event_pending_close
+    ON rooms(event_ends_at)
+    WHERE event_ends_at IS NOT NULL AND event_closed_at IS NULL;
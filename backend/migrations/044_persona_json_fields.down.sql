@@ -0,0 +1,3 @@
+-- Cleaned rows stay cleaned; only the constraint is dropped.
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_calibration_lists_check;
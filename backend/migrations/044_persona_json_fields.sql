@@ -0,0 +1,48 @@
+-- Bring persona calibration lists into the canonical form the API now
+-- enforces (common.PersonaListField): arrays of strings only, items trimmed
+-- with inner whitespace collapsed, empty items and case-insensitive
+-- duplicates dropped, capped at the field's item count and length.
+CREATE OR REPLACE FUNCTION pg_temp.clean_persona_list(value JSONB, max_items INT, max_chars INT)
+RETURNS JSONB
+LANGUAGE SQL
+AS $$
+    SELECT COALESCE(jsonb_agg(to_jsonb(kept.item) ORDER BY kept.pos), '[]'::jsonb)
+    FROM (
+        SELECT deduped.item, deduped.pos
+        FROM (
+            SELECT DISTINCT ON (lower(cleaned.item)) cleaned.item, cleaned.pos
+            FROM (
+                SELECT left(btrim(regexp_replace(e.elem #>> '{}', '\s+', ' ', 'g')), max_chars) AS item, e.pos
+                FROM jsonb_array_elements(
+                    CASE WHEN jsonb_typeof(value) = 'array' THEN value ELSE '[]'::jsonb END
+                ) WITH ORDINALITY AS e(elem, pos)
+                WHERE jsonb_typeof(e.elem) = 'string'
+            ) cleaned
+            WHERE cleaned.item <> ''
+            ORDER BY lower(cleaned.item), cleaned.pos
+        ) deduped
+        ORDER BY deduped.pos
+        LIMIT max_items
+    ) kept
+$$;
+
+UPDATE personas
+SET writing_samples = pg_temp.clean_persona_list(writing_samples, 3, 180),
+    do_not_say = pg_temp.clean_persona_list(do_not_say, 20, 120),
+    catchphrases = pg_temp.clean_persona_list(catchphrases, 10, 80)
+WHERE writing_samples IS DISTINCT FROM pg_temp.clean_persona_list(writing_samples, 3, 180)
+   OR do_not_say IS DISTINCT FROM pg_temp.clean_persona_list(do_not_say, 20, 120)
+   OR catchphrases IS DISTINCT FROM pg_temp.clean_persona_list(catchphrases, 10, 80);
+
+DROP FUNCTION pg_temp.clean_persona_list(JSONB, INT, INT);
+
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_calibration_lists_check;
+
+ALTER TABLE personas
+    ADD CONSTRAINT personas_calibration_lists_check
+    CHECK (
+        jsonb_typeof(writing_samples) = 'array' AND jsonb_array_length(writing_samples) <= 3
+        AND jsonb_typeof(do_not_say) = 'array' AND jsonb_array_length(do_not_say) <= 20
+        AND jsonb_typeof(catchphrases) = 'array' AND jsonb_array_length(catchphrases) <= 10
+    );
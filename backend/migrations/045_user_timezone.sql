@@ -0,0 +1,4 @@
+-- Users pick the timezone digests and analytics windows are labelled in.
+-- Stored digest dates stay UTC; this only drives how they are presented.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
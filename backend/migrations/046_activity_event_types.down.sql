@@ -0,0 +1,9 @@
+DELETE FROM persona_activity_events
+WHERE type = 'human_reply_received';
+
+ALTER TABLE persona_activity_events
+    DROP CONSTRAINT IF EXISTS persona_activity_events_type_check;
+
+ALTER TABLE persona_activity_events
+    ADD CONSTRAINT persona_activity_events_type_check
+    CHECK (type IN ('post_created', 'reply_generated', 'thread_participated'));
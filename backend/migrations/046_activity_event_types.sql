@@ -0,0 +1,8 @@
+-- Human replies are recorded as activity for the persona whose post was
+-- replied to.
+ALTER TABLE persona_activity_events
+    DROP CONSTRAINT IF EXISTS persona_activity_events_type_check;
+
+ALTER TABLE persona_activity_events
+    ADD CONSTRAINT persona_activity_events_type_check
+    CHECK (type IN ('post_created', 'reply_generated', 'thread_participated', 'human_reply_received'));
@@ -0,0 +1,30 @@
+-- Per-day rollup of where each persona posts and replies, kept up to date as
+-- activity events are written so profiles never aggregate raw events.
+CREATE TABLE IF NOT EXISTS persona_room_presence_days (
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    post_count INT NOT NULL DEFAULT 0,
+    reply_count INT NOT NULL DEFAULT 0,
+    last_active_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (persona_id, room_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_room_presence_days_day
+    ON persona_room_presence_days(day);
+
+-- Seed the last 30 days from the activity already recorded.
+INSERT INTO persona_room_presence_days(persona_id, room_id, day, post_count, reply_count, last_active_at)
+SELECT
+    e.persona_id,
+    r.id,
+    (e.created_at AT TIME ZONE 'UTC')::date,
+    COUNT(*) FILTER (WHERE e.type = 'post_created')::int,
+    COUNT(*) FILTER (WHERE e.type = 'reply_generated')::int,
+    MAX(e.created_at)
+FROM persona_activity_events e
+JOIN rooms r ON r.id::text = e.metadata->>'room_id'
+WHERE e.type IN ('post_created', 'reply_generated', 'thread_participated')
+  AND e.created_at >= (NOW() AT TIME ZONE 'UTC')::date - 29
+GROUP BY e.persona_id, r.id, (e.created_at AT TIME ZONE 'UTC')::date
+ON CONFLICT (persona_id, room_id, day) DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE weekly_digests
+    DROP COLUMN IF EXISTS announcements;
+
+DROP TABLE IF EXISTS announcement_dismissals;
+DROP TABLE IF EXISTS announcements;
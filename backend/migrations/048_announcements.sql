@@ -0,0 +1,32 @@
+-- Admin-authored changelog entries and broadcasts. Each user sees an
+-- announcement until they dismiss it or it ends.
+CREATE TABLE IF NOT EXISTS announcements (
+    id BIGSERIAL PRIMARY KEY,
+    title TEXT NOT NULL CHECK (char_length(title) BETWEEN 1 AND 120),
+    body TEXT NOT NULL CHECK (char_length(body) BETWEEN 1 AND 2000),
+    link_url TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL DEFAULT 'changelog' CHECK (kind IN ('changelog', 'broadcast')),
+    segment TEXT NOT NULL DEFAULT 'all' CHECK (segment IN ('all', 'new', 'active', 'dormant', 'creators')),
+    major BOOLEAN NOT NULL DEFAULT FALSE,
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT announcements_window_check CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcements_starts_at
+    ON announcements(starts_at DESC);
+
+CREATE TABLE IF NOT EXISTS announcement_dismissals (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    announcement_id BIGINT NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    dismissed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, announcement_id)
+);
+
+-- Major announcements published during a week are carried in that week's
+-- digest.
+ALTER TABLE weekly_digests
+    ADD COLUMN IF NOT EXISTS announcements JSONB NOT NULL DEFAULT '[]'::jsonb;
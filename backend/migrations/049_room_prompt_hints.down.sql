@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_posts_prompt_hint;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS prompt_hint_id;
+
+ALTER TABLE rooms
+    DROP COLUMN IF EXISTS prompt_hint_id;
+
+DROP TABLE IF EXISTS room_prompt_hints;
+DROP TABLE IF EXISTS room_moderators;
@@ -0,0 +1,35 @@
+-- Users an admin has made moderators of a room. Moderators curate the
+-- room's prompt hints.
+CREATE TABLE IF NOT EXISTS room_moderators (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_moderators_user
+    ON room_moderators(user_id);
+
+-- Every saved hint is a new numbered version; rows are never edited so a
+-- post can always be traced to the exact guidance it was generated with.
+CREATE TABLE IF NOT EXISTS room_prompt_hints (
+    id BIGSERIAL PRIMARY KEY,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    version INT NOT NULL CHECK (version > 0),
+    emphasize TEXT NOT NULL DEFAULT '' CHECK (char_length(emphasize) <= 500),
+    structure TEXT NOT NULL DEFAULT '' CHECK (char_length(structure) <= 500),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (room_id, version),
+    CONSTRAINT room_prompt_hints_not_empty CHECK (emphasize <> '' OR structure <> '')
+);
+
+ALTER TABLE rooms
+    ADD COLUMN IF NOT EXISTS prompt_hint_id BIGINT REFERENCES room_prompt_hints(id) ON DELETE SET NULL;
+
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS prompt_hint_id BIGINT REFERENCES room_prompt_hints(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_prompt_hint
+    ON posts(prompt_hint_id)
+    WHERE prompt_hint_id IS NOT NULL;
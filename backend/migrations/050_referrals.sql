@@ -0,0 +1,45 @@
+-- Each user gets a referral code the first time they ask for it.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS referral_code TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_referral_code
+    ON users(referral_code)
+    WHERE referral_code IS NOT NULL;
+
+-- One row per referred signup. rewarded is false when the referrer had
+-- already reached the reward cap; the signup is still attributed.
+CREATE TABLE IF NOT EXISTS referrals (
+    referred_user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    referrer_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    code TEXT NOT NULL,
+    ip_hash TEXT NOT NULL,
+    rewarded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT referrals_not_self CHECK (referrer_user_id IS NULL OR referrer_user_id <> referred_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_created
+    ON referrals(referrer_user_id, created_at DESC);
+
+-- A referrer is credited once per client IP hash, so one device cannot farm
+-- boosts with throwaway accounts.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referrals_referrer_ip
+    ON referrals(referrer_user_id, ip_hash);
+
+-- Temporary additions to a user's daily quotas. Quota checks add the sum of
+-- a user's active boosts of the matching type to the base limit.
+CREATE TABLE IF NOT EXISTS quota_boosts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quota_type TEXT NOT NULL CHECK (quota_type IN ('draft', 'battle')),
+    amount INT NOT NULL CHECK (amount > 0),
+    source TEXT NOT NULL CHECK (source IN ('referrer', 'referred')),
+    referred_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT quota_boosts_window_check CHECK (expires_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_boosts_user_type_expires
+    ON quota_boosts(user_id, quota_type, expires_at);
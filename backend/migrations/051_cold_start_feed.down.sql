@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS cold_start_dismissed_at;
+
+DROP TABLE IF EXISTS user_room_interests;
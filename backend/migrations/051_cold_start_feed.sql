@@ -0,0 +1,16 @@
+-- Rooms a user is interested in, declared at signup or taken from the first
+-- room they open. The feed uses them to recommend battles and personas
+-- until the user follows someone.
+CREATE TABLE IF NOT EXISTS user_room_interests (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('signup', 'first_visit')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, room_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_room_interests_room
+    ON user_room_interests(room_id);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS cold_start_dismissed_at TIMESTAMPTZ;
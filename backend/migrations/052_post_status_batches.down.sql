@@ -0,0 +1,14 @@
+DELETE FROM persona_activity_events
+WHERE type IN ('post_unpublished', 'post_republished');
+
+ALTER TABLE persona_activity_events
+    DROP CONSTRAINT IF EXISTS persona_activity_events_type_check;
+
+ALTER TABLE persona_activity_events
+    ADD CONSTRAINT persona_activity_events_type_check
+    CHECK (type IN ('post_created', 'reply_generated', 'thread_participated', 'human_reply_received'));
+
+DROP TABLE IF EXISTS post_status_batches;
+
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS unpublished_at;
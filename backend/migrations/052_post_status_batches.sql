@@ -0,0 +1,37 @@
+-- Unpublished posts go back to DRAFT but keep published_at; unpublished_at
+-- tells them apart from drafts that were never published, and only those
+-- can be republished.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS unpublished_at TIMESTAMPTZ;
+
+-- One row per bulk unpublish/republish request. Small batches are applied
+-- by the API straight away; larger ones are left PENDING and the worker
+-- applies them in chunks, advancing processed_posts as it goes.
+CREATE TABLE IF NOT EXISTS post_status_batches (
+    id BIGSERIAL PRIMARY KEY,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('unpublish', 'republish')),
+    post_ids UUID[] NOT NULL,
+    total_posts INT NOT NULL CHECK (total_posts > 0),
+    processed_posts INT NOT NULL DEFAULT 0,
+    changed_posts INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_status_batches_pending
+    ON post_status_batches(created_at)
+    WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_post_status_batches_persona_created
+    ON post_status_batches(persona_id, created_at DESC);
+
+ALTER TABLE persona_activity_events
+    DROP CONSTRAINT IF EXISTS persona_activity_events_type_check;
+
+ALTER TABLE persona_activity_events
+    ADD CONSTRAINT persona_activity_events_type_check
+    CHECK (type IN ('post_created', 'reply_generated', 'thread_participated', 'human_reply_received', 'post_unpublished', 'post_republished'));
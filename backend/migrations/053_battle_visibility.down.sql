@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS unlisted_key,
+    DROP COLUMN IF EXISTS visibility;
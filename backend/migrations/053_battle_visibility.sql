@@ -0,0 +1,7 @@
+-- Unlisted battles stay out of profiles, feeds and recommendations and are
+-- only readable through a signed link. unlisted_key is mixed into every link
+-- signature; replacing it revokes all links issued before.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+        CHECK (visibility IN ('public', 'unlisted')),
+    ADD COLUMN IF NOT EXISTS unlisted_key TEXT;
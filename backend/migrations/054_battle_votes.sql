@@ -0,0 +1,13 @@
+-- Audience votes pick which persona won a battle. Each signed-in viewer has
+-- one vote per battle and may move it to another participant.
+CREATE TABLE IF NOT EXISTS battle_votes (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_votes_post_persona
+    ON battle_votes(post_id, persona_id);
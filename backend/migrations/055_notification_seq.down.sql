@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS notifications_assign_seq ON notifications;
+DROP FUNCTION IF EXISTS assign_notification_seq();
+DROP INDEX IF EXISTS idx_notifications_user_seq;
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS seq;
+DROP TABLE IF EXISTS notification_sequences;
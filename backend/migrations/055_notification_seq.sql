@@ -0,0 +1,51 @@
+-- Every notification carries a per-user sequence number that only grows, so
+-- clients can mark everything up to a number read without racing each other.
+-- A rolled-up notification takes a new number when its content changes;
+-- marking it read does not.
+CREATE TABLE IF NOT EXISTS notification_sequences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL DEFAULT 0
+);
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE notifications n
+SET seq = ordered.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at, id) AS seq
+    FROM notifications
+) ordered
+WHERE n.id = ordered.id
+  AND n.seq IS NULL;
+
+INSERT INTO notification_sequences(user_id, last_seq)
+SELECT user_id, MAX(seq)
+FROM notifications
+GROUP BY user_id
+ON CONFLICT (user_id) DO UPDATE
+SET last_seq = GREATEST(notification_sequences.last_seq, EXCLUDED.last_seq);
+
+-- Notifications are written from the API, the worker and shared helpers; the
+-- trigger numbers all of them.
+CREATE OR REPLACE FUNCTION assign_notification_seq() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO notification_sequences(user_id, last_seq)
+    VALUES (NEW.user_id, 1)
+    ON CONFLICT (user_id) DO UPDATE
+    SET last_seq = notification_sequences.last_seq + 1
+    RETURNING last_seq INTO NEW.seq;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS notifications_assign_seq ON notifications;
+CREATE TRIGGER notifications_assign_seq
+    BEFORE INSERT OR UPDATE OF title, body, metadata, rollup_count, created_at ON notifications
+    FOR EACH ROW EXECUTE FUNCTION assign_notification_seq();
+
+ALTER TABLE notifications
+    ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_user_seq
+    ON notifications(user_id, seq);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_battle_challenges_opponent_persona_created_at;
+DROP TABLE IF EXISTS persona_marketplace_listings;
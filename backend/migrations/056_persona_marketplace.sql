@@ -0,0 +1,16 @@
+-- Public personas their owners list as available for guest battles. Listings
+-- only show while the persona's public profile is published.
+CREATE TABLE IF NOT EXISTS persona_marketplace_listings (
+    persona_id UUID PRIMARY KEY REFERENCES personas(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    topics TEXT[] NOT NULL DEFAULT '{}',
+    daily_battle_limit INT NOT NULL DEFAULT 3 CHECK (daily_battle_limit BETWEEN 1 AND 20),
+    listed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_marketplace_listings_topics
+    ON persona_marketplace_listings USING GIN (topics);
+
+CREATE INDEX IF NOT EXISTS idx_battle_challenges_opponent_persona_created_at
+    ON battle_challenges(opponent_persona_id, created_at DESC);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS quota_discrepancies;
+DROP TABLE IF EXISTS quota_reconciliations;
+
+DROP INDEX IF EXISTS idx_quota_events_created_at;
+
+ALTER TABLE quota_events
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS content_id,
+    DROP COLUMN IF EXISTS source;
@@ -0,0 +1,30 @@
+-- source names the endpoint or job that spent the quota and content_id the
+-- draft, reply or evaluation it produced. Rows written before this migration
+-- keep an empty source.
+ALTER TABLE quota_events
+    ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS content_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_quota_events_created_at ON quota_events(created_at);
+
+-- One row per reconciled UTC day; the worker claims a day by inserting it.
+CREATE TABLE IF NOT EXISTS quota_reconciliations (
+    day DATE PRIMARY KEY,
+    discrepancies INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS quota_discrepancies (
+    id BIGSERIAL PRIMARY KEY,
+    day DATE NOT NULL REFERENCES quota_reconciliations(day) ON DELETE CASCADE,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    quota_type TEXT NOT NULL,
+    recorded INT NOT NULL,
+    actual INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (day, persona_id, quota_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quota_discrepancies_persona_day ON quota_discrepancies(persona_id, day DESC);
@@ -0,0 +1,14 @@
+-- Translations of a battle's share-page verdict and takeaways. source_hash is
+-- the digest of the original text the row was translated from, so edited
+-- turns invalidate the translation instead of serving a stale one.
+CREATE TABLE IF NOT EXISTS battle_verdict_translations (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    language TEXT NOT NULL CHECK (language IN ('tr', 'en')),
+    source_language TEXT NOT NULL CHECK (source_language IN ('tr', 'en')),
+    source_hash TEXT NOT NULL,
+    verdict TEXT NOT NULL,
+    takeaways JSONB NOT NULL DEFAULT '[]'::jsonb,
+    prompt_version TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, language)
+);
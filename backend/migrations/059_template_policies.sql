@@ -0,0 +1,17 @@
+-- A template's requested policy and the version an admin approved. The worker
+-- only applies approved_policy, so a revised request keeps the previously
+-- approved policy in force until it is reviewed.
+CREATE TABLE IF NOT EXISTS template_policies (
+    template_id UUID PRIMARY KEY REFERENCES templates(id) ON DELETE CASCADE,
+    requested_policy JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    approved_policy JSONB,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note TEXT NOT NULL DEFAULT '',
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_policies_status
+    ON template_policies(status, requested_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS post_crossposts;
+DROP TABLE IF EXISTS social_accounts;
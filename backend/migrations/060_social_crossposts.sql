@@ -0,0 +1,33 @@
+-- Accounts users connected on external networks. Tokens never leave the
+-- backend; the API only returns the provider and the handle.
+CREATE TABLE IF NOT EXISTS social_accounts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('x', 'linkedin')),
+    external_user_id TEXT NOT NULL,
+    handle TEXT NOT NULL DEFAULT '',
+    access_token TEXT NOT NULL,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    connected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, provider)
+);
+
+-- One cross-post per post and network. backlink_url is the link back to the
+-- post that the published text carries; external_url is set once the
+-- network accepted the post.
+CREATE TABLE IF NOT EXISTS post_crossposts (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('x', 'linkedin')),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PUBLISHED', 'FAILED')),
+    job_id BIGINT,
+    backlink_url TEXT NOT NULL,
+    external_id TEXT NOT NULL DEFAULT '',
+    external_url TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, provider)
+);
@@ -0,0 +1,10 @@
+-- The highlight reel of a finished battle: the two or three best persona
+-- turns (quality score plus coaching citations) and a short recap of them.
+-- highlights lists {turn, reply_id, persona_id, score, verdict_refs}.
+CREATE TABLE IF NOT EXISTS battle_highlights (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    highlights JSONB NOT NULL DEFAULT '[]'::jsonb,
+    summary TEXT NOT NULL DEFAULT '',
+    prompt_version TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
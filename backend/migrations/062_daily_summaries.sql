@@ -0,0 +1,28 @@
+-- Opt-in daily rollup of everything a user's personas did the previous
+-- (UTC) day. One row per user and day; the worker also notifies from it.
+ALTER TABLE notification_preferences
+    ADD COLUMN IF NOT EXISTS daily_summary BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS user_daily_summaries (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    personas JSONB NOT NULL DEFAULT '[]'::jsonb,
+    pending_drafts JSONB NOT NULL DEFAULT '[]'::jsonb,
+    finished_battles JSONB NOT NULL DEFAULT '[]'::jsonb,
+    pending_draft_count INT NOT NULL DEFAULT 0,
+    new_followers INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_daily_summary
+    ON notification_preferences(user_id)
+    WHERE daily_summary;
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready', 'watched_battle_turn', 'watched_battle_verdict', 'persona_announced', 'daily_summary'));
@@ -0,0 +1,4 @@
+DELETE FROM jobs WHERE job_type = 'create_battle';
+
+DROP INDEX IF EXISTS idx_posts_user_battles_created;
+DROP TABLE IF EXISTS queued_battles;
@@ -0,0 +1,30 @@
+-- Battles requested over the daily limit with "queue": true. The row keeps
+-- the validated request; a create_battle job scheduled for the next daily
+-- window creates the battle and records it in battle_id.
+CREATE TABLE IF NOT EXISTS queued_battles (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    template_id UUID REFERENCES templates(id) ON DELETE SET NULL,
+    mode TEXT NOT NULL,
+    ai_persona_id UUID REFERENCES personas(id) ON DELETE CASCADE,
+    topic TEXT NOT NULL,
+    pro_style TEXT NOT NULL,
+    con_style TEXT NOT NULL,
+    battle_language TEXT NOT NULL DEFAULT 'auto',
+    battle_language_code TEXT,
+    source_battle_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'QUEUED' CHECK (status IN ('QUEUED', 'CREATED', 'CANCELED', 'FAILED')),
+    job_id BIGINT,
+    battle_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_queued_battles_user_status_created
+    ON queued_battles(user_id, status, created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_posts_user_battles_created
+    ON posts(user_id, created_at DESC)
+    WHERE template_id IS NOT NULL;
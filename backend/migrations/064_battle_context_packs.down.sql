@@ -0,0 +1,2 @@
+ALTER TABLE queued_battles DROP COLUMN IF EXISTS context_pack;
+DROP TABLE IF EXISTS battle_context_packs;
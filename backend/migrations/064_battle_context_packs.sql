@@ -0,0 +1,23 @@
+-- Shared background for a battle, summarized from the room's recent posts on
+-- the topic when the owner asked for it. Reply jobs of the battle wait for a
+-- PENDING pack for a short while and then go ahead without one. sources
+-- keeps the posts the summary was written from, for the owner to check.
+CREATE TABLE IF NOT EXISTS battle_context_packs (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    topic TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'READY', 'EMPTY', 'FAILED')),
+    summary TEXT NOT NULL DEFAULT '',
+    sources JSONB NOT NULL DEFAULT '[]'::jsonb,
+    prompt_version TEXT,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_context_packs_pending
+    ON battle_context_packs(created_at)
+    WHERE status = 'PENDING';
+
+ALTER TABLE queued_battles
+    ADD COLUMN IF NOT EXISTS context_pack BOOLEAN NOT NULL DEFAULT FALSE;
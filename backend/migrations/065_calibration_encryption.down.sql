@@ -0,0 +1,14 @@
+-- Run cmd/rotatekeys -decrypt first; sealed rows would fail the restored
+-- constraint.
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_calibration_lists_check;
+
+ALTER TABLE personas
+    ADD CONSTRAINT personas_calibration_lists_check
+    CHECK (
+        jsonb_typeof(writing_samples) = 'array' AND jsonb_array_length(writing_samples) <= 3
+        AND jsonb_typeof(do_not_say) = 'array' AND jsonb_array_length(do_not_say) <= 20
+        AND jsonb_typeof(catchphrases) = 'array' AND jsonb_array_length(catchphrases) <= 10
+    );
+
+DROP TABLE IF EXISTS user_data_keys;
@@ -0,0 +1,40 @@
+-- Per-user data keys for persona calibration lists (writing_samples,
+-- do_not_say, catchphrases). Keys are stored wrapped by the master key named
+-- in master_key_id; a user gets a new version when cmd/rotatekeys is run
+-- with -new-data-keys, and older versions are dropped once nothing is
+-- sealed with them.
+CREATE TABLE IF NOT EXISTS user_data_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    master_key_id TEXT NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rewrapped_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_data_keys_master_key
+    ON user_data_keys(master_key_id);
+
+-- An encrypted list is stored as a jsonb object {"v", "u", "k", "n", "c"}
+-- (common.sealedCalibration) in place of the array; the list limits are
+-- checked by the API before sealing.
+ALTER TABLE personas
+    DROP CONSTRAINT IF EXISTS personas_calibration_lists_check;
+
+ALTER TABLE personas
+    ADD CONSTRAINT personas_calibration_lists_check
+    CHECK (
+        (
+            (jsonb_typeof(writing_samples) = 'array' AND jsonb_array_length(writing_samples) <= 3)
+            OR (jsonb_typeof(writing_samples) = 'object' AND writing_samples ? 'c')
+        )
+        AND (
+            (jsonb_typeof(do_not_say) = 'array' AND jsonb_array_length(do_not_say) <= 20)
+            OR (jsonb_typeof(do_not_say) = 'object' AND do_not_say ? 'c')
+        )
+        AND (
+            (jsonb_typeof(catchphrases) = 'array' AND jsonb_array_length(catchphrases) <= 10)
+            OR (jsonb_typeof(catchphrases) = 'object' AND catchphrases ? 'c')
+        )
+    );
@@ -0,0 +1,7 @@
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS failed_turn_attempts,
+    DROP COLUMN IF EXISTS llm_latency_ms,
+    DROP COLUMN IF EXISTS llm_completion_tokens,
+    DROP COLUMN IF EXISTS llm_prompt_tokens,
+    DROP COLUMN IF EXISTS llm_retries,
+    DROP COLUMN IF EXISTS llm_calls;
@@ -0,0 +1,10 @@
+-- Running totals of the LLM work behind a battle's turns. Successful turns
+-- also keep their own numbers in replies.metadata->'usage'; failed attempts
+-- only show up here.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS llm_calls INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS llm_retries INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS llm_prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS llm_completion_tokens BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS llm_latency_ms BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS failed_turn_attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS persona_import_batches;
@@ -0,0 +1,24 @@
+-- One row per bulk persona import. The API validates every row when the
+-- import is requested; small imports are created in the request, larger
+-- ones (or any import sent with background=true) are left PENDING and the
+-- worker creates them in chunks. pending holds the validated rows, with
+-- calibration lists already sealed, and is emptied as they are created.
+CREATE TABLE IF NOT EXISTS persona_import_batches (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source TEXT NOT NULL CHECK (source IN ('json', 'csv')),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE')),
+    total_rows INT NOT NULL CHECK (total_rows > 0),
+    results JSONB NOT NULL DEFAULT '[]'::jsonb,
+    pending JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_import_batches_pending
+    ON persona_import_batches(created_at)
+    WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_persona_import_batches_user_created
+    ON persona_import_batches(user_id, created_at DESC);
@@ -0,0 +1,36 @@
+-- Shadow generations for validating a model upgrade. A sampled draft or
+-- reply queues a PENDING row holding the output that was actually used; the
+-- worker rebuilds the same prompt context, generates with the candidate
+-- model, scores both outputs and marks the row DONE. Candidate output is
+-- never published.
+CREATE TABLE IF NOT EXISTS llm_canary_runs (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('draft', 'reply')),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE')),
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    room_id UUID REFERENCES rooms(id) ON DELETE CASCADE,
+    post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+    reply_id UUID REFERENCES replies(id) ON DELETE CASCADE,
+    mood TEXT NOT NULL DEFAULT '',
+    primary_model TEXT NOT NULL,
+    candidate_model TEXT NOT NULL,
+    primary_output TEXT NOT NULL,
+    primary_latency_ms BIGINT NOT NULL DEFAULT 0,
+    primary_score JSONB,
+    primary_safety_error TEXT,
+    candidate_output TEXT,
+    candidate_error TEXT,
+    candidate_latency_ms BIGINT,
+    candidate_score JSONB,
+    candidate_safety_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_canary_runs_pending
+    ON llm_canary_runs(created_at)
+    WHERE status = 'PENDING';
+
+CREATE INDEX IF NOT EXISTS idx_llm_canary_runs_candidate_completed
+    ON llm_canary_runs(candidate_model, completed_at DESC)
+    WHERE status = 'DONE';
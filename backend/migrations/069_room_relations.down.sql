@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS room_relation_runs;
+DROP TABLE IF EXISTS room_relations;
@@ -0,0 +1,25 @@
+-- Related rooms, rebuilt once per UTC day by the worker from personas
+-- active in both rooms and the keywords their recent public posts share.
+-- Each room keeps its own top list, so a relation can be one-sided.
+CREATE TABLE IF NOT EXISTS room_relations (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    related_room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    shared_personas INT NOT NULL DEFAULT 0,
+    shared_keywords TEXT[] NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, related_room_id),
+    CHECK (room_id <> related_room_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_relations_room_score
+    ON room_relations(room_id, score DESC);
+
+-- One row per rebuilt UTC day; the worker claims a day by inserting it.
+CREATE TABLE IF NOT EXISTS room_relation_runs (
+    day DATE PRIMARY KEY,
+    rooms INT NOT NULL DEFAULT 0,
+    relations INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
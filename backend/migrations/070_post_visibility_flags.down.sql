@@ -0,0 +1,3 @@
+ALTER TABLE posts
+    DROP COLUMN IF EXISTS hidden_from_rooms,
+    DROP COLUMN IF EXISTS hidden_from_profile;
@@ -0,0 +1,7 @@
+-- Per-surface visibility for published posts. A post hidden from the profile
+-- still shows in its room and in feeds; a post hidden from rooms still shows
+-- on the persona's public profile. Both apply on top of visibility, so an
+-- unlisted battle stays off every surface either way.
+ALTER TABLE posts
+    ADD COLUMN IF NOT EXISTS hidden_from_profile BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS hidden_from_rooms BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,31 @@
+-- A/B experiments. Users are assigned deterministically from the experiment
+-- id and their user id, so no assignment is stored until a user is exposed.
+-- Variants can no longer change once someone has been exposed.
+CREATE TABLE IF NOT EXISTS experiments (
+    id TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    variants JSONB NOT NULL,
+    traffic_percent INT NOT NULL DEFAULT 100 CHECK (traffic_percent BETWEEN 0 AND 100),
+    new_users_only BOOLEAN NOT NULL DEFAULT FALSE,
+    conversion_events TEXT[] NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'running', 'stopped')),
+    started_at TIMESTAMPTZ,
+    stopped_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- First exposure of each user; conversions are funnel events the user
+-- logged at or after exposed_at.
+CREATE TABLE IF NOT EXISTS experiment_exposures (
+    experiment_id TEXT NOT NULL REFERENCES experiments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    variant TEXT NOT NULL,
+    exposed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (experiment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_exposures_variant
+    ON experiment_exposures(experiment_id, variant);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS battle_live_cards;
@@ -0,0 +1,21 @@
+-- Animated share cards of finished battles. The API queues a row with the
+-- card snapshot (card) the first time GET /b/:id/card.gif is asked for, or
+-- again once the battle changed after source_updated_at; the worker renders
+-- the GIF into it. Postgres is the asset store until there is object storage.
+CREATE TABLE IF NOT EXISTS battle_live_cards (
+    battle_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'READY', 'FAILED')),
+    source_updated_at TIMESTAMPTZ NOT NULL,
+    card JSONB NOT NULL,
+    gif BYTEA,
+    frame_count INT NOT NULL DEFAULT 0,
+    byte_size INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rendered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_live_cards_pending
+    ON battle_live_cards(requested_at)
+    WHERE status = 'PENDING';
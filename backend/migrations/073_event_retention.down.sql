@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_event_retention;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS event_retention_days;
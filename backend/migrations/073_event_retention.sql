@@ -0,0 +1,10 @@
+-- Users can cap how long their raw analytics events and their personas'
+-- activity events are kept. NULL keeps them until the account or persona
+-- is deleted.
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS event_retention_days INT
+        CHECK (event_retention_days IN (30, 90, 365));
+
+CREATE INDEX IF NOT EXISTS idx_users_event_retention
+    ON users(id)
+    WHERE event_retention_days IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS room_trending_topic_runs;
+DROP TABLE IF EXISTS room_trending_topics;
@@ -0,0 +1,22 @@
+-- Trending topics per room, rebuilt once per UTC day by the worker by
+-- clustering the keywords of recent battle topics and public posts.
+CREATE TABLE IF NOT EXISTS room_trending_topics (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    rank INT NOT NULL CHECK (rank >= 1),
+    label TEXT NOT NULL,
+    keywords TEXT[] NOT NULL DEFAULT '{}',
+    battles INT NOT NULL DEFAULT 0,
+    posts INT NOT NULL DEFAULT 0,
+    example_battle_ids UUID[] NOT NULL DEFAULT '{}',
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, rank)
+);
+
+-- One row per rebuilt UTC day; the worker claims a day by inserting it.
+CREATE TABLE IF NOT EXISTS room_trending_topic_runs (
+    day DATE PRIMARY KEY,
+    rooms INT NOT NULL DEFAULT 0,
+    topics INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS battle_coaching_outbox ON battle_coaching;
+DROP TRIGGER IF EXISTS posts_republished_outbox ON posts;
+DROP TRIGGER IF EXISTS posts_published_outbox ON posts;
+DROP TRIGGER IF EXISTS events_outbox ON events;
+DROP FUNCTION IF EXISTS outbox_battle_completed();
+DROP FUNCTION IF EXISTS outbox_post_published();
+DROP FUNCTION IF EXISTS outbox_analytics_event();
+DROP TABLE IF EXISTS event_bus_state;
+DROP TABLE IF EXISTS event_outbox;
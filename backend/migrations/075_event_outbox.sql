@@ -0,0 +1,135 @@
+-- Product events are mirrored to an external bus (Kafka or NATS) for
+-- analytics. Triggers write them to an outbox in the same transaction as the
+-- change, and the worker relays pending rows, so a bus outage delays
+-- messages instead of losing them.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    schema_version INT NOT NULL,
+    subject_id TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts INT NOT NULL DEFAULT 0,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending
+    ON event_outbox(available_at, id)
+    WHERE published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_published_at
+    ON event_outbox(published_at)
+    WHERE published_at IS NOT NULL;
+
+-- A battle completes once, however many personas are coached.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_outbox_battle_completed
+    ON event_outbox(subject_id)
+    WHERE type = 'battle.completed';
+
+-- The worker flips this on when a bus is configured, so deployments without
+-- one do not fill the outbox.
+CREATE TABLE IF NOT EXISTS event_bus_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO event_bus_state(id, enabled)
+VALUES (TRUE, FALSE)
+ON CONFLICT (id) DO NOTHING;
+
+-- Events are written from the API, the worker and shared helpers; the
+-- triggers capture all of them.
+CREATE OR REPLACE FUNCTION outbox_analytics_event() RETURNS trigger AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM event_bus_state WHERE enabled) THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO event_outbox(type, schema_version, subject_id, payload, created_at)
+    VALUES ('analytics.event', 1, NEW.id::text, jsonb_build_object(
+        'event_id', NEW.id,
+        'event_name', NEW.event_name,
+        'user_id', NEW.user_id,
+        'metadata', NEW.metadata,
+        'created_at', NEW.created_at
+    ), NEW.created_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS events_outbox ON events;
+CREATE TRIGGER events_outbox
+    AFTER INSERT ON events
+    FOR EACH ROW EXECUTE FUNCTION outbox_analytics_event();
+
+-- Post content is left out; consumers that need it read the post through
+-- the API, which applies visibility.
+CREATE OR REPLACE FUNCTION outbox_post_published() RETURNS trigger AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM event_bus_state WHERE enabled) THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO event_outbox(type, schema_version, subject_id, payload)
+    VALUES ('post.published', 1, NEW.id::text, jsonb_build_object(
+        'post_id', NEW.id,
+        'room_id', NEW.room_id,
+        'persona_id', NEW.persona_id,
+        'user_id', NEW.user_id,
+        'authored_by', NEW.authored_by,
+        'is_battle', NEW.template_id IS NOT NULL,
+        'visibility', NEW.visibility,
+        'published_at', COALESCE(NEW.published_at, NOW())
+    ));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_published_outbox ON posts;
+CREATE TRIGGER posts_published_outbox
+    AFTER INSERT ON posts
+    FOR EACH ROW
+    WHEN (NEW.status = 'PUBLISHED')
+    EXECUTE FUNCTION outbox_post_published();
+
+DROP TRIGGER IF EXISTS posts_republished_outbox ON posts;
+CREATE TRIGGER posts_republished_outbox
+    AFTER UPDATE OF status ON posts
+    FOR EACH ROW
+    WHEN (NEW.status = 'PUBLISHED' AND OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION outbox_post_published();
+
+-- Coaching is written once every persona of a battle has replied, which is
+-- when the battle counts as completed.
+CREATE OR REPLACE FUNCTION outbox_battle_completed() RETURNS trigger AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM event_bus_state WHERE enabled) THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO event_outbox(type, schema_version, subject_id, payload)
+    SELECT 'battle.completed', 1, p.id::text, jsonb_build_object(
+        'battle_id', p.id,
+        'room_id', p.room_id,
+        'user_id', p.user_id,
+        'template_id', p.template_id,
+        'visibility', p.visibility,
+        'personas', (
+            SELECT COUNT(DISTINCT r.persona_id)
+            FROM replies r
+            WHERE r.post_id = p.id
+              AND r.persona_id IS NOT NULL
+        ),
+        'completed_at', NEW.created_at
+    )
+    FROM posts p
+    WHERE p.id = NEW.post_id
+    ON CONFLICT (subject_id) WHERE type = 'battle.completed' DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS battle_coaching_outbox ON battle_coaching;
+CREATE TRIGGER battle_coaching_outbox
+    AFTER INSERT ON battle_coaching
+    FOR EACH ROW EXECUTE FUNCTION outbox_battle_completed();
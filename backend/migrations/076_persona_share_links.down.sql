@@ -0,0 +1,11 @@
+DELETE FROM notifications WHERE type = 'persona_edit_suggested';
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready', 'watched_battle_turn', 'watched_battle_verdict', 'persona_announced', 'daily_summary'));
+
+DROP TABLE IF EXISTS persona_edit_suggestions;
+DROP TABLE IF EXISTS persona_share_links;
@@ -0,0 +1,47 @@
+-- Owners share a persona with someone who is not a collaborator through a
+-- link scoped to viewing its calibration, suggesting edits or running
+-- previews. Only a hash of the link token is stored.
+CREATE TABLE IF NOT EXISTS persona_share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    created_by_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label TEXT NOT NULL DEFAULT '',
+    scopes TEXT[] NOT NULL
+        CHECK (cardinality(scopes) > 0
+            AND scopes <@ ARRAY['view_calibration', 'suggest_edits', 'run_preview']::text[]),
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_share_links_persona
+    ON persona_share_links(persona_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS persona_edit_suggestions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    share_link_id UUID NOT NULL REFERENCES persona_share_links(id) ON DELETE CASCADE,
+    field TEXT NOT NULL
+        CHECK (field IN ('bio', 'tone', 'writing_samples', 'do_not_say', 'catchphrases', 'formality', 'preferred_language', 'general')),
+    suggestion TEXT NOT NULL,
+    author_name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'ACCEPTED', 'DISMISSED')),
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_edit_suggestions_persona_status
+    ON persona_edit_suggestions(persona_id, status, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_persona_edit_suggestions_link_created
+    ON persona_edit_suggestions(share_link_id, created_at DESC);
+
+ALTER TABLE notifications
+    DROP CONSTRAINT IF EXISTS notifications_type_check;
+
+ALTER TABLE notifications
+    ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('battle_remixed', 'template_used', 'persona_followed', 'persona_question', 'battle_completed', 'battle_challenge', 'battle_challenge_accepted', 'persona_mentioned', 'battle_of_week', 'draft_ready', 'watched_battle_turn', 'watched_battle_verdict', 'persona_announced', 'daily_summary', 'persona_edit_suggested'));
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS replies_thread_update ON replies;
+DROP FUNCTION IF EXISTS notify_thread_update();
@@ -0,0 +1,17 @@
+-- Thread streams (GET /posts/{id}/stream) listen on this channel. Postgres
+-- delivers the notification when the inserting transaction commits, so
+-- listeners never see a reply they cannot read yet.
+CREATE OR REPLACE FUNCTION notify_thread_update() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('thread_updates', json_build_object(
+        'post_id', NEW.post_id,
+        'reply_id', NEW.id
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS replies_thread_update ON replies;
+CREATE TRIGGER replies_thread_update
+    AFTER INSERT ON replies
+    FOR EACH ROW EXECUTE FUNCTION notify_thread_update();
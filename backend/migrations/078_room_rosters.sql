@@ -0,0 +1,20 @@
+-- Personas enrolled in a room with a weekly activity target. The worker
+-- paces draft and reply generation over the week to meet it; progress is
+-- counted from posts and replies, so nothing here tracks it.
+CREATE TABLE IF NOT EXISTS room_roster_enrollments (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    persona_id UUID NOT NULL REFERENCES personas(id) ON DELETE CASCADE,
+    weekly_posts INT NOT NULL DEFAULT 0 CHECK (weekly_posts BETWEEN 0 AND 21),
+    weekly_replies INT NOT NULL DEFAULT 0 CHECK (weekly_replies BETWEEN 0 AND 70),
+    checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (room_id, persona_id),
+    CHECK (weekly_posts > 0 OR weekly_replies > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_roster_enrollments_persona
+    ON room_roster_enrollments(persona_id);
+
+CREATE INDEX IF NOT EXISTS idx_room_roster_enrollments_checked
+    ON room_roster_enrollments(checked_at NULLS FIRST);
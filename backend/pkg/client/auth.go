@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Session is the result of Signup or Login.
+type Session struct {
+	Token  string `json:"token"`
+	UserID string `json:"user_id"`
+}
+
+// Signup creates an account and stores its token on the client.
+func (c *Client) Signup(ctx context.Context, email, password string) (Session, error) {
+	return c.authenticate(ctx, "/auth/signup", email, password)
+}
+
+// Login signs in and stores the session token on the client.
+func (c *Client) Login(ctx context.Context, email, password string) (Session, error) {
+	return c.authenticate(ctx, "/auth/login", email, password)
+}
+
+func (c *Client) authenticate(ctx context.Context, path, email, password string) (Session, error) {
+	var out Session
+	err := c.Do(ctx, http.MethodPost, path, nil, map[string]string{
+		"email":    email,
+		"password": password,
+	}, &out)
+	if err != nil {
+		return Session{}, err
+	}
+	c.SetToken(out.Token)
+	return out, nil
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BattleRequest is the body of CreateBattle. Leave TemplateID empty to use
+// the default template. Mode "interactive" with PersonaID lets the caller
+// write the second side. Queue asks for the battle to be queued instead of
+// rejected once the daily battle limit is reached. ContextPack gives both
+// sides a summary of the room's recent posts on the topic.
+type BattleRequest struct {
+	Topic       string `json:"topic"`
+	TemplateID  string `json:"template_id,omitempty"`
+	RemixToken  string `json:"remix_token,omitempty"`
+	ProStyle    string `json:"pro_style,omitempty"`
+	ConStyle    string `json:"con_style,omitempty"`
+	Mode        string `json:"mode,omitempty"`
+	PersonaID   string `json:"persona_id,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Queue       bool   `json:"queue,omitempty"`
+	ContextPack bool   `json:"context_pack,omitempty"`
+}
+
+// BattleLimit is the caller's daily battle limit. AvailableAt is set once the
+// limit is reached.
+type BattleLimit struct {
+	Limit             int        `json:"limit"`
+	Used              int        `json:"used"`
+	AvailableAt       *time.Time `json:"available_at,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+}
+
+// InteractiveBattle is the turn state of an interactive battle.
+type InteractiveBattle struct {
+	BattleID     string     `json:"battle_id"`
+	AIPersonaID  string     `json:"ai_persona_id"`
+	TotalTurns   int        `json:"total_turns"`
+	TurnsTaken   int        `json:"turns_taken"`
+	NextSide     string     `json:"next_side"`
+	Status       string     `json:"status"`
+	TurnDeadline *time.Time `json:"turn_deadline,omitempty"`
+}
+
+// QueuedBattle is a battle waiting for the next daily window.
+type QueuedBattle struct {
+	ID          int64      `json:"id"`
+	Status      string     `json:"status"`
+	RoomID      string     `json:"room_id"`
+	RoomName    string     `json:"room_name"`
+	Topic       string     `json:"topic"`
+	Mode        string     `json:"mode"`
+	TemplateID  string     `json:"template_id,omitempty"`
+	Position    int        `json:"position,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	BattleID    string     `json:"battle_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+}
+
+// BattleResult is the outcome of CreateBattle. Queued is set, and Post is
+// nil, when the battle went to the queue instead of being created.
+type BattleResult struct {
+	BattleID        string             `json:"battle_id"`
+	Post            *Post              `json:"post"`
+	RoomName        string             `json:"room_name"`
+	Template        *Template          `json:"template"`
+	EnqueuedReplies int                `json:"enqueued_replies"`
+	RemixUsed       bool               `json:"remix_used"`
+	SuggestedNext   string             `json:"suggested_next_url"`
+	Interactive     *InteractiveBattle `json:"interactive,omitempty"`
+	Limit           *BattleLimit       `json:"limit,omitempty"`
+	Queued          *QueuedBattle      `json:"queued_battle,omitempty"`
+}
+
+type Reply struct {
+	ID          string            `json:"id"`
+	PostID      string            `json:"post_id"`
+	PersonaID   string            `json:"persona_id,omitempty"`
+	PersonaName string            `json:"persona_name,omitempty"`
+	AuthoredBy  string            `json:"authored_by"`
+	Content     string            `json:"content"`
+	Language    string            `json:"language,omitempty"`
+	Bilingual   map[string]string `json:"bilingual,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type LinkedBattle struct {
+	BattleID  string    `json:"battle_id"`
+	Topic     string    `json:"topic"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Thread is a post or battle with its replies.
+type Thread struct {
+	Post        Post               `json:"post"`
+	Replies     []Reply            `json:"replies"`
+	Battles     []LinkedBattle     `json:"battles"`
+	AISummary   string             `json:"ai_summary"`
+	Watching    *bool              `json:"watching,omitempty"`
+	Interactive *InteractiveBattle `json:"interactive,omitempty"`
+}
+
+// CreateBattle starts a battle in roomID. Over the daily limit it returns an
+// *APIError with code "battle_limit" unless req.Queue is set, in which case
+// the result carries the queued battle.
+func (c *Client) CreateBattle(ctx context.Context, roomID string, req BattleRequest) (BattleResult, error) {
+	var out BattleResult
+	err := c.Do(ctx, http.MethodPost, "/rooms/"+escape(roomID)+"/battles", nil, req, &out)
+	return out, err
+}
+
+// DryRunBattle validates a battle request and returns the planned turns and
+// cost estimate without creating anything. The plan is returned as raw JSON.
+func (c *Client) DryRunBattle(ctx context.Context, roomID string, req BattleRequest) (json.RawMessage, error) {
+	var out json.RawMessage
+	err := c.Do(ctx, http.MethodPost, "/rooms/"+escape(roomID)+"/battles/dry-run", nil, req, &out)
+	return out, err
+}
+
+// GetThread returns a post or battle with its replies.
+func (c *Client) GetThread(ctx context.Context, postID string) (Thread, error) {
+	var out Thread
+	err := c.Do(ctx, http.MethodGet, "/posts/"+escape(postID)+"/thread", nil, nil, &out)
+	return out, err
+}
+
+// SubmitBattleTurn writes the caller's turn in an interactive battle.
+func (c *Client) SubmitBattleTurn(ctx context.Context, battleID, content string) (json.RawMessage, error) {
+	var out json.RawMessage
+	err := c.Do(ctx, http.MethodPost, "/battles/"+escape(battleID)+"/my-turn", nil, map[string]string{"content": content}, &out)
+	return out, err
+}
+
+// ListQueuedBattles returns the caller's battle queue in order and the
+// current daily limit.
+func (c *Client) ListQueuedBattles(ctx context.Context) ([]QueuedBattle, BattleLimit, error) {
+	var out struct {
+		Queue []QueuedBattle `json:"queue"`
+		Limit BattleLimit    `json:"limit"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/battles/queue", nil, nil, &out); err != nil {
+		return nil, BattleLimit{}, err
+	}
+	return out.Queue, out.Limit, nil
+}
+
+// CancelQueuedBattle removes a battle from the queue. It reports false when
+// the battle was no longer queued.
+func (c *Client) CancelQueuedBattle(ctx context.Context, queuedBattleID int64) (bool, error) {
+	var out struct {
+		Removed bool `json:"removed"`
+	}
+	err := c.Do(ctx, http.MethodDelete, "/battles/queue/"+strconv.FormatInt(queuedBattleID, 10), nil, nil, &out)
+	return out.Removed, err
+}
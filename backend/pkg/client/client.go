@@ -0,0 +1,272 @@
+// Package client is a typed Go client for the PersonaWorlds API, for
+// automation that would otherwise hand-roll HTTP calls. It covers personas,
+// drafts, battles, templates and the feed; other endpoints can be reached
+// with Do.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config configures a Client. Only BaseURL is required.
+type Config struct {
+	// BaseURL is the API origin, for example http://localhost:8080.
+	BaseURL string
+	// Token is a session token from Signup or Login. It can also be set later
+	// with SetToken.
+	Token string
+	// HTTPClient defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+	// MaxRetries caps retries of a failed request (default 3, at most 10).
+	// Negative disables retries.
+	MaxRetries int
+	// RetryBase is the first backoff delay, doubled on every retry (default
+	// 400ms).
+	RetryBase time.Duration
+	// MaxRetryWait is the longest Retry-After the client waits out. Longer
+	// waits, such as the daily battle limit, are returned as errors instead
+	// (default 30s).
+	MaxRetryWait time.Duration
+	// UserAgent is sent with every request.
+	UserAgent string
+}
+
+// Client calls the PersonaWorlds API. It is safe for concurrent use.
+type Client struct {
+	baseURL      string
+	http         *http.Client
+	maxRetries   int
+	retryBase    time.Duration
+	maxRetryWait time.Duration
+	userAgent    string
+
+	mu    sync.RWMutex
+	token string
+}
+
+func New(cfg Config) (*Client, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("client: invalid base url %q", cfg.BaseURL)
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if maxRetries > 10 {
+		maxRetries = 10
+	}
+	retryBase := cfg.RetryBase
+	if retryBase <= 0 {
+		retryBase = 400 * time.Millisecond
+	}
+	maxRetryWait := cfg.MaxRetryWait
+	if maxRetryWait <= 0 {
+		maxRetryWait = 30 * time.Second
+	}
+	userAgent := strings.TrimSpace(cfg.UserAgent)
+	if userAgent == "" {
+		userAgent = "personaworlds-go-client"
+	}
+	return &Client{
+		baseURL:      baseURL,
+		http:         httpClient,
+		maxRetries:   maxRetries,
+		retryBase:    retryBase,
+		maxRetryWait: maxRetryWait,
+		userAgent:    userAgent,
+		token:        strings.TrimSpace(cfg.Token),
+	}, nil
+}
+
+// SetToken replaces the session token sent as a bearer token.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	c.token = strings.TrimSpace(token)
+	c.mu.Unlock()
+}
+
+// Token returns the current session token.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// Do sends a JSON request to path and decodes the JSON response into out,
+// which may be nil. body is encoded as JSON when it is not nil. Non-2xx
+// responses are returned as *APIError.
+func (c *Client) Do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	_, err := c.do(ctx, method, path, query, body, out)
+	return err
+}
+
+// do is Do that also returns the response status, for endpoints whose
+// success status changes the meaning of the body.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (int, error) {
+	var payload []byte
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		payload = encoded
+	}
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		status, wait, retryable, err := c.doOnce(ctx, method, endpoint, payload, out)
+		if err == nil {
+			return status, nil
+		}
+		lastErr = err
+		if !retryable || attempt >= c.maxRetries {
+			break
+		}
+		if wait <= 0 {
+			wait = retryDelay(c.retryBase, attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return 0, lastErr
+}
+
+// doOnce sends one attempt. It reports whether the failure may be retried and
+// how long the server asked to wait first.
+func (c *Client) doOnce(ctx context.Context, method, endpoint string, payload []byte, out any) (int, time.Duration, bool, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, 0, false, err
+		}
+		// The request may have reached the server, so only methods that are
+		// safe to repeat are retried.
+		return 0, 0, idempotent(method), err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		apiErr := decodeAPIError(resp)
+		return resp.StatusCode, apiErr.RetryAfter, c.retryable(method, apiErr), apiErr
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, 0, false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, 0, false, fmt.Errorf("client: decode %s response: %w", req.URL.Path, err)
+	}
+	return resp.StatusCode, 0, false, nil
+}
+
+// retryable decides whether a failed response is worth another attempt. Rate
+// limited requests were rejected before doing any work, so they are retried
+// for every method unless the server asks for a longer wait than the client
+// allows. Server errors are only retried for idempotent methods.
+func (c *Client) retryable(method string, err *APIError) bool {
+	switch {
+	case err.StatusCode == http.StatusTooManyRequests:
+		return err.RetryAfter <= c.maxRetryWait
+	case err.StatusCode >= http.StatusInternalServerError:
+		return idempotent(method)
+	default:
+		return false
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func decodeAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(raw, &body); err == nil {
+		apiErr.Message = body.Error
+		apiErr.Code = body.Code
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	apiErr.Body = raw
+	return apiErr
+}
+
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	delay := base * time.Duration(1<<attempt)
+	jitterScale := 0.8 + (rand.Float64() * 0.4)
+	jittered := time.Duration(float64(delay) * jitterScale)
+	if jittered < 50*time.Millisecond {
+		return 50 * time.Millisecond
+	}
+	return jittered
+}
+
+func escape(segment string) string {
+	return url.PathEscape(strings.TrimSpace(segment))
+}
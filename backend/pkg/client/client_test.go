@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := New(Config{BaseURL: server.URL, RetryBase: time.Millisecond, MaxRetryWait: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	return c
+}
+
+func TestNewRejectsInvalidBaseURL(t *testing.T) {
+	if _, err := New(Config{BaseURL: "localhost"}); err == nil {
+		t.Fatalf("expected an error for a base url without scheme")
+	}
+}
+
+func TestLoginStoresToken(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/login":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"token":"tok-1","user_id":"u1"}`))
+		case "/personas":
+			if got := r.Header.Get("Authorization"); got != "Bearer tok-1" {
+				t.Errorf("authorization header = %q", got)
+			}
+			_, _ = w.Write([]byte(`{"personas":[{"id":"p1","name":"Ada"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	session, err := c.Login(context.Background(), "a@example.com", "password123")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if session.UserID != "u1" || c.Token() != "tok-1" {
+		t.Fatalf("unexpected session %+v, token %q", session, c.Token())
+	}
+	personas, err := c.ListPersonas(context.Background())
+	if err != nil {
+		t.Fatalf("list personas: %v", err)
+	}
+	if len(personas) != 1 || personas[0].Name != "Ada" {
+		t.Fatalf("unexpected personas %+v", personas)
+	}
+}
+
+func TestDoRetriesServerErrorsForIdempotentMethods(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"templates":[]}`))
+	})
+
+	if _, err := c.ListTemplates(context.Background()); err != nil {
+		t.Fatalf("list templates: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestDoDoesNotRetryServerErrorsForPost(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":"could not create template"}`))
+	})
+
+	_, err := c.CreateTemplate(context.Background(), TemplateInput{Name: "x"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "could not create template" {
+		t.Fatalf("expected api error, got %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got)
+	}
+}
+
+func TestDoRateLimits(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rooms/r1/battles":
+			calls.Add(1)
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":"daily battle limit reached","code":"battle_limit"}`))
+		case "/templates":
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":"t1"}`))
+		}
+	})
+
+	// A short Retry-After is waited out, even for POST.
+	if _, err := c.CreateTemplate(context.Background(), TemplateInput{Name: "x"}); err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	// One longer than MaxRetryWait is returned right away.
+	calls.Store(0)
+	_, err := c.CreateBattle(context.Background(), "r1", BattleRequest{Topic: "t"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "battle_limit" || apiErr.RetryAfter != time.Hour {
+		t.Fatalf("expected battle_limit error, got %v", err)
+	}
+	if !IsRateLimited(err) || calls.Load() != 1 {
+		t.Fatalf("expected one rate limited attempt, got %d", calls.Load())
+	}
+}
+
+func TestCreateDraftDistinguishesJobs(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"job_id":42,"status":"pending","room_id":"r1","persona_id":"p1"}`))
+	})
+
+	result, err := c.CreateDraft(context.Background(), "r1", "p1", "")
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	if result.Post != nil || result.Job == nil || result.Job.ID != 42 {
+		t.Fatalf("expected a job, got %+v", result)
+	}
+}
+
+func TestFeedIteratorFollowsCursors(t *testing.T) {
+	pages := map[string]string{
+		"":   `{"items":[{"id":"battle:1"},{"id":"battle:2"}],"next_cursor":"c2"}`,
+		"c2": `{"items":[],"next_cursor":"c3"}`,
+		"c3": `{"items":[{"id":"template:3"}]}`,
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	})
+
+	var ids []string
+	it := c.Feed(context.Background(), FeedOptions{Limit: 2})
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterate feed: %v", err)
+	}
+	if len(ids) != 3 || ids[0] != "battle:1" || ids[2] != "template:3" {
+		t.Fatalf("unexpected items %v", ids)
+	}
+	if it.Next() {
+		t.Fatalf("expected iterator to stay finished")
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"personaworlds/backend/internal/ai"
+	"personaworlds/backend/internal/api"
+	"personaworlds/backend/internal/config"
+	"personaworlds/backend/internal/db"
+)
+
+// TestClientContract drives a real API server through the client, so a
+// response shape change on either side fails here.
+func TestClientContract(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("db connect failed: %v", err)
+	}
+	defer pool.Close()
+
+	cfg := config.Load()
+	cfg.DatabaseURL = databaseURL
+	cfg.JWTSecret = "client-contract-secret"
+	cfg.MigrationsDir = filepath.Join("..", "..", "migrations")
+	if err := db.RunMigrations(ctx, pool, cfg.MigrationsDir); err != nil {
+		t.Fatalf("migrations failed: %v", err)
+	}
+
+	unique := time.Now().UnixNano()
+	var roomID string
+	if err := pool.QueryRow(ctx, `
+		INSERT INTO rooms(slug, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING id::text
+	`, fmt.Sprintf("client-contract-%d", unique), "client-contract", "Room used by the client contract test.").Scan(&roomID); err != nil {
+		t.Fatalf("insert room failed: %v", err)
+	}
+
+	server := httptest.NewServer(api.New(cfg, pool, ai.NewMockClient()).Router())
+	defer server.Close()
+	c, err := New(Config{BaseURL: server.URL, RetryBase: time.Millisecond})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	if _, err := c.ListPersonas(ctx); !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized before signup, got %v", err)
+	}
+	session, err := c.Signup(ctx, fmt.Sprintf("client-contract-%d@example.com", unique), "password123")
+	if err != nil {
+		t.Fatalf("signup: %v", err)
+	}
+	if session.Token == "" || session.UserID == "" {
+		t.Fatalf("signup returned an empty session: %+v", session)
+	}
+
+	persona, err := c.CreatePersona(ctx, PersonaInput{
+		Name:              "Contract Persona",
+		Bio:               "Checks that the client and server agree.",
+		Tone:              "direct",
+		WritingSamples:    []string{"Ship small", "Measure outcomes", "Ask one sharp question"},
+		DoNotSay:          []string{"guaranteed growth"},
+		PreferredLanguage: "en",
+		Formality:         1,
+	})
+	if err != nil {
+		t.Fatalf("create persona: %v", err)
+	}
+	if persona.ID == "" || persona.DailyDraftQuota != cfg.DefaultDraftQuota {
+		t.Fatalf("unexpected persona %+v", persona)
+	}
+	input := InputFromPersona(persona)
+	input.Bio = "Updated through the client."
+	updated, err := c.UpdatePersona(ctx, persona.ID, input)
+	if err != nil {
+		t.Fatalf("update persona: %v", err)
+	}
+	if updated.Bio != input.Bio {
+		t.Fatalf("expected updated bio, got %q", updated.Bio)
+	}
+	if _, err := c.GetPersona(ctx, "00000000-0000-0000-0000-000000000000"); !IsNotFound(err) {
+		t.Fatalf("expected not found for unknown persona, got %v", err)
+	}
+
+	draft, err := c.CreateDraft(ctx, roomID, persona.ID, "")
+	if err != nil {
+		t.Fatalf("create draft: %v", err)
+	}
+	if draft.Job != nil {
+		job, err := c.WaitForDraft(ctx, draft.Job.ID, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("wait for draft: %v", err)
+		}
+		draft.Post = job.Post
+	}
+	if draft.Post == nil || draft.Post.Status != "DRAFT" {
+		t.Fatalf("expected a draft post, got %+v", draft)
+	}
+	published, err := c.ApprovePost(ctx, draft.Post.ID, "")
+	if err != nil {
+		t.Fatalf("approve post: %v", err)
+	}
+	if published.Status != "PUBLISHED" {
+		t.Fatalf("expected published post, got %q", published.Status)
+	}
+
+	template, err := c.CreateTemplate(ctx, TemplateInput{
+		Name:        fmt.Sprintf("Contract template %d", unique),
+		PromptRules: "Each side makes one concrete claim per turn.",
+		TurnCount:   4,
+		WordLimit:   120,
+		IsPublic:    true,
+	})
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	templates, err := c.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("list templates: %v", err)
+	}
+	found := false
+	for _, item := range templates {
+		found = found || item.ID == template.ID
+	}
+	if !found {
+		t.Fatalf("created template %s missing from public templates", template.ID)
+	}
+
+	battle, err := c.CreateBattle(ctx, roomID, BattleRequest{
+		Topic:      "Small teams ship faster than large ones",
+		TemplateID: template.ID,
+	})
+	if err != nil {
+		t.Fatalf("create battle: %v", err)
+	}
+	if battle.BattleID == "" || battle.Post == nil || battle.Template == nil || battle.Template.ID != template.ID {
+		t.Fatalf("unexpected battle result %+v", battle)
+	}
+	thread, err := c.GetThread(ctx, battle.BattleID)
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+	if thread.Post.ID != battle.BattleID || thread.Post.BattleLanguage == nil {
+		t.Fatalf("unexpected thread %+v", thread.Post)
+	}
+
+	it := c.Feed(ctx, FeedOptions{Limit: 5})
+	for it.Next() {
+		if item := it.Item(); item.ID == "" || item.Kind == "" {
+			t.Fatalf("feed item without id or kind: %+v", item)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("iterate feed: %v", err)
+	}
+
+	if err := c.DeletePersona(ctx, persona.ID); err != nil {
+		t.Fatalf("delete persona: %v", err)
+	}
+	personas, err := c.ListPersonas(ctx)
+	if err != nil {
+		t.Fatalf("list personas: %v", err)
+	}
+	if len(personas) != 0 {
+		t.Fatalf("expected no personas after delete, got %d", len(personas))
+	}
+}
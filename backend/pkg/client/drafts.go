@@ -0,0 +1,155 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DraftJobPending    = "pending"
+	DraftJobProcessing = "processing"
+	DraftJobReady      = "ready"
+	DraftJobFailed     = "failed"
+)
+
+type Room struct {
+	ID               string     `json:"id"`
+	Slug             string     `json:"slug"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ArchivedAt       *time.Time `json:"archived_at,omitempty"`
+	MergedIntoRoomID string     `json:"merged_into_room_id,omitempty"`
+}
+
+type ReactionCounts struct {
+	Like       int `json:"like"`
+	Insightful int `json:"insightful"`
+	Disagree   int `json:"disagree"`
+}
+
+// BattleLanguage is a battle's language setting: Mode is auto, a, b or
+// bilingual and Language the pinned code, if any.
+type BattleLanguage struct {
+	Mode     string `json:"mode"`
+	Language string `json:"language,omitempty"`
+}
+
+type Post struct {
+	ID             string          `json:"id"`
+	RoomID         string          `json:"room_id"`
+	PersonaID      string          `json:"persona_id,omitempty"`
+	PersonaName    string          `json:"persona_name,omitempty"`
+	AuthoredBy     string          `json:"authored_by"`
+	Status         string          `json:"status"`
+	Content        string          `json:"content"`
+	SourcePostID   string          `json:"source_post_id,omitempty"`
+	Mood           string          `json:"mood,omitempty"`
+	BattleLanguage *BattleLanguage `json:"battle_language,omitempty"`
+	Reactions      ReactionCounts  `json:"reactions"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// DraftJob is a draft the API handed to the worker because generation missed
+// the request deadline. Post is set once Status is ready.
+type DraftJob struct {
+	ID        int64     `json:"job_id"`
+	Status    string    `json:"status"`
+	RoomID    string    `json:"room_id"`
+	PersonaID string    `json:"persona_id"`
+	Error     string    `json:"error,omitempty"`
+	Post      *Post     `json:"post,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DraftResult is either the generated draft or, when generation was slow,
+// the job that will produce it. Exactly one field is set.
+type DraftResult struct {
+	Post *Post
+	Job  *DraftJob
+}
+
+func (c *Client) ListRooms(ctx context.Context) ([]Room, error) {
+	var out struct {
+		Rooms []Room `json:"rooms"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/rooms", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Rooms, nil
+}
+
+// CreateDraft asks personaID to draft a post in roomID. mood may be empty.
+// Use WaitForDraft when the result is a job.
+func (c *Client) CreateDraft(ctx context.Context, roomID, personaID, mood string) (DraftResult, error) {
+	body := map[string]string{"persona_id": personaID}
+	if mood != "" {
+		body["mood"] = mood
+	}
+	// A 201 carries the draft and a 202 the job.
+	var raw json.RawMessage
+	status, err := c.do(ctx, http.MethodPost, "/rooms/"+escape(roomID)+"/posts/draft", nil, body, &raw)
+	if err != nil {
+		return DraftResult{}, err
+	}
+	if status == http.StatusAccepted {
+		var job DraftJob
+		if err := json.Unmarshal(raw, &job); err != nil {
+			return DraftResult{}, err
+		}
+		return DraftResult{Job: &job}, nil
+	}
+	var post Post
+	if err := json.Unmarshal(raw, &post); err != nil {
+		return DraftResult{}, err
+	}
+	return DraftResult{Post: &post}, nil
+}
+
+func (c *Client) GetDraftJob(ctx context.Context, jobID int64) (DraftJob, error) {
+	var out DraftJob
+	err := c.Do(ctx, http.MethodGet, "/drafts/jobs/"+strconv.FormatInt(jobID, 10), nil, nil, &out)
+	return out, err
+}
+
+// WaitForDraft polls a draft job every interval (default 2s) until it is
+// ready or failed, or ctx ends. A failed job is returned without an error;
+// check Status.
+func (c *Client) WaitForDraft(ctx context.Context, jobID int64, interval time.Duration) (DraftJob, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.GetDraftJob(ctx, jobID)
+		if err != nil {
+			return DraftJob{}, err
+		}
+		if job.Status == DraftJobReady || job.Status == DraftJobFailed {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ApprovePost publishes a draft. content replaces the draft text when it is
+// not empty.
+func (c *Client) ApprovePost(ctx context.Context, postID, content string) (Post, error) {
+	body := map[string]string{}
+	if content != "" {
+		body["content"] = content
+	}
+	var out Post
+	err := c.Do(ctx, http.MethodPost, "/posts/"+escape(postID)+"/approve", nil, body, &out)
+	return out, err
+}
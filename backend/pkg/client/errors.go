@@ -0,0 +1,50 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIError is a non-2xx response. Message is the API's "error" field and
+// Code its machine-readable "code", when the endpoint sends one (for example
+// "battle_limit" or "room_cooldown").
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string
+	// RetryAfter is the server's Retry-After header, zero when absent.
+	RetryAfter time.Duration
+	// Body is the raw response body, for fields the typed methods drop.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("personaworlds api error: status=%d code=%s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("personaworlds api error: status=%d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the API.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is a 401 from the API, meaning the token
+// is missing, expired or invalid.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited reports whether err is a 429 from the API that outlasted the
+// client's retries.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type FeedTemplateRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type FeedBattle struct {
+	BattleID    string           `json:"battle_id"`
+	RoomID      string           `json:"room_id"`
+	RoomName    string           `json:"room_name"`
+	PersonaID   string           `json:"persona_id,omitempty"`
+	PersonaName string           `json:"persona_name,omitempty"`
+	Topic       string           `json:"topic"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Shares      int              `json:"shares"`
+	Remixes     int              `json:"remixes"`
+	Reactions   ReactionCounts   `json:"reactions"`
+	Template    *FeedTemplateRef `json:"template,omitempty"`
+	Featured    bool             `json:"featured,omitempty"`
+}
+
+type FeedTemplate struct {
+	TemplateID  string    `json:"template_id"`
+	Name        string    `json:"name"`
+	PromptRules string    `json:"prompt_rules"`
+	TurnCount   int       `json:"turn_count"`
+	WordLimit   int       `json:"word_limit"`
+	CreatedAt   time.Time `json:"created_at"`
+	UsageCount  int       `json:"usage_count"`
+	IsTrending  bool      `json:"is_trending"`
+}
+
+type FeedPersona struct {
+	PersonaID     string    `json:"persona_id"`
+	Name          string    `json:"name"`
+	Slug          string    `json:"slug"`
+	Bio           string    `json:"bio"`
+	Followers     int       `json:"followers"`
+	RecentBattles int       `json:"recent_battles"`
+	RoomID        string    `json:"room_id,omitempty"`
+	RoomName      string    `json:"room_name,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FeedItem is one ranked entry; exactly one of Battle, Template and Persona
+// is set, matching Kind. IDs are stable across pages and polls.
+type FeedItem struct {
+	ID        string        `json:"id"`
+	Kind      string        `json:"kind"`
+	Reason    string        `json:"reason"`
+	Reasons   []string      `json:"reasons"`
+	Score     float64       `json:"score"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Battle    *FeedBattle   `json:"battle,omitempty"`
+	Template  *FeedTemplate `json:"template,omitempty"`
+	Persona   *FeedPersona  `json:"persona,omitempty"`
+}
+
+// FeedPage is one page of the ranked feed. Pass AsOf back as FeedOptions.Since
+// to poll for changes.
+type FeedPage struct {
+	Items             []FeedItem    `json:"items"`
+	HighlightTemplate *FeedTemplate `json:"highlight_template,omitempty"`
+	NextCursor        string        `json:"next_cursor,omitempty"`
+	AsOf              time.Time     `json:"as_of"`
+}
+
+// FeedOptions selects a feed page. Limit is capped at 50 by the server.
+type FeedOptions struct {
+	Limit  int
+	Cursor string
+	Since  time.Time
+}
+
+func (o FeedOptions) query() url.Values {
+	query := url.Values{}
+	if o.Limit > 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		query.Set("cursor", o.Cursor)
+	}
+	if !o.Since.IsZero() {
+		query.Set("since", o.Since.UTC().Format(time.RFC3339Nano))
+	}
+	return query
+}
+
+// GetFeed returns one page of the signed-in user's feed.
+func (c *Client) GetFeed(ctx context.Context, opts FeedOptions) (FeedPage, error) {
+	var out FeedPage
+	err := c.Do(ctx, http.MethodGet, "/feed", opts.query(), nil, &out)
+	return out, err
+}
+
+// FeedIterator walks the feed page by page:
+//
+//	it := c.Feed(ctx, client.FeedOptions{})
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type FeedIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   FeedOptions
+
+	page    []FeedItem
+	index   int
+	item    FeedItem
+	started bool
+	done    bool
+	err     error
+}
+
+// Feed returns an iterator over the feed starting at opts. The first page
+// pins the ranking, so later pages neither repeat nor skip items.
+func (c *Client) Feed(ctx context.Context, opts FeedOptions) *FeedIterator {
+	return &FeedIterator{client: c, ctx: ctx, opts: opts}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false at the end of the feed or on error.
+func (it *FeedIterator) Next() bool {
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		if it.started && it.opts.Cursor == "" {
+			it.done = true
+			return false
+		}
+		page, err := it.client.GetFeed(it.ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.page = page.Items
+		it.index = 0
+		it.opts.Cursor = page.NextCursor
+		if page.NextCursor == "" && len(page.Items) == 0 {
+			it.done = true
+		}
+	}
+	it.item = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Item is the current item; valid after Next returned true.
+func (it *FeedIterator) Item() FeedItem {
+	return it.item
+}
+
+// Err is the error that stopped iteration, if any.
+func (it *FeedIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PersonaStyle holds the 0-100 style sliders.
+type PersonaStyle struct {
+	Humor         int `json:"humor"`
+	Assertiveness int `json:"assertiveness"`
+	Technicality  int `json:"technicality"`
+	Brevity       int `json:"brevity"`
+}
+
+type Persona struct {
+	ID                string       `json:"id"`
+	Name              string       `json:"name"`
+	Bio               string       `json:"bio"`
+	Tone              string       `json:"tone"`
+	WritingSamples    []string     `json:"writing_samples"`
+	DoNotSay          []string     `json:"do_not_say"`
+	Catchphrases      []string     `json:"catchphrases"`
+	PreferredLanguage string       `json:"preferred_language"`
+	Formality         int          `json:"formality"`
+	DailyDraftQuota   int          `json:"daily_draft_quota"`
+	DailyReplyQuota   int          `json:"daily_reply_quota"`
+	ActiveHoursStart  *int         `json:"active_hours_start"`
+	ActiveHoursEnd    *int         `json:"active_hours_end"`
+	Timezone          string       `json:"timezone"`
+	Style             PersonaStyle `json:"style"`
+	StyleSource       string       `json:"style_source"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// PersonaInput is the body of CreatePersona and UpdatePersona. Zero quotas
+// take the server defaults on create; a nil Style keeps the stored sliders on
+// update.
+type PersonaInput struct {
+	Name              string        `json:"name"`
+	Bio               string        `json:"bio"`
+	Tone              string        `json:"tone"`
+	WritingSamples    []string      `json:"writing_samples"`
+	DoNotSay          []string      `json:"do_not_say"`
+	Catchphrases      []string      `json:"catchphrases"`
+	PreferredLanguage string        `json:"preferred_language"`
+	Formality         int           `json:"formality"`
+	DailyDraftQuota   int           `json:"daily_draft_quota,omitempty"`
+	DailyReplyQuota   int           `json:"daily_reply_quota,omitempty"`
+	ActiveHoursStart  *int          `json:"active_hours_start,omitempty"`
+	ActiveHoursEnd    *int          `json:"active_hours_end,omitempty"`
+	Timezone          string        `json:"timezone,omitempty"`
+	Style             *PersonaStyle `json:"style,omitempty"`
+}
+
+// ListPersonas returns the signed-in user's personas, newest first.
+func (c *Client) ListPersonas(ctx context.Context) ([]Persona, error) {
+	var out struct {
+		Personas []Persona `json:"personas"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/personas", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Personas, nil
+}
+
+func (c *Client) GetPersona(ctx context.Context, personaID string) (Persona, error) {
+	var out Persona
+	err := c.Do(ctx, http.MethodGet, "/personas/"+escape(personaID), nil, nil, &out)
+	return out, err
+}
+
+func (c *Client) CreatePersona(ctx context.Context, input PersonaInput) (Persona, error) {
+	var out Persona
+	err := c.Do(ctx, http.MethodPost, "/personas", nil, input, &out)
+	return out, err
+}
+
+// UpdatePersona replaces a persona's calibration. The API requires positive
+// quotas on update, so start from the persona's current values.
+func (c *Client) UpdatePersona(ctx context.Context, personaID string, input PersonaInput) (Persona, error) {
+	var out Persona
+	err := c.Do(ctx, http.MethodPut, "/personas/"+escape(personaID), nil, input, &out)
+	return out, err
+}
+
+func (c *Client) DeletePersona(ctx context.Context, personaID string) error {
+	return c.Do(ctx, http.MethodDelete, "/personas/"+escape(personaID), nil, nil, nil)
+}
+
+// InputFromPersona copies a persona's editable fields, as a starting point for
+// UpdatePersona.
+func InputFromPersona(p Persona) PersonaInput {
+	style := p.Style
+	return PersonaInput{
+		Name:              p.Name,
+		Bio:               p.Bio,
+		Tone:              p.Tone,
+		WritingSamples:    p.WritingSamples,
+		DoNotSay:          p.DoNotSay,
+		Catchphrases:      p.Catchphrases,
+		PreferredLanguage: p.PreferredLanguage,
+		Formality:         p.Formality,
+		DailyDraftQuota:   p.DailyDraftQuota,
+		DailyReplyQuota:   p.DailyReplyQuota,
+		ActiveHoursStart:  p.ActiveHoursStart,
+		ActiveHoursEnd:    p.ActiveHoursEnd,
+		Timezone:          p.Timezone,
+		Style:             &style,
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Template struct {
+	ID          string    `json:"id"`
+	OwnerUserID string    `json:"owner_user_id,omitempty"`
+	Name        string    `json:"name"`
+	PromptRules string    `json:"prompt_rules"`
+	TurnCount   int       `json:"turn_count"`
+	WordLimit   int       `json:"word_limit"`
+	CreatedAt   time.Time `json:"created_at"`
+	IsPublic    bool      `json:"is_public"`
+}
+
+// TemplateInput is the body of CreateTemplate. Template policies are not
+// covered; send them with Do.
+type TemplateInput struct {
+	Name        string `json:"name"`
+	PromptRules string `json:"prompt_rules"`
+	TurnCount   int    `json:"turn_count"`
+	WordLimit   int    `json:"word_limit"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// ListTemplates returns the newest public templates. It needs no token.
+func (c *Client) ListTemplates(ctx context.Context) ([]Template, error) {
+	var out struct {
+		Templates []Template `json:"templates"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/templates", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Templates, nil
+}
+
+func (c *Client) CreateTemplate(ctx context.Context, input TemplateInput) (Template, error) {
+	var out Template
+	err := c.Do(ctx, http.MethodPost, "/templates", nil, input, &out)
+	return out, err
+}
@@ -8,10 +8,15 @@ import {
   RemixIntentResponse,
   createBattle,
   createBattleRemixIntent,
+  createBattleShareLink,
   getAPIBaseURL,
   getPublicBattleMeta,
   pollBattleProgress,
-  trackEvent
+  retractBattleVote,
+  trackEvent,
+  unwatchBattle,
+  voteInBattle,
+  watchBattle
 } from '../../../lib/api';
 import { SkeletonList } from '../../../components/skeleton';
 import { Spinner } from '../../../components/spinner';
@@ -21,6 +26,8 @@ const API_BASE = getAPIBaseURL();
 const TOKEN_KEY = 'personaworlds_token';
 const REMIX_INTENT_KEY = 'personaworlds_pending_remix_intent';
 const PREFERRED_TEMPLATE_KEY = 'personaworlds_preferred_template_id';
+const SHARE_TOKEN_KEY = 'personaworlds_share_token';
+const GUEST_INTENT_KEY = 'personaworlds_guest_intent';
 
 type StoredRemixIntent = RemixIntentResponse & {
   saved_at: string;
@@ -118,7 +125,28 @@ export default function BattleCardPage() {
   const params = useParams<{ id: string }>();
   const searchParams = useSearchParams();
   const battleID = useMemo(() => (params?.id || '').toString().trim(), [params]);
-  const cardURL = useMemo(() => `${API_BASE}/b/${encodeURIComponent(battleID)}/card.png`, [battleID]);
+  const turnIndex = useMemo(() => {
+    const parsed = Number.parseInt((searchParams.get('t') || '').trim(), 10);
+    return Number.isFinite(parsed) && parsed > 0 ? parsed : 0;
+  }, [searchParams]);
+  // Links to unlisted battles carry a signed `ua` token that every request
+  // for the battle has to repeat.
+  const unlistedAccess = useMemo(() => (searchParams.get('ua') || '').trim(), [searchParams]);
+  const cardURL = useMemo(() => {
+    const base = `${API_BASE}/b/${encodeURIComponent(battleID)}`;
+    const path = turnIndex > 0 ? `${base}/turns/${turnIndex}/card.png` : `${base}/card.png`;
+    return unlistedAccess ? `${path}?ua=${encodeURIComponent(unlistedAccess)}` : path;
+  }, [battleID, turnIndex, unlistedAccess]);
+
+  // Cited turns link back to this page with `t` set, which quotes the turn
+  // and switches the card to it.
+  const turnHref = (turn: number) => {
+    const query = new URLSearchParams({ t: String(turn) });
+    if (unlistedAccess) {
+      query.set('ua', unlistedAccess);
+    }
+    return `/b/${encodeURIComponent(battleID)}?${query.toString()}`;
+  };
 
   const [token, setToken] = useState('');
   const [meta, setMeta] = useState<PublicBattleMeta | null>(null);
@@ -127,6 +155,9 @@ export default function BattleCardPage() {
   const [loadingMeta, setLoadingMeta] = useState(true);
   const [working, setWorking] = useState(false);
   const [sharing, setSharing] = useState(false);
+  const [watching, setWatching] = useState(false);
+  const [watchBusy, setWatchBusy] = useState(false);
+  const [voteBusy, setVoteBusy] = useState(false);
   const [submittingRemix, setSubmittingRemix] = useState(false);
   const [checkingRemixStatus, setCheckingRemixStatus] = useState(false);
   const [showRemixModal, setShowRemixModal] = useState(false);
@@ -146,13 +177,42 @@ export default function BattleCardPage() {
     if (!battleID || typeof window === 'undefined') {
       return '';
     }
-    return `${window.location.origin}/b/${encodeURIComponent(battleID)}`;
-  }, [battleID]);
+    const params = new URLSearchParams();
+    if (turnIndex > 0) {
+      params.set('t', String(turnIndex));
+    }
+    if (unlistedAccess) {
+      params.set('ua', unlistedAccess);
+    }
+    const query = params.toString();
+    return `${window.location.origin}/b/${encodeURIComponent(battleID)}${query ? `?${query}` : ''}`;
+  }, [battleID, turnIndex, unlistedAccess]);
 
   useEffect(() => {
     setToken(getStoredToken());
   }, []);
 
+  useEffect(() => {
+    const shareToken = (searchParams.get('st') || '').trim();
+    if (shareToken && typeof window !== 'undefined') {
+      localStorage.setItem(SHARE_TOKEN_KEY, shareToken);
+    }
+  }, [searchParams]);
+
+  // Signed-in viewers share a tokenized link so signups it brings in are
+  // attributed to them; anyone else shares the plain battle URL.
+  async function resolveShareURL() {
+    if (!token || meta?.visibility === 'unlisted') {
+      return battleURL;
+    }
+    try {
+      const link = await createBattleShareLink(token, battleID);
+      return turnIndex > 0 ? `${link.share_url}&t=${turnIndex}` : link.share_url;
+    } catch {
+      return battleURL;
+    }
+  }
+
   useEffect(() => {
     if (!battleID) {
       setLoadingMeta(false);
@@ -166,7 +226,16 @@ export default function BattleCardPage() {
       try {
         setLoadingMeta(true);
         setError('');
-        const battleMeta = await getPublicBattleMeta(battleID);
+        const battleMeta = await getPublicBattleMeta(
+          battleID,
+          turnIndex,
+          {
+            variant: (searchParams.get('cv') || '').trim(),
+            signature: (searchParams.get('cs') || '').trim()
+          },
+          unlistedAccess,
+          getStoredToken() || undefined
+        );
         if (!cancelled) {
           setMeta(battleMeta);
         }
@@ -187,7 +256,7 @@ export default function BattleCardPage() {
     return () => {
       cancelled = true;
     };
-  }, [battleID, toast]);
+  }, [battleID, searchParams, toast, turnIndex, unlistedAccess]);
 
   useEffect(() => {
     if (autoRemixChecked) {
@@ -267,6 +336,9 @@ export default function BattleCardPage() {
       const intent = await createBattleRemixIntent(battleID, token || undefined);
       if (!token) {
         writeStoredRemixIntent(intent);
+        if (intent.intent_token) {
+          localStorage.setItem(GUEST_INTENT_KEY, intent.intent_token);
+        }
         const nextValue = `/b/${encodeURIComponent(battleID)}`;
         window.location.href = `/signup?next=${encodeURIComponent(nextValue)}&remix=1`;
         return;
@@ -333,6 +405,46 @@ export default function BattleCardPage() {
     }
   }
 
+  async function onToggleWatch() {
+    if (!token || !battleID) {
+      return;
+    }
+    try {
+      setWatchBusy(true);
+      setError('');
+      const response = watching ? await unwatchBattle(token, battleID) : await watchBattle(token, battleID);
+      setWatching(response.watching);
+      toast.success(response.watching ? 'You will be notified about new turns and the verdict.' : 'Stopped watching this battle.');
+    } catch (err) {
+      const messageText = err instanceof Error ? err.message : 'could not update watch';
+      setError(messageText);
+      toast.error(messageText);
+    } finally {
+      setWatchBusy(false);
+    }
+  }
+
+  // Voting for the persona already picked takes the vote back.
+  async function onVote(personaID: string) {
+    if (!token || !battleID) {
+      return;
+    }
+    try {
+      setVoteBusy(true);
+      setError('');
+      const retracting = meta?.my_vote === personaID;
+      const response = retracting ? await retractBattleVote(token, battleID) : await voteInBattle(token, battleID, personaID);
+      setMeta((current) => (current ? { ...current, votes: response.votes, my_vote: response.my_vote } : current));
+      toast.success(retracting ? 'Vote removed.' : 'Vote counted.');
+    } catch (err) {
+      const messageText = err instanceof Error ? err.message : 'could not save vote';
+      setError(messageText);
+      toast.error(messageText);
+    } finally {
+      setVoteBusy(false);
+    }
+  }
+
   async function onShare() {
     if (!battleID) {
       return;
@@ -343,7 +455,7 @@ export default function BattleCardPage() {
       setError('');
       setMessage('');
 
-      const shareURL = battleURL || `${window.location.origin}/b/${encodeURIComponent(battleID)}`;
+      const shareURL = (await resolveShareURL()) || `${window.location.origin}/b/${encodeURIComponent(battleID)}`;
       const shareText = 'Battle card summary';
 
       if (navigator.share) {
@@ -411,12 +523,13 @@ export default function BattleCardPage() {
       setError('');
       setMessage('');
 
+      const shareURL = await resolveShareURL();
       if (navigator.clipboard?.writeText) {
-        await navigator.clipboard.writeText(battleURL);
+        await navigator.clipboard.writeText(shareURL);
         setMessage('Battle link copied.');
         toast.success('Battle link copied.');
       } else {
-        window.prompt('Copy this battle link', battleURL);
+        window.prompt('Copy this battle link', shareURL);
         toast.info('Copy link manually from the prompt.');
       }
 
@@ -573,6 +686,63 @@ export default function BattleCardPage() {
               <>
                 <p>{meta.topic}</p>
                 <p className="subtle">Room: {meta.room_name}</p>
+                {meta.turn && (
+                  <blockquote className="subtle">
+                    Turn {meta.turn.index}/{meta.turn.total} - {meta.turn.persona_name}: &ldquo;{meta.turn.quote}&rdquo;
+                  </blockquote>
+                )}
+                {meta.verdict && (
+                  <div className="stack">
+                    <p>{meta.verdict.verdict}</p>
+                    <ul>
+                      {meta.verdict.takeaways.map((takeaway, idx) => {
+                        const cited = meta.verdict?.takeaway_turns?.[idx] || [];
+                        return (
+                          <li key={takeaway} className={cited.includes(turnIndex) ? 'cited-takeaway' : undefined}>
+                            {takeaway}
+                            {cited.length > 0 && (
+                              <span className="subtle">
+                                {' '}
+                                ({cited.map((turn, turnIdx) => (
+                                  <span key={turn}>
+                                    {turnIdx > 0 && ', '}
+                                    <Link href={turnHref(turn)}>turn {turn}</Link>
+                                  </span>
+                                ))}
+                                )
+                              </span>
+                            )}
+                          </li>
+                        );
+                      })}
+                    </ul>
+                  </div>
+                )}
+                {meta.votes && meta.votes.length > 0 && (
+                  <div className="stack">
+                    <p className="subtle">
+                      Who won? <Link href="/leaderboard">Leaderboard</Link>
+                    </p>
+                    <ul className="battle-votes">
+                      {meta.votes.map((tally) => (
+                        <li key={tally.persona_id} className={meta.my_vote === tally.persona_id ? 'battle-vote mine' : 'battle-vote'}>
+                          <span>
+                            {tally.persona_name} <span className="subtle">({tally.votes} {tally.votes === 1 ? 'vote' : 'votes'})</span>
+                          </span>
+                          {token && (
+                            <button type="button" className="secondary" onClick={() => void onVote(tally.persona_id)} disabled={voteBusy}>
+                              <span className="button-content">
+                                {voteBusy && <Spinner />}
+                                <span>{meta.my_vote === tally.persona_id ? 'Your pick' : 'Vote'}</span>
+                              </span>
+                            </button>
+                          )}
+                        </li>
+                      ))}
+                    </ul>
+                    {!token && <p className="subtle">Sign in to vote for the winner.</p>}
+                  </div>
+                )}
                 {meta.template && (
                   <p className="subtle">
                     Made with template:{' '}
@@ -624,6 +794,14 @@ export default function BattleCardPage() {
               </span>
             </button>
           )}
+          {token && (
+            <button type="button" className="secondary" onClick={onToggleWatch} disabled={watchBusy}>
+              <span className="button-content">
+                {watchBusy && <Spinner />}
+                <span>{watching ? 'Unwatch' : 'Watch battle'}</span>
+              </span>
+            </button>
+          )}
           <button type="button" className="secondary" onClick={onCopyImage} disabled={sharing}>
             <span className="button-content">
               {sharing && <Spinner />}
@@ -0,0 +1,116 @@
+'use client';
+
+import Link from 'next/link';
+import { useEffect, useState } from 'react';
+import { BattleLeaderboardEntry, BattleLeaderboardWindow, getBattleLeaderboard } from '../../lib/api';
+import { SkeletonList } from '../../components/skeleton';
+import { useToast } from '../../components/toast-provider';
+
+const WINDOWS: { value: BattleLeaderboardWindow; label: string }[] = [
+  { value: 'week', label: 'This week' },
+  { value: 'month', label: 'Last 30 days' },
+  { value: 'all', label: 'All time' }
+];
+
+export default function LeaderboardPage() {
+  const toast = useToast();
+
+  const [period, setPeriod] = useState<BattleLeaderboardWindow>('month');
+  const [entries, setEntries] = useState<BattleLeaderboardEntry[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    let cancelled = false;
+    const load = async () => {
+      try {
+        setLoading(true);
+        setError('');
+        const response = await getBattleLeaderboard({ window: period });
+        if (!cancelled) {
+          setEntries(response.entries || []);
+        }
+      } catch (err) {
+        if (!cancelled) {
+          const messageText = err instanceof Error ? err.message : 'could not load leaderboard';
+          setError(messageText);
+          toast.error(messageText);
+        }
+      } finally {
+        if (!cancelled) {
+          setLoading(false);
+        }
+      }
+    };
+
+    void load();
+    return () => {
+      cancelled = true;
+    };
+  }, [toast, period]);
+
+  return (
+    <main className="container">
+      <section className="panel stack">
+        <div className="battle-card-head">
+          <div className="stack">
+            <h1>Battle Leaderboard</h1>
+            <p className="subtle">Personas ranked by battles won on audience votes.</p>
+          </div>
+          <div className="row">
+            <select value={period} onChange={(event) => setPeriod(event.target.value as BattleLeaderboardWindow)}>
+              {WINDOWS.map((option) => (
+                <option key={option.value} value={option.value}>
+                  {option.label}
+                </option>
+              ))}
+            </select>
+            <Link className="primary-link" href="/">
+              Dashboard
+            </Link>
+          </div>
+        </div>
+
+        {loading && <SkeletonList rows={4} />}
+        {!loading && entries.length === 0 && <p className="subtle">No voted battles in this window yet.</p>}
+
+        {!loading && entries.length > 0 && (
+          <table className="leaderboard-table">
+            <thead>
+              <tr>
+                <th>#</th>
+                <th>Persona</th>
+                <th>W-T-L</th>
+                <th>Votes</th>
+              </tr>
+            </thead>
+            <tbody>
+              {entries.map((entry) => (
+                <tr key={entry.persona_id}>
+                  <td>{entry.rank}</td>
+                  <td>
+                    {entry.public_slug ? (
+                      <Link href={`/p/${encodeURIComponent(entry.public_slug)}`}>{entry.persona_name}</Link>
+                    ) : (
+                      entry.persona_name
+                    )}
+                  </td>
+                  <td>
+                    {entry.wins}-{entry.ties}-{entry.losses}
+                  </td>
+                  <td>{entry.votes}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+
+        {error && (
+          <div className="status-bar">
+            <span className="error">{error}</span>
+          </div>
+        )}
+      </section>
+    </main>
+  );
+}
@@ -3,13 +3,14 @@
 import Link from 'next/link';
 import { useParams } from 'next/navigation';
 import { useEffect, useMemo, useState } from 'react';
-import { PublicPersonaPost, PublicPersonaProfileResponse, followPublicPersona, getPublicPersonaPosts, getPublicPersonaProfile, trackEvent } from '../../../lib/api';
+import { APIError, PublicPersonaPost, PublicPersonaProfileResponse, followPublicPersona, getPublicPersonaPosts, getPublicPersonaProfile, trackEvent } from '../../../lib/api';
 import { SkeletonList } from '../../../components/skeleton';
 import { Spinner } from '../../../components/spinner';
 import { useToast } from '../../../components/toast-provider';
 
 const TOKEN_KEY = 'personaworlds_token';
 const SHARE_SLUG_KEY = 'personaworlds_share_slug';
+const GUEST_INTENT_KEY = 'personaworlds_guest_intent';
 
 function publicPostBadge(post: PublicPersonaPost) {
   if (post.authored_by === 'AI_DRAFT_APPROVED') {
@@ -123,6 +124,12 @@ export default function PublicPersonaPage() {
     } catch (err) {
       const messageText = err instanceof Error ? err.message : 'follow failed';
       if (messageText.includes('signup_required')) {
+        // The follow finishes server-side once the visitor signs up or logs in.
+        if (err instanceof APIError && err.intentToken) {
+          localStorage.setItem(GUEST_INTENT_KEY, err.intentToken);
+          window.location.href = `/signup?next=${encodeURIComponent(`/p/${encodeURIComponent(slug)}`)}`;
+          return;
+        }
         const text = 'Signup required to follow personas. Create your own persona to join.';
         setError(text);
         toast.error(text);
@@ -236,7 +243,7 @@ export default function PublicPersonaPage() {
           {posts.map((post) => {
             const badge = publicPostBadge(post);
             return (
-              <article key={post.id} className="post-card">
+              <article key={post.id} id={`post-${post.id}`} className="post-card">
                 <div className="post-meta">
                   <span className="status">{post.room_name || 'Room'}</span>
                   <span className={badge.className}>{badge.label}</span>
@@ -4,36 +4,50 @@ import { FormEvent, useEffect, useMemo, useState } from 'react';
 import Link from 'next/link';
 import {
   BattlePollResult,
+  DRAFT_MOODS,
   DigestThread,
+  DraftMood,
+  FeedColdStart,
   FeedItem,
   FeedTemplateItem,
   Notification,
   Persona,
   PersonaDigestResponse,
   PersonaPayload,
+  PersonaStyle,
   Post,
   PreviewResponse,
   Room,
+  RoomEvent,
+  RoomRosterEntry,
   ThreadResponse,
   WeeklyDigestResponse,
   approvePost,
   createBattle,
   createDraft,
   createPersona,
+  dismissColdStart,
+  enrollRosterPersona,
   generateReplies,
   getFeed,
   getLatestDigest,
   getNotifications,
+  getRoomRoster,
   getWeeklyDigest,
   getTodayDigest,
   getThread,
+  streamThread,
+  isDraftJob,
+  leaveRoster,
   listPersonas,
   listRoomPosts,
   listRooms,
   markAllNotificationsRead,
+  markNotificationsReadUpTo,
   markNotificationRead,
   login,
   pollBattleProgress,
+  pollDraftJob,
   publishPersonaProfile,
   unpublishPersonaProfile,
   previewPersona,
@@ -47,8 +61,19 @@ import { useToast } from '../components/toast-provider';
 
 const TOKEN_KEY = 'personaworlds_token';
 const SHARE_SLUG_KEY = 'personaworlds_share_slug';
+const SHARE_TOKEN_KEY = 'personaworlds_share_token';
+const GUEST_INTENT_KEY = 'personaworlds_guest_intent';
 const DAILY_RETURN_KEY_PREFIX = 'personaworlds_daily_return';
 const PREFERRED_TEMPLATE_KEY = 'personaworlds_preferred_template_id';
+const THREAD_STREAM_LIMIT = 5;
+const THREAD_STREAM_RETRY_MS = 5000;
+const DEFAULT_PERSONA_STYLE: PersonaStyle = { humor: 50, assertiveness: 50, technicality: 50, brevity: 50 };
+const PERSONA_STYLE_SLIDERS: { key: keyof PersonaStyle; label: string }[] = [
+  { key: 'humor', label: 'Humor' },
+  { key: 'assertiveness', label: 'Assertiveness' },
+  { key: 'technicality', label: 'Technicality' },
+  { key: 'brevity', label: 'Brevity' }
+];
 
 function Badge({ authoredBy }: { authoredBy: Post['authored_by'] | ThreadResponse['replies'][number]['authored_by'] }) {
   const className =
@@ -57,6 +82,23 @@ function Badge({ authoredBy }: { authoredBy: Post['authored_by'] | ThreadRespons
   return <span className={className}>{authoredBy}</span>;
 }
 
+function RosterProgress({ label, done, due, target }: { label: string; done: number; due: number; target: number }) {
+  const percent = Math.min(100, Math.round((done / target) * 100));
+  const behind = done < due;
+
+  return (
+    <div className="roster-progress">
+      <span className="subtle">
+        {label}: {done}/{target}
+        {behind && ` (${due} due by now)`}
+      </span>
+      <div className="roster-bar">
+        <div className={behind ? 'roster-bar-fill behind' : 'roster-bar-fill'} style={{ width: `${percent}%` }} />
+      </div>
+    </div>
+  );
+}
+
 function parseLines(value: string) {
   return value
     .split('\n')
@@ -65,18 +107,52 @@ function parseLines(value: string) {
 }
 
 function feedReasonLabel(reason: string) {
+  if (reason === 'watched_battle') {
+    return 'Battle you watch';
+  }
   if (reason === 'followed_persona') {
     return 'From followed personas';
   }
   if (reason === 'trending_battle') {
     return 'Trending now';
   }
+  if (reason === 'battle_of_the_week') {
+    return 'Battle of the week';
+  }
   if (reason === 'new_template') {
     return 'New template';
   }
+  if (reason === 'cold_start_room_interest') {
+    return 'Popular in your rooms';
+  }
+  if (reason === 'cold_start_popular') {
+    return 'Popular right now';
+  }
   return reason;
 }
 
+function formatCountdown(seconds: number) {
+  const minutes = Math.max(0, Math.floor(seconds / 60));
+  if (minutes < 60) {
+    return `${minutes}m`;
+  }
+  const hours = Math.floor(minutes / 60);
+  if (hours < 48) {
+    return `${hours}h ${minutes % 60}m`;
+  }
+  return `${Math.floor(hours / 24)}d ${hours % 24}h`;
+}
+
+function roomEventLabel(event: RoomEvent) {
+  if (event.status === 'upcoming') {
+    return `Event starts in ${formatCountdown(event.seconds_until_start)}`;
+  }
+  if (event.status === 'live') {
+    return `Live event, ends in ${formatCountdown(event.seconds_until_end)}`;
+  }
+  return 'Event ended';
+}
+
 function readMetadataString(metadata: Record<string, unknown>, key: string) {
   const value = metadata[key];
   return typeof value === 'string' ? value.trim() : '';
@@ -142,6 +218,9 @@ export default function HomePage() {
   const [catchphrasesText, setCatchphrasesText] = useState('Ship, learn, iterate');
   const [preferredLanguage, setPreferredLanguage] = useState<'tr' | 'en'>('en');
   const [formality, setFormality] = useState(1);
+  const [personaStyle, setPersonaStyle] = useState<PersonaStyle>(DEFAULT_PERSONA_STYLE);
+  const [styleTouched, setStyleTouched] = useState(false);
+  const [draftMood, setDraftMood] = useState<DraftMood | ''>('');
 
   const [draftQuota, setDraftQuota] = useState(5);
   const [replyQuota, setReplyQuota] = useState(25);
@@ -153,6 +232,7 @@ export default function HomePage() {
   const [digestSource, setDigestSource] = useState<'today' | 'latest' | 'empty'>('empty');
   const [feedItems, setFeedItems] = useState<FeedItem[]>([]);
   const [feedHighlightTemplate, setFeedHighlightTemplate] = useState<FeedTemplateItem | null>(null);
+  const [feedColdStart, setFeedColdStart] = useState<FeedColdStart | null>(null);
   const [feedLoading, setFeedLoading] = useState(false);
   const [feedBattleTopic, setFeedBattleTopic] = useState('');
   const [selectedFeedTemplateId, setSelectedFeedTemplateId] = useState('');
@@ -161,11 +241,16 @@ export default function HomePage() {
   const [notificationsLoading, setNotificationsLoading] = useState(false);
   const [notificationsOpen, setNotificationsOpen] = useState(false);
   const [unreadNotifications, setUnreadNotifications] = useState(0);
+  const [notificationsLastSeq, setNotificationsLastSeq] = useState(0);
 
   const [weeklyDigestResponse, setWeeklyDigestResponse] = useState<WeeklyDigestResponse | null>(null);
   const [weeklyDigestLoading, setWeeklyDigestLoading] = useState(false);
 
   const [postsLoading, setPostsLoading] = useState(false);
+  const [roster, setRoster] = useState<RoomRosterEntry[]>([]);
+  const [rosterLoading, setRosterLoading] = useState(false);
+  const [rosterWeeklyPosts, setRosterWeeklyPosts] = useState(3);
+  const [rosterWeeklyReplies, setRosterWeeklyReplies] = useState(10);
   const [actionLoading, setActionLoading] = useState<Record<string, boolean>>({});
   const [battleGeneration, setBattleGeneration] = useState<BattleGenerationState | null>(null);
 
@@ -220,9 +305,11 @@ export default function HomePage() {
   useEffect(() => {
     if (!token || !selectedRoomId) {
       setPosts([]);
+      setRoster([]);
       return;
     }
     void refreshPosts(token, selectedRoomId);
+    void refreshRoster(token, selectedRoomId);
   }, [token, selectedRoomId]);
 
   useEffect(() => {
@@ -261,6 +348,51 @@ export default function HomePage() {
     };
   }, [token]);
 
+  // Loaded threads follow new replies over GET /posts/:id/stream instead of
+  // being reloaded; the server allows a few streams per user, so only the
+  // most recently opened threads stream.
+  const streamedThreadIds = useMemo(() => Object.keys(threads).slice(-THREAD_STREAM_LIMIT).join(','), [threads]);
+  useEffect(() => {
+    if (!token || !streamedThreadIds) {
+      return;
+    }
+    const controller = new AbortController();
+    for (const postId of streamedThreadIds.split(',')) {
+      void (async () => {
+        while (!controller.signal.aborted) {
+          try {
+            await streamThread(
+              token,
+              postId,
+              {
+                onReply: (reply) =>
+                  setThreads((current) => {
+                    const thread = current[postId];
+                    if (!thread || thread.replies.some((existing) => existing.id === reply.id)) {
+                      return current;
+                    }
+                    return { ...current, [postId]: { ...thread, replies: [...thread.replies, reply] } };
+                  }),
+                onSummary: (summary) =>
+                  setThreads((current) => {
+                    const thread = current[postId];
+                    return thread ? { ...current, [postId]: { ...thread, ai_summary: summary } } : current;
+                  })
+              },
+              controller.signal
+            );
+          } catch {
+            // Reconnect below unless the effect was cleaned up.
+          }
+          await new Promise((resolve) => window.setTimeout(resolve, THREAD_STREAM_RETRY_MS));
+        }
+      })();
+    }
+    return () => {
+      controller.abort();
+    };
+  }, [token, streamedThreadIds]);
+
   useEffect(() => {
     if (!selectedPersona) {
       return;
@@ -273,6 +405,8 @@ export default function HomePage() {
     setCatchphrasesText(selectedPersona.catchphrases.join('\n'));
     setPreferredLanguage(selectedPersona.preferred_language);
     setFormality(selectedPersona.formality);
+    setPersonaStyle(selectedPersona.style ?? DEFAULT_PERSONA_STYLE);
+    setStyleTouched(false);
     setDraftQuota(selectedPersona.daily_draft_quota);
     setReplyQuota(selectedPersona.daily_reply_quota);
   }, [selectedPersona]);
@@ -369,6 +503,56 @@ export default function HomePage() {
     }
   }
 
+  async function refreshRoster(authToken: string, roomId: string) {
+    try {
+      setRosterLoading(true);
+      const rosterRes = await getRoomRoster(authToken, roomId);
+      setRoster(rosterRes.roster);
+    } catch (err) {
+      toast.error(toErrorMessage(err, 'could not load roster'));
+    } finally {
+      setRosterLoading(false);
+    }
+  }
+
+  async function onEnrollRoster(event: FormEvent) {
+    event.preventDefault();
+    if (!token || !selectedRoomId || !selectedPersonaId) {
+      return;
+    }
+
+    try {
+      setActionBusy('roster-enroll', true);
+      const { enrollment } = await enrollRosterPersona(token, selectedRoomId, selectedPersonaId, {
+        weekly_posts: rosterWeeklyPosts,
+        weekly_replies: rosterWeeklyReplies
+      });
+      toast.success(`${enrollment.persona_name} is on the roster: ${enrollment.weekly_posts} posts, ${enrollment.weekly_replies} replies a week.`);
+      void refreshRoster(token, selectedRoomId);
+    } catch (err) {
+      toast.error(toErrorMessage(err, 'could not join roster'));
+    } finally {
+      setActionBusy('roster-enroll', false);
+    }
+  }
+
+  async function onLeaveRoster(personaId: string) {
+    if (!token || !selectedRoomId) {
+      return;
+    }
+
+    try {
+      setActionBusy(`roster-leave-${personaId}`, true);
+      await leaveRoster(token, selectedRoomId, personaId);
+      setRoster((current) => current.filter((entry) => entry.persona_id !== personaId));
+      toast.info('Removed from the roster.');
+    } catch (err) {
+      toast.error(toErrorMessage(err, 'could not leave roster'));
+    } finally {
+      setActionBusy(`roster-leave-${personaId}`, false);
+    }
+  }
+
   async function refreshDigest(authToken: string, personaId: string) {
     try {
       setDigestLoading(true);
@@ -401,6 +585,7 @@ export default function HomePage() {
       const response = await getFeed(authToken);
       setFeedItems(response.items || []);
       setFeedHighlightTemplate(response.highlight_template || null);
+      setFeedColdStart(response.cold_start || null);
       if (!selectedFeedTemplateId && response.highlight_template?.template_id) {
         setSelectedFeedTemplateId(response.highlight_template.template_id);
       }
@@ -419,6 +604,7 @@ export default function HomePage() {
       const response = await getNotifications(authToken, 24);
       setNotifications(response.notifications || []);
       setUnreadNotifications(response.unread_count || 0);
+      setNotificationsLastSeq(response.last_seq || 0);
     } catch (err) {
       const messageText = toErrorMessage(err, 'could not load notifications');
       setError(messageText);
@@ -463,7 +649,13 @@ export default function HomePage() {
       preferred_language: preferredLanguage,
       formality,
       daily_draft_quota: draftQuota,
-      daily_reply_quota: replyQuota
+      daily_reply_quota: replyQuota,
+      active_hours_start: selectedPersona?.active_hours_start ?? null,
+      active_hours_end: selectedPersona?.active_hours_end ?? null,
+      timezone: selectedPersona?.timezone ?? 'UTC',
+      // Untouched sliders are left out so a pending persona still gets its
+      // estimate from the tone text.
+      ...(styleTouched || !selectedPersona ? { style: personaStyle } : {})
     };
   }
 
@@ -490,12 +682,23 @@ export default function HomePage() {
       setLoading(true);
       const shareSlug =
         isSignup && typeof window !== 'undefined' ? (localStorage.getItem(SHARE_SLUG_KEY) || '').trim() : '';
-      const response = isSignup ? await signup(email, password, shareSlug) : await login(email, password);
+      const shareToken =
+        isSignup && typeof window !== 'undefined' ? (localStorage.getItem(SHARE_TOKEN_KEY) || '').trim() : '';
+      const intentToken = typeof window !== 'undefined' ? (localStorage.getItem(GUEST_INTENT_KEY) || '').trim() : '';
+      const response = isSignup
+        ? await signup(email, password, shareSlug, shareToken, intentToken)
+        : await login(email, password, intentToken);
       localStorage.setItem(TOKEN_KEY, response.token);
       setToken(response.token);
+      if (intentToken) {
+        localStorage.removeItem(GUEST_INTENT_KEY);
+      }
       if (isSignup && shareSlug) {
         localStorage.removeItem(SHARE_SLUG_KEY);
       }
+      if (isSignup && shareToken) {
+        localStorage.removeItem(SHARE_TOKEN_KEY);
+      }
       const successText = isSignup ? 'Account created.' : 'Logged in.';
       setMessage(successText);
       toast.success(successText);
@@ -604,7 +807,7 @@ export default function HomePage() {
         upsertPersonaInState(updated);
       }
 
-      const preview = await previewPersona(token, personaId, selectedRoomId);
+      const preview = await previewPersona(token, personaId, selectedRoomId, draftMood);
       setPreviewDrafts(preview.drafts);
       setPreviewQuota(preview.quota);
       setMessage('Voice preview generated.');
@@ -619,6 +822,24 @@ export default function HomePage() {
     }
   }
 
+  async function waitForDraftJob(authToken: string, jobId: number) {
+    try {
+      const job = await pollDraftJob(authToken, jobId);
+      if (job.status === 'ready' && job.post) {
+        const readyPost = job.post;
+        setPosts((current) => (current.some((post) => post.id === readyPost.id) ? current : [readyPost, ...current]));
+        toast.success('Your AI draft is ready. Review and approve to publish.');
+        void refreshFeed(authToken);
+        return;
+      }
+      if (job.status === 'failed') {
+        toast.error(job.error ? `Draft failed: ${job.error}` : 'Draft generation failed.');
+      }
+    } catch (err) {
+      toast.error(toErrorMessage(err, 'could not check draft status'));
+    }
+  }
+
   async function onCreateDraft() {
     if (!token || !selectedRoomId || !selectedPersonaId) {
       setError('select a room and persona first');
@@ -629,7 +850,14 @@ export default function HomePage() {
       setActionBusy('create-draft', true);
       setLoading(true);
       setError('');
-      const draft = await createDraft(token, selectedRoomId, selectedPersonaId);
+      const draft = await createDraft(token, selectedRoomId, selectedPersonaId, draftMood);
+      if (isDraftJob(draft)) {
+        const pendingText = 'The persona is taking longer than usual. The draft will show up here when it is ready.';
+        setMessage(pendingText);
+        toast.success(pendingText);
+        void waitForDraftJob(token, draft.job_id);
+        return;
+      }
       setPosts((current) => [draft, ...current]);
       const successText = 'AI draft post created. Review and approve to publish.';
       setMessage(successText);
@@ -664,6 +892,9 @@ export default function HomePage() {
       }
       void refreshFeed(token);
       void refreshWeeklyDigest(token);
+      if (selectedRoomId) {
+        void refreshRoster(token, selectedRoomId);
+      }
     } catch (err) {
       const messageText = toErrorMessage(err, 'could not approve post');
       setError(messageText);
@@ -765,13 +996,20 @@ export default function HomePage() {
     }
     try {
       setActionBusy('notifications-mark-all', true);
-      const response = await markAllNotificationsRead(token);
+      // Only clear what this client has shown; newer notifications stay unread.
+      const readUpTo = notificationsLastSeq;
+      const response =
+        readUpTo > 0 ? await markNotificationsReadUpTo(token, readUpTo) : await markAllNotificationsRead(token);
       setUnreadNotifications(response.unread_count || 0);
       setNotifications((current) =>
-        current.map((notification) => ({
-          ...notification,
-          read_at: notification.read_at || new Date().toISOString()
-        }))
+        current.map((notification) =>
+          readUpTo > 0 && notification.seq > readUpTo
+            ? notification
+            : {
+                ...notification,
+                read_at: notification.read_at || new Date().toISOString()
+              }
+        )
       );
       toast.success('All notifications marked as read.');
     } catch (err) {
@@ -783,6 +1021,24 @@ export default function HomePage() {
     }
   }
 
+  async function onDismissColdStart() {
+    if (!token) {
+      return;
+    }
+    try {
+      setActionBusy('feed-cold-start-dismiss', true);
+      await dismissColdStart(token);
+      setFeedColdStart(null);
+      setFeedItems((current) => current.filter((item) => !item.reasons.every((reason) => reason.startsWith('cold_start_'))));
+    } catch (err) {
+      const messageText = toErrorMessage(err, 'could not dismiss recommendations');
+      setError(messageText);
+      toast.error(messageText);
+    } finally {
+      setActionBusy('feed-cold-start-dismiss', false);
+    }
+  }
+
   async function onNotificationClick(notification: Notification) {
     if (!token) {
       return;
@@ -1278,6 +1534,27 @@ export default function HomePage() {
           </div>
         )}
 
+        {!feedLoading && feedColdStart && (
+          <div className="mini-card row">
+            <p className="subtle">
+              {feedColdStart.source === 'popular'
+                ? 'Follow a few personas to personalize your feed. Until then, here is what is popular.'
+                : 'Follow a few personas to personalize your feed. Until then, here is what is popular in your rooms.'}
+            </p>
+            <button
+              type="button"
+              className="secondary"
+              onClick={() => void onDismissColdStart()}
+              disabled={isActionLoading('feed-cold-start-dismiss')}
+            >
+              <span className="button-content">
+                {isActionLoading('feed-cold-start-dismiss') && <Spinner />}
+                <span>Hide suggestions</span>
+              </span>
+            </button>
+          </div>
+        )}
+
         {!feedLoading && feedItems.length > 0 && (
           <div className="feed-list">
             {feedItems.map((item) => {
@@ -1334,6 +1611,32 @@ export default function HomePage() {
                 );
               }
 
+              if (item.kind === 'persona' && item.persona) {
+                const persona = item.persona;
+                return (
+                  <article key={item.id} className="post-card feed-item">
+                    <div className="post-meta">
+                      {item.reasons.map((reason) => (
+                        <span key={`${item.id}-${reason}`} className="status">
+                          {feedReasonLabel(reason)}
+                        </span>
+                      ))}
+                      {persona.room_name && <span className="subtle">{persona.room_name}</span>}
+                    </div>
+                    <h3>{persona.name}</h3>
+                    {persona.bio && <p>{persona.bio}</p>}
+                    <div className="row">
+                      <span className="subtle">
+                        Followers: {persona.followers} · Battles this month: {persona.recent_battles}
+                      </span>
+                      <Link className="cta-link" href={`/p/${encodeURIComponent(persona.slug)}`}>
+                        View profile
+                      </Link>
+                    </div>
+                  </article>
+                );
+              }
+
               return null;
             })}
           </div>
@@ -1481,6 +1784,17 @@ export default function HomePage() {
               ))}
             </select>
           </label>
+          <label>
+            Draft Mood
+            <select value={draftMood} onChange={(e) => setDraftMood(e.target.value as DraftMood | '')}>
+              <option value="">Base tone</option>
+              {DRAFT_MOODS.map((mood) => (
+                <option key={mood} value={mood}>
+                  {mood}
+                </option>
+              ))}
+            </select>
+          </label>
 
           <form className="stack" onSubmit={onCreatePersona}>
             <input value={personaName} onChange={(e) => setPersonaName(e.target.value)} placeholder="name" required />
@@ -1524,6 +1838,25 @@ export default function HomePage() {
                 onChange={(e) => setFormality(Math.max(0, Math.min(3, Number(e.target.value) || 0)))}
               />
             </label>
+            {PERSONA_STYLE_SLIDERS.map((slider) => (
+              <label key={slider.key}>
+                {slider.label}: {personaStyle[slider.key]}
+                <input
+                  type="range"
+                  min={0}
+                  max={100}
+                  value={personaStyle[slider.key]}
+                  onChange={(e) => {
+                    const value = Math.max(0, Math.min(100, Number(e.target.value) || 0));
+                    setPersonaStyle((current) => ({ ...current, [slider.key]: value }));
+                    setStyleTouched(true);
+                  }}
+                />
+              </label>
+            ))}
+            {selectedPersona?.style_source === 'pending' && !styleTouched && (
+              <p className="subtle">Style sliders will be estimated from the tone text shortly.</p>
+            )}
             <label>
               Draft Quota
               <input
@@ -1597,10 +1930,77 @@ export default function HomePage() {
               >
                 <strong>{room.name}</strong>
                 <span>{room.description}</span>
+                {room.event && <span className="subtle">{roomEventLabel(room.event)}</span>}
               </button>
             ))}
           </div>
 
+          <h2>Roster</h2>
+          <p className="subtle">
+            Enrolled personas commit to a weekly pace here. The worker drafts posts for review and writes replies to keep them on
+            track, within daily quotas.
+          </p>
+          <form className="row" onSubmit={onEnrollRoster}>
+            <label>
+              Posts/week
+              <input
+                type="number"
+                min={0}
+                max={21}
+                value={rosterWeeklyPosts}
+                onChange={(e) => setRosterWeeklyPosts(Number(e.target.value))}
+              />
+            </label>
+            <label>
+              Replies/week
+              <input
+                type="number"
+                min={0}
+                max={70}
+                value={rosterWeeklyReplies}
+                onChange={(e) => setRosterWeeklyReplies(Number(e.target.value))}
+              />
+            </label>
+            <button
+              type="submit"
+              disabled={!selectedRoomId || !selectedPersonaId || isActionLoading('roster-enroll')}
+            >
+              <span className="button-content">
+                {isActionLoading('roster-enroll') && <Spinner />}
+                <span>Enroll {selectedPersona?.name || 'persona'}</span>
+              </span>
+            </button>
+          </form>
+          <div className="stack">
+            {rosterLoading && roster.length === 0 && <SkeletonList rows={2} />}
+            {!rosterLoading && roster.length === 0 && <p className="subtle">No personas enrolled in this room yet.</p>}
+            {roster.map((entry) => (
+              <div key={entry.persona_id} className="roster-entry">
+                <div className="post-meta">
+                  <strong>{entry.persona_name}</strong>
+                  {entry.mine && <span className="badge">yours</span>}
+                  {entry.drafts_waiting > 0 && <span className="subtle">{entry.drafts_waiting} drafts waiting</span>}
+                  {entry.mine && (
+                    <button
+                      type="button"
+                      className="secondary"
+                      onClick={() => onLeaveRoster(entry.persona_id)}
+                      disabled={isActionLoading(`roster-leave-${entry.persona_id}`)}
+                    >
+                      Leave
+                    </button>
+                  )}
+                </div>
+                {entry.weekly_posts > 0 && (
+                  <RosterProgress label="Posts" done={entry.posts} due={entry.posts_due} target={entry.weekly_posts} />
+                )}
+                {entry.weekly_replies > 0 && (
+                  <RosterProgress label="Replies" done={entry.replies} due={entry.replies_due} target={entry.weekly_replies} />
+                )}
+              </div>
+            ))}
+          </div>
+
           {previewDrafts.length > 0 && (
             <>
               <h2>Preview Voice</h2>
@@ -1615,6 +2015,7 @@ export default function HomePage() {
                     <div className="post-meta">
                       <span className="badge badge-preview">AI Preview</span>
                       <span className="status">{draft.label}</span>
+                      {draft.mood && <span className="subtle">mood: {draft.mood}</span>}
                     </div>
                     <p>{draft.content}</p>
                   </article>
@@ -1636,6 +2037,7 @@ export default function HomePage() {
                     <Badge authoredBy={post.authored_by} />
                     <span className="status">{post.status}</span>
                     <span>{post.persona_name || 'Persona'}</span>
+                    {post.mood && <span className="subtle">mood: {post.mood}</span>}
                   </div>
                   <p>{post.content}</p>
 
@@ -0,0 +1,204 @@
+'use client';
+
+import Link from 'next/link';
+import { useParams } from 'next/navigation';
+import { FormEvent, useEffect, useMemo, useState } from 'react';
+import {
+  PersonaEditSuggestionField,
+  PreviewResponse,
+  SharedPersonaResponse,
+  getSharedPersona,
+  previewSharedPersona,
+  suggestSharedPersonaEdit
+} from '../../../../lib/api';
+import { SkeletonList } from '../../../../components/skeleton';
+import { Spinner } from '../../../../components/spinner';
+import { useToast } from '../../../../components/toast-provider';
+
+const SUGGESTION_FIELDS: PersonaEditSuggestionField[] = [
+  'general',
+  'bio',
+  'tone',
+  'writing_samples',
+  'do_not_say',
+  'catchphrases',
+  'formality',
+  'preferred_language'
+];
+
+export default function SharedPersonaPage() {
+  const toast = useToast();
+  const params = useParams<{ token: string }>();
+  const shareToken = useMemo(() => (params?.token || '').toString().trim(), [params]);
+
+  const [loading, setLoading] = useState(true);
+  const [data, setData] = useState<SharedPersonaResponse | null>(null);
+  const [error, setError] = useState('');
+
+  const [field, setField] = useState<PersonaEditSuggestionField>('general');
+  const [suggestion, setSuggestion] = useState('');
+  const [authorName, setAuthorName] = useState('');
+  const [sending, setSending] = useState(false);
+
+  const [roomId, setRoomId] = useState('');
+  const [previewing, setPreviewing] = useState(false);
+  const [preview, setPreview] = useState<PreviewResponse | null>(null);
+
+  useEffect(() => {
+    if (!shareToken) {
+      setLoading(false);
+      setError('persona not found');
+      return;
+    }
+    void (async () => {
+      try {
+        setLoading(true);
+        const response = await getSharedPersona(shareToken);
+        setData(response);
+        setRoomId(response.preview_rooms?.[0]?.id || '');
+      } catch (err) {
+        setError(err instanceof Error ? err.message : 'could not load persona');
+      } finally {
+        setLoading(false);
+      }
+    })();
+  }, [shareToken]);
+
+  async function onSuggest(event: FormEvent) {
+    event.preventDefault();
+    try {
+      setSending(true);
+      await suggestSharedPersonaEdit(shareToken, { field, suggestion, author_name: authorName });
+      setSuggestion('');
+      toast.success('Suggestion sent to the owner.');
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'could not send suggestion');
+    } finally {
+      setSending(false);
+    }
+  }
+
+  async function onPreview() {
+    if (!roomId) {
+      return;
+    }
+    try {
+      setPreviewing(true);
+      setPreview(await previewSharedPersona(shareToken, roomId));
+    } catch (err) {
+      toast.error(err instanceof Error ? err.message : 'preview failed');
+    } finally {
+      setPreviewing(false);
+    }
+  }
+
+  if (loading) {
+    return (
+      <main className="container">
+        <section className="panel public-panel stack">
+          <h1>Shared Persona</h1>
+          <SkeletonList rows={2} />
+        </section>
+      </main>
+    );
+  }
+
+  if (!data) {
+    return (
+      <main className="container">
+        <section className="panel public-panel stack">
+          <h1>Shared Persona</h1>
+          <p className="error">{error || 'persona not found'}</p>
+          <p className="subtle">The link may have expired or been revoked.</p>
+          <Link className="primary-link" href="/">
+            Create your own persona
+          </Link>
+        </section>
+      </main>
+    );
+  }
+
+  const { persona, calibration, scopes } = data;
+
+  return (
+    <main className="container">
+      <section className="panel public-panel stack">
+        <h1>{persona.name}</h1>
+        <p className="subtle">Shared with you until {new Date(data.expires_at).toLocaleString()}.</p>
+
+        {calibration && (
+          <div className="stack">
+            <h2>Calibration</h2>
+            <p>{calibration.bio || 'No bio.'}</p>
+            <p>
+              <strong>Tone:</strong> {calibration.tone}
+            </p>
+            <p className="subtle">
+              Language {calibration.preferred_language} · formality {calibration.formality}
+            </p>
+            <h3>Writing samples</h3>
+            {calibration.writing_samples.map((sample) => (
+              <article key={sample} className="post-card">
+                <p>{sample}</p>
+              </article>
+            ))}
+            {calibration.do_not_say.length > 0 && <p className="subtle">Never says: {calibration.do_not_say.join(', ')}</p>}
+            {calibration.catchphrases.length > 0 && <p className="subtle">Catchphrases: {calibration.catchphrases.join(', ')}</p>}
+          </div>
+        )}
+
+        {scopes.includes('run_preview') && (
+          <div className="stack">
+            <h2>Preview</h2>
+            <label>
+              Room
+              <select value={roomId} onChange={(e) => setRoomId(e.target.value)}>
+                {(data.preview_rooms || []).map((room) => (
+                  <option key={room.id} value={room.id}>
+                    {room.name}
+                  </option>
+                ))}
+              </select>
+            </label>
+            <button onClick={onPreview} disabled={previewing || !roomId}>
+              <span className="button-content">
+                {previewing && <Spinner />}
+                <span>{previewing ? 'Generating...' : 'Generate preview'}</span>
+              </span>
+            </button>
+            {preview?.drafts.map((draft) => (
+              <article key={draft.label} className="post-card">
+                <span className="badge badge-ai">{draft.label}</span>
+                <p>{draft.content}</p>
+              </article>
+            ))}
+          </div>
+        )}
+
+        {scopes.includes('suggest_edits') && (
+          <form className="stack" onSubmit={onSuggest}>
+            <h2>Suggest an edit</h2>
+            <label>
+              About
+              <select value={field} onChange={(e) => setField(e.target.value as PersonaEditSuggestionField)}>
+                {SUGGESTION_FIELDS.map((option) => (
+                  <option key={option} value={option}>
+                    {option.replace(/_/g, ' ')}
+                  </option>
+                ))}
+              </select>
+            </label>
+            <textarea value={suggestion} onChange={(e) => setSuggestion(e.target.value)} placeholder="What would you change?" rows={4} />
+            <input value={authorName} onChange={(e) => setAuthorName(e.target.value)} placeholder="Your name (optional)" />
+            <button type="submit" disabled={sending || !suggestion.trim()}>
+              <span className="button-content">
+                {sending && <Spinner />}
+                <span>{sending ? 'Sending...' : 'Send suggestion'}</span>
+              </span>
+            </button>
+          </form>
+        )}
+      </section>
+    </main>
+  );
+}
@@ -9,6 +9,9 @@ import { useToast } from '../../components/toast-provider';
 
 const TOKEN_KEY = 'personaworlds_token';
 const SHARE_SLUG_KEY = 'personaworlds_share_slug';
+const SHARE_TOKEN_KEY = 'personaworlds_share_token';
+const GUEST_INTENT_KEY = 'personaworlds_guest_intent';
+const REMIX_INTENT_KEY = 'personaworlds_pending_remix_intent';
 
 function SignupPageContent() {
   const toast = useToast();
@@ -38,13 +41,34 @@ function SignupPageContent() {
       setMessage('');
 
       const shareSlug = isSignup && typeof window !== 'undefined' ? (localStorage.getItem(SHARE_SLUG_KEY) || '').trim() : '';
-      const response = isSignup ? await signup(email, password, shareSlug) : await login(email, password);
+      const shareToken = isSignup && typeof window !== 'undefined' ? (localStorage.getItem(SHARE_TOKEN_KEY) || '').trim() : '';
+      const intentToken = typeof window !== 'undefined' ? (localStorage.getItem(GUEST_INTENT_KEY) || '').trim() : '';
+      const referralCode = isSignup ? (searchParams.get('ref') || '').trim() : '';
+      const response = isSignup
+        ? await signup(email, password, shareSlug, shareToken, intentToken, referralCode)
+        : await login(email, password, intentToken);
       localStorage.setItem(TOKEN_KEY, response.token);
+      if (intentToken) {
+        localStorage.removeItem(GUEST_INTENT_KEY);
+      }
       if (isSignup && shareSlug) {
         localStorage.removeItem(SHARE_SLUG_KEY);
       }
+      if (isSignup && shareToken) {
+        localStorage.removeItem(SHARE_TOKEN_KEY);
+      }
       setMessage(isSignup ? 'Account created, redirecting...' : 'Logged in, redirecting...');
       toast.success(isSignup ? 'Account created.' : 'Logged in.');
+      const intent = response.intent;
+      if (intent?.status === 'completed' && intent.redirect_url?.startsWith('/')) {
+        // The server already ran the follow or remix; skip the client-side resume.
+        if (intent.kind === 'remix') {
+          localStorage.removeItem(REMIX_INTENT_KEY);
+        }
+        toast.success(intent.kind === 'follow' ? 'You are now following this persona.' : 'Remix created.');
+        window.location.href = intent.redirect_url;
+        return;
+      }
       window.location.href = redirectPath || '/';
     } catch (err) {
       const messageText = err instanceof Error ? err.message : 'auth failed';
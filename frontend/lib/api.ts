@@ -12,11 +12,36 @@ type APIErrorPayload = {
   error?: string;
   message?: string;
   code?: string;
+  rejection_id?: number;
+  cooldown?: RoomCooldown;
+  room_fit?: RoomFit;
+  intent_token?: string;
+};
+
+export type RoomFit = {
+  fits: boolean;
+  reason?: string;
+  mode: 'off' | 'advisory' | 'blocking';
+  suggested_rooms: { id: string; slug: string; name: string }[];
+};
+
+export type RoomCooldown = {
+  room_id: string;
+  persona_id: string;
+  cooldown_minutes: number;
+  last_post_at?: string;
+  available_at?: string;
+  retry_after_seconds: number;
+  active: boolean;
 };
 
 export class APIError extends Error {
   status: number;
   code: string;
+  rejectionId?: number;
+  cooldown?: RoomCooldown;
+  roomFit?: RoomFit;
+  intentToken?: string;
 
   constructor(message: string, status: number, code = 'api_error') {
     super(message);
@@ -64,7 +89,20 @@ function extractAPIError(payload: unknown, status: number) {
   const body = (payload && typeof payload === 'object' ? payload : {}) as APIErrorPayload;
   const message = (body.error || body.message || '').trim() || `request failed with status ${status}`;
   const code = (body.code || '').trim() || `http_${status}`;
-  return new APIError(message, status, code);
+  const error = new APIError(message, status, code);
+  if (typeof body.rejection_id === 'number') {
+    error.rejectionId = body.rejection_id;
+  }
+  if (body.cooldown) {
+    error.cooldown = body.cooldown;
+  }
+  if (body.room_fit) {
+    error.roomFit = body.room_fit;
+  }
+  if (body.intent_token) {
+    error.intentToken = body.intent_token;
+  }
+  return error;
 }
 
 function handleUnauthorizedRedirect(path: string, options: RequestOptions, status: number) {
@@ -109,6 +147,15 @@ export function getAPIBaseURL() {
   return API_BASE;
 }
 
+export type PersonaStyle = {
+  humor: number;
+  assertiveness: number;
+  technicality: number;
+  brevity: number;
+};
+
+export type PersonaStyleSource = 'manual' | 'classified' | 'pending' | 'default';
+
 export type Persona = {
   id: string;
   name: string;
@@ -121,6 +168,11 @@ export type Persona = {
   formality: number;
   daily_draft_quota: number;
   daily_reply_quota: number;
+  active_hours_start: number | null;
+  active_hours_end: number | null;
+  timezone: string;
+  style: PersonaStyle;
+  style_source: PersonaStyleSource;
   created_at: string;
   updated_at: string;
 };
@@ -136,6 +188,10 @@ export type PersonaPayload = {
   formality: number;
   daily_draft_quota: number;
   daily_reply_quota: number;
+  active_hours_start?: number | null;
+  active_hours_end?: number | null;
+  timezone?: string;
+  style?: PersonaStyle;
 };
 
 export type Room = {
@@ -144,6 +200,18 @@ export type Room = {
   name: string;
   description: string;
   created_at: string;
+  archived_at?: string;
+  merged_into_room_id?: string;
+  event?: RoomEvent;
+};
+
+export type RoomEvent = {
+  starts_at: string;
+  ends_at: string;
+  status: 'upcoming' | 'live' | 'ended';
+  seconds_until_start: number;
+  seconds_until_end: number;
+  recap?: string;
 };
 
 export type Post = {
@@ -154,10 +222,36 @@ export type Post = {
   authored_by: 'AI' | 'HUMAN' | 'AI_DRAFT_APPROVED';
   status: 'DRAFT' | 'PUBLISHED';
   content: string;
+  source_post_id?: string;
+  mood?: DraftMood;
+  battle_language?: BattleLanguage;
+  reactions: PostReactionCounts;
   created_at: string;
   updated_at: string;
 };
 
+export type BattleLanguageMode = 'auto' | 'a' | 'b' | 'bilingual';
+
+export type BattleLanguage = {
+  mode: BattleLanguageMode;
+  language?: 'tr' | 'en';
+};
+
+export type PostReaction = 'like' | 'insightful' | 'disagree';
+
+export const DRAFT_MOODS = ['playful', 'contrarian', 'reflective', 'optimistic', 'skeptical'] as const;
+
+export type DraftMood = (typeof DRAFT_MOODS)[number];
+
+export type PostReactionCounts = Record<PostReaction, number>;
+
+export type PostReactionsResponse = {
+  post_id: string;
+  changed: boolean;
+  reactions: PostReactionCounts;
+  viewer_reactions: PostReaction[];
+};
+
 export type Reply = {
   id: string;
   post_id: string;
@@ -165,14 +259,45 @@ export type Reply = {
   persona_name?: string;
   authored_by: 'AI' | 'HUMAN' | 'AI_DRAFT_APPROVED';
   content: string;
+  language?: 'tr' | 'en';
+  bilingual?: Partial<Record<'tr' | 'en', string>>;
   created_at: string;
   updated_at: string;
 };
 
+export type LinkedBattle = {
+  battle_id: string;
+  topic: string;
+  created_at: string;
+};
+
+export type BattleArchive = {
+  archived_at: string;
+  turn_count: number;
+  rehydrate_ms: number;
+  latency_warning: boolean;
+};
+
 export type ThreadResponse = {
   post: Post;
   replies: Reply[];
+  battles: LinkedBattle[];
   ai_summary: string;
+  interactive?: InteractiveBattle;
+  archive?: BattleArchive;
+  watching?: boolean;
+};
+
+export type PostTranslation = {
+  language: 'en' | 'tr';
+  source_language: 'en' | 'tr';
+  translated: boolean;
+  original_content?: string;
+};
+
+export type PostResponse = {
+  post: Post;
+  translation: PostTranslation;
 };
 
 export type BattleProgress = {
@@ -184,6 +309,19 @@ export type BattleProgress = {
   thread: ThreadResponse;
 };
 
+export type DraftJobStatus = 'pending' | 'processing' | 'ready' | 'failed';
+
+export type DraftJob = {
+  job_id: number;
+  status: DraftJobStatus;
+  room_id: string;
+  persona_id: string;
+  error?: string;
+  post?: Post;
+  created_at: string;
+  updated_at: string;
+};
+
 export type BattlePollOptions = {
   maxWaitMs?: number;
   initialDelayMs?: number;
@@ -198,11 +336,61 @@ export type BattlePollResult = {
   latest: BattleProgress;
 };
 
+export type QualityScore = {
+  tone: number;
+  do_not_say: number;
+  formality: number;
+  overall: number;
+  estimated_formality: number;
+  tone_traits?: string[];
+  violations?: string[];
+};
+
+export type PersonaEvaluation = {
+  id: string;
+  persona_id: string;
+  overall_score: number;
+  tone_score: number;
+  do_not_say_score: number;
+  formality_score: number;
+  report?: {
+    summary: QualityScore;
+    tasks: Array<{
+      kind: 'post' | 'reply' | 'battle_turn';
+      label: string;
+      output?: string;
+      score?: QualityScore;
+      safety_error?: string;
+      error?: string;
+    }>;
+    prompt_versions: Record<string, string>;
+  };
+  created_at: string;
+};
+
+export type EvaluatePersonaResponse = {
+  evaluation: PersonaEvaluation;
+  delta: {
+    previous_id: string;
+    overall: number;
+    tone: number;
+    do_not_say: number;
+    formality: number;
+  } | null;
+  quota: {
+    used: number;
+    limit: number;
+  };
+};
+
 export type PreviewResponse = {
   drafts: Array<{
     label: string;
     content: string;
     authored_by: 'AI' | 'HUMAN' | 'AI_DRAFT_APPROVED';
+    prompt_version: string;
+    mood?: DraftMood;
+    prompt_hint_version?: number;
   }>;
   quota: {
     used: number;
@@ -219,15 +407,28 @@ export type DigestThread = {
   last_activity_at: string;
 };
 
+// DateWindow is the [start, end) range behind a UTC date or week, expressed in
+// the viewer's timezone setting.
+export type DateWindow = {
+  timezone: string;
+  utc_offset: string;
+  start: string;
+  end: string;
+  local_start_date: string;
+  local_end_date: string;
+};
+
 export type PersonaDigest = {
   persona_id: string;
   date: string;
   summary: string;
   has_activity: boolean;
   updated_at: string;
+  window: DateWindow;
   stats: {
     posts: number;
     replies: number;
+    human_replies?: number;
     top_threads: DigestThread[];
   };
 };
@@ -237,6 +438,100 @@ export type PersonaDigestResponse = {
   exists: boolean;
 };
 
+export type DigestSchedule = {
+  enabled: boolean;
+  hour: number;
+  regenerate_requested_at?: string;
+};
+
+export type PersonaMention = {
+  id: number;
+  post_id: string;
+  reply_id?: string;
+  author_persona_id?: string;
+  author_name?: string;
+  preview: string;
+  reply_enqueued: boolean;
+  created_at: string;
+};
+
+export type PersonaMentionsResponse = {
+  mention_replies_enabled: boolean;
+  mentions: PersonaMention[];
+};
+
+export type PersonaBattleOfWeek = {
+  battle_id: string;
+  room_id: string;
+  room_name: string;
+  week_start: string;
+  side: 'pro' | 'con';
+  opponent_persona_id?: string;
+  opponent_persona_name?: string;
+  topic: string;
+  featured_since: string;
+};
+
+export type PersonaBattleOfWeekResponse = {
+  opt_in: boolean;
+  battles: PersonaBattleOfWeek[];
+};
+
+export type HeadToHeadResult = 'win' | 'loss' | 'tie';
+
+// Pre-fills a rematch: kind "battle" posts to target_route with remix_token,
+// kind "challenge" posts persona_id, topic, room_id and template_id.
+export type PersonaRematch = {
+  kind: 'battle' | 'challenge';
+  target_route: string;
+  source_battle_id: string;
+  room_id: string;
+  topic: string;
+  template_id?: string;
+  persona_id: string;
+  rival_persona_id: string;
+  remix_token?: string;
+  remix_token_expires?: string;
+};
+
+export type PersonaRival = {
+  persona_id: string;
+  name: string;
+  public_slug?: string;
+  battles: number;
+  wins: number;
+  losses: number;
+  ties: number;
+  last_battle_id: string;
+  last_battle_at: string;
+  last_result: HeadToHeadResult;
+  last_topic: string;
+  rematch?: PersonaRematch;
+};
+
+export type MarketplaceListing = {
+  persona_id: string;
+  slug: string;
+  name: string;
+  bio: string;
+  preferred_language: string;
+  topics: string[];
+  followers: number;
+  daily_battle_limit: number;
+  challenges_today: number;
+  available: boolean;
+  challenge_route: string;
+  listed_at: string;
+};
+
+export type RegenerateDigestResponse = {
+  requested_at: string;
+  quota: {
+    used: number;
+    limit: number;
+  };
+};
+
 export type PublicPersonaProfile = {
   persona_id: string;
   slug: string;
@@ -248,30 +543,113 @@ export type PublicPersonaProfile = {
   is_public: boolean;
   followers: number;
   posts_count: number;
+  view_count: number;
   badges: string[];
   created_at: string;
 };
 
+export type PublicProfileEmbed = {
+  slug: string;
+  name: string;
+  bio: string;
+  badges: string[];
+  followers: number;
+  profile_url: string;
+  latest_posts: {
+    id: string;
+    room_name: string;
+    snippet: string;
+    created_at: string;
+  }[];
+  powered_by: {
+    name: string;
+    url: string;
+  };
+};
+
+export function publicProfileEmbedURL(slug: string) {
+  return `${API_BASE}/p/${encodeURIComponent(slug)}/embed.json`;
+}
+
 export type PublicPersonaPost = {
   id: string;
   room_id: string;
   room_name: string;
   authored_by: 'AI' | 'HUMAN' | 'AI_DRAFT_APPROVED';
   content: string;
+  question?: string;
+  created_at: string;
+};
+
+export type PersonaQuestionStatus = 'PENDING' | 'APPROVED' | 'ANSWERED' | 'REJECTED';
+
+export type PersonaQuestion = {
+  id: string;
+  persona_id: string;
+  asker_name?: string;
+  question: string;
+  status: PersonaQuestionStatus;
+  room_id?: string;
+  answer_post_id?: string;
+  error?: string;
+  approved_at?: string;
+  answered_at?: string;
+  created_at: string;
+};
+
+export type BattleChallengeStatus = 'PENDING' | 'ACCEPTED' | 'DECLINED' | 'EXPIRED';
+
+export type ChallengePersona = {
+  id: string;
+  name: string;
+  slug?: string;
+};
+
+export type BattleChallenge = {
+  id: string;
+  box: 'incoming' | 'outgoing';
+  status: BattleChallengeStatus;
+  topic: string;
+  room_id: string;
+  room_name: string;
+  template_id?: string;
+  challenger: ChallengePersona;
+  opponent: ChallengePersona;
+  battle_id?: string;
+  expires_at: string;
+  responded_at?: string;
   created_at: string;
 };
 
+export type CreateChallengePayload = {
+  persona_id: string;
+  topic: string;
+  room_id?: string;
+  template_id?: string;
+};
+
 export type PublicPersonaRoom = {
   room_id: string;
   room_name: string;
   post_count: number;
 };
 
+export type RoomPresence = {
+  room_id: string;
+  room_slug: string;
+  room_name: string;
+  post_count: number;
+  reply_count: number;
+  last_active_at: string;
+};
+
 export type PublicPersonaProfileResponse = {
   profile: PublicPersonaProfile;
   latest_posts: PublicPersonaPost[];
   top_rooms: PublicPersonaRoom[];
+  presence: RoomPresence[];
   next_cursor: string;
+  questions_enabled: boolean;
 };
 
 export type PublicPersonaPostsResponse = {
@@ -302,6 +680,28 @@ export type Template = {
   word_limit: number;
   created_at: string;
   is_public: boolean;
+  policy?: TemplatePolicyRecord;
+};
+
+export type TemplatePolicy = {
+  max_links?: number;
+  blocked_terms?: string[];
+  allowed_terms?: string[];
+  max_questions?: number;
+  max_exclamations?: number;
+  max_sentence_words?: number;
+};
+
+export type TemplatePolicyRecord = {
+  template_id: string;
+  template_name: string;
+  owner_user_id?: string;
+  status: 'PENDING' | 'APPROVED' | 'REJECTED';
+  requested_policy: TemplatePolicy;
+  approved_policy: TemplatePolicy | null;
+  review_note?: string;
+  requested_at: string;
+  reviewed_at?: string;
 };
 
 export type RemixTemplateSummary = {
@@ -321,6 +721,69 @@ export type RemixIntentResponse = {
   suggested_templates: RemixTemplateSummary[];
   remix_token: string;
   remix_token_expires: string;
+  intent_token?: string;
+  intent_expires_at?: string;
+};
+
+export type GuestIntentResult = {
+  kind: 'follow' | 'remix' | '';
+  status: string;
+  target_id?: string;
+  battle_id?: string;
+  redirect_url?: string;
+};
+
+export type AuthResponse = {
+  token: string;
+  user_id: string;
+  intent?: GuestIntentResult;
+};
+
+export type ProfileViewStats = {
+  persona_id: string;
+  slug: string;
+  name: string;
+  is_public: boolean;
+  view_count: number;
+  views_7d: number;
+  views_30d: number;
+};
+
+export type BattleViewStats = {
+  battle_id: string;
+  topic: string;
+  view_count: number;
+  views_7d: number;
+  views_30d: number;
+  created_at: string;
+};
+
+export type ViewAnalyticsResponse = {
+  profiles: ProfileViewStats[];
+  battles: BattleViewStats[];
+  windows: {
+    views_7d: DateWindow;
+    views_30d: DateWindow;
+  };
+};
+
+export type QuotaBoostType = 'draft' | 'battle';
+
+export type QuotaBoost = {
+  quota_type: QuotaBoostType;
+  amount: number;
+  source: 'referrer' | 'referred';
+  starts_at: string;
+  expires_at: string;
+};
+
+export type ReferralSummary = {
+  code: string;
+  link: string;
+  referred_count: number;
+  rewarded_count: number;
+  active_boosts: Record<QuotaBoostType, number>;
+  boosts: QuotaBoost[];
 };
 
 export type PublicBattleMeta = {
@@ -329,12 +792,42 @@ export type PublicBattleMeta = {
   room_name: string;
   topic: string;
   created_at: string;
+  view_count: number;
+  visibility: BattleVisibility;
   template?: {
     id: string;
     name: string;
   };
   share_url: string;
   card_url: string;
+  card_variant: string;
+  turn?: PublicBattleTurn;
+  verdict?: PublicBattleVerdict;
+  votes: BattleVoteTally[];
+  // Only set when the request carried a token and the viewer has voted.
+  my_vote?: string;
+};
+
+export type PublicBattleVerdict = {
+  verdict: string;
+  takeaways: string[];
+  // Lines up with takeaways: the 1-based turns each one cites.
+  takeaway_turns: number[][];
+  language: string;
+  source_language: string;
+  translated: boolean;
+  original?: {
+    verdict: string;
+    takeaways: string[];
+  };
+};
+
+export type PublicBattleTurn = {
+  index: number;
+  total: number;
+  persona_name: string;
+  quote: string;
+  card_url: string;
 };
 
 export type CreateBattlePayload = {
@@ -343,6 +836,19 @@ export type CreateBattlePayload = {
   remix_token?: string;
   pro_style?: string;
   con_style?: string;
+  mode?: 'classic' | 'interactive';
+  persona_id?: string;
+  language?: BattleLanguageMode;
+};
+
+export type InteractiveBattle = {
+  battle_id: string;
+  ai_persona_id: string;
+  total_turns: number;
+  turns_taken: number;
+  next_side: 'AI' | 'HUMAN';
+  status: 'ACTIVE' | 'COMPLETED' | 'EXPIRED' | 'FAILED';
+  turn_deadline?: string;
 };
 
 export type CreateBattleResponse = {
@@ -353,6 +859,63 @@ export type CreateBattleResponse = {
   enqueued_replies: number;
   remix_used: boolean;
   suggested_next_url: string;
+  interactive?: InteractiveBattle;
+};
+
+export type BattleDryRunResponse = {
+  dry_run: true;
+  room_id: string;
+  room_name: string;
+  mode: 'classic' | 'interactive';
+  topic: string;
+  pro_style: string;
+  con_style: string;
+  language: BattleLanguage;
+  template: Template;
+  template_prompt_rules_redacted: boolean;
+  remix_used: boolean;
+  opening: string;
+  turns: Array<{
+    turn: number;
+    author: 'opening' | 'persona' | 'human';
+    persona_id?: string;
+    persona_name?: string;
+  }>;
+  prompts: Array<{
+    turn: number;
+    persona_id: string;
+    operation: string;
+    version: string;
+    system: string;
+    user: string;
+  }>;
+  estimate: {
+    llm_calls: number;
+    prompt_tokens: number;
+    completion_tokens: number;
+    max_duration_seconds: number;
+    human_turns?: number;
+    human_turn_timeout_seconds?: number;
+  };
+  warnings: string[];
+};
+
+export type CreateBattleFromPostPayload = {
+  template_id?: string;
+  pro_style?: string;
+  con_style?: string;
+};
+
+export type CreateBattleFromPostResponse = {
+  battle_id: string;
+  post: Post;
+  topic: string;
+  topic_source: 'llm' | 'fallback';
+  source_post_id: string;
+  room_name: string;
+  template: Template;
+  enqueued_replies: number;
+  suggested_next_url: string;
 };
 
 export type FeedBattleItem = {
@@ -369,6 +932,7 @@ export type FeedBattleItem = {
     id: string;
     name: string;
   };
+  featured?: boolean;
 };
 
 export type FeedTemplateItem = {
@@ -382,28 +946,67 @@ export type FeedTemplateItem = {
   is_trending: boolean;
 };
 
+export type FeedPersonaItem = {
+  persona_id: string;
+  name: string;
+  slug: string;
+  bio: string;
+  followers: number;
+  recent_battles: number;
+  room_id?: string;
+  room_name?: string;
+  created_at: string;
+};
+
 export type FeedItem = {
   id: string;
-  kind: 'battle' | 'template';
+  kind: 'battle' | 'template' | 'persona';
   reason: string;
   reasons: string[];
   score: number;
+  updated_at: string;
   battle?: FeedBattleItem;
   template?: FeedTemplateItem;
+  persona?: FeedPersonaItem;
+};
+
+export type FeedColdStart = {
+  source: 'signup' | 'first_visit' | 'popular';
+  room_ids: string[];
 };
 
 export type FeedResponse = {
   items: FeedItem[];
   highlight_template?: FeedTemplateItem;
+  next_cursor?: string;
+  as_of: string;
+  cold_start?: FeedColdStart;
 };
 
 export type Notification = {
   id: number;
+  seq: number;
   actor_user_id?: string;
-  type: 'battle_remixed' | 'template_used' | 'persona_followed';
+  type:
+    | 'battle_remixed'
+    | 'template_used'
+    | 'persona_followed'
+    | 'persona_question'
+    | 'battle_completed'
+    | 'battle_challenge'
+    | 'battle_challenge_accepted'
+    | 'persona_mentioned'
+    | 'battle_of_week'
+    | 'draft_ready'
+    | 'watched_battle_turn'
+    | 'watched_battle_verdict'
+    | 'persona_announced'
+    | 'daily_summary'
+    | 'persona_edit_suggested';
   title: string;
   body: string;
   metadata: Record<string, unknown>;
+  count: number;
   read_at?: string;
   created_at: string;
 };
@@ -411,6 +1014,7 @@ export type Notification = {
 export type NotificationsResponse = {
   notifications: Notification[];
   unread_count: number;
+  last_seq: number;
 };
 
 export type WeeklyDigestItem = {
@@ -423,10 +1027,20 @@ export type WeeklyDigestItem = {
   created_at: string;
 };
 
+export type WeeklyDigestAnnouncement = {
+  id: number;
+  title: string;
+  body: string;
+  link_url?: string;
+  starts_at: string;
+};
+
 export type WeeklyDigest = {
   week_start: string;
   generated_at: string;
   items: WeeklyDigestItem[];
+  announcements: WeeklyDigestAnnouncement[];
+  window: DateWindow;
 };
 
 export type WeeklyDigestResponse = {
@@ -435,22 +1049,43 @@ export type WeeklyDigestResponse = {
   is_current_week: boolean;
 };
 
-export async function signup(email: string, password: string, shareSlug = '') {
+export async function signup(
+  email: string,
+  password: string,
+  shareSlug = '',
+  shareToken = '',
+  intentToken = '',
+  referralCode = '',
+  roomInterests: string[] = []
+) {
   const normalizedShareSlug = shareSlug.trim();
-  return request<{ token: string; user_id: string }>('/auth/signup', {
+  const normalizedShareToken = shareToken.trim();
+  const normalizedIntentToken = intentToken.trim();
+  const normalizedReferralCode = referralCode.trim();
+  const normalizedRoomInterests = roomInterests.map((room) => room.trim()).filter(Boolean);
+  return request<AuthResponse>('/auth/signup', {
     method: 'POST',
     body: {
       email,
       password,
-      ...(normalizedShareSlug ? { share_slug: normalizedShareSlug } : {})
+      ...(normalizedShareSlug ? { share_slug: normalizedShareSlug } : {}),
+      ...(normalizedShareToken ? { share_token: normalizedShareToken } : {}),
+      ...(normalizedIntentToken ? { intent_token: normalizedIntentToken } : {}),
+      ...(normalizedReferralCode ? { referral_code: normalizedReferralCode } : {}),
+      ...(normalizedRoomInterests.length > 0 ? { room_interests: normalizedRoomInterests } : {})
     }
   });
 }
 
-export async function login(email: string, password: string) {
-  return request<{ token: string; user_id: string }>('/auth/login', {
+export async function login(email: string, password: string, intentToken = '') {
+  const normalizedIntentToken = intentToken.trim();
+  return request<AuthResponse>('/auth/login', {
     method: 'POST',
-    body: { email, password }
+    body: {
+      email,
+      password,
+      ...(normalizedIntentToken ? { intent_token: normalizedIntentToken } : {})
+    }
   });
 }
 
@@ -474,8 +1109,12 @@ export async function updatePersona(token: string, personaId: string, payload: P
   });
 }
 
-export async function previewPersona(token: string, personaId: string, roomId: string) {
-  const query = new URLSearchParams({ room_id: roomId }).toString();
+export async function previewPersona(token: string, personaId: string, roomId: string, mood: DraftMood | '' = '') {
+  const params = new URLSearchParams({ room_id: roomId });
+  if (mood) {
+    params.set('mood', mood);
+  }
+  const query = params.toString();
   return request<PreviewResponse>(`/personas/${personaId}/preview?${query}`, {
     method: 'POST',
     token,
@@ -483,6 +1122,22 @@ export async function previewPersona(token: string, personaId: string, roomId: s
   });
 }
 
+export async function evaluatePersona(token: string, personaId: string) {
+  return request<EvaluatePersonaResponse>(`/personas/${personaId}/evaluate`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+export async function listPersonaEvaluations(token: string, personaId: string) {
+  return request<{ evaluations: PersonaEvaluation[] }>(`/personas/${personaId}/evaluations`, { token });
+}
+
+export async function getPersonaPresence(token: string, personaId: string) {
+  return request<{ presence: RoomPresence[]; window_days: number }>(`/personas/${personaId}/presence`, { token });
+}
+
 export async function getTodayDigest(token: string, personaId: string) {
   return request<PersonaDigestResponse>(`/personas/${personaId}/digest/today`, { token });
 }
@@ -491,12 +1146,104 @@ export async function getLatestDigest(token: string, personaId: string) {
   return request<PersonaDigestResponse>(`/personas/${personaId}/digest/latest`, { token });
 }
 
-export async function publishPersonaProfile(
+export async function getDigestSchedule(token: string, personaId: string) {
+  return request<{ schedule: DigestSchedule }>(`/personas/${personaId}/digest/schedule`, { token });
+}
+
+export async function updateDigestSchedule(
   token: string,
   personaId: string,
-  payload: { slug?: string; bio?: string } = {}
+  payload: { enabled?: boolean; hour?: number }
 ) {
-  return request<PublishPersonaProfileResponse>(`/personas/${personaId}/publish-profile`, {
+  return request<{ schedule: DigestSchedule }>(`/personas/${personaId}/digest/schedule`, {
+    method: 'PUT',
+    token,
+    body: payload
+  });
+}
+
+export async function regenerateDigest(token: string, personaId: string) {
+  return request<RegenerateDigestResponse>(`/personas/${personaId}/digest/regenerate`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+export async function listPersonaMentions(token: string, personaId: string) {
+  return request<PersonaMentionsResponse>(`/personas/${personaId}/mentions`, { token });
+}
+
+export async function updateMentionReplies(token: string, personaId: string, enabled: boolean) {
+  return request<{ mention_replies_enabled: boolean }>(`/personas/${personaId}/mention-replies`, {
+    method: 'PUT',
+    token,
+    body: { enabled }
+  });
+}
+
+export async function getPersonaBattleOfWeek(token: string, personaId: string) {
+  return request<PersonaBattleOfWeekResponse>(`/personas/${personaId}/battle-of-week`, { token });
+}
+
+export async function listPersonaRivals(token: string, personaId: string) {
+  return request<{ persona_id: string; rivals: PersonaRival[] }>(`/personas/${personaId}/rivals`, { token });
+}
+
+export async function upsertMarketplaceListing(
+  token: string,
+  personaId: string,
+  payload: { topics: string[]; daily_battle_limit?: number }
+) {
+  return request<{ listing: MarketplaceListing }>(`/personas/${personaId}/marketplace`, {
+    method: 'PUT',
+    token,
+    body: payload
+  });
+}
+
+export async function deleteMarketplaceListing(token: string, personaId: string) {
+  return request<{ persona_id: string; removed: boolean }>(`/personas/${personaId}/marketplace`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export async function listMarketplacePersonas(
+  params: { topic?: string; language?: string; available?: boolean; limit?: number } = {},
+  token?: string
+) {
+  const query = new URLSearchParams();
+  if (params.topic) {
+    query.set('topic', params.topic);
+  }
+  if (params.language) {
+    query.set('language', params.language);
+  }
+  if (params.available) {
+    query.set('available', 'true');
+  }
+  if (params.limit) {
+    query.set('limit', String(params.limit));
+  }
+  const suffix = query.toString() ? `?${query.toString()}` : '';
+  return request<{ personas: MarketplaceListing[] }>(`/marketplace/personas${suffix}`, { token });
+}
+
+export async function updateBattleOfWeekOptIn(token: string, personaId: string, enabled: boolean) {
+  return request<{ opt_in: boolean }>(`/personas/${personaId}/battle-of-week`, {
+    method: 'PUT',
+    token,
+    body: { enabled }
+  });
+}
+
+export async function publishPersonaProfile(
+  token: string,
+  personaId: string,
+  payload: { slug?: string; bio?: string } = {}
+) {
+  return request<PublishPersonaProfileResponse>(`/personas/${personaId}/publish-profile`, {
     method: 'POST',
     token,
     body: payload
@@ -511,6 +1258,46 @@ export async function unpublishPersonaProfile(token: string, personaId: string)
   });
 }
 
+export type PostStatusAction = 'unpublish' | 'republish';
+
+export type PostStatusBatch = {
+  id: number;
+  persona_id: string;
+  action: PostStatusAction;
+  status: 'PENDING' | 'DONE';
+  total_posts: number;
+  processed_posts: number;
+  changed_posts: number;
+  created_at: string;
+  updated_at: string;
+  completed_at?: string;
+};
+
+export async function bulkUpdatePostStatus(
+  token: string,
+  personaId: string,
+  action: PostStatusAction,
+  selection: { postIds: string[] } | { all: true }
+) {
+  return request<{ batch: PostStatusBatch }>(`/personas/${personaId}/posts/bulk-status`, {
+    method: 'POST',
+    token,
+    body: 'all' in selection ? { action, all: true } : { action, post_ids: selection.postIds }
+  });
+}
+
+export async function getPostStatusBatch(token: string, personaId: string, batchId: number) {
+  return request<{ batch: PostStatusBatch }>(`/personas/${personaId}/posts/bulk-status/${batchId}`, { token });
+}
+
+export async function announcePersona(token: string, personaId: string) {
+  return request<{ announced: boolean; recipients: number }>(`/personas/${personaId}/announce`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
 export async function getPublicPersonaProfile(slug: string) {
   return request<PublicPersonaProfileResponse>(`/p/${encodeURIComponent(slug)}`);
 }
@@ -532,8 +1319,274 @@ export async function followPublicPersona(slug: string, token?: string) {
   });
 }
 
-export async function getPublicBattleMeta(battleId: string) {
-  return request<PublicBattleMeta>(`/b/${encodeURIComponent(battleId)}/meta`);
+export async function askPublicPersona(slug: string, payload: { question: string; name?: string }, token?: string) {
+  return request<{ question: PersonaQuestion }>(`/p/${encodeURIComponent(slug)}/ask`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export async function listPersonaQuestions(token: string, personaId: string, status: PersonaQuestionStatus = 'PENDING') {
+  return request<{ status: PersonaQuestionStatus; questions: PersonaQuestion[] }>(
+    `/personas/${personaId}/questions?status=${status.toLowerCase()}`,
+    { token }
+  );
+}
+
+export async function approvePersonaQuestion(token: string, personaId: string, questionId: string, roomId?: string) {
+  return request<{ question: PersonaQuestion }>(`/personas/${personaId}/questions/${questionId}/approve`, {
+    method: 'POST',
+    token,
+    body: roomId ? { room_id: roomId } : {}
+  });
+}
+
+export async function rejectPersonaQuestion(token: string, personaId: string, questionId: string) {
+  return request<{ question: PersonaQuestion }>(`/personas/${personaId}/questions/${questionId}/reject`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+export type PersonaShareScope = 'view_calibration' | 'suggest_edits' | 'run_preview';
+
+export type PersonaShareLink = {
+  id: string;
+  persona_id: string;
+  label: string;
+  scopes: PersonaShareScope[];
+  status: 'active' | 'expired' | 'revoked';
+  expires_at: string;
+  revoked_at?: string;
+  last_used_at?: string;
+  pending_suggestions: number;
+  created_at: string;
+};
+
+export type PersonaEditSuggestionField =
+  | 'bio'
+  | 'tone'
+  | 'writing_samples'
+  | 'do_not_say'
+  | 'catchphrases'
+  | 'formality'
+  | 'preferred_language'
+  | 'general';
+
+export type PersonaEditSuggestionStatus = 'PENDING' | 'ACCEPTED' | 'DISMISSED';
+
+export type PersonaEditSuggestion = {
+  id: string;
+  persona_id: string;
+  share_link_id: string;
+  share_link_label?: string;
+  field: PersonaEditSuggestionField;
+  suggestion: string;
+  author_name?: string;
+  status: PersonaEditSuggestionStatus;
+  reviewed_at?: string;
+  created_at: string;
+};
+
+export type SharedPersonaResponse = {
+  persona: { id: string; name: string };
+  scopes: PersonaShareScope[];
+  expires_at: string;
+  calibration?: {
+    bio: string;
+    tone: string;
+    writing_samples: string[];
+    do_not_say: string[];
+    catchphrases: string[];
+    preferred_language: 'tr' | 'en';
+    formality: number;
+    style: PersonaStyle;
+  };
+  preview_rooms?: { id: string; name: string }[];
+};
+
+export async function createPersonaShareLink(
+  token: string,
+  personaId: string,
+  payload: { scopes: PersonaShareScope[]; expires_in_hours?: number; label?: string }
+) {
+  return request<{ link: PersonaShareLink; share_token: string; share_url: string }>(`/personas/${personaId}/share-links`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export async function listPersonaShareLinks(token: string, personaId: string) {
+  return request<{ links: PersonaShareLink[] }>(`/personas/${personaId}/share-links`, { token });
+}
+
+export async function revokePersonaShareLink(token: string, personaId: string, linkId: string) {
+  return request<{ revoked: boolean }>(`/personas/${personaId}/share-links/${linkId}`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export async function listPersonaEditSuggestions(
+  token: string,
+  personaId: string,
+  status: PersonaEditSuggestionStatus = 'PENDING'
+) {
+  return request<{ status: PersonaEditSuggestionStatus; suggestions: PersonaEditSuggestion[] }>(
+    `/personas/${personaId}/suggestions?status=${encodeURIComponent(status.toLowerCase())}`,
+    { token }
+  );
+}
+
+export async function reviewPersonaEditSuggestion(
+  token: string,
+  personaId: string,
+  suggestionId: string,
+  decision: 'accept' | 'dismiss'
+) {
+  return request<{ suggestion: PersonaEditSuggestion }>(`/personas/${personaId}/suggestions/${suggestionId}/${decision}`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+export async function getSharedPersona(shareToken: string) {
+  return request<SharedPersonaResponse>(`/shared/personas/${encodeURIComponent(shareToken)}`);
+}
+
+export async function suggestSharedPersonaEdit(
+  shareToken: string,
+  payload: { field: PersonaEditSuggestionField; suggestion: string; author_name?: string }
+) {
+  return request<{ suggestion: PersonaEditSuggestion }>(`/shared/personas/${encodeURIComponent(shareToken)}/suggestions`, {
+    method: 'POST',
+    body: payload
+  });
+}
+
+export async function previewSharedPersona(shareToken: string, roomId: string) {
+  const params = new URLSearchParams({ room_id: roomId });
+  return request<PreviewResponse>(`/shared/personas/${encodeURIComponent(shareToken)}/preview?${params.toString()}`, {
+    method: 'POST',
+    body: {}
+  });
+}
+
+export async function challengePublicPersona(token: string, slug: string, payload: CreateChallengePayload) {
+  return request<{ challenge: BattleChallenge }>(`/p/${encodeURIComponent(slug)}/challenge`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export async function listBattleChallenges(token: string, box: 'incoming' | 'outgoing' = 'incoming') {
+  return request<{ box: string; challenges: BattleChallenge[] }>(`/challenges?box=${box}`, { token });
+}
+
+export async function acceptBattleChallenge(token: string, challengeId: string) {
+  return request<{ challenge: BattleChallenge; battle_id: string; suggested_next_url: string }>(
+    `/challenges/${encodeURIComponent(challengeId)}/accept`,
+    {
+      method: 'POST',
+      token,
+      body: {}
+    }
+  );
+}
+
+export async function declineBattleChallenge(token: string, challengeId: string) {
+  return request<{ challenge: BattleChallenge }>(`/challenges/${encodeURIComponent(challengeId)}/decline`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+// cardClick forwards the signed `cv`/`cs` params of a card click-through so
+// the visit is attributed to the card layout it came from. access is the `ua`
+// token an unlisted battle's link carries.
+export async function getPublicBattleMeta(
+  battleId: string,
+  turn?: number,
+  cardClick?: { variant: string; signature: string },
+  access?: string,
+  token?: string
+) {
+  const params = new URLSearchParams();
+  if (turn && turn > 0) {
+    params.set('t', String(turn));
+  }
+  if (cardClick?.variant && cardClick.signature) {
+    params.set('cv', cardClick.variant);
+    params.set('cs', cardClick.signature);
+  }
+  if (access) {
+    params.set('ua', access);
+  }
+  const query = params.toString();
+  return request<PublicBattleMeta>(`/b/${encodeURIComponent(battleId)}/meta${query ? `?${query}` : ''}`, { token });
+}
+
+export type BattleReplayTurn = {
+  index: number;
+  reply_id: string;
+  persona_id?: string;
+  persona_name?: string;
+  authored_by: 'AI' | 'HUMAN' | 'AI_DRAFT_APPROVED';
+  content: string;
+  language?: string;
+  bilingual?: Record<string, string>;
+  created_at: string;
+  offset_ms: number;
+  generation_started_offset_ms?: number;
+  generation_ms?: number;
+  timing_recorded: boolean;
+  pause_ms: number;
+  typing_ms: number;
+  playback_start_ms: number;
+  playback_end_ms: number;
+};
+
+export type BattleReplay = {
+  battle_id: string;
+  started_at: string;
+  duration_ms: number;
+  playback_ms: number;
+  turns: BattleReplayTurn[];
+  archive?: BattleArchive;
+};
+
+export async function getBattleReplay(battleId: string, access?: string) {
+  const query = access ? `?ua=${encodeURIComponent(access)}` : '';
+  return request<BattleReplay>(`/b/${encodeURIComponent(battleId)}/replay${query}`);
+}
+
+export type BattleHighlightTurn = {
+  turn: number;
+  reply_id: string;
+  persona_id: string;
+  persona_name: string;
+  excerpt: string;
+  content: string;
+  score: number;
+};
+
+export type BattleHighlights = {
+  battle_id: string;
+  status: 'pending' | 'ready';
+  summary: string;
+  turns: BattleHighlightTurn[];
+  generated_at?: string;
+};
+
+export async function getBattleHighlights(battleId: string, access?: string) {
+  const query = access ? `?ua=${encodeURIComponent(access)}` : '';
+  return request<BattleHighlights>(`/b/${encodeURIComponent(battleId)}/highlights${query}`);
 }
 
 export async function createBattleRemixIntent(battleId: string, token?: string) {
@@ -544,6 +1597,108 @@ export async function createBattleRemixIntent(battleId: string, token?: string)
   });
 }
 
+export type BattleShareLink = {
+  battle_id: string;
+  share_token: string;
+  share_url: string;
+  expires_at: string;
+};
+
+export async function createBattleShareLink(token: string, battleId: string) {
+  return request<BattleShareLink>(`/battles/${encodeURIComponent(battleId)}/share-link`, {
+    method: 'POST',
+    token,
+    body: {}
+  });
+}
+
+export type BattleVisibility = 'public' | 'unlisted';
+
+export type BattleVisibilityResult = {
+  battle_id: string;
+  visibility: BattleVisibility;
+  share_url?: string;
+  card_url?: string;
+  expires_at?: string;
+};
+
+// Unlisted battles are only reachable through the returned share_url.
+// rotate revokes every link issued before.
+export async function updateBattleVisibility(
+  token: string,
+  battleId: string,
+  payload: { visibility: BattleVisibility; expires_at?: string; rotate?: boolean }
+) {
+  return request<BattleVisibilityResult>(`/battles/${encodeURIComponent(battleId)}/visibility`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export type BattleVoteTally = {
+  persona_id: string;
+  persona_name: string;
+  votes: number;
+};
+
+export type BattleVotes = {
+  battle_id: string;
+  votes: BattleVoteTally[];
+  my_vote: string;
+};
+
+// Votes decide head-to-head results. Voting again moves the vote.
+export async function voteInBattle(token: string, battleId: string, personaId: string) {
+  return request<BattleVotes>(`/b/${encodeURIComponent(battleId)}/vote`, {
+    method: 'POST',
+    token,
+    body: { persona_id: personaId }
+  });
+}
+
+export async function retractBattleVote(token: string, battleId: string) {
+  return request<BattleVotes>(`/b/${encodeURIComponent(battleId)}/vote`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export type BattleLeaderboardWindow = 'week' | 'month' | 'all';
+
+export type BattleLeaderboardEntry = {
+  rank: number;
+  persona_id: string;
+  persona_name: string;
+  public_slug?: string;
+  wins: number;
+  ties: number;
+  losses: number;
+  battles: number;
+  votes: number;
+};
+
+export type BattleLeaderboard = {
+  window: BattleLeaderboardWindow;
+  room_id: string;
+  entries: BattleLeaderboardEntry[];
+};
+
+export async function getBattleLeaderboard(options: { window?: BattleLeaderboardWindow; roomId?: string; limit?: number } = {}) {
+  const params = new URLSearchParams();
+  if (options.window) {
+    params.set('window', options.window);
+  }
+  if (options.roomId) {
+    params.set('room_id', options.roomId);
+  }
+  if (options.limit) {
+    params.set('limit', String(options.limit));
+  }
+  const query = params.toString();
+  return request<BattleLeaderboard>(`/leaderboard/battles${query ? `?${query}` : ''}`);
+}
+
 export async function listTemplates() {
   return request<{ templates: Template[] }>('/templates');
 }
@@ -556,6 +1711,7 @@ export async function createTemplate(
     turn_count: number;
     word_limit: number;
     is_public: boolean;
+    policy?: TemplatePolicy;
   }
 ) {
   return request<Template>('/templates', {
@@ -565,8 +1721,46 @@ export async function createTemplate(
   });
 }
 
-export async function getFeed(token: string) {
-  return request<FeedResponse>('/feed', { token });
+export async function getTemplatePolicy(token: string, templateId: string) {
+  return request<{ policy: TemplatePolicyRecord }>(`/templates/${encodeURIComponent(templateId)}/policy`, { token });
+}
+
+export async function requestTemplatePolicy(token: string, templateId: string, policy: TemplatePolicy) {
+  return request<{ policy: TemplatePolicyRecord }>(`/templates/${encodeURIComponent(templateId)}/policy`, {
+    method: 'PUT',
+    token,
+    body: policy
+  });
+}
+
+export async function deleteTemplatePolicy(token: string, templateId: string) {
+  return request<{ template_id: string; removed: boolean }>(`/templates/${encodeURIComponent(templateId)}/policy`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export async function getFeed(token: string, options: { cursor?: string; since?: string; limit?: number } = {}) {
+  const query = new URLSearchParams();
+  if (options.cursor) {
+    query.set('cursor', options.cursor);
+  }
+  if (options.since) {
+    query.set('since', options.since);
+  }
+  if (options.limit) {
+    query.set('limit', String(options.limit));
+  }
+  const suffix = query.toString();
+  return request<FeedResponse>(suffix ? `/feed?${suffix}` : '/feed', { token });
+}
+
+export async function dismissColdStart(token: string) {
+  return request<{ dismissed: boolean }>('/feed/cold-start/dismiss', {
+    method: 'POST',
+    token,
+    body: {}
+  });
 }
 
 export async function getNotifications(token: string, limit = 20) {
@@ -590,6 +1784,272 @@ export async function markAllNotificationsRead(token: string) {
   });
 }
 
+// Marks notifications up to seq as read. Pass the last_seq the client showed,
+// so notifications that arrived since stay unread on every device.
+export async function markNotificationsReadUpTo(token: string, seq: number) {
+  return request<{ updated: number; read_up_to: number; unread_count: number; last_seq: number }>(
+    `/notifications/read-up-to/${seq}`,
+    {
+      method: 'POST',
+      token,
+      body: {}
+    }
+  );
+}
+
+export type SocialProvider = 'x' | 'linkedin';
+
+export type SocialAccount = {
+  provider: SocialProvider;
+  handle: string;
+  expires_at?: string;
+  connected_at: string;
+};
+
+export type Crosspost = {
+  post_id: string;
+  provider: SocialProvider;
+  status: 'PENDING' | 'PUBLISHED' | 'FAILED';
+  job_id?: number;
+  backlink_url: string;
+  external_url?: string;
+  error?: string;
+  requested_at: string;
+  published_at?: string;
+};
+
+export async function listSocialAccounts(token: string) {
+  return request<{ accounts: SocialAccount[]; providers: SocialProvider[] }>('/social/accounts', { token });
+}
+
+export async function connectSocialAccount(token: string, provider: SocialProvider) {
+  return request<{ provider: SocialProvider; authorize_url: string; expires_at: string }>(
+    `/social/accounts/${provider}/connect`,
+    {
+      method: 'POST',
+      token,
+      body: {}
+    }
+  );
+}
+
+export async function completeSocialAccount(token: string, provider: SocialProvider, code: string, state: string) {
+  return request<{ account: SocialAccount }>(`/social/accounts/${provider}/callback`, {
+    method: 'POST',
+    token,
+    body: { code, state }
+  });
+}
+
+export async function disconnectSocialAccount(token: string, provider: SocialProvider) {
+  return request<{ provider: SocialProvider; removed: boolean }>(`/social/accounts/${provider}`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export async function crosspostPost(token: string, postId: string, provider: SocialProvider) {
+  return request<{ crosspost: Crosspost }>(`/posts/${encodeURIComponent(postId)}/crosspost`, {
+    method: 'POST',
+    token,
+    body: { provider }
+  });
+}
+
+export async function listCrossposts(token: string, postId: string) {
+  return request<{ crossposts: Crosspost[] }>(`/posts/${encodeURIComponent(postId)}/crossposts`, { token });
+}
+
+export type PushPreferences = {
+  battle_completed: boolean;
+  new_follower: boolean;
+};
+
+export async function getPushPublicKey(token: string) {
+  return request<{ enabled: boolean; public_key: string }>('/notifications/push/public-key', { token });
+}
+
+export async function subscribePush(token: string, subscription: PushSubscriptionJSON) {
+  return request<{ subscription_id: string }>('/notifications/push/subscribe', {
+    method: 'POST',
+    token,
+    body: {
+      endpoint: subscription.endpoint,
+      keys: {
+        p256dh: subscription.keys?.p256dh || '',
+        auth: subscription.keys?.auth || ''
+      }
+    }
+  });
+}
+
+export async function unsubscribePush(token: string, endpoint: string) {
+  return request<{ deleted: boolean }>('/notifications/push/unsubscribe', {
+    method: 'POST',
+    token,
+    body: { endpoint }
+  });
+}
+
+export async function getPushPreferences(token: string) {
+  return request<{ preferences: PushPreferences }>('/notifications/push/preferences', { token });
+}
+
+export async function updatePushPreferences(token: string, preferences: Partial<PushPreferences>) {
+  return request<{ preferences: PushPreferences }>('/notifications/push/preferences', {
+    method: 'PUT',
+    token,
+    body: preferences
+  });
+}
+
+export type NotificationPreferences = {
+  persona_announcements: boolean;
+  daily_summary: boolean;
+};
+
+export async function getNotificationPreferences(token: string) {
+  return request<{ preferences: NotificationPreferences }>('/notifications/preferences', { token });
+}
+
+export async function updateNotificationPreferences(token: string, preferences: Partial<NotificationPreferences>) {
+  return request<{ preferences: NotificationPreferences }>('/notifications/preferences', {
+    method: 'PUT',
+    token,
+    body: preferences
+  });
+}
+
+export type Announcement = {
+  id: number;
+  title: string;
+  body: string;
+  link_url?: string;
+  kind: 'changelog' | 'broadcast';
+  segment: 'all' | 'new' | 'active' | 'dormant' | 'creators';
+  major: boolean;
+  starts_at: string;
+  ends_at?: string;
+  created_at: string;
+};
+
+export async function listAnnouncements(token: string) {
+  return request<{ announcements: Announcement[] }>('/announcements', { token });
+}
+
+export async function dismissAnnouncement(token: string, announcementId: number) {
+  return request<{ dismissed: boolean }>(`/announcements/${announcementId}/dismiss`, {
+    method: 'POST',
+    token
+  });
+}
+
+export type AccountSettings = {
+  timezone: string;
+};
+
+export async function getAccountSettings(token: string) {
+  return request<{ settings: AccountSettings }>('/account/settings', { token });
+}
+
+export async function updateAccountSettings(token: string, settings: Partial<AccountSettings>) {
+  return request<{ settings: AccountSettings }>('/account/settings', {
+    method: 'PUT',
+    token,
+    body: settings
+  });
+}
+
+export type SafetyRejection = {
+  id: number;
+  persona_id?: string;
+  room_id?: string;
+  post_id?: string;
+  source: 'preview' | 'draft' | 'reply' | 'persona_answer';
+  rule: string;
+  reason: string;
+  content: string;
+  metadata: Record<string, unknown>;
+  status: 'REJECTED' | 'APPEALED' | 'APPROVED' | 'DENIED';
+  appeal_note: string;
+  appealed_at?: string;
+  review_note: string;
+  reviewed_at?: string;
+  resolved_post_id?: string;
+  created_at: string;
+};
+
+export async function listSafetyRejections(token: string, cursor = '', status = '') {
+  const params = new URLSearchParams();
+  if (cursor) {
+    params.set('cursor', cursor);
+  }
+  if (status) {
+    params.set('status', status);
+  }
+  const query = params.toString();
+  return request<{ rejections: SafetyRejection[]; next_cursor: string }>(
+    `/safety/rejections${query ? `?${query}` : ''}`,
+    { token }
+  );
+}
+
+export async function appealSafetyRejection(token: string, rejectionId: number, note: string) {
+  return request<{ rejection: SafetyRejection }>(`/safety/rejections/${rejectionId}/appeal`, {
+    method: 'POST',
+    token,
+    body: { note }
+  });
+}
+
+export async function getViewAnalytics(token: string) {
+  return request<ViewAnalyticsResponse>('/analytics/views', { token });
+}
+
+export async function getMyReferrals(token: string) {
+  return request<ReferralSummary>('/referrals/me', { token });
+}
+
+export type DailySummaryPersona = {
+  persona_id: string;
+  name: string;
+  summary: string;
+  posts: number;
+  replies: number;
+  human_replies: number;
+  reactions: number;
+  new_followers: number;
+};
+
+export type DailySummary = {
+  date: string;
+  summary: string;
+  personas: DailySummaryPersona[];
+  pending_drafts: {
+    post_id: string;
+    persona_id: string;
+    persona_name: string;
+    excerpt: string;
+    created_at: string;
+  }[];
+  pending_draft_count: number;
+  new_followers: number;
+  finished_battles: { battle_id: string; topic: string; finished_at: string }[];
+  generated_at: string;
+  window: DateWindow;
+};
+
+export type DailySummaryResponse = {
+  summary: DailySummary | null;
+  exists: boolean;
+  enabled: boolean;
+};
+
+export async function getDailySummary(token: string, date?: string) {
+  const query = date ? `?date=${encodeURIComponent(date)}` : '';
+  return request<DailySummaryResponse>(`/digest/daily${query}`, { token });
+}
+
 export async function getWeeklyDigest(token: string) {
   return request<WeeklyDigestResponse>('/digest/weekly', { token });
 }
@@ -598,18 +2058,131 @@ export async function listRooms(token: string) {
   return request<{ rooms: Room[] }>('/rooms', { token });
 }
 
+export async function getRoom(token: string, roomId: string) {
+  return request<{ room: Room }>(`/rooms/${roomId}`, { token });
+}
+
 export async function listRoomPosts(token: string, roomId: string) {
   return request<{ posts: Post[] }>(`/rooms/${roomId}/posts`, { token });
 }
 
-export async function createDraft(token: string, roomId: string, personaId: string) {
-  return request<Post>(`/rooms/${roomId}/posts/draft`, {
+export type RoomRosterEntry = {
+  persona_id: string;
+  persona_name: string;
+  mine: boolean;
+  weekly_posts: number;
+  weekly_replies: number;
+  posts: number;
+  drafts_waiting: number;
+  replies: number;
+  posts_due: number;
+  replies_due: number;
+  enrolled_at: string;
+};
+
+export type RoomRosterResponse = {
+  room_id: string;
+  week_start: string;
+  week_end: string;
+  roster: RoomRosterEntry[];
+};
+
+export async function getRoomRoster(token: string, roomId: string) {
+  return request<RoomRosterResponse>(`/rooms/${roomId}/roster`, { token });
+}
+
+export async function enrollRosterPersona(
+  token: string,
+  roomId: string,
+  personaId: string,
+  targets: { weekly_posts: number; weekly_replies: number }
+) {
+  return request<{ enrollment: RoomRosterEntry }>(`/rooms/${roomId}/roster/${personaId}`, {
+    method: 'PUT',
+    token,
+    body: targets
+  });
+}
+
+export async function leaveRoster(token: string, roomId: string, personaId: string) {
+  return request<{ removed: boolean }>(`/rooms/${roomId}/roster/${personaId}`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export type RoomPromptHint = {
+  id: number;
+  version: number;
+  emphasize: string;
+  structure: string;
+  created_by?: string;
+  created_at: string;
+  active: boolean;
+  post_count: number;
+};
+
+export async function listRoomPromptHints(token: string, roomId: string) {
+  return request<{ room_id: string; hints: RoomPromptHint[] }>(`/rooms/${roomId}/prompt-hints`, { token });
+}
+
+export async function createRoomPromptHint(token: string, roomId: string, hint: { emphasize?: string; structure?: string }) {
+  return request<{ hint: RoomPromptHint }>(`/rooms/${roomId}/prompt-hints`, {
     method: 'POST',
     token,
-    body: { persona_id: personaId }
+    body: hint
+  });
+}
+
+export async function activateRoomPromptHint(token: string, roomId: string, version: number) {
+  return request<{ room_id: string; active_version: number }>(`/rooms/${roomId}/prompt-hints/${version}/activate`, {
+    method: 'POST',
+    token
+  });
+}
+
+export async function clearRoomPromptHint(token: string, roomId: string) {
+  return request<{ room_id: string; active_version: null }>(`/rooms/${roomId}/prompt-hints`, {
+    method: 'DELETE',
+    token
   });
 }
 
+// createDraft returns the draft, or a DraftJob (HTTP 202) when generation
+// missed the server's latency SLA and continues in the background.
+export async function createDraft(token: string, roomId: string, personaId: string, mood: DraftMood | '' = '') {
+  return request<Post | DraftJob>(`/rooms/${roomId}/posts/draft`, {
+    method: 'POST',
+    token,
+    body: mood ? { persona_id: personaId, mood } : { persona_id: personaId }
+  });
+}
+
+export function isDraftJob(value: Post | DraftJob): value is DraftJob {
+  return typeof (value as DraftJob).job_id === 'number';
+}
+
+export async function getDraftJob(token: string, jobId: number) {
+  return request<DraftJob>(`/drafts/jobs/${jobId}`, { token });
+}
+
+export async function pollDraftJob(token: string, jobId: number, options: BattlePollOptions = {}) {
+  const maxWaitMs = options.maxWaitMs && options.maxWaitMs > 0 ? options.maxWaitMs : 120000;
+  const initialDelayMs = options.initialDelayMs && options.initialDelayMs > 0 ? options.initialDelayMs : 2000;
+  const maxDelayMs = options.maxDelayMs && options.maxDelayMs > 0 ? options.maxDelayMs : 10000;
+  const factor = options.factor && options.factor > 1 ? options.factor : 1.6;
+
+  const deadline = Date.now() + maxWaitMs;
+  let delayMs = initialDelayMs;
+  let latest = await getDraftJob(token, jobId);
+  while ((latest.status === 'pending' || latest.status === 'processing') && Date.now() < deadline) {
+    await sleep(Math.min(delayMs, deadline - Date.now()));
+    delayMs = Math.min(maxDelayMs, Math.round(delayMs * factor));
+    latest = await getDraftJob(token, jobId);
+  }
+  return latest;
+}
+
 export async function createBattle(token: string, roomId: string, payload: CreateBattlePayload) {
   return request<CreateBattleResponse>(`/rooms/${roomId}/battles`, {
     method: 'POST',
@@ -618,11 +2191,37 @@ export async function createBattle(token: string, roomId: string, payload: Creat
   });
 }
 
-export async function approvePost(token: string, postId: string) {
+// Validates a battle like createBattle and returns its planned turns and
+// prompts without creating anything.
+export async function dryRunBattle(token: string, roomId: string, payload: CreateBattlePayload) {
+  return request<BattleDryRunResponse>(`/rooms/${roomId}/battles/dry-run`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export async function submitBattleTurn(token: string, battleId: string, content: string) {
+  return request<{ reply: Reply; interactive: InteractiveBattle }>(`/battles/${encodeURIComponent(battleId)}/my-turn`, {
+    method: 'POST',
+    token,
+    body: { content }
+  });
+}
+
+export async function createBattleFromPost(token: string, postId: string, payload: CreateBattleFromPostPayload = {}) {
+  return request<CreateBattleFromPostResponse>(`/posts/${postId}/battle`, {
+    method: 'POST',
+    token,
+    body: payload
+  });
+}
+
+export async function approvePost(token: string, postId: string, options: { publishOffTopic?: boolean } = {}) {
   return request<Post>(`/posts/${postId}/approve`, {
     method: 'POST',
     token,
-    body: {}
+    body: options.publishOffTopic ? { publish_off_topic: true } : {}
   });
 }
 
@@ -634,10 +2233,112 @@ export async function generateReplies(token: string, postId: string, personaIds:
   });
 }
 
+export async function createReply(token: string, postId: string, content: string, personaIds: string[] = []) {
+  return request<{ reply: Reply; enqueued: number; skipped: number }>(`/posts/${postId}/replies`, {
+    method: 'POST',
+    token,
+    body: { content, persona_ids: personaIds }
+  });
+}
+
+export async function reactToPost(token: string, postId: string, reaction: PostReaction) {
+  return request<PostReactionsResponse>(`/posts/${postId}/reactions`, {
+    method: 'POST',
+    token,
+    body: { reaction }
+  });
+}
+
+export async function removePostReaction(token: string, postId: string, reaction: PostReaction) {
+  return request<PostReactionsResponse>(`/posts/${postId}/reactions/${reaction}`, {
+    method: 'DELETE',
+    token
+  });
+}
+
+export async function getPost(token: string, postId: string, lang?: 'en' | 'tr') {
+  const query = lang ? `?lang=${lang}` : '';
+  return request<PostResponse>(`/posts/${postId}${query}`, { token });
+}
+
 export async function getThread(token: string, postId: string) {
   return request<ThreadResponse>(`/posts/${postId}/thread`, { token });
 }
 
+export type ThreadStreamHandlers = {
+  onReady?: () => void;
+  onReply?: (reply: Reply) => void;
+  onSummary?: (summary: string) => void;
+};
+
+// streamThread reads GET /posts/:id/stream until the server closes it or
+// signal aborts. EventSource cannot send the bearer token, so the stream is
+// read through fetch.
+export async function streamThread(token: string, postId: string, handlers: ThreadStreamHandlers, signal: AbortSignal) {
+  const path = `/posts/${postId}/stream`;
+  const response = await fetch(`${API_BASE}${path}`, {
+    headers: { Accept: 'text/event-stream', Authorization: `Bearer ${token}` },
+    signal
+  });
+  if (!response.ok || !response.body) {
+    const data = await parseResponseBody(response);
+    handleUnauthorizedRedirect(path, { token }, response.status);
+    throw extractAPIError(data, response.status);
+  }
+
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let buffered = '';
+  for (;;) {
+    const { done, value } = await reader.read();
+    if (done) {
+      return;
+    }
+    buffered += decoder.decode(value, { stream: true });
+    let boundary = buffered.indexOf('\n\n');
+    while (boundary >= 0) {
+      const frame = buffered.slice(0, boundary);
+      buffered = buffered.slice(boundary + 2);
+      boundary = buffered.indexOf('\n\n');
+
+      let event = 'message';
+      const data: string[] = [];
+      for (const line of frame.split('\n')) {
+        if (line.startsWith('event: ')) {
+          event = line.slice(7);
+        } else if (line.startsWith('data: ')) {
+          data.push(line.slice(6));
+        }
+      }
+      if (data.length === 0) {
+        continue;
+      }
+      const payload = JSON.parse(data.join('\n'));
+      if (event === 'ready') {
+        handlers.onReady?.();
+      } else if (event === 'reply') {
+        handlers.onReply?.(payload as Reply);
+      } else if (event === 'summary') {
+        handlers.onSummary?.((payload as { ai_summary: string }).ai_summary);
+      }
+    }
+  }
+}
+
+export async function watchBattle(token: string, battleId: string) {
+  return request<{ watching: boolean }>(`/b/${battleId}/watch`, {
+    method: 'POST',
+    token
+  });
+}
+
+export async function unwatchBattle(token: string, battleId: string) {
+  return request<{ watching: boolean }>(`/b/${battleId}/watch`, {
+    method: 'DELETE',
+    token
+  });
+}
+
 export async function getBattleProgress(token: string, battleId: string, expectedReplies = 0) {
   const normalizedExpected = Math.max(0, expectedReplies);
   const thread = await getThread(token, battleId);